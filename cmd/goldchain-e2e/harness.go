@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+const (
+	// startTimeout is the time allowed for a process to serve its API
+	startTimeout = time.Minute
+	// pollInterval is the interval at which the harness checks whether an expected state is reached
+	pollInterval = 250 * time.Millisecond
+	// mineInterval is the interval at which blocks are created while waiting for a state
+	// which requires blocks, such as the confirmation of a transaction
+	mineInterval = time.Second
+	// constantsFile is the file in the working directory overwriting the chain constants of the devnet
+	constantsFile = "constants.json"
+)
+
+// daemon is a goldchaind process of the devnet.
+type daemon struct {
+	name    string
+	rpcAddr modules.NetAddress
+	client  *goldchainclient.Client
+	process *process
+}
+
+// process is a process started by the harness.
+type process struct {
+	cmd *exec.Cmd
+	// exited is closed once the process exited
+	exited chan struct{}
+}
+
+// harness runs a devnet of goldchaind processes and a faucet on the local machine,
+// all storing their data in the working directory. No blocks are created unless mined explicitly.
+type harness struct {
+	dir string
+
+	// miner owns the devnet genesis wallet, which is created by the faucet it serves
+	miner *daemon
+	// fork owns the devnet genesis wallet as well, such that it can mine a competing chain
+	fork *daemon
+	// user owns a wallet with a random seed, of which address is authorized and funded by the faucet
+	user    *daemon
+	address types.UnlockHash
+
+	faucetURL string
+	faucet    *process
+	http      *http.Client
+
+	constants modules.DaemonConstants
+}
+
+// startHarness starts the daemons of the devnet, connecting the others to the miner, and the faucet.
+// The harness has to be closed, even if it failed to start, in order to stop all processes.
+func startHarness(ctx context.Context, h *harness) error {
+	h.http = &http.Client{Timeout: time.Minute}
+	// blocks are mined far more frequently than the devnet block frequency,
+	// which would otherwise get their timestamps beyond the future threshold
+	frequency := types.BlockHeight(1)
+	b, err := json.Marshal(config.ChainConstantsOverrides{BlockFrequency: &frequency})
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(h.dir, constantsFile), b, 0600); err != nil {
+		return err
+	}
+	if h.miner, err = h.startDaemon(ctx, "miner", "cgtwbe"); err != nil {
+		return err
+	}
+	if h.fork, err = h.startDaemon(ctx, "fork", "cgtwb"); err != nil {
+		return err
+	}
+	if h.user, err = h.startDaemon(ctx, "user", "cgtw"); err != nil {
+		return err
+	}
+	if h.constants, err = h.miner.client.Constants(ctx); err != nil {
+		return err
+	}
+	if _, err = h.fork.client.ProvisionWallet(ctx, wallet.ProvisionOptions{Mnemonic: config.DevnetGenesisMnemonic}); err != nil {
+		return fmt.Errorf("failed to create the wallet of the fork daemon: %v", err)
+	}
+	provisioned, err := h.user.client.ProvisionWallet(ctx, wallet.ProvisionOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create the wallet of the user daemon: %v", err)
+	}
+	h.address = provisioned.Addresses[0]
+	for _, d := range []*daemon{h.fork, h.user} {
+		if err = d.client.ConnectPeer(ctx, h.miner.rpcAddr); err != nil {
+			return fmt.Errorf("failed to connect the %s daemon to the miner: %v", d.name, err)
+		}
+	}
+
+	// the faucet creates the wallet of the miner, and serves once the genesis address is authorized
+	if err = h.startFaucet(ctx); err != nil {
+		return err
+	}
+	return h.sync(ctx)
+}
+
+// startDaemon starts a devnet daemon running the given modules, which only creates blocks on demand.
+func (h *harness) startDaemon(ctx context.Context, name, moduleSet string) (*daemon, error) {
+	apiAddr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	rpcAddr, err := freeAddress()
+	if err != nil {
+		return nil, err
+	}
+	args := []string{
+		"--network", config.NetworkNameDev, "--no-bootstrap", "-M", moduleSet,
+		"--constants-file", filepath.Join(h.dir, constantsFile),
+		"--api-addr", apiAddr, "--rpc-addr", rpcAddr, "-d", filepath.Join(h.dir, name),
+	}
+	if strings.Contains(moduleSet, "b") {
+		args = append(args, "--no-block-creation")
+	}
+	p, err := h.start(name, daemonBin, args...)
+	if err != nil {
+		return nil, err
+	}
+	d := &daemon{name: name, rpcAddr: modules.NetAddress(rpcAddr), client: goldchainclient.New(apiAddr, ""), process: p}
+	err = waitFor(ctx, startTimeout, func() (bool, error) {
+		if p.hasExited() {
+			return false, fmt.Errorf("exited, see %s.log", name)
+		}
+		_, err := d.client.Consensus(ctx)
+		return err == nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s daemon did not serve its API: %v", name, err)
+	}
+	log.Printf("[INFO] Started %s daemon (API %s, RPC %s)\n", name, apiAddr, rpcAddr)
+	return d, nil
+}
+
+// startFaucet starts the faucet in devnet setup mode, mining blocks until it serves.
+func (h *harness) startFaucet(ctx context.Context) error {
+	addr, err := freeAddress()
+	if err != nil {
+		return err
+	}
+	_, port, _ := net.SplitHostPort(addr)
+	h.faucetURL = "http://" + addr
+	h.faucet, err = h.start("faucet", faucetBin,
+		"-daemon-address", "http://"+h.miner.client.RootURL, "-port", port,
+		"-devnet-setup", "-ownership-proof=false", "-rate-limit", "0")
+	if err != nil {
+		return err
+	}
+	err = h.mineUntil(ctx, startTimeout, func() (bool, error) {
+		if h.faucet.hasExited() {
+			return false, errors.New("exited, see faucet.log")
+		}
+		resp, err := h.http.Get(h.faucetURL + "/")
+		if err != nil {
+			return false, nil
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK, nil
+	})
+	if err != nil {
+		return fmt.Errorf("faucet did not serve: %v", err)
+	}
+	log.Println("[INFO] Started faucet at", h.faucetURL)
+	return nil
+}
+
+// start starts the given binary, running in its own subdirectory of the working directory
+// and logging to <name>.log in the working directory.
+func (h *harness) start(name, binary string, args ...string) (*process, error) {
+	dir := filepath.Join(h.dir, name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	logFile, err := os.Create(filepath.Join(h.dir, name+".log"))
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err = cmd.Start(); err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("failed to start %s: %v", name, err)
+	}
+	p := &process{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		cmd.Wait()
+		logFile.Close()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// Close stops all processes of the harness.
+func (h *harness) Close() {
+	if h.faucet != nil {
+		h.faucet.stop()
+	}
+	for _, d := range []*daemon{h.user, h.fork, h.miner} {
+		if d != nil {
+			d.process.stop()
+		}
+	}
+}
+
+func (p *process) hasExited() bool {
+	select {
+	case <-p.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop interrupts the process, killing it should it not exit in time.
+func (p *process) stop() {
+	p.cmd.Process.Signal(os.Interrupt)
+	select {
+	case <-p.exited:
+	case <-time.After(30 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.exited
+	}
+}
+
+// mineUntil creates a block on the miner every mineInterval until the given condition is met,
+// failing once the timeout is exceeded.
+func (h *harness) mineUntil(ctx context.Context, timeout time.Duration, condition func() (bool, error)) error {
+	lastMined := time.Now()
+	return waitFor(ctx, timeout, func() (bool, error) {
+		met, err := condition()
+		if met || err != nil {
+			return met, err
+		}
+		if time.Since(lastMined) >= mineInterval {
+			// the miner cannot create blocks until the faucet created its wallet
+			if _, err := h.miner.client.Mine(ctx, 1); err != nil {
+				log.Println("[DEBUG] Failed to mine a block:", err)
+			}
+			lastMined = time.Now()
+		}
+		return false, nil
+	})
+}
+
+// sync waits until all daemons have the same current block as the miner.
+func (h *harness) sync(ctx context.Context) error {
+	return waitFor(ctx, startTimeout, func() (bool, error) {
+		cs, err := h.miner.client.Consensus(ctx)
+		if err != nil {
+			return false, err
+		}
+		for _, d := range []*daemon{h.fork, h.user} {
+			other, err := d.client.Consensus(ctx)
+			if err != nil {
+				return false, err
+			}
+			if other.CurrentBlock != cs.CurrentBlock {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// postFaucet posts the given body as JSON to an endpoint of the faucet, decoding the response into reply.
+func (h *harness) postFaucet(ctx context.Context, endpoint string, body, reply interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, h.faucetURL+endpoint, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var faucetErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&faucetErr)
+		return fmt.Errorf("faucet responded to %s with status %d: %s", endpoint, resp.StatusCode, faucetErr.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// waitFor polls the given condition until it is met or returns an error,
+// failing once the timeout is exceeded.
+func waitFor(ctx context.Context, timeout time.Duration, condition func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		met, err := condition()
+		if err != nil || met {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return errors.New("timed out after " + timeout.String())
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// freeAddress returns a local address of which the port is currently not in use.
+func freeAddress() (string, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return "localhost:" + strconv.Itoa(listener.Addr().(*net.TCPAddr).Port), nil
+}
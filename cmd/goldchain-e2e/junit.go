@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"time"
+)
+
+// junitSuite is a JUnit test suite, as read by CI systems.
+type junitSuite struct {
+	XMLName   xml.Name    `xml:"testsuite"`
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Skipped   int         `xml:"skipped,attr"`
+	Time      float64     `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+// junitCase is the result of a single scenario.
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+func newJUnitSuite(name string, start time.Time) *junitSuite {
+	return &junitSuite{Name: name, Timestamp: start.UTC().Format("2006-01-02T15:04:05")}
+}
+
+// pass records a scenario which passed, with a description of what it verified.
+func (s *junitSuite) pass(name string, elapsed time.Duration, summary string) {
+	s.add(junitCase{Name: name, Time: elapsed.Seconds(), SystemOut: summary})
+}
+
+// fail records a scenario which failed.
+func (s *junitSuite) fail(name string, elapsed time.Duration, err error, log string) {
+	s.Failures++
+	s.add(junitCase{Name: name, Time: elapsed.Seconds(), Failure: &junitFailure{Message: err.Error(), Details: log}})
+}
+
+// skip records a scenario which was not run.
+func (s *junitSuite) skip(name, reason string) {
+	s.Skipped++
+	s.add(junitCase{Name: name, Skipped: &junitSkipped{Message: reason}})
+}
+
+func (s *junitSuite) add(c junitCase) {
+	c.ClassName = s.Name
+	s.Tests++
+	s.Time += c.Time
+	s.Cases = append(s.Cases, c)
+}
+
+// write writes the suite as JUnit XML report to the given file.
+func (s *junitSuite) write(path string) error {
+	b, err := xml.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append([]byte(xml.Header), append(b, '\n')...), 0644)
+}
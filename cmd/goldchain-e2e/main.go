@@ -0,0 +1,163 @@
+// Command goldchain-e2e runs end-to-end scenarios against a devnet of goldchaind processes and a faucet
+// started on the local machine, guarding releases of the chain and the faucet together,
+// and writes the results as a JUnit report.
+package main
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+var (
+	daemonBin       = "goldchaind"
+	faucetBin       = "faucet"
+	reportFile      = "goldchain-e2e.xml"
+	workDir         string
+	keepWorkDir     bool
+	scenarioTimeout = 2 * time.Minute
+	runPattern      string
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs the selected scenarios, returning the exit code of the process.
+func run() int {
+	filter, err := regexp.Compile(runPattern)
+	if err != nil {
+		log.Println("[ERROR] invalid scenario pattern:", err)
+		return 2
+	}
+	dir := workDir
+	if dir == "" {
+		dir, err = ioutil.TempDir("", "goldchain-e2e")
+	} else {
+		err = os.MkdirAll(dir, 0700)
+	}
+	if err != nil {
+		log.Println("[ERROR] failed to create the working directory:", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("[INFO] Stopping the scenarios...")
+		cancel()
+	}()
+
+	start := time.Now()
+	suite := newJUnitSuite("goldchain-e2e", start)
+	selected := selectScenarios(filter)
+	log.Println("[INFO] Starting the devnet in", dir)
+	h := &harness{dir: dir}
+	err = startHarness(ctx, h)
+	if err != nil {
+		log.Println("[ERROR] Failed to start the devnet:", err)
+		suite.fail("setup", time.Since(start), err, "")
+		for _, sc := range selected {
+			suite.skip(sc.name, "the devnet failed to start")
+		}
+	} else {
+		suite.pass("setup", time.Since(start), "started the miner, fork and user daemons and the faucet")
+		runScenarios(ctx, h, suite, selected)
+	}
+	h.Close()
+
+	if err = suite.write(reportFile); err != nil {
+		log.Println("[ERROR] failed to write the JUnit report:", err)
+		return 2
+	}
+	log.Printf("[INFO] %d scenarios, %d failed, %d skipped, reported in %s\n", suite.Tests, suite.Failures, suite.Skipped, reportFile)
+	if suite.Failures > 0 {
+		// the logs of the processes are kept to investigate the failures
+		log.Println("[INFO] The logs of the daemons and the faucet are kept in", dir)
+		return 1
+	}
+	if !keepWorkDir && workDir == "" {
+		os.RemoveAll(dir)
+	}
+	return 0
+}
+
+// selectScenarios returns the scenarios matching the given pattern,
+// along with the scenarios they require.
+func selectScenarios(filter *regexp.Regexp) []scenario {
+	required := make(map[string]bool)
+	for i := len(scenarios) - 1; i >= 0; i-- {
+		sc := scenarios[i]
+		if required[sc.name] || filter.MatchString(sc.name) {
+			required[sc.name] = true
+			for _, name := range sc.requires {
+				required[name] = true
+			}
+		}
+	}
+	var selected []scenario
+	for _, sc := range scenarios {
+		if required[sc.name] {
+			selected = append(selected, sc)
+		}
+	}
+	return selected
+}
+
+// runScenarios runs the given scenarios in order, skipping those of which a required scenario did not pass.
+func runScenarios(ctx context.Context, h *harness, suite *junitSuite, selected []scenario) {
+	passed := make(map[string]bool)
+	for _, sc := range selected {
+		if ctx.Err() != nil {
+			suite.skip(sc.name, "interrupted")
+			continue
+		}
+		var missing string
+		for _, name := range sc.requires {
+			if !passed[name] {
+				missing = name
+				break
+			}
+		}
+		if missing != "" {
+			log.Printf("[INFO] Skipping %s, as %s did not pass\n", sc.name, missing)
+			suite.skip(sc.name, "requires the "+missing+" scenario to pass")
+			continue
+		}
+
+		log.Println("[INFO] Running", sc.name)
+		r := &recorder{scenario: sc.name}
+		start := time.Now()
+		scenarioCtx, cancel := context.WithTimeout(ctx, scenarioTimeout)
+		err := sc.run(scenarioCtx, h, r)
+		cancel()
+		if err != nil {
+			log.Printf("[ERROR] %s failed: %v\n", sc.name, err)
+			suite.fail(sc.name, time.Since(start), err, r.String())
+			continue
+		}
+		passed[sc.name] = true
+		log.Printf("[INFO] %s passed in %v\n", sc.name, time.Since(start).Round(time.Millisecond))
+		suite.pass(sc.name, time.Since(start), r.String())
+	}
+}
+
+func init() {
+	flag.StringVar(&daemonBin, "daemon", daemonBin, "goldchaind binary to test")
+	flag.StringVar(&faucetBin, "faucet", faucetBin, "faucet binary to test")
+	flag.StringVar(&reportFile, "junit", reportFile, "file the JUnit report is written to")
+	flag.StringVar(&workDir, "dir", workDir, "directory storing the data and logs of the daemons and the faucet, a temporary directory if empty")
+	flag.BoolVar(&keepWorkDir, "keep", keepWorkDir, "keep the temporary directory, which is only kept otherwise if a scenario failed")
+	flag.DurationVar(&scenarioTimeout, "timeout", scenarioTimeout, "maximum duration of a single scenario")
+	flag.StringVar(&runPattern, "run", runPattern, "regular expression selecting the scenarios to run, along with the scenarios they require")
+	flag.Parse()
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	rivineclient "github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// scenario is an end-to-end flow, reported as a single test case.
+type scenario struct {
+	name string
+	// requires lists the (earlier) scenarios which have to pass for this scenario to run
+	requires []string
+	run      func(ctx context.Context, h *harness, r *recorder) error
+}
+
+// scenarios are run in order, sharing the devnet and the wallet of the user daemon.
+var scenarios = []scenario{
+	{name: "authorize", run: authorizeScenario},
+	{name: "drip", requires: []string{"authorize"}, run: dripScenario},
+	{name: "send", requires: []string{"drip"}, run: sendScenario},
+	{name: "multisig-spend", run: multiSigSpendScenario},
+	{name: "deauthorize-blocks-send", requires: []string{"drip"}, run: deauthorizeScenario},
+	{name: "reorg", run: reorgScenario},
+}
+
+// recorder logs the steps of a scenario, which are added to its test case.
+type recorder struct {
+	scenario string
+	steps    []string
+}
+
+func (r *recorder) logf(format string, args ...interface{}) {
+	step := fmt.Sprintf(format, args...)
+	log.Printf("[INFO] %s: %s\n", r.scenario, step)
+	r.steps = append(r.steps, step)
+}
+
+func (r *recorder) String() string {
+	return strings.Join(r.steps, "\n")
+}
+
+// authorizeScenario gets the address of the user authorized by the faucet,
+// checking the authorization reaches the user daemon.
+func authorizeScenario(ctx context.Context, h *harness, r *recorder) error {
+	txID, err := h.faucetTransaction(ctx, "/api/v1/authorize", h.address)
+	if err != nil {
+		return err
+	}
+	r.logf("faucet authorized %s in transaction %s", h.address.String(), txID.String())
+	if err = h.waitAuthorized(ctx, h.user, h.address, true); err != nil {
+		return err
+	}
+	r.logf("user daemon reports the address as authorized")
+	return nil
+}
+
+// dripScenario gets coins dripped to the address of the user,
+// checking they are spendable by the wallet of the user daemon.
+func dripScenario(ctx context.Context, h *harness, r *recorder) error {
+	txID, err := h.faucetTransaction(ctx, "/api/v1/coins", h.address)
+	if err != nil {
+		return err
+	}
+	r.logf("faucet dripped coins in transaction %s", txID.String())
+	if err = h.waitConfirmed(ctx, h.user, txID); err != nil {
+		return err
+	}
+	balance, err := h.user.client.WalletBalance(ctx)
+	if err != nil {
+		return err
+	}
+	if balance.Spendable.IsZero() {
+		return fmt.Errorf("the dripped coins are not spendable: %+v", balance)
+	}
+	r.logf("user wallet can spend %s", h.formatCoins(balance.Spendable))
+	return nil
+}
+
+// sendScenario sends coins from the wallet of the user back to the genesis address,
+// checking the transaction is confirmed by the miner.
+func sendScenario(ctx context.Context, h *harness, r *recorder) error {
+	value := h.constants.OneCoin.Mul64(100)
+	txID, err := h.user.client.SendCoins(ctx, h.payTo(genesisAddress(), value), wallet.BuildOptions{})
+	if err != nil {
+		return err
+	}
+	r.logf("user sent %s to the genesis address in transaction %s", h.formatCoins(value), txID.String())
+	if err = h.waitConfirmed(ctx, h.miner, txID); err != nil {
+		return err
+	}
+	r.logf("miner confirmed the transaction")
+	return nil
+}
+
+// multiSigSpendScenario gets a 2-of-2 multisig address owned by the miner and the user authorized by the faucet,
+// funds it and spends its coins using a transaction signed by the wallets of both daemons.
+func multiSigSpendScenario(ctx context.Context, h *harness, r *recorder) error {
+	condition := types.NewCondition(types.NewMultiSignatureCondition(types.UnlockHashSlice{genesisAddress(), h.address}, 2))
+	address := condition.UnlockHash()
+	txID, err := h.faucetTransaction(ctx, "/api/v1/authorize", address)
+	if err != nil {
+		return err
+	}
+	r.logf("faucet authorized multisig address %s in transaction %s", address.String(), txID.String())
+	if err = h.waitAuthorized(ctx, h.miner, address, true); err != nil {
+		return err
+	}
+	// the faucet cannot drip to a multisig address, as paying to it requires the full multisig condition
+	value := h.constants.OneCoin.Mul64(50)
+	fundID, err := h.miner.client.SendCoins(ctx, []types.CoinOutput{{Value: value, Condition: condition}}, wallet.BuildOptions{})
+	if err != nil {
+		return err
+	}
+	if err = h.waitConfirmed(ctx, h.miner, fundID); err != nil {
+		return err
+	}
+	fund, _, err := h.miner.client.ConsensusTransaction(ctx, fundID)
+	if err != nil {
+		return err
+	}
+	var txn types.Transaction
+	for index, output := range fund.CoinOutputs {
+		if output.Condition.UnlockHash() == address {
+			txn = types.Transaction{
+				Version:     h.constants.DefaultTransactionVersion,
+				CoinInputs:  []types.CoinInput{{ParentID: fund.CoinOutputID(uint64(index))}},
+				CoinOutputs: h.payTo(genesisAddress(), output.Value.Sub(h.constants.MinimumTransactionFee)),
+				MinerFees:   []types.Currency{h.constants.MinimumTransactionFee},
+			}
+		}
+	}
+	if len(txn.CoinInputs) == 0 {
+		return fmt.Errorf("transaction %s does not pay to the multisig address", fundID.String())
+	}
+	r.logf("miner sent %s to the multisig address in transaction %s", h.formatCoins(value), fundID.String())
+
+	// each wallet adds the signature of its key to the multisig fulfillment
+	for _, d := range []*daemon{h.miner, h.user} {
+		if txn, err = d.client.SignTransaction(ctx, txn); err != nil {
+			return fmt.Errorf("%s failed to sign the multisig transaction: %v", d.name, err)
+		}
+	}
+	spendID, err := h.user.client.SubmitTransaction(ctx, txn)
+	if err != nil {
+		return err
+	}
+	r.logf("user submitted the multisig transaction %s, signed by the miner and the user", spendID.String())
+	if err = h.waitConfirmed(ctx, h.miner, spendID); err != nil {
+		return err
+	}
+	r.logf("miner confirmed the multisig transaction")
+	return nil
+}
+
+// deauthorizeScenario gets the address of the user deauthorized by the faucet,
+// checking the wallet of the user daemon can no longer send its coins.
+func deauthorizeScenario(ctx context.Context, h *harness, r *recorder) error {
+	var resp struct {
+		TxID types.TransactionID `json:"txid"`
+	}
+	// the address still holds coins, which are stranded on purpose
+	err := h.postFaucet(ctx, "/api/v1/deauthorize", map[string]interface{}{"address": h.address.String(), "force": true}, &resp)
+	if err != nil {
+		return err
+	}
+	r.logf("faucet deauthorized %s in transaction %s", h.address.String(), resp.TxID.String())
+	if err = h.waitAuthorized(ctx, h.user, h.address, false); err != nil {
+		return err
+	}
+	balance, err := h.user.client.WalletBalance(ctx)
+	if err != nil {
+		return err
+	}
+	if balance.Unauthorized.IsZero() {
+		return fmt.Errorf("expected the coins of the deauthorized address to be reported as unauthorized: %+v", balance)
+	}
+	r.logf("user wallet reports %s as unauthorized", h.formatCoins(balance.Unauthorized))
+	txID, err := h.user.client.SendCoins(ctx, h.payTo(genesisAddress(), h.constants.OneCoin), wallet.BuildOptions{})
+	if err == nil {
+		return fmt.Errorf("the deauthorized address sent coins in transaction %s", txID.String())
+	}
+	r.logf("user daemon refused to send from the deauthorized address: %v", err)
+	return nil
+}
+
+// reorgScenario confirms a transaction on the miner while the fork is disconnected,
+// after which the fork mines a longer chain. Once connected to the fork, the miner has to reorganize to the chain of the fork,
+// reverting the transaction, which is confirmed again once resubmitted.
+func reorgScenario(ctx context.Context, h *harness, r *recorder) error {
+	if err := h.sync(ctx); err != nil {
+		return err
+	}
+	start, err := h.miner.client.Consensus(ctx)
+	if err != nil {
+		return err
+	}
+	if err = h.fork.client.DisconnectPeer(ctx, h.miner.rpcAddr); err != nil {
+		return err
+	}
+	r.logf("disconnected the fork from the miner at height %d", start.Height)
+
+	txID, err := h.miner.client.SendCoins(ctx, h.payTo(genesisAddress(), h.constants.OneCoin), wallet.BuildOptions{})
+	if err != nil {
+		return err
+	}
+	mined, err := h.miner.client.Mine(ctx, 1)
+	if err != nil {
+		return err
+	}
+	status, err := h.miner.client.TransactionStatus(ctx, txID)
+	if err != nil {
+		return err
+	}
+	if status.Status != goldchainapi.TransactionStatusConfirmed || status.Height != mined.Height {
+		return fmt.Errorf("expected transaction %s to be confirmed at height %d: %+v", txID.String(), mined.Height, status)
+	}
+	txn, _, err := h.miner.client.ConsensusTransaction(ctx, txID)
+	if err != nil {
+		return err
+	}
+	r.logf("miner confirmed transaction %s at height %d", txID.String(), mined.Height)
+	forked, err := h.fork.client.Mine(ctx, 3)
+	if err != nil {
+		return err
+	}
+	r.logf("fork mined a competing chain up to height %d", forked.Height)
+
+	// a daemon downloads the blocks of the peers it connects to
+	if err = h.miner.client.ConnectPeer(ctx, h.fork.rpcAddr); err != nil {
+		return err
+	}
+	tip := forked.BlockIDs[len(forked.BlockIDs)-1]
+	err = waitFor(ctx, scenarioTimeout, func() (bool, error) {
+		cs, err := h.miner.client.Consensus(ctx)
+		return err == nil && cs.CurrentBlock == tip, err
+	})
+	if err != nil {
+		return fmt.Errorf("miner did not reorganize to the chain of the fork: %v", err)
+	}
+	block, err := h.miner.client.ConsensusAtHeight(ctx, mined.Height)
+	if err != nil {
+		return err
+	}
+	if block.ID != forked.BlockIDs[0] {
+		return fmt.Errorf("expected block %s at height %d, got %s", forked.BlockIDs[0].String(), mined.Height, block.ID.String())
+	}
+	status, err = h.miner.client.TransactionStatus(ctx, txID)
+	if err != nil {
+		return err
+	}
+	if status.Status == goldchainapi.TransactionStatusConfirmed {
+		return fmt.Errorf("expected transaction %s to be reverted by the reorganization: %+v", txID.String(), status)
+	}
+	r.logf("miner reorganized to the chain of the fork, reverting the transaction (%s)", status.Status)
+
+	// the transaction pool does not take back the transactions of reverted blocks
+	if _, err = h.miner.client.SubmitTransaction(ctx, txn); err != nil {
+		return fmt.Errorf("failed to resubmit the reverted transaction: %v", err)
+	}
+	r.logf("resubmitted the reverted transaction")
+	if err = h.waitConfirmed(ctx, h.miner, txID); err != nil {
+		return err
+	}
+	status, err = h.miner.client.TransactionStatus(ctx, txID)
+	if err != nil {
+		return err
+	}
+	r.logf("miner confirmed the transaction again at height %d", status.Height)
+	return h.sync(ctx)
+}
+
+// faucetTransaction posts the given address to an endpoint of the faucet, returning the ID of the transaction it created.
+func (h *harness) faucetTransaction(ctx context.Context, endpoint string, address types.UnlockHash) (types.TransactionID, error) {
+	var resp struct {
+		TxID types.TransactionID `json:"txid"`
+	}
+	err := h.postFaucet(ctx, endpoint, map[string]string{"address": address.String()}, &resp)
+	return resp.TxID, err
+}
+
+// waitAuthorized mines blocks until the given daemon reports the requested authorization state of the address.
+func (h *harness) waitAuthorized(ctx context.Context, d *daemon, address types.UnlockHash, authorized bool) error {
+	err := h.mineUntil(ctx, scenarioTimeout, func() (bool, error) {
+		state, err := d.client.IsAuthorized(ctx, address)
+		return err == nil && state == authorized, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s daemon did not report the authorization state of %s as %v: %v", d.name, address.String(), authorized, err)
+	}
+	return nil
+}
+
+// waitConfirmed mines blocks until the given daemon reports the transaction as confirmed.
+func (h *harness) waitConfirmed(ctx context.Context, d *daemon, id types.TransactionID) error {
+	var status goldchainapi.TransactionStatusGET
+	err := h.mineUntil(ctx, scenarioTimeout, func() (bool, error) {
+		var err error
+		status, err = d.client.TransactionStatus(ctx, id)
+		if goldchainclient.IsStatus(err, http.StatusNotFound) {
+			// the transaction did not reach the daemon yet
+			return false, nil
+		}
+		return err == nil && status.Status == goldchainapi.TransactionStatusConfirmed, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s daemon did not confirm transaction %s (%s): %v", d.name, id.String(), status.Status, err)
+	}
+	return nil
+}
+
+// payTo returns the coin outputs paying the given value to the given address.
+func (h *harness) payTo(address types.UnlockHash, value types.Currency) []types.CoinOutput {
+	return []types.CoinOutput{{Value: value, Condition: types.NewCondition(types.NewUnlockHashCondition(address))}}
+}
+
+func (h *harness) formatCoins(c types.Currency) string {
+	network := config.GetDevnetNetworkDescriptor()
+	return rivineclient.NewCurrencyConvertor(network.CurrencyUnits(), network.CoinUnit).ToCoinStringWithUnit(c)
+}
+
+// genesisAddress returns the devnet genesis address, owned by the wallets of the miner and the fork.
+func genesisAddress() types.UnlockHash {
+	return config.GetDevnetGenesisMintCondition().UnlockHash()
+}
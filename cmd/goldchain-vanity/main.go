@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/threefoldtech/rivine/modules"
+
+	"github.com/nbh-digital/goldchain/pkg/client"
+	"github.com/nbh-digital/goldchain/pkg/vanity"
+)
+
+var (
+	prefix        string
+	workers       = runtime.NumCPU()
+	progress      = 10 * time.Second
+	importSeed    bool
+	daemonAddr    = "localhost:22110"
+	password      string
+	askPassphrase bool
+)
+
+func main() {
+	s, err := vanity.NewSearch(prefix)
+	if err != nil {
+		log.Fatal("[ERROR] invalid prefix: ", err)
+	}
+	var passphrase string
+	if importSeed && askPassphrase {
+		passphrase, err = speakeasy.Ask("Wallet passphrase: ")
+		if err != nil {
+			log.Fatal("[ERROR] failed to read the wallet passphrase: ", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("[INFO] Stopping the search...")
+		cancel()
+	}()
+
+	log.Printf("[INFO] Searching for an address starting with 01%s using %d workers, expecting %.0f attempts\n",
+		s.Prefix(), workers, s.Difficulty())
+	start := time.Now()
+	if progress > 0 {
+		go reportProgress(ctx, s, start)
+	}
+	match, err := s.Run(ctx, workers)
+	if err != nil {
+		log.Fatalf("[ERROR] no match found after %d attempts: %v", s.Attempts(), err)
+	}
+	log.Printf("[INFO] Found a match after %d attempts in %s\n", s.Attempts(), time.Since(start).Round(time.Second))
+
+	mnemonic, err := modules.NewMnemonic(match.Seed)
+	if err != nil {
+		log.Fatal("[ERROR] failed to encode the seed as mnemonic: ", err)
+	}
+	fmt.Println("Address: ", match.Address.String())
+	fmt.Println("Index:   ", match.Index)
+	fmt.Println("Seed:    ", match.Seed.String())
+	fmt.Println("Mnemonic:", mnemonic)
+	fmt.Fprintln(os.Stderr, "WARNING: anyone who knows the seed can spend the funds of the address, store it safely")
+
+	if !importSeed {
+		return
+	}
+	c := client.New(daemonAddr, password)
+	err = c.LoadSeed(context.Background(), mnemonic, passphrase)
+	if err != nil {
+		log.Fatal("[ERROR] failed to load the seed into the wallet: ", err)
+	}
+	log.Printf("[INFO] Loaded the seed into the wallet of the daemon at %s\n", daemonAddr)
+}
+
+// reportProgress logs the amount of attempts and the rate of the search at the progress interval,
+// until the context is done.
+func reportProgress(ctx context.Context, s *vanity.Search, start time.Time) {
+	ticker := time.NewTicker(progress)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		attempts := s.Attempts()
+		rate := float64(attempts) / time.Since(start).Seconds()
+		if rate <= 0 {
+			continue
+		}
+		log.Printf("[INFO] %d attempts, %.0f addresses/s, a match is expected every %s\n",
+			attempts, rate, formatSeconds(s.Difficulty()/rate))
+	}
+}
+
+// formatSeconds formats the given amount of seconds as a duration,
+// or as years in case it exceeds the range of a duration.
+func formatSeconds(seconds float64) string {
+	const secondsPerYear = 365 * 24 * 60 * 60
+	if seconds > 100*secondsPerYear {
+		return fmt.Sprintf("%.0f years", seconds/secondsPerYear)
+	}
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
+
+func init() {
+	flag.StringVar(&prefix, "prefix", prefix,
+		"hex prefix of the desired address, following the 01 type prefix of every wallet address, at most 16 characters")
+	flag.IntVar(&workers, "workers", workers, "amount of addresses derived in parallel, defaults to the amount of CPUs")
+	flag.DurationVar(&progress, "progress", progress, "interval at which the progress of the search is logged, disabled if 0")
+	flag.BoolVar(&importSeed, "import", importSeed, "load the seed of the found address into the (unlocked) wallet of the daemon")
+	flag.StringVar(&daemonAddr, "addr", daemonAddr, "API address of the daemon of which the wallet loads the seed")
+	flag.StringVar(&password, "password", password, "API password of the daemon, used to import the seed")
+	flag.BoolVar(&askPassphrase, "encrypted", askPassphrase, "ask for the passphrase of the wallet, required to import a seed into an encrypted wallet")
+	flag.Parse()
+}
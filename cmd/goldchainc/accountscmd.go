@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createAccountsCmds registers the wallet commands used to manage sub-accounts.
+func createAccountsCmds(cliClient *client.CommandLineClient) {
+	accountsCmd := &accountsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "accounts",
+		Short: "List the sub-accounts of the wallet",
+		Long: `List the labeled sub-accounts of the wallet, such as "faucet", "hot" and "cold-change",
+which allow a single seed to back multiple logical wallets.
+
+The seed of every account is derived from the primary seed and the label of the account,
+such that an account of a restored wallet is restored by creating it again using the same label.
+The funds of an account are only sent using the --account flag of the send commands,
+and are never used otherwise. The balance of the wallet includes the funds of all accounts.`,
+		Args: cobra.NoArgs,
+		Run:  accountsCmd.listCmd,
+	}
+	createCmd := &cobra.Command{
+		Use:   "create <label>",
+		Short: "Create or restore an account",
+		Args:  cobra.ExactArgs(1),
+		Run:   accountsCmd.createCmd,
+	}
+	createCmd.Flags().BoolVar(&accountsCmd.createCfg.Plain, "plain", false,
+		"the wallet is a plain wallet, requiring no passphrase")
+	rootCmd.AddCommand(createCmd)
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "show <label>",
+		Short: "Show the balance and addresses of an account",
+		Args:  cobra.ExactArgs(1),
+		Run:   accountsCmd.showCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "address <label>",
+		Short: "Generate a new address of an account",
+		Args:  cobra.ExactArgs(1),
+		Run:   accountsCmd.addressCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "transactions <label>",
+		Short: "List the confirmed transactions of an account",
+		Args:  cobra.ExactArgs(1),
+		Run:   accountsCmd.transactionsCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type accountsCmd struct {
+	cli       *client.CommandLineClient
+	createCfg struct {
+		Plain bool
+	}
+}
+
+// listCmd lists all accounts of the wallet.
+func (accountsCmd *accountsCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAccountsGET
+	err := accountsCmd.cli.GetAPI("/wallet/accounts", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the accounts of the wallet", err)
+	}
+	if len(resp.Accounts) == 0 {
+		fmt.Println("No accounts.")
+		return
+	}
+	for _, account := range resp.Accounts {
+		fmt.Printf("%s\t%d address(es)\n", account.Label, len(account.Addresses))
+	}
+}
+
+// createCmd creates (or restores) an account.
+func (accountsCmd *accountsCmd) createCmd(cmd *cobra.Command, args []string) {
+	body := goldchainapi.WalletAccountsPOST{Label: args[0]}
+	if !accountsCmd.createCfg.Plain {
+		passphrase, err := speakeasy.Ask("Wallet passphrase: ")
+		if err != nil {
+			cli.DieWithError("failed to read the passphrase", err)
+		}
+		body.Passphrase = passphrase
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the account", err)
+	}
+	var resp goldchainapi.WalletAccountGET
+	err = accountsCmd.cli.PostResp("/wallet/accounts", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to create the account", err)
+	}
+	fmt.Printf("Created account %s\n", resp.Label)
+	accountsCmd.printBalance(resp.Balance)
+}
+
+// showCmd shows the balance and generated addresses of an account.
+func (accountsCmd *accountsCmd) showCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAccountGET
+	err := accountsCmd.cli.GetAPI("/wallet/accounts/"+url.PathEscape(args[0]), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the account", err)
+	}
+	accountsCmd.printBalance(resp.Balance)
+	if len(resp.Addresses) == 0 {
+		fmt.Println("No addresses generated.")
+		return
+	}
+	fmt.Println("Addresses:")
+	for _, address := range resp.Addresses {
+		fmt.Println("  " + address.String())
+	}
+}
+
+// addressCmd generates a new address of an account.
+func (accountsCmd *accountsCmd) addressCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAccountAddressPOSTResp
+	err := accountsCmd.cli.PostResp("/wallet/accounts/"+url.PathEscape(args[0])+"/address", "", &resp)
+	if err != nil {
+		cli.DieWithError("failed to generate an address of the account", err)
+	}
+	fmt.Println(resp.Address.String())
+}
+
+// transactionsCmd lists the confirmed transactions of an account.
+func (accountsCmd *accountsCmd) transactionsCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAccountTransactionsGET
+	err := accountsCmd.cli.GetAPI("/wallet/accounts/"+url.PathEscape(args[0])+"/transactions", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the transactions of the account", err)
+	}
+	if len(resp.Transactions) == 0 {
+		fmt.Println("No transactions.")
+		return
+	}
+	currencyConvertor := accountsCmd.cli.CreateCurrencyConvertor()
+	for _, txn := range resp.Transactions {
+		fmt.Printf("%s at height %d: received %s, spent %s\n", txn.ID.String(), txn.Height,
+			currencyConvertor.ToCoinStringWithUnit(txn.Received), currencyConvertor.ToCoinStringWithUnit(txn.Spent))
+	}
+}
+
+// printBalance prints the balance of an account.
+func (accountsCmd *accountsCmd) printBalance(balance wallet.AccountBalance) {
+	currencyConvertor := accountsCmd.cli.CreateCurrencyConvertor()
+	fmt.Println("Spendable:   ", currencyConvertor.ToCoinStringWithUnit(balance.Spendable))
+	fmt.Println("Locked:      ", currencyConvertor.ToCoinStringWithUnit(balance.Locked))
+	fmt.Println("Block stakes:", balance.BlockStakes.String())
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// network describes the network the CLI is used for,
+// it is defined prior to running any command.
+var network = config.GetStandardNetworkDescriptor()
+
+// createAddressChecks makes the rivine send blockstakes command refuse
+// addresses of other blockchains and mistyped addresses as destination.
+// The goldchain send commands check their destinations themselves.
+func createAddressChecks(cliClient *client.CommandLineClient) {
+	for _, sendCmd := range cliClient.WalletCmd.RootCmdSend.Commands() {
+		if sendCmd.Name() != "blockstakes" {
+			continue
+		}
+		run := sendCmd.Run
+		sendCmd.Run = func(cmd *cobra.Command, args []string) {
+			checkDestinations(args)
+			run(cmd, args)
+		}
+	}
+}
+
+// checkDestinations dies should any destination of the pairs of '<dest>' and '<amount>' arguments
+// look like an address, while coins cannot be sent to it on the network.
+// Contact names, JSON-encoded conditions and condition descriptors are left to the command.
+func checkDestinations(args []string) {
+	for i := 0; i < len(args); i += 2 {
+		dest := strings.TrimSpace(args[i])
+		if strings.ContainsAny(dest, "{(") || (wallet.ValidateContactName(dest) == nil && config.ForeignAddressChain(dest) == "") {
+			continue
+		}
+		if _, err := network.ParseAddress(dest); err != nil {
+			cli.Die(fmt.Sprintf("invalid address for output #%d:", i/2), err)
+		}
+	}
+}
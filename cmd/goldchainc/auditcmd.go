@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createAuditCmds registers the wallet commands used to export and verify the audit log of the wallet API.
+func createAuditCmds(cliClient *client.CommandLineClient) {
+	auditCmd := &auditCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Export and verify the audit log of the wallet API",
+		Long: `Export and verify the audit log of the wallet API.
+
+The daemon records every wallet API mutation (POST request) in an append-only audit log,
+with the parameters of the request (secrets redacted), the credential and address of its caller,
+and its outcome, including the IDs of the transactions it created. Every record contains the hash of
+the previous record, such that records cannot be altered, removed or reordered without being detected.`,
+	}
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the audit log",
+		Long: `Export the audit log as stored by the daemon, a JSON record per line,
+verifying it while exporting, and printing the hash of its last record.`,
+		Args: cobra.NoArgs,
+		Run:  auditCmd.exportCmd,
+	}
+	exportCmd.Flags().StringVar(&auditCmd.out, "out", "", "file the audit log is exported to (required)")
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "verify [file]",
+		Short: "Verify the audit log",
+		Long: `Verify the hash chain of an exported audit log, or of the audit log of the daemon if no file is given,
+printing the amount of records and the hash of the last record, which identifies the complete log.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  auditCmd.verifyCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type auditCmd struct {
+	cli *client.CommandLineClient
+	out string
+}
+
+// exportCmd exports the audit log of the daemon to a file.
+func (auditCmd *auditCmd) exportCmd(cmd *cobra.Command, args []string) {
+	if auditCmd.out == "" {
+		cli.Die("the file to export the audit log to is required (--out)")
+	}
+	resp, err := (&consensusCmd{cli: auditCmd.cli}).get("/wallet/audit")
+	if err != nil {
+		cli.DieWithError("failed to export the audit log", err)
+	}
+	defer resp.Body.Close()
+
+	file, err := os.Create(auditCmd.out)
+	if err != nil {
+		cli.DieWithError("failed to create the export file", err)
+	}
+	// verify the log while writing it, such that a corrupt export is never mistaken for a valid one
+	head, err := wallet.VerifyAuditLog(io.TeeReader(resp.Body, file))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil && (strconv.FormatUint(head.Records, 10) != resp.Header.Get("Audit-Records") || head.Hash != resp.Header.Get("Audit-Head")) {
+		err = fmt.Errorf("the export ends with record %d (%s), while the daemon exported %s records (%s)",
+			head.Records, head.Hash, resp.Header.Get("Audit-Records"), resp.Header.Get("Audit-Head"))
+	}
+	if err != nil {
+		os.Remove(auditCmd.out)
+		cli.DieWithError("failed to export the audit log", err)
+	}
+	fmt.Printf("Exported %d audit records to %s\n", head.Records, auditCmd.out)
+	fmt.Println("Hash of the last record: " + head.Hash)
+}
+
+// verifyCmd verifies an exported audit log, or the audit log of the daemon.
+func (auditCmd *auditCmd) verifyCmd(cmd *cobra.Command, args []string) {
+	var head wallet.AuditHead
+	if len(args) == 1 {
+		file, err := os.Open(args[0])
+		if err != nil {
+			cli.DieWithError("failed to open the audit log", err)
+		}
+		defer file.Close()
+		head, err = wallet.VerifyAuditLog(file)
+		if err != nil {
+			cli.DieWithError("the audit log is invalid", err)
+		}
+	} else {
+		var resp goldchainapi.WalletAuditVerifyGET
+		err := auditCmd.cli.GetAPI("/wallet/audit/verify", &resp)
+		if err != nil {
+			cli.DieWithError("failed to verify the audit log", err)
+		}
+		if !resp.Valid {
+			cli.Die("the audit log of the daemon is invalid: " + resp.Error)
+		}
+		head = resp.AuditHead
+	}
+	fmt.Printf("The audit log is valid, containing %d records\n", head.Records)
+	if head.Records > 0 {
+		fmt.Println("Hash of the last record: " + head.Hash)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+)
+
+// createAuthCoinCmds registers the commands used to reconcile the authorized addresses.
+func createAuthCoinCmds(cliClient *client.CommandLineClient) {
+	authCoinCmd := &authCoinCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "authcoin",
+		Short: "Inspect the authorized addresses of the network",
+	}
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export all authorized addresses as CSV or JSON",
+		Long: `Export all addresses authorized at the current (or given) height as CSV or JSON,
+together with the height at which their authorization last changed,
+and the amount of times they got (de)authorized.
+
+Exporting at a fixed height allows the registry to be reconciled
+against an off-chain (KYC) database, regardless of later changes.
+The daemon has to run with a consensus set synced from scratch.`,
+		Args: cobra.NoArgs,
+		Run:  authCoinCmd.exportCmd,
+	}
+	exportCmd.Flags().Uint64Var(&authCoinCmd.exportCfg.height, "height", 0,
+		"export the registry as it was at the given block height, instead of at the current height")
+	exportCmd.Flags().BoolVar(&authCoinCmd.exportCfg.deauthorized, "deauthorized", false,
+		"export the deauthorized addresses as well")
+	exportCmd.Flags().StringVar(&authCoinCmd.exportCfg.format, "format", "csv",
+		"format of the export, one of: csv, json")
+	exportCmd.Flags().StringVarP(&authCoinCmd.exportCfg.output, "output", "o", "",
+		"file to write the export to, instead of the standard output")
+	rootCmd.AddCommand(exportCmd)
+
+	cliClient.RootCmd.AddCommand(rootCmd)
+}
+
+type authCoinCmd struct {
+	cli       *client.CommandLineClient
+	exportCfg struct {
+		height       uint64
+		deauthorized bool
+		format       string
+		output       string
+	}
+}
+
+// exportCmd exports the registry of authorized addresses.
+func (authCoinCmd *authCoinCmd) exportCmd(cmd *cobra.Command, args []string) {
+	cfg := authCoinCmd.exportCfg
+	if cfg.format != "csv" && cfg.format != "json" {
+		cmd.UsageFunc()(cmd)
+		cli.Die("unsupported format:", cfg.format)
+	}
+	query := url.Values{}
+	if cmd.Flags().Changed("height") {
+		query.Set("height", strconv.FormatUint(cfg.height, 10))
+	}
+	if cfg.deauthorized {
+		query.Set("deauthorized", "true")
+	}
+	var resp goldchainapi.ConsensusAuthCoinRegistryGET
+	err := authCoinCmd.cli.GetAPI("/consensus/authcoin/registry?"+query.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the authorized address registry", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if cfg.output != "" {
+		file, err := os.Create(cfg.output)
+		if err != nil {
+			cli.DieWithError("failed to create export file", err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if cfg.format == "json" {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "\t")
+		err = encoder.Encode(resp.Registry)
+	} else {
+		err = resp.Registry.WriteCSV(w)
+	}
+	if err != nil {
+		cli.DieWithError("failed to write the authorized address registry", err)
+	}
+	if cfg.output != "" {
+		fmt.Printf("Exported %d addresses at height %d to %s (%d authorized, %d deauthorized)\n",
+			len(resp.Registry.Addresses), resp.Registry.Height, cfg.output,
+			resp.Registry.AuthorizedCount, resp.Registry.DeauthorizedCount)
+	}
+}
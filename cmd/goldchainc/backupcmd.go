@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createBackupCmds registers the wallet commands used to back up and restore a wallet.
+func createBackupCmds(cliClient *client.CommandLineClient) {
+	backupCmd := &backupCmd{cli: cliClient}
+
+	createCmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Create an encrypted backup of the wallet",
+		Long: `Create a backup of the seeds, generated addresses and address book of the wallet,
+encrypted using a passphrase which is asked for, and which is required to restore the backup.
+
+The backup is versioned, and can be restored using 'wallet restore'.
+The wallet has to be unlocked.`,
+		Args: cobra.NoArgs,
+		Run:  backupCmd.backupCmd,
+	}
+	createCmd.Flags().StringVarP(&backupCmd.backupCfg.out, "out", "o", "wallet.gcb",
+		"file to write the encrypted backup to")
+	createCmd.Flags().BoolVar(&backupCmd.backupCfg.force, "force", false,
+		"overwrite the file should it already exist")
+	cliClient.WalletCmd.AddCommand(createCmd)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore a wallet from an encrypted backup",
+		Long: `Restore a wallet from a backup created using 'wallet backup'.
+
+A new wallet is created using the primary seed of the backup,
+after which all other seeds and the contacts of the address book are loaded,
+and the same amount of addresses is generated as the backed up wallet had generated.
+The daemon may not have a wallet yet, and has to be on the same network as the backed up wallet.`,
+		Args: cobra.ExactArgs(1),
+		Run:  backupCmd.restoreCmd,
+	}
+	restoreCmd.Flags().BoolVar(&backupCmd.restoreCfg.plain, "plain", false,
+		"create a plain (unencrypted) wallet, rather than asking for a wallet passphrase")
+	cliClient.WalletCmd.AddCommand(restoreCmd)
+}
+
+type backupCmd struct {
+	cli *client.CommandLineClient
+
+	backupCfg struct {
+		out   string
+		force bool
+	}
+	restoreCfg struct {
+		plain bool
+	}
+}
+
+// backupCmd creates an encrypted backup of the wallet.
+func (backupCmd *backupCmd) backupCmd(cmd *cobra.Command, args []string) {
+	cfg := backupCmd.backupCfg
+	if _, err := os.Stat(cfg.out); err == nil && !cfg.force {
+		cli.Die(cfg.out, "already exists, use the --force flag to overwrite it")
+	}
+
+	var constants modules.DaemonConstants
+	err := backupCmd.cli.GetAPI("/daemon/constants", &constants)
+	if err != nil {
+		cli.DieWithError("failed to get the daemon constants", err)
+	}
+	var seeds api.WalletSeedsGET
+	err = backupCmd.cli.GetAPI("/wallet/seeds", &seeds)
+	if err != nil {
+		cli.DieWithError("failed to get the wallet seeds", err)
+	}
+	var addresses api.WalletAddressesGET
+	err = backupCmd.cli.GetAPI("/wallet/addresses", &addresses)
+	if err != nil {
+		cli.DieWithError("failed to get the wallet addresses", err)
+	}
+	backup := wallet.Backup{
+		Network:            constants.ChainInfo.NetworkName,
+		Created:            types.Timestamp(time.Now().Unix()),
+		PrimarySeed:        seeds.PrimarySeed,
+		AddressesGenerated: uint64(modules.PublicKeysPerSeed - seeds.AddressesRemaining),
+		Addresses:          addresses.Addresses,
+	}
+	for _, seed := range seeds.AllSeeds {
+		if seed != seeds.PrimarySeed {
+			backup.Seeds = append(backup.Seeds, seed)
+		}
+	}
+	var contacts goldchainapi.WalletContactsGET
+	err = backupCmd.cli.GetAPI("/wallet/contacts", &contacts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "WARNING: the address book is not backed up:", err)
+	}
+	backup.Contacts = contacts.Contacts
+
+	passphrase := askNewPassphrase("Backup passphrase: ")
+	b, err := wallet.EncryptBackup(backup, passphrase)
+	if err != nil {
+		cli.DieWithError("failed to encrypt the backup", err)
+	}
+	err = ioutil.WriteFile(cfg.out, append(b, '\n'), 0600)
+	if err != nil {
+		cli.DieWithError("failed to write the backup", err)
+	}
+	fmt.Printf("Backed up %d seed(s), %d address(es) and %d contact(s) to %s\n",
+		len(backup.Seeds)+1, len(backup.Addresses), len(backup.Contacts), cfg.out)
+	fmt.Println("The backup cannot be restored without its passphrase.")
+}
+
+// restoreCmd restores a wallet from an encrypted backup.
+func (backupCmd *backupCmd) restoreCmd(cmd *cobra.Command, args []string) {
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		cli.DieWithError("failed to read the backup", err)
+	}
+	passphrase, err := speakeasy.Ask("Backup passphrase: ")
+	if err != nil {
+		cli.DieWithError("failed to read the backup passphrase", err)
+	}
+	backup, err := wallet.DecryptBackup(b, passphrase)
+	if err != nil {
+		cli.DieWithError("failed to decrypt the backup", err)
+	}
+	var constants modules.DaemonConstants
+	err = backupCmd.cli.GetAPI("/daemon/constants", &constants)
+	if err != nil {
+		cli.DieWithError("failed to get the daemon constants", err)
+	}
+	if backup.Network != constants.ChainInfo.NetworkName {
+		cli.Die(fmt.Sprintf("backup of a %s wallet cannot be restored on the %s", backup.Network, constants.ChainInfo.NetworkName))
+	}
+
+	// create the wallet using the primary seed
+	var walletPassphrase string
+	if !backupCmd.restoreCfg.plain {
+		walletPassphrase = askNewPassphrase("Wallet passphrase: ")
+	}
+	walletValues := func(values url.Values) string {
+		if walletPassphrase != "" {
+			values.Set("passphrase", walletPassphrase)
+		}
+		return values.Encode()
+	}
+	seed, err := modules.InitialSeedFromMnemonic(backup.PrimarySeed)
+	if err != nil {
+		cli.DieWithError("invalid primary seed", err)
+	}
+	var initResp api.WalletInitPOST
+	err = backupCmd.cli.PostResp("/wallet/init", walletValues(url.Values{"seed": {seed.String()}}), &initResp)
+	if err != nil {
+		cli.DieWithError("failed to create the wallet", err)
+	}
+	if walletPassphrase != "" {
+		fmt.Println("Unlocking the wallet. This may take several minutes...")
+		err = backupCmd.cli.Post("/wallet/unlock", walletValues(url.Values{}))
+		if err != nil {
+			cli.DieWithError("failed to unlock the wallet", err)
+		}
+	}
+	for _, mnemonic := range backup.Seeds {
+		err = backupCmd.cli.Post("/wallet/seed", walletValues(url.Values{"mnemonic": {mnemonic}}))
+		if err != nil {
+			cli.DieWithError("failed to load a seed", err)
+		}
+	}
+
+	// generate the addresses handed out by the backed up wallet,
+	// such that they aren't handed out again
+	var seeds api.WalletSeedsGET
+	err = backupCmd.cli.GetAPI("/wallet/seeds", &seeds)
+	if err != nil {
+		cli.DieWithError("failed to get the wallet seeds", err)
+	}
+	for generated := uint64(modules.PublicKeysPerSeed - seeds.AddressesRemaining); generated < backup.AddressesGenerated; generated++ {
+		var resp api.WalletAddressGET
+		err = backupCmd.cli.GetAPI("/wallet/address", &resp)
+		if err != nil {
+			cli.DieWithError("failed to generate an address", err)
+		}
+	}
+
+	restoredContacts := 0
+	for _, contact := range backup.Contacts {
+		body, err := json.Marshal(goldchainapi.WalletContactsPOST{Name: contact.Name, Address: contact.Address})
+		if err != nil {
+			cli.DieWithError("failed to encode contact", err)
+		}
+		var resp wallet.Contact
+		err = backupCmd.cli.PostResp("/wallet/contacts", string(body), &resp)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: failed to restore contact %s: %v\n", contact.Name, err)
+			continue
+		}
+		restoredContacts++
+	}
+
+	err = backupCmd.verifyAddresses(backup.Addresses)
+	if err != nil {
+		cli.DieWithError("the wallet is restored, but differs from the backup", err)
+	}
+	fmt.Printf("Restored %d seed(s), %d address(es) and %d of %d contact(s)\n",
+		len(backup.Seeds)+1, len(backup.Addresses), restoredContacts, len(backup.Contacts))
+}
+
+// verifyAddresses returns an error should any of the given addresses not be part of the wallet.
+func (backupCmd *backupCmd) verifyAddresses(addresses []types.UnlockHash) error {
+	var resp api.WalletAddressesGET
+	err := backupCmd.cli.GetAPI("/wallet/addresses", &resp)
+	if err != nil {
+		return err
+	}
+	known := make(map[types.UnlockHash]struct{}, len(resp.Addresses))
+	for _, uh := range resp.Addresses {
+		known[uh] = struct{}{}
+	}
+	var missing int
+	for _, uh := range addresses {
+		if _, ok := known[uh]; !ok {
+			missing++
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d of the %d backed up addresses are missing", missing, len(addresses))
+	}
+	return nil
+}
+
+// askNewPassphrase asks for a new, non-empty, passphrase, to be entered twice.
+func askNewPassphrase(prompt string) string {
+	passphrase, err := speakeasy.Ask(prompt)
+	if err != nil {
+		cli.DieWithError("failed to read passphrase", err)
+	}
+	if passphrase == "" {
+		cli.DieWithError("invalid passphrase", errors.New("passphrase cannot be empty"))
+	}
+	repeated, err := speakeasy.Ask("Reenter passphrase: ")
+	if err != nil {
+		cli.DieWithError("failed to read passphrase", err)
+	}
+	if repeated != passphrase {
+		cli.Die("Given passphrases do not match")
+	}
+	return passphrase
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+)
+
+// createBlockCreatorCmds registers the commands used to control and inspect the block creator.
+func createBlockCreatorCmds(cliClient *client.CommandLineClient) {
+	blockCreatorCmd := &blockCreatorCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "blockcreator",
+		Short: "Control and inspect the creation of blocks using the blockstakes of the wallet",
+		Args:  cobra.NoArgs,
+		Run:   blockCreatorCmd.statusCmd,
+	}
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Print the block creation status and statistics",
+		Long: `Print whether block creation is enabled, the blockstakes of the wallet which can be used to create blocks,
+the expected time until the wallet creates a block, and the statistics since the daemon started:
+the amount of blocks created (and orphaned), as well as the amount of missed slots,
+being the blocks created by others while the wallet owned blockstakes, but could not create blocks itself,
+as block creation was disabled or the wallet was locked.`,
+		Args: cobra.NoArgs,
+		Run:  blockCreatorCmd.statusCmd,
+	}
+	healthCmd := &cobra.Command{
+		Use:   "health",
+		Short: "Print whether the blockstakes of the wallet create blocks as expected",
+		Long: `Print the result of the most recent health check of the block creator, which is checked once per block frequency.
+Block creation is considered stalled when the wallet hasn't created a block within a multiple (by default 5)
+of the expected time between its blocks. The likely causes are reported as well:
+a locked wallet, no peers, an unsynced daemon or a local clock drifting from the network.
+Exits with status 1 should there be problems, such that it can be used by monitoring scripts.`,
+		Args: cobra.NoArgs,
+		Run:  blockCreatorCmd.healthCmd,
+	}
+	startCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Enable block creation",
+		Long: `Enable block creation, should it have been disabled using 'blockcreator stop'.
+Block creation is enabled whenever the daemon starts.`,
+		Args: cobra.NoArgs,
+		Run:  blockCreatorCmd.startCmd,
+	}
+	stopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Disable block creation",
+		Long: `Disable block creation until it is enabled using 'blockcreator start' or until the daemon restarts,
+e.g. in order to move the blockstakes of the wallet to another node without both nodes creating blocks.`,
+		Args: cobra.NoArgs,
+		Run:  blockCreatorCmd.stopCmd,
+	}
+	blocksCmd := &cobra.Command{
+		Use:   "blocks",
+		Short: "List the blocks created using the blockstakes of the wallet",
+		Long: `List the blocks created using the blockstakes of the wallet within a range of block heights,
+by default the last 1000 blocks. At most 10000 blocks are scanned at once. The wallet has to be unlocked.`,
+		Args: cobra.NoArgs,
+		Run:  blockCreatorCmd.blocksCmd,
+	}
+	blocksCmd.Flags().Uint64Var(&blockCreatorCmd.blocksCfg.start, "start", 0,
+		"height of the first block to scan, defaults to 1000 blocks before the end")
+	blocksCmd.Flags().Uint64Var(&blockCreatorCmd.blocksCfg.end, "end", 0,
+		"height of the last block to scan, defaults to the current height")
+	delegateCmd := &cobra.Command{
+		Use:   "delegate <operator>",
+		Short: "Delegate block creation using the blockstakes of the wallet to an operator",
+		Long: `Delegate the right to create blocks using the blockstakes of the wallet to the address of an operator,
+whose node stays online to create blocks, without transferring the ownership of the blockstakes.
+The block creator fees and transaction fees of the blocks created by the operator are paid to the wallet.
+
+All blockstake outputs of the delegating addresses are respent unchanged by the delegation transaction,
+as such the blockstakes have to age once more before they can be used to create blocks.
+By default all addresses of the wallet owning blockstakes delegate their blockstakes,
+a delegation can be revoked at any time using 'blockcreator revoke'.`,
+		Args: cobra.ExactArgs(1),
+		Run:  blockCreatorCmd.delegateCmd,
+	}
+	delegateCmd.Flags().StringSliceVar(&blockCreatorCmd.delegationCfg.addresses, "address", nil,
+		"address of the wallet delegating its blockstakes, can be repeated, defaults to all addresses owning blockstakes")
+	revokeCmd := &cobra.Command{
+		Use:   "revoke",
+		Short: "Revoke the delegation of block creation using the blockstakes of the wallet",
+		Args:  cobra.NoArgs,
+		Run:   blockCreatorCmd.revokeCmd,
+	}
+	revokeCmd.Flags().StringSliceVar(&blockCreatorCmd.delegationCfg.addresses, "address", nil,
+		"address of the wallet revoking its delegation, can be repeated, defaults to all addresses owning blockstakes")
+	delegationsCmd := &cobra.Command{
+		Use:   "delegations",
+		Short: "List the active blockstake delegations of the network",
+		Args:  cobra.NoArgs,
+		Run:   blockCreatorCmd.delegationsCmd,
+	}
+	delegationsCmd.Flags().StringVar(&blockCreatorCmd.delegationCfg.operator, "operator", "",
+		"only list the delegations to the given operator")
+	rootCmd.AddCommand(statusCmd, healthCmd, startCmd, stopCmd, blocksCmd, delegateCmd, revokeCmd, delegationsCmd)
+
+	cliClient.RootCmd.AddCommand(rootCmd)
+}
+
+type blockCreatorCmd struct {
+	cli       *client.CommandLineClient
+	blocksCfg struct {
+		start, end uint64
+	}
+	delegationCfg struct {
+		addresses []string
+		operator  string
+	}
+}
+
+// statusCmd prints the block creation status and statistics.
+func (blockCreatorCmd *blockCreatorCmd) statusCmd(*cobra.Command, []string) {
+	var status goldchainapi.BlockCreatorStatusGET
+	err := blockCreatorCmd.cli.GetAPI("/blockcreator/status", &status)
+	if err != nil {
+		cli.DieWithError("failed to get the block creator status", err)
+	}
+	if status.Enabled {
+		fmt.Println("Block creation:      enabled")
+	} else {
+		fmt.Println("Block creation:      disabled")
+	}
+	if !status.WalletUnlocked {
+		fmt.Println("Wallet:              locked, blocks cannot be created")
+	} else {
+		fmt.Printf("BlockStakes:         %v BS (%v BS active, out of %v BS)\n",
+			status.OwnedStake, status.ActiveStake, status.TotalStake)
+		if status.ExpectedSecondsToNextBlock > 0 {
+			fmt.Printf("Expected block time: every %v on average\n",
+				time.Duration(status.ExpectedSecondsToNextBlock)*time.Second)
+		} else {
+			fmt.Println("Expected block time: never, as the wallet has no active blockstakes")
+		}
+	}
+	fmt.Printf("Blocks created:      %d (%d orphaned)\n", status.BlocksCreated, status.OrphanedBlocks)
+	fmt.Printf("Missed slots:        %d\n", status.MissedSlots)
+	if status.LastCreatedBlock != nil {
+		fmt.Print("Last created block:  ")
+		printCreatedBlock(*status.LastCreatedBlock)
+	}
+}
+
+// healthCmd prints the result of the most recent health check of the block creator.
+func (blockCreatorCmd *blockCreatorCmd) healthCmd(*cobra.Command, []string) {
+	var health goldchainapi.BlockCreatorHealthGET
+	err := blockCreatorCmd.cli.GetAPI("/blockcreator/health", &health)
+	if err != nil {
+		cli.DieWithError("failed to get the block creator health", err)
+	}
+	if health.StallThresholdSeconds > 0 {
+		fmt.Printf("Last block created:  %v ago (stalled after %v)\n",
+			time.Duration(health.SecondsSinceLastBlock)*time.Second, time.Duration(health.StallThresholdSeconds)*time.Second)
+	}
+	fmt.Printf("Peers:               %d\n", health.Peers)
+	fmt.Printf("Synced:              %t\n", health.Synced)
+	if !health.Enabled {
+		fmt.Println("Block creation is disabled")
+		return
+	}
+	if health.Healthy {
+		fmt.Println("Block creation is healthy")
+		return
+	}
+	cli.Die("Block creation appears to be stalled:\n  - " + strings.Join(health.Problems, "\n  - "))
+}
+
+// startCmd enables block creation.
+func (blockCreatorCmd *blockCreatorCmd) startCmd(*cobra.Command, []string) {
+	err := blockCreatorCmd.cli.Post("/blockcreator/start", "")
+	if err != nil {
+		cli.DieWithError("failed to enable block creation", err)
+	}
+	fmt.Println("Block creation is enabled")
+}
+
+// stopCmd disables block creation.
+func (blockCreatorCmd *blockCreatorCmd) stopCmd(*cobra.Command, []string) {
+	err := blockCreatorCmd.cli.Post("/blockcreator/stop", "")
+	if err != nil {
+		cli.DieWithError("failed to disable block creation", err)
+	}
+	fmt.Println("Block creation is disabled")
+}
+
+// blocksCmd lists the blocks created using the blockstakes of the wallet.
+func (blockCreatorCmd *blockCreatorCmd) blocksCmd(cmd *cobra.Command, _ []string) {
+	cfg := blockCreatorCmd.blocksCfg
+	if !cmd.Flags().Changed("end") {
+		var consensus api.ConsensusGET
+		err := blockCreatorCmd.cli.GetAPI("/consensus", &consensus)
+		if err != nil {
+			cli.DieWithError("failed to get the current height", err)
+		}
+		cfg.end = uint64(consensus.Height)
+	}
+	if !cmd.Flags().Changed("start") && cfg.end >= 1000 {
+		cfg.start = cfg.end - 999
+	}
+	query := url.Values{}
+	query.Set("end", strconv.FormatUint(cfg.end, 10))
+	query.Set("start", strconv.FormatUint(cfg.start, 10))
+	var resp goldchainapi.BlockCreatorBlocksGET
+	err := blockCreatorCmd.cli.GetAPI("/blockcreator/blocks?"+query.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the created blocks", err)
+	}
+	if len(resp.Blocks) == 0 {
+		fmt.Println("No blocks were created using the blockstakes of the wallet")
+		return
+	}
+	for _, block := range resp.Blocks {
+		printCreatedBlock(block)
+	}
+}
+
+// delegateCmd delegates block creation using the blockstakes of the wallet to an operator.
+func (blockCreatorCmd *blockCreatorCmd) delegateCmd(cmd *cobra.Command, args []string) {
+	var operator types.UnlockHash
+	err := operator.LoadString(args[0])
+	if err != nil || operator.Type == types.UnlockTypeNil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid operator address", err)
+	}
+	id := blockCreatorCmd.delegate(cmd, operator)
+	fmt.Printf("Delegated block creation to %s in transaction %s\n", operator.String(), id.String())
+}
+
+// revokeCmd revokes the delegation of block creation using the blockstakes of the wallet.
+func (blockCreatorCmd *blockCreatorCmd) revokeCmd(cmd *cobra.Command, _ []string) {
+	id := blockCreatorCmd.delegate(cmd, types.NilUnlockHash)
+	fmt.Printf("Revoked the delegation of block creation in transaction %s\n", id.String())
+}
+
+// delegate submits a blockstake delegation transaction, delegating to the given operator.
+func (blockCreatorCmd *blockCreatorCmd) delegate(cmd *cobra.Command, operator types.UnlockHash) types.TransactionID {
+	body := goldchainapi.WalletDelegationPOST{Operator: operator}
+	for _, str := range blockCreatorCmd.delegationCfg.addresses {
+		var uh types.UnlockHash
+		err := uh.LoadString(str)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid address", err)
+		}
+		body.Addresses = append(body.Addresses, uh)
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.WalletDelegationPOSTResp
+	err = blockCreatorCmd.cli.PostResp("/wallet/delegation", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to submit the delegation", err)
+	}
+	return resp.TransactionID
+}
+
+// delegationsCmd lists the active blockstake delegations.
+func (blockCreatorCmd *blockCreatorCmd) delegationsCmd(cmd *cobra.Command, _ []string) {
+	query := url.Values{}
+	if blockCreatorCmd.delegationCfg.operator != "" {
+		query.Set("operator", blockCreatorCmd.delegationCfg.operator)
+	}
+	var resp goldchainapi.ConsensusDelegationsGET
+	err := blockCreatorCmd.cli.GetAPI("/consensus/delegations?"+query.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the delegations", err)
+	}
+	if len(resp.Delegations) == 0 {
+		fmt.Println("No active delegations")
+		return
+	}
+	for _, d := range resp.Delegations {
+		fmt.Printf("%s delegates %v BS to %s since block #%d\n", d.Delegator.String(), d.Stake, d.Operator.String(), d.Height)
+	}
+}
+
+func printCreatedBlock(block staking.CreatedBlock) {
+	fmt.Printf("#%d %s at %s, by %s using %v BS\n", block.Height, block.ID.String(),
+		time.Unix(int64(block.Timestamp), 0).UTC().Format(time.RFC3339), block.Creator.String(), block.Stake)
+}
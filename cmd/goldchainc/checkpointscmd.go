@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/finality"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+)
+
+// createCheckpointsCmds registers the commands used to inspect, create and add the checkpoints of the network.
+func createCheckpointsCmds(cliClient *client.CommandLineClient) {
+	checkpointsCmd := &checkpointsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "checkpoints",
+		Short: "List the checkpoints enforced by the daemon",
+		Long: `List the maximum amount of blocks a reorganization can revert on the daemon,
+as well as the checkpoints it enforces, being blocks embedded in the release or signed by the foundation,
+which no reorganization can replace.`,
+		Args: cobra.NoArgs,
+		Run:  checkpointsCmd.listCmd,
+	}
+	createCmd := &cobra.Command{
+		Use:   "create <height> <blockid>",
+		Short: "Create a checkpoint signed by the foundation",
+		Long: `Create a checkpoint for the block with the given ID at the given height,
+signed using the key of the foundation address by a remote signer (goldchainsigner).
+The signed checkpoint is printed as JSON, to be published and added to nodes using 'checkpoints add'.
+Verify the block ID using several nodes, as nodes following another chain refuse to sync past the checkpoint.`,
+		Args: cobra.ExactArgs(2),
+		Run:  checkpointsCmd.createCmd,
+	}
+	createCmd.Flags().StringVar(&checkpointsCmd.createCfg.signer, "signer", "",
+		"API address of the remote signer holding the key of the foundation address (required)")
+	createCmd.Flags().StringVar(&checkpointsCmd.createCfg.signerPassword, "signer-password", "",
+		"API password of the remote signer, asked for if not set")
+	addCmd := &cobra.Command{
+		Use:   "add <file>",
+		Short: "Add a signed checkpoint to the daemon",
+		Long: `Add the signed checkpoint stored as JSON in the given file to the daemon, which enforces it from now on.
+Should the blockchain of the daemon conflict with the checkpoint, the checkpoint is stored nonetheless,
+but the daemon has to be resynced, by removing its consensus directory.`,
+		Args: cobra.ExactArgs(1),
+		Run:  checkpointsCmd.addCmd,
+	}
+	rootCmd.AddCommand(createCmd, addCmd)
+
+	cliClient.RootCmd.AddCommand(rootCmd)
+}
+
+type checkpointsCmd struct {
+	cli       *client.CommandLineClient
+	createCfg struct {
+		signer, signerPassword string
+	}
+}
+
+// listCmd prints the finality policy and checkpoints of the daemon.
+func (checkpointsCmd *checkpointsCmd) listCmd(*cobra.Command, []string) {
+	var resp goldchainapi.ConsensusCheckpointsGET
+	err := checkpointsCmd.cli.GetAPI("/consensus/checkpoints", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the checkpoints", err)
+	}
+	if resp.MaxReorgDepth > 0 {
+		fmt.Printf("Maximum reorganization depth: %d blocks\n", resp.MaxReorgDepth)
+	} else {
+		fmt.Println("Maximum reorganization depth: unlimited")
+	}
+	fmt.Println("Checkpoint authority:        ", resp.Authority.String())
+	if len(resp.EmbeddedCheckpoints) == 0 && len(resp.Checkpoints) == 0 {
+		fmt.Println("No checkpoints")
+		return
+	}
+	fmt.Println("Checkpoints:")
+	for _, cp := range resp.EmbeddedCheckpoints {
+		fmt.Printf("  %d\t%s (embedded)\n", cp.Height, cp.BlockID.String())
+	}
+	for _, scp := range resp.Checkpoints {
+		fmt.Printf("  %d\t%s\n", scp.Height, scp.BlockID.String())
+	}
+}
+
+// createCmd creates a checkpoint signed by the foundation using a remote signer.
+func (checkpointsCmd *checkpointsCmd) createCmd(_ *cobra.Command, args []string) {
+	height, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		cli.DieWithError("invalid height", err)
+	}
+	var hash crypto.Hash
+	err = hash.LoadString(args[1])
+	if err != nil {
+		cli.DieWithError("invalid block ID", err)
+	}
+	if checkpointsCmd.createCfg.signer == "" {
+		cli.Die("the address of the remote signer is required (--signer)")
+	}
+	password := checkpointsCmd.createCfg.signerPassword
+	if password == "" {
+		password, err = speakeasy.Ask("Enter remote signer password: ")
+		if err != nil {
+			cli.DieWithError("failed to read the remote signer password", err)
+		}
+	}
+
+	var resp goldchainapi.ConsensusCheckpointsGET
+	err = checkpointsCmd.cli.GetAPI("/consensus/checkpoints", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the checkpoint authority", err)
+	}
+	cp := finality.Checkpoint{Height: types.BlockHeight(height), BlockID: types.BlockID(hash)}
+	challenge, err := signer.NewClient(checkpointsCmd.createCfg.signer, password).SignChallenge(resp.Authority, cp.Message())
+	if err != nil {
+		cli.DieWithError("failed to sign the checkpoint", err)
+	}
+	scp := finality.NewSignedCheckpoint(cp, challenge)
+	err = scp.Verify(resp.Authority)
+	if err != nil {
+		cli.DieWithError("invalid signed checkpoint", err)
+	}
+	b, err := json.MarshalIndent(scp, "", "  ")
+	if err != nil {
+		cli.DieWithError("failed to encode the checkpoint", err)
+	}
+	fmt.Println(string(b))
+}
+
+// addCmd adds the signed checkpoint stored in the given file to the daemon.
+func (checkpointsCmd *checkpointsCmd) addCmd(_ *cobra.Command, args []string) {
+	b, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		cli.DieWithError("failed to read the checkpoint", err)
+	}
+	var scp finality.SignedCheckpoint
+	err = json.Unmarshal(b, &scp)
+	if err != nil {
+		cli.DieWithError("failed to decode the checkpoint", err)
+	}
+	err = checkpointsCmd.cli.Post("/consensus/checkpoints", string(b))
+	if err != nil {
+		cli.DieWithError("failed to add the checkpoint", err)
+	}
+	fmt.Println("Added checkpoint for", scp.Checkpoint.String())
+}
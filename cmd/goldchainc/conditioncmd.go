@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	gctypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// createConditionCmds registers the commands converting unlock conditions
+// from and to condition descriptors.
+func createConditionCmds(cliClient *client.CommandLineClient) {
+	conditionCmd := &cobra.Command{
+		Use:   "condition",
+		Short: "Convert unlock conditions from and to condition descriptors",
+		Long: `Convert unlock conditions from and to condition descriptors,
+a textual representation of (nested) unlock conditions:
+
+	nil()
+	addr(<unlockhash>)
+	multi(<minimumsignaturecount>,<unlockhash>,<unlockhash>...)
+	timelock(<locktime>,nil()|addr(...)|multi(...))
+	htlc(<sender>,<receiver>,<hashedsecret>,<timelock>)
+
+A lock time is a block height when lower than 500000000, a unix epoch timestamp (in seconds) otherwise.
+Condition descriptors can be used as destination of the wallet send commands.`,
+	}
+	conditionCmd.AddCommand(&cobra.Command{
+		Use:   "describe <rawCondition>",
+		Short: "Print the descriptor of a JSON-encoded unlock condition",
+		Args:  cobra.ExactArgs(1),
+		Run:   describeConditionCmd,
+	})
+	conditionCmd.AddCommand(&cobra.Command{
+		Use:   "parse <descriptor>",
+		Short: "Print the JSON-encoded unlock condition and unlock hash of a condition descriptor",
+		Args:  cobra.ExactArgs(1),
+		Run:   parseConditionCmd,
+	})
+	cliClient.RootCmd.AddCommand(conditionCmd)
+}
+
+func describeConditionCmd(cmd *cobra.Command, args []string) {
+	var condition types.UnlockConditionProxy
+	err := condition.UnmarshalJSON([]byte(args[0]))
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid JSON-encoded unlock condition", err)
+	}
+	descriptor, err := gctypes.ConditionDescriptor(condition)
+	if err != nil {
+		cli.DieWithError("failed to describe unlock condition", err)
+	}
+	fmt.Println(descriptor)
+}
+
+func parseConditionCmd(cmd *cobra.Command, args []string) {
+	condition, err := gctypes.ParseConditionDescriptor(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid condition descriptor", err)
+	}
+	b, err := json.Marshal(condition)
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode unlock condition", err)
+	}
+	fmt.Println(string(b))
+	fmt.Println("unlock hash:", condition.UnlockHash().String())
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/blockstream"
+)
+
+// createConsensusCmds registers the goldchain-specific consensus commands.
+func createConsensusCmds(cliClient *client.CommandLineClient) {
+	consensusCmd := &consensusCmd{cli: cliClient}
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the raw blocks of the blockchain to a file",
+		Long: `Export the raw blocks within the given range of heights to a file, in the block stream format
+of the /consensus/rawblocks endpoint, which contains a checksum of every block.
+The file can be imported by another daemon using 'goldchaind --import <file>', which validates all blocks,
+such that mirrors can be seeded and the blockchain audited without connecting to peers.`,
+		Args: cobra.NoArgs,
+		Run:  consensusCmd.exportCmd,
+	}
+	exportCmd.Flags().Uint64Var(&consensusCmd.exportCfg.from, "from", 0, "height of the first exported block")
+	exportCmd.Flags().StringVar(&consensusCmd.exportCfg.to, "to", "tip", "height of the last exported block, or tip for the current height")
+	exportCmd.Flags().StringVar(&consensusCmd.exportCfg.out, "out", "", "file the blocks are exported to (required)")
+	cliClient.ConsensusCmd.AddCommand(exportCmd)
+	cliClient.ConsensusCmd.AddCommand(&cobra.Command{
+		Use:   "forks",
+		Short: "List the hard forks scheduled for the network",
+		Long: `List the hard forks scheduled for the network, and the block size and arbitrary data limits they activate,
+as well as the limits in effect for the next block.`,
+		Args: cobra.NoArgs,
+		Run:  consensusCmd.forksCmd,
+	})
+	versionsCmd := &cobra.Command{
+		Use:   "versions",
+		Short: "List the transaction versions of the network and whether they are valid",
+		Long: `List the transaction versions of the network, the height of the first block which can contain them,
+and whether they are valid in the next block, or in the block at the given height.`,
+		Args: cobra.NoArgs,
+		Run:  consensusCmd.versionsCmd,
+	}
+	versionsCmd.Flags().Uint64Var(&consensusCmd.versionsCfg.height, "height", 0,
+		"height of the block the validity applies to, the next block by default")
+	cliClient.ConsensusCmd.AddCommand(versionsCmd)
+}
+
+type consensusCmd struct {
+	cli       *client.CommandLineClient
+	exportCfg struct {
+		from    uint64
+		to, out string
+	}
+	versionsCfg struct {
+		height uint64
+	}
+}
+
+// exportCmd exports the raw blocks within the configured range to a file.
+func (consensusCmd *consensusCmd) exportCmd(*cobra.Command, []string) {
+	cfg := consensusCmd.exportCfg
+	if cfg.out == "" {
+		cli.Die("the file to export the blocks to is required (--out)")
+	}
+	call := fmt.Sprintf("/consensus/rawblocks?start=%d", cfg.from)
+	if cfg.to != "tip" {
+		to, err := strconv.ParseUint(cfg.to, 10, 64)
+		if err != nil {
+			cli.DieWithError("invalid end height", err)
+		}
+		call += fmt.Sprintf("&end=%d", to)
+	}
+
+	resp, err := consensusCmd.get(call)
+	if err != nil {
+		cli.DieWithError("failed to export blocks", err)
+	}
+	defer resp.Body.Close()
+	end, err := strconv.ParseUint(resp.Header.Get("Block-Range-End"), 10, 64)
+	if err != nil {
+		cli.DieWithError("failed to export blocks", errors.New("the daemon did not return the height of the last block"))
+	}
+
+	file, err := os.Create(cfg.out)
+	if err != nil {
+		cli.DieWithError("failed to create the export file", err)
+	}
+	// verify the checksums and order of all blocks while writing them,
+	// such that an incomplete export is never mistaken for a complete one
+	err = exportBlocks(blockstream.NewReader(resp.Body, ^uint64(0)), blockstream.NewWriter(file),
+		types.BlockHeight(cfg.from), types.BlockHeight(end))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(cfg.out)
+		cli.DieWithError("failed to export blocks", err)
+	}
+	fmt.Printf("Exported %d blocks (heights %d to %d) to %s\n", end-cfg.from+1, cfg.from, end, cfg.out)
+}
+
+// forksCmd prints the hard fork activation table of the network.
+func (consensusCmd *consensusCmd) forksCmd(*cobra.Command, []string) {
+	var resp goldchainapi.ConsensusForksGET
+	err := consensusCmd.cli.GetAPI("/consensus/forks", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the scheduled forks", err)
+	}
+	fmt.Printf("Limits of the next block (height %d): blocks of %d bytes, %d bytes of arbitrary data per transaction\n",
+		resp.Height+1, resp.Next.BlockSizeLimit, resp.Next.ArbitraryDataSizeLimit)
+	if len(resp.Forks) == 0 {
+		fmt.Println("No forks scheduled")
+		return
+	}
+	fmt.Printf("Genesis:\tblocks of %d bytes, %d bytes of arbitrary data\n", resp.Genesis.BlockSizeLimit, resp.Genesis.ArbitraryDataSizeLimit)
+	for _, fork := range resp.Forks {
+		status := "scheduled"
+		if fork.Height <= resp.Height+1 {
+			status = "activated"
+		}
+		fmt.Printf("%s (%s at height %d):\tblocks of %d bytes, %d bytes of arbitrary data\n",
+			fork.Name, status, fork.Height, fork.BlockSizeLimit, fork.ArbitraryDataSizeLimit)
+	}
+}
+
+// versionsCmd prints the transaction versions of the network and their validity at a height.
+func (consensusCmd *consensusCmd) versionsCmd(cmd *cobra.Command, _ []string) {
+	call := "/consensus/transactionversions"
+	if cmd.Flags().Changed("height") {
+		call += fmt.Sprintf("?height=%d", consensusCmd.versionsCfg.height)
+	}
+	var resp goldchainapi.ConsensusTransactionVersionsGET
+	err := consensusCmd.cli.GetAPI(call, &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the transaction versions", err)
+	}
+	fmt.Printf("Transaction versions at height %d:\n", resp.Height)
+	for _, version := range resp.Versions {
+		status := fmt.Sprintf("valid since height %d", version.ActivationHeight)
+		if !version.Active {
+			status = fmt.Sprintf("not valid until height %d", version.ActivationHeight)
+		}
+		fmt.Printf("%d\t%s\t%s\n", version.Version, version.Name, status)
+	}
+}
+
+// exportBlocks copies the blocks of the given stream, which are expected to range from start to end.
+func exportBlocks(stream *blockstream.Reader, w *blockstream.Writer, start, end types.BlockHeight) error {
+	next := start
+	for {
+		frame, err := stream.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if frame.Height != next {
+			return fmt.Errorf("expected block at height %d, got block at height %d", next, frame.Height)
+		}
+		err = w.WriteRawBlock(frame.Height, frame.Raw)
+		if err != nil {
+			return err
+		}
+		next++
+	}
+	if next != end+1 {
+		// the chain got reorganized to a shorter one while exporting
+		return fmt.Errorf("export ended prematurely at height %d rather than %d", next-1, end)
+	}
+	return nil
+}
+
+// get calls the given endpoint of the daemon, returning the response as is should it succeed.
+func (consensusCmd *consensusCmd) get(call string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, consensusCmd.cli.RootURL+call, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", consensusCmd.cli.UserAgent)
+	if consensusCmd.cli.Password != "" {
+		req.SetBasicAuth("", consensusCmd.cli.Password)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, errors.New("no response from daemon")
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr api.Error
+		b, _ := ioutil.ReadAll(resp.Body)
+		if json.Unmarshal(b, &apiErr) == nil && apiErr.Message != "" {
+			return nil, apiErr
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, b)
+	}
+	return resp, nil
+}
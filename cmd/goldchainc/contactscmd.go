@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	authcointxcli "github.com/threefoldtech/rivine/extensions/authcointx/client"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createContactsCmds registers the wallet commands used to manage the address book.
+func createContactsCmds(cliClient *client.CommandLineClient) {
+	contactsCmd := &contactsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "contacts",
+		Short: "List the contacts of the address book",
+		Long: `List the named contacts of the address book of the wallet.
+
+The address book is encrypted using the primary seed of the wallet,
+and can thus only be used while the wallet is unlocked.
+Coins can be sent to a contact by using its name instead of an address,
+as long as the address of the contact is authorized to receive coins.`,
+		Args: cobra.NoArgs,
+		Run:  contactsCmd.listCmd,
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "add <name> <address>",
+		Short: "Add a contact to the address book",
+		Args:  cobra.ExactArgs(2),
+		Run:   contactsCmd.addCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "update <name> <address>",
+		Short: "Update the address of a contact",
+		Args:  cobra.ExactArgs(2),
+		Run:   contactsCmd.updateCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a contact from the address book",
+		Args:  cobra.ExactArgs(1),
+		Run:   contactsCmd.removeCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type contactsCmd struct {
+	cli *client.CommandLineClient
+}
+
+// listCmd lists all contacts of the address book.
+func (contactsCmd *contactsCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletContactsGET
+	err := contactsCmd.cli.GetAPI("/wallet/contacts", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the contacts of the address book", err)
+	}
+	if len(resp.Contacts) == 0 {
+		fmt.Println("No contacts.")
+		return
+	}
+	for _, contact := range resp.Contacts {
+		fmt.Printf("%s\t%s\n", contact.Name, contact.Address.String())
+	}
+}
+
+// addCmd adds a contact to the address book.
+func (contactsCmd *contactsCmd) addCmd(cmd *cobra.Command, args []string) {
+	err := wallet.ValidateContactName(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid contact name", err)
+	}
+	address := parseContactAddress(cmd, args[1])
+	b, err := json.Marshal(goldchainapi.WalletContactsPOST{
+		Name:    args[0],
+		Address: address,
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the contact", err)
+	}
+	var contact wallet.Contact
+	err = contactsCmd.cli.PostResp("/wallet/contacts", string(b), &contact)
+	if err != nil {
+		cli.DieWithError("failed to add the contact", err)
+	}
+	fmt.Printf("Added contact %s with address %s\n", contact.Name, contact.Address.String())
+}
+
+// updateCmd updates the address of a contact.
+func (contactsCmd *contactsCmd) updateCmd(cmd *cobra.Command, args []string) {
+	address := parseContactAddress(cmd, args[1])
+	b, err := json.Marshal(goldchainapi.WalletContactPOST{
+		Address: address,
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the contact", err)
+	}
+	var contact wallet.Contact
+	err = contactsCmd.cli.PostResp("/wallet/contacts/"+url.PathEscape(args[0]), string(b), &contact)
+	if err != nil {
+		cli.DieWithError("failed to update the contact", err)
+	}
+	fmt.Printf("Updated contact %s to address %s\n", contact.Name, contact.Address.String())
+}
+
+// removeCmd removes a contact from the address book.
+func (contactsCmd *contactsCmd) removeCmd(cmd *cobra.Command, args []string) {
+	err := contactsCmd.cli.Post("/wallet/contacts/"+url.PathEscape(args[0])+"/remove", "")
+	if err != nil {
+		cli.DieWithError("failed to remove the contact", err)
+	}
+	fmt.Println("Removed contact " + args[0])
+}
+
+// parseContactAddress parses the address of a contact.
+func parseContactAddress(cmd *cobra.Command, str string) types.UnlockHash {
+	address, err := network.ParseAddress(str)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.Die("invalid contact address:", err)
+	}
+	return address
+}
+
+// resolveContacts replaces the contact names used as destination
+// in pairs of '<dest>' and '<amount>' arguments by the addresses of those contacts.
+// Sending to a contact is refused should its address not be authorized.
+func resolveContacts(cliClient *client.CommandLineClient, args []string) []string {
+	var resolved []string
+	for i := 0; i < len(args); i += 2 {
+		if wallet.ValidateContactName(args[i]) != nil {
+			continue
+		}
+		var contact wallet.Contact
+		err := cliClient.GetAPI("/wallet/contacts/"+url.PathEscape(args[i]), &contact)
+		if err != nil {
+			cli.DieWithError("failed to get the address of contact "+args[i], err)
+		}
+		ensureAuthorized(cliClient, contact.Address, "the address of contact "+contact.Name)
+		if resolved == nil {
+			resolved = append([]string(nil), args...)
+		}
+		resolved[i] = contact.Address.String()
+	}
+	if resolved == nil {
+		return args
+	}
+	return resolved
+}
+
+// ensureAuthorized dies in case the given address is currently not authorized to receive coins,
+// using the given description of the address in the error message.
+func ensureAuthorized(cliClient *client.CommandLineClient, address types.UnlockHash, description string) {
+	states, err := authcointxcli.NewPluginConsensusClient(cliClient).GetAddressesAuthStateNow([]types.UnlockHash{address}, nil)
+	if err != nil {
+		cli.DieWithError("failed to check whether "+description+" is authorized", err)
+	}
+	if !states[0] {
+		cli.Die(description + " " + address.String() + " is not authorized to receive coins")
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createDepositsCmds registers the wallet commands used to allocate deposit addresses.
+func createDepositsCmds(cliClient *client.CommandLineClient) {
+	depositsCmd := &depositsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "deposits",
+		Short: "List the allocated deposit addresses",
+		Long: `List the deposit addresses allocated for customers,
+each of them a fresh address of the wallet labeled using the reference of the customer and tagged as deposit.`,
+		Args: cobra.NoArgs,
+		Run:  depositsCmd.listCmd,
+	}
+	rootCmd.Flags().StringVar(&depositsCmd.listCfg.Reference, "reference", "",
+		"only list the deposit addresses allocated for this customer reference")
+	allocateCmd := &cobra.Command{
+		Use:   "allocate <reference>",
+		Short: "Allocate a fresh deposit address for a customer reference",
+		Long: `Allocate a fresh deposit address for a customer reference,
+posting every deposit confirmed or reverted while the daemon runs to the given webhook, if any.`,
+		Args: cobra.ExactArgs(1),
+		Run:  depositsCmd.allocateCmd,
+	}
+	allocateCmd.Flags().StringVar(&depositsCmd.allocateCfg.Webhook.URL, "webhook", "",
+		"HTTP(S) URL to which the deposits to the address are posted as JSON")
+	allocateCmd.Flags().StringVar(&depositsCmd.allocateCfg.Webhook.Secret, "secret", "",
+		"secret used to sign the notifications using HMAC-SHA256, sent as the "+wallet.DepositSignatureHeader+" header")
+	rootCmd.AddCommand(allocateCmd)
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type depositsCmd struct {
+	cli     *client.CommandLineClient
+	listCfg struct {
+		Reference string
+	}
+	allocateCfg struct {
+		Webhook wallet.DepositWebhook
+	}
+}
+
+// listCmd lists the allocated deposit addresses.
+func (depositsCmd *depositsCmd) listCmd(cmd *cobra.Command, args []string) {
+	call := "/wallet/deposits"
+	if depositsCmd.listCfg.Reference != "" {
+		call += "?reference=" + url.QueryEscape(depositsCmd.listCfg.Reference)
+	}
+	var resp goldchainapi.WalletDepositsGET
+	err := depositsCmd.cli.GetAPI(call, &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the deposit addresses", err)
+	}
+	if len(resp.Addresses) == 0 {
+		fmt.Println("No deposit addresses.")
+		return
+	}
+	for _, address := range resp.Addresses {
+		printDepositAddress(address)
+	}
+}
+
+// allocateCmd allocates a fresh deposit address.
+func (depositsCmd *depositsCmd) allocateCmd(cmd *cobra.Command, args []string) {
+	b, err := json.Marshal(goldchainapi.WalletDepositsPOST{
+		Reference: args[0],
+		Webhook:   depositsCmd.allocateCfg.Webhook,
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the deposit address", err)
+	}
+	var address wallet.DepositAddress
+	err = depositsCmd.cli.PostResp("/wallet/deposits", string(b), &address)
+	if err != nil {
+		cli.DieWithError("failed to allocate a deposit address", err)
+	}
+	printDepositAddress(address)
+}
+
+func printDepositAddress(address wallet.DepositAddress) {
+	line := fmt.Sprintf("%s\t%s\tallocated at height %d", address.Address.String(), address.Reference, address.Height)
+	if address.Webhook != "" {
+		line += "\tnotifying " + address.Webhook
+	}
+	fmt.Println(line)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+)
+
+// dryRun is set by the global --dry-run flag.
+var dryRun bool
+
+// createDryRunFlag registers the global --dry-run flag,
+// with which the send commands validate the transaction they would create or publish,
+// instead of broadcasting it. It has to be called after all other commands are registered.
+//
+// In dry-run mode all other POST calls are refused,
+// such that commands without dry-run support cannot change the state of the wallet or transaction pool.
+func createDryRunFlag(cliClient *client.CommandLineClient) {
+	cliClient.RootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false,
+		"validate and print the transaction a command would send, without broadcasting it")
+	cobra.OnInitialize(func() {
+		if dryRun {
+			http.DefaultClient.Transport = dryRunTransport{next: http.DefaultTransport}
+		}
+	})
+
+	walletCmd := &walletCmd{cli: cliClient}
+	for _, sendCmd := range cliClient.WalletCmd.RootCmdSend.Commands() {
+		switch sendCmd.Name() {
+		case "blockstakes":
+			sendCmd.Run = dryRunOr(sendCmd.Run, walletCmd.dryRunSendBlockStakesCmd)
+		case "transaction":
+			sendCmd.Run = dryRunOr(sendCmd.Run, func(cmd *cobra.Command, args []string) {
+				if len(args) != 1 {
+					cmd.UsageFunc()(cmd)
+					cli.Die("exactly one transaction has to be given")
+				}
+				var txn types.Transaction
+				err := json.Unmarshal([]byte(args[0]), &txn)
+				if err != nil {
+					cli.DieWithError("failed to decode the transaction", err)
+				}
+				dryRunTransaction(cliClient, txn)
+			})
+		}
+	}
+}
+
+// dryRunOr returns a command run function calling dryRunFn in dry-run mode, and fn otherwise.
+func dryRunOr(fn, dryRunFn func(*cobra.Command, []string)) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		if dryRun {
+			dryRunFn(cmd, args)
+			return
+		}
+		fn(cmd, args)
+	}
+}
+
+// dryRunSendBlockStakesCmd validates the transaction the rivine send blockstakes command would send.
+func (walletCmd *walletCmd) dryRunSendBlockStakesCmd(cmd *cobra.Command, args []string) {
+	blockStakeOutputs, err := parseBlockStakeOutputs(args)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.Die(err)
+	}
+	body := api.WalletBlockStakesPOST{
+		BlockStakeOutputs: blockStakeOutputs,
+	}
+	body.Data, body.RefundAddress, body.GenerateRefundAddress = rivineSendFlags(cmd)
+	walletCmd.dryRunSend("/wallet/blockstakes", body, url.Values{})
+}
+
+// dryRunSend validates the transaction a POST call to the given wallet send endpoint would send,
+// printing the transaction and the outputs funding it.
+func (walletCmd *walletCmd) dryRunSend(call string, body interface{}, query url.Values) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	query.Set("dryrun", "true")
+	var resp goldchainapi.WalletDryRunPOSTResp
+	err = walletCmd.cli.PostResp(call+"?"+query.Encode(), string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to dry run the transaction", err)
+	}
+	if resp.FundedTransaction != nil {
+		currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+		printTransaction(resp.Transaction, currencyConvertor)
+		for _, input := range resp.CoinInputs {
+			fmt.Printf("Funded by coin output %s of %s\n",
+				input.ID.String(), currencyConvertor.ToCoinStringWithUnit(input.Output.Value))
+		}
+		for _, input := range resp.BlockStakeInputs {
+			fmt.Printf("Funded by blockstake output %s of %s BS\n", input.ID.String(), input.Output.Value.String())
+		}
+	}
+	printDryRunResult(resp.Valid, resp.Error)
+}
+
+// dryRunTransaction validates the given transaction against the current consensus state,
+// without publishing it, and prints it.
+func dryRunTransaction(cliClient *client.CommandLineClient, txn types.Transaction) {
+	b, err := json.Marshal(goldchainapi.ConsensusValidatePOST{Transactions: []types.Transaction{txn}})
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.ConsensusValidatePOSTResp
+	err = cliClient.PostResp("/consensus/validate", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to validate the transaction", err)
+	}
+	printTransaction(txn, cliClient.CreateCurrencyConvertor())
+	printDryRunResult(resp.Valid, resp.Error)
+}
+
+// printTransaction prints the decoded transaction, its outputs and fees.
+func printTransaction(txn types.Transaction, currencyConvertor client.CurrencyConvertor) {
+	b, err := json.MarshalIndent(txn, "", "  ")
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the transaction", err)
+	}
+	fmt.Println(string(b))
+	fmt.Println("Transaction ID: " + txn.ID().String())
+	for _, co := range txn.CoinOutputs {
+		fmt.Printf("Sends %s to %s (using ConditionType %d)\n",
+			currencyConvertor.ToCoinStringWithUnit(co.Value), co.Condition.UnlockHash(), co.Condition.ConditionType())
+	}
+	for _, bso := range txn.BlockStakeOutputs {
+		fmt.Printf("Sends %s BS to %s (using ConditionType %d)\n",
+			bso.Value.String(), bso.Condition.UnlockHash(), bso.Condition.ConditionType())
+	}
+	var fees types.Currency
+	for _, fee := range txn.MinerFees {
+		fees = fees.Add(fee)
+	}
+	fmt.Println("Miner fees: " + currencyConvertor.ToCoinStringWithUnit(fees))
+}
+
+// printDryRunResult prints the validation result of a dry run,
+// exiting with an error in case the transaction is invalid.
+func printDryRunResult(valid bool, reason string) {
+	if !valid {
+		cli.Die("Dry run: transaction is invalid: " + reason)
+	}
+	fmt.Println("Dry run: transaction is valid, it has not been broadcast")
+}
+
+// dryRunTransport refuses all POST calls which could change the state of the daemon,
+// allowing only the calls used to validate transactions.
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.RoundTrip
+func (t dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodPost && req.URL.Path != "/consensus/validate" {
+		if ok, _ := strconv.ParseBool(req.URL.Query().Get("dryrun")); !ok {
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			// respond with an API error, as the client hides transport errors
+			b, _ := json.Marshal(api.Error{Message: "call to " + req.URL.Path + " is not allowed in dry-run mode"})
+			return &http.Response{
+				Status:     "403 Forbidden",
+				StatusCode: http.StatusForbidden,
+				Proto:      req.Proto,
+				ProtoMajor: req.ProtoMajor,
+				ProtoMinor: req.ProtoMinor,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       ioutil.NopCloser(bytes.NewReader(b)),
+				Request:    req,
+			}, nil
+		}
+	}
+	return t.next.RoundTrip(req)
+}
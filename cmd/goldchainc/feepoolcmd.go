@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createFeePoolCmds registers the wallet commands used to account for and distribute the transaction fee pool.
+func createFeePoolCmds(cliClient *client.CommandLineClient) {
+	feePoolCmd := &feePoolCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "feepool",
+		Short: "Show the fees which flowed to the transaction fee pool",
+		Long: `Show the fees which flowed to the transaction fee pool, in total and per period,
+as tracked by the explorer of the daemon.
+
+The fee pool is distributed to its beneficiaries using a payout template funded by the fee pool address,
+named feepool unless defined otherwise using the --template flag. Every distribution sent is recorded
+in the distribution log of the wallet.`,
+		Args: cobra.NoArgs,
+		Run:  feePoolCmd.inflowCmd,
+	}
+	rootCmd.Flags().StringVar(&feePoolCmd.inflowCfg.period, "period", "week", "period by which the fees are aggregated: day, week or month")
+	rootCmd.Flags().Uint64Var(&feePoolCmd.inflowCfg.periods, "periods", 12, "amount of most recent periods shown")
+	rootCmd.PersistentFlags().StringVar(&feePoolCmd.template, "template", "feepool", "name of the payout template distributing the fee pool")
+
+	beneficiariesCmd := &cobra.Command{
+		Use:   "beneficiaries <beneficiaries.json>",
+		Short: "Define the beneficiaries of the fee pool",
+		Long: `Define the beneficiaries of the fee pool, adding or updating the payout template distributing it.
+The beneficiaries are defined by a JSON file such as:
+
+{
+  "recipients": [
+    {"label": "operations", "address": "01...", "amount": "1000000000"},
+    {"label": "team", "address": "01...", "percentage": 60},
+    {"label": "foundation", "address": "01...", "percentage": 40}
+  ],
+  "schedule": "0 12 * * 1"
+}
+
+The percentages apply to the balance of the fee pool left once the fixed amounts and the miner fee are paid.
+The optional schedule lets the daemon distribute the fee pool, see the wallet payouts add command.
+The fee pool address funds the distributions, unless another address is defined using the --source flag.`,
+		Args: cobra.ExactArgs(1),
+		Run:  feePoolCmd.beneficiariesCmd,
+	}
+	beneficiariesCmd.Flags().StringVar(&feePoolCmd.source, "source", "",
+		"address funding the distributions, the transaction fee pool address of the network by default")
+	rootCmd.AddCommand(beneficiariesCmd)
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "distribute",
+		Short: "Distribute the fee pool to its beneficiaries",
+		Long: `Distribute the balance of the fee pool to its beneficiaries in a single transaction, recording the distribution.
+Use the global --dry-run flag to print the transaction without broadcasting it.`,
+		Args: cobra.NoArgs,
+		Run:  feePoolCmd.distributeCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "distributions",
+		Short: "List the recorded distributions of the fee pool",
+		Args:  cobra.NoArgs,
+		Run:   feePoolCmd.distributionsCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type feePoolCmd struct {
+	cli       *client.CommandLineClient
+	template  string
+	source    string
+	inflowCfg struct {
+		period  string
+		periods uint64
+	}
+}
+
+// inflowCmd shows the fees which flowed to the fee pool, in total and per period.
+func (feePoolCmd *feePoolCmd) inflowCmd(cmd *cobra.Command, args []string) {
+	query := url.Values{}
+	query.Set("period", feePoolCmd.inflowCfg.period)
+	query.Set("periods", strconv.FormatUint(feePoolCmd.inflowCfg.periods, 10))
+	var resp goldchainapi.ExplorerFeePoolGET
+	err := feePoolCmd.cli.GetAPI("/explorer/feepool?"+query.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the fee pool inflow", err)
+	}
+	currencyConvertor := feePoolCmd.cli.CreateCurrencyConvertor()
+	if resp.Address.Type == types.UnlockTypeNil {
+		fmt.Println("The network has no transaction fee pool, fees are paid to the block creators.")
+	} else {
+		fmt.Println("Fee pool address: " + resp.Address.String())
+	}
+	fmt.Printf("Total inflow at height %d: %s\n", resp.Height, currencyConvertor.ToCoinStringWithUnit(resp.Total))
+	for _, period := range resp.Periods {
+		fmt.Printf("  %s\t%6d blocks\t%s\n", time.Unix(int64(period.Start), 0).UTC().Format("2006-01-02"),
+			period.Blocks, currencyConvertor.ToCoinStringWithUnit(period.Inflow))
+	}
+}
+
+// beneficiariesCmd adds or updates the payout template distributing the fee pool.
+func (feePoolCmd *feePoolCmd) beneficiariesCmd(cmd *cobra.Command, args []string) {
+	def := readPayoutDefinition(args[0])
+	source := feePoolCmd.sourceAddress()
+	def.Source = &source
+
+	var payouts goldchainapi.WalletPayoutsGET
+	err := feePoolCmd.cli.GetAPI("/wallet/payouts", &payouts)
+	if err != nil {
+		cli.DieWithError("failed to get the payout templates", err)
+	}
+	call, body := "/wallet/payouts", interface{}(goldchainapi.WalletPayoutsPOST{Name: feePoolCmd.template, PayoutDefinition: def})
+	for _, template := range payouts.Templates {
+		if template.Name == feePoolCmd.template {
+			call, body = "/wallet/payouts/"+url.PathEscape(feePoolCmd.template), goldchainapi.WalletPayoutPOST{PayoutDefinition: def}
+			break
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the payout template", err)
+	}
+	var template wallet.PayoutTemplate
+	err = feePoolCmd.cli.PostResp(call, string(b), &template)
+	if err != nil {
+		cli.DieWithError("failed to define the beneficiaries of the fee pool", err)
+	}
+	fmt.Printf("The fee pool %s is distributed by payout template %s to %d beneficiaries\n",
+		source.String(), template.Name, len(template.Recipients))
+}
+
+// distributeCmd executes the payout template distributing the fee pool, or dry runs it in dry-run mode.
+func (feePoolCmd *feePoolCmd) distributeCmd(cmd *cobra.Command, args []string) {
+	call := "/wallet/payouts/" + url.PathEscape(feePoolCmd.template) + "/execute"
+	if dryRun {
+		walletCmd := &walletCmd{cli: feePoolCmd.cli}
+		walletCmd.dryRunSend(call, struct{}{}, url.Values{})
+		return
+	}
+	var resp struct {
+		goldchainapi.WalletPayoutExecutePOSTResp
+		// Pending is defined should the distribution exceed the approval threshold of the spend policy
+		Pending *wallet.PendingSpend `json:"pending"`
+	}
+	err := feePoolCmd.cli.PostResp(call, "", &resp)
+	if err != nil {
+		cli.DieWithError("failed to distribute the fee pool", err)
+	}
+	if resp.Pending != nil {
+		fmt.Println("Distribution awaits approval as pending spend " + resp.Pending.ID)
+		return
+	}
+	currencyConvertor := feePoolCmd.cli.CreateCurrencyConvertor()
+	fmt.Println("Distributed the fee pool balance of " + currencyConvertor.ToCoinStringWithUnit(resp.Payout.Balance) + ":")
+	for _, co := range resp.Payout.CoinOutputs {
+		fmt.Printf("  %s\t%s\n", co.Condition.UnlockHash().String(), currencyConvertor.ToCoinStringWithUnit(co.Value))
+	}
+	fmt.Println("Transaction ID: " + resp.TransactionID.String())
+}
+
+// distributionsCmd lists the recorded distributions of the fee pool.
+func (feePoolCmd *feePoolCmd) distributionsCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletDistributionsGET
+	err := feePoolCmd.cli.GetAPI("/wallet/distributions?template="+url.QueryEscape(feePoolCmd.template), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the distributions of the fee pool", err)
+	}
+	if len(resp.Distributions) == 0 {
+		fmt.Println("No distributions recorded.")
+		return
+	}
+	currencyConvertor := feePoolCmd.cli.CreateCurrencyConvertor()
+	for _, d := range resp.Distributions {
+		kind := "requested"
+		if d.Scheduled {
+			kind = "scheduled"
+		}
+		fmt.Printf("%s (height %d, %s): %s distributed in transaction %s\n",
+			time.Unix(int64(d.Time), 0).UTC().Format(time.RFC3339), d.Height, kind,
+			currencyConvertor.ToCoinStringWithUnit(d.Balance), d.TransactionID.String())
+		for _, share := range d.Shares {
+			label := share.Label
+			if label == "" {
+				label = "-"
+			}
+			fmt.Printf("  %s\t%s\t%s\n", label, share.Address.String(), currencyConvertor.ToCoinStringWithUnit(share.Value))
+		}
+	}
+}
+
+// sourceAddress returns the address funding the distributions: the --source flag if defined,
+// or the transaction fee pool address of the network otherwise.
+func (feePoolCmd *feePoolCmd) sourceAddress() types.UnlockHash {
+	var uh types.UnlockHash
+	if feePoolCmd.source != "" {
+		err := uh.LoadString(feePoolCmd.source)
+		if err != nil {
+			cli.DieWithError("invalid source address", err)
+		}
+		return uh
+	}
+	var resp goldchainapi.ExplorerFeePoolGET
+	err := feePoolCmd.cli.GetAPI("/explorer/feepool?periods=0", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the fee pool address, define it using the --source flag", err)
+	}
+	if resp.Address.Type == types.UnlockTypeNil {
+		cli.Die("the network has no transaction fee pool, define the address to distribute using the --source flag")
+	}
+	return resp.Address
+}
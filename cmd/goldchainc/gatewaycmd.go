@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+)
+
+// createGatewayCmds registers the gateway commands used to inspect the scores of the peers and manage their bans.
+func createGatewayCmds(cliClient *client.CommandLineClient) {
+	gatewayCmd := &gatewayCmd{cli: cliClient}
+
+	scoresCmd := &cobra.Command{
+		Use:   "scores",
+		Short: "List the scores of the peers and the banned hosts",
+		Long: `List the scores and metrics of the connected and recently connected peers, as well as the banned hosts.
+
+Peers lose score by relaying invalid blocks or transactions and by failing to respond,
+regaining it over time. Peers are preferred by score when syncing, and the hosts of peers
+of which the score drops below the ban score of the daemon are banned.`,
+		Args: cobra.NoArgs,
+		Run:  gatewayCmd.scoresCmd,
+	}
+	banCmd := &cobra.Command{
+		Use:   "ban <host>",
+		Short: "Ban a host",
+		Long: `Ban the given host (or the host of the given network address), disconnecting all its peers
+and refusing all its connections until the ban expires.`,
+		Args: cobra.ExactArgs(1),
+		Run:  gatewayCmd.banCmd,
+	}
+	banCmd.Flags().DurationVar(&gatewayCmd.banDuration, "duration", 0,
+		"duration of the ban, the ban duration of the daemon by default")
+	unbanCmd := &cobra.Command{
+		Use:   "unban <host>",
+		Short: "Lift the ban of a host",
+		Args:  cobra.ExactArgs(1),
+		Run:   gatewayCmd.unbanCmd,
+	}
+
+	portMappingCmd := &cobra.Command{
+		Use:   "portmapping",
+		Short: "Print the status of the mapping of the RPC port on the NAT gateway",
+		Args:  cobra.NoArgs,
+		Run:   gatewayCmd.portMappingCmd,
+	}
+
+	cliClient.GatewayCmd.AddCommand(scoresCmd, banCmd, unbanCmd, portMappingCmd)
+}
+
+type gatewayCmd struct {
+	cli         *client.CommandLineClient
+	banDuration time.Duration
+}
+
+// scoresCmd prints the scores of the peers and the banned hosts.
+func (gatewayCmd *gatewayCmd) scoresCmd(*cobra.Command, []string) {
+	var resp goldchainapi.GatewayScoresGET
+	err := gatewayCmd.cli.GetAPI("/gateway/scores", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the peer scores", err)
+	}
+	if resp.Policy.BanDuration > 0 {
+		fmt.Printf("Peers scoring below %v are banned for %v\n", resp.Policy.BanScore, resp.Policy.BanDuration)
+	} else {
+		fmt.Println("Banning is disabled")
+	}
+	fmt.Printf("Upload limit: %s, download limit: %s, message rate per peer: %s\n",
+		formatRate(float64(resp.Limits.UploadRate)/1024, "KiB/s"), formatRate(float64(resp.Limits.DownloadRate)/1024, "KiB/s"),
+		formatRate(resp.Limits.PeerMessageRate, "RPCs/s"))
+	if len(resp.Peers) == 0 {
+		fmt.Println("No peers")
+	} else {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Peer\tScore\tConnected\tInvalid blocks\tInvalid txn sets\tFailed RPCs\tRate limited\tUp (KiB)\tDown (KiB)\tLatency\tUptime")
+		for _, peer := range resp.Peers {
+			fmt.Fprintf(w, "%s\t%.2f\t%v\t%d\t%d\t%d/%d\t%d\t%d\t%d\t%v\t%v\n", peer.NetAddress, peer.Score, peer.Connected,
+				peer.InvalidBlocks, peer.InvalidTransactionSets, peer.FailedRPCs, peer.FailedRPCs+peer.SuccessfulRPCs, peer.RateLimitedRPCs,
+				peer.BytesUploaded/1024, peer.BytesDownloaded/1024, peer.Latency.Round(time.Millisecond), peer.Uptime.Round(time.Second))
+		}
+		w.Flush()
+	}
+	if len(resp.Bans) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Banned host\tUntil\tReason")
+		for _, ban := range resp.Bans {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", ban.Host, ban.Until.Local().Format(time.RFC3339), ban.Reason)
+		}
+		w.Flush()
+	}
+}
+
+// banCmd bans a host.
+func (gatewayCmd *gatewayCmd) banCmd(cmd *cobra.Command, args []string) {
+	values := url.Values{}
+	if gatewayCmd.banDuration != 0 {
+		values.Set("duration", gatewayCmd.banDuration.String())
+	}
+	err := gatewayCmd.cli.Post("/gateway/ban/"+args[0], values.Encode())
+	if err != nil {
+		cli.DieWithError("failed to ban the host", err)
+	}
+	fmt.Println("Banned", args[0])
+}
+
+// unbanCmd lifts the ban of a host.
+func (gatewayCmd *gatewayCmd) unbanCmd(cmd *cobra.Command, args []string) {
+	err := gatewayCmd.cli.Post("/gateway/unban/"+args[0], "")
+	if err != nil {
+		cli.DieWithError("failed to unban the host", err)
+	}
+	fmt.Println("Unbanned", args[0])
+}
+
+// portMappingCmd prints the status of the port mapping.
+func (gatewayCmd *gatewayCmd) portMappingCmd(*cobra.Command, []string) {
+	var resp goldchainapi.GatewayPortMappingGET
+	err := gatewayCmd.cli.GetAPI("/gateway/portmapping", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the port mapping", err)
+	}
+	if !resp.Enabled {
+		fmt.Println("Port mapping using NAT-PMP is disabled")
+		return
+	}
+	mapping := resp.Mapping
+	fmt.Println("NAT gateway:", mapping.Gateway)
+	fmt.Println("Internal port:", mapping.InternalPort)
+	if mapping.ExternalPort != 0 {
+		fmt.Println("External address:", net.JoinHostPort(mapping.ExternalIP, fmt.Sprint(mapping.ExternalPort)))
+		fmt.Println("Expires:", mapping.Expires.Local().Format(time.RFC3339))
+	}
+	if mapping.Error != "" {
+		fmt.Println("Error:", mapping.Error)
+	}
+}
+
+// formatRate formats a rate limit, 0 being unlimited.
+func formatRate(rate float64, unit string) string {
+	if rate == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g %s", rate, unit)
+}
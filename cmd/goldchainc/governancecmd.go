@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	gctypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// createGovernanceCmds registers the commands used to vote on governance proposals and inspect their results.
+func createGovernanceCmds(cliClient *client.CommandLineClient) {
+	governanceCmd := &governanceCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "governance",
+		Short: "Vote on governance proposals using the blockstakes of the wallet, and inspect their results",
+		Args:  cobra.NoArgs,
+		Run:   governanceCmd.proposalsCmd,
+	}
+	proposalsCmd := &cobra.Command{
+		Use:   "proposals",
+		Short: "List the governance proposals voted on",
+		Args:  cobra.NoArgs,
+		Run:   governanceCmd.proposalsCmd,
+	}
+	proposalCmd := &cobra.Command{
+		Use:   "proposal <id>",
+		Short: "Print the tally of the votes on a governance proposal",
+		Long: `Print the tally of the votes on a governance proposal, weighted by the blockstakes of the voters
+at the end height of the proposal, or at the current height while the proposal is open.
+A closed proposal is approved in case the voters own more than half of all blockstakes,
+and more blockstakes voted yes than no.`,
+		Args: cobra.ExactArgs(1),
+		Run:  governanceCmd.proposalCmd,
+	}
+	voteCmd := &cobra.Command{
+		Use:   "vote yes|no|abstain",
+		Short: "Vote on a governance proposal using the blockstakes of the wallet",
+		Long: `Vote on a governance proposal using the blockstakes of the wallet, up to (and including) the end height of the proposal.
+A proposal voted on already is referred to using its ID (--proposal),
+a new proposal is defined by its title, optional reference and end height instead.
+
+All blockstake outputs of the voting addresses are respent unchanged by the vote transaction,
+as such the blockstakes have to age once more before they can be used to create blocks.
+By default all addresses of the wallet owning blockstakes vote, each weighted by its blockstakes at the end of the vote.
+Voting again on the same proposal replaces the earlier vote.`,
+		Args: cobra.ExactArgs(1),
+		Run:  governanceCmd.voteCmd,
+	}
+	voteCmd.Flags().StringVar(&governanceCmd.voteCfg.proposal, "proposal", "",
+		"ID of the proposal to vote on")
+	voteCmd.Flags().StringVar(&governanceCmd.voteCfg.title, "title", "",
+		"title of the new proposal to vote on")
+	voteCmd.Flags().StringVar(&governanceCmd.voteCfg.reference, "reference", "",
+		"reference to the full description of the new proposal, such as a URL")
+	voteCmd.Flags().Uint64Var(&governanceCmd.voteCfg.endHeight, "end-height", 0,
+		"height of the last block which can contain a vote on the new proposal")
+	voteCmd.Flags().StringSliceVar(&governanceCmd.voteCfg.addresses, "address", nil,
+		"address of the wallet voting using its blockstakes, can be repeated, defaults to all addresses owning blockstakes")
+	rootCmd.AddCommand(proposalsCmd, proposalCmd, voteCmd)
+
+	cliClient.RootCmd.AddCommand(rootCmd)
+}
+
+type governanceCmd struct {
+	cli     *client.CommandLineClient
+	voteCfg struct {
+		proposal         string
+		title, reference string
+		endHeight        uint64
+		addresses        []string
+	}
+}
+
+// proposalsCmd lists the governance proposals voted on.
+func (governanceCmd *governanceCmd) proposalsCmd(*cobra.Command, []string) {
+	var resp goldchainapi.ConsensusProposalsGET
+	err := governanceCmd.cli.GetAPI("/consensus/proposals", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the proposals", err)
+	}
+	if len(resp.Proposals) == 0 {
+		fmt.Println("No proposals")
+		return
+	}
+	for _, info := range resp.Proposals {
+		fmt.Printf("%s %q, voted on from block #%d up to block #%d\n",
+			info.ID.String(), info.Proposal.Title, info.Height, info.Proposal.EndHeight)
+	}
+}
+
+// proposalCmd prints the tally of the votes on a governance proposal.
+func (governanceCmd *governanceCmd) proposalCmd(cmd *cobra.Command, args []string) {
+	var id crypto.Hash
+	err := id.LoadString(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid proposal ID", err)
+	}
+	var result goldchainapi.ConsensusProposalGET
+	err = governanceCmd.cli.GetAPI("/consensus/proposals/"+id.String(), &result)
+	if err != nil {
+		cli.DieWithError("failed to get the proposal", err)
+	}
+	fmt.Printf("Proposal:   %s\n", result.Proposal.Title)
+	if result.Proposal.Reference != "" {
+		fmt.Printf("Reference:  %s\n", result.Proposal.Reference)
+	}
+	switch {
+	case !result.Closed:
+		fmt.Printf("Status:     open up to block #%d, tallied at block #%d\n", result.Proposal.EndHeight, result.TallyHeight)
+	case result.Approved:
+		fmt.Printf("Status:     approved at block #%d\n", result.TallyHeight)
+	default:
+		fmt.Printf("Status:     rejected at block #%d\n", result.TallyHeight)
+	}
+	fmt.Printf("Yes:        %v BS\n", result.Yes)
+	fmt.Printf("No:         %v BS\n", result.No)
+	fmt.Printf("Abstain:    %v BS\n", result.Abstain)
+	fmt.Printf("Total:      %v BS\n", result.TotalStake)
+	for _, vote := range result.Votes {
+		fmt.Printf("%s votes %s with %v BS since block #%d\n", vote.Voter.String(), vote.Choice.String(), vote.Stake, vote.Height)
+	}
+}
+
+// voteCmd votes on a governance proposal using the blockstakes of the wallet.
+func (governanceCmd *governanceCmd) voteCmd(cmd *cobra.Command, args []string) {
+	cfg := governanceCmd.voteCfg
+	var body goldchainapi.WalletVotePOST
+	err := body.Choice.UnmarshalText([]byte(args[0]))
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid choice", err)
+	}
+	if cfg.proposal != "" {
+		if cfg.title != "" || cfg.reference != "" || cfg.endHeight != 0 {
+			cmd.UsageFunc()(cmd)
+			cli.Die("a proposal is referred to using either its ID or its definition, not both")
+		}
+		var result goldchainapi.ConsensusProposalGET
+		err = governanceCmd.cli.GetAPI("/consensus/proposals/"+cfg.proposal, &result)
+		if err != nil {
+			cli.DieWithError("failed to get the proposal", err)
+		}
+		body.Proposal = result.Proposal
+	} else {
+		body.Proposal = gctypes.Proposal{Title: cfg.title, Reference: cfg.reference, EndHeight: types.BlockHeight(cfg.endHeight)}
+		err = gctypes.ValidateProposal(body.Proposal)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid proposal", err)
+		}
+	}
+	for _, str := range cfg.addresses {
+		var uh types.UnlockHash
+		err := uh.LoadString(str)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid address", err)
+		}
+		body.Addresses = append(body.Addresses, uh)
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.WalletVotePOSTResp
+	err = governanceCmd.cli.PostResp("/wallet/vote", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to submit the vote", err)
+	}
+	fmt.Printf("Voted %s on proposal %s in transaction %s\n", body.Choice.String(), resp.ProposalID.String(), resp.TransactionID.String())
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createLabelsCmds registers the wallet commands used to label addresses.
+func createLabelsCmds(cliClient *client.CommandLineClient) {
+	labelsCmd := &labelsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "labels",
+		Short: "List the labeled addresses of the wallet",
+		Long: `List the addresses of the wallet with their label and tags,
+which describe for example the customer or purpose each deposit address served.
+
+The --label and --tag flags select the addresses labeled or tagged as such,
+and apply to the balance and transactions commands as well.`,
+		Args: cobra.NoArgs,
+		Run:  labelsCmd.listCmd,
+	}
+	labelsCmd.addFilterFlags(rootCmd)
+	setCmd := &cobra.Command{
+		Use:   "set <address>",
+		Short: "Attach a label and tags to an address of the wallet",
+		Long: `Attach a label and tags to an address of the wallet,
+replacing its previous label and tags.`,
+		Args: cobra.ExactArgs(1),
+		Run:  labelsCmd.setCmd,
+	}
+	labelsCmd.addLabelFlags(setCmd)
+	rootCmd.AddCommand(setCmd)
+	newCmd := &cobra.Command{
+		Use:   "new",
+		Short: "Generate a new address with a label and tags",
+		Args:  cobra.NoArgs,
+		Run:   labelsCmd.newCmd,
+	}
+	labelsCmd.addLabelFlags(newCmd)
+	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "remove <address>",
+		Short: "Remove the label and tags of an address",
+		Args:  cobra.ExactArgs(1),
+		Run:   labelsCmd.removeCmd,
+	})
+	balanceCmd := &cobra.Command{
+		Use:   "balance",
+		Short: "Show the confirmed balance of the labeled addresses",
+		Args:  cobra.NoArgs,
+		Run:   labelsCmd.balanceCmd,
+	}
+	labelsCmd.addFilterFlags(balanceCmd)
+	rootCmd.AddCommand(balanceCmd)
+	transactionsCmd := &cobra.Command{
+		Use:   "transactions",
+		Short: "List the confirmed transactions of the labeled addresses",
+		Args:  cobra.NoArgs,
+		Run:   labelsCmd.transactionsCmd,
+	}
+	labelsCmd.addFilterFlags(transactionsCmd)
+	rootCmd.AddCommand(transactionsCmd)
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type labelsCmd struct {
+	cli       *client.CommandLineClient
+	filterCfg struct {
+		Label string
+		Tag   string
+	}
+	labelCfg struct {
+		Label string
+		Tags  []string
+	}
+}
+
+func (labelsCmd *labelsCmd) addFilterFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&labelsCmd.filterCfg.Label, "label", "", "only select the addresses with this label")
+	cmd.Flags().StringVar(&labelsCmd.filterCfg.Tag, "tag", "", "only select the addresses with this tag")
+}
+
+func (labelsCmd *labelsCmd) addLabelFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&labelsCmd.labelCfg.Label, "label", "", "label of the address, such as the customer it is handed out to")
+	cmd.Flags().StringSliceVar(&labelsCmd.labelCfg.Tags, "tag", nil, "tag of the address, can be given multiple times")
+}
+
+// filterQuery returns the query selecting the addresses defined by the filter flags.
+func (labelsCmd *labelsCmd) filterQuery() string {
+	values := url.Values{}
+	if labelsCmd.filterCfg.Label != "" {
+		values.Set("label", labelsCmd.filterCfg.Label)
+	}
+	if labelsCmd.filterCfg.Tag != "" {
+		values.Set("tag", labelsCmd.filterCfg.Tag)
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// listCmd lists the labeled addresses.
+func (labelsCmd *labelsCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAddressLabelsGET
+	err := labelsCmd.cli.GetAPI("/wallet/addresses/labels"+labelsCmd.filterQuery(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the labeled addresses", err)
+	}
+	if len(resp.Labels) == 0 {
+		fmt.Println("No labeled addresses.")
+		return
+	}
+	for _, label := range resp.Labels {
+		printAddressLabel(label)
+	}
+}
+
+// setCmd attaches a label and tags to an address.
+func (labelsCmd *labelsCmd) setCmd(cmd *cobra.Command, args []string) {
+	var address types.UnlockHash
+	err := address.LoadString(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid address", err)
+	}
+	labelsCmd.postLabel(goldchainapi.WalletAddressLabelsPOST{
+		Address: address,
+		Label:   labelsCmd.labelCfg.Label,
+		Tags:    labelsCmd.labelCfg.Tags,
+	})
+}
+
+// newCmd generates a new address with a label and tags.
+func (labelsCmd *labelsCmd) newCmd(cmd *cobra.Command, args []string) {
+	labelsCmd.postLabel(goldchainapi.WalletAddressLabelsPOST{
+		Label: labelsCmd.labelCfg.Label,
+		Tags:  labelsCmd.labelCfg.Tags,
+	})
+}
+
+func (labelsCmd *labelsCmd) postLabel(body goldchainapi.WalletAddressLabelsPOST) {
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the label", err)
+	}
+	var label wallet.AddressLabel
+	err = labelsCmd.cli.PostResp("/wallet/addresses/labels", string(b), &label)
+	if err != nil {
+		cli.DieWithError("failed to label the address", err)
+	}
+	printAddressLabel(label)
+}
+
+// removeCmd removes the label and tags of an address.
+func (labelsCmd *labelsCmd) removeCmd(cmd *cobra.Command, args []string) {
+	var address types.UnlockHash
+	err := address.LoadString(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid address", err)
+	}
+	b, err := json.Marshal(goldchainapi.WalletAddressLabelsRemovePOST{Address: address})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the address", err)
+	}
+	err = labelsCmd.cli.Post("/wallet/addresses/labels/remove", string(b))
+	if err != nil {
+		cli.DieWithError("failed to remove the label", err)
+	}
+	fmt.Println("Removed the label of " + address.String())
+}
+
+// balanceCmd shows the confirmed balance of the labeled addresses.
+func (labelsCmd *labelsCmd) balanceCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAddressLabelsBalanceGET
+	err := labelsCmd.cli.GetAPI("/wallet/addresses/labels/balance"+labelsCmd.filterQuery(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the balance of the labeled addresses", err)
+	}
+	currencyConvertor := labelsCmd.cli.CreateCurrencyConvertor()
+	fmt.Println("Addresses:   ", resp.Addresses)
+	fmt.Println("Spendable:   ", currencyConvertor.ToCoinStringWithUnit(resp.Spendable))
+	fmt.Println("Locked:      ", currencyConvertor.ToCoinStringWithUnit(resp.Locked))
+	fmt.Println("Block stakes:", resp.BlockStakes.String())
+}
+
+// transactionsCmd lists the confirmed transactions of the labeled addresses.
+func (labelsCmd *labelsCmd) transactionsCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAddressLabelsTransactionsGET
+	err := labelsCmd.cli.GetAPI("/wallet/addresses/labels/transactions"+labelsCmd.filterQuery(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the transactions of the labeled addresses", err)
+	}
+	if len(resp.Transactions) == 0 {
+		fmt.Println("No transactions.")
+		return
+	}
+	currencyConvertor := labelsCmd.cli.CreateCurrencyConvertor()
+	for _, txn := range resp.Transactions {
+		fmt.Printf("%s at height %d: received %s, spent %s\n", txn.ID.String(), txn.Height,
+			currencyConvertor.ToCoinStringWithUnit(txn.Received), currencyConvertor.ToCoinStringWithUnit(txn.Spent))
+	}
+}
+
+func printAddressLabel(label wallet.AddressLabel) {
+	line := label.Address.String()
+	if label.Label != "" {
+		line += "\t" + label.Label
+	}
+	if len(label.Tags) > 0 {
+		line += "\t[" + strings.Join(label.Tags, ", ") + "]"
+	}
+	fmt.Println(line)
+}
@@ -41,6 +41,29 @@ func main() {
 		types.TransactionVersionAuthConditionUpdateTx,
 		types.TransactionVersionAuthAddressUpdateTx,
 	)
+	createWalletCmds(cliClient.CommandLineClient)
+	createMultiSigCmds(cliClient.CommandLineClient)
+	createContactsCmds(cliClient.CommandLineClient)
+	createAccountsCmds(cliClient.CommandLineClient)
+	createLabelsCmds(cliClient.CommandLineClient)
+	createDepositsCmds(cliClient.CommandLineClient)
+	createSweepsCmds(cliClient.CommandLineClient)
+	createPayoutsCmds(cliClient.CommandLineClient)
+	createFeePoolCmds(cliClient.CommandLineClient)
+	createAuditCmds(cliClient.CommandLineClient)
+	createConditionCmds(cliClient.CommandLineClient)
+	createAuthCoinCmds(cliClient.CommandLineClient)
+	createBlockCreatorCmds(cliClient.CommandLineClient)
+	createGovernanceCmds(cliClient.CommandLineClient)
+	createCheckpointsCmds(cliClient.CommandLineClient)
+	createSeedCmds(cliClient.CommandLineClient)
+	createBackupCmds(cliClient.CommandLineClient)
+	createSeedPassphraseFlags(cliClient.CommandLineClient)
+	createAddressChecks(cliClient.CommandLineClient)
+	createConsensusCmds(cliClient.CommandLineClient)
+	createGatewayCmds(cliClient.CommandLineClient)
+	createSmokeTestCmd(cliClient.CommandLineClient)
+	createDryRunFlag(cliClient.CommandLineClient)
 
 	// define preRun function
 	cliClient.PreRunE = func(cfg *client.Config) (*client.Config, error) {
@@ -73,6 +96,16 @@ func main() {
 			return nil, fmt.Errorf("Network name %q not recognized", cfg.NetworkName)
 		}
 
+		// present coins as defined by the network descriptor,
+		// such that the CLI, faucet and explorer all agree
+		var err error
+		network, err = config.GetNetworkDescriptor(cfg.NetworkName)
+		if err != nil {
+			return nil, err
+		}
+		cfg.CurrencyUnits = network.CurrencyUnits()
+		cfg.CurrencyCoinUnit = network.CoinUnit
+
 		return cfg, nil
 	}
 
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/multisig"
+	gctypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// createMultiSigCmds registers the wallet commands used to create multisig addresses,
+// and to spend their outputs by collecting the signatures of their owners.
+func createMultiSigCmds(cliClient *client.CommandLineClient) {
+	multiSigCmd := &multiSigCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "multisig",
+		Short: "Create multisig addresses and collect signatures to spend their outputs",
+		Long: `Create m-of-n multisig addresses and spend the outputs they own.
+
+A spend transaction is created and signed by one owner, after which it is passed to the other owners,
+either as a file or using the coordination endpoints of a daemon (see the --coordinator flag),
+until enough owners signed it for the transaction to be broadcast.
+
+With the --coordinator flag defined, commands taking a <file> take a proposal ID instead.`,
+	}
+	rootCmd.PersistentFlags().StringVar(&multiSigCmd.coordinator, "coordinator", "",
+		"address of the daemon used to collect signatures, instead of passing transactions as files")
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "publickey",
+		Short: "Get a new public key of the wallet, to be shared with the other owners of a multisig address",
+		Args:  cobra.NoArgs,
+		Run:   multiSigCmd.publicKeyCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "address <minsigs> <publickey> <publickey>...",
+		Short: "Create a multisig address, requiring minsigs signatures of the owners of the given public keys",
+		Args:  cobra.MinimumNArgs(3),
+		Run:   multiSigCmd.addressCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the outputs of all multisig addresses co-owned by the wallet",
+		Args:  cobra.NoArgs,
+		Run:   multiSigCmd.listCmd,
+	})
+	spendCmd := &cobra.Command{
+		Use:   "spend <address> <dest>|<rawCondition>|<descriptor> <amount> [<dest>|<rawCondition>|<descriptor> <amount>]...",
+		Short: "Create a transaction spending the outputs of a multisig address, signed by this wallet",
+		Long: `Create a transaction sending coins from a multisig address co-owned by the wallet,
+refunding the remainder to the multisig address. The transaction is signed by this wallet,
+and written to the file given by the --out flag, or submitted to the coordinator as a new proposal.`,
+		Args: cobra.MinimumNArgs(3),
+		Run:  multiSigCmd.spendCmd,
+	}
+	spendCmd.Flags().StringVar(&multiSigCmd.spendCfg.MinerFee, "fee", "",
+		"miner fee paid by the transaction, defaults to the minimum transaction fee")
+	spendCmd.Flags().StringVar(&multiSigCmd.spendCfg.Out, "out", "",
+		"file the signed transaction is written to, required unless a coordinator is defined")
+	cli.ArbitraryDataFlagVar(spendCmd.Flags(), &multiSigCmd.spendCfg.Data,
+		"data", "optional arbitrary data (or description) to attach to the transaction")
+	rootCmd.AddCommand(spendCmd)
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "sign <file>",
+		Short: "Add the signatures of this wallet to a multisig transaction",
+		Args:  cobra.ExactArgs(1),
+		Run:   multiSigCmd.signCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "merge <file> <file>...",
+		Short: "Merge the signatures of multiple copies of a multisig transaction into the first file",
+		Args:  cobra.MinimumNArgs(2),
+		Run:   multiSigCmd.mergeCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "status [<file>]",
+		Short: "Show the signatures collected for a multisig transaction",
+		Long: `Show the signatures collected for all inputs of a multisig transaction.
+Without arguments all proposals of the coordinator are listed.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  multiSigCmd.statusCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "broadcast <file>",
+		Short: "Submit a multisig transaction to the transaction pool, once enough signatures are collected",
+		Args:  cobra.ExactArgs(1),
+		Run:   multiSigCmd.broadcastCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type multiSigCmd struct {
+	cli         *client.CommandLineClient
+	coordinator string
+	spendCfg    struct {
+		MinerFee string
+		Out      string
+		Data     []byte
+	}
+}
+
+// publicKeyCmd prints a new public key of the wallet.
+func (multiSigCmd *multiSigCmd) publicKeyCmd(cmd *cobra.Command, args []string) {
+	var resp api.WalletPublicKeyGET
+	err := multiSigCmd.cli.GetAPI("/wallet/publickey", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get a new public key", err)
+	}
+	fmt.Println(resp.PublicKey.String())
+}
+
+// addressCmd prints the multisig address and condition for the given public keys.
+func (multiSigCmd *multiSigCmd) addressCmd(cmd *cobra.Command, args []string) {
+	minSigs, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid minimum signature count", err)
+	}
+	uhs := make(types.UnlockHashSlice, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		var pk types.PublicKey
+		err = pk.LoadString(arg)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid public key "+arg, err)
+		}
+		uhs = append(uhs, types.NewPubKeyUnlockHash(pk))
+	}
+	if minSigs == 0 || minSigs > uint64(len(uhs)) {
+		cmd.UsageFunc()(cmd)
+		cli.Die(fmt.Sprintf("the minimum signature count has to be between 1 and %d", len(uhs)))
+	}
+	condition := types.NewCondition(types.NewMultiSignatureCondition(uhs, minSigs))
+	descriptor, err := gctypes.ConditionDescriptor(condition)
+	if err != nil {
+		cli.DieWithError("failed to describe multisig condition", err)
+	}
+	b, err := json.Marshal(condition)
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode multisig condition", err)
+	}
+	fmt.Println("address:", condition.UnlockHash().String())
+	fmt.Println("descriptor:", descriptor)
+	fmt.Println("condition:", string(b))
+}
+
+// listCmd lists the outputs of all multisig addresses co-owned by the wallet.
+func (multiSigCmd *multiSigCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletMultiSigGET
+	err := multiSigCmd.cli.GetAPI("/wallet/multisig", &resp)
+	if err != nil {
+		cli.DieWithError("failed to list multisig addresses", err)
+	}
+	if len(resp.Addresses) == 0 {
+		fmt.Println("The wallet does not co-own any multisig address.")
+		return
+	}
+	currencyConvertor := multiSigCmd.cli.CreateCurrencyConvertor()
+	for _, address := range resp.Addresses {
+		fmt.Printf("%s (%d-of-%d), spendable %s\n", address.Address.String(), address.MinSigs,
+			len(address.Owners), currencyConvertor.ToCoinStringWithUnit(address.Spendable))
+		for _, owner := range address.Owners {
+			fmt.Println("  owner:", owner.String())
+		}
+		for _, output := range address.CoinOutputs {
+			var status string
+			if output.Locked {
+				status = " (locked)"
+			} else if output.Pending {
+				status = " (spent by unconfirmed transaction)"
+			}
+			fmt.Printf("  coin output %s: %s%s\n", output.ID.String(),
+				currencyConvertor.ToCoinStringWithUnit(output.Value), status)
+		}
+		for _, output := range address.BlockStakeOutputs {
+			var status string
+			if output.Locked {
+				status = " (locked)"
+			}
+			fmt.Printf("  blockstake output %s: %s BS%s\n", output.ID.String(), output.Value.String(), status)
+		}
+	}
+}
+
+// spendCmd creates a transaction spending the outputs of a multisig address, signed by the wallet.
+func (multiSigCmd *multiSigCmd) spendCmd(cmd *cobra.Command, args []string) {
+	cfg := multiSigCmd.spendCfg
+	if cfg.Out == "" && multiSigCmd.coordinator == "" {
+		cmd.UsageFunc()(cmd)
+		cli.Die("either --out or --coordinator has to be defined")
+	}
+	currencyConvertor := multiSigCmd.cli.CreateCurrencyConvertor()
+	body := goldchainapi.WalletMultiSigTransactionPOST{Data: cfg.Data}
+	err := body.Address.LoadString(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid multisig address", err)
+	}
+	body.CoinOutputs, err = parseCoinOutputs(args[1:], currencyConvertor)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.Die(err)
+	}
+	if cfg.MinerFee != "" {
+		body.MinerFee, err = currencyConvertor.ParseCoinString(cfg.MinerFee)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid miner fee", err)
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.WalletMultiSigTransactionPOSTResp
+	err = multiSigCmd.cli.PostResp("/wallet/multisig/transaction", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to create multisig transaction", err)
+	}
+	if multiSigCmd.coordinator != "" {
+		proposal := multiSigCmd.submitProposal(resp.Transaction)
+		fmt.Println("Submitted multisig transaction as proposal " + proposal.ID.String())
+		printStatus(proposal.Status)
+		return
+	}
+	writeTransactionFile(cfg.Out, resp.Transaction)
+	fmt.Println("Written multisig transaction to " + cfg.Out)
+	printStatus(resp.Status)
+}
+
+// signCmd adds the signatures of the wallet to a multisig transaction.
+func (multiSigCmd *multiSigCmd) signCmd(cmd *cobra.Command, args []string) {
+	txn := multiSigCmd.loadTransaction(args[0])
+	var signed types.Transaction
+	b, err := json.Marshal(txn)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the transaction", err)
+	}
+	err = multiSigCmd.cli.PostResp("/wallet/sign", string(b), &signed)
+	if err != nil {
+		cli.DieWithError("failed to sign transaction", err)
+	}
+	if multiSigCmd.coordinator != "" {
+		proposal := multiSigCmd.submitProposal(signed)
+		fmt.Printf("Added %d signature(s) to proposal %s\n", proposal.Added, proposal.ID.String())
+		printStatus(proposal.Status)
+		return
+	}
+	// the wallet signs all inputs it can, merging drops the duplicate signatures
+	added, err := multisig.MergeSignatures(&txn, signed, consensusOutputGetter{client: multiSigCmd.cli.HTTPClient})
+	if err != nil {
+		cli.DieWithError("failed to merge signatures", err)
+	}
+	writeTransactionFile(args[0], txn)
+	fmt.Printf("Added %d signature(s) to %s\n", added, args[0])
+	multiSigCmd.printTransactionStatus(txn)
+}
+
+// mergeCmd merges the signatures of multiple copies of a multisig transaction into the first file.
+func (multiSigCmd *multiSigCmd) mergeCmd(cmd *cobra.Command, args []string) {
+	if multiSigCmd.coordinator != "" {
+		cli.Die("signatures are merged by the coordinator, use the sign command instead")
+	}
+	getter := consensusOutputGetter{client: multiSigCmd.cli.HTTPClient}
+	txn := readTransactionFile(args[0])
+	var added int
+	for _, path := range args[1:] {
+		n, err := multisig.MergeSignatures(&txn, readTransactionFile(path), getter)
+		if err != nil {
+			cli.DieWithError("failed to merge signatures of "+path, err)
+		}
+		added += n
+	}
+	writeTransactionFile(args[0], txn)
+	fmt.Printf("Added %d signature(s) to %s\n", added, args[0])
+	multiSigCmd.printTransactionStatus(txn)
+}
+
+// statusCmd shows the signatures collected for a multisig transaction,
+// or lists all proposals of the coordinator.
+func (multiSigCmd *multiSigCmd) statusCmd(cmd *cobra.Command, args []string) {
+	if len(args) == 1 {
+		if multiSigCmd.coordinator != "" {
+			printStatus(multiSigCmd.getProposal(args[0]).Status)
+			return
+		}
+		multiSigCmd.printTransactionStatus(readTransactionFile(args[0]))
+		return
+	}
+	if multiSigCmd.coordinator == "" {
+		cmd.UsageFunc()(cmd)
+		cli.Die("a file has to be given unless a coordinator is defined")
+	}
+	var resp goldchainapi.MultiSigProposalsGET
+	err := multiSigCmd.coordinatorClient().GetAPI("/multisig/proposals", &resp)
+	if err != nil {
+		cli.DieWithError("failed to list proposals", err)
+	}
+	if len(resp.Proposals) == 0 {
+		fmt.Println("No proposals.")
+		return
+	}
+	for _, proposal := range resp.Proposals {
+		fmt.Println("proposal " + proposal.ID.String())
+		printStatus(proposal.Status)
+	}
+}
+
+// broadcastCmd submits a multisig transaction to the transaction pool, once enough signatures are collected.
+func (multiSigCmd *multiSigCmd) broadcastCmd(cmd *cobra.Command, args []string) {
+	if multiSigCmd.coordinator != "" && !dryRun {
+		var resp goldchainapi.MultiSigProposalBroadcastPOSTResp
+		err := multiSigCmd.coordinatorClient().PostResp("/multisig/proposals/"+args[0]+"/broadcast", "", &resp)
+		if err != nil {
+			cli.DieWithError("failed to broadcast proposal", err)
+		}
+		fmt.Println("Broadcast multisig transaction " + resp.TransactionID.String())
+		return
+	}
+	txn := multiSigCmd.loadTransaction(args[0])
+	status, err := multisig.GetStatus(txn, consensusOutputGetter{client: multiSigCmd.cli.HTTPClient})
+	if err != nil {
+		cli.DieWithError("failed to get the signature status", err)
+	}
+	if !status.Complete {
+		printStatus(status)
+		cli.Die("not all inputs have the required amount of signatures")
+	}
+	if dryRun {
+		dryRunTransaction(multiSigCmd.cli, txn)
+		return
+	}
+	b, err := json.Marshal(txn)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the transaction", err)
+	}
+	var resp api.TransactionPoolPOST
+	err = multiSigCmd.cli.PostResp("/transactionpool/transactions", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to broadcast transaction", err)
+	}
+	fmt.Println("Broadcast multisig transaction " + resp.TransactionID.String())
+}
+
+// loadTransaction loads the transaction from the given file, or the given proposal of the coordinator.
+func (multiSigCmd *multiSigCmd) loadTransaction(arg string) types.Transaction {
+	if multiSigCmd.coordinator != "" {
+		return multiSigCmd.getProposal(arg).Transaction
+	}
+	return readTransactionFile(arg)
+}
+
+// getProposal gets the proposal with the given ID from the coordinator.
+func (multiSigCmd *multiSigCmd) getProposal(id string) goldchainapi.MultiSigProposal {
+	var proposal goldchainapi.MultiSigProposal
+	err := multiSigCmd.coordinatorClient().GetAPI("/multisig/proposals/"+id, &proposal)
+	if err != nil {
+		cli.DieWithError("failed to get proposal "+id, err)
+	}
+	return proposal
+}
+
+// submitProposal submits the given transaction to the coordinator.
+func (multiSigCmd *multiSigCmd) submitProposal(txn types.Transaction) goldchainapi.MultiSigProposalsPOSTResp {
+	b, err := json.Marshal(goldchainapi.MultiSigProposalsPOST{Transaction: txn})
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.MultiSigProposalsPOSTResp
+	err = multiSigCmd.coordinatorClient().PostResp("/multisig/proposals", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to submit transaction to the coordinator", err)
+	}
+	return resp
+}
+
+// coordinatorClient creates a client for the coordinator,
+// defaulting to http for local addresses and https otherwise.
+func (multiSigCmd *multiSigCmd) coordinatorClient() *api.HTTPClient {
+	address := multiSigCmd.coordinator
+	if !strings.Contains(address, "://") {
+		if strings.HasPrefix(address, "localhost") || strings.HasPrefix(address, "127.0.0.1") {
+			address = "http://" + address
+		} else {
+			address = "https://" + address
+		}
+	}
+	return &api.HTTPClient{
+		RootURL:   strings.TrimSuffix(address, "/"),
+		UserAgent: multiSigCmd.cli.UserAgent,
+	}
+}
+
+// printTransactionStatus prints the signatures collected for the given transaction.
+func (multiSigCmd *multiSigCmd) printTransactionStatus(txn types.Transaction) {
+	status, err := multisig.GetStatus(txn, consensusOutputGetter{client: multiSigCmd.cli.HTTPClient})
+	if err != nil {
+		cli.DieWithError("failed to get the signature status", err)
+	}
+	printStatus(status)
+}
+
+func printStatus(status multisig.Status) {
+	printInputs := func(kind string, inputs []multisig.InputStatus) {
+		for _, input := range inputs {
+			fmt.Printf("  %s input %s: %d of %d signature(s)\n", kind, input.ParentID.String(), len(input.Signers), input.Required)
+			for _, signer := range input.Signers {
+				fmt.Println("    signed by", signer.String())
+			}
+		}
+	}
+	printInputs("coin", status.CoinInputs)
+	printInputs("blockstake", status.BlockStakeInputs)
+	if status.Complete {
+		fmt.Println("All inputs have the required amount of signatures, the transaction can be broadcast.")
+	} else {
+		fmt.Println("Not all inputs have the required amount of signatures yet.")
+	}
+}
+
+func readTransactionFile(path string) types.Transaction {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		cli.DieWithError("failed to read transaction file", err)
+	}
+	var txn types.Transaction
+	err = json.Unmarshal(b, &txn)
+	if err != nil {
+		cli.DieWithError("failed to decode transaction file "+path, err)
+	}
+	return txn
+}
+
+func writeTransactionFile(path string, txn types.Transaction) {
+	b, err := json.MarshalIndent(txn, "", "  ")
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the transaction", err)
+	}
+	err = ioutil.WriteFile(path, append(b, '\n'), 0644)
+	if err != nil {
+		cli.DieWithError("failed to write transaction file", err)
+	}
+}
+
+// consensusOutputGetter gets unspent outputs from the consensus set of the daemon,
+// implementing multisig.OutputGetter.
+type consensusOutputGetter struct {
+	client *api.HTTPClient
+}
+
+func (getter consensusOutputGetter) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	var resp api.ConsensusGetUnspentCoinOutput
+	err := getter.client.GetAPI("/consensus/unspent/coinoutputs/"+id.String(), &resp)
+	return resp.Output, err
+}
+
+func (getter consensusOutputGetter) GetBlockStakeOutput(id types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	var resp api.ConsensusGetUnspentBlockstakeOutput
+	err := getter.client.GetAPI("/consensus/unspent/blockstakeoutputs/"+id.String(), &resp)
+	return resp.Output, err
+}
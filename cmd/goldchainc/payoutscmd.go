@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createPayoutsCmds registers the wallet commands used to manage and execute payout templates.
+func createPayoutsCmds(cliClient *client.CommandLineClient) {
+	payoutsCmd := &payoutsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "payouts",
+		Short: "List the payout templates of the wallet",
+		Long: `List the payout templates of the wallet.
+
+A payout template is a named list of recipients, each receiving either a fixed amount of coins,
+or a percentage of the payout balance: the balance of the source of the template (or of the wallet)
+left once the fixed amounts and the miner fee are paid. A template is executed on request,
+or by the daemon according to its schedule, sending all recipients their coins in a single transaction.`,
+		Args: cobra.NoArgs,
+		Run:  payoutsCmd.listCmd,
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "add <name> <definition.json>",
+		Short: "Add a payout template",
+		Long: `Add a payout template, defined by a JSON file such as:
+
+{
+  "source": "01...",
+  "recipients": [
+    {"label": "operations", "address": "01...", "amount": "1000000000"},
+    {"address": "01...", "percentage": 60},
+    {"address": "01...", "percentage": 40}
+  ],
+  "schedule": "0 12 * * 1"
+}
+
+The source, labels and schedule are optional. Amounts are expressed in the smallest unit.
+The schedule is a cron expression (minute hour day month weekday), evaluated in UTC,
+or one of @hourly, @daily, @weekly, @monthly and @yearly.`,
+		Args: cobra.ExactArgs(2),
+		Run:  payoutsCmd.addCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "update <name> <definition.json>",
+		Short: "Replace the definition of a payout template",
+		Args:  cobra.ExactArgs(2),
+		Run:   payoutsCmd.updateCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a payout template",
+		Args:  cobra.ExactArgs(1),
+		Run:   payoutsCmd.removeCmd,
+	})
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "execute <name>",
+		Short: "Send the payout of a template",
+		Long: `Send the payout of a template in a single transaction.
+Use the global --dry-run flag to print the transaction without broadcasting it.`,
+		Args: cobra.ExactArgs(1),
+		Run:  payoutsCmd.executeCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type payoutsCmd struct {
+	cli *client.CommandLineClient
+}
+
+// listCmd lists all payout templates.
+func (payoutsCmd *payoutsCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletPayoutsGET
+	err := payoutsCmd.cli.GetAPI("/wallet/payouts", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the payout templates", err)
+	}
+	if len(resp.Templates) == 0 {
+		fmt.Println("No payout templates.")
+		return
+	}
+	currencyConvertor := payoutsCmd.cli.CreateCurrencyConvertor()
+	for _, template := range resp.Templates {
+		fmt.Println(template.Name + ":")
+		if template.Source != nil {
+			fmt.Println("  source:   " + template.Source.String())
+		}
+		for _, recipient := range template.Recipients {
+			address := recipient.Address.String()
+			if recipient.Label != "" {
+				address += " (" + recipient.Label + ")"
+			}
+			if recipient.Amount.IsZero() {
+				fmt.Printf("  %s\t%g%%\n", address, recipient.Percentage)
+			} else {
+				fmt.Printf("  %s\t%s\n", address, currencyConvertor.ToCoinStringWithUnit(recipient.Amount))
+			}
+		}
+		if template.Schedule != "" {
+			fmt.Printf("  schedule: %s (next run: %s)\n", template.Schedule, template.NextRun().Format(time.RFC3339))
+		}
+		if run := template.LastRun; run != nil {
+			outcome := run.Error
+			switch {
+			case run.TransactionID != nil:
+				outcome = "sent in transaction " + run.TransactionID.String()
+			case run.PendingSpend != "":
+				outcome = "awaiting approval as pending spend " + run.PendingSpend
+			}
+			fmt.Printf("  last run: %s, %s\n", time.Unix(int64(run.Time), 0).UTC().Format(time.RFC3339), outcome)
+		}
+	}
+}
+
+// addCmd adds a payout template.
+func (payoutsCmd *payoutsCmd) addCmd(cmd *cobra.Command, args []string) {
+	b, err := json.Marshal(goldchainapi.WalletPayoutsPOST{
+		Name:             args[0],
+		PayoutDefinition: readPayoutDefinition(args[1]),
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the payout template", err)
+	}
+	var template wallet.PayoutTemplate
+	err = payoutsCmd.cli.PostResp("/wallet/payouts", string(b), &template)
+	if err != nil {
+		cli.DieWithError("failed to add the payout template", err)
+	}
+	fmt.Printf("Added payout template %s with %d recipients\n", template.Name, len(template.Recipients))
+}
+
+// updateCmd replaces the definition of a payout template.
+func (payoutsCmd *payoutsCmd) updateCmd(cmd *cobra.Command, args []string) {
+	b, err := json.Marshal(goldchainapi.WalletPayoutPOST{
+		PayoutDefinition: readPayoutDefinition(args[1]),
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the payout template", err)
+	}
+	var template wallet.PayoutTemplate
+	err = payoutsCmd.cli.PostResp("/wallet/payouts/"+url.PathEscape(args[0]), string(b), &template)
+	if err != nil {
+		cli.DieWithError("failed to update the payout template", err)
+	}
+	fmt.Printf("Updated payout template %s with %d recipients\n", template.Name, len(template.Recipients))
+}
+
+// removeCmd removes a payout template.
+func (payoutsCmd *payoutsCmd) removeCmd(cmd *cobra.Command, args []string) {
+	err := payoutsCmd.cli.Post("/wallet/payouts/"+url.PathEscape(args[0])+"/remove", "")
+	if err != nil {
+		cli.DieWithError("failed to remove the payout template", err)
+	}
+	fmt.Println("Removed payout template " + args[0])
+}
+
+// executeCmd sends the payout of a template, or dry runs it in dry-run mode.
+func (payoutsCmd *payoutsCmd) executeCmd(cmd *cobra.Command, args []string) {
+	call := "/wallet/payouts/" + url.PathEscape(args[0]) + "/execute"
+	if dryRun {
+		walletCmd := &walletCmd{cli: payoutsCmd.cli}
+		walletCmd.dryRunSend(call, struct{}{}, url.Values{})
+		return
+	}
+	var resp struct {
+		goldchainapi.WalletPayoutExecutePOSTResp
+		// Pending is defined should the payout exceed the approval threshold of the spend policy
+		Pending *wallet.PendingSpend `json:"pending"`
+	}
+	err := payoutsCmd.cli.PostResp(call, "", &resp)
+	if err != nil {
+		cli.DieWithError("failed to execute the payout template", err)
+	}
+	if resp.Pending != nil {
+		fmt.Println("Payout awaits approval as pending spend " + resp.Pending.ID)
+		return
+	}
+	currencyConvertor := payoutsCmd.cli.CreateCurrencyConvertor()
+	for _, co := range resp.Payout.CoinOutputs {
+		fmt.Printf("Sent %s to %s\n", currencyConvertor.ToCoinStringWithUnit(co.Value), co.Condition.UnlockHash().String())
+	}
+	fmt.Println("Transaction ID: " + resp.TransactionID.String())
+}
+
+// readPayoutDefinition reads the definition of a payout template from the given JSON file.
+func readPayoutDefinition(filename string) wallet.PayoutDefinition {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		cli.DieWithError("failed to read the payout template definition", err)
+	}
+	var def wallet.PayoutDefinition
+	err = json.Unmarshal(b, &def)
+	if err != nil {
+		cli.DieWithError("failed to decode the payout template definition", err)
+	}
+	return def
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+// createSeedCmds registers the offline commands used to inspect and convert seeds.
+func createSeedCmds(cliClient *client.CommandLineClient) {
+	seedCmd := &seedCmd{}
+
+	rootCmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Convert seeds between the Rivine and BIP39 formats",
+	}
+	convertCmd := &cobra.Command{
+		Use:   "convert [<seed>]",
+		Short: "Convert a seed into the formats used by Rivine wallets",
+		Long: `Convert a hex-encoded seed (or BIP39 entropy), or a BIP39 mnemonic in any of the
+supported languages, into the hex-encoded seed and English mnemonic used by Rivine wallets.
+The seed is read from the standard input should it not be given as an argument.
+
+Rivine wallets only use BIP39 to encode their seed as a mnemonic:
+their addresses are derived from the seed directly, and thus differ from those
+derived from the same mnemonic by other (BIP32/BIP44) wallets.
+Use the --addresses flag to compare the derived addresses before sending any funds,
+the first address handed out by a new wallet is the one at index 25.
+
+This command works offline, the seed is never sent to the daemon.
+Supported languages: english, spanish, french, italian, japanese, korean,
+chinese_simplified and chinese_traditional.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  seedCmd.convertCmd,
+	}
+	convertCmd.Flags().StringVar(&seedCmd.convertCfg.language, "language", "",
+		"language of the given mnemonic, detected by default")
+	convertCmd.Flags().StringVar(&seedCmd.convertCfg.to, "to", string(seed.LanguageEnglish),
+		"language of the mnemonic to convert the seed to")
+	convertCmd.Flags().Uint64Var(&seedCmd.convertCfg.addresses, "addresses", 0,
+		"amount of addresses to derive from the seed, starting at index 0")
+	rootCmd.AddCommand(convertCmd)
+
+	cliClient.RootCmd.AddCommand(rootCmd)
+}
+
+type seedCmd struct {
+	convertCfg struct {
+		language  string
+		to        string
+		addresses uint64
+	}
+}
+
+// convertCmd converts a seed into the formats used by Rivine wallets.
+func (seedCmd *seedCmd) convertCmd(cmd *cobra.Command, args []string) {
+	cfg := seedCmd.convertCfg
+	var (
+		from seed.Language
+		err  error
+	)
+	if cfg.language != "" {
+		from, err = seed.ParseLanguage(cfg.language)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid language", err)
+		}
+	}
+	to, err := seed.ParseLanguage(cfg.to)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid language", err)
+	}
+
+	var str string
+	if len(args) == 1 {
+		str = args[0]
+	} else {
+		str, err = speakeasy.Ask("Seed or mnemonic: ")
+		if err != nil {
+			cli.DieWithError("failed to read seed", err)
+		}
+	}
+	c, err := seed.Convert(str, from)
+	if err != nil {
+		cli.DieWithError("failed to convert seed", err)
+	}
+	mnemonic, err := c.Mnemonic(seed.LanguageEnglish)
+	if err != nil {
+		cli.DieWithError("failed to encode seed as mnemonic", err)
+	}
+
+	if c.Language != "" {
+		fmt.Printf("Detected %s mnemonic of %d bits of entropy.\n", c.Language, len(c.Entropy)*8)
+	}
+	fmt.Println("Rivine seed:    ", c.Seed.String())
+	fmt.Println("Rivine mnemonic:", mnemonic)
+	if to != seed.LanguageEnglish {
+		converted, err := c.Mnemonic(to)
+		if err != nil {
+			cli.DieWithError("failed to encode seed as mnemonic", err)
+		}
+		fmt.Printf("BIP39 mnemonic (%s): %s\n", to, converted)
+	}
+	for index := uint64(0); index < cfg.addresses; index++ {
+		fmt.Printf("  #%d %s\n", index, seed.Address(c.Seed, index).String())
+	}
+	for _, warning := range c.Warnings {
+		fmt.Fprintln(os.Stderr, "WARNING:", warning)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+)
+
+// errEmptySeedPassphraseWallet is reported when a seed passphrase derives a seed of which no address was ever used.
+var errEmptySeedPassphraseWallet = errors.New("none of the addresses derived from the mnemonic and seed passphrase received any coins or block stakes: " +
+	"make sure the seed passphrase is correct, as every passphrase derives a different (and valid) wallet")
+
+// createSeedPassphraseFlags adds the --seed-passphrase flag to the rivine commands
+// used to recover a wallet and to load a seed, such that a BIP39 passphrase (the 25th word) can be used.
+func createSeedPassphraseFlags(cliClient *client.CommandLineClient) {
+	seedPassphraseCmd := &seedPassphraseCmd{cli: cliClient}
+	for _, walletCmd := range cliClient.WalletCmd.Commands() {
+		switch walletCmd.Name() {
+		case "recover":
+			seedPassphraseCmd.extend(walletCmd, seedPassphraseCmd.recoverCmd)
+		case "load":
+			for _, loadCmd := range walletCmd.Commands() {
+				if loadCmd.Name() == "seed" {
+					seedPassphraseCmd.extend(loadCmd, seedPassphraseCmd.loadSeedCmd)
+				}
+			}
+		}
+	}
+}
+
+type seedPassphraseCmd struct {
+	cli *client.CommandLineClient
+}
+
+// extend adds the --seed-passphrase flag to the given command,
+// running fn instead of the original command should the flag be set.
+func (seedPassphraseCmd *seedPassphraseCmd) extend(cmd *cobra.Command, fn func(*cobra.Command, []string)) {
+	var enabled bool
+	cmd.Flags().BoolVar(&enabled, "seed-passphrase", false,
+		"ask for the BIP39 passphrase (25th word) protecting the seed, every passphrase derives a different wallet")
+	run := cmd.Run
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		if enabled {
+			fn(cmd, args)
+			return
+		}
+		run(cmd, args)
+	}
+}
+
+// recoverCmd recovers a wallet using the seed derived from the given mnemonic and seed passphrase.
+func (seedPassphraseCmd *seedPassphraseCmd) recoverCmd(cmd *cobra.Command, args []string) {
+	plain, _ := cmd.Flags().GetBool("plain")
+	values := url.Values{}
+	if !plain {
+		values.Set("passphrase", askNewPassphrase("Wallet passphrase: "))
+	}
+	mnemonic, _ := cmd.Flags().GetString("seed")
+	if mnemonic == "" {
+		var err error
+		mnemonic, err = speakeasy.Ask("Enter existing mnemonic to be used as primary seed: ")
+		if err != nil {
+			cli.DieWithError("failed to read the mnemonic", err)
+		}
+	}
+	seed, err := modules.InitialSeedFromMnemonic(mnemonic)
+	if err != nil {
+		cli.DieWithError("invalid mnemonic given", err)
+	}
+	values.Set("seed", seed.String())
+	values.Set("seedpassphrase", askNewPassphrase("Seed passphrase (25th word): "))
+
+	var resp goldchainapi.WalletSeedPassphrasePOSTResp
+	err = seedPassphraseCmd.cli.PostResp("/wallet/init", values.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("failed to create the wallet", err)
+	}
+	if resp.PrimarySeed == mnemonic {
+		cli.Die("the wallet is created without seed passphrase, as the daemon doesn't support seed passphrases")
+	}
+	fmt.Printf("Mnemonic of primary seed, derived from the given mnemonic and seed passphrase:\n%s\n\n", resp.PrimarySeed)
+	fmt.Println("The wallet can also be recovered using this mnemonic, without seed passphrase.")
+	if !plain {
+		fmt.Println("Wallet encrypted with given passphrase")
+	}
+	if resp.UsedAddresses == 0 {
+		cli.Die("the wallet is created, but it is empty:", errEmptySeedPassphraseWallet)
+	}
+	fmt.Printf("Recovered a wallet of which %d address(es) are used\n", resp.UsedAddresses)
+}
+
+// loadSeedCmd loads the seed derived from the given mnemonic and seed passphrase into the wallet.
+func (seedPassphraseCmd *seedPassphraseCmd) loadSeedCmd(cmd *cobra.Command, args []string) {
+	plain, _ := cmd.Flags().GetBool("plain")
+	values := url.Values{}
+	if !plain {
+		passphrase, err := speakeasy.Ask("Wallet passphrase: ")
+		if err != nil {
+			cli.DieWithError("failed to read the wallet passphrase", err)
+		}
+		values.Set("passphrase", passphrase)
+	}
+	mnemonic, _ := cmd.Flags().GetString("seed")
+	if mnemonic == "" {
+		var err error
+		mnemonic, err = speakeasy.Ask("Existing Mnemonic: ")
+		if err != nil {
+			cli.DieWithError("failed to read the mnemonic", err)
+		}
+	}
+	values.Set("mnemonic", mnemonic)
+	values.Set("seedpassphrase", askNewPassphrase("Seed passphrase (25th word): "))
+
+	var resp goldchainapi.WalletSeedPassphrasePOSTResp
+	err := seedPassphraseCmd.cli.PostResp("/wallet/seed", values.Encode(), &resp)
+	if err != nil {
+		cli.DieWithError("could not add seed", err)
+	}
+	if resp.UsedAddresses == 0 {
+		cli.Die("the seed is added, but it is empty:", errEmptySeedPassphraseWallet)
+	}
+	fmt.Printf("Added Key, of which %d address(es) are used\n", resp.UsedAddresses)
+	fmt.Println("Restart the daemon in order for the wallet to find the coins received before the seed was added.")
+}
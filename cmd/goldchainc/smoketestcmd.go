@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/crypto"
+	authcointxcli "github.com/threefoldtech/rivine/extensions/authcointx/client"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+)
+
+const (
+	// smokeTestPollInterval is the interval at which the smoke test
+	// checks whether an authorization or transaction has been confirmed.
+	smokeTestPollInterval = 5 * time.Second
+	// smokeTestFaucetTimeout is the timeout of a single call to the faucet.
+	smokeTestFaucetTimeout = time.Minute
+)
+
+// createSmokeTestCmd registers the smoketest command,
+// used by operators to check a (freshly deployed) network end-to-end.
+func createSmokeTestCmd(cliClient *client.CommandLineClient) {
+	smokeTestCmd := &smokeTestCmd{
+		cli:     cliClient,
+		network: config.NetworkNameTest,
+		timeout: 15 * time.Minute,
+	}
+	cmd := &cobra.Command{
+		Use:   "smoketest",
+		Short: "Run an end-to-end smoke test against a live network",
+		Long: `Run a scripted end-to-end check against the network of the daemon,
+reporting a pass/fail summary. The following checks are run in order,
+all remaining checks are skipped as soon as one of them fails:
+
+  - the daemon is reachable and part of the expected network;
+  - the daemon is synced;
+  - a throwaway address is authorized by the faucet,
+    as reported by the auth status of the daemon;
+  - the faucet drips coins to the throwaway address;
+  - the dripped coins are sent back (minus the minimum transaction fee),
+    by default to the address of the faucet.
+
+The throwaway address is deauthorized again once the smoke test is finished.
+The command exits with a non-zero status code if any check fails.`,
+		Args: cobra.NoArgs,
+		Run:  smokeTestCmd.run,
+	}
+	cmd.Flags().StringVar(&smokeTestCmd.network, "network", smokeTestCmd.network,
+		"name of the network the daemon is expected to be part of")
+	cmd.Flags().StringVar(&smokeTestCmd.faucetURL, "faucet", "",
+		"root URL of the faucet to use, defaults to the faucet of the network")
+	cmd.Flags().DurationVar(&smokeTestCmd.timeout, "timeout", smokeTestCmd.timeout,
+		"maximum duration of the entire smoke test")
+	cmd.Flags().StringVar(&smokeTestCmd.returnAddressStr, "return-address", "",
+		"address to send the dripped coins back to, defaults to the address of the faucet")
+
+	cliClient.RootCmd.AddCommand(cmd)
+}
+
+type smokeTestCmd struct {
+	cli *client.CommandLineClient
+
+	network          string
+	faucetURL        string
+	timeout          time.Duration
+	returnAddressStr string
+
+	// state shared between the checks
+	deadline      time.Time
+	faucet        *http.Client
+	constants     modules.DaemonConstants
+	sk            crypto.SecretKey
+	pk            types.PublicKey
+	address       types.UnlockHash
+	authorized    bool
+	pending       bool
+	returnAddress types.UnlockHash
+	dripOutputID  types.CoinOutputID
+	dripValue     types.Currency
+}
+
+// smokeTestCheck is a single check of the smoke test,
+// returning a short description of what was verified.
+type smokeTestCheck struct {
+	name string
+	run  func() (string, error)
+}
+
+// run runs all checks of the smoke test, and reports a pass/fail summary.
+func (smokeTestCmd *smokeTestCmd) run(cmd *cobra.Command, args []string) {
+	if dryRun {
+		cli.Die("a smoke test cannot be run in dry-run mode, as it requires transactions to be broadcasted")
+	}
+	if smokeTestCmd.faucetURL == "" {
+		network, err := config.GetNetworkDescriptor(smokeTestCmd.network)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid network", err)
+		}
+		if network.FaucetURL == "" {
+			cmd.UsageFunc()(cmd)
+			cli.Die("no faucet is known for network " + smokeTestCmd.network + ", define one using --faucet")
+		}
+		smokeTestCmd.faucetURL = network.FaucetURL
+	}
+	smokeTestCmd.faucetURL = strings.TrimRight(smokeTestCmd.faucetURL, "/")
+	if smokeTestCmd.returnAddressStr != "" {
+		err := smokeTestCmd.returnAddress.LoadString(smokeTestCmd.returnAddressStr)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid return address", err)
+		}
+	}
+
+	checks := []smokeTestCheck{
+		{name: "node reachable", run: smokeTestCmd.checkReachable},
+		{name: "node synced", run: smokeTestCmd.checkSynced},
+		{name: "address authorized", run: smokeTestCmd.checkAuthorization},
+		{name: "faucet drip", run: smokeTestCmd.checkDrip},
+		{name: "send back", run: smokeTestCmd.checkSendBack},
+	}
+	smokeTestCmd.deadline = time.Now().Add(smokeTestCmd.timeout)
+	smokeTestCmd.faucet = &http.Client{Timeout: smokeTestFaucetTimeout}
+
+	var passed int
+	var failed bool
+	for _, check := range checks {
+		if failed {
+			fmt.Printf("SKIP  %s\n", check.name)
+			continue
+		}
+		start := time.Now()
+		description, err := check.run()
+		if err != nil {
+			fmt.Printf("FAIL  %s: %v\n", check.name, err)
+			failed = true
+			continue
+		}
+		passed++
+		fmt.Printf("PASS  %s: %s (%s)\n", check.name, description, time.Since(start).Round(time.Second))
+	}
+
+	// a transaction involving the throwaway address might still be confirmed,
+	// which is why it is only deauthorized if no such transaction is pending
+	if smokeTestCmd.authorized && !smokeTestCmd.pending {
+		_, err := smokeTestCmd.postFaucet("/api/v1/deauthorize")
+		if err != nil {
+			fmt.Printf("WARN  failed to deauthorize throwaway address %s: %v\n", smokeTestCmd.address.String(), err)
+		}
+	} else if smokeTestCmd.authorized {
+		fmt.Printf("WARN  throwaway address %s remains authorized, as one of its transactions is still pending\n", smokeTestCmd.address.String())
+	}
+
+	fmt.Println()
+	if passed != len(checks) {
+		cli.Die(fmt.Sprintf("Smoke test failed: %d/%d checks passed", passed, len(checks)))
+	}
+	fmt.Printf("Smoke test passed: %d/%d checks passed\n", passed, len(checks))
+}
+
+// checkReachable checks that the daemon is reachable and part of the expected network.
+func (smokeTestCmd *smokeTestCmd) checkReachable() (string, error) {
+	err := smokeTestCmd.cli.GetAPI("/daemon/constants", &smokeTestCmd.constants)
+	if err != nil {
+		return "", err
+	}
+	chainInfo := smokeTestCmd.constants.ChainInfo
+	if chainInfo.NetworkName != smokeTestCmd.network {
+		return "", fmt.Errorf("daemon is part of network %s instead of %s", chainInfo.NetworkName, smokeTestCmd.network)
+	}
+	return fmt.Sprintf("%s daemon v%s at %s", chainInfo.NetworkName, chainInfo.ChainVersion.String(), smokeTestCmd.cli.RootURL), nil
+}
+
+// checkSynced checks that the daemon is synced.
+func (smokeTestCmd *smokeTestCmd) checkSynced() (string, error) {
+	var cg api.ConsensusGET
+	err := smokeTestCmd.cli.GetAPI("/consensus", &cg)
+	if err != nil {
+		return "", err
+	}
+	if !cg.Synced {
+		return "", fmt.Errorf("daemon is not synced, it is at height %d", cg.Height)
+	}
+	return fmt.Sprintf("at height %d", cg.Height), nil
+}
+
+// checkAuthorization generates a throwaway address, and checks that it gets authorized by the faucet.
+func (smokeTestCmd *smokeTestCmd) checkAuthorization() (string, error) {
+	sk, pk := crypto.GenerateKeyPair()
+	smokeTestCmd.sk = sk
+	smokeTestCmd.pk = types.Ed25519PublicKey(pk)
+	smokeTestCmd.address = types.NewPubKeyUnlockHash(smokeTestCmd.pk)
+
+	txID, err := smokeTestCmd.postFaucet("/api/v1/authorize")
+	if err != nil {
+		return "", err
+	}
+	smokeTestCmd.authorized, smokeTestCmd.pending = true, true
+	consensusClient := authcointxcli.NewPluginConsensusClient(smokeTestCmd.cli)
+	err = smokeTestCmd.waitFor("the authorization of "+smokeTestCmd.address.String(), func() (bool, error) {
+		states, err := consensusClient.GetAddressesAuthStateNow([]types.UnlockHash{smokeTestCmd.address}, nil)
+		if err != nil {
+			return false, err
+		}
+		return states[0], nil
+	})
+	if err != nil {
+		return "", err
+	}
+	smokeTestCmd.pending = false
+	return fmt.Sprintf("throwaway address %s authorized in transaction %s", smokeTestCmd.address.String(), txID.String()), nil
+}
+
+// checkDrip checks that the faucet drips coins to the throwaway address.
+func (smokeTestCmd *smokeTestCmd) checkDrip() (string, error) {
+	txID, err := smokeTestCmd.postFaucet("/api/v1/coins")
+	if err != nil {
+		return "", err
+	}
+	smokeTestCmd.pending = true
+	txn, err := smokeTestCmd.waitForTransaction(txID)
+	if err != nil {
+		return "", err
+	}
+	smokeTestCmd.pending = false
+	var found bool
+	for i, co := range txn.CoinOutputs {
+		uh := co.Condition.UnlockHash()
+		if !found && uh.Cmp(smokeTestCmd.address) == 0 {
+			smokeTestCmd.dripOutputID = txn.CoinOutputID(uint64(i))
+			smokeTestCmd.dripValue = co.Value
+			found = true
+		} else if smokeTestCmd.returnAddress.Type == types.UnlockTypeNil {
+			// the other output is the change of the faucet
+			smokeTestCmd.returnAddress = uh
+		}
+	}
+	if !found {
+		return "", fmt.Errorf("drip transaction %s does not pay to the throwaway address", txID.String())
+	}
+	currencyConvertor := smokeTestCmd.cli.CreateCurrencyConvertor()
+	return fmt.Sprintf("received %s in transaction %s", currencyConvertor.ToCoinStringWithUnit(smokeTestCmd.dripValue), txID.String()), nil
+}
+
+// checkSendBack checks that the dripped coins can be sent back from the throwaway address.
+func (smokeTestCmd *smokeTestCmd) checkSendBack() (string, error) {
+	if smokeTestCmd.returnAddress.Type == types.UnlockTypeNil {
+		return "", fmt.Errorf("no address to send the coins back to, define one using --return-address")
+	}
+	fee := smokeTestCmd.constants.MinimumTransactionFee
+	currencyConvertor := smokeTestCmd.cli.CreateCurrencyConvertor()
+	if smokeTestCmd.dripValue.Cmp(fee) <= 0 {
+		return "", fmt.Errorf("dripped coins do not cover the minimum transaction fee of %s", currencyConvertor.ToCoinStringWithUnit(fee))
+	}
+	value := smokeTestCmd.dripValue.Sub(fee)
+	txn := types.Transaction{
+		Version: smokeTestCmd.constants.DefaultTransactionVersion,
+		CoinInputs: []types.CoinInput{{
+			ParentID:    smokeTestCmd.dripOutputID,
+			Fulfillment: types.NewFulfillment(types.NewSingleSignatureFulfillment(smokeTestCmd.pk)),
+		}},
+		CoinOutputs: []types.CoinOutput{{
+			Value:     value,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(smokeTestCmd.returnAddress)),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	err := txn.CoinInputs[0].Fulfillment.Sign(types.FulfillmentSignContext{
+		ExtraObjects: []interface{}{uint64(0)},
+		Transaction:  txn,
+		Key:          smokeTestCmd.sk,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the transaction: %v", err)
+	}
+	b, err := json.Marshal(txn)
+	if err != nil {
+		return "", fmt.Errorf("failed to JSON-encode the transaction: %v", err)
+	}
+	var resp api.TransactionPoolPOST
+	err = smokeTestCmd.cli.PostResp("/transactionpool/transactions", string(b), &resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast the transaction: %v", err)
+	}
+	smokeTestCmd.pending = true
+	_, err = smokeTestCmd.waitForTransaction(resp.TransactionID)
+	if err != nil {
+		return "", err
+	}
+	smokeTestCmd.pending = false
+	return fmt.Sprintf("sent %s to %s in transaction %s",
+		currencyConvertor.ToCoinStringWithUnit(value), smokeTestCmd.returnAddress.String(), resp.TransactionID.String()), nil
+}
+
+// postFaucet requests the faucet to create a transaction for the throwaway address,
+// returning the ID of that transaction.
+func (smokeTestCmd *smokeTestCmd) postFaucet(call string) (types.TransactionID, error) {
+	b, err := json.Marshal(struct {
+		Address types.UnlockHash `json:"address"`
+	}{Address: smokeTestCmd.address})
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	resp, err := smokeTestCmd.faucet.Post(smokeTestCmd.faucetURL+call, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return types.TransactionID{}, fmt.Errorf("faucet is unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return types.TransactionID{}, fmt.Errorf("faucet call to %s failed: %s", call, resp.Status)
+	}
+	var body struct {
+		TxID types.TransactionID `json:"txid"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return types.TransactionID{}, fmt.Errorf("failed to decode the faucet response: %v", err)
+	}
+	return body.TxID, nil
+}
+
+// waitForTransaction waits until the transaction with the given ID is part of the blockchain.
+func (smokeTestCmd *smokeTestCmd) waitForTransaction(id types.TransactionID) (types.Transaction, error) {
+	var resp api.ConsensusGetTransaction
+	err := smokeTestCmd.waitFor("transaction "+id.String()+" to be confirmed", func() (bool, error) {
+		err := smokeTestCmd.cli.GetAPI("/consensus/transactions/"+id.String(), &resp)
+		// the daemon returns the genesis transaction for unknown transactions,
+		// hence the ID of the returned transaction has to be checked as well
+		return err == nil && resp.Transaction.ID() == id, err
+	})
+	return resp.Transaction, err
+}
+
+// waitFor polls the given condition until it is met,
+// failing once the deadline of the smoke test is exceeded.
+// Errors returned by the condition are only reported when timing out.
+func (smokeTestCmd *smokeTestCmd) waitFor(description string, condition func() (bool, error)) error {
+	for {
+		ok, err := condition()
+		if ok {
+			return nil
+		}
+		if time.Now().After(smokeTestCmd.deadline) {
+			if err != nil {
+				return fmt.Errorf("timed out waiting for %s: %v", description, err)
+			}
+			return fmt.Errorf("timed out waiting for %s", description)
+		}
+		time.Sleep(smokeTestPollInterval)
+	}
+}
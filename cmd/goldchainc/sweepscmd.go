@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createSweepsCmds registers the wallet commands used to inspect and trigger sweeps to cold storage.
+func createSweepsCmds(cliClient *client.CommandLineClient) {
+	sweepsCmd := &sweepsCmd{cli: cliClient}
+
+	rootCmd := &cobra.Command{
+		Use:   "sweeps",
+		Short: "List the sweeps of tagged addresses to cold storage",
+		Long: `Show the sweep policy of the daemon and list the recorded sweeps, the most recent one first,
+mapping every swept coin output to its address and label.
+
+The daemon sweeps the confirmed coins of the addresses tagged using its --sweep-tag
+to its --sweep-to condition, once an address holds at least --sweep-threshold coins,
+sweeping all addresses due by a single transaction whenever a block is applied.`,
+		Args: cobra.NoArgs,
+		Run:  sweepsCmd.listCmd,
+	}
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "run",
+		Short: "Sweep the tagged addresses without waiting for the next block",
+		Args:  cobra.NoArgs,
+		Run:   sweepsCmd.runCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(rootCmd)
+}
+
+type sweepsCmd struct {
+	cli *client.CommandLineClient
+}
+
+// listCmd shows the sweep policy and lists the recorded sweeps.
+func (sweepsCmd *sweepsCmd) listCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletSweepsGET
+	err := sweepsCmd.cli.GetAPI("/wallet/sweeps", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the sweeps", err)
+	}
+	if resp.Policy == nil {
+		fmt.Println("Sweeping is disabled.")
+	} else {
+		descriptor, err := goldchaintypes.ConditionDescriptor(resp.Policy.Condition)
+		if err != nil {
+			descriptor = resp.Policy.Condition.UnlockHash().String()
+		}
+		fmt.Printf("Sweeping addresses tagged %s holding at least %s to %s\n", resp.Policy.Tag,
+			sweepsCmd.cli.CreateCurrencyConvertor().ToCoinStringWithUnit(resp.Policy.Threshold), descriptor)
+	}
+	if len(resp.Sweeps) == 0 {
+		fmt.Println("No sweeps.")
+		return
+	}
+	sweepsCmd.printSweeps(resp.Sweeps)
+}
+
+// runCmd sweeps the tagged addresses.
+func (sweepsCmd *sweepsCmd) runCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletSweepPOSTResp
+	err := sweepsCmd.cli.PostResp("/wallet/sweep", "", &resp)
+	if err != nil {
+		cli.DieWithError("failed to sweep the tagged addresses", err)
+	}
+	sweepsCmd.printSweeps(resp.Sweeps)
+}
+
+func (sweepsCmd *sweepsCmd) printSweeps(sweeps []wallet.SweepRecord) {
+	currencyConvertor := sweepsCmd.cli.CreateCurrencyConvertor()
+	for _, sweep := range sweeps {
+		fmt.Printf("%s at height %d: swept %s to %s (miner fee %s)\n", sweep.TransactionID.String(), sweep.Height,
+			currencyConvertor.ToCoinStringWithUnit(sweep.Value), sweep.Target.String(),
+			currencyConvertor.ToCoinStringWithUnit(sweep.MinerFee))
+		for _, input := range sweep.Inputs {
+			fmt.Printf("  %s\t%s\t%s\t%s\n", input.ID.String(), input.Address.String(), input.Label,
+				currencyConvertor.ToCoinStringWithUnit(input.Value))
+		}
+	}
+}
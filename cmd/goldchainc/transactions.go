@@ -1,14 +1,11 @@
 package main
 
 import (
-	"github.com/threefoldtech/rivine/extensions/minting"
 	mintingcli "github.com/threefoldtech/rivine/extensions/minting/client"
 
-	"github.com/threefoldtech/rivine/extensions/authcointx"
 	authcointxcli "github.com/threefoldtech/rivine/extensions/authcointx/client"
 
 	"github.com/threefoldtech/rivine/pkg/client"
-	"github.com/threefoldtech/rivine/types"
 
 	gctypes "github.com/nbh-digital/goldchain/pkg/types"
 )
@@ -29,30 +26,10 @@ func RegisterDevnetTransactions(cli *client.CommandLineClient) {
 }
 
 func registerTransactions(cli *client.CommandLineClient) {
-	// create minting plugin client...
-	mintingCLI := mintingcli.NewPluginConsensusClient(cli)
-	// ...and register minting types
-	types.RegisterTransactionVersion(gctypes.MinterDefinitionTxVersion, minting.MinterDefinitionTransactionController{
-		MintConditionGetter: mintingCLI,
-		TransactionVersion:  gctypes.MinterDefinitionTxVersion,
-	})
-	types.RegisterTransactionVersion(gctypes.CoinCreationTxVersion, minting.CoinCreationTransactionController{
-		MintConditionGetter: mintingCLI,
-		TransactionVersion:  gctypes.CoinCreationTxVersion,
-	})
-	types.RegisterTransactionVersion(gctypes.CoinDestructionTxVersion, minting.CoinDestructionTransactionController{
-		TransactionVersion: gctypes.CoinDestructionTxVersion,
-	})
-
-	// create coin auth tx plugin client...
-	authCoinTxCLI := authcointxcli.NewPluginConsensusClient(cli)
-	// ...and register coin auth tx types
-	types.RegisterTransactionVersion(gctypes.TransactionVersionAuthConditionUpdateTx, authcointx.AuthConditionUpdateTransactionController{
-		AuthInfoGetter:     authCoinTxCLI,
-		TransactionVersion: gctypes.TransactionVersionAuthConditionUpdateTx,
-	})
-	types.RegisterTransactionVersion(gctypes.TransactionVersionAuthAddressUpdateTx, authcointx.AuthAddressUpdateTransactionController{
-		AuthInfoGetter:     authCoinTxCLI,
-		TransactionVersion: gctypes.TransactionVersionAuthAddressUpdateTx,
+	// the minting and auth coin transactions are validated and signed
+	// using the mint and auth conditions of the daemon
+	gctypes.RegisterTransactionVersions(gctypes.TransactionControllerGetters{
+		MintConditionGetter: mintingcli.NewPluginConsensusClient(cli),
+		AuthInfoGetter:      authcointxcli.NewPluginConsensusClient(cli),
 	})
 }
@@ -0,0 +1,969 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/qrcode"
+	gctypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// createWalletCmds registers the goldchain-specific wallet commands.
+func createWalletCmds(cliClient *client.CommandLineClient) {
+	walletCmd := &walletCmd{cli: cliClient}
+
+	accelerateCmd := &cobra.Command{
+		Use:   "accelerate [txid]",
+		Short: "Accelerate the confirmation of an unconfirmed transaction (child-pays-for-parent)",
+		Long: `Accelerate the confirmation of an unconfirmed transaction,
+by spending one of its outputs owned by this wallet back to the same address,
+in a child transaction paying a higher miner fee. The fee can be at most 50 times
+the estimated minimum transaction fee, and counts as a spend of the spend policy of the daemon.
+
+Without a transaction ID, all unconfirmed transactions which can be accelerated are listed.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  walletCmd.accelerateCmd,
+	}
+	accelerateCmd.Flags().StringVar(&walletCmd.accelerateCfg.MinerFee, "fee", "",
+		"miner fee paid by the child transaction, defaults to a multiple of the minimum transaction fee")
+
+	cliClient.WalletCmd.AddCommand(accelerateCmd)
+
+	consolidateCmd := &cobra.Command{
+		Use:   "consolidate",
+		Short: "Merge many small coin outputs into fewer large ones",
+		Long: `Merge the unlocked coin outputs of the wallet, smallest outputs first,
+into a single coin output per transaction. Multiple transactions are created
+should the wallet have more coin outputs than can be merged in a single transaction.`,
+		Args: cobra.NoArgs,
+		Run:  walletCmd.consolidateCmd,
+	}
+	consolidateCmd.Flags().IntVar(&walletCmd.consolidateCfg.MaxInputs, "max-inputs", wallet.DefaultConsolidationMaxInputs,
+		"maximum amount of coin outputs merged by a single transaction")
+	consolidateCmd.Flags().StringVar(&walletCmd.consolidateCfg.MinerFee, "fee", "",
+		"miner fee paid by each transaction, defaults to the minimum transaction fee")
+	consolidateCmd.Flags().StringVar(&walletCmd.consolidateCfg.MaxValue, "max-value", "",
+		"only merge coin outputs of a lower value, by default all coin outputs are merged")
+	consolidateCmd.Flags().StringVar(&walletCmd.consolidateCfg.Address, "address", "",
+		"wallet address the merged coin outputs are sent to, defaults to a new wallet address")
+
+	cliClient.WalletCmd.AddCommand(consolidateCmd)
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "fsck",
+		Short: "Check the integrity of the wallet",
+		Long: `Check the outputs and transactions tracked by the wallet against
+the consensus set and transaction pool, reporting missing outputs,
+orphaned transactions and stale unconfirmed transactions.
+
+The wallet rebuilds all of its indexes from the consensus set when the daemon starts,
+restarting the daemon thus repairs any reported inconsistency.`,
+		Args: cobra.NoArgs,
+		Run:  walletCmd.fsckCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "balance",
+		Short: "Print the breakdown of the coin balance of the wallet",
+		Long: `Print the coins the wallet can spend right away, separately from
+the time-locked coins, the coins of multisig wallets it co-owns,
+the coins on addresses which are currently not authorized (and thus cannot be spent),
+and the coins sent and received by unconfirmed transactions.`,
+		Args: cobra.NoArgs,
+		Run:  walletCmd.balanceCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "timelocked",
+		Short: "List the time-locked coin outputs of the wallet and their unlock schedule",
+		Long: `List the time-locked coin outputs owned by the wallet (incoming),
+as well as those sent by the wallet to other addresses which are still locked (outgoing),
+together with the value unlocked per lock time.`,
+		Args: cobra.NoArgs,
+		Run:  walletCmd.timeLockedCmd,
+	})
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "addressreport",
+		Short: "Report reused addresses and the address gap of the wallet",
+		Long: `Report which addresses of the primary seed of the wallet received coins,
+distinguishing deposits from change, in support of a policy
+of using every deposit address only once.
+
+Addresses which received more than one deposit, or received deposits as well as change,
+are reported as reused. The gap is the amount of generated addresses
+following the highest used address. Recommendations are printed for any issue found.`,
+		Args: cobra.NoArgs,
+		Run:  walletCmd.addressReportCmd,
+	})
+
+	importCmd := &cobra.Command{
+		Use:   "import <address>",
+		Short: "Import a watch-only address",
+		Long: `Import an address of which the wallet does not own the keys,
+tracking its transactions and balance from then on.
+
+Its history from the start height onwards is backfilled instantly
+should the daemon have the explorer module loaded, or by rescanning
+the blockchain in the background otherwise.`,
+		Args: cobra.ExactArgs(1),
+		Run:  walletCmd.importCmd,
+	}
+	importCmd.Flags().Uint64Var(&walletCmd.importCfg.StartHeight, "start-height", 0,
+		"height of the first block of which the transactions are part of the history")
+
+	cliClient.WalletCmd.AddCommand(importCmd)
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "imported [<address>]",
+		Short: "List the imported watch-only addresses, or the history of one of them",
+		Args:  cobra.MaximumNArgs(1),
+		Run:   walletCmd.importedCmd,
+	})
+
+	requestCmd := &cobra.Command{
+		Use:   "request [<amount>]",
+		Short: "Create a payment request URI, and optionally its QR code, to receive coins",
+		Long: `Create a goldchain: payment request URI, asking for a payment of the given amount
+(or of any amount should none be given) to a new address of the wallet,
+or to the address defined by the --address flag.
+
+The payment request can be paid using 'wallet send coins <uri>',
+and is written as a QR code (PNG) image to the file defined by the --qr flag.`,
+		Args: cobra.MaximumNArgs(1),
+		Run:  walletCmd.requestCmd,
+	}
+	requestCmd.Flags().StringVar(&walletCmd.requestCfg.Address, "address", "",
+		"address to receive the payment on, defaults to a new wallet address")
+	requestCmd.Flags().StringVar(&walletCmd.requestCfg.Memo, "memo", "",
+		"memo to be attached as arbitrary data to the payment")
+	requestCmd.Flags().BoolVar(&walletCmd.requestCfg.AuthRequired, "auth", false,
+		"indicate the address has to be authorized to receive coins")
+	requestCmd.Flags().StringVar(&walletCmd.requestCfg.QRFile, "qr", "",
+		"file to write the QR code (PNG) image of the payment request to")
+
+	cliClient.WalletCmd.AddCommand(requestCmd)
+
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "signmessage <address> <message>",
+		Short: "Sign a message using the key of an address, proving control of that address",
+		Long: `Sign a message using the key of a single signature address of the wallet,
+printing the signature as <public key>:<signature>.
+
+The signature proves control of the address without creating a transaction,
+e.g. to the faucet, which requires a signed challenge to authorize an address.
+It can be verified by anyone using 'wallet verifymessage'.`,
+		Args: cobra.ExactArgs(2),
+		Run:  walletCmd.signMessageCmd,
+	})
+	cliClient.WalletCmd.AddCommand(&cobra.Command{
+		Use:   "verifymessage <address> <message> <signature>",
+		Short: "Verify that a message is signed using the key of an address",
+		Long: `Verify that a message is signed using the key of the given address,
+as printed by 'wallet signmessage'. The verification does not require a daemon.`,
+		Args: cobra.ExactArgs(3),
+		Run:  walletCmd.verifyMessageCmd,
+	})
+
+	sendExpiringCoinsCmd := &cobra.Command{
+		Use:   "expiringcoins <dest>|<rawCondition>|<descriptor> <amount> [<dest>|<rawCondition>|<descriptor> <amount>]...",
+		Short: "Send coins in a transaction that expires if not confirmed in time",
+		Long: `Send coins to one or multiple addresses (or conditions),
+in a transaction that becomes permanently invalid should it not be confirmed
+at or below the given expiration height.`,
+		Args: cobra.MinimumNArgs(2),
+		Run:  walletCmd.sendExpiringCoinsCmd,
+	}
+	sendExpiringCoinsCmd.Flags().Uint64Var(&walletCmd.sendExpiringCoinsCfg.ExpirationHeight, "expiration-height", 0,
+		"height of the last block the transaction can be part of")
+	sendExpiringCoinsCmd.Flags().Uint64Var(&walletCmd.sendExpiringCoinsCfg.ExpireAfter, "expire-after", 0,
+		"amount of blocks, starting from the current height, the transaction can be part of")
+	cli.ArbitraryDataFlagVar(sendExpiringCoinsCmd.Flags(), &walletCmd.sendExpiringCoinsCfg.Data,
+		"data", "optional arbitrary data (or description) to attach to the transaction")
+
+	registerLockedUntilFlag(sendExpiringCoinsCmd.Flags(), &walletCmd.sendExpiringCoinsCfg.LockedUntil)
+	walletCmd.sendExpiringCoinsCfg.CoinSelection.registerFlags(sendExpiringCoinsCmd.Flags())
+
+	cliClient.WalletCmd.RootCmdSend.AddCommand(sendExpiringCoinsCmd)
+
+	// extend the rivine send coins command with lock time and coin selection flags
+	for _, sendCoinsCmd := range cliClient.WalletCmd.RootCmdSend.Commands() {
+		if sendCoinsCmd.Name() != "coins" {
+			continue
+		}
+		walletCmd.sendCoinsFallback = sendCoinsCmd.Run
+		sendCoinsCmd.Run = walletCmd.sendCoinsCmd
+		sendCoinsCmd.Long += `
+	Instead of destination and amount pairs, a goldchain: payment request URI can be given,
+	followed by the amount should the payment request not define one.
+	The memo of the payment request is attached as arbitrary data, unless the --data flag is defined.
+
+	The name of a contact of the address book can be used as destination,
+	as long as the address of the contact is authorized to receive coins.
+	`
+		registerLockedUntilFlag(sendCoinsCmd.Flags(), &walletCmd.sendCoinsCfg.LockedUntil)
+		walletCmd.sendCoinsCfg.CoinSelection.registerFlags(sendCoinsCmd.Flags())
+	}
+}
+
+type walletCmd struct {
+	cli           *client.CommandLineClient
+	accelerateCfg struct {
+		MinerFee string
+	}
+	consolidateCfg struct {
+		MaxInputs int
+		MinerFee  string
+		MaxValue  string
+		Address   string
+	}
+	sendExpiringCoinsCfg struct {
+		ExpirationHeight uint64
+		ExpireAfter      uint64
+		Data             []byte
+		LockedUntil      string
+		CoinSelection    coinSelectionCfg
+	}
+	importCfg struct {
+		StartHeight uint64
+	}
+	requestCfg struct {
+		Address      string
+		Memo         string
+		AuthRequired bool
+		QRFile       string
+	}
+	sendCoinsCfg struct {
+		LockedUntil   string
+		CoinSelection coinSelectionCfg
+	}
+	sendCoinsFallback func(cmd *cobra.Command, args []string)
+}
+
+// coinSelectionCfg contains the optional coin selection flags of the send commands.
+type coinSelectionCfg struct {
+	Strategy string
+	Include  []string
+	Exclude  []string
+	Account  string
+}
+
+func (cfg *coinSelectionCfg) registerFlags(flagSet *pflag.FlagSet) {
+	flagSet.StringVar(&cfg.Strategy, "coin-selection", "", fmt.Sprintf(
+		"strategy used to select the coin outputs funding the transaction, one of: %s (default), %s, %s",
+		wallet.CoinSelectionLargestFirst, wallet.CoinSelectionSmallestFirst, wallet.CoinSelectionBranchAndBound))
+	flagSet.StringSliceVar(&cfg.Include, "include-output", nil,
+		"ID of a coin output which has to fund the transaction, can be given multiple times")
+	flagSet.StringSliceVar(&cfg.Exclude, "exclude-output", nil,
+		"ID of a coin output which may not fund the transaction, can be given multiple times")
+	flagSet.StringVar(&cfg.Account, "account", "",
+		"label of the wallet account funding the transaction, the funds of the accounts are never used if not given")
+}
+
+// isSet returns true in case any of the coin selection flags is defined.
+func (cfg *coinSelectionCfg) isSet() bool {
+	return cfg.Strategy != "" || len(cfg.Include) > 0 || len(cfg.Exclude) > 0 || cfg.Account != ""
+}
+
+// addQuery adds the defined coin selection flags as query parameters of the wallet send endpoints.
+func (cfg *coinSelectionCfg) addQuery(values url.Values) {
+	if cfg.Strategy != "" {
+		values.Set("coinselection", cfg.Strategy)
+	}
+	if len(cfg.Include) > 0 {
+		values.Set("includeoutputs", strings.Join(cfg.Include, ","))
+	}
+	if len(cfg.Exclude) > 0 {
+		values.Set("excludeoutputs", strings.Join(cfg.Exclude, ","))
+	}
+	if cfg.Account != "" {
+		values.Set("account", cfg.Account)
+	}
+}
+
+// registerLockedUntilFlag registers the flag time-locking the outputs of the send commands.
+func registerLockedUntilFlag(flagSet *pflag.FlagSet, lockedUntil *string) {
+	flagSet.StringVar(lockedUntil, "locked-until", "",
+		"time-lock all outputs until the given block height, unix epoch timestamp or date (RFC 3339 or YYYY-MM-DD, UTC)")
+}
+
+// parseLockTime parses a lock time given as a block height, unix epoch timestamp or date.
+func parseLockTime(str string) (uint64, error) {
+	if lockTime, err := strconv.ParseUint(str, 10, 64); err == nil {
+		return lockTime, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, str); err == nil {
+			if t.Unix() < types.LockTimeMinTimestampValue {
+				return 0, fmt.Errorf("date %s is too early to be used as lock time", str)
+			}
+			return uint64(t.Unix()), nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not a block height, unix epoch timestamp or date", str)
+}
+
+// printLockTime prints the lock time of the sent outputs, if any.
+func printLockTime(lockedUntil string) {
+	if lockTime, err := parseLockTime(lockedUntil); err == nil && lockTime != 0 {
+		fmt.Println("All sent outputs are locked until " + formatLockTime(lockTime))
+	}
+}
+
+// formatLockTime formats a lock time as either a block height or a date.
+func formatLockTime(lockTime uint64) string {
+	if lockTime < types.LockTimeMinTimestampValue {
+		return fmt.Sprintf("block height %d", lockTime)
+	}
+	return time.Unix(int64(lockTime), 0).UTC().Format(time.RFC3339)
+}
+
+// accelerateCmd lists the unconfirmed transactions which can be accelerated,
+// or accelerates the given unconfirmed transaction.
+func (walletCmd *walletCmd) accelerateCmd(cmd *cobra.Command, args []string) {
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	if len(args) == 0 {
+		var resp goldchainapi.WalletAccelerateGET
+		err := walletCmd.cli.GetAPI("/wallet/accelerate", &resp)
+		if err != nil {
+			cli.DieWithError("failed to list accelerable transactions", err)
+		}
+		if len(resp.Transactions) == 0 {
+			fmt.Println("No unconfirmed transactions can be accelerated by this wallet.")
+			return
+		}
+		for _, txn := range resp.Transactions {
+			var stuck string
+			if txn.Stuck {
+				stuck = " (stuck)"
+			}
+			fmt.Printf("%s: miner fee %s, spendable output of %s%s\n",
+				txn.TransactionID.String(), currencyConvertor.ToCoinStringWithUnit(txn.MinerFee),
+				currencyConvertor.ToCoinStringWithUnit(txn.Output.Value), stuck)
+		}
+		return
+	}
+
+	var id types.TransactionID
+	err := id.LoadString(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid transaction ID", err)
+	}
+	var body string
+	if walletCmd.accelerateCfg.MinerFee != "" {
+		fee, err := currencyConvertor.ParseCoinString(walletCmd.accelerateCfg.MinerFee)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid miner fee", err)
+		}
+		b, err := json.Marshal(goldchainapi.WalletAcceleratePOST{MinerFee: fee})
+		if err != nil {
+			cli.DieWithError("failed to JSON Marshal the input body", err)
+		}
+		body = string(b)
+	}
+	var resp goldchainapi.WalletAcceleratePOSTResp
+	err = walletCmd.cli.PostResp("/wallet/accelerate/"+id.String(), body, &resp)
+	if err != nil {
+		cli.DieWithError("failed to accelerate transaction", err)
+	}
+	fmt.Printf("Accelerated transaction %s with child transaction %s, paying a miner fee of %s\n",
+		id.String(), resp.TransactionID.String(), currencyConvertor.ToCoinStringWithUnit(resp.Transaction.MinerFees[0]))
+}
+
+// consolidateCmd merges the coin outputs of the wallet.
+func (walletCmd *walletCmd) consolidateCmd(cmd *cobra.Command, args []string) {
+	cfg := walletCmd.consolidateCfg
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	body := goldchainapi.WalletConsolidatePOST{
+		ConsolidationOptions: wallet.ConsolidationOptions{
+			MaxInputs: cfg.MaxInputs,
+		},
+	}
+	var err error
+	if cfg.MinerFee != "" {
+		body.MinerFee, err = currencyConvertor.ParseCoinString(cfg.MinerFee)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid miner fee", err)
+		}
+	}
+	if cfg.MaxValue != "" {
+		body.MaxValue, err = currencyConvertor.ParseCoinString(cfg.MaxValue)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid maximum value", err)
+		}
+	}
+	if cfg.Address != "" {
+		var uh types.UnlockHash
+		err = uh.LoadString(cfg.Address)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid address", err)
+		}
+		body.Address = &uh
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp goldchainapi.WalletConsolidatePOSTResp
+	err = walletCmd.cli.PostResp("/wallet/consolidate", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to consolidate coin outputs", err)
+	}
+	fmt.Printf("Consolidated coin outputs in %d transaction(s):\n", len(resp.TransactionIDs))
+	for _, id := range resp.TransactionIDs {
+		fmt.Println(id.String())
+	}
+}
+
+// fsckCmd checks the integrity of the wallet.
+func (walletCmd *walletCmd) fsckCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletFsckGET
+	err := walletCmd.cli.GetAPI("/wallet/fsck", &resp)
+	if err != nil {
+		cli.DieWithError("failed to check the wallet integrity", err)
+	}
+	fmt.Printf("Checked %d coin outputs, %d blockstake outputs, %d transactions and %d unconfirmed transactions at height %d.\n",
+		resp.CheckedCoinOutputs, resp.CheckedBlockStakeOutputs, resp.CheckedTransactions,
+		resp.CheckedUnconfirmedTransactions, resp.Height)
+	if resp.Consistent {
+		fmt.Println("No issues found.")
+		return
+	}
+	for _, id := range resp.MissingCoinOutputs {
+		fmt.Println("missing coin output:", id.String())
+	}
+	for _, id := range resp.MissingBlockStakeOutputs {
+		fmt.Println("missing blockstake output:", id.String())
+	}
+	for _, id := range resp.OrphanedTransactions {
+		fmt.Println("orphaned transaction:", id.String())
+	}
+	for _, id := range resp.StaleUnconfirmedTransactions {
+		fmt.Println("stale unconfirmed transaction:", id.String())
+	}
+	cli.Die("Wallet is inconsistent, restart the daemon to rebuild the wallet from the consensus set.")
+}
+
+// balanceCmd prints the breakdown of the coin balance of the wallet.
+func (walletCmd *walletCmd) balanceCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletBalanceGET
+	err := walletCmd.cli.GetAPI("/wallet/balance", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the wallet balance", err)
+	}
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	fmt.Printf("Balance at block height %d:\n", resp.Height)
+	fmt.Println("  spendable:         ", currencyConvertor.ToCoinStringWithUnit(resp.Spendable))
+	fmt.Println("  time-locked:       ", currencyConvertor.ToCoinStringWithUnit(resp.Locked))
+	fmt.Println("  multisig:          ", currencyConvertor.ToCoinStringWithUnit(resp.MultiSig))
+	fmt.Println("  unauthorized:      ", currencyConvertor.ToCoinStringWithUnit(resp.Unauthorized))
+	for _, funds := range resp.UnauthorizedAddresses {
+		fmt.Printf("    %s on %s\n", currencyConvertor.ToCoinStringWithUnit(funds.Value), funds.Address.String())
+	}
+	fmt.Println("  pending incoming:  ", currencyConvertor.ToCoinStringWithUnit(resp.PendingIncoming))
+	fmt.Println("  pending outgoing:  ", currencyConvertor.ToCoinStringWithUnit(resp.PendingOutgoing))
+	fmt.Println("  expected spendable:", currencyConvertor.ToCoinStringWithUnit(resp.Expected))
+}
+
+// timeLockedCmd lists the time-locked coin outputs of the wallet and their unlock schedule.
+func (walletCmd *walletCmd) timeLockedCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletTimeLockedGET
+	err := walletCmd.cli.GetAPI("/wallet/timelocked", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the locked coin outputs", err)
+	}
+	if len(resp.Schedule) == 0 {
+		fmt.Println("No time-locked coin outputs.")
+		return
+	}
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	printOutputs := func(title string, outputs []wallet.LockedCoinOutput) {
+		if len(outputs) == 0 {
+			return
+		}
+		fmt.Println(title)
+		for _, output := range outputs {
+			status := ""
+			if !output.Confirmed {
+				status = " (unconfirmed)"
+			}
+			fmt.Printf("  %s %s to %s until %s%s\n", output.ID.String(),
+				currencyConvertor.ToCoinStringWithUnit(output.Value), output.UnlockHash.String(),
+				formatLockTime(output.LockTime), status)
+		}
+	}
+	printOutputs(fmt.Sprintf("Incoming (%s):", currencyConvertor.ToCoinStringWithUnit(resp.IncomingTotal)), resp.Incoming)
+	printOutputs(fmt.Sprintf("Outgoing (%s):", currencyConvertor.ToCoinStringWithUnit(resp.OutgoingTotal)), resp.Outgoing)
+	fmt.Printf("Unlock schedule (current block height %d):\n", resp.Height)
+	for _, entry := range resp.Schedule {
+		fmt.Printf("  %s: +%s incoming, -%s outgoing\n", formatLockTime(entry.LockTime),
+			currencyConvertor.ToCoinStringWithUnit(entry.Incoming),
+			currencyConvertor.ToCoinStringWithUnit(entry.Outgoing))
+	}
+}
+
+// addressReportCmd reports the reused addresses and the address gap of the wallet.
+func (walletCmd *walletCmd) addressReportCmd(cmd *cobra.Command, args []string) {
+	var resp goldchainapi.WalletAddressReportGET
+	err := walletCmd.cli.GetAPI("/wallet/addressreport", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the wallet address report", err)
+	}
+	fmt.Printf("%d of %d generated addresses received coins at height %d.\n",
+		resp.UsedAddresses, resp.GeneratedAddresses, resp.Height)
+	if resp.HighestUsedIndex != nil {
+		fmt.Printf("Highest used address index: %d (gap of %d addresses)\n", *resp.HighestUsedIndex, resp.Gap)
+	}
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	for _, usage := range resp.Addresses {
+		status := ""
+		if usage.Reused {
+			status = " (reused)"
+		}
+		fmt.Printf("  #%d %s: %d deposit(s), %d change output(s), %d miner payout(s), %s received%s\n",
+			usage.Index, usage.Address.String(), usage.Deposits, usage.ChangeOutputs, usage.MinerPayouts,
+			currencyConvertor.ToCoinStringWithUnit(usage.Received), status)
+	}
+	fmt.Printf("Reused addresses: %d, addresses which received change: %d\n", resp.ReusedAddresses, resp.ChangeAddresses)
+	if len(resp.Recommendations) == 0 {
+		fmt.Println("No issues found.")
+		return
+	}
+	fmt.Println("Recommendations:")
+	for _, recommendation := range resp.Recommendations {
+		fmt.Println("  -", recommendation)
+	}
+}
+
+// signMessageCmd signs a message using the key of an address of the wallet.
+func (walletCmd *walletCmd) signMessageCmd(cmd *cobra.Command, args []string) {
+	address, err := network.ParseAddress(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid address", err)
+	}
+	b, err := json.Marshal(goldchainapi.WalletSignMessagePOST{Address: address, Message: args[1]})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the message", err)
+	}
+	var resp goldchainapi.WalletSignMessagePOSTResp
+	err = walletCmd.cli.PostResp("/wallet/signmessage", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to sign the message", err)
+	}
+	fmt.Println(resp.Signature.String())
+}
+
+// verifyMessageCmd verifies that a message is signed using the key of an address.
+func (walletCmd *walletCmd) verifyMessageCmd(cmd *cobra.Command, args []string) {
+	address, err := network.ParseAddress(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid address", err)
+	}
+	var sig gctypes.MessageSignature
+	err = sig.LoadString(args[2])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid signature", err)
+	}
+	err = sig.Verify(address, args[1])
+	if err != nil {
+		cli.DieWithError("failed to verify the signature", err)
+	}
+	fmt.Printf("The message is signed using the key of %s\n", address.String())
+}
+
+// importCmd imports a watch-only address.
+func (walletCmd *walletCmd) importCmd(cmd *cobra.Command, args []string) {
+	address, err := network.ParseAddress(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid address", err)
+	}
+	b, err := json.Marshal(goldchainapi.WalletAddressesImportPOST{
+		Address:     address,
+		StartHeight: types.BlockHeight(walletCmd.importCfg.StartHeight),
+	})
+	if err != nil {
+		cli.DieWithError("failed to JSON-encode the address", err)
+	}
+	var resp goldchainapi.WalletImportedAddressGET
+	err = walletCmd.cli.PostResp("/wallet/addresses/import", string(b), &resp)
+	if err != nil {
+		cli.DieWithError("failed to import the address", err)
+	}
+	fmt.Printf("Imported address %s\n", address.String())
+	walletCmd.printImportedAddress(resp.ImportedAddress)
+}
+
+// importedCmd lists the imported watch-only addresses, or the history of one of them.
+func (walletCmd *walletCmd) importedCmd(cmd *cobra.Command, args []string) {
+	if len(args) == 1 {
+		address, err := network.ParseAddress(args[0])
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid address", err)
+		}
+		var resp goldchainapi.WalletImportedAddressGET
+		err = walletCmd.cli.GetAPI("/wallet/addresses/imported/"+address.String(), &resp)
+		if err != nil {
+			cli.DieWithError("failed to get the imported address", err)
+		}
+		walletCmd.printImportedAddress(resp.ImportedAddress)
+		for _, txn := range resp.Transactions {
+			fmt.Printf("  %s at height %d\n", txn.ID.String(), txn.Height)
+		}
+		return
+	}
+	var resp goldchainapi.WalletImportedAddressesGET
+	err := walletCmd.cli.GetAPI("/wallet/addresses/imported", &resp)
+	if err != nil {
+		cli.DieWithError("failed to get the imported addresses", err)
+	}
+	if len(resp.Addresses) == 0 {
+		fmt.Println("No imported addresses.")
+		return
+	}
+	for _, address := range resp.Addresses {
+		walletCmd.printImportedAddress(address)
+	}
+}
+
+// printImportedAddress prints the balance and amount of transactions of an imported address.
+func (walletCmd *walletCmd) printImportedAddress(address wallet.ImportedAddress) {
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	status := ""
+	if !address.Synced {
+		status = " (backfilling)"
+	}
+	fmt.Printf("%s: %s, %s block stakes, %d transaction(s) since height %d%s\n",
+		address.Address.String(), currencyConvertor.ToCoinStringWithUnit(address.ConfirmedCoinBalance),
+		address.ConfirmedBlockStakeBalance.String(), len(address.Transactions), address.StartHeight, status)
+}
+
+// requestCmd creates a payment request URI, and optionally writes it as QR code image.
+func (walletCmd *walletCmd) requestCmd(cmd *cobra.Command, args []string) {
+	cfg := walletCmd.requestCfg
+	pr := gctypes.PaymentRequest{
+		Memo:         cfg.Memo,
+		AuthRequired: cfg.AuthRequired,
+	}
+	var err error
+	if len(args) == 1 {
+		pr.Amount, err = walletCmd.cli.CreateCurrencyConvertor().ParseCoinString(args[0])
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid amount", err)
+		}
+	}
+	if cfg.Address != "" {
+		err = pr.Address.LoadString(cfg.Address)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid address", err)
+		}
+	} else {
+		var resp api.WalletAddressGET
+		err = walletCmd.cli.GetAPI("/wallet/address", &resp)
+		if err != nil {
+			cli.DieWithError("failed to get a new wallet address", err)
+		}
+		pr.Address = resp.Address
+	}
+
+	uri := pr.String()
+	fmt.Println(uri)
+	if cfg.QRFile == "" {
+		return
+	}
+	code, err := qrcode.Encode([]byte(uri), qrcode.Medium)
+	if err != nil {
+		cli.DieWithError("failed to encode the payment request as QR code", err)
+	}
+	file, err := os.Create(cfg.QRFile)
+	if err != nil {
+		cli.DieWithError("failed to create the QR code file", err)
+	}
+	err = png.Encode(file, code.Image(8))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		cli.DieWithError("failed to write the QR code file", err)
+	}
+	fmt.Println("QR code written to " + cfg.QRFile)
+}
+
+// sendExpiringCoinsCmd sends coins to one or multiple destinations, using an expiring transaction.
+func (walletCmd *walletCmd) sendExpiringCoinsCmd(cmd *cobra.Command, args []string) {
+	cfg := walletCmd.sendExpiringCoinsCfg
+	if (cfg.ExpirationHeight == 0) == (cfg.ExpireAfter == 0) {
+		cmd.UsageFunc()(cmd)
+		cli.Die("exactly one of --expiration-height or --expire-after has to be defined")
+	}
+	expirationHeight := types.BlockHeight(cfg.ExpirationHeight)
+	if cfg.ExpireAfter != 0 {
+		var cs api.ConsensusGET
+		err := walletCmd.cli.GetAPI("/consensus", &cs)
+		if err != nil {
+			cli.DieWithError("failed to get the current block height", err)
+		}
+		expirationHeight = cs.Height + types.BlockHeight(cfg.ExpireAfter)
+	}
+
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	coinOutputs, err := parseCoinOutputs(resolveContacts(walletCmd.cli, args), currencyConvertor)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.Die(err)
+	}
+	query := url.Values{}
+	query.Set("expirationheight", strconv.FormatUint(uint64(expirationHeight), 10))
+	if cfg.LockedUntil != "" {
+		lockTime, err := parseLockTime(cfg.LockedUntil)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid lock time", err)
+		}
+		query.Set("lockeduntil", strconv.FormatUint(lockTime, 10))
+	}
+	cfg.CoinSelection.addQuery(query)
+	body := api.WalletCoinsPOST{
+		CoinOutputs: coinOutputs,
+		Data:        cfg.Data,
+	}
+	if dryRun {
+		walletCmd.dryRunSend("/wallet/coins", body, query)
+		return
+	}
+	txID := walletCmd.sendCoins(body, query)
+	fmt.Printf("Successfully sent coins as transaction %s, expiring after block height %d\n",
+		txID.String(), expirationHeight)
+	printSentCoinOutputs(coinOutputs, currencyConvertor)
+	printLockTime(cfg.LockedUntil)
+}
+
+// sendCoinsCmd extends the rivine send coins command,
+// sending the coins using the lock time and coin selection flags in case any is defined,
+// and supporting condition descriptors as destinations and payment requests.
+func (walletCmd *walletCmd) sendCoinsCmd(cmd *cobra.Command, args []string) {
+	cfg := walletCmd.sendCoinsCfg
+	paymentRequest := len(args) > 0 && gctypes.IsPaymentRequest(args[0])
+	var memo string
+	if paymentRequest {
+		args, memo = walletCmd.paymentRequestArgs(cmd, args)
+	} else {
+		checkDestinations(args)
+		args = resolveContacts(walletCmd.cli, args)
+	}
+	if !dryRun && !paymentRequest && !cfg.CoinSelection.isSet() && cfg.LockedUntil == "" && !hasConditionDescriptors(args) {
+		walletCmd.sendCoinsFallback(cmd, args)
+		return
+	}
+
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	coinOutputs, err := parseCoinOutputs(args, currencyConvertor)
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.Die(err)
+	}
+	body := api.WalletCoinsPOST{
+		CoinOutputs: coinOutputs,
+	}
+	body.Data, body.RefundAddress, body.GenerateRefundAddress = rivineSendFlags(cmd)
+	if body.Data == nil && memo != "" {
+		body.Data = []byte(memo)
+	}
+
+	query := url.Values{}
+	if cfg.LockedUntil != "" {
+		lockTime, err := parseLockTime(cfg.LockedUntil)
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid lock time", err)
+		}
+		query.Set("lockeduntil", strconv.FormatUint(lockTime, 10))
+	}
+	cfg.CoinSelection.addQuery(query)
+	if dryRun {
+		walletCmd.dryRunSend("/wallet/coins", body, query)
+		return
+	}
+	txID := walletCmd.sendCoins(body, query)
+	fmt.Println("Successfully sent coins as transaction " + txID.String())
+	printSentCoinOutputs(coinOutputs, currencyConvertor)
+	printLockTime(cfg.LockedUntil)
+}
+
+// paymentRequestArgs converts the '<uri> [<amount>]' arguments of the send coins command
+// into a '<dest>' and '<amount>' argument pair, returning the memo of the payment request.
+// A payment request requiring an authorized address is refused should the address not be authorized.
+func (walletCmd *walletCmd) paymentRequestArgs(cmd *cobra.Command, args []string) ([]string, string) {
+	if len(args) > 2 {
+		cmd.UsageFunc()(cmd)
+		cli.Die("a payment request can only be followed by an amount")
+	}
+	pr, err := gctypes.ParsePaymentRequest(args[0])
+	if err != nil {
+		cmd.UsageFunc()(cmd)
+		cli.DieWithError("invalid payment request", err)
+	}
+	currencyConvertor := walletCmd.cli.CreateCurrencyConvertor()
+	if len(args) == 2 {
+		amount, err := currencyConvertor.ParseCoinString(args[1])
+		if err != nil {
+			cmd.UsageFunc()(cmd)
+			cli.DieWithError("invalid amount", err)
+		}
+		if !pr.Amount.IsZero() && !pr.Amount.Equals(amount) {
+			cli.Die("the given amount differs from the requested amount of " + currencyConvertor.ToCoinStringWithUnit(pr.Amount))
+		}
+		pr.Amount = amount
+	}
+	if pr.Amount.IsZero() {
+		cmd.UsageFunc()(cmd)
+		cli.Die("the payment request does not define an amount, it has to be given after the payment request")
+	}
+	if pr.AuthRequired {
+		ensureAuthorized(walletCmd.cli, pr.Address, "the requested address")
+	}
+	return []string{pr.Address.String(), currencyConvertor.ToCoinString(pr.Amount)}, pr.Memo
+}
+
+// rivineSendFlags returns the data and refund flags defined by the rivine send commands.
+func rivineSendFlags(cmd *cobra.Command) (data []byte, refundAddress *types.UnlockHash, generateRefundAddress bool) {
+	if flag := cmd.Flags().Lookup("data"); flag != nil && flag.Changed {
+		// the flag value is printed quoted
+		data = []byte(flag.Value.String())
+		if unquoted, err := strconv.Unquote(`"` + flag.Value.String() + `"`); err == nil {
+			data = []byte(unquoted)
+		}
+	}
+	if address, _ := cmd.Flags().GetString("refund-address"); address != "" {
+		uh, err := network.ParseAddress(address)
+		if err != nil {
+			cli.Die("invalid refund address specified:", err)
+		}
+		refundAddress = &uh
+	} else if refundAddressNew, _ := cmd.Flags().GetBool("refund-address-new"); refundAddressNew {
+		generateRefundAddress = true
+	}
+	return
+}
+
+// sendCoins sends coins using the /wallet/coins endpoint with the given query parameters.
+func (walletCmd *walletCmd) sendCoins(body api.WalletCoinsPOST, query url.Values) types.TransactionID {
+	b, err := json.Marshal(body)
+	if err != nil {
+		cli.DieWithError("failed to JSON Marshal the input body", err)
+	}
+	var resp api.WalletCoinsPOSTResp
+	err = walletCmd.cli.PostResp("/wallet/coins?"+query.Encode(), string(b), &resp)
+	if err != nil {
+		cli.DieWithError("could not send coins", err)
+	}
+	return resp.TransactionID
+}
+
+func printSentCoinOutputs(coinOutputs []types.CoinOutput, currencyConvertor client.CurrencyConvertor) {
+	for _, co := range coinOutputs {
+		fmt.Printf("Sent %s to %s (using ConditionType %d)\n",
+			currencyConvertor.ToCoinStringWithUnit(co.Value), co.Condition.UnlockHash(),
+			co.Condition.ConditionType())
+	}
+}
+
+// hasConditionDescriptors returns true in case any of the destinations
+// of the given '<dest>|<rawCondition>|<descriptor>' and '<amount>' argument pairs is a condition descriptor.
+func hasConditionDescriptors(args []string) bool {
+	for i := 0; i < len(args); i += 2 {
+		if arg := strings.TrimSpace(args[i]); strings.Contains(arg, "(") && !strings.HasPrefix(arg, "{") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCoinOutputs parses pairs of '<dest>|<rawCondition>|<descriptor>' and '<amount>' arguments as coin outputs.
+func parseCoinOutputs(args []string, currencyConvertor client.CurrencyConvertor) ([]types.CoinOutput, error) {
+	return parseOutputs(args, currencyConvertor.ParseCoinString)
+}
+
+// parseBlockStakeOutputs parses pairs of '<dest>|<rawCondition>|<descriptor>' and '<amount>' arguments as blockstake outputs,
+// the amounts being expressed as an integral amount of blockstakes.
+func parseBlockStakeOutputs(args []string) ([]types.BlockStakeOutput, error) {
+	outputs, err := parseOutputs(args, func(str string) (types.Currency, error) {
+		value, err := strconv.ParseUint(str, 10, 64)
+		return types.NewCurrency64(value), err
+	})
+	if err != nil {
+		return nil, err
+	}
+	blockStakeOutputs := make([]types.BlockStakeOutput, 0, len(outputs))
+	for _, output := range outputs {
+		blockStakeOutputs = append(blockStakeOutputs, types.BlockStakeOutput{
+			Value:     output.Value,
+			Condition: output.Condition,
+		})
+	}
+	return blockStakeOutputs, nil
+}
+
+// parseOutputs parses pairs of '<dest>|<rawCondition>|<descriptor>' and '<amount>' arguments,
+// using the given function to parse the amounts.
+func parseOutputs(args []string, parseValue func(string) (types.Currency, error)) ([]types.CoinOutput, error) {
+	if len(args) < 2 || len(args)%2 != 0 {
+		return nil, errors.New("arguments have to be given in pairs of '<dest>|<rawCondition>|<descriptor>'+'<amount>'")
+	}
+	coinOutputs := make([]types.CoinOutput, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		value, err := parseValue(args[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse amount of output #%d: %v", i/2, err)
+		}
+		var condition types.UnlockConditionProxy
+		if dest := strings.TrimSpace(args[i]); strings.HasPrefix(dest, "{") {
+			if err = condition.UnmarshalJSON([]byte(args[i])); err != nil {
+				return nil, fmt.Errorf("invalid JSON-encoded UnlockCondition for output #%d: %v", i/2, err)
+			}
+		} else if strings.Contains(dest, "(") {
+			if condition, err = gctypes.ParseConditionDescriptor(args[i]); err != nil {
+				return nil, fmt.Errorf("invalid condition descriptor for output #%d: %v", i/2, err)
+			}
+		} else {
+			// refuse addresses of other blockchains and mistyped addresses, which would lose the coins
+			uh, err := network.ParseAddress(dest)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address for output #%d: %v", i/2, err)
+			}
+			condition = types.NewCondition(types.NewUnlockHashCondition(uh))
+		}
+		coinOutputs = append(coinOutputs, types.CoinOutput{
+			Value:     value,
+			Condition: condition,
+		})
+	}
+	return coinOutputs, nil
+}
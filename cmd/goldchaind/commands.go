@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
 	"strings"
 
@@ -18,13 +17,22 @@ import (
 type commands struct {
 	cfg           ExtendedDaemonConfig
 	moduleSetFlag daemon.ModuleSetFlag
+	bootstrapCfg  bootstrapConfig
+	snapshotCfg   snapshotConfig
+
+	// configSources defines the source of every flag, once the configuration is loaded
+	configSources map[string]configSource
 }
 
-func (cmds *commands) rootCommand(*cobra.Command, []string) {
+func (cmds *commands) rootCommand(cmd *cobra.Command, _ []string) {
 	var err error
 
-	// Silently append a subdirectory for storage with the name of the network so we don't create conflicts
-	cmds.cfg.RootPersistentDir = filepath.Join(cmds.cfg.RootPersistentDir, cmds.cfg.BlockchainInfo.NetworkName)
+	// define the flags not defined on the command line using the environment and configuration file
+	cmds.loadConfig(cmd)
+
+	// Silently append a subdirectory for storage with the name of the network so we don't create conflicts,
+	// unless the data directory is defined explicitly
+	cmds.cfg.RootPersistentDir = cmds.cfg.NetworkDir()
 
 	// Check if we require an api password
 	if cmds.cfg.AuthenticateAPI {
@@ -45,11 +53,44 @@ func (cmds *commands) rootCommand(*cobra.Command, []string) {
 		cmds.cfg.APIPassword = ""
 	}
 
+	if cmds.cfg.RemoteSigner != "" && cmds.cfg.RemoteSignerPassword == "" {
+		cmds.cfg.RemoteSignerPassword, err = speakeasy.Ask("Enter remote signer password: ")
+		if err != nil {
+			cli.DieWithError("failed to ask for remote signer password", err)
+		}
+		if cmds.cfg.RemoteSignerPassword == "" {
+			cli.DieWithError("failed to configure daemon", errors.New("remote signer password cannot be blank"))
+		}
+	}
+
+	if cmds.cfg.WalletApprovalThreshold != "" && cmds.cfg.WalletApprovalPassword == "" {
+		cmds.cfg.WalletApprovalPassword, err = speakeasy.Ask("Enter wallet approval password: ")
+		if err != nil {
+			cli.DieWithError("failed to ask for wallet approval password", err)
+		}
+		if cmds.cfg.WalletApprovalPassword == "" {
+			cli.DieWithError("failed to configure daemon", errors.New("wallet approval password cannot be blank"))
+		}
+	}
+
 	// Process the config variables, cleaning up slightly invalid values
 	cmds.cfg.Config = daemon.ProcessConfig(cmds.cfg.Config)
+	err = cmds.cfg.Validate()
+	if err != nil {
+		cli.DieWithError("failed to configure daemon", err)
+	}
+
+	// load the modules of the role, if any, instead of those of the modules flag
+	moduleIdentifiers := cmds.moduleSetFlag.ModuleIdentifiers()
+	if cmds.cfg.Role != "" {
+		moduleIdentifiers, err = roleModules(cmds.cfg.Role)
+		if err != nil {
+			cli.DieWithError("failed to configure daemon", err)
+		}
+	}
 
 	// run daemon
-	err = runDaemon(cmds.cfg, cmds.moduleSetFlag.ModuleIdentifiers())
+	err = runDaemon(cmds.cfg, moduleIdentifiers)
 	if err != nil {
 		cli.DieWithError("daemon failed", err)
 	}
@@ -82,4 +123,9 @@ func (cmds *commands) modulesCommand(*cobra.Command, []string) {
 	if err != nil {
 		cli.DieWithError("failed to write usage of the modules flag", err)
 	}
+	fmt.Println()
+	err = writeRolesDescription(os.Stdout)
+	if err != nil {
+		cli.DieWithError("failed to write usage of the role flag", err)
+	}
 }
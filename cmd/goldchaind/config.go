@@ -1,12 +1,159 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/eventsink"
+	"github.com/nbh-digital/goldchain/pkg/extplugin"
+	"github.com/nbh-digital/goldchain/pkg/peers"
+	"github.com/nbh-digital/goldchain/pkg/relay"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/spf13/pflag"
+	"github.com/threefoldtech/rivine/pkg/client"
 	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
 )
 
 // ExtendedDaemonConfig contains all configurable variables for the deamon.
 type ExtendedDaemonConfig struct {
 	daemon.Config
+
+	// ConfigFile is the path to the YAML file defining the flags not defined on the command line or in the environment,
+	// an empty string uses goldchaind.yaml in the working directory, should it exist
+	ConfigFile string
+
+	// DataDir is the directory storing the data of the daemon as is,
+	// overriding the subdirectory named after the network of the root persistent directory
+	DataDir string
+
+	// Role is the name of the role defining the modules to load,
+	// an empty string loads the modules defined by the modules flag
+	Role string
+
+	// ChainConstantsFile is the path to a JSON file overwriting individual chain constants,
+	// only supported for the devnet, an empty string uses the default constants
+	ChainConstantsFile string
+
+	// RelayMinimumMinerFee is the minimum miner fee (in coins) a transaction
+	// received from a peer has to pay in order to be accepted and relayed,
+	// an empty string disables the fee floor
+	RelayMinimumMinerFee string
+	// RelayMaxArbitraryDataSize is the maximum size in bytes of the arbitrary data of a transaction
+	// received from a peer in order to be accepted and relayed, 0 disables this limit
+	RelayMaxArbitraryDataSize uint64
+	// RelayDustThreshold is the value (in coins) below which a coin output is considered dust,
+	// an empty string disables the dust rules
+	RelayDustThreshold string
+	// RelayDustOutputFee is the additional miner fee (in coins) to be paid for each dust output,
+	// an empty string refuses all transactions creating dust outputs
+	RelayDustOutputFee string
+	// OrphanPoolSize is the maximum amount of transaction sets received before their parents,
+	// which are kept until their parents arrive, 0 disables the orphan pool
+	OrphanPoolSize int
+	// RebroadcastInterval is the interval at which local unconfirmed transaction sets are rebroadcasted,
+	// 0 disables rebroadcasting
+	RebroadcastInterval time.Duration
+
+	// PeerBanScore is the score below which peers are banned, peers losing score
+	// by relaying invalid blocks or transactions, or failing to respond
+	PeerBanScore float64
+	// PeerBanDuration is the duration for which the host of a peer is banned, 0 disables banning
+	PeerBanDuration time.Duration
+	// UploadLimit is the maximum amount of KiB per second sent to all peers together, 0 is unlimited
+	UploadLimit uint64
+	// DownloadLimit is the maximum amount of KiB per second received from all peers together, 0 is unlimited
+	DownloadLimit uint64
+	// PeerMessageRate is the maximum amount of RPCs per second a single peer can call, 0 is unlimited
+	PeerMessageRate float64
+	// NATPMP maps the RPC port on the NAT gateway using NAT-PMP
+	NATPMP bool
+	// NATPMPGateway is the IPv4 address of the NAT-PMP gateway, the default gateway if empty
+	NATPMPGateway string
+
+	// CacheSize is the amount of blocks, coin outputs and blockstake outputs
+	// cached in front of the consensus database for the API, 0 disables caching
+	CacheSize int
+
+	// MultiSigProposals is the maximum amount of multisig transactions for which the daemon collects signatures,
+	// 0 disables the multisig coordination endpoints
+	MultiSigProposals int
+
+	// MaxReorgDepth is the maximum amount of blocks a reorganization can revert,
+	// forks reverting more blocks are refused, 0 allows reorganizations of any depth
+	MaxReorgDepth uint64
+
+	// PluginTimeout is the maximum duration of a single call of a consensus plugin, 0 disables the timeout
+	PluginTimeout time.Duration
+	// DegradePlugins skips the informational consensus plugins which fail, panic or time out,
+	// rather than refusing the block being applied
+	DegradePlugins bool
+	// Plugins are the external plugins launched by the daemon, each defined as <name>=<path of the executable>
+	Plugins []string
+
+	// EventSink is the URL of the broker (nats:// or kafka://) the consensus events are published to,
+	// an empty string disables publishing
+	EventSink string
+	// EventSinkFormat is the serialization format of the published events, json or protobuf
+	EventSinkFormat string
+
+	// ImportFile is the path to a block stream exported using 'goldchainc consensus export',
+	// of which the blocks are validated and imported before syncing with peers
+	ImportFile string
+
+	// NoBlockCreation starts the daemon with block creation disabled,
+	// such that blocks are only created once it is enabled, or on demand on the devnet
+	NoBlockCreation bool
+
+	// AlertRulesFile is the path to a JSON file defining the on-chain activity for which alerts are raised,
+	// an empty string disables alerts
+	AlertRulesFile string
+
+	// RemoteSigner is the API address of the remote signer used to sign transactions,
+	// such that the daemon never holds the seed, an empty string signs using the wallet module
+	RemoteSigner string
+	// RemoteSignerPassword is the API password of the remote signer
+	RemoteSignerPassword string
+
+	// WalletMaxPerTransaction is the maximum amount of coins a single wallet transaction can send,
+	// an empty string disables this limit
+	WalletMaxPerTransaction string
+	// WalletMaxPerDay is the maximum amount of coins the wallet can send within 24 hours,
+	// an empty string disables this limit
+	WalletMaxPerDay string
+	// WalletApprovalThreshold is the amount of coins above which a wallet transaction is only sent
+	// once approved using the approval password, an empty string disables approvals
+	WalletApprovalThreshold string
+	// WalletApprovalPassword is the password required to approve wallet transactions above the approval threshold
+	WalletApprovalPassword string
+
+	// SweepTag is the tag of the wallet addresses swept to cold storage, an empty string disables sweeping
+	SweepTag string
+	// SweepTo is the descriptor of the cold storage condition the tagged addresses are swept to
+	SweepTo string
+	// SweepThreshold is the minimum amount of confirmed coins of a tagged address for it to be swept
+	SweepThreshold string
+
+	// UnlockFrom is the source of the password used to unlock the wallet when the daemon starts,
+	// as parsed by wallet.ParsePasswordSource, an empty string keeps the wallet locked
+	UnlockFrom string
+
+	// APITimeout is the maximum duration of an API request, 0 disables the timeout
+	APITimeout time.Duration
+	// APIRouteTimeouts overwrites the API timeout for all routes starting with the given path prefixes
+	APIRouteTimeouts map[string]string
+
+	// SignResponses signs the responses of the signed routes using the response signing key of the daemon
+	SignResponses bool
+	// SignedRoutes are the path prefixes of the routes of which the responses are signed
+	SignedRoutes []string
 }
 
 // DefaultConfig returns the default daemon configuration
@@ -16,3 +163,339 @@ func DefaultConfig() daemon.Config {
 	cfg.RPCaddr = ":22112"
 	return cfg
 }
+
+// DefaultExtendedConfig returns the default extended daemon configuration
+func DefaultExtendedConfig() ExtendedDaemonConfig {
+	return ExtendedDaemonConfig{
+		Config:              DefaultConfig(),
+		OrphanPoolSize:      100,
+		RebroadcastInterval: 10 * time.Minute,
+		PeerBanScore:        peers.DefaultPolicy().BanScore,
+		PeerBanDuration:     peers.DefaultPolicy().BanDuration,
+		CacheSize:           4096,
+		EventSinkFormat:     string(eventsink.FormatJSON),
+		APITimeout:          time.Minute,
+		APIRouteTimeouts: map[string]string{
+			// streams end when the client stops reading
+			"/consensus/rawblocks": "0",
+		},
+		SignedRoutes: api.DefaultSignedRoutes,
+	}
+}
+
+// RegisterAsFlags registers all properties —for which it makes sense— as a flag,
+// including the properties of the embedded rivine daemon config.
+func (cfg *ExtendedDaemonConfig) RegisterAsFlags(flagSet *pflag.FlagSet) {
+	cfg.Config.RegisterAsFlags(flagSet)
+	cfg.registerDataDirFlag(flagSet)
+
+	flagSet.StringVarP(&cfg.ConfigFile, "config", "", cfg.ConfigFile,
+		fmt.Sprintf("YAML file defining the flags which aren't defined on the command line or as %s<FLAG> or %s<FLAG> environment variable (default %s if it exists)", configEnvPrefix, configSharedEnvPrefix, defaultConfigFile))
+	flagSet.StringVarP(&cfg.APIPassword, "api-password", "", cfg.APIPassword,
+		"API password, required if authenticate-api is set, asked for if not set (preferably defined as environment variable)")
+	flagSet.StringVarP(&cfg.Role, "role", "", cfg.Role,
+		fmt.Sprintf("load the modules of a role instead of those defined by the modules flag, one of: %s", strings.Join(roleNames(), ", ")))
+	flagSet.StringVarP(&cfg.ChainConstantsFile, "constants-file", "", cfg.ChainConstantsFile,
+		"JSON file overwriting the block frequency, maturity delay and/or minimum transaction fee of the devnet")
+	flagSet.StringVarP(&cfg.RelayMinimumMinerFee, "relay-min-fee", "", cfg.RelayMinimumMinerFee,
+		"minimum miner fee (in coins) of transactions received from peers in order to be relayed")
+	flagSet.Uint64VarP(&cfg.RelayMaxArbitraryDataSize, "relay-max-arbitrary-data", "", cfg.RelayMaxArbitraryDataSize,
+		"maximum arbitrary data size (in bytes) of transactions received from peers in order to be relayed")
+	flagSet.StringVarP(&cfg.RelayDustThreshold, "relay-dust-threshold", "", cfg.RelayDustThreshold,
+		"value (in coins) below which coin outputs are considered dust, disabled if empty")
+	flagSet.StringVarP(&cfg.RelayDustOutputFee, "relay-dust-fee", "", cfg.RelayDustOutputFee,
+		"additional miner fee (in coins) per dust output, transactions creating dust are refused if empty")
+	flagSet.IntVarP(&cfg.OrphanPoolSize, "orphan-pool-size", "", cfg.OrphanPoolSize,
+		"maximum amount of transaction sets received before their parents, kept until their parents arrive, 0 disables the orphan pool")
+	flagSet.DurationVarP(&cfg.RebroadcastInterval, "rebroadcast-interval", "", cfg.RebroadcastInterval,
+		"interval at which local unconfirmed transactions are rebroadcasted to all peers, 0 disables rebroadcasting")
+	flagSet.Float64VarP(&cfg.PeerBanScore, "peer-ban-score", "", cfg.PeerBanScore,
+		fmt.Sprintf("score (out of %d) below which peers relaying invalid blocks or transactions, or failing to respond, are banned", peers.MaxScore))
+	flagSet.DurationVarP(&cfg.PeerBanDuration, "peer-ban-duration", "", cfg.PeerBanDuration,
+		"duration for which the host of a peer is banned once its score drops below the ban score, 0 disables banning")
+	flagSet.Uint64VarP(&cfg.UploadLimit, "upload-limit", "", cfg.UploadLimit,
+		"maximum amount of KiB per second sent to all peers together, e.g. while peers sync from this node, 0 is unlimited")
+	flagSet.Uint64VarP(&cfg.DownloadLimit, "download-limit", "", cfg.DownloadLimit,
+		"maximum amount of KiB per second received from all peers together, 0 is unlimited")
+	flagSet.Float64VarP(&cfg.PeerMessageRate, "peer-message-rate", "", cfg.PeerMessageRate,
+		"maximum amount of RPCs per second a single peer can call (up to 10 seconds worth in a burst), 0 is unlimited")
+	flagSet.BoolVarP(&cfg.NATPMP, "nat-pmp", "", cfg.NATPMP,
+		"map the RPC port on the NAT gateway using NAT-PMP, such that peers can connect to this node (UPnP is always tried)")
+	flagSet.StringVarP(&cfg.NATPMPGateway, "nat-pmp-gateway", "", cfg.NATPMPGateway,
+		"IPv4 address of the NAT-PMP gateway, the default gateway if empty")
+	flagSet.IntVarP(&cfg.CacheSize, "cache-size", "", cfg.CacheSize,
+		"amount of blocks and outputs (each) cached in front of the consensus database for the API, 0 disables caching")
+	flagSet.IntVarP(&cfg.MultiSigProposals, "multisig-proposals", "", cfg.MultiSigProposals,
+		"maximum amount of multisig transactions for which signatures are collected, 0 disables the multisig coordination endpoints")
+	flagSet.Uint64VarP(&cfg.MaxReorgDepth, "max-reorg-depth", "", cfg.MaxReorgDepth,
+		"maximum amount of blocks a reorganization can revert, forks reverting more blocks are refused, 0 disables this limit")
+	flagSet.DurationVarP(&cfg.PluginTimeout, "plugin-timeout", "", cfg.PluginTimeout,
+		"maximum duration of a single call of a consensus plugin, slower calls are reported (and degrade informational plugins if enabled), 0 disables the timeout")
+	flagSet.BoolVarP(&cfg.DegradePlugins, "degrade-plugins", "", cfg.DegradePlugins,
+		"disable informational consensus plugins (stakes, ledger, explorer statistics, ...) which fail, panic or time out, rather than refusing the block")
+	flagSet.StringArrayVarP(&cfg.Plugins, "plugin", "", cfg.Plugins,
+		"launch the external plugin <name>=<path of the executable>, fed all consensus changes (can be repeated)")
+	flagSet.StringVarP(&cfg.EventSink, "event-sink", "", cfg.EventSink,
+		"publish all consensus events to NATS (nats://[user:pass@]host[:port][/subject]) or Kafka (kafka://host[:port]/<topic>)")
+	flagSet.StringVarP(&cfg.EventSinkFormat, "event-sink-format", "", cfg.EventSinkFormat,
+		"serialization format of the events published to the event sink: json or protobuf")
+	flagSet.StringVarP(&cfg.ImportFile, "import", "", cfg.ImportFile,
+		"validate and import the blocks of a file exported using 'goldchainc consensus export' before syncing with peers")
+	flagSet.BoolVarP(&cfg.NoBlockCreation, "no-block-creation", "", cfg.NoBlockCreation,
+		"start with block creation disabled, until enabled using 'goldchainc blockcreator start' (on the devnet blocks can be created on demand using POST /dev/mine)")
+	flagSet.StringVarP(&cfg.AlertRulesFile, "alerts-file", "", cfg.AlertRulesFile,
+		"JSON file defining the unusual on-chain activity (large transactions, mints, auth changes) for which alerts are raised")
+	flagSet.StringVarP(&cfg.RemoteSigner, "remote-signer", "", cfg.RemoteSigner,
+		"API address of the remote signer (goldchainsigner) used to sign wallet transactions, instead of the wallet seed "+
+			"(https://<host>:<port> should it serve its API over TLS)")
+	flagSet.StringVarP(&cfg.RemoteSignerPassword, "remote-signer-password", "", cfg.RemoteSignerPassword,
+		"API password of the remote signer, asked for if not set")
+	flagSet.StringVarP(&cfg.WalletMaxPerTransaction, "wallet-max-per-tx", "", cfg.WalletMaxPerTransaction,
+		"maximum amount of coins a single wallet transaction can send to addresses other than those of the wallet, disabled if empty")
+	flagSet.StringVarP(&cfg.WalletMaxPerDay, "wallet-max-per-day", "", cfg.WalletMaxPerDay,
+		"maximum amount of coins the wallet can send to addresses other than its own within 24 hours, disabled if empty")
+	flagSet.StringVarP(&cfg.WalletApprovalThreshold, "wallet-approval-threshold", "", cfg.WalletApprovalThreshold,
+		"amount of coins above which wallet transactions are queued until approved using the approval password, disabled if empty")
+	flagSet.StringVarP(&cfg.WalletApprovalPassword, "wallet-approval-password", "", cfg.WalletApprovalPassword,
+		"password required to approve queued wallet transactions, asked for if the approval threshold is set")
+	flagSet.StringVarP(&cfg.SweepTag, "sweep-tag", "", cfg.SweepTag,
+		"tag of the wallet addresses of which the confirmed coins are swept to cold storage, such as deposit, disabled if empty")
+	flagSet.StringVarP(&cfg.SweepTo, "sweep-to", "", cfg.SweepTo,
+		"descriptor of the cold storage condition the tagged addresses are swept to, such as addr(<address>) or multi(2,<address>,<address>,<address>)")
+	flagSet.StringVarP(&cfg.SweepThreshold, "sweep-threshold", "", cfg.SweepThreshold,
+		"minimum amount of confirmed coins of a tagged address for it to be swept")
+	flagSet.StringVarP(&cfg.UnlockFrom, "unlock-from", "", cfg.UnlockFrom,
+		"unlock the wallet on start using the password read from file:<path>, credential:<systemd credential>, env:<variable> or exec:<command>")
+	flagSet.DurationVarP(&cfg.APITimeout, "api-timeout", "", cfg.APITimeout,
+		"maximum duration of an API request, 0 disables the timeout")
+	flagSet.StringToStringVarP(&cfg.APIRouteTimeouts, "api-route-timeouts", "", cfg.APIRouteTimeouts,
+		"API timeouts of all routes starting with a given path prefix, overwriting the api-timeout (e.g. /explorer=10s,/wallet=0)")
+	flagSet.BoolVarP(&cfg.SignResponses, "sign-responses", "", cfg.SignResponses,
+		"sign the responses of the signed routes using the response signing key of the daemon, as a Response-Signature header")
+	flagSet.StringSliceVarP(&cfg.SignedRoutes, "signed-routes", "", cfg.SignedRoutes,
+		"path prefixes of the routes of which the responses are signed when sign-responses is enabled")
+}
+
+// registerDataDirFlag registers the flag overriding the data directory of the network.
+func (cfg *ExtendedDaemonConfig) registerDataDirFlag(flagSet *pflag.FlagSet) {
+	flagSet.StringVarP(&cfg.DataDir, "datadir", "", cfg.DataDir,
+		"directory storing the data of the daemon as is, instead of the subdirectory named after the network of the persistent directory")
+}
+
+// NetworkDir returns the directory storing the data of the daemon for the configured network:
+// the data directory should it be defined, or the subdirectory named after the network of the root persistent directory otherwise,
+// such that the data of different networks is never mixed by default.
+func (cfg *ExtendedDaemonConfig) NetworkDir() string {
+	if cfg.DataDir != "" {
+		return cfg.DataDir
+	}
+	return filepath.Join(cfg.RootPersistentDir, cfg.BlockchainInfo.NetworkName)
+}
+
+// Validate the extended daemon config,
+// returning an error for any property that cannot be used.
+func (cfg *ExtendedDaemonConfig) Validate() error {
+	if cfg.Role != "" {
+		if _, err := roleModules(cfg.Role); err != nil {
+			return err
+		}
+	}
+	if cfg.ChainConstantsFile != "" && cfg.BlockchainInfo.NetworkName != config.NetworkNameDev {
+		return fmt.Errorf("chain constants can only be overwritten for the %s, not for the %s", config.NetworkNameDev, cfg.BlockchainInfo.NetworkName)
+	}
+	if cfg.OrphanPoolSize < 0 {
+		return fmt.Errorf("invalid orphan pool size %d", cfg.OrphanPoolSize)
+	}
+	if cfg.RebroadcastInterval < 0 {
+		return fmt.Errorf("invalid rebroadcast interval %v", cfg.RebroadcastInterval)
+	}
+	if cfg.PeerBanScore >= peers.MaxScore {
+		return fmt.Errorf("invalid peer ban score %v: should be lower than %d", cfg.PeerBanScore, peers.MaxScore)
+	}
+	if cfg.PeerBanDuration < 0 {
+		return fmt.Errorf("invalid peer ban duration %v", cfg.PeerBanDuration)
+	}
+	if cfg.PeerMessageRate < 0 {
+		return fmt.Errorf("invalid peer message rate %v", cfg.PeerMessageRate)
+	}
+	if cfg.NATPMPGateway != "" {
+		if !cfg.NATPMP {
+			return errors.New("the NAT-PMP gateway requires NAT-PMP to be enabled")
+		}
+		if ip := net.ParseIP(cfg.NATPMPGateway); ip == nil || ip.To4() == nil {
+			return fmt.Errorf("invalid NAT-PMP gateway %q: should be an IPv4 address", cfg.NATPMPGateway)
+		}
+	}
+	if cfg.PluginTimeout < 0 {
+		return fmt.Errorf("invalid plugin timeout %v", cfg.PluginTimeout)
+	}
+	if _, err := cfg.externalPlugins(); err != nil {
+		return err
+	}
+	if sink := cfg.eventSink(); sink != nil {
+		if err := sink.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.MultiSigProposals < 0 {
+		return fmt.Errorf("invalid maximum amount of multisig proposals %d", cfg.MultiSigProposals)
+	}
+	if _, err := cfg.apiTimeouts(); err != nil {
+		return err
+	}
+	for _, route := range cfg.SignedRoutes {
+		if !strings.HasPrefix(route, "/") {
+			return fmt.Errorf("invalid signed route %q: should start with /", route)
+		}
+	}
+	if _, err := cfg.relayPolicy(); err != nil {
+		return fmt.Errorf("invalid relay policy: %v", err)
+	}
+	if _, err := cfg.spendPolicy(); err != nil {
+		return fmt.Errorf("invalid wallet spend policy: %v", err)
+	}
+	if _, err := cfg.sweepPolicy(); err != nil {
+		return fmt.Errorf("invalid wallet sweep policy: %v", err)
+	}
+	return nil
+}
+
+// apiTimeouts returns the configured API timeouts.
+func (cfg *ExtendedDaemonConfig) apiTimeouts() (api.RouteTimeouts, error) {
+	routes, err := api.ParseRouteTimeouts(cfg.APIRouteTimeouts)
+	if err != nil {
+		return api.RouteTimeouts{}, fmt.Errorf("invalid API route timeouts: %v", err)
+	}
+	return api.RouteTimeouts{
+		Default: cfg.APITimeout,
+		Routes:  routes,
+	}, nil
+}
+
+// externalPlugins returns the configuration of the external plugins, as defined by the plugin flags.
+func (cfg *ExtendedDaemonConfig) externalPlugins() ([]extplugin.Config, error) {
+	plugins := make([]extplugin.Config, 0, len(cfg.Plugins))
+	names := make(map[string]struct{}, len(cfg.Plugins))
+	for _, plugin := range cfg.Plugins {
+		parts := strings.SplitN(plugin, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid plugin %q, expected <name>=<path of the executable>", plugin)
+		}
+		if _, ok := names[parts[0]]; ok {
+			return nil, fmt.Errorf("plugin %q is defined more than once", parts[0])
+		}
+		names[parts[0]] = struct{}{}
+		plugins = append(plugins, extplugin.Config{
+			Name:        parts[0],
+			Path:        parts[1],
+			CallTimeout: cfg.PluginTimeout,
+		})
+	}
+	return plugins, nil
+}
+
+// eventSink returns the configured event sink, nil if none is configured.
+func (cfg *ExtendedDaemonConfig) eventSink() *eventsink.Config {
+	if cfg.EventSink == "" {
+		return nil
+	}
+	return &eventsink.Config{
+		URL:    cfg.EventSink,
+		Format: eventsink.Format(cfg.EventSinkFormat),
+	}
+}
+
+// relayPolicy creates the relay policy as configured,
+// using the currency units of the configured network to parse the configured coin values.
+func (cfg *ExtendedDaemonConfig) relayPolicy() (relay.Policy, error) {
+	policy := relay.Policy{
+		MaxArbitraryDataSize: cfg.RelayMaxArbitraryDataSize,
+	}
+	nd, err := config.GetNetworkDescriptor(cfg.BlockchainInfo.NetworkName)
+	if err != nil {
+		return relay.Policy{}, err
+	}
+	cc := client.NewCurrencyConvertor(nd.CurrencyUnits(), cfg.BlockchainInfo.CoinUnit)
+	for _, value := range []struct {
+		name   string
+		str    string
+		target *types.Currency
+	}{
+		{"minimum miner fee", cfg.RelayMinimumMinerFee, &policy.MinimumMinerFee},
+		{"dust threshold", cfg.RelayDustThreshold, &policy.DustThreshold},
+		{"dust output fee", cfg.RelayDustOutputFee, &policy.DustOutputFee},
+	} {
+		if value.str == "" {
+			continue
+		}
+		c, err := cc.ParseCoinString(value.str)
+		if err != nil {
+			return relay.Policy{}, fmt.Errorf("invalid relay %s %q: %v", value.name, value.str, err)
+		}
+		*value.target = c
+	}
+	return policy, nil
+}
+
+// spendPolicy creates the wallet spend policy as configured,
+// using the currency units of the configured network to parse the configured coin values.
+func (cfg *ExtendedDaemonConfig) spendPolicy() (wallet.SpendPolicy, error) {
+	var policy wallet.SpendPolicy
+	nd, err := config.GetNetworkDescriptor(cfg.BlockchainInfo.NetworkName)
+	if err != nil {
+		return wallet.SpendPolicy{}, err
+	}
+	cc := client.NewCurrencyConvertor(nd.CurrencyUnits(), cfg.BlockchainInfo.CoinUnit)
+	for _, value := range []struct {
+		name   string
+		str    string
+		target *types.Currency
+	}{
+		{"maximum per transaction", cfg.WalletMaxPerTransaction, &policy.MaxPerTransaction},
+		{"maximum per day", cfg.WalletMaxPerDay, &policy.MaxPerDay},
+		{"approval threshold", cfg.WalletApprovalThreshold, &policy.ApprovalThreshold},
+	} {
+		if value.str == "" {
+			continue
+		}
+		c, err := cc.ParseCoinString(value.str)
+		if err != nil {
+			return wallet.SpendPolicy{}, fmt.Errorf("invalid %s %q: %v", value.name, value.str, err)
+		}
+		*value.target = c
+	}
+	return policy, nil
+}
+
+// sweepPolicy creates the wallet sweep policy as configured, nil if sweeping is disabled,
+// using the currency units of the configured network to parse the threshold.
+func (cfg *ExtendedDaemonConfig) sweepPolicy() (*wallet.SweepPolicy, error) {
+	if cfg.SweepTag == "" {
+		if cfg.SweepTo != "" || cfg.SweepThreshold != "" {
+			return nil, errors.New("a sweep tag is required to sweep to cold storage")
+		}
+		return nil, nil
+	}
+	policy := wallet.SweepPolicy{Tag: cfg.SweepTag}
+	var err error
+	policy.Condition, err = goldchaintypes.ParseConditionDescriptor(cfg.SweepTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cold storage condition %q: %v", cfg.SweepTo, err)
+	}
+	if cfg.SweepThreshold != "" {
+		nd, err := config.GetNetworkDescriptor(cfg.BlockchainInfo.NetworkName)
+		if err != nil {
+			return nil, err
+		}
+		cc := client.NewCurrencyConvertor(nd.CurrencyUnits(), cfg.BlockchainInfo.CoinUnit)
+		policy.Threshold, err = cc.ParseCoinString(cfg.SweepThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold %q: %v", cfg.SweepThreshold, err)
+		}
+	}
+	err = policy.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
@@ -0,0 +1,442 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/threefoldtech/rivine/pkg/cli"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// configEnvPrefix prefixes the environment variables defining flags,
+	// e.g. GOLDCHAIND_API_ADDR defines the api-addr flag
+	configEnvPrefix = "GOLDCHAIND_"
+	// configSharedEnvPrefix prefixes the environment variables defining flags shared with the faucet,
+	// e.g. GOLDCHAIN_NETWORK, used unless the variable prefixed with configEnvPrefix is defined
+	configSharedEnvPrefix = "GOLDCHAIN_"
+	// defaultConfigFile is the configuration file used if it exists in the working directory,
+	// and no other configuration file is defined
+	defaultConfigFile = "goldchaind.yaml"
+)
+
+// configSource is the source defining the value of a flag, ordered by precedence.
+type configSource int
+
+// configuration sources, the latter taking precedence over the former
+const (
+	configSourceDefault configSource = iota
+	configSourceFile
+	configSourceEnv
+	configSourceFlag
+)
+
+func (source configSource) String() string {
+	switch source {
+	case configSourceDefault:
+		return "default"
+	case configSourceFile:
+		return "file"
+	case configSourceEnv:
+		return "env"
+	case configSourceFlag:
+		return "flag"
+	default:
+		return "unknown"
+	}
+}
+
+// configEnvNames returns the names of the environment variables defining the given flag, ordered by precedence.
+func configEnvNames(flagName string) []string {
+	name := strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+	return []string{configEnvPrefix + name, configSharedEnvPrefix + name}
+}
+
+// lookupConfigEnv returns the value of the first environment variable defining the given flag.
+func lookupConfigEnv(flagName string) (name, value string, ok bool) {
+	for _, name = range configEnvNames(flagName) {
+		if value, ok = os.LookupEnv(name); ok {
+			return
+		}
+	}
+	return "", "", false
+}
+
+// secretConfigFlags are the flags defining a secret, of which the value is redacted by config dump.
+// A flag defining a secret has to be added here, as flags are never redacted based on their name.
+var secretConfigFlags = map[string]bool{
+	"api-password":             true,
+	"remote-signer-password":   true,
+	"wallet-approval-password": true,
+}
+
+// credentialURLConfigFlags are the flags defining a URL which can contain credentials,
+// such as the user and password or token of a NATS server, which are redacted by config dump.
+var credentialURLConfigFlags = map[string]bool{
+	"event-sink": true,
+}
+
+// isListFlag returns true for the flags which can be repeated, each value being appended.
+func isListFlag(flag *pflag.Flag) bool {
+	switch flag.Value.Type() {
+	case "stringArray", "stringSlice", "NetAddressArray":
+		return true
+	default:
+		return false
+	}
+}
+
+// isConfigurableFlag returns false for the flags which can only be defined on the command line.
+func isConfigurableFlag(flag *pflag.Flag) bool {
+	return flag.Name != "help"
+}
+
+// loadConfig defines the flags of the command which aren't defined on the command line,
+// using the environment variables and configuration file, and resolves the flags which cannot be combined.
+// The configuration is only loaded once, such that commands can start the daemon as the root command does.
+func (cmds *commands) loadConfig(cmd *cobra.Command) {
+	if cmds.configSources != nil {
+		return
+	}
+	sources, err := loadConfigSources(cmd.Flags())
+	if err != nil {
+		cli.DieWithError("failed to configure daemon", err)
+	}
+	cmds.configSources = sources
+	err = cmds.resolveConfigConflicts()
+	if err != nil {
+		cli.DieWithError("failed to configure daemon", err)
+	}
+}
+
+// loadConfigSources defines the flags which aren't defined on the command line, using their environment variable,
+// or else using the configuration file, returning the source of every flag.
+func loadConfigSources(flagSet *pflag.FlagSet) (map[string]configSource, error) {
+	sources := make(map[string]configSource)
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if flag.Changed {
+			sources[flag.Name] = configSourceFlag
+		}
+	})
+
+	var err error
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if err != nil || sources[flag.Name] != configSourceDefault || !isConfigurableFlag(flag) {
+			return
+		}
+		name, value, ok := lookupConfigEnv(flag.Name)
+		if !ok {
+			return
+		}
+		values := []string{value}
+		if isListFlag(flag) {
+			values = strings.Split(value, ",")
+		}
+		if err = setFlag(flagSet, flag, values); err != nil {
+			err = fmt.Errorf("invalid environment variable %s: %v", name, err)
+			return
+		}
+		sources[flag.Name] = configSourceEnv
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the configuration file can be defined using a flag or environment variable only
+	configFlag := flagSet.Lookup("config")
+	path := configFlag.Value.String()
+	if path == "" {
+		if _, err = os.Stat(defaultConfigFile); err != nil {
+			return sources, nil
+		}
+		path = defaultConfigFile
+		configFlag.Value.Set(path)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %v", err)
+	}
+	err = applyConfigFile(flagSet, data, sources)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	return sources, nil
+}
+
+// configValue is a value of the configuration file, a scalar, sequence or mapping.
+// Scalars are kept as written rather than as the type YAML resolves them to, as they are parsed by the flags,
+// null values being empty scalars.
+type configValue struct {
+	kind   configValueKind
+	scalar string
+	items  []configValue
+	fields map[string]configValue
+}
+
+// configValueKind is the kind of a configuration value.
+type configValueKind int
+
+// configuration value kinds
+const (
+	configScalar configValueKind = iota
+	configSequence
+	configMapping
+)
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (value *configValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw interface{}
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+	switch raw.(type) {
+	case []interface{}:
+		value.kind = configSequence
+		return unmarshal(&value.items)
+	case map[interface{}]interface{}:
+		value.kind = configMapping
+		return unmarshal(&value.fields)
+	default:
+		value.kind = configScalar
+		return unmarshal(&value.scalar)
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler,
+// used by yaml.v2 for the quoted "null" and "~" scalars, which aren't passed to UnmarshalYAML.
+func (value *configValue) UnmarshalText(text []byte) error {
+	*value = configValue{kind: configScalar, scalar: string(text)}
+	return nil
+}
+
+// sortedConfigKeys returns the keys of the given mapping, sorted.
+func sortedConfigKeys(fields map[string]configValue) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyConfigFile defines the flags not defined by any other source using the given YAML document,
+// a mapping of flag names to their value.
+func applyConfigFile(flagSet *pflag.FlagSet, data []byte, sources map[string]configSource) error {
+	var doc configValue
+	err := yaml.UnmarshalStrict(data, &doc)
+	if err != nil {
+		return err
+	}
+	// empty documents are decoded as an empty scalar
+	if doc.kind == configSequence || doc.scalar != "" {
+		return errors.New("expected a mapping of flag names to their value")
+	}
+	return applyConfigFields(flagSet, doc.fields, "", sources)
+}
+
+// applyConfigFields defines the flags named after the keys of the given mapping, prefixed with the given prefix.
+// Mappings nest flags sharing a prefix, e.g. relay: {min-fee: 1} defines the relay-min-fee flag.
+func applyConfigFields(flagSet *pflag.FlagSet, fields map[string]configValue, prefix string, sources map[string]configSource) error {
+	for _, key := range sortedConfigKeys(fields) {
+		name, value := prefix+key, fields[key]
+		flag := flagSet.Lookup(name)
+		if flag == nil {
+			if value.kind == configMapping {
+				if err := applyConfigFields(flagSet, value.fields, name+"-", sources); err != nil {
+					return err
+				}
+				continue
+			}
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if !isConfigurableFlag(flag) || flag.Name == "config" {
+			return fmt.Errorf("flag %q cannot be defined in the configuration file", name)
+		}
+		var values []string
+		switch value.kind {
+		case configScalar:
+			values = []string{value.scalar}
+		case configSequence:
+			if !isListFlag(flag) {
+				return fmt.Errorf("flag %q takes a single value, not a sequence", name)
+			}
+			for _, item := range value.items {
+				if item.kind != configScalar {
+					return fmt.Errorf("flag %q takes a sequence of scalars", name)
+				}
+				values = append(values, item.scalar)
+			}
+		case configMapping:
+			if flag.Value.Type() != "stringToString" {
+				return fmt.Errorf("flag %q takes a single value, not a mapping", name)
+			}
+			for _, key := range sortedConfigKeys(value.fields) {
+				if value.fields[key].kind != configScalar {
+					return fmt.Errorf("flag %q takes a mapping of scalars", name)
+				}
+				values = append(values, key+"="+value.fields[key].scalar)
+			}
+		}
+		if sources[name] != configSourceDefault {
+			// defined by a source taking precedence
+			continue
+		}
+		if err := setFlag(flagSet, flag, values); err != nil {
+			return err
+		}
+		sources[name] = configSourceFile
+	}
+	return nil
+}
+
+// setFlag sets the given values of the flag, as if the flag was repeated for each value.
+func setFlag(flagSet *pflag.FlagSet, flag *pflag.Flag, values []string) error {
+	for _, value := range values {
+		if err := flagSet.Set(flag.Name, value); err != nil {
+			return fmt.Errorf("invalid value %q for flag %q: %v", value, flag.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveConfigConflicts resolves the flags which cannot be combined, the source taking precedence defining the configuration,
+// unless both flags are defined by the same source.
+func (cmds *commands) resolveConfigConflicts() error {
+	for _, conflict := range []struct {
+		name, other string
+		// value is reset should the other flag take precedence, an empty value not conflicting
+		value *string
+	}{
+		{"role", "modules", &cmds.cfg.Role},
+		{"datadir", "persistent-directory", &cmds.cfg.DataDir},
+	} {
+		source, other := cmds.configSources[conflict.name], cmds.configSources[conflict.other]
+		if *conflict.value == "" || source == configSourceDefault || other == configSourceDefault {
+			continue
+		}
+		if source == other {
+			return fmt.Errorf("the %s and %s flags cannot be combined (both defined by %s)", conflict.name, conflict.other, source)
+		}
+		if other > source {
+			*conflict.value = ""
+			cmds.configSources[conflict.name] = configSourceDefault
+		}
+	}
+	return nil
+}
+
+// configDumpCommand prints the effective configuration of the daemon, as a configuration file.
+func (cmds *commands) configDumpCommand(cmd *cobra.Command, _ []string) {
+	cmds.loadConfig(cmd)
+	err := writeConfig(os.Stdout, cmd.Flags(), cmds.configSources)
+	if err != nil {
+		cli.DieWithError("failed to print configuration", err)
+	}
+}
+
+// writeConfig writes the value of all configurable flags as a configuration file, commenting the source of each value.
+// The values of the secretConfigFlags, and the credentials of the credentialURLConfigFlags, are redacted.
+func writeConfig(w io.Writer, flagSet *pflag.FlagSet, sources map[string]configSource) error {
+	var lines []string
+	if path := flagSet.Lookup("config").Value.String(); path != "" {
+		lines = append(lines, "# configuration file: "+path)
+	} else {
+		lines = append(lines, "# no configuration file")
+	}
+	lines = append(lines, "# precedence: flag > env ("+configEnvPrefix+"<FLAG>, "+configSharedEnvPrefix+"<FLAG>) > file > default")
+	flagSet.VisitAll(func(flag *pflag.Flag) {
+		if !isConfigurableFlag(flag) || flag.Name == "config" {
+			return
+		}
+		comment := " # " + sources[flag.Name].String()
+		key := flag.Name + ":"
+		switch {
+		case secretConfigFlags[flag.Name]:
+			value := `""`
+			if flag.Value.String() != "" {
+				value = quoteConfigValue("<redacted>")
+			}
+			lines = append(lines, key+" "+value+comment)
+		case credentialURLConfigFlags[flag.Name]:
+			lines = append(lines, key+" "+quoteConfigValue(redactURLCredentials(flag.Value.String()))+comment)
+		case isListFlag(flag):
+			values := listFlagValues(flagSet, flag)
+			if len(values) == 0 {
+				lines = append(lines, key+" []"+comment)
+				return
+			}
+			lines = append(lines, key+comment)
+			for _, value := range values {
+				lines = append(lines, "  - "+quoteConfigValue(value))
+			}
+		case flag.Value.Type() == "stringToString":
+			values, _ := flagSet.GetStringToString(flag.Name)
+			if len(values) == 0 {
+				lines = append(lines, key+" {}"+comment)
+				return
+			}
+			lines = append(lines, key+comment)
+			keys := make([]string, 0, len(values))
+			for key := range values {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				lines = append(lines, "  "+quoteConfigValue(key)+": "+quoteConfigValue(values[key]))
+			}
+		default:
+			lines = append(lines, key+" "+quoteConfigValue(flag.Value.String())+comment)
+		}
+	})
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// quoteConfigValue returns the given string as a YAML scalar on a single line, quoted if needed.
+func quoteConfigValue(str string) string {
+	data, err := yaml.Marshal(str)
+	if quoted := strings.TrimSuffix(string(data), "\n"); err == nil && !strings.Contains(quoted, "\n") {
+		return quoted
+	}
+	// long strings are folded over multiple lines, double-quoted YAML scalars using the escapes Go does
+	return strconv.Quote(str)
+}
+
+// redactURLCredentials returns the given URL with its user information redacted,
+// the whole value being redacted should it not be a valid URL.
+func redactURLCredentials(value string) string {
+	u, err := url.Parse(value)
+	if err != nil {
+		return "<redacted>"
+	}
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+	return u.String()
+}
+
+// listFlagValues returns the values of a flag which can be repeated.
+func listFlagValues(flagSet *pflag.FlagSet, flag *pflag.Flag) []string {
+	switch flag.Value.Type() {
+	case "stringArray":
+		values, _ := flagSet.GetStringArray(flag.Name)
+		return values
+	case "stringSlice":
+		values, _ := flagSet.GetStringSlice(flag.Name)
+		return values
+	default:
+		if str := flag.Value.String(); str != "" {
+			return strings.Split(str, ",")
+		}
+		return nil
+	}
+}
@@ -14,7 +14,7 @@ import (
 func main() {
 	var cmds commands
 	// load default config to start with
-	cmds.cfg.Config = DefaultConfig()
+	cmds.cfg = DefaultExtendedConfig()
 	cmds.cfg.BlockchainInfo = config.GetBlockchainInfo()
 
 	// load default config flag
@@ -44,11 +44,58 @@ func main() {
 
 	rootCommand.AddCommand(&cobra.Command{
 		Use:   "modules",
-		Short: "List available modules for use with -M, --modules flag",
-		Long:  "List available modules for use with -M, --modules flag and their uses",
+		Short: "List available modules and roles for use with the -M, --modules and --role flags",
+		Long:  "List available modules for use with -M, --modules flag and their uses, as well as the roles combining them",
 		Run:   cmds.modulesCommand,
 	})
 
+	bootstrapCommand := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Install a consensus snapshot and start the daemon",
+		Long: `Download, verify and install the consensus snapshot at the given URL, and start the daemon,
+which syncs the blocks created since the snapshot was taken, rather than syncing from the genesis block.
+The snapshot has to be embedded in the release, signed by the foundation or pinned using the hash flag.
+Should the daemon have a consensus directory already, the daemon is started without installing the snapshot,
+such that containers redeployed with the same volume can use the same command.
+All flags of the daemon are supported.`,
+		Args: cobra.NoArgs,
+		Run:  cmds.bootstrapCommand,
+	}
+	cmds.cfg.RegisterAsFlags(bootstrapCommand.Flags())
+	cmds.moduleSetFlag.RegisterFlag(bootstrapCommand.Flags(), fmt.Sprintf("%s modules", os.Args[0]))
+	cmds.registerBootstrapFlags(bootstrapCommand)
+	rootCommand.AddCommand(bootstrapCommand)
+
+	configCommand := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration of the daemon",
+	}
+	configDumpCommand := &cobra.Command{
+		Use:   "dump",
+		Short: "Print the effective configuration of the daemon",
+		Long: `Print the effective configuration of the daemon as a configuration file, commenting the source of each value.
+Flags take precedence over environment variables, which take precedence over the configuration file.
+All flags of the daemon are supported, such that the configuration of a command line can be inspected.`,
+		Args: cobra.NoArgs,
+		Run:  cmds.configDumpCommand,
+	}
+	cmds.cfg.RegisterAsFlags(configDumpCommand.Flags())
+	cmds.moduleSetFlag.RegisterFlag(configDumpCommand.Flags(), fmt.Sprintf("%s modules", os.Args[0]))
+	configCommand.AddCommand(configDumpCommand)
+	rootCommand.AddCommand(configCommand)
+
+	snapshotCommand := &cobra.Command{
+		Use:   "snapshot <file>",
+		Short: "Create a consensus snapshot",
+		Long: `Create a snapshot of the consensus directory of the daemon, to be installed by other daemons using 'bootstrap'.
+The daemon has to be stopped while the snapshot is created. Using a remote signer (goldchainsigner) holding the key
+of the foundation address, the snapshot is signed as well, writing its signature to the file suffixed with .sig.`,
+		Args: cobra.ExactArgs(1),
+		Run:  cmds.snapshotCommand,
+	}
+	cmds.registerSnapshotFlags(snapshotCommand)
+	rootCommand.AddCommand(snapshotCommand)
+
 	// Parse cmdline flags, overwriting both the default values and the config
 	// file values.
 	if err := rootCommand.Execute(); err != nil {
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/threefoldtech/rivine/pkg/daemon"
+)
+
+// moduleRole is the named set of modules of a common role of a daemon,
+// selected using the --role flag.
+type moduleRole struct {
+	Name        string
+	Description string
+	Modules     []*daemon.Module
+}
+
+// moduleRoles are all roles which can be selected using the --role flag.
+var moduleRoles = []moduleRole{
+	{
+		Name: "explorer",
+		Description: "headless explorer node, serving the chain and explorer API without transaction pool or wallet, " +
+			"e.g. as backend of a public explorer",
+		Modules: []*daemon.Module{daemon.GatewayModule, daemon.ConsensusSetModule, daemon.ExplorerModule},
+	},
+	{
+		Name: "relay",
+		Description: "relay node, validating and relaying blocks and transactions without wallet, " +
+			"e.g. as public API node or bootstrap peer",
+		Modules: []*daemon.Module{daemon.GatewayModule, daemon.ConsensusSetModule, daemon.TransactionPoolModule},
+	},
+	{
+		Name:        "wallet",
+		Description: "full wallet node, able to send transactions and create blocks",
+		Modules: []*daemon.Module{daemon.GatewayModule, daemon.ConsensusSetModule, daemon.TransactionPoolModule,
+			daemon.WalletModule, daemon.BlockCreatorModule},
+	},
+}
+
+// roleNames returns the names of all roles.
+func roleNames() []string {
+	names := make([]string, 0, len(moduleRoles))
+	for _, role := range moduleRoles {
+		names = append(names, role.Name)
+	}
+	return names
+}
+
+// roleModules returns the identifiers of the modules of the role with the given name.
+func roleModules(name string) (daemon.ModuleIdentifierSet, error) {
+	for _, role := range moduleRoles {
+		if role.Name != name {
+			continue
+		}
+		var ids []daemon.ModuleIdentifier
+		for _, mod := range role.Modules {
+			ids = append(ids, mod.Identifier())
+		}
+		return daemon.NewIdentifierSet(ids...)
+	}
+	return daemon.ModuleIdentifierSet{}, fmt.Errorf("unknown role %q, it has to be one of: %s",
+		name, strings.Join(roleNames(), ", "))
+}
+
+// writeRolesDescription writes the description of all roles to the given writer.
+func writeRolesDescription(w io.Writer) error {
+	_, err := fmt.Fprintln(w, "Roles, for use with the --role flag instead of the -M, --modules flag:")
+	if err != nil {
+		return err
+	}
+	for _, role := range moduleRoles {
+		ids, err := roleModules(role.Name)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "  %-10s (%s) %s\n", role.Name, ids.String(), role.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
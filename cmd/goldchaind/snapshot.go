@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/nbh-digital/goldchain/pkg/snapshot"
+	"github.com/spf13/cobra"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/cli"
+)
+
+// bootstrapConfig configures the snapshot installed by the bootstrap command.
+type bootstrapConfig struct {
+	url, signatureURL, hash string
+}
+
+// snapshotConfig configures the snapshot created by the snapshot command.
+type snapshotConfig struct {
+	signer, signerPassword string
+}
+
+// registerBootstrapFlags registers the flags of the bootstrap command, next to those of the daemon.
+func (cmds *commands) registerBootstrapFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&cmds.bootstrapCfg.url, "from-url", "",
+		"URL of the consensus snapshot to install (required)")
+	cmd.Flags().StringVar(&cmds.bootstrapCfg.signatureURL, "signature-url", "",
+		"URL of the foundation signature of the snapshot, defaults to the snapshot URL suffixed with .sig")
+	cmd.Flags().StringVar(&cmds.bootstrapCfg.hash, "hash", "",
+		"hash of the snapshot to trust, next to the snapshots embedded in the release or signed by the foundation")
+}
+
+// registerSnapshotFlags registers the flags of the snapshot command.
+func (cmds *commands) registerSnapshotFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&cmds.cfg.RootPersistentDir, "persistent-directory", "d", cmds.cfg.RootPersistentDir,
+		"location of the root directory used to store persistent data of the (stopped) daemon")
+	cmds.cfg.registerDataDirFlag(cmd.Flags())
+	cmd.Flags().StringVarP(&cmds.cfg.BlockchainInfo.NetworkName, "network", "n", cmds.cfg.BlockchainInfo.NetworkName,
+		"the name of the network of which the snapshot is created")
+	cmd.Flags().StringVar(&cmds.snapshotCfg.signer, "signer", "",
+		"API address of the remote signer holding the key of the foundation address, the snapshot is signed if defined")
+	cmd.Flags().StringVar(&cmds.snapshotCfg.signerPassword, "signer-password", "",
+		"API password of the remote signer, asked for if not set")
+}
+
+// bootstrapCommand installs the consensus snapshot at the configured URL, unless the daemon has a consensus directory already,
+// after which the daemon is started as usual, syncing the blocks created since the snapshot was taken.
+func (cmds *commands) bootstrapCommand(cmd *cobra.Command, _ []string) {
+	cmds.loadConfig(cmd)
+	if cmds.bootstrapCfg.url == "" {
+		cli.Die("the URL of the snapshot is required (--from-url)")
+	}
+	trust, err := snapshotTrust(cmds.cfg.BlockchainInfo.NetworkName)
+	if err != nil {
+		cli.DieWithError("failed to configure daemon", err)
+	}
+	if cmds.bootstrapCfg.hash != "" {
+		var hash crypto.Hash
+		err = hash.LoadString(cmds.bootstrapCfg.hash)
+		if err != nil {
+			cli.DieWithError("invalid snapshot hash", err)
+		}
+		trust.Hashes = append(trust.Hashes, hash)
+	}
+
+	dir := filepath.Join(cmds.cfg.NetworkDir(), modules.ConsensusDir)
+	if _, err = os.Stat(dir); err == nil {
+		// containers redeployed with the same volume continue syncing instead
+		fmt.Println("Consensus directory exists already, not installing the snapshot")
+	} else {
+		err = installSnapshot(cmds.bootstrapCfg, trust, dir)
+		if err != nil {
+			cli.DieWithError("failed to bootstrap the daemon", err)
+		}
+	}
+	cmds.rootCommand(cmd, nil)
+}
+
+// installSnapshot downloads the configured snapshot next to the given consensus directory,
+// and installs it in that directory once verified.
+func installSnapshot(cfg bootstrapConfig, trust snapshot.Trust, dir string) error {
+	err := os.MkdirAll(filepath.Dir(dir), 0700)
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile(filepath.Dir(dir), "snapshot-*.tar")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	ctx := context.Background()
+	fmt.Println("Downloading snapshot", cfg.url)
+	hash, err := snapshot.Download(ctx, cfg.url, file)
+	if err != nil {
+		return err
+	}
+	signatureURL := cfg.signatureURL
+	if signatureURL == "" {
+		signatureURL = cfg.url + ".sig"
+	}
+	sig, err := snapshot.DownloadSignature(ctx, signatureURL)
+	if err != nil {
+		return err
+	}
+	err = trust.Verify(hash, sig)
+	if err != nil {
+		return fmt.Errorf("failed to verify snapshot %s: %v", hash.String(), err)
+	}
+
+	fmt.Println("Installing snapshot", hash.String())
+	_, err = file.Seek(0, 0)
+	if err != nil {
+		return err
+	}
+	return snapshot.Install(file, dir)
+}
+
+// snapshotCommand creates a snapshot of the consensus directory of the stopped daemon,
+// signing it using the key of the foundation address should a remote signer be configured.
+func (cmds *commands) snapshotCommand(_ *cobra.Command, args []string) {
+	trust, err := snapshotTrust(cmds.cfg.BlockchainInfo.NetworkName)
+	if err != nil {
+		cli.DieWithError("failed to create snapshot", err)
+	}
+	var client *signer.Client
+	if cmds.snapshotCfg.signer != "" {
+		password := cmds.snapshotCfg.signerPassword
+		if password == "" {
+			password, err = speakeasy.Ask("Enter remote signer password: ")
+			if err != nil {
+				cli.DieWithError("failed to read the remote signer password", err)
+			}
+		}
+		client = signer.NewClient(cmds.snapshotCfg.signer, password)
+	}
+
+	file, err := os.Create(args[0])
+	if err != nil {
+		cli.DieWithError("failed to create snapshot", err)
+	}
+	dir := filepath.Join(cmds.cfg.NetworkDir(), modules.ConsensusDir)
+	hash, err := snapshot.Create(dir, file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(args[0])
+		cli.DieWithError("failed to create snapshot", err)
+	}
+	fmt.Println("Created snapshot", hash.String())
+	if client == nil {
+		return
+	}
+
+	challenge, err := client.SignChallenge(trust.Authority, snapshot.Message(hash))
+	if err != nil {
+		cli.DieWithError("failed to sign the snapshot", err)
+	}
+	sig := snapshot.NewSignature(hash, challenge)
+	err = sig.Verify(trust.Authority)
+	if err != nil {
+		cli.DieWithError("invalid snapshot signature", err)
+	}
+	b, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		cli.DieWithError("failed to encode the snapshot signature", err)
+	}
+	err = ioutil.WriteFile(args[0]+".sig", b, 0644)
+	if err != nil {
+		cli.DieWithError("failed to write the snapshot signature", err)
+	}
+	fmt.Println("Signed snapshot in", args[0]+".sig")
+}
+
+// snapshotTrust returns the snapshots of the given network trusted by this release.
+func snapshotTrust(networkName string) (snapshot.Trust, error) {
+	switch networkName {
+	case config.NetworkNameStandard:
+		return snapshot.Trust{
+			Hashes:    config.GetStandardnetSnapshots(),
+			Authority: config.GetStandardDaemonNetworkConfig().FoundationPoolAddress,
+		}, nil
+	case config.NetworkNameTest:
+		return snapshot.Trust{
+			Hashes:    config.GetTestnetSnapshots(),
+			Authority: config.GetTestnetDaemonNetworkConfig().FoundationPoolAddress,
+		}, nil
+	case config.NetworkNameDev:
+		return snapshot.Trust{
+			Hashes:    config.GetDevnetSnapshots(),
+			Authority: config.GetDevnetDaemonNetworkConfig().FoundationPoolAddress,
+		}, nil
+	default:
+		return snapshot.Trust{}, errors.New("network name not recognized")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bgentry/speakeasy"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/seed"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	gtypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// passwordEnv is the environment variable defining the API password,
+// which is never given as argument, as arguments are visible to all users of the machine.
+const passwordEnv = "GOLDCHAINSIGNER_PASSWORD"
+
+var (
+	apiAddr      = "localhost:22120"
+	seedFile     string
+	addresses    = uint64(modules.PublicKeysPerSeed)
+	passwordFile string
+	tlsCertFile  string
+	tlsKeyFile   string
+)
+
+// loadPassword returns the API password, defined by the environment variable or password file,
+// or asked for should neither be defined.
+func loadPassword() (string, error) {
+	if password, ok := os.LookupEnv(passwordEnv); ok {
+		return password, nil
+	}
+	if passwordFile != "" {
+		b, err := ioutil.ReadFile(passwordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return speakeasy.Ask("API password: ")
+}
+
+// checkListenAddress refuses to serve the API over plain HTTP on an address which isn't a loopback address,
+// as the password and signed transactions would be sent in the clear.
+func checkListenAddress(addr string, tls bool) error {
+	if tls {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid API address %q: %v", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return nil
+	}
+	return fmt.Errorf("the API can only be served on a loopback address without TLS, not on %q", addr)
+}
+
+func loadSeed() (modules.Seed, error) {
+	var (
+		str string
+		err error
+	)
+	if seedFile != "" {
+		var b []byte
+		b, err = ioutil.ReadFile(seedFile)
+		str = string(b)
+	} else {
+		str, err = speakeasy.Ask("Seed or mnemonic: ")
+	}
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	c, err := seed.Convert(str, seed.LanguageEnglish)
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	if len(c.Entropy) != crypto.EntropySize {
+		return modules.Seed{}, fmt.Errorf("seed has %d bits of entropy, while a wallet seed has %d bits", len(c.Entropy)*8, crypto.EntropySize*8)
+	}
+	return c.Seed, nil
+}
+
+func main() {
+	if (tlsCertFile == "") != (tlsKeyFile == "") {
+		log.Fatal("[ERROR] the TLS certificate and key files are required together")
+	}
+	tls := tlsCertFile != ""
+	if err := checkListenAddress(apiAddr, tls); err != nil {
+		log.Fatal("[ERROR] ", err)
+	}
+	password, err := loadPassword()
+	if err != nil {
+		log.Fatal("[ERROR] failed to load the API password: ", err)
+	}
+	if password == "" {
+		log.Fatal("[ERROR] an API password is required, as anyone who can reach the signer can spend its funds")
+	}
+	s, err := loadSeed()
+	if err != nil {
+		log.Fatal("[ERROR] failed to load seed: ", err)
+	}
+	log.Printf("[INFO] Deriving the keys of %d addresses\n", addresses)
+	sgn := signer.New(s, addresses)
+
+	router := api.NewDocumentedRouter()
+	api.RegisterSignerHTTPHandlers(router, sgn, password)
+	api.RegisterOpenAPIHTTPHandlers(router, api.OpenAPIInfo{
+		Title:   "goldchain signer API",
+		Version: config.Version.String(),
+	})
+
+	if tls {
+		log.Println("[INFO] Signer listening on", apiAddr, "(TLS)")
+		log.Fatal(http.ListenAndServeTLS(apiAddr, tlsCertFile, tlsKeyFile, router))
+	}
+	log.Println("[INFO] Signer listening on", apiAddr)
+	log.Fatal(http.ListenAndServe(apiAddr, router))
+}
+
+func init() {
+	flag.StringVar(&apiAddr, "api-addr", apiAddr, "address on which the signer API is served, which should only be reachable by the daemon, "+
+		"a loopback address unless TLS is used")
+	flag.StringVar(&seedFile, "seed-file", seedFile, "file containing the (hex-encoded or mnemonic) seed, read from the standard input if not set")
+	flag.Uint64Var(&addresses, "addresses", addresses, "amount of addresses derived from the seed, which the signer can sign for")
+	flag.StringVar(&passwordFile, "password-file", passwordFile,
+		"file containing the API password required by the daemon to use the signer, used unless "+passwordEnv+" is defined, asked for if neither is")
+	flag.StringVar(&tlsCertFile, "tls-cert", tlsCertFile, "PEM encoded certificate file, serving the API over TLS, such that it can be reached over the network")
+	flag.StringVar(&tlsKeyFile, "tls-key", tlsKeyFile, "PEM encoded private key file of the TLS certificate")
+	flag.Parse()
+
+	// register the goldchain transaction versions, such that they can be decoded and signed,
+	// the conditions of their fulfillments are given by the daemon as part of each sign request
+	gtypes.RegisterTransactionVersions(gtypes.TransactionControllerGetters{
+		MintConditionGetter: requestConditionGetter{},
+		AuthInfoGetter:      requestConditionGetter{},
+	})
+}
+
+// requestConditionGetter satisfies the transaction controllers, which require the active mint and auth conditions
+// to sign the extension of a transaction, while the signer uses the conditions given as part of the sign request.
+type requestConditionGetter struct{}
+
+var errNoConsensus = errors.New("the signer has no access to the consensus set")
+
+func (requestConditionGetter) GetActiveMintCondition() (types.UnlockConditionProxy, error) {
+	return types.NewCondition(&types.NilCondition{}), nil
+}
+
+func (requestConditionGetter) GetMintConditionAt(types.BlockHeight) (types.UnlockConditionProxy, error) {
+	return types.UnlockConditionProxy{}, errNoConsensus
+}
+
+func (requestConditionGetter) GetActiveAuthCondition() (types.UnlockConditionProxy, error) {
+	return types.NewCondition(&types.NilCondition{}), nil
+}
+
+func (requestConditionGetter) GetAuthConditionAt(types.BlockHeight) (types.UnlockConditionProxy, error) {
+	return types.UnlockConditionProxy{}, errNoConsensus
+}
+
+func (requestConditionGetter) GetAddressesAuthStateNow([]types.UnlockHash, func(int, bool) bool) ([]bool, error) {
+	return nil, errNoConsensus
+}
+
+func (requestConditionGetter) GetAddressesAuthStateAt(types.BlockHeight, []types.UnlockHash, func(int, bool) bool) ([]bool, error) {
+	return nil, errNoConsensus
+}
@@ -20,9 +20,9 @@ function showCurrentStats() {
 }
 
 function setCurrentValues(values) {
-    document.getElementById('current-difficulty').innerHTML = values.difficulty+ ' BS';
+    document.getElementById('current-difficulty').innerHTML = readableBlockStakes(values.difficulty);
     document.getElementById('current-height').innerHTML = values.height;
-    document.getElementById('current-bs').innerHTML = values.estimatedactivebs + ' BS';
+    document.getElementById('current-bs').innerHTML = readableBlockStakes(values.estimatedactivebs);
 }
 
 function loadRange() {
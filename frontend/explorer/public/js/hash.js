@@ -1460,7 +1460,7 @@ function appendUnlockHashTransactionElements(domParent, hash, explorerHash, addr
 							value: value,
 							confirmed: confirmed
 						});
-						appendStat(table, 'Value', value + ' BS');
+						appendStat(table, 'Value', readableBlockStakes(value));
 						tables.push(table);
 						sfoids.push(explorerHash.transactions[i].blockstakeoutputids[j]);
 						sfoidMatches.push(false);
@@ -1579,15 +1579,15 @@ function appendUnlockHashTransactionElements(domParent, hash, explorerHash, addr
 	}
 
 	// add total confirmed block stake balance for the address
-	appendStat(addressInfoTable, 'Confirmed Block Stake Balance', totalValue + ' BS');
+	appendStat(addressInfoTable, 'Confirmed Block Stake Balance', readableBlockStakes(totalValue));
 	if (totalUnconfirmedValue !== 0) {
-		appendStat(addressInfoTable, 'Unconfirmed Block Stake Balance', totalUnconfirmedValue + ' BS');
+		appendStat(addressInfoTable, 'Unconfirmed Block Stake Balance', readableBlockStakes(totalUnconfirmedValue));
 	}
 	if (totalLockedValue !== 0) {
-		appendStat(addressInfoTable, 'Locked Block Stake Balance', totalLockedValue + ' BS');
+		appendStat(addressInfoTable, 'Locked Block Stake Balance', readableBlockStakes(totalLockedValue));
 	}
 	if (totalUnconfirmedLockedValue !== 0) {
-		appendStat(addressInfoTable, 'Unconfirmed Locked Block Stake Balance', totalUnconfirmedLockedValue + ' BS');
+		appendStat(addressInfoTable, 'Unconfirmed Locked Block Stake Balance', readableBlockStakes(totalUnconfirmedLockedValue));
 	}
 
 	// add info about last spend
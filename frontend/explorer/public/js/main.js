@@ -17,18 +17,26 @@ function toTitleCase(str) {
 }
 
 // readableCoins converts a number of hastings into a more readable volume of
-// siacoins.
+// coins, using the precision and unit of the network descriptor.
 function readableCoins(hastings) {
-	if (hastings < 1000000000000000000) {
-		return addCommasToNumber((hastings / 1000000000)) + " GFT";
+	var network = getNetworkDescriptor();
+	var oneCoin = Math.pow(10, network.coinprecision);
+	if (hastings < oneCoin * 1000000000) {
+		return addCommasToNumber((hastings / oneCoin)) + ' ' + network.coinunit;
 	} else {
-		return addCommasToNumber((hastings / 1000000000000000000)) + " billion GFT";
+		return addCommasToNumber((hastings / (oneCoin * 1000000000))) + ' billion ' + network.coinunit;
 	}
 }
 
+// readableBlockStakes appends the block stake unit of the network descriptor
+// to the given amount of block stakes.
+function readableBlockStakes(blockStakes) {
+	return blockStakes + ' ' + getNetworkDescriptor().blockstakeunit;
+}
+
 // readableDifficulty takes a difficulty and formats into something readable.
 function readableDifficulty(hashes) {
-	return addCommasToNumber((hashes / 1)) + ' BS';
+	return readableBlockStakes(addCommasToNumber((hashes / 1)));
 }
 
 // linkHash takes a hash and returns a link that has the hash as text and
@@ -473,6 +481,27 @@ function getBlockchainConstants() {
 	return JSON.parse(request.responseText);
 }
 
+// networkDescriptor caches the network descriptor, as it never changes
+var networkDescriptor = null;
+
+// getNetworkDescriptor returns the descriptor of the network,
+// defining how coins and block stakes are presented,
+// as defined by the remote/local explorer
+function getNetworkDescriptor() {
+	if (networkDescriptor != null) {
+		return networkDescriptor;
+	}
+	var request = new XMLHttpRequest();
+	request.open('GET', '/explorer/network', false);
+	request.send();
+	if (request.status != 200) {
+		// fall back to the defaults of all goldchain networks, without caching them
+		return {coinunit: 'GFT', coinprecision: 9, blockstakeunit: 'BS'};
+	}
+	networkDescriptor = JSON.parse(request.responseText);
+	return networkDescriptor;
+}
+
 //Changes the document title according to the network the page is running on
 function buildPageTitle() {
 	var networkName = getBlockchainConstants().chaininfo.NetworkName;
@@ -2,9 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"log"
 	"net/http"
+	"strings"
 
+	"github.com/nbh-digital/goldchain/pkg/config"
 	"github.com/threefoldtech/rivine/types"
 )
 
@@ -15,7 +18,7 @@ func (f *faucet) requestCoins(w http.ResponseWriter, r *http.Request) {
 	}
 
 	body := struct {
-		Address types.UnlockHash `json:"address"`
+		Address string `json:"address"`
 	}{}
 
 	err := json.NewDecoder(r.Body).Decode(&body)
@@ -23,12 +26,21 @@ func (f *faucet) requestCoins(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	address, err := f.network.ParseAddress(body.Address)
+	if err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if !f.allowDrip(w, r, address) {
+		return
+	}
 
-	log.Printf("[DEBUG] Requesting coins (%s) through API\n", body.Address.String())
+	log.Printf("[DEBUG] Requesting coins (%s) through API\n", address.String())
 
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	txID, err := dripCoins(body.Address, f.coinsToGive)
+	txID, err := f.dripCoins(r.Context(), address, f.coinsToGive)
 
 	if err != nil {
 		log.Println("[ERROR] Failed to drip coins:", err)
@@ -53,7 +65,10 @@ func (f *faucet) requestAuthorization(w http.ResponseWriter, r *http.Request) {
 	}
 
 	body := struct {
-		Address types.UnlockHash `json:"address"`
+		Address string `json:"address"`
+		// Applicant identifies the applicant at the KYC provider, only used in authorizer mode
+		Applicant string `json:"applicant"`
+		ownershipProof
 	}{}
 
 	err := json.NewDecoder(r.Body).Decode(&body)
@@ -61,10 +76,25 @@ func (f *faucet) requestAuthorization(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	address, err := f.network.ParseAddress(body.Address)
+	if err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if !f.verifyOwnership(r.Context(), w, address, body.ownershipProof, false) {
+		return
+	}
+
+	log.Printf("[DEBUG] Requesting address authorization (%s) through API\n", address.String())
 
-	log.Printf("[DEBUG] Requesting address authorization (%s) through API\n", body.Address.String())
+	if f.kyc != nil {
+		request, err := f.requestKYCAuthorization(r.Context(), address, body.Applicant, false)
+		writeKYCRequestResponse(w, request, request.AuthorizationTxID, err)
+		return
+	}
 
-	txID, err := updateAddressAuthorization(body.Address, true)
+	txID, err := f.updateAddressAuthorization(r.Context(), address, true)
 	if err != nil {
 		log.Println("[ERROR] Failed to authorize address:", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
@@ -85,6 +115,8 @@ func (f *faucet) requestDeauthorization(w http.ResponseWriter, r *http.Request)
 
 	body := struct {
 		Address types.UnlockHash `json:"address"`
+		// Force deauthorizes the address even if it still holds coins
+		Force bool `json:"force"`
 	}{}
 
 	err := json.NewDecoder(r.Body).Decode(&body)
@@ -95,7 +127,21 @@ func (f *faucet) requestDeauthorization(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("[DEBUG] Requesting address deauthorization (%s) through API\n", body.Address.String())
 
-	txID, err := updateAddressAuthorization(body.Address, false)
+	err = f.checkDeauthorization(r.Context(), body.Address, body.Force)
+	if err != nil {
+		log.Println("[ERROR] Refusing to deauthorize address:", err.Error())
+		status := http.StatusInternalServerError
+		if _, ok := err.(*fundedAddressError); ok {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			Error string `json:"error"`
+		}{Error: err.Error()})
+		return
+	}
+
+	txID, err := f.updateAddressAuthorization(r.Context(), body.Address, false)
 	if err != nil {
 		log.Println("[ERROR] Failed to deauthorize address:", err.Error())
 		w.WriteHeader(http.StatusInternalServerError)
@@ -107,3 +153,280 @@ func (f *faucet) requestDeauthorization(w http.ResponseWriter, r *http.Request)
 		TxID types.TransactionID `json:"txid"`
 	}{TxID: txID})
 }
+
+// requestAuthorizationAndCoins authorizes the address if required, and drips coins to it
+// once that authorization is confirmed, as a single (blocking) call.
+func (f *faucet) requestAuthorizationAndCoins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body := struct {
+		Address string `json:"address"`
+		// Applicant identifies the applicant at the KYC provider, only used in authorizer mode
+		Applicant string `json:"applicant"`
+		ownershipProof
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	address, err := f.network.ParseAddress(body.Address)
+	if err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if !f.verifyOwnership(r.Context(), w, address, body.ownershipProof, true) {
+		return
+	}
+	if !f.allowDrip(w, r, address) {
+		return
+	}
+
+	log.Printf("[DEBUG] Requesting address authorization and coins (%s) through API\n", address.String())
+
+	if f.kyc != nil {
+		request, err := f.requestKYCAuthorization(r.Context(), address, body.Applicant, true)
+		writeKYCRequestResponse(w, request, request.DripTxID, err)
+		return
+	}
+
+	authTxID, txID, err := f.authorizeAndDrip(r.Context(), address)
+	if err != nil {
+		log.Println("[ERROR] Failed to authorize address and drip coins:", err)
+		status := http.StatusInternalServerError
+		if err == errAuthorizationTimeout {
+			status = http.StatusGatewayTimeout
+		}
+		// the authorization might have succeeded, even though the drip did not
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(struct {
+			AuthorizationTxID *types.TransactionID `json:"authorizationtxid,omitempty"`
+			Error             string               `json:"error"`
+		}{AuthorizationTxID: authTxID, Error: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(struct {
+		AuthorizationTxID *types.TransactionID `json:"authorizationtxid,omitempty"`
+		TxID              types.TransactionID  `json:"txid"`
+	}{AuthorizationTxID: authTxID, TxID: txID})
+}
+
+// requestDripRequest starts a drip request, authorizing the address if required and dripping coins to it
+// in the background, returning the request with status 202 such that its status can be polled.
+func (f *faucet) requestDripRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body := struct {
+		Address string `json:"address"`
+		// Applicant identifies the applicant at the KYC provider, only used in authorizer mode
+		Applicant string `json:"applicant"`
+		ownershipProof
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	address, err := f.network.ParseAddress(body.Address)
+	if err != nil {
+		writeAddressError(w, err)
+		return
+	}
+
+	if !f.verifyOwnership(r.Context(), w, address, body.ownershipProof, true) {
+		return
+	}
+	if !f.allowDrip(w, r, address) {
+		return
+	}
+
+	log.Printf("[DEBUG] Requesting drip (%s) through API\n", address.String())
+
+	request, err := f.requestDrip(address, body.Applicant)
+	if err != nil {
+		log.Println("[ERROR] Failed to start drip request:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", "/api/v1/drips/"+request.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(f.dripRequestResponse(request))
+}
+
+// requestDripStatus returns the drip request with the ID defined by the path.
+func (f *faucet) requestDripStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	request, ok := f.drips.Get(strings.TrimPrefix(r.URL.Path, "/api/v1/drips/"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(struct {
+			Error string `json:"error"`
+		}{Error: errUnknownDripRequest.Error()})
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(f.dripRequestResponse(request))
+}
+
+// requestAddressValidation validates the address given as the addr query parameter,
+// such that the web UI can point out what is wrong with an address before requesting coins for it.
+// The authorization state is only returned for valid addresses.
+func (f *faucet) requestAddressValidation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	resp := struct {
+		config.AddressDiagnosis
+		Authorized *bool `json:"authorized,omitempty"`
+	}{AddressDiagnosis: f.network.DiagnoseAddress(r.URL.Query().Get("addr"))}
+	if resp.Valid {
+		authorized, err := f.isAuthorized(r.Context(), *resp.Address)
+		if err != nil {
+			log.Println("[ERROR] Failed to validate address:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp.Authorized = &authorized
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// dripRequestResponse is a drip request as returned by the API,
+// linking its transactions on the explorer, if the network has one.
+type dripRequestResponse struct {
+	dripRequest
+	AuthorizationTxURL string `json:"authorizationtxurl,omitempty"`
+	DripTxURL          string `json:"driptxurl,omitempty"`
+}
+
+func (f *faucet) dripRequestResponse(request dripRequest) dripRequestResponse {
+	resp := dripRequestResponse{dripRequest: request}
+	if request.AuthorizationTxID != nil {
+		resp.AuthorizationTxURL = f.network.TransactionURL(*request.AuthorizationTxID)
+	}
+	if request.DripTxID != nil {
+		resp.DripTxURL = f.network.TransactionURL(*request.DripTxID)
+	}
+	return resp
+}
+
+// requestKYCDecision handles the webhook KYC providers post their asynchronous decisions to,
+// signed using the webhook secret of the faucet.
+func (f *faucet) requestKYCDecision(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	err = f.kyc.VerifySignature(data, r.Header.Get(kycSignatureHeader))
+	if err != nil {
+		log.Println("[ERROR] Refusing KYC decision:", err)
+		writeKYCError(w, http.StatusUnauthorized, err)
+		return
+	}
+	var verdict kycVerdict
+	err = json.Unmarshal(data, &verdict)
+	if err == nil {
+		err = verdict.validate()
+	}
+	if err != nil {
+		writeKYCError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	log.Printf("[DEBUG] Received KYC decision for request %s: %s\n", verdict.RequestID, verdict.Decision)
+
+	request, err := f.decideKYCRequest(r.Context(), verdict.RequestID, verdict, true)
+	switch err {
+	case nil:
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(request)
+	case errKYCUnknownRequest:
+		writeKYCError(w, http.StatusNotFound, err)
+	case errKYCDecided:
+		writeKYCError(w, http.StatusConflict, err)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(request)
+	}
+}
+
+// requestKYCRequest returns the KYC request with the ID defined by the path.
+func (f *faucet) requestKYCRequest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	request, ok := f.kyc.requests.Get(strings.TrimPrefix(r.URL.Path, "/api/v1/kyc/requests/"))
+	if !ok {
+		writeKYCError(w, http.StatusNotFound, errKYCUnknownRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(request)
+}
+
+// writeKYCRequestResponse writes the KYC request to which an authorization request was forwarded,
+// using status 202 while the request is pending, and status 403 should it be rejected.
+// The given transaction ID is returned as txid, for compatibility with the responses returned without KYC.
+func writeKYCRequestResponse(w http.ResponseWriter, request kycRequest, txID *types.TransactionID, err error) {
+	if err != nil && request.ID == "" {
+		log.Println("[ERROR] Failed to forward authorization request to the KYC provider:", err)
+		writeKYCError(w, http.StatusBadGateway, err)
+		return
+	}
+	status := http.StatusOK
+	switch {
+	case err != nil:
+		status = http.StatusInternalServerError
+		if err == errAuthorizationTimeout {
+			status = http.StatusGatewayTimeout
+		}
+	case request.Status == kycPending:
+		status = http.StatusAccepted
+	case request.Status == kycRejected:
+		status = http.StatusForbidden
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		kycRequest
+		TxID *types.TransactionID `json:"txid,omitempty"`
+	}{kycRequest: request, TxID: txID})
+}
+
+func writeKYCError(w http.ResponseWriter, status int, err error) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
+
+// writeAddressError writes status 400 with the reason the address of a request is refused,
+// such as it being an address of another blockchain.
+func writeAddressError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// dripAuditRecord links a drip to the authorization transaction it required.
+type dripAuditRecord struct {
+	Time    time.Time        `json:"time"`
+	Address types.UnlockHash `json:"address"`
+	// AuthorizationTxID is undefined if the address was already authorized
+	AuthorizationTxID *types.TransactionID `json:"authorizationtxid,omitempty"`
+	DripTxID          types.TransactionID  `json:"driptxid"`
+}
+
+// dripAuditLog appends a JSON record per line for all drips that
+// got combined with the authorization of the address.
+type dripAuditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// Record appends the record to the audit log,
+// records are only logged should no audit file be configured.
+func (audit *dripAuditLog) Record(record dripAuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	log.Println("[INFO] Drip audit:", string(data))
+	if audit.path == "" {
+		return nil
+	}
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	file, err := os.OpenFile(audit.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
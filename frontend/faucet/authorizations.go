@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// authorizationStore keeps track of all addresses authorized through this faucet,
+// and when they were last authorized, persisted as a JSON file.
+type authorizationStore struct {
+	path string
+
+	mu        sync.Mutex
+	addresses map[types.UnlockHash]time.Time
+}
+
+func loadAuthorizationStore(path string) (*authorizationStore, error) {
+	store := &authorizationStore{
+		path:      path,
+		addresses: make(map[types.UnlockHash]time.Time),
+	}
+	if path == "" {
+		return store, nil // in-memory only
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	var records map[string]time.Time
+	err = json.NewDecoder(file).Decode(&records)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode authorization store %s: %v", path, err)
+	}
+	for str, t := range records {
+		var uh types.UnlockHash
+		err = uh.LoadString(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %q in authorization store %s: %v", str, path, err)
+		}
+		store.addresses[uh] = t
+	}
+	return store, nil
+}
+
+// Authorized records that the given address got authorized at the given time.
+func (store *authorizationStore) Authorized(uh types.UnlockHash, t time.Time) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.addresses[uh] = t
+	return store.save()
+}
+
+// Deauthorized removes the given addresses from the store.
+func (store *authorizationStore) Deauthorized(uhs ...types.UnlockHash) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, uh := range uhs {
+		delete(store.addresses, uh)
+	}
+	return store.save()
+}
+
+// Snapshot returns a copy of all addresses and their authorization time.
+func (store *authorizationStore) Snapshot() map[types.UnlockHash]time.Time {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	snapshot := make(map[types.UnlockHash]time.Time, len(store.addresses))
+	for uh, t := range store.addresses {
+		snapshot[uh] = t
+	}
+	return snapshot
+}
+
+func (store *authorizationStore) save() error {
+	if store.path == "" {
+		return nil
+	}
+	records := make(map[string]time.Time, len(store.addresses))
+	for uh, t := range store.addresses {
+		records[uh.String()] = t
+	}
+	data, err := json.MarshalIndent(records, "", "\t")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so we never end up with a half-written store
+	tmpPath := store.path + ".tmp"
+	err = writeFile(tmpPath, data)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, store.path)
+}
+
+func writeFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// updateAddressAuthorization updates the authorization of a single address,
+// keeping track of the addresses authorized through this faucet.
+func (f *faucet) updateAddressAuthorization(ctx context.Context, address types.UnlockHash, authorize bool) (types.TransactionID, error) {
+	var (
+		txID types.TransactionID
+		err  error
+	)
+	if authorize {
+		log.Println("[DEBUG] Updating address", address.String(), "to be authorized")
+		txID, err = f.updateAddressesAuthorization(ctx, []types.UnlockHash{address}, nil)
+	} else {
+		log.Println("[DEBUG] Updating address", address.String(), "to be deauthorized")
+		txID, err = f.updateAddressesAuthorization(ctx, nil, []types.UnlockHash{address})
+	}
+	if err != nil {
+		return txID, err
+	}
+	if authorize {
+		err = f.authorizations.Authorized(address, time.Now())
+	} else {
+		err = f.authorizations.Deauthorized(address)
+	}
+	if err != nil {
+		log.Println("[ERROR] Failed to update authorization store:", err)
+	}
+	return txID, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// setupDevnet makes the faucet usable on a fresh devnet daemon without any manual step:
+// the wallet of the daemon is created from the devnet genesis mnemonic, unless it exists already,
+// after which the genesis address, which holds the coins dripped and receives their change, is authorized.
+// It returns once the authorization is confirmed.
+func (f *faucet) setupDevnet(ctx context.Context) error {
+	_, err := f.client.ProvisionWallet(ctx, wallet.ProvisionOptions{Mnemonic: config.DevnetGenesisMnemonic})
+	switch {
+	case err == nil:
+		log.Println("[INFO] Created the devnet wallet of the daemon, using the devnet genesis mnemonic")
+	case goldchainclient.IsStatus(err, http.StatusConflict):
+		log.Println("[INFO] Wallet of the devnet daemon exists already, assuming it was created using the devnet genesis mnemonic")
+	default:
+		return fmt.Errorf("failed to create the devnet wallet: %v", err)
+	}
+	address := config.GetDevnetGenesisMintCondition().UnlockHash()
+
+	authorized, err := f.isAuthorized(ctx, address)
+	if err != nil {
+		return err
+	}
+	if authorized {
+		log.Println("[INFO] Devnet genesis address", address.String(), "is authorized already")
+		return nil
+	}
+	log.Println("[INFO] Authorizing devnet genesis address", address.String())
+	// the authorization is signed using the genesis auth condition, fulfilled by the wallet
+	_, err = f.updateAddressesAuthorization(ctx, []types.UnlockHash{address}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to authorize the devnet genesis address: %v", err)
+	}
+	return f.waitForAuthorization(ctx, address, authorizationTimeout)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// maxDeauthAddressesPerTx is the maximum amount of addresses
+	// deauthorized within a single auth address update transaction
+	maxDeauthAddressesPerTx = 250
+)
+
+// dormantConfig configures the job that deauthorizes dormant addresses.
+type dormantConfig struct {
+	// After is the period of inactivity after which an address is considered dormant,
+	// 0 disables the job
+	After time.Duration
+	// Interval defines how often the job checks for dormant addresses
+	Interval time.Duration
+	// Exempt addresses are never deauthorized by the job
+	Exempt map[types.UnlockHash]struct{}
+}
+
+// parseExemptAddresses parses a comma-separated list of addresses.
+func parseExemptAddresses(str string) (map[types.UnlockHash]struct{}, error) {
+	exempt := make(map[types.UnlockHash]struct{})
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		var uh types.UnlockHash
+		err := uh.LoadString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exempt address %q: %v", s, err)
+		}
+		exempt[uh] = struct{}{}
+	}
+	return exempt, nil
+}
+
+// deauthorizeDormantAddresses runs the dormant address job until the process exits,
+// it only runs on testnet as it is meant to simulate real-world churn.
+func (f *faucet) deauthorizeDormantAddresses(cfg dormantConfig) {
+	if cfg.After == 0 {
+		return
+	}
+	if f.cts.ChainInfo.NetworkName != config.NetworkNameTest {
+		log.Printf("[INFO] Dormant address deauthorization is only supported on %s, disabling it on %s\n",
+			config.NetworkNameTest, f.cts.ChainInfo.NetworkName)
+		return
+	}
+	log.Printf("[INFO] Deauthorizing addresses dormant for %v, checking every %v\n", cfg.After, cfg.Interval)
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		addresses, err := f.findDormantAddresses(context.Background(), cfg)
+		if err != nil {
+			log.Println("[ERROR] Failed to find dormant addresses:", err)
+		} else if len(addresses) > 0 {
+			f.deauthorizeAddresses(context.Background(), addresses)
+		}
+		<-ticker.C
+	}
+}
+
+// findDormantAddresses returns all addresses authorized by this faucet,
+// which haven't been part of any transaction for the configured period.
+func (f *faucet) findDormantAddresses(ctx context.Context, cfg dormantConfig) ([]types.UnlockHash, error) {
+	cs, err := f.client.Consensus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get consensus state: %v", err)
+	}
+	now := time.Now()
+	var dormant []types.UnlockHash
+	for uh, authorizedAt := range f.authorizations.Snapshot() {
+		if _, ok := cfg.Exempt[uh]; ok {
+			continue
+		}
+		lastActivity := authorizedAt
+		resp, err := f.client.ExplorerHash(ctx, uh.String())
+		if err != nil && !strings.Contains(err.Error(), "unrecognized hash") {
+			return nil, fmt.Errorf("failed to get transactions for address %s: %v", uh.String(), err)
+		}
+		for _, txn := range resp.Transactions {
+			if txn.Unconfirmed || txn.Height > cs.Height {
+				lastActivity = now
+				break
+			}
+			// estimate the time of the transaction using the block frequency
+			age := time.Duration(cs.Height-txn.Height) * time.Duration(f.cts.BlockFrequency) * time.Second
+			if t := now.Add(-age); t.After(lastActivity) {
+				lastActivity = t
+			}
+		}
+		if now.Sub(lastActivity) < cfg.After {
+			continue
+		}
+		err = f.checkDeauthorization(ctx, uh, false)
+		if err != nil {
+			if _, ok := err.(*fundedAddressError); !ok {
+				return nil, err
+			}
+			log.Println("[INFO] Not deauthorizing dormant address:", err)
+			continue
+		}
+		dormant = append(dormant, uh)
+	}
+	return dormant, nil
+}
+
+// deauthorizeAddresses deauthorizes the given addresses,
+// batched in as few transactions as possible.
+func (f *faucet) deauthorizeAddresses(ctx context.Context, addresses []types.UnlockHash) {
+	for len(addresses) > 0 {
+		n := len(addresses)
+		if n > maxDeauthAddressesPerTx {
+			n = maxDeauthAddressesPerTx
+		}
+		batch := addresses[:n]
+		addresses = addresses[n:]
+		txID, err := f.updateAddressesAuthorization(ctx, nil, batch)
+		if err != nil {
+			log.Printf("[ERROR] Failed to deauthorize %d dormant addresses: %v\n", len(batch), err)
+			continue
+		}
+		err = f.authorizations.Deauthorized(batch...)
+		if err != nil {
+			log.Println("[ERROR] Failed to update authorization store:", err)
+		}
+		log.Printf("[INFO] Deauthorized %d dormant addresses in transaction %s\n", len(batch), txID.String())
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// dripRequestRetention is the time drip requests are kept track of,
+	// and the maximum time a drip request waits for the decision of the KYC provider
+	dripRequestRetention = 24 * time.Hour
+)
+
+// dripStatus is the progress of a drip request.
+type dripStatus string
+
+const (
+	// dripKYCPending awaits the decision of the KYC provider about the authorization of the address
+	dripKYCPending dripStatus = "kyc-pending"
+	// dripAuthorizationPending awaits the authorization of the address to be confirmed
+	dripAuthorizationPending dripStatus = "authorization-pending"
+	// dripPending awaits the drip to be confirmed
+	dripPending dripStatus = "drip-pending"
+	// dripConfirmed is the final status of a fulfilled request
+	dripConfirmed dripStatus = "confirmed"
+	// dripRejected is the final status of a request rejected by the KYC provider
+	dripRejected dripStatus = "rejected"
+	// dripFailed is the final status of a request which failed to be fulfilled
+	dripFailed dripStatus = "failed"
+)
+
+// errUnknownDripRequest is returned for drip requests unknown to the faucet
+var errUnknownDripRequest = errors.New("unknown drip request")
+
+// dripRequest tracks the authorization of an address (if required) and the drip of coins to it,
+// as requested through the web UI, such that its status can be polled.
+type dripRequest struct {
+	ID      string           `json:"id"`
+	Address types.UnlockHash `json:"address"`
+	Status  dripStatus       `json:"status"`
+	// KYCRequestID is defined once the authorization is forwarded to the KYC provider, in authorizer mode
+	KYCRequestID string `json:"kycrequestid,omitempty"`
+	// AuthorizationTxID is undefined if the address was already authorized
+	AuthorizationTxID *types.TransactionID `json:"authorizationtxid,omitempty"`
+	DripTxID          *types.TransactionID `json:"driptxid,omitempty"`
+	// Error is the reason the request failed or got rejected
+	Error   string    `json:"error,omitempty"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// dripRequestStore keeps track of the drip requests in memory, for dripRequestRetention.
+// Requests are not persisted, as they are fulfilled by the process that accepted them.
+type dripRequestStore struct {
+	mu       sync.Mutex
+	requests map[string]dripRequest
+}
+
+func newDripRequestStore() *dripRequestStore {
+	return &dripRequestStore{requests: make(map[string]dripRequest)}
+}
+
+// Get returns the request with the given ID.
+func (store *dripRequestStore) Get(id string) (dripRequest, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	request, ok := store.requests[id]
+	return request, ok
+}
+
+// Put adds or updates the given request, forgetting all requests older than dripRequestRetention.
+func (store *dripRequestStore) Put(request dripRequest) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	request.Updated = time.Now()
+	store.requests[request.ID] = request
+	for id, r := range store.requests {
+		if time.Since(r.Created) > dripRequestRetention {
+			delete(store.requests, id)
+		}
+	}
+}
+
+// requestDrip starts a drip request for the given address, fulfilled in the background.
+// The applicant identifies the applicant at the KYC provider, and is only used in authorizer mode.
+func (f *faucet) requestDrip(address types.UnlockHash, applicant string) (dripRequest, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return dripRequest{}, err
+	}
+	now := time.Now()
+	request := dripRequest{
+		ID:      id,
+		Address: address,
+		Status:  dripAuthorizationPending,
+		Created: now,
+		Updated: now,
+	}
+	f.drips.Put(request)
+	go f.processDripRequest(request, applicant)
+	return request, nil
+}
+
+// processDripRequest fulfils the drip request, marking it as failed should that fail.
+func (f *faucet) processDripRequest(request dripRequest, applicant string) {
+	ctx, cancel := context.WithTimeout(context.Background(), dripRequestRetention)
+	defer cancel()
+	err := f.fulfilDripRequest(ctx, &request, applicant)
+	if err != nil {
+		log.Printf("[ERROR] Failed to fulfil drip request %s: %v\n", request.ID, err)
+		if request.Status != dripRejected {
+			request.Status = dripFailed
+		}
+		request.Error = err.Error()
+		f.drips.Put(request)
+	}
+}
+
+// fulfilDripRequest authorizes the address of the request should it not be authorized yet,
+// and drips coins to it once that authorization is confirmed, updating the status of the request along the way.
+func (f *faucet) fulfilDripRequest(ctx context.Context, request *dripRequest, applicant string) error {
+	authorized, err := f.isAuthorized(ctx, request.Address)
+	if err != nil {
+		return err
+	}
+	if !authorized {
+		var authTxID types.TransactionID
+		if f.kyc != nil {
+			authTxID, err = f.awaitKYCAuthorization(ctx, request, applicant)
+		} else {
+			authTxID, err = f.updateAddressAuthorization(ctx, request.Address, true)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to authorize address: %v", err)
+		}
+		request.AuthorizationTxID = &authTxID
+		request.Status = dripAuthorizationPending
+		f.drips.Put(*request)
+		err = f.waitForAuthorization(ctx, request.Address, authorizationTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	dripTxID, err := f.dripAudited(ctx, request.Address, request.AuthorizationTxID)
+	if err != nil {
+		return err
+	}
+	request.DripTxID = &dripTxID
+	request.Status = dripPending
+	f.drips.Put(*request)
+	err = f.waitForTransaction(ctx, dripTxID, confirmationTimeout)
+	if err != nil {
+		return err
+	}
+	request.Status = dripConfirmed
+	f.drips.Put(*request)
+	log.Printf("[INFO] Drip request %s: sent %s tokens to %s\n", request.ID, f.coinsToGive.String(), request.Address.String())
+	return nil
+}
+
+// awaitKYCAuthorization forwards the authorization of the address of the request to the KYC provider,
+// and waits until the provider decided about it, returning the ID of the authorization transaction once approved.
+func (f *faucet) awaitKYCAuthorization(ctx context.Context, request *dripRequest, applicant string) (types.TransactionID, error) {
+	kycReq, err := f.requestKYCAuthorization(ctx, request.Address, applicant, false)
+	if kycReq.ID == "" {
+		return types.TransactionID{}, err
+	}
+	request.KYCRequestID = kycReq.ID
+	request.Status = dripKYCPending
+	f.drips.Put(*request)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	// an approved request is only fulfilled once its address is authorized, or that authorization failed
+	for kycReq.Status == kycPending || (kycReq.Status == kycApproved && kycReq.AuthorizationTxID == nil && kycReq.Error == "") {
+		select {
+		case <-ctx.Done():
+			return types.TransactionID{}, ctx.Err()
+		case <-ticker.C:
+		}
+		var ok bool
+		kycReq, ok = f.kyc.requests.Get(request.KYCRequestID)
+		if !ok {
+			return types.TransactionID{}, errKYCUnknownRequest
+		}
+	}
+	if kycReq.Status == kycRejected {
+		request.Status = dripRejected
+		return types.TransactionID{}, fmt.Errorf("rejected by the KYC provider: %s", kycReq.Reason)
+	}
+	if kycReq.Error != "" {
+		return types.TransactionID{}, errors.New(kycReq.Error)
+	}
+	return *kycReq.AuthorizationTxID, nil
+}
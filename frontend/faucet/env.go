@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix prefixes the environment variables defining the flags of the faucet,
+// e.g. GOLDCHAIN_DAEMON_ADDRESS defines the daemon-address flag
+const envPrefix = "GOLDCHAIN_"
+
+// envName returns the name of the environment variable defining the given flag.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(flagName, "-", "_", -1))
+}
+
+// applyEnv defines the flags which aren't defined on the command line using their environment variable,
+// such that containers can configure the faucet without translating their environment into flags.
+func applyEnv(flagSet *flag.FlagSet) error {
+	defined := make(map[string]bool)
+	flagSet.Visit(func(f *flag.Flag) {
+		defined[f.Name] = true
+	})
+	var err error
+	flagSet.VisitAll(func(f *flag.Flag) {
+		if err != nil || defined[f.Name] {
+			return
+		}
+		name := envName(f.Name)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if setErr := flagSet.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("invalid environment variable %s: %v", name, setErr)
+		}
+	})
+	return err
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// policies for the deauthorization of addresses which still hold unspent coins
+const (
+	// deauthFundedRefuse refuses to deauthorize funded addresses, unless forced
+	deauthFundedRefuse = "refuse"
+	// deauthFundedWarn deauthorizes funded addresses, logging a warning
+	deauthFundedWarn = "warn"
+)
+
+// fundedAddressError is returned when refusing to deauthorize an address which still holds unspent coins,
+// as those coins cannot be spent until the address gets authorized again.
+type fundedAddressError struct {
+	address types.UnlockHash
+	balance string
+	// pending is true if unconfirmed transactions pay to the address
+	pending bool
+}
+
+func (err *fundedAddressError) Error() string {
+	if err.pending {
+		return fmt.Sprintf("address %s has unconfirmed incoming transactions, it can only be deauthorized once those are confirmed", err.address.String())
+	}
+	return fmt.Sprintf("address %s still holds %s, deauthorizing it would strand these coins", err.address.String(), err.balance)
+}
+
+func parseDeauthFundedPolicy(str string) (string, error) {
+	switch str {
+	case deauthFundedRefuse, deauthFundedWarn:
+		return str, nil
+	default:
+		return "", fmt.Errorf("invalid funded address deauthorization policy %q, expected %s or %s", str, deauthFundedRefuse, deauthFundedWarn)
+	}
+}
+
+// addressFunds returns the sum of all unspent coin outputs of the given address,
+// unconfirmed transactions included, as known by the explorer module of the daemon,
+// as well as whether any unconfirmed transaction pays to the address.
+func (f *faucet) addressFunds(ctx context.Context, address types.UnlockHash) (balance types.Currency, pending bool, err error) {
+	resp, err := f.client.ExplorerHash(ctx, address.String())
+	if err != nil {
+		if strings.Contains(err.Error(), "unrecognized hash") {
+			return types.ZeroCurrency, false, nil // address never used
+		}
+		return types.Currency{}, false, fmt.Errorf("failed to get transactions for address %s: %v", address.String(), err)
+	}
+	outputs := make(map[types.CoinOutputID]types.Currency)
+	for _, block := range resp.Blocks {
+		for i, mp := range block.RawBlock.MinerPayouts {
+			if mp.UnlockHash == address && i < len(block.MinerPayoutIDs) {
+				outputs[block.MinerPayoutIDs[i]] = mp.Value
+			}
+		}
+	}
+	spent := make(map[types.CoinOutputID]struct{})
+	for _, txn := range resp.Transactions {
+		for i, uh := range txn.CoinOutputUnlockHashes {
+			if uh == address && i < len(txn.CoinOutputIDs) && i < len(txn.RawTransaction.CoinOutputs) {
+				outputs[txn.CoinOutputIDs[i]] = txn.RawTransaction.CoinOutputs[i].Value
+				pending = pending || txn.Unconfirmed
+			}
+		}
+		for _, ci := range txn.RawTransaction.CoinInputs {
+			spent[ci.ParentID] = struct{}{}
+		}
+	}
+	balance = types.ZeroCurrency
+	for id, value := range outputs {
+		if _, ok := spent[id]; !ok {
+			balance = balance.Add(value)
+		}
+	}
+	return balance, pending, nil
+}
+
+// checkDeauthorization checks whether the given address can be deauthorized,
+// returning a *fundedAddressError if it still holds unspent coins and the faucet refuses
+// to deauthorize such addresses, unless forced, or if coins are still being sent to it.
+// A warning is logged for funded addresses otherwise.
+func (f *faucet) checkDeauthorization(ctx context.Context, address types.UnlockHash, force bool) error {
+	balance, pending, err := f.addressFunds(ctx, address)
+	if err != nil {
+		return err
+	}
+	if balance.IsZero() {
+		return nil
+	}
+	balanceStr := client.NewCurrencyConvertor(f.network.CurrencyUnits(), f.network.CoinUnit).ToCoinStringWithUnit(balance)
+	// a block combining the deauthorization with a transaction paying to the address is invalid,
+	// and would be rejected by the block creator, which is why this can never be forced
+	if pending || (f.deauthFunded == deauthFundedRefuse && !force) {
+		return &fundedAddressError{address: address, balance: balanceStr, pending: pending}
+	}
+	log.Printf("[WARN] Deauthorizing address %s which still holds %s, these coins cannot be spent until it is authorized again\n",
+		address.String(), balanceStr)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// defaultLanguage is used for browsers accepting none of the supported languages,
+// and for messages missing in the catalog of a language
+const defaultLanguage = "en"
+
+// catalogs contains the messages of the web UI per language,
+// using the placeholders {chain}, {network}, {amount}, {unit} and {version}.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"title":                        "{chain} {network} faucet",
+		"language":                     "English",
+		"noscript":                     "This faucet requires JavaScript. The API is documented in the repository of the faucet.",
+		"address":                      "Address",
+		"applicant":                    "Applicant reference (KYC)",
+		"request.title":                "Request {amount} {unit}",
+		"request.intro":                "Enter your address to receive {amount} {unit}. Addresses which are not authorized yet get authorized first.",
+		"request.submit":               "Request {amount} {unit}",
+		"proof.intro":                  "This address is not authorized yet. Prove you control it by signing the following message using the key of the address, e.g. using goldchainc wallet signmessage <address> \"<message>\", and paste the signature below.",
+		"proof.signature":              "Signature",
+		"status.title":                 "Request status",
+		"status.kyc-pending":           "Your authorization request awaits the decision of the KYC provider. You can close this page and come back later.",
+		"status.authorization-pending": "Your address is being authorized, waiting for the authorization to be confirmed.",
+		"status.drip-pending":          "{amount} {unit} have been sent, waiting for the transaction to be confirmed.",
+		"status.confirmed":             "{amount} {unit} have been sent to your address.",
+		"status.rejected":              "The authorization of your address has been rejected by the KYC provider.",
+		"status.failed":                "Your request failed.",
+		"status.unknown":               "This request is unknown, requests are only kept track of for a day.",
+		"status.new":                   "New request",
+		"step.kyc":                     "KYC decision",
+		"step.authorization":           "Authorization",
+		"step.drip":                    "Drip",
+		"step.confirmed":               "Confirmed",
+		"transaction":                  "transaction",
+		"deauthorize.title":            "Deauthorize an address",
+		"deauthorize.force":            "Deauthorize even if the address still holds coins",
+		"deauthorize.submit":           "Deauthorize",
+		"deauthorize.done":             "The address has been deauthorized by transaction",
+		"error.unavailable":            "The faucet could not be reached, please try again later.",
+		"footer":                       "{chain} faucet v{version}",
+	},
+	"nl": {
+		"title":                        "{chain} {network} faucet",
+		"language":                     "Nederlands",
+		"noscript":                     "Deze faucet vereist JavaScript. De API is gedocumenteerd in de repository van de faucet.",
+		"address":                      "Adres",
+		"applicant":                    "Referentie van de aanvrager (KYC)",
+		"request.title":                "Vraag {amount} {unit} aan",
+		"request.intro":                "Geef je adres in om {amount} {unit} te ontvangen. Adressen die nog niet geautoriseerd zijn, worden eerst geautoriseerd.",
+		"request.submit":               "Vraag {amount} {unit} aan",
+		"proof.intro":                  "Dit adres is nog niet geautoriseerd. Bewijs dat je het beheert door het volgende bericht te ondertekenen met de sleutel van het adres, bijvoorbeeld met goldchainc wallet signmessage <adres> \"<bericht>\", en plak de handtekening hieronder.",
+		"proof.signature":              "Handtekening",
+		"status.title":                 "Status van de aanvraag",
+		"status.kyc-pending":           "Je autorisatieaanvraag wacht op de beslissing van de KYC-provider. Je kan deze pagina sluiten en later terugkomen.",
+		"status.authorization-pending": "Je adres wordt geautoriseerd, wachten tot de autorisatie bevestigd is.",
+		"status.drip-pending":          "{amount} {unit} werden verstuurd, wachten tot de transactie bevestigd is.",
+		"status.confirmed":             "{amount} {unit} werden naar je adres verstuurd.",
+		"status.rejected":              "De autorisatie van je adres werd door de KYC-provider geweigerd.",
+		"status.failed":                "Je aanvraag is mislukt.",
+		"status.unknown":               "Deze aanvraag is onbekend, aanvragen worden slechts een dag bijgehouden.",
+		"status.new":                   "Nieuwe aanvraag",
+		"step.kyc":                     "KYC-beslissing",
+		"step.authorization":           "Autorisatie",
+		"step.drip":                    "Uitbetaling",
+		"step.confirmed":               "Bevestigd",
+		"transaction":                  "transactie",
+		"deauthorize.title":            "Een adres deautoriseren",
+		"deauthorize.force":            "Deautoriseren, ook als het adres nog munten bevat",
+		"deauthorize.submit":           "Deautoriseren",
+		"deauthorize.done":             "Het adres werd gedeautoriseerd door transactie",
+		"error.unavailable":            "De faucet is niet bereikbaar, probeer het later opnieuw.",
+		"footer":                       "{chain} faucet v{version}",
+	},
+	"fr": {
+		"title":                        "Faucet {chain} {network}",
+		"language":                     "Français",
+		"noscript":                     "Ce faucet nécessite JavaScript. L'API est documentée dans le dépôt du faucet.",
+		"address":                      "Adresse",
+		"applicant":                    "Référence du demandeur (KYC)",
+		"request.title":                "Demander {amount} {unit}",
+		"request.intro":                "Saisissez votre adresse pour recevoir {amount} {unit}. Les adresses qui ne sont pas encore autorisées le sont d'abord.",
+		"request.submit":               "Demander {amount} {unit}",
+		"proof.intro":                  "Cette adresse n'est pas encore autorisée. Prouvez que vous la contrôlez en signant le message suivant avec la clé de l'adresse, par exemple avec goldchainc wallet signmessage <adresse> \"<message>\", et collez la signature ci-dessous.",
+		"proof.signature":              "Signature",
+		"status.title":                 "État de la demande",
+		"status.kyc-pending":           "Votre demande d'autorisation attend la décision du prestataire KYC. Vous pouvez fermer cette page et revenir plus tard.",
+		"status.authorization-pending": "Votre adresse est en cours d'autorisation, en attente de la confirmation de l'autorisation.",
+		"status.drip-pending":          "{amount} {unit} ont été envoyés, en attente de la confirmation de la transaction.",
+		"status.confirmed":             "{amount} {unit} ont été envoyés à votre adresse.",
+		"status.rejected":              "L'autorisation de votre adresse a été refusée par le prestataire KYC.",
+		"status.failed":                "Votre demande a échoué.",
+		"status.unknown":               "Cette demande est inconnue, les demandes ne sont conservées que pendant un jour.",
+		"status.new":                   "Nouvelle demande",
+		"step.kyc":                     "Décision KYC",
+		"step.authorization":           "Autorisation",
+		"step.drip":                    "Envoi",
+		"step.confirmed":               "Confirmé",
+		"transaction":                  "transaction",
+		"deauthorize.title":            "Désautoriser une adresse",
+		"deauthorize.force":            "Désautoriser même si l'adresse détient encore des coins",
+		"deauthorize.submit":           "Désautoriser",
+		"deauthorize.done":             "L'adresse a été désautorisée par la transaction",
+		"error.unavailable":            "Le faucet est injoignable, veuillez réessayer plus tard.",
+		"footer":                       "Faucet {chain} v{version}",
+	},
+}
+
+// languages returns the supported languages, sorted.
+func languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// negotiateLanguage returns the language of the web UI for the request:
+// the language given by the lang query parameter if supported,
+// or else the first supported language accepted by the browser.
+func negotiateLanguage(r *http.Request) string {
+	if lang := r.URL.Query().Get("lang"); catalogs[lang] != nil {
+		return lang
+	}
+	// the languages are assumed to be listed in order of preference, ignoring their quality values
+	for _, accepted := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		lang := strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])
+		lang = strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+		if catalogs[lang] != nil {
+			return lang
+		}
+	}
+	return defaultLanguage
+}
+
+// translate returns the messages of the given language, falling back to the default language for missing messages,
+// with the placeholders replaced using the given replacer.
+func translate(lang string, replacer *strings.Replacer) map[string]string {
+	messages := make(map[string]string, len(catalogs[defaultLanguage]))
+	for key, message := range catalogs[defaultLanguage] {
+		messages[key] = replacer.Replace(message)
+	}
+	for key, message := range catalogs[lang] {
+		messages[key] = replacer.Replace(message)
+	}
+	return messages
+}
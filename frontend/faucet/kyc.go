@@ -0,0 +1,395 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// KYC providers which can be used by the faucet in authorizer mode
+const (
+	// kycProviderREST forwards applications to the REST endpoint of a KYC provider
+	kycProviderREST = "rest"
+	// kycProviderManual leaves all decisions to an operator, posting them to the webhook
+	kycProviderManual = "manual"
+)
+
+const (
+	// kycSubmitTimeout is the maximum time a KYC provider gets to accept an application
+	kycSubmitTimeout = 30 * time.Second
+	// kycSignatureHeader contains the hex-encoded HMAC-SHA256 of the webhook body,
+	// using the webhook secret of the faucet as key
+	kycSignatureHeader = "X-KYC-Signature"
+)
+
+// kycDecision is the decision of a KYC provider about an application.
+type kycDecision string
+
+const (
+	kycPending  kycDecision = "pending"
+	kycApproved kycDecision = "approved"
+	kycRejected kycDecision = "rejected"
+)
+
+var (
+	// errKYCUnknownRequest is returned for decisions about requests unknown to the faucet
+	errKYCUnknownRequest = errors.New("unknown KYC request")
+	// errKYCDecided is returned for decisions about requests which were already decided
+	errKYCDecided = errors.New("KYC request was already decided")
+	// errKYCInvalidSignature is returned for webhook calls which are not signed using the webhook secret
+	errKYCInvalidSignature = errors.New("invalid KYC webhook signature")
+)
+
+// kycApplication is forwarded to the KYC provider for every authorization request.
+type kycApplication struct {
+	RequestID string           `json:"requestid"`
+	Address   types.UnlockHash `json:"address"`
+	// Applicant identifies the applicant at the KYC provider, as given by the requester
+	Applicant string `json:"applicant,omitempty"`
+	// CallbackURL is the webhook asynchronous decisions are to be posted to
+	CallbackURL string `json:"callbackurl,omitempty"`
+}
+
+// kycVerdict is the decision of a KYC provider, returned when submitting an application,
+// or posted to the webhook of the faucet should the decision be asynchronous.
+type kycVerdict struct {
+	RequestID string      `json:"requestid,omitempty"`
+	Decision  kycDecision `json:"decision"`
+	Reason    string      `json:"reason,omitempty"`
+}
+
+func (verdict kycVerdict) validate() error {
+	switch verdict.Decision {
+	case kycPending, kycApproved, kycRejected:
+		return nil
+	default:
+		return fmt.Errorf("invalid KYC decision %q", verdict.Decision)
+	}
+}
+
+// kycProvider decides whether the applicant requesting the authorization of an address passes KYC.
+type kycProvider interface {
+	// Submit forwards the application to the provider, returning a pending verdict
+	// should the decision be posted to the webhook of the faucet later on.
+	Submit(ctx context.Context, application kycApplication) (kycVerdict, error)
+}
+
+func newKYCProvider(name, url, token string) (kycProvider, error) {
+	switch name {
+	case kycProviderManual:
+		return manualKYCProvider{}, nil
+	case kycProviderREST:
+		if url == "" {
+			return nil, errors.New("the rest KYC provider requires a URL")
+		}
+		return &restKYCProvider{url: url, token: token, client: &http.Client{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown KYC provider %q", name)
+	}
+}
+
+// manualKYCProvider leaves all decisions to an operator, who posts them to the webhook.
+type manualKYCProvider struct{}
+
+// Submit implements kycProvider.Submit
+func (manualKYCProvider) Submit(_ context.Context, application kycApplication) (kycVerdict, error) {
+	log.Printf("[INFO] KYC request %s for address %s (applicant %q) awaits a manual decision\n",
+		application.RequestID, application.Address.String(), application.Applicant)
+	return kycVerdict{Decision: kycPending}, nil
+}
+
+// restKYCProvider posts applications as JSON to the REST endpoint of a KYC provider,
+// which responds with its verdict, or with status 202 should it decide asynchronously.
+type restKYCProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// Submit implements kycProvider.Submit
+func (provider *restKYCProvider) Submit(ctx context.Context, application kycApplication) (kycVerdict, error) {
+	data, err := json.Marshal(application)
+	if err != nil {
+		return kycVerdict{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, provider.url, bytes.NewReader(data))
+	if err != nil {
+		return kycVerdict{}, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	if provider.token != "" {
+		req.Header.Set("Authorization", "Bearer "+provider.token)
+	}
+	resp, err := provider.client.Do(req)
+	if err != nil {
+		return kycVerdict{}, fmt.Errorf("failed to submit KYC application: %v", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return kycVerdict{Decision: kycPending}, nil
+	case http.StatusOK:
+		var verdict kycVerdict
+		err = json.NewDecoder(resp.Body).Decode(&verdict)
+		if err != nil {
+			return kycVerdict{}, fmt.Errorf("failed to decode KYC verdict: %v", err)
+		}
+		return verdict, verdict.validate()
+	default:
+		body, _ := ioutil.ReadAll(resp.Body)
+		return kycVerdict{}, fmt.Errorf("KYC provider responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+}
+
+// kycRequest tracks an authorization request forwarded to the KYC provider.
+type kycRequest struct {
+	ID        string           `json:"id"`
+	Address   types.UnlockHash `json:"address"`
+	Applicant string           `json:"applicant,omitempty"`
+	// Drip is true if coins are to be dripped to the address once authorized
+	Drip    bool        `json:"drip"`
+	Status  kycDecision `json:"status"`
+	Reason  string      `json:"reason,omitempty"`
+	Created time.Time   `json:"created"`
+	Decided *time.Time  `json:"decided,omitempty"`
+	// AuthorizationTxID is undefined until the address of an approved request is authorized
+	AuthorizationTxID *types.TransactionID `json:"authorizationtxid,omitempty"`
+	DripTxID          *types.TransactionID `json:"driptxid,omitempty"`
+	// Error is the reason an approved request failed to be fulfilled
+	Error string `json:"error,omitempty"`
+}
+
+// kycRequestStore keeps track of all KYC requests, persisted as a JSON file.
+type kycRequestStore struct {
+	path string
+
+	mu       sync.Mutex
+	requests map[string]kycRequest
+}
+
+func loadKYCRequestStore(path string) (*kycRequestStore, error) {
+	store := &kycRequestStore{
+		path:     path,
+		requests: make(map[string]kycRequest),
+	}
+	if path == "" {
+		return store, nil // in-memory only
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	err = json.NewDecoder(file).Decode(&store.requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode KYC request store %s: %v", path, err)
+	}
+	return store, nil
+}
+
+// Get returns the request with the given ID.
+func (store *kycRequestStore) Get(id string) (kycRequest, bool) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	request, ok := store.requests[id]
+	return request, ok
+}
+
+// Put adds or updates the given request.
+func (store *kycRequestStore) Put(request kycRequest) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.requests[request.ID] = request
+	return store.save()
+}
+
+// Delete removes the request with the given ID.
+func (store *kycRequestStore) Delete(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.requests, id)
+	return store.save()
+}
+
+// Decide applies the verdict to the pending request with the given ID,
+// returning the updated request. A pending verdict leaves the request untouched.
+func (store *kycRequestStore) Decide(id string, verdict kycVerdict) (kycRequest, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	request, ok := store.requests[id]
+	if !ok {
+		return kycRequest{}, errKYCUnknownRequest
+	}
+	if request.Status != kycPending {
+		return request, errKYCDecided
+	}
+	if verdict.Decision == kycPending {
+		return request, nil
+	}
+	now := time.Now()
+	request.Status = verdict.Decision
+	request.Reason = verdict.Reason
+	request.Decided = &now
+	store.requests[id] = request
+	return request, store.save()
+}
+
+func (store *kycRequestStore) save() error {
+	if store.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(store.requests, "", "\t")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first, so we never end up with a half-written store
+	tmpPath := store.path + ".tmp"
+	err = writeFile(tmpPath, data)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, store.path)
+}
+
+// kycAuthorizer forwards authorization requests to a KYC provider,
+// only authorizing addresses once the provider approves them.
+type kycAuthorizer struct {
+	provider kycProvider
+	requests *kycRequestStore
+	// callbackURL is the public URL of the webhook of the faucet, passed to the provider
+	callbackURL string
+	// webhookSecret is used to verify the signature of decisions posted to the webhook
+	webhookSecret []byte
+}
+
+// VerifySignature verifies the hex-encoded HMAC-SHA256 signature of a webhook body.
+// All signatures are refused without webhook secret, as anyone can sign using an empty key.
+func (kyc *kycAuthorizer) VerifySignature(body []byte, signature string) error {
+	if len(kyc.webhookSecret) == 0 {
+		return errKYCInvalidSignature
+	}
+	expected, err := hex.DecodeString(signature)
+	if err != nil || len(expected) == 0 {
+		return errKYCInvalidSignature
+	}
+	mac := hmac.New(sha256.New, kyc.webhookSecret)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return errKYCInvalidSignature
+	}
+	return nil
+}
+
+func newRequestID() (string, error) {
+	var id [16]byte
+	_, err := rand.Read(id[:])
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// requestKYCAuthorization forwards the authorization request to the KYC provider,
+// authorizing the address (and dripping coins to it, if requested) immediately should the provider approve it.
+// The returned request is pending should the provider decide asynchronously.
+func (f *faucet) requestKYCAuthorization(ctx context.Context, address types.UnlockHash, applicant string, drip bool) (kycRequest, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return kycRequest{}, err
+	}
+	request := kycRequest{
+		ID:        id,
+		Address:   address,
+		Applicant: applicant,
+		Drip:      drip,
+		Status:    kycPending,
+		Created:   time.Now(),
+	}
+	// store the request prior to submitting it, as the provider might call the webhook right away
+	err = f.kyc.requests.Put(request)
+	if err != nil {
+		return kycRequest{}, err
+	}
+	submitCtx, cancel := context.WithTimeout(ctx, kycSubmitTimeout)
+	defer cancel()
+	verdict, err := f.kyc.provider.Submit(submitCtx, kycApplication{
+		RequestID:   id,
+		Address:     address,
+		Applicant:   applicant,
+		CallbackURL: f.kyc.callbackURL,
+	})
+	if err != nil {
+		if err := f.kyc.requests.Delete(id); err != nil {
+			log.Println("[ERROR] Failed to update KYC request store:", err)
+		}
+		return kycRequest{}, err
+	}
+	return f.decideKYCRequest(ctx, id, verdict, false)
+}
+
+// decideKYCRequest applies the verdict of the KYC provider to the request with the given ID,
+// authorizing the address once approved. Requests which require coins to be dripped as well
+// are fulfilled in the background should async be true, as that requires the authorization to be confirmed.
+func (f *faucet) decideKYCRequest(ctx context.Context, id string, verdict kycVerdict, async bool) (kycRequest, error) {
+	request, err := f.kyc.requests.Decide(id, verdict)
+	if err != nil {
+		return request, err
+	}
+	switch request.Status {
+	case kycPending:
+		return request, nil
+	case kycRejected:
+		log.Printf("[INFO] KYC request %s for address %s rejected: %s\n", id, request.Address.String(), request.Reason)
+		return request, nil
+	}
+	log.Printf("[INFO] KYC request %s for address %s approved\n", id, request.Address.String())
+	if async && request.Drip {
+		go f.fulfilKYCRequest(context.Background(), request)
+		return request, nil
+	}
+	return f.fulfilKYCRequest(ctx, request)
+}
+
+// fulfilKYCRequest authorizes the address of an approved request, and drips coins to it if requested.
+func (f *faucet) fulfilKYCRequest(ctx context.Context, request kycRequest) (kycRequest, error) {
+	var err error
+	if request.Drip {
+		var dripTxID types.TransactionID
+		request.AuthorizationTxID, dripTxID, err = f.authorizeAndDrip(ctx, request.Address)
+		if err == nil {
+			request.DripTxID = &dripTxID
+		}
+	} else {
+		var txID types.TransactionID
+		txID, err = f.updateAddressAuthorization(ctx, request.Address, true)
+		if err == nil {
+			request.AuthorizationTxID = &txID
+		}
+	}
+	if err != nil {
+		log.Printf("[ERROR] Failed to fulfil approved KYC request %s: %v\n", request.ID, err)
+		request.Error = err.Error()
+	}
+	if err := f.kyc.requests.Put(request); err != nil {
+		log.Println("[ERROR] Failed to update KYC request store:", err)
+	}
+	return request, err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func signKYCWebhook(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestKYCWebhook(t *testing.T) {
+	const body = `{"requestid": "request", "decision": "rejected", "reason": "unknown applicant"}`
+	testCases := []struct {
+		name      string
+		secret    string
+		signature string
+		header    bool
+		status    int
+	}{
+		{name: "valid signature", secret: "secret", signature: signKYCWebhook("secret", body), header: true, status: http.StatusOK},
+		{name: "signature of another secret", secret: "secret", signature: signKYCWebhook("other", body), header: true, status: http.StatusUnauthorized},
+		{name: "signature of another body", secret: "secret", signature: signKYCWebhook("secret", body+" "), header: true, status: http.StatusUnauthorized},
+		{name: "invalid signature", secret: "secret", signature: "not hex", header: true, status: http.StatusUnauthorized},
+		{name: "empty signature", secret: "secret", header: true, status: http.StatusUnauthorized},
+		{name: "missing header", secret: "secret", status: http.StatusUnauthorized},
+		{name: "missing secret", signature: signKYCWebhook("", body), header: true, status: http.StatusUnauthorized},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			requests, err := loadKYCRequestStore("")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = requests.Put(kycRequest{ID: "request", Status: kycPending, Created: time.Now()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			f := &faucet{kyc: &kycAuthorizer{requests: requests, webhookSecret: []byte(testCase.secret)}}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/kyc/webhook", strings.NewReader(body))
+			if testCase.header {
+				req.Header.Set(kycSignatureHeader, testCase.signature)
+			}
+			rec := httptest.NewRecorder()
+			f.requestKYCDecision(rec, req)
+			if rec.Code != testCase.status {
+				t.Fatalf("expected status %d, got %d: %s", testCase.status, rec.Code, rec.Body.String())
+			}
+
+			// only decisions signed using the secret are applied
+			expected := kycPending
+			if testCase.status == http.StatusOK {
+				expected = kycRejected
+			}
+			request, _ := requests.Get("request")
+			if request.Status != expected {
+				t.Errorf("expected the request to be %s, got %s", expected, request.Status)
+			}
+		})
+	}
+}
+
+func TestLoadKYCAuthorizerRequiresSecret(t *testing.T) {
+	defer func(name, secret string) { kycProviderName, kycWebhookSecret = name, secret }(kycProviderName, kycWebhookSecret)
+	kycProviderName, kycWebhookSecret = kycProviderManual, ""
+	if _, err := loadKYCAuthorizer("", ""); err == nil {
+		t.Error("expected the authorizer mode to require a webhook secret")
+	}
+	kycWebhookSecret = "secret"
+	if _, err := loadKYCAuthorizer("", ""); err != nil {
+		t.Errorf("expected the authorizer to load: %v", err)
+	}
+}
@@ -1,76 +1,304 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
 	"github.com/nbh-digital/goldchain/pkg/config"
 	"github.com/threefoldtech/rivine/extensions/authcointx"
 	"github.com/threefoldtech/rivine/modules"
-	"github.com/threefoldtech/rivine/pkg/api"
-	"github.com/threefoldtech/rivine/pkg/daemon"
 	"github.com/threefoldtech/rivine/types"
 
 	gtypes "github.com/nbh-digital/goldchain/pkg/types"
 )
 
 type faucet struct {
+	// client talks to the daemon (with unlocked wallet) of the network of this faucet
+	client *goldchainclient.Client
 	// cts is a cached version of daemon constants
 	// caching here avoids requiring a call to the daemon even if it is local
 	cts *modules.DaemonConstants
+	// network describes how coins are presented on the network of the daemon
+	network config.NetworkDescriptor
+	// fundAmount is the amount of coins given in a single transaction, in whole coins
+	fundAmount uint64
 	// coinsToGive is the amount of coins given in a single transaction
 	coinsToGive types.Currency
 
+	// authorizations keeps track of all addresses authorized by this faucet
+	authorizations *authorizationStore
+	// audit links drips to the authorizations they required
+	audit *dripAuditLog
+	// deauthFunded defines whether addresses which still hold unspent coins
+	// are refused or deauthorized with a warning
+	deauthFunded string
+	// kyc forwards authorization requests to a KYC provider,
+	// undefined unless the faucet runs in authorizer mode
+	kyc *kycAuthorizer
+	// challenges keeps track of the challenges to sign in order to authorize an address,
+	// undefined unless the faucet requires a proof of control of the addresses it authorizes
+	challenges *challengeStore
+	// drips keeps track of the drip requests of the web UI
+	drips *dripRequestStore
+	// limiter limits the drips per client and address, shared by the faucets of all networks
+	limiter *rateLimiter
+	// reauth tracks the auth address updates until they are confirmed,
+	// resubmitting those failing due to a rotation of the auth condition
+	reauth *reauthQueue
+
 	// lock to protect the fund endpoints. This ensures the wallet
 	// we talk to only has 1 tx in progress at the same time
 	mu sync.Mutex
 }
 
 var (
-	websitePort int
-	httpClient  = &api.HTTPClient{
-		RootURL:   "http://localhost:22110",
-		Password:  "",
-		UserAgent: daemon.RivineUserAgent,
-	}
-	coinsToGive uint64 = 300
+	websitePort     int
+	daemonAddresses = "http://localhost:22110"
+	daemonPassword  string
+	coinsToGive     uint64 = 300
+	fundAmounts     string
+
+	rateLimit         = 5
+	rateLimitInterval = time.Hour
+	trustForwardedFor bool
+
+	authorizationsFile    = "authorizations.json"
+	auditFile             = "drips.log"
+	authorizationTimeout  = 10 * time.Minute
+	confirmationTimeout   = 10 * time.Minute
+	dormantAfterDays      uint
+	dormantCheckInterval  = 24 * time.Hour
+	dormantExemptAddreses string
+	deauthFundedPolicy    = deauthFundedRefuse
+
+	kycProviderName  string
+	kycProviderURL   string
+	kycProviderToken string
+	kycCallbackURL   string
+	kycWebhookSecret string
+	kycRequestsFile  = "kyc-requests.json"
+
+	requireOwnershipProof = true
+
+	reauthInterval = time.Minute
+	notifyURL      string
+
+	devnetSetup bool
 )
 
-func getDaemonConstants() (*modules.DaemonConstants, error) {
-	var constants modules.DaemonConstants
-	err := httpClient.GetAPI("/daemon/constants", &constants)
+// parseFundAmounts parses a comma-separated list of network=amount pairs.
+func parseFundAmounts(str string) (map[string]uint64, error) {
+	amounts := make(map[string]uint64)
+	for _, s := range strings.Split(str, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid fund amount %q, expected network=amount", s)
+		}
+		amount, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fund amount %q: %v", s, err)
+		}
+		amounts[strings.TrimSpace(parts[0])] = amount
+	}
+	return amounts, nil
+}
+
+// networkFile returns the file used by the faucet of the given network, should the faucet serve multiple networks,
+// inserting the name of the network before the extension of the given file, e.g. authorizations.testnet.json.
+func networkFile(path, network string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + network + ext
+}
+
+func loadKYCAuthorizer(requestsFile, callbackURL string) (*kycAuthorizer, error) {
+	provider, err := newKYCProvider(kycProviderName, kycProviderURL, kycProviderToken)
+	if err != nil {
+		return nil, err
+	}
+	if kycWebhookSecret == "" {
+		return nil, errors.New("authorizer mode requires a KYC webhook secret")
+	}
+	requests, err := loadKYCRequestStore(requestsFile)
 	if err != nil {
 		return nil, err
 	}
-	return &constants, nil
+	return &kycAuthorizer{
+		provider:      provider,
+		requests:      requests,
+		callbackURL:   callbackURL,
+		webhookSecret: []byte(kycWebhookSecret),
+	}, nil
+}
+
+// loadFaucet loads the faucet of the network of the daemon at the given address.
+// Should the faucet serve multiple networks, each network keeps track of its authorizations,
+// drips and KYC requests in its own files.
+func loadFaucet(daemonAddress string, amounts map[string]uint64, multiNetwork bool, limiter *rateLimiter) (*faucet, error) {
+	c := goldchainclient.New(daemonAddress, daemonPassword)
+	log.Println("[INFO] Loading daemon constants of", daemonAddress)
+	cts, err := c.Constants(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	name := cts.ChainInfo.NetworkName
+	network, err := config.GetNetworkDescriptor(name)
+	if err != nil {
+		return nil, err
+	}
+	file := func(path string) string {
+		if multiNetwork {
+			return networkFile(path, name)
+		}
+		return path
+	}
+
+	log.Println("[INFO] Loading authorized addresses of", name)
+	authorizations, err := loadAuthorizationStore(file(authorizationsFile))
+	if err != nil {
+		return nil, err
+	}
+	amount, ok := amounts[name]
+	if !ok {
+		amount = coinsToGive
+	}
+	f := &faucet{
+		client:         c,
+		cts:            &cts,
+		network:        network,
+		fundAmount:     amount,
+		coinsToGive:    network.CurrencyUnits().OneCoin.Mul64(amount),
+		authorizations: authorizations,
+		audit:          &dripAuditLog{path: file(auditFile)},
+		drips:          newDripRequestStore(),
+		limiter:        limiter,
+		reauth:         newReauthQueue(),
+	}
+	if requireOwnershipProof {
+		f.challenges = newChallengeStore(name)
+	}
+
+	if devnetSetup {
+		if name != config.NetworkNameDev {
+			log.Println("[WARN] Skipping devnet setup of", name)
+		} else if err = f.setupDevnet(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	if kycProviderName != "" {
+		log.Println("[INFO] Loading KYC requests of", name)
+		f.kyc, err = loadKYCAuthorizer(file(kycRequestsFile), strings.Replace(kycCallbackURL, "{network}", name, -1))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// handler returns the web UI and API of the faucet, served at the given path prefix,
+// linking the web UI of the given networks.
+func (f *faucet) handler(prefix string, networks []*faucet) http.Handler {
+	mux := http.NewServeMux()
+	page := f.pageHandler(prefix, networks)
+	mux.HandleFunc("/", page)
+	mux.HandleFunc("/drips/", page)
+
+	// register API endpoint
+	mux.HandleFunc("/api/v1/coins", f.requestCoins)
+	mux.HandleFunc("/api/v1/authorize", f.requestAuthorization)
+	if f.challenges != nil {
+		mux.HandleFunc("/api/v1/challenge", f.requestChallenge)
+	}
+	mux.HandleFunc("/api/v1/deauthorize", f.requestDeauthorization)
+	mux.HandleFunc("/api/v1/authorize-and-drip", f.requestAuthorizationAndCoins)
+	mux.HandleFunc("/api/v1/drips", f.requestDripRequest)
+	mux.HandleFunc("/api/v1/drips/", f.requestDripStatus)
+	mux.HandleFunc("/api/v1/validate-address", f.requestAddressValidation)
+	if f.kyc != nil {
+		mux.HandleFunc("/api/v1/kyc/webhook", f.requestKYCDecision)
+		mux.HandleFunc("/api/v1/kyc/requests/", f.requestKYCRequest)
+	}
+	return mux
 }
 
 func main() {
+	flag.Parse()
+	// flags not defined on the command line can be defined using GOLDCHAIN_<FLAG> environment variables
+	if err := applyEnv(flag.CommandLine); err != nil {
+		panic(err)
+	}
 	log.Println("[INFO] Starting faucet")
-	log.Println("[INFO] Loading daemon constants")
-	cts, err := getDaemonConstants()
+	amounts, err := parseFundAmounts(fundAmounts)
+	if err != nil {
+		panic(err)
+	}
+	exemptAddresses, err := parseExemptAddresses(dormantExemptAddreses)
+	if err != nil {
+		panic(err)
+	}
+	deauthFunded, err := parseDeauthFundedPolicy(deauthFundedPolicy)
 	if err != nil {
 		panic(err)
 	}
 
-	f := faucet{
-		cts:         cts,
-		coinsToGive: config.GetTestnetGenesis().CurrencyUnits.OneCoin.Mul64(coinsToGive),
+	limiter := newRateLimiter(rateLimit, rateLimitInterval)
+	addresses := strings.Split(daemonAddresses, ",")
+	var faucets []*faucet
+	for _, address := range addresses {
+		f, err := loadFaucet(strings.TrimSpace(address), amounts, len(addresses) > 1, limiter)
+		if err != nil {
+			panic(err)
+		}
+		name := f.cts.ChainInfo.NetworkName
+		for _, other := range faucets {
+			if other.cts.ChainInfo.NetworkName == name {
+				panic(fmt.Errorf("multiple daemons of network %s", name))
+			}
+		}
+		f.deauthFunded = deauthFunded
+		faucets = append(faucets, f)
+		delete(amounts, name)
+
+		go f.deauthorizeDormantAddresses(dormantConfig{
+			After:    time.Duration(dormantAfterDays) * 24 * time.Hour,
+			Interval: dormantCheckInterval,
+			Exempt:   exemptAddresses,
+		})
+		go f.resubmitAuthorizations(reauthInterval)
+	}
+	for name := range amounts {
+		panic(fmt.Errorf("fund amount given for network %s, which is not served by any of the daemons", name))
 	}
 
 	log.Println("[INFO] Faucet listening on port", websitePort)
 
-	http.HandleFunc("/", f.requestFormHandler)
-	http.HandleFunc("/request/tokens", f.requestTokensHandler)
-	http.HandleFunc("/request/authorize", f.requestAuthorizationHandler)
-
-	// register API endpoint
-	http.HandleFunc("/api/v1/coins", f.requestCoins)
-	http.HandleFunc("/api/v1/authorize", f.requestAuthorization)
-	http.HandleFunc("/api/v1/deauthorize", f.requestDeauthorization)
+	// the first network is served at the root as well, as it is by a faucet serving a single network
+	http.Handle("/", faucets[0].handler("", faucets))
+	for _, f := range faucets {
+		prefix := "/" + f.cts.ChainInfo.NetworkName
+		http.Handle(prefix+"/", http.StripPrefix(prefix, f.handler(prefix, faucets)))
+		log.Printf("[INFO] Serving %s at %s/, dripping %d %s\n", f.cts.ChainInfo.NetworkName, prefix, f.fundAmount, f.network.CoinUnit)
+		if f.kyc != nil {
+			log.Printf("[INFO] Faucet runs in authorizer mode on %s, using the %s KYC provider\n", f.cts.ChainInfo.NetworkName, kycProviderName)
+		}
+	}
 
 	log.Println("[INFO] Faucet ready to serve")
 
@@ -79,10 +307,33 @@ func main() {
 
 func init() {
 	flag.IntVar(&websitePort, "port", 2020, "local port to expose this web faucet on")
-	flag.StringVar(&httpClient.Password, "daemon-password", httpClient.Password, "optional password, should the used daemon require it")
-	flag.StringVar(&httpClient.RootURL, "daemon-address", httpClient.RootURL, "address of the daemon (with unlocked wallet) to talk to")
+	flag.StringVar(&daemonPassword, "daemon-password", daemonPassword, "optional password, should the used daemons require it")
+	flag.StringVar(&daemonAddresses, "daemon-address", daemonAddresses, "comma-separated addresses of the daemons (with unlocked wallet) to talk to, one per network served, the first network being served at the root as well")
 	flag.Uint64Var(&coinsToGive, "fund-amount", coinsToGive, "amount of coins to give per drip of the faucet")
-	flag.Parse()
+	flag.StringVar(&fundAmounts, "fund-amounts", fundAmounts, "comma-separated network=amount pairs, overriding the fund amount for the given networks")
+	flag.IntVar(&rateLimit, "rate-limit", rateLimit, "maximum amount of drips per client IP and per address within the rate limit interval, shared by all networks, 0 disables it")
+	flag.DurationVar(&rateLimitInterval, "rate-limit-interval", rateLimitInterval, "interval in which the drips are rate limited")
+	flag.BoolVar(&trustForwardedFor, "trust-forwarded-for", trustForwardedFor, "rate limit the client IP given by the X-Forwarded-For header, for faucets behind a reverse proxy")
+	flag.StringVar(&authorizationsFile, "authorizations-file", authorizationsFile, "file used to keep track of the addresses authorized by this faucet, empty to keep them in memory only")
+	flag.StringVar(&auditFile, "audit-file", auditFile, "file to which drips combined with the authorization of the address are appended, empty to only log them")
+	flag.DurationVar(&authorizationTimeout, "authorization-timeout", authorizationTimeout, "maximum time to wait for the authorization of an address to be confirmed, before dripping coins to it")
+	flag.DurationVar(&confirmationTimeout, "confirmation-timeout", confirmationTimeout, "maximum time to wait for a drip requested through the web UI to be confirmed")
+	flag.UintVar(&dormantAfterDays, "deauth-dormant-after", dormantAfterDays, "deauthorize testnet addresses inactive for the given amount of days, 0 disables it")
+	flag.DurationVar(&dormantCheckInterval, "deauth-dormant-interval", dormantCheckInterval, "interval in which to check for dormant addresses")
+	flag.StringVar(&dormantExemptAddreses, "deauth-dormant-exempt", dormantExemptAddreses, "comma-separated list of addresses never to deauthorize for being dormant")
+	flag.StringVar(&deauthFundedPolicy, "deauth-funded", deauthFundedPolicy, fmt.Sprintf(
+		"policy for deauthorizing addresses which still hold coins: %s (unless forced by the request) or %s", deauthFundedRefuse, deauthFundedWarn))
+	flag.StringVar(&kycProviderName, "kyc-provider", kycProviderName, fmt.Sprintf(
+		"run as authorizer, only authorizing addresses approved by the given KYC provider: %s or %s, empty to authorize all addresses", kycProviderREST, kycProviderManual))
+	flag.StringVar(&kycProviderURL, "kyc-url", kycProviderURL, "endpoint of the rest KYC provider, to which applications are posted")
+	flag.StringVar(&kycProviderToken, "kyc-token", kycProviderToken, "optional bearer token used to authenticate to the rest KYC provider")
+	flag.StringVar(&kycCallbackURL, "kyc-callback-url", kycCallbackURL, "public URL of the KYC webhook of this faucet, passed to the KYC provider, {network} being replaced by the name of the network")
+	flag.StringVar(&kycWebhookSecret, "kyc-webhook-secret", kycWebhookSecret, "secret used to verify the HMAC-SHA256 signature of the decisions posted to the KYC webhook, required in authorizer mode")
+	flag.BoolVar(&requireOwnershipProof, "ownership-proof", requireOwnershipProof, "require a challenge signed using the key of an address to authorize it, proving control of the address")
+	flag.DurationVar(&reauthInterval, "reauth-interval", reauthInterval, "interval in which unconfirmed authorization transactions are checked, resubmitting those signed using a previous auth condition, 0 disables it")
+	flag.StringVar(&notifyURL, "notify-url", notifyURL, "optional URL to which operator notifications (e.g. resubmitted authorization transactions) are posted as JSON")
+	flag.BoolVar(&devnetSetup, "devnet-setup", devnetSetup, "create the wallet of a devnet daemon using the devnet genesis mnemonic, unless it exists already, and authorize its genesis address, such that the faucet is usable without manual steps")
+	flag.StringVar(&kycRequestsFile, "kyc-requests-file", kycRequestsFile, "file used to keep track of the requests forwarded to the KYC provider, empty to keep them in memory only")
 
 	// register tx versions for authentication
 	_ = authcointx.NewPlugin(
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	gtypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// challengeValidity is the time within which a challenge has to be signed and used
+	challengeValidity = 15 * time.Minute
+	// maxChallengesPerAddress is the maximum amount of open challenges per address,
+	// issuing another one forgets the oldest challenge of the address
+	maxChallengesPerAddress = 3
+	// maxChallenges is the maximum amount of open challenges of all addresses,
+	// no challenge is issued once reached, until some expire or are used
+	maxChallenges = 10000
+)
+
+var (
+	// errOwnershipProofRequired is returned when an address is to be authorized without a signed challenge
+	errOwnershipProofRequired = errors.New("authorizing an address requires a signed challenge proving control of the address")
+	// errUnknownChallenge is returned for challenges which are unknown, expired, used already or issued for another address
+	errUnknownChallenge = errors.New("unknown or expired challenge, sign the new challenge")
+	// errMultiSigOwnership is returned when a multisig address is to be authorized,
+	// as control of a multisig address cannot be proven by signing a challenge
+	errMultiSigOwnership = errors.New("only single signature addresses can be authorized, as control of a multisig address cannot be proven by a signed challenge")
+	// errTooManyChallenges is returned when no challenge can be issued, as too many challenges are open
+	errTooManyChallenges = errors.New("too many open challenges, try again later")
+)
+
+// ownershipChallenge is a message to be signed using the key of an address,
+// proving control of that address in order to authorize it.
+type ownershipChallenge struct {
+	ID      string           `json:"challenge"`
+	Address types.UnlockHash `json:"address"`
+	// Message is the message to sign, e.g. using goldchainc wallet signmessage <address> <message>
+	Message string    `json:"message"`
+	Expires time.Time `json:"expires"`
+}
+
+// challengeStore keeps track of the challenges issued by the faucet in memory, until they expire or are used.
+// Challenges are not persisted, as a restart merely requires the challenge to be signed again.
+type challengeStore struct {
+	network string
+
+	mu         sync.Mutex
+	challenges map[string]ownershipChallenge
+}
+
+func newChallengeStore(network string) *challengeStore {
+	return &challengeStore{network: network, challenges: make(map[string]ownershipChallenge)}
+}
+
+// Issue issues a new challenge for the given address, forgetting all expired challenges,
+// as well as the oldest challenge of the address should it have too many open challenges.
+// errTooManyChallenges is returned should the faucet have too many open challenges.
+func (store *challengeStore) Issue(address types.UnlockHash) (ownershipChallenge, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return ownershipChallenge{}, err
+	}
+	now := time.Now()
+	challenge := ownershipChallenge{
+		ID:      id,
+		Address: address,
+		// the network is part of the message, such that a signature cannot be used on the faucet of another network
+		Message: fmt.Sprintf("Authorize %s on the goldchain %s faucet (challenge %s)", address.String(), store.network, id),
+		Expires: now.Add(challengeValidity),
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	var open []ownershipChallenge
+	for id, c := range store.challenges {
+		if now.After(c.Expires) {
+			delete(store.challenges, id)
+		} else if c.Address.Cmp(address) == 0 {
+			open = append(open, c)
+		}
+	}
+	if len(open) >= maxChallengesPerAddress {
+		sort.Slice(open, func(i, j int) bool { return open[i].Expires.Before(open[j].Expires) })
+		for _, c := range open[:len(open)-maxChallengesPerAddress+1] {
+			delete(store.challenges, c.ID)
+		}
+	}
+	if len(store.challenges) >= maxChallenges {
+		return ownershipChallenge{}, errTooManyChallenges
+	}
+	store.challenges[id] = challenge
+	return challenge, nil
+}
+
+// Use verifies the signature of the challenge with the given ID, issued for the given address,
+// forgetting the challenge once verified, such that it can only be used once.
+func (store *challengeStore) Use(address types.UnlockHash, id string, signature gtypes.MessageSignature) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	challenge, ok := store.challenges[id]
+	if !ok || challenge.Address.Cmp(address) != 0 || time.Now().After(challenge.Expires) {
+		return errUnknownChallenge
+	}
+	err := signature.Verify(address, challenge.Message)
+	if err != nil {
+		return err
+	}
+	delete(store.challenges, id)
+	return nil
+}
+
+// ownershipProof is the signed challenge given in the body of the authorization requests.
+type ownershipProof struct {
+	Challenge string `json:"challenge"`
+	// Signature is the signature of the message of the challenge, formatted as <public key>:<signature>
+	Signature string `json:"signature"`
+}
+
+// verifyOwnership verifies that the requester proved control of the address to authorize,
+// writing status 401 along with a new challenge should the proof be missing or invalid.
+// In case requireUnauthorized is true, no proof is required for addresses which are authorized already.
+func (f *faucet) verifyOwnership(ctx context.Context, w http.ResponseWriter, address types.UnlockHash, proof ownershipProof, requireUnauthorized bool) bool {
+	if f.challenges == nil {
+		return true
+	}
+	if requireUnauthorized {
+		authorized, err := f.isAuthorized(ctx, address)
+		if err != nil {
+			log.Println("[ERROR] Failed to check whether an ownership proof is required:", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return false
+		}
+		if authorized {
+			return true
+		}
+	}
+	if address.Type != types.UnlockTypePubKey {
+		writeAddressError(w, errMultiSigOwnership)
+		return false
+	}
+	err := errOwnershipProofRequired
+	if proof.Challenge != "" || proof.Signature != "" {
+		var sig gtypes.MessageSignature
+		err = sig.LoadString(proof.Signature)
+		if err == nil {
+			err = f.challenges.Use(address, proof.Challenge, sig)
+		}
+		if err == nil {
+			return true
+		}
+		log.Printf("[INFO] Refusing ownership proof of %s: %v\n", address.String(), err)
+	}
+	challenge, cerr := f.challenges.Issue(address)
+	if cerr != nil {
+		writeChallengeError(w, cerr)
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(struct {
+		Error     string             `json:"error"`
+		Challenge ownershipChallenge `json:"challenge"`
+	}{Error: err.Error(), Challenge: challenge})
+	return false
+}
+
+// requestChallenge issues a challenge to be signed in order to authorize the address of the request.
+func (f *faucet) requestChallenge(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	body := struct {
+		Address string `json:"address"`
+	}{}
+
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	address, err := f.network.ParseAddress(body.Address)
+	if err != nil {
+		writeAddressError(w, err)
+		return
+	}
+	if address.Type != types.UnlockTypePubKey {
+		writeAddressError(w, errMultiSigOwnership)
+		return
+	}
+
+	challenge, err := f.challenges.Issue(address)
+	if err != nil {
+		writeChallengeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(challenge)
+}
+
+// writeChallengeError writes the failure to issue a challenge,
+// status 503 should too many challenges be open, status 500 otherwise.
+func writeChallengeError(w http.ResponseWriter, err error) {
+	if err != errTooManyChallenges {
+		log.Println("[ERROR] Failed to issue challenge:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	log.Println("[WARN] Refusing to issue a challenge:", err)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(challengeValidity.Seconds())))
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	gtypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func testOwner() (types.UnlockHash, crypto.SecretKey) {
+	sk, pk := crypto.GenerateKeyPair()
+	return types.NewPubKeyUnlockHash(types.Ed25519PublicKey(pk)), sk
+}
+
+func TestChallengeStoreUse(t *testing.T) {
+	store := newChallengeStore("devnet")
+	address, sk := testOwner()
+	other, otherSK := testOwner()
+
+	challenge, err := store.Issue(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if challenge.Address.Cmp(address) != 0 || !challenge.Expires.After(time.Now()) {
+		t.Fatalf("unexpected challenge: %+v", challenge)
+	}
+	signature := gtypes.SignMessage(challenge.Message, sk)
+
+	// the challenge has to be signed by the key of the address it was issued for
+	if err = store.Use(other, challenge.ID, gtypes.SignMessage(challenge.Message, otherSK)); err != errUnknownChallenge {
+		t.Errorf("expected the challenge of another address to be refused, got %v", err)
+	}
+	if err = store.Use(address, challenge.ID, gtypes.SignMessage(challenge.Message, otherSK)); err == nil {
+		t.Error("expected a signature of another key to be refused")
+	}
+	if err = store.Use(address, challenge.ID, gtypes.SignMessage("another message", sk)); err == nil {
+		t.Error("expected a signature of another message to be refused")
+	}
+	if err = store.Use(address, "unknown", signature); err != errUnknownChallenge {
+		t.Errorf("expected an unknown challenge to be refused, got %v", err)
+	}
+
+	// a challenge can only be used once
+	if err = store.Use(address, challenge.ID, signature); err != nil {
+		t.Fatalf("expected the signed challenge to be accepted: %v", err)
+	}
+	if err = store.Use(address, challenge.ID, signature); err != errUnknownChallenge {
+		t.Errorf("expected a replayed challenge to be refused, got %v", err)
+	}
+}
+
+func TestChallengeStoreExpiry(t *testing.T) {
+	store := newChallengeStore("devnet")
+	address, sk := testOwner()
+	challenge, err := store.Issue(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an expired challenge is refused, even if signed correctly
+	challenge.Expires = time.Now().Add(-time.Second)
+	store.challenges[challenge.ID] = challenge
+	if err = store.Use(address, challenge.ID, gtypes.SignMessage(challenge.Message, sk)); err != errUnknownChallenge {
+		t.Errorf("expected an expired challenge to be refused, got %v", err)
+	}
+
+	// and forgotten once another challenge is issued
+	if _, err = store.Issue(address); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.challenges[challenge.ID]; ok || len(store.challenges) != 1 {
+		t.Errorf("expected the expired challenge to be forgotten, got %d challenges", len(store.challenges))
+	}
+}
+
+func TestChallengeStoreLimits(t *testing.T) {
+	store := newChallengeStore("devnet")
+	address, sk := testOwner()
+
+	// issuing more challenges than allowed per address forgets the oldest one
+	var challenges []ownershipChallenge
+	for i := 0; i <= maxChallengesPerAddress; i++ {
+		challenge, err := store.Issue(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// challenges issued within the same clock tick would expire at the same time
+		challenge.Expires = challenge.Expires.Add(time.Duration(i) * time.Second)
+		store.challenges[challenge.ID] = challenge
+		challenges = append(challenges, challenge)
+	}
+	if len(store.challenges) != maxChallengesPerAddress {
+		t.Fatalf("expected %d open challenges, got %d", maxChallengesPerAddress, len(store.challenges))
+	}
+	oldest := challenges[0]
+	if err := store.Use(address, oldest.ID, gtypes.SignMessage(oldest.Message, sk)); err != errUnknownChallenge {
+		t.Errorf("expected the oldest challenge to be forgotten, got %v", err)
+	}
+
+	// no challenge is issued once the store is full, until challenges expire
+	for i := len(store.challenges); i < maxChallenges; i++ {
+		other, _ := testOwner()
+		store.challenges[other.String()] = ownershipChallenge{ID: other.String(), Address: other, Expires: time.Now().Add(challengeValidity)}
+	}
+	fresh, _ := testOwner()
+	if _, err := store.Issue(fresh); err != errTooManyChallenges {
+		t.Fatalf("expected no challenge to be issued by a full store, got %v", err)
+	}
+	latest := challenges[len(challenges)-1]
+	if err := store.Use(address, latest.ID, gtypes.SignMessage(latest.Message, sk)); err != nil {
+		t.Errorf("expected the open challenges to remain usable by a full store: %v", err)
+	}
+	for id, challenge := range store.challenges {
+		challenge.Expires = time.Now().Add(-time.Second)
+		store.challenges[id] = challenge
+	}
+	if _, err := store.Issue(fresh); err != nil {
+		t.Errorf("expected a challenge to be issued once the open challenges expired: %v", err)
+	}
+	if len(store.challenges) != 1 {
+		t.Errorf("expected the expired challenges to be forgotten, got %d challenges", len(store.challenges))
+	}
+}
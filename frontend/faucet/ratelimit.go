@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// rateLimiter limits the amount of drips per client and per address within a sliding interval,
+// shared by the faucets of all networks, such that serving multiple networks does not multiply the limit.
+type rateLimiter struct {
+	// limit is the maximum amount of drips per key within the interval, 0 disables the limiter
+	limit    int
+	interval time.Duration
+
+	mu    sync.Mutex
+	drips map[string][]time.Time
+}
+
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:    limit,
+		interval: interval,
+		drips:    make(map[string][]time.Time),
+	}
+}
+
+// Allow records a drip for all given keys, unless any of them exceeded the limit,
+// in which case the time to wait until the drip is allowed is returned.
+func (limiter *rateLimiter) Allow(keys ...string) (bool, time.Duration) {
+	if limiter.limit <= 0 {
+		return true, 0
+	}
+	limiter.mu.Lock()
+	defer limiter.mu.Unlock()
+	now := time.Now()
+	// forget all drips outside of the interval, as they no longer count
+	for key, drips := range limiter.drips {
+		for len(drips) > 0 && now.Sub(drips[0]) >= limiter.interval {
+			drips = drips[1:]
+		}
+		if len(drips) == 0 {
+			delete(limiter.drips, key)
+		} else {
+			limiter.drips[key] = drips
+		}
+	}
+	var wait time.Duration
+	for _, key := range keys {
+		if drips := limiter.drips[key]; len(drips) >= limiter.limit {
+			if w := limiter.interval - now.Sub(drips[len(drips)-limiter.limit]); w > wait {
+				wait = w
+			}
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+	for _, key := range keys {
+		limiter.drips[key] = append(limiter.drips[key], now)
+	}
+	return true, 0
+}
+
+// allowDrip checks the shared rate limiter for a drip to the given address, requested by the client of the request,
+// writing status 429 should the limit be exceeded.
+func (f *faucet) allowDrip(w http.ResponseWriter, r *http.Request, address types.UnlockHash) bool {
+	ip := clientIP(r)
+	ok, wait := f.limiter.Allow("ip:"+ip, "address:"+address.String())
+	if ok {
+		return true
+	}
+	log.Printf("[INFO] Refusing drip to %s requested by %s: rate limit exceeded\n", address.String(), ip)
+	wait = wait.Round(time.Second) + time.Second
+	w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())))
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: fmt.Sprintf("rate limit of %d drips per %v exceeded, try again in %v", f.limiter.limit, f.limiter.interval, wait)})
+	return false
+}
+
+// clientIP returns the IP address of the client of the request,
+// as forwarded by the reverse proxy in front of the faucet, if trusted.
+func clientIP(r *http.Request) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
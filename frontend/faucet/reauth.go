@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// maxReauthAttempts is the maximum amount of times an auth address update is rebuilt and resubmitted,
+	// after which the operator has to authorize the addresses manually
+	maxReauthAttempts = 3
+	// notifyTimeout is the time allowed to post a notification to the operator
+	notifyTimeout = 10 * time.Second
+)
+
+// The events the operator is notified of.
+const (
+	// notifyReauthorized is sent when an auth address update is rebuilt and resubmitted,
+	// as it failed since the auth condition of the chain changed after it was signed
+	notifyReauthorized = "reauthorized"
+	// notifyReauthorizationFailed is sent when rebuilding or resubmitting an auth address update failed
+	notifyReauthorizationFailed = "reauthorization-failed"
+	// notifyAuthorizationDropped is sent when an auth address update failed while the auth condition did not change,
+	// in which case it is not resubmitted
+	notifyAuthorizationDropped = "authorization-dropped"
+)
+
+// pendingAuthUpdate is an auth address update transaction submitted by the faucet, which is not confirmed yet.
+type pendingAuthUpdate struct {
+	TransactionID   types.TransactionID
+	AuthAddresses   []types.UnlockHash
+	DeauthAddresses []types.UnlockHash
+	// Condition is the unlock hash of the auth condition active when the transaction was signed
+	Condition types.UnlockHash
+	// Attempts is the amount of times the update was rebuilt and resubmitted
+	Attempts int
+}
+
+// reauthQueue keeps track of the auth address update transactions submitted by the faucet until they are confirmed,
+// such that those failing after an auth condition rotation can be rebuilt and resubmitted.
+// The queue is kept in memory only.
+type reauthQueue struct {
+	mu      sync.Mutex
+	pending map[types.TransactionID]pendingAuthUpdate
+}
+
+func newReauthQueue() *reauthQueue {
+	return &reauthQueue{pending: make(map[types.TransactionID]pendingAuthUpdate)}
+}
+
+// Add tracks the given auth address update until it is removed.
+func (q *reauthQueue) Add(update pendingAuthUpdate) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[update.TransactionID] = update
+}
+
+// Remove stops tracking the auth address update with the given transaction ID.
+func (q *reauthQueue) Remove(id types.TransactionID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.pending, id)
+}
+
+// Snapshot returns a copy of all pending auth address updates.
+func (q *reauthQueue) Snapshot() []pendingAuthUpdate {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	updates := make([]pendingAuthUpdate, 0, len(q.pending))
+	for _, update := range q.pending {
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+// operatorNotification is logged, and posted as JSON to the notification URL of the faucet should one be configured.
+type operatorNotification struct {
+	Network string    `json:"network"`
+	Event   string    `json:"event"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	// TransactionID is the ID of the auth address update the notification is about
+	TransactionID types.TransactionID `json:"transactionid"`
+	// ResubmittedAs is the ID of the rebuilt auth address update, only defined if it was resubmitted
+	ResubmittedAs   *types.TransactionID `json:"resubmittedas,omitempty"`
+	AuthAddresses   []types.UnlockHash   `json:"authaddresses,omitempty"`
+	DeauthAddresses []types.UnlockHash   `json:"deauthaddresses,omitempty"`
+}
+
+// notifyOperator logs the given notification, and posts it to the notification URL in the background.
+func (f *faucet) notifyOperator(n operatorNotification) {
+	n.Network = f.cts.ChainInfo.NetworkName
+	n.Time = time.Now()
+	log.Printf("[WARN] %s (%s)\n", n.Message, n.Event)
+	if notifyURL == "" {
+		return
+	}
+	go func() {
+		data, err := json.Marshal(n)
+		if err != nil {
+			log.Println("[ERROR] Failed to encode operator notification:", err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		req, err := http.NewRequest(http.MethodPost, notifyURL, bytes.NewReader(data))
+		if err != nil {
+			log.Println("[ERROR] Failed to notify operator:", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+		if err != nil {
+			log.Println("[ERROR] Failed to notify operator:", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			log.Printf("[ERROR] Failed to notify operator: notification URL responded with status %d\n", resp.StatusCode)
+		}
+	}()
+}
+
+// resubmitAuthorizations runs the re-authorization job until the process exits,
+// checking the pending auth address updates at the given interval, 0 disabling the job.
+func (f *faucet) resubmitAuthorizations(interval time.Duration) {
+	if interval == 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		f.checkPendingAuthorizations(context.Background())
+	}
+}
+
+// checkPendingAuthorizations forgets the pending auth address updates which are confirmed,
+// and rebuilds and resubmits those which failed as the auth condition of the chain changed since they were signed.
+func (f *faucet) checkPendingAuthorizations(ctx context.Context) {
+	pending := f.reauth.Snapshot()
+	if len(pending) == 0 {
+		return
+	}
+	condition, err := f.client.AuthCondition(ctx)
+	if err != nil {
+		log.Println("[ERROR] Failed to get the auth condition:", err)
+		return
+	}
+	for _, update := range pending {
+		status, err := f.client.TransactionStatus(ctx, update.TransactionID)
+		if err != nil && !goldchainclient.IsStatus(err, http.StatusNotFound) {
+			log.Printf("[ERROR] Failed to get the status of auth address update %s: %v\n", update.TransactionID.String(), err)
+			continue
+		}
+		// a transaction unknown to the daemon (e.g. as it restarted) is no longer in its transaction pool
+		if err == nil {
+			switch status.Status {
+			case goldchainapi.TransactionStatusConfirmed:
+				f.reauth.Remove(update.TransactionID)
+				continue
+			case goldchainapi.TransactionStatusUnconfirmed:
+				continue
+			}
+		}
+
+		// the update left the transaction pool without being confirmed
+		f.reauth.Remove(update.TransactionID)
+		auth, deauth, err := f.outstandingAuthUpdate(ctx, update)
+		if err != nil {
+			log.Printf("[ERROR] Failed to check auth address update %s: %v\n", update.TransactionID.String(), err)
+			f.reauth.Add(update)
+			continue
+		}
+		if len(auth) == 0 && len(deauth) == 0 {
+			continue // the addresses got their requested state anyway
+		}
+		update.AuthAddresses, update.DeauthAddresses = auth, deauth
+		if update.Condition == condition.UnlockHash() {
+			f.notifyOperator(operatorNotification{
+				Event:           notifyAuthorizationDropped,
+				Message:         fmt.Sprintf("auth address update %s was not confirmed, while the auth condition did not change", update.TransactionID.String()),
+				TransactionID:   update.TransactionID,
+				AuthAddresses:   auth,
+				DeauthAddresses: deauth,
+			})
+			continue
+		}
+		f.reauthorize(ctx, update)
+	}
+}
+
+// outstandingAuthUpdate returns the addresses of the given update of which the authorization state
+// still differs from the requested one.
+func (f *faucet) outstandingAuthUpdate(ctx context.Context, update pendingAuthUpdate) ([]types.UnlockHash, []types.UnlockHash, error) {
+	addresses := append(append([]types.UnlockHash(nil), update.AuthAddresses...), update.DeauthAddresses...)
+	states, err := f.client.GetAuthStatus(ctx, addresses...)
+	if err != nil {
+		return nil, nil, err
+	}
+	var auth, deauth []types.UnlockHash
+	for i, address := range update.AuthAddresses {
+		if !states[i] {
+			auth = append(auth, address)
+		}
+	}
+	for i, address := range update.DeauthAddresses {
+		if states[len(update.AuthAddresses)+i] {
+			deauth = append(deauth, address)
+		}
+	}
+	return auth, deauth, nil
+}
+
+// reauthorize rebuilds the given auth address update, signed using the current auth condition, and resubmits it.
+// An update failing to be resubmitted is retried at the next check, until it was attempted maxReauthAttempts times.
+func (f *faucet) reauthorize(ctx context.Context, update pendingAuthUpdate) {
+	notification := operatorNotification{
+		TransactionID:   update.TransactionID,
+		AuthAddresses:   update.AuthAddresses,
+		DeauthAddresses: update.DeauthAddresses,
+	}
+	if update.Attempts >= maxReauthAttempts {
+		notification.Event = notifyReauthorizationFailed
+		notification.Message = fmt.Sprintf("gave up resubmitting auth address update %s after %d attempts, the addresses have to be updated manually",
+			update.TransactionID.String(), update.Attempts)
+		f.notifyOperator(notification)
+		return
+	}
+	update.Attempts++
+	txID, signedUnder, err := f.submitAuthorizationUpdate(ctx, update.AuthAddresses, update.DeauthAddresses)
+	if err != nil {
+		// e.g. the wallet of the daemon cannot sign using the new auth condition yet
+		notification.Event = notifyReauthorizationFailed
+		notification.Message = fmt.Sprintf("failed to resubmit auth address update %s, signed using a previous auth condition (attempt %d of %d): %v",
+			update.TransactionID.String(), update.Attempts, maxReauthAttempts, err)
+		f.notifyOperator(notification)
+		f.reauth.Add(update)
+		return
+	}
+	f.reauth.Add(pendingAuthUpdate{
+		TransactionID:   txID,
+		AuthAddresses:   update.AuthAddresses,
+		DeauthAddresses: update.DeauthAddresses,
+		Condition:       signedUnder,
+		Attempts:        update.Attempts,
+	})
+	notification.Event = notifyReauthorized
+	notification.Message = fmt.Sprintf("resubmitted auth address update %s, signed using a previous auth condition, as %s",
+		update.TransactionID.String(), txID.String())
+	notification.ResubmittedAs = &txID
+	f.notifyOperator(notification)
+}
@@ -1,10 +1,7 @@
 package main
 
 import (
-	"fmt"
 	"html/template"
-
-	"github.com/nbh-digital/goldchain/pkg/config"
 )
 
 func mustTemplate(title, text string) *template.Template {
@@ -12,94 +9,297 @@ func mustTemplate(title, text string) *template.Template {
 	return template.Must(p.Parse(text))
 }
 
-// RequestBody is used to render the request.html template
-type RequestBody struct {
-	ChainName    string
-	ChainNetwork string
-	CoinUnit     string
-	Error        string
+// PageBody is used to render the page.html template
+type PageBody struct {
+	// Language is the language of the messages
+	Language  string
+	Messages  map[string]string
+	Languages []PageLanguage
+	// Prefix is the path prefix of the web UI and API of the network, empty at the root
+	Prefix string
+	// Networks links the web UI of all networks served, undefined if only one network is served
+	Networks []PageNetwork
+	// KYC is true if the faucet runs in authorizer mode, forwarding authorization requests to a KYC provider
+	KYC bool
+}
+
+// PageLanguage links a supported language in the page.html template
+type PageLanguage struct {
+	Code string
+	Name string
+}
+
+// PageNetwork links the web UI of a network in the page.html template
+type PageNetwork struct {
+	Name string
+	// Current is true for the network of the page
+	Current bool
 }
 
-var requestTemplate = mustTemplate("request.html", fmt.Sprintf(`
+// pageTemplate is the single page of the web UI. It requests drips and deauthorizations using the API,
+// and polls the status of a drip request at <prefix>/drips/<id>, such that the status page can be revisited.
+var pageTemplate = mustTemplate("page.html", `<!DOCTYPE html>
+<html lang="{{.Language}}">
 <head>
-	<title>{{.CoinUnit}} Faucet</title>
+	<meta charset="utf-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1">
+	<title>{{index .Messages "title"}}</title>
+	<style>
+		body { font-family: sans-serif; margin: 0; color: #222; background: #fafafa; }
+		main { max-width: 46em; margin: 0 auto; padding: 2em 1em; }
+		nav { text-align: right; }
+		nav a { margin-left: 0.5em; }
+		nav.networks { text-align: center; }
+		nav.networks a.current { font-weight: bold; text-decoration: none; color: inherit; }
+		h1 { text-align: center; margin: 1em 0; }
+		section { background: #fff; border: 1px solid #ddd; border-radius: 4px; padding: 1em 1.5em; margin-bottom: 2em; }
+		label { display: block; margin: 0.75em 0; }
+		input[type=text] { box-sizing: border-box; width: 100%; padding: 0.4em; font-family: monospace; }
+		button { padding: 0.5em 2em; font-weight: bold; font-size: 1em; }
+		code { word-break: break-all; }
+		pre.challenge { white-space: pre-wrap; word-break: break-all; background: #f4f4f4; padding: 0.5em; }
+		.error { color: #b00; font-weight: bold; }
+		.info { color: #060; font-weight: bold; }
+		ol.steps { list-style: none; padding: 0; display: flex; }
+		ol.steps li { flex: 1; text-align: center; padding: 0.5em; border-bottom: 4px solid #ddd; color: #888; }
+		ol.steps li.done { border-color: #060; color: #060; }
+		ol.steps li.active { border-color: #c90; color: #222; font-weight: bold; }
+		ol.steps li.failed { border-color: #b00; color: #b00; }
+		footer { text-align: center; }
+	</style>
 </head>
 <body>
-	<div align="center">
-		<h1 style="margin-top:3em">{{.ChainName}} {{.ChainNetwork}} faucet</h1>
-
-		{{if .Error}}
-		<div style="margin:50px;display:inline-flex;align-items:center;border:3px solid red;padding:10px;background:#ffe5e5;">
-			<div style="font-size:80px;border:2px solid red;border-radius:50%%;width:80px;color:red;line-height:80px;">!</div>
-			<div style="color:red;display:inline-block;padding: 0 20px;font-weight:bold">{{.Error}}</div>
-		</div>
-		{{end}}
-
-		<h3>Request %[1]d {{.CoinUnit}} by entering your address below and submitting the form.</h3>
-		<form action="/request/tokens" method="POST">
-			<div>Address: <input type="text" size="78" name="uh"></div>
-			<br>
-			<div><input type="submit" value="Request %[1]d {{.CoinUnit}}" style="width:20em;height:2em;font-weight:bold;font-size:1em;"></div>
+<main>
+	<nav>{{range .Languages}}<a href="?lang={{.Code}}" hreflang="{{.Code}}">{{.Name}}</a>{{end}}</nav>
+	<h1>{{index .Messages "title"}}</h1>
+	{{if .Networks}}<nav class="networks">{{range .Networks}}<a href="/{{.Name}}/"{{if .Current}} class="current"{{end}}>{{.Name}}</a>{{end}}</nav>{{end}}
+	<noscript><p class="error">{{index .Messages "noscript"}}</p></noscript>
+
+	<section id="request">
+		<h2>{{index .Messages "request.title"}}</h2>
+		<p>{{index .Messages "request.intro"}}</p>
+		<form id="request-form">
+			<label>{{index .Messages "address"}} <input type="text" name="address" required></label>
+			{{if .KYC}}<label>{{index .Messages "applicant"}} <input type="text" name="applicant"></label>{{end}}
+			<div class="proof" hidden>
+				<p>{{index .Messages "proof.intro"}}</p>
+				<pre class="challenge"></pre>
+				<label>{{index .Messages "proof.signature"}} <input type="text" name="signature"></label>
+			</div>
+			<p class="error" hidden></p>
+			<button type="submit">{{index .Messages "request.submit"}}</button>
 		</form>
+	</section>
 
-		<h3 style="margin-top:50px;">Request authorization or deauthorization by entering your address below and submitting the form.</h3>
-		<form action="/request/authorize" method="POST">
-			<div>Address: <input type="text" size="78" name="uh"></div>
-			<br>
-			<input type="radio" name="authorize" value="true" checked>Authorize<br>
-			<input type="radio" name="authorize" value="false">Deauthorize<br>
-			<br>
-			<div><input type="submit" value="Request address authorization update" style="width:20em;height:2em;font-weight:bold;font-size:1em;"></div>
+	<section id="status" hidden>
+		<h2>{{index .Messages "status.title"}}</h2>
+		<p><code class="address"></code></p>
+		<ol class="steps">
+			{{if .KYC}}<li data-status="kyc-pending">{{index .Messages "step.kyc"}}</li>{{end}}
+			<li data-status="authorization-pending">{{index .Messages "step.authorization"}}</li>
+			<li data-status="drip-pending">{{index .Messages "step.drip"}}</li>
+			<li data-status="confirmed">{{index .Messages "step.confirmed"}}</li>
+		</ol>
+		<p class="message"></p>
+		<p class="error" hidden></p>
+		<ul class="transactions"></ul>
+		<p><a href="{{.Prefix}}/">{{index .Messages "status.new"}}</a></p>
+	</section>
+
+	<section id="deauthorize">
+		<h2>{{index .Messages "deauthorize.title"}}</h2>
+		<form id="deauthorize-form">
+			<label>{{index .Messages "address"}} <input type="text" name="address" required></label>
+			<label><input type="checkbox" name="force"> {{index .Messages "deauthorize.force"}}</label>
+			<p class="error" hidden></p>
+			<p class="info" hidden></p>
+			<button type="submit">{{index .Messages "deauthorize.submit"}}</button>
 		</form>
-	
-		<div style="margin-top:50px;"><small>{{.ChainName}} faucet v%s</small></div>
-	</div>
-</body>
-`, coinsToGive, config.Version.String()))
-
-// CoinConfirmationBody is used to render the coinconfirmation.html template
-type CoinConfirmationBody struct {
-	ChainName     string
-	ChainNetwork  string
-	CoinUnit      string
-	Address       string
-	TransactionID string
-}
+	</section>
 
-var coinConfirmationTemplate = mustTemplate("coinconfirmation.html", fmt.Sprintf(`
-<head>
-	<title>{{.CoinUnit}} Faucet</title>
-</head>
-<body>
-	<div align="center">
-		<h1>%d {{.CoinUnit}} succesfully transferred on {{.ChainName}}'s {{.ChainNetwork}} to {{.Address}}</h1>
-		<p>You can look up the transaction using the following ID:</p>
-		<div><code>{{.TransactionID}}</code></div>
-		<div style="margin-top:50px;"><small>{{.ChainName}} faucet v%s</small></div>
-	</div>
-</body>
-`, coinsToGive, config.Version.String()))
-
-// AuthorizationConfirmationBody is used to render the authorizationconfirmation.html page
-type AuthorizationConfirmationBody struct {
-	ChainName     string
-	ChainNetwork  string
-	CoinUnit      string
-	Address       string
-	Action        string
-	TransactionID string
-}
+	<footer><small>{{index .Messages "footer"}}</small></footer>
+</main>
+<script>
+(function () {
+	var messages = {{.Messages}};
+	var prefix = {{.Prefix}};
+	var pollInterval = 3000;
+	var steps = ["kyc-pending", "authorization-pending", "drip-pending", "confirmed"];
+	var final = { "confirmed": true, "rejected": true, "failed": true };
+	// challenge is the last challenge to sign in order to authorize the address, issued by the faucet
+	var challenge = null;
 
-var authorizationConfirmationTemplate = mustTemplate("authorizationconfirmation.html", fmt.Sprintf(`
-<head>
-	<title>{{.CoinUnit}} Faucet</title>
-</head>
-<body>
-	<div align="center">
-		<h1>Succesfully {{.Action}} address {{.Address}} on {{.ChainName}}'s {{.ChainNetwork}}</h1>
-		<p>You can look up the transaction using the following ID:</p>
-		<div><code>{{.TransactionID}}</code></div>
-		<div style="margin-top:50px;"><small>{{.ChainName}} faucet v%s</small></div>
-	</div>
+	function $(section, selector) {
+		return document.querySelector("#" + section + " " + selector);
+	}
+
+	function showError(section, message) {
+		var el = $(section, ".error");
+		el.textContent = message || "";
+		el.hidden = !message;
+	}
+
+	// call the API, passing the decoded body (if any) and the status to done
+	function call(method, path, body, done) {
+		var xhr = new XMLHttpRequest();
+		xhr.open(method, path);
+		xhr.setRequestHeader("Content-Type", "application/json");
+		xhr.onload = function () {
+			var data = null;
+			try { data = JSON.parse(xhr.responseText); } catch (e) {}
+			done(xhr.status, data);
+		};
+		xhr.onerror = function () { done(0, null); };
+		xhr.send(body ? JSON.stringify(body) : null);
+	}
+
+	function errorMessage(status, data) {
+		if (data && data.error) {
+			return data.error;
+		}
+		return messages["error.unavailable"];
+	}
+
+	function transactionLink(label, id, url) {
+		var item = document.createElement("li");
+		item.appendChild(document.createTextNode(label + ": "));
+		var code = document.createElement("code");
+		code.textContent = id;
+		if (url) {
+			var link = document.createElement("a");
+			link.href = url;
+			link.appendChild(code);
+			item.appendChild(link);
+		} else {
+			item.appendChild(code);
+		}
+		return item;
+	}
+
+	function renderStatus(request) {
+		$("status", ".address").textContent = request.address;
+		var current = steps.indexOf(request.status);
+		if (current < 0) {
+			// rejected or failed at the step following the last one reached
+			current = request.driptxid ? 2 : request.authorizationtxid ? 1 : request.kycrequestid ? 0 : 1;
+		}
+		var items = document.querySelectorAll("#status ol.steps li");
+		for (var i = 0; i < items.length; i++) {
+			var step = steps.indexOf(items[i].getAttribute("data-status"));
+			var done = step < current || request.status === "confirmed";
+			items[i].className = done ? "done" : step === current ? (final[request.status] ? "failed" : "active") : "";
+		}
+		$("status", ".message").textContent = messages["status." + request.status] || request.status;
+		showError("status", request.error);
+		var transactions = $("status", ".transactions");
+		transactions.innerHTML = "";
+		if (request.authorizationtxid) {
+			transactions.appendChild(transactionLink(messages["step.authorization"] + " " + messages["transaction"], request.authorizationtxid, request.authorizationtxurl));
+		}
+		if (request.driptxid) {
+			transactions.appendChild(transactionLink(messages["step.drip"] + " " + messages["transaction"], request.driptxid, request.driptxurl));
+		}
+	}
+
+	function poll(id) {
+		call("GET", prefix + "/api/v1/drips/" + encodeURIComponent(id), null, function (status, data) {
+			if (status === 404) {
+				$("status", ".message").textContent = messages["status.unknown"];
+				return;
+			}
+			if (status !== 200 || !data) {
+				showError("status", messages["error.unavailable"]);
+				setTimeout(function () { poll(id); }, pollInterval);
+				return;
+			}
+			renderStatus(data);
+			if (!final[data.status]) {
+				setTimeout(function () { poll(id); }, pollInterval);
+			}
+		});
+	}
+
+	function showStatus(id) {
+		document.getElementById("request").hidden = true;
+		document.getElementById("deauthorize").hidden = true;
+		document.getElementById("status").hidden = false;
+		poll(id);
+	}
+
+	document.getElementById("request-form").addEventListener("submit", function (event) {
+		event.preventDefault();
+		var form = event.target;
+		var body = { address: form.address.value.trim() };
+		if (form.applicant) {
+			body.applicant = form.applicant.value.trim();
+		}
+		if (challenge && challenge.address === body.address && form.signature.value.trim()) {
+			body.challenge = challenge.challenge;
+			body.signature = form.signature.value.trim();
+		}
+		showError("request", "");
+		form.querySelector("button").disabled = true;
+		call("POST", prefix + "/api/v1/drips", body, function (status, data) {
+			form.querySelector("button").disabled = false;
+			if (status === 401 && data && data.challenge) {
+				// the address has to be authorized, which requires the challenge to be signed
+				challenge = data.challenge;
+				$("request", ".challenge").textContent = challenge.message;
+				$("request", ".proof").hidden = false;
+				form.signature.value = "";
+				showError("request", body.signature ? data.error : "");
+				return;
+			}
+			if (status !== 202 || !data) {
+				showError("request", errorMessage(status, data));
+				return;
+			}
+			history.pushState(null, "", prefix + "/drips/" + data.id + location.search);
+			renderStatus(data);
+			showStatus(data.id);
+		});
+	});
+
+	// point out what is wrong with an address as soon as it is entered, rather than once coins are requested
+	document.querySelector("#request-form input[name=address]").addEventListener("change", function (event) {
+		var address = event.target.value.trim();
+		if (!address) {
+			showError("request", "");
+			return;
+		}
+		call("GET", prefix + "/api/v1/validate-address?addr=" + encodeURIComponent(address), null, function (status, data) {
+			if (status === 200 && data && event.target.value.trim() === address) {
+				showError("request", data.valid ? "" : data.error);
+			}
+		});
+	});
+
+	document.getElementById("deauthorize-form").addEventListener("submit", function (event) {
+		event.preventDefault();
+		var form = event.target;
+		var info = $("deauthorize", ".info");
+		info.hidden = true;
+		showError("deauthorize", "");
+		form.querySelector("button").disabled = true;
+		call("POST", prefix + "/api/v1/deauthorize", { address: form.address.value.trim(), force: form.force.checked }, function (status, data) {
+			form.querySelector("button").disabled = false;
+			if (status !== 200 || !data) {
+				showError("deauthorize", errorMessage(status, data));
+				return;
+			}
+			info.textContent = messages["deauthorize.done"] + " " + data.txid;
+			info.hidden = false;
+		});
+	});
+
+	window.addEventListener("popstate", function () { location.reload(); });
+
+	var match = /^\/drips\/([0-9a-f]+)$/.exec(location.pathname.slice(prefix.length));
+	if (match) {
+		showStatus(match[1]);
+	}
+})();
+</script>
 </body>
-`, config.Version.String()))
+</html>
+`)
@@ -1,100 +1,223 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/threefoldtech/rivine/extensions/authcointx"
-	"github.com/threefoldtech/rivine/pkg/api"
 	"github.com/threefoldtech/rivine/types"
 
+	goldchainclient "github.com/nbh-digital/goldchain/pkg/client"
 	gtypes "github.com/nbh-digital/goldchain/pkg/types"
-	authapi "github.com/threefoldtech/rivine/extensions/authcointx/api"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+const (
+	// pollInterval is the interval at which the authorization state of an address,
+	// or the chain, is checked while waiting for a transaction to be confirmed
+	pollInterval = 5 * time.Second
 )
 
 var (
 	// errUnauthorized is returned when an address wants to receive coins, but
 	// is currently unauthorized
 	errUnauthorized = errors.New("can't send coins to a currently unauthorized address")
+	// errAuthorizationTimeout is returned when the authorization of an address
+	// did not get confirmed in time, in order to drip coins to it
+	errAuthorizationTimeout = errors.New("timed out waiting for the authorization of the address to be confirmed")
+	// errConfirmationTimeout is returned when a transaction did not get confirmed in time
+	errConfirmationTimeout = errors.New("timed out waiting for the transaction to be confirmed")
 )
 
-func updateAddressAuthorization(address types.UnlockHash, authorize bool) (types.TransactionID, error) {
+// updateAddressesAuthorization submits an auth address update transaction, tracking it until it is confirmed,
+// such that it is rebuilt and resubmitted should it fail due to a rotation of the auth condition.
+func (f *faucet) updateAddressesAuthorization(ctx context.Context, authAddresses, deauthAddresses []types.UnlockHash) (types.TransactionID, error) {
+	txID, condition, err := f.submitAuthorizationUpdate(ctx, authAddresses, deauthAddresses)
+	if _, ok := err.(*goldchainclient.RejectedError); ok {
+		// the auth condition might have changed while the transaction was being signed
+		current, conditionErr := f.client.AuthCondition(ctx)
+		if conditionErr == nil && current.UnlockHash() != condition {
+			log.Println("[INFO] Auth condition changed while signing the authorization transaction, signing it again")
+			txID, condition, err = f.submitAuthorizationUpdate(ctx, authAddresses, deauthAddresses)
+		}
+	}
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	f.reauth.Add(pendingAuthUpdate{
+		TransactionID:   txID,
+		AuthAddresses:   authAddresses,
+		DeauthAddresses: deauthAddresses,
+		Condition:       condition,
+	})
+	return txID, nil
+}
+
+// submitAuthorizationUpdate signs and submits an auth address update transaction,
+// returning its ID and the unlock hash of the auth condition active when it was signed.
+func (f *faucet) submitAuthorizationUpdate(ctx context.Context, authAddresses, deauthAddresses []types.UnlockHash) (types.TransactionID, types.UnlockHash, error) {
+	condition, err := f.client.AuthCondition(ctx)
+	if err != nil {
+		return types.TransactionID{}, types.UnlockHash{}, fmt.Errorf("failed to get the auth condition: %v", err)
+	}
+
 	// Create transaction
-	tx := authcointx.AuthAddressUpdateTransaction{Nonce: types.RandomTransactionNonce()}
-	if authorize {
-		log.Println("[DEBUG] Updating address", address.String(), "to be authorized")
-		tx.AuthAddresses = []types.UnlockHash{address}
-	} else {
-		log.Println("[DEBUG] Updating address", address.String(), "to be deauthorized")
-		tx.DeauthAddresses = []types.UnlockHash{address}
+	tx := authcointx.AuthAddressUpdateTransaction{
+		Nonce:           types.RandomTransactionNonce(),
+		AuthAddresses:   authAddresses,
+		DeauthAddresses: deauthAddresses,
 	}
 
 	// Sign transaction
 	log.Println("[DEBUG] Signing authorization transaction")
-	var signedTx interface{}
-	data, err := json.Marshal(tx.Transaction(types.TransactionVersion(gtypes.TransactionVersionAuthAddressUpdateTx)))
+	signedTx, err := f.client.SignTransaction(ctx, tx.Transaction(types.TransactionVersion(gtypes.TransactionVersionAuthAddressUpdateTx)))
 	if err != nil {
-		return types.TransactionID{}, err
-	}
-	err = httpClient.PostResp("/wallet/sign", string(data), &signedTx)
-	if err != nil {
-		return types.TransactionID{}, err
+		return types.TransactionID{}, types.UnlockHash{}, err
 	}
 
 	// Post transaction
 	log.Println("[DEBUG] Pushing authorization transaction")
-	data, err = json.Marshal(signedTx)
+	txID, err := f.client.SubmitTransaction(ctx, signedTx)
+	return txID, condition.UnlockHash(), err
+}
+
+// isAuthorized returns whether the given address is currently authorized.
+func (f *faucet) isAuthorized(ctx context.Context, address types.UnlockHash) (bool, error) {
+	authorized, err := f.client.IsAuthorized(ctx, address)
 	if err != nil {
-		return types.TransactionID{}, err
+		return false, fmt.Errorf("failed to check authorization state for address %s: %v", address.String(), err)
 	}
-
-	var resp api.TransactionPoolPOST
-	err = httpClient.PostResp("/transactionpool/transactions", string(data), &resp)
-	return resp.TransactionID, err
+	return authorized, nil
 }
 
-func dripCoins(address types.UnlockHash, amount types.Currency) (types.TransactionID, error) {
+func (f *faucet) dripCoins(ctx context.Context, address types.UnlockHash, amount types.Currency) (types.TransactionID, error) {
 	// Check if address is authorized first
-	var result authapi.GetAddressesAuthStateResponse
-	err := httpClient.GetAPI(fmt.Sprintf("/consensus/authcoin/status?addr=%s", address.String()), &result)
+	authorized, err := f.isAuthorized(ctx, address)
 	if err != nil {
 		return types.TransactionID{}, err
 	}
-	if len(result.AuthStates) == 0 {
-		return types.TransactionID{}, fmt.Errorf(
-			"failed to check authorization state for address %s: no auth states or error returned",
-			address.String())
-	}
-	if len(result.AuthStates) > 1 {
-		return types.TransactionID{}, fmt.Errorf(
-			"failed to check authorization state for address %s: ambiguity issue: more than one auth state returned, while one was expected",
-			address.String())
-	}
-
-	if !result.AuthStates[0] {
+	if !authorized {
 		return types.TransactionID{}, errUnauthorized
 	}
 
-	data, err := json.Marshal(api.WalletCoinsPOST{
-		CoinOutputs: []types.CoinOutput{
-			{
-				Value:     amount,
-				Condition: types.NewCondition(types.NewUnlockHashCondition(address)),
-			},
+	outputs := []types.CoinOutput{
+		{
+			Value:     amount,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(address)),
 		},
-	})
+	}
+
+	// pre-flight the drip, such that invalid drips are reported with the reason why
+	dryRun, err := f.client.DryRunCoins(ctx, outputs, wallet.BuildOptions{})
 	if err != nil {
-		return types.TransactionID{}, err
+		return types.TransactionID{}, fmt.Errorf("failed to pre-flight drip: %v", err)
+	}
+	if !dryRun.Valid {
+		return types.TransactionID{}, fmt.Errorf("drip to address %s would be invalid: %s", address.String(), dryRun.Error)
 	}
 
 	log.Println("[DEBUG] Dripping", amount.String(), "coins to address", address.String())
 
-	var resp api.WalletCoinsPOSTResp
-	err = httpClient.PostResp("/wallet/coins", string(data), &resp)
+	return f.client.SendCoins(ctx, outputs, wallet.BuildOptions{})
+}
+
+// authorizeAndDrip authorizes the address should it not be authorized yet,
+// waits until that authorization is confirmed, and drips coins to it once it is.
+// The ID of the authorization transaction is returned as well, nil if the address was already authorized.
+func (f *faucet) authorizeAndDrip(ctx context.Context, address types.UnlockHash) (*types.TransactionID, types.TransactionID, error) {
+	authorized, err := f.isAuthorized(ctx, address)
 	if err != nil {
-		log.Println("[ERROR] /wallet/coins - request body:", string(data))
+		return nil, types.TransactionID{}, err
+	}
+	var authTxID *types.TransactionID
+	if !authorized {
+		txID, err := f.updateAddressAuthorization(ctx, address, true)
+		if err != nil {
+			return nil, types.TransactionID{}, fmt.Errorf("failed to authorize address: %v", err)
+		}
+		authTxID = &txID
+		err = f.waitForAuthorization(ctx, address, authorizationTimeout)
+		if err != nil {
+			return authTxID, types.TransactionID{}, err
+		}
+	}
+
+	dripTxID, err := f.dripAudited(ctx, address, authTxID)
+	return authTxID, dripTxID, err
+}
+
+// dripAudited drips coins to the address, linking the drip to the given authorization transaction in the audit log.
+func (f *faucet) dripAudited(ctx context.Context, address types.UnlockHash, authTxID *types.TransactionID) (types.TransactionID, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	dripTxID, err := f.dripCoins(ctx, address, f.coinsToGive)
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	err = f.audit.Record(dripAuditRecord{
+		Time:              time.Now(),
+		Address:           address,
+		AuthorizationTxID: authTxID,
+		DripTxID:          dripTxID,
+	})
+	if err != nil {
+		log.Println("[ERROR] Failed to update drip audit log:", err)
+	}
+	return dripTxID, nil
+}
+
+// waitForAuthorization polls the authorization state of the address until it is authorized,
+// failing with errAuthorizationTimeout once the timeout is exceeded.
+func (f *faucet) waitForAuthorization(ctx context.Context, address types.UnlockHash, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		authorized, err := f.isAuthorized(ctx, address)
+		if err != nil {
+			log.Println("[ERROR] Failed to check authorization state:", err)
+		} else if authorized {
+			log.Printf("[DEBUG] Authorization of address %s confirmed after %v\n", address.String(), time.Since(start).Round(time.Second))
+			return nil
+		}
+		log.Printf("[DEBUG] Waiting for authorization of address %s to be confirmed (%v elapsed)\n", address.String(), time.Since(start).Round(time.Second))
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return errAuthorizationTimeout
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitForTransaction polls the chain until the transaction with the given ID is part of it,
+// failing with errConfirmationTimeout once the timeout is exceeded.
+func (f *faucet) waitForTransaction(ctx context.Context, id types.TransactionID, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		_, found, err := f.client.ConsensusTransaction(ctx, id)
+		if err != nil {
+			log.Println("[ERROR] Failed to look up transaction:", err)
+		} else if found {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return errConfirmationTimeout
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
-	return resp.TransactionID, err
 }
@@ -0,0 +1,84 @@
+// Package plugintest provides the bolt database the tests of the consensus set plugins
+// register their plugin to, taking the place of the consensus set database.
+package plugintest
+
+import (
+	"path/filepath"
+	"testing"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+)
+
+// DB is a bolt database containing the bucket of a single plugin,
+// closed once the test which created it finishes.
+type DB struct {
+	*bolt.DB
+	bucket []byte
+}
+
+// NewDB creates a new database in a temporary directory of the given test,
+// storing the plugin in the bucket with the given name.
+func NewDB(t testing.TB, bucket string) *DB {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return &DB{DB: db, bucket: []byte(bucket)}
+}
+
+// InitPlugin creates the bucket of the plugin, and initializes the given plugin for the first time,
+// failing the test should the plugin fail to initialize.
+func (db *DB) InitPlugin(t testing.TB, plugin modules.ConsensusSetPlugin, unregisterCallback modules.PluginUnregisterCallback) {
+	t.Helper()
+	err := db.DB.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket(db.bucket)
+		if err != nil {
+			return err
+		}
+		_, err = plugin.InitPlugin(nil, bucket, storage{db}, unregisterCallback)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Bucket returns the bucket of the plugin within the given transaction,
+// as it is passed to the plugin by the consensus set.
+func (db *DB) Bucket(tx *bolt.Tx) *persist.LazyBoltBucket {
+	return persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+		return tx.Bucket(db.bucket), nil
+	})
+}
+
+// UpdateBucket calls the given function with the bucket of the plugin within a read-write transaction.
+func (db *DB) UpdateBucket(fn func(bucket *persist.LazyBoltBucket) error) error {
+	return db.DB.Update(func(tx *bolt.Tx) error {
+		return fn(db.Bucket(tx))
+	})
+}
+
+// ViewBucket calls the given function with the bucket of the plugin within a read-only transaction,
+// as used by the consensus set to replay the blocks to a plugin which is registered after them.
+func (db *DB) ViewBucket(fn func(bucket *persist.LazyBoltBucket) error) error {
+	return db.DB.View(func(tx *bolt.Tx) error {
+		return fn(db.Bucket(tx))
+	})
+}
+
+// storage is the view of the bucket of the plugin, as used by the plugin to answer queries.
+type storage struct {
+	db *DB
+}
+
+func (s storage) View(callback func(bucket *bolt.Bucket) error) error {
+	return s.db.DB.View(func(tx *bolt.Tx) error {
+		return callback(tx.Bucket(s.db.bucket))
+	})
+}
+
+func (s storage) Close() error { return nil }
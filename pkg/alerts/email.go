@@ -0,0 +1,94 @@
+package alerts
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// mail sends the given alert as a plain text email to all recipients of the given email configuration.
+func (m *Monitor) mail(email Email, alert Alert) error {
+	msg, err := emailMessage(email, alert)
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(email.SMTPAddress)
+	if err != nil {
+		return err
+	}
+	conn, err := net.DialTimeout("tcp", email.SMTPAddress, webhookTimeout)
+	if err != nil {
+		return err
+	}
+	// the deadline covers the whole SMTP conversation, such that a slow server cannot block other alerts
+	err = conn.SetDeadline(time.Now().Add(webhookTimeout))
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+	if email.Username != "" {
+		err = c.Auth(smtp.PlainAuth("", email.Username, email.Password, host))
+		if err != nil {
+			return err
+		}
+	}
+	err = c.Mail(email.From)
+	if err != nil {
+		return err
+	}
+	for _, to := range email.To {
+		err = c.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(msg)
+	if err != nil {
+		w.Close()
+		return err
+	}
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// emailMessage returns the email message for the given alert,
+// containing its message as well as the alert as JSON.
+func emailMessage(email Email, alert Alert) ([]byte, error) {
+	body, err := json.MarshalIndent(alert, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode alert: %v", err)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", email.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(email.To, ", "))
+	fmt.Fprintf(&msg, "Subject: Goldchain alert (%s) at height %d\r\n", alert.Kind, alert.Height)
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n", alert.Message)
+	msg.Write(bytes.Replace(body, []byte("\n"), []byte("\r\n"), -1))
+	msg.WriteString("\r\n")
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,399 @@
+package alerts
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// SignatureHeader contains the hex-encoded HMAC-SHA256 of the body of a webhook request,
+	// using the secret of the webhook as key
+	SignatureHeader = "X-Goldchain-Signature"
+
+	// recentAlerts is the amount of alerts kept in memory
+	recentAlerts = 100
+	// webhookQueueSize is the amount of alerts which can wait to be posted to the webhooks,
+	// alerts raised while the queue is full are not posted
+	webhookQueueSize = 256
+	// webhookTimeout is the maximum duration of a single webhook request or email
+	webhookTimeout = 10 * time.Second
+)
+
+// Kind is the kind of activity an alert is raised for.
+type Kind string
+
+// All kinds of alerts.
+const (
+	// KindLargeTransaction is raised for a transaction exceeding the maximum transaction value
+	KindLargeTransaction Kind = "largetransaction"
+	// KindAuthConditionUpdate is raised for a transaction updating the auth condition
+	KindAuthConditionUpdate Kind = "authconditionupdate"
+	// KindCoinCreation is raised for a transaction creating coins
+	KindCoinCreation Kind = "coincreation"
+	// KindMinterDefinition is raised for a transaction updating the mint condition
+	KindMinterDefinition Kind = "minterdefinition"
+	// KindDeauthorizations is raised for a block deauthorizing more addresses than allowed
+	KindDeauthorizations Kind = "deauthorizations"
+	// KindStakingStalled is raised when the block creator of the node appears to be stalled,
+	// and again whenever the problems stalling it change
+	KindStakingStalled Kind = "stakingstalled"
+	// KindStakingRecovered is raised when the block creator of the node no longer appears to be stalled
+	KindStakingRecovered Kind = "stakingrecovered"
+)
+
+type (
+	// Alert describes unusual on-chain activity, as defined by the rules of a Monitor.
+	Alert struct {
+		Kind      Kind              `json:"kind"`
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		BlockID   types.BlockID     `json:"blockid"`
+		// TransactionID is undefined for alerts about a block as a whole
+		TransactionID *types.TransactionID `json:"transactionid,omitempty"`
+		Message       string               `json:"message"`
+	}
+
+	// Metrics contains the amount of alerts raised by a Monitor per kind since it was created,
+	// as well as the amount of alerts which could not be posted to a webhook or mailed.
+	Metrics struct {
+		LargeTransactions    uint64 `json:"largetransactions"`
+		AuthConditionUpdates uint64 `json:"authconditionupdates"`
+		CoinCreations        uint64 `json:"coincreations"`
+		MinterDefinitions    uint64 `json:"minterdefinitions"`
+		Deauthorizations     uint64 `json:"deauthorizations"`
+		StakingStalls        uint64 `json:"stakingstalls"`
+		WebhookFailures      uint64 `json:"webhookfailures"`
+		EmailFailures        uint64 `json:"emailfailures"`
+	}
+)
+
+// Monitor raises alerts for unusual activity in the blocks applied to a consensus set,
+// logging them, counting them and posting them to the webhooks (and email) defined by its rules.
+// Other subsystems of the node can raise alerts through the Monitor as well.
+//
+// Alerts are only raised once the consensus set is synced,
+// such that syncing the blockchain doesn't raise alerts about past activity.
+type Monitor struct {
+	rules               Rules
+	maxTransactionValue types.Currency
+	currencyConvertor   client.CurrencyConvertor
+	output              io.Writer
+
+	cs      modules.ConsensusSet
+	height  types.BlockHeight
+	queue   chan Alert
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+	client  *http.Client
+
+	mu      sync.Mutex
+	metrics Metrics
+	recent  []Alert
+}
+
+// NewMonitor creates a new Monitor for the given rules and network,
+// subscribing it to the given consensus set, which is expected not to be started yet.
+// Alerts are logged to the given writer.
+func NewMonitor(rules Rules, network config.NetworkDescriptor, cs modules.ConsensusSet, output io.Writer) (*Monitor, error) {
+	m, err := newMonitor(rules, network, output)
+	if err != nil {
+		return nil, err
+	}
+	m.cs = cs
+	m.height = cs.Height()
+	err = cs.ConsensusSetSubscribe(m, modules.ConsensusChangeRecent, m.closeCh)
+	if err != nil {
+		m.Close()
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	return m, nil
+}
+
+func newMonitor(rules Rules, network config.NetworkDescriptor, output io.Writer) (*Monitor, error) {
+	maxTransactionValue, err := rules.maxTransactionValue(network.CurrencyUnits())
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		output = ioutil.Discard
+	}
+	m := &Monitor{
+		rules:               rules,
+		maxTransactionValue: maxTransactionValue,
+		currencyConvertor:   client.NewCurrencyConvertor(network.CurrencyUnits(), network.CoinUnit),
+		output:              output,
+		queue:               make(chan Alert, webhookQueueSize),
+		closeCh:             make(chan struct{}),
+		client:              &http.Client{Timeout: webhookTimeout},
+	}
+	m.wg.Add(1)
+	go m.postAlerts()
+	return m, nil
+}
+
+// Close unsubscribes the Monitor from the consensus set,
+// and stops posting alerts to the webhooks and email.
+func (m *Monitor) Close() error {
+	if m.cs != nil {
+		m.cs.Unsubscribe(m)
+	}
+	close(m.closeCh)
+	m.wg.Wait()
+	return nil
+}
+
+// Rules returns the rules applied by this Monitor.
+func (m *Monitor) Rules() Rules {
+	rules := m.rules
+	rules.Webhooks = make([]Webhook, 0, len(m.rules.Webhooks))
+	for _, webhook := range m.rules.Webhooks {
+		// never expose the secrets
+		rules.Webhooks = append(rules.Webhooks, Webhook{URL: webhook.URL})
+	}
+	if m.rules.Email != nil {
+		email := *m.rules.Email
+		email.Password = ""
+		rules.Email = &email
+	}
+	return rules
+}
+
+// Metrics returns a snapshot of the alert statistics of this Monitor.
+func (m *Monitor) Metrics() Metrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metrics
+}
+
+// Recent returns the most recently raised alerts, the most recent one first.
+func (m *Monitor) Recent() []Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alerts := make([]Alert, 0, len(m.recent))
+	for i := len(m.recent) - 1; i >= 0; i-- {
+		alerts = append(alerts, m.recent[i])
+	}
+	return alerts
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// applying the rules of this Monitor to all applied blocks.
+func (m *Monitor) ProcessConsensusChange(cc modules.ConsensusChange) {
+	m.height -= types.BlockHeight(len(cc.RevertedBlocks))
+	// the outputs spent by the applied blocks, used to tell the change of a transaction apart
+	spent := make(map[types.CoinOutputID]types.UnlockHash)
+	for _, diff := range cc.CoinOutputDiffs {
+		if diff.Direction == modules.DiffRevert {
+			spent[diff.ID] = diff.CoinOutput.Condition.UnlockHash()
+		}
+	}
+	for _, block := range cc.AppliedBlocks {
+		m.height++
+		if !cc.Synced {
+			continue
+		}
+		for _, alert := range m.applyRules(block, spent) {
+			m.raise(alert)
+		}
+	}
+}
+
+// applyRules returns the alerts raised by the given block.
+func (m *Monitor) applyRules(block types.Block, spent map[types.CoinOutputID]types.UnlockHash) []Alert {
+	var alerts []Alert
+	newAlert := func(kind Kind, txn *types.Transaction, format string, args ...interface{}) {
+		alert := Alert{
+			Kind:      kind,
+			Height:    m.height,
+			Timestamp: block.Timestamp,
+			BlockID:   block.ID(),
+			Message:   fmt.Sprintf(format, args...),
+		}
+		if txn != nil {
+			id := txn.ID()
+			alert.TransactionID = &id
+		}
+		alerts = append(alerts, alert)
+	}
+	var deauthorizations int
+	for i := range block.Transactions {
+		txn := &block.Transactions[i]
+		switch txn.Version {
+		case goldchaintypes.TransactionVersionAuthConditionUpdateTx:
+			if m.rules.AuthConditionUpdates {
+				newAlert(KindAuthConditionUpdate, txn, "auth condition updated by transaction %s", txn.ID().String())
+			}
+		case goldchaintypes.MinterDefinitionTxVersion:
+			if m.rules.Mints {
+				newAlert(KindMinterDefinition, txn, "mint condition updated by transaction %s", txn.ID().String())
+			}
+		case goldchaintypes.CoinCreationTxVersion:
+			if m.rules.Mints {
+				newAlert(KindCoinCreation, txn, "%s created by transaction %s",
+					m.currencyConvertor.ToCoinStringWithUnit(coinOutputsValue(txn.CoinOutputs)), txn.ID().String())
+			}
+		case goldchaintypes.TransactionVersionAuthAddressUpdateTx:
+			aautx, err := authcointx.AuthAddressUpdateTransactionFromTransaction(*txn, goldchaintypes.TransactionVersionAuthAddressUpdateTx)
+			if err == nil {
+				deauthorizations += len(aautx.DeauthAddresses)
+			}
+		}
+		if !m.maxTransactionValue.IsZero() {
+			if value := transferredValue(*txn, spent); value.Cmp(m.maxTransactionValue) > 0 {
+				newAlert(KindLargeTransaction, txn, "transaction %s transfers %s, exceeding the maximum of %s",
+					txn.ID().String(), m.currencyConvertor.ToCoinStringWithUnit(value),
+					m.currencyConvertor.ToCoinStringWithUnit(m.maxTransactionValue))
+			}
+		}
+	}
+	if m.rules.MaxDeauthorizationsPerBlock > 0 && uint64(deauthorizations) > m.rules.MaxDeauthorizationsPerBlock {
+		newAlert(KindDeauthorizations, nil, "%d addresses deauthorized by block %s, exceeding the maximum of %d",
+			deauthorizations, block.ID().String(), m.rules.MaxDeauthorizationsPerBlock)
+	}
+	return alerts
+}
+
+// Raise raises the given alert, as detected by another subsystem of the node.
+func (m *Monitor) Raise(alert Alert) {
+	m.raise(alert)
+}
+
+// raise logs and counts the given alert, and queues it to be posted to the webhooks and email.
+func (m *Monitor) raise(alert Alert) {
+	fmt.Fprintf(m.output, "Alert (%s) at height %d: %s\n", alert.Kind, alert.Height, alert.Message)
+
+	m.mu.Lock()
+	switch alert.Kind {
+	case KindLargeTransaction:
+		m.metrics.LargeTransactions++
+	case KindAuthConditionUpdate:
+		m.metrics.AuthConditionUpdates++
+	case KindCoinCreation:
+		m.metrics.CoinCreations++
+	case KindMinterDefinition:
+		m.metrics.MinterDefinitions++
+	case KindDeauthorizations:
+		m.metrics.Deauthorizations++
+	case KindStakingStalled:
+		m.metrics.StakingStalls++
+	}
+	m.recent = append(m.recent, alert)
+	if len(m.recent) > recentAlerts {
+		m.recent = m.recent[len(m.recent)-recentAlerts:]
+	}
+	m.mu.Unlock()
+
+	if len(m.rules.Webhooks) == 0 && m.rules.Email == nil {
+		return
+	}
+	select {
+	case m.queue <- alert:
+	default:
+		m.webhookFailed(fmt.Errorf("webhook queue is full, alert (%s) at height %d is not posted", alert.Kind, alert.Height))
+	}
+}
+
+// postAlerts posts all queued alerts to the webhooks and email, until the Monitor is closed.
+func (m *Monitor) postAlerts() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.closeCh:
+			return
+		case alert := <-m.queue:
+			body, err := json.Marshal(alert)
+			if err != nil {
+				m.webhookFailed(fmt.Errorf("failed to encode alert: %v", err))
+				continue
+			}
+			for _, webhook := range m.rules.Webhooks {
+				err = m.post(webhook, body)
+				if err != nil {
+					m.webhookFailed(fmt.Errorf("failed to post alert to webhook %s: %v", webhook.URL, err))
+				}
+			}
+			if m.rules.Email != nil {
+				err = m.mail(*m.rules.Email, alert)
+				if err != nil {
+					fmt.Fprintln(m.output, "Alert email error:", err)
+					m.mu.Lock()
+					m.metrics.EmailFailures++
+					m.mu.Unlock()
+				}
+			}
+		}
+	}
+}
+
+// post posts the given body to the given webhook, signing it if the webhook has a secret.
+func (m *Monitor) post(webhook Webhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write(body)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (m *Monitor) webhookFailed(err error) {
+	fmt.Fprintln(m.output, "Alert webhook error:", err)
+	m.mu.Lock()
+	m.metrics.WebhookFailures++
+	m.mu.Unlock()
+}
+
+// transferredValue returns the value of the coin outputs of the given transaction,
+// not counting the outputs returning coins to any of the addresses funding it.
+func transferredValue(txn types.Transaction, spent map[types.CoinOutputID]types.UnlockHash) types.Currency {
+	funding := make(map[types.UnlockHash]struct{}, len(txn.CoinInputs))
+	for _, ci := range txn.CoinInputs {
+		if uh, ok := spent[ci.ParentID]; ok {
+			funding[uh] = struct{}{}
+		}
+	}
+	var value types.Currency
+	for _, co := range txn.CoinOutputs {
+		if _, ok := funding[co.Condition.UnlockHash()]; ok {
+			continue
+		}
+		value = value.Add(co.Value)
+	}
+	return value
+}
+
+func coinOutputsValue(outputs []types.CoinOutput) types.Currency {
+	var value types.Currency
+	for _, co := range outputs {
+		value = value.Add(co.Value)
+	}
+	return value
+}
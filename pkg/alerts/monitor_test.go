@@ -0,0 +1,147 @@
+package alerts
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestMonitor(t *testing.T) {
+	network := config.GetDevnetNetworkDescriptor()
+	oneCoin := network.CurrencyUnits().OneCoin
+
+	received := make(chan Alert, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if r.Header.Get(SignatureHeader) != hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("invalid webhook signature")
+		}
+		var alert Alert
+		if err := json.Unmarshal(body, &alert); err != nil {
+			t.Error(err)
+		}
+		received <- alert
+	}))
+	defer webhook.Close()
+
+	m, err := newMonitor(Rules{
+		MaxTransactionValue:         "1000",
+		Mints:                       true,
+		MaxDeauthorizationsPerBlock: 1,
+		Webhooks:                    []Webhook{{URL: webhook.URL, Secret: "secret"}},
+	}, network, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	address := func(b byte) types.UnlockHash {
+		return types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{b})
+	}
+	output := func(value uint64, b byte) types.CoinOutput {
+		return types.CoinOutput{
+			Value:     oneCoin.Mul64(value),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(address(b))),
+		}
+	}
+	spentID := types.CoinOutputID{1}
+	deauthorizations := authcointx.AuthAddressUpdateTransaction{
+		DeauthAddresses: []types.UnlockHash{address(3), address(4)},
+	}
+	block := types.Block{
+		Transactions: []types.Transaction{
+			{
+				// only 800 coins are transferred, the rest being change
+				Version:     types.TransactionVersionOne,
+				CoinInputs:  []types.CoinInput{{ParentID: spentID}},
+				CoinOutputs: []types.CoinOutput{output(800, 2), output(5000, 1)},
+			},
+			{
+				Version:     types.TransactionVersionOne,
+				CoinInputs:  []types.CoinInput{{ParentID: spentID}},
+				CoinOutputs: []types.CoinOutput{output(1200, 2)},
+			},
+			{
+				Version:     goldchaintypes.CoinCreationTxVersion,
+				CoinOutputs: []types.CoinOutput{output(10, 2)},
+			},
+			deauthorizations.Transaction(goldchaintypes.TransactionVersionAuthAddressUpdateTx),
+		},
+	}
+	cc := modules.ConsensusChange{
+		AppliedBlocks: []types.Block{block},
+		CoinOutputDiffs: []modules.CoinOutputDiff{
+			{Direction: modules.DiffRevert, ID: spentID, CoinOutput: output(6000, 1)},
+		},
+	}
+
+	// no alerts are raised while syncing
+	m.ProcessConsensusChange(cc)
+	if alerts := m.Recent(); len(alerts) != 0 {
+		t.Fatalf("expected no alerts while syncing, got %v", alerts)
+	}
+
+	cc.Synced = true
+	m.ProcessConsensusChange(cc)
+	expected := Metrics{
+		LargeTransactions: 1,
+		CoinCreations:     1,
+		Deauthorizations:  1,
+	}
+	if metrics := m.Metrics(); metrics != expected {
+		t.Errorf("unexpected metrics: %+v", metrics)
+	}
+	alerts := m.Recent()
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts, got %v", alerts)
+	}
+	if alerts[0].Kind != KindDeauthorizations || alerts[0].Height != 2 || alerts[0].TransactionID != nil {
+		t.Errorf("unexpected deauthorizations alert: %+v", alerts[0])
+	}
+	if alerts[2].Kind != KindLargeTransaction || *alerts[2].TransactionID != block.Transactions[1].ID() {
+		t.Errorf("unexpected large transaction alert: %+v", alerts[2])
+	}
+
+	for i := 0; i < 3; i++ {
+		select {
+		case alert := <-received:
+			if alert.Kind != alerts[2-i].Kind {
+				t.Errorf("unexpected webhook alert %+v", alert)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for webhook")
+		}
+	}
+	if m.Rules().Webhooks[0].Secret != "" {
+		t.Error("expected the webhook secret not to be exposed")
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	network := config.GetDevnetNetworkDescriptor()
+	for _, rules := range []Rules{
+		{MaxTransactionValue: "foo"},
+		{MaxTransactionValue: "0"},
+		{Webhooks: []Webhook{{URL: "localhost:8080"}}},
+	} {
+		if m, err := newMonitor(rules, network, nil); err == nil {
+			m.Close()
+			t.Errorf("expected rules %+v to be refused", rules)
+		}
+	}
+}
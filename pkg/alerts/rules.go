@@ -0,0 +1,106 @@
+package alerts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Rules define the on-chain activity for which alerts are raised, as configured by the operator.
+// A zero-value Rules raises no alerts.
+type Rules struct {
+	// MaxTransactionValue is the value (in coins) above which a transaction raises an alert,
+	// not counting the coins it returns to the addresses funding it, an empty string disables this rule
+	MaxTransactionValue string `json:"maxtransactionvalue,omitempty"`
+	// AuthConditionUpdates raises an alert for every transaction updating the auth condition
+	AuthConditionUpdates bool `json:"authconditionupdates,omitempty"`
+	// Mints raises an alert for every transaction creating coins or updating the mint condition
+	Mints bool `json:"mints,omitempty"`
+	// MaxDeauthorizationsPerBlock is the amount of addresses which can be deauthorized by a single block
+	// without raising an alert, 0 disables this rule
+	MaxDeauthorizationsPerBlock uint64 `json:"maxdeauthorizationsperblock,omitempty"`
+	// StakingStalled raises an alert whenever the block creator of the node appears to be stalled,
+	// and once it recovers, requiring the block creator module
+	StakingStalled bool `json:"stakingstalled,omitempty"`
+	// StakingStallFactor is the multiple of the expected time between two blocks created by the node,
+	// after which block creation is considered stalled, 0 defaults to staking.DefaultStallFactor
+	StakingStallFactor uint64 `json:"stakingstallfactor,omitempty"`
+
+	// Webhooks receive all alerts, next to them being logged
+	Webhooks []Webhook `json:"webhooks,omitempty"`
+	// Email receives all alerts, next to them being logged, no emails are sent if nil
+	Email *Email `json:"email,omitempty"`
+}
+
+// Webhook is an URL to which all alerts are posted as JSON.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret is used to sign the body of each request, using HMAC-SHA256,
+	// the hex-encoded signature being sent as the SignatureHeader, no signature is sent if empty
+	Secret string `json:"secret,omitempty"`
+}
+
+// Email defines the SMTP server used to mail all alerts, as well as its recipients.
+type Email struct {
+	// SMTPAddress is the host:port of the SMTP server, STARTTLS is used when supported by the server
+	SMTPAddress string `json:"smtpaddress"`
+	// Username and Password authenticate to the SMTP server, no authentication is used if the username is empty
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// LoadRules loads the alert rules from the given JSON file,
+// refusing unknown properties such that typos do not go unnoticed.
+func LoadRules(filename string) (Rules, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return Rules{}, fmt.Errorf("failed to open alert rules: %v", err)
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	var rules Rules
+	err = decoder.Decode(&rules)
+	if err != nil {
+		return Rules{}, fmt.Errorf("failed to decode alert rules %s: %v", filename, err)
+	}
+	return rules, nil
+}
+
+// maxTransactionValue validates the rules,
+// returning the maximum transaction value, zero if that rule is disabled.
+func (rules Rules) maxTransactionValue(units types.CurrencyUnits) (types.Currency, error) {
+	for _, webhook := range rules.Webhooks {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return types.Currency{}, fmt.Errorf("invalid webhook URL %q", webhook.URL)
+		}
+	}
+	if email := rules.Email; email != nil {
+		if _, _, err := net.SplitHostPort(email.SMTPAddress); err != nil {
+			return types.Currency{}, fmt.Errorf("invalid SMTP address %q: %v", email.SMTPAddress, err)
+		}
+		if email.From == "" || len(email.To) == 0 {
+			return types.Currency{}, errors.New("invalid email: both the sender and at least one recipient are required")
+		}
+	}
+	if rules.MaxTransactionValue == "" {
+		return types.Currency{}, nil
+	}
+	value, err := client.NewCurrencyConvertor(units, "").ParseCoinString(rules.MaxTransactionValue)
+	if err != nil {
+		return types.Currency{}, fmt.Errorf("invalid maximum transaction value %q: %v", rules.MaxTransactionValue, err)
+	}
+	if value.IsZero() {
+		return types.Currency{}, errors.New("invalid maximum transaction value: cannot be zero, leave it empty to disable the rule")
+	}
+	return value, nil
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletAccountsGET contains all accounts of the wallet,
+	// as returned by a GET call to /wallet/accounts.
+	WalletAccountsGET struct {
+		Accounts []wallet.Account `json:"accounts"`
+	}
+
+	// WalletAccountsPOST contains the label of the account to create,
+	// as given as the body of a POST call to /wallet/accounts.
+	WalletAccountsPOST struct {
+		Label string `json:"label"`
+		// Passphrase is the passphrase the wallet is encrypted with, omitted for a plain wallet
+		Passphrase string `json:"passphrase,omitempty"`
+	}
+
+	// WalletAccountGET contains an account with its balance,
+	// as returned by a GET call to /wallet/accounts/:label or a POST call to /wallet/accounts.
+	WalletAccountGET struct {
+		wallet.Account
+		Balance wallet.AccountBalance `json:"balance"`
+	}
+
+	// WalletAccountAddressPOSTResp contains the generated address of an account,
+	// as returned by a POST call to /wallet/accounts/:label/address.
+	WalletAccountAddressPOSTResp struct {
+		Address types.UnlockHash `json:"address"`
+	}
+
+	// WalletAccountTransactionsGET contains the confirmed transactions involving an account,
+	// as returned by a GET call to /wallet/accounts/:label/transactions.
+	WalletAccountTransactionsGET struct {
+		Transactions []wallet.AccountTransaction `json:"transactions"`
+	}
+)
+
+// RegisterWalletAccountsHTTPHandlers registers the handlers for the wallet sub-account HTTP endpoints.
+// Coins and blockstakes are sent from an account using the account query parameter of /wallet/coins and /wallet/blockstakes.
+func RegisterWalletAccountsHTTPHandlers(router rapi.Router, accounts *wallet.Accounts, requiredPassword string) {
+	router.GET("/wallet/accounts", rapi.RequirePasswordHandler(NewWalletAccountsHandler(accounts), requiredPassword))
+	router.POST("/wallet/accounts", rapi.RequirePasswordHandler(NewWalletCreateAccountHandler(accounts), requiredPassword))
+	router.GET("/wallet/accounts/:label", rapi.RequirePasswordHandler(NewWalletAccountHandler(accounts), requiredPassword))
+	router.POST("/wallet/accounts/:label/address", rapi.RequirePasswordHandler(NewWalletAccountAddressHandler(accounts), requiredPassword))
+	router.GET("/wallet/accounts/:label/transactions", rapi.RequirePasswordHandler(NewWalletAccountTransactionsHandler(accounts), requiredPassword))
+}
+
+// NewWalletAccountsHandler creates a handler to handle the GET API calls to /wallet/accounts.
+func NewWalletAccountsHandler(accounts *wallet.Accounts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, WalletAccountsGET{Accounts: accounts.Accounts()})
+	}
+}
+
+// NewWalletCreateAccountHandler creates a handler to handle the POST API calls to /wallet/accounts,
+// creating (or restoring) an account of the unlocked wallet.
+func NewWalletCreateAccountHandler(accounts *wallet.Accounts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletAccountsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts: invalid body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		account, err := accounts.Create(body.Label, body.Passphrase)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		balance, err := accounts.Balance(account.Label)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAccountGET{Account: account, Balance: balance})
+	}
+}
+
+// NewWalletAccountHandler creates a handler to handle the GET API calls to /wallet/accounts/:label.
+func NewWalletAccountHandler(accounts *wallet.Accounts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		account, err := accounts.Account(ps.ByName("label"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		balance, err := accounts.Balance(account.Label)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAccountGET{Account: account, Balance: balance})
+	}
+}
+
+// NewWalletAccountAddressHandler creates a handler to handle the POST API calls to /wallet/accounts/:label/address,
+// generating the next address of an account.
+func NewWalletAccountAddressHandler(accounts *wallet.Accounts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		address, err := accounts.NextAddress(ps.ByName("label"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts/:label/address: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAccountAddressPOSTResp{Address: address})
+	}
+}
+
+// NewWalletAccountTransactionsHandler creates a handler to handle the GET API calls to /wallet/accounts/:label/transactions.
+func NewWalletAccountTransactionsHandler(accounts *wallet.Accounts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		txns, err := accounts.Transactions(ps.ByName("label"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/accounts/:label/transactions: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAccountTransactionsGET{Transactions: txns})
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/ledger"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// ExplorerAddressStateGET contains the balance and authorization of an address
+	// as they were after the block at a given height,
+	// as returned by a GET call to /explorer/addresses/:hash/state.
+	ExplorerAddressStateGET struct {
+		Address   types.UnlockHash  `json:"address"`
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		// Balance includes the locked coins of the address
+		Balance types.Currency `json:"balance"`
+		// Received and Sent are the totals of the coins received and sent by the address up to that height,
+		// netted per transaction, such that change returned to the address is not included
+		Received   types.Currency `json:"received"`
+		Sent       types.Currency `json:"sent"`
+		Authorized bool           `json:"authorized"`
+	}
+)
+
+// RegisterExplorerAddressStateHTTPHandlers registers the handler for the historical address state explorer HTTP endpoint,
+// which is not registered in case the ledger plugin is not given.
+func RegisterExplorerAddressStateHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, ledgerPlugin *ledger.Plugin, authPlugin *authcointx.Plugin) {
+	if ledgerPlugin == nil {
+		return
+	}
+	router.GET("/explorer/addresses/:hash/state", NewExplorerAddressStateHandler(cs, ledgerPlugin, authPlugin))
+}
+
+// NewExplorerAddressStateHandler creates a handler to handle the API calls to /explorer/addresses/:hash/state,
+// returning the balance and authorization of the address as they were after the block at the height
+// given by the optional height query parameter, which defaults to the current height.
+// The balance is looked up in the ledger, which stores the totals of an address at every height it changed.
+func NewExplorerAddressStateHandler(cs modules.ConsensusSet, ledgerPlugin *ledger.Plugin, authPlugin *authcointx.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var uh types.UnlockHash
+		err := uh.LoadString(ps.ByName("hash"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/addresses/:hash/state: invalid address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		height, err := parseHeight(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/addresses/:hash/state: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		block, ok := cs.BlockAtHeight(height)
+		if !ok {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/addresses/:hash/state: no block found at the given height"}, http.StatusNotFound)
+			return
+		}
+		ab, err := ledgerPlugin.GetAccountBalanceAt(uh.String(), height)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/addresses/:hash/state: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		states, err := authPlugin.GetAddressesAuthStateAt(height, []types.UnlockHash{uh}, nil)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/addresses/:hash/state: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ExplorerAddressStateGET{
+			Address:    uh,
+			Height:     height,
+			Timestamp:  block.Timestamp,
+			Balance:    ab.DebitBalance,
+			Received:   ab.Debits,
+			Sent:       ab.Credits,
+			Authorized: states[0],
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/alerts"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// ConsensusAlertsGET contains the alert rules and metrics, as well as the most recent alerts,
+// as returned by a GET call to /consensus/alerts.
+type ConsensusAlertsGET struct {
+	Rules   alerts.Rules   `json:"rules"`
+	Metrics alerts.Metrics `json:"metrics"`
+	Alerts  []alerts.Alert `json:"alerts"`
+}
+
+// RegisterAlertsHTTPHandlers registers the handlers for all alert HTTP endpoints.
+func RegisterAlertsHTTPHandlers(router rapi.Router, monitor *alerts.Monitor) {
+	router.GET("/consensus/alerts", NewConsensusAlertsHandler(monitor))
+}
+
+// NewConsensusAlertsHandler creates a handler to handle the API calls to /consensus/alerts.
+func NewConsensusAlertsHandler(monitor *alerts.Monitor) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, ConsensusAlertsGET{
+			Rules:   monitor.Rules(),
+			Metrics: monitor.Metrics(),
+			Alerts:  monitor.Recent(),
+		})
+	}
+}
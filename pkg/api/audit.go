@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// maxAuditedResponseSize is the maximum size of the response of which the transaction IDs are recorded.
+const maxAuditedResponseSize = 1 << 20
+
+// auditRedacted replaces the values of all secrets of an audited request.
+const auditRedacted = "[redacted]"
+
+// auditSecretKeys are the (lower case) parts of the names of the parameters and body fields of which the value is redacted.
+var auditSecretKeys = []string{"password", "passphrase", "seed", "mnemonic", "secret", "privatekey"}
+
+type (
+	// AuditCredential is an API password, named such that audit records identify the password a request was authenticated with.
+	AuditCredential struct {
+		Name     string
+		Password string
+	}
+
+	// WalletAuditVerifyGET contains the outcome of the verification of the audit log,
+	// as returned by a GET call to /wallet/audit/verify.
+	WalletAuditVerifyGET struct {
+		Valid bool `json:"valid"`
+		wallet.AuditHead
+		// Error describes why the audit log is invalid
+		Error string `json:"error,omitempty"`
+	}
+)
+
+// auditedRouter wraps a router, such that all POST requests to the handlers registered to it are recorded in an audit log.
+type auditedRouter struct {
+	rapi.Router
+	log         *wallet.AuditLog
+	credentials []AuditCredential
+	onError     func(record wallet.AuditRecord, err error)
+}
+
+// NewAuditedRouter wraps the given router, recording every POST request to the handlers registered to it in the given audit log.
+// A pending record is appended before a request is handled, and completed by a record of its outcome once handled,
+// such that a request which cannot be recorded is never handled.
+// Requests are identified by the first of the given credentials they were authenticated with.
+// The given function is called with the record of every outcome which cannot be recorded, as the request is handled already.
+func NewAuditedRouter(router rapi.Router, log *wallet.AuditLog, credentials []AuditCredential, onError func(record wallet.AuditRecord, err error)) rapi.Router {
+	return &auditedRouter{Router: router, log: log, credentials: credentials, onError: onError}
+}
+
+// POST implements rapi.Router.POST
+func (router *auditedRouter) POST(path string, handle httprouter.Handle) {
+	router.Router.POST(path, router.audited(path, handle))
+}
+
+// audited wraps the given handler of the given route, recording every request it handles.
+func (router *auditedRouter) audited(path string, handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		record := wallet.AuditRecord{
+			Method:   req.Method,
+			Endpoint: path,
+			Path:     req.URL.Path,
+			Caller: wallet.AuditCaller{
+				RemoteAddr: req.RemoteAddr,
+				UserAgent:  req.UserAgent(),
+			},
+		}
+		if user, password, ok := req.BasicAuth(); ok {
+			record.Caller.User = user
+			for _, credential := range router.credentials {
+				if credential.Password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(credential.Password)) == 1 {
+					record.Caller.Credential = credential.Name
+					break
+				}
+			}
+		}
+		for _, p := range ps {
+			if record.Parameters == nil {
+				record.Parameters = make(map[string]string)
+			}
+			record.Parameters[p.Key] = redactAuditValue(p.Key, p.Value)
+		}
+		for key, values := range req.URL.Query() {
+			if record.Parameters == nil {
+				record.Parameters = make(map[string]string)
+			}
+			record.Parameters[key] = redactAuditValue(key, strings.Join(values, ","))
+		}
+		// the body is read once, and replayed to the handler
+		body, err := ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error reading the request body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		record.Body = redactAuditBody(body)
+
+		// the request is recorded before it is handled, such that no request goes unrecorded
+		record.Pending = true
+		pending, err := router.log.Append(record)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error recording the request in the audit log: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+
+		rw := &auditResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		handle(rw, req, ps)
+
+		completion := wallet.AuditRecord{
+			Method:    record.Method,
+			Endpoint:  record.Endpoint,
+			Path:      record.Path,
+			Caller:    record.Caller,
+			Status:    rw.status,
+			Completes: pending.Sequence,
+		}
+		completion.TransactionIDs, completion.Error = auditResponse(rw.status, rw.body.Bytes())
+		if _, err := router.log.Append(completion); err != nil && router.onError != nil {
+			router.onError(completion, err)
+		}
+	}
+}
+
+// auditResponseWriter records the status and the start of the body of a response.
+type auditResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader implements http.ResponseWriter.WriteHeader
+func (w *auditResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements http.ResponseWriter.Write
+func (w *auditResponseWriter) Write(b []byte) (int, error) {
+	if n := maxAuditedResponseSize - w.body.Len(); n > 0 {
+		if n > len(b) {
+			n = len(b)
+		}
+		w.body.Write(b[:n])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// auditResponse returns the transaction IDs of a successful response, or the error message of a failed response.
+func auditResponse(status int, body []byte) ([]types.TransactionID, string) {
+	if status >= http.StatusBadRequest {
+		var apiErr rapi.Error
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return nil, apiErr.Message
+		}
+		return nil, http.StatusText(status)
+	}
+	var resp interface{}
+	if json.Unmarshal(body, &resp) != nil {
+		return nil, ""
+	}
+	var ids []types.TransactionID
+	addID := func(v interface{}) {
+		str, _ := v.(string)
+		var id types.TransactionID
+		if id.LoadString(str) == nil {
+			ids = append(ids, id)
+		}
+	}
+	// transaction IDs are returned as a transactionid or transactionids field, possibly nested
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				switch strings.ToLower(key) {
+				case "transactionid":
+					addID(value)
+				case "transactionids":
+					if values, ok := value.([]interface{}); ok {
+						for _, value := range values {
+							addID(value)
+						}
+					} else {
+						addID(value)
+					}
+				default:
+					walk(value)
+				}
+			}
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+	walk(resp)
+	return ids, ""
+}
+
+// redactAuditBody returns the given JSON or form-encoded request body as a JSON value with all secrets redacted,
+// nil in case the body is empty or neither JSON nor form-encoded.
+func redactAuditBody(body []byte) json.RawMessage {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil
+	}
+	var value interface{}
+	if body[0] == '{' || body[0] == '[' {
+		if json.Unmarshal(body, &value) != nil {
+			return nil
+		}
+		value = redactAuditJSON(value)
+	} else {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil
+		}
+		fields := make(map[string]string, len(form))
+		for key, values := range form {
+			fields[key] = redactAuditValue(key, strings.Join(values, ","))
+		}
+		value = fields
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// redactAuditJSON redacts the values of all secret fields of the given decoded JSON value.
+func redactAuditJSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isAuditSecret(key) {
+				v[key] = auditRedacted
+			} else {
+				v[key] = redactAuditJSON(field)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactAuditJSON(v[i])
+		}
+	}
+	return value
+}
+
+// redactAuditValue returns the given value, or auditRedacted in case the key names a secret.
+func redactAuditValue(key, value string) string {
+	if isAuditSecret(key) {
+		return auditRedacted
+	}
+	return value
+}
+
+// isAuditSecret returns true in case the given parameter or field name names a secret.
+func isAuditSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range auditSecretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterWalletAuditHTTPHandlers registers the handlers for the wallet audit log HTTP endpoints.
+func RegisterWalletAuditHTTPHandlers(router rapi.Router, log *wallet.AuditLog, requiredPassword string) {
+	router.GET("/wallet/audit", rapi.RequirePasswordHandler(NewWalletAuditHandler(log), requiredPassword))
+	router.GET("/wallet/audit/verify", rapi.RequirePasswordHandler(NewWalletAuditVerifyHandler(log), requiredPassword))
+}
+
+// NewWalletAuditHandler creates a handler to handle the API calls to /wallet/audit,
+// exporting the audit log as stored: a JSON record per line.
+// The amount of exported records and the hash of the last one are returned as the Audit-Records and Audit-Head headers.
+func NewWalletAuditHandler(log *wallet.AuditLog) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var buf bytes.Buffer
+		head, err := log.Export(&buf)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/audit: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Audit-Records", strconv.FormatUint(head.Records, 10))
+		w.Header().Set("Audit-Head", head.Hash)
+		w.Write(buf.Bytes())
+	}
+}
+
+// NewWalletAuditVerifyHandler creates a handler to handle the API calls to /wallet/audit/verify,
+// verifying the hash chain of the audit log as stored.
+func NewWalletAuditVerifyHandler(log *wallet.AuditLog) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		head, err := log.Verify()
+		if err != nil {
+			rapi.WriteJSON(w, WalletAuditVerifyGET{Error: err.Error()})
+			return
+		}
+		rapi.WriteJSON(w, WalletAuditVerifyGET{Valid: true, AuditHead: head})
+	}
+}
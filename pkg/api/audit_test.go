@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+func TestAuditedRouter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, wallet.AuditFile)
+	log, err := wallet.OpenAuditLog(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var handled int
+	handle := func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		handled++
+		// the request is recorded before it is handled
+		if head := log.Head(); head.Records != uint64(2*handled-1) {
+			t.Errorf("expected the request to be recorded before it is handled, log has %d records", head.Records)
+		}
+		rapi.WriteJSON(w, map[string]string{"transactionid": "0000000000000000000000000000000000000000000000000000000000000001"})
+	}
+	router := httprouter.New()
+	NewAuditedRouter(router, log, []AuditCredential{{Name: "api", Password: "secret"}}, nil).POST("/wallet/coins", handle)
+
+	req := httptest.NewRequest(http.MethodPost, "/wallet/coins", strings.NewReader(`{"passphrase":"foo","amount":"1"}`))
+	req.SetBasicAuth("", "secret")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || handled != 1 {
+		t.Fatalf("expected the request to be handled, got status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var buf bytes.Buffer
+	if _, err = log.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected a pending and a completion record, got %d records", len(lines))
+	}
+	if !strings.Contains(lines[0], `"pending":true`) || !strings.Contains(lines[0], `"credential":"api"`) || strings.Contains(lines[0], "foo") {
+		t.Errorf("unexpected pending record: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], `"completes":1`) || !strings.Contains(lines[1], `"status":200`) || !strings.Contains(lines[1], `"transactionids":["0000`) {
+		t.Errorf("unexpected completion record: %s", lines[1])
+	}
+
+	// a request which cannot be recorded is never handled
+	if err = os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(filename, 0700); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wallet/coins", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusInternalServerError || handled != 1 {
+		t.Fatalf("expected an unrecorded request to be refused, got status %d (handled %d times)", rec.Code, handled)
+	}
+}
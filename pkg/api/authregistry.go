@@ -0,0 +1,66 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/authregistry"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+type (
+	// ConsensusAuthCoinRegistryGET contains the authorization state of all (de)authorized addresses at a given height,
+	// as returned by a GET call to /consensus/authcoin/registry.
+	ConsensusAuthCoinRegistryGET struct {
+		authregistry.Registry
+	}
+)
+
+// RegisterAuthRegistryHTTPHandlers registers the handler for the authorized address registry HTTP endpoint,
+// which is not registered in case the authorized address registry plugin is not given.
+func RegisterAuthRegistryHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, plugin *authregistry.Plugin) {
+	if plugin == nil {
+		return
+	}
+	router.GET("/consensus/authcoin/registry", NewConsensusAuthCoinRegistryHandler(cs, plugin))
+}
+
+// NewConsensusAuthCoinRegistryHandler creates a handler to handle the API calls to /consensus/authcoin/registry,
+// returning all addresses authorized at the height given by the optional height query parameter,
+// which defaults to the current height. Deauthorized addresses are returned as well should the deauthorized query parameter be true.
+// The addresses are exported as CSV, one line per address, should the format query parameter be csv.
+func NewConsensusAuthCoinRegistryHandler(cs modules.ConsensusSet, plugin *authregistry.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		height, err := parseHeight(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/authcoin/registry: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var deauthorized bool
+		if str := req.URL.Query().Get("deauthorized"); str != "" {
+			deauthorized, err = strconv.ParseBool(str)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/authcoin/registry: invalid deauthorized flag: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		format := req.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/authcoin/registry: unsupported format " + format}, http.StatusBadRequest)
+			return
+		}
+		registry, err := plugin.GetRegistryAt(height, deauthorized)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/authcoin/registry: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if format == "csv" {
+			w.Header().Set("Content-Type", "text/csv")
+			registry.WriteCSV(w)
+			return
+		}
+		rapi.WriteJSON(w, ConsensusAuthCoinRegistryGET{Registry: registry})
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// WalletBalanceGET contains the breakdown of the coin balance of the wallet,
+// as returned by a GET call to /wallet/balance.
+type WalletBalanceGET struct {
+	wallet.Balance
+}
+
+// RegisterWalletBalanceHTTPHandlers registers the handler for the wallet balance breakdown HTTP endpoint.
+func RegisterWalletBalanceHTTPHandlers(router rapi.Router, w modules.Wallet, cs modules.ConsensusSet, auth wallet.AuthStateGetter, requiredPassword string) {
+	router.GET("/wallet/balance", rapi.RequirePasswordHandler(NewWalletBalanceHandler(w, cs, auth), requiredPassword))
+}
+
+// NewWalletBalanceHandler creates a handler to handle the API calls to /wallet/balance.
+func NewWalletBalanceHandler(w modules.Wallet, cs modules.ConsensusSet, auth wallet.AuthStateGetter) httprouter.Handle {
+	return func(rw http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		balance, err := wallet.GetBalance(w, cs, auth)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/balance: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletBalanceGET{Balance: balance})
+	}
+}
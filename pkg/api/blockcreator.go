@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// maxCreatedBlocksRange is the maximum amount of blocks scanned by a single call to /blockcreator/blocks.
+const maxCreatedBlocksRange = 10000
+
+type (
+	// BlockCreatorStatusGET contains the block creation status and statistics,
+	// as returned by a GET call to /blockcreator/status.
+	BlockCreatorStatusGET struct {
+		staking.Status
+	}
+
+	// BlockCreatorHealthGET contains whether the block creator creates blocks as expected,
+	// as returned by a GET call to /blockcreator/health.
+	BlockCreatorHealthGET struct {
+		staking.Health
+	}
+
+	// BlockCreatorBlocksGET contains the blocks created using the blockstakes of the wallet within a range of blocks,
+	// as returned by a GET call to /blockcreator/blocks.
+	BlockCreatorBlocksGET struct {
+		Blocks []staking.CreatedBlock `json:"blocks"`
+	}
+)
+
+// RegisterBlockCreatorHTTPHandlers registers the handlers for all block creator HTTP endpoints.
+func RegisterBlockCreatorHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, controller *staking.Controller, monitor *staking.HealthMonitor, requiredPassword string) {
+	router.GET("/blockcreator/status", NewBlockCreatorStatusHandler(controller))
+	router.GET("/blockcreator/health", NewBlockCreatorHealthHandler(monitor))
+	router.GET("/blockcreator/blocks", NewBlockCreatorBlocksHandler(cs, controller))
+	router.POST("/blockcreator/start", rapi.RequirePasswordHandler(NewBlockCreatorStartHandler(controller), requiredPassword))
+	router.POST("/blockcreator/stop", rapi.RequirePasswordHandler(NewBlockCreatorStopHandler(controller), requiredPassword))
+}
+
+// NewBlockCreatorStatusHandler creates a handler to handle the API calls to /blockcreator/status.
+func NewBlockCreatorStatusHandler(controller *staking.Controller) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		status, err := controller.Status()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/status: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, BlockCreatorStatusGET{Status: status})
+	}
+}
+
+// NewBlockCreatorHealthHandler creates a handler to handle the API calls to /blockcreator/health,
+// returning the result of the most recent health check.
+func NewBlockCreatorHealthHandler(monitor *staking.HealthMonitor) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, BlockCreatorHealthGET{Health: monitor.Health()})
+	}
+}
+
+// NewBlockCreatorBlocksHandler creates a handler to handle the API calls to /blockcreator/blocks,
+// returning the blocks created using the blockstakes of the wallet within the inclusive range
+// given by the start and (optional) end query parameters.
+// At most 10000 blocks are scanned, the end of the range defaults to the current height, and is capped to it.
+func NewBlockCreatorBlocksHandler(cs modules.ConsensusSet, controller *staking.Controller) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		start, end, err := parseBlockRange(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/blocks: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if end-start >= maxCreatedBlocksRange {
+			end = start + maxCreatedBlocksRange - 1
+		}
+		blocks, err := controller.CreatedBlocks(start, end)
+		if err == modules.ErrLockedWallet {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/blocks: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/blocks: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, BlockCreatorBlocksGET{Blocks: blocks})
+	}
+}
+
+// NewBlockCreatorStartHandler creates a handler to handle the API calls to /blockcreator/start,
+// enabling block creation should it be disabled.
+func NewBlockCreatorStartHandler(controller *staking.Controller) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		err := controller.Enable()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/start: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewBlockCreatorStopHandler creates a handler to handle the API calls to /blockcreator/stop,
+// disabling block creation should it be enabled.
+func NewBlockCreatorStopHandler(controller *staking.Controller) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		err := controller.Disable()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /blockcreator/stop: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/blockstream"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// headers set on the response of a raw block call
+const (
+	headerBlockID     = "Block-ID"
+	headerBlockHeight = "Block-Height"
+	// headerBlockRangeEnd is the height of the last block of a streamed range
+	headerBlockRangeEnd = "Block-Range-End"
+)
+
+// Encoding is the encoding in which the raw block and transaction endpoints return their object,
+// as selected using the encoding query parameter.
+type Encoding string
+
+// encodings supported by the raw block and transaction endpoints
+const (
+	// EncodingSiabin returns the canonical siabin bytes of the object, as hashed to compute its ID.
+	// It is the default encoding.
+	EncodingSiabin Encoding = "siabin"
+	// EncodingHex returns the canonical siabin bytes of the object as a hex string.
+	EncodingHex Encoding = "hex"
+	// EncodingJSON returns the JSON rendering of the object, as returned by the other endpoints.
+	EncodingJSON Encoding = "json"
+)
+
+// RegisterRawBlocksHTTPHandlers registers the handlers for the raw block consensus HTTP endpoints.
+func RegisterRawBlocksHTTPHandlers(router rapi.Router, cs modules.ConsensusSet) {
+	router.GET("/consensus/rawblocks", NewRawBlockRangeHandler(cs))
+	router.GET("/consensus/rawblocks/:height", NewRawBlockAtHeightHandler(cs))
+}
+
+// RegisterExplorerRawBlocksHTTPHandlers registers the handlers for the raw block explorer HTTP endpoints.
+func RegisterExplorerRawBlocksHTTPHandlers(router rapi.Router, explorer modules.Explorer) {
+	router.GET("/explorer/rawblocks/:id", NewRawBlockByIDHandler(explorer))
+}
+
+// NewRawBlockAtHeightHandler creates a handler to handle the API calls to /consensus/rawblocks/:height,
+// returning the block at the given height in the requested encoding.
+func NewRawBlockAtHeightHandler(cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		encoding, err := parseEncoding(req)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/rawblocks: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		height, err := strconv.ParseUint(ps.ByName("height"), 10, 64)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/rawblocks: invalid height: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		block, ok := cs.BlockAtHeight(types.BlockHeight(height))
+		if !ok {
+			rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf("error after call to /consensus/rawblocks: no block found at height %d", height)}, http.StatusNotFound)
+			return
+		}
+		writeRawBlock(w, types.BlockHeight(height), block, encoding)
+	}
+}
+
+// NewRawBlockByIDHandler creates a handler to handle the API calls to /explorer/rawblocks/:id,
+// returning the block with the given ID in the requested encoding.
+func NewRawBlockByIDHandler(explorer modules.Explorer) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		encoding, err := parseEncoding(req)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawblocks: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var hash crypto.Hash
+		err = hash.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawblocks: invalid block ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		id := types.BlockID(hash)
+		block, height, ok := explorer.Block(id)
+		if !ok {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawblocks: no block found with ID " + id.String()}, http.StatusNotFound)
+			return
+		}
+		writeRawBlock(w, height, block, encoding)
+	}
+}
+
+func writeRawBlock(w http.ResponseWriter, height types.BlockHeight, block types.Block, encoding Encoding) {
+	w.Header().Set(headerBlockID, block.ID().String())
+	w.Header().Set(headerBlockHeight, strconv.FormatUint(uint64(height), 10))
+	writeEncoded(w, block, encoding)
+}
+
+// parseEncoding parses the optional encoding query parameter of the request,
+// which defaults to the siabin encoding.
+func parseEncoding(req *http.Request) (Encoding, error) {
+	switch encoding := Encoding(req.URL.Query().Get("encoding")); encoding {
+	case "":
+		return EncodingSiabin, nil
+	case EncodingSiabin, EncodingHex, EncodingJSON:
+		return encoding, nil
+	default:
+		return "", fmt.Errorf("invalid encoding %q, expected %s, %s or %s", encoding, EncodingSiabin, EncodingHex, EncodingJSON)
+	}
+}
+
+// writeEncoded writes the given object as the body of the response, in the given encoding.
+func writeEncoded(w http.ResponseWriter, object interface{}, encoding Encoding) {
+	switch encoding {
+	case EncodingJSON:
+		rapi.WriteJSON(w, object)
+	case EncodingHex:
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(hex.EncodeToString(siabin.Marshal(object))))
+	default:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(siabin.Marshal(object))
+	}
+}
+
+// NewRawBlockRangeHandler creates a handler to handle the API calls to /consensus/rawblocks,
+// streaming all blocks within the inclusive range given by the start and (optional) end query parameters,
+// in the format defined by the blockstream package.
+// The end of the range defaults to the current height, and is capped to it.
+// The stream contains the siabin-encoded blocks, other encodings are refused.
+func NewRawBlockRangeHandler(cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		if encoding, err := parseEncoding(req); err != nil || encoding != EncodingSiabin {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/rawblocks: block ranges are only streamed siabin-encoded"}, http.StatusBadRequest)
+			return
+		}
+		start, end, err := parseBlockRange(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/rawblocks: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", blockstream.ContentType)
+		w.Header().Set(headerBlockRangeEnd, strconv.FormatUint(uint64(end), 10))
+		flusher, _ := w.(http.Flusher)
+		stream := blockstream.NewWriter(w)
+		for height := start; height <= end; height++ {
+			select {
+			case <-req.Context().Done():
+				return
+			default:
+			}
+			block, ok := cs.BlockAtHeight(height)
+			if !ok {
+				// the chain got reorganized to a shorter one, the client detects
+				// the premature end of the stream using the range end header
+				return
+			}
+			if err := stream.WriteBlock(height, block); err != nil {
+				return
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func parseBlockRange(req *http.Request, currentHeight types.BlockHeight) (start, end types.BlockHeight, err error) {
+	values := req.URL.Query()
+	str := values.Get("start")
+	if str == "" {
+		return 0, 0, errors.New("no start height given")
+	}
+	height, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start height: %v", err)
+	}
+	start = types.BlockHeight(height)
+	end = currentHeight
+	if str = values.Get("end"); str != "" {
+		height, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid end height: %v", err)
+		}
+		if types.BlockHeight(height) < end {
+			end = types.BlockHeight(height)
+		}
+	}
+	if start > end {
+		return 0, 0, fmt.Errorf("start height %d exceeds end height %d", start, end)
+	}
+	return start, end, nil
+}
+
+// parseHeight parses the optional height query parameter of the request,
+// which defaults to the current height, and cannot exceed it.
+func parseHeight(req *http.Request, currentHeight types.BlockHeight) (types.BlockHeight, error) {
+	str := req.URL.Query().Get("height")
+	if str == "" {
+		return currentHeight, nil
+	}
+	height, err := strconv.ParseUint(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid height: %v", err)
+	}
+	if types.BlockHeight(height) > currentHeight {
+		return 0, fmt.Errorf("height %d is beyond the current height %d", height, currentHeight)
+	}
+	return types.BlockHeight(height), nil
+}
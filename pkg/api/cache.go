@@ -0,0 +1,21 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/cache"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// RegisterConsensusCacheHTTPHandlers registers the handlers for all consensus cache HTTP endpoints.
+func RegisterConsensusCacheHTTPHandlers(router rapi.Router, cs *cache.ConsensusSet) {
+	router.GET("/consensus/cache", NewConsensusCacheHandler(cs))
+}
+
+// NewConsensusCacheHandler creates a handler to handle the API calls to /consensus/cache.
+func NewConsensusCacheHandler(cs *cache.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, cs.Stats())
+	}
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/chainstats"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// defaultStatsDays is the amount of days returned by a call to /explorer/stats, if not specified
+	defaultStatsDays = 30
+	// maxStatsDays is the maximum amount of days returned by a single call to /explorer/stats
+	maxStatsDays = 365
+	// defaultFeePoolPeriods is the amount of periods returned by a call to /explorer/feepool, if not specified
+	defaultFeePoolPeriods = 12
+	// maxFeePoolPeriods is the maximum amount of periods returned by a single call to /explorer/feepool
+	maxFeePoolPeriods = 366
+)
+
+type (
+	// ExplorerStatsGET contains the rolling metrics of the blockchain and the statistics of its most recent days,
+	// as returned by a GET call to /explorer/stats.
+	ExplorerStatsGET struct {
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		// TargetBlockTime is the amount of seconds the network aims for between two blocks
+		TargetBlockTime uint64              `json:"targetblocktime"`
+		Difficulty      types.Difficulty    `json:"difficulty"`
+		Windows         []chainstats.Window `json:"windows"`
+		// Days contains the statistics of the most recent days containing blocks, most recent day first
+		Days []ExplorerStatsDay `json:"days"`
+	}
+
+	// ExplorerStatsDay contains the statistics of a day, including the difficulty after its last block.
+	ExplorerStatsDay struct {
+		chainstats.DayStats
+		Difficulty types.Difficulty `json:"difficulty"`
+	}
+
+	// ExplorerFeePoolGET contains the fees which flowed to the transaction fee pool, in total and per period,
+	// as returned by a GET call to /explorer/feepool.
+	ExplorerFeePoolGET struct {
+		// Address is the address of the transaction fee pool
+		Address types.UnlockHash `json:"address"`
+		chainstats.FeePoolInflow
+	}
+)
+
+// RegisterExplorerStatsHTTPHandlers registers the handlers for the chain statistics explorer HTTP endpoints,
+// which are only registered in case the chain statistics plugin is given.
+func RegisterExplorerStatsHTTPHandlers(router rapi.Router, explorer modules.Explorer, plugin *chainstats.Plugin, constants types.ChainConstants) {
+	if plugin != nil {
+		router.GET("/explorer/stats", NewExplorerStatsHandler(explorer, plugin, constants))
+		router.GET("/explorer/feepool", NewExplorerFeePoolHandler(plugin, constants))
+	}
+}
+
+// NewExplorerStatsHandler creates a handler to handle the API calls to /explorer/stats,
+// returning the rolling metrics of the blockchain, as well as the statistics of the amount of most recent days
+// given by the optional days query parameter (30 by default, at most 365).
+func NewExplorerStatsHandler(explorer modules.Explorer, plugin *chainstats.Plugin, constants types.ChainConstants) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		days := uint64(defaultStatsDays)
+		if str := req.URL.Query().Get("days"); str != "" {
+			var err error
+			days, err = strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/stats: invalid amount of days: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			if days > maxStatsDays {
+				days = maxStatsDays
+			}
+		}
+		stats, err := plugin.GetStats(days)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/stats: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		resp := ExplorerStatsGET{
+			Height:          stats.Height,
+			Timestamp:       stats.Timestamp,
+			TargetBlockTime: uint64(constants.BlockFrequency),
+			Windows:         stats.Windows,
+			Days:            make([]ExplorerStatsDay, 0, len(stats.Days)),
+		}
+		if facts, ok := explorer.BlockFacts(stats.Height); ok {
+			resp.Difficulty = facts.Difficulty
+		}
+		for _, day := range stats.Days {
+			facts, _ := explorer.BlockFacts(day.LastHeight)
+			resp.Days = append(resp.Days, ExplorerStatsDay{DayStats: day, Difficulty: facts.Difficulty})
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// NewExplorerFeePoolHandler creates a handler to handle the API calls to /explorer/feepool,
+// returning the fees which flowed to the transaction fee pool, in total and for the amount of most recent periods
+// given by the optional periods query parameter (12 by default, at most 366).
+// The optional period query parameter is either day, week (the default) or month.
+func NewExplorerFeePoolHandler(plugin *chainstats.Plugin, constants types.ChainConstants) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		query := req.URL.Query()
+		period := chainstats.PeriodWeek
+		if str := query.Get("period"); str != "" {
+			var err error
+			period, err = chainstats.ParsePeriod(str)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/feepool: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		periods := uint64(defaultFeePoolPeriods)
+		if str := query.Get("periods"); str != "" {
+			var err error
+			periods, err = strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/feepool: invalid amount of periods: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			if periods > maxFeePoolPeriods {
+				periods = maxFeePoolPeriods
+			}
+		}
+		inflow, err := plugin.GetFeePoolInflow(period, periods)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/feepool: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ExplorerFeePoolGET{
+			Address:       constants.TransactionFeeCondition.UnlockHash(),
+			FeePoolInflow: inflow,
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/rawtx"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// TransactionPoolDecodePOSTResp contains the decoded transaction,
+	// as returned by a POST call to /transactionpool/decode.
+	TransactionPoolDecodePOSTResp struct {
+		rawtx.DecodedTransaction
+	}
+
+	// TransactionPoolComposePOST contains the intent of the transaction to compose,
+	// as given as the body of a POST call to /transactionpool/compose.
+	TransactionPoolComposePOST struct {
+		rawtx.Intent
+	}
+
+	// TransactionPoolComposePOSTResp contains the unsigned transaction,
+	// as returned by a POST call to /transactionpool/compose.
+	TransactionPoolComposePOSTResp struct {
+		rawtx.Composition
+	}
+)
+
+// maxDecodeRequestSize limits the size of the body of a decode request,
+// which fits a hex-encoded transaction of the largest block.
+const maxDecodeRequestSize = 4 << 20
+
+// RegisterTransactionPoolComposeHTTPHandlers registers the handlers for the raw transaction decode and compose HTTP endpoints.
+// Transactions can only be funded using addresses if the given address index (the explorer) is defined.
+func RegisterTransactionPoolComposeHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, tpool modules.TransactionPool, index rawtx.AddressIndex, constants types.ChainConstants) {
+	router.POST("/transactionpool/decode", NewTransactionPoolDecodeHandler(cs, tpool))
+	router.POST("/transactionpool/compose", NewTransactionPoolComposeHandler(cs, tpool, index, constants))
+}
+
+// NewTransactionPoolDecodeHandler creates a handler to handle the API calls to /transactionpool/decode,
+// decoding the transaction given as the body, in the encoding given by the optional encoding query parameter,
+// and looking up the outputs spent by its inputs.
+func NewTransactionPoolDecodeHandler(cs modules.ConsensusSet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		encoding, err := parseEncoding(req)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/decode: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, req.Body, maxDecodeRequestSize))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/decode: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := decodeTransaction(body, encoding)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, TransactionPoolDecodePOSTResp{
+			DecodedTransaction: rawtx.Decode(txn, cs, tpool.TransactionList()),
+		})
+	}
+}
+
+// NewTransactionPoolComposeHandler creates a handler to handle the API calls to /transactionpool/compose,
+// composing the unsigned transaction of the intent given as the body.
+func NewTransactionPoolComposeHandler(cs modules.ConsensusSet, tpool modules.TransactionPool, index rawtx.AddressIndex, constants types.ChainConstants) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body TransactionPoolComposePOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied intent: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		composition, err := rawtx.Compose(body.Intent, rawtx.ComposeContext{
+			Outputs: cs,
+			Index:   index,
+			Pool:    tpool.TransactionList(),
+			Fulfillable: types.FulfillableContext{
+				BlockHeight: cs.Height(),
+				BlockTime:   cs.CurrentBlock().Timestamp,
+			},
+			Constants: constants,
+		})
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/compose: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, TransactionPoolComposePOSTResp{Composition: composition})
+	}
+}
+
+// decodeTransaction decodes a transaction given in the given encoding.
+func decodeTransaction(b []byte, encoding Encoding) (types.Transaction, error) {
+	var txn types.Transaction
+	switch encoding {
+	case EncodingJSON:
+		err := json.Unmarshal(b, &txn)
+		return txn, err
+	case EncodingHex:
+		var err error
+		b, err = hex.DecodeString(strings.TrimSpace(string(b)))
+		if err != nil {
+			return types.Transaction{}, fmt.Errorf("invalid hex: %v", err)
+		}
+	}
+	err := siabin.Unmarshal(b, &txn)
+	return txn, err
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// ConsensusValidatePOST contains the transaction set,
+	// as given as the body of a POST call to /consensus/validate.
+	ConsensusValidatePOST struct {
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// ConsensusValidatePOSTResp contains the validation result of a transaction set,
+	// as returned by a POST call to /consensus/validate.
+	// The diffs are only defined in case the transaction set is valid.
+	ConsensusValidatePOSTResp struct {
+		Valid                 bool                           `json:"valid"`
+		Error                 string                         `json:"error,omitempty"`
+		CoinOutputDiffs       []modules.CoinOutputDiff       `json:"coinoutputdiffs,omitempty"`
+		BlockStakeOutputDiffs []modules.BlockStakeOutputDiff `json:"blockstakeoutputdiffs,omitempty"`
+	}
+)
+
+const (
+	// maxValidateRequestSize limits the size of the body of a validation request,
+	// as the route is not authenticated.
+	maxValidateRequestSize = 4 << 20
+	// maxValidateTransactions limits the amount of transactions validated by a single request,
+	// as these are validated within a database transaction of the consensus set.
+	maxValidateTransactions = 100
+)
+
+// RegisterConsensusValidateHTTPHandlers registers the handlers for the consensus validation HTTP endpoints.
+func RegisterConsensusValidateHTTPHandlers(router rapi.Router, cs modules.ConsensusSet) {
+	router.POST("/consensus/validate", NewConsensusValidateHandler(cs))
+}
+
+// NewConsensusValidateHandler creates a handler to handle the API calls to /consensus/validate.
+// The transaction set is validated against the current consensus state, including all plugin validators,
+// without it being broadcasted or added to the transaction pool.
+// The size of the body and the amount of transactions are limited, as the route is not authenticated.
+func NewConsensusValidateHandler(cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body ConsensusValidatePOST
+		err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxValidateRequestSize)).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied transaction set: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if len(body.Transactions) == 0 {
+			rapi.WriteError(w, rapi.Error{Message: "no transactions supplied"}, http.StatusBadRequest)
+			return
+		}
+		if len(body.Transactions) > maxValidateTransactions {
+			rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+				"too many transactions supplied: %d, at most %d can be validated at once", len(body.Transactions), maxValidateTransactions)}, http.StatusBadRequest)
+			return
+		}
+		cc, err := cs.TryTransactionSet(body.Transactions)
+		if err != nil {
+			rapi.WriteJSON(w, ConsensusValidatePOSTResp{
+				Valid: false,
+				Error: err.Error(),
+			})
+			return
+		}
+		rapi.WriteJSON(w, ConsensusValidatePOSTResp{
+			Valid:                 true,
+			CoinOutputDiffs:       cc.CoinOutputDiffs,
+			BlockStakeOutputDiffs: cc.BlockStakeOutputDiffs,
+		})
+	}
+}
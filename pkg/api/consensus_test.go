@@ -0,0 +1,36 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestConsensusValidateLimits(t *testing.T) {
+	// both requests are refused before the consensus set is used
+	handler := NewConsensusValidateHandler(nil)
+
+	body := `{"transactions":[` + strings.Repeat(" ", maxValidateRequestSize) + `]}`
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/consensus/validate", strings.NewReader(body)), nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a body above the size limit to be refused, got status %d", rec.Code)
+	}
+
+	b, err := json.Marshal(ConsensusValidatePOST{
+		Transactions: make([]types.Transaction, maxValidateTransactions+1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/consensus/validate", bytes.NewReader(b)), nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "too many transactions") {
+		t.Fatalf("expected a set above the transaction limit to be refused, got status %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletContactsGET contains all contacts of the address book,
+	// as returned by a GET call to /wallet/contacts.
+	WalletContactsGET struct {
+		Contacts []wallet.Contact `json:"contacts"`
+	}
+
+	// WalletContactsPOST contains the contact to add,
+	// as given as the body of a POST call to /wallet/contacts.
+	WalletContactsPOST struct {
+		Name    string           `json:"name"`
+		Address types.UnlockHash `json:"address"`
+	}
+
+	// WalletContactPOST contains the new address of a contact,
+	// as given as the body of a POST call to /wallet/contacts/:name.
+	WalletContactPOST struct {
+		Address types.UnlockHash `json:"address"`
+	}
+)
+
+// RegisterWalletContactsHTTPHandlers registers the handlers for the wallet address book HTTP endpoints.
+func RegisterWalletContactsHTTPHandlers(router rapi.Router, addressBook *wallet.AddressBook, requiredPassword string) {
+	router.GET("/wallet/contacts", rapi.RequirePasswordHandler(NewWalletContactsHandler(addressBook), requiredPassword))
+	router.POST("/wallet/contacts", rapi.RequirePasswordHandler(NewWalletAddContactHandler(addressBook), requiredPassword))
+	router.GET("/wallet/contacts/:name", rapi.RequirePasswordHandler(NewWalletContactHandler(addressBook), requiredPassword))
+	router.POST("/wallet/contacts/:name", rapi.RequirePasswordHandler(NewWalletUpdateContactHandler(addressBook), requiredPassword))
+	router.POST("/wallet/contacts/:name/remove", rapi.RequirePasswordHandler(NewWalletRemoveContactHandler(addressBook), requiredPassword))
+}
+
+// NewWalletContactsHandler creates a handler to handle the GET API calls to /wallet/contacts.
+func NewWalletContactsHandler(addressBook *wallet.AddressBook) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		contacts, err := addressBook.Contacts()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/contacts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletContactsGET{Contacts: contacts})
+	}
+}
+
+// NewWalletAddContactHandler creates a handler to handle the POST API calls to /wallet/contacts,
+// adding a new contact to the address book.
+func NewWalletAddContactHandler(addressBook *wallet.AddressBook) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletContactsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied contact: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		contact, err := addressBook.AddContact(body.Name, body.Address)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/contacts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, contact)
+	}
+}
+
+// NewWalletContactHandler creates a handler to handle the GET API calls to /wallet/contacts/:name.
+func NewWalletContactHandler(addressBook *wallet.AddressBook) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		contact, err := addressBook.Contact(ps.ByName("name"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/contacts/$(name): " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, contact)
+	}
+}
+
+// NewWalletUpdateContactHandler creates a handler to handle the POST API calls to /wallet/contacts/:name,
+// updating the address of an existing contact.
+func NewWalletUpdateContactHandler(addressBook *wallet.AddressBook) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var body WalletContactPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied contact: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		contact, err := addressBook.UpdateContact(ps.ByName("name"), body.Address)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/contacts/$(name): " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, contact)
+	}
+}
+
+// NewWalletRemoveContactHandler creates a handler to handle the API calls to /wallet/contacts/:name/remove.
+func NewWalletRemoveContactHandler(addressBook *wallet.AddressBook) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		err := addressBook.RemoveContact(ps.ByName("name"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/contacts/$(name)/remove: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
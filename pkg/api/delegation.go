@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/delegation"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// ConsensusDelegationsGET contains the active blockstake delegations,
+	// as returned by a GET call to /consensus/delegations.
+	ConsensusDelegationsGET struct {
+		Delegations []delegation.Delegation `json:"delegations"`
+	}
+
+	// WalletDelegationPOST contains the operator to delegate the blockstakes of the wallet to,
+	// as given as the body of a POST call to /wallet/delegation.
+	WalletDelegationPOST struct {
+		// Operator is the address allowed to create blocks using the blockstakes,
+		// the nil address (an empty string) revokes the delegation
+		Operator types.UnlockHash `json:"operator"`
+		// Addresses are the addresses of the wallet delegating their blockstakes,
+		// all addresses of the wallet owning blockstakes delegate their blockstakes if none are given
+		Addresses []types.UnlockHash `json:"addresses,omitempty"`
+	}
+
+	// WalletDelegationPOSTResp contains the ID of the blockstake delegation transaction,
+	// as returned by a POST call to /wallet/delegation.
+	WalletDelegationPOSTResp struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+)
+
+// RegisterConsensusDelegationHTTPHandlers registers the handler for the blockstake delegation consensus HTTP endpoint.
+func RegisterConsensusDelegationHTTPHandlers(router rapi.Router, plugin *delegation.Plugin) {
+	router.GET("/consensus/delegations", NewConsensusDelegationsHandler(plugin))
+}
+
+// NewConsensusDelegationsHandler creates a handler to handle the API calls to /consensus/delegations,
+// returning all active blockstake delegations, limited to the delegations to the operator
+// given by the optional operator query parameter.
+func NewConsensusDelegationsHandler(plugin *delegation.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var operator *types.UnlockHash
+		if str := req.URL.Query().Get("operator"); str != "" {
+			operator = new(types.UnlockHash)
+			err := operator.LoadString(str)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/delegations: invalid operator: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		delegations, err := plugin.GetDelegations(operator)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/delegations: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ConsensusDelegationsGET{Delegations: delegations})
+	}
+}
+
+// NewWalletDelegationHandler creates a handler to handle the API calls to /wallet/delegation,
+// delegating the right to create blocks using the blockstakes of the wallet to an operator,
+// or revoking that delegation.
+func NewWalletDelegationHandler(w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletDelegationPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied delegation: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := wallet.Delegate(w, tpool, body.Operator, body.Addresses, constants)
+		if err != nil {
+			status := walletErrorToHTTPStatus(err)
+			if cErr, ok := err.(types.ClientError); ok {
+				status = cErr.Kind.AsHTTPStatusCode()
+			}
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/delegation: " + err.Error()}, status)
+			return
+		}
+		rapi.WriteJSON(rw, WalletDelegationPOSTResp{TransactionID: txn.ID()})
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletDepositsGET contains the deposit addresses selected by the reference query parameter,
+	// as returned by a GET call to /wallet/deposits.
+	WalletDepositsGET struct {
+		Addresses []wallet.DepositAddress `json:"addresses"`
+	}
+
+	// WalletDepositsPOST contains the customer reference and webhook of the deposit address to allocate,
+	// as given as the body of a POST call to /wallet/deposits.
+	WalletDepositsPOST struct {
+		Reference string `json:"reference"`
+		// Webhook receives the deposits to the address, no notifications are posted if omitted
+		Webhook wallet.DepositWebhook `json:"webhook"`
+	}
+)
+
+// RegisterWalletDepositsHTTPHandlers registers the handlers for the wallet deposit address HTTP endpoints.
+func RegisterWalletDepositsHTTPHandlers(router rapi.Router, deposits *wallet.Deposits, requiredPassword string) {
+	router.GET("/wallet/deposits", rapi.RequirePasswordHandler(NewWalletDepositsHandler(deposits), requiredPassword))
+	router.POST("/wallet/deposits", rapi.RequirePasswordHandler(NewWalletAllocateDepositHandler(deposits), requiredPassword))
+	router.GET("/wallet/deposits/:address", rapi.RequirePasswordHandler(NewWalletDepositHandler(deposits), requiredPassword))
+}
+
+// NewWalletDepositsHandler creates a handler to handle the GET API calls to /wallet/deposits.
+func NewWalletDepositsHandler(deposits *wallet.Deposits) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, WalletDepositsGET{Addresses: deposits.Addresses(req.FormValue("reference"))})
+	}
+}
+
+// NewWalletAllocateDepositHandler creates a handler to handle the POST API calls to /wallet/deposits,
+// allocating a fresh deposit address for a customer.
+func NewWalletAllocateDepositHandler(deposits *wallet.Deposits) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletDepositsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/deposits: invalid body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		address, err := deposits.Allocate(body.Reference, body.Webhook)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/deposits: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, address)
+	}
+}
+
+// NewWalletDepositHandler creates a handler to handle the GET API calls to /wallet/deposits/:address.
+func NewWalletDepositHandler(deposits *wallet.Deposits) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var uh types.UnlockHash
+		err := uh.LoadString(ps.ByName("address"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/deposits: invalid address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		address, err := deposits.Address(uh)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/deposits: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, address)
+	}
+}
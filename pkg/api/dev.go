@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// maxMinedBlocks is the maximum amount of blocks created by a single call to /dev/mine.
+const maxMinedBlocks = 1000
+
+// DevMinePOST contains the blocks created on demand,
+// as returned by a POST call to /dev/mine.
+type DevMinePOST struct {
+	BlockIDs []types.BlockID `json:"blockids"`
+	// Height is the height of the chain after the blocks were created
+	Height types.BlockHeight `json:"height"`
+}
+
+// RegisterDevHTTPHandlers registers the handlers for the HTTP endpoints only available on the devnet.
+func RegisterDevHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, miner *staking.Miner, requiredPassword string) {
+	router.POST("/dev/mine", rapi.RequirePasswordHandler(NewDevMineHandler(cs, miner), requiredPassword))
+}
+
+// NewDevMineHandler creates a handler to handle the API calls to /dev/mine,
+// immediately creating the amount of blocks given by the optional blocks query parameter, 1 by default.
+// At most 1000 blocks are created by a single call.
+func NewDevMineHandler(cs modules.ConsensusSet, miner *staking.Miner) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		count := 1
+		if str := req.FormValue("blocks"); str != "" {
+			var err error
+			count, err = strconv.Atoi(str)
+			if err != nil || count < 1 || count > maxMinedBlocks {
+				rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+					"error after call to /dev/mine: invalid amount of blocks %q: has to be a number from 1 to %d", str, maxMinedBlocks)},
+					http.StatusBadRequest)
+				return
+			}
+		}
+		ids, err := miner.Mine(count)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == staking.ErrNoActiveBlockStakes || err == staking.ErrFutureThreshold || err == modules.ErrLockedWallet {
+				status = http.StatusBadRequest
+			}
+			rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+				"error after call to /dev/mine: created %d of %d blocks: %v", len(ids), count, err)}, status)
+			return
+		}
+		rapi.WriteJSON(w, DevMinePOST{BlockIDs: ids, Height: cs.Height()})
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/spends"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// The statuses of a transaction, as reported by the double spend endpoints.
+const (
+	// TransactionStatusConfirmed is the status of a transaction which is part of the blockchain
+	TransactionStatusConfirmed = "confirmed"
+	// TransactionStatusUnconfirmed is the status of a transaction which is in the transaction pool
+	TransactionStatusUnconfirmed = "unconfirmed"
+	// TransactionStatusDoubleSpent is the status of a transaction of which at least one input
+	// is spent by another confirmed transaction, such that it can never be confirmed
+	TransactionStatusDoubleSpent = "doublespent"
+	// TransactionStatusDropped is the status of a transaction which is neither confirmed nor in the transaction pool,
+	// without any of its inputs being spent by another confirmed transaction
+	TransactionStatusDropped = "dropped"
+)
+
+type (
+	// ConsensusDoubleSpendsGET contains the status of a transaction,
+	// as returned by a call to /consensus/doublespends.
+	ConsensusDoubleSpendsGET struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Status        string              `json:"status"`
+		// Height is the height of the block containing the transaction, in case it is confirmed
+		Height types.BlockHeight `json:"height,omitempty"`
+		// Conflicts are the inputs of the transaction spent by other confirmed transactions
+		Conflicts []spends.Spend `json:"conflicts"`
+	}
+
+	// ConsensusDoubleSpendsPOST contains the transaction to check,
+	// as given as the body of a POST call to /consensus/doublespends.
+	ConsensusDoubleSpendsPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+)
+
+// RegisterDoubleSpendsHTTPHandlers registers the handlers for the double spend HTTP endpoints,
+// none of which are registered in case the spends plugin is not given.
+func RegisterDoubleSpendsHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, tpool modules.TransactionPool, plugin *spends.Plugin, recent *spends.RecentTransactions) {
+	if plugin == nil {
+		return
+	}
+	router.GET("/consensus/doublespends/:id", NewConsensusDoubleSpendsHandler(cs, tpool, plugin, recent))
+	router.POST("/consensus/doublespends", NewConsensusDoubleSpendsCheckHandler(cs, tpool, plugin))
+}
+
+// NewConsensusDoubleSpendsHandler creates a handler to handle the API calls to /consensus/doublespends/:id,
+// returning the status of the transaction with the given ID, which has to be confirmed,
+// in the transaction pool or seen in the transaction pool recently. Other transactions
+// can be checked by giving the full transaction using a POST call to /consensus/doublespends.
+func NewConsensusDoubleSpendsHandler(cs modules.ConsensusSet, tpool modules.TransactionPool, plugin *spends.Plugin, recent *spends.RecentTransactions) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var id types.TransactionID
+		err := id.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/doublespends: invalid transaction ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if height, ok := confirmedHeight(cs, id); ok {
+			rapi.WriteJSON(w, ConsensusDoubleSpendsGET{
+				TransactionID: id,
+				Status:        TransactionStatusConfirmed,
+				Height:        height,
+				Conflicts:     []spends.Spend{},
+			})
+			return
+		}
+		txn, err := tpool.Transaction(id)
+		if err != nil {
+			var ok bool
+			if txn, ok = recent.Transaction(id); !ok {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/doublespends: unknown transaction " + id.String() +
+					", POST the full transaction to /consensus/doublespends instead"}, http.StatusNotFound)
+				return
+			}
+		}
+		writeDoubleSpendStatus(w, tpool, plugin, txn)
+	}
+}
+
+// NewConsensusDoubleSpendsCheckHandler creates a handler to handle the POST API calls to /consensus/doublespends,
+// returning the status of the given transaction.
+func NewConsensusDoubleSpendsCheckHandler(cs modules.ConsensusSet, tpool modules.TransactionPool, plugin *spends.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body ConsensusDoubleSpendsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/doublespends: error decoding the supplied transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		id := body.Transaction.ID()
+		if height, ok := confirmedHeight(cs, id); ok {
+			rapi.WriteJSON(w, ConsensusDoubleSpendsGET{
+				TransactionID: id,
+				Status:        TransactionStatusConfirmed,
+				Height:        height,
+				Conflicts:     []spends.Spend{},
+			})
+			return
+		}
+		writeDoubleSpendStatus(w, tpool, plugin, body.Transaction)
+	}
+}
+
+// confirmedHeight returns the height of the block containing the transaction with the given ID, should it be confirmed.
+func confirmedHeight(cs modules.ConsensusSet, id types.TransactionID) (types.BlockHeight, bool) {
+	txn, shortID, ok := cs.TransactionAtID(id)
+	// the consensus set returns the first transaction of the genesis block for unknown IDs
+	if !ok || txn.ID() != id {
+		return 0, false
+	}
+	return shortID.BlockHeight(), true
+}
+
+// writeDoubleSpendStatus writes the status of an unconfirmed transaction.
+func writeDoubleSpendStatus(w http.ResponseWriter, tpool modules.TransactionPool, plugin *spends.Plugin, txn types.Transaction) {
+	id := txn.ID()
+	conflicts, err := plugin.Conflicts(txn)
+	if err != nil {
+		rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/doublespends: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	resp := ConsensusDoubleSpendsGET{
+		TransactionID: id,
+		Status:        TransactionStatusDropped,
+		Conflicts:     conflicts,
+	}
+	if len(conflicts) > 0 {
+		resp.Status = TransactionStatusDoubleSpent
+	} else if _, err := tpool.Transaction(id); err == nil {
+		resp.Status = TransactionStatusUnconfirmed
+	}
+	if resp.Conflicts == nil {
+		resp.Conflicts = []spends.Spend{}
+	}
+	rapi.WriteJSON(w, resp)
+}
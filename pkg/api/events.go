@@ -0,0 +1,169 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/events"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+const (
+	// defaultEventsLimit is the number of events returned by a call to /consensus/events,
+	// should the limit query parameter not be given
+	defaultEventsLimit = 1000
+	// maxEventsLimit is the maximum number of events returned by a call to /consensus/events
+	maxEventsLimit = 10000
+	// maxEventsWait is the maximum duration a call to /consensus/events waits for an event.
+	maxEventsWait = 10 * time.Minute
+)
+
+type (
+	// ConsensusEventsGET contains the events following a given sequence number,
+	// as returned by a GET call to /consensus/events.
+	ConsensusEventsGET struct {
+		// LastSeq is the sequence number of the last recorded event
+		LastSeq uint64 `json:"lastseq"`
+		// Events are the selected events, oldest first,
+		// the sequence number of the last one is to be used as the after query parameter of the next call
+		Events []events.Event `json:"events"`
+	}
+
+	// ConsensusEventCursorsGET contains the sequence number of the last event processed by every consumer,
+	// as returned by a GET call to /consensus/events/cursors.
+	ConsensusEventCursorsGET struct {
+		Cursors map[string]uint64 `json:"cursors"`
+	}
+
+	// ConsensusEventCursorPOST is the body of a POST call to /consensus/events/cursors/:name,
+	// storing the sequence number of the last event processed by the named consumer.
+	ConsensusEventCursorPOST struct {
+		Seq uint64 `json:"seq"`
+	}
+)
+
+// RegisterEventsHTTPHandlers registers the handlers for the events consensus HTTP endpoints.
+func RegisterEventsHTTPHandlers(router rapi.Router, plugin *events.Plugin, cursors *events.Cursors, requiredPassword string) {
+	if plugin == nil {
+		return
+	}
+	router.GET("/consensus/events", NewConsensusEventsHandler(plugin, cursors))
+	router.GET("/consensus/events/cursors", NewConsensusEventCursorsHandler(cursors))
+	router.POST("/consensus/events/cursors/:name", rapi.RequirePasswordHandler(NewConsensusSetEventCursorHandler(cursors), requiredPassword))
+	router.POST("/consensus/events/cursors/:name/remove", rapi.RequirePasswordHandler(NewConsensusRemoveEventCursorHandler(cursors), requiredPassword))
+}
+
+// NewConsensusEventsHandler creates a handler to handle the API calls to /consensus/events,
+// returning at most limit (1000 by default, 10000 at most) events with a sequence number greater than the after query parameter.
+// Instead of the after query parameter, the cursor query parameter can be given,
+// in which case the events following the sequence number stored for that consumer are returned.
+//
+// Given the optional wait query parameter (a duration of at most 10 minutes), the call waits for such an event,
+// should none be recorded yet, returning no events once the duration passed.
+// The duration is capped to the remaining time before the API timeout of the request.
+func NewConsensusEventsHandler(plugin *events.Plugin, cursors *events.Cursors) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		query := req.URL.Query()
+		var after uint64
+		limit := defaultEventsLimit
+		var wait time.Duration
+		var err error
+		if str := query.Get("after"); str != "" {
+			after, err = strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events: invalid after sequence number: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		} else if name := query.Get("cursor"); name != "" {
+			after, err = cursors.Get(name)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events: " + err.Error()}, eventsErrorToHTTPStatus(err))
+				return
+			}
+		}
+		if str := query.Get("limit"); str != "" {
+			limit, err = strconv.Atoi(str)
+			if err != nil || limit < 1 || limit > maxEventsLimit {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events: invalid limit: " + str}, http.StatusBadRequest)
+				return
+			}
+		}
+		if str := query.Get("wait"); str != "" {
+			wait, err = time.ParseDuration(str)
+			if err != nil || wait < 0 || wait > maxEventsWait {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events: invalid wait duration: " + str}, http.StatusBadRequest)
+				return
+			}
+		}
+
+		var resp ConsensusEventsGET
+		if wait == 0 {
+			resp.Events, resp.LastSeq, err = plugin.Events(after, limit)
+		} else {
+			// respond before the request times out
+			if deadline, ok := req.Context().Deadline(); ok {
+				if remaining := time.Until(deadline) - time.Second; remaining < wait {
+					wait = remaining
+				}
+			}
+			ctx, cancel := context.WithTimeout(req.Context(), wait)
+			defer cancel()
+			resp.Events, resp.LastSeq, err = plugin.Wait(ctx, after, limit)
+		}
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// NewConsensusEventCursorsHandler creates a handler to handle the API calls to /consensus/events/cursors.
+func NewConsensusEventCursorsHandler(cursors *events.Cursors) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, ConsensusEventCursorsGET{Cursors: cursors.All()})
+	}
+}
+
+// NewConsensusSetEventCursorHandler creates a handler to handle the API calls to /consensus/events/cursors/:name,
+// storing the sequence number of the last event processed by the named consumer.
+func NewConsensusSetEventCursorHandler(cursors *events.Cursors) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var body ConsensusEventCursorPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied cursor: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = cursors.Set(ps.ByName("name"), body.Seq)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events/cursors/$(name): " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewConsensusRemoveEventCursorHandler creates a handler to handle the API calls to /consensus/events/cursors/:name/remove.
+func NewConsensusRemoveEventCursorHandler(cursors *events.Cursors) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		err := cursors.Delete(ps.ByName("name"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/events/cursors/$(name)/remove: " + err.Error()}, eventsErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// eventsErrorToHTTPStatus returns the HTTP status matching the given cursor error.
+func eventsErrorToHTTPStatus(err error) int {
+	if err == events.ErrUnknownCursor {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/faults"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DebugFaultsGET contains the faults injected into the node and how many were injected,
+// as returned by a GET call to /debug/faults.
+type DebugFaultsGET struct {
+	Faults faults.Faults `json:"faults"`
+	Stats  faults.Stats  `json:"stats"`
+}
+
+// DebugReorgPOST contains the blocks of the competing chain which replaced the last blocks of the chain,
+// as returned by a POST call to /debug/reorg.
+type DebugReorgPOST struct {
+	BlockIDs []types.BlockID `json:"blockids"`
+	// Height is the height of the chain after the reorganization
+	Height types.BlockHeight `json:"height"`
+}
+
+// RegisterFaultsHTTPHandlers registers the handlers for the HTTP endpoints controlling the faults injected into the node,
+// only available in nodes built using the faults build tag. Reorganizations can only be forced should a miner be given.
+func RegisterFaultsHTTPHandlers(router rapi.Router, injector *faults.Injector, cs modules.ConsensusSet, miner *staking.Miner, requiredPassword string) {
+	router.GET("/debug/faults", NewDebugFaultsHandler(injector))
+	router.POST("/debug/faults", rapi.RequirePasswordHandler(NewDebugSetFaultsHandler(injector), requiredPassword))
+	if miner != nil {
+		router.POST("/debug/reorg", rapi.RequirePasswordHandler(NewDebugReorgHandler(cs, miner), requiredPassword))
+	}
+}
+
+// NewDebugFaultsHandler creates a handler to handle the GET API calls to /debug/faults.
+func NewDebugFaultsHandler(injector *faults.Injector) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, DebugFaultsGET{
+			Faults: injector.Faults(),
+			Stats:  injector.Stats(),
+		})
+	}
+}
+
+// NewDebugSetFaultsHandler creates a handler to handle the POST API calls to /debug/faults,
+// replacing the faults injected by the faults given in the body, such that an empty object stops injecting faults.
+func NewDebugSetFaultsHandler(injector *faults.Injector) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body faults.Faults
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied faults: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = injector.SetFaults(body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /debug/faults: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewDebugReorgHandler creates a handler to handle the API calls to /debug/reorg,
+// forcing a reorganization of the depth given by the optional depth query parameter, 1 by default.
+func NewDebugReorgHandler(cs modules.ConsensusSet, miner *staking.Miner) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		depth := uint64(1)
+		if str := req.FormValue("depth"); str != "" {
+			var err error
+			depth, err = strconv.ParseUint(str, 10, 64)
+			if err != nil || depth == 0 {
+				rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+					"error after call to /debug/reorg: invalid depth %q: has to be a positive number", str)}, http.StatusBadRequest)
+				return
+			}
+		}
+		if types.BlockHeight(depth) > cs.Height() {
+			rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+				"error after call to /debug/reorg: cannot revert %d blocks at height %d", depth, cs.Height())}, http.StatusBadRequest)
+			return
+		}
+		ids, err := miner.Reorg(types.BlockHeight(depth))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == staking.ErrNoForkBlockStakes || err == staking.ErrFutureThreshold || err == modules.ErrLockedWallet {
+				status = http.StatusBadRequest
+			}
+			rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf(
+				"error after call to /debug/reorg: mined %d blocks of the competing chain: %v", len(ids), err)}, status)
+			return
+		}
+		rapi.WriteJSON(w, DebugReorgPOST{BlockIDs: ids, Height: cs.Height()})
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/finality"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ConsensusCheckpointsGET contains the finality policy of the node,
+// as returned by a GET call to /consensus/checkpoints.
+type ConsensusCheckpointsGET struct {
+	// MaxReorgDepth is the maximum amount of blocks a reorganization can revert, 0 if unlimited
+	MaxReorgDepth types.BlockHeight `json:"maxreorgdepth"`
+	// Authority is the address signing the checkpoints of the network
+	Authority types.UnlockHash `json:"authority"`
+	// EmbeddedCheckpoints are the checkpoints embedded in the release, lowest height first
+	EmbeddedCheckpoints []finality.Checkpoint `json:"embeddedcheckpoints"`
+	// Checkpoints are the signed checkpoints added to the node, lowest height first
+	Checkpoints []finality.SignedCheckpoint `json:"checkpoints"`
+}
+
+// RegisterFinalityHTTPHandlers registers the handlers for the checkpoints consensus HTTP endpoints.
+func RegisterFinalityHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, plugin *finality.Plugin, requiredPassword string) {
+	router.GET("/consensus/checkpoints", NewConsensusCheckpointsHandler(plugin))
+	router.POST("/consensus/checkpoints", rapi.RequirePasswordHandler(NewConsensusAddCheckpointHandler(cs, plugin), requiredPassword))
+}
+
+// NewConsensusCheckpointsHandler creates a handler to handle the GET API calls to /consensus/checkpoints.
+func NewConsensusCheckpointsHandler(plugin *finality.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, ConsensusCheckpointsGET{
+			MaxReorgDepth:       plugin.MaxReorgDepth(),
+			Authority:           plugin.Authority(),
+			EmbeddedCheckpoints: plugin.EmbeddedCheckpoints(),
+			Checkpoints:         plugin.Checkpoints(),
+		})
+	}
+}
+
+// NewConsensusAddCheckpointHandler creates a handler to handle the POST API calls to /consensus/checkpoints,
+// enforcing the signed checkpoint given as body from now on.
+//
+// Should the blockchain of the node conflict with the checkpoint, it is stored nonetheless,
+// but 409 is returned, as the node has to be resynced in order to follow the checkpointed chain.
+func NewConsensusAddCheckpointHandler(cs modules.ConsensusSet, plugin *finality.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var scp finality.SignedCheckpoint
+		err := json.NewDecoder(req.Body).Decode(&scp)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied checkpoint: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = plugin.AddCheckpoint(scp, cs)
+		if _, ok := err.(*finality.ConflictError); ok {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/checkpoints: " + err.Error() +
+				", the checkpoint is stored but the node has to be resynced"}, http.StatusConflict)
+			return
+		}
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/checkpoints: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
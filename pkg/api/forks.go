@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ConsensusForksGET contains the hard fork activation table of the network,
+// as returned by a GET call to /consensus/forks.
+type ConsensusForksGET struct {
+	// Height is the height of the current block
+	Height types.BlockHeight `json:"height"`
+	// Next are the limits in effect for the next block
+	Next config.Limits `json:"next"`
+	// Genesis are the limits in effect from the genesis block until the first fork
+	Genesis config.Limits `json:"genesis"`
+	// Forks are the scheduled hard forks, lowest height first, with all their limits defined
+	Forks []config.Fork `json:"forks"`
+}
+
+// RegisterForksHTTPHandlers registers the handler for the forks consensus HTTP endpoint.
+func RegisterForksHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, schedule config.ForkSchedule) {
+	router.GET("/consensus/forks", NewConsensusForksHandler(cs, schedule))
+}
+
+// NewConsensusForksHandler creates a handler to handle the GET API calls to /consensus/forks.
+func NewConsensusForksHandler(cs modules.ConsensusSet, schedule config.ForkSchedule) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		height := cs.Height()
+		rapi.WriteJSON(w, ConsensusForksGET{
+			Height:  height,
+			Next:    schedule.LimitsAt(height + 1),
+			Genesis: schedule.Genesis(),
+			Forks:   schedule.Forks(),
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/governance"
+	"github.com/nbh-digital/goldchain/pkg/stakes"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// ConsensusProposalsGET contains all proposals voted on,
+	// as returned by a GET call to /consensus/proposals.
+	ConsensusProposalsGET struct {
+		Proposals []governance.ProposalInfo `json:"proposals"`
+	}
+
+	// ConsensusProposalGET contains the tally of the votes on a proposal,
+	// as returned by a GET call to /consensus/proposals/:id.
+	ConsensusProposalGET struct {
+		governance.Result
+	}
+
+	// WalletVotePOST contains the vote to cast using the blockstakes of the wallet,
+	// as given as the body of a POST call to /wallet/vote.
+	WalletVotePOST struct {
+		Proposal goldchaintypes.Proposal   `json:"proposal"`
+		Choice   goldchaintypes.VoteChoice `json:"choice"`
+		// Addresses are the addresses of the wallet voting using their blockstakes,
+		// all addresses of the wallet owning blockstakes vote if none are given
+		Addresses []types.UnlockHash `json:"addresses,omitempty"`
+	}
+
+	// WalletVotePOSTResp contains the ID of the vote transaction and of the proposal voted on,
+	// as returned by a POST call to /wallet/vote.
+	WalletVotePOSTResp struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		ProposalID    crypto.Hash         `json:"proposalid"`
+	}
+)
+
+// RegisterGovernanceHTTPHandlers registers the handlers for the governance consensus HTTP endpoints,
+// which are only registered in case both the governance and stake distribution plugins are given,
+// as votes are weighted using the stake distribution.
+func RegisterGovernanceHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, plugin *governance.Plugin, stakesPlugin *stakes.Plugin) {
+	if plugin == nil || stakesPlugin == nil {
+		return
+	}
+	router.GET("/consensus/proposals", NewConsensusProposalsHandler(plugin))
+	router.GET("/consensus/proposals/:id", NewConsensusProposalHandler(cs, plugin, stakesPlugin))
+}
+
+// NewConsensusProposalsHandler creates a handler to handle the API calls to /consensus/proposals,
+// returning all proposals voted on, the proposal with the most recent first vote first.
+func NewConsensusProposalsHandler(plugin *governance.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		proposals, err := plugin.GetProposals()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ConsensusProposalsGET{Proposals: proposals})
+	}
+}
+
+// NewConsensusProposalHandler creates a handler to handle the API calls to /consensus/proposals/:id,
+// returning the tally of the votes on the proposal with the given ID,
+// weighted by the blockstakes of the voters at the end height of the proposal, or at the current height while it is open.
+func NewConsensusProposalHandler(cs modules.ConsensusSet, plugin *governance.Plugin, stakesPlugin *stakes.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var id crypto.Hash
+		err := id.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: invalid proposal ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		height := cs.Height()
+		info, _, err := plugin.GetProposal(id, height)
+		if err == governance.ErrProposalNotFound {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		tallyHeight := governance.TallyHeight(info.Proposal, height)
+		_, votes, err := plugin.GetProposal(id, tallyHeight)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		distribution, err := stakesPlugin.GetDistributionAt(tallyHeight)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/proposals: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ConsensusProposalGET{Result: governance.Tally(info, votes, distribution, height)})
+	}
+}
+
+// NewWalletVoteHandler creates a handler to handle the API calls to /wallet/vote,
+// casting a vote on a proposal using the blockstakes of the wallet.
+func NewWalletVoteHandler(w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletVotePOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied vote: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := wallet.Vote(w, tpool, body.Proposal, body.Choice, body.Addresses, constants)
+		if err != nil {
+			status := walletErrorToHTTPStatus(err)
+			if cErr, ok := err.(types.ClientError); ok {
+				status = cErr.Kind.AsHTTPStatusCode()
+			}
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/vote: " + err.Error()}, status)
+			return
+		}
+		rapi.WriteJSON(rw, WalletVotePOSTResp{TransactionID: txn.ID(), ProposalID: body.Proposal.ID()})
+	}
+}
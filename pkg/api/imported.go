@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletImportedAddressesGET contains all imported addresses with their history and balance,
+	// as returned by a GET call to /wallet/addresses/imported.
+	WalletImportedAddressesGET struct {
+		Addresses []wallet.ImportedAddress `json:"addresses"`
+	}
+
+	// WalletImportedAddressGET contains an imported address with its history and balance,
+	// as returned by a GET call to /wallet/addresses/imported/:address
+	// or a POST call to /wallet/addresses/import.
+	WalletImportedAddressGET struct {
+		wallet.ImportedAddress
+	}
+
+	// WalletAddressesImportPOST contains the watch-only address to import,
+	// as given as the body of a POST call to /wallet/addresses/import.
+	WalletAddressesImportPOST struct {
+		Address types.UnlockHash `json:"address"`
+		// StartHeight is the optional height of the first block of which the transactions are part of the history
+		StartHeight types.BlockHeight `json:"startheight"`
+	}
+)
+
+// RegisterWalletImportedAddressesHTTPHandlers registers the handlers for the imported (watch-only) address HTTP endpoints.
+func RegisterWalletImportedAddressesHTTPHandlers(router rapi.Router, imported *wallet.ImportedAddresses, requiredPassword string) {
+	router.POST("/wallet/addresses/import", rapi.RequirePasswordHandler(NewWalletImportAddressHandler(imported), requiredPassword))
+	router.GET("/wallet/addresses/imported", rapi.RequirePasswordHandler(NewWalletImportedAddressesHandler(imported), requiredPassword))
+	router.GET("/wallet/addresses/imported/:address", rapi.RequirePasswordHandler(NewWalletImportedAddressHandler(imported), requiredPassword))
+}
+
+// NewWalletImportAddressHandler creates a handler to handle the POST API calls to /wallet/addresses/import,
+// importing a watch-only address, of which the history is backfilled using the explorer should it be loaded.
+func NewWalletImportAddressHandler(imported *wallet.ImportedAddresses) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletAddressesImportPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/import: invalid body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		address, err := imported.Import(body.Address, body.StartHeight)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/import: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletImportedAddressGET{ImportedAddress: address})
+	}
+}
+
+// NewWalletImportedAddressesHandler creates a handler to handle the GET API calls to /wallet/addresses/imported.
+func NewWalletImportedAddressesHandler(imported *wallet.ImportedAddresses) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		addresses, err := imported.Addresses()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/imported: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletImportedAddressesGET{Addresses: addresses})
+	}
+}
+
+// NewWalletImportedAddressHandler creates a handler to handle the GET API calls to /wallet/addresses/imported/:address.
+func NewWalletImportedAddressHandler(imported *wallet.ImportedAddresses) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var uh types.UnlockHash
+		err := uh.LoadString(ps.ByName("address"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/imported: invalid address: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		address, err := imported.Address(uh)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/imported: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletImportedAddressGET{ImportedAddress: address})
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletAddressLabelsGET contains the labeled addresses selected by the label and tag query parameters,
+	// as returned by a GET call to /wallet/addresses/labels.
+	WalletAddressLabelsGET struct {
+		Labels []wallet.AddressLabel `json:"labels"`
+	}
+
+	// WalletAddressLabelsPOST contains the label and tags to attach to an address,
+	// as given as the body of a POST call to /wallet/addresses/labels.
+	WalletAddressLabelsPOST struct {
+		// Address is the address to label, a new address is generated and labeled if omitted
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+		Tags    []string         `json:"tags"`
+	}
+
+	// WalletAddressLabelsRemovePOST contains the address of which to remove the label,
+	// as given as the body of a POST call to /wallet/addresses/labels/remove.
+	WalletAddressLabelsRemovePOST struct {
+		Address types.UnlockHash `json:"address"`
+	}
+
+	// WalletAddressLabelsBalanceGET contains the confirmed balance of the labeled addresses
+	// selected by the label and tag query parameters, as returned by a GET call to /wallet/addresses/labels/balance.
+	WalletAddressLabelsBalanceGET struct {
+		wallet.AccountBalance
+		// Addresses is the amount of labeled addresses selected
+		Addresses int `json:"addresses"`
+	}
+
+	// WalletAddressLabelsTransactionsGET contains the confirmed transactions involving the labeled addresses
+	// selected by the label and tag query parameters, as returned by a GET call to /wallet/addresses/labels/transactions.
+	WalletAddressLabelsTransactionsGET struct {
+		Transactions []wallet.AccountTransaction `json:"transactions"`
+	}
+)
+
+// RegisterWalletAddressLabelsHTTPHandlers registers the handlers for the wallet address label HTTP endpoints.
+func RegisterWalletAddressLabelsHTTPHandlers(router rapi.Router, labels *wallet.AddressLabels, requiredPassword string) {
+	router.GET("/wallet/addresses/labels", rapi.RequirePasswordHandler(NewWalletAddressLabelsHandler(labels), requiredPassword))
+	router.POST("/wallet/addresses/labels", rapi.RequirePasswordHandler(NewWalletSetAddressLabelHandler(labels), requiredPassword))
+	router.POST("/wallet/addresses/labels/remove", rapi.RequirePasswordHandler(NewWalletRemoveAddressLabelHandler(labels), requiredPassword))
+	router.GET("/wallet/addresses/labels/balance", rapi.RequirePasswordHandler(NewWalletAddressLabelsBalanceHandler(labels), requiredPassword))
+	router.GET("/wallet/addresses/labels/transactions", rapi.RequirePasswordHandler(NewWalletAddressLabelsTransactionsHandler(labels), requiredPassword))
+}
+
+// NewWalletAddressLabelsHandler creates a handler to handle the GET API calls to /wallet/addresses/labels.
+func NewWalletAddressLabelsHandler(labels *wallet.AddressLabels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, WalletAddressLabelsGET{Labels: labels.Labels(addressLabelFilter(req))})
+	}
+}
+
+// NewWalletSetAddressLabelHandler creates a handler to handle the POST API calls to /wallet/addresses/labels,
+// attaching a label and tags to an (optionally new) address of the wallet.
+func NewWalletSetAddressLabelHandler(labels *wallet.AddressLabels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletAddressLabelsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels: invalid body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		label, err := labels.Set(body.Address, body.Label, body.Tags)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, label)
+	}
+}
+
+// NewWalletRemoveAddressLabelHandler creates a handler to handle the POST API calls to /wallet/addresses/labels/remove.
+func NewWalletRemoveAddressLabelHandler(labels *wallet.AddressLabels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletAddressLabelsRemovePOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels/remove: invalid body: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = labels.Remove(body.Address)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels/remove: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewWalletAddressLabelsBalanceHandler creates a handler to handle the GET API calls to /wallet/addresses/labels/balance.
+func NewWalletAddressLabelsBalanceHandler(labels *wallet.AddressLabels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		filter := addressLabelFilter(req)
+		balance, err := labels.Balance(filter)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels/balance: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAddressLabelsBalanceGET{
+			AccountBalance: balance,
+			Addresses:      len(labels.Labels(filter)),
+		})
+	}
+}
+
+// NewWalletAddressLabelsTransactionsHandler creates a handler to handle the GET API calls to /wallet/addresses/labels/transactions.
+func NewWalletAddressLabelsTransactionsHandler(labels *wallet.AddressLabels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		txns, err := labels.Transactions(addressLabelFilter(req))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses/labels/transactions: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletAddressLabelsTransactionsGET{Transactions: txns})
+	}
+}
+
+// addressLabelFilter returns the filter defined by the label and tag query parameters of the given request.
+func addressLabelFilter(req *http.Request) wallet.AddressLabelFilter {
+	return wallet.AddressLabelFilter{
+		Label: req.FormValue("label"),
+		Tag:   req.FormValue("tag"),
+	}
+}
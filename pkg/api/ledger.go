@@ -0,0 +1,190 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/ledger"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// maxJournalRange is the maximum amount of blocks of which the entries are returned by a single call to /ledger/journal.
+const maxJournalRange = 1000
+
+type (
+	// LedgerTrialBalanceGET contains the balances of all ledger accounts at a given height,
+	// as returned by a GET call to /ledger/trialbalance.
+	LedgerTrialBalanceGET struct {
+		ledger.TrialBalance
+	}
+
+	// LedgerJournalGET contains the journal entries of a range of blocks,
+	// as returned by a GET call to /ledger/journal.
+	LedgerJournalGET struct {
+		Start   types.BlockHeight `json:"start"`
+		End     types.BlockHeight `json:"end"`
+		Entries []ledger.Entry    `json:"entries"`
+	}
+
+	// LedgerLabelsGET contains all labeled addresses,
+	// as returned by a GET call to /ledger/labels.
+	LedgerLabelsGET struct {
+		Labels []ledger.AddressLabel `json:"labels"`
+	}
+
+	// LedgerLabelsPOST contains the label to give to an address,
+	// as given as the body of a POST call to /ledger/labels.
+	// An empty label removes the label of the address.
+	LedgerLabelsPOST struct {
+		ledger.AddressLabel
+	}
+)
+
+// RegisterLedgerHTTPHandlers registers the handlers for the ledger HTTP endpoints,
+// none of which are registered in case the ledger plugin is not given.
+func RegisterLedgerHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, plugin *ledger.Plugin, labels *ledger.Labels, requiredPassword string) {
+	if plugin == nil {
+		return
+	}
+	router.GET("/ledger/trialbalance", NewLedgerTrialBalanceHandler(cs, plugin, labels))
+	router.GET("/ledger/journal", NewLedgerJournalHandler(cs, plugin, labels))
+	router.GET("/ledger/labels", NewLedgerLabelsHandler(labels))
+	router.POST("/ledger/labels", rapi.RequirePasswordHandler(NewLedgerSetLabelHandler(labels), requiredPassword))
+}
+
+// NewLedgerTrialBalanceHandler creates a handler to handle the API calls to /ledger/trialbalance,
+// returning the balances of all accounts at the height given by the optional height query parameter,
+// which defaults to the current height. Labeled addresses are merged into a single account per label
+// should the entities query parameter be true.
+func NewLedgerTrialBalanceHandler(cs modules.ConsensusSet, plugin *ledger.Plugin, labels *ledger.Labels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		height, err := parseHeight(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/trialbalance: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var entities bool
+		if str := req.URL.Query().Get("entities"); str != "" {
+			entities, err = strconv.ParseBool(str)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/trialbalance: invalid entities flag: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		tb, err := plugin.GetTrialBalanceAt(height)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/trialbalance: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		accountLabels := labels.AccountLabels()
+		if entities {
+			tb = tb.GroupByLabel(accountLabels)
+		} else {
+			for i := range tb.Accounts {
+				tb.Accounts[i].Label = accountLabels[tb.Accounts[i].Account]
+			}
+		}
+		rapi.WriteJSON(w, LedgerTrialBalanceGET{TrialBalance: tb})
+	}
+}
+
+// NewLedgerJournalHandler creates a handler to handle the API calls to /ledger/journal,
+// returning the journal entries of all blocks within the inclusive range given by the start and (optional) end query parameters.
+// The entries of at most 1000 blocks are returned, the end of the range defaults to the current height, and is capped to it.
+// The entries are exported as CSV, one line per posting, should the format query parameter be csv.
+func NewLedgerJournalHandler(cs modules.ConsensusSet, plugin *ledger.Plugin, labels *ledger.Labels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		start, end, err := parseBlockRange(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/journal: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		format := req.URL.Query().Get("format")
+		if format != "" && format != "json" && format != "csv" {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/journal: unsupported format " + format}, http.StatusBadRequest)
+			return
+		}
+		if end-start >= maxJournalRange {
+			end = start + maxJournalRange - 1
+		}
+		entries, err := plugin.GetJournal(start, end)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/journal: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		accountLabels := labels.AccountLabels()
+		for _, entry := range entries {
+			for i := range entry.Postings {
+				entry.Postings[i].Label = accountLabels[entry.Postings[i].Account]
+			}
+		}
+		if format == "csv" {
+			writeJournalCSV(w, entries)
+			return
+		}
+		rapi.WriteJSON(w, LedgerJournalGET{Start: start, End: end, Entries: entries})
+	}
+}
+
+// writeJournalCSV writes the entries as CSV, one line per posting,
+// with all amounts expressed in the smallest coin unit.
+func writeJournalCSV(w http.ResponseWriter, entries []ledger.Entry) {
+	w.Header().Set("Content-Type", "text/csv")
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"height", "timestamp", "blockid", "transactionid", "kind", "account", "label", "debit", "credit"})
+	for _, entry := range entries {
+		var txID string
+		if entry.Kind != ledger.EntryKindReward {
+			txID = entry.TransactionID.String()
+		}
+		for _, posting := range entry.Postings {
+			cw.Write([]string{
+				strconv.FormatUint(uint64(entry.Height), 10),
+				strconv.FormatUint(uint64(entry.Timestamp), 10),
+				entry.BlockID.String(),
+				txID,
+				string(entry.Kind),
+				posting.Account,
+				posting.Label,
+				posting.Debit.String(),
+				posting.Credit.String(),
+			})
+		}
+	}
+	cw.Flush()
+}
+
+// NewLedgerLabelsHandler creates a handler to handle the GET API calls to /ledger/labels.
+func NewLedgerLabelsHandler(labels *ledger.Labels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, LedgerLabelsGET{Labels: labels.Labels()})
+	}
+}
+
+// NewLedgerSetLabelHandler creates a handler to handle the POST API calls to /ledger/labels,
+// labeling an address, or removing its label.
+func NewLedgerSetLabelHandler(labels *ledger.Labels) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body LedgerLabelsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied label: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = labels.SetLabel(body.Address, body.Label)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == ledger.ErrInvalidLabel || err == ledger.ErrNilLabelAddress {
+				status = http.StatusBadRequest
+			}
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /ledger/labels: " + err.Error()}, status)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
@@ -0,0 +1,233 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/multisig"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletMultiSigGET contains the unspent outputs of all multisig addresses co-owned by the wallet,
+	// as returned by a GET call to /wallet/multisig.
+	WalletMultiSigGET struct {
+		Addresses []wallet.MultiSigAddress `json:"addresses"`
+	}
+
+	// WalletMultiSigTransactionPOST contains the multisig address and the coin outputs to send,
+	// as given as the body of a POST call to /wallet/multisig/transaction.
+	WalletMultiSigTransactionPOST struct {
+		Address     types.UnlockHash   `json:"address"`
+		CoinOutputs []types.CoinOutput `json:"coinoutputs"`
+		Data        []byte             `json:"data,omitempty"`
+		// MinerFee defaults to the minimum transaction fee
+		MinerFee types.Currency `json:"minerfee"`
+	}
+
+	// MultiSigProposal is a transaction for which signatures are being collected,
+	// together with the signatures collected so far.
+	MultiSigProposal struct {
+		multisig.Proposal
+		Status multisig.Status `json:"status"`
+	}
+
+	// WalletMultiSigTransactionPOSTResp contains the transaction signed by the wallet,
+	// as returned by a POST call to /wallet/multisig/transaction.
+	WalletMultiSigTransactionPOSTResp struct {
+		Transaction types.Transaction `json:"transaction"`
+		Status      multisig.Status   `json:"status"`
+	}
+
+	// MultiSigProposalsGET contains all proposals of the coordinator,
+	// as returned by a GET call to /multisig/proposals.
+	MultiSigProposalsGET struct {
+		Proposals []MultiSigProposal `json:"proposals"`
+	}
+
+	// MultiSigProposalsPOST contains a (partially) signed transaction,
+	// as given as the body of a POST call to /multisig/proposals.
+	MultiSigProposalsPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
+	// MultiSigProposalsPOSTResp contains the proposal the signatures were merged into,
+	// as returned by a POST call to /multisig/proposals.
+	MultiSigProposalsPOSTResp struct {
+		MultiSigProposal
+		// Added is the amount of new signatures added to the proposal
+		Added int `json:"added"`
+	}
+
+	// MultiSigProposalBroadcastPOSTResp contains the ID of the submitted transaction,
+	// as returned by a POST call to /multisig/proposals/:id/broadcast.
+	MultiSigProposalBroadcastPOSTResp struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+	}
+)
+
+// RegisterWalletMultiSigHTTPHandlers registers the handlers for the wallet multisig HTTP endpoints.
+// The multisig transactions signed by the wallet are only created within the spend policy of the given approvals, should they be given.
+func RegisterWalletMultiSigHTTPHandlers(router rapi.Router, w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool, constants types.ChainConstants, approvals *SpendApprovals, requiredPassword string) {
+	router.GET("/wallet/multisig", rapi.RequirePasswordHandler(NewWalletMultiSigHandler(w, cs, tpool), requiredPassword))
+	router.POST("/wallet/multisig/transaction", rapi.RequirePasswordHandler(approvals.guarded("/wallet/multisig/transaction", outgoingMultiSigTransaction,
+		NewWalletMultiSigTransactionHandler(w, cs, tpool, constants)), requiredPassword))
+}
+
+// NewWalletMultiSigHandler creates a handler to handle the API calls to /wallet/multisig.
+func NewWalletMultiSigHandler(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		addresses, err := wallet.MultiSigAddresses(w, cs, tpool)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/multisig: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletMultiSigGET{Addresses: addresses})
+	}
+}
+
+// NewWalletMultiSigTransactionHandler creates a handler to handle the API calls to /wallet/multisig/transaction,
+// creating a transaction spending the outputs of a multisig address, signed by the wallet.
+func NewWalletMultiSigTransactionHandler(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletMultiSigTransactionPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied multisig transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := wallet.BuildMultiSigTransaction(w, cs, tpool, body.Address, body.CoinOutputs, body.Data, body.MinerFee, constants)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/multisig/transaction: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		status, err := multisig.GetStatus(txn, cs)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/multisig/transaction: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(rw, WalletMultiSigTransactionPOSTResp{
+			Transaction: txn,
+			Status:      status,
+		})
+	}
+}
+
+// RegisterMultiSigHTTPHandlers registers the handlers for the multisig coordination HTTP endpoints,
+// used by the owners of a multisig address to collect their signatures.
+// These endpoints do not require the API password, as they only deal with public data.
+func RegisterMultiSigHTTPHandlers(router rapi.Router, store *multisig.Store, cs modules.ConsensusSet, tpool modules.TransactionPool) {
+	router.GET("/multisig/proposals", NewMultiSigProposalsHandler(store, cs))
+	router.POST("/multisig/proposals", NewMultiSigSubmitProposalHandler(store, cs))
+	router.GET("/multisig/proposals/:id", NewMultiSigProposalHandler(store, cs))
+	router.POST("/multisig/proposals/:id/broadcast", NewMultiSigBroadcastProposalHandler(store, cs, tpool))
+}
+
+// NewMultiSigProposalsHandler creates a handler to handle the API calls to /multisig/proposals.
+func NewMultiSigProposalsHandler(store *multisig.Store, cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		proposals := store.Proposals()
+		resp := MultiSigProposalsGET{Proposals: make([]MultiSigProposal, 0, len(proposals))}
+		for _, proposal := range proposals {
+			status, err := multisig.GetStatus(proposal.Transaction, cs)
+			if err != nil {
+				continue // the proposal spends outputs which no longer exist
+			}
+			resp.Proposals = append(resp.Proposals, MultiSigProposal{Proposal: proposal, Status: status})
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// NewMultiSigSubmitProposalHandler creates a handler to handle the POST API calls to /multisig/proposals,
+// adding a new proposal or merging the signatures of the given transaction into an existing one.
+func NewMultiSigSubmitProposalHandler(store *multisig.Store, cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body MultiSigProposalsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		proposal, added, err := store.Submit(body.Transaction)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /multisig/proposals: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		status, err := multisig.GetStatus(proposal.Transaction, cs)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /multisig/proposals: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, MultiSigProposalsPOSTResp{
+			MultiSigProposal: MultiSigProposal{Proposal: proposal, Status: status},
+			Added:            added,
+		})
+	}
+}
+
+// NewMultiSigProposalHandler creates a handler to handle the API calls to /multisig/proposals/:id.
+func NewMultiSigProposalHandler(store *multisig.Store, cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		proposal, ok := getMultiSigProposal(w, store, ps.ByName("id"), "/multisig/proposals/$(id)")
+		if !ok {
+			return
+		}
+		status, err := multisig.GetStatus(proposal.Transaction, cs)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /multisig/proposals/$(id): " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, MultiSigProposal{Proposal: proposal, Status: status})
+	}
+}
+
+// NewMultiSigBroadcastProposalHandler creates a handler to handle the API calls to /multisig/proposals/:id/broadcast,
+// submitting the transaction of the proposal to the transaction pool once it has collected all required signatures.
+func NewMultiSigBroadcastProposalHandler(store *multisig.Store, cs modules.ConsensusSet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		const call = "/multisig/proposals/$(id)/broadcast"
+		proposal, ok := getMultiSigProposal(w, store, ps.ByName("id"), call)
+		if !ok {
+			return
+		}
+		status, err := multisig.GetStatus(proposal.Transaction, cs)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !status.Complete {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to " + call + ": not all inputs have the required amount of signatures"}, http.StatusBadRequest)
+			return
+		}
+		err = tpool.AcceptTransactionSet([]types.Transaction{proposal.Transaction})
+		if err != nil && err != modules.ErrDuplicateTransactionSet {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		store.Remove(proposal.ID)
+		rapi.WriteJSON(w, MultiSigProposalBroadcastPOSTResp{TransactionID: proposal.Transaction.ID()})
+	}
+}
+
+// getMultiSigProposal gets the proposal with the given (string-encoded) ID from the store,
+// writing an error response in case it cannot be found.
+func getMultiSigProposal(w http.ResponseWriter, store *multisig.Store, str, call string) (multisig.Proposal, bool) {
+	var id types.TransactionID
+	err := id.LoadString(str)
+	if err != nil {
+		rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf("error after call to %s: invalid proposal ID: %v", call, err)}, http.StatusBadRequest)
+		return multisig.Proposal{}, false
+	}
+	proposal, err := store.Get(id)
+	if err != nil {
+		rapi.WriteError(w, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusNotFound)
+		return multisig.Proposal{}, false
+	}
+	return proposal, true
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ExplorerValidateAddressGET contains the diagnosis of an address, and whether it is authorized should it be valid,
+// as returned by a GET call to /explorer/validate-address.
+type ExplorerValidateAddressGET struct {
+	config.AddressDiagnosis
+	// Authorized is only defined in case the address is valid
+	Authorized *bool `json:"authorized,omitempty"`
+}
+
+// RegisterExplorerNetworkHTTPHandlers registers the handler for the network descriptor explorer HTTP endpoint.
+func RegisterExplorerNetworkHTTPHandlers(router rapi.Router, network config.NetworkDescriptor) {
+	router.GET("/explorer/network", NewExplorerNetworkHandler(network))
+}
+
+// RegisterExplorerValidateAddressHTTPHandlers registers the handler for the address validation explorer HTTP endpoint.
+func RegisterExplorerValidateAddressHTTPHandlers(router rapi.Router, network config.NetworkDescriptor, authPlugin *authcointx.Plugin) {
+	router.GET("/explorer/validate-address", NewExplorerValidateAddressHandler(network, authPlugin))
+}
+
+// NewExplorerNetworkHandler creates a handler to handle the API calls to /explorer/network,
+// returning the descriptor of the network, used by frontends to present coins and addresses.
+func NewExplorerNetworkHandler(network config.NetworkDescriptor) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, network)
+	}
+}
+
+// NewExplorerValidateAddressHandler creates a handler to handle the API calls to /explorer/validate-address,
+// validating the address given as the addr query parameter, such that forms can validate user input in a single call.
+// An invalid address is not an error of the call, its diagnosis explains why it is invalid instead.
+func NewExplorerValidateAddressHandler(network config.NetworkDescriptor, authPlugin *authcointx.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		resp := ExplorerValidateAddressGET{AddressDiagnosis: network.DiagnoseAddress(req.FormValue("addr"))}
+		if resp.Valid {
+			states, err := authPlugin.GetAddressesAuthStateNow([]types.UnlockHash{*resp.Address}, nil)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/validate-address: " + err.Error()}, http.StatusInternalServerError)
+				return
+			}
+			resp.Authorized = &states[0]
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
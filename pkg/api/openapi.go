@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification to which the API document conforms.
+const OpenAPIVersion = "3.0.3"
+
+type (
+	// OpenAPIDocument is an OpenAPI document describing the endpoints served by a daemon,
+	// as returned by a GET call to /api/spec.
+	OpenAPIDocument struct {
+		OpenAPI    string                                 `json:"openapi"`
+		Info       OpenAPIInfo                            `json:"info"`
+		Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+		Components OpenAPIComponents                      `json:"components"`
+	}
+
+	// OpenAPIInfo describes the API of an OpenAPI document.
+	OpenAPIInfo struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	// OpenAPIOperation describes an endpoint, being a method of a path.
+	OpenAPIOperation struct {
+		Tags        []string                   `json:"tags,omitempty"`
+		Summary     string                     `json:"summary"`
+		Description string                     `json:"description,omitempty"`
+		Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+		Security    []map[string][]string      `json:"security,omitempty"`
+		Responses   map[string]OpenAPIResponse `json:"responses"`
+	}
+
+	// OpenAPIParameter describes a path or query parameter of an endpoint.
+	OpenAPIParameter struct {
+		Name        string            `json:"name"`
+		In          string            `json:"in"`
+		Description string            `json:"description,omitempty"`
+		Required    bool              `json:"required,omitempty"`
+		Schema      map[string]string `json:"schema"`
+	}
+
+	// OpenAPIResponse describes a response of an endpoint.
+	OpenAPIResponse struct {
+		Description string `json:"description"`
+	}
+
+	// OpenAPIComponents contains the security schemes referred to by the operations of an OpenAPI document.
+	OpenAPIComponents struct {
+		SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes"`
+	}
+
+	// OpenAPISecurityScheme describes how a client authenticates itself.
+	OpenAPISecurityScheme struct {
+		Type        string `json:"type"`
+		Scheme      string `json:"scheme"`
+		Description string `json:"description,omitempty"`
+	}
+)
+
+// Operation annotates an endpoint, as documented by the OpenAPI document of the daemon.
+type Operation struct {
+	Summary     string
+	Description string
+	// Query lists the query parameters of the endpoint, mapped to their description.
+	Query map[string]string
+	// Authenticated defines whether the endpoint requires the API password, should the daemon have one.
+	Authenticated bool
+}
+
+// securitySchemeAPIPassword is the name of the security scheme of endpoints requiring the API password
+const securitySchemeAPIPassword = "apiPassword"
+
+// DocumentedRouter is a router recording all routes registered to it,
+// such that the daemon serves an OpenAPI document describing exactly the endpoints it serves.
+type DocumentedRouter struct {
+	*httprouter.Router
+
+	mu     sync.Mutex
+	routes map[string][]string // path -> methods
+}
+
+// NewDocumentedRouter creates a new router, recording the routes registered to it.
+func NewDocumentedRouter() *DocumentedRouter {
+	return &DocumentedRouter{
+		Router: httprouter.New(),
+		routes: map[string][]string{},
+	}
+}
+
+// GET registers a handler for GET requests to the given path.
+func (router *DocumentedRouter) GET(path string, handle httprouter.Handle) {
+	router.record(http.MethodGet, path)
+	router.Router.GET(path, handle)
+}
+
+// POST registers a handler for POST requests to the given path.
+func (router *DocumentedRouter) POST(path string, handle httprouter.Handle) {
+	router.record(http.MethodPost, path)
+	router.Router.POST(path, handle)
+}
+
+// OPTIONS registers a handler for OPTIONS requests to the given path.
+// OPTIONS routes are not documented.
+func (router *DocumentedRouter) OPTIONS(path string, handle httprouter.Handle) {
+	router.Router.OPTIONS(path, handle)
+}
+
+func (router *DocumentedRouter) record(method, path string) {
+	router.mu.Lock()
+	router.routes[path] = append(router.routes[path], method)
+	router.mu.Unlock()
+}
+
+// Undocumented returns the routes registered to the router which are not annotated, as "METHOD path".
+func (router *DocumentedRouter) Undocumented() []string {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	var undocumented []string
+	for path, methods := range router.routes {
+		for _, method := range methods {
+			if _, ok := operations[method+" "+path]; !ok {
+				undocumented = append(undocumented, method+" "+path)
+			}
+		}
+	}
+	sort.Strings(undocumented)
+	return undocumented
+}
+
+// OpenAPI creates the OpenAPI document describing all routes registered to the router,
+// using their annotations.
+func (router *DocumentedRouter) OpenAPI(info OpenAPIInfo) OpenAPIDocument {
+	router.mu.Lock()
+	defer router.mu.Unlock()
+	doc := OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]OpenAPIOperation, len(router.routes)),
+		Components: OpenAPIComponents{
+			SecuritySchemes: map[string]OpenAPISecurityScheme{
+				securitySchemeAPIPassword: {
+					Type:        "http",
+					Scheme:      "basic",
+					Description: "the API password of the daemon as password, with an empty username",
+				},
+			},
+		},
+	}
+	for path, methods := range router.routes {
+		openAPIPath, pathParams := openAPIPath(path)
+		operations := make(map[string]OpenAPIOperation, len(methods))
+		for _, method := range methods {
+			operations[strings.ToLower(method)] = newOpenAPIOperation(method, path, pathParams)
+		}
+		doc.Paths[openAPIPath] = operations
+	}
+	return doc
+}
+
+// newOpenAPIOperation describes the endpoint of the given method and path, using its annotation.
+func newOpenAPIOperation(method, path string, pathParams []string) OpenAPIOperation {
+	annotation, ok := operations[method+" "+path]
+	if !ok {
+		annotation.Summary = "undocumented"
+	}
+	op := OpenAPIOperation{
+		Tags:        []string{strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]},
+		Summary:     annotation.Summary,
+		Description: annotation.Description,
+		Responses: map[string]OpenAPIResponse{
+			"200":     {Description: "success, as JSON unless described otherwise"},
+			"default": {Description: `error, as a JSON object with its "message"`},
+		},
+	}
+	for _, name := range pathParams {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: name, In: "path", Required: true, Schema: map[string]string{"type": "string"},
+		})
+	}
+	query := make([]string, 0, len(annotation.Query))
+	for name := range annotation.Query {
+		query = append(query, name)
+	}
+	sort.Strings(query)
+	for _, name := range query {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name: name, In: "query", Description: annotation.Query[name], Schema: map[string]string{"type": "string"},
+		})
+	}
+	if annotation.Authenticated {
+		op.Security = []map[string][]string{{securitySchemeAPIPassword: {}}}
+	}
+	return op
+}
+
+// openAPIPath converts a router path (e.g. /explorer/blocks/:height) to an OpenAPI path (/explorer/blocks/{height}),
+// returning the names of its path parameters.
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// RegisterOpenAPIHTTPHandlers registers the handler serving the OpenAPI document of all routes of the given router.
+func RegisterOpenAPIHTTPHandlers(router *DocumentedRouter, info OpenAPIInfo) {
+	router.GET("/api/spec", NewOpenAPIHandler(router, info))
+}
+
+// NewOpenAPIHandler creates a handler to handle the API calls to /api/spec,
+// returning the OpenAPI document describing all routes of the given router, including those registered later on.
+func NewOpenAPIHandler(router *DocumentedRouter, info OpenAPIInfo) httprouter.Handle {
+	return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, router.OpenAPI(info))
+	}
+}
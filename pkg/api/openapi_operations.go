@@ -0,0 +1,511 @@
+package api
+
+// query parameters shared by several endpoints
+var (
+	queryEncoding = map[string]string{
+		"encoding": "encoding of the returned object: siabin (default), hex or json",
+	}
+	queryHeight = map[string]string{
+		"height": "block height at which the state is computed, defaults to the current height",
+	}
+	queryWalletSend = map[string]string{
+		"dryrun":           "if true, the transaction is created and returned, but not broadcast",
+		"expirationheight": "block height after which the transaction is no longer valid",
+		"lockeduntil":      "block height or timestamp until which the sent outputs are locked",
+		"coinselection":    "strategy used to select the coin outputs to spend",
+		"includeoutputs":   "comma-separated coin output IDs which have to be spent",
+		"excludeoutputs":   "comma-separated coin output IDs which cannot be spent",
+		"account":          "label of the account sending the outputs, the funds of the accounts are never spent if not given",
+	}
+	queryAddressLabels = map[string]string{
+		"label": "label the addresses have to be labeled with",
+		"tag":   "tag the addresses have to be tagged with",
+	}
+)
+
+// operations annotates all endpoints served by goldchaind and goldchainsigner,
+// keyed by "METHOD path", with the path as registered to the router.
+// Every route registered to a DocumentedRouter is expected to be annotated here,
+// which is enforced by the tests of the node package.
+var operations = map[string]Operation{
+	// api
+	"GET /api/spec": {Summary: "get the OpenAPI document describing all endpoints of the daemon"},
+
+	// daemon
+	"GET /daemon/constants":  {Summary: "get the constants of the daemon and its network"},
+	"GET /daemon/signingkey": {Summary: "get the public key signing the API responses, should response signing be enabled"},
+	"GET /daemon/version":    {Summary: "get the version of the daemon and its chain"},
+	"POST /daemon/stop":      {Summary: "stop the daemon", Authenticated: true},
+
+	// gateway
+	"GET /gateway": {Summary: "get the network address and peers of the gateway"},
+	"POST /gateway/ban/:host": {
+		Summary:       "ban the given host (or host of the given network address), disconnecting its peers",
+		Query:         map[string]string{"duration": "duration of the ban, the ban duration of the policy by default"},
+		Authenticated: true,
+	},
+	"GET /gateway/compactblocks": {
+		Summary: "get the statistics of the compact blocks received from and sent to the peers",
+	},
+	"POST /gateway/connect/:netaddress":    {Summary: "connect to the peer at the given network address", Authenticated: true},
+	"POST /gateway/disconnect/:netaddress": {Summary: "disconnect from the peer at the given network address", Authenticated: true},
+	"GET /gateway/portmapping": {
+		Summary: "get the status of the mapping of the RPC port on the NAT gateway, should port mapping be enabled",
+	},
+	"GET /gateway/scores": {
+		Summary: "get the scores and metrics of the (recently connected) peers, as well as the banned hosts",
+	},
+	"POST /gateway/unban/:host": {Summary: "lift the ban of the given host", Authenticated: true},
+
+	// consensus
+	"GET /consensus": {Summary: "get the state of the consensus set, such as its height and current block"},
+	"GET /consensus/alerts": {
+		Summary: "get the alerts raised by the chain monitor of the daemon",
+	},
+	"GET /consensus/authcoin/condition": {
+		Summary: "get the current auth condition, authorized to (de)authorize addresses",
+	},
+	"GET /consensus/authcoin/condition/:height": {
+		Summary: "get the auth condition active at the given block height",
+	},
+	"GET /consensus/authcoin/registry": {
+		Summary: "get all addresses whose authorization state was ever changed, with their state",
+		Query: mergeQuery(queryHeight, map[string]string{
+			"deauthorized": "if true, the deauthorized addresses are returned instead of the authorized ones",
+			"format":       "format of the registry: json (default) or csv",
+		}),
+	},
+	"GET /consensus/authcoin/status": {
+		Summary: "get the authorization state of the given addresses",
+		Query:   map[string]string{"addr": "address of which the authorization state is returned, can be repeated"},
+	},
+	"GET /consensus/cache": {Summary: "get the statistics of the consensus caches"},
+	"GET /consensus/checkpoints": {
+		Summary: "get the checkpoints, being the block IDs which cannot be reverted",
+	},
+	"POST /consensus/checkpoints": {
+		Summary:       "add a checkpoint, being a block ID at a given height which cannot be reverted",
+		Authenticated: true,
+	},
+	"GET /consensus/delegations": {
+		Summary: "get the active block stake delegations",
+		Query:   map[string]string{"operator": "only return the delegations to the given operator address"},
+	},
+	"GET /consensus/difficulty": {Summary: "get the current difficulty and its expected evolution"},
+	"GET /consensus/doublespends/:id": {
+		Summary: "get the transactions spending the same outputs as the transaction with the given ID",
+	},
+	"POST /consensus/doublespends": {
+		Summary: "check whether the given transaction double spends any confirmed or unconfirmed output",
+	},
+	"GET /consensus/events": {
+		Summary:     "get the ordered events of the blockchain, replaying them from a given sequence number",
+		Description: "The events of reverted blocks are returned in reverse order, marked as reverted.",
+		Query: map[string]string{
+			"after":  "sequence number of the last processed event, 0 by default",
+			"cursor": "name of the consumer of which the stored sequence number is used, should after not be given",
+			"limit":  "maximum amount of events returned, 1000 by default, 10000 at most",
+			"wait":   "duration (e.g. 30s, 10m at most) to wait for an event, should there be none yet",
+		},
+	},
+	"GET /consensus/events/cursors": {
+		Summary: "get the sequence number of the last event processed by every consumer",
+	},
+	"POST /consensus/events/cursors/:name": {
+		Summary:       "store the sequence number of the last event processed by the named consumer",
+		Authenticated: true,
+	},
+	"POST /consensus/events/cursors/:name/remove": {
+		Summary:       "remove the cursor of the named consumer",
+		Authenticated: true,
+	},
+	"GET /consensus/forks": {
+		Summary: "get the scheduled hard forks and the block size and arbitrary data limits they activate",
+	},
+	"GET /consensus/mintcondition": {Summary: "get the current mint condition"},
+	"GET /consensus/mintcondition/:height": {
+		Summary: "get the mint condition active at the given block height",
+	},
+	"GET /consensus/plugins": {
+		Summary: "get the status of the consensus set plugins (bucket size, last applied height, call durations, errors, timeouts and degradation) and of the external plugins",
+	},
+	"GET /consensus/proposals": {Summary: "get the governance proposals voted on"},
+	"GET /consensus/proposals/:id": {
+		Summary: "get the tally of the votes on the governance proposal with the given ID, weighted by block stakes",
+	},
+	"GET /consensus/rawblocks": {
+		Summary:     "stream a range of blocks, as length-prefixed siabin-encoded frames",
+		Description: "The end of the range is returned in the Block-Range-End header.",
+		Query: map[string]string{
+			"start":    "height of the first block of the range",
+			"end":      "height of the last block of the range, defaults to the current height",
+			"encoding": "encoding of the blocks, only siabin is supported",
+		},
+	},
+	"GET /consensus/rawblocks/:height": {
+		Summary: "get the block at the given height in the given encoding",
+		Query:   queryEncoding,
+	},
+	"GET /consensus/reorgs": {Summary: "get the recent chain reorganizations"},
+	"GET /consensus/stakedistribution": {
+		Summary: "get the distribution of the block stakes over all addresses",
+		Query:   queryHeight,
+	},
+	"GET /consensus/transactions/:id": {Summary: "get the confirmed transaction with the given short ID"},
+	"GET /consensus/transactionversions": {
+		Summary: "get the transaction versions of the network, their activation heights and whether they are valid at the given height",
+		Query:   map[string]string{"height": "height of the block the validity applies to, the next block by default"},
+	},
+	"GET /consensus/unspent/blockstakeoutputs/:id": {
+		Summary: "get the unspent block stake output with the given ID",
+	},
+	"GET /consensus/unspent/coinoutputs/:id": {Summary: "get the unspent coin output with the given ID"},
+	"POST /consensus/validate": {
+		Summary:     "validate the given transaction against the current consensus state",
+		Description: "The body is limited to 4 MiB and the set to 100 transactions.",
+	},
+
+	// blockcreator
+	"GET /blockcreator/blocks": {Summary: "get the blocks recently created by this daemon"},
+	"GET /blockcreator/health": {Summary: "get the health of the block creator"},
+	"GET /blockcreator/status": {Summary: "get whether the block creator is active, and why not"},
+	"POST /blockcreator/start": {Summary: "start creating blocks", Authenticated: true},
+	"POST /blockcreator/stop":  {Summary: "stop creating blocks", Authenticated: true},
+
+	// debug
+	"GET /debug/faults": {Summary: "get the faults injected into the daemon, only available in daemons built using the faults build tag"},
+	"POST /debug/faults": {
+		Summary:       "replace the faults injected into the daemon, only available in daemons built using the faults build tag",
+		Description:   "The body contains the database write delay (in nanoseconds), the drop rate of peer messages and the RPCs of which the messages are dropped. An empty object stops injecting faults.",
+		Authenticated: true,
+	},
+	"POST /debug/reorg": {
+		Summary:       "force a reorganization, only available on devnet daemons built using the faults build tag",
+		Description:   "The last blocks are replaced by a competing chain of blocks created by the wallet, reverting their transactions.",
+		Query:         map[string]string{"depth": "amount of blocks to revert, defaults to 1"},
+		Authenticated: true,
+	},
+
+	// dev
+	"POST /dev/mine": {
+		Summary:       "create blocks immediately, only available on devnet",
+		Query:         map[string]string{"blocks": "amount of blocks to create, defaults to 1"},
+		Authenticated: true,
+	},
+
+	// explorer
+	"GET /explorer": {Summary: "get the statistics of the explorer at the current height"},
+	"GET /explorer/addresses/:hash/state": {
+		Summary:     "get the balance and authorization of an address at a given height",
+		Description: "The balance includes locked coins. Requires the ledger to be enabled.",
+		Query:       map[string]string{"height": "height of the block after which the state is returned, the current height by default"},
+	},
+	"GET /explorer/authcoin/condition": {
+		Summary: "get the current auth condition, authorized to (de)authorize addresses",
+	},
+	"GET /explorer/authcoin/condition/:height": {
+		Summary: "get the auth condition active at the given block height",
+	},
+	"GET /explorer/authcoin/status": {
+		Summary: "get the authorization state of the given addresses",
+		Query:   map[string]string{"addr": "address of which the authorization state is returned, can be repeated"},
+	},
+	"GET /explorer/blocks/:height":    {Summary: "get the block at the given height, with its related information"},
+	"GET /explorer/constants":         {Summary: "get the constants of the network"},
+	"GET /explorer/downloader/status": {Summary: "get the status of the initial block download"},
+	"GET /explorer/feepool": {
+		Summary: "get the fees which flowed to the transaction fee pool, in total and per period",
+		Query: map[string]string{
+			"period":  "period by which the fees are aggregated: day, week (default) or month",
+			"periods": "amount of most recent periods",
+		},
+	},
+	"GET /explorer/hashes/:hash": {
+		Summary: "get the block, transaction, output or address identified by the given hash",
+		Query:   map[string]string{"minheight": "minimum height of the blocks of which the transactions of an address are returned"},
+	},
+	"GET /explorer/mintcondition": {Summary: "get the current mint condition"},
+	"GET /explorer/mintcondition/:height": {
+		Summary: "get the mint condition active at the given block height",
+	},
+	"GET /explorer/network": {Summary: "get the name and genesis of the network served by the daemon"},
+	"GET /explorer/rawblocks/:id": {
+		Summary: "get the block with the given ID in the given encoding",
+		Query:   queryEncoding,
+	},
+	"GET /explorer/rawtransactions/:id": {
+		Summary:     "get the confirmed transaction with the given ID in the given encoding",
+		Description: "The ID and height of its block are returned in the Block-ID and Block-Height headers.",
+		Query:       queryEncoding,
+	},
+	"GET /explorer/richlist": {
+		Summary: "get the addresses with the highest coin balances",
+		Query:   map[string]string{"count": "amount of addresses returned"},
+	},
+	"GET /explorer/richlist/distribution": {Summary: "get the distribution of the coin balances over all addresses"},
+	"GET /explorer/stats": {
+		Summary: "get the daily statistics of the chain",
+		Query:   map[string]string{"days": "amount of days for which the statistics are returned"},
+	},
+	"GET /explorer/stats/history": {
+		Summary: "get the statistics of the given amount of most recent blocks",
+		Query:   map[string]string{"history": "amount of blocks"},
+	},
+	"GET /explorer/stats/range": {
+		Summary: "get the statistics of the blocks within the given range",
+		Query: map[string]string{
+			"start": "height of the first block of the range",
+			"end":   "height of the last block of the range",
+		},
+	},
+	"GET /explorer/validate-address": {
+		Summary: "validate the given address, returning its type, checksum diagnostics and whether it is authorized",
+		Query:   map[string]string{"addr": "address to validate"},
+	},
+
+	// ledger
+	"GET /ledger/journal": {
+		Summary: "get the journal of all coin movements, as double-entry bookkeeping records",
+		Query:   map[string]string{"format": "format of the journal: json (default) or csv"},
+	},
+	"GET /ledger/labels": {Summary: "get the labels given to addresses in the ledger reports"},
+	"POST /ledger/labels": {
+		Summary:       "label an address in the ledger reports",
+		Authenticated: true,
+	},
+	"GET /ledger/trialbalance": {
+		Summary: "get the trial balance of all addresses",
+		Query: mergeQuery(queryHeight, map[string]string{
+			"entities": "if true, the balances are grouped by their labeled entity",
+		}),
+	},
+
+	// multisig
+	"GET /multisig/proposals":     {Summary: "get all pending multisig transaction proposals"},
+	"POST /multisig/proposals":    {Summary: "submit a multisig transaction proposal, or signatures for it"},
+	"GET /multisig/proposals/:id": {Summary: "get the multisig transaction proposal with the given ID"},
+	"POST /multisig/proposals/:id/broadcast": {
+		Summary: "broadcast the fully signed multisig transaction proposal with the given ID",
+	},
+
+	// transactionpool
+	"POST /transactionpool/broadcast": {
+		Summary:       "check the given transaction set locally, and only relay it to the peers once accepted by the transaction pool",
+		Description:   "A rejected transaction set is not an error: its status is returned together with the check which rejected it (policy, consensus or transactionpool) and the reason, such that it can be told apart from a transaction set accepted and awaiting confirmation.",
+		Authenticated: true,
+	},
+	"POST /transactionpool/compose": {
+		Summary:     "compose the unsigned transaction paying the given recipients, funded by the given coin inputs and addresses",
+		Description: "Funding addresses are only supported if the explorer module is loaded. The fulfillments of the coin inputs have to be signed, using the returned signature hashes, before the transaction is submitted.",
+	},
+	"POST /transactionpool/decode": {
+		Summary: "decode the given transaction, computing its IDs and looking up the outputs spent by its inputs",
+		Query:   map[string]string{"encoding": "encoding of the given transaction: siabin (default), hex or json"},
+	},
+	"GET /transactionpool/rawtransactions/:id": {
+		Summary: "get the unconfirmed transaction with the given ID in the given encoding",
+		Query:   queryEncoding,
+	},
+	"GET /transactionpool/relay": {Summary: "get the relay statistics of the transaction pool"},
+	"GET /transactionpool/transactions": {
+		Summary: "get the unconfirmed transactions",
+		Query:   map[string]string{"unlockhash": "only return the transactions related to the given address"},
+	},
+	"POST /transactionpool/transactions": {
+		Summary:       "add the given transaction to the transaction pool and broadcast it",
+		Authenticated: true,
+	},
+
+	// transactions
+	"GET /transactions/:id/status": {
+		Summary:     "get the status of the transaction with the given ID: confirmed, unconfirmed, doublespent, conflicted, evicted or dropped",
+		Description: "Confirmed transactions are returned with the ID and height of their block and their amount of confirmations. Unconfirmed transactions are only known by ID as long as they are in the transaction pool or were seen there since the daemon started.",
+	},
+
+	// wallet
+	"GET /wallet":                 {Summary: "get the state and balances of the wallet", Authenticated: true},
+	"GET /wallet/accelerate":      {Summary: "get the unconfirmed wallet transactions which can be accelerated", Authenticated: true},
+	"POST /wallet/accelerate/:id": {Summary: "accelerate the given unconfirmed transaction by paying a higher fee", Authenticated: true},
+	"GET /wallet/accounts":        {Summary: "get all sub-accounts of the wallet", Authenticated: true},
+	"POST /wallet/accounts": {
+		Summary:       "create or restore the sub-account with the given label, derived from the primary seed",
+		Authenticated: true,
+	},
+	"GET /wallet/accounts/:label":              {Summary: "get the sub-account with the given label and its balance", Authenticated: true},
+	"POST /wallet/accounts/:label/address":     {Summary: "generate a new address of the given sub-account", Authenticated: true},
+	"GET /wallet/accounts/:label/transactions": {Summary: "get the confirmed transactions of the given sub-account", Authenticated: true},
+	"GET /wallet/address":                      {Summary: "generate a new address", Authenticated: true},
+	"GET /wallet/addresses":                    {Summary: "get all addresses of the wallet", Authenticated: true},
+	"POST /wallet/addresses/import":            {Summary: "import an address, tracked by the wallet without its keys", Authenticated: true},
+	"GET /wallet/addresses/imported":           {Summary: "get all imported addresses", Authenticated: true},
+	"GET /wallet/addresses/imported/:address": {
+		Summary:       "get the imported address with its balance",
+		Authenticated: true,
+	},
+	"GET /wallet/addresses/labels": {
+		Summary:       "get the labeled addresses with their label and tags",
+		Query:         queryAddressLabels,
+		Authenticated: true,
+	},
+	"POST /wallet/addresses/labels": {
+		Summary:       "attach a label and tags to an address, generating a new address if none is given",
+		Authenticated: true,
+	},
+	"GET /wallet/addresses/labels/balance": {
+		Summary:       "get the confirmed balance of the labeled addresses",
+		Query:         queryAddressLabels,
+		Authenticated: true,
+	},
+	"POST /wallet/addresses/labels/remove": {Summary: "remove the label and tags of an address", Authenticated: true},
+	"GET /wallet/addresses/labels/transactions": {
+		Summary:       "get the confirmed transactions of the labeled addresses",
+		Query:         queryAddressLabels,
+		Authenticated: true,
+	},
+	"GET /wallet/addressreport": {Summary: "get the balance and usage of every wallet address", Authenticated: true},
+	"GET /wallet/audit": {
+		Summary:       "export the hash-chained audit log of all wallet API mutations, as a JSON record per line",
+		Authenticated: true,
+	},
+	"GET /wallet/audit/verify": {Summary: "verify the hash chain of the audit log", Authenticated: true},
+	"GET /wallet/backup":       {Summary: "create a backup of the wallet", Authenticated: true},
+	"GET /wallet/balance": {
+		Summary:       "get the breakdown of the coin balance of the wallet: spendable, time-locked, multisig, unauthorized and pending",
+		Authenticated: true,
+	},
+	"POST /wallet/blockstakes": {
+		Summary:       "send block stakes",
+		Query:         queryWalletSend,
+		Authenticated: true,
+	},
+	"GET /wallet/blockstakestats": {Summary: "get the block creation statistics of the wallet", Authenticated: true},
+	"POST /wallet/coins": {
+		Summary:       "send coins",
+		Query:         queryWalletSend,
+		Authenticated: true,
+	},
+	"POST /wallet/consolidate":           {Summary: "consolidate the unspent coin outputs of the wallet", Authenticated: true},
+	"GET /wallet/contacts":               {Summary: "get all contacts of the address book", Authenticated: true},
+	"POST /wallet/contacts":              {Summary: "add a contact to the address book", Authenticated: true},
+	"GET /wallet/contacts/:name":         {Summary: "get the contact with the given name", Authenticated: true},
+	"POST /wallet/contacts/:name":        {Summary: "update the contact with the given name", Authenticated: true},
+	"POST /wallet/contacts/:name/remove": {Summary: "remove the contact with the given name", Authenticated: true},
+	"POST /wallet/create/transaction":    {Summary: "create an unsigned transaction funded by the wallet", Authenticated: true},
+	"POST /wallet/data":                  {Summary: "send arbitrary data", Authenticated: true},
+	"POST /wallet/delegation":            {Summary: "delegate the block stakes of the wallet to an operator", Authenticated: true},
+	"GET /wallet/deposits": {
+		Summary:       "get the allocated deposit addresses",
+		Query:         map[string]string{"reference": "customer reference of which the deposit addresses are returned"},
+		Authenticated: true,
+	},
+	"POST /wallet/deposits": {
+		Summary:       "allocate a fresh deposit address for a customer reference, posting its deposits to the given webhook",
+		Authenticated: true,
+	},
+	"GET /wallet/deposits/:address": {Summary: "get the given deposit address", Authenticated: true},
+	"GET /wallet/distributions": {
+		Summary:       "get the recorded distributions of the payout templates",
+		Query:         map[string]string{"template": "name of the payout template of which the distributions are returned"},
+		Authenticated: true,
+	},
+	"GET /wallet/fsck": {Summary: "check the consistency of the wallet with the consensus set", Authenticated: true},
+	"GET /wallet/fund/coins": {
+		Summary:       "get coin inputs of the wallet funding the given amount",
+		Query:         map[string]string{"amount": "amount of coins to fund", "refund": "address to which the remainder is refunded"},
+		Authenticated: true,
+	},
+	"POST /wallet/init": {
+		Summary: "create the wallet, optionally from an existing seed",
+		Query: map[string]string{
+			"passphrase":     "passphrase with which the wallet is encrypted",
+			"seed":           "mnemonic of an existing seed",
+			"seedpassphrase": "BIP39 passphrase of the seed",
+		},
+		Authenticated: true,
+	},
+	"GET /wallet/key/:unlockhash": {Summary: "get the key pair of the given wallet address", Authenticated: true},
+	"POST /wallet/lock":           {Summary: "lock the wallet", Authenticated: true},
+	"GET /wallet/locked":          {Summary: "get the locked outputs of the wallet", Authenticated: true},
+	"GET /wallet/multisig":        {Summary: "get the multisig wallets of which the wallet is a signer", Authenticated: true},
+	"POST /wallet/multisig/transaction": {
+		Summary:       "create and sign a transaction spending from a multisig wallet",
+		Authenticated: true,
+	},
+	"GET /wallet/payouts":               {Summary: "get all payout templates", Authenticated: true},
+	"POST /wallet/payouts":              {Summary: "add a payout template", Authenticated: true},
+	"GET /wallet/payouts/:name":         {Summary: "get the payout template with the given name", Authenticated: true},
+	"POST /wallet/payouts/:name":        {Summary: "update the payout template with the given name", Authenticated: true},
+	"POST /wallet/payouts/:name/remove": {Summary: "remove the payout template with the given name", Authenticated: true},
+	"POST /wallet/payouts/:name/execute": {
+		Summary:       "send the payout of the template with the given name in a single transaction",
+		Query:         map[string]string{"dryrun": "if true, the transaction is created and returned, but not broadcast"},
+		Authenticated: true,
+	},
+	"POST /wallet/provision": {
+		Summary:       "create and unlock the wallet from the given or a generated mnemonic, returning the mnemonic and first addresses",
+		Authenticated: true,
+	},
+	"GET /wallet/publickey": {Summary: "generate a new public key", Authenticated: true},
+	"POST /wallet/seed": {
+		Summary: "load an additional seed into the wallet",
+		Query: map[string]string{
+			"passphrase":     "passphrase of the wallet",
+			"mnemonic":       "mnemonic of the seed",
+			"seedpassphrase": "BIP39 passphrase of the seed",
+		},
+		Authenticated: true,
+	},
+	"GET /wallet/seeds": {Summary: "get the seeds of the wallet", Authenticated: true},
+	"POST /wallet/sign": {Summary: "sign the given transaction with the keys of the wallet", Authenticated: true},
+	"POST /wallet/signmessage": {
+		Summary:       "sign the given message using the key of the given address of the wallet, proving control of that address",
+		Authenticated: true,
+	},
+	"GET /wallet/spends": {Summary: "get the spend policy of the wallet, the coins it sent today and the spends awaiting approval", Authenticated: true},
+	"POST /wallet/spends/:id/approve": {
+		Summary:       "approve and execute the pending spend with the given ID, authenticated using the approval password",
+		Authenticated: true,
+	},
+	"POST /wallet/spends/:id/reject": {Summary: "reject the pending spend with the given ID", Authenticated: true},
+	"POST /wallet/sweep": {
+		Summary:       "sweep the tagged addresses which reached the threshold of the sweep policy to cold storage",
+		Authenticated: true,
+	},
+	"GET /wallet/sweeps":          {Summary: "get the sweep policy and the recorded sweeps", Authenticated: true},
+	"GET /wallet/timelocked":      {Summary: "get the time-locked balance of the wallet", Authenticated: true},
+	"POST /wallet/transaction":    {Summary: "create and broadcast a transaction funded by the wallet", Authenticated: true},
+	"GET /wallet/transaction/:id": {Summary: "get the wallet transaction with the given ID"},
+	"GET /wallet/transactions": {
+		Summary: "get the wallet transactions confirmed within the given height range, and all unconfirmed ones",
+		Query: map[string]string{
+			"startheight": "height of the first block of the range",
+			"endheight":   "height of the last block of the range",
+		},
+	},
+	"GET /wallet/transactions/:addr": {Summary: "get the transactions related to the given wallet address"},
+	"POST /wallet/unlock":            {Summary: "unlock the wallet", Authenticated: true},
+	"GET /wallet/unlocked":           {Summary: "get the unlocked outputs of the wallet", Authenticated: true},
+	"POST /wallet/vote": {
+		Summary:       "vote on a governance proposal using the block stakes of the wallet",
+		Authenticated: true,
+	},
+
+	// signer, served by goldchainsigner
+	"GET /signer/addresses": {Summary: "get the addresses of the signer", Authenticated: true},
+	"POST /signer/sign":     {Summary: "sign the given transaction with the keys of the signer", Authenticated: true},
+	"POST /signer/challenge": {
+		Summary:       "sign the given challenge, proving the ownership of an address",
+		Authenticated: true,
+	},
+}
+
+// mergeQuery merges the given query parameter annotations into a new map.
+func mergeQuery(queries ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, query := range queries {
+		for name, description := range query {
+			merged[name] = description
+		}
+	}
+	return merged
+}
@@ -0,0 +1,289 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletPayoutsGET contains all payout templates of the wallet,
+	// as returned by a GET call to /wallet/payouts.
+	WalletPayoutsGET struct {
+		Templates []wallet.PayoutTemplate `json:"templates"`
+	}
+
+	// WalletPayoutsPOST contains the payout template to add,
+	// as given as the body of a POST call to /wallet/payouts.
+	WalletPayoutsPOST struct {
+		Name string `json:"name"`
+		wallet.PayoutDefinition
+	}
+
+	// WalletPayoutPOST contains the new definition of a payout template,
+	// as given as the body of a POST call to /wallet/payouts/:name.
+	WalletPayoutPOST struct {
+		wallet.PayoutDefinition
+	}
+
+	// WalletDistributionsGET contains the recorded distributions of the wallet, in the order they were sent,
+	// as returned by a GET call to /wallet/distributions.
+	WalletDistributionsGET struct {
+		Distributions []wallet.Distribution `json:"distributions"`
+	}
+
+	// WalletPayoutExecutePOSTResp contains the ID of the transaction sending the payout,
+	// and the payout it sends, as returned by a POST call to /wallet/payouts/:name/execute.
+	WalletPayoutExecutePOSTResp struct {
+		TransactionID types.TransactionID   `json:"transactionid"`
+		Payout        wallet.ResolvedPayout `json:"payout"`
+	}
+)
+
+// walletPayoutExecuteCall is the path of the spending endpoint executing a payout template.
+const walletPayoutExecuteCall = "/wallet/payouts/:name/execute"
+
+// RegisterWalletPayoutsHTTPHandlers registers the handlers for the wallet payout template HTTP endpoints.
+// Payouts are only executed within the spend policy of the given approvals, should they be given,
+// every payout sent being recorded in the given distribution log.
+func RegisterWalletPayoutsHTTPHandlers(router rapi.Router, payouts *wallet.Payouts, distributions *wallet.DistributionLog, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants, approvals *SpendApprovals, requiredPassword string) {
+	router.GET("/wallet/payouts", rapi.RequirePasswordHandler(NewWalletPayoutsHandler(payouts), requiredPassword))
+	router.POST("/wallet/payouts", rapi.RequirePasswordHandler(NewWalletAddPayoutHandler(payouts), requiredPassword))
+	router.GET("/wallet/payouts/:name", rapi.RequirePasswordHandler(NewWalletPayoutHandler(payouts), requiredPassword))
+	router.POST("/wallet/payouts/:name", rapi.RequirePasswordHandler(NewWalletUpdatePayoutHandler(payouts), requiredPassword))
+	router.POST("/wallet/payouts/:name/remove", rapi.RequirePasswordHandler(NewWalletRemovePayoutHandler(payouts), requiredPassword))
+	router.POST(walletPayoutExecuteCall, rapi.RequirePasswordHandler(approvals.guarded(walletPayoutExecuteCall,
+		outgoingPayout(payouts, tpool, constants), NewWalletExecutePayoutHandler(payouts, distributions, w, tpool, cs, constants)), requiredPassword))
+	router.GET("/wallet/distributions", rapi.RequirePasswordHandler(NewWalletDistributionsHandler(distributions), requiredPassword))
+}
+
+// NewWalletPayoutsHandler creates a handler to handle the GET API calls to /wallet/payouts.
+func NewWalletPayoutsHandler(payouts *wallet.Payouts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, WalletPayoutsGET{Templates: payouts.Templates()})
+	}
+}
+
+// NewWalletAddPayoutHandler creates a handler to handle the POST API calls to /wallet/payouts,
+// adding a new payout template.
+func NewWalletAddPayoutHandler(payouts *wallet.Payouts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletPayoutsPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied payout template: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		template, err := payouts.AddTemplate(body.Name, body.PayoutDefinition)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/payouts: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, template)
+	}
+}
+
+// NewWalletPayoutHandler creates a handler to handle the GET API calls to /wallet/payouts/:name.
+func NewWalletPayoutHandler(payouts *wallet.Payouts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		template, err := payouts.Template(ps.ByName("name"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/payouts/$(name): " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, template)
+	}
+}
+
+// NewWalletUpdatePayoutHandler creates a handler to handle the POST API calls to /wallet/payouts/:name,
+// replacing the definition of an existing payout template.
+func NewWalletUpdatePayoutHandler(payouts *wallet.Payouts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var body WalletPayoutPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied payout template: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		template, err := payouts.UpdateTemplate(ps.ByName("name"), body.PayoutDefinition)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/payouts/$(name): " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, template)
+	}
+}
+
+// NewWalletRemovePayoutHandler creates a handler to handle the API calls to /wallet/payouts/:name/remove.
+func NewWalletRemovePayoutHandler(payouts *wallet.Payouts) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		err := payouts.RemoveTemplate(ps.ByName("name"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/payouts/$(name)/remove: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewWalletExecutePayoutHandler creates a handler to handle the API calls to /wallet/payouts/:name/execute,
+// sending the payout of the template in a single transaction, or dry running it should the dryrun query parameter be true.
+// The outcome of every payout which isn't dry run is recorded as the last run of the template,
+// every payout sent being recorded in the distribution log as well.
+func NewWalletExecutePayoutHandler(payouts *wallet.Payouts, distributions *wallet.DistributionLog, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var (
+			query walletSendQuery
+			err   error
+		)
+		if str := req.URL.Query().Get("dryrun"); str != "" {
+			query.DryRun, err = strconv.ParseBool(str)
+			if err != nil {
+				rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/payouts/$(name)/execute: invalid dryrun query parameter: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		name := ps.ByName("name")
+		template, err := payouts.Template(name)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/payouts/$(name)/execute: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		writeWalletSendResponse(rw, "/wallet/payouts/$(name)/execute", query, func() (wallet.DryRunResult, error) {
+			payout, err := wallet.ResolvePayout(w, tpool, template, constants)
+			if err != nil {
+				return wallet.DryRunResult{}, err
+			}
+			return wallet.DryRun(w, tpool, cs, payout.CoinOutputs, nil, nil, payout.Options, constants)
+		}, func() (interface{}, error) {
+			run := wallet.PayoutRun{Time: types.Timestamp(time.Now().Unix())}
+			payout, err := wallet.ResolvePayout(w, tpool, template, constants)
+			var txn types.Transaction
+			if err == nil {
+				txn, err = wallet.SendOutputs(w, tpool, payout.CoinOutputs, nil, nil, payout.Options, constants)
+			}
+			if err != nil {
+				run.Error = err.Error()
+			} else {
+				id := txn.ID()
+				run.TransactionID = &id
+			}
+			// the run is recorded in memory even if it cannot be persisted, which doesn't undo the payout
+			payouts.RecordRun(name, run)
+			if err == nil {
+				err = distributions.Record(wallet.NewDistribution(template, payout, txn.ID(), cs.Height(), run.Time, false, constants))
+				if err != nil {
+					err = fmt.Errorf("payout sent in transaction %s, but not recorded: %v", txn.ID().String(), err)
+				}
+			}
+			return WalletPayoutExecutePOSTResp{TransactionID: txn.ID(), Payout: payout}, err
+		})
+	}
+}
+
+// NewScheduledPayoutFunc returns the function executing the scheduled payout templates, as used by a wallet.PayoutScheduler,
+// within the spend policy of the given approvals, should they be given, recording every payout sent in the given distribution log.
+// A scheduled payout exceeding the approval threshold is queued, and executed once approved,
+// as if it was requested by a POST call to /wallet/payouts/:name/execute.
+// Payouts are postponed while the consensus set isn't synced, as the balance of the wallet might not be up to date.
+// The given function is called with the outcome of every scheduled payout, and the error recording it, it can be nil.
+func NewScheduledPayoutFunc(payouts *wallet.Payouts, distributions *wallet.DistributionLog, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants, approvals *SpendApprovals, onRun func(name string, run wallet.PayoutRun, err error)) func(name string) {
+	return func(name string) {
+		if !cs.Synced() {
+			// still due once synced
+			return
+		}
+		template, err := payouts.Template(name)
+		if err != nil {
+			// removed since it was found to be due
+			return
+		}
+		run, recordErr := executeScheduledPayout(distributions, w, tpool, cs, template, constants, approvals)
+		err = payouts.RecordRun(name, run)
+		if recordErr != nil {
+			err = recordErr
+		}
+		if onRun != nil {
+			onRun(name, run, err)
+		}
+	}
+}
+
+// executeScheduledPayout sends the payout of the given template, within the spend policy of the given approvals,
+// returning the outcome of the run, and the error recording it in the distribution log should it have been sent.
+func executeScheduledPayout(distributions *wallet.DistributionLog, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, template wallet.PayoutTemplate, constants types.ChainConstants, approvals *SpendApprovals) (wallet.PayoutRun, error) {
+	run := wallet.PayoutRun{Time: types.Timestamp(time.Now().Unix()), Scheduled: true}
+	payout, err := wallet.ResolvePayout(w, tpool, template, constants)
+	if err != nil {
+		run.Error = err.Error()
+		return run, nil
+	}
+	release := func() error { return nil }
+	if approvals != nil {
+		amount, err := wallet.OutgoingCoins(w, payout.CoinOutputs)
+		if err == nil {
+			release, err = approvals.guard.Reserve(amount, walletPayoutExecuteCall, false)
+		}
+		if err == wallet.ErrApprovalRequired {
+			var pending wallet.PendingSpend
+			pending, err = approvals.guard.Queue(amount, "/wallet/payouts/"+template.Name+"/execute", []byte{})
+			run.PendingSpend = pending.ID
+		}
+		if err != nil || run.PendingSpend != "" {
+			if err != nil {
+				run.Error = err.Error()
+			}
+			return run, nil
+		}
+	}
+	txn, err := wallet.SendOutputs(w, tpool, payout.CoinOutputs, nil, nil, payout.Options, constants)
+	if err != nil {
+		// the coins were not sent, and no longer count towards the daily limit
+		release()
+		run.Error = err.Error()
+		return run, nil
+	}
+	id := txn.ID()
+	run.TransactionID = &id
+	return run, distributions.Record(wallet.NewDistribution(template, payout, id, cs.Height(), run.Time, true, constants))
+}
+
+// NewWalletDistributionsHandler creates a handler to handle the API calls to /wallet/distributions,
+// returning the recorded distributions of the payout template given by the optional template query parameter,
+// or of all payout templates if none is given.
+func NewWalletDistributionsHandler(distributions *wallet.DistributionLog) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		records, err := distributions.Distributions(req.URL.Query().Get("template"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/distributions: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		if records == nil {
+			records = []wallet.Distribution{}
+		}
+		rapi.WriteJSON(w, WalletDistributionsGET{Distributions: records})
+	}
+}
+
+// outgoingPayout returns the coins sent by a POST call to /wallet/payouts/:name/execute.
+func outgoingPayout(payouts *wallet.Payouts, tpool modules.TransactionPool, constants types.ChainConstants) outgoingCoinsFunc {
+	return func(w modules.Wallet, ps httprouter.Params, _ []byte) (types.Currency, error) {
+		template, err := payouts.Template(ps.ByName("name"))
+		if err != nil {
+			return types.Currency{}, err
+		}
+		payout, err := wallet.ResolvePayout(w, tpool, template, constants)
+		if err != nil {
+			return types.Currency{}, err
+		}
+		return wallet.OutgoingCoins(w, payout.CoinOutputs)
+	}
+}
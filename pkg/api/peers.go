@@ -0,0 +1,104 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/compact"
+	"github.com/nbh-digital/goldchain/pkg/peers"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// GatewayScoresGET contains the ban policy and limits, the scores of the (recently connected) peers and the active bans,
+// as returned by a GET call to /gateway/scores.
+type GatewayScoresGET struct {
+	Policy peers.Policy      `json:"policy"`
+	Limits peers.Limits      `json:"limits"`
+	Peers  []peers.PeerScore `json:"peers"`
+	Bans   []peers.Ban       `json:"bans"`
+}
+
+// RegisterPeerScoresHTTPHandlers registers the handlers for all peer score HTTP endpoints.
+func RegisterPeerScoresHTTPHandlers(router rapi.Router, gateway *peers.Gateway, requiredPassword string) {
+	router.GET("/gateway/scores", NewGatewayScoresHandler(gateway))
+	router.POST("/gateway/ban/:host", rapi.RequirePasswordHandler(NewGatewayBanHandler(gateway), requiredPassword))
+	router.POST("/gateway/unban/:host", rapi.RequirePasswordHandler(NewGatewayUnbanHandler(gateway), requiredPassword))
+}
+
+// NewGatewayScoresHandler creates a handler to handle the API calls to /gateway/scores.
+func NewGatewayScoresHandler(gateway *peers.Gateway) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, GatewayScoresGET{
+			Policy: gateway.Policy(),
+			Limits: gateway.Limits(),
+			Peers:  gateway.Scores(),
+			Bans:   gateway.Bans(),
+		})
+	}
+}
+
+// NewGatewayBanHandler creates a handler to handle the API calls to /gateway/ban/:host,
+// banning the host (or the host of the given network address) for the duration of the ban policy,
+// or the duration given as query parameter.
+func NewGatewayBanHandler(gateway *peers.Gateway) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		duration := gateway.Policy().BanDuration
+		if str := req.FormValue("duration"); str != "" {
+			var err error
+			duration, err = time.ParseDuration(str)
+			if err != nil || duration <= 0 {
+				rapi.WriteError(w, rapi.Error{Message: "invalid ban duration " + str}, http.StatusBadRequest)
+				return
+			}
+		}
+		if duration <= 0 {
+			rapi.WriteError(w, rapi.Error{Message: "banning is disabled, a ban duration is required"}, http.StatusBadRequest)
+			return
+		}
+		err := gateway.Ban(peerHost(ps.ByName("host")), duration, "banned over the API")
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /gateway/ban: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// NewGatewayUnbanHandler creates a handler to handle the API calls to /gateway/unban/:host.
+func NewGatewayUnbanHandler(gateway *peers.Gateway) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		err := gateway.Unban(peerHost(ps.ByName("host")))
+		if err != nil {
+			status := http.StatusInternalServerError
+			if err == peers.ErrNotBanned {
+				status = http.StatusBadRequest
+			}
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /gateway/unban: " + err.Error()}, status)
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+// peerHost returns the host of the given network address, or the string as is should it be a host.
+func peerHost(str string) string {
+	if host := modules.NetAddress(str).Host(); host != "" {
+		return host
+	}
+	return str
+}
+
+// RegisterCompactBlocksHTTPHandlers registers the handlers for all compact block relay HTTP endpoints.
+func RegisterCompactBlocksHTTPHandlers(router rapi.Router, gateway *compact.Gateway) {
+	router.GET("/gateway/compactblocks", NewGatewayCompactBlocksHandler(gateway))
+}
+
+// NewGatewayCompactBlocksHandler creates a handler to handle the API calls to /gateway/compactblocks,
+// returning the statistics of the compact blocks received from and sent to the peers.
+func NewGatewayCompactBlocksHandler(gateway *compact.Gateway) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, gateway.Stats())
+	}
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/extplugin"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// ConsensusPluginsGET contains the status of all consensus set plugins, and of the external plugins,
+// as returned by a GET call to /consensus/plugins.
+type ConsensusPluginsGET struct {
+	// Healthy is false as long as any of the plugins has problems
+	Healthy  bool                 `json:"healthy"`
+	Plugins  []pluginstats.Status `json:"plugins"`
+	External []extplugin.Status   `json:"external"`
+}
+
+// RegisterConsensusPluginsHTTPHandlers registers the handlers for the consensus plugin status HTTP endpoints.
+func RegisterConsensusPluginsHTTPHandlers(router rapi.Router, monitor *pluginstats.Monitor, external []*extplugin.Host) {
+	router.GET("/consensus/plugins", NewConsensusPluginsHandler(monitor, external))
+}
+
+// NewConsensusPluginsHandler creates a handler to handle the API calls to /consensus/plugins.
+func NewConsensusPluginsHandler(monitor *pluginstats.Monitor, external []*extplugin.Host) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		resp := ConsensusPluginsGET{
+			Healthy:  true,
+			Plugins:  monitor.Status(),
+			External: make([]extplugin.Status, 0, len(external)),
+		}
+		for _, status := range resp.Plugins {
+			resp.Healthy = resp.Healthy && status.Healthy
+		}
+		for _, host := range external {
+			status := host.Status()
+			resp.External = append(resp.External, status)
+			resp.Healthy = resp.Healthy && status.Healthy
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/portmap"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// GatewayPortMappingGET contains the status of the port mapping of the RPC port on the NAT gateway,
+// as returned by a GET call to /gateway/portmapping.
+type GatewayPortMappingGET struct {
+	Enabled bool `json:"enabled"`
+	// Mapping is the status of the port mapping, nil if port mapping is disabled
+	Mapping *portmap.Status `json:"mapping,omitempty"`
+}
+
+// RegisterPortMappingHTTPHandlers registers the handlers for all port mapping HTTP endpoints,
+// the mapper is nil if port mapping is disabled.
+func RegisterPortMappingHTTPHandlers(router rapi.Router, mapper *portmap.Mapper) {
+	router.GET("/gateway/portmapping", NewGatewayPortMappingHandler(mapper))
+}
+
+// NewGatewayPortMappingHandler creates a handler to handle the API calls to /gateway/portmapping.
+func NewGatewayPortMappingHandler(mapper *portmap.Mapper) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		if mapper == nil {
+			rapi.WriteJSON(w, GatewayPortMappingGET{})
+			return
+		}
+		status := mapper.Status()
+		rapi.WriteJSON(w, GatewayPortMappingGET{Enabled: true, Mapping: &status})
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/relay"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// TransactionPoolRelayGET contains the relay policy and metrics,
+// as returned by a GET call to /transactionpool/relay.
+type TransactionPoolRelayGET struct {
+	Policy  relay.Policy  `json:"policy"`
+	Metrics relay.Metrics `json:"metrics"`
+	// Orphans are the statistics of the orphan pool, omitted if disabled
+	Orphans *relay.OrphanMetrics `json:"orphans,omitempty"`
+	// Rebroadcasting is the amount of unconfirmed transaction sets accepted locally, which are rebroadcasted
+	Rebroadcasting int `json:"rebroadcasting"`
+}
+
+type (
+	// TransactionPoolBroadcastPOST contains the transaction set,
+	// as given as the body of a POST call to /transactionpool/broadcast.
+	TransactionPoolBroadcastPOST struct {
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// TransactionPoolBroadcastPOSTResp contains the result of the broadcast of a transaction set,
+	// as returned by a POST call to /transactionpool/broadcast.
+	TransactionPoolBroadcastPOSTResp struct {
+		relay.BroadcastResult
+	}
+)
+
+// RegisterRelayPolicyHTTPHandlers registers the handlers for all relay policy HTTP endpoints,
+// the rebroadcaster is optional.
+func RegisterRelayPolicyHTTPHandlers(router rapi.Router, filter *relay.Filter, rebroadcaster *relay.Rebroadcaster) {
+	router.GET("/transactionpool/relay", NewTransactionPoolRelayHandler(filter, rebroadcaster))
+}
+
+// NewTransactionPoolRelayHandler creates a handler to handle the API calls to /transactionpool/relay.
+func NewTransactionPoolRelayHandler(filter *relay.Filter, rebroadcaster *relay.Rebroadcaster) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		resp := TransactionPoolRelayGET{
+			Policy:  filter.Policy(),
+			Metrics: filter.Metrics(),
+		}
+		if orphans := filter.Orphans(); orphans != nil {
+			metrics := orphans.Metrics()
+			resp.Orphans = &metrics
+		}
+		if rebroadcaster != nil {
+			resp.Rebroadcasting = rebroadcaster.Pending()
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// RegisterTransactionPoolBroadcastHTTPHandlers registers the handlers for the transaction broadcast HTTP endpoints.
+func RegisterTransactionPoolBroadcastHTTPHandlers(router rapi.Router, broadcaster *relay.Broadcaster, requiredPassword string) {
+	router.POST("/transactionpool/broadcast", rapi.RequirePasswordHandler(NewTransactionPoolBroadcastHandler(broadcaster), requiredPassword))
+}
+
+// NewTransactionPoolBroadcastHandler creates a handler to handle the API calls to /transactionpool/broadcast.
+// A transaction set rejected locally is not an API error, its status being returned instead,
+// such that it can be told apart from a transaction set accepted and awaiting confirmation.
+func NewTransactionPoolBroadcastHandler(broadcaster *relay.Broadcaster) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body TransactionPoolBroadcastPOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied transaction set: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if len(body.Transactions) == 0 {
+			rapi.WriteError(w, rapi.Error{Message: "no transactions supplied"}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, TransactionPoolBroadcastPOSTResp{BroadcastResult: broadcaster.Broadcast(body.Transactions)})
+	}
+}
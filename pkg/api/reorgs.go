@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/reorgs"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// maxReorgsWait is the maximum duration a call to /consensus/reorgs waits for a reorganization.
+const maxReorgsWait = 10 * time.Minute
+
+// ConsensusReorgsGET contains the most recent reorganizations of the blockchain,
+// as returned by a GET call to /consensus/reorgs.
+type ConsensusReorgsGET struct {
+	// LastID is the ID of the last recorded reorganization, to be used as the after query parameter of the next call
+	LastID uint64 `json:"lastid"`
+	// Reorgs are the selected reorganizations, oldest first
+	Reorgs []reorgs.Reorg `json:"reorgs"`
+}
+
+// RegisterReorgsHTTPHandlers registers the handler for the reorganizations consensus HTTP endpoint.
+func RegisterReorgsHTTPHandlers(router rapi.Router, tracker *reorgs.Tracker) {
+	router.GET("/consensus/reorgs", NewConsensusReorgsHandler(tracker))
+}
+
+// NewConsensusReorgsHandler creates a handler to handle the API calls to /consensus/reorgs,
+// returning the recorded reorganizations with an ID greater than the optional after query parameter,
+// which reverted at least as many blocks as the optional mindepth query parameter.
+//
+// Given the optional wait query parameter (a duration of at most 10 minutes), the call waits for such a reorganization,
+// should none be recorded yet, returning no reorganizations once the duration passed.
+// The duration is capped to the remaining time before the API timeout of the request.
+func NewConsensusReorgsHandler(tracker *reorgs.Tracker) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		query := req.URL.Query()
+		var after, minDepth uint64
+		var wait time.Duration
+		var err error
+		if str := query.Get("after"); str != "" {
+			after, err = strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/reorgs: invalid after ID: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if str := query.Get("mindepth"); str != "" {
+			minDepth, err = strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/reorgs: invalid minimum depth: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if str := query.Get("wait"); str != "" {
+			wait, err = time.ParseDuration(str)
+			if err != nil || wait < 0 || wait > maxReorgsWait {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/reorgs: invalid wait duration: " + str}, http.StatusBadRequest)
+				return
+			}
+		}
+
+		var resp ConsensusReorgsGET
+		if wait == 0 {
+			resp.Reorgs, resp.LastID = tracker.Reorgs(after, minDepth)
+			rapi.WriteJSON(w, resp)
+			return
+		}
+		// respond before the request times out
+		if deadline, ok := req.Context().Deadline(); ok {
+			if remaining := time.Until(deadline) - time.Second; remaining < wait {
+				wait = remaining
+			}
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), wait)
+		defer cancel()
+		resp.Reorgs, resp.LastID = tracker.Wait(ctx, after, minDepth)
+		rapi.WriteJSON(w, resp)
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/richlist"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+const (
+	// defaultRichListCount is the amount of addresses returned by a call to /explorer/richlist, if not specified
+	defaultRichListCount = 100
+	// maxRichListCount is the maximum amount of addresses returned by a single call to /explorer/richlist
+	maxRichListCount = 1000
+)
+
+type (
+	// ExplorerRichListGET contains the addresses owning the most coins,
+	// as returned by a GET call to /explorer/richlist.
+	ExplorerRichListGET struct {
+		richlist.RichList
+	}
+
+	// ExplorerBalanceDistributionGET contains the distribution of the balances of all addresses owning coins,
+	// as returned by a GET call to /explorer/richlist/distribution.
+	ExplorerBalanceDistributionGET struct {
+		richlist.Distribution
+	}
+)
+
+// RegisterExplorerRichListHTTPHandlers registers the handlers for the rich list explorer HTTP endpoints,
+// which are only registered in case the rich list plugin is given.
+func RegisterExplorerRichListHTTPHandlers(router rapi.Router, plugin *richlist.Plugin) {
+	if plugin != nil {
+		router.GET("/explorer/richlist", NewExplorerRichListHandler(plugin))
+		router.GET("/explorer/richlist/distribution", NewExplorerBalanceDistributionHandler(plugin))
+	}
+}
+
+// NewExplorerRichListHandler creates a handler to handle the API calls to /explorer/richlist,
+// returning the addresses owning the most coins, as many as given by the optional count query parameter
+// (100 by default, at most 1000).
+func NewExplorerRichListHandler(plugin *richlist.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		count := defaultRichListCount
+		if str := req.URL.Query().Get("count"); str != "" {
+			var err error
+			count, err = strconv.Atoi(str)
+			if err != nil || count < 1 {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/richlist: invalid count: " + str}, http.StatusBadRequest)
+				return
+			}
+			if count > maxRichListCount {
+				count = maxRichListCount
+			}
+		}
+		list, err := plugin.GetRichList(count)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/richlist: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ExplorerRichListGET{RichList: list})
+	}
+}
+
+// NewExplorerBalanceDistributionHandler creates a handler to handle the API calls to /explorer/richlist/distribution,
+// returning the distribution of the balances of all addresses owning coins, per order of magnitude.
+func NewExplorerBalanceDistributionHandler(plugin *richlist.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		distribution, err := plugin.GetDistribution()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/richlist/distribution: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ExplorerBalanceDistributionGET{Distribution: distribution})
+	}
+}
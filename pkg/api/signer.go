@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// SignerAddressesGET contains the addresses the signer can sign for,
+	// as returned by a GET call to /signer/addresses.
+	SignerAddressesGET struct {
+		signer.AddressesResponse
+	}
+
+	// SignerSignPOST contains the transaction to sign and the conditions of its inputs,
+	// as given as the body of a POST call to /signer/sign.
+	SignerSignPOST struct {
+		signer.SignRequest
+	}
+
+	// SignerChallengePOST contains the challenge to sign and the address to sign it with,
+	// as given as the body of a POST call to /signer/challenge.
+	SignerChallengePOST struct {
+		signer.ChallengeRequest
+	}
+
+	// SignerChallengePOSTResp contains the signed challenge,
+	// as returned by a POST call to /signer/challenge.
+	SignerChallengePOSTResp struct {
+		signer.Challenge
+	}
+)
+
+// RegisterSignerHTTPHandlers registers the handlers for the HTTP endpoints of a (remote) signer.
+// All endpoints require the given password, as anyone who can reach them can spend the funds of the signer.
+func RegisterSignerHTTPHandlers(router rapi.Router, s *signer.Signer, requiredPassword string) {
+	router.GET("/signer/addresses", rapi.RequirePasswordHandler(NewSignerAddressesHandler(s), requiredPassword))
+	router.POST("/signer/sign", rapi.RequirePasswordHandler(NewSignerSignHandler(s), requiredPassword))
+	router.POST("/signer/challenge", rapi.RequirePasswordHandler(NewSignerChallengeHandler(s), requiredPassword))
+}
+
+// NewSignerAddressesHandler creates a handler to handle the API calls to /signer/addresses.
+func NewSignerAddressesHandler(s *signer.Signer) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, SignerAddressesGET{AddressesResponse: signer.AddressesResponse{Addresses: s.Addresses()}})
+	}
+}
+
+// NewSignerSignHandler creates a handler to handle the API calls to /signer/sign.
+func NewSignerSignHandler(s *signer.Signer) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body SignerSignPOST
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied sign request: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := s.SignTransaction(body.SignRequest)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /signer/sign: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, txn)
+	}
+}
+
+// NewSignerChallengeHandler creates a handler to handle the API calls to /signer/challenge.
+func NewSignerChallengeHandler(s *signer.Signer) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body SignerChallengePOST
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied challenge: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if len(body.Challenge) == 0 {
+			rapi.WriteError(w, rapi.Error{Message: "a challenge has to be specified"}, http.StatusBadRequest)
+			return
+		}
+		challenge, err := s.SignChallenge(body.Address, body.Challenge)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /signer/challenge: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		rapi.WriteJSON(w, SignerChallengePOSTResp{Challenge: challenge})
+	}
+}
+
+// RegisterRemoteSignerHTTPHandlers registers the wallet HTTP endpoints which are proxied to a remote signer,
+// for daemons which do not load the wallet module as they don't hold the seed themselves.
+func RegisterRemoteSignerHTTPHandlers(router rapi.Router, client *signer.Client, cs modules.ConsensusSet, requiredPassword string) {
+	router.GET("/wallet/addresses", rapi.RequirePasswordHandler(NewRemoteSignerAddressesHandler(client), requiredPassword))
+	router.POST("/wallet/sign", rapi.RequirePasswordHandler(NewRemoteSignerSignHandler(client, cs), requiredPassword))
+}
+
+// NewRemoteSignerAddressesHandler creates a handler to handle the API calls to /wallet/addresses,
+// returning the addresses of the remote signer.
+func NewRemoteSignerAddressesHandler(client *signer.Client) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		addresses, err := client.Addresses()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/addresses: remote signer: " + err.Error()}, http.StatusBadGateway)
+			return
+		}
+		rapi.WriteJSON(w, rapi.WalletAddressesGET{Addresses: addresses})
+	}
+}
+
+// NewRemoteSignerSignHandler creates a handler to handle the API calls to /wallet/sign,
+// signing the transaction using the remote signer rather than the wallet.
+// The conditions of the outputs spent by the transaction are looked up in the consensus set,
+// the same way the wallet does when signing.
+func NewRemoteSignerSignHandler(client *signer.Client, cs modules.ConsensusSet) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body types.Transaction
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error decoding the supplied transaction: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		signReq, err := signer.NewSignRequest(body, cs)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/sign: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		txn, err := client.SignTransaction(signReq)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/sign: remote signer: " + err.Error()}, http.StatusBadGateway)
+			return
+		}
+		rapi.WriteJSON(w, txn)
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ResponseSigningKeyFile is the name of the file storing the key signing the API responses, stored in the data directory of the daemon.
+const ResponseSigningKeyFile = "response-signing.key"
+
+// The headers of a signed API response.
+const (
+	// ResponseSignatureHeader contains the hex-encoded Ed25519 signature of the response
+	ResponseSignatureHeader = "Response-Signature"
+	// ResponseSignatureKeyHeader contains the public key of the daemon, which the client has to compare to the key it trusts
+	ResponseSignatureKeyHeader = "Response-Signature-Key"
+	// ResponseSignatureTimeHeader contains the unix epoch timestamp (in seconds) at which the response was signed
+	ResponseSignatureTimeHeader = "Response-Signature-Time"
+)
+
+// responseSignatureVersion prefixes the signed message of all responses.
+const responseSignatureVersion = "goldchain-response-v1"
+
+// DefaultSignedRoutes are the routes of which the responses are signed by default:
+// the wallet, the balance and auth status of addresses, and transactions.
+var DefaultSignedRoutes = []string{
+	"/wallet",
+	"/explorer/addresses",
+	"/explorer/authcoin/status",
+	"/explorer/hashes",
+	"/explorer/rawtransactions",
+	"/consensus/authcoin/status",
+	"/consensus/transactions",
+	"/consensus/unspent",
+	"/transactionpool/transactions",
+	"/transactionpool/rawtransactions",
+	"/transactionpool/broadcast",
+	"/transactions",
+}
+
+var responseSigningKeyMetadata = persist.Metadata{
+	Header:  "Goldchain Response Signing Key",
+	Version: "1.0",
+}
+
+type (
+	// ResponseSigner signs API responses using the key of the daemon.
+	ResponseSigner struct {
+		sk crypto.SecretKey
+		pk types.PublicKey
+	}
+
+	// responseSigningKeyFile is the persisted form of the response signing key.
+	responseSigningKeyFile struct {
+		SecretKey crypto.SecretKey `json:"secretkey"`
+	}
+
+	// DaemonSigningKeyGET contains the public key signing the API responses,
+	// as returned by a GET call to /daemon/signingkey.
+	DaemonSigningKeyGET struct {
+		PublicKey types.PublicKey `json:"publickey"`
+		// Routes are the routes of which the responses are signed
+		Routes []string `json:"routes"`
+	}
+)
+
+// LoadResponseSigner loads the response signing key stored in the given file,
+// generating it should the file not exist yet.
+func LoadResponseSigner(filename string) (*ResponseSigner, error) {
+	var file responseSigningKeyFile
+	err := persist.LoadJSON(responseSigningKeyMetadata, &file, filename)
+	if os.IsNotExist(err) {
+		file.SecretKey, _ = crypto.GenerateKeyPair()
+		err = persist.SaveJSON(responseSigningKeyMetadata, file, filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save response signing key: %v", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load response signing key: %v", err)
+	}
+	return &ResponseSigner{sk: file.SecretKey, pk: types.Ed25519PublicKey(file.SecretKey.PublicKey())}, nil
+}
+
+// PublicKey returns the public key verifying the signed responses.
+func (s *ResponseSigner) PublicKey() types.PublicKey {
+	return s.pk
+}
+
+// ResponseSignatureHash returns the hash signed for a response: the BLAKE2b-256 hash of
+// "goldchain-response-v1\n<method>\n<request URI>\n<status>\n<timestamp>\n<body>",
+// such that a signed response cannot be replayed for another request.
+func ResponseSignatureHash(method, requestURI string, status int, timestamp int64, body []byte) crypto.Hash {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n%s\n%s\n%d\n%d\n", responseSignatureVersion, method, requestURI, status, timestamp)
+	buf.Write(body)
+	return crypto.HashBytes(buf.Bytes())
+}
+
+// VerifyResponse verifies the signature of a response to the request with the given method and request URI
+// (path and raw query), using the given trusted public key of the daemon.
+func VerifyResponse(pk types.PublicKey, method, requestURI string, status int, header http.Header, body []byte) error {
+	if key := header.Get(ResponseSignatureKeyHeader); key != pk.String() {
+		return fmt.Errorf("response is signed by %q rather than by the trusted key", key)
+	}
+	timestamp, err := strconv.ParseInt(header.Get(ResponseSignatureTimeHeader), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid response signature time: %v", err)
+	}
+	b, err := hex.DecodeString(header.Get(ResponseSignatureHeader))
+	if err != nil || len(b) != crypto.SignatureSize {
+		return errors.New("missing or malformed response signature")
+	}
+	var epk crypto.PublicKey
+	if pk.Algorithm != types.SignatureAlgoEd25519 || len(pk.Key) != len(epk) {
+		return errors.New("the trusted key isn't an ed25519 public key")
+	}
+	copy(epk[:], pk.Key)
+	var sig crypto.Signature
+	copy(sig[:], b)
+	return crypto.VerifyHash(ResponseSignatureHash(method, requestURI, status, timestamp, body), epk, sig)
+}
+
+// signedRoute returns true in case the given path is one of the given routes, or a subpath of one.
+func signedRoute(routes []string, path string) bool {
+	for _, route := range routes {
+		if path == route || strings.HasPrefix(path, strings.TrimSuffix(route, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSigningHandler wraps the given handler, signing the responses to all requests
+// of which the path is one of the given routes, or a subpath of one.
+// Signed responses are buffered, such that the signature can be sent as the Response-Signature header.
+func NewSigningHandler(handler http.Handler, signer *ResponseSigner, routes []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !signedRoute(routes, req.URL.Path) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		rw := &signingResponseWriter{header: make(http.Header), status: http.StatusOK}
+		handler.ServeHTTP(rw, req)
+
+		timestamp := time.Now().Unix()
+		sig := crypto.SignHash(ResponseSignatureHash(req.Method, req.URL.RequestURI(), rw.status, timestamp, rw.body.Bytes()), signer.sk)
+		header := w.Header()
+		for key, values := range rw.header {
+			header[key] = values
+		}
+		header.Set(ResponseSignatureHeader, hex.EncodeToString(sig[:]))
+		header.Set(ResponseSignatureKeyHeader, signer.pk.String())
+		header.Set(ResponseSignatureTimeHeader, strconv.FormatInt(timestamp, 10))
+		w.WriteHeader(rw.status)
+		w.Write(rw.body.Bytes())
+	})
+}
+
+// signingResponseWriter buffers a response, such that it can be signed before it is written.
+type signingResponseWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Header implements http.ResponseWriter.Header
+func (w *signingResponseWriter) Header() http.Header {
+	return w.header
+}
+
+// WriteHeader implements http.ResponseWriter.WriteHeader
+func (w *signingResponseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status, w.wroteHeader = status, true
+	}
+}
+
+// Write implements http.ResponseWriter.Write
+func (w *signingResponseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.body.Write(b)
+}
+
+// RegisterDaemonSigningKeyHTTPHandler registers the handler returning the public key signing the API responses.
+func RegisterDaemonSigningKeyHTTPHandler(router rapi.Router, signer *ResponseSigner, routes []string) {
+	router.GET("/daemon/signingkey", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, DaemonSigningKeyGET{PublicKey: signer.PublicKey(), Routes: routes})
+	})
+}
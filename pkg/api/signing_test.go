@@ -0,0 +1,90 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSigningHandler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "signing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ResponseSigningKeyFile)
+
+	signer, err := LoadResponseSigner(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the key is generated once, and loaded afterwards
+	key := signer.PublicKey()
+	loaded, err := LoadResponseSigner(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedKey := loaded.PublicKey(); loadedKey.String() != key.String() {
+		t.Fatalf("expected the stored key %s to be loaded, got %s", key.String(), loadedKey.String())
+	}
+
+	handler := NewSigningHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Test", "ok")
+		if req.URL.Path == "/wallet/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		w.Write([]byte(`{"confirmedcoinbalance":"1000"}`))
+	}), signer, []string{"/wallet", "/explorer/hashes/"})
+
+	testCases := map[string]int{
+		"/wallet":                  http.StatusOK,
+		"/wallet/missing":          http.StatusNotFound,
+		"/explorer/hashes/abcdef":  http.StatusOK,
+		"/wallets":                 0,
+		"/consensus?height=1":      0,
+		"/explorer/hashesabcdef/a": 0,
+	}
+	for uri, status := range testCases {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, uri, nil))
+		if rec.Header().Get("X-Test") != "ok" {
+			t.Errorf("%s: expected the headers of the handler to be kept", uri)
+		}
+		if status == 0 {
+			if rec.Header().Get(ResponseSignatureHeader) != "" {
+				t.Errorf("%s: expected the response not to be signed", uri)
+			}
+			continue
+		}
+		if rec.Code != status {
+			t.Errorf("%s: unexpected status %d != %d", uri, rec.Code, status)
+		}
+		body := rec.Body.Bytes()
+		if err := VerifyResponse(signer.PublicKey(), http.MethodGet, uri, rec.Code, rec.Header(), body); err != nil {
+			t.Errorf("%s: failed to verify the signed response: %v", uri, err)
+		}
+		// a response is only valid for the signed content and request
+		tampered := append([]byte(nil), body...)
+		tampered[len(tampered)-3] = '9'
+		if VerifyResponse(signer.PublicKey(), http.MethodGet, uri, rec.Code, rec.Header(), tampered) == nil {
+			t.Errorf("%s: expected the tampered response to be refused", uri)
+		}
+		if VerifyResponse(signer.PublicKey(), http.MethodGet, uri+"/other", rec.Code, rec.Header(), body) == nil {
+			t.Errorf("%s: expected the response to be refused for another request", uri)
+		}
+	}
+
+	// responses signed by another key are refused
+	other, err := LoadResponseSigner(filepath.Join(dir, "other.key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wallet", nil))
+	if VerifyResponse(other.PublicKey(), http.MethodGet, "/wallet", rec.Code, rec.Header(), rec.Body.Bytes()) == nil {
+		t.Error("expected a response signed by another key to be refused")
+	}
+}
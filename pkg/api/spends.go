@@ -0,0 +1,296 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletSpendsGET contains the spend policy of the wallet, the coins it sent within the last 24 hours,
+	// and the spends awaiting approval, as returned by a GET call to /wallet/spends.
+	WalletSpendsGET struct {
+		Policy     wallet.SpendPolicy    `json:"policy"`
+		SpentToday types.Currency        `json:"spenttoday"`
+		Pending    []wallet.PendingSpend `json:"pending"`
+	}
+
+	// WalletSpendPendingResp contains the spend awaiting approval, as returned with status 202 Accepted
+	// by the spending wallet endpoints in case the spend exceeds the approval threshold.
+	WalletSpendPendingResp struct {
+		Pending wallet.PendingSpend `json:"pending"`
+	}
+)
+
+// maxSpendRequestSize limits the size of the body of a guarded spend request.
+const maxSpendRequestSize = 1 << 20
+
+// approvedSpendKey marks the context of a spend request replayed once approved.
+type approvedSpendKey struct{}
+
+// outgoingCoinsFunc returns the coins a spend request sends to addresses other than those of the wallet.
+// The parameters are those of the path of the spending endpoint.
+type outgoingCoinsFunc func(w modules.Wallet, ps httprouter.Params, body []byte) (types.Currency, error)
+
+// SpendApprovals enforces a spend policy on the wallet endpoints sending coins
+// (/wallet/coins, /wallet/transaction, /wallet/sign, /wallet/multisig/transaction and /wallet/payouts/:name/execute)
+// and on the miner fees paid by /wallet/sign and /wallet/accelerate/:id, queueing the spends exceeding the approval threshold,
+// which are executed once approved using a second password.
+type SpendApprovals struct {
+	guard  *wallet.SpendGuard
+	wallet modules.Wallet
+
+	mu sync.Mutex
+	// handlers are the guarded handlers by path, used to execute approved spends
+	handlers map[string]httprouter.Handle
+}
+
+// NewSpendApprovals creates the spend approvals of the given wallet, enforcing the policy of the given guard.
+func NewSpendApprovals(guard *wallet.SpendGuard, w modules.Wallet) *SpendApprovals {
+	return &SpendApprovals{
+		guard:    guard,
+		wallet:   w,
+		handlers: make(map[string]httprouter.Handle),
+	}
+}
+
+// guarded wraps the handler of the given spending endpoint, enforcing the spend policy.
+// The path of the endpoint can contain named parameters, e.g. /wallet/payouts/:name/execute.
+// The handler is returned as is if the approvals are nil, such that no policy is enforced.
+func (sa *SpendApprovals) guarded(call string, outgoing outgoingCoinsFunc, handle httprouter.Handle) httprouter.Handle {
+	if sa == nil {
+		return handle
+	}
+	guarded := func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxSpendRequestSize))
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if req.URL.Query().Get("dryrun") == "true" {
+			// dry runs never send coins
+			handle(rw, req, ps)
+			return
+		}
+		amount, err := outgoing(sa.wallet, ps, body)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		approved, _ := req.Context().Value(approvedSpendKey{}).(bool)
+		release, err := sa.guard.Reserve(amount, call, approved)
+		if err == wallet.ErrApprovalRequired {
+			pending, err := sa.guard.Queue(amount, req.URL.RequestURI(), body)
+			if err != nil {
+				rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, http.StatusInternalServerError)
+				return
+			}
+			rw.Header().Set("Content-Type", "application/json; charset=utf-8")
+			rw.WriteHeader(http.StatusAccepted)
+			json.NewEncoder(rw).Encode(WalletSpendPendingResp{Pending: pending})
+			return
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if _, ok := err.(*wallet.SpendLimitError); ok {
+				status = http.StatusForbidden
+			}
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, status)
+			return
+		}
+		sw := &statusWriter{ResponseWriter: rw, status: http.StatusOK}
+		handle(sw, req, ps)
+		if sw.status < 200 || sw.status > 299 {
+			// the coins were not sent, and no longer count towards the daily limit
+			release()
+		}
+	}
+	sa.mu.Lock()
+	sa.handlers[call] = guarded
+	sa.mu.Unlock()
+	return guarded
+}
+
+// execute executes the approved spend, writing the response of the endpoint which requested it,
+// authenticated using the given API password.
+func (sa *SpendApprovals) execute(ctx context.Context, rw http.ResponseWriter, spend wallet.PendingSpend, requiredPassword string) {
+	u, err := url.ParseRequestURI(spend.Call)
+	if err != nil {
+		rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/spends: invalid call: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var (
+		handle httprouter.Handle
+		ps     httprouter.Params
+		ok     bool
+	)
+	sa.mu.Lock()
+	for call, h := range sa.handlers {
+		if ps, ok = matchCallPath(call, u.Path); ok {
+			handle = h
+			break
+		}
+	}
+	sa.mu.Unlock()
+	if !ok {
+		rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/spends: no spending endpoint " + u.Path}, http.StatusInternalServerError)
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, spend.Call, bytes.NewReader(spend.Body))
+	if err != nil {
+		rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/spends: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	if requiredPassword != "" {
+		req.SetBasicAuth("", requiredPassword)
+	}
+	handle(rw, req.WithContext(context.WithValue(ctx, approvedSpendKey{}, true)), ps)
+}
+
+// matchCallPath matches the given path against the path of a spending endpoint,
+// returning the values of its named parameters should it match.
+func matchCallPath(call, path string) (httprouter.Params, bool) {
+	callSegments, pathSegments := strings.Split(call, "/"), strings.Split(path, "/")
+	if len(callSegments) != len(pathSegments) {
+		return nil, false
+	}
+	var ps httprouter.Params
+	for i, segment := range callSegments {
+		if strings.HasPrefix(segment, ":") {
+			ps = append(ps, httprouter.Param{Key: segment[1:], Value: pathSegments[i]})
+		} else if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+	return ps, true
+}
+
+// statusWriter records the status code written to a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.WriteHeader
+func (sw *statusWriter) WriteHeader(status int) {
+	sw.status = status
+	sw.ResponseWriter.WriteHeader(status)
+}
+
+// outgoingWalletCoins returns the coins sent by a POST call to /wallet/coins.
+func outgoingWalletCoins(w modules.Wallet, _ httprouter.Params, body []byte) (types.Currency, error) {
+	var req rapi.WalletCoinsPOST
+	if err := json.Unmarshal(body, &req); err != nil {
+		return types.Currency{}, err
+	}
+	return wallet.OutgoingCoins(w, req.CoinOutputs)
+}
+
+// outgoingWalletTransaction returns the coins sent by a POST call to /wallet/transaction.
+func outgoingWalletTransaction(w modules.Wallet, _ httprouter.Params, body []byte) (types.Currency, error) {
+	var req rapi.WalletTransactionPOST
+	if err := json.Unmarshal(body, &req); err != nil {
+		return types.Currency{}, err
+	}
+	return wallet.OutgoingCoins(w, []types.CoinOutput{{Value: req.Amount, Condition: req.Condition}})
+}
+
+// outgoingSignedTransaction returns the coins sent by the transaction signed by a POST call to /wallet/sign,
+// including the miner fees it pays.
+func outgoingSignedTransaction(w modules.Wallet, _ httprouter.Params, body []byte) (types.Currency, error) {
+	var txn types.Transaction
+	if err := json.Unmarshal(body, &txn); err != nil {
+		return types.Currency{}, err
+	}
+	outgoing, err := wallet.OutgoingCoins(w, txn.CoinOutputs)
+	if err != nil {
+		return types.Currency{}, err
+	}
+	for _, fee := range txn.MinerFees {
+		outgoing = outgoing.Add(fee)
+	}
+	return outgoing, nil
+}
+
+// outgoingMultiSigTransaction returns the coins sent by the multisig transaction signed by a POST call to /wallet/multisig/transaction,
+// the coins sent back to the multisig address excluded.
+func outgoingMultiSigTransaction(w modules.Wallet, _ httprouter.Params, body []byte) (types.Currency, error) {
+	var req WalletMultiSigTransactionPOST
+	if err := json.Unmarshal(body, &req); err != nil {
+		return types.Currency{}, err
+	}
+	outputs := make([]types.CoinOutput, 0, len(req.CoinOutputs))
+	for _, co := range req.CoinOutputs {
+		if co.Condition.UnlockHash() != req.Address {
+			outputs = append(outputs, co)
+		}
+	}
+	return wallet.OutgoingCoins(w, outputs)
+}
+
+// RegisterWalletSpendsHTTPHandlers registers the handlers for the wallet spend approval HTTP endpoints.
+// Spends are approved using the approval password, which should differ from the API password,
+// such that a single party cannot request and approve a spend.
+func RegisterWalletSpendsHTTPHandlers(router rapi.Router, approvals *SpendApprovals, requiredPassword, approvalPassword string) {
+	router.GET("/wallet/spends", rapi.RequirePasswordHandler(NewWalletSpendsHandler(approvals), requiredPassword))
+	router.POST("/wallet/spends/:id/approve", rapi.RequirePasswordHandler(NewWalletApproveSpendHandler(approvals, requiredPassword), approvalPassword))
+	router.POST("/wallet/spends/:id/reject", rapi.RequirePasswordHandler(NewWalletRejectSpendHandler(approvals), requiredPassword))
+}
+
+// NewWalletSpendsHandler creates a handler to handle the API calls to /wallet/spends.
+func NewWalletSpendsHandler(approvals *SpendApprovals) httprouter.Handle {
+	return func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		rapi.WriteJSON(w, WalletSpendsGET{
+			Policy:     approvals.guard.Policy(),
+			SpentToday: approvals.guard.SpentToday(),
+			Pending:    approvals.guard.Pending(),
+		})
+	}
+}
+
+// NewWalletApproveSpendHandler creates a handler to handle the API calls to /wallet/spends/:id/approve,
+// executing the approved spend, and returning the response of the endpoint which requested it.
+// The spend is no longer pending once approved, and has to be requested again should it fail.
+func NewWalletApproveSpendHandler(approvals *SpendApprovals, requiredPassword string) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		spend, err := approvals.guard.Take(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/spends/:id/approve: " + err.Error()}, spendErrorToHTTPStatus(err))
+			return
+		}
+		approvals.execute(req.Context(), w, spend, requiredPassword)
+	}
+}
+
+// NewWalletRejectSpendHandler creates a handler to handle the API calls to /wallet/spends/:id/reject,
+// dropping the pending spend.
+func NewWalletRejectSpendHandler(approvals *SpendApprovals) httprouter.Handle {
+	return func(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+		_, err := approvals.guard.Take(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/spends/:id/reject: " + err.Error()}, spendErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteSuccess(w)
+	}
+}
+
+func spendErrorToHTTPStatus(err error) int {
+	if err == wallet.ErrUnknownPendingSpend {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testWallet is a wallet only listing its addresses, as used by the spend guard.
+type testWallet struct {
+	modules.Wallet
+	addresses []types.UnlockHash
+}
+
+func (w *testWallet) AllAddresses() ([]types.UnlockHash, error) {
+	return w.addresses, nil
+}
+
+func testUnlockHash(b byte) types.UnlockHash {
+	return types.NewPubKeyUnlockHash(types.Ed25519PublicKey(crypto.PublicKey{b}))
+}
+
+func TestWalletMultiSigTransactionGuarded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	guard, err := wallet.NewSpendGuard(wallet.SpendPolicy{
+		MaxPerTransaction: types.NewCurrency64(100),
+		ApprovalThreshold: types.NewCurrency64(50),
+	}, filepath.Join(dir, wallet.SpendsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &testWallet{addresses: []types.UnlockHash{testUnlockHash(1)}}
+	router := httprouter.New()
+	// the multisig handlers are registered without a consensus set or transaction pool,
+	// as the guard refuses or queues the spends before the transaction is created
+	RegisterWalletMultiSigHTTPHandlers(router, w, nil, nil, types.ChainConstants{}, NewSpendApprovals(guard, w), "")
+
+	multisigAddress, external := testUnlockHash(2), testUnlockHash(3)
+	post := func(outputs ...types.CoinOutput) *httptest.ResponseRecorder {
+		b, err := json.Marshal(WalletMultiSigTransactionPOST{Address: multisigAddress, CoinOutputs: outputs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wallet/multisig/transaction", bytes.NewReader(b)))
+		return rec
+	}
+
+	rec := post(types.CoinOutput{Value: types.NewCurrency64(101), Condition: types.NewCondition(types.NewUnlockHashCondition(external))})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected a spend above the per transaction limit to be refused, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	// the coins sent back to the multisig address and to the wallet are not spent
+	rec = post(
+		types.CoinOutput{Value: types.NewCurrency64(60), Condition: types.NewCondition(types.NewUnlockHashCondition(external))},
+		types.CoinOutput{Value: types.NewCurrency64(500), Condition: types.NewCondition(types.NewUnlockHashCondition(multisigAddress))},
+		types.CoinOutput{Value: types.NewCurrency64(500), Condition: types.NewCondition(types.NewUnlockHashCondition(testUnlockHash(1)))},
+	)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected a spend above the approval threshold to be queued, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp WalletSpendPendingResp
+	if err = json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Pending.Amount.Equals64(60) || resp.Pending.Call != "/wallet/multisig/transaction" {
+		t.Fatalf("unexpected pending spend: %+v", resp.Pending)
+	}
+}
+
+// testFeeTransactionPool is a transaction pool only estimating fees.
+type testFeeTransactionPool struct {
+	modules.TransactionPool
+	minimumFee types.Currency
+}
+
+func (tpool *testFeeTransactionPool) FeeEstimation() (types.Currency, types.Currency) {
+	return tpool.minimumFee, tpool.minimumFee.Mul64(2)
+}
+
+func TestWalletAccelerateGuarded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	guard, err := wallet.NewSpendGuard(wallet.SpendPolicy{
+		MaxPerTransaction: types.NewCurrency64(1000),
+		ApprovalThreshold: types.NewCurrency64(200),
+	}, filepath.Join(dir, wallet.SpendsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &testWallet{}
+	tpool := &testFeeTransactionPool{minimumFee: types.NewCurrency64(10)}
+	router := httprouter.New()
+	RegisterWalletAccelerateHTTPHandlers(router, w, tpool, types.ChainConstants{MinimumTransactionFee: types.NewCurrency64(10)}, NewSpendApprovals(guard, w), "")
+
+	post := func(fee uint64) *httptest.ResponseRecorder {
+		b, err := json.Marshal(WalletAcceleratePOST{MinerFee: types.NewCurrency64(fee)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wallet/accelerate/"+types.TransactionID{1}.String(), bytes.NewReader(b)))
+		return rec
+	}
+
+	// fees above the maximum acceleration fee are refused before the policy is consulted
+	if rec := post(501); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected a fee above the maximum acceleration fee to be refused, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	// the fee counts as a spend
+	rec := post(300)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected a fee above the approval threshold to be queued, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp WalletSpendPendingResp
+	if err = json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Pending.Amount.Equals64(300) || resp.Pending.Call != "/wallet/accelerate/"+(types.TransactionID{1}).String() {
+		t.Fatalf("unexpected pending spend: %+v", resp.Pending)
+	}
+}
+
+func TestWalletSignGuarded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	guard, err := wallet.NewSpendGuard(wallet.SpendPolicy{
+		MaxPerTransaction: types.NewCurrency64(100),
+		ApprovalThreshold: types.NewCurrency64(50),
+	}, filepath.Join(dir, wallet.SpendsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &testWallet{addresses: []types.UnlockHash{testUnlockHash(1)}}
+	var signed int
+	router := httprouter.New()
+	router.POST("/wallet/sign", NewSpendApprovals(guard, w).guarded("/wallet/sign", outgoingSignedTransaction,
+		func(rw http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+			signed++
+		}))
+
+	post := func(fees ...uint64) *httptest.ResponseRecorder {
+		txn := types.Transaction{CoinOutputs: []types.CoinOutput{
+			{Value: types.NewCurrency64(40), Condition: types.NewCondition(types.NewUnlockHashCondition(testUnlockHash(3)))},
+			{Value: types.NewCurrency64(500), Condition: types.NewCondition(types.NewUnlockHashCondition(testUnlockHash(1)))},
+		}}
+		for _, fee := range fees {
+			txn.MinerFees = append(txn.MinerFees, types.NewCurrency64(fee))
+		}
+		b, err := json.Marshal(txn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/wallet/sign", bytes.NewReader(b)))
+		return rec
+	}
+
+	// the miner fees count as a spend, together with the coins sent to other addresses
+	if rec := post(30, 31); rec.Code != http.StatusForbidden {
+		t.Fatalf("expected fees exceeding the per transaction limit to be refused, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := post(20)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected fees exceeding the approval threshold to be queued, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp WalletSpendPendingResp
+	if err = json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Pending.Amount.Equals64(60) {
+		t.Fatalf("unexpected pending spend: %+v", resp.Pending)
+	}
+	if rec = post(5); rec.Code != http.StatusOK || signed != 1 {
+		t.Fatalf("expected a spend below the approval threshold to be signed, got status %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/stakes"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// maxDifficultyRange is the maximum amount of blocks returned by a single call to /consensus/difficulty.
+const maxDifficultyRange = 1000
+
+type (
+	// ConsensusDifficultyGET contains the target and difficulty of a range of blocks,
+	// as returned by a GET call to /consensus/difficulty.
+	ConsensusDifficultyGET struct {
+		Blocks []BlockDifficulty `json:"blocks"`
+	}
+
+	// BlockDifficulty contains the target and difficulty a block had to meet,
+	// as well as the blockstakes used to create it.
+	BlockDifficulty struct {
+		Height     types.BlockHeight `json:"height"`
+		ID         types.BlockID     `json:"id"`
+		Timestamp  types.Timestamp   `json:"timestamp"`
+		Target     types.Target      `json:"target"`
+		Difficulty types.Difficulty  `json:"difficulty"`
+		// Creator is the owner of the blockstakes used to create the block,
+		// undefined for blocks without a block creating transaction (e.g. the genesis block)
+		Creator types.UnlockHash `json:"creator"`
+		// Stake is the value of the blockstake output used to create the block
+		Stake types.Currency `json:"stake"`
+	}
+
+	// ConsensusStakeDistributionGET contains the blockstake distribution at a given height,
+	// as returned by a GET call to /consensus/stakedistribution.
+	ConsensusStakeDistributionGET struct {
+		stakes.Distribution
+	}
+)
+
+// RegisterStakesHTTPHandlers registers the handlers for the difficulty and stake distribution consensus HTTP endpoints,
+// the latter only being registered in case the stake distribution plugin is given.
+func RegisterStakesHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, plugin *stakes.Plugin, constants types.ChainConstants) {
+	router.GET("/consensus/difficulty", NewConsensusDifficultyHandler(cs, constants))
+	if plugin != nil {
+		router.GET("/consensus/stakedistribution", NewConsensusStakeDistributionHandler(cs, plugin))
+	}
+}
+
+// NewConsensusDifficultyHandler creates a handler to handle the API calls to /consensus/difficulty,
+// returning the target and difficulty of all blocks within the inclusive range given by the start and (optional) end query parameters.
+// At most 1000 blocks are returned, the end of the range defaults to the current height, and is capped to it.
+func NewConsensusDifficultyHandler(cs modules.ConsensusSet, constants types.ChainConstants) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		start, end, err := parseBlockRange(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/difficulty: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if end-start >= maxDifficultyRange {
+			end = start + maxDifficultyRange - 1
+		}
+		resp := ConsensusDifficultyGET{Blocks: make([]BlockDifficulty, 0, end-start+1)}
+		for height := start; height <= end; height++ {
+			if err := req.Context().Err(); err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/difficulty: " + err.Error()}, http.StatusServiceUnavailable)
+				return
+			}
+			block, ok := cs.BlockAtHeight(height)
+			if !ok {
+				break // the chain got reorganized to a shorter one
+			}
+			target, ok := cs.ChildTarget(block.ParentID)
+			if !ok {
+				// the genesis block has no parent
+				target = constants.RootTarget()
+			}
+			bd := BlockDifficulty{
+				Height:     height,
+				ID:         block.ID(),
+				Timestamp:  block.Timestamp,
+				Target:     target,
+				Difficulty: target.Difficulty(constants.RootDepth),
+			}
+			if created, ok := staking.NewCreatedBlock(block, height); ok {
+				bd.Creator = created.Creator
+				bd.Stake = created.Stake
+			}
+			resp.Blocks = append(resp.Blocks, bd)
+		}
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// NewConsensusStakeDistributionHandler creates a handler to handle the API calls to /consensus/stakedistribution,
+// returning the blockstake distribution at the height given by the optional height query parameter,
+// which defaults to the current height.
+func NewConsensusStakeDistributionHandler(cs modules.ConsensusSet, plugin *stakes.Plugin) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		height, err := parseHeight(req, cs.Height())
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/stakedistribution: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		distribution, err := plugin.GetDistributionAt(height)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/stakedistribution: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(w, ConsensusStakeDistributionGET{Distribution: distribution})
+	}
+}
@@ -0,0 +1,60 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+type (
+	// WalletSweepsGET contains the sweep policy and the recorded sweeps, the most recent one first,
+	// as returned by a GET call to /wallet/sweeps.
+	WalletSweepsGET struct {
+		// Policy is undefined in case sweeping is disabled
+		Policy *wallet.SweepPolicy  `json:"policy,omitempty"`
+		Sweeps []wallet.SweepRecord `json:"sweeps"`
+	}
+
+	// WalletSweepPOSTResp contains the sweeps sent by a POST call to /wallet/sweep.
+	WalletSweepPOSTResp struct {
+		Sweeps []wallet.SweepRecord `json:"sweeps"`
+	}
+)
+
+// RegisterWalletSweepsHTTPHandlers registers the handlers for the wallet sweep HTTP endpoints,
+// the sweeper being nil in case sweeping is disabled.
+func RegisterWalletSweepsHTTPHandlers(router rapi.Router, sweeper *wallet.Sweeper, requiredPassword string) {
+	router.GET("/wallet/sweeps", rapi.RequirePasswordHandler(NewWalletSweepsHandler(sweeper), requiredPassword))
+	router.POST("/wallet/sweep", rapi.RequirePasswordHandler(NewWalletSweepHandler(sweeper), requiredPassword))
+}
+
+// NewWalletSweepsHandler creates a handler to handle the GET API calls to /wallet/sweeps.
+func NewWalletSweepsHandler(sweeper *wallet.Sweeper) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		if sweeper == nil {
+			rapi.WriteJSON(w, WalletSweepsGET{Sweeps: []wallet.SweepRecord{}})
+			return
+		}
+		policy := sweeper.Policy()
+		rapi.WriteJSON(w, WalletSweepsGET{Policy: &policy, Sweeps: sweeper.Sweeps()})
+	}
+}
+
+// NewWalletSweepHandler creates a handler to handle the POST API calls to /wallet/sweep,
+// sweeping the tagged addresses without waiting for the next block.
+func NewWalletSweepHandler(sweeper *wallet.Sweeper) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		if sweeper == nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/sweep: " + wallet.ErrSweepingDisabled.Error()}, http.StatusBadRequest)
+			return
+		}
+		records, err := sweeper.Sweep()
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /wallet/sweep: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(w, WalletSweepPOSTResp{Sweeps: records})
+	}
+}
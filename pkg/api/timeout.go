@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// RouteTimeouts defines the maximum duration of API requests.
+// A zero timeout disables the timeout.
+type RouteTimeouts struct {
+	// Default is the timeout of all requests for which no route timeout is defined
+	Default time.Duration
+	// Routes maps path prefixes to the timeout of all requests of which the path starts with it,
+	// the longest matching prefix is used
+	Routes map[string]time.Duration
+}
+
+// ParseRouteTimeouts parses a map of path prefixes to timeout strings.
+func ParseRouteTimeouts(routes map[string]string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration, len(routes))
+	for prefix, str := range routes {
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid route %q: has to start with a slash", prefix)
+		}
+		timeout, err := time.ParseDuration(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for route %q: %v", prefix, err)
+		}
+		timeouts[prefix] = timeout
+	}
+	return timeouts, nil
+}
+
+// Timeout returns the timeout of requests to the given path.
+func (rt RouteTimeouts) Timeout(path string) time.Duration {
+	timeout, match := rt.Default, ""
+	for prefix, routeTimeout := range rt.Routes {
+		if len(prefix) > len(match) && strings.HasPrefix(path, prefix) {
+			timeout, match = routeTimeout, prefix
+		}
+	}
+	return timeout
+}
+
+// NewTimeoutHandler wraps the given handler, cancelling the context of requests
+// which exceed the timeout of their route.
+//
+// Only the handlers looping over the consensus set stop their work once the request context is done:
+// the block ranges of the consensus endpoints (raw block stream and difficulty), wallet consolidation,
+// the wallet integrity check and the wallet scans of the consensus set (time-locked balance, address report and used seed addresses),
+// as well as the long polls and streams. The other consensus and explorer handlers, including all those of rivine's api package,
+// look up a single block, transaction or address, which cannot be interrupted, and run to completion regardless.
+// A read-only (GET, HEAD or OPTIONS) request which timed out before the handler wrote a response,
+// is therefore responded to immediately with a 503 error, discarding the late response of the handler.
+// Any other request might change state (e.g. send coins), such that it is responded to only once its handler returned,
+// as a client retrying it after a 503 error could otherwise repeat the change.
+// Responses which are already being written (e.g. streams), are never interrupted by this handler.
+func NewTimeoutHandler(handler http.Handler, timeouts RouteTimeouts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		timeout := timeouts.Timeout(req.URL.Path)
+		if timeout <= 0 {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), timeout)
+		defer cancel()
+
+		tw := &timeoutWriter{w: w, h: make(http.Header)}
+		done := make(chan struct{})
+		panicChan := make(chan interface{}, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			handler.ServeHTTP(tw, req.WithContext(ctx))
+			close(done)
+		}()
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+		case <-ctx.Done():
+			if readOnlyMethod(req.Method) && tw.timeout() {
+				rapi.WriteError(w, rapi.Error{Message: fmt.Sprintf("request timed out after %v", timeout)}, http.StatusServiceUnavailable)
+				return
+			}
+			// the response is being written already, or the request might change state,
+			// wait for the handler to stop
+			select {
+			case p := <-panicChan:
+				panic(p)
+			case <-done:
+			}
+		}
+	})
+}
+
+// readOnlyMethod returns whether requests of the given method never change the state of the daemon.
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// timeoutWriter forwards all writes to the underlying response writer,
+// until the request timed out without a response having been written.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	// h is the header map used by the handler, which is only copied to the underlying writer
+	// once a response is written, such that a late handler never races with the timeout response
+	h http.Header
+
+	mu       sync.Mutex
+	written  bool
+	timedOut bool
+}
+
+// Header implements http.ResponseWriter.Header
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// Write implements http.ResponseWriter.Write
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeaders()
+	return tw.w.Write(b)
+}
+
+// WriteHeader implements http.ResponseWriter.WriteHeader
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.written {
+		return
+	}
+	tw.writeHeaders()
+	tw.w.WriteHeader(code)
+}
+
+// writeHeaders copies the headers of the handler to the underlying writer, prior to the first write.
+func (tw *timeoutWriter) writeHeaders() {
+	if tw.written {
+		return
+	}
+	tw.written = true
+	dst := tw.w.Header()
+	for key, values := range tw.h {
+		dst[key] = values
+	}
+}
+
+// Flush implements http.Flusher.Flush
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if f, ok := tw.w.(http.Flusher); ok && !tw.timedOut {
+		f.Flush()
+	}
+}
+
+// timeout marks the request as timed out, returning false
+// in case it is too late to do so, as a response is already being written.
+func (tw *timeoutWriter) timeout() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.written {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
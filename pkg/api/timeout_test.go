@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouteTimeouts(t *testing.T) {
+	timeouts := RouteTimeouts{
+		Default: time.Minute,
+		Routes: map[string]time.Duration{
+			"/explorer":        time.Second,
+			"/explorer/hashes": 0,
+		},
+	}
+	testCases := map[string]time.Duration{
+		"/consensus":              time.Minute,
+		"/explorer/blocks/1":      time.Second,
+		"/explorer/hashes/abcdef": 0,
+	}
+	for path, expected := range testCases {
+		if timeout := timeouts.Timeout(path); timeout != expected {
+			t.Errorf("unexpected timeout for %s: %v != %v", path, timeout, expected)
+		}
+	}
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	// the slow handler does not stop when its request is cancelled
+	unblock := make(chan struct{})
+	defer close(unblock)
+	handler := NewTimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow" {
+			<-unblock
+		}
+		w.Header().Set("X-Test", "ok")
+		w.Write([]byte("done"))
+	}), RouteTimeouts{Default: 10 * time.Millisecond})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/fast", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "done" || rec.Header().Get("X-Test") != "ok" {
+		t.Errorf("unexpected fast response: %d %q", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/slow", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("X-Test") != "" {
+		t.Errorf("unexpected slow response: %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTimeoutHandlerWaitsForStateChanges(t *testing.T) {
+	// the handler ignores the cancellation of its request, as most handlers do
+	handler := NewTimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("sent"))
+	}), RouteTimeouts{Default: 10 * time.Millisecond})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/wallet/coins", nil))
+	if rec.Code != http.StatusCreated || rec.Body.String() != "sent" {
+		t.Errorf("expected the late response of the state changing request, got: %d %q", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// headers set on the response of a raw transaction call
+const (
+	headerTransactionID = "Transaction-ID"
+)
+
+// RegisterExplorerRawTransactionsHTTPHandlers registers the handlers for the raw transaction explorer HTTP endpoints.
+func RegisterExplorerRawTransactionsHTTPHandlers(router rapi.Router, explorer modules.Explorer) {
+	router.GET("/explorer/rawtransactions/:id", NewRawConfirmedTransactionHandler(explorer))
+}
+
+// RegisterTransactionPoolRawTransactionsHTTPHandlers registers the handlers for the raw transaction pool HTTP endpoints.
+func RegisterTransactionPoolRawTransactionsHTTPHandlers(router rapi.Router, tpool modules.TransactionPool) {
+	router.GET("/transactionpool/rawtransactions/:id", NewRawUnconfirmedTransactionHandler(tpool))
+}
+
+// NewRawConfirmedTransactionHandler creates a handler to handle the API calls to /explorer/rawtransactions/:id,
+// returning the confirmed transaction with the given ID in the requested encoding,
+// along with the ID and height of the block containing it as headers.
+func NewRawConfirmedTransactionHandler(explorer modules.Explorer) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		encoding, err := parseEncoding(req)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawtransactions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var hash crypto.Hash
+		err = hash.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawtransactions: invalid transaction ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		id := types.TransactionID(hash)
+		block, height, ok := explorer.Transaction(id)
+		if ok {
+			for _, txn := range block.Transactions {
+				if txn.ID() == id {
+					w.Header().Set(headerBlockID, block.ID().String())
+					w.Header().Set(headerBlockHeight, strconv.FormatUint(uint64(height), 10))
+					writeRawTransaction(w, txn, encoding)
+					return
+				}
+			}
+		}
+		rapi.WriteError(w, rapi.Error{Message: "error after call to /explorer/rawtransactions: no confirmed transaction found with ID " + id.String()}, http.StatusNotFound)
+	}
+}
+
+// NewRawUnconfirmedTransactionHandler creates a handler to handle the API calls to /transactionpool/rawtransactions/:id,
+// returning the unconfirmed transaction with the given ID in the requested encoding.
+func NewRawUnconfirmedTransactionHandler(tpool modules.TransactionPool) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		encoding, err := parseEncoding(req)
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/rawtransactions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var hash crypto.Hash
+		err = hash.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/rawtransactions: invalid transaction ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		id := types.TransactionID(hash)
+		txn, err := tpool.Transaction(id)
+		if err == modules.ErrTransactionNotFound {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/rawtransactions: no unconfirmed transaction found with ID " + id.String()}, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactionpool/rawtransactions: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		writeRawTransaction(w, txn, encoding)
+	}
+}
+
+func writeRawTransaction(w http.ResponseWriter, txn types.Transaction, encoding Encoding) {
+	w.Header().Set(headerTransactionID, txn.ID().String())
+	writeEncoded(w, txn, encoding)
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/spends"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// The statuses of a transaction reported by the transaction status endpoint only,
+// on top of those reported by the double spend endpoints.
+const (
+	// TransactionStatusConflicted is the status of a transaction which is no longer in the transaction pool,
+	// as at least one of its inputs is spent by another unconfirmed transaction (e.g. one paying a higher fee)
+	TransactionStatusConflicted = "conflicted"
+	// TransactionStatusEvicted is the status of an expiring transaction which is no longer in the transaction pool,
+	// as it expired before being confirmed
+	TransactionStatusEvicted = "evicted"
+)
+
+// TransactionStatusGET contains the status of a transaction,
+// as returned by a call to /transactions/:id/status.
+type TransactionStatusGET struct {
+	TransactionID types.TransactionID `json:"transactionid"`
+	// Status is one of confirmed, unconfirmed, doublespent, conflicted, evicted or dropped
+	Status string `json:"status"`
+	// Height and BlockID identify the block containing the transaction, in case it is confirmed
+	Height  types.BlockHeight `json:"height,omitempty"`
+	BlockID *types.BlockID    `json:"blockid,omitempty"`
+	// Confirmations is the amount of blocks confirming the transaction, including the block containing it
+	Confirmations uint64 `json:"confirmations,omitempty"`
+	// ExpirationHeight is the height of the last block an unconfirmed expiring transaction can be part of
+	ExpirationHeight types.BlockHeight `json:"expirationheight,omitempty"`
+	// Conflicts are the inputs of an unconfirmed transaction spent by other transactions,
+	// confirmed ones only if the spends plugin is loaded
+	Conflicts []spends.Spend `json:"conflicts"`
+}
+
+// RegisterTransactionStatusHTTPHandlers registers the handlers for the transaction status HTTP endpoints,
+// the explorer and spends plugin are optional.
+func RegisterTransactionStatusHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, tpool modules.TransactionPool, explorer modules.Explorer, plugin *spends.Plugin, recent *spends.RecentTransactions) {
+	router.GET("/transactions/:id/status", NewTransactionStatusHandler(cs, tpool, explorer, plugin, recent))
+}
+
+// NewTransactionStatusHandler creates a handler to handle the API calls to /transactions/:id/status,
+// returning the status of the transaction with the given ID, which has to be confirmed,
+// in the transaction pool or seen in the transaction pool recently.
+// Confirmed transactions are looked up using the explorer, or the consensus set should the explorer not be loaded.
+func NewTransactionStatusHandler(cs modules.ConsensusSet, tpool modules.TransactionPool, explorer modules.Explorer, plugin *spends.Plugin, recent *spends.RecentTransactions) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var id types.TransactionID
+		err := id.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactions/:id/status: invalid transaction ID: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		resp := TransactionStatusGET{TransactionID: id, Conflicts: []spends.Spend{}}
+		if blockID, height, ok := confirmedBlock(cs, explorer, id); ok {
+			resp.Status = TransactionStatusConfirmed
+			resp.Height = height
+			resp.BlockID = &blockID
+			resp.Confirmations = uint64(cs.Height()-height) + 1
+			rapi.WriteJSON(w, resp)
+			return
+		}
+		txn, err := tpool.Transaction(id)
+		if err == nil {
+			resp.Status = TransactionStatusUnconfirmed
+			resp.ExpirationHeight, _ = goldchaintypes.ExpirationHeight(txn)
+			rapi.WriteJSON(w, resp)
+			return
+		}
+		txn, ok := recent.Transaction(id)
+		if !ok {
+			rapi.WriteError(w, rapi.Error{Message: "error after call to /transactions/:id/status: unknown transaction " + id.String()}, http.StatusNotFound)
+			return
+		}
+
+		// the transaction disappeared from the transaction pool without being confirmed
+		expirationHeight, expiring := goldchaintypes.ExpirationHeight(txn)
+		resp.ExpirationHeight = expirationHeight
+		if plugin != nil {
+			conflicts, err := plugin.Conflicts(txn)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /transactions/:id/status: " + err.Error()}, http.StatusInternalServerError)
+				return
+			}
+			resp.Conflicts = append(resp.Conflicts, conflicts...)
+		}
+		unconfirmedConflicts := unconfirmedSpends(tpool.TransactionList(), txn)
+		switch {
+		case len(resp.Conflicts) > 0:
+			resp.Status = TransactionStatusDoubleSpent
+		case len(unconfirmedConflicts) > 0:
+			resp.Status = TransactionStatusConflicted
+		case expiring && expirationHeight <= cs.Height():
+			resp.Status = TransactionStatusEvicted
+		default:
+			resp.Status = TransactionStatusDropped
+		}
+		resp.Conflicts = append(resp.Conflicts, unconfirmedConflicts...)
+		rapi.WriteJSON(w, resp)
+	}
+}
+
+// confirmedBlock returns the ID and height of the block containing the transaction with the given ID,
+// should it be confirmed.
+func confirmedBlock(cs modules.ConsensusSet, explorer modules.Explorer, id types.TransactionID) (types.BlockID, types.BlockHeight, bool) {
+	if explorer != nil {
+		block, height, ok := explorer.Transaction(id)
+		if !ok {
+			return types.BlockID{}, 0, false
+		}
+		return block.ID(), height, true
+	}
+	height, ok := confirmedHeight(cs, id)
+	if !ok {
+		return types.BlockID{}, 0, false
+	}
+	block, ok := cs.BlockAtHeight(height)
+	if !ok {
+		return types.BlockID{}, 0, false
+	}
+	return block.ID(), height, true
+}
+
+// unconfirmedSpends returns the inputs of the given transaction spent by other transactions of the given pool.
+func unconfirmedSpends(pool []types.Transaction, txn types.Transaction) []spends.Spend {
+	id := txn.ID()
+	spent := make(map[crypto.Hash]types.TransactionID)
+	for _, other := range pool {
+		if other.ID() == id {
+			continue
+		}
+		for _, ci := range other.CoinInputs {
+			spent[crypto.Hash(ci.ParentID)] = other.ID()
+		}
+		for _, bsi := range other.BlockStakeInputs {
+			spent[crypto.Hash(bsi.ParentID)] = other.ID()
+		}
+	}
+	var conflicts []spends.Spend
+	add := func(outputID crypto.Hash, outputType string) {
+		if spentBy, ok := spent[outputID]; ok {
+			conflicts = append(conflicts, spends.Spend{
+				OutputID:    outputID,
+				OutputType:  outputType,
+				SpentBy:     spentBy,
+				Unconfirmed: true,
+			})
+		}
+	}
+	for _, ci := range txn.CoinInputs {
+		add(crypto.Hash(ci.ParentID), spends.OutputTypeCoin)
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		add(crypto.Hash(bsi.ParentID), spends.OutputTypeBlockStake)
+	}
+	return conflicts
+}
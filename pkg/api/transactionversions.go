@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ConsensusTransactionVersionsGET contains the status of all transaction versions at a height,
+// as returned by a GET call to /consensus/transactionversions.
+type ConsensusTransactionVersionsGET struct {
+	// Height is the height of the block the statuses apply to,
+	// the next block unless a height is given
+	Height   types.BlockHeight                         `json:"height"`
+	Versions []goldchaintypes.TransactionVersionStatus `json:"versions"`
+}
+
+// RegisterTransactionVersionsHTTPHandlers registers the handler for the transaction versions consensus HTTP endpoint.
+func RegisterTransactionVersionsHTTPHandlers(router rapi.Router, cs modules.ConsensusSet, registry goldchaintypes.TransactionVersionRegistry) {
+	router.GET("/consensus/transactionversions", NewConsensusTransactionVersionsHandler(cs, registry))
+}
+
+// NewConsensusTransactionVersionsHandler creates a handler to handle the GET API calls to /consensus/transactionversions,
+// returning which transaction versions are valid at the height given as the optional height query parameter.
+func NewConsensusTransactionVersionsHandler(cs modules.ConsensusSet, registry goldchaintypes.TransactionVersionRegistry) httprouter.Handle {
+	return func(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		height := cs.Height() + 1
+		if str := req.FormValue("height"); str != "" {
+			h, err := strconv.ParseUint(str, 10, 64)
+			if err != nil {
+				rapi.WriteError(w, rapi.Error{Message: "error after call to /consensus/transactionversions: invalid height: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			height = types.BlockHeight(h)
+		}
+		rapi.WriteJSON(w, ConsensusTransactionVersionsGET{
+			Height:   height,
+			Versions: registry.StatusAt(height),
+		})
+	}
+}
@@ -0,0 +1,662 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/seed"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// WalletAccelerateGET contains the unconfirmed transactions which can be accelerated,
+	// as returned by a GET call to /wallet/accelerate.
+	WalletAccelerateGET struct {
+		Transactions []wallet.AccelerableTransaction `json:"transactions"`
+	}
+
+	// WalletAcceleratePOST contains the optional miner fee of the child transaction,
+	// as given as the body of a POST call to /wallet/accelerate/:id.
+	WalletAcceleratePOST struct {
+		MinerFee types.Currency `json:"minerfee"`
+	}
+
+	// WalletDryRunPOSTResp contains the would-be transaction and its validation result,
+	// as returned by a POST call to /wallet/coins or /wallet/blockstakes with the dryrun query parameter set.
+	// An expiring transaction is built in case the expirationheight query parameter is set as well.
+	WalletDryRunPOSTResp struct {
+		wallet.DryRunResult
+	}
+
+	// WalletConsolidatePOST contains the optional consolidation properties,
+	// as given as the body of a POST call to /wallet/consolidate.
+	WalletConsolidatePOST struct {
+		wallet.ConsolidationOptions
+	}
+
+	// WalletConsolidatePOSTResp contains the IDs of the consolidation transactions,
+	// as returned by a POST call to /wallet/consolidate.
+	WalletConsolidatePOSTResp struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// WalletFsckGET contains the result of the wallet integrity check,
+	// as returned by a GET call to /wallet/fsck.
+	WalletFsckGET struct {
+		wallet.IntegrityReport
+		Consistent bool `json:"consistent"`
+	}
+
+	// WalletTimeLockedGET contains the time-locked coin outputs of the wallet and their unlock schedule,
+	// as returned by a GET call to /wallet/timelocked.
+	WalletTimeLockedGET struct {
+		wallet.LockedBalance
+	}
+
+	// WalletAddressReportGET contains the usage of the addresses of the primary seed of the wallet,
+	// as returned by a GET call to /wallet/addressreport.
+	WalletAddressReportGET struct {
+		wallet.AddressReport
+	}
+
+	// WalletSeedPassphrasePOSTResp is returned by a POST call to /wallet/init or /wallet/seed,
+	// in case a seed passphrase is given.
+	WalletSeedPassphrasePOSTResp struct {
+		// PrimarySeed is the mnemonic of the seed derived from the given seed and seed passphrase,
+		// which is the primary seed stored by the wallet, only returned by /wallet/init
+		PrimarySeed string `json:"primaryseed,omitempty"`
+		// UsedAddresses is the amount of addresses derived from the given seed and seed passphrase
+		// which ever received coins or block stakes, 0 likely indicating a wrong seed passphrase
+		UsedAddresses int `json:"usedaddresses"`
+	}
+
+	// WalletProvisionPOST contains the properties of the wallet to create,
+	// as given as the body of a POST call to /wallet/provision.
+	WalletProvisionPOST struct {
+		wallet.ProvisionOptions
+	}
+
+	// WalletProvisionPOSTResp contains the mnemonic and first addresses of the created wallet,
+	// as returned by a POST call to /wallet/provision.
+	WalletProvisionPOSTResp struct {
+		wallet.ProvisionedWallet
+	}
+
+	// WalletSignMessagePOST contains the message to sign and the address signing it,
+	// as given as the body of a POST call to /wallet/signmessage.
+	WalletSignMessagePOST struct {
+		Address types.UnlockHash `json:"address"`
+		Message string           `json:"message"`
+	}
+
+	// WalletSignMessagePOSTResp contains the signature proving control of the address,
+	// as returned by a POST call to /wallet/signmessage.
+	WalletSignMessagePOSTResp struct {
+		Signature goldchaintypes.MessageSignature `json:"signature"`
+	}
+
+	// WalletAcceleratePOSTResp contains the child transaction,
+	// as returned by a POST call to /wallet/accelerate/:id.
+	WalletAcceleratePOSTResp struct {
+		TransactionID types.TransactionID `json:"transactionid"`
+		Transaction   types.Transaction   `json:"transaction"`
+	}
+)
+
+// RegisterWalletHTTPHandlers registers the rivine wallet HTTP handlers,
+// extended with support for dry runs, expiring transactions, time-locked outputs, coin selection, seed passphrases
+// and sending from sub-accounts, as well as all goldchain-specific wallet HTTP handlers.
+// Transactions are signed by the given remote signer instead of the wallet, should one be given,
+// and coins are only sent within the spend policy of the given approvals, should they be given.
+// The funds of the given accounts are only sent from when selected explicitly, should accounts be given.
+func RegisterWalletHTTPHandlers(router rapi.Router, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants, remoteSigner *signer.Client, approvals *SpendApprovals, accounts *wallet.Accounts, requiredPassword string) {
+	extensions := map[string]func(httprouter.Handle) httprouter.Handle{
+		"/wallet/coins": func(handle httprouter.Handle) httprouter.Handle {
+			return rapi.RequirePasswordHandler(approvals.guarded("/wallet/coins", outgoingWalletCoins,
+				NewWalletCoinsHandler(w, tpool, cs, constants, accounts, handle)), requiredPassword)
+		},
+		"/wallet/transaction": func(handle httprouter.Handle) httprouter.Handle {
+			return rapi.RequirePasswordHandler(approvals.guarded("/wallet/transaction", outgoingWalletTransaction, handle), requiredPassword)
+		},
+		"/wallet/blockstakes": func(handle httprouter.Handle) httprouter.Handle {
+			return rapi.RequirePasswordHandler(NewWalletBlockStakesHandler(w, tpool, cs, constants, accounts, handle), requiredPassword)
+		},
+		"/wallet/init": func(handle httprouter.Handle) httprouter.Handle {
+			return rapi.RequirePasswordHandler(NewWalletInitHandler(w, cs, handle), requiredPassword)
+		},
+		"/wallet/seed": func(handle httprouter.Handle) httprouter.Handle {
+			return rapi.RequirePasswordHandler(NewWalletSeedHandler(w, cs, handle), requiredPassword)
+		},
+	}
+	extensions["/wallet/sign"] = func(handle httprouter.Handle) httprouter.Handle {
+		if remoteSigner != nil {
+			handle = NewRemoteSignerSignHandler(remoteSigner, cs)
+		}
+		return rapi.RequirePasswordHandler(approvals.guarded("/wallet/sign", outgoingSignedTransaction, handle), requiredPassword)
+	}
+	rapi.RegisterWalletHTTPHandlers(&extendedRouter{Router: router, extensions: extensions}, w, requiredPassword)
+	RegisterWalletAccelerateHTTPHandlers(router, w, tpool, constants, approvals, requiredPassword)
+	router.POST("/wallet/consolidate", rapi.RequirePasswordHandler(NewWalletConsolidateHandler(w, tpool, constants), requiredPassword))
+	router.POST("/wallet/delegation", rapi.RequirePasswordHandler(NewWalletDelegationHandler(w, tpool, constants), requiredPassword))
+	router.POST("/wallet/vote", rapi.RequirePasswordHandler(NewWalletVoteHandler(w, tpool, constants), requiredPassword))
+	router.GET("/wallet/fsck", rapi.RequirePasswordHandler(NewWalletFsckHandler(w, cs, tpool), requiredPassword))
+	router.GET("/wallet/timelocked", rapi.RequirePasswordHandler(NewWalletTimeLockedHandler(w, cs, tpool), requiredPassword))
+	router.GET("/wallet/addressreport", rapi.RequirePasswordHandler(NewWalletAddressReportHandler(w, cs), requiredPassword))
+	router.POST("/wallet/provision", rapi.RequirePasswordHandler(NewWalletProvisionHandler(w), requiredPassword))
+	router.POST("/wallet/signmessage", rapi.RequirePasswordHandler(NewWalletSignMessageHandler(w), requiredPassword))
+	RegisterWalletMultiSigHTTPHandlers(router, w, cs, tpool, constants, approvals, requiredPassword)
+}
+
+// extendedRouter wraps a router, such that the POST handlers of the paths for which an extension is defined,
+// are replaced by the handler created by that extension, using the original handler as fallback.
+type extendedRouter struct {
+	rapi.Router
+	extensions map[string]func(httprouter.Handle) httprouter.Handle
+}
+
+// POST implements rapi.Router.POST
+func (router *extendedRouter) POST(path string, handle httprouter.Handle) {
+	if extension, ok := router.extensions[path]; ok {
+		handle = extension(handle)
+	}
+	router.Router.POST(path, handle)
+}
+
+// walletSendQuery contains the optional query parameters of the wallet send endpoints.
+type walletSendQuery struct {
+	DryRun bool
+	// Account is the label of the account to send from, if any
+	Account string
+	Options wallet.BuildOptions
+}
+
+// parseWalletSendQuery parses the optional query parameters of the wallet send endpoints,
+// returning false in case none are given.
+func parseWalletSendQuery(req *http.Request) (walletSendQuery, bool, error) {
+	var (
+		query walletSendQuery
+		err   error
+	)
+	values := req.URL.Query()
+	if str := values.Get("dryrun"); str != "" {
+		query.DryRun, err = strconv.ParseBool(str)
+		if err != nil {
+			return walletSendQuery{}, false, fmt.Errorf("invalid dryrun query parameter: %v", err)
+		}
+	}
+	if str := values.Get("expirationheight"); str != "" {
+		height, err := strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return walletSendQuery{}, false, fmt.Errorf("invalid expirationheight query parameter: %v", err)
+		}
+		query.Options.ExpirationHeight = types.BlockHeight(height)
+	}
+	if str := values.Get("lockeduntil"); str != "" {
+		query.Options.LockedUntil, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return walletSendQuery{}, false, fmt.Errorf("invalid lockeduntil query parameter: %v", err)
+		}
+	}
+	if str := values.Get("coinselection"); str != "" {
+		query.Options.CoinSelection, err = wallet.ParseCoinSelectionStrategy(str)
+		if err != nil {
+			return walletSendQuery{}, false, fmt.Errorf("invalid coinselection query parameter: %v", err)
+		}
+	}
+	query.Options.IncludeCoinOutputs, err = parseCoinOutputIDs(values.Get("includeoutputs"))
+	if err != nil {
+		return walletSendQuery{}, false, fmt.Errorf("invalid includeoutputs query parameter: %v", err)
+	}
+	query.Options.ExcludeCoinOutputs, err = parseCoinOutputIDs(values.Get("excludeoutputs"))
+	if err != nil {
+		return walletSendQuery{}, false, fmt.Errorf("invalid excludeoutputs query parameter: %v", err)
+	}
+	query.Account = values.Get("account")
+	ok := query.DryRun || query.Account != "" || query.Options.ExpirationHeight != 0 || query.Options.LockedUntil != 0 || query.Options.CoinSelection != "" ||
+		len(query.Options.IncludeCoinOutputs) > 0 || len(query.Options.ExcludeCoinOutputs) > 0
+	return query, ok, nil
+}
+
+// parseCoinOutputIDs parses a comma-separated list of coin output IDs.
+func parseCoinOutputIDs(str string) ([]types.CoinOutputID, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var ids []types.CoinOutputID
+	for _, s := range strings.Split(str, ",") {
+		var id types.CoinOutputID
+		err := id.LoadString(strings.TrimSpace(s))
+		if err != nil {
+			return nil, fmt.Errorf("invalid coin output ID %q: %v", s, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// NewWalletCoinsHandler creates a handler to handle the API calls to /wallet/coins,
+// handling dry runs, expiring transactions, time-locked outputs, coin selection and accounts, and using the given handler for all other calls.
+// Once accounts exist, all calls are handled, such that the funds of the accounts are only sent from when selected.
+func NewWalletCoinsHandler(w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants, accounts *wallet.Accounts, fallback httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		query, ok, err := parseWalletSendQuery(req)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/coins: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !ok && (accounts == nil || accounts.Empty()) {
+			fallback(rw, req, ps)
+			return
+		}
+		var body rapi.WalletCoinsPOST
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied coin outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		query.Options.Funding, err = accountFunding(accounts, query.Account)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/coins: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		query.Options.RefundAddress, err = refundAddress(w, accounts, query.Account, body.RefundAddress, body.GenerateRefundAddress)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/coins: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		writeWalletSendResponse(rw, "/wallet/coins", query, func() (wallet.DryRunResult, error) {
+			return wallet.DryRun(w, tpool, cs, body.CoinOutputs, nil, body.Data, query.Options, constants)
+		}, func() (interface{}, error) {
+			txn, err := wallet.SendOutputs(w, tpool, body.CoinOutputs, nil, body.Data, query.Options, constants)
+			return rapi.WalletCoinsPOSTResp{TransactionID: txn.ID()}, err
+		})
+	}
+}
+
+// NewWalletBlockStakesHandler creates a handler to handle the API calls to /wallet/blockstakes,
+// handling dry runs, expiring transactions, time-locked outputs, coin selection and accounts, and using the given handler for all other calls.
+// Once accounts exist, all calls are handled, such that the funds of the accounts are only sent from when selected.
+func NewWalletBlockStakesHandler(w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, constants types.ChainConstants, accounts *wallet.Accounts, fallback httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		query, ok, err := parseWalletSendQuery(req)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/blockstakes: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !ok && (accounts == nil || accounts.Empty()) {
+			fallback(rw, req, ps)
+			return
+		}
+		var body rapi.WalletBlockStakesPOST
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied blockstake outputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		query.Options.Funding, err = accountFunding(accounts, query.Account)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/blockstakes: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		query.Options.RefundAddress, err = refundAddress(w, accounts, query.Account, body.RefundAddress, body.GenerateRefundAddress)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/blockstakes: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		writeWalletSendResponse(rw, "/wallet/blockstakes", query, func() (wallet.DryRunResult, error) {
+			return wallet.DryRun(w, tpool, cs, nil, body.BlockStakeOutputs, body.Data, query.Options, constants)
+		}, func() (interface{}, error) {
+			txn, err := wallet.SendOutputs(w, tpool, nil, body.BlockStakeOutputs, body.Data, query.Options, constants)
+			return rapi.WalletBlockStakesPOSTResp{TransactionID: txn.ID()}, err
+		})
+	}
+}
+
+// refundAddress returns the refund address to use for a send call,
+// generating a new address of the wallet or of the given account if requested and no address is given.
+func refundAddress(w modules.Wallet, accounts *wallet.Accounts, account string, address *types.UnlockHash, generate bool) (*types.UnlockHash, error) {
+	if address != nil || !generate {
+		return address, nil
+	}
+	if account != "" {
+		uh, err := accounts.NextAddress(account)
+		if err != nil {
+			return nil, err
+		}
+		return &uh, nil
+	}
+	uh, err := w.NextAddress()
+	if err != nil {
+		return nil, err
+	}
+	return &uh, nil
+}
+
+// accountFunding returns the funding filter of a send call: the addresses of the given account,
+// or the addresses of none of the accounts should no account be given.
+func accountFunding(accounts *wallet.Accounts, account string) (func(types.UnlockHash) bool, error) {
+	if accounts == nil {
+		if account != "" {
+			return nil, wallet.ErrUnknownAccount
+		}
+		return nil, nil
+	}
+	if account == "" && accounts.Empty() {
+		return nil, nil
+	}
+	return accounts.Funding(account)
+}
+
+// writeWalletSendResponse either dry runs or sends the transaction, as requested,
+// and writes the response of either call.
+func writeWalletSendResponse(rw http.ResponseWriter, call string, query walletSendQuery, dryRun func() (wallet.DryRunResult, error), send func() (interface{}, error)) {
+	if query.DryRun {
+		result, err := dryRun()
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletDryRunPOSTResp{DryRunResult: result})
+		return
+	}
+	resp, err := send()
+	if err != nil {
+		rapi.WriteError(rw, rapi.Error{Message: "error after call to " + call + ": " + err.Error()}, walletErrorToHTTPStatus(err))
+		return
+	}
+	rapi.WriteJSON(rw, resp)
+}
+
+// RegisterWalletAccelerateHTTPHandlers registers the handlers for the child-pays-for-parent wallet HTTP endpoints.
+// The miner fee paid by a child transaction counts as a spend of the spend policy, enforced should approvals be given.
+func RegisterWalletAccelerateHTTPHandlers(router rapi.Router, w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants, approvals *SpendApprovals, requiredPassword string) {
+	router.GET("/wallet/accelerate", rapi.RequirePasswordHandler(NewWalletAccelerableTransactionsHandler(w, tpool), requiredPassword))
+	router.POST("/wallet/accelerate/:id", rapi.RequirePasswordHandler(approvals.guarded("/wallet/accelerate/:id",
+		outgoingAccelerationFee(tpool, constants), NewWalletAccelerateHandler(w, tpool, constants)), requiredPassword))
+}
+
+// outgoingAccelerationFee returns the miner fee paid by a POST call to /wallet/accelerate/:id.
+func outgoingAccelerationFee(tpool modules.TransactionPool, constants types.ChainConstants) outgoingCoinsFunc {
+	return func(_ modules.Wallet, _ httprouter.Params, body []byte) (types.Currency, error) {
+		var req WalletAcceleratePOST
+		if len(bytes.TrimSpace(body)) != 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				return types.Currency{}, err
+			}
+		}
+		return wallet.AccelerationFee(tpool, req.MinerFee, constants)
+	}
+}
+
+// NewWalletAccelerableTransactionsHandler creates a handler to handle the GET API calls to /wallet/accelerate.
+func NewWalletAccelerableTransactionsHandler(w modules.Wallet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		txns, err := wallet.AccelerableTransactions(w, tpool)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/accelerate: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletAccelerateGET{Transactions: txns})
+	}
+}
+
+// NewWalletAccelerateHandler creates a handler to handle the POST API calls to /wallet/accelerate/:id.
+func NewWalletAccelerateHandler(w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		var id types.TransactionID
+		err := id.LoadString(ps.ByName("id"))
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/accelerate/$(id): " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var body WalletAcceleratePOST
+		if req.ContentLength != 0 {
+			err = json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied miner fee: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		child, err := wallet.Accelerate(w, tpool, id, body.MinerFee, constants)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/accelerate/$(id): " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletAcceleratePOSTResp{
+			TransactionID: child.ID(),
+			Transaction:   child,
+		})
+	}
+}
+
+// NewWalletConsolidateHandler creates a handler to handle the API calls to /wallet/consolidate.
+func NewWalletConsolidateHandler(w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletConsolidatePOST
+		if req.ContentLength != 0 {
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied consolidation options: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		txns, err := wallet.Consolidate(req.Context(), w, tpool, body.ConsolidationOptions, constants)
+		if err != nil {
+			msg := "error after call to /wallet/consolidate: " + err.Error()
+			if len(txns) > 0 {
+				msg = fmt.Sprintf("error after call to /wallet/consolidate: failed after submitting %d transaction(s): %v", len(txns), err)
+			}
+			rapi.WriteError(rw, rapi.Error{Message: msg}, walletErrorToHTTPStatus(err))
+			return
+		}
+		resp := WalletConsolidatePOSTResp{TransactionIDs: make([]types.TransactionID, 0, len(txns))}
+		for _, txn := range txns {
+			resp.TransactionIDs = append(resp.TransactionIDs, txn.ID())
+		}
+		rapi.WriteJSON(rw, resp)
+	}
+}
+
+// NewWalletFsckHandler creates a handler to handle the API calls to /wallet/fsck.
+func NewWalletFsckHandler(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		report, err := wallet.CheckIntegrity(req.Context(), w, cs, tpool)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/fsck: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletFsckGET{
+			IntegrityReport: report,
+			Consistent:      report.Consistent(),
+		})
+	}
+}
+
+// NewWalletTimeLockedHandler creates a handler to handle the API calls to /wallet/timelocked.
+func NewWalletTimeLockedHandler(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		balance, err := wallet.GetLockedBalance(req.Context(), w, cs, tpool)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/timelocked: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletTimeLockedGET{LockedBalance: balance})
+	}
+}
+
+// NewWalletInitHandler creates a handler to handle the API calls to /wallet/init,
+// creating the wallet using the seed derived from the given seed and seed passphrase (the BIP39 passphrase),
+// and using the given handler for all calls without seed passphrase.
+func NewWalletInitHandler(w modules.Wallet, cs modules.ConsensusSet, fallback httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		seedPassphrase := req.FormValue("seedpassphrase")
+		if seedPassphrase == "" {
+			fallback(rw, req, ps)
+			return
+		}
+		seedStr := req.FormValue("seed")
+		if seedStr == "" {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: a seed passphrase requires a seed to be given"}, http.StatusBadRequest)
+			return
+		}
+		var s modules.Seed
+		if err := s.LoadString(seedStr); err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: invalid seed given: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		derived, err := seed.WithPassphrase(s, seedPassphrase)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if passphrase := req.FormValue("passphrase"); passphrase == "" {
+			_, err = w.Init(derived)
+		} else {
+			_, err = w.Encrypt(crypto.TwofishKey(crypto.HashObject(passphrase)), derived)
+		}
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var resp WalletSeedPassphrasePOSTResp
+		resp.PrimarySeed, err = modules.NewMnemonic(derived)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		resp.UsedAddresses, err = wallet.CountUsedSeedAddresses(req.Context(), cs, derived)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/init: wallet is created, but failed to scan its addresses: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(rw, resp)
+	}
+}
+
+// NewWalletSeedHandler creates a handler to handle the API calls to /wallet/seed,
+// loading the seed derived from the given mnemonic and seed passphrase (the BIP39 passphrase),
+// and using the given handler for all calls without seed passphrase.
+func NewWalletSeedHandler(w modules.Wallet, cs modules.ConsensusSet, fallback httprouter.Handle) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		seedPassphrase := req.FormValue("seedpassphrase")
+		if seedPassphrase == "" {
+			fallback(rw, req, ps)
+			return
+		}
+		s, err := modules.InitialSeedFromMnemonic(req.FormValue("mnemonic"))
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		derived, err := seed.WithPassphrase(s, seedPassphrase)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if passphrase := req.FormValue("passphrase"); passphrase == "" {
+			err = w.LoadPlainSeed(derived)
+		} else {
+			err = w.LoadSeed(crypto.TwofishKey(crypto.HashObject(passphrase)), derived)
+		}
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/seed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		usedAddresses, err := wallet.CountUsedSeedAddresses(req.Context(), cs, derived)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error when calling /wallet/seed: seed is loaded, but failed to scan its addresses: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		rapi.WriteJSON(rw, WalletSeedPassphrasePOSTResp{UsedAddresses: usedAddresses})
+	}
+}
+
+// NewWalletProvisionHandler creates a handler to handle the API calls to /wallet/provision,
+// creating and unlocking the wallet non-interactively, from the given or a generated mnemonic.
+func NewWalletProvisionHandler(w modules.Wallet) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletProvisionPOST
+		if req.ContentLength != 0 {
+			err := json.NewDecoder(req.Body).Decode(&body)
+			if err != nil {
+				rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied provision options: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		provisioned, err := wallet.Provision(w, body.ProvisionOptions)
+		if err != nil {
+			status := http.StatusBadRequest
+			if err == wallet.ErrWalletProvisioned {
+				status = http.StatusConflict
+			}
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/provision: " + err.Error()}, status)
+			return
+		}
+		rapi.WriteJSON(rw, WalletProvisionPOSTResp{ProvisionedWallet: provisioned})
+	}
+}
+
+// NewWalletSignMessageHandler creates a handler to handle the API calls to /wallet/signmessage,
+// signing a message using the key of an address of the wallet, proving control of that address.
+func NewWalletSignMessageHandler(w modules.Wallet) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		var body WalletSignMessagePOST
+		err := json.NewDecoder(req.Body).Decode(&body)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error decoding the supplied message: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		sig, err := wallet.SignMessage(w, body.Address, body.Message)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/signmessage: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletSignMessagePOSTResp{Signature: sig})
+	}
+}
+
+// NewWalletAddressReportHandler creates a handler to handle the API calls to /wallet/addressreport.
+func NewWalletAddressReportHandler(w modules.Wallet, cs modules.ConsensusSet) httprouter.Handle {
+	return func(rw http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+		report, err := wallet.AnalyseAddresses(req.Context(), w, cs)
+		if err != nil {
+			rapi.WriteError(rw, rapi.Error{Message: "error after call to /wallet/addressreport: " + err.Error()}, walletErrorToHTTPStatus(err))
+			return
+		}
+		rapi.WriteJSON(rw, WalletAddressReportGET{AddressReport: report})
+	}
+}
+
+func walletErrorToHTTPStatus(err error) int {
+	switch err.(type) {
+	case wallet.UnavailableOutputError, wallet.InvalidPayoutTemplateError, wallet.InvalidAccelerationFeeError, wallet.InvalidConsolidationFeeError:
+		return http.StatusBadRequest
+	}
+	switch err {
+	case modules.ErrLockedWallet:
+		return http.StatusForbidden
+	case wallet.ErrNoAccelerableOutput, wallet.ErrNothingToConsolidate, wallet.ErrNothingToDelegate, wallet.ErrNothingToVoteWith, wallet.ErrConditionNotLockable,
+		wallet.ErrUnknownMultiSigAddress, wallet.ErrNoOutputs, modules.ErrLowBalance,
+		wallet.ErrInvalidContactName, wallet.ErrNilContactAddress, wallet.ErrContactExists, wallet.ErrUnknownContact,
+		wallet.ErrAddressImported, wallet.ErrAddressNotImported, wallet.ErrNilImportedAddress,
+		wallet.ErrInvalidPayoutTemplateName, wallet.ErrPayoutTemplateExists, wallet.ErrUnknownPayoutTemplate,
+		wallet.ErrAddressNotOwned, wallet.ErrInvalidAccountLabel, wallet.ErrAccountExists, wallet.ErrUnknownAccount, wallet.ErrAccountAddressesExhausted,
+		wallet.ErrInvalidAddressLabel, wallet.ErrInvalidAddressTag, wallet.ErrEmptyAddressLabel, wallet.ErrUnlabeledAddress,
+		wallet.ErrEmptyDepositReference, wallet.ErrInvalidDepositWebhook, wallet.ErrUnknownDepositAddress, wallet.ErrNothingToSweep:
+		return http.StatusBadRequest
+	case wallet.ErrConsensusChanged, context.DeadlineExceeded, context.Canceled:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,311 @@
+package authregistry
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "AuthorizedAddressRegistryPlugin"
+)
+
+var (
+	// bucketAddresses contains a bucket per address ever (de)authorized, mapping the block heights
+	// at which its authorization changed, to its authorization state after that block
+	bucketAddresses = []byte("addresses")
+)
+
+type (
+	// Plugin is a consensus set plugin, keeping track of the authorization of all addresses over time,
+	// such that the full registry of authorized addresses can be exported.
+	Plugin struct {
+		authAddressUpdateTransactionVersion types.TransactionVersion
+		storage                             modules.PluginViewStorage
+		unregisterCallback                  modules.PluginUnregisterCallback
+	}
+
+	// AddressState is the authorization state of an address at a given height.
+	AddressState struct {
+		Address    types.UnlockHash `json:"address"`
+		Authorized bool             `json:"authorized"`
+		// LastChangeHeight is the height of the last block (de)authorizing the address
+		LastChangeHeight types.BlockHeight `json:"lastchangeheight"`
+		// Changes is the amount of blocks which (de)authorized the address
+		Changes uint64 `json:"changes"`
+	}
+
+	// Registry contains the authorization state of all addresses ever (de)authorized, at a given height.
+	Registry struct {
+		Height types.BlockHeight `json:"height"`
+		// Addresses contains the authorized addresses, as well as the deauthorized ones if requested,
+		// sorted by address
+		Addresses         []AddressState `json:"addresses"`
+		AuthorizedCount   uint64         `json:"authorizedcount"`
+		DeauthorizedCount uint64         `json:"deauthorizedcount"`
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new authorized address registry plugin,
+// for the chain using the given auth address update transaction version.
+func NewPlugin(authAddressUpdateTransactionVersion types.TransactionVersion) *Plugin {
+	return &Plugin{authAddressUpdateTransactionVersion: authAddressUpdateTransactionVersion}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		_, err := bucket.CreateBucketIfNotExists(bucketAddresses)
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", bucketAddresses, err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies the auth address update transactions of the block.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction applies the transaction if it is an auth address update transaction,
+// storing the authorization state of all addresses it (de)authorizes at the given height.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if txn.Version != p.authAddressUpdateTransactionVersion {
+		return nil
+	}
+	aautx, err := authcointx.AuthAddressUpdateTransactionFromTransaction(txn, p.authAddressUpdateTransactionVersion)
+	if err != nil {
+		return fmt.Errorf("failed to unpack auth address update transaction: %v", err)
+	}
+	addressesBucket, err := bucket.Bucket(bucketAddresses)
+	if err != nil {
+		return errors.New("addresses bucket does not exist")
+	}
+	for _, update := range []struct {
+		addresses  []types.UnlockHash
+		authorized bool
+	}{
+		{aautx.AuthAddresses, true},
+		{aautx.DeauthAddresses, false},
+	} {
+		for _, uh := range update.addresses {
+			addressBucket, err := addressesBucket.CreateBucketIfNotExists(rivbin.Marshal(uh))
+			if err == bolt.ErrTxNotWritable {
+				return pluginstats.ErrCatchUpUnsupported
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create bucket for address %s: %v", uh.String(), err)
+			}
+			err = addressBucket.Put(encodeBlockHeight(height), rivbin.Marshal(update.authorized))
+			if err == bolt.ErrTxNotWritable {
+				return pluginstats.ErrCatchUpUnsupported
+			}
+			if err != nil {
+				return fmt.Errorf("failed to store authorization of address %s at height %d: %v", uh.String(), height, err)
+			}
+		}
+	}
+	return nil
+}
+
+// RevertBlock reverts the auth address update transactions of the block.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		err := p.RevertTransaction(block.Transactions[i], block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertTransaction reverts the transaction if it is an auth address update transaction,
+// by deleting the authorization state of all addresses it (de)authorizes at the given height.
+// Addresses which were never (de)authorized before are removed from the registry.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if txn.Version != p.authAddressUpdateTransactionVersion {
+		return nil
+	}
+	aautx, err := authcointx.AuthAddressUpdateTransactionFromTransaction(txn, p.authAddressUpdateTransactionVersion)
+	if err != nil {
+		return fmt.Errorf("failed to unpack auth address update transaction: %v", err)
+	}
+	addressesBucket, err := bucket.Bucket(bucketAddresses)
+	if err != nil {
+		return errors.New("addresses bucket does not exist")
+	}
+	for _, addresses := range [][]types.UnlockHash{aautx.AuthAddresses, aautx.DeauthAddresses} {
+		for _, uh := range addresses {
+			key := rivbin.Marshal(uh)
+			addressBucket := addressesBucket.Bucket(key)
+			if addressBucket == nil {
+				continue
+			}
+			err = addressBucket.Delete(encodeBlockHeight(height))
+			if err != nil {
+				return fmt.Errorf("failed to delete authorization of address %s at height %d: %v", uh.String(), height, err)
+			}
+			if k, _ := addressBucket.Cursor().First(); k == nil {
+				err = addressesBucket.DeleteBucket(key)
+				if err != nil {
+					return fmt.Errorf("failed to delete bucket of address %s: %v", uh.String(), err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetRegistryAt returns the registry of authorized addresses as it was after the block at the given height,
+// including the addresses deauthorized at that height should includeDeauthorized be true.
+func (p *Plugin) GetRegistryAt(height types.BlockHeight, includeDeauthorized bool) (Registry, error) {
+	registry := Registry{Height: height}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		addressesBucket := bucket.Bucket(bucketAddresses)
+		if addressesBucket == nil {
+			return errors.New("addresses bucket does not exist")
+		}
+		return addressesBucket.ForEach(func(k, _ []byte) error {
+			addressBucket := addressesBucket.Bucket(k)
+			if addressBucket == nil {
+				return nil
+			}
+			state, ok, err := stateAt(addressBucket, height)
+			if err != nil || !ok {
+				return err // not (de)authorized yet at the given height
+			}
+			if state.Authorized {
+				registry.AuthorizedCount++
+			} else {
+				registry.DeauthorizedCount++
+				if !includeDeauthorized {
+					return nil
+				}
+			}
+			err = rivbin.Unmarshal(k, &state.Address)
+			if err != nil {
+				return fmt.Errorf("failed to decode address: %v", err)
+			}
+			registry.Addresses = append(registry.Addresses, state)
+			return nil
+		})
+	})
+	if err != nil {
+		return Registry{}, err
+	}
+	sort.Slice(registry.Addresses, func(i, j int) bool {
+		return registry.Addresses[i].Address.Cmp(registry.Addresses[j].Address) < 0
+	})
+	return registry, nil
+}
+
+// WriteCSV writes the addresses of the registry as CSV, one line per address.
+func (registry Registry) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"address", "authorized", "lastchangeheight", "changes"})
+	for _, state := range registry.Addresses {
+		cw.Write([]string{
+			state.Address.String(),
+			strconv.FormatBool(state.Authorized),
+			strconv.FormatUint(uint64(state.LastChangeHeight), 10),
+			strconv.FormatUint(state.Changes, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// stateAt returns the authorization state stored in the given address bucket,
+// as it was after the block at the given height, the address not being set
+// in the returned state. False is returned if the address wasn't (de)authorized yet at that height.
+func stateAt(addressBucket *bolt.Bucket, height types.BlockHeight) (AddressState, bool, error) {
+	var state AddressState
+	cursor := addressBucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		changeHeight := decodeBlockHeight(k)
+		if changeHeight > height {
+			break
+		}
+		err := rivbin.Unmarshal(v, &state.Authorized)
+		if err != nil {
+			return AddressState{}, false, fmt.Errorf("failed to decode authorization state: %v", err)
+		}
+		state.LastChangeHeight = changeHeight
+		state.Changes++
+	}
+	return state, state.Changes > 0, nil
+}
+
+func encodeBlockHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
+
+func decodeBlockHeight(b []byte) types.BlockHeight {
+	return types.BlockHeight(binary.BigEndian.Uint64(b))
+}
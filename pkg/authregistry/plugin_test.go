@@ -0,0 +1,100 @@
+package authregistry
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	gtypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginRegistry(t *testing.T) {
+	db := plugintest.NewDB(t, "authregistry")
+
+	p := NewPlugin(gtypes.TransactionVersionAuthAddressUpdateTx)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	registryAt := func(height types.BlockHeight, includeDeauthorized bool) Registry {
+		t.Helper()
+		registry, err := p.GetRegistryAt(height, includeDeauthorized)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return registry
+	}
+	authBlock := func(auth, deauth []types.UnlockHash) types.Block {
+		aautx := authcointx.AuthAddressUpdateTransaction{
+			AuthAddresses:   auth,
+			DeauthAddresses: deauth,
+		}
+		return types.Block{Transactions: []types.Transaction{
+			{Version: types.TransactionVersionOne},
+			aautx.Transaction(gtypes.TransactionVersionAuthAddressUpdateTx),
+		}}
+	}
+
+	uhA := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uhB := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	block1 := authBlock([]types.UnlockHash{uhB, uhA}, nil)
+	block2 := authBlock(nil, []types.UnlockHash{uhB})
+
+	// blocks replayed using a read-only transaction cannot be applied
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block1, 1, bucket)
+	})
+	if err != pluginstats.ErrCatchUpUnsupported {
+		t.Fatalf("expected %v, got %v", pluginstats.ErrCatchUpUnsupported, err)
+	}
+
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block1, 1, bucket)
+	})
+	// new blocks are applied transaction per transaction
+	update(func(bucket *persist.LazyBoltBucket) error {
+		for _, txn := range block2.Transactions {
+			if err := p.ApplyTransaction(txn, block2, 2, bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if registry := registryAt(0, true); len(registry.Addresses) != 0 || registry.AuthorizedCount != 0 || registry.DeauthorizedCount != 0 {
+		t.Errorf("unexpected registry at height 0: %+v", registry)
+	}
+	registry := registryAt(1, false)
+	if len(registry.Addresses) != 2 || registry.AuthorizedCount != 2 ||
+		registry.Addresses[0].Address.Cmp(uhA) != 0 || registry.Addresses[1].Address.Cmp(uhB) != 0 {
+		t.Errorf("unexpected registry at height 1: %+v", registry)
+	}
+	registry = registryAt(2, false)
+	if len(registry.Addresses) != 1 || registry.AuthorizedCount != 1 || registry.DeauthorizedCount != 1 ||
+		registry.Addresses[0] != (AddressState{Address: uhA, Authorized: true, LastChangeHeight: 1, Changes: 1}) {
+		t.Errorf("unexpected registry at height 2: %+v", registry)
+	}
+	registry = registryAt(2, true)
+	if len(registry.Addresses) != 2 ||
+		registry.Addresses[1] != (AddressState{Address: uhB, Authorized: false, LastChangeHeight: 2, Changes: 2}) {
+		t.Errorf("unexpected registry at height 2, including deauthorized addresses: %+v", registry)
+	}
+
+	// reverting removes addresses which were never (de)authorized before
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block2, 2, bucket)
+	})
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block1, 1, bucket)
+	})
+	if registry := registryAt(2, true); len(registry.Addresses) != 0 || registry.AuthorizedCount != 0 || registry.DeauthorizedCount != 0 {
+		t.Errorf("unexpected registry after reverting all blocks: %+v", registry)
+	}
+}
@@ -0,0 +1,55 @@
+package blockstream
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/rivine/modules"
+)
+
+// ImportResult summarizes the blocks imported from a block stream.
+type ImportResult struct {
+	// Imported is the amount of blocks accepted by the consensus set
+	Imported int
+	// Known is the amount of blocks skipped as the consensus set contained them already
+	Known int
+}
+
+// Import reads all blocks of the given stream, and submits them in order to the consensus set,
+// which validates them in full. The genesis block of the stream, if included, has to be the one of the consensus set.
+// The import stops at the first invalid block, returning the blocks imported until then.
+func Import(cs modules.ConsensusSet, r io.Reader, blockSizeLimit uint64) (ImportResult, error) {
+	var result ImportResult
+	genesis, _ := cs.BlockAtHeight(0)
+	stream := NewReader(r, blockSizeLimit)
+	for {
+		frame, err := stream.Next()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		block, err := frame.Block()
+		if err != nil {
+			return result, fmt.Errorf("failed to decode block at height %d: %v", frame.Height, err)
+		}
+		if frame.Height == 0 {
+			if block.ID() != genesis.ID() {
+				return result, fmt.Errorf("genesis block %s belongs to another blockchain than genesis block %s",
+					block.ID().String(), genesis.ID().String())
+			}
+			result.Known++
+			continue
+		}
+		err = cs.AcceptBlock(block)
+		switch err {
+		case nil, modules.ErrNonExtendingBlock:
+			result.Imported++
+		case modules.ErrBlockKnown:
+			result.Known++
+		default:
+			return result, fmt.Errorf("invalid block %s at height %d: %v", block.ID().String(), frame.Height, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Package blockstream defines the binary format used to stream
+// a range of raw blocks, as served by the /consensus/rawblocks endpoint.
+//
+// A stream is a sequence of frames, one per block, in ascending height order.
+// Each frame is encoded as:
+//
+//	height   uint64, little endian
+//	length   uint64, little endian, the length of the raw block
+//	block    the raw (siabin-encoded) block
+//	checksum the 32-byte blake2b hash of the raw block
+//
+// The stream ends when the last frame has been written,
+// a stream which ends in the middle of a frame is incomplete.
+package blockstream
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ContentType is the HTTP content type of a block stream.
+const ContentType = "application/vnd.goldchain.blockstream"
+
+var (
+	// ErrChecksumMismatch is returned in case the checksum of a frame
+	// does not match the raw block it contains.
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// Writer writes blocks as frames to an underlying writer.
+type Writer struct {
+	w io.Writer
+}
+
+// NewWriter creates a new block stream writer.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// WriteBlock encodes the block and writes it as a single frame.
+func (w *Writer) WriteBlock(height types.BlockHeight, block types.Block) error {
+	return w.WriteRawBlock(height, siabin.Marshal(block))
+}
+
+// WriteRawBlock writes an already encoded block as a single frame.
+func (w *Writer) WriteRawBlock(height types.BlockHeight, raw []byte) error {
+	var header [16]byte
+	binary.LittleEndian.PutUint64(header[:8], uint64(height))
+	binary.LittleEndian.PutUint64(header[8:], uint64(len(raw)))
+	checksum := crypto.HashBytes(raw)
+	for _, b := range [][]byte{header[:], raw, checksum[:]} {
+		if _, err := w.w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Frame is a single block of a block stream.
+type Frame struct {
+	Height types.BlockHeight
+	Raw    []byte
+}
+
+// Block decodes the raw block of the frame.
+func (f Frame) Block() (types.Block, error) {
+	var block types.Block
+	err := siabin.Unmarshal(f.Raw, &block)
+	return block, err
+}
+
+// Reader reads and verifies the frames of a block stream.
+type Reader struct {
+	r              io.Reader
+	blockSizeLimit uint64
+}
+
+// NewReader creates a new block stream reader,
+// refusing any frame containing a block larger than the given limit.
+func NewReader(r io.Reader, blockSizeLimit uint64) *Reader {
+	return &Reader{r: r, blockSizeLimit: blockSizeLimit}
+}
+
+// Next reads the next frame, verifying its checksum.
+// io.EOF is returned once the stream has ended.
+func (r *Reader) Next() (Frame, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Frame{}, fmt.Errorf("incomplete frame header: %v", err)
+		}
+		return Frame{}, err
+	}
+	frame := Frame{Height: types.BlockHeight(binary.LittleEndian.Uint64(header[:8]))}
+	length := binary.LittleEndian.Uint64(header[8:])
+	if length > r.blockSizeLimit {
+		return Frame{}, fmt.Errorf("block at height %d is %d bytes, exceeding the limit of %d bytes",
+			frame.Height, length, r.blockSizeLimit)
+	}
+	frame.Raw = make([]byte, length)
+	var checksum crypto.Hash
+	for _, b := range [][]byte{frame.Raw, checksum[:]} {
+		if _, err := io.ReadFull(r.r, b); err != nil {
+			return Frame{}, fmt.Errorf("incomplete frame for block at height %d: %v", frame.Height, err)
+		}
+	}
+	if crypto.HashBytes(frame.Raw) != checksum {
+		return Frame{}, fmt.Errorf("block at height %d: %v", frame.Height, ErrChecksumMismatch)
+	}
+	return frame, nil
+}
@@ -0,0 +1,107 @@
+package blockstream
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestStreamRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for height := types.BlockHeight(0); height < 3; height++ {
+		err := w.WriteBlock(height, types.Block{Timestamp: types.Timestamp(height)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw := buf.Bytes()
+
+	r := NewReader(bytes.NewReader(raw), 1<<20)
+	for height := types.BlockHeight(0); height < 3; height++ {
+		frame, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		block, err := frame.Block()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if frame.Height != height || block.Timestamp != types.Timestamp(height) {
+			t.Fatalf("unexpected frame at height %d: height %d, timestamp %d", height, frame.Height, block.Timestamp)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got: %v", err)
+	}
+
+	// corrupt the first raw block
+	raw[16] ^= 0xff
+	if _, err := NewReader(bytes.NewReader(raw), 1<<20).Next(); err == nil {
+		t.Fatal("expected checksum error")
+	}
+}
+
+// acceptingConsensusSet accepts blocks with a timestamp below the invalid one.
+type acceptingConsensusSet struct {
+	modules.ConsensusSet
+	genesis  types.Block
+	accepted map[types.BlockID]bool
+	invalid  types.Timestamp
+}
+
+func (cs *acceptingConsensusSet) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	return cs.genesis, height == 0
+}
+
+func (cs *acceptingConsensusSet) AcceptBlock(block types.Block) error {
+	if block.Timestamp >= cs.invalid {
+		return errors.New("invalid block")
+	}
+	if cs.accepted[block.ID()] {
+		return modules.ErrBlockKnown
+	}
+	cs.accepted[block.ID()] = true
+	return nil
+}
+
+func TestImport(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	for height := types.BlockHeight(0); height < 4; height++ {
+		err := w.WriteBlock(height, types.Block{Timestamp: types.Timestamp(height)})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	cs := &acceptingConsensusSet{
+		genesis:  types.Block{Timestamp: 0},
+		accepted: map[types.BlockID]bool{},
+		invalid:  3,
+	}
+	result, err := Import(cs, bytes.NewReader(buf.Bytes()), 1<<20)
+	if err == nil {
+		t.Fatal("expected the invalid block to stop the import")
+	}
+	if result.Imported != 2 || result.Known != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	cs.invalid = 4
+	result, err = Import(cs, bytes.NewReader(buf.Bytes()), 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Imported != 1 || result.Known != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	cs.genesis = types.Block{Timestamp: 42}
+	if _, err = Import(cs, bytes.NewReader(buf.Bytes()), 1<<20); err == nil {
+		t.Fatal("expected a stream of another blockchain to be refused")
+	}
+}
@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"sync"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ConsensusSet wraps a consensus set, caching the blocks and unspent outputs
+// it returns, such that repeated lookups (e.g. by the explorer endpoints) do not hit the database.
+// Cached values are invalidated as consensus changes get applied.
+//
+// Only the lookups of the API go through this cache. Transactions are validated by the vendored rivine consensus module,
+// which reads the outputs they spend directly from its database, within the database transaction of the validation,
+// such that these reads cannot be cached outside of that module.
+type ConsensusSet struct {
+	modules.ConsensusSet
+
+	blocks      *LRU
+	coinOutputs *LRU
+	bsOutputs   *LRU
+
+	// generation is incremented for every consensus change,
+	// so values read from the database during a change are not cached
+	mu         sync.RWMutex
+	generation uint64
+}
+
+var _ modules.ConsensusSet = (*ConsensusSet)(nil)
+
+// NewConsensusSet creates a caching consensus set, wrapping the given consensus set,
+// caching up to size entries for each kind of cached value.
+func NewConsensusSet(cs modules.ConsensusSet, size int, cancel <-chan struct{}) (*ConsensusSet, error) {
+	ccs := &ConsensusSet{
+		ConsensusSet: cs,
+		blocks:       NewLRU(size),
+		coinOutputs:  NewLRU(size),
+		bsOutputs:    NewLRU(size),
+	}
+	err := cs.ConsensusSetSubscribe(ccs, modules.ConsensusChangeRecent, cancel)
+	if err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+// BlockAtHeight implements modules.ConsensusSet.BlockAtHeight
+func (ccs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	if block, ok := ccs.blocks.Get(height); ok {
+		return block.(types.Block), true
+	}
+	generation := ccs.currentGeneration()
+	block, ok := ccs.ConsensusSet.BlockAtHeight(height)
+	if ok {
+		ccs.addIfGeneration(generation, ccs.blocks, height, block)
+	}
+	return block, ok
+}
+
+// GetCoinOutput implements modules.ConsensusSet.GetCoinOutput
+func (ccs *ConsensusSet) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	if co, ok := ccs.coinOutputs.Get(id); ok {
+		return co.(types.CoinOutput), nil
+	}
+	generation := ccs.currentGeneration()
+	co, err := ccs.ConsensusSet.GetCoinOutput(id)
+	if err == nil {
+		ccs.addIfGeneration(generation, ccs.coinOutputs, id, co)
+	}
+	return co, err
+}
+
+// GetBlockStakeOutput implements modules.ConsensusSet.GetBlockStakeOutput
+func (ccs *ConsensusSet) GetBlockStakeOutput(id types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	if bso, ok := ccs.bsOutputs.Get(id); ok {
+		return bso.(types.BlockStakeOutput), nil
+	}
+	generation := ccs.currentGeneration()
+	bso, err := ccs.ConsensusSet.GetBlockStakeOutput(id)
+	if err == nil {
+		ccs.addIfGeneration(generation, ccs.bsOutputs, id, bso)
+	}
+	return bso, err
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// invalidating all cached values affected by the change.
+func (ccs *ConsensusSet) ProcessConsensusChange(cc modules.ConsensusChange) {
+	ccs.mu.Lock()
+	defer ccs.mu.Unlock()
+	ccs.generation++
+	if len(cc.RevertedBlocks) > 0 {
+		// block heights are no longer guaranteed to map to the same blocks
+		ccs.blocks.Purge()
+	}
+	for _, diff := range cc.CoinOutputDiffs {
+		ccs.coinOutputs.Remove(diff.ID)
+	}
+	for _, diff := range cc.BlockStakeOutputDiffs {
+		ccs.bsOutputs.Remove(diff.ID)
+	}
+}
+
+// CacheStats contains the usage statistics of all caches of a ConsensusSet.
+type CacheStats struct {
+	Blocks            Stats `json:"blocks"`
+	CoinOutputs       Stats `json:"coinoutputs"`
+	BlockStakeOutputs Stats `json:"blockstakeoutputs"`
+}
+
+// Stats returns the usage statistics of all caches.
+func (ccs *ConsensusSet) Stats() CacheStats {
+	return CacheStats{
+		Blocks:            ccs.blocks.Stats(),
+		CoinOutputs:       ccs.coinOutputs.Stats(),
+		BlockStakeOutputs: ccs.bsOutputs.Stats(),
+	}
+}
+
+func (ccs *ConsensusSet) currentGeneration() uint64 {
+	ccs.mu.RLock()
+	defer ccs.mu.RUnlock()
+	return ccs.generation
+}
+
+func (ccs *ConsensusSet) addIfGeneration(generation uint64, c *LRU, key, value interface{}) {
+	ccs.mu.RLock()
+	defer ccs.mu.RUnlock()
+	if ccs.generation == generation {
+		c.Add(key, value)
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// LRU is a thread-safe fixed-size cache, evicting the least recently used entry
+// when a new entry is added to a full cache.
+type LRU struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[interface{}]*list.Element
+	order   *list.List
+
+	hits, misses uint64
+}
+
+type lruEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+// NewLRU creates a new LRU cache, which can contain up to size entries.
+func NewLRU(size int) *LRU {
+	return &LRU{
+		size:    size,
+		entries: make(map[interface{}]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// Get the value for the given key, returning false if it isn't cached.
+func (c *LRU) Get(key interface{}) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry).value, true
+}
+
+// Add a value for the given key, overwriting any existing value.
+func (c *LRU) Add(key, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry).value = value
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value})
+	if c.order.Len() > c.size {
+		el := c.order.Back()
+		c.order.Remove(el)
+		delete(c.entries, el.Value.(*lruEntry).key)
+	}
+}
+
+// Remove the value for the given key, if it is cached.
+func (c *LRU) Remove(key interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.Remove(el)
+		delete(c.entries, key)
+	}
+}
+
+// Purge all cached values.
+func (c *LRU) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[interface{}]*list.Element, c.size)
+	c.order.Init()
+}
+
+// Stats contains the usage statistics of a cache.
+type Stats struct {
+	Size    int    `json:"size"`
+	Entries int    `json:"entries"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+}
+
+// Stats returns the usage statistics of this cache.
+func (c *LRU) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Size:    c.size,
+		Entries: c.order.Len(),
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+}
@@ -0,0 +1,31 @@
+package cache
+
+import "testing"
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU(2)
+	c.Add(1, "a")
+	c.Add(2, "b")
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("expected key 1 to be cached")
+	}
+	// key 2 is now the least recently used
+	c.Add(3, "c")
+	if _, ok := c.Get(2); ok {
+		t.Fatal("expected key 2 to be evicted")
+	}
+	if v, ok := c.Get(1); !ok || v.(string) != "a" {
+		t.Fatalf("unexpected value for key 1: %v (%v)", v, ok)
+	}
+	if v, ok := c.Get(3); !ok || v.(string) != "c" {
+		t.Fatalf("unexpected value for key 3: %v (%v)", v, ok)
+	}
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Hits != 3 || stats.Misses != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	c.Purge()
+	if _, ok := c.Get(1); ok {
+		t.Fatal("expected cache to be empty after purge")
+	}
+}
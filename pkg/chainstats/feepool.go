@@ -0,0 +1,154 @@
+package chainstats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Period is the length of the (UTC) periods by which the fee pool inflow is aggregated.
+type Period string
+
+// The supported periods, weeks starting on monday.
+const (
+	PeriodDay   Period = "day"
+	PeriodWeek  Period = "week"
+	PeriodMonth Period = "month"
+)
+
+// ParsePeriod parses a period, as named by one of the Period constants.
+func ParsePeriod(str string) (Period, error) {
+	switch period := Period(str); period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return period, nil
+	default:
+		return "", fmt.Errorf("unknown period %q, expected one of %s, %s or %s", str, PeriodDay, PeriodWeek, PeriodMonth)
+	}
+}
+
+// start returns the start of the period containing the given time.
+func (p Period) start(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case PeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case PeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// next returns the start of the period following the one starting at the given time.
+func (p Period) next(start time.Time) time.Time {
+	switch p {
+	case PeriodWeek:
+		return start.AddDate(0, 0, 7)
+	case PeriodMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+type (
+	// FeePoolPeriod contains the fees which flowed to the transaction fee pool during a period.
+	FeePoolPeriod struct {
+		// Start and End are the timestamps at which the period starts and ends, the end being exclusive
+		Start  types.Timestamp `json:"start"`
+		End    types.Timestamp `json:"end"`
+		Blocks uint64          `json:"blocks"`
+		// Inflow is the part of the fees paid to the transaction fee pool, Fees being all fees paid
+		Inflow types.Currency `json:"inflow"`
+		Fees   types.Currency `json:"fees"`
+		// LastHeight is the height of the last block of the period, zero if it has no blocks
+		LastHeight types.BlockHeight `json:"lastheight"`
+	}
+
+	// FeePoolInflow contains the fees which flowed to the transaction fee pool,
+	// in total and for its most recent periods.
+	FeePoolInflow struct {
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		Period    Period            `json:"period"`
+		// Total is the inflow since the genesis block
+		Total types.Currency `json:"total"`
+		// Periods contains the most recent periods, most recent period first,
+		// the first period being the one which is still ongoing
+		Periods []FeePoolPeriod `json:"periods"`
+	}
+)
+
+// GetFeePoolInflow returns the fees which flowed to the transaction fee pool,
+// for the given amount of most recent periods as well as in total.
+// The periods are relative to the timestamp of the last block rather than the local clock,
+// periods without blocks being included as well.
+func (p *Plugin) GetFeePoolInflow(period Period, periods uint64) (FeePoolInflow, error) {
+	inflow := FeePoolInflow{Period: period}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		timestampsBucket := bucket.Bucket(bucketTimestamps)
+		daysBucket := bucket.Bucket(bucketDays)
+		if timestampsBucket == nil || daysBucket == nil {
+			return errors.New("chain statistics buckets do not exist")
+		}
+		k, v := timestampsBucket.Cursor().Last()
+		if k == nil {
+			return errors.New("no blocks applied")
+		}
+		inflow.Height = types.BlockHeight(binary.BigEndian.Uint64(k))
+		inflow.Timestamp = types.Timestamp(binary.BigEndian.Uint64(v))
+
+		// periods are ordered from the most recent one to the oldest one
+		starts := make([]time.Time, 0, periods)
+		for start := period.start(time.Unix(int64(inflow.Timestamp), 0)); uint64(len(starts)) < periods && start.Unix() >= 0; {
+			starts = append(starts, start)
+			inflow.Periods = append(inflow.Periods, FeePoolPeriod{
+				Start: types.Timestamp(start.Unix()),
+				End:   types.Timestamp(period.next(start).Unix()),
+			})
+			start = period.start(start.Add(-time.Second))
+		}
+
+		return daysBucket.ForEach(func(k, v []byte) error {
+			day := binary.BigEndian.Uint64(k)
+			var record dayRecord
+			err := rivbin.Unmarshal(v, &record)
+			if err != nil {
+				return fmt.Errorf("failed to decode statistics of day %d: %v", day, err)
+			}
+			inflow.Total = inflow.Total.Add(record.FeePool)
+			if len(starts) == 0 {
+				return nil
+			}
+			index := -1
+			start := period.start(time.Unix(int64(day*secondsPerDay), 0))
+			for i := range starts {
+				if starts[i].Equal(start) {
+					index = i
+					break
+				}
+			}
+			if index < 0 {
+				return nil
+			}
+			fp := &inflow.Periods[index]
+			fp.Blocks += record.Blocks
+			fp.Inflow = fp.Inflow.Add(record.FeePool)
+			fp.Fees = fp.Fees.Add(record.Fees)
+			if record.LastHeight > fp.LastHeight {
+				fp.LastHeight = record.LastHeight
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return FeePoolInflow{}, err
+	}
+	return inflow, nil
+}
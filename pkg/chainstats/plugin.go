@@ -0,0 +1,434 @@
+package chainstats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "ChainStatsPlugin"
+
+	// secondsPerDay is the length of the (UTC) days by which the statistics are aggregated
+	secondsPerDay = 24 * 60 * 60
+)
+
+var (
+	// bucketTimestamps maps the height of all applied blocks to their timestamp,
+	// such that the time between a block and its parent is known
+	bucketTimestamps = []byte("timestamps")
+	// bucketDays maps the (UTC) days to the statistics of the blocks with a timestamp within that day
+	bucketDays = []byte("days")
+	// bucketAddresses contains a bucket per day, mapping the addresses active during that day
+	// to the amount of transactions they were active in
+	bucketAddresses = []byte("addresses")
+)
+
+type (
+	// Plugin is a consensus set plugin, maintaining statistics of the blockchain per (UTC) day,
+	// such that rolling metrics can be computed without scanning the blockchain.
+	Plugin struct {
+		genesis            types.Block
+		feePool            types.UnlockHash
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// dayRecord is the stored form of the statistics of a day.
+	dayRecord struct {
+		Blocks       uint64
+		Transactions uint64
+		Addresses    uint64
+		// BlockTime is the sum of the time between each block and its parent,
+		// Intervals being the amount of blocks it sums
+		BlockTime  uint64
+		Intervals  uint64
+		Fees       types.Currency
+		FeePool    types.Currency
+		LastHeight types.BlockHeight
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new chain statistics plugin, for the chain starting with the given genesis block,
+// counting the miner payouts to the given fee pool address as fees flowing to the fee pool.
+func NewPlugin(genesis types.Block, feePool types.UnlockHash) *Plugin {
+	return &Plugin{genesis: genesis, feePool: feePool}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time,
+// applying the genesis block while the bucket is still writable.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketTimestamps, bucketDays, bucketAddresses} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		err := p.applyBlock(p.genesis, 0, persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+			return bucket, nil
+		}))
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to apply genesis block: %v", err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies the statistics of the block and all its transactions,
+// except for the genesis block, which is applied when the plugin is initialized.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	return p.applyBlock(block, height, bucket)
+}
+
+// applyBlock applies the statistics of the block and all its transactions.
+func (p *Plugin) applyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if len(block.Transactions) == 0 {
+		return p.applyBlockStats(block, height, bucket)
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction applies the statistics of the transaction to the day of its block.
+// As the consensus set only applies the transactions of newly created blocks,
+// the statistics of the block itself are applied together with its last transaction.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	day := dayOf(block.Timestamp)
+	record, err := getDay(bucket, day)
+	if err != nil {
+		return err
+	}
+	if !isBlockCreatingTransaction(txn, block) {
+		record.Transactions++
+	}
+	for _, fee := range txn.MinerFees {
+		record.Fees = record.Fees.Add(fee)
+	}
+	added, err := updateAddresses(bucket, day, transactionAddresses(txn), true)
+	if err != nil {
+		return err
+	}
+	record.Addresses += added
+	err = putDay(bucket, day, record)
+	if err != nil {
+		return err
+	}
+	if n := len(block.Transactions); n > 0 && block.Transactions[n-1].ID() == txn.ID() {
+		return p.applyBlockStats(block, height, bucket)
+	}
+	return nil
+}
+
+// applyBlockStats applies the statistics of the block itself to its day:
+// the block count, the time since its parent and the fees paid to the fee pool.
+func (p *Plugin) applyBlockStats(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	timestampsBucket, err := bucket.Bucket(bucketTimestamps)
+	if err != nil {
+		return errors.New("timestamps bucket does not exist")
+	}
+	err = timestampsBucket.Put(encodeUint64(uint64(height)), encodeUint64(uint64(block.Timestamp)))
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store timestamp of block %d: %v", height, err)
+	}
+	day := dayOf(block.Timestamp)
+	record, err := getDay(bucket, day)
+	if err != nil {
+		return err
+	}
+	record.Blocks++
+	if seconds, ok := blockTime(timestampsBucket, block, height); ok {
+		record.BlockTime += seconds
+		record.Intervals++
+	}
+	record.FeePool = record.FeePool.Add(p.feePoolPayouts(block))
+	record.LastHeight = height
+	return putDay(bucket, day, record)
+}
+
+// RevertBlock reverts the statistics of the block and all its transactions,
+// the genesis block is never reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	timestampsBucket, err := bucket.Bucket(bucketTimestamps)
+	if err != nil {
+		return errors.New("timestamps bucket does not exist")
+	}
+	day := dayOf(block.Timestamp)
+	record, err := getDay(bucket, day)
+	if err != nil {
+		return err
+	}
+	record.Blocks--
+	if seconds, ok := blockTime(timestampsBucket, block, height); ok {
+		record.BlockTime -= seconds
+		record.Intervals--
+	}
+	record.FeePool = record.FeePool.Sub(p.feePoolPayouts(block))
+	// blocks are reverted starting from the tip, such that its parent is the last block once more
+	record.LastHeight = height - 1
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		txn := block.Transactions[i]
+		if !isBlockCreatingTransaction(txn, block) {
+			record.Transactions--
+		}
+		for _, fee := range txn.MinerFees {
+			record.Fees = record.Fees.Sub(fee)
+		}
+		removed, err := updateAddresses(bucket, day, transactionAddresses(txn), false)
+		if err != nil {
+			return err
+		}
+		record.Addresses -= removed
+	}
+	err = putDay(bucket, day, record)
+	if err != nil {
+		return err
+	}
+	err = timestampsBucket.Delete(encodeUint64(uint64(height)))
+	if err != nil {
+		return fmt.Errorf("failed to delete timestamp of block %d: %v", height, err)
+	}
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// as the consensus set reverts entire blocks only, transactions are reverted by RevertBlock.
+func (p *Plugin) RevertTransaction(types.Transaction, types.Block, types.BlockHeight, *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// feePoolPayouts returns the value of the miner payouts of the block paid to the fee pool.
+func (p *Plugin) feePoolPayouts(block types.Block) types.Currency {
+	var value types.Currency
+	if p.feePool == types.NilUnlockHash {
+		return value
+	}
+	for _, payout := range block.MinerPayouts {
+		if payout.UnlockHash == p.feePool {
+			value = value.Add(payout.Value)
+		}
+	}
+	return value
+}
+
+// isBlockCreatingTransaction returns whether the given transaction is the block creating transaction of the block,
+// which respends the blockstake output used to create the block, and isn't counted as a transaction.
+func isBlockCreatingTransaction(txn types.Transaction, block types.Block) bool {
+	if len(block.Transactions) == 0 || len(txn.BlockStakeInputs) != 1 || len(txn.BlockStakeOutputs) != 1 ||
+		len(txn.CoinInputs) != 0 || len(txn.CoinOutputs) != 0 {
+		return false
+	}
+	return block.Transactions[0].ID() == txn.ID()
+}
+
+// transactionAddresses returns the addresses active in the given transaction:
+// the addresses receiving its outputs, and the addresses of the keys signing its inputs.
+func transactionAddresses(txn types.Transaction) map[types.UnlockHash]struct{} {
+	addresses := make(map[types.UnlockHash]struct{})
+	add := func(uh types.UnlockHash) {
+		if uh != types.NilUnlockHash {
+			addresses[uh] = struct{}{}
+		}
+	}
+	signers := func(fulfillment types.UnlockFulfillmentProxy) {
+		switch f := fulfillment.Fulfillment.(type) {
+		case *types.SingleSignatureFulfillment:
+			add(types.NewPubKeyUnlockHash(f.PublicKey))
+		case *types.AtomicSwapFulfillment:
+			add(types.NewPubKeyUnlockHash(f.PublicKey))
+		case *types.MultiSignatureFulfillment:
+			for _, pair := range f.Pairs {
+				add(types.NewPubKeyUnlockHash(pair.PublicKey))
+			}
+		}
+	}
+	for _, ci := range txn.CoinInputs {
+		signers(ci.Fulfillment)
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		signers(bsi.Fulfillment)
+	}
+	for _, co := range txn.CoinOutputs {
+		add(co.Condition.UnlockHash())
+	}
+	for _, bso := range txn.BlockStakeOutputs {
+		add(bso.Condition.UnlockHash())
+	}
+	return addresses
+}
+
+// updateAddresses adds (or removes) a transaction in which the given addresses were active during the given day,
+// returning the amount of addresses which became (or are no longer) active during that day.
+func updateAddresses(bucket *persist.LazyBoltBucket, day uint64, addresses map[types.UnlockHash]struct{}, add bool) (uint64, error) {
+	if len(addresses) == 0 {
+		return 0, nil
+	}
+	addressesBucket, err := bucket.Bucket(bucketAddresses)
+	if err != nil {
+		return 0, errors.New("addresses bucket does not exist")
+	}
+	dayBucket, err := addressesBucket.CreateBucketIfNotExists(encodeUint64(day))
+	if err == bolt.ErrTxNotWritable {
+		return 0, pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to create addresses bucket of day %d: %v", day, err)
+	}
+	var changed uint64
+	for uh := range addresses {
+		key := rivbin.Marshal(uh)
+		var count uint64
+		if b := dayBucket.Get(key); len(b) == 8 {
+			count = binary.BigEndian.Uint64(b)
+		}
+		switch {
+		case add:
+			count++
+			if count == 1 {
+				changed++
+			}
+			err = dayBucket.Put(key, encodeUint64(count))
+		case count <= 1:
+			changed++
+			err = dayBucket.Delete(key)
+		default:
+			err = dayBucket.Put(key, encodeUint64(count-1))
+		}
+		if err == bolt.ErrTxNotWritable {
+			return 0, pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to update address %s: %v", uh.String(), err)
+		}
+	}
+	return changed, nil
+}
+
+// getDay returns the statistics of the given day, a zero record if none exist.
+func getDay(bucket *persist.LazyBoltBucket, day uint64) (dayRecord, error) {
+	daysBucket, err := bucket.Bucket(bucketDays)
+	if err != nil {
+		return dayRecord{}, errors.New("days bucket does not exist")
+	}
+	var record dayRecord
+	b := daysBucket.Get(encodeUint64(day))
+	if len(b) == 0 {
+		return record, nil
+	}
+	err = rivbin.Unmarshal(b, &record)
+	if err != nil {
+		return dayRecord{}, fmt.Errorf("failed to decode statistics of day %d: %v", day, err)
+	}
+	return record, nil
+}
+
+// putDay stores the statistics of the given day, deleting them should the day no longer contain any blocks.
+func putDay(bucket *persist.LazyBoltBucket, day uint64, record dayRecord) error {
+	daysBucket, err := bucket.Bucket(bucketDays)
+	if err != nil {
+		return errors.New("days bucket does not exist")
+	}
+	if record.Blocks == 0 && record.Transactions == 0 && record.Addresses == 0 {
+		err = daysBucket.Delete(encodeUint64(day))
+	} else {
+		err = daysBucket.Put(encodeUint64(day), rivbin.Marshal(record))
+	}
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store statistics of day %d: %v", day, err)
+	}
+	return nil
+}
+
+// blockTime returns the amount of seconds between the given block and its parent,
+// zero should the block have a timestamp before its parent.
+// The genesis block and the first block are not counted, as the genesis block predates the launch of the network.
+func blockTime(timestampsBucket *bolt.Bucket, block types.Block, height types.BlockHeight) (uint64, bool) {
+	if height < 2 {
+		return 0, false
+	}
+	b := timestampsBucket.Get(encodeUint64(uint64(height - 1)))
+	if len(b) != 8 {
+		return 0, false
+	}
+	parent := types.Timestamp(binary.BigEndian.Uint64(b))
+	if block.Timestamp < parent {
+		return 0, true
+	}
+	return uint64(block.Timestamp - parent), true
+}
+
+func dayOf(timestamp types.Timestamp) uint64 {
+	return uint64(timestamp) / secondsPerDay
+}
+
+func encodeUint64(x uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, x)
+	return b
+}
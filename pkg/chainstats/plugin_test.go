@@ -0,0 +1,158 @@
+package chainstats
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginStats(t *testing.T) {
+	db := plugintest.NewDB(t, "chainstats")
+
+	address := func(b byte) types.UnlockHash {
+		return types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{b})
+	}
+	feePool := address(9)
+	const day = 100 * secondsPerDay
+	genesis := types.Block{Timestamp: day}
+	// block creates a block at the given timestamp, with a transfer to each of the given addresses paying a fee of 1
+	block := func(timestamp types.Timestamp, recipients ...byte) types.Block {
+		creator := types.NewCondition(types.NewUnlockHashCondition(address(1)))
+		b := types.Block{
+			Timestamp: timestamp,
+			Transactions: []types.Transaction{{
+				Version:           types.TransactionVersionOne,
+				BlockStakeInputs:  []types.BlockStakeInput{{ParentID: types.BlockStakeOutputID{byte(timestamp)}}},
+				BlockStakeOutputs: []types.BlockStakeOutput{{Value: types.NewCurrency64(1), Condition: creator}},
+			}},
+		}
+		var fees types.Currency
+		for _, recipient := range recipients {
+			b.Transactions = append(b.Transactions, types.Transaction{
+				Version:     types.TransactionVersionOne,
+				CoinOutputs: []types.CoinOutput{{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(address(recipient)))}},
+				MinerFees:   []types.Currency{types.NewCurrency64(1)},
+			})
+			fees = fees.Add(types.NewCurrency64(1))
+		}
+		b.MinerPayouts = []types.MinerPayout{{Value: types.NewCurrency64(100), UnlockHash: address(1)}}
+		if !fees.IsZero() {
+			b.MinerPayouts = append(b.MinerPayouts, types.MinerPayout{Value: fees, UnlockHash: feePool})
+		}
+		return b
+	}
+
+	p := NewPlugin(genesis, feePool)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		t.Helper()
+		err := db.UpdateBucket(func(bucket *persist.LazyBoltBucket) error {
+			return fn(bucket)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// blocks cannot be replayed using a read-only transaction
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block(day), 1, bucket)
+	})
+	if err != pluginstats.ErrCatchUpUnsupported {
+		t.Fatalf("expected catching up to be unsupported, got %v", err)
+	}
+
+	// new blocks are applied transaction per transaction, forwarded blocks as a whole
+	blocks := []types.Block{block(day+60, 2, 3), block(day+120, 2), block(day+secondsPerDay+90, 4)}
+	update(func(bucket *persist.LazyBoltBucket) error {
+		for _, txn := range blocks[0].Transactions {
+			if err := p.ApplyTransaction(txn, blocks[0], 1, bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	for i, b := range blocks[1:] {
+		b, height := b, types.BlockHeight(i+2)
+		update(func(bucket *persist.LazyBoltBucket) error {
+			return p.ApplyBlock(b, height, bucket)
+		})
+	}
+
+	stats, err := p.GetStats(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Height != 3 || stats.Timestamp != blocks[2].Timestamp || len(stats.Days) != 2 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	today, yesterday := stats.Days[0], stats.Days[1]
+	if today.Blocks != 1 || today.Transactions != 1 || today.ActiveAddresses != 2 || !today.Fees.Equals64(1) ||
+		!today.FeePool.Equals64(1) || today.LastHeight != 3 || today.AverageBlockTime != secondsPerDay-30 {
+		t.Errorf("unexpected statistics of today: %+v", today)
+	}
+	// the genesis block is part of the first day
+	if yesterday.Blocks != 3 || yesterday.Transactions != 3 || yesterday.ActiveAddresses != 3 || !yesterday.Fees.Equals64(3) ||
+		yesterday.LastHeight != 2 || yesterday.AverageBlockTime != 60 {
+		t.Errorf("unexpected statistics of yesterday: %+v", yesterday)
+	}
+	if len(stats.Windows) != len(Windows) {
+		t.Fatalf("unexpected windows: %+v", stats.Windows)
+	}
+	if w := stats.Windows[0]; w.Days != 1 || w.Blocks != 1 || w.ActiveAddresses != 2 {
+		t.Errorf("unexpected window of a day: %+v", w)
+	}
+	// addresses active on multiple days are counted once
+	if w := stats.Windows[1]; w.Blocks != 4 || w.Transactions != 4 || w.ActiveAddresses != 4 ||
+		!w.Fees.Equals64(4) || w.TransactionsPerDay != 4.0/7 {
+		t.Errorf("unexpected window of a week: %+v", w)
+	}
+
+	// the fee pool inflow is aggregated per period, including the periods without blocks
+	inflow, err := p.GetFeePoolInflow(PeriodDay, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !inflow.Total.Equals64(4) || inflow.Height != 3 || len(inflow.Periods) != 3 ||
+		inflow.Periods[0].Start != day+secondsPerDay || inflow.Periods[0].End != day+2*secondsPerDay ||
+		!inflow.Periods[0].Inflow.Equals64(1) || !inflow.Periods[1].Inflow.Equals64(3) || inflow.Periods[1].LastHeight != 2 ||
+		inflow.Periods[2].Blocks != 0 || !inflow.Periods[2].Inflow.IsZero() {
+		t.Errorf("unexpected daily fee pool inflow: %+v", inflow)
+	}
+	// both days are part of the week starting on monday the 6th of april 1970
+	if inflow, err = p.GetFeePoolInflow(PeriodWeek, 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(inflow.Periods) != 1 || inflow.Periods[0].Start != day-5*secondsPerDay || inflow.Periods[0].Blocks != 4 ||
+		!inflow.Periods[0].Inflow.Equals64(4) || !inflow.Periods[0].Fees.Equals64(4) || inflow.Periods[0].LastHeight != 3 {
+		t.Errorf("unexpected weekly fee pool inflow: %+v", inflow)
+	}
+	if inflow, err = p.GetFeePoolInflow(PeriodMonth, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(inflow.Periods) != 2 || inflow.Periods[0].Start != day-10*secondsPerDay ||
+		inflow.Periods[1].Start != day-41*secondsPerDay || !inflow.Periods[0].Inflow.Equals64(4) {
+		t.Errorf("unexpected monthly fee pool inflow: %+v", inflow)
+	}
+
+	// reverting restores the previous statistics
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(blocks[2], 3, bucket)
+	})
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(blocks[1], 2, bucket)
+	})
+	if stats, err = p.GetStats(30); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Height != 1 || len(stats.Days) != 1 {
+		t.Fatalf("unexpected stats after revert: %+v", stats)
+	}
+	if d := stats.Days[0]; d.Blocks != 2 || d.Transactions != 2 || d.ActiveAddresses != 3 || !d.Fees.Equals64(2) || d.LastHeight != 1 {
+		t.Errorf("unexpected statistics after revert: %+v", d)
+	}
+}
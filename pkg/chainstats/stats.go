@@ -0,0 +1,159 @@
+package chainstats
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Windows are the amounts of days over which the rolling metrics are computed.
+var Windows = []uint64{1, 7, 30}
+
+type (
+	// DayStats contains the statistics of the blocks with a timestamp within a (UTC) day.
+	DayStats struct {
+		// Day is the timestamp at which the day starts
+		Day    types.Timestamp `json:"day"`
+		Blocks uint64          `json:"blocks"`
+		// AverageBlockTime is the average amount of seconds between the blocks of the day and their parents,
+		// not counting the first block, as the genesis block predates the launch of the network
+		AverageBlockTime float64 `json:"averageblocktime"`
+		// Transactions is the amount of transactions, not counting the block creating transactions
+		Transactions uint64 `json:"transactions"`
+		// ActiveAddresses is the amount of addresses receiving outputs or signing inputs
+		ActiveAddresses uint64         `json:"activeaddresses"`
+		Fees            types.Currency `json:"fees"`
+		// FeePool is the part of the fees paid to the transaction fee pool
+		FeePool types.Currency `json:"feepool"`
+		// LastHeight is the height of the last block of the day
+		LastHeight types.BlockHeight `json:"lastheight"`
+	}
+
+	// Window contains the rolling metrics over the last days of the blockchain, including the current day.
+	Window struct {
+		Days             uint64  `json:"days"`
+		Blocks           uint64  `json:"blocks"`
+		AverageBlockTime float64 `json:"averageblocktime"`
+		Transactions     uint64  `json:"transactions"`
+		// TransactionsPerDay is the average amount of transactions per day
+		TransactionsPerDay float64 `json:"transactionsperday"`
+		// ActiveAddresses is the amount of distinct addresses active during the window
+		ActiveAddresses uint64         `json:"activeaddresses"`
+		Fees            types.Currency `json:"fees"`
+		FeePool         types.Currency `json:"feepool"`
+	}
+
+	// Stats contains the rolling metrics of the blockchain,
+	// as well as the statistics of its most recent days.
+	Stats struct {
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		Windows   []Window          `json:"windows"`
+		// Days contains the statistics of the most recent days containing blocks, most recent day first
+		Days []DayStats `json:"days"`
+	}
+)
+
+// GetStats returns the rolling metrics of all Windows, as well as the statistics of the given amount of most recent days.
+// The days are relative to the timestamp of the last block rather than the local clock.
+func (p *Plugin) GetStats(days uint64) (Stats, error) {
+	var stats Stats
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		timestampsBucket := bucket.Bucket(bucketTimestamps)
+		daysBucket := bucket.Bucket(bucketDays)
+		addressesBucket := bucket.Bucket(bucketAddresses)
+		if timestampsBucket == nil || daysBucket == nil || addressesBucket == nil {
+			return errors.New("chain statistics buckets do not exist")
+		}
+		k, v := timestampsBucket.Cursor().Last()
+		if k == nil {
+			return errors.New("no blocks applied")
+		}
+		stats.Height = types.BlockHeight(binary.BigEndian.Uint64(k))
+		stats.Timestamp = types.Timestamp(binary.BigEndian.Uint64(v))
+		today := dayOf(stats.Timestamp)
+
+		maxDays := days
+		for _, window := range Windows {
+			if window > maxDays {
+				maxDays = window
+			}
+		}
+		records := make(map[uint64]dayRecord)
+		for day := today; day+maxDays > today; day-- {
+			b := daysBucket.Get(encodeUint64(day))
+			if len(b) > 0 {
+				var record dayRecord
+				err := rivbin.Unmarshal(b, &record)
+				if err != nil {
+					return fmt.Errorf("failed to decode statistics of day %d: %v", day, err)
+				}
+				records[day] = record
+				if today-day < days {
+					stats.Days = append(stats.Days, newDayStats(day, record))
+				}
+			}
+			if day == 0 {
+				break
+			}
+		}
+
+		for _, days := range Windows {
+			window := Window{Days: days}
+			var blockTime, intervals uint64
+			active := make(map[string]struct{})
+			for day := today; day+days > today; day-- {
+				record := records[day]
+				window.Blocks += record.Blocks
+				window.Transactions += record.Transactions
+				window.Fees = window.Fees.Add(record.Fees)
+				window.FeePool = window.FeePool.Add(record.FeePool)
+				blockTime += record.BlockTime
+				intervals += record.Intervals
+				if dayBucket := addressesBucket.Bucket(encodeUint64(day)); dayBucket != nil {
+					err := dayBucket.ForEach(func(k, _ []byte) error {
+						active[string(k)] = struct{}{}
+						return nil
+					})
+					if err != nil {
+						return err
+					}
+				}
+				if day == 0 {
+					break
+				}
+			}
+			window.ActiveAddresses = uint64(len(active))
+			window.TransactionsPerDay = float64(window.Transactions) / float64(days)
+			if intervals > 0 {
+				window.AverageBlockTime = float64(blockTime) / float64(intervals)
+			}
+			stats.Windows = append(stats.Windows, window)
+		}
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats, nil
+}
+
+func newDayStats(day uint64, record dayRecord) DayStats {
+	stats := DayStats{
+		Day:             types.Timestamp(day * secondsPerDay),
+		Blocks:          record.Blocks,
+		Transactions:    record.Transactions,
+		ActiveAddresses: record.Addresses,
+		Fees:            record.Fees,
+		FeePool:         record.FeePool,
+		LastHeight:      record.LastHeight,
+	}
+	if record.Intervals > 0 {
+		stats.AverageBlockTime = float64(record.BlockTime) / float64(record.Intervals)
+	}
+	return stats
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	authapi "github.com/threefoldtech/rivine/extensions/authcointx/api"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// GetAuthStatus returns whether each of the given addresses is currently authorized, in the given order.
+func (c *Client) GetAuthStatus(ctx context.Context, addresses ...types.UnlockHash) ([]bool, error) {
+	if len(addresses) == 0 {
+		return nil, nil
+	}
+	query := url.Values{}
+	for _, address := range addresses {
+		query.Add("addr", address.String())
+	}
+	var resp authapi.GetAddressesAuthStateResponse
+	err := c.get(ctx, "/consensus/authcoin/status?"+query.Encode(), &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.AuthStates) != len(addresses) {
+		return nil, fmt.Errorf("expected the authorization state of %d addresses, got %d", len(addresses), len(resp.AuthStates))
+	}
+	return resp.AuthStates, nil
+}
+
+// IsAuthorized returns whether the given address is currently authorized.
+func (c *Client) IsAuthorized(ctx context.Context, address types.UnlockHash) (bool, error) {
+	states, err := c.GetAuthStatus(ctx, address)
+	if err != nil {
+		return false, err
+	}
+	return states[0], nil
+}
+
+// AuthCondition returns the auth condition currently active on the chain of the daemon,
+// which has to be fulfilled by auth address update transactions.
+func (c *Client) AuthCondition(ctx context.Context) (types.UnlockConditionProxy, error) {
+	var resp authapi.GetAuthConditionResponse
+	err := c.get(ctx, "/consensus/authcoin/condition", &resp)
+	return resp.AuthCondition, err
+}
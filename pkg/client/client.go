@@ -0,0 +1,224 @@
+// Package client is a typed Go client of the HTTP API of goldchaind,
+// for services such as the faucet, replacing hand-written calls and JSON (un)marshaling.
+//
+// All calls take a context, and idempotent calls are retried
+// while the daemon cannot be reached or answers with a server error.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/threefoldtech/rivine/pkg/daemon"
+)
+
+const (
+	// DefaultRetries is the default amount of times an idempotent call is retried.
+	DefaultRetries = 3
+	// DefaultRetryInterval is the default time waited before retrying a call,
+	// doubled for every next attempt.
+	DefaultRetryInterval = time.Second
+	// DefaultPollInterval is the default interval at which SubscribeBlocks checks for new blocks.
+	DefaultPollInterval = 5 * time.Second
+)
+
+// Error is returned for calls answered by the daemon with a non-2xx status code.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+// Error implements error.Error
+func (err *Error) Error() string {
+	return fmt.Sprintf("HTTP %d error: %s", err.StatusCode, err.Message)
+}
+
+// IsStatus returns whether the given error is an Error with the given status code.
+func IsStatus(err error, code int) bool {
+	apiErr, ok := err.(*Error)
+	return ok && apiErr.StatusCode == code
+}
+
+// RejectedError is returned for transactions rejected locally by the daemon, which are not relayed to its peers.
+type RejectedError struct {
+	// RejectedBy is the check which rejected the transactions, such as relay.CheckConsensus
+	RejectedBy string
+	Message    string
+}
+
+// Error implements error.Error
+func (err *RejectedError) Error() string {
+	return fmt.Sprintf("transaction rejected by the %s check: %s", err.RejectedBy, err.Message)
+}
+
+// Client calls the HTTP API of a goldchain daemon.
+// The exported fields can be modified until the first call.
+type Client struct {
+	// RootURL is the address of the daemon, http is assumed if it has no scheme
+	RootURL string
+	// Password is the API password of the daemon, only sent if defined
+	Password string
+	// UserAgent is required by the daemon for all calls
+	UserAgent string
+	// HTTPClient sends the requests, http.DefaultClient is used if undefined
+	HTTPClient *http.Client
+
+	// Retries is the amount of times an idempotent (GET) call is retried,
+	// in case the daemon cannot be reached or answers with a server error
+	Retries int
+	// RetryInterval is the time waited before the first retry, doubled for every next retry
+	RetryInterval time.Duration
+	// PollInterval is the interval at which SubscribeBlocks checks for new blocks
+	PollInterval time.Duration
+}
+
+// New creates a client for the daemon at the given address,
+// authenticating using the given password, if any.
+func New(address, password string) *Client {
+	return &Client{
+		RootURL:       address,
+		Password:      password,
+		UserAgent:     daemon.RivineUserAgent,
+		Retries:       DefaultRetries,
+		RetryInterval: DefaultRetryInterval,
+		PollInterval:  DefaultPollInterval,
+	}
+}
+
+// get makes a GET call, decoding the JSON response into reply.
+func (c *Client) get(ctx context.Context, call string, reply interface{}) error {
+	return c.getWith(ctx, call, func(resp *http.Response) error {
+		return json.NewDecoder(resp.Body).Decode(reply)
+	})
+}
+
+// getWith makes a GET call, retrying it if possible, and reads its successful response using the given function.
+// The call is only retried if the response could not be read at all.
+func (c *Client) getWith(ctx context.Context, call string, read func(resp *http.Response) error) error {
+	interval := c.RetryInterval
+	for attempt := 0; ; attempt++ {
+		resp, err := c.do(ctx, http.MethodGet, call, "", nil)
+		if err == nil {
+			err = read(resp)
+			resp.Body.Close()
+			return err
+		}
+		if attempt >= c.Retries || !retryable(err) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// post makes a POST call, sending the body as JSON and decoding the JSON response into reply, if not nil.
+// POST calls are never retried, as they might have been applied by the daemon.
+func (c *Client) post(ctx context.Context, call string, body, reply interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, call, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if reply == nil {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return fmt.Errorf("expected a response from %s, but the daemon returned none", call)
+	}
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
+
+// postForm makes a POST call, sending the values as a form, for the calls of the daemon which do not accept JSON.
+// The response is discarded, and as any POST call it is never retried.
+func (c *Client) postForm(ctx context.Context, call string, values url.Values) error {
+	resp, err := c.do(ctx, http.MethodPost, call, "application/x-www-form-urlencoded", strings.NewReader(values.Encode()))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// do sends a single request, returning an *Error for non-2xx responses.
+// The body of the returned response has to be closed.
+func (c *Client) do(ctx context.Context, method, call, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.url(call), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("User-Agent", c.UserAgent)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.Password != "" {
+		req.SetBasicAuth("", c.Password)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("no response from daemon: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp, call)
+	}
+	return resp, nil
+}
+
+// decodeError decodes the error message of a non-2xx response.
+func decodeError(resp *http.Response, call string) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(b, &apiErr) != nil || apiErr.Message == "" {
+		if resp.StatusCode == http.StatusNotFound {
+			apiErr.Message = "API call not recognized: " + call
+		} else {
+			apiErr.Message = strings.TrimSpace(string(b))
+		}
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: apiErr.Message}
+}
+
+// retryable returns whether a failed call can be retried:
+// either the daemon could not be reached, or it answered with a server error.
+func retryable(err error) bool {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false
+	}
+	if apiErr, ok := err.(*Error); ok {
+		return apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+func (c *Client) url(call string) string {
+	root := strings.TrimSuffix(c.RootURL, "/")
+	if !strings.Contains(root, "://") {
+		root = "http://" + root
+	}
+	return root + call
+}
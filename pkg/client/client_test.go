@@ -0,0 +1,279 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/threefoldtech/rivine/modules"
+	rwallet "github.com/threefoldtech/rivine/modules/wallet"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/relay"
+	"github.com/nbh-digital/goldchain/pkg/seed"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	"github.com/nbh-digital/goldchain/pkg/testnet"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+func TestRetries(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.UserAgent() != "Rivine-Agent" {
+			t.Errorf("unexpected user agent %q", req.UserAgent())
+		}
+		switch atomic.AddInt32(&calls, 1) {
+		case 1, 2:
+			api.WriteError(w, api.Error{Message: "temporarily unavailable"}, http.StatusServiceUnavailable)
+		default:
+			api.WriteJSON(w, api.ConsensusGET{Height: 42})
+		}
+	}))
+	defer server.Close()
+
+	c := New(server.URL, "")
+	c.RetryInterval = time.Millisecond
+	cs, err := c.Consensus(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.Height != 42 || calls != 3 {
+		t.Errorf("expected height 42 after 3 calls, got height %d after %d calls", cs.Height, calls)
+	}
+
+	// client errors are not retried, and POST calls never are
+	calls = 0
+	unknown := httptest.NewServer(http.NotFoundHandler())
+	defer unknown.Close()
+	c.RootURL = unknown.URL
+	_, err = c.Consensus(context.Background())
+	if !IsStatus(err, http.StatusNotFound) || calls != 0 {
+		t.Errorf("expected a single not found error, got %v", err)
+	}
+	c.RootURL = server.URL
+	calls = 0
+	_, err = c.SubmitTransaction(context.Background(), types.Transaction{})
+	if !IsStatus(err, http.StatusServiceUnavailable) || calls != 1 {
+		t.Errorf("expected the POST call not to be retried, got %v after %d calls", err, calls)
+	}
+}
+
+func TestClient(t *testing.T) {
+	if testing.Short() {
+		// closing the gateway waits for its port forwarding attempt to time out
+		t.SkipNow()
+	}
+	network, err := testnet.New(testnet.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Close()
+	server := httptest.NewServer(network.Node().Router())
+	defer server.Close()
+	ctx := context.Background()
+	c := New(server.URL, "")
+	c.PollInterval = 10 * time.Millisecond
+
+	constants, err := c.Constants(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oneCoin := constants.OneCoin
+
+	receiver, err := network.NewWallet(types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var unauthorized types.UnlockHash
+	unauthorized.Type = types.UnlockTypePubKey
+	states, err := c.GetAuthStatus(ctx, receiver.Address, unauthorized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 || !states[0] || states[1] {
+		t.Errorf("unexpected authorization states: %v", states)
+	}
+
+	outputs := []types.CoinOutput{{
+		Value:     oneCoin.Mul64(10),
+		Condition: types.NewCondition(types.NewUnlockHashCondition(receiver.Address)),
+	}}
+	dryRun, err := c.DryRunCoins(ctx, outputs, wallet.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dryRun.Valid {
+		t.Fatalf("expected a valid dry run, got: %s", dryRun.Error)
+	}
+	txID, err := c.SendCoins(ctx, outputs, wallet.BuildOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// transactions rejected by the daemon are not broadcasted
+	orphan := types.Transaction{
+		Version: constants.DefaultTransactionVersion,
+		CoinInputs: []types.CoinInput{{
+			ParentID:    types.CoinOutputID{1},
+			Fulfillment: types.NewFulfillment(types.NewSingleSignatureFulfillment(types.PublicKey{Algorithm: types.SignatureAlgoEd25519, Key: make([]byte, 32)})),
+		}},
+		CoinOutputs: outputs,
+		MinerFees:   []types.Currency{constants.MinimumTransactionFee},
+	}
+	if _, err = c.SubmitTransaction(ctx, orphan); err == nil {
+		t.Error("expected the transaction spending an unknown output to be rejected")
+	} else if rejected, ok := err.(*RejectedError); !ok || rejected.RejectedBy != relay.CheckConsensus {
+		t.Errorf("expected the transaction to be rejected by the consensus check, got %v", err)
+	}
+	ids, err := network.Mine(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gateway, err := c.Gateway(ctx); err != nil || gateway.NetAddress == "" || len(gateway.Peers) != 0 {
+		t.Errorf("unexpected gateway %+v (%v)", gateway, err)
+	}
+
+	cs, err := c.Consensus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.CurrentBlock != ids[1] {
+		t.Fatalf("expected the current block to be the last mined block")
+	}
+	block, err := c.ConsensusAtHeight(ctx, cs.Height-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.ID != ids[0] {
+		t.Fatalf("unexpected block at height %d", block.Height)
+	}
+	confirmed := false
+	for _, txn := range block.Transactions {
+		confirmed = confirmed || txn.ID() == txID
+	}
+	if !confirmed {
+		t.Error("expected the sent transaction to be confirmed in the first mined block")
+	}
+	if _, found, err := c.ConsensusTransaction(ctx, txID); err != nil || !found {
+		t.Errorf("expected the sent transaction to be found (%v)", err)
+	}
+	if _, found, err := c.ConsensusTransaction(ctx, types.TransactionID{1}); err != nil || found {
+		t.Errorf("expected an unknown transaction not to be found (%v)", err)
+	}
+	status, err := c.TransactionStatus(ctx, txID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != goldchainapi.TransactionStatusConfirmed || status.Height != block.Height ||
+		status.BlockID == nil || *status.BlockID != ids[0] || status.Confirmations != 2 {
+		t.Errorf("unexpected transaction status: %+v", status)
+	}
+	if _, err := c.TransactionStatus(ctx, types.TransactionID{1}); !IsStatus(err, http.StatusNotFound) {
+		t.Errorf("expected the status of an unknown transaction not to be found, got %v", err)
+	}
+
+	// the test network has no block creator, blocks are mined on demand using its own handler
+	node := network.Node()
+	devRouter := httprouter.New()
+	devRouter.POST("/dev/mine", goldchainapi.NewDevMineHandler(node.ConsensusSet(),
+		staking.NewMiner(node.ConsensusSet(), node.Wallet(), node.TransactionPool(), node.Constants())))
+	devServer := httptest.NewServer(devRouter)
+	defer devServer.Close()
+	mined, err := New(devServer.URL, "").Mine(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mined.BlockIDs) != 2 || mined.Height != block.Height+3 {
+		t.Errorf("unexpected mined blocks: %+v", mined)
+	}
+
+	// the wallet of the daemon exists already, another one is provisioned using its own handler
+	if _, err = c.ProvisionWallet(ctx, wallet.ProvisionOptions{}); !IsStatus(err, http.StatusConflict) {
+		t.Errorf("expected the existing wallet not to be provisioned again, got %v", err)
+	}
+	w, err := rwallet.New(network.Node().ConsensusSet(), network.Node().TransactionPool(), t.TempDir(),
+		testnet.DefaultConfig().BlockchainInfo, network.Node().Constants(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	router := httprouter.New()
+	router.POST("/wallet/provision", goldchainapi.NewWalletProvisionHandler(w))
+	provisionServer := httptest.NewServer(router)
+	defer provisionServer.Close()
+	newSeed := modules.Seed{1, 2, 3}
+	mnemonic, err := modules.NewMnemonic(newSeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	provisioned, err := New(provisionServer.URL, "").ProvisionWallet(ctx, wallet.ProvisionOptions{
+		Mnemonic:   mnemonic,
+		Passphrase: "passphrase",
+		Addresses:  3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if provisioned.Mnemonic != mnemonic || !provisioned.Encrypted || !w.Unlocked() || len(provisioned.Addresses) != 3 ||
+		provisioned.Addresses[2] != seed.Address(newSeed, 2) {
+		t.Errorf("unexpected provisioned wallet: %+v", provisioned)
+	}
+
+	// coins on deauthorized addresses are not spendable
+	address, err := network.Foundation().NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = network.Fund(address, oneCoin.Mul64(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err = network.Deauthorize(address); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := c.WalletBalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Unauthorized.Equals(oneCoin.Mul64(5)) || len(balance.UnauthorizedAddresses) != 1 ||
+		balance.UnauthorizedAddresses[0].Address != address {
+		t.Errorf("expected 5 unauthorized coins on %s, got %v", address.String(), balance.UnauthorizedAddresses)
+	}
+	if balance.Spendable.IsZero() || !balance.PendingIncoming.IsZero() {
+		t.Errorf("unexpected balance: %+v", balance)
+	}
+	cs, err = c.Consensus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// all blocks are passed in order, including those created while subscribed
+	var passed []Block
+	subCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	err = c.SubscribeBlocks(subCtx, 0, func(b Block) error {
+		if b.Height != types.BlockHeight(len(passed)) {
+			t.Fatalf("expected block %d, got block %d", len(passed), b.Height)
+		}
+		passed = append(passed, b)
+		if b.Height == cs.Height {
+			if _, err := network.Mine(1); err != nil {
+				return err
+			}
+		}
+		if b.Height == cs.Height+1 {
+			cancel()
+		}
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected the subscription to be cancelled, got: %v", err)
+	}
+	if len(passed) != int(cs.Height)+2 || passed[cs.Height].ID != cs.CurrentBlock || passed[cs.Height+1].ParentID != cs.CurrentBlock {
+		t.Fatalf("unexpected blocks passed: %d", len(passed))
+	}
+}
@@ -0,0 +1,214 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/blockstream"
+)
+
+// maxBlockSize limits the size of the raw blocks read from the daemon.
+const maxBlockSize = 1 << 21
+
+// errReorganized is returned internally when the blocks streamed do not extend the blocks passed before.
+var errReorganized = errors.New("the chain got reorganized")
+
+// Block is a block of the chain, with its height and ID.
+type Block struct {
+	Height types.BlockHeight
+	ID     types.BlockID
+	types.Block
+}
+
+// Constants returns the constants of the daemon and its network.
+func (c *Client) Constants(ctx context.Context) (modules.DaemonConstants, error) {
+	var constants modules.DaemonConstants
+	err := c.get(ctx, "/daemon/constants", &constants)
+	return constants, err
+}
+
+// Consensus returns the current consensus state of the daemon.
+func (c *Client) Consensus(ctx context.Context) (rapi.ConsensusGET, error) {
+	var cs rapi.ConsensusGET
+	err := c.get(ctx, "/consensus", &cs)
+	return cs, err
+}
+
+// Mine creates the given amount of blocks on demand, using the blockstakes of the wallet of the daemon,
+// confirming the transactions of its transaction pool. Only devnet daemons can create blocks on demand.
+func (c *Client) Mine(ctx context.Context, blocks int) (goldchainapi.DevMinePOST, error) {
+	var resp goldchainapi.DevMinePOST
+	err := c.post(ctx, "/dev/mine?blocks="+strconv.Itoa(blocks), nil, &resp)
+	return resp, err
+}
+
+// ConsensusAtHeight returns the block at the given height of the chain of the daemon.
+func (c *Client) ConsensusAtHeight(ctx context.Context, height types.BlockHeight) (Block, error) {
+	var block types.Block
+	err := c.getWith(ctx, fmt.Sprintf("/consensus/rawblocks/%d", height), func(resp *http.Response) error {
+		b, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBlockSize+1))
+		if err != nil {
+			return err
+		}
+		if len(b) > maxBlockSize {
+			return fmt.Errorf("block at height %d exceeds the maximum size of %d bytes", height, maxBlockSize)
+		}
+		return siabin.Unmarshal(b, &block)
+	})
+	if err != nil {
+		return Block{}, err
+	}
+	return Block{Height: height, ID: block.ID(), Block: block}, nil
+}
+
+// ConsensusTransaction returns the transaction with the given ID,
+// found being false should the transaction not (yet) be part of the chain of the daemon.
+func (c *Client) ConsensusTransaction(ctx context.Context, id types.TransactionID) (txn types.Transaction, found bool, err error) {
+	err = c.getWith(ctx, "/consensus/transactions/"+id.String(), func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&txn); err != nil {
+			return err
+		}
+		// the daemon returns the genesis transaction for unknown transactions
+		found = txn.ID() == id
+		return nil
+	})
+	return txn, found, err
+}
+
+// TransactionStatus returns the status of the transaction with the given ID, which is confirmed,
+// in the transaction pool of the daemon, or was seen there recently.
+func (c *Client) TransactionStatus(ctx context.Context, id types.TransactionID) (goldchainapi.TransactionStatusGET, error) {
+	var resp goldchainapi.TransactionStatusGET
+	err := c.get(ctx, "/transactions/"+id.String()+"/status", &resp)
+	return resp, err
+}
+
+// Events returns at most limit events with a sequence number greater than the given one, oldest first,
+// waiting up to the given duration for such an event should there be none yet.
+// The duration has to be shorter than the API timeout of the daemon (one minute by default).
+func (c *Client) Events(ctx context.Context, after uint64, limit int, wait time.Duration) (goldchainapi.ConsensusEventsGET, error) {
+	query := url.Values{}
+	query.Set("after", strconv.FormatUint(after, 10))
+	query.Set("limit", strconv.Itoa(limit))
+	if wait > 0 {
+		query.Set("wait", wait.String())
+	}
+	var resp goldchainapi.ConsensusEventsGET
+	err := c.get(ctx, "/consensus/events?"+query.Encode(), &resp)
+	return resp, err
+}
+
+// SubscribeBlocks passes all blocks of the chain of the daemon to the given handler in order,
+// starting at the given height, until the context is done or the handler returns an error.
+// New blocks are polled for using the PollInterval of the client,
+// and streamed using the /consensus/rawblocks endpoint.
+//
+// Should the chain be reorganized, the blocks of the new chain are passed again,
+// starting at the height at which it forked from the blocks passed before.
+// The error of the context or handler is returned, as well as any error returned by the daemon.
+func (c *Client) SubscribeBlocks(ctx context.Context, start types.BlockHeight, handle func(Block) error) error {
+	// the recently passed blocks, used to detect reorganizations
+	var recent []Block
+	next := start
+	poll := c.PollInterval
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+	for {
+		cs, err := c.Consensus(ctx)
+		if err != nil {
+			return err
+		}
+		if cs.Height >= next {
+			next, recent, err = c.streamBlocks(ctx, next, cs.Height, recent, handle)
+			if err != nil {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(poll):
+		}
+	}
+}
+
+// maxRecentBlocks is the amount of recently passed blocks remembered by SubscribeBlocks,
+// limiting the depth of the reorganizations it can follow.
+const maxRecentBlocks = 144
+
+// streamBlocks passes the blocks within the given range to the handler,
+// returning the height of the next block to pass.
+func (c *Client) streamBlocks(ctx context.Context, start, end types.BlockHeight, recent []Block, handle func(Block) error) (types.BlockHeight, []Block, error) {
+	next := start
+	query := url.Values{}
+	query.Set("start", strconv.FormatUint(uint64(start), 10))
+	query.Set("end", strconv.FormatUint(uint64(end), 10))
+	err := c.getWith(ctx, "/consensus/rawblocks?"+query.Encode(), func(resp *http.Response) error {
+		stream := blockstream.NewReader(resp.Body, maxBlockSize)
+		for {
+			frame, err := stream.Next()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			block, err := frame.Block()
+			if err != nil {
+				return err
+			}
+			if n := len(recent); n > 0 && recent[n-1].Height+1 == frame.Height && recent[n-1].ID != block.ParentID {
+				// the chain got reorganized since the previous block was passed
+				return errReorganized
+			}
+			b := Block{Height: frame.Height, ID: block.ID(), Block: block}
+			if err = handle(b); err != nil {
+				return err
+			}
+			recent = append(recent, b)
+			if len(recent) > maxRecentBlocks {
+				recent = recent[1:]
+			}
+			next = frame.Height + 1
+		}
+	})
+	if err == errReorganized {
+		next, recent, err = c.forkPoint(ctx, recent)
+	}
+	return next, recent, err
+}
+
+// forkPoint finds the highest recently passed block which is still part of the chain,
+// returning the height of the block after it, and the recent blocks up to it.
+func (c *Client) forkPoint(ctx context.Context, recent []Block) (types.BlockHeight, []Block, error) {
+	for len(recent) > 0 {
+		last := recent[len(recent)-1]
+		block, err := c.ConsensusAtHeight(ctx, last.Height)
+		if err != nil && !IsStatus(err, http.StatusNotFound) {
+			return 0, nil, err
+		}
+		if err == nil && block.ID == last.ID {
+			return last.Height + 1, recent, nil
+		}
+		recent = recent[:len(recent)-1]
+	}
+	return 0, nil, fmt.Errorf("the chain got reorganized deeper than the last %d blocks passed", maxRecentBlocks)
+}
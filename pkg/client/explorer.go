@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// ExplorerHash returns the block, transaction, output or address identified by the given hash,
+// as known by the explorer module of the daemon.
+func (c *Client) ExplorerHash(ctx context.Context, hash string) (rapi.ExplorerHashGET, error) {
+	var resp rapi.ExplorerHashGET
+	err := c.get(ctx, "/explorer/hashes/"+url.PathEscape(hash), &resp)
+	return resp, err
+}
+
+// ValidateAddress returns the diagnosis of the given address, and whether it is authorized should it be valid.
+func (c *Client) ValidateAddress(ctx context.Context, address string) (goldchainapi.ExplorerValidateAddressGET, error) {
+	var resp goldchainapi.ExplorerValidateAddressGET
+	err := c.get(ctx, "/explorer/validate-address?addr="+url.QueryEscape(address), &resp)
+	return resp, err
+}
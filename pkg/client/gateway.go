@@ -0,0 +1,25 @@
+package client
+
+import (
+	"context"
+
+	"github.com/threefoldtech/rivine/modules"
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+)
+
+// Gateway returns the address of the gateway of the daemon and its peers.
+func (c *Client) Gateway(ctx context.Context) (rapi.GatewayGET, error) {
+	var gateway rapi.GatewayGET
+	err := c.get(ctx, "/gateway", &gateway)
+	return gateway, err
+}
+
+// ConnectPeer connects the gateway of the daemon to the peer at the given address.
+func (c *Client) ConnectPeer(ctx context.Context, address modules.NetAddress) error {
+	return c.postForm(ctx, "/gateway/connect/"+string(address), nil)
+}
+
+// DisconnectPeer disconnects the gateway of the daemon from the peer at the given address.
+func (c *Client) DisconnectPeer(ctx context.Context, address modules.NetAddress) error {
+	return c.postForm(ctx, "/gateway/disconnect/"+string(address), nil)
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+
+	rapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/relay"
+	"github.com/nbh-digital/goldchain/pkg/wallet"
+)
+
+// WalletBalance returns the breakdown of the coin balance of the wallet of the daemon.
+func (c *Client) WalletBalance(ctx context.Context) (wallet.Balance, error) {
+	var resp goldchainapi.WalletBalanceGET
+	err := c.get(ctx, "/wallet/balance", &resp)
+	return resp.Balance, err
+}
+
+// ProvisionWallet creates and unlocks the wallet of the daemon, from the given mnemonic or from a generated one,
+// returning the mnemonic of its primary seed and its first addresses.
+func (c *Client) ProvisionWallet(ctx context.Context, opts wallet.ProvisionOptions) (wallet.ProvisionedWallet, error) {
+	var resp goldchainapi.WalletProvisionPOSTResp
+	err := c.post(ctx, "/wallet/provision", goldchainapi.WalletProvisionPOST{ProvisionOptions: opts}, &resp)
+	return resp.ProvisionedWallet, err
+}
+
+// SendCoins sends the given coin outputs using the wallet of the daemon, built using the given options,
+// returning the ID of the transaction.
+func (c *Client) SendCoins(ctx context.Context, outputs []types.CoinOutput, opts wallet.BuildOptions) (types.TransactionID, error) {
+	var resp rapi.WalletCoinsPOSTResp
+	err := c.post(ctx, "/wallet/coins"+sendQuery(opts, false), walletCoinsBody(outputs, opts), &resp)
+	return resp.TransactionID, err
+}
+
+// DryRunCoins builds the transaction SendCoins would send, and validates it against
+// the current consensus state of the daemon, without broadcasting it.
+func (c *Client) DryRunCoins(ctx context.Context, outputs []types.CoinOutput, opts wallet.BuildOptions) (wallet.DryRunResult, error) {
+	var resp goldchainapi.WalletDryRunPOSTResp
+	err := c.post(ctx, "/wallet/coins"+sendQuery(opts, true), walletCoinsBody(outputs, opts), &resp)
+	return resp.DryRunResult, err
+}
+
+// SignTransaction signs all inputs of the given transaction the wallet of the daemon can sign for.
+func (c *Client) SignTransaction(ctx context.Context, txn types.Transaction) (types.Transaction, error) {
+	var signed types.Transaction
+	err := c.post(ctx, "/wallet/sign", txn, &signed)
+	return signed, err
+}
+
+// SubmitTransaction adds the given transaction to the transaction pool of the daemon,
+// which broadcasts it, returning its ID. A *RejectedError is returned
+// in case the daemon rejected the transaction, in which case it was not broadcasted.
+func (c *Client) SubmitTransaction(ctx context.Context, txn types.Transaction) (types.TransactionID, error) {
+	result, err := c.BroadcastTransactions(ctx, []types.Transaction{txn})
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	if result.Status == relay.BroadcastRejected {
+		return types.TransactionID{}, &RejectedError{RejectedBy: result.RejectedBy, Message: result.Error}
+	}
+	return txn.ID(), nil
+}
+
+// BroadcastTransactions checks the given transaction set against the relay policy, consensus state
+// and transaction pool of the daemon, which only relays it to its peers once accepted by its transaction pool,
+// returning whether it was rejected locally or accepted and awaiting confirmation.
+func (c *Client) BroadcastTransactions(ctx context.Context, txns []types.Transaction) (relay.BroadcastResult, error) {
+	var resp goldchainapi.TransactionPoolBroadcastPOSTResp
+	err := c.post(ctx, "/transactionpool/broadcast", goldchainapi.TransactionPoolBroadcastPOST{Transactions: txns}, &resp)
+	return resp.BroadcastResult, err
+}
+
+// LoadSeed adds the seed of the given mnemonic to the unlocked wallet of the daemon,
+// such that the wallet can spend the outputs of all addresses derived from it.
+// The passphrase is the one the wallet is encrypted with, and empty for a plain wallet.
+func (c *Client) LoadSeed(ctx context.Context, mnemonic, passphrase string) error {
+	values := url.Values{}
+	values.Set("mnemonic", mnemonic)
+	if passphrase != "" {
+		values.Set("passphrase", passphrase)
+	}
+	return c.postForm(ctx, "/wallet/seed", values)
+}
+
+func walletCoinsBody(outputs []types.CoinOutput, opts wallet.BuildOptions) rapi.WalletCoinsPOST {
+	return rapi.WalletCoinsPOST{CoinOutputs: outputs, RefundAddress: opts.RefundAddress}
+}
+
+// sendQuery encodes the build options as the query parameters of the wallet send endpoints.
+func sendQuery(opts wallet.BuildOptions, dryRun bool) string {
+	query := url.Values{}
+	if dryRun {
+		query.Set("dryrun", "true")
+	}
+	if opts.ExpirationHeight != 0 {
+		query.Set("expirationheight", strconv.FormatUint(uint64(opts.ExpirationHeight), 10))
+	}
+	if opts.LockedUntil != 0 {
+		query.Set("lockeduntil", strconv.FormatUint(opts.LockedUntil, 10))
+	}
+	if opts.CoinSelection != "" {
+		query.Set("coinselection", string(opts.CoinSelection))
+	}
+	if ids := coinOutputIDs(opts.IncludeCoinOutputs); ids != "" {
+		query.Set("includeoutputs", ids)
+	}
+	if ids := coinOutputIDs(opts.ExcludeCoinOutputs); ids != "" {
+		query.Set("excludeoutputs", ids)
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+func coinOutputIDs(ids []types.CoinOutputID) string {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,393 @@
+// Package compact relays blocks as compact blocks between upgraded peers: a block announced by a peer
+// is fetched as its header and the short IDs of its transactions, of which only the transactions
+// missing from the transaction pool are fetched, rather than fetching the full block.
+package compact
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// RPCSendCompactBlock is the RPC sending a block as compact block,
+	// which replaces the SendBlk RPC of the consensus set for upgraded peers
+	RPCSendCompactBlock = "SendCmpctBlk"
+	// rpcSendBlk is the RPC of the consensus set sending a full block
+	rpcSendBlk = "SendBlk"
+
+	// recentDepth is the amount of blocks of the current chain served as compact blocks,
+	// older blocks and blocks of other forks are fetched in full
+	recentDepth = 10
+	// unsupportedRetry is the duration after which a peer not supporting compact blocks is tried again,
+	// as it might have been upgraded
+	unsupportedRetry = time.Hour
+)
+
+// errMismatch is returned when the reconstructed block doesn't match the requested block,
+// even when all its transactions were fetched from the peer
+var errMismatch = errors.New("compact block does not match the requested block")
+
+// Stats are the statistics of the compact blocks received and sent.
+type Stats struct {
+	// CompactBlocks is the amount of blocks received as compact blocks
+	CompactBlocks uint64 `json:"compactblocks"`
+	// FullBlocks is the amount of blocks fetched in full instead, from peers not supporting compact blocks
+	// or not having the block on their current chain, or as the reconstructed block didn't match
+	FullBlocks uint64 `json:"fullblocks"`
+	// PoolTransactions and FetchedTransactions are the amount of transactions of the compact blocks
+	// found in the transaction pool, and fetched from the peers
+	PoolTransactions    uint64 `json:"pooltransactions"`
+	FetchedTransactions uint64 `json:"fetchedtransactions"`
+	// ServedBlocks is the amount of compact blocks sent to peers
+	ServedBlocks uint64 `json:"servedblocks"`
+}
+
+// compactBlock is a block of which the transactions are replaced by their short IDs.
+type compactBlock struct {
+	ParentID     types.BlockID
+	Timestamp    types.Timestamp
+	POBSOutput   types.BlockStakeOutputIndexes
+	MinerPayouts []types.MinerPayout
+	ShortIDs     []uint64
+}
+
+// shortID returns the short ID of a transaction of the given block, salted with the block ID,
+// such that colliding transactions can't be created before the block is.
+func shortID(block types.BlockID, txn types.TransactionID) uint64 {
+	h := crypto.HashAll(block, txn)
+	return binary.LittleEndian.Uint64(h[:8])
+}
+
+// newCompactBlock creates the compact block of the given block.
+func newCompactBlock(b types.Block) compactBlock {
+	id := b.ID()
+	cb := compactBlock{
+		ParentID:     b.ParentID,
+		Timestamp:    b.Timestamp,
+		POBSOutput:   b.POBSOutput,
+		MinerPayouts: b.MinerPayouts,
+		ShortIDs:     make([]uint64, len(b.Transactions)),
+	}
+	for i, txn := range b.Transactions {
+		cb.ShortIDs[i] = shortID(id, txn.ID())
+	}
+	return cb
+}
+
+// reconstruct returns the block of the compact block, filled with the given transactions,
+// returning the indices of the transactions which are missing, or ambiguous.
+func (cb compactBlock) reconstruct(id types.BlockID, pool []types.Transaction) (types.Block, []uint64) {
+	txns := make(map[uint64]*types.Transaction, len(pool))
+	ambiguous := make(map[uint64]struct{})
+	for i := range pool {
+		sid := shortID(id, pool[i].ID())
+		if _, ok := txns[sid]; ok {
+			ambiguous[sid] = struct{}{}
+		}
+		txns[sid] = &pool[i]
+	}
+	b := types.Block{
+		ParentID:     cb.ParentID,
+		Timestamp:    cb.Timestamp,
+		POBSOutput:   cb.POBSOutput,
+		MinerPayouts: cb.MinerPayouts,
+		Transactions: make([]types.Transaction, len(cb.ShortIDs)),
+	}
+	var missing []uint64
+	for i, sid := range cb.ShortIDs {
+		txn, ok := txns[sid]
+		if _, amb := ambiguous[sid]; !ok || amb {
+			missing = append(missing, uint64(i))
+			continue
+		}
+		b.Transactions[i] = *txn
+	}
+	return b, missing
+}
+
+// Gateway wraps a gateway, fetching the blocks announced by upgraded peers as compact blocks,
+// instead of calling the SendBlk RPC of the consensus set, and serving compact blocks to its peers.
+// Compact blocks are only relayed once it is enabled, as it requires the transaction pool.
+type Gateway struct {
+	modules.Gateway
+
+	mu             sync.Mutex
+	cs             modules.ConsensusSet
+	tpool          modules.TransactionPool
+	blockSizeLimit uint64
+	unsupported    map[modules.NetAddress]time.Time
+	stats          Stats
+}
+
+var _ modules.Gateway = (*Gateway)(nil)
+
+// NewGateway creates a new Gateway, wrapping the given gateway.
+func NewGateway(g modules.Gateway) *Gateway {
+	return &Gateway{
+		Gateway:     g,
+		unsupported: make(map[modules.NetAddress]time.Time),
+	}
+}
+
+// Enable enables relaying compact blocks, reconstructing them from the transactions in the given transaction pool,
+// and serving the recent blocks of the given consensus set as compact blocks.
+func (g *Gateway) Enable(cs modules.ConsensusSet, tpool modules.TransactionPool, blockSizeLimit uint64) {
+	g.mu.Lock()
+	g.cs = cs
+	g.tpool = tpool
+	g.blockSizeLimit = blockSizeLimit
+	g.mu.Unlock()
+	g.Gateway.RegisterRPC(RPCSendCompactBlock, g.rpcSendCompactBlock)
+}
+
+// Stats returns the statistics of the compact blocks received and sent.
+func (g *Gateway) Stats() Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stats
+}
+
+// RPC implements modules.Gateway.RPC, fetching the blocks requested using the SendBlk RPC as compact blocks,
+// should the peer support them, falling back to the SendBlk RPC otherwise.
+func (g *Gateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	if name != rpcSendBlk || !g.supported(addr) {
+		return g.Gateway.RPC(addr, name, fn)
+	}
+	var fallback bool
+	err := g.Gateway.RPC(addr, RPCSendCompactBlock, func(conn modules.PeerConn) error {
+		var err error
+		fallback, err = g.receiveCompactBlock(conn, fn)
+		return err
+	})
+	if err != nil || !fallback {
+		return err
+	}
+	g.mu.Lock()
+	g.stats.FullBlocks++
+	g.mu.Unlock()
+	return g.Gateway.RPC(addr, name, fn)
+}
+
+// supported returns true if compact blocks are enabled, and the given peer isn't known to not support them.
+func (g *Gateway) supported(addr modules.NetAddress) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tpool == nil {
+		return false
+	}
+	since, ok := g.unsupported[addr]
+	if ok && time.Since(since) < unsupportedRetry {
+		g.stats.FullBlocks++
+		return false
+	}
+	delete(g.unsupported, addr)
+	return true
+}
+
+// receiveCompactBlock is the calling end of the SendCmpctBlk RPC, passing the reconstructed block
+// to the given calling end of the SendBlk RPC, returning true if the block has to be fetched in full instead.
+func (g *Gateway) receiveCompactBlock(conn modules.PeerConn, fn modules.RPCFunc) (bool, error) {
+	lc := newLocalConn(conn)
+	done := make(chan error, 1)
+	go func() {
+		err := fn(lc)
+		// unblock reading the block ID should the calling end return early
+		lc.reqW.Close()
+		done <- err
+	}()
+	block, fallback, err := g.fetchCompactBlock(conn, lc)
+	if err != nil || fallback {
+		lc.abort()
+		<-done
+		return fallback, err
+	}
+	// pass the block to the SendBlk RPC as if it was received from the peer
+	if err := lc.respond(block); err != nil {
+		<-done
+		return false, err
+	}
+	return false, <-done
+}
+
+// fetchCompactBlock fetches the block requested by the calling end of the SendBlk RPC as compact block.
+func (g *Gateway) fetchCompactBlock(conn modules.PeerConn, lc *localConn) (types.Block, bool, error) {
+	var id types.BlockID
+	if err := siabin.ReadObject(lc.requests, &id, crypto.HashSize); err != nil {
+		return types.Block{}, false, err
+	}
+	var found bool
+	err := siabin.WriteObject(conn, id)
+	if err == nil {
+		err = siabin.ReadObject(conn, &found, 1)
+	}
+	if err == io.EOF || err == io.ErrClosedPipe {
+		// the peer closed the connection without responding, as it doesn't know the RPC
+		g.mu.Lock()
+		g.unsupported[conn.RPCAddr()] = time.Now()
+		g.mu.Unlock()
+		return types.Block{}, true, nil
+	}
+	if err != nil {
+		return types.Block{}, false, err
+	}
+	if !found {
+		return types.Block{}, true, nil
+	}
+
+	g.mu.Lock()
+	tpool, blockSizeLimit := g.tpool, g.blockSizeLimit
+	g.mu.Unlock()
+	var cb compactBlock
+	if err := siabin.ReadObject(conn, &cb, blockSizeLimit); err != nil {
+		return types.Block{}, false, err
+	}
+	block, missing := cb.reconstruct(id, tpool.TransactionList())
+	if err := siabin.WriteObject(conn, missing); err != nil {
+		return types.Block{}, false, err
+	}
+	var txns []types.Transaction
+	if err := siabin.ReadObject(conn, &txns, blockSizeLimit); err != nil {
+		return types.Block{}, false, err
+	}
+	if len(txns) != len(missing) {
+		return types.Block{}, false, errMismatch
+	}
+	for i, index := range missing {
+		block.Transactions[index] = txns[i]
+	}
+	// fetch all transactions should the block not match, as short IDs of the pool can collide
+	resend := block.ID() != id
+	if err := siabin.WriteObject(conn, resend); err != nil {
+		return types.Block{}, false, err
+	}
+	fetched := uint64(len(missing))
+	if resend {
+		if err := siabin.ReadObject(conn, &block.Transactions, blockSizeLimit); err != nil {
+			return types.Block{}, false, err
+		}
+		if block.ID() != id {
+			return types.Block{}, false, errMismatch
+		}
+		fetched = uint64(len(block.Transactions))
+	}
+
+	g.mu.Lock()
+	g.stats.CompactBlocks++
+	g.stats.FetchedTransactions += fetched
+	g.stats.PoolTransactions += uint64(len(block.Transactions)) - fetched
+	g.mu.Unlock()
+	return block, false, nil
+}
+
+// rpcSendCompactBlock is the RPC sending a recent block of the current chain as compact block,
+// followed by the transactions requested by the peer.
+func (g *Gateway) rpcSendCompactBlock(conn modules.PeerConn) error {
+	var id types.BlockID
+	if err := siabin.ReadObject(conn, &id, crypto.HashSize); err != nil {
+		return err
+	}
+	block, found := g.recentBlock(id)
+	if err := siabin.WriteObject(conn, found); err != nil || !found {
+		return err
+	}
+	if err := siabin.WriteObject(conn, newCompactBlock(block)); err != nil {
+		return err
+	}
+	var missing []uint64
+	if err := siabin.ReadObject(conn, &missing, uint64(len(block.Transactions)+1)*8); err != nil {
+		return err
+	}
+	txns := make([]types.Transaction, len(missing))
+	for i, index := range missing {
+		if index >= uint64(len(block.Transactions)) {
+			return errors.New("requested transaction index out of range")
+		}
+		txns[i] = block.Transactions[index]
+	}
+	if err := siabin.WriteObject(conn, txns); err != nil {
+		return err
+	}
+	var resend bool
+	if err := siabin.ReadObject(conn, &resend, 1); err != nil {
+		return err
+	}
+	if resend {
+		if err := siabin.WriteObject(conn, block.Transactions); err != nil {
+			return err
+		}
+	}
+	g.mu.Lock()
+	g.stats.ServedBlocks++
+	g.mu.Unlock()
+	return nil
+}
+
+// recentBlock returns the block with the given ID, should it be one of the recent blocks of the current chain.
+func (g *Gateway) recentBlock(id types.BlockID) (types.Block, bool) {
+	g.mu.Lock()
+	cs := g.cs
+	g.mu.Unlock()
+	height := cs.Height()
+	for depth := types.BlockHeight(0); depth < recentDepth && depth <= height; depth++ {
+		block, ok := cs.BlockAtHeight(height - depth)
+		if ok && block.ID() == id {
+			return block, true
+		}
+	}
+	return types.Block{}, false
+}
+
+// localConn is the connection passed to the calling end of the SendBlk RPC,
+// receiving the ID of the block it requests, and responding with the reconstructed block.
+type localConn struct {
+	modules.PeerConn
+	requests *io.PipeReader
+	reqW     *io.PipeWriter
+	respR    *io.PipeReader
+	respW    *io.PipeWriter
+}
+
+func newLocalConn(conn modules.PeerConn) *localConn {
+	lc := &localConn{PeerConn: conn}
+	lc.requests, lc.reqW = io.Pipe()
+	lc.respR, lc.respW = io.Pipe()
+	return lc
+}
+
+func (lc *localConn) Read(b []byte) (int, error) {
+	return lc.respR.Read(b)
+}
+
+func (lc *localConn) Write(b []byte) (int, error) {
+	return lc.reqW.Write(b)
+}
+
+// Close closes the local connection only, the connection with the peer is closed once the RPC returns.
+func (lc *localConn) Close() error {
+	lc.reqW.Close()
+	lc.respR.Close()
+	return nil
+}
+
+// respond sends the given block to the calling end of the SendBlk RPC.
+func (lc *localConn) respond(block types.Block) error {
+	err := siabin.WriteObject(lc.respW, block)
+	lc.respW.Close()
+	// unblock the calling end should it write more than the block ID
+	lc.requests.Close()
+	return err
+}
+
+// abort fails the calling end of the SendBlk RPC.
+func (lc *localConn) abort() {
+	lc.requests.CloseWithError(io.ErrClosedPipe)
+	lc.respW.CloseWithError(io.ErrClosedPipe)
+}
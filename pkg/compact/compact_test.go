@@ -0,0 +1,206 @@
+package compact
+
+import (
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testConn struct {
+	net.Conn
+	addr modules.NetAddress
+}
+
+func (conn testConn) RPCAddr() modules.NetAddress { return conn.addr }
+
+// testGateway calls the RPCs on the handlers of its peer over an in-memory connection.
+type testGateway struct {
+	modules.Gateway
+	peer *testGateway
+
+	mu       sync.Mutex
+	handlers map[string]modules.RPCFunc
+	calls    map[string]int
+}
+
+func newTestGateways() (*testGateway, *testGateway) {
+	a := &testGateway{handlers: make(map[string]modules.RPCFunc), calls: make(map[string]int)}
+	b := &testGateway{handlers: make(map[string]modules.RPCFunc), calls: make(map[string]int)}
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+func (g *testGateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.handlers[name] = fn
+}
+
+func (g *testGateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	g.mu.Lock()
+	g.calls[name]++
+	g.mu.Unlock()
+	g.peer.mu.Lock()
+	handler, ok := g.peer.handlers[name]
+	g.peer.mu.Unlock()
+	local, remote := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer remote.Close()
+		if ok {
+			handler(testConn{Conn: remote, addr: "local:1"})
+		}
+	}()
+	err := fn(testConn{Conn: local, addr: addr})
+	local.Close()
+	// wait for the handler, such that its effects are visible once the RPC returns
+	<-done
+	return err
+}
+
+type testConsensusSet struct {
+	modules.ConsensusSet
+	blocks []types.Block
+}
+
+func (cs *testConsensusSet) Height() types.BlockHeight {
+	return types.BlockHeight(len(cs.blocks) - 1)
+}
+
+func (cs *testConsensusSet) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	if height >= types.BlockHeight(len(cs.blocks)) {
+		return types.Block{}, false
+	}
+	return cs.blocks[height], true
+}
+
+type testTransactionPool struct {
+	modules.TransactionPool
+	txns []types.Transaction
+}
+
+func (tpool *testTransactionPool) TransactionList() []types.Transaction {
+	return append([]types.Transaction(nil), tpool.txns...)
+}
+
+func testTransaction(i byte) types.Transaction {
+	return types.Transaction{Version: types.TransactionVersionOne, ArbitraryData: []byte{i}}
+}
+
+// receiveBlock returns the calling end of the SendBlk RPC, as implemented by the consensus set,
+// storing the received block.
+func receiveBlock(id types.BlockID, received *types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		if err := siabin.WriteObject(conn, id); err != nil {
+			return err
+		}
+		return siabin.ReadObject(conn, received, 1e6)
+	}
+}
+
+// sendBlock is the SendBlk RPC of the consensus set, sending any of the given blocks.
+func sendBlock(blocks ...types.Block) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		var id types.BlockID
+		if err := siabin.ReadObject(conn, &id, crypto.HashSize); err != nil {
+			return err
+		}
+		for _, b := range blocks {
+			if b.ID() == id {
+				return siabin.WriteObject(conn, b)
+			}
+		}
+		return modules.ErrBlockUnsolved
+	}
+}
+
+func TestCompactBlocks(t *testing.T) {
+	block := types.Block{
+		Timestamp:    1234,
+		MinerPayouts: []types.MinerPayout{{Value: types.NewCurrency64(10)}},
+		Transactions: []types.Transaction{testTransaction(1), testTransaction(2), testTransaction(3)},
+	}
+	fork := types.Block{Timestamp: 5678, Transactions: []types.Transaction{testTransaction(4)}}
+
+	a, b := newTestGateways()
+	receiver, sender := NewGateway(a), NewGateway(b)
+	receiver.Enable(&testConsensusSet{}, &testTransactionPool{txns: []types.Transaction{testTransaction(3), testTransaction(1)}}, 1e6)
+	sender.Enable(&testConsensusSet{blocks: []types.Block{{}, block}}, &testTransactionPool{}, 1e6)
+	b.RegisterRPC(rpcSendBlk, sendBlock(block, fork))
+
+	// the transactions in the pool aren't fetched
+	var received types.Block
+	err := receiver.RPC("remote:1", rpcSendBlk, receiveBlock(block.ID(), &received))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.ID() != block.ID() {
+		t.Fatal("received another block:", received)
+	}
+	stats := receiver.Stats()
+	if stats.CompactBlocks != 1 || stats.PoolTransactions != 2 || stats.FetchedTransactions != 1 || stats.FullBlocks != 0 {
+		t.Error("unexpected receiver stats:", stats)
+	}
+	if stats := sender.Stats(); stats.ServedBlocks != 1 {
+		t.Error("unexpected sender stats:", stats)
+	}
+
+	// blocks which aren't part of the recent blocks of the current chain are fetched in full
+	received = types.Block{}
+	err = receiver.RPC("remote:1", rpcSendBlk, receiveBlock(fork.ID(), &received))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if received.ID() != fork.ID() {
+		t.Fatal("received another block:", received)
+	}
+	if stats := receiver.Stats(); stats.CompactBlocks != 1 || stats.FullBlocks != 1 {
+		t.Error("unexpected receiver stats:", stats)
+	}
+}
+
+func TestCompactBlocksUnsupported(t *testing.T) {
+	block := types.Block{Timestamp: 1234, Transactions: []types.Transaction{testTransaction(1)}}
+
+	a, b := newTestGateways()
+	receiver := NewGateway(a)
+	receiver.Enable(&testConsensusSet{}, &testTransactionPool{}, 1e6)
+	b.RegisterRPC(rpcSendBlk, sendBlock(block))
+
+	// peers not knowing the RPC are sent the SendBlk RPC, and aren't tried again
+	for i := 0; i < 2; i++ {
+		var received types.Block
+		err := receiver.RPC("remote:1", rpcSendBlk, receiveBlock(block.ID(), &received))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if received.ID() != block.ID() {
+			t.Fatal("received another block:", received)
+		}
+	}
+	if a.calls[RPCSendCompactBlock] != 1 || a.calls[rpcSendBlk] != 2 {
+		t.Error("unexpected RPC calls:", a.calls)
+	}
+	if stats := receiver.Stats(); stats.CompactBlocks != 0 || stats.FullBlocks != 2 {
+		t.Error("unexpected receiver stats:", stats)
+	}
+}
+
+func TestReconstruct(t *testing.T) {
+	block := types.Block{Transactions: []types.Transaction{testTransaction(1), testTransaction(2), testTransaction(3)}}
+	cb := newCompactBlock(block)
+	reconstructed, missing := cb.reconstruct(block.ID(), []types.Transaction{testTransaction(3), testTransaction(4), testTransaction(1)})
+	if len(missing) != 1 || missing[0] != 1 {
+		t.Fatal("expected the second transaction to be missing, got", missing)
+	}
+	reconstructed.Transactions[1] = testTransaction(2)
+	if reconstructed.ID() != block.ID() {
+		t.Error("the reconstructed block does not match")
+	}
+}
@@ -0,0 +1,198 @@
+package config
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ErrNilAddress is returned for the nil address, of which the outputs can be spent by anyone.
+var ErrNilAddress = errors.New("the nil address cannot receive coins, as anyone can spend the coins sent to it")
+
+// addressLength is the length of a hex-encoded address:
+// 1 byte for the unlock type, 32 for the hash and 6 for the checksum.
+const addressLength = (1 + crypto.HashSize + types.UnlockHashChecksumSize) * 2
+
+// ParseAddress parses the given address, refusing addresses coins are not expected to be sent to on the network.
+// Addresses of other blockchains and mistyped addresses are detected where possible, explaining why they are refused.
+// Addresses of other Rivine-based blockchains, as well as of the other networks of this blockchain,
+// share the same format however, and cannot be told apart.
+func (nd NetworkDescriptor) ParseAddress(str string) (types.UnlockHash, error) {
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return types.UnlockHash{}, errors.New("no address given")
+	}
+	if len(str) == addressLength-2 && isHex(str) {
+		// a Rivine address of which the unlock type got lost, e.g. when copying it
+		for _, prefix := range nd.AddressPrefixes {
+			var uh types.UnlockHash
+			if uh.LoadString(prefix+str) == nil {
+				return types.UnlockHash{}, fmt.Errorf("%s lacks the unlock type of the address, did you mean %s?", str, prefix+str)
+			}
+		}
+	}
+	if chain := ForeignAddressChain(str); chain != "" {
+		return types.UnlockHash{}, fmt.Errorf("%s looks like an address of %s, which cannot receive %s", str, chain, nd.CoinUnit)
+	}
+	if !isHex(str) {
+		return types.UnlockHash{}, fmt.Errorf("%s is not a %s address, as those are hex-encoded", str, nd.CoinUnit)
+	}
+	if len(str) != addressLength {
+		return types.UnlockHash{}, fmt.Errorf("%s is not a %s address, as it consists of %d characters instead of %d",
+			str, nd.CoinUnit, len(str), addressLength)
+	}
+	var uh types.UnlockHash
+	if err := uh.LoadString(str); err != nil {
+		if err == types.ErrInvalidUnlockHashChecksum {
+			return types.UnlockHash{}, fmt.Errorf("%s has an invalid checksum: the address is mistyped", str)
+		}
+		return types.UnlockHash{}, fmt.Errorf("invalid address %s: %v", str, err)
+	}
+	return uh, nd.ValidateAddress(uh)
+}
+
+// AddressDiagnosis explains whether a string is an address which can receive coins on the network,
+// as returned by NetworkDescriptor.DiagnoseAddress.
+type AddressDiagnosis struct {
+	Valid bool `json:"valid"`
+	// Address is the parsed address, only defined in case the string is formatted as an address with a valid checksum,
+	// other than the nil address
+	Address *types.UnlockHash `json:"address,omitempty"`
+	// Type is the unlock type of the address (nil, pubkey, atomicswap, multisig or unknown),
+	// only defined in case the string is formatted as an address
+	Type string `json:"type,omitempty"`
+	// Checksum is only defined in case the string is formatted as an address
+	Checksum *ChecksumDiagnosis `json:"checksum,omitempty"`
+	// ForeignChain is the name of the blockchain the string looks like an address of, if any
+	ForeignChain string `json:"foreignchain,omitempty"`
+	// Error explains why the address is invalid
+	Error string `json:"error,omitempty"`
+}
+
+// ChecksumDiagnosis compares the checksum of an address to the checksum expected for its unlock type and hash.
+type ChecksumDiagnosis struct {
+	Valid    bool   `json:"valid"`
+	Given    string `json:"given"`
+	Expected string `json:"expected"`
+}
+
+// DiagnoseAddress parses the given address as ParseAddress does, explaining its type and checksum
+// should it be formatted as an address, such that forms can point out what is wrong with it.
+func (nd NetworkDescriptor) DiagnoseAddress(str string) AddressDiagnosis {
+	str = strings.TrimSpace(str)
+	var diagnosis AddressDiagnosis
+	uh, err := nd.ParseAddress(str)
+	if err != nil {
+		diagnosis.Error = err.Error()
+	} else {
+		diagnosis.Valid = true
+	}
+	diagnosis.ForeignChain = ForeignAddressChain(str)
+	if len(str) != addressLength || !isHex(str) {
+		return diagnosis
+	}
+	b, _ := hex.DecodeString(str)
+	unlockType := types.UnlockType(b[0])
+	diagnosis.Type = unlockTypeName(unlockType)
+	var hash crypto.Hash
+	copy(hash[:], b[1:1+crypto.HashSize])
+	// the nil address has an empty checksum
+	expected := make([]byte, types.UnlockHashChecksumSize)
+	if unlockType != types.UnlockTypeNil {
+		checksum := crypto.HashAll(unlockType, hash)
+		copy(expected, checksum[:])
+	}
+	diagnosis.Checksum = &ChecksumDiagnosis{
+		Given:    hex.EncodeToString(b[1+crypto.HashSize:]),
+		Expected: hex.EncodeToString(expected),
+	}
+	diagnosis.Checksum.Valid = diagnosis.Checksum.Given == diagnosis.Checksum.Expected
+	if diagnosis.Checksum.Valid && unlockType != types.UnlockTypeNil {
+		uh = types.UnlockHash{Type: unlockType, Hash: hash}
+		diagnosis.Address = &uh
+	}
+	return diagnosis
+}
+
+func unlockTypeName(unlockType types.UnlockType) string {
+	switch unlockType {
+	case types.UnlockTypeNil:
+		return "nil"
+	case types.UnlockTypePubKey:
+		return "pubkey"
+	case types.UnlockTypeAtomicSwap:
+		return "atomicswap"
+	case types.UnlockTypeMultiSig:
+		return "multisig"
+	default:
+		return "unknown"
+	}
+}
+
+// ValidateAddress returns an error should coins not be expected to be sent to the given address on the network,
+// based on its unlock type.
+func (nd NetworkDescriptor) ValidateAddress(uh types.UnlockHash) error {
+	if uh.Type == types.UnlockTypeNil {
+		return ErrNilAddress
+	}
+	prefix := fmt.Sprintf("%02x", uh.Type)
+	for _, addressPrefix := range nd.AddressPrefixes {
+		if addressPrefix == prefix {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has unlock type %d, while %s addresses start with %s",
+		uh.String(), uh.Type, nd.CoinUnit, strings.Join(nd.AddressPrefixes, " or "))
+}
+
+// ForeignAddressChain returns the name of the blockchain the given string looks like an address of,
+// or an empty string should it not look like an address of any of the well-known blockchains.
+func ForeignAddressChain(str string) string {
+	switch {
+	case len(str) == 42 && (strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X")) && isHex(str[2:]):
+		return "Ethereum"
+	case len(str) == 56 && str[0] == 'G' && isCharset(str, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"):
+		return "Stellar"
+	case strings.HasPrefix(str, "bc1") || strings.HasPrefix(str, "tb1"):
+		return "Bitcoin"
+	case len(str) >= 26 && len(str) <= 35 && (str[0] == '1' || str[0] == '3') && !isHex(str) &&
+		isCharset(str, "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"):
+		return "Bitcoin"
+	case isSiaAddress(str):
+		return "Sia"
+	default:
+		return ""
+	}
+}
+
+// isSiaAddress returns true in case the given string is a hex-encoded hash,
+// followed by the checksum Sia addresses use, which unlike Rivine addresses have no unlock type.
+func isSiaAddress(str string) bool {
+	if len(str) != (crypto.HashSize+types.UnlockHashChecksumSize)*2 {
+		return false
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return false
+	}
+	checksum := crypto.HashBytes(b[:crypto.HashSize])
+	return bytes.Equal(checksum[:types.UnlockHashChecksumSize], b[crypto.HashSize:])
+}
+
+func isHex(str string) bool {
+	return isCharset(str, "0123456789abcdefABCDEF")
+}
+
+func isCharset(str, charset string) bool {
+	for _, r := range str {
+		if !strings.ContainsRune(charset, r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+//go:generate go run gen_checkpoints.go -network testnet -out checkpoints_testnet.go
+
+// GetStandardnetCheckpoints returns the block IDs embedded in this release for the standard network, by height.
+func GetStandardnetCheckpoints() map[types.BlockHeight]types.BlockID {
+	return nil
+}
+
+// GetTestnetCheckpoints returns the block IDs embedded in this release for the testnet, by height,
+// as generated from the public explorers of the testnet using 'make checkpoints'.
+func GetTestnetCheckpoints() map[types.BlockHeight]types.BlockID {
+	return checkpointsFromHex(testnetCheckpoints)
+}
+
+// GetDevnetCheckpoints returns the block IDs embedded in this release for the devnet, by height,
+// of which there are none, as every devnet has its own blockchain.
+func GetDevnetCheckpoints() map[types.BlockHeight]types.BlockID {
+	return nil
+}
+
+func checkpointsFromHex(checkpoints map[types.BlockHeight]string) map[types.BlockHeight]types.BlockID {
+	ids := make(map[types.BlockHeight]types.BlockID, len(checkpoints))
+	for height, hstr := range checkpoints {
+		var hash crypto.Hash
+		err := hash.LoadString(hstr)
+		if err != nil {
+			panic(fmt.Sprintf("func checkpointsFromHex(%d: %s) failed: %v", height, hstr, err))
+		}
+		ids[height] = types.BlockID(hash)
+	}
+	return ids
+}
@@ -52,6 +52,9 @@ func GetBlockchainInfo() types.BlockchainInfo {
 func GetStandardnetGenesis() types.ChainConstants {
 	cfg := types.StandardnetChainConstants()
 
+	// currency units are defined by the network descriptor
+	cfg.CurrencyUnits = GetStandardNetworkDescriptor().CurrencyUnits()
+
 	// set transaction versions
 	cfg.DefaultTransactionVersion = types.TransactionVersionOne
 	cfg.GenesisTransactionVersion = types.TransactionVersionOne
@@ -85,6 +88,11 @@ func GetStandardnetGenesis() types.ChainConstants {
 	// Use 0.001 coins as minimum transaction fee
 	cfg.MinimumTransactionFee = cfg.CurrencyUnits.OneCoin.Div64(1000)
 
+	// 2MB blocks, with up to 83 bytes of arbitrary data per transaction,
+	// as changed by the hard forks scheduled for the network
+	cfg.BlockSizeLimit = 2e6
+	cfg.ArbitraryDataSizeLimit = 83
+
 	// Foundation receives all transactions fees in a single pool address,
 	cfg.TransactionFeeCondition = types.NewCondition(types.NewUnlockHashCondition(unlockHashFromHex(
 		"")))
@@ -115,6 +123,9 @@ func GetStandardnetGenesis() types.ChainConstants {
 func GetTestnetGenesis() types.ChainConstants {
 	cfg := types.TestnetChainConstants()
 
+	// currency units are defined by the network descriptor
+	cfg.CurrencyUnits = GetTestnetNetworkDescriptor().CurrencyUnits()
+
 	// set transaction versions
 	cfg.DefaultTransactionVersion = types.TransactionVersionOne
 	cfg.GenesisTransactionVersion = types.TransactionVersionOne
@@ -148,6 +159,11 @@ func GetTestnetGenesis() types.ChainConstants {
 	// Use 0.001 coins as minimum transaction fee
 	cfg.MinimumTransactionFee = cfg.CurrencyUnits.OneCoin.Div64(1000)
 
+	// 2MB blocks, with up to 83 bytes of arbitrary data per transaction,
+	// as changed by the hard forks scheduled for the network
+	cfg.BlockSizeLimit = 2e6
+	cfg.ArbitraryDataSizeLimit = 83
+
 	// Start with 100M coins
 	cfg.GenesisCoinDistribution = []types.CoinOutput{
 		{
@@ -175,6 +191,9 @@ func GetTestnetGenesis() types.ChainConstants {
 func GetDevnetGenesis() types.ChainConstants {
 	cfg := types.DevnetChainConstants()
 
+	// currency units are defined by the network descriptor
+	cfg.CurrencyUnits = GetDevnetNetworkDescriptor().CurrencyUnits()
+
 	// set transaction versions
 	cfg.DefaultTransactionVersion = types.TransactionVersionOne
 	cfg.GenesisTransactionVersion = types.TransactionVersionOne
@@ -212,6 +231,11 @@ func GetDevnetGenesis() types.ChainConstants {
 	// Use 0.1 coins as minimum transaction fee
 	cfg.MinimumTransactionFee = cfg.CurrencyUnits.OneCoin.Mul64(1)
 
+	// 2MB blocks, with up to 83 bytes of arbitrary data per transaction,
+	// as changed by the hard forks scheduled for the network
+	cfg.BlockSizeLimit = 2e6
+	cfg.ArbitraryDataSizeLimit = 83
+
 	// distribute initial coins
 	cfg.GenesisCoinDistribution = []types.CoinOutput{
 		{
@@ -272,6 +296,10 @@ func GetTestnetBootstrapPeers() []modules.NetAddress {
 	}
 }
 
+// DevnetGenesisMnemonic is the mnemonic of the wallet owning the devnet genesis coins and block stakes,
+// which fulfills the genesis mint and auth conditions of the devnet as well.
+const DevnetGenesisMnemonic = "carbon boss inject cover mountain fetch fiber fit tornado cloth wing dinosaur proof joy intact fabric thumb rebel borrow poet chair network expire else"
+
 // GetDevnetGenesisAuthCoinCondition returns the genesis auth condition used for the devnet
 func GetDevnetGenesisAuthCoinCondition() types.UnlockConditionProxy {
 	return types.NewCondition(types.NewUnlockHashCondition(unlockHashFromHex("015a080a9259b9d4aaa550e2156f49b1a79a64c7ea463d810d4493e8242e6791584fbdac553e6f")))
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// minBlockSizeLimit is the smallest block size limit a network can use,
+// as 5KB of every block is reserved for the block header, miner payouts and block creating transaction.
+const minBlockSizeLimit = 10e3
+
+// Limits are the size limits the blocks and transactions of a network have to respect.
+type Limits struct {
+	// BlockSizeLimit is the maximum size of a block in bytes
+	BlockSizeLimit uint64 `json:"blocksizelimit,omitempty"`
+	// ArbitraryDataSizeLimit is the maximum size of the arbitrary data of a transaction in bytes
+	ArbitraryDataSizeLimit uint64 `json:"arbitrarydatasizelimit,omitempty"`
+}
+
+// LimitsOf returns the limits defined by the given chain constants.
+func LimitsOf(constants types.ChainConstants) Limits {
+	return Limits{
+		BlockSizeLimit:         constants.BlockSizeLimit,
+		ArbitraryDataSizeLimit: constants.ArbitraryDataSizeLimit,
+	}
+}
+
+// Fork is a hard fork of a network, changing its limits from the block at the activation height onwards.
+// Limits which are not defined (zero) keep the value they had prior to the fork,
+// such that a fork can also only activate the transaction versions mapped to its name.
+type Fork struct {
+	// Name identifies the fork in logs and the API
+	Name string `json:"name"`
+	// Height is the height of the first block the limits of the fork apply to
+	Height types.BlockHeight `json:"height"`
+	Limits
+}
+
+// ForkSchedule is the hard fork activation table of a network,
+// defining which limits apply to the block at any given height.
+//
+// All nodes of a network have to use the same schedule, or they will reject each other's blocks
+// once the first fork they disagree on activates. Forks are therefore scheduled in a release
+// well ahead of their activation height, giving everyone sufficient time to upgrade.
+type ForkSchedule struct {
+	genesis Limits
+	// forks are sorted by height, with all their limits defined
+	forks []Fork
+}
+
+// NewForkSchedule creates the fork schedule of a network, starting with the given limits at the genesis block.
+// Forks have to be named and scheduled at distinct heights after the genesis block,
+// and the limits in effect after each fork have to be sane.
+func NewForkSchedule(genesis Limits, forks []Fork) (ForkSchedule, error) {
+	err := genesis.validate()
+	if err != nil {
+		return ForkSchedule{}, fmt.Errorf("invalid genesis limits: %v", err)
+	}
+	sorted := append([]Fork(nil), forks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Height < sorted[j].Height
+	})
+	schedule := ForkSchedule{genesis: genesis, forks: make([]Fork, 0, len(sorted))}
+	previous := genesis
+	for i, fork := range sorted {
+		if fork.Name == "" {
+			return ForkSchedule{}, fmt.Errorf("invalid fork at height %d: has no name", fork.Height)
+		}
+		if fork.Height == 0 {
+			return ForkSchedule{}, fmt.Errorf("invalid fork %q: cannot activate at the genesis block", fork.Name)
+		}
+		if i > 0 && fork.Height == sorted[i-1].Height {
+			return ForkSchedule{}, fmt.Errorf("invalid fork %q: fork %q activates at the same height %d", fork.Name, sorted[i-1].Name, fork.Height)
+		}
+		if fork.BlockSizeLimit == 0 {
+			fork.BlockSizeLimit = previous.BlockSizeLimit
+		}
+		if fork.ArbitraryDataSizeLimit == 0 {
+			fork.ArbitraryDataSizeLimit = previous.ArbitraryDataSizeLimit
+		}
+		err = fork.Limits.validate()
+		if err != nil {
+			return ForkSchedule{}, fmt.Errorf("invalid fork %q: %v", fork.Name, err)
+		}
+		schedule.forks = append(schedule.forks, fork)
+		previous = fork.Limits
+	}
+	return schedule, nil
+}
+
+func (limits Limits) validate() error {
+	if limits.BlockSizeLimit < minBlockSizeLimit {
+		return fmt.Errorf("block size limit of %d bytes is below the minimum of %d bytes", limits.BlockSizeLimit, uint64(minBlockSizeLimit))
+	}
+	if limits.ArbitraryDataSizeLimit == 0 {
+		return errors.New("arbitrary data size limit cannot be zero")
+	}
+	if limits.ArbitraryDataSizeLimit >= limits.BlockSizeLimit {
+		return fmt.Errorf("arbitrary data size limit of %d bytes does not fit in a block of %d bytes", limits.ArbitraryDataSizeLimit, limits.BlockSizeLimit)
+	}
+	return nil
+}
+
+// LimitsAt returns the limits which apply to the block at the given height.
+func (schedule ForkSchedule) LimitsAt(height types.BlockHeight) Limits {
+	limits := schedule.genesis
+	for _, fork := range schedule.forks {
+		if fork.Height > height {
+			break
+		}
+		limits = fork.Limits
+	}
+	return limits
+}
+
+// MaxLimits returns the highest limits which apply at any height, one limit at a time.
+// These are the limits the chain constants are set to, as the consensus set enforces those at every height.
+func (schedule ForkSchedule) MaxLimits() Limits {
+	limits := schedule.genesis
+	for _, fork := range schedule.forks {
+		if fork.BlockSizeLimit > limits.BlockSizeLimit {
+			limits.BlockSizeLimit = fork.BlockSizeLimit
+		}
+		if fork.ArbitraryDataSizeLimit > limits.ArbitraryDataSizeLimit {
+			limits.ArbitraryDataSizeLimit = fork.ArbitraryDataSizeLimit
+		}
+	}
+	return limits
+}
+
+// Genesis returns the limits which apply from the genesis block until the first fork.
+func (schedule ForkSchedule) Genesis() Limits {
+	return schedule.genesis
+}
+
+// Forks returns the forks of the schedule, lowest height first, with all their limits defined.
+func (schedule ForkSchedule) Forks() []Fork {
+	return append([]Fork(nil), schedule.forks...)
+}
+
+// Names of the hard forks activating the transaction versions added after the genesis block,
+// mapped to these versions by the transaction version registry of pkg/types.
+const (
+	ForkExpiringTransactions = "expiring transactions"
+	ForkBlockStakeDelegation = "blockstake delegation"
+	ForkGovernanceVotes      = "governance votes"
+)
+
+// GetStandardnetForks returns the hard forks scheduled for the standard network.
+func GetStandardnetForks() []Fork {
+	return []Fork{
+		{Name: ForkExpiringTransactions, Height: 10000},
+		{Name: ForkBlockStakeDelegation, Height: 20000},
+		{Name: ForkGovernanceVotes, Height: 30000},
+	}
+}
+
+// GetTestnetForks returns the hard forks scheduled for the testnet.
+func GetTestnetForks() []Fork {
+	return []Fork{
+		{Name: ForkExpiringTransactions, Height: 450000},
+		{Name: ForkBlockStakeDelegation, Height: 460000},
+		{Name: ForkGovernanceVotes, Height: 470000},
+	}
+}
+
+// GetDevnetForks returns the hard forks scheduled for the devnet,
+// activating the transaction versions within minutes, such that their activation can be observed.
+// Other forks can be scheduled using the chain constants overrides instead.
+func GetDevnetForks() []Fork {
+	return []Fork{
+		{Name: ForkExpiringTransactions, Height: 10},
+		{Name: ForkBlockStakeDelegation, Height: 20},
+		{Name: ForkGovernanceVotes, Height: 30},
+	}
+}
@@ -0,0 +1,168 @@
+//go:build ignore
+// +build ignore
+
+// gen_checkpoints generates the checkpoints embedded in a release,
+// being the IDs of the blocks at a regular interval which all given explorers agree upon,
+// old enough that no honest reorganization can replace them.
+//
+// Usage (from the pkg/config directory, or using 'make checkpoints'):
+//
+//	go run gen_checkpoints.go -network testnet -out checkpoints_testnet.go [-explorer https://...]
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type explorers []string
+
+func (e *explorers) String() string     { return strings.Join(*e, ",") }
+func (e *explorers) Set(s string) error { *e = append(*e, s); return nil }
+
+var (
+	network  = config.NetworkNameTest
+	out      string
+	sources  explorers
+	interval = types.BlockHeight(10000)
+	age      = 7 * 24 * time.Hour
+)
+
+func main() {
+	var intervalFlag uint64
+	flag.StringVar(&network, "network", network, "network of which the checkpoints are generated")
+	flag.StringVar(&out, "out", out, "generated Go file, printed to the standard output if not set")
+	flag.Var(&sources, "explorer", "root URL of an explorer to query, can be repeated, defaults to the public explorers of the network")
+	flag.Uint64Var(&intervalFlag, "interval", uint64(interval), "amount of blocks between two checkpoints")
+	flag.DurationVar(&age, "age", age, "minimum age of a checkpointed block, as estimated from the block frequency")
+	flag.Parse()
+	if intervalFlag == 0 {
+		log.Fatal("[ERROR] the interval has to be at least 1 block")
+	}
+	interval = types.BlockHeight(intervalFlag)
+
+	var constants types.ChainConstants
+	switch network {
+	case config.NetworkNameStandard:
+		constants = config.GetStandardnetGenesis()
+	case config.NetworkNameTest:
+		constants = config.GetTestnetGenesis()
+	default:
+		log.Fatalf("[ERROR] checkpoints cannot be embedded for the %s", network)
+	}
+	if len(sources) == 0 {
+		descriptor, err := config.GetNetworkDescriptor(network)
+		if err != nil {
+			log.Fatal("[ERROR] ", err)
+		}
+		sources = descriptor.ExplorerURLs
+	}
+	if len(sources) == 0 {
+		log.Fatalf("[ERROR] the %s has no public explorers, define them using -explorer", network)
+	}
+	clients := make([]*api.HTTPClient, 0, len(sources))
+	for _, source := range sources {
+		clients = append(clients, &api.HTTPClient{
+			RootURL:   strings.TrimSuffix(source, "/"),
+			UserAgent: daemon.RivineUserAgent,
+		})
+	}
+
+	// only blocks which are old enough on all explorers are checkpointed
+	var tip types.BlockHeight
+	for i, client := range clients {
+		var resp api.ExplorerGET
+		err := client.GetAPI("/explorer", &resp)
+		if err != nil {
+			log.Fatalf("[ERROR] failed to get the height of %s: %v", sources[i], err)
+		}
+		if i == 0 || resp.Height < tip {
+			tip = resp.Height
+		}
+	}
+	depth := types.BlockHeight(age / (time.Duration(constants.BlockFrequency) * time.Second))
+	if tip < depth {
+		log.Fatalf("[ERROR] the blockchain (height %d) has no blocks older than %v", tip, age)
+	}
+
+	checkpoints := make(map[types.BlockHeight]types.BlockID)
+	for height := interval; height <= tip-depth; height += interval {
+		var id types.BlockID
+		for i, client := range clients {
+			// only the ID is decoded, such that the transactions don't need to be registered
+			var resp struct {
+				Block struct {
+					BlockID types.BlockID `json:"blockid"`
+				} `json:"block"`
+			}
+			err := client.GetAPI(fmt.Sprintf("/explorer/blocks/%d", height), &resp)
+			if err != nil {
+				log.Fatalf("[ERROR] failed to get block %d from %s: %v", height, sources[i], err)
+			}
+			if i > 0 && resp.Block.BlockID != id {
+				log.Fatalf("[ERROR] explorers %s and %s disagree on block %d: %s != %s",
+					sources[0], sources[i], height, id.String(), resp.Block.BlockID.String())
+			}
+			id = resp.Block.BlockID
+		}
+		checkpoints[height] = id
+	}
+	log.Printf("[INFO] %d checkpoints of the %s, up to height %d, verified using %d explorer(s)\n",
+		len(checkpoints), network, tip-depth, len(clients))
+
+	b, err := generate(network, checkpoints)
+	if err != nil {
+		log.Fatal("[ERROR] failed to generate the checkpoints: ", err)
+	}
+	if out == "" {
+		fmt.Print(string(b))
+		return
+	}
+	err = ioutil.WriteFile(out, b, 0644)
+	if err != nil {
+		log.Fatal("[ERROR] failed to write the checkpoints: ", err)
+	}
+}
+
+// generate returns the formatted Go source defining the checkpoints of the given network.
+func generate(network string, checkpoints map[types.BlockHeight]types.BlockID) ([]byte, error) {
+	heights := make([]types.BlockHeight, 0, len(checkpoints))
+	for height := range checkpoints {
+		heights = append(heights, height)
+	}
+	sort.Slice(heights, func(i, j int) bool {
+		return heights[i] < heights[j]
+	})
+	name := "testnet"
+	if network == config.NetworkNameStandard {
+		name = "standardnet"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by gen_checkpoints.go; DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "package config")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, `import "github.com/threefoldtech/rivine/types"`)
+	fmt.Fprintln(&buf)
+	fmt.Fprintf(&buf, "// %sCheckpoints are the hex-encoded block IDs of the %s, by height,\n", name, network)
+	fmt.Fprintf(&buf, "// which all queried explorers of the %s agreed upon when generated.\n", network)
+	fmt.Fprintf(&buf, "var %sCheckpoints = map[types.BlockHeight]string{\n", name)
+	for _, height := range heights {
+		fmt.Fprintf(&buf, "%d: %q,\n", height, checkpoints[height].String())
+	}
+	fmt.Fprintln(&buf, "}")
+	return format.Source(buf.Bytes())
+}
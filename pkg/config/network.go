@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// NetworkDescriptor describes how the coins, addresses and public services of a network are presented,
+// and is the single source of truth for the daemon, CLI, faucet and explorer.
+type NetworkDescriptor struct {
+	NetworkName string `json:"networkname"`
+	// CoinUnit is the display unit of one coin
+	CoinUnit string `json:"coinunit"`
+	// CoinPrecision is the amount of decimals of one coin,
+	// one coin being 10^CoinPrecision in the smallest currency unit
+	CoinPrecision uint `json:"coinprecision"`
+	// BlockStakeUnit is the display unit of one block stake
+	BlockStakeUnit string `json:"blockstakeunit"`
+	// AddressPrefixes are the hex-encoded unlock types of the addresses coins are expected to be sent to
+	AddressPrefixes []string `json:"addressprefixes"`
+	// ExplorerURLs are the root URLs of the public explorers, the first one being preferred
+	ExplorerURLs []string `json:"explorerurls"`
+	// FaucetURL is the root URL of the public faucet, if any
+	FaucetURL string `json:"fauceturl,omitempty"`
+}
+
+// CurrencyUnits returns the currency units defined by the coin precision.
+func (nd NetworkDescriptor) CurrencyUnits() types.CurrencyUnits {
+	return types.CurrencyUnits{
+		OneCoin: types.NewCurrency(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(nd.CoinPrecision)), nil)),
+	}
+}
+
+// TransactionURL returns the URL of the given transaction on the preferred explorer,
+// or an empty string in case the network has no public explorer.
+func (nd NetworkDescriptor) TransactionURL(id types.TransactionID) string {
+	if len(nd.ExplorerURLs) == 0 {
+		return ""
+	}
+	return nd.ExplorerURLs[0] + "/hash.html?hash=" + id.String()
+}
+
+// GetNetworkDescriptor returns the descriptor of the network with the given name.
+func GetNetworkDescriptor(networkName string) (NetworkDescriptor, error) {
+	switch networkName {
+	case NetworkNameStandard:
+		return GetStandardNetworkDescriptor(), nil
+	case NetworkNameTest:
+		return GetTestnetNetworkDescriptor(), nil
+	case NetworkNameDev:
+		return GetDevnetNetworkDescriptor(), nil
+	default:
+		return NetworkDescriptor{}, fmt.Errorf("network name %q not recognized", networkName)
+	}
+}
+
+// GetStandardNetworkDescriptor returns the descriptor of the standard (prod) network
+func GetStandardNetworkDescriptor() NetworkDescriptor {
+	return NetworkDescriptor{
+		NetworkName:     NetworkNameStandard,
+		CoinUnit:        GolchainTokenUnit,
+		CoinPrecision:   9,
+		BlockStakeUnit:  "BS",
+		AddressPrefixes: defaultAddressPrefixes(),
+		// TODO: define the public explorer once the standard network is launched
+		ExplorerURLs: nil,
+	}
+}
+
+// GetTestnetNetworkDescriptor returns the descriptor of the testnet
+func GetTestnetNetworkDescriptor() NetworkDescriptor {
+	return NetworkDescriptor{
+		NetworkName:     NetworkNameTest,
+		CoinUnit:        GolchainTokenUnit,
+		CoinPrecision:   9,
+		BlockStakeUnit:  "BS",
+		AddressPrefixes: defaultAddressPrefixes(),
+		ExplorerURLs: []string{
+			"https://explorer.testnet.nbh-digital.com",
+		},
+		// TODO: define the public faucet URL
+		FaucetURL: "",
+	}
+}
+
+// GetDevnetNetworkDescriptor returns the descriptor of the devnet,
+// of which the explorer and faucet run locally using their default configuration.
+func GetDevnetNetworkDescriptor() NetworkDescriptor {
+	return NetworkDescriptor{
+		NetworkName:     NetworkNameDev,
+		CoinUnit:        GolchainTokenUnit,
+		CoinPrecision:   9,
+		BlockStakeUnit:  "BS",
+		AddressPrefixes: defaultAddressPrefixes(),
+		ExplorerURLs: []string{
+			"http://localhost:2015",
+		},
+		FaucetURL: "http://localhost:2020",
+	}
+}
+
+// defaultAddressPrefixes returns the prefixes of single signature and multisig addresses.
+func defaultAddressPrefixes() []string {
+	return []string{
+		fmt.Sprintf("%02x", types.UnlockTypePubKey),
+		fmt.Sprintf("%02x", types.UnlockTypeMultiSig),
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestNetworkDescriptors(t *testing.T) {
+	testCases := []struct {
+		network   NetworkDescriptor
+		constants types.ChainConstants
+	}{
+		{GetStandardNetworkDescriptor(), GetStandardnetGenesis()},
+		{GetTestnetNetworkDescriptor(), GetTestnetGenesis()},
+		{GetDevnetNetworkDescriptor(), GetDevnetGenesis()},
+	}
+	for _, tc := range testCases {
+		network, err := GetNetworkDescriptor(tc.network.NetworkName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tc.constants.CurrencyUnits.OneCoin.Equals(network.CurrencyUnits().OneCoin) {
+			t.Errorf("%s: currency units of the genesis and descriptor differ", network.NetworkName)
+		}
+		// precision and units are shared by all networks, as they are part of the payment request URIs
+		if network.CoinPrecision != 9 || network.CoinUnit != GolchainTokenUnit {
+			t.Errorf("%s: unexpected coin precision %d or unit %q", network.NetworkName, network.CoinPrecision, network.CoinUnit)
+		}
+	}
+	if _, err := GetNetworkDescriptor("foo"); err == nil {
+		t.Error("expected unknown network to be rejected")
+	}
+
+	var id types.TransactionID
+	if url := GetStandardNetworkDescriptor().TransactionURL(id); url != "" {
+		t.Errorf("expected no transaction URL without explorer, got %q", url)
+	}
+	if url := GetDevnetNetworkDescriptor().TransactionURL(id); url != "http://localhost:2015/hash.html?hash="+id.String() {
+		t.Errorf("unexpected transaction URL %q", url)
+	}
+}
+
+func TestChainConstantsOverrides(t *testing.T) {
+	load := func(content string) (ChainConstantsOverrides, error) {
+		filename := filepath.Join(t.TempDir(), "constants.json")
+		if err := ioutil.WriteFile(filename, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return LoadChainConstantsOverrides(filename)
+	}
+
+	overrides, err := load(`{"blockfrequency": 3, "minimumtransactionfee": "0.5"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants := GetDevnetGenesis()
+	changes, err := overrides.Apply(&constants, GolchainTokenUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+	defaults := GetDevnetGenesis()
+	if constants.BlockFrequency != 3 || constants.MaturityDelay != defaults.MaturityDelay ||
+		!constants.MinimumTransactionFee.Equals(defaults.CurrencyUnits.OneCoin.Div64(2)) {
+		t.Errorf("unexpected constants after applying overrides: %+v", constants)
+	}
+	if err = constants.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	overrides, err = load(`{"arbitrarydatasizelimit": 1024, "forks": [{"name": "bigger blocks", "height": 100, "blocksizelimit": 4000000}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants = GetDevnetGenesis()
+	changes, err = overrides.Apply(&constants, GolchainTokenUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || !strings.Contains(changes[1], `hard fork "bigger blocks" at height 100`) {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+	if constants.ArbitraryDataSizeLimit != 1024 || constants.BlockSizeLimit != defaults.BlockSizeLimit {
+		t.Errorf("unexpected limits after applying overrides: %d, %d", constants.BlockSizeLimit, constants.ArbitraryDataSizeLimit)
+	}
+
+	overrides, err = load(`{"transactionversions": {"194": 50, "192": 10}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	constants = GetDevnetGenesis()
+	changes, err = overrides.Apply(&constants, GolchainTokenUnit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0] != "transaction version 192 activated at height 10" || changes[1] != "transaction version 194 activated at height 50" {
+		t.Errorf("unexpected changes: %v", changes)
+	}
+
+	if _, err = load(`{"blockfrequncy": 3}`); err == nil {
+		t.Error("expected unknown property to be rejected")
+	}
+	for _, content := range []string{
+		`{"blockfrequency": 0}`,
+		`{"maturitydelay": 0}`,
+		`{"minimumtransactionfee": "0"}`,
+		`{"blockfrequency": 3, "minimumtransactionfee": "foo"}`,
+		`{"blocksizelimit": 5000}`,
+		`{"arbitrarydatasizelimit": 3000000}`,
+		`{"forks": [{"name": "genesis", "height": 0, "blocksizelimit": 4000000}]}`,
+	} {
+		overrides, err = load(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		constants = GetDevnetGenesis()
+		if _, err = overrides.Apply(&constants, GolchainTokenUnit); err == nil {
+			t.Errorf("expected overrides %s to be rejected", content)
+		}
+		if constants.BlockFrequency != defaults.BlockFrequency {
+			t.Errorf("constants got modified by invalid overrides %s", content)
+		}
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	network := GetDevnetNetworkDescriptor()
+	const address = "0175e1a00548730d67ec1b46bc0fe469e7b9888cfab3c08548aaf900afaa52564520c537d665ca"
+	uh, err := network.ParseAddress(" " + address + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uh.String() != address {
+		t.Errorf("unexpected address %s", uh.String())
+	}
+
+	siaHash := crypto.HashBytes([]byte("sia"))
+	siaChecksum := crypto.HashBytes(siaHash[:])
+	siaAddress := siaHash.String() + hex.EncodeToString(siaChecksum[:types.UnlockHashChecksumSize])
+	multiSigAddress := types.NewUnlockHash(types.UnlockTypeMultiSig, siaHash).String()
+	atomicSwapAddress := types.NewUnlockHash(types.UnlockTypeAtomicSwap, siaHash).String()
+	if _, err = network.ParseAddress(multiSigAddress); err != nil {
+		t.Errorf("expected multisig address to be accepted: %v", err)
+	}
+
+	for _, tc := range []struct {
+		address string
+		reason  string
+	}{
+		{"", "no address"},
+		{address[2:], "did you mean " + address},
+		{address[:len(address)-1] + "b", "invalid checksum"},
+		{address[:len(address)-2], "consists of 76 characters"},
+		{"0x52908400098527886E0F7030069857D2E4169EE7", "Ethereum"},
+		{"GAHK7EEG2WWHVKDNT4CEQFZGKF2LGDSW2IVM4S5DP42RBW3K6BTODB4A", "Stellar"},
+		{"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "Bitcoin"},
+		{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "Bitcoin"},
+		{siaAddress, "Sia"},
+		{"foo", "hex-encoded"},
+		{strings.Repeat("0", 78), "nil address"},
+		{atomicSwapAddress, "unlock type 2"},
+	} {
+		if _, err = network.ParseAddress(tc.address); err == nil || !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("expected %q to be refused for %q, got: %v", tc.address, tc.reason, err)
+		}
+	}
+}
+
+func TestCheckpoints(t *testing.T) {
+	// the embedded checkpoints are parsed when the node starts, and never checkpoint the genesis block
+	for height := range GetTestnetCheckpoints() {
+		if height == 0 {
+			t.Error("unexpected checkpoint of the testnet genesis block")
+		}
+	}
+	if checkpointsFromHex(map[types.BlockHeight]string{1: crypto.Hash{1}.String()})[1] != (types.BlockID{1}) {
+		t.Error("unexpected checkpoint block ID")
+	}
+}
+
+func TestForkSchedule(t *testing.T) {
+	genesis := Limits{BlockSizeLimit: 2e6, ArbitraryDataSizeLimit: 83}
+	schedule, err := NewForkSchedule(genesis, []Fork{
+		{Name: "smaller blocks", Height: 200, Limits: Limits{BlockSizeLimit: 1e6}},
+		{Name: "more data", Height: 100, Limits: Limits{BlockSizeLimit: 4e6, ArbitraryDataSizeLimit: 1024}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		height types.BlockHeight
+		limits Limits
+	}{
+		{0, genesis},
+		{99, genesis},
+		{100, Limits{BlockSizeLimit: 4e6, ArbitraryDataSizeLimit: 1024}},
+		{199, Limits{BlockSizeLimit: 4e6, ArbitraryDataSizeLimit: 1024}},
+		// limits which are not defined keep the value of the previous fork
+		{200, Limits{BlockSizeLimit: 1e6, ArbitraryDataSizeLimit: 1024}},
+		{1e6, Limits{BlockSizeLimit: 1e6, ArbitraryDataSizeLimit: 1024}},
+	} {
+		if limits := schedule.LimitsAt(tc.height); limits != tc.limits {
+			t.Errorf("height %d: expected limits %+v, got %+v", tc.height, tc.limits, limits)
+		}
+	}
+	if limits := schedule.MaxLimits(); limits != (Limits{BlockSizeLimit: 4e6, ArbitraryDataSizeLimit: 1024}) {
+		t.Errorf("unexpected maximum limits: %+v", limits)
+	}
+	if forks := schedule.Forks(); len(forks) != 2 || forks[0].Name != "more data" || forks[1].ArbitraryDataSizeLimit != 1024 {
+		t.Errorf("unexpected forks: %+v", forks)
+	}
+
+	for _, tc := range []struct {
+		forks  []Fork
+		reason string
+	}{
+		{[]Fork{{Name: "a", Height: 0}}, "genesis block"},
+		{[]Fork{{Height: 10}}, "no name"},
+		{[]Fork{{Name: "a", Height: 10}, {Name: "b", Height: 10}}, "same height"},
+		{[]Fork{{Name: "a", Height: 10, Limits: Limits{BlockSizeLimit: 1e3}}}, "below the minimum"},
+		{[]Fork{{Name: "a", Height: 10, Limits: Limits{BlockSizeLimit: 20e3, ArbitraryDataSizeLimit: 30e3}}}, "does not fit"},
+	} {
+		if _, err = NewForkSchedule(genesis, tc.forks); err == nil || !strings.Contains(err.Error(), tc.reason) {
+			t.Errorf("expected forks %+v to be refused for %q, got: %v", tc.forks, tc.reason, err)
+		}
+	}
+
+	// the forks scheduled for every network are valid
+	for _, tc := range []struct {
+		constants types.ChainConstants
+		forks     []Fork
+	}{
+		{GetStandardnetGenesis(), GetStandardnetForks()},
+		{GetTestnetGenesis(), GetTestnetForks()},
+		{GetDevnetGenesis(), GetDevnetForks()},
+	} {
+		if _, err = NewForkSchedule(LimitsOf(tc.constants), tc.forks); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestDiagnoseAddress(t *testing.T) {
+	network := GetDevnetNetworkDescriptor()
+	const address = "0175e1a00548730d67ec1b46bc0fe469e7b9888cfab3c08548aaf900afaa52564520c537d665ca"
+	diagnosis := network.DiagnoseAddress(address)
+	if !diagnosis.Valid || diagnosis.Type != "pubkey" || diagnosis.Address == nil || diagnosis.Address.String() != address ||
+		diagnosis.Checksum == nil || !diagnosis.Checksum.Valid || diagnosis.Error != "" {
+		t.Errorf("unexpected diagnosis of a valid address: %+v", diagnosis)
+	}
+
+	diagnosis = network.DiagnoseAddress(address[:len(address)-1] + "b")
+	if diagnosis.Valid || diagnosis.Type != "pubkey" || diagnosis.Address != nil || diagnosis.Checksum == nil ||
+		diagnosis.Checksum.Valid || diagnosis.Checksum.Given != "20c537d665cb" || diagnosis.Checksum.Expected != "20c537d665ca" {
+		t.Errorf("unexpected diagnosis of a mistyped address: %+v", diagnosis)
+	}
+
+	diagnosis = network.DiagnoseAddress(strings.Repeat("0", 78))
+	if diagnosis.Valid || diagnosis.Type != "nil" || !diagnosis.Checksum.Valid || !strings.Contains(diagnosis.Error, "nil address") {
+		t.Errorf("unexpected diagnosis of the nil address: %+v", diagnosis)
+	}
+
+	diagnosis = network.DiagnoseAddress("0x52908400098527886E0F7030069857D2E4169EE7")
+	if diagnosis.Valid || diagnosis.Type != "" || diagnosis.Checksum != nil || diagnosis.ForeignChain != "Ethereum" {
+		t.Errorf("unexpected diagnosis of an Ethereum address: %+v", diagnosis)
+	}
+}
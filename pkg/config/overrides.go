@@ -0,0 +1,115 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/threefoldtech/rivine/pkg/client"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ChainConstantsOverrides overwrites individual chain constants of an experimental devnet,
+// as loaded from a local JSON file. Constants which are not defined keep their default value.
+//
+// All nodes of a network have to use the same overrides, or they will reject each other's blocks.
+type ChainConstantsOverrides struct {
+	// BlockFrequency is the targeted amount of seconds between two blocks
+	BlockFrequency *types.BlockHeight `json:"blockfrequency,omitempty"`
+	// MaturityDelay is the amount of blocks before a miner payout can be spent
+	MaturityDelay *types.BlockHeight `json:"maturitydelay,omitempty"`
+	// MinimumTransactionFee is the minimum miner fee of a transaction, expressed in coins
+	MinimumTransactionFee *string `json:"minimumtransactionfee,omitempty"`
+	// BlockSizeLimit is the maximum size of a block in bytes, from the genesis block onwards
+	BlockSizeLimit *uint64 `json:"blocksizelimit,omitempty"`
+	// ArbitraryDataSizeLimit is the maximum size of the arbitrary data of a transaction in bytes, from the genesis block onwards
+	ArbitraryDataSizeLimit *uint64 `json:"arbitrarydatasizelimit,omitempty"`
+	// Forks replace the hard forks scheduled for the devnet, such that their activation can be tried out
+	Forks []Fork `json:"forks,omitempty"`
+	// TransactionVersions replace the activation heights of the transaction versions of the devnet,
+	// mapping a transaction version to the height of the first block which can contain it
+	TransactionVersions map[types.TransactionVersion]types.BlockHeight `json:"transactionversions,omitempty"`
+}
+
+// LoadChainConstantsOverrides loads the chain constants overrides from the given JSON file,
+// refusing unknown properties such that typos do not go unnoticed.
+func LoadChainConstantsOverrides(filename string) (ChainConstantsOverrides, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return ChainConstantsOverrides{}, fmt.Errorf("failed to open chain constants overrides: %v", err)
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	var overrides ChainConstantsOverrides
+	err = decoder.Decode(&overrides)
+	if err != nil {
+		return ChainConstantsOverrides{}, fmt.Errorf("failed to decode chain constants overrides %s: %v", filename, err)
+	}
+	return overrides, nil
+}
+
+// Apply validates and applies the overrides to the given chain constants,
+// returning a description of each overwritten constant, to be logged by the caller.
+// The constants are left untouched should any override be invalid.
+func (overrides ChainConstantsOverrides) Apply(constants *types.ChainConstants, coinUnit string) ([]string, error) {
+	updated := *constants
+	var changes []string
+	if overrides.BlockFrequency != nil {
+		if *overrides.BlockFrequency == 0 {
+			return nil, errors.New("invalid block frequency override: has to be at least 1 second")
+		}
+		changes = append(changes, fmt.Sprintf("block frequency: %d → %d seconds", updated.BlockFrequency, *overrides.BlockFrequency))
+		updated.BlockFrequency = *overrides.BlockFrequency
+	}
+	if overrides.MaturityDelay != nil {
+		if *overrides.MaturityDelay == 0 {
+			return nil, errors.New("invalid maturity delay override: has to be at least 1 block")
+		}
+		changes = append(changes, fmt.Sprintf("maturity delay: %d → %d blocks", updated.MaturityDelay, *overrides.MaturityDelay))
+		updated.MaturityDelay = *overrides.MaturityDelay
+	}
+	if overrides.MinimumTransactionFee != nil {
+		cc := client.NewCurrencyConvertor(updated.CurrencyUnits, coinUnit)
+		fee, err := cc.ParseCoinString(*overrides.MinimumTransactionFee)
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum transaction fee override %q: %v", *overrides.MinimumTransactionFee, err)
+		}
+		if fee.IsZero() {
+			return nil, errors.New("invalid minimum transaction fee override: cannot be zero")
+		}
+		changes = append(changes, fmt.Sprintf("minimum transaction fee: %s → %s",
+			cc.ToCoinStringWithUnit(updated.MinimumTransactionFee), cc.ToCoinStringWithUnit(fee)))
+		updated.MinimumTransactionFee = fee
+	}
+	if overrides.BlockSizeLimit != nil {
+		changes = append(changes, fmt.Sprintf("block size limit: %d → %d bytes", updated.BlockSizeLimit, *overrides.BlockSizeLimit))
+		updated.BlockSizeLimit = *overrides.BlockSizeLimit
+	}
+	if overrides.ArbitraryDataSizeLimit != nil {
+		changes = append(changes, fmt.Sprintf("arbitrary data size limit: %d → %d bytes", updated.ArbitraryDataSizeLimit, *overrides.ArbitraryDataSizeLimit))
+		updated.ArbitraryDataSizeLimit = *overrides.ArbitraryDataSizeLimit
+	}
+	// the limits are validated as part of the fork schedule
+	schedule, err := NewForkSchedule(LimitsOf(updated), overrides.Forks)
+	if err != nil {
+		return nil, fmt.Errorf("invalid size limits overrides: %v", err)
+	}
+	for _, fork := range schedule.Forks() {
+		changes = append(changes, fmt.Sprintf("hard fork %q at height %d: block size limit of %d bytes, arbitrary data size limit of %d bytes",
+			fork.Name, fork.Height, fork.BlockSizeLimit, fork.ArbitraryDataSizeLimit))
+	}
+	// the transaction versions are validated by the transaction version registry of the node
+	versions := make([]types.TransactionVersion, 0, len(overrides.TransactionVersions))
+	for version := range overrides.TransactionVersions {
+		versions = append(versions, version)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	for _, version := range versions {
+		changes = append(changes, fmt.Sprintf("transaction version %d activated at height %d", version, overrides.TransactionVersions[version]))
+	}
+	*constants = updated
+	return changes, nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+)
+
+// GetStandardnetSnapshots returns the hashes of the consensus snapshots of the standard network trusted by this release.
+func GetStandardnetSnapshots() []crypto.Hash {
+	return nil
+}
+
+// GetTestnetSnapshots returns the hashes of the consensus snapshots of the testnet trusted by this release.
+// Snapshots published after a release are trusted once signed by the foundation address of the network.
+func GetTestnetSnapshots() []crypto.Hash {
+	return hashesFromHex(testnetSnapshots)
+}
+
+// GetDevnetSnapshots returns the hashes of the consensus snapshots of the devnet trusted by this release,
+// of which there are none, as every devnet has its own blockchain.
+func GetDevnetSnapshots() []crypto.Hash {
+	return nil
+}
+
+// testnetSnapshots are the hex-encoded hashes of the published testnet snapshots,
+// which are verified before adding them.
+var testnetSnapshots = []string{}
+
+func hashesFromHex(hashes []string) []crypto.Hash {
+	result := make([]crypto.Hash, 0, len(hashes))
+	for _, hstr := range hashes {
+		var hash crypto.Hash
+		err := hash.LoadString(hstr)
+		if err != nil {
+			panic(fmt.Sprintf("func hashesFromHex(%s) failed: %v", hstr, err))
+		}
+		result = append(result, hash)
+	}
+	return result
+}
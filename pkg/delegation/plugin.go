@@ -0,0 +1,522 @@
+package delegation
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/modules/consensus"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "BlockStakeDelegationPlugin"
+)
+
+var (
+	// bucketDelegations contains a bucket per delegating address, mapping the block heights at which its delegation changed,
+	// to the operator it delegates to since that block, the nil address in case the delegation got revoked
+	bucketDelegations = []byte("delegations")
+	// bucketUnspent maps the IDs of the unspent blockstake outputs created while their owner delegated its blockstakes,
+	// to these outputs and their indexes
+	bucketUnspent = []byte("unspent")
+	// bucketSpent maps the IDs of the spent blockstake outputs which were tracked as unspent,
+	// such that they can be tracked once more should their spending be reverted
+	bucketSpent = []byte("spent")
+)
+
+type (
+	// Plugin is a consensus set plugin, keeping track of the blockstake delegations,
+	// and the blockstake outputs the operators can use to create blocks.
+	Plugin struct {
+		txVersion types.TransactionVersion
+		// activationHeight is the height from which operators can fulfill delegated blockstake inputs
+		activationHeight   types.BlockHeight
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// Delegation is the active delegation of the blockstakes of an address to an operator.
+	Delegation struct {
+		Delegator types.UnlockHash `json:"delegator"`
+		Operator  types.UnlockHash `json:"operator"`
+		// Height is the height of the block which contains the delegation transaction
+		Height types.BlockHeight `json:"height"`
+		// Stake is the amount of blockstakes the operator can use to create blocks
+		Stake types.Currency `json:"stake"`
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new blockstake delegation plugin,
+// applying the blockstake delegation transactions of the given version,
+// which is activated at the given height.
+func NewPlugin(txVersion types.TransactionVersion, activationHeight types.BlockHeight) *Plugin {
+	return &Plugin{txVersion: txVersion, activationHeight: activationHeight}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketDelegations, bucketUnspent, bucketSpent} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies all transactions of the block.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction applies the delegation of a blockstake delegation transaction,
+// and tracks the blockstake outputs created for delegating addresses.
+// Blockstake outputs of an address are only tracked while it delegates its blockstakes,
+// such that blocks applied before the first delegation do not change the plugin.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(txn.BlockStakeInputs) == 0 && len(txn.BlockStakeOutputs) == 0 {
+		return nil
+	}
+	delegationsBucket, unspentBucket, spentBucket, err := getBuckets(bucket)
+	if err != nil {
+		return err
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		key := rivbin.Marshal(bsi.ParentID)
+		b := unspentBucket.Get(key)
+		if len(b) == 0 {
+			continue // not delegated
+		}
+		err = spentBucket.Put(key, b)
+		if err == nil {
+			err = unspentBucket.Delete(key)
+		}
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark delegated blockstake output %s as spent: %v", bsi.ParentID.String(), err)
+		}
+	}
+	if operator, ok := p.operator(txn); ok {
+		for _, uh := range goldchaintypes.DelegatingAddresses(txn) {
+			addressBucket, err := delegationsBucket.CreateBucketIfNotExists(rivbin.Marshal(uh))
+			if err == bolt.ErrTxNotWritable {
+				return pluginstats.ErrCatchUpUnsupported
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create delegation bucket for address %s: %v", uh.String(), err)
+			}
+			err = addressBucket.Put(encodeBlockHeight(height), rivbin.Marshal(operator))
+			if err != nil {
+				return fmt.Errorf("failed to store delegation of address %s at height %d: %v", uh.String(), height, err)
+			}
+		}
+	}
+	txIndex := -1
+	for index, bso := range txn.BlockStakeOutputs {
+		operator, _, err := activeDelegation(delegationsBucket, bso.Condition.UnlockHash())
+		if err != nil {
+			return err
+		}
+		if operator.Type == types.UnlockTypeNil {
+			continue
+		}
+		if txIndex == -1 {
+			txIndex = transactionIndex(txn, block)
+		}
+		id := txn.BlockStakeOutputID(uint64(index))
+		err = unspentBucket.Put(rivbin.Marshal(id), rivbin.Marshal(types.UnspentBlockStakeOutput{
+			BlockStakeOutputID: id,
+			Indexes: types.BlockStakeOutputIndexes{
+				BlockHeight:      height,
+				TransactionIndex: uint64(txIndex),
+				OutputIndex:      uint64(index),
+			},
+			Value:     bso.Value,
+			Condition: bso.Condition,
+		}))
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store delegated blockstake output %s: %v", id.String(), err)
+		}
+	}
+	return nil
+}
+
+// RevertBlock reverts all transactions of the block, last transaction first.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		err := p.RevertTransaction(block.Transactions[i], block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertTransaction reverts the delegation and tracked blockstake outputs of the transaction.
+// As this reverts the delegations made by all transactions of the block for the delegating addresses,
+// it is only to be used for reverting entire blocks, last transaction first.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(txn.BlockStakeInputs) == 0 && len(txn.BlockStakeOutputs) == 0 {
+		return nil
+	}
+	delegationsBucket, unspentBucket, spentBucket, err := getBuckets(bucket)
+	if err != nil {
+		return err
+	}
+	for index := range txn.BlockStakeOutputs {
+		err = unspentBucket.Delete(rivbin.Marshal(txn.BlockStakeOutputID(uint64(index))))
+		if err != nil {
+			return fmt.Errorf("failed to delete delegated blockstake output: %v", err)
+		}
+	}
+	if _, ok := p.operator(txn); ok {
+		for _, uh := range goldchaintypes.DelegatingAddresses(txn) {
+			addressBucket := delegationsBucket.Bucket(rivbin.Marshal(uh))
+			if addressBucket == nil {
+				continue
+			}
+			err = addressBucket.Delete(encodeBlockHeight(height))
+			if err != nil {
+				return fmt.Errorf("failed to delete delegation of address %s at height %d: %v", uh.String(), height, err)
+			}
+		}
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		key := rivbin.Marshal(bsi.ParentID)
+		b := spentBucket.Get(key)
+		if len(b) == 0 {
+			continue // not delegated
+		}
+		err = unspentBucket.Put(key, b)
+		if err == nil {
+			err = spentBucket.Delete(key)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark delegated blockstake output %s as unspent: %v", bsi.ParentID.String(), err)
+		}
+	}
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the blockstake delegation transaction is validated using stand alone validators.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// validating that the blockstake inputs of all transactions are fulfilled,
+// replacing the standard consensus.ValidateBlockStakeInputsAreFulfilled validator.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return []modules.PluginTransactionValidationFunction{
+		p.validateBlockStakeInputsAreFulfilled,
+	}
+}
+
+// StandardTransactionValidators returns the standard transaction validators of the consensus set,
+// except for the validator checking that all blockstake inputs are fulfilled by their owner,
+// as the Plugin allows operators to fulfill the blockstake input of a block creating transaction.
+func StandardTransactionValidators() []modules.TransactionValidationFunction {
+	return []modules.TransactionValidationFunction{
+		consensus.ValidateTransactionFitsInABlock,
+		consensus.ValidateTransactionArbitraryData,
+		consensus.ValidateCoinInputsAreValid,
+		consensus.ValidateCoinOutputsAreValid,
+		consensus.ValidateBlockStakeInputsAreValid,
+		consensus.ValidateBlockStakeOutputsAreValid,
+		consensus.ValidateMinerFeesAreValid,
+		consensus.ValidateDoubleCoinSpends,
+		consensus.ValidateDoubleBlockStakeSpends,
+		consensus.ValidateCoinInputsAreFulfilled,
+	}
+}
+
+// validateBlockStakeInputsAreFulfilled validates that all blockstake inputs are fulfilled by their owner,
+// or, for a block creating transaction respending a delegated blockstake output unchanged, by the operator.
+// Prior to the activation height of the delegation transaction version, only the owner can fulfill a blockstake input,
+// as validated by the standard validator this validator replaces.
+func (p *Plugin) validateBlockStakeInputsAreFulfilled(tx types.Transaction, ctx types.TransactionValidationContext, css modules.ConsensusStateGetter, bucket *persist.LazyBoltBucket) error {
+	for index, bsi := range tx.BlockStakeInputs {
+		bso, err := css.UnspentBlockStakeOutputGet(bsi.ParentID)
+		if err != nil {
+			return fmt.Errorf(
+				"unable to find parent ID %s as an unspent block stake output in the current consensus state at block height %d",
+				bsi.ParentID.String(), ctx.BlockHeight)
+		}
+		fulfillCtx := types.FulfillContext{
+			ExtraObjects: []interface{}{uint64(index)},
+			BlockHeight:  ctx.BlockHeight,
+			BlockTime:    ctx.BlockTime,
+			Transaction:  tx,
+		}
+		err = bso.Condition.Fulfill(bsi.Fulfillment, fulfillCtx)
+		if err == nil {
+			continue
+		}
+		if ctx.BlockHeight < p.activationHeight || !ctx.IsBlockCreatingTx || !goldchaintypes.SameBlockStakeOutput(bso, tx.BlockStakeOutputs[0]) {
+			return err
+		}
+		operator, delegated, dErr := p.delegatedOutputOperator(bsi.ParentID, bso.Condition.UnlockHash(), bucket)
+		if dErr != nil {
+			return dErr
+		}
+		if !delegated {
+			return err
+		}
+		operatorErr := types.NewCondition(types.NewUnlockHashCondition(operator)).Fulfill(bsi.Fulfillment, fulfillCtx)
+		if operatorErr != nil {
+			return fmt.Errorf("blockstake input %s is fulfilled neither by its owner (%v) nor by operator %s (%v)",
+				bsi.ParentID.String(), err, operator.String(), operatorErr)
+		}
+	}
+	return nil
+}
+
+// delegatedOutputOperator returns the operator allowed to create blocks using the given blockstake output,
+// and false in case the output cannot be used by an operator.
+func (p *Plugin) delegatedOutputOperator(id types.BlockStakeOutputID, owner types.UnlockHash, bucket *persist.LazyBoltBucket) (types.UnlockHash, bool, error) {
+	delegationsBucket, unspentBucket, _, err := getBuckets(bucket)
+	if err != nil {
+		return types.UnlockHash{}, false, err
+	}
+	if len(unspentBucket.Get(rivbin.Marshal(id))) == 0 {
+		return types.UnlockHash{}, false, nil
+	}
+	operator, _, err := activeDelegation(delegationsBucket, owner)
+	if err != nil {
+		return types.UnlockHash{}, false, err
+	}
+	return operator, operator.Type != types.UnlockTypeNil, nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetDelegation returns the operator the given address delegates its blockstakes to,
+// the nil address in case it does not delegate its blockstakes.
+func (p *Plugin) GetDelegation(address types.UnlockHash) (types.UnlockHash, error) {
+	var operator types.UnlockHash
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		delegationsBucket := bucket.Bucket(bucketDelegations)
+		if delegationsBucket == nil {
+			return errors.New("delegations bucket does not exist")
+		}
+		var err error
+		operator, _, err = activeDelegation(delegationsBucket, address)
+		return err
+	})
+	return operator, err
+}
+
+// GetDelegations returns all active delegations, ordered by delegator,
+// limited to the delegations to the given operator should it be defined.
+func (p *Plugin) GetDelegations(operator *types.UnlockHash) ([]Delegation, error) {
+	delegations := []Delegation{}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		delegationsBucket := bucket.Bucket(bucketDelegations)
+		if delegationsBucket == nil {
+			return errors.New("delegations bucket does not exist")
+		}
+		stakes, err := delegatedStakes(bucket)
+		if err != nil {
+			return err
+		}
+		return delegationsBucket.ForEach(func(k, _ []byte) error {
+			var delegator types.UnlockHash
+			err := rivbin.Unmarshal(k, &delegator)
+			if err != nil {
+				return fmt.Errorf("failed to decode delegator: %v", err)
+			}
+			active, height, err := activeDelegation(delegationsBucket, delegator)
+			if err != nil || active.Type == types.UnlockTypeNil {
+				return err
+			}
+			if operator != nil && *operator != active {
+				return nil
+			}
+			delegations = append(delegations, Delegation{
+				Delegator: delegator,
+				Operator:  active,
+				Height:    height,
+				Stake:     stakes[delegator],
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(delegations, func(i, j int) bool {
+		return delegations[i].Delegator.Cmp(delegations[j].Delegator) < 0
+	})
+	return delegations, nil
+}
+
+// GetDelegatedBlockStakeOutputs returns all unspent blockstake outputs delegated to any of the given operators.
+func (p *Plugin) GetDelegatedBlockStakeOutputs(operators []types.UnlockHash) ([]types.UnspentBlockStakeOutput, error) {
+	isOperator := make(map[types.UnlockHash]struct{}, len(operators))
+	for _, uh := range operators {
+		isOperator[uh] = struct{}{}
+	}
+	var outputs []types.UnspentBlockStakeOutput
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		delegationsBucket := bucket.Bucket(bucketDelegations)
+		if delegationsBucket == nil {
+			return errors.New("delegations bucket does not exist")
+		}
+		return forEachDelegatedOutput(bucket, func(ubso types.UnspentBlockStakeOutput) error {
+			operator, _, err := activeDelegation(delegationsBucket, ubso.Condition.UnlockHash())
+			if err != nil {
+				return err
+			}
+			if _, ok := isOperator[operator]; ok {
+				outputs = append(outputs, ubso)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return outputs, nil
+}
+
+// operator returns the operator of a blockstake delegation transaction,
+// and false in case the transaction is not a blockstake delegation transaction.
+func (p *Plugin) operator(txn types.Transaction) (types.UnlockHash, bool) {
+	if txn.Version != p.txVersion {
+		return types.UnlockHash{}, false
+	}
+	return goldchaintypes.DelegationOperator(txn)
+}
+
+// delegatedStakes returns the sum of the tracked unspent blockstake outputs per owner.
+func delegatedStakes(bucket *bolt.Bucket) (map[types.UnlockHash]types.Currency, error) {
+	stakes := make(map[types.UnlockHash]types.Currency)
+	err := forEachDelegatedOutput(bucket, func(ubso types.UnspentBlockStakeOutput) error {
+		uh := ubso.Condition.UnlockHash()
+		stakes[uh] = stakes[uh].Add(ubso.Value)
+		return nil
+	})
+	return stakes, err
+}
+
+// forEachDelegatedOutput calls the given function for each tracked unspent blockstake output.
+func forEachDelegatedOutput(bucket *bolt.Bucket, fn func(types.UnspentBlockStakeOutput) error) error {
+	unspentBucket := bucket.Bucket(bucketUnspent)
+	if unspentBucket == nil {
+		return errors.New("unspent blockstake outputs bucket does not exist")
+	}
+	return unspentBucket.ForEach(func(_, v []byte) error {
+		var ubso types.UnspentBlockStakeOutput
+		err := rivbin.Unmarshal(v, &ubso)
+		if err != nil {
+			return fmt.Errorf("failed to decode delegated blockstake output: %v", err)
+		}
+		return fn(ubso)
+	})
+}
+
+// activeDelegation returns the operator the given address delegates its blockstakes to,
+// as well as the height at which it delegated its blockstakes to that operator.
+func activeDelegation(delegationsBucket *bolt.Bucket, address types.UnlockHash) (types.UnlockHash, types.BlockHeight, error) {
+	addressBucket := delegationsBucket.Bucket(rivbin.Marshal(address))
+	if addressBucket == nil {
+		return types.UnlockHash{}, 0, nil
+	}
+	k, v := addressBucket.Cursor().Last()
+	if k == nil {
+		return types.UnlockHash{}, 0, nil
+	}
+	var operator types.UnlockHash
+	err := rivbin.Unmarshal(v, &operator)
+	if err != nil {
+		return types.UnlockHash{}, 0, fmt.Errorf("failed to decode operator of address %s: %v", address.String(), err)
+	}
+	return operator, types.BlockHeight(binary.BigEndian.Uint64(k)), nil
+}
+
+// transactionIndex returns the index of the transaction within the block,
+// the amount of transactions of the block in case the transaction is not part of it yet.
+func transactionIndex(txn types.Transaction, block types.Block) int {
+	id := txn.ID()
+	for index, t := range block.Transactions {
+		if t.ID() == id {
+			return index
+		}
+	}
+	return len(block.Transactions)
+}
+
+func getBuckets(bucket *persist.LazyBoltBucket) (delegations, unspent, spent *bolt.Bucket, err error) {
+	delegations, err = bucket.Bucket(bucketDelegations)
+	if err != nil {
+		return nil, nil, nil, errors.New("delegations bucket does not exist")
+	}
+	unspent, err = bucket.Bucket(bucketUnspent)
+	if err != nil {
+		return nil, nil, nil, errors.New("unspent blockstake outputs bucket does not exist")
+	}
+	spent, err = bucket.Bucket(bucketSpent)
+	if err != nil {
+		return nil, nil, nil, errors.New("spent blockstake outputs bucket does not exist")
+	}
+	return delegations, unspent, spent, nil
+}
+
+func encodeBlockHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
@@ -0,0 +1,172 @@
+package delegation
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testConsensusState struct {
+	modules.ConsensusStateGetter
+	outputs map[types.BlockStakeOutputID]types.BlockStakeOutput
+}
+
+func (css testConsensusState) UnspentBlockStakeOutputGet(id types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	bso, ok := css.outputs[id]
+	if !ok {
+		return types.BlockStakeOutput{}, errors.New("unknown blockstake output")
+	}
+	return bso, nil
+}
+
+func TestPluginDelegation(t *testing.T) {
+	db := plugintest.NewDB(t, "delegation")
+
+	sk, pk := crypto.GenerateKeyPair()
+	operatorKey := types.Ed25519PublicKey(pk)
+	operator := types.NewPubKeyUnlockHash(operatorKey)
+	delegator := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	output := types.BlockStakeOutput{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(delegator))}
+	genesis := types.Block{Transactions: []types.Transaction{{BlockStakeOutputs: []types.BlockStakeOutput{output}}}}
+
+	p := NewPlugin(goldchaintypes.TransactionVersionBlockStakeDelegation, 1)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) error {
+		return db.UpdateBucket(fn)
+	}
+	apply := func(block types.Block, height types.BlockHeight) {
+		t.Helper()
+		err := update(func(bucket *persist.LazyBoltBucket) error {
+			return p.ApplyBlock(block, height, bucket)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDelegated := func(expected ...types.BlockStakeOutputIndexes) {
+		t.Helper()
+		outputs, err := p.GetDelegatedBlockStakeOutputs([]types.UnlockHash{operator})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(outputs) != len(expected) {
+			t.Fatalf("expected %d delegated outputs, got %+v", len(expected), outputs)
+		}
+		for i, ubso := range outputs {
+			if ubso.Indexes != expected[i] || !ubso.Value.Equals(output.Value) {
+				t.Errorf("unexpected delegated output: %+v", ubso)
+			}
+		}
+	}
+
+	// blocks without delegations can be replayed using a read-only transaction
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(genesis, 0, bucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	apply(genesis, 0)
+	expectDelegated()
+
+	delegation := goldchaintypes.BlockStakeDelegationTransaction{
+		BlockStakeInputs:  []types.BlockStakeInput{{ParentID: genesis.Transactions[0].BlockStakeOutputID(0)}},
+		BlockStakeOutputs: []types.BlockStakeOutput{output},
+		Operator:          operator,
+	}
+	delegationBlock := types.Block{Transactions: []types.Transaction{
+		{Version: types.TransactionVersionOne},
+		delegation.Transaction(goldchaintypes.TransactionVersionBlockStakeDelegation),
+	}}
+	delegatedID := delegationBlock.Transactions[1].BlockStakeOutputID(0)
+	apply(delegationBlock, 1)
+	expectDelegated(types.BlockStakeOutputIndexes{BlockHeight: 1, TransactionIndex: 1})
+	delegations, err := p.GetDelegations(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(delegations) != 1 || delegations[0].Delegator != delegator || delegations[0].Operator != operator ||
+		delegations[0].Height != 1 || !delegations[0].Stake.Equals64(10) {
+		t.Errorf("unexpected delegations: %+v", delegations)
+	}
+
+	// the operator can only respend the delegated output unchanged, in a block creating transaction
+	blockCreatingTx := func(out types.BlockStakeOutput) types.Transaction {
+		fulfillment := types.NewFulfillment(types.NewSingleSignatureFulfillment(operatorKey))
+		txn := types.Transaction{
+			Version:           types.TransactionVersionOne,
+			BlockStakeInputs:  []types.BlockStakeInput{{ParentID: delegatedID, Fulfillment: fulfillment}},
+			BlockStakeOutputs: []types.BlockStakeOutput{out},
+		}
+		err := fulfillment.Sign(types.FulfillmentSignContext{ExtraObjects: []interface{}{uint64(0)}, Transaction: txn, Key: sk[:]})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return txn
+	}
+	css := testConsensusState{outputs: map[types.BlockStakeOutputID]types.BlockStakeOutput{delegatedID: output}}
+	validate := func(txn types.Transaction, blockCreating bool, height types.BlockHeight) error {
+		ctx := types.TransactionValidationContext{ValidationContext: types.ValidationContext{BlockHeight: height, IsBlockCreatingTx: blockCreating}}
+		return update(func(bucket *persist.LazyBoltBucket) error {
+			for _, validator := range p.TransactionValidators() {
+				if err := validator(txn, ctx, css, bucket); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if err := validate(blockCreatingTx(output), true, 2); err != nil {
+		t.Errorf("expected the operator to be able to create blocks: %v", err)
+	}
+	if err := validate(blockCreatingTx(output), true, 0); err == nil {
+		t.Error("expected the operator not to be able to create blocks prior to the activation of delegation")
+	}
+	if err := validate(blockCreatingTx(output), false, 2); err == nil {
+		t.Error("expected the operator not to be able to spend the delegated output in a regular transaction")
+	}
+	stolen := types.BlockStakeOutput{Value: output.Value, Condition: types.NewCondition(types.NewUnlockHashCondition(operator))}
+	if err := validate(blockCreatingTx(stolen), true, 2); err == nil {
+		t.Error("expected the operator not to be able to take ownership of the delegated output")
+	}
+
+	// the block creating transaction keeps the output delegated
+	creatingBlock := types.Block{Transactions: []types.Transaction{blockCreatingTx(output)}}
+	apply(creatingBlock, 2)
+	expectDelegated(types.BlockStakeOutputIndexes{BlockHeight: 2})
+
+	// once revoked the delegated outputs can no longer be used by the operator
+	revocation := goldchaintypes.BlockStakeDelegationTransaction{
+		BlockStakeInputs:  []types.BlockStakeInput{{ParentID: creatingBlock.Transactions[0].BlockStakeOutputID(0)}},
+		BlockStakeOutputs: []types.BlockStakeOutput{output},
+	}
+	revocationBlock := types.Block{Transactions: []types.Transaction{revocation.Transaction(goldchaintypes.TransactionVersionBlockStakeDelegation)}}
+	apply(revocationBlock, 3)
+	expectDelegated()
+	if delegations, err = p.GetDelegations(nil); err != nil || len(delegations) != 0 {
+		t.Errorf("expected no active delegations, got %+v (%v)", delegations, err)
+	}
+	if err := validate(blockCreatingTx(output), true, 4); err == nil {
+		t.Error("expected the operator not to be able to use a revoked delegation")
+	}
+
+	// reverting restores the delegation and the delegated outputs
+	for height, block := range []types.Block{revocationBlock, creatingBlock} {
+		err = update(func(bucket *persist.LazyBoltBucket) error {
+			return p.RevertBlock(block, types.BlockHeight(3-height), bucket)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDelegated(types.BlockStakeOutputIndexes{BlockHeight: 1, TransactionIndex: 1})
+	if op, err := p.GetDelegation(delegator); err != nil || op != operator {
+		t.Errorf("expected %s to delegate to %s, got %s (%v)", delegator.String(), operator.String(), op.String(), err)
+	}
+}
@@ -0,0 +1,157 @@
+package delegation
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// blockCreatorWallet is a wallet which can also use the blockstakes delegated to its addresses,
+	// as to be used by the block creator.
+	blockCreatorWallet struct {
+		modules.Wallet
+		plugin *Plugin
+	}
+
+	// blockCreatorTransactionBuilder is a transaction builder which spends delegated blockstake outputs,
+	// signing them using the key of the operator. A transaction spending a delegated blockstake output
+	// is a block creating transaction, and thus contains only that input and its (unchanged) output.
+	blockCreatorTransactionBuilder struct {
+		modules.TransactionBuilder
+		wallet    *blockCreatorWallet
+		delegated *types.UnspentBlockStakeOutput
+		output    *types.BlockStakeOutput
+	}
+)
+
+// NewBlockCreatorWallet wraps the given wallet, such that the block creator using it
+// also creates blocks using the blockstakes delegated to the addresses of the wallet.
+func NewBlockCreatorWallet(w modules.Wallet, plugin *Plugin) modules.Wallet {
+	return &blockCreatorWallet{Wallet: w, plugin: plugin}
+}
+
+// GetUnspentBlockStakeOutputs returns the blockstake outputs owned by the wallet,
+// as well as the blockstake outputs delegated to its addresses.
+func (w *blockCreatorWallet) GetUnspentBlockStakeOutputs() ([]types.UnspentBlockStakeOutput, error) {
+	owned, err := w.Wallet.GetUnspentBlockStakeOutputs()
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := w.Wallet.AllAddresses()
+	if err != nil {
+		return nil, err
+	}
+	delegated, err := w.plugin.GetDelegatedBlockStakeOutputs(addresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegated blockstake outputs: %v", err)
+	}
+	// outputs delegated to another address of the same wallet are owned already
+	isOwned := make(map[types.BlockStakeOutputID]struct{}, len(owned))
+	for _, ubso := range owned {
+		isOwned[ubso.BlockStakeOutputID] = struct{}{}
+	}
+	for _, ubso := range delegated {
+		if _, ok := isOwned[ubso.BlockStakeOutputID]; !ok {
+			owned = append(owned, ubso)
+		}
+	}
+	return owned, nil
+}
+
+// StartTransaction implements modules.Wallet.StartTransaction.
+func (w *blockCreatorWallet) StartTransaction() modules.TransactionBuilder {
+	return &blockCreatorTransactionBuilder{
+		TransactionBuilder: w.Wallet.StartTransaction(),
+		wallet:             w,
+	}
+}
+
+// delegatedOutput returns the unspent blockstake output with the given ID
+// should it be delegated to an address of the wallet.
+func (w *blockCreatorWallet) delegatedOutput(id types.BlockStakeOutputID) (*types.UnspentBlockStakeOutput, error) {
+	addresses, err := w.Wallet.AllAddresses()
+	if err != nil {
+		return nil, err
+	}
+	delegated, err := w.plugin.GetDelegatedBlockStakeOutputs(addresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegated blockstake outputs: %v", err)
+	}
+	for _, ubso := range delegated {
+		if ubso.BlockStakeOutputID == id {
+			return &ubso, nil
+		}
+	}
+	return nil, nil
+}
+
+// SpendBlockStake spends the given blockstake output,
+// using the key of the operator should it be delegated to the wallet.
+func (tb *blockCreatorTransactionBuilder) SpendBlockStake(ubsoid types.BlockStakeOutputID) error {
+	err := tb.TransactionBuilder.SpendBlockStake(ubsoid)
+	if err == nil {
+		return nil
+	}
+	delegated, dErr := tb.wallet.delegatedOutput(ubsoid)
+	if dErr != nil {
+		return dErr
+	}
+	if delegated == nil {
+		return err
+	}
+	if tb.delegated != nil {
+		return errors.New("only a single delegated blockstake output can be spent by a transaction")
+	}
+	tb.delegated = delegated
+	return nil
+}
+
+// AddBlockStakeOutput adds a blockstake output to the transaction, returning the
+// index of the blockstake output within the transaction.
+func (tb *blockCreatorTransactionBuilder) AddBlockStakeOutput(output types.BlockStakeOutput) uint64 {
+	if tb.delegated == nil {
+		return tb.TransactionBuilder.AddBlockStakeOutput(output)
+	}
+	tb.output = &output
+	return 0
+}
+
+// Sign signs the transaction, the input spending a delegated blockstake output
+// being signed using the key of the operator it is delegated to.
+func (tb *blockCreatorTransactionBuilder) Sign() ([]types.Transaction, error) {
+	if tb.delegated == nil {
+		return tb.TransactionBuilder.Sign()
+	}
+	if tb.output == nil {
+		return nil, errors.New("a transaction spending a delegated blockstake output has to respend it")
+	}
+	operator, err := tb.wallet.plugin.GetDelegation(tb.delegated.Condition.UnlockHash())
+	if err != nil {
+		return nil, err
+	}
+	pk, sk, err := tb.wallet.GetKey(operator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key of operator %s: %v", operator.String(), err)
+	}
+	fulfillment := types.NewFulfillment(types.NewSingleSignatureFulfillment(pk))
+	txn := types.Transaction{
+		Version: types.TransactionVersionOne,
+		BlockStakeInputs: []types.BlockStakeInput{{
+			ParentID:    tb.delegated.BlockStakeOutputID,
+			Fulfillment: fulfillment,
+		}},
+		BlockStakeOutputs: []types.BlockStakeOutput{*tb.output},
+	}
+	err = fulfillment.Sign(types.FulfillmentSignContext{
+		ExtraObjects: []interface{}{uint64(0)},
+		Transaction:  txn,
+		Key:          sk,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign delegated blockstake input: %v", err)
+	}
+	return []types.Transaction{txn}, nil
+}
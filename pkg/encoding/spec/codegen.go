@@ -0,0 +1,94 @@
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Document contains the spec and test vectors of all transaction versions,
+// as emitted for third-party SDKs.
+type Document struct {
+	Spec    Spec     `json:"spec"`
+	Vectors []Vector `json:"vectors"`
+}
+
+// NewDocument describes all transaction versions and creates their test vectors.
+// The transaction versions have to be registered, see RegisterTransactionVersions.
+func NewDocument() (Document, error) {
+	vectors, err := Vectors()
+	if err != nil {
+		return Document{}, err
+	}
+	return Document{Spec: Describe(), Vectors: vectors}, nil
+}
+
+// generatedHeader marks the emitted source files as generated.
+const generatedHeader = "Code generated by gen_vectors.go; DO NOT EDIT."
+
+// WriteJSON writes the document as indented JSON.
+func (doc Document) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(doc, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(b, '\n'))
+	return err
+}
+
+// WriteJavaScript writes the document as a CommonJS module, exporting the SPEC and VECTORS constants.
+func (doc Document) WriteJavaScript(w io.Writer) error {
+	spec, vectors, err := doc.marshal()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, `// %s
+// Goldchain transaction encoding spec and serialization test vectors.
+'use strict';
+
+const SPEC = %s;
+
+const VECTORS = %s;
+
+module.exports = { SPEC, VECTORS };
+`, generatedHeader, spec, vectors)
+	return err
+}
+
+// WritePython writes the document as a Python module, defining the SPEC and VECTORS constants.
+func (doc Document) WritePython(w io.Writer) error {
+	spec, vectors, err := doc.marshal()
+	if err != nil {
+		return err
+	}
+	// the JSON is embedded as raw string literals
+	for _, s := range []string{spec, vectors} {
+		if strings.Contains(s, "'''") {
+			return errors.New("the document cannot be embedded as a Python string literal")
+		}
+	}
+	_, err = fmt.Fprintf(w, `# %s
+"""Goldchain transaction encoding spec and serialization test vectors."""
+import json
+
+SPEC = json.loads(r'''%s''')
+
+VECTORS = json.loads(r'''%s''')
+`, generatedHeader, spec, vectors)
+	return err
+}
+
+func (doc Document) marshal() (spec, vectors string, err error) {
+	b, err := json.MarshalIndent(doc.Spec, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	spec = string(b)
+	b, err = json.MarshalIndent(doc.Vectors, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	return spec, string(b), nil
+}
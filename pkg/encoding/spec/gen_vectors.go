@@ -0,0 +1,59 @@
+//go:build ignore
+// +build ignore
+
+// gen_vectors generates the encoding spec and serialization test vectors of all goldchain transaction versions,
+// as a JSON document, a JavaScript module and a Python module, to be shipped along third-party SDKs.
+//
+// Usage (from the pkg/encoding/spec directory, or using 'make spec-vectors'):
+//
+//	go run gen_vectors.go -out vectors
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/nbh-digital/goldchain/pkg/encoding/spec"
+)
+
+var out = "vectors"
+
+func main() {
+	flag.StringVar(&out, "out", out, "directory to which the generated files are written")
+	flag.Parse()
+
+	spec.RegisterTransactionVersions()
+	doc, err := spec.NewDocument()
+	if err != nil {
+		log.Fatalf("[ERROR] failed to create the test vectors: %v", err)
+	}
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		log.Fatalf("[ERROR] %v", err)
+	}
+	files := []struct {
+		name  string
+		write func(doc spec.Document, buf *bytes.Buffer) error
+	}{
+		{"vectors.json", func(doc spec.Document, buf *bytes.Buffer) error { return doc.WriteJSON(buf) }},
+		{"vectors.js", func(doc spec.Document, buf *bytes.Buffer) error { return doc.WriteJavaScript(buf) }},
+		{"vectors.py", func(doc spec.Document, buf *bytes.Buffer) error { return doc.WritePython(buf) }},
+	}
+	for _, file := range files {
+		var buf bytes.Buffer
+		err = file.write(doc, &buf)
+		if err != nil {
+			log.Fatalf("[ERROR] failed to generate %s: %v", file.name, err)
+		}
+		err = ioutil.WriteFile(filepath.Join(out, file.name), buf.Bytes(), 0644)
+		if err != nil {
+			log.Fatalf("[ERROR] %v", err)
+		}
+	}
+	log.Printf("generated %d test vectors of %d transaction versions in %s",
+		len(doc.Vectors), len(doc.Spec.TransactionVersions), out)
+}
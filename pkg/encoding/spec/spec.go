@@ -0,0 +1,193 @@
+// Package spec describes the binary encoding of all goldchain transaction versions,
+// and emits serialization test vectors of them, such that third-party SDKs
+// can validate their encoders against the encoding used by the node.
+package spec
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/extensions/minting"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+//go:generate go run gen_vectors.go -out vectors
+
+// Encoding is the binary encoding of the data of a transaction version.
+type Encoding string
+
+// binary encodings used by the transaction versions
+const (
+	// Siabin is the legacy encoding, prefixing slices with their length as an uint64.
+	Siabin Encoding = "siabin"
+	// Rivbin is the compact encoding, prefixing slices with their length as a variable-length integer.
+	Rivbin Encoding = "rivbin"
+)
+
+type (
+	// TransactionVersion describes the binary encoding of a transaction version.
+	// A transaction is encoded as its version (a single byte), followed by its data.
+	TransactionVersion struct {
+		Version types.TransactionVersion `json:"version"`
+		Name    string                   `json:"name"`
+		// Encoding is the encoding of the transaction data.
+		Encoding Encoding `json:"encoding"`
+		// LengthPrefixed defines whether the encoded transaction data is prefixed by its length,
+		// as if it was a siabin-encoded byte slice.
+		LengthPrefixed bool `json:"lengthprefixed,omitempty"`
+		// IDSpecifier is the specifier prefixing the transaction data hashed to compute the transaction ID,
+		// rather than hashing the encoded transaction as a whole.
+		IDSpecifier string `json:"idspecifier,omitempty"`
+		// Fields are the fields of the transaction data, in encoding order.
+		Fields []Field `json:"fields"`
+	}
+
+	// Field describes a field of an encoded structure.
+	Field struct {
+		// Name is the name of the field in the JSON encoding.
+		Name string `json:"name"`
+		// Type is the (Go) type of the field, as described by Spec.Types in case it is a structure.
+		Type string `json:"type"`
+		// Optional defines whether the field is omitted from the JSON encoding when empty.
+		// All fields are part of the binary encoding.
+		Optional bool `json:"optional,omitempty"`
+	}
+
+	// Spec describes the binary encoding of all goldchain transaction versions.
+	Spec struct {
+		TransactionVersions []TransactionVersion `json:"transactionversions"`
+		// Types describes the fields of the structures used by the transaction versions, in encoding order.
+		// Types which are not described, such as conditions, fulfillments, currencies and addresses,
+		// have an encoding of their own, of which the test vectors contain examples.
+		Types map[string][]Field `json:"types"`
+	}
+)
+
+// transactionVersion describes a transaction version using the structure it is encoded as.
+type transactionVersion struct {
+	TransactionVersion
+	data interface{}
+}
+
+var transactionVersions = []transactionVersion{
+	{TransactionVersion{
+		Version: types.TransactionVersionOne, Name: "standard",
+		Encoding: Siabin, LengthPrefixed: true,
+	}, types.TransactionData{}},
+	{TransactionVersion{
+		Version: goldchaintypes.MinterDefinitionTxVersion, Name: "minter definition",
+		Encoding: Rivbin, IDSpecifier: minting.SpecifierMintDefinitionTransaction.String(),
+	}, minting.MinterDefinitionTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.CoinCreationTxVersion, Name: "coin creation",
+		Encoding: Rivbin, IDSpecifier: minting.SpecifierCoinCreationTransaction.String(),
+	}, minting.CoinCreationTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.CoinDestructionTxVersion, Name: "coin destruction",
+		Encoding: Rivbin, IDSpecifier: minting.SpecifierCoinDestructionTransaction.String(),
+	}, minting.CoinDestructionTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.TransactionVersionAuthAddressUpdateTx, Name: "auth address update",
+		Encoding: Rivbin, IDSpecifier: authcointx.SpecifierAuthAddressUpdateTransaction.String(),
+	}, authcointx.AuthAddressUpdateTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.TransactionVersionAuthConditionUpdateTx, Name: "auth condition update",
+		Encoding: Rivbin, IDSpecifier: authcointx.SpecifierAuthConditionUpdateTransaction.String(),
+	}, authcointx.AuthConditionUpdateTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.TransactionVersionExpiring, Name: "expiring",
+		Encoding: Rivbin, IDSpecifier: goldchaintypes.SpecifierExpiringTransaction.String(),
+	}, goldchaintypes.ExpiringTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.TransactionVersionBlockStakeDelegation, Name: "blockstake delegation",
+		Encoding: Rivbin, IDSpecifier: goldchaintypes.SpecifierBlockStakeDelegationTransaction.String(),
+	}, goldchaintypes.BlockStakeDelegationTransaction{}},
+	{TransactionVersion{
+		Version: goldchaintypes.TransactionVersionVote, Name: "vote",
+		Encoding: Rivbin, IDSpecifier: goldchaintypes.SpecifierVoteTransaction.String(),
+	}, goldchaintypes.VoteTransaction{}},
+}
+
+// Describe describes the binary encoding of all goldchain transaction versions,
+// as derived from the structures they are encoded as.
+func Describe() Spec {
+	spec := Spec{Types: map[string][]Field{}}
+	for _, version := range transactionVersions {
+		version.Fields = describeFields(reflect.TypeOf(version.data), spec.Types)
+		spec.TransactionVersions = append(spec.TransactionVersions, version.TransactionVersion)
+	}
+	return spec
+}
+
+var (
+	siaMarshaler    = reflect.TypeOf((*siabin.SiaMarshaler)(nil)).Elem()
+	rivineMarshaler = reflect.TypeOf((*rivbin.RivineMarshaler)(nil)).Elem()
+)
+
+// describeFields describes the encoded fields of the given structure,
+// adding the structures used by these fields to the given types.
+func describeFields(t reflect.Type, structs map[string][]Field) []Field {
+	var fields []Field
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" {
+			// not encoded
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, Field{
+			Name:     name,
+			Type:     typeName(field.Type),
+			Optional: strings.Contains(tag, ",omitempty"),
+		})
+		describeStruct(field.Type, structs)
+	}
+	return fields
+}
+
+// describeStruct adds the given type to the given types,
+// should it be a structure without an encoding of its own.
+func describeStruct(t reflect.Type, structs map[string][]Field) {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Ptr {
+		if t.Elem().Kind() == reflect.Uint8 {
+			return
+		}
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t.Implements(siaMarshaler) || t.Implements(rivineMarshaler) ||
+		reflect.PtrTo(t).Implements(siaMarshaler) || reflect.PtrTo(t).Implements(rivineMarshaler) {
+		return
+	}
+	name := typeName(t)
+	if _, ok := structs[name]; ok {
+		return
+	}
+	structs[name] = nil // guards against recursive structures
+	structs[name] = describeFields(t, structs)
+}
+
+// typeName returns the name of the given type without package qualifiers, e.g. []CoinInput.
+func typeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && t.Elem().PkgPath() == "" {
+			return "[]byte"
+		}
+		return "[]" + typeName(t.Elem())
+	case reflect.Ptr:
+		return "*" + typeName(t.Elem())
+	}
+	if t.Name() == "" {
+		return t.String()
+	}
+	return t.Name()
+}
@@ -0,0 +1,69 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func init() {
+	RegisterTransactionVersions()
+}
+
+func TestGeneratedVectors(t *testing.T) {
+	doc, err := NewDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = doc.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	generated, err := ioutil.ReadFile(filepath.Join("vectors", "vectors.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), generated) {
+		t.Fatal("the generated test vectors are outdated, regenerate them using 'make spec-vectors'")
+	}
+
+	var shipped Document
+	if err = json.Unmarshal(generated, &shipped); err != nil {
+		t.Fatal(err)
+	}
+	versions := map[uint8]bool{}
+	for _, version := range shipped.Spec.TransactionVersions {
+		versions[uint8(version.Version)] = true
+		if len(version.Fields) == 0 {
+			t.Errorf("no fields described for transaction version %d", version.Version)
+		}
+	}
+	for _, vector := range shipped.Vectors {
+		if err = vector.Verify(); err != nil {
+			t.Errorf("invalid vector %q: %v", vector.Description, err)
+		}
+		delete(versions, uint8(vector.Version))
+	}
+	if len(versions) != 0 {
+		t.Errorf("no vectors for transaction versions %v", versions)
+	}
+}
+
+func TestVerifyCorruptedVector(t *testing.T) {
+	vectors, err := Vectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vector := vectors[0]
+	vector.ID[0] ^= 0xff
+	if err = vector.Verify(); err == nil {
+		t.Error("expected a vector with another ID to be refused")
+	}
+	vector = vectors[0]
+	vector.JSON = bytes.Replace(vector.JSON, []byte(`"minerfees":["100000000"]`), []byte(`"minerfees":["100000001"]`), 1)
+	if err = vector.Verify(); err == nil {
+		t.Error("expected a vector with another JSON encoding to be refused")
+	}
+}
@@ -0,0 +1,314 @@
+package spec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/extensions/minting"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+)
+
+// Vector is a serialization test vector of a transaction.
+type Vector struct {
+	Description string                   `json:"description"`
+	Version     types.TransactionVersion `json:"version"`
+	// JSON is the JSON encoding of the transaction, as accepted and returned by the API.
+	JSON json.RawMessage `json:"json"`
+	// Binary is the hex-encoded binary encoding of the transaction,
+	// as sent to peers and hashed as part of the block.
+	Binary string `json:"binary"`
+	// ID is the ID of the transaction.
+	ID types.TransactionID `json:"id"`
+}
+
+// RegisterTransactionVersions registers the controllers of all goldchain transaction versions,
+// as required to encode and decode them. These controllers have no access to the mint and auth conditions,
+// and thus cannot sign or validate the extension transactions. Processes which do, such as the daemon and client,
+// register these controllers using their own getters instead.
+func RegisterTransactionVersions() {
+	goldchaintypes.RegisterTransactionVersions(goldchaintypes.TransactionControllerGetters{})
+}
+
+// Vectors returns the serialization test vectors of all goldchain transaction versions,
+// which are deterministic, such that they can be generated once and shipped along an SDK.
+// The signatures of the transactions are not valid, as only their encoding is tested.
+// The transaction versions have to be registered, see RegisterTransactionVersions.
+func Vectors() ([]Vector, error) {
+	vectors := make([]Vector, 0, len(exampleTransactions))
+	for _, example := range exampleTransactions {
+		txn := example.transaction()
+		vector, err := newVector(example.description, txn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create vector %q: %v", example.description, err)
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// Verify verifies that the given vector decodes from both of its encodings to the same transaction,
+// which encodes again to the encodings and ID of the vector.
+// The transaction version of the vector has to be registered, see RegisterTransactionVersions.
+func (v Vector) Verify() error {
+	b, err := hex.DecodeString(v.Binary)
+	if err != nil {
+		return fmt.Errorf("invalid binary encoding: %v", err)
+	}
+	// the JSON of a vector read from an indented document is compared compacted
+	var expectedJSON bytes.Buffer
+	err = json.Compact(&expectedJSON, v.JSON)
+	if err != nil {
+		return fmt.Errorf("invalid JSON encoding: %v", err)
+	}
+	var fromBinary, fromJSON types.Transaction
+	err = siabin.Unmarshal(b, &fromBinary)
+	if err != nil {
+		return fmt.Errorf("failed to decode the binary encoding: %v", err)
+	}
+	err = json.Unmarshal(v.JSON, &fromJSON)
+	if err != nil {
+		return fmt.Errorf("failed to decode the JSON encoding: %v", err)
+	}
+	for _, txn := range []types.Transaction{fromBinary, fromJSON} {
+		encoded, err := newVector(v.Description, txn)
+		if err != nil {
+			return err
+		}
+		if encoded.Version != v.Version {
+			return fmt.Errorf("decoded version %d rather than version %d", encoded.Version, v.Version)
+		}
+		if encoded.Binary != v.Binary {
+			return fmt.Errorf("binary encoding %s differs from %s", encoded.Binary, v.Binary)
+		}
+		if !bytes.Equal(encoded.JSON, expectedJSON.Bytes()) {
+			return fmt.Errorf("JSON encoding %s differs from %s", encoded.JSON, expectedJSON.Bytes())
+		}
+		if encoded.ID != v.ID {
+			return fmt.Errorf("ID %s differs from %s", encoded.ID.String(), v.ID.String())
+		}
+	}
+	return nil
+}
+
+func newVector(description string, txn types.Transaction) (Vector, error) {
+	var buf bytes.Buffer
+	err := txn.MarshalSia(&buf)
+	if err != nil {
+		return Vector{}, err
+	}
+	b, err := json.Marshal(txn)
+	if err != nil {
+		return Vector{}, err
+	}
+	return Vector{
+		Description: description,
+		Version:     txn.Version,
+		JSON:        b,
+		Binary:      hex.EncodeToString(buf.Bytes()),
+		ID:          txn.ID(),
+	}, nil
+}
+
+// exampleTransactions covers all transaction versions, as well as all conditions and fulfillments.
+// Minter definition and coin creation transactions have no miner fees, as goldchain does not require them.
+var exampleTransactions = []struct {
+	description string
+	transaction func() types.Transaction
+}{
+	{"standard coin transfer", func() types.Transaction {
+		return types.Transaction{
+			Version: types.TransactionVersionOne,
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("coin output")), Fulfillment: exampleSingleSignatureFulfillment(1)},
+			},
+			CoinOutputs: []types.CoinOutput{
+				{Value: types.NewCurrency64(1000000000), Condition: exampleUnlockHashCondition(2)},
+				{Value: types.NewCurrency64(42), Condition: types.NewCondition(types.NewTimeLockCondition(1600000000, types.NewUnlockHashCondition(exampleAddress(1))))},
+				{Value: types.NewCurrency64(7), Condition: types.NewCondition(&types.NilCondition{})},
+			},
+			MinerFees:     []types.Currency{types.NewCurrency64(100000000)},
+			ArbitraryData: []byte("payment for invoice 42"),
+		}
+	}},
+	{"standard blockstake transfer using a multisignature wallet", func() types.Transaction {
+		return types.Transaction{
+			Version: types.TransactionVersionOne,
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("multisig coin output")), Fulfillment: exampleMultiSignatureFulfillment(1, 2)},
+			},
+			BlockStakeInputs: []types.BlockStakeInput{
+				{ParentID: types.BlockStakeOutputID(exampleHash("multisig blockstake output")), Fulfillment: exampleMultiSignatureFulfillment(1, 2)},
+			},
+			BlockStakeOutputs: []types.BlockStakeOutput{
+				{Value: types.NewCurrency64(10), Condition: exampleMultiSignatureCondition(1, 2, 3)},
+			},
+			MinerFees: []types.Currency{types.NewCurrency64(100000000)},
+		}
+	}},
+	{"minter definition", func() types.Transaction {
+		txn := minting.MinterDefinitionTransaction{
+			Nonce:           exampleNonce(1),
+			MintFulfillment: exampleSingleSignatureFulfillment(1),
+			MintCondition:   exampleMultiSignatureCondition(1, 2, 3),
+			ArbitraryData:   []byte("new minters"),
+		}
+		return txn.Transaction(goldchaintypes.MinterDefinitionTxVersion)
+	}},
+	{"coin creation", func() types.Transaction {
+		txn := minting.CoinCreationTransaction{
+			Nonce:           exampleNonce(2),
+			MintFulfillment: exampleMultiSignatureFulfillment(1, 2),
+			CoinOutputs: []types.CoinOutput{
+				{Value: types.NewCurrency64(5000000000000), Condition: exampleUnlockHashCondition(4)},
+			},
+			ArbitraryData: []byte("gold deposit 42"),
+		}
+		return txn.Transaction(goldchaintypes.CoinCreationTxVersion)
+	}},
+	{"coin destruction", func() types.Transaction {
+		txn := minting.CoinDestructionTransaction{
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("destroyed coin output")), Fulfillment: exampleSingleSignatureFulfillment(4)},
+			},
+			RefundCoinOutput: &types.CoinOutput{Value: types.NewCurrency64(3), Condition: exampleUnlockHashCondition(4)},
+			MinerFees:        []types.Currency{types.NewCurrency64(100000000)},
+			ArbitraryData:    []byte("gold withdrawal 42"),
+		}
+		return txn.Transaction(goldchaintypes.CoinDestructionTxVersion)
+	}},
+	{"auth address update", func() types.Transaction {
+		txn := authcointx.AuthAddressUpdateTransaction{
+			Nonce:           exampleNonce(3),
+			AuthAddresses:   []types.UnlockHash{exampleAddress(4), exampleAddress(5)},
+			DeauthAddresses: []types.UnlockHash{exampleAddress(6)},
+			ArbitraryData:   []byte("kyc batch 42"),
+			AuthFulfillment: exampleSingleSignatureFulfillment(1),
+		}
+		return txn.Transaction(goldchaintypes.TransactionVersionAuthAddressUpdateTx)
+	}},
+	{"auth condition update", func() types.Transaction {
+		txn := authcointx.AuthConditionUpdateTransaction{
+			Nonce:           exampleNonce(4),
+			AuthCondition:   exampleUnlockHashCondition(2),
+			AuthFulfillment: exampleSingleSignatureFulfillment(1),
+		}
+		return txn.Transaction(goldchaintypes.TransactionVersionAuthConditionUpdateTx)
+	}},
+	{"expiring coin transfer", func() types.Transaction {
+		txn := goldchaintypes.ExpiringTransaction{
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("expiring coin output")), Fulfillment: exampleSingleSignatureFulfillment(2)},
+			},
+			CoinOutputs: []types.CoinOutput{
+				{Value: types.NewCurrency64(1000000000), Condition: exampleUnlockHashCondition(5)},
+			},
+			MinerFees:        []types.Currency{types.NewCurrency64(100000000)},
+			ExpirationHeight: 123456,
+		}
+		return txn.Transaction(goldchaintypes.TransactionVersionExpiring)
+	}},
+	{"blockstake delegation", func() types.Transaction {
+		txn := goldchaintypes.BlockStakeDelegationTransaction{
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("delegating coin output")), Fulfillment: exampleSingleSignatureFulfillment(3)},
+			},
+			BlockStakeInputs: []types.BlockStakeInput{
+				{ParentID: types.BlockStakeOutputID(exampleHash("delegated blockstake output")), Fulfillment: exampleSingleSignatureFulfillment(3)},
+			},
+			BlockStakeOutputs: []types.BlockStakeOutput{
+				{Value: types.NewCurrency64(100), Condition: exampleUnlockHashCondition(3)},
+			},
+			MinerFees: []types.Currency{types.NewCurrency64(100000000)},
+			Operator:  exampleAddress(6),
+		}
+		return txn.Transaction(goldchaintypes.TransactionVersionBlockStakeDelegation)
+	}},
+	{"vote", func() types.Transaction {
+		txn := goldchaintypes.VoteTransaction{
+			CoinInputs: []types.CoinInput{
+				{ParentID: types.CoinOutputID(exampleHash("voting coin output")), Fulfillment: exampleSingleSignatureFulfillment(3)},
+			},
+			BlockStakeInputs: []types.BlockStakeInput{
+				{ParentID: types.BlockStakeOutputID(exampleHash("voting blockstake output")), Fulfillment: exampleSingleSignatureFulfillment(3)},
+			},
+			BlockStakeOutputs: []types.BlockStakeOutput{
+				{Value: types.NewCurrency64(100), Condition: exampleUnlockHashCondition(3)},
+			},
+			MinerFees: []types.Currency{types.NewCurrency64(100000000)},
+			Proposal: goldchaintypes.Proposal{
+				Title:     "lower the minimum transaction fee",
+				Reference: "https://example.org/proposals/1",
+				EndHeight: 123456,
+			},
+			Choice: goldchaintypes.VoteChoiceYes,
+		}
+		return txn.Transaction(goldchaintypes.TransactionVersionVote)
+	}},
+}
+
+func exampleHash(s string) crypto.Hash {
+	return crypto.HashBytes([]byte(s))
+}
+
+func exampleNonce(i byte) (nonce types.TransactionNonce) {
+	for j := range nonce {
+		nonce[j] = i
+	}
+	return
+}
+
+// examplePublicKey returns the i-th example public key, which is not a valid curve point,
+// but encodes as any other public key.
+func examplePublicKey(i byte) types.PublicKey {
+	var pk crypto.PublicKey
+	for j := range pk {
+		pk[j] = i
+	}
+	return types.Ed25519PublicKey(pk)
+}
+
+func exampleSignature(i byte) types.ByteSlice {
+	sig := make(types.ByteSlice, crypto.SignatureSize)
+	for j := range sig {
+		sig[j] = i ^ byte(j)
+	}
+	return sig
+}
+
+func exampleAddress(i byte) types.UnlockHash {
+	return types.NewPubKeyUnlockHash(examplePublicKey(i))
+}
+
+func exampleUnlockHashCondition(i byte) types.UnlockConditionProxy {
+	return types.NewCondition(types.NewUnlockHashCondition(exampleAddress(i)))
+}
+
+func exampleMultiSignatureCondition(minSignatures uint64, keys ...byte) types.UnlockConditionProxy {
+	addresses := make(types.UnlockHashSlice, 0, len(keys))
+	for _, i := range keys {
+		addresses = append(addresses, exampleAddress(i))
+	}
+	return types.NewCondition(types.NewMultiSignatureCondition(addresses, minSignatures))
+}
+
+func exampleSingleSignatureFulfillment(i byte) types.UnlockFulfillmentProxy {
+	return types.NewFulfillment(&types.SingleSignatureFulfillment{
+		PublicKey: examplePublicKey(i),
+		Signature: exampleSignature(i),
+	})
+}
+
+func exampleMultiSignatureFulfillment(keys ...byte) types.UnlockFulfillmentProxy {
+	pairs := make([]types.PublicKeySignaturePair, 0, len(keys))
+	for _, i := range keys {
+		pairs = append(pairs, types.PublicKeySignaturePair{PublicKey: examplePublicKey(i), Signature: exampleSignature(i)})
+	}
+	return types.NewFulfillment(types.NewMultiSignatureFulfillment(pairs))
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/threefoldtech/rivine/persist"
+)
+
+// CursorsFile is the name of the file in which the cursors of the event consumers are stored.
+const CursorsFile = "eventcursors.json"
+
+// ErrUnknownCursor is returned in case no cursor exists with a given name.
+var ErrUnknownCursor = errors.New("unknown cursor")
+
+var cursorsMetadata = persist.Metadata{
+	Header:  "Goldchain Event Cursors",
+	Version: "1.0",
+}
+
+// cursorNamePattern restricts the names of cursors to those which can be used as path segment as is.
+var cursorNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Cursors stores the sequence number of the last event processed per named consumer,
+// such that a consumer resumes from its last position after downtime, without keeping any state itself.
+type Cursors struct {
+	filename string
+
+	mu      sync.Mutex
+	cursors map[string]uint64
+}
+
+// NewCursors creates the cursors, loading those stored in the given file.
+func NewCursors(filename string) (*Cursors, error) {
+	c := &Cursors{
+		filename: filename,
+		cursors:  make(map[string]uint64),
+	}
+	err := persist.LoadJSON(cursorsMetadata, &c.cursors, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load event cursors: %v", err)
+	}
+	return c, nil
+}
+
+// Get returns the sequence number of the last event processed by the named consumer.
+func (c *Cursors) Get(name string) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seq, ok := c.cursors[name]
+	if !ok {
+		return 0, ErrUnknownCursor
+	}
+	return seq, nil
+}
+
+// All returns the sequence numbers of all consumers by name.
+func (c *Cursors) All() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cursors := make(map[string]uint64, len(c.cursors))
+	for name, seq := range c.cursors {
+		cursors[name] = seq
+	}
+	return cursors
+}
+
+// Set stores the sequence number of the last event processed by the named consumer.
+func (c *Cursors) Set(name string, seq uint64) error {
+	if !cursorNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid cursor name %q, only letters, digits, '_', '.' and '-' are allowed", name)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	previous, existed := c.cursors[name]
+	c.cursors[name] = seq
+	err := persist.SaveJSON(cursorsMetadata, c.cursors, c.filename)
+	if err != nil {
+		if existed {
+			c.cursors[name] = previous
+		} else {
+			delete(c.cursors, name)
+		}
+		return fmt.Errorf("failed to store event cursor: %v", err)
+	}
+	return nil
+}
+
+// Delete removes the cursor of the named consumer.
+func (c *Cursors) Delete(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seq, ok := c.cursors[name]
+	if !ok {
+		return ErrUnknownCursor
+	}
+	delete(c.cursors, name)
+	err := persist.SaveJSON(cursorsMetadata, c.cursors, c.filename)
+	if err != nil {
+		c.cursors[name] = seq
+		return fmt.Errorf("failed to store event cursors: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package events
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "EventsPlugin"
+)
+
+// bucketEvents maps the sequence number of every event to the JSON-encoded event
+var bucketEvents = []byte("events")
+
+// Type is the type of an event.
+type Type string
+
+// Types of the events.
+const (
+	// TypeBlock is emitted for every block, before the events of its transactions
+	TypeBlock Type = "block"
+	// TypeTransaction is emitted for every transaction, before the events of its inputs and outputs
+	TypeTransaction Type = "transaction"
+	// TypeOutputCreated is emitted for every coin output created by a transaction, or a miner payout of a block
+	TypeOutputCreated Type = "outputcreated"
+	// TypeOutputSpent is emitted for every coin output spent by a transaction
+	TypeOutputSpent Type = "outputspent"
+	// TypeAuthStateChanged is emitted for every address (de)authorized by an auth address update transaction
+	TypeAuthStateChanged Type = "authstatechanged"
+)
+
+// Event is a change applied to the blockchain, or reverted from it.
+// The events of a reverted block are the events of the block when it was applied, in reverse order,
+// marked as reverted, such that an indexer undoes each of them.
+type Event struct {
+	// Seq is the sequence number of the event, increasing by one for every event, starting at 1
+	Seq      uint64 `json:"seq"`
+	Type     Type   `json:"type"`
+	Reverted bool   `json:"reverted"`
+
+	Height    types.BlockHeight `json:"height"`
+	BlockID   types.BlockID     `json:"blockid"`
+	Timestamp types.Timestamp   `json:"timestamp"`
+	// TransactionID is undefined for block events and miner payouts
+	TransactionID *types.TransactionID `json:"transactionid,omitempty"`
+
+	// OutputID is defined for the output events, and Output for the created outputs
+	OutputID *types.CoinOutputID `json:"outputid,omitempty"`
+	Output   *types.CoinOutput   `json:"output,omitempty"`
+
+	// Address and Authorized are defined for the auth state events,
+	// Authorized being the state of the address once the change is applied
+	Address    *types.UnlockHash `json:"address,omitempty"`
+	Authorized *bool             `json:"authorized,omitempty"`
+}
+
+// Plugin is a consensus set plugin, recording the changes applied to the blockchain as an ordered log of events,
+// such that downstream indexers can replay them from the last event they processed.
+// The events are stored together with the blocks, such that the log always matches the blockchain.
+type Plugin struct {
+	genesis                             types.Block
+	authAddressUpdateTransactionVersion types.TransactionVersion
+
+	storage            modules.PluginViewStorage
+	unregisterCallback modules.PluginUnregisterCallback
+
+	mu     sync.Mutex
+	closed bool
+	// changed is closed (and replaced) whenever events are committed
+	changed chan struct{}
+}
+
+var (
+	_ modules.ConsensusSetPlugin     = (*Plugin)(nil)
+	_ modules.ConsensusSetSubscriber = (*Plugin)(nil)
+)
+
+// NewPlugin creates a new events plugin, for the chain starting with the given genesis block,
+// using the given auth address update transaction version.
+// The plugin is to be subscribed to the consensus set as well, in order to notify the callers waiting for events.
+func NewPlugin(genesis types.Block, authAddressUpdateTransactionVersion types.TransactionVersion) *Plugin {
+	return &Plugin{
+		genesis:                             genesis,
+		authAddressUpdateTransactionVersion: authAddressUpdateTransactionVersion,
+		changed:                             make(chan struct{}),
+	}
+}
+
+// InitPlugin initializes the bucket of the plugin for the first time,
+// recording the events of the genesis block while the bucket is still writable.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		_, err := bucket.CreateBucketIfNotExists(bucketEvents)
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", bucketEvents, err)
+		}
+		events, err := p.blockEvents(p.genesis, 0)
+		if err != nil {
+			return persist.Metadata{}, err
+		}
+		err = p.record(events, persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+			return bucket, nil
+		}))
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to record genesis block: %v", err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock records the events of the block,
+// except for the genesis block, which is recorded when the plugin is initialized.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if height == 0 {
+		return nil
+	}
+	events, err := p.blockEvents(block, height)
+	if err != nil {
+		return err
+	}
+	return p.record(events, bucket)
+}
+
+// RevertBlock records the events of the block in reverse order, marked as reverted,
+// the genesis block is never reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if height == 0 {
+		return nil
+	}
+	events, err := p.blockEvents(block, height)
+	if err != nil {
+		return err
+	}
+	reverted := make([]Event, 0, len(events))
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		event.Reverted = true
+		reverted = append(reverted, event)
+	}
+	return p.record(reverted, bucket)
+}
+
+// ApplyTransaction implements modules.ConsensusSetPlugin,
+// transactions are only recorded as part of their block,
+// as the consensus set applies unconfirmed transactions as well, in order to validate them.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are only reverted as part of their block.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// notifying the callers waiting for events once the events of the change are committed.
+func (p *Plugin) ProcessConsensusChange(modules.ConsensusChange) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// Close releases the storage of the plugin, and stops all callers waiting for events.
+func (p *Plugin) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.changed)
+	}
+	p.mu.Unlock()
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// Events returns at most limit events with a sequence number greater than the given one, oldest first,
+// as well as the sequence number of the last recorded event.
+func (p *Plugin) Events(after uint64, limit int) ([]Event, uint64, error) {
+	events := []Event{}
+	var lastSeq uint64
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		eventsBucket := bucket.Bucket(bucketEvents)
+		if eventsBucket == nil {
+			return errors.New("events bucket does not exist")
+		}
+		cursor := eventsBucket.Cursor()
+		if k, _ := cursor.Last(); k != nil {
+			lastSeq = binary.BigEndian.Uint64(k)
+		}
+		for k, v := cursor.Seek(encodeSeq(after + 1)); k != nil && len(events) < limit; k, v = cursor.Next() {
+			var event Event
+			err := json.Unmarshal(v, &event)
+			if err != nil {
+				return fmt.Errorf("failed to decode event %d: %v", binary.BigEndian.Uint64(k), err)
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, lastSeq, err
+}
+
+// Wait returns the same events as Events,
+// waiting for such an event to be recorded should there be none yet, until the given context is done.
+func (p *Plugin) Wait(ctx context.Context, after uint64, limit int) ([]Event, uint64, error) {
+	for {
+		p.mu.Lock()
+		changed := p.changed
+		p.mu.Unlock()
+		events, lastSeq, err := p.Events(after, limit)
+		if err != nil || len(events) > 0 {
+			return events, lastSeq, err
+		}
+		select {
+		case <-changed:
+			p.mu.Lock()
+			closed := p.closed
+			p.mu.Unlock()
+			if closed {
+				return events, lastSeq, nil
+			}
+		case <-ctx.Done():
+			return events, lastSeq, nil
+		}
+	}
+}
+
+// blockEvents returns the events of the given block when applied, without sequence numbers.
+func (p *Plugin) blockEvents(block types.Block, height types.BlockHeight) ([]Event, error) {
+	blockID := block.ID()
+	base := Event{
+		Height:    height,
+		BlockID:   blockID,
+		Timestamp: block.Timestamp,
+	}
+	blockEvent := base
+	blockEvent.Type = TypeBlock
+	events := []Event{blockEvent}
+	for index, mp := range block.MinerPayouts {
+		id := types.CoinOutputID(block.MinerPayoutID(uint64(index)))
+		event := base
+		event.Type = TypeOutputCreated
+		event.OutputID = &id
+		event.Output = &types.CoinOutput{
+			Value:     mp.Value,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(mp.UnlockHash)),
+		}
+		events = append(events, event)
+	}
+
+	for _, txn := range block.Transactions {
+		txnID := txn.ID()
+		txnBase := base
+		txnBase.TransactionID = &txnID
+		txnEvent := txnBase
+		txnEvent.Type = TypeTransaction
+		events = append(events, txnEvent)
+		for _, ci := range txn.CoinInputs {
+			id := ci.ParentID
+			event := txnBase
+			event.Type = TypeOutputSpent
+			event.OutputID = &id
+			events = append(events, event)
+		}
+		for index := range txn.CoinOutputs {
+			id := txn.CoinOutputID(uint64(index))
+			co := txn.CoinOutputs[index]
+			event := txnBase
+			event.Type = TypeOutputCreated
+			event.OutputID = &id
+			event.Output = &co
+			events = append(events, event)
+		}
+		if txn.Version != p.authAddressUpdateTransactionVersion {
+			continue
+		}
+		aautx, err := authcointx.AuthAddressUpdateTransactionFromTransaction(txn, p.authAddressUpdateTransactionVersion)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack auth address update transaction: %v", err)
+		}
+		for _, update := range []struct {
+			addresses  []types.UnlockHash
+			authorized bool
+		}{
+			{aautx.AuthAddresses, true},
+			{aautx.DeauthAddresses, false},
+		} {
+			for index := range update.addresses {
+				authorized := update.authorized
+				event := txnBase
+				event.Type = TypeAuthStateChanged
+				event.Address = &update.addresses[index]
+				event.Authorized = &authorized
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// record stores the given events, numbering them after the last recorded event.
+func (p *Plugin) record(events []Event, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	eventsBucket, err := bucket.Bucket(bucketEvents)
+	if err != nil {
+		return errors.New("events bucket does not exist")
+	}
+	var seq uint64
+	if k, _ := eventsBucket.Cursor().Last(); k != nil {
+		seq = binary.BigEndian.Uint64(k)
+	}
+	for _, event := range events {
+		seq++
+		event.Seq = seq
+		b, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to encode event %d: %v", seq, err)
+		}
+		err = eventsBucket.Put(encodeSeq(seq), b)
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store event %d: %v", seq, err)
+		}
+	}
+	return nil
+}
+
+func encodeSeq(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
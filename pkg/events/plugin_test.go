@@ -0,0 +1,193 @@
+package events
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	gtypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginEvents(t *testing.T) {
+	db := plugintest.NewDB(t, "events")
+
+	uhA := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uhB := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	genesis := types.Block{
+		MinerPayouts: []types.MinerPayout{{Value: types.NewCurrency64(10), UnlockHash: uhA}},
+	}
+	p := NewPlugin(genesis, gtypes.TransactionVersionAuthAddressUpdateTx)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		t.Helper()
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkTypes := func(events []Event, expected ...Type) {
+		t.Helper()
+		var actual []Type
+		for _, event := range events {
+			actual = append(actual, event.Type)
+		}
+		if len(actual) != len(expected) {
+			t.Fatalf("expected events %v, got %v", expected, actual)
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Fatalf("expected events %v, got %v", expected, actual)
+			}
+		}
+	}
+
+	// the genesis block is recorded when the plugin is initialized
+	events, lastSeq, err := p.Events(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastSeq != 2 {
+		t.Errorf("expected last sequence number 2, got %d", lastSeq)
+	}
+	checkTypes(events, TypeBlock, TypeOutputCreated)
+	if events[0].Seq != 1 || events[1].Seq != 2 || *events[1].OutputID != types.CoinOutputID(genesis.MinerPayoutID(0)) {
+		t.Errorf("unexpected genesis events: %+v", events)
+	}
+
+	aautx := authcointx.AuthAddressUpdateTransaction{
+		AuthAddresses:   []types.UnlockHash{uhB},
+		DeauthAddresses: []types.UnlockHash{uhA},
+	}
+	txn := types.Transaction{
+		Version:     types.TransactionVersionOne,
+		CoinInputs:  []types.CoinInput{{ParentID: types.CoinOutputID(genesis.MinerPayoutID(0))}},
+		CoinOutputs: []types.CoinOutput{{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(uhB))}},
+	}
+	block := types.Block{
+		ParentID:     genesis.ID(),
+		Timestamp:    1,
+		Transactions: []types.Transaction{txn, aautx.Transaction(gtypes.TransactionVersionAuthAddressUpdateTx)},
+	}
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	events, lastSeq, err = p.Events(2, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTypes(events, TypeBlock, TypeTransaction, TypeOutputSpent, TypeOutputCreated,
+		TypeTransaction, TypeAuthStateChanged, TypeAuthStateChanged)
+	if lastSeq != 9 || events[0].Seq != 3 || events[6].Seq != 9 {
+		t.Errorf("unexpected sequence numbers: %d %+v", lastSeq, events)
+	}
+	if *events[1].TransactionID != txn.ID() || events[0].TransactionID != nil || events[0].BlockID != block.ID() {
+		t.Errorf("unexpected identifiers: %+v", events)
+	}
+	if *events[5].Address != uhB || !*events[5].Authorized || *events[6].Address != uhA || *events[6].Authorized {
+		t.Errorf("unexpected auth state events: %+v %+v", events[5], events[6])
+	}
+
+	// the events of a reverted block are recorded in reverse order
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block, 1, bucket)
+	})
+	reverted, lastSeq, err := p.Events(9, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastSeq != 16 || len(reverted) != len(events) {
+		t.Fatalf("unexpected reverted events: %d %+v", lastSeq, reverted)
+	}
+	for i, event := range reverted {
+		applied := events[len(events)-1-i]
+		if !event.Reverted || event.Type != applied.Type || event.Seq != uint64(10+i) {
+			t.Errorf("unexpected reverted event %d: %+v", i, event)
+		}
+	}
+
+	// the limit is respected
+	events, _, err = p.Events(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 || events[2].Seq != 3 {
+		t.Errorf("unexpected limited events: %+v", events)
+	}
+
+	// callers waiting for events are notified once the change is committed
+	done := make(chan []Event)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		events, _, _ := p.Wait(ctx, 16, 100)
+		done <- events
+	}()
+	time.Sleep(50 * time.Millisecond)
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(types.Block{ParentID: genesis.ID(), Timestamp: 2}, 1, bucket)
+	})
+	p.ProcessConsensusChange(modules.ConsensusChange{})
+	events = <-done
+	checkTypes(events, TypeBlock)
+	if events[0].Seq != 17 {
+		t.Errorf("unexpected awaited event: %+v", events[0])
+	}
+
+	// waiting callers return without events once the plugin is closed
+	go func() {
+		events, _, _ := p.Wait(context.Background(), 17, 100)
+		done <- events
+	}()
+	time.Sleep(50 * time.Millisecond)
+	p.Close()
+	if events = <-done; len(events) != 0 {
+		t.Errorf("expected no events once closed, got %+v", events)
+	}
+}
+
+func TestCursors(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), CursorsFile)
+	cursors, err := NewCursors(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = cursors.Get("indexer"); err != ErrUnknownCursor {
+		t.Errorf("expected unknown cursor, got %v", err)
+	}
+	if err = cursors.Set("indexer/1", 1); err == nil {
+		t.Error("expected invalid cursor name to be refused")
+	}
+	for _, name := range []string{"indexer", "other"} {
+		if err = cursors.Set(name, 5); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = cursors.Set("indexer", 7); err != nil {
+		t.Fatal(err)
+	}
+	if err = cursors.Delete("other"); err != nil {
+		t.Fatal(err)
+	}
+	if err = cursors.Delete("other"); err != ErrUnknownCursor {
+		t.Errorf("expected unknown cursor, got %v", err)
+	}
+
+	// the cursors are restored once loaded again
+	cursors, err = NewCursors(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seq, err := cursors.Get("indexer"); err != nil || seq != 7 {
+		t.Errorf("expected cursor at 7, got %d: %v", seq, err)
+	}
+	if all := cursors.All(); len(all) != 1 {
+		t.Errorf("expected a single cursor, got %v", all)
+	}
+}
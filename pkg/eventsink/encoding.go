@@ -0,0 +1,46 @@
+package eventsink
+
+import (
+	"encoding/json"
+
+	"github.com/nbh-digital/goldchain/pkg/events"
+	eventproto "github.com/nbh-digital/goldchain/pkg/eventsink/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// encodeJSON encodes the event as returned by the /consensus/events endpoint.
+func encodeJSON(event events.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+// encodeProtobuf encodes the event as a goldchain.events.v1.Event protocol buffer message,
+// as defined in proto/event.proto.
+func encodeProtobuf(event events.Event) ([]byte, error) {
+	msg := &eventproto.Event{
+		Seq:        event.Seq,
+		Type:       string(event.Type),
+		Reverted:   event.Reverted,
+		Height:     uint64(event.Height),
+		BlockId:    event.BlockID[:],
+		Timestamp:  uint64(event.Timestamp),
+		Authorized: event.Authorized,
+	}
+	if event.TransactionID != nil {
+		msg.TransactionId = event.TransactionID[:]
+	}
+	if event.OutputID != nil {
+		msg.OutputId = event.OutputID[:]
+	}
+	if event.Output != nil {
+		msg.OutputValue = event.Output.Value.String()
+		condition, err := json.Marshal(event.Output.Condition)
+		if err != nil {
+			return nil, err
+		}
+		msg.OutputCondition = condition
+	}
+	if event.Address != nil {
+		msg.Address = event.Address.String()
+	}
+	return proto.Marshal(msg)
+}
@@ -0,0 +1,62 @@
+package eventsink
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/events"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// defaultKafkaPort is the port of the Kafka broker, should the URL not define it
+	defaultKafkaPort = "9092"
+	// kafkaKey is the key of every message, such that all events are stored in the same partition, preserving their order
+	kafkaKey = "goldchain"
+	// kafkaBatchTimeout is the duration the writer waits for more messages before producing an incomplete batch,
+	// as the publisher writes all messages of a batch at once
+	kafkaBatchTimeout = 10 * time.Millisecond
+)
+
+// kafkaSink produces events to a Kafka topic using the Kafka client,
+// the broker of the URL being used to discover the leader of the partition.
+type kafkaSink struct {
+	writer *kafka.Writer
+}
+
+func newKafkaSink(u *url.URL) *kafkaSink {
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), defaultKafkaPort)
+	}
+	return &kafkaSink{writer: &kafka.Writer{
+		Addr:         kafka.TCP(address),
+		Topic:        strings.Trim(u.Path, "/"),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		// failed publications are retried by the publisher
+		MaxAttempts:  1,
+		BatchSize:    batchSize,
+		BatchTimeout: kafkaBatchTimeout,
+		ReadTimeout:  sinkTimeout,
+		WriteTimeout: sinkTimeout,
+	}}
+}
+
+// publish produces all events to the topic, returning once all replicas acknowledged them.
+func (s *kafkaSink) publish(batch []events.Event, payloads [][]byte) error {
+	messages := make([]kafka.Message, 0, len(payloads))
+	for _, payload := range payloads {
+		messages = append(messages, kafka.Message{Key: []byte(kafkaKey), Value: payload})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sinkTimeout)
+	defer cancel()
+	return s.writer.WriteMessages(ctx, messages...)
+}
+
+func (s *kafkaSink) close() error {
+	return s.writer.Close()
+}
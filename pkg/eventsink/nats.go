@@ -0,0 +1,78 @@
+package eventsink
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nbh-digital/goldchain/pkg/events"
+)
+
+// defaultNATSSubject is the prefix of the subjects the events are published to, should the URL not define it
+const defaultNATSSubject = "goldchain.events"
+
+// natsSink publishes events to a NATS server using the NATS client.
+// TLS is used should the server require it.
+type natsSink struct {
+	url     string
+	subject string
+
+	conn *nats.Conn
+}
+
+func newNATSSink(u *url.URL) *natsSink {
+	// the path defines the subject prefix rather than being part of the server URL
+	server := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	s := &natsSink{
+		url:     server.String(),
+		subject: strings.Trim(u.Path, "/"),
+	}
+	if s.subject == "" {
+		s.subject = defaultNATSSubject
+	}
+	return s
+}
+
+// publish publishes every event to the subject <subject prefix>.<event type>,
+// flushing the connection such that the events are known to be processed by the server once it returns.
+func (s *natsSink) publish(batch []events.Event, payloads [][]byte) error {
+	if s.conn == nil {
+		conn, err := nats.Connect(s.url,
+			nats.Name("goldchaind"),
+			nats.Timeout(sinkTimeout),
+			// failed publications are retried by the publisher, using a new connection
+			nats.NoReconnect(),
+			// errors are returned by publish instead
+			nats.ErrorHandler(func(*nats.Conn, *nats.Subscription, error) {}),
+		)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	var err error
+	for i, event := range batch {
+		if err = s.conn.Publish(s.subject+"."+string(event.Type), payloads[i]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = s.conn.FlushTimeout(sinkTimeout)
+	}
+	if err == nil {
+		// such as a permissions violation, reported asynchronously by the server
+		err = s.conn.LastError()
+	}
+	if err != nil {
+		s.close()
+	}
+	return err
+}
+
+func (s *natsSink) close() error {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package eventsink
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/events"
+)
+
+const (
+	// CursorName is the name of the event cursor storing the sequence number of the last published event,
+	// such that publishing resumes from it once the daemon is restarted.
+	CursorName = "eventsink"
+
+	// DefaultRetryDelay is the duration the publisher waits before retrying to publish events which failed to be published.
+	DefaultRetryDelay = 10 * time.Second
+
+	// batchSize is the maximum amount of events published at once
+	batchSize = 500
+	// sinkTimeout is the maximum duration of a single call to the broker
+	sinkTimeout = 30 * time.Second
+)
+
+// Format is the serialization format of the published events.
+type Format string
+
+// Supported formats.
+const (
+	// FormatJSON serializes the events as returned by the /consensus/events endpoint
+	FormatJSON Format = "json"
+	// FormatProtobuf serializes the events as protocol buffers, as defined in specs/event_sink.md
+	FormatProtobuf Format = "protobuf"
+)
+
+// Config defines the broker the events are published to.
+type Config struct {
+	// URL of the broker:
+	// nats://[user:password@]host[:port][/subject prefix] publishes to the NATS subject <subject prefix>.<event type>,
+	// using the subject prefix goldchain.events if none is given;
+	// kafka://host[:port]/<topic> produces to the Kafka topic, the given broker being used to discover the cluster
+	URL string
+	// Format is the serialization format of the events, JSON if empty
+	Format Format
+}
+
+// Validate returns an error should the URL or format not be supported.
+func (cfg Config) Validate() error {
+	_, err := newSink(cfg)
+	return err
+}
+
+// sink delivers encoded events to a broker.
+type sink interface {
+	// publish delivers the given events, encoded as the given payloads, in order,
+	// returning only once the broker accepted all of them
+	publish(events []events.Event, payloads [][]byte) error
+	close() error
+}
+
+// newSink creates the sink for the broker defined by the given config, without connecting to it.
+func newSink(cfg Config) (sink, error) {
+	switch cfg.Format {
+	case "", FormatJSON, FormatProtobuf:
+	default:
+		return nil, fmt.Errorf("unsupported event format %q, expected %s or %s", cfg.Format, FormatJSON, FormatProtobuf)
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event sink URL: %v", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid event sink URL %q: no host", cfg.URL)
+	}
+	switch u.Scheme {
+	case "nats":
+		return newNATSSink(u), nil
+	case "kafka":
+		if topic := strings.Trim(u.Path, "/"); topic == "" || strings.Contains(topic, "/") {
+			return nil, fmt.Errorf("invalid event sink URL %q: expected the topic as path, e.g. kafka://localhost:9092/goldchain", cfg.URL)
+		}
+		return newKafkaSink(u), nil
+	default:
+		return nil, fmt.Errorf("unsupported event sink URL %q, expected a nats:// or kafka:// URL", cfg.URL)
+	}
+}
+
+// Publisher publishes all events recorded by the events plugin to a broker, in order,
+// resuming from the last published event once the daemon is restarted.
+// Events are published at least once: events published right before a crash
+// are published again when the daemon is restarted.
+type Publisher struct {
+	plugin     *events.Plugin
+	cursors    *events.Cursors
+	sink       sink
+	encode     func(events.Event) ([]byte, error)
+	output     io.Writer
+	retryDelay time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPublisher creates a publisher publishing the events of the given plugin to the broker defined by the given config,
+// starting after the event stored in the cursor named CursorName. Publishing errors are reported to the given writer.
+func NewPublisher(cfg Config, plugin *events.Plugin, cursors *events.Cursors, output io.Writer) (*Publisher, error) {
+	p, err := newPublisher(cfg, plugin, cursors, output)
+	if err != nil {
+		return nil, err
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p, nil
+}
+
+func newPublisher(cfg Config, plugin *events.Plugin, cursors *events.Cursors, output io.Writer) (*Publisher, error) {
+	s, err := newSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if output == nil {
+		output = ioutil.Discard
+	}
+	encode := encodeJSON
+	if cfg.Format == FormatProtobuf {
+		encode = encodeProtobuf
+	}
+	p := &Publisher{
+		plugin:     plugin,
+		cursors:    cursors,
+		sink:       s,
+		encode:     encode,
+		output:     output,
+		retryDelay: DefaultRetryDelay,
+	}
+	p.ctx, p.cancel = context.WithCancel(context.Background())
+	return p, nil
+}
+
+// Close stops publishing events, and disconnects from the broker.
+func (p *Publisher) Close() error {
+	p.cancel()
+	p.wg.Wait()
+	return p.sink.close()
+}
+
+// run publishes all events following the stored cursor, until the publisher or the plugin is closed.
+func (p *Publisher) run() {
+	defer p.wg.Done()
+	after, err := p.cursors.Get(CursorName)
+	if err != nil && err != events.ErrUnknownCursor {
+		fmt.Fprintln(p.output, "Event sink error:", err)
+		return
+	}
+	for {
+		batch, _, err := p.plugin.Wait(p.ctx, after, batchSize)
+		if err != nil {
+			fmt.Fprintln(p.output, "Event sink error: failed to read events:", err)
+			if !p.sleep() {
+				return
+			}
+			continue
+		}
+		if len(batch) == 0 {
+			// either the publisher or the plugin is closed
+			return
+		}
+		payloads := make([][]byte, 0, len(batch))
+		for _, event := range batch {
+			payload, err := p.encode(event)
+			if err != nil {
+				fmt.Fprintf(p.output, "Event sink error: failed to encode event %d: %v\n", event.Seq, err)
+				return
+			}
+			payloads = append(payloads, payload)
+		}
+		for {
+			err = p.sink.publish(batch, payloads)
+			if err == nil {
+				break
+			}
+			fmt.Fprintf(p.output, "Event sink error: failed to publish events %d-%d: %v\n", batch[0].Seq, batch[len(batch)-1].Seq, err)
+			if !p.sleep() {
+				return
+			}
+		}
+		after = batch[len(batch)-1].Seq
+		err = p.cursors.Set(CursorName, after)
+		if err != nil {
+			fmt.Fprintln(p.output, "Event sink error:", err)
+		}
+	}
+}
+
+// sleep waits for the retry delay, returning false if the publisher is closed in the meantime.
+func (p *Publisher) sleep() bool {
+	select {
+	case <-p.ctx.Done():
+		return false
+	case <-time.After(p.retryDelay):
+		return true
+	}
+}
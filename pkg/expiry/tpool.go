@@ -0,0 +1,127 @@
+package expiry
+
+import (
+	"sync"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// chain is the part of modules.ConsensusSet used to get the height of the next block.
+type chain interface {
+	Height() types.BlockHeight
+}
+
+// TransactionPool wraps the transaction pool used to create blocks,
+// only handing out the transactions which can still be part of the next block.
+// The transaction pool itself only drops an expiring transaction once it is no longer valid
+// at the current height, which is one block too late: until then, the expired transaction,
+// and all transactions spending its outputs, are left out of the blocks.
+type TransactionPool struct {
+	modules.TransactionPool
+	cs chain
+
+	mu          sync.Mutex
+	subscribers map[modules.TransactionPoolSubscriber]*subscriber
+}
+
+var _ modules.TransactionPool = (*TransactionPool)(nil)
+
+// NewTransactionPool creates a new transaction pool, handing out the transactions of the given transaction pool
+// which did not expire at the next block of the given consensus set.
+func NewTransactionPool(tpool modules.TransactionPool, cs modules.ConsensusSet) *TransactionPool {
+	return &TransactionPool{
+		TransactionPool: tpool,
+		cs:              cs,
+		subscribers:     make(map[modules.TransactionPoolSubscriber]*subscriber),
+	}
+}
+
+// TransactionList implements modules.TransactionPool.TransactionList,
+// returning the transactions of the pool which can be part of the next block.
+func (tp *TransactionPool) TransactionList() []types.Transaction {
+	return tp.unexpired(tp.TransactionPool.TransactionList())
+}
+
+// TransactionPoolSubscribe implements modules.TransactionPool.TransactionPoolSubscribe,
+// sending the subscriber only the transactions of the pool which can be part of the next block.
+func (tp *TransactionPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) {
+	wrapped := &subscriber{TransactionPoolSubscriber: s, tp: tp}
+	tp.mu.Lock()
+	tp.subscribers[s] = wrapped
+	tp.mu.Unlock()
+	tp.TransactionPool.TransactionPoolSubscribe(wrapped)
+}
+
+// Unsubscribe implements modules.TransactionPool.Unsubscribe.
+func (tp *TransactionPool) Unsubscribe(s modules.TransactionPoolSubscriber) {
+	tp.mu.Lock()
+	wrapped, ok := tp.subscribers[s]
+	delete(tp.subscribers, s)
+	tp.mu.Unlock()
+	if ok {
+		tp.TransactionPool.Unsubscribe(wrapped)
+	}
+}
+
+// unexpired returns the given transactions, in order, leaving out the transactions expiring before the next block,
+// as well as the transactions spending the outputs of the ones left out.
+// An expiring transaction can be part of the next block as long as its height does not exceed the expiration height.
+func (tp *TransactionPool) unexpired(txns []types.Transaction) []types.Transaction {
+	nextHeight := tp.cs.Height() + 1
+	var (
+		unexpired          []types.Transaction
+		removedCoinOutputs map[types.CoinOutputID]struct{}
+		removedBSOutputs   map[types.BlockStakeOutputID]struct{}
+	)
+	for i, txn := range txns {
+		remove := false
+		if expirationHeight, ok := goldchaintypes.ExpirationHeight(txn); ok && expirationHeight < nextHeight {
+			remove = true
+		}
+		for _, ci := range txn.CoinInputs {
+			if _, ok := removedCoinOutputs[ci.ParentID]; ok {
+				remove = true
+			}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			if _, ok := removedBSOutputs[bsi.ParentID]; ok {
+				remove = true
+			}
+		}
+		if !remove {
+			if unexpired != nil {
+				unexpired = append(unexpired, txn)
+			}
+			continue
+		}
+		if unexpired == nil {
+			// the transactions are only copied once one is left out
+			unexpired = append(make([]types.Transaction, 0, len(txns)-1), txns[:i]...)
+			removedCoinOutputs = make(map[types.CoinOutputID]struct{})
+			removedBSOutputs = make(map[types.BlockStakeOutputID]struct{})
+		}
+		for j := range txn.CoinOutputs {
+			removedCoinOutputs[txn.CoinOutputID(uint64(j))] = struct{}{}
+		}
+		for j := range txn.BlockStakeOutputs {
+			removedBSOutputs[txn.BlockStakeOutputID(uint64(j))] = struct{}{}
+		}
+	}
+	if unexpired == nil {
+		return txns
+	}
+	return unexpired
+}
+
+// subscriber receives the transactions of the pool which can be part of the next block.
+type subscriber struct {
+	modules.TransactionPoolSubscriber
+	tp *TransactionPool
+}
+
+// ReceiveUpdatedUnconfirmedTransactions implements modules.TransactionPoolSubscriber.
+func (s *subscriber) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, cc modules.ConsensusChange) {
+	s.TransactionPoolSubscriber.ReceiveUpdatedUnconfirmedTransactions(s.tp.unexpired(txns), cc)
+}
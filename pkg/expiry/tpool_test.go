@@ -0,0 +1,118 @@
+package expiry
+
+import (
+	"testing"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testConsensusSet struct {
+	modules.ConsensusSet
+	height types.BlockHeight
+}
+
+func (cs *testConsensusSet) Height() types.BlockHeight { return cs.height }
+
+type testTransactionPool struct {
+	modules.TransactionPool
+	txns       []types.Transaction
+	subscriber modules.TransactionPoolSubscriber
+}
+
+func (tp *testTransactionPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) {
+	tp.subscriber = s
+}
+
+func (tp *testTransactionPool) Unsubscribe(s modules.TransactionPoolSubscriber) {
+	if tp.subscriber == s {
+		tp.subscriber = nil
+	}
+}
+
+func (tp *testTransactionPool) TransactionList() []types.Transaction {
+	return append([]types.Transaction(nil), tp.txns...)
+}
+
+// testSubscriber records the last transactions it received.
+type testSubscriber struct {
+	txns []types.Transaction
+}
+
+func (s *testSubscriber) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, _ modules.ConsensusChange) {
+	s.txns = txns
+}
+
+func expiringTransaction(expirationHeight types.BlockHeight) types.Transaction {
+	etx := goldchaintypes.ExpiringTransaction{
+		ExpirationHeight: expirationHeight,
+		CoinOutputs:      []types.CoinOutput{{Value: types.NewCurrency64(uint64(expirationHeight))}},
+	}
+	return etx.Transaction(goldchaintypes.TransactionVersionExpiring)
+}
+
+// spending returns a regular transaction spending the first coin output of the given transaction.
+func spending(parent types.Transaction, data string) types.Transaction {
+	return types.Transaction{
+		Version:       types.TransactionVersionOne,
+		CoinInputs:    []types.CoinInput{{ParentID: parent.CoinOutputID(0)}},
+		CoinOutputs:   []types.CoinOutput{{Value: types.NewCurrency64(1)}},
+		ArbitraryData: []byte(data),
+	}
+}
+
+func TestTransactionPool(t *testing.T) {
+	// the outputs of the expiring transactions are identified using their encoding
+	types.RegisterTransactionVersion(goldchaintypes.TransactionVersionExpiring, goldchaintypes.ExpiringTransactionController{
+		TransactionVersion: goldchaintypes.TransactionVersionExpiring,
+	})
+	defer types.RegisterTransactionVersion(goldchaintypes.TransactionVersionExpiring, nil)
+	regular := types.Transaction{Version: types.TransactionVersionOne, ArbitraryData: []byte("regular")}
+	expiring, later := expiringTransaction(10), expiringTransaction(11)
+	child := spending(expiring, "child")
+	grandchild := spending(child, "grandchild")
+	unrelated := spending(later, "unrelated")
+	txns := []types.Transaction{regular, expiring, child, later, grandchild, unrelated}
+	cs := &testConsensusSet{height: 9}
+	parent := &testTransactionPool{txns: txns}
+	tpool := NewTransactionPool(parent, cs)
+
+	// all transactions can still be part of the next block
+	if list := tpool.TransactionList(); len(list) != len(txns) {
+		t.Fatalf("expected no transaction to be left out, got %d transactions", len(list))
+	}
+
+	// a transaction expiring at the current height can no longer be part of the next block,
+	// nor can the transactions depending on it
+	cs.height = 10
+	list := tpool.TransactionList()
+	expected := []types.Transaction{regular, later, unrelated}
+	if len(list) != len(expected) {
+		t.Fatalf("expected %d transactions, got %d", len(expected), len(list))
+	}
+	for i, txn := range expected {
+		if list[i].ID() != txn.ID() {
+			t.Errorf("expected transaction %d to be %s, got %s", i, txn.ID().String(), list[i].ID().String())
+		}
+	}
+	// the transaction pool itself is left untouched
+	if len(parent.txns) != len(txns) {
+		t.Errorf("expected the transaction pool to keep its %d transactions, got %d", len(txns), len(parent.txns))
+	}
+
+	// subscribers receive the same transactions
+	s := new(testSubscriber)
+	tpool.TransactionPoolSubscribe(s)
+	if parent.subscriber == nil {
+		t.Fatal("expected the subscriber to subscribe to the transaction pool")
+	}
+	parent.subscriber.ReceiveUpdatedUnconfirmedTransactions(txns, modules.ConsensusChange{})
+	if len(s.txns) != len(expected) {
+		t.Errorf("expected the subscriber to receive %d transactions, got %d", len(expected), len(s.txns))
+	}
+	tpool.Unsubscribe(s)
+	if parent.subscriber != nil {
+		t.Error("expected the subscriber to unsubscribe from the transaction pool")
+	}
+}
@@ -0,0 +1,180 @@
+package extplugin
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testPluginDirKey is the environment variable defining the directory in which the test plugin stores its data.
+const testPluginDirKey = "GOLDCHAIN_TEST_PLUGIN_DIR"
+
+// TestMain runs the test binary as the test plugin, should it be launched as a plugin.
+func TestMain(m *testing.M) {
+	if os.Getenv(MagicCookieKey) == MagicCookieValue {
+		err := Serve(&testIndexer{dir: os.Getenv(testPluginDirKey)})
+		if err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testIndexer stores the IDs of all processed consensus changes, one per line,
+// failing to process the second change the first time it is launched.
+type testIndexer struct {
+	dir string
+}
+
+func (ti *testIndexer) Init() (modules.ConsensusChangeID, error) {
+	ids, err := ti.processed()
+	if err != nil || len(ids) == 0 {
+		return modules.ConsensusChangeBeginning, err
+	}
+	var id crypto.Hash
+	err = id.LoadString(ids[len(ids)-1])
+	return modules.ConsensusChangeID(id), err
+}
+
+func (ti *testIndexer) ProcessConsensusChange(change Change) error {
+	ids, err := ti.processed()
+	if err != nil {
+		return err
+	}
+	failedMarker := filepath.Join(ti.dir, "failed")
+	if len(ids) == 1 {
+		if _, err = os.Stat(failedMarker); os.IsNotExist(err) {
+			ioutil.WriteFile(failedMarker, nil, 0600)
+			return errors.New("broken")
+		}
+	}
+	f, err := os.OpenFile(filepath.Join(ti.dir, "changes"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(change.ID.String() + "\n")
+	return err
+}
+
+func (ti *testIndexer) processed() ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(ti.dir, "changes"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(b)), nil
+}
+
+// testCS is a consensus set of which the subscribers receive a fixed list of changes.
+type testCS struct {
+	modules.ConsensusSet
+	changes []modules.ConsensusChange
+
+	mu     sync.Mutex
+	starts []modules.ConsensusChangeID
+}
+
+func (cs *testCS) ConsensusSetSubscribe(s modules.ConsensusSetSubscriber, start modules.ConsensusChangeID, _ <-chan struct{}) error {
+	cs.mu.Lock()
+	cs.starts = append(cs.starts, start)
+	cs.mu.Unlock()
+	found := start == modules.ConsensusChangeBeginning
+	for _, cc := range cs.changes {
+		if found {
+			s.ProcessConsensusChange(cc)
+		}
+		found = found || cc.ID == start
+	}
+	if !found {
+		return modules.ErrInvalidConsensusChangeID
+	}
+	return nil
+}
+
+func (cs *testCS) Unsubscribe(modules.ConsensusSetSubscriber) {}
+
+// syncBuffer is a buffer which can be written to concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (sb *syncBuffer) Write(b []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.Write(b)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buf.String()
+}
+
+func TestHost(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(testPluginDirKey, dir)
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs := &testCS{}
+	for i := 0; i < 3; i++ {
+		cs.changes = append(cs.changes, modules.ConsensusChange{
+			ID:            modules.ConsensusChangeID{byte(10 + i)},
+			AppliedBlocks: []types.Block{{Timestamp: types.Timestamp(i)}},
+			Synced:        i == 2,
+		})
+	}
+
+	output := &syncBuffer{}
+	h := newHost(Config{Name: "test", Path: executable, CallTimeout: 10 * time.Second}, cs, output)
+	h.restartDelay = 10 * time.Millisecond
+	go h.run()
+	defer h.Close()
+
+	// the plugin fails to process the second change, and resumes from the first change once launched again
+	last := crypto.Hash(cs.changes[2].ID)
+	deadline := time.Now().Add(30 * time.Second)
+	for h.Status().ConsensusChangeID != last {
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin didn't process all changes in time: %+v\n%s", h.Status(), output.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := h.Status()
+	if !status.Healthy || !status.Synced || status.Restarts != 1 || status.PID == 0 || !strings.Contains(status.LastError, "broken") {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "changes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected string
+	for _, cc := range cs.changes {
+		expected += crypto.Hash(cc.ID).String() + "\n"
+	}
+	if string(b) != expected {
+		t.Errorf("expected every change to be processed once, in order, got:\n%s", b)
+	}
+	cs.mu.Lock()
+	starts := cs.starts
+	cs.mu.Unlock()
+	if len(starts) != 2 || starts[0] != modules.ConsensusChangeBeginning || starts[1] != cs.changes[0].ID {
+		t.Errorf("unexpected subscriptions: %v", starts)
+	}
+}
@@ -0,0 +1,385 @@
+package extplugin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/nbh-digital/goldchain/pkg/extplugin/proto"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// DefaultCallTimeout is the maximum duration of a single call to a plugin, unless configured otherwise.
+	DefaultCallTimeout = 30 * time.Second
+	// DefaultRestartDelay is the duration after which a failed plugin is launched again.
+	DefaultRestartDelay = 10 * time.Second
+
+	// startTimeout is the maximum duration between launching a plugin and it completing the go-plugin handshake
+	startTimeout = 10 * time.Second
+	// exitCheckInterval is the interval at which the host checks whether a plugin exited
+	exitCheckInterval = time.Second
+)
+
+type (
+	// Config configures an external plugin.
+	Config struct {
+		// Name identifies the plugin in the logs and status of the daemon
+		Name string
+		// Path is the path of the plugin executable, launched with the given arguments
+		Path string
+		Args []string
+		// CallTimeout is the maximum duration of a single call to the plugin,
+		// after which the plugin is considered to have failed, DefaultCallTimeout if 0
+		CallTimeout time.Duration
+	}
+
+	// Status is the status of an external plugin.
+	Status struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+		// Healthy is false as long as the plugin isn't running
+		Healthy  bool     `json:"healthy"`
+		Problems []string `json:"problems"`
+		// PID is the process ID of the plugin, 0 if it isn't running
+		PID int `json:"pid"`
+		// ConsensusChangeID is the ID of the last consensus change processed by the plugin
+		ConsensusChangeID crypto.Hash `json:"consensuschangeid"`
+		// Synced is true once the plugin processed all consensus changes of a synced consensus set
+		Synced bool `json:"synced"`
+		// Changes is the amount of consensus changes processed by the plugin since the daemon started
+		Changes uint64 `json:"changes"`
+		// Restarts is the amount of times the plugin was launched again after failing
+		Restarts uint64 `json:"restarts"`
+		// LastError is the most recent failure of the plugin, if any
+		LastError     string          `json:"lasterror,omitempty"`
+		LastErrorTime types.Timestamp `json:"lasterrortime,omitempty"`
+	}
+)
+
+// Host launches an external plugin, and feeds it all consensus changes once committed by the consensus set.
+// As the plugin runs out of process, it never takes part in the validation of blocks,
+// and a failing plugin is launched again, resuming from the last consensus change it processed.
+type Host struct {
+	cfg          Config
+	cs           modules.ConsensusSet
+	output       io.Writer
+	restartDelay time.Duration
+	closeCh      chan struct{}
+	done         chan struct{}
+
+	mu sync.Mutex
+	// process is the running plugin, nil while it isn't running or once it failed
+	process       *process
+	failed        chan error
+	changeID      crypto.Hash
+	synced        bool
+	changes       uint64
+	restarts      uint64
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// NewHost creates a host for the configured plugin, launching it and subscribing it to the given consensus set.
+// The output of the plugin, as well as its failures, are written to the given writer.
+func NewHost(cfg Config, cs modules.ConsensusSet, output io.Writer) *Host {
+	h := newHost(cfg, cs, output)
+	go h.run()
+	return h
+}
+
+func newHost(cfg Config, cs modules.ConsensusSet, output io.Writer) *Host {
+	if cfg.CallTimeout == 0 {
+		cfg.CallTimeout = DefaultCallTimeout
+	}
+	if output == nil {
+		output = ioutil.Discard
+	}
+	h := &Host{
+		cfg:          cfg,
+		cs:           cs,
+		output:       output,
+		restartDelay: DefaultRestartDelay,
+		closeCh:      make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	return h
+}
+
+// Close stops the plugin, and unsubscribes it from the consensus set.
+func (h *Host) Close() error {
+	close(h.closeCh)
+	<-h.done
+	return nil
+}
+
+// Status returns the status of the plugin.
+func (h *Host) Status() Status {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	status := Status{
+		Name:              h.cfg.Name,
+		Path:              h.cfg.Path,
+		Problems:          []string{},
+		ConsensusChangeID: h.changeID,
+		Synced:            h.synced,
+		Changes:           h.changes,
+		Restarts:          h.restarts,
+		LastError:         h.lastError,
+	}
+	if h.lastError != "" {
+		status.LastErrorTime = types.Timestamp(h.lastErrorTime.Unix())
+	}
+	if h.process != nil {
+		status.PID = h.process.pid()
+	} else {
+		status.Problems = append(status.Problems, "not running")
+	}
+	status.Healthy = len(status.Problems) == 0
+	return status
+}
+
+// run launches the plugin, and launches it again whenever it fails, until the host is closed.
+func (h *Host) run() {
+	defer close(h.done)
+	for {
+		err := h.session()
+		select {
+		case <-h.closeCh:
+			return
+		default:
+		}
+		h.mu.Lock()
+		h.process = nil
+		h.restarts++
+		h.lastError = err.Error()
+		h.lastErrorTime = time.Now()
+		h.mu.Unlock()
+		fmt.Fprintf(h.output, "Plugin %s failed, launching it again in %v: %v\n", h.cfg.Name, h.restartDelay, err)
+		select {
+		case <-h.closeCh:
+			return
+		case <-time.After(h.restartDelay):
+		}
+	}
+}
+
+// session launches the plugin, and feeds it the consensus changes following the last one it processed,
+// until it fails or the host is closed.
+func (h *Host) session() error {
+	p, err := launch(h.cfg, h.output)
+	if err != nil {
+		return err
+	}
+	defer p.stop()
+	var reply *proto.InitResponse
+	err = h.call("Init", func(ctx context.Context) (err error) {
+		reply, err = p.plugin.Init(ctx, &proto.InitRequest{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %v", err)
+	}
+	var changeID crypto.Hash
+	err = decodeHash(&changeID, reply.ConsensusChangeId)
+	if err != nil {
+		return fmt.Errorf("failed to initialize: %v", err)
+	}
+	failed := make(chan error, 1)
+	h.mu.Lock()
+	h.process = p
+	h.failed = failed
+	h.changeID = changeID
+	h.mu.Unlock()
+
+	err = h.cs.ConsensusSetSubscribe(h, modules.ConsensusChangeID(changeID), h.closeCh)
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	defer h.cs.Unsubscribe(h)
+	select {
+	case err = <-failed:
+		return err
+	case <-p.exited:
+		return errors.New("plugin exited")
+	case <-h.closeCh:
+		return nil
+	}
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// sending the consensus change to the plugin. Once the plugin fails, all changes are dropped,
+// as the plugin resumes from the last change it processed once launched again.
+func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
+	h.mu.Lock()
+	p, failed := h.process, h.failed
+	h.mu.Unlock()
+	if p == nil {
+		return
+	}
+	change := &proto.ConsensusChange{
+		Id:     cc.ID[:],
+		Synced: cc.Synced,
+	}
+	var err error
+	change.RevertedBlocks, err = encodeBlocks(cc.RevertedBlocks)
+	if err == nil {
+		change.AppliedBlocks, err = encodeBlocks(cc.AppliedBlocks)
+	}
+	if err == nil {
+		err = h.call("ProcessConsensusChange", func(ctx context.Context) error {
+			_, err := p.plugin.ProcessConsensusChange(ctx, change)
+			return err
+		})
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.process != p {
+		return
+	}
+	if err != nil {
+		h.process = nil
+		failed <- fmt.Errorf("failed to process consensus change %v: %v", cc.ID, err)
+		return
+	}
+	h.changeID = crypto.Hash(cc.ID)
+	h.synced = cc.Synced
+	h.changes++
+}
+
+// call calls the plugin, failing if the call exceeds the call timeout.
+func (h *Host) call(name string, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cfg.CallTimeout)
+	defer cancel()
+	err := fn(ctx)
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("%s exceeded the timeout of %v", name, h.cfg.CallTimeout)
+	}
+	return err
+}
+
+// process is a running plugin.
+type process struct {
+	client *plugin.Client
+	plugin proto.PluginClient
+	// exited is closed once the process exited
+	exited chan struct{}
+	stopCh chan struct{}
+}
+
+// launch launches the plugin, and connects to it once the go-plugin handshake is done.
+func launch(cfg Config, output io.Writer) (*process, error) {
+	prefix := "[" + cfg.Name + "] "
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: Handshake,
+		Plugins: plugin.PluginSet{
+			indexerPluginName: &IndexerPlugin{},
+		},
+		Cmd:              exec.Command(cfg.Path, cfg.Args...),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		StartTimeout:     startTimeout,
+		// the output of the plugin is written as is, go-plugin's own logging is disabled,
+		// as the host reports the failures of the plugin itself
+		Stderr:     &prefixWriter{prefix: prefix, w: output},
+		SyncStdout: &prefixWriter{prefix: prefix, w: output},
+		SyncStderr: &prefixWriter{prefix: prefix, w: output},
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Name:   cfg.Name,
+			Level:  hclog.Off,
+			Output: ioutil.Discard,
+		}),
+	})
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to launch: %v", err)
+	}
+	raw, err := rpcClient.Dispense(indexerPluginName)
+	if err != nil {
+		client.Kill()
+		return nil, fmt.Errorf("failed to connect: %v", err)
+	}
+	p := &process{
+		client: client,
+		plugin: raw.(proto.PluginClient),
+		exited: make(chan struct{}),
+		stopCh: make(chan struct{}),
+	}
+	go p.watch()
+	return p, nil
+}
+
+// watch closes the exited channel once the process exited, or stops watching once the process is stopped.
+// go-plugin doesn't notify the exit of a plugin, hence the polling.
+func (p *process) watch() {
+	ticker := time.NewTicker(exitCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			if p.client.Exited() {
+				close(p.exited)
+				return
+			}
+		}
+	}
+}
+
+// pid returns the process ID of the plugin.
+func (p *process) pid() int {
+	if cfg := p.client.ReattachConfig(); cfg != nil {
+		return cfg.Pid
+	}
+	return 0
+}
+
+// stop stops the plugin, killing it should it not exit in time.
+func (p *process) stop() {
+	close(p.stopCh)
+	p.client.Kill()
+}
+
+// prefixWriter prefixes every line written to the underlying writer.
+type prefixWriter struct {
+	prefix string
+	w      io.Writer
+
+	mu      sync.Mutex
+	midLine bool
+}
+
+// Write implements io.Writer.Write
+func (pw *prefixWriter) Write(b []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	var buf bytes.Buffer
+	for rest := b; len(rest) > 0; {
+		if !pw.midLine {
+			buf.WriteString(pw.prefix)
+		}
+		line := rest
+		if index := bytes.IndexByte(rest, '\n'); index >= 0 {
+			line = rest[:index+1]
+		}
+		buf.Write(line)
+		pw.midLine = line[len(line)-1] != '\n'
+		rest = rest[len(line):]
+	}
+	if _, err := pw.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
@@ -0,0 +1,294 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        v5.29.3
+// source: plugin.proto
+
+// Package goldchain.plugin.v1 defines the gRPC service implemented by external goldchaind plugins,
+// served as a hashicorp/go-plugin gRPC plugin.
+
+package proto
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type InitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InitRequest) Reset() {
+	*x = InitRequest{}
+	mi := &file_plugin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitRequest) ProtoMessage() {}
+
+func (x *InitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitRequest.ProtoReflect.Descriptor instead.
+func (*InitRequest) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{0}
+}
+
+type InitResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// consensus_change_id is the ID of the last consensus change processed by the plugin,
+	// 32 zero bytes (or empty) in case it didn't process any change yet.
+	// All consensus changes following it are sent to the plugin.
+	ConsensusChangeId []byte `protobuf:"bytes,1,opt,name=consensus_change_id,json=consensusChangeId,proto3" json:"consensus_change_id,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *InitResponse) Reset() {
+	*x = InitResponse{}
+	mi := &file_plugin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InitResponse) ProtoMessage() {}
+
+func (x *InitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InitResponse.ProtoReflect.Descriptor instead.
+func (*InitResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *InitResponse) GetConsensusChangeId() []byte {
+	if x != nil {
+		return x.ConsensusChangeId
+	}
+	return nil
+}
+
+// ConsensusChange is a consensus change committed by the consensus set.
+// A plugin has to store the ID of the change together with the data derived from it,
+// such that it resumes from the right change when it is launched again.
+type ConsensusChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is the 32 byte ID of the change
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// reverted_blocks are the blocks reverted by the change, starting with the most recent one,
+	// each block JSON encoded as returned by the explorer endpoints of the daemon
+	RevertedBlocks [][]byte `protobuf:"bytes,2,rep,name=reverted_blocks,json=revertedBlocks,proto3" json:"reverted_blocks,omitempty"`
+	// applied_blocks are the blocks applied by the change, in ascending height order,
+	// each block JSON encoded as returned by the explorer endpoints of the daemon
+	AppliedBlocks [][]byte `protobuf:"bytes,3,rep,name=applied_blocks,json=appliedBlocks,proto3" json:"applied_blocks,omitempty"`
+	// synced is true once the consensus set is synced with its peers
+	Synced        bool `protobuf:"varint,4,opt,name=synced,proto3" json:"synced,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConsensusChange) Reset() {
+	*x = ConsensusChange{}
+	mi := &file_plugin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConsensusChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConsensusChange) ProtoMessage() {}
+
+func (x *ConsensusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConsensusChange.ProtoReflect.Descriptor instead.
+func (*ConsensusChange) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ConsensusChange) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *ConsensusChange) GetRevertedBlocks() [][]byte {
+	if x != nil {
+		return x.RevertedBlocks
+	}
+	return nil
+}
+
+func (x *ConsensusChange) GetAppliedBlocks() [][]byte {
+	if x != nil {
+		return x.AppliedBlocks
+	}
+	return nil
+}
+
+func (x *ConsensusChange) GetSynced() bool {
+	if x != nil {
+		return x.Synced
+	}
+	return false
+}
+
+type ProcessConsensusChangeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessConsensusChangeResponse) Reset() {
+	*x = ProcessConsensusChangeResponse{}
+	mi := &file_plugin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessConsensusChangeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessConsensusChangeResponse) ProtoMessage() {}
+
+func (x *ProcessConsensusChangeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_plugin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessConsensusChangeResponse.ProtoReflect.Descriptor instead.
+func (*ProcessConsensusChangeResponse) Descriptor() ([]byte, []int) {
+	return file_plugin_proto_rawDescGZIP(), []int{3}
+}
+
+var File_plugin_proto protoreflect.FileDescriptor
+
+const file_plugin_proto_rawDesc = "" +
+	"\n" +
+	"\fplugin.proto\x12\x13goldchain.plugin.v1\"\r\n" +
+	"\vInitRequest\">\n" +
+	"\fInitResponse\x12.\n" +
+	"\x13consensus_change_id\x18\x01 \x01(\fR\x11consensusChangeId\"\x89\x01\n" +
+	"\x0fConsensusChange\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\fR\x02id\x12'\n" +
+	"\x0freverted_blocks\x18\x02 \x03(\fR\x0erevertedBlocks\x12%\n" +
+	"\x0eapplied_blocks\x18\x03 \x03(\fR\rappliedBlocks\x12\x16\n" +
+	"\x06synced\x18\x04 \x01(\bR\x06synced\" \n" +
+	"\x1eProcessConsensusChangeResponse2\xca\x01\n" +
+	"\x06Plugin\x12K\n" +
+	"\x04Init\x12 .goldchain.plugin.v1.InitRequest\x1a!.goldchain.plugin.v1.InitResponse\x12s\n" +
+	"\x16ProcessConsensusChange\x12$.goldchain.plugin.v1.ConsensusChange\x1a3.goldchain.plugin.v1.ProcessConsensusChangeResponseB6Z4github.com/nbh-digital/goldchain/pkg/extplugin/protob\x06proto3"
+
+var (
+	file_plugin_proto_rawDescOnce sync.Once
+	file_plugin_proto_rawDescData []byte
+)
+
+func file_plugin_proto_rawDescGZIP() []byte {
+	file_plugin_proto_rawDescOnce.Do(func() {
+		file_plugin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_plugin_proto_rawDesc), len(file_plugin_proto_rawDesc)))
+	})
+	return file_plugin_proto_rawDescData
+}
+
+var file_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_plugin_proto_goTypes = []any{
+	(*InitRequest)(nil),                    // 0: goldchain.plugin.v1.InitRequest
+	(*InitResponse)(nil),                   // 1: goldchain.plugin.v1.InitResponse
+	(*ConsensusChange)(nil),                // 2: goldchain.plugin.v1.ConsensusChange
+	(*ProcessConsensusChangeResponse)(nil), // 3: goldchain.plugin.v1.ProcessConsensusChangeResponse
+}
+var file_plugin_proto_depIdxs = []int32{
+	0, // 0: goldchain.plugin.v1.Plugin.Init:input_type -> goldchain.plugin.v1.InitRequest
+	2, // 1: goldchain.plugin.v1.Plugin.ProcessConsensusChange:input_type -> goldchain.plugin.v1.ConsensusChange
+	1, // 2: goldchain.plugin.v1.Plugin.Init:output_type -> goldchain.plugin.v1.InitResponse
+	3, // 3: goldchain.plugin.v1.Plugin.ProcessConsensusChange:output_type -> goldchain.plugin.v1.ProcessConsensusChangeResponse
+	2, // [2:4] is the sub-list for method output_type
+	0, // [0:2] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_plugin_proto_init() }
+func file_plugin_proto_init() {
+	if File_plugin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_plugin_proto_rawDesc), len(file_plugin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   4,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_plugin_proto_goTypes,
+		DependencyIndexes: file_plugin_proto_depIdxs,
+		MessageInfos:      file_plugin_proto_msgTypes,
+	}.Build()
+	File_plugin_proto = out.File
+	file_plugin_proto_goTypes = nil
+	file_plugin_proto_depIdxs = nil
+}
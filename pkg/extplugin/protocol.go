@@ -0,0 +1,67 @@
+package extplugin
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/nbh-digital/goldchain/pkg/extplugin/proto"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+	"google.golang.org/grpc"
+)
+
+const (
+	// ProtocolVersion is the version of the plugin protocol,
+	// increased with every change which is incompatible with existing plugins.
+	ProtocolVersion = 2
+
+	// MagicCookieKey and MagicCookieValue are the environment variable set by the daemon when launching a plugin,
+	// such that a plugin executable can tell it was launched as a plugin, rather than by a user.
+	// They are not a security measure.
+	MagicCookieKey   = "GOLDCHAIN_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "5f0b0e2d6c1a4b1e9d4c7e8a3f2b6d91"
+
+	// indexerPluginName is the name under which a plugin executable serves its indexer
+	indexerPluginName = "indexer"
+)
+
+// Handshake is the go-plugin handshake shared by the daemon and its plugins.
+var Handshake = plugin.HandshakeConfig{
+	ProtocolVersion:  ProtocolVersion,
+	MagicCookieKey:   MagicCookieKey,
+	MagicCookieValue: MagicCookieValue,
+}
+
+// Change is a consensus change sent to a plugin,
+// for every consensus change once committed by the consensus set, in order.
+// A plugin has to store the ID of the change together with the data derived from it,
+// such that it resumes from the right change when it is launched again.
+type Change struct {
+	// ID is the ID of the change, a modules.ConsensusChangeID
+	ID crypto.Hash
+	// RevertedBlocks are the blocks reverted by the change, starting with the most recent one
+	RevertedBlocks []types.Block
+	// AppliedBlocks are the blocks applied by the change, in ascending height order
+	AppliedBlocks []types.Block
+	// Synced is true once the consensus set is synced with its peers
+	Synced bool
+}
+
+// IndexerPlugin is the go-plugin plugin serving an Indexer over gRPC,
+// using the Plugin service defined in proto/plugin.proto.
+// Impl is only used by the plugin executable.
+type IndexerPlugin struct {
+	plugin.NetRPCUnsupportedPlugin
+	Impl Indexer
+}
+
+// GRPCServer implements plugin.GRPCPlugin.GRPCServer
+func (p *IndexerPlugin) GRPCServer(_ *plugin.GRPCBroker, s *grpc.Server) error {
+	proto.RegisterPluginServer(s, &grpcServer{indexer: p.Impl})
+	return nil
+}
+
+// GRPCClient implements plugin.GRPCPlugin.GRPCClient
+func (p *IndexerPlugin) GRPCClient(_ context.Context, _ *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
+	return proto.NewPluginClient(c), nil
+}
@@ -0,0 +1,127 @@
+package extplugin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"github.com/nbh-digital/goldchain/pkg/extplugin/proto"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ErrNotLaunched is returned by Serve in case the executable was not launched by the daemon as a plugin.
+var ErrNotLaunched = errors.New("this executable is a goldchaind plugin, and has to be launched by the daemon (goldchaind --plugin <name>=<path>)")
+
+// Indexer is implemented by an external plugin written in Go, processing all consensus changes.
+type Indexer interface {
+	// Init returns the ID of the last consensus change processed by the indexer,
+	// modules.ConsensusChangeBeginning should it not have processed any change yet.
+	Init() (modules.ConsensusChangeID, error)
+	// ProcessConsensusChange processes the given consensus change,
+	// storing its ID together with the data derived from it.
+	// Once an error is returned, the daemon relaunches the plugin.
+	ProcessConsensusChange(change Change) error
+}
+
+// Serve serves the given indexer to the daemon which launched the executable as a plugin,
+// returning once the daemon stops the plugin.
+// It writes the go-plugin handshake to stdout, which is not to be written to before.
+func Serve(indexer Indexer) error {
+	// plugin.Serve exits the process itself when the cookie is missing, return an error instead
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return ErrNotLaunched
+	}
+	plugin.Serve(&plugin.ServeConfig{
+		HandshakeConfig: Handshake,
+		Plugins: plugin.PluginSet{
+			indexerPluginName: &IndexerPlugin{Impl: indexer},
+		},
+		GRPCServer: plugin.DefaultGRPCServer,
+		Logger: hclog.New(&hclog.LoggerOptions{
+			Level:  hclog.Warn,
+			Output: os.Stderr,
+		}),
+	})
+	return nil
+}
+
+// grpcServer exposes an indexer as the Plugin gRPC service.
+type grpcServer struct {
+	proto.UnimplementedPluginServer
+	indexer Indexer
+}
+
+// Init implements proto.PluginServer.Init
+func (s *grpcServer) Init(context.Context, *proto.InitRequest) (*proto.InitResponse, error) {
+	id, err := s.indexer.Init()
+	if err != nil {
+		return nil, err
+	}
+	return &proto.InitResponse{ConsensusChangeId: id[:]}, nil
+}
+
+// ProcessConsensusChange implements proto.PluginServer.ProcessConsensusChange
+func (s *grpcServer) ProcessConsensusChange(_ context.Context, cc *proto.ConsensusChange) (*proto.ProcessConsensusChangeResponse, error) {
+	change := Change{Synced: cc.Synced}
+	err := decodeHash(&change.ID, cc.Id)
+	if err != nil {
+		return nil, err
+	}
+	change.RevertedBlocks, err = decodeBlocks(cc.RevertedBlocks)
+	if err != nil {
+		return nil, err
+	}
+	change.AppliedBlocks, err = decodeBlocks(cc.AppliedBlocks)
+	if err != nil {
+		return nil, err
+	}
+	err = s.indexer.ProcessConsensusChange(change)
+	if err != nil {
+		return nil, err
+	}
+	return &proto.ProcessConsensusChangeResponse{}, nil
+}
+
+// decodeHash decodes a 32 byte ID, an empty ID being the zero hash.
+func decodeHash(h *crypto.Hash, b []byte) error {
+	if len(b) == 0 {
+		*h = crypto.Hash{}
+		return nil
+	}
+	if len(b) != crypto.HashSize {
+		return fmt.Errorf("invalid ID of %d bytes, expected %d", len(b), crypto.HashSize)
+	}
+	copy(h[:], b)
+	return nil
+}
+
+// encodeBlocks JSON encodes each of the given blocks.
+func encodeBlocks(blocks []types.Block) ([][]byte, error) {
+	encoded := make([][]byte, 0, len(blocks))
+	for _, block := range blocks {
+		b, err := json.Marshal(block)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, b)
+	}
+	return encoded, nil
+}
+
+// decodeBlocks decodes blocks encoded by encodeBlocks.
+func decodeBlocks(encoded [][]byte) ([]types.Block, error) {
+	blocks := make([]types.Block, len(encoded))
+	for i, b := range encoded {
+		err := json.Unmarshal(b, &blocks[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid block: %v", err)
+		}
+	}
+	return blocks, nil
+}
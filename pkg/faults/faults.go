@@ -0,0 +1,137 @@
+// Package faults injects faults into a node, delaying the database writes of its consensus set
+// and dropping the messages exchanged with its peers, such that the services built on top of goldchain,
+// such as exchange integrations, can be tested against a misbehaving node.
+//
+// Faults are only injected into nodes built using the faults build tag, which can be controlled over the debug API.
+package faults
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ErrDropped is returned for the RPCs dropped by the injector.
+var ErrDropped = errors.New("message dropped by fault injection")
+
+// Faults are the faults injected into a node, none by default.
+type Faults struct {
+	// DBWriteDelay delays the database transaction of every block applied to or reverted from the consensus set,
+	// blocking all other access to the consensus set in the meantime
+	DBWriteDelay time.Duration `json:"dbwritedelay"`
+	// DropRate is the probability, from 0 to 1, with which a message exchanged with a peer is dropped
+	DropRate float64 `json:"droprate"`
+	// DropRPCs limits the messages dropped to those of the RPCs with the given names, such as "RelayHeader",
+	// the messages of all RPCs are dropped if empty
+	DropRPCs []string `json:"droprpcs,omitempty"`
+}
+
+// Validate returns an error in case the faults cannot be injected.
+func (f Faults) Validate() error {
+	if f.DBWriteDelay < 0 {
+		return errors.New("the database write delay cannot be negative")
+	}
+	if f.DropRate < 0 || f.DropRate > 1 {
+		return errors.New("the drop rate has to be a probability from 0 to 1")
+	}
+	return nil
+}
+
+// Stats counts the faults injected since the node started.
+type Stats struct {
+	// DelayedWrites is the amount of blocks of which the database write was delayed
+	DelayedWrites uint64 `json:"delayedwrites"`
+	// Dropped is the amount of dropped messages per RPC
+	Dropped map[string]uint64 `json:"dropped"`
+}
+
+// Injector decides which faults are injected, as configured at runtime.
+type Injector struct {
+	mu      sync.Mutex
+	faults  Faults
+	drop    map[string]struct{}
+	rand    *rand.Rand
+	delayed uint64
+	dropped map[string]uint64
+}
+
+// NewInjector creates an injector which doesn't inject any faults until they are set.
+func NewInjector() *Injector {
+	return &Injector{
+		rand:    rand.New(rand.NewSource(time.Now().UnixNano())),
+		dropped: make(map[string]uint64),
+	}
+}
+
+// Faults returns the faults currently injected.
+func (inj *Injector) Faults() Faults {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	return inj.faults
+}
+
+// SetFaults replaces the faults injected.
+func (inj *Injector) SetFaults(f Faults) error {
+	if err := f.Validate(); err != nil {
+		return err
+	}
+	var drop map[string]struct{}
+	if len(f.DropRPCs) > 0 {
+		drop = make(map[string]struct{}, len(f.DropRPCs))
+		for _, name := range f.DropRPCs {
+			drop[name] = struct{}{}
+		}
+	}
+	inj.mu.Lock()
+	inj.faults = f
+	inj.drop = drop
+	inj.mu.Unlock()
+	return nil
+}
+
+// Stats returns the amount of faults injected.
+func (inj *Injector) Stats() Stats {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	stats := Stats{
+		DelayedWrites: inj.delayed,
+		Dropped:       make(map[string]uint64, len(inj.dropped)),
+	}
+	for name, count := range inj.dropped {
+		stats.Dropped[name] = count
+	}
+	return stats
+}
+
+// dropMessage returns whether a message of the given RPC is dropped, counting it if so.
+func (inj *Injector) dropMessage(rpc string) bool {
+	inj.mu.Lock()
+	defer inj.mu.Unlock()
+	if inj.faults.DropRate == 0 {
+		return false
+	}
+	if inj.drop != nil {
+		if _, ok := inj.drop[rpc]; !ok {
+			return false
+		}
+	}
+	if inj.rand.Float64() >= inj.faults.DropRate {
+		return false
+	}
+	inj.dropped[rpc]++
+	return true
+}
+
+// delayWrite blocks for the configured database write delay.
+func (inj *Injector) delayWrite() {
+	inj.mu.Lock()
+	delay := inj.faults.DBWriteDelay
+	if delay > 0 {
+		inj.delayed++
+	}
+	inj.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
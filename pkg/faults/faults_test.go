@@ -0,0 +1,124 @@
+package faults
+
+import (
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testGateway is a gateway calling all RPCs directly, without any connection.
+type testGateway struct {
+	modules.Gateway
+
+	handlers  map[string]modules.RPCFunc
+	called    []string
+	broadcast []modules.Peer
+}
+
+func (g *testGateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	g.handlers[name] = fn
+}
+
+func (g *testGateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	g.called = append(g.called, name)
+	return nil
+}
+
+func (g *testGateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
+	g.broadcast = append(g.broadcast, peers...)
+}
+
+func TestFaultsValidate(t *testing.T) {
+	for _, f := range []Faults{{DBWriteDelay: -time.Second}, {DropRate: -0.1}, {DropRate: 1.5}} {
+		if err := NewInjector().SetFaults(f); err == nil {
+			t.Errorf("expected faults %+v to be refused", f)
+		}
+	}
+	if err := NewInjector().SetFaults(Faults{DBWriteDelay: time.Second, DropRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGateway(t *testing.T) {
+	injector := NewInjector()
+	g := &testGateway{handlers: make(map[string]modules.RPCFunc)}
+	fg := NewGateway(g, injector)
+	var handled int
+	fg.RegisterRPC("RelayHeader", func(modules.PeerConn) error {
+		handled++
+		return nil
+	})
+	peers := []modules.Peer{{NetAddress: "127.0.0.1:1"}, {NetAddress: "127.0.0.1:2"}}
+
+	// nothing is dropped by default
+	if err := g.handlers["RelayHeader"](nil); err != nil || handled != 1 {
+		t.Fatalf("expected the RPC to be handled, got %v", err)
+	}
+	if err := fg.RPC("127.0.0.1:1", "RelayHeader", nil); err != nil || len(g.called) != 1 {
+		t.Fatalf("expected the RPC to be called, got %v", err)
+	}
+	fg.Broadcast("RelayHeader", nil, peers)
+	if len(g.broadcast) != 2 {
+		t.Fatalf("expected the broadcast to reach 2 peers, reached %d", len(g.broadcast))
+	}
+
+	// only the messages of the given RPCs are dropped
+	err := injector.SetFaults(Faults{DropRate: 1, DropRPCs: []string{"RelayHeader"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.handlers["RelayHeader"](nil); err != ErrDropped || handled != 1 {
+		t.Fatalf("expected the RPC to be dropped, got %v", err)
+	}
+	if err := fg.RPC("127.0.0.1:1", "RelayHeader", nil); err != ErrDropped || len(g.called) != 1 {
+		t.Fatalf("expected the RPC call to be dropped, got %v", err)
+	}
+	if err := fg.RPC("127.0.0.1:1", "ShareNodes", nil); err != nil || len(g.called) != 2 {
+		t.Fatalf("expected the RPC of another name to be called, got %v", err)
+	}
+	fg.Broadcast("RelayHeader", nil, peers)
+	if len(g.broadcast) != 2 {
+		t.Fatalf("expected the broadcast to be dropped, reached %d more peers", len(g.broadcast)-2)
+	}
+	if stats := injector.Stats(); stats.Dropped["RelayHeader"] != 4 || len(stats.Dropped) != 1 {
+		t.Fatalf("unexpected dropped messages: %v", stats.Dropped)
+	}
+}
+
+func TestPluginDelaysWrites(t *testing.T) {
+	injector := NewInjector()
+	p := NewPlugin(injector)
+	if err := p.ApplyBlock(types.Block{}, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if injector.Stats().DelayedWrites != 0 {
+		t.Fatal("expected no write to be delayed by default")
+	}
+
+	err := injector.SetFaults(Faults{DBWriteDelay: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err = p.ApplyBlock(types.Block{}, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.RevertBlock(types.Block{}, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	// blocks applied for the first time are applied transaction per transaction
+	block := types.Block{Transactions: []types.Transaction{{Version: types.TransactionVersionOne}, {Version: types.TransactionVersionZero}}}
+	for _, txn := range block.Transactions {
+		if err = p.ApplyTransaction(txn, block, 1, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("expected all 3 writes to be delayed, took %v", elapsed)
+	}
+	if delayed := injector.Stats().DelayedWrites; delayed != 3 {
+		t.Fatalf("expected 3 delayed writes, got %d", delayed)
+	}
+}
@@ -0,0 +1,54 @@
+package faults
+
+import (
+	"github.com/threefoldtech/rivine/modules"
+)
+
+// Gateway wraps a gateway, dropping the messages of the RPCs registered, called and broadcasted through it,
+// as decided by the injector. The RPCs called when connecting to a peer are never dropped.
+//
+// Only the RPCs going through this Gateway are dropped,
+// so it has to be given to the consensus set and transaction pool, instead of the wrapped gateway.
+type Gateway struct {
+	modules.Gateway
+	injector *Injector
+}
+
+var _ modules.Gateway = (*Gateway)(nil)
+
+// NewGateway creates a new Gateway, dropping the messages of the given gateway as decided by the given injector.
+func NewGateway(g modules.Gateway, injector *Injector) *Gateway {
+	return &Gateway{Gateway: g, injector: injector}
+}
+
+// RegisterRPC implements modules.Gateway.RegisterRPC,
+// dropping the calls of peers, closing their connection without handling them.
+func (fg *Gateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	fg.Gateway.RegisterRPC(name, func(conn modules.PeerConn) error {
+		if fg.injector.dropMessage(name) {
+			return ErrDropped
+		}
+		return fn(conn)
+	})
+}
+
+// RPC implements modules.Gateway.RPC, dropping the call without reaching the peer.
+func (fg *Gateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	if fg.injector.dropMessage(name) {
+		return ErrDropped
+	}
+	return fg.Gateway.RPC(addr, name, fn)
+}
+
+// Broadcast implements modules.Gateway.Broadcast, broadcasting to the peers of which the message isn't dropped.
+func (fg *Gateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
+	reached := make([]modules.Peer, 0, len(peers))
+	for _, peer := range peers {
+		if !fg.injector.dropMessage(name) {
+			reached = append(reached, peer)
+		}
+	}
+	if len(reached) > 0 {
+		fg.Gateway.Broadcast(name, obj, reached)
+	}
+}
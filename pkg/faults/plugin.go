@@ -0,0 +1,94 @@
+package faults
+
+import (
+	"errors"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "FaultInjectionPlugin"
+)
+
+// Plugin is a consensus set plugin delaying the database write of every block applied or reverted,
+// as the consensus set calls its plugins within the database transaction of the block. It doesn't store anything.
+//
+// The consensus set applies the blocks it didn't apply before transaction per transaction,
+// so these are delayed when their first transaction is applied.
+type Plugin struct {
+	injector *Injector
+	storage  modules.PluginViewStorage
+}
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new plugin, delaying the database writes as decided by the given injector.
+func NewPlugin(injector *Injector) *Plugin {
+	return &Plugin{injector: injector}
+}
+
+// InitPlugin implements modules.ConsensusSetPlugin.InitPlugin.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	if metadata == nil {
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock implements modules.ConsensusSetPlugin.ApplyBlock, delaying the database write of the block.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	p.injector.delayWrite()
+	return nil
+}
+
+// RevertBlock implements modules.ConsensusSetPlugin.RevertBlock, delaying the database write of the block.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	p.injector.delayWrite()
+	return nil
+}
+
+// ApplyTransaction implements modules.ConsensusSetPlugin.ApplyTransaction,
+// delaying the database write of the block when its first transaction is applied.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if len(block.Transactions) > 0 && txn.ID() == block.Transactions[0].ID() {
+		p.injector.delayWrite()
+	}
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin.RevertTransaction.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
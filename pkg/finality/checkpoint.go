@@ -0,0 +1,76 @@
+package finality
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrNoAuthority is returned when verifying a checkpoint on a network
+	// for which no address is defined to sign checkpoints.
+	ErrNoAuthority = errors.New("no address is defined to sign checkpoints on this network")
+	// ErrInvalidCheckpoint is returned in case the signature of a checkpoint is invalid,
+	// or isn't created by the key of the address signing the checkpoints of the network.
+	ErrInvalidCheckpoint = errors.New("checkpoint is not signed by the foundation")
+)
+
+// checkpointSpecifier prefixes the message signed for a checkpoint,
+// such that no other signed challenge is a valid checkpoint.
+var checkpointSpecifier = types.Specifier{'g', 'o', 'l', 'd', 'c', 'h', 'a', 'i', 'n', ' ', 'c', 'k', 'p', 't'}
+
+type (
+	// Checkpoint defines the block at a given height, which no reorganization can replace.
+	Checkpoint struct {
+		Height  types.BlockHeight `json:"height"`
+		BlockID types.BlockID     `json:"blockid"`
+	}
+
+	// SignedCheckpoint is a checkpoint signed by the key of the foundation address of the network,
+	// such that nodes can verify it before enforcing it.
+	SignedCheckpoint struct {
+		Checkpoint
+		PublicKey types.PublicKey `json:"publickey"`
+		Signature types.ByteSlice `json:"signature"`
+	}
+)
+
+// Message returns the message to be signed for the checkpoint,
+// to be signed as a challenge by the foundation address, e.g. using a remote signer.
+func (cp Checkpoint) Message() []byte {
+	return rivbin.MarshalAll(checkpointSpecifier, cp.Height, cp.BlockID)
+}
+
+// NewSignedCheckpoint creates a signed checkpoint from the challenge signing the message of the checkpoint.
+func NewSignedCheckpoint(cp Checkpoint, challenge signer.Challenge) SignedCheckpoint {
+	return SignedCheckpoint{
+		Checkpoint: cp,
+		PublicKey:  challenge.PublicKey,
+		Signature:  challenge.Signature,
+	}
+}
+
+// Verify verifies that the checkpoint is signed by the key of the given address.
+func (scp SignedCheckpoint) Verify(authority types.UnlockHash) error {
+	if authority.Type != types.UnlockTypePubKey {
+		return ErrNoAuthority
+	}
+	challenge := signer.Challenge{
+		Address:   authority,
+		Challenge: scp.Message(),
+		PublicKey: scp.PublicKey,
+		Signature: scp.Signature,
+	}
+	if challenge.Verify() != nil {
+		return ErrInvalidCheckpoint
+	}
+	return nil
+}
+
+// String returns the checkpoint as height and block ID.
+func (cp Checkpoint) String() string {
+	return fmt.Sprintf("block %s at height %d", cp.BlockID.String(), cp.Height)
+}
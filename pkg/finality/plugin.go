@@ -0,0 +1,322 @@
+package finality
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "FinalityPlugin"
+
+	// CheckpointsFile is the name of the file in which the checkpoints are stored,
+	// which is kept outside of the consensus directory, such that it survives a resync.
+	CheckpointsFile = "checkpoints.json"
+)
+
+var (
+	// ErrReorgTooDeep is returned when applying a fork which reverts more blocks than allowed.
+	ErrReorgTooDeep = errors.New("reorganization exceeds the maximum reorganization depth")
+)
+
+// ConflictError is returned for a block conflicting with a checkpoint.
+type ConflictError struct {
+	Checkpoint Checkpoint
+	BlockID    types.BlockID
+}
+
+// Error implements error.Error
+func (err *ConflictError) Error() string {
+	return fmt.Sprintf("block %s at height %d conflicts with checkpoint %s",
+		err.BlockID.String(), err.Checkpoint.Height, err.Checkpoint.BlockID.String())
+}
+
+var checkpointsMetadata = persist.Metadata{
+	Header:  "Goldchain Checkpoints",
+	Version: "1.0",
+}
+
+// keyReorg is the key of the reorganization in progress, stored in the plugin bucket itself,
+// which only exists within the database transaction in which the consensus set reverts and applies blocks
+var keyReorg = []byte("reorg")
+
+// chain is the part of modules.ConsensusSet used to verify the checkpoints against the blockchain.
+type chain interface {
+	BlockAtHeight(types.BlockHeight) (types.Block, bool)
+}
+
+// Plugin is a consensus set plugin, protecting the blockchain against deep reorganizations,
+// by refusing forks which revert more blocks than a configurable maximum,
+// as well as blocks which conflict with a checkpoint embedded in the release or signed by the foundation of the network.
+//
+// As a plugin returning an error while a fork is applied makes the consensus set reject that fork,
+// the protection applies to both the blocks received from peers and those created by the node itself.
+type Plugin struct {
+	maxReorgDepth types.BlockHeight
+	authority     types.UnlockHash
+	embedded      map[types.BlockHeight]types.BlockID
+	filename      string
+
+	storage            modules.PluginViewStorage
+	unregisterCallback modules.PluginUnregisterCallback
+
+	mu          sync.RWMutex
+	checkpoints map[types.BlockHeight]SignedCheckpoint
+}
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new finality plugin, refusing reorganizations deeper than the given depth (unlimited if 0),
+// and enforcing the embedded checkpoints, as well as the checkpoints signed by the given address, which are stored in the given file.
+func NewPlugin(maxReorgDepth types.BlockHeight, authority types.UnlockHash, embedded map[types.BlockHeight]types.BlockID, filename string) (*Plugin, error) {
+	p := &Plugin{
+		maxReorgDepth: maxReorgDepth,
+		authority:     authority,
+		embedded:      embedded,
+		filename:      filename,
+		checkpoints:   make(map[types.BlockHeight]SignedCheckpoint),
+	}
+	var list []SignedCheckpoint
+	err := persist.LoadJSON(checkpointsMetadata, &list, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load checkpoints: %v", err)
+	}
+	for _, scp := range list {
+		err = scp.Verify(authority)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint (%s) in %s: %v", scp.Checkpoint.String(), filename, err)
+		}
+		if id, ok := embedded[scp.Height]; ok && id != scp.BlockID {
+			return nil, fmt.Errorf("checkpoint (%s) in %s conflicts with the embedded checkpoint for block %s", scp.Checkpoint.String(), filename, id.String())
+		}
+		p.checkpoints[scp.Height] = scp
+	}
+	return p, nil
+}
+
+// InitPlugin initializes the plugin, which doesn't store anything outside of the application of a fork.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock verifies the block, as applied when forwarding to a fork.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	return p.verifyBlock(block, height, bucket)
+}
+
+// ApplyTransaction verifies the block of the transaction, once per block, as new blocks are applied transaction per transaction.
+// Transactions which are tried out by the transaction pool are not part of a block, and are never refused.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(block.Transactions) == 0 || block.Transactions[0].ID() != txn.ID() {
+		return nil
+	}
+	return p.verifyBlock(block, height, bucket)
+}
+
+// verifyBlock refuses the block should it conflict with a checkpoint,
+// or should it be the first block applied by a reorganization reverting too many blocks.
+func (p *Plugin) verifyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if id, ok := p.embedded[height]; ok && id != block.ID() {
+		return &ConflictError{Checkpoint: Checkpoint{Height: height, BlockID: id}, BlockID: block.ID()}
+	}
+	p.mu.RLock()
+	scp, ok := p.checkpoints[height]
+	p.mu.RUnlock()
+	if ok && scp.BlockID != block.ID() {
+		return &ConflictError{Checkpoint: scp.Checkpoint, BlockID: block.ID()}
+	}
+
+	b, err := bucket.Get(keyReorg)
+	if err != nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(b) != 16 {
+		return nil
+	}
+	// the consensus set reverts all blocks of a reorganization before applying the first block of the fork
+	tip := types.BlockHeight(binary.BigEndian.Uint64(b[:8]))
+	fork := types.BlockHeight(binary.BigEndian.Uint64(b[8:]))
+	err = bucket.Delete(keyReorg)
+	if err != nil {
+		return fmt.Errorf("failed to delete reorganization: %v", err)
+	}
+	if depth := tip - fork; p.maxReorgDepth > 0 && depth > p.maxReorgDepth {
+		return fmt.Errorf("%v: fork at height %d reverts %d blocks, exceeding the maximum of %d", ErrReorgTooDeep, fork, depth, p.maxReorgDepth)
+	}
+	return nil
+}
+
+// RevertBlock records the reorganization in progress: the height of the block reverted first, and the height of the fork.
+// Blocks are never refused when reverted, as the consensus set cannot recover from that,
+// instead the first block applied by the reorganization is refused, which rejects the fork as a whole.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	b, err := bucket.Get(keyReorg)
+	if err != nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	reorg := make([]byte, 16)
+	if len(b) == 16 {
+		copy(reorg, b[:8])
+	} else {
+		binary.BigEndian.PutUint64(reorg[:8], uint64(height))
+	}
+	binary.BigEndian.PutUint64(reorg[8:], uint64(height-1))
+	err = bucket.Put(keyReorg, reorg)
+	if err != nil {
+		return fmt.Errorf("failed to store reorganization: %v", err)
+	}
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are only reverted as part of their block.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// MaxReorgDepth returns the maximum amount of blocks a reorganization can revert, 0 if unlimited.
+func (p *Plugin) MaxReorgDepth() types.BlockHeight {
+	return p.maxReorgDepth
+}
+
+// Authority returns the address signing the checkpoints.
+func (p *Plugin) Authority() types.UnlockHash {
+	return p.authority
+}
+
+// EmbeddedCheckpoints returns the checkpoints embedded in the release, lowest height first.
+func (p *Plugin) EmbeddedCheckpoints() []Checkpoint {
+	list := make([]Checkpoint, 0, len(p.embedded))
+	for height, id := range p.embedded {
+		list = append(list, Checkpoint{Height: height, BlockID: id})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Height < list[j].Height
+	})
+	return list
+}
+
+// Checkpoints returns all signed checkpoints, lowest height first.
+func (p *Plugin) Checkpoints() []SignedCheckpoint {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	list := make([]SignedCheckpoint, 0, len(p.checkpoints))
+	for _, scp := range p.checkpoints {
+		list = append(list, scp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Height < list[j].Height
+	})
+	return list
+}
+
+// AddCheckpoint verifies and stores the given checkpoint, enforcing it from now on.
+// Should the blockchain of the node conflict with the checkpoint, it is stored nonetheless,
+// but a *ConflictError is returned, as the node has to be resynced.
+// The given chain is not to be called while the consensus set calls the plugin.
+func (p *Plugin) AddCheckpoint(scp SignedCheckpoint, c chain) error {
+	err := scp.Verify(p.authority)
+	if err != nil {
+		return err
+	}
+	if id, ok := p.embedded[scp.Height]; ok && id != scp.BlockID {
+		return fmt.Errorf("the embedded checkpoint at height %d is for another block (%s)", scp.Height, id.String())
+	}
+	conflict := verifyCheckpoint(scp.Checkpoint, c)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if existing, ok := p.checkpoints[scp.Height]; ok && existing.BlockID != scp.BlockID {
+		return fmt.Errorf("a checkpoint for another block (%s) already exists at height %d", existing.BlockID.String(), scp.Height)
+	}
+	p.checkpoints[scp.Height] = scp
+	list := make([]SignedCheckpoint, 0, len(p.checkpoints))
+	for _, scp := range p.checkpoints {
+		list = append(list, scp)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Height < list[j].Height
+	})
+	err = persist.SaveJSON(checkpointsMetadata, list, p.filename)
+	if err != nil {
+		delete(p.checkpoints, scp.Height)
+		return fmt.Errorf("failed to store checkpoint: %v", err)
+	}
+	return conflict
+}
+
+// VerifyChain returns a *ConflictError should the blockchain of the node conflict with any checkpoint.
+func (p *Plugin) VerifyChain(c chain) error {
+	for _, cp := range p.EmbeddedCheckpoints() {
+		err := verifyCheckpoint(cp, c)
+		if err != nil {
+			return err
+		}
+	}
+	for _, scp := range p.Checkpoints() {
+		err := verifyCheckpoint(scp.Checkpoint, c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyCheckpoint returns a *ConflictError should the given chain contain another block at the height of the checkpoint.
+func verifyCheckpoint(cp Checkpoint, c chain) error {
+	block, ok := c.BlockAtHeight(cp.Height)
+	if ok && block.ID() != cp.BlockID {
+		return &ConflictError{Checkpoint: cp, BlockID: block.ID()}
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package finality
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var testBucket = []byte("finality")
+
+// testChain is a chain of blocks, the block at each height having that height as timestamp.
+type testChain types.BlockHeight
+
+func (c testChain) BlockAtHeight(height types.BlockHeight) (types.Block, bool) {
+	if height > types.BlockHeight(c) {
+		return types.Block{}, false
+	}
+	return types.Block{Timestamp: types.Timestamp(height)}, true
+}
+
+func TestPluginReorgDepth(t *testing.T) {
+	dir := t.TempDir()
+	db, err := bolt.Open(filepath.Join(dir, "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucket(testBucket)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := NewPlugin(2, types.UnlockHash{}, nil, filepath.Join(dir, CheckpointsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block := func(timestamp types.Timestamp) types.Block {
+		return types.Block{Timestamp: timestamp, Transactions: []types.Transaction{{Version: types.TransactionVersionOne}}}
+	}
+	// reorg reverts the blocks down to the given height, and applies a fork of the given length on top of it
+	reorg := func(tip, fork types.BlockHeight, length int) error {
+		return db.Update(func(tx *bolt.Tx) error {
+			bucket := persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+				return tx.Bucket(testBucket), nil
+			})
+			for height := tip; height > fork; height-- {
+				if err := p.RevertBlock(block(types.Timestamp(height)), height, bucket); err != nil {
+					return err
+				}
+			}
+			for i := 1; i <= length; i++ {
+				b := block(types.Timestamp(100 + i))
+				height := fork + types.BlockHeight(i)
+				if err := p.ApplyTransaction(b.Transactions[0], b, height, bucket); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if err := reorg(10, 8, 3); err != nil {
+		t.Fatalf("expected a reorganization of 2 blocks to be allowed, got %v", err)
+	}
+	if err := reorg(11, 8, 4); err == nil || !strings.HasPrefix(err.Error(), ErrReorgTooDeep.Error()) {
+		t.Fatalf("expected a reorganization of 3 blocks to be refused, got %v", err)
+	}
+	// the refused reorganization is rolled back, and doesn't affect the next blocks
+	if err := reorg(11, 11, 1); err != nil {
+		t.Fatalf("expected a block to be applied, got %v", err)
+	}
+}
+
+func TestPluginCheckpoints(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, CheckpointsFile)
+	foundation := signer.New(modules.Seed{1}, 1)
+	authority := foundation.Addresses()[0]
+	sign := func(s *signer.Signer, cp Checkpoint) SignedCheckpoint {
+		t.Helper()
+		challenge, err := s.SignChallenge(s.Addresses()[0], cp.Message())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return NewSignedCheckpoint(cp, challenge)
+	}
+
+	chain := testChain(10)
+	block2, _ := chain.BlockAtHeight(2)
+	embedded := map[types.BlockHeight]types.BlockID{2: block2.ID()}
+	p, err := NewPlugin(0, authority, embedded, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block5, _ := chain.BlockAtHeight(5)
+	cp := Checkpoint{Height: 5, BlockID: block5.ID()}
+
+	// only checkpoints signed by the foundation are accepted
+	if err := p.AddCheckpoint(sign(signer.New(modules.Seed{2}, 1), cp), chain); err != ErrInvalidCheckpoint {
+		t.Fatalf("expected a checkpoint signed by another address to be refused, got %v", err)
+	}
+	forged := sign(foundation, cp)
+	forged.Height = 6
+	if err := p.AddCheckpoint(forged, chain); err != ErrInvalidCheckpoint {
+		t.Fatalf("expected a modified checkpoint to be refused, got %v", err)
+	}
+	if err := p.AddCheckpoint(sign(foundation, Checkpoint{Height: 2, BlockID: types.BlockID{2}}), chain); err == nil {
+		t.Fatal("expected a checkpoint conflicting with an embedded checkpoint to be refused")
+	}
+	if err := p.AddCheckpoint(sign(foundation, cp), chain); err != nil {
+		t.Fatal(err)
+	}
+	// checkpoints conflicting with the chain of the node are stored, but reported
+	future := Checkpoint{Height: 8, BlockID: types.BlockID{1}}
+	if err := p.AddCheckpoint(sign(foundation, future), chain); err == nil {
+		t.Fatal("expected a conflicting checkpoint to be reported")
+	} else if conflict, ok := err.(*ConflictError); !ok || conflict.Checkpoint != future {
+		t.Fatalf("unexpected conflict: %v", err)
+	}
+	if err := p.VerifyChain(testChain(7)); err != nil {
+		t.Fatalf("expected a shorter chain to be valid, got %v", err)
+	}
+
+	// the checkpoints are enforced when blocks are applied, and survive restarts
+	p, err = NewPlugin(0, authority, embedded, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkpoints := p.Checkpoints(); len(checkpoints) != 2 || checkpoints[0].Checkpoint != cp || checkpoints[1].Checkpoint != future {
+		t.Fatalf("unexpected checkpoints: %v", checkpoints)
+	}
+	if _, ok := p.VerifyChain(chain).(*ConflictError); !ok {
+		t.Fatal("expected the chain to conflict with the checkpoints")
+	}
+	db, err := bolt.Open(filepath.Join(dir, "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket(testBucket)
+		if err != nil {
+			return err
+		}
+		lazy := persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) { return bucket, nil })
+		if err := p.ApplyBlock(block5, 5, lazy); err != nil {
+			return err
+		}
+		if _, ok := p.ApplyBlock(types.Block{Timestamp: 1234}, 2, lazy).(*ConflictError); !ok {
+			t.Error("expected a block conflicting with an embedded checkpoint to be refused")
+		}
+		if _, ok := p.ApplyBlock(types.Block{Timestamp: 1234}, 5, lazy).(*ConflictError); !ok {
+			t.Error("expected a block conflicting with a checkpoint to be refused")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
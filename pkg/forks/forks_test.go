@@ -0,0 +1,165 @@
+package forks
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testSchedule raises the block size limit at height 10, and lowers both limits again at height 20.
+func testSchedule(t *testing.T) config.ForkSchedule {
+	schedule, err := config.NewForkSchedule(config.Limits{BlockSizeLimit: 20e3, ArbitraryDataSizeLimit: 83}, []config.Fork{
+		{Name: "lower", Height: 20, Limits: config.Limits{BlockSizeLimit: 15e3, ArbitraryDataSizeLimit: 10}},
+		{Name: "raise", Height: 10, Limits: config.Limits{BlockSizeLimit: 40e3}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schedule
+}
+
+// testTransaction creates a transaction with the given amount of arbitrary data and coin outputs,
+// each coin output adding 17 bytes to the 57 bytes of an empty transaction.
+func testTransaction(arbitraryData, outputs int) types.Transaction {
+	return types.Transaction{
+		Version:       types.TransactionVersionOne,
+		ArbitraryData: bytes.Repeat([]byte{1}, arbitraryData),
+		CoinOutputs:   make([]types.CoinOutput, outputs),
+	}
+}
+
+func TestPluginBlockSize(t *testing.T) {
+	p := NewPlugin(testSchedule(t))
+	// a block of about 30KB
+	block := types.Block{Transactions: []types.Transaction{testTransaction(0, 0), testTransaction(0, 600), testTransaction(0, 600), testTransaction(0, 600)}}
+	for _, tc := range []struct {
+		height  types.BlockHeight
+		refused bool
+	}{
+		{9, true},
+		{10, false},
+		{19, false},
+		{20, true},
+	} {
+		err := p.ApplyBlock(block, tc.height, nil)
+		if refused := err != nil; refused != tc.refused {
+			t.Errorf("height %d: expected the block to be refused: %v, got: %v", tc.height, tc.refused, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), ErrBlockTooLarge.Error()) {
+			t.Errorf("height %d: unexpected error: %v", tc.height, err)
+		}
+		// the block is verified once, when its first transaction is applied
+		err = p.ApplyTransaction(block.Transactions[0], block, tc.height, nil)
+		if refused := err != nil; refused != tc.refused {
+			t.Errorf("height %d: expected the first transaction to be refused: %v, got: %v", tc.height, tc.refused, err)
+		}
+		if err = p.ApplyTransaction(block.Transactions[1], block, tc.height, nil); err != nil {
+			t.Errorf("height %d: unexpected error for the second transaction: %v", tc.height, err)
+		}
+	}
+}
+
+func TestPluginTransactionValidators(t *testing.T) {
+	p := NewPlugin(testSchedule(t))
+	validate := func(txn types.Transaction, height types.BlockHeight) error {
+		ctx := types.TransactionValidationContext{ValidationContext: types.ValidationContext{Confirmed: true, BlockHeight: height}}
+		for _, validator := range p.TransactionValidators() {
+			if err := validator(txn, ctx, nil, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := validate(testTransaction(83, 0), 19); err != nil {
+		t.Errorf("expected 83 bytes of arbitrary data to be valid prior to the fork: %v", err)
+	}
+	if err := validate(testTransaction(83, 0), 20); err != types.ErrArbitraryDataTooLarge {
+		t.Errorf("expected 83 bytes of arbitrary data to be invalid after the fork, got: %v", err)
+	}
+	// a transaction of about 17KB
+	large := testTransaction(0, 1000)
+	if err := validate(large, 10); err != nil {
+		t.Errorf("expected a large transaction to be valid after raising the block size limit: %v", err)
+	}
+	if err := validate(large, 9); err != types.ErrTransactionTooLarge {
+		t.Errorf("expected a large transaction to be invalid prior to raising the block size limit, got: %v", err)
+	}
+}
+
+type testChain types.BlockHeight
+
+func (c testChain) Height() types.BlockHeight { return types.BlockHeight(c) }
+
+type testTransactionPool struct {
+	modules.TransactionPool
+	txns        []types.Transaction
+	subscribers []modules.TransactionPoolSubscriber
+}
+
+func (tpool *testTransactionPool) TransactionList() []types.Transaction {
+	return tpool.txns
+}
+
+func (tpool *testTransactionPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) {
+	tpool.subscribers = append(tpool.subscribers, s)
+	s.ReceiveUpdatedUnconfirmedTransactions(tpool.txns, modules.ConsensusChange{})
+}
+
+func (tpool *testTransactionPool) Unsubscribe(s modules.TransactionPoolSubscriber) {
+	for i := range tpool.subscribers {
+		if tpool.subscribers[i] == s {
+			tpool.subscribers = append(tpool.subscribers[:i], tpool.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+type testSubscriber struct {
+	txns []types.Transaction
+}
+
+func (s *testSubscriber) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, _ modules.ConsensusChange) {
+	s.txns = txns
+}
+
+func TestTransactionPool(t *testing.T) {
+	// a small transaction with 50 bytes of arbitrary data, followed by transactions of about 4KB
+	inner := &testTransactionPool{txns: []types.Transaction{
+		testTransaction(50, 0), testTransaction(0, 235), testTransaction(0, 235), testTransaction(0, 235), testTransaction(0, 235),
+	}}
+	chain := testChain(8)
+	tp := &TransactionPool{TransactionPool: inner, cs: &chain, schedule: testSchedule(t),
+		subscribers: make(map[modules.TransactionPoolSubscriber]*subscriber)}
+
+	// only 15KB of the 20KB block is available for the transactions of the pool
+	if n := len(tp.TransactionList()); n != 4 {
+		t.Errorf("expected 4 transactions to fit in the next block, got %d", n)
+	}
+	var s testSubscriber
+	tp.TransactionPoolSubscribe(&s)
+	if len(s.txns) != 4 {
+		t.Errorf("expected the subscriber to receive 4 transactions, got %d", len(s.txns))
+	}
+
+	// all transactions fit in the 40KB block at height 10
+	chain = 9
+	if n := len(tp.TransactionList()); n != 5 {
+		t.Errorf("expected all transactions to fit in the next block, got %d", n)
+	}
+
+	// no transaction is skipped, so none fit once the arbitrary data of the first one is too large
+	chain = 19
+	inner.subscribers[0].ReceiveUpdatedUnconfirmedTransactions(inner.txns, modules.ConsensusChange{})
+	if len(s.txns) != 0 {
+		t.Errorf("expected the subscriber to receive no transactions, got %d", len(s.txns))
+	}
+
+	tp.Unsubscribe(&s)
+	if len(inner.subscribers) != 0 || len(tp.subscribers) != 0 {
+		t.Error("the subscriber was not unsubscribed")
+	}
+}
@@ -0,0 +1,143 @@
+// Package forks enforces the hard fork activation table of a network,
+// applying the size limits in effect at the height of every block and transaction.
+package forks
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "ForksPlugin"
+
+	// blockSizeReserve is the part of the block size limit a transaction cannot use,
+	// leaving room for the block header, miner payouts and block creating transaction, as the consensus set does.
+	blockSizeReserve = 5e3
+)
+
+var (
+	// ErrBlockTooLarge is returned for a block exceeding the block size limit in effect at its height.
+	ErrBlockTooLarge = errors.New("block exceeds the block size limit")
+)
+
+// Plugin is a consensus set plugin enforcing the size limits of the fork schedule of a network.
+//
+// The consensus set itself enforces the size limits of the chain constants at every height,
+// which are set to the highest limits of the schedule, such that the plugin only has to refuse
+// the blocks and transactions exceeding the lower limits in effect at their height.
+// Transactions are validated against the limits in effect at the height of their block,
+// or at the current height for the transactions tried out by the transaction pool.
+type Plugin struct {
+	schedule config.ForkSchedule
+
+	storage            modules.PluginViewStorage
+	unregisterCallback modules.PluginUnregisterCallback
+}
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new forks plugin, enforcing the given fork schedule.
+func NewPlugin(schedule config.ForkSchedule) *Plugin {
+	return &Plugin{schedule: schedule}
+}
+
+// InitPlugin initializes the plugin, which doesn't store anything.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock verifies the block, as applied when forwarding to a fork.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return p.verifyBlock(block, height)
+}
+
+// ApplyTransaction verifies the block of the transaction, once per block, as new blocks are applied transaction per transaction.
+// Transactions which are tried out by the transaction pool are not part of a block, and are verified by the transaction validators instead.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if len(block.Transactions) == 0 || block.Transactions[0].ID() != txn.ID() {
+		return nil
+	}
+	return p.verifyBlock(block, height)
+}
+
+// verifyBlock refuses the block should it exceed the block size limit in effect at its height.
+func (p *Plugin) verifyBlock(block types.Block, height types.BlockHeight) error {
+	limits := p.schedule.LimitsAt(height)
+	if size := uint64(len(siabin.Marshal(block))); size > limits.BlockSizeLimit {
+		return fmt.Errorf("%v: block %s at height %d has a size of %d bytes, exceeding the limit of %d bytes",
+			ErrBlockTooLarge, block.ID().String(), height, size, limits.BlockSizeLimit)
+	}
+	return nil
+}
+
+// RevertBlock implements modules.ConsensusSetPlugin,
+// blocks are never refused when reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are never refused when reverted.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the limits apply to all transaction versions alike.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// validating that all transactions respect the size limits in effect at their height.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return []modules.PluginTransactionValidationFunction{
+		p.validateTransactionFitsInABlock,
+		p.validateTransactionArbitraryData,
+	}
+}
+
+// validateTransactionFitsInABlock validates that the transaction fits in a block, as the standard consensus validator does,
+// using the block size limit in effect at the height of the transaction.
+func (p *Plugin) validateTransactionFitsInABlock(tx types.Transaction, ctx types.TransactionValidationContext, _ modules.ConsensusStateGetter, _ *persist.LazyBoltBucket) error {
+	return types.TransactionFitsInABlock(tx, p.schedule.LimitsAt(ctx.BlockHeight).BlockSizeLimit)
+}
+
+// validateTransactionArbitraryData validates that the arbitrary data of the transaction fits, as the standard consensus validator does,
+// using the arbitrary data size limit in effect at the height of the transaction.
+func (p *Plugin) validateTransactionArbitraryData(tx types.Transaction, ctx types.TransactionValidationContext, _ modules.ConsensusStateGetter, _ *persist.LazyBoltBucket) error {
+	return types.ArbitraryDataFits(tx.ArbitraryData, p.schedule.LimitsAt(ctx.BlockHeight).ArbitraryDataSizeLimit)
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// Schedule returns the fork schedule enforced by the plugin.
+func (p *Plugin) Schedule() config.ForkSchedule {
+	return p.schedule
+}
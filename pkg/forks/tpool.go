@@ -0,0 +1,96 @@
+package forks
+
+import (
+	"sync"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// chain is the part of modules.ConsensusSet used to get the height of the next block.
+type chain interface {
+	Height() types.BlockHeight
+}
+
+// TransactionPool wraps the transaction pool used to create blocks,
+// only handing out the transactions which fit in the next block, under the limits in effect at its height.
+// The block creators fill blocks using the limits of the chain constants instead,
+// which are the highest limits of the fork schedule.
+type TransactionPool struct {
+	modules.TransactionPool
+	cs       chain
+	schedule config.ForkSchedule
+
+	mu          sync.Mutex
+	subscribers map[modules.TransactionPoolSubscriber]*subscriber
+}
+
+var _ modules.TransactionPool = (*TransactionPool)(nil)
+
+// NewTransactionPool creates a new transaction pool, handing out the transactions of the given transaction pool
+// which fit in the next block of the given consensus set, under the limits of the given fork schedule.
+func NewTransactionPool(tpool modules.TransactionPool, cs modules.ConsensusSet, schedule config.ForkSchedule) *TransactionPool {
+	return &TransactionPool{
+		TransactionPool: tpool,
+		cs:              cs,
+		schedule:        schedule,
+		subscribers:     make(map[modules.TransactionPoolSubscriber]*subscriber),
+	}
+}
+
+// TransactionList implements modules.TransactionPool.TransactionList,
+// returning the transactions of the pool which fit in the next block.
+func (tp *TransactionPool) TransactionList() []types.Transaction {
+	return tp.fit(tp.TransactionPool.TransactionList())
+}
+
+// TransactionPoolSubscribe implements modules.TransactionPool.TransactionPoolSubscribe,
+// sending the subscriber only the transactions of the pool which fit in the next block.
+func (tp *TransactionPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) {
+	wrapped := &subscriber{TransactionPoolSubscriber: s, tp: tp}
+	tp.mu.Lock()
+	tp.subscribers[s] = wrapped
+	tp.mu.Unlock()
+	tp.TransactionPool.TransactionPoolSubscribe(wrapped)
+}
+
+// Unsubscribe implements modules.TransactionPool.Unsubscribe.
+func (tp *TransactionPool) Unsubscribe(s modules.TransactionPoolSubscriber) {
+	tp.mu.Lock()
+	wrapped, ok := tp.subscribers[s]
+	delete(tp.subscribers, s)
+	tp.mu.Unlock()
+	if ok {
+		tp.TransactionPool.Unsubscribe(wrapped)
+	}
+}
+
+// fit returns the given transactions, in order, until the first one which does not fit in the next block,
+// as transactions might depend on previous ones, so no transaction is skipped.
+func (tp *TransactionPool) fit(txns []types.Transaction) []types.Transaction {
+	limits := tp.schedule.LimitsAt(tp.cs.Height() + 1)
+	remaining := int(limits.BlockSizeLimit - blockSizeReserve)
+	for i, txn := range txns {
+		if uint64(len(txn.ArbitraryData)) > limits.ArbitraryDataSizeLimit {
+			return txns[:i]
+		}
+		remaining -= len(siabin.Marshal(txn))
+		if remaining < 0 {
+			return txns[:i]
+		}
+	}
+	return txns
+}
+
+// subscriber receives the transactions of the pool which fit in the next block.
+type subscriber struct {
+	modules.TransactionPoolSubscriber
+	tp *TransactionPool
+}
+
+// ReceiveUpdatedUnconfirmedTransactions implements modules.TransactionPoolSubscriber.
+func (s *subscriber) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, cc modules.ConsensusChange) {
+	s.TransactionPoolSubscriber.ReceiveUpdatedUnconfirmedTransactions(s.tp.fit(txns), cc)
+}
@@ -0,0 +1,377 @@
+// Package governance keeps track of the votes blockstake holders cast on governance proposals,
+// such as changes of the chain parameters, and tallies these votes weighted by blockstakes.
+package governance
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "GovernancePlugin"
+)
+
+// ErrProposalNotFound is returned for a proposal nobody voted on.
+var ErrProposalNotFound = errors.New("proposal not found")
+
+var (
+	// bucketProposals maps the IDs of all proposals voted on to the proposals and the height of their first vote
+	bucketProposals = []byte("proposals")
+	// bucketVotes contains a bucket per proposal, containing a bucket per voter,
+	// mapping the block heights at which the voter voted on the proposal to its choice
+	bucketVotes = []byte("votes")
+)
+
+type (
+	// Plugin is a consensus set plugin, keeping track of the votes cast on governance proposals.
+	Plugin struct {
+		txVersion          types.TransactionVersion
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// ProposalInfo is a proposal voted on, identified by its ID.
+	ProposalInfo struct {
+		ID       crypto.Hash             `json:"id"`
+		Proposal goldchaintypes.Proposal `json:"proposal"`
+		// Height is the height of the block which contains the first vote on the proposal
+		Height types.BlockHeight `json:"height"`
+	}
+
+	// Vote is the last vote of an address on a proposal.
+	Vote struct {
+		Voter  types.UnlockHash          `json:"voter"`
+		Choice goldchaintypes.VoteChoice `json:"choice"`
+		// Height is the height of the block which contains the vote
+		Height types.BlockHeight `json:"height"`
+	}
+
+	proposalRecord struct {
+		Proposal goldchaintypes.Proposal
+		Height   types.BlockHeight
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new governance plugin,
+// applying the vote transactions of the given version.
+func NewPlugin(txVersion types.TransactionVersion) *Plugin {
+	return &Plugin{txVersion: txVersion}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketProposals, bucketVotes} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies all transactions of the block.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction stores the vote of each voting address of a vote transaction,
+// as well as the proposal voted on, should it be its first vote.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	proposal, choice, ok := p.vote(txn)
+	if !ok {
+		return nil
+	}
+	proposalsBucket, votesBucket, err := getBuckets(bucket)
+	if err != nil {
+		return err
+	}
+	id := proposal.ID()
+	if proposalsBucket.Get(id[:]) == nil {
+		err = proposalsBucket.Put(id[:], rivbin.Marshal(proposalRecord{Proposal: proposal, Height: height}))
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store proposal %s: %v", id.String(), err)
+		}
+	}
+	proposalBucket, err := votesBucket.CreateBucketIfNotExists(id[:])
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create votes bucket for proposal %s: %v", id.String(), err)
+	}
+	for _, uh := range goldchaintypes.VotingAddresses(txn) {
+		voterBucket, err := proposalBucket.CreateBucketIfNotExists(rivbin.Marshal(uh))
+		if err != nil {
+			return fmt.Errorf("failed to create votes bucket for address %s: %v", uh.String(), err)
+		}
+		err = voterBucket.Put(encodeBlockHeight(height), []byte{byte(choice)})
+		if err != nil {
+			return fmt.Errorf("failed to store vote of address %s at height %d: %v", uh.String(), height, err)
+		}
+	}
+	return nil
+}
+
+// RevertBlock reverts all transactions of the block, last transaction first.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		err := p.RevertTransaction(block.Transactions[i], block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertTransaction reverts the votes of a vote transaction, deleting the proposal once no votes remain.
+// As this reverts the votes made by all transactions of the block for the voting addresses,
+// it is only to be used for reverting entire blocks, last transaction first.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	proposal, _, ok := p.vote(txn)
+	if !ok {
+		return nil
+	}
+	proposalsBucket, votesBucket, err := getBuckets(bucket)
+	if err != nil {
+		return err
+	}
+	id := proposal.ID()
+	proposalBucket := votesBucket.Bucket(id[:])
+	if proposalBucket == nil {
+		return nil
+	}
+	for _, uh := range goldchaintypes.VotingAddresses(txn) {
+		key := rivbin.Marshal(uh)
+		voterBucket := proposalBucket.Bucket(key)
+		if voterBucket == nil {
+			continue
+		}
+		err = voterBucket.Delete(encodeBlockHeight(height))
+		if err != nil {
+			return fmt.Errorf("failed to delete vote of address %s at height %d: %v", uh.String(), height, err)
+		}
+		if k, _ := voterBucket.Cursor().First(); k == nil {
+			err = proposalBucket.DeleteBucket(key)
+			if err != nil {
+				return fmt.Errorf("failed to delete votes bucket of address %s: %v", uh.String(), err)
+			}
+		}
+	}
+	if k, _ := proposalBucket.Cursor().First(); k == nil {
+		err = votesBucket.DeleteBucket(id[:])
+		if err == nil {
+			err = proposalsBucket.Delete(id[:])
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete proposal %s: %v", id.String(), err)
+		}
+	}
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the vote transaction is validated using stand alone validators.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetProposals returns all proposals voted on, the proposal with the most recent first vote first.
+func (p *Plugin) GetProposals() ([]ProposalInfo, error) {
+	proposals := []ProposalInfo{}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		proposalsBucket := bucket.Bucket(bucketProposals)
+		if proposalsBucket == nil {
+			return errors.New("proposals bucket does not exist")
+		}
+		return proposalsBucket.ForEach(func(k, v []byte) error {
+			info, err := decodeProposal(k, v)
+			if err != nil {
+				return err
+			}
+			proposals = append(proposals, info)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(proposals, func(i, j int) bool {
+		if proposals[i].Height != proposals[j].Height {
+			return proposals[i].Height > proposals[j].Height
+		}
+		return proposals[i].ID.String() < proposals[j].ID.String()
+	})
+	return proposals, nil
+}
+
+// GetProposal returns the proposal with the given ID,
+// as well as the last vote of each address on that proposal up to (and including) the given height, ordered by voter.
+func (p *Plugin) GetProposal(id crypto.Hash, height types.BlockHeight) (ProposalInfo, []Vote, error) {
+	var (
+		info  ProposalInfo
+		votes []Vote
+	)
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		proposalsBucket := bucket.Bucket(bucketProposals)
+		if proposalsBucket == nil {
+			return errors.New("proposals bucket does not exist")
+		}
+		votesBucket := bucket.Bucket(bucketVotes)
+		if votesBucket == nil {
+			return errors.New("votes bucket does not exist")
+		}
+		v := proposalsBucket.Get(id[:])
+		if v == nil {
+			return ErrProposalNotFound
+		}
+		var err error
+		info, err = decodeProposal(id[:], v)
+		if err != nil {
+			return err
+		}
+		proposalBucket := votesBucket.Bucket(id[:])
+		if proposalBucket == nil {
+			return nil
+		}
+		return proposalBucket.ForEach(func(k, _ []byte) error {
+			voterBucket := proposalBucket.Bucket(k)
+			if voterBucket == nil {
+				return nil
+			}
+			var voter types.UnlockHash
+			err := rivbin.Unmarshal(k, &voter)
+			if err != nil {
+				return fmt.Errorf("failed to decode voter: %v", err)
+			}
+			vote, ok := voteAt(voterBucket, height)
+			if !ok {
+				return nil
+			}
+			vote.Voter = voter
+			votes = append(votes, vote)
+			return nil
+		})
+	})
+	if err != nil {
+		return ProposalInfo{}, nil, err
+	}
+	sort.Slice(votes, func(i, j int) bool {
+		return votes[i].Voter.Cmp(votes[j].Voter) < 0
+	})
+	return info, votes, nil
+}
+
+// vote returns the proposal and choice of a vote transaction,
+// and false in case the transaction is not a vote transaction.
+func (p *Plugin) vote(txn types.Transaction) (goldchaintypes.Proposal, goldchaintypes.VoteChoice, bool) {
+	if txn.Version != p.txVersion {
+		return goldchaintypes.Proposal{}, 0, false
+	}
+	return goldchaintypes.TransactionVote(txn)
+}
+
+// voteAt returns the last vote stored in the given voter bucket up to (and including) the given height,
+// and false in case the voter did not vote yet at that height.
+func voteAt(voterBucket *bolt.Bucket, height types.BlockHeight) (Vote, bool) {
+	cursor := voterBucket.Cursor()
+	k, v := cursor.Seek(encodeBlockHeight(height + 1))
+	if k == nil {
+		k, v = cursor.Last()
+	} else {
+		k, v = cursor.Prev()
+	}
+	if k == nil || len(v) != 1 {
+		return Vote{}, false
+	}
+	return Vote{Choice: goldchaintypes.VoteChoice(v[0]), Height: types.BlockHeight(binary.BigEndian.Uint64(k))}, true
+}
+
+func decodeProposal(k, v []byte) (ProposalInfo, error) {
+	var record proposalRecord
+	err := rivbin.Unmarshal(v, &record)
+	if err != nil {
+		return ProposalInfo{}, fmt.Errorf("failed to decode proposal: %v", err)
+	}
+	info := ProposalInfo{Proposal: record.Proposal, Height: record.Height}
+	copy(info.ID[:], k)
+	return info, nil
+}
+
+func getBuckets(bucket *persist.LazyBoltBucket) (proposals, votes *bolt.Bucket, err error) {
+	proposals, err = bucket.Bucket(bucketProposals)
+	if err != nil {
+		return nil, nil, errors.New("proposals bucket does not exist")
+	}
+	votes, err = bucket.Bucket(bucketVotes)
+	if err != nil {
+		return nil, nil, errors.New("votes bucket does not exist")
+	}
+	return proposals, votes, nil
+}
+
+func encodeBlockHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
@@ -0,0 +1,124 @@
+package governance
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/stakes"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginVotes(t *testing.T) {
+	db := plugintest.NewDB(t, "governance")
+
+	p := NewPlugin(goldchaintypes.TransactionVersionVote)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		t.Helper()
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	alice := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	bob := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	proposal := goldchaintypes.Proposal{Title: "raise the block size limit", EndHeight: 10}
+	vote := func(choice goldchaintypes.VoteChoice, voters ...types.UnlockHash) types.Block {
+		vtx := goldchaintypes.VoteTransaction{Proposal: proposal, Choice: choice}
+		for _, voter := range voters {
+			vtx.BlockStakeOutputs = append(vtx.BlockStakeOutputs, types.BlockStakeOutput{
+				Value: types.NewCurrency64(1), Condition: types.NewCondition(types.NewUnlockHashCondition(voter))})
+		}
+		return types.Block{Transactions: []types.Transaction{
+			{Version: types.TransactionVersionOne},
+			vtx.Transaction(goldchaintypes.TransactionVersionVote),
+		}}
+	}
+
+	// blocks without votes can be replayed using a read-only transaction
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(types.Block{Transactions: []types.Transaction{{Version: types.TransactionVersionOne}}}, 1, bucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := vote(goldchaintypes.VoteChoiceYes, alice, bob), vote(goldchaintypes.VoteChoiceNo, bob)
+	update(func(bucket *persist.LazyBoltBucket) error { return p.ApplyBlock(first, 2, bucket) })
+	update(func(bucket *persist.LazyBoltBucket) error { return p.ApplyBlock(second, 5, bucket) })
+
+	proposals, err := p.GetProposals()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proposals) != 1 || proposals[0].ID != proposal.ID() || proposals[0].Height != 2 || proposals[0].Proposal != proposal {
+		t.Fatalf("unexpected proposals: %+v", proposals)
+	}
+	// the last vote of every voter up to the given height counts
+	for height, expected := range map[types.BlockHeight][]goldchaintypes.VoteChoice{
+		4:  {goldchaintypes.VoteChoiceYes, goldchaintypes.VoteChoiceYes},
+		10: {goldchaintypes.VoteChoiceYes, goldchaintypes.VoteChoiceNo},
+	} {
+		_, votes, err := p.GetProposal(proposal.ID(), height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(votes) != 2 || votes[0].Voter != alice || votes[0].Choice != expected[0] || votes[1].Voter != bob || votes[1].Choice != expected[1] {
+			t.Errorf("height %d: unexpected votes: %+v", height, votes)
+		}
+	}
+
+	// the proposal is deleted once all its votes are reverted
+	update(func(bucket *persist.LazyBoltBucket) error { return p.RevertBlock(second, 5, bucket) })
+	_, votes, err := p.GetProposal(proposal.ID(), 10)
+	if err != nil || len(votes) != 2 || votes[1].Choice != goldchaintypes.VoteChoiceYes {
+		t.Errorf("unexpected votes after reverting the second vote: %+v (%v)", votes, err)
+	}
+	update(func(bucket *persist.LazyBoltBucket) error { return p.RevertBlock(first, 2, bucket) })
+	if _, _, err = p.GetProposal(proposal.ID(), 10); err != ErrProposalNotFound {
+		t.Errorf("expected the proposal to be deleted, got: %v", err)
+	}
+}
+
+func TestTally(t *testing.T) {
+	alice := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	bob := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	carol := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{3})
+	info := ProposalInfo{Proposal: goldchaintypes.Proposal{Title: "lower the minimum miner fee", EndHeight: 10}}
+	distribution := stakes.Distribution{
+		Height: 10,
+		Stakes: []stakes.AddressStake{
+			{UnlockHash: alice, Stake: types.NewCurrency64(40)},
+			{UnlockHash: bob, Stake: types.NewCurrency64(35)},
+			{UnlockHash: carol, Stake: types.NewCurrency64(25)},
+		},
+		Total: types.NewCurrency64(100),
+	}
+	votes := []Vote{
+		{Voter: alice, Choice: goldchaintypes.VoteChoiceYes},
+		{Voter: carol, Choice: goldchaintypes.VoteChoiceNo},
+	}
+
+	result := Tally(info, votes, distribution, 11)
+	if !result.Closed || !result.Approved || !result.Yes.Equals64(40) || !result.No.Equals64(25) || len(result.Votes) != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result = Tally(info, votes, distribution, 10); result.Closed || result.Approved {
+		t.Errorf("expected an open proposal not to be approved: %+v", result)
+	}
+	// a turnout of less than half of all blockstakes
+	if result = Tally(info, votes[:1], distribution, 11); result.Approved {
+		t.Errorf("expected the proposal not to be approved without quorum: %+v", result)
+	}
+	votes = append(votes, Vote{Voter: bob, Choice: goldchaintypes.VoteChoiceNo})
+	if result = Tally(info, votes, distribution, 11); result.Approved || !result.No.Equals64(60) {
+		t.Errorf("expected the proposal to be rejected: %+v", result)
+	}
+	if TallyHeight(info.Proposal, 5) != 5 || TallyHeight(info.Proposal, 20) != 10 {
+		t.Error("unexpected tally height")
+	}
+}
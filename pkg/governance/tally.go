@@ -0,0 +1,81 @@
+package governance
+
+import (
+	"github.com/nbh-digital/goldchain/pkg/stakes"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// Result is the tally of the votes cast on a proposal.
+	//
+	// Every vote is weighted by the blockstakes of the voter at the tally height,
+	// being the end height of the proposal once it is closed, and the current height while it is open.
+	// A closed proposal is approved in case the voters own more than half of all blockstakes,
+	// and the stake voting yes exceeds the stake voting no.
+	Result struct {
+		ID       crypto.Hash             `json:"id"`
+		Proposal goldchaintypes.Proposal `json:"proposal"`
+		// Height is the height of the block which contains the first vote on the proposal
+		Height types.BlockHeight `json:"height"`
+		// Closed is true once the chain grew past the end height of the proposal
+		Closed      bool              `json:"closed"`
+		TallyHeight types.BlockHeight `json:"tallyheight"`
+		Yes         types.Currency    `json:"yes"`
+		No          types.Currency    `json:"no"`
+		Abstain     types.Currency    `json:"abstain"`
+		// TotalStake is the amount of all blockstakes at the tally height
+		TotalStake types.Currency `json:"totalstake"`
+		Approved   bool           `json:"approved"`
+		// Votes contains the last vote of every voter, ordered by voter
+		Votes []WeightedVote `json:"votes"`
+	}
+
+	// WeightedVote is the vote of an address, weighted by its blockstakes at the tally height.
+	WeightedVote struct {
+		Vote
+		Stake types.Currency `json:"stake"`
+	}
+)
+
+// Tally returns the result of the given votes on a proposal at the given current height,
+// weighting them using the given blockstake distribution at the tally height.
+func Tally(info ProposalInfo, votes []Vote, distribution stakes.Distribution, height types.BlockHeight) Result {
+	result := Result{
+		ID:          info.ID,
+		Proposal:    info.Proposal,
+		Height:      info.Height,
+		Closed:      height > info.Proposal.EndHeight,
+		TallyHeight: distribution.Height,
+		TotalStake:  distribution.Total,
+		Votes:       make([]WeightedVote, 0, len(votes)),
+	}
+	stakeOf := make(map[types.UnlockHash]types.Currency, len(distribution.Stakes))
+	for _, stake := range distribution.Stakes {
+		stakeOf[stake.UnlockHash] = stake.Stake
+	}
+	for _, vote := range votes {
+		stake := stakeOf[vote.Voter]
+		switch vote.Choice {
+		case goldchaintypes.VoteChoiceYes:
+			result.Yes = result.Yes.Add(stake)
+		case goldchaintypes.VoteChoiceNo:
+			result.No = result.No.Add(stake)
+		case goldchaintypes.VoteChoiceAbstain:
+			result.Abstain = result.Abstain.Add(stake)
+		}
+		result.Votes = append(result.Votes, WeightedVote{Vote: vote, Stake: stake})
+	}
+	turnout := result.Yes.Add(result.No).Add(result.Abstain)
+	result.Approved = result.Closed && turnout.Mul64(2).Cmp(result.TotalStake) > 0 && result.Yes.Cmp(result.No) > 0
+	return result
+}
+
+// TallyHeight returns the height at which the votes on the given proposal are tallied at the given current height.
+func TallyHeight(proposal goldchaintypes.Proposal, height types.BlockHeight) types.BlockHeight {
+	if height > proposal.EndHeight {
+		return proposal.EndHeight
+	}
+	return height
+}
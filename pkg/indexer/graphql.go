@@ -0,0 +1,589 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/nbh-digital/goldchain/pkg/postgres"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// defaultFirst is the default amount of items returned by a list query
+	defaultFirst = 100
+	// maxFirst is the maximum amount of items returned by a list query
+	maxFirst = 1000
+	// maxRequestSize limits the size of the GraphQL requests
+	maxRequestSize = 1 << 20
+)
+
+// NewSchema returns the GraphQL schema querying the given indexed database.
+// As every field is resolved by its own query, the events applied in the meantime can be partially visible.
+func NewSchema(db DB) (graphql.Schema, error) {
+	r := &resolver{db: db}
+	var block, transaction, output, authEvent *graphql.Object
+
+	nonNull := func(t graphql.Type) graphql.Type { return graphql.NewNonNull(t) }
+	list := func(t graphql.Type) graphql.Type { return nonNull(graphql.NewList(nonNull(t))) }
+	pageArgs := graphql.FieldConfigArgument{
+		"first": {Type: graphql.Int, DefaultValue: defaultFirst, Description: fmt.Sprintf("maximum amount of items, at most %d", maxFirst)},
+		"skip":  {Type: graphql.Int, DefaultValue: 0, Description: "amount of items skipped"},
+	}
+	heightArgs := graphql.FieldConfigArgument{
+		"minHeight": {Type: graphql.Int, Description: "lowest block height, inclusive"},
+		"maxHeight": {Type: graphql.Int, Description: "highest block height, inclusive"},
+	}
+	args := func(args ...graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+		all := make(graphql.FieldConfigArgument)
+		for _, a := range args {
+			for name, arg := range a {
+				all[name] = arg
+			}
+		}
+		return all
+	}
+	addressArg := graphql.FieldConfigArgument{"address": {Type: graphql.String, Description: "receiving address"}}
+	minValueArg := graphql.FieldConfigArgument{"minValue": {Type: graphql.String, Description: "minimum value of the output, in the smallest unit, inclusive"}}
+	unspentArg := graphql.FieldConfigArgument{"unspent": {Type: graphql.Boolean, Description: "only unspent (true) or spent (false) outputs"}}
+
+	// the object types refer to each other, their fields are defined once all of them exist
+	block = graphql.NewObject(graphql.ObjectConfig{Name: "Block", Description: "A block of the blockchain.", Fields: graphql.FieldsThunk(func() graphql.Fields {
+		return graphql.Fields{
+			"id":           {Type: nonNull(graphql.ID)},
+			"height":       {Type: nonNull(graphql.Int)},
+			"timestamp":    {Type: nonNull(graphql.Int), Description: "Unix timestamp of the block, in seconds."},
+			"transactions": {Type: list(transaction), Resolve: r.blockTransactions},
+		}
+	})})
+	transaction = graphql.NewObject(graphql.ObjectConfig{Name: "Transaction", Description: "A transaction of a block.", Fields: graphql.FieldsThunk(func() graphql.Fields {
+		return graphql.Fields{
+			"id":         {Type: nonNull(graphql.ID)},
+			"height":     {Type: nonNull(graphql.Int)},
+			"block":      {Type: nonNull(block), Resolve: r.sourceBlock},
+			"outputs":    {Type: list(output), Description: "The outputs created by the transaction.", Resolve: r.transactionOutputs},
+			"inputs":     {Type: list(output), Description: "The outputs spent by the transaction.", Resolve: r.transactionInputs},
+			"authEvents": {Type: list(authEvent), Description: "The authorizations changed by the transaction.", Resolve: r.transactionAuthEvents},
+			"authorized": {
+				Type: nonNull(graphql.Boolean), Resolve: r.transactionAuthorized,
+				Description: "Whether the given address is authorized, once the block of the transaction is applied.",
+				Args:        graphql.FieldConfigArgument{"address": {Type: nonNull(graphql.String)}},
+			},
+		}
+	})})
+	output = graphql.NewObject(graphql.ObjectConfig{Name: "Output", Description: "A coin output, created by a transaction or as block reward.", Fields: graphql.FieldsThunk(func() graphql.Fields {
+		return graphql.Fields{
+			"id":               {Type: nonNull(graphql.ID)},
+			"height":           {Type: nonNull(graphql.Int)},
+			"block":            {Type: nonNull(block), Resolve: r.sourceBlock},
+			"transaction":      {Type: transaction, Description: "The transaction creating the output, null for block rewards.", Resolve: r.outputTransaction},
+			"address":          {Type: nonNull(graphql.String)},
+			"value":            {Type: nonNull(graphql.String), Description: "The value, in the smallest unit."},
+			"condition":        {Type: nonNull(graphql.String), Description: "The JSON encoded condition of the output."},
+			"spent":            {Type: nonNull(graphql.Boolean)},
+			"spentHeight":      {Type: graphql.Int},
+			"spentTransaction": {Type: transaction, Resolve: r.outputSpentTransaction},
+			"authorized": {
+				Type: nonNull(graphql.Boolean), Resolve: r.outputAuthorized,
+				Description: "Whether the address is authorized, once the block creating the output is applied.",
+			},
+		}
+	})})
+	authEvent = graphql.NewObject(graphql.ObjectConfig{Name: "AuthEvent", Description: "A change of the authorization of an address.", Fields: graphql.FieldsThunk(func() graphql.Fields {
+		return graphql.Fields{
+			"height":      {Type: nonNull(graphql.Int)},
+			"address":     {Type: nonNull(graphql.String)},
+			"authorized":  {Type: nonNull(graphql.Boolean)},
+			"block":       {Type: nonNull(block), Resolve: r.sourceBlock},
+			"transaction": {Type: nonNull(transaction), Resolve: r.sourceTransaction},
+		}
+	})})
+	address := graphql.NewObject(graphql.ObjectConfig{Name: "Address", Description: "An address, receiving coins or of which the authorization changed.", Fields: graphql.Fields{
+		"address": {Type: nonNull(graphql.String)},
+		"balance": {Type: nonNull(graphql.String), Description: "The confirmed balance, in the smallest unit.", Resolve: r.addressBalance},
+		"authorized": {
+			Type: nonNull(graphql.Boolean), Resolve: r.addressAuthorized,
+			Description: "Whether the address is authorized, once the block at the given height is applied, the last indexed block by default.",
+			Args:        graphql.FieldConfigArgument{"height": {Type: graphql.Int}},
+		},
+		"authEvents": {Type: list(authEvent), Args: args(heightArgs, pageArgs), Resolve: r.authEvents},
+		"outputs":    {Type: list(output), Args: args(minValueArg, unspentArg, heightArgs, pageArgs), Resolve: r.outputs},
+	}})
+	status := graphql.NewObject(graphql.ObjectConfig{Name: "Status", Description: "The progress of the indexer.", Fields: graphql.Fields{
+		"seq":    {Type: nonNull(graphql.Int), Description: "The sequence number of the last applied event."},
+		"height": {Type: graphql.Int, Description: "The height of the last indexed block."},
+	}})
+
+	query := graphql.NewObject(graphql.ObjectConfig{Name: "Query", Fields: graphql.Fields{
+		"block": {
+			Type: block, Resolve: r.block, Description: "The block of the given ID or height.",
+			Args: graphql.FieldConfigArgument{"id": {Type: graphql.ID}, "height": {Type: graphql.Int}},
+		},
+		"blocks": {Type: list(block), Args: args(heightArgs, pageArgs), Resolve: r.blocks, Description: "The blocks, in order of height."},
+		"transaction": {
+			Type: transaction, Resolve: r.transaction,
+			Args: graphql.FieldConfigArgument{"id": {Type: nonNull(graphql.ID)}},
+		},
+		"transactions": {
+			Type: list(transaction), Resolve: r.transactions,
+			Description: "The transactions, in order of the blockchain, creating an output matching the given address and minimum value.",
+			Args:        args(addressArg, minValueArg, heightArgs, pageArgs),
+		},
+		"outputs": {
+			Type: list(output), Resolve: r.outputs, Description: "The coin outputs, in order of the blockchain.",
+			Args: args(addressArg, minValueArg, unspentArg, heightArgs, pageArgs),
+		},
+		"address": {
+			Type: nonNull(address), Resolve: r.address,
+			Args: graphql.FieldConfigArgument{"address": {Type: nonNull(graphql.String)}},
+		},
+		"authEvents": {
+			Type: list(authEvent), Resolve: r.authEvents, Description: "The changes of authorizations, in order of the blockchain.",
+			Args: args(graphql.FieldConfigArgument{"address": {Type: graphql.String}}, heightArgs, pageArgs),
+		},
+		"status": {Type: nonNull(status), Resolve: r.status},
+	}})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// graphQLRequest is a GraphQL request, as given in the JSON body of a POST request.
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// NewHandler returns the handler executing GraphQL requests against the given schema, given as a POST request
+// with a JSON body, or the query itself as application/graphql body, or a GET request with the query, operationName
+// and variables parameters.
+func NewHandler(schema graphql.Schema) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if variables := query.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeGraphQLError(w, "invalid variables: "+err.Error())
+					return
+				}
+			}
+		case http.MethodPost:
+			body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize+1))
+			if err == nil && len(body) > maxRequestSize {
+				err = fmt.Errorf("the request exceeds %d bytes", maxRequestSize)
+			}
+			if err == nil {
+				if strings.HasPrefix(r.Header.Get("Content-Type"), "application/graphql") {
+					req.Query = string(body)
+				} else {
+					err = json.Unmarshal(body, &req)
+				}
+			}
+			if err != nil {
+				writeGraphQLError(w, "invalid request: "+err.Error())
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "expected a GET or POST request", http.StatusMethodNotAllowed)
+			return
+		}
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			OperationName:  req.OperationName,
+			VariableValues: req.Variables,
+			Context:        r.Context(),
+		})
+		status := http.StatusOK
+		if result.Data == nil {
+			// the query could not be executed at all
+			status = http.StatusBadRequest
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(result)
+	})
+}
+
+// writeGraphQLError writes a GraphQL response refusing an invalid request.
+func writeGraphQLError(w http.ResponseWriter, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(&graphql.Result{Errors: []gqlerrors.FormattedError{{Message: msg}}})
+}
+
+// resolver resolves the fields of the schema, their sources being maps of the columns of the rows.
+type resolver struct {
+	db DB
+}
+
+// objects executes the given statement, returning its rows as maps of the given columns,
+// a column being named "name", or "name:int" or "name:bool" to convert the values.
+func (r *resolver) objects(stmt postgres.Statement, columns ...string) ([]interface{}, error) {
+	rows, err := r.db.Query(stmt.Query, stmt.Args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the database: %v", err)
+	}
+	objects := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("unexpected amount of columns: %d", len(row))
+		}
+		obj := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			name, kind := column, ""
+			if idx := strings.IndexByte(column, ':'); idx >= 0 {
+				name, kind = column[:idx], column[idx+1:]
+			}
+			if row[i] == nil {
+				obj[name] = nil
+				continue
+			}
+			switch kind {
+			case "int":
+				obj[name], err = strconv.ParseInt(*row[i], 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %q", name, *row[i])
+				}
+			case "bool":
+				obj[name] = *row[i] == "t"
+			default:
+				obj[name] = *row[i]
+			}
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// object executes the given statement, returning its first row, or nil if it returned none.
+func (r *resolver) object(stmt postgres.Statement, columns ...string) (interface{}, error) {
+	objects, err := r.objects(stmt, columns...)
+	if err != nil || len(objects) == 0 {
+		return nil, err
+	}
+	return objects[0], nil
+}
+
+var (
+	blockColumns       = []string{"id", "height:int", "timestamp:int"}
+	blockSelect        = "SELECT id, height, EXTRACT(EPOCH FROM timestamp)::BIGINT FROM blocks"
+	transactionColumns = []string{"id", "height:int", "blockID"}
+	transactionSelect  = "SELECT id, height, block_id FROM transactions"
+	outputColumns      = []string{"id", "height:int", "blockID", "transactionID", "address", "value", "condition",
+		"spent:bool", "spentHeight:int", "spentTransactionID"}
+	outputSelect = "SELECT id, height, block_id, transaction_id, address, value, condition, " +
+		"spent_transaction_id IS NOT NULL, spent_height, spent_transaction_id FROM outputs"
+	authEventColumns = []string{"height:int", "address", "authorized:bool", "blockID", "transactionID"}
+	authEventSelect  = "SELECT height, address, authorized, block_id, transaction_id FROM auth_events"
+)
+
+// parseID parses the given argument, the ID of a block or transaction,
+// returning it in the encoding of the database.
+func parseID(name, str string) (string, error) {
+	var id crypto.Hash
+	if err := id.LoadString(str); err != nil {
+		return "", fmt.Errorf("invalid %s %q: %v", name, str, err)
+	}
+	return id.String(), nil
+}
+
+// parseAddress parses the given address argument, returning it in the encoding of the database.
+func parseAddress(str string) (string, error) {
+	var uh types.UnlockHash
+	if err := uh.LoadString(str); err != nil {
+		return "", fmt.Errorf("invalid address %q: %v", str, err)
+	}
+	return uh.String(), nil
+}
+
+// filter is the list of conditions of a WHERE clause, together with the values of their parameters.
+type filter struct {
+	conditions []string
+	args       []interface{}
+}
+
+// add adds the given condition, its %s verbs being replaced by the parameters of the given values.
+func (f *filter) add(condition string, values ...interface{}) {
+	params := make([]interface{}, len(values))
+	for i, value := range values {
+		f.args = append(f.args, value)
+		params[i] = "$" + strconv.Itoa(len(f.args))
+	}
+	f.conditions = append(f.conditions, fmt.Sprintf(condition, params...))
+}
+
+func (f filter) String() string {
+	if len(f.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(f.conditions, " AND ")
+}
+
+// heights adds the minHeight and maxHeight arguments of the given column to the filter.
+func (f *filter) heights(args map[string]interface{}, column string) {
+	if min, ok := args["minHeight"].(int); ok {
+		f.add(column+" >= %s", min)
+	}
+	if max, ok := args["maxHeight"].(int); ok {
+		f.add(column+" <= %s", max)
+	}
+}
+
+// outputs adds the address, minValue and unspent arguments of the outputs table to the filter.
+func (f *filter) outputs(args map[string]interface{}) error {
+	if str, ok := args["address"].(string); ok {
+		address, err := parseAddress(str)
+		if err != nil {
+			return err
+		}
+		f.add("address = %s", address)
+	}
+	if minValue, ok := args["minValue"].(string); ok {
+		value, ok := new(big.Int).SetString(minValue, 10)
+		if !ok || value.Sign() < 0 {
+			return fmt.Errorf("invalid minValue %q, expected an amount in the smallest unit", minValue)
+		}
+		f.add("value >= %s", value.String())
+	}
+	if unspent, ok := args["unspent"].(bool); ok {
+		f.add("(spent_transaction_id IS NULL) = %s", unspent)
+	}
+	return nil
+}
+
+// page returns the LIMIT and OFFSET clauses of the first and skip arguments.
+func page(args map[string]interface{}) (string, error) {
+	first, _ := args["first"].(int)
+	skip, _ := args["skip"].(int)
+	if first < 0 || first > maxFirst {
+		return "", fmt.Errorf("invalid first %d, expected at most %d", first, maxFirst)
+	}
+	if skip < 0 {
+		return "", fmt.Errorf("invalid skip %d", skip)
+	}
+	return fmt.Sprintf(" LIMIT %d OFFSET %d", first, skip), nil
+}
+
+func (r *resolver) block(p graphql.ResolveParams) (interface{}, error) {
+	var f filter
+	if str, ok := p.Args["id"].(string); ok {
+		id, err := parseID("id", str)
+		if err != nil {
+			return nil, err
+		}
+		f.add("id = %s", id)
+	}
+	if height, ok := p.Args["height"].(int); ok {
+		f.add("height = %s", height)
+	}
+	if len(f.conditions) == 0 {
+		return nil, fmt.Errorf("expected the id or height of the block")
+	}
+	return r.object(statement(blockSelect+f.String(), f.args...), blockColumns...)
+}
+
+func (r *resolver) blocks(p graphql.ResolveParams) (interface{}, error) {
+	var f filter
+	f.heights(p.Args, "height")
+	limit, err := page(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	return r.objects(statement(blockSelect+f.String()+" ORDER BY height"+limit, f.args...), blockColumns...)
+}
+
+// sourceBlock resolves the block of a transaction, output or auth event.
+func (r *resolver) sourceBlock(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["blockID"].(string)
+	return r.object(statement(blockSelect+" WHERE id = $1", id), blockColumns...)
+}
+
+func (r *resolver) blockTransactions(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["id"].(string)
+	return r.objects(statement(transactionSelect+" WHERE block_id = $1 ORDER BY seq", id), transactionColumns...)
+}
+
+func (r *resolver) transaction(p graphql.ResolveParams) (interface{}, error) {
+	str, _ := p.Args["id"].(string)
+	id, err := parseID("id", str)
+	if err != nil {
+		return nil, err
+	}
+	return r.object(statement(transactionSelect+" WHERE id = $1", id), transactionColumns...)
+}
+
+func (r *resolver) transactions(p graphql.ResolveParams) (interface{}, error) {
+	var f filter
+	f.heights(p.Args, "height")
+	// the conditions of the subquery are numbered following the ones of the query
+	outputs := filter{args: f.args}
+	err := outputs.outputs(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	if len(outputs.conditions) > 0 {
+		f.conditions = append(f.conditions, "id IN (SELECT transaction_id FROM outputs"+outputs.String()+")")
+		f.args = outputs.args
+	}
+	limit, err := page(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	return r.objects(statement(transactionSelect+f.String()+" ORDER BY seq"+limit, f.args...), transactionColumns...)
+}
+
+// sourceTransaction resolves the transaction of an auth event.
+func (r *resolver) sourceTransaction(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["transactionID"].(string)
+	return r.object(statement(transactionSelect+" WHERE id = $1", id), transactionColumns...)
+}
+
+func (r *resolver) transactionOutputs(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["id"].(string)
+	return r.objects(statement(outputSelect+" WHERE transaction_id = $1 ORDER BY seq", id), outputColumns...)
+}
+
+func (r *resolver) transactionInputs(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["id"].(string)
+	return r.objects(statement(outputSelect+" WHERE spent_transaction_id = $1 ORDER BY seq", id), outputColumns...)
+}
+
+func (r *resolver) transactionAuthEvents(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Source.(map[string]interface{})["id"].(string)
+	return r.objects(statement(authEventSelect+" WHERE transaction_id = $1 ORDER BY seq", id), authEventColumns...)
+}
+
+func (r *resolver) transactionAuthorized(p graphql.ResolveParams) (interface{}, error) {
+	str, _ := p.Args["address"].(string)
+	address, err := parseAddress(str)
+	if err != nil {
+		return nil, err
+	}
+	height, _ := p.Source.(map[string]interface{})["height"].(int64)
+	return r.authorized(address, height)
+}
+
+func (r *resolver) outputs(p graphql.ResolveParams) (interface{}, error) {
+	args := p.Args
+	if p.Info.ParentType.Name() == "Address" {
+		// the outputs of an address
+		source := p.Source.(map[string]interface{})
+		args = make(map[string]interface{}, len(p.Args)+1)
+		for k, v := range p.Args {
+			args[k] = v
+		}
+		args["address"] = source["address"]
+	}
+	var f filter
+	f.heights(args, "height")
+	err := f.outputs(args)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := page(args)
+	if err != nil {
+		return nil, err
+	}
+	return r.objects(statement(outputSelect+f.String()+" ORDER BY seq"+limit, f.args...), outputColumns...)
+}
+
+func (r *resolver) outputTransaction(p graphql.ResolveParams) (interface{}, error) {
+	id, ok := p.Source.(map[string]interface{})["transactionID"].(string)
+	if !ok {
+		return nil, nil
+	}
+	return r.object(statement(transactionSelect+" WHERE id = $1", id), transactionColumns...)
+}
+
+func (r *resolver) outputSpentTransaction(p graphql.ResolveParams) (interface{}, error) {
+	id, ok := p.Source.(map[string]interface{})["spentTransactionID"].(string)
+	if !ok {
+		return nil, nil
+	}
+	return r.object(statement(transactionSelect+" WHERE id = $1", id), transactionColumns...)
+}
+
+func (r *resolver) outputAuthorized(p graphql.ResolveParams) (interface{}, error) {
+	source := p.Source.(map[string]interface{})
+	address, _ := source["address"].(string)
+	height, _ := source["height"].(int64)
+	return r.authorized(address, height)
+}
+
+func (r *resolver) address(p graphql.ResolveParams) (interface{}, error) {
+	str, _ := p.Args["address"].(string)
+	address, err := parseAddress(str)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"address": address}, nil
+}
+
+func (r *resolver) addressBalance(p graphql.ResolveParams) (interface{}, error) {
+	address, _ := p.Source.(map[string]interface{})["address"].(string)
+	balance, err := r.object(statement("SELECT confirmed FROM balances WHERE address = $1", address), "confirmed")
+	if err != nil || balance == nil {
+		return "0", err
+	}
+	return balance.(map[string]interface{})["confirmed"], nil
+}
+
+func (r *resolver) addressAuthorized(p graphql.ResolveParams) (interface{}, error) {
+	address, _ := p.Source.(map[string]interface{})["address"].(string)
+	height, ok := p.Args["height"].(int)
+	if !ok {
+		height = -1
+	}
+	return r.authorized(address, int64(height))
+}
+
+// authorized returns whether the given address is authorized once the block at the given height is applied,
+// or once the last indexed block is applied if the height is negative.
+func (r *resolver) authorized(address string, height int64) (interface{}, error) {
+	var f filter
+	f.add("address = %s", address)
+	if height >= 0 {
+		f.add("height <= %s", height)
+	}
+	state, err := r.object(statement("SELECT authorized FROM auth_events"+f.String()+" ORDER BY seq DESC LIMIT 1", f.args...), "authorized:bool")
+	if err != nil || state == nil {
+		return false, err
+	}
+	return state.(map[string]interface{})["authorized"], nil
+}
+
+func (r *resolver) authEvents(p graphql.ResolveParams) (interface{}, error) {
+	var f filter
+	if p.Info.ParentType.Name() == "Address" {
+		f.add("address = %s", p.Source.(map[string]interface{})["address"])
+	} else if str, ok := p.Args["address"].(string); ok {
+		address, err := parseAddress(str)
+		if err != nil {
+			return nil, err
+		}
+		f.add("address = %s", address)
+	}
+	f.heights(p.Args, "height")
+	limit, err := page(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	return r.objects(statement(authEventSelect+f.String()+" ORDER BY seq"+limit, f.args...), authEventColumns...)
+}
+
+func (r *resolver) status(p graphql.ResolveParams) (interface{}, error) {
+	return r.object(statement("SELECT seq, (SELECT MAX(height) FROM blocks) FROM indexer_state"), "seq:int", "height:int")
+}
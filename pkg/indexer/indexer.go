@@ -0,0 +1,227 @@
+// Package indexer maintains a normalized PostgreSQL schema of the blockchain,
+// applying the events recorded by a daemon (see /consensus/events), reverted events included,
+// such that the database always matches the chain of the daemon.
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/events"
+	"github.com/nbh-digital/goldchain/pkg/postgres"
+)
+
+const (
+	// DefaultBatchSize is the default maximum amount of events applied in a single transaction.
+	DefaultBatchSize = 1000
+	// DefaultRetryDelay is the default duration waited before fetching events again, should the daemon be unreachable.
+	DefaultRetryDelay = 10 * time.Second
+
+	// pollWait is the duration the daemon waits for new events, shorter than its default API timeout of one minute
+	pollWait = 50 * time.Second
+)
+
+// DB executes queries, as implemented by postgres.DB.
+type DB interface {
+	// Exec executes the given statements as a single transaction
+	Exec(statements ...postgres.Statement) error
+	// Query executes the given statement with the given values of its $1, $2, ... parameters,
+	// returning the rows returned by it, as text
+	Query(query string, args ...interface{}) ([][]*string, error)
+}
+
+// Source returns the events recorded by a daemon, as implemented by client.Client.
+type Source interface {
+	Events(ctx context.Context, after uint64, limit int, wait time.Duration) (goldchainapi.ConsensusEventsGET, error)
+}
+
+// Indexer applies the events of a daemon to a database.
+// The exported fields can be modified until the indexer runs.
+type Indexer struct {
+	db     DB
+	source Source
+	output io.Writer
+
+	// BatchSize is the maximum amount of events applied in a single transaction
+	BatchSize int
+	// RetryDelay is the duration waited before fetching events again, should the daemon be unreachable
+	RetryDelay time.Duration
+}
+
+// New creates an indexer applying the events of the given source to the given database,
+// of which the schema is expected to be migrated. Progress and errors are reported to the given writer.
+func New(db DB, source Source, output io.Writer) *Indexer {
+	if output == nil {
+		output = ioutil.Discard
+	}
+	return &Indexer{
+		db:         db,
+		source:     source,
+		output:     output,
+		BatchSize:  DefaultBatchSize,
+		RetryDelay: DefaultRetryDelay,
+	}
+}
+
+// Seq returns the sequence number of the last applied event.
+func (ix *Indexer) Seq() (uint64, error) {
+	rows, err := ix.db.Query("SELECT seq FROM indexer_state")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read indexer state: %v", err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 || rows[0][0] == nil {
+		return 0, fmt.Errorf("unexpected indexer state: %v", rows)
+	}
+	return strconv.ParseUint(*rows[0][0], 10, 64)
+}
+
+// Run applies all events following the last applied event, waiting for new events once all are applied,
+// until the context is done or the database returns an error.
+// Errors of the daemon are reported, and the events fetched again once the RetryDelay passed.
+func (ix *Indexer) Run(ctx context.Context) error {
+	seq, err := ix.Seq()
+	if err != nil {
+		return err
+	}
+	for {
+		resp, err := ix.source.Events(ctx, seq, ix.BatchSize, pollWait)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			fmt.Fprintln(ix.output, "Failed to fetch events:", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(ix.RetryDelay):
+			}
+			continue
+		}
+		if resp.LastSeq < seq {
+			return fmt.Errorf("the daemon recorded %d events, while %d events are applied: "+
+				"its consensus set was resynced, so the database has to be recreated", resp.LastSeq, seq)
+		}
+		if len(resp.Events) == 0 {
+			continue
+		}
+		err = ix.Apply(resp.Events)
+		if err != nil {
+			return err
+		}
+		last := resp.Events[len(resp.Events)-1]
+		seq = last.Seq
+		fmt.Fprintf(ix.output, "Applied events %d-%d of %d (height %d)\n", resp.Events[0].Seq, seq, resp.LastSeq, last.Height)
+	}
+}
+
+// Apply applies the given events, following the last applied event, in a single transaction.
+func (ix *Indexer) Apply(batch []events.Event) error {
+	statements := make([]postgres.Statement, 0, len(batch)*2+1)
+	for _, event := range batch {
+		eventStatements, err := eventSQL(event)
+		if err != nil {
+			return fmt.Errorf("failed to apply event %d: %v", event.Seq, err)
+		}
+		statements = append(statements, eventStatements...)
+	}
+	statements = append(statements, statement("UPDATE indexer_state SET seq = $1", batch[len(batch)-1].Seq))
+	err := ix.db.Exec(statements...)
+	if err != nil {
+		return fmt.Errorf("failed to apply events %d-%d: %v", batch[0].Seq, batch[len(batch)-1].Seq, err)
+	}
+	return nil
+}
+
+func statement(query string, args ...interface{}) postgres.Statement {
+	return postgres.Statement{Query: query, Args: args}
+}
+
+// eventSQL returns the statements applying the given event, or undoing it if reverted.
+func eventSQL(event events.Event) ([]postgres.Statement, error) {
+	blockID := event.BlockID.String()
+	var transactionID interface{}
+	if event.TransactionID != nil {
+		transactionID = event.TransactionID.String()
+	}
+	height := uint64(event.Height)
+	switch event.Type {
+	case events.TypeBlock:
+		if event.Reverted {
+			return []postgres.Statement{statement("DELETE FROM blocks WHERE id = $1", blockID)}, nil
+		}
+		return []postgres.Statement{statement("INSERT INTO blocks (id, seq, height, timestamp) VALUES ($1, $2, $3, to_timestamp($4))",
+			blockID, event.Seq, height, uint64(event.Timestamp))}, nil
+
+	case events.TypeTransaction:
+		if event.TransactionID == nil {
+			return nil, fmt.Errorf("transaction event without transaction ID")
+		}
+		if event.Reverted {
+			return []postgres.Statement{statement("DELETE FROM transactions WHERE id = $1", transactionID)}, nil
+		}
+		return []postgres.Statement{statement("INSERT INTO transactions (id, seq, block_id, height) VALUES ($1, $2, $3, $4)",
+			transactionID, event.Seq, blockID, height)}, nil
+
+	case events.TypeOutputCreated:
+		if event.OutputID == nil || event.Output == nil {
+			return nil, fmt.Errorf("output event without output")
+		}
+		outputID := event.OutputID.String()
+		address := event.Output.Condition.UnlockHash().String()
+		value := event.Output.Value.String()
+		if event.Reverted {
+			return []postgres.Statement{
+				statement("UPDATE balances SET confirmed = confirmed - $1 WHERE address = $2", value, address),
+				statement("DELETE FROM outputs WHERE id = $1", outputID),
+			}, nil
+		}
+		condition, err := json.Marshal(event.Output.Condition)
+		if err != nil {
+			return nil, err
+		}
+		return []postgres.Statement{
+			statement("INSERT INTO outputs (id, seq, block_id, height, transaction_id, address, value, condition) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)",
+				outputID, event.Seq, blockID, height, transactionID, address, value, string(condition)),
+			statement("INSERT INTO balances (address, confirmed) VALUES ($1, $2) "+
+				"ON CONFLICT (address) DO UPDATE SET confirmed = balances.confirmed + EXCLUDED.confirmed", address, value),
+		}, nil
+
+	case events.TypeOutputSpent:
+		if event.OutputID == nil || event.TransactionID == nil {
+			return nil, fmt.Errorf("output event without output or transaction ID")
+		}
+		outputID := event.OutputID.String()
+		if event.Reverted {
+			return []postgres.Statement{
+				statement("UPDATE balances SET confirmed = balances.confirmed + outputs.value FROM outputs WHERE outputs.id = $1 AND balances.address = outputs.address", outputID),
+				statement("UPDATE outputs SET spent_transaction_id = NULL, spent_height = NULL WHERE id = $1", outputID),
+			}, nil
+		}
+		return []postgres.Statement{
+			statement("UPDATE balances SET confirmed = balances.confirmed - outputs.value FROM outputs WHERE outputs.id = $1 AND balances.address = outputs.address", outputID),
+			statement("UPDATE outputs SET spent_transaction_id = $1, spent_height = $2 WHERE id = $3", transactionID, height, outputID),
+		}, nil
+
+	case events.TypeAuthStateChanged:
+		if event.Address == nil || event.Authorized == nil || event.TransactionID == nil {
+			return nil, fmt.Errorf("auth state event without address, state or transaction ID")
+		}
+		address := event.Address.String()
+		if event.Reverted {
+			return []postgres.Statement{statement("DELETE FROM auth_events WHERE seq = "+
+				"(SELECT MAX(seq) FROM auth_events WHERE transaction_id = $1 AND address = $2)", transactionID, address)}, nil
+		}
+		return []postgres.Statement{statement("INSERT INTO auth_events (seq, block_id, height, transaction_id, address, authorized) VALUES ($1, $2, $3, $4, $5, $6)",
+			event.Seq, blockID, height, transactionID, address, *event.Authorized)}, nil
+
+	default:
+		// events of types added after this indexer are skipped
+		return nil, nil
+	}
+}
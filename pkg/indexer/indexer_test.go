@@ -0,0 +1,250 @@
+package indexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/events"
+	"github.com/nbh-digital/goldchain/pkg/postgres"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testDB records the executed transactions, answering queries with the given rows,
+// the statements being formatted as the query followed by the values of its parameters.
+type testDB struct {
+	execs   []string
+	rows    map[string][][]*string
+	failing string
+}
+
+func formatStatement(query string, args []interface{}) string {
+	if len(args) == 0 {
+		return query
+	}
+	return fmt.Sprintf("%s %v", query, args)
+}
+
+func (db *testDB) Exec(statements ...postgres.Statement) error {
+	formatted := make([]string, 0, len(statements))
+	for _, stmt := range statements {
+		formatted = append(formatted, formatStatement(stmt.Query, stmt.Args))
+	}
+	txn := strings.Join(formatted, ";\n")
+	if db.failing != "" && strings.Contains(txn, db.failing) {
+		return errors.New("failed")
+	}
+	db.execs = append(db.execs, txn)
+	return nil
+}
+
+func (db *testDB) Query(query string, args ...interface{}) ([][]*string, error) {
+	return db.rows[formatStatement(query, args)], nil
+}
+
+func row(values ...string) [][]*string {
+	r := make([]*string, 0, len(values))
+	for i := range values {
+		r = append(r, &values[i])
+	}
+	return [][]*string{r}
+}
+
+func TestMigrate(t *testing.T) {
+	db := &testDB{rows: map[string][][]*string{
+		"SELECT COALESCE(MAX(version), 0) FROM schema_migrations": row("1"),
+	}}
+	err := Migrate(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the schema_migrations table is created, and only the second migration is applied
+	if len(db.execs) != 2 || !strings.Contains(db.execs[1], "CREATE VIEW auth_state") ||
+		!strings.HasSuffix(db.execs[1], "INSERT INTO schema_migrations (version) VALUES ($1) [2]") {
+		t.Errorf("unexpected statements: %q", db.execs)
+	}
+
+	db.rows["SELECT COALESCE(MAX(version), 0) FROM schema_migrations"] = row("3")
+	if err = Migrate(db); err == nil {
+		t.Error("expected a newer schema to be refused")
+	}
+}
+
+// testSource returns the given responses, one per call.
+type testSource struct {
+	responses []goldchainapi.ConsensusEventsGET
+	after     []uint64
+}
+
+func (s *testSource) Events(ctx context.Context, after uint64, limit int, wait time.Duration) (goldchainapi.ConsensusEventsGET, error) {
+	s.after = append(s.after, after)
+	if len(s.responses) == 0 {
+		return goldchainapi.ConsensusEventsGET{}, errors.New("unavailable")
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+func TestIndexer(t *testing.T) {
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	txnID := types.TransactionID{2}
+	outputID := types.CoinOutputID{3}
+	blockID := types.BlockID{4}
+	authorized := true
+	block := events.Event{Seq: 11, Type: events.TypeBlock, Height: 5, BlockID: blockID, Timestamp: 1600000000}
+	txn := block
+	txn.Seq, txn.Type, txn.TransactionID = 12, events.TypeTransaction, &txnID
+	created := txn
+	created.Seq, created.Type, created.OutputID = 13, events.TypeOutputCreated, &outputID
+	created.Output = &types.CoinOutput{Value: types.NewCurrency64(100), Condition: types.NewCondition(types.NewUnlockHashCondition(uh))}
+	spent := txn
+	spent.Seq, spent.Type, spent.OutputID = 14, events.TypeOutputSpent, &outputID
+	auth := txn
+	auth.Seq, auth.Type, auth.Address, auth.Authorized = 15, events.TypeAuthStateChanged, &uh, &authorized
+	reverted := block
+	reverted.Seq, reverted.Reverted = 16, true
+
+	db := &testDB{rows: map[string][][]*string{"SELECT seq FROM indexer_state": row("10")}}
+	source := &testSource{responses: []goldchainapi.ConsensusEventsGET{
+		{LastSeq: 15, Events: []events.Event{block, txn, created, spent, auth}},
+		{LastSeq: 16, Events: []events.Event{reverted}},
+		{LastSeq: 12},
+	}}
+	ix := New(db, source, nil)
+	ix.RetryDelay = time.Millisecond
+	err := ix.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "resynced") {
+		t.Fatalf("expected the indexer to stop once the daemon recorded fewer events, got %v", err)
+	}
+	if len(source.after) != 3 || source.after[0] != 10 || source.after[1] != 15 || source.after[2] != 16 {
+		t.Errorf("unexpected calls: %v", source.after)
+	}
+	if len(db.execs) != 2 {
+		t.Fatalf("expected a transaction per batch, got %q", db.execs)
+	}
+	for _, expected := range []string{
+		"INSERT INTO blocks (id, seq, height, timestamp) VALUES ($1, $2, $3, to_timestamp($4)) [" + blockID.String() + " 11 5 1600000000]",
+		"INSERT INTO transactions (id, seq, block_id, height) VALUES ($1, $2, $3, $4) [" + txnID.String() + " 12 ",
+		" " + uh.String() + " 100 {\"type\":1,",
+		"ON CONFLICT (address) DO UPDATE SET confirmed = balances.confirmed + EXCLUDED.confirmed [" + uh.String() + " 100]",
+		"UPDATE balances SET confirmed = balances.confirmed - outputs.value FROM outputs WHERE outputs.id = $1 AND balances.address = outputs.address [" + outputID.String() + "]",
+		"UPDATE outputs SET spent_transaction_id = $1, spent_height = $2 WHERE id = $3 [" + txnID.String() + " 5 " + outputID.String() + "]",
+		"INSERT INTO auth_events (seq, block_id, height, transaction_id, address, authorized) VALUES ($1, $2, $3, $4, $5, $6) [15 ",
+		" " + uh.String() + " true]",
+		"UPDATE indexer_state SET seq = $1 [15]",
+	} {
+		if !strings.Contains(db.execs[0], expected) {
+			t.Errorf("expected %q to be executed, got:\n%s", expected, db.execs[0])
+		}
+	}
+	if db.execs[1] != "DELETE FROM blocks WHERE id = $1 ["+blockID.String()+"];\nUPDATE indexer_state SET seq = $1 [16]" {
+		t.Errorf("unexpected statements of the reverted block:\n%s", db.execs[1])
+	}
+
+	// database errors stop the indexer
+	db.failing = "DELETE"
+	if err = ix.Apply([]events.Event{reverted}); err == nil {
+		t.Error("expected the failing statement to be reported")
+	}
+}
+
+func TestGraphQL(t *testing.T) {
+	addr := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1}).String()
+	output := row("out", "5", "block", "txn", addr, "7", `{"type":1}`, "f", "", "")
+	output[0][8], output[0][9] = nil, nil
+	db := &testDB{rows: map[string][][]*string{
+		transactionSelect + " WHERE height >= $1 AND height <= $2 AND id IN (SELECT transaction_id FROM outputs WHERE address = $3 AND value >= $4)" +
+			" ORDER BY seq LIMIT 100 OFFSET 0 [1 10 " + addr + " 5]": row("txn", "5", "block"),
+		outputSelect + " WHERE transaction_id = $1 ORDER BY seq [txn]":                                                      output,
+		"SELECT authorized FROM auth_events WHERE address = $1 AND height <= $2 ORDER BY seq DESC LIMIT 1 [" + addr + " 5]": row("t"),
+		"SELECT seq, (SELECT MAX(height) FROM blocks) FROM indexer_state":                                                   row("16", "5"),
+	}}
+	schema, err := NewSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := graphql.Do(graphql.Params{Schema: schema, Context: context.Background(), RequestString: `{
+		transactions(address: "` + addr + `", minHeight: 1, maxHeight: 10, minValue: "5") {
+			id height outputs { value spent spentHeight authorized } authorized(address: "` + addr + `")
+		}
+		status { seq height }
+	}`})
+	b, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"data":{"status":{"height":5,"seq":16},"transactions":[{"authorized":true,"height":5,"id":"txn",` +
+		`"outputs":[{"authorized":true,"spent":false,"spentHeight":null,"value":"7"}]}]}}`
+	if string(b) != expected {
+		t.Errorf("unexpected response:\n%s", b)
+	}
+
+	resp = graphql.Do(graphql.Params{Schema: schema, Context: context.Background(), RequestString: `{ outputs(minValue: "-1") { id } }`})
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "invalid minValue") {
+		t.Errorf("expected an invalid value to be refused, got %v", resp.Errors)
+	}
+
+	// addresses and IDs are parsed before being passed to the database
+	for query, msg := range map[string]string{
+		`{ outputs(address: "x' OR 1=1 --") { id } }`:                      "invalid address",
+		`{ address(address: "addr") { balance } }`:                         "invalid address",
+		`{ authEvents(address: "addr') ; DROP TABLE blocks") { height } }`: "invalid address",
+		`{ transaction(id: "'; DROP TABLE blocks; --") { id } }`:           "invalid id",
+		`{ block(id: "block") { id } }`:                                    "invalid id",
+	} {
+		resp = graphql.Do(graphql.Params{Schema: schema, Context: context.Background(), RequestString: query})
+		if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, msg) {
+			t.Errorf("expected %s to be refused, got %v", query, resp.Errors)
+		}
+	}
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	db := &testDB{rows: map[string][][]*string{
+		blockSelect + " WHERE height = $1 [5]": row("block", "5", "1500000000"),
+	}}
+	schema, err := NewSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(NewHandler(schema))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(
+		`{"query": "query ($height: Int) { block(height: $height) { id timestamp } }", "variables": {"height": 5}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		Data struct {
+			Block struct {
+				ID        string
+				Timestamp int64
+			}
+		}
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil || resp.StatusCode != http.StatusOK || result.Data.Block.ID != "block" || result.Data.Block.Timestamp != 1500000000 {
+		t.Errorf("unexpected response %d: %+v (%v)", resp.StatusCode, result, err)
+	}
+
+	resp, err = http.Get(server.URL + "?query=%7B%20unknown%20%7D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected an invalid query to be refused, got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,102 @@
+package indexer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/nbh-digital/goldchain/pkg/postgres"
+)
+
+// migrations are the schema migrations, migration i upgrading the schema to version i+1.
+// Existing migrations are never modified, changes are made by appending a migration.
+var migrations = []string{
+	// 1: the normalized chain data
+	`CREATE TABLE indexer_state (
+	id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+	seq BIGINT NOT NULL
+);
+INSERT INTO indexer_state (seq) VALUES (0);
+CREATE TABLE blocks (
+	id TEXT PRIMARY KEY,
+	seq BIGINT NOT NULL,
+	height BIGINT NOT NULL UNIQUE,
+	timestamp TIMESTAMPTZ NOT NULL
+);
+CREATE TABLE transactions (
+	id TEXT PRIMARY KEY,
+	seq BIGINT NOT NULL,
+	block_id TEXT NOT NULL REFERENCES blocks (id),
+	height BIGINT NOT NULL
+);
+CREATE INDEX transactions_height ON transactions (height);
+CREATE TABLE outputs (
+	id TEXT PRIMARY KEY,
+	seq BIGINT NOT NULL,
+	block_id TEXT NOT NULL REFERENCES blocks (id),
+	height BIGINT NOT NULL,
+	transaction_id TEXT REFERENCES transactions (id),
+	address TEXT NOT NULL,
+	value NUMERIC(78, 0) NOT NULL,
+	condition JSONB NOT NULL,
+	spent_transaction_id TEXT REFERENCES transactions (id),
+	spent_height BIGINT
+);
+CREATE INDEX outputs_address ON outputs (address);
+CREATE INDEX outputs_transaction_id ON outputs (transaction_id);
+CREATE INDEX outputs_spent_transaction_id ON outputs (spent_transaction_id);
+CREATE TABLE auth_events (
+	seq BIGINT PRIMARY KEY,
+	block_id TEXT NOT NULL REFERENCES blocks (id),
+	height BIGINT NOT NULL,
+	transaction_id TEXT NOT NULL REFERENCES transactions (id),
+	address TEXT NOT NULL,
+	authorized BOOLEAN NOT NULL
+);
+CREATE INDEX auth_events_address ON auth_events (address);
+CREATE TABLE balances (
+	address TEXT PRIMARY KEY,
+	confirmed NUMERIC(78, 0) NOT NULL
+)`,
+	// 2: the current authorization state of every address
+	`CREATE VIEW auth_state AS
+	SELECT DISTINCT ON (address) address, authorized, height
+	FROM auth_events
+	ORDER BY address, seq DESC`,
+}
+
+// SchemaVersion is the version of the schema created by Migrate.
+var SchemaVersion = len(migrations)
+
+// Migrate upgrades the schema of the database to the SchemaVersion,
+// applying every pending migration in its own transaction.
+// The versions of the applied migrations are stored in the schema_migrations table.
+func Migrate(db DB) error {
+	err := db.Exec(postgres.Statement{Query: `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version INTEGER PRIMARY KEY,
+	applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`})
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %v", err)
+	}
+	rows, err := db.Query("SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 1 || rows[0][0] == nil {
+		return fmt.Errorf("unexpected schema version: %v", rows)
+	}
+	version, err := strconv.Atoi(*rows[0][0])
+	if err != nil {
+		return fmt.Errorf("invalid schema version %q", *rows[0][0])
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("the schema version %d of the database is newer than the version %d of this indexer", version, SchemaVersion)
+	}
+	for ; version < SchemaVersion; version++ {
+		err = db.Exec(postgres.Statement{Query: migrations[version]}, statement("INSERT INTO schema_migrations (version) VALUES ($1)", version+1))
+		if err != nil {
+			return fmt.Errorf("failed to migrate the schema to version %d: %v", version+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// LabelsFile is the name of the file in which the labels of the ledger accounts are stored.
+	LabelsFile = "ledgerlabels.json"
+	// MaxLabelLength is the maximum length of a label.
+	MaxLabelLength = 64
+)
+
+var (
+	// ErrInvalidLabel is returned in case a label is too long, or clashes with a system account.
+	ErrInvalidLabel = fmt.Errorf("a label can be at most %d characters long, and cannot be the name of a system account", MaxLabelLength)
+	// ErrNilLabelAddress is returned in case a label is given to the nil address.
+	ErrNilLabelAddress = errors.New("the nil address cannot be labeled")
+)
+
+var labelsMetadata = persist.Metadata{
+	Header:  "Goldchain Ledger Labels",
+	Version: "1.0",
+}
+
+// AddressLabel labels an address as (being part of) an entity,
+// such as an exchange, custodian or the issuer itself.
+type AddressLabel struct {
+	Address types.UnlockHash `json:"address"`
+	Label   string           `json:"label"`
+}
+
+// Labels keeps the labels of the address accounts of the ledger on disk.
+// Multiple addresses can share the same label, in order to account them as a single entity.
+type Labels struct {
+	mu       sync.Mutex
+	filename string
+	labels   map[types.UnlockHash]string
+}
+
+// NewLabels creates the labels of the ledger accounts, persisted in the given file.
+func NewLabels(filename string) (*Labels, error) {
+	l := &Labels{
+		filename: filename,
+		labels:   make(map[types.UnlockHash]string),
+	}
+	var list []AddressLabel
+	err := persist.LoadJSON(labelsMetadata, &list, filename)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ledger labels: %v", err)
+	}
+	for _, al := range list {
+		l.labels[al.Address] = al.Label
+	}
+	return l, nil
+}
+
+// Labels returns all labeled addresses, sorted by label.
+func (l *Labels) Labels() []AddressLabel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]AddressLabel, 0, len(l.labels))
+	for address, label := range l.labels {
+		list = append(list, AddressLabel{Address: address, Label: label})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Label != list[j].Label {
+			return list[i].Label < list[j].Label
+		}
+		return list[i].Address.Cmp(list[j].Address) < 0
+	})
+	return list
+}
+
+// AccountLabels returns the labels mapped by the accounts of the labeled addresses.
+func (l *Labels) AccountLabels() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	labels := make(map[string]string, len(l.labels))
+	for address, label := range l.labels {
+		labels[address.String()] = label
+	}
+	return labels
+}
+
+// SetLabel labels the given address, an empty label removes the label of the address.
+func (l *Labels) SetLabel(address types.UnlockHash, label string) error {
+	if address.Type == types.UnlockTypeNil {
+		return ErrNilLabelAddress
+	}
+	switch label {
+	case AccountIssuance, AccountBurned, AccountFees, AccountRewards:
+		return ErrInvalidLabel
+	}
+	if len(label) > MaxLabelLength {
+		return ErrInvalidLabel
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous, ok := l.labels[address]
+	if label == "" {
+		delete(l.labels, address)
+	} else {
+		l.labels[address] = label
+	}
+	err := l.save()
+	if err != nil {
+		// restore the label as it was
+		if ok {
+			l.labels[address] = previous
+		} else {
+			delete(l.labels, address)
+		}
+		return err
+	}
+	return nil
+}
+
+// save writes the labels, the lock has to be held by the caller.
+func (l *Labels) save() error {
+	list := make([]AddressLabel, 0, len(l.labels))
+	for address, label := range l.labels {
+		list = append(list, AddressLabel{Address: address, Label: label})
+	}
+	err := persist.SaveJSON(labelsMetadata, list, l.filename)
+	if err != nil {
+		return fmt.Errorf("failed to save ledger labels: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,659 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "LedgerPlugin"
+)
+
+// System accounts, next to an account per address, used to balance the journal entries.
+const (
+	// AccountIssuance is credited with all coins created, be it in the genesis block or minted afterwards
+	AccountIssuance = "issuance"
+	// AccountBurned is debited with all coins destroyed
+	AccountBurned = "burned"
+	// AccountFees is debited with the miner fees of transactions,
+	// and credited once these fees are paid out as part of the block they are included in
+	AccountFees = "fees"
+	// AccountRewards is credited with the coins created to reward block creators
+	AccountRewards = "rewards"
+)
+
+// EntryKind is the kind of a journal entry.
+type EntryKind string
+
+// All kinds of journal entries.
+const (
+	// EntryKindTransfer is a transaction transferring coins between addresses, paying fees
+	EntryKindTransfer EntryKind = "transfer"
+	// EntryKindMint is a transaction creating coins, such as the transactions of the genesis block
+	EntryKindMint EntryKind = "mint"
+	// EntryKindBurn is a transaction destroying coins
+	EntryKindBurn EntryKind = "burn"
+	// EntryKindReward is the payout of the block creator reward and the fees of all transactions of a block
+	EntryKindReward EntryKind = "reward"
+)
+
+var (
+	// bucketOutputs maps the IDs of all coin outputs ever created to the outputs,
+	// such that the owner and value of spent outputs are known
+	bucketOutputs = []byte("outputs")
+	// bucketJournal maps the block height and sequence number of all journal entries to the entries
+	bucketJournal = []byte("journal")
+	// bucketAccounts contains a bucket per account, mapping the block heights at which the account changed,
+	// to the total debits and credits of the account after that block
+	bucketAccounts = []byte("accounts")
+)
+
+type (
+	// Plugin is a consensus set plugin, deriving a double-entry ledger of all coins from the blockchain.
+	// Each account is either an address or one of the system accounts,
+	// blockstakes are not part of the ledger.
+	Plugin struct {
+		genesis            types.Block
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// Posting is a single line of a journal entry,
+	// of which either the debit or credit is non-zero.
+	// Debiting an address account increases its balance, crediting it decreases its balance.
+	Posting struct {
+		Account string         `json:"account"`
+		Label   string         `json:"label,omitempty"`
+		Debit   types.Currency `json:"debit"`
+		Credit  types.Currency `json:"credit"`
+	}
+
+	// Entry is a journal entry, of which the debits and credits of all postings are balanced.
+	Entry struct {
+		Height    types.BlockHeight `json:"height"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		BlockID   types.BlockID     `json:"blockid"`
+		// TransactionID is undefined for reward entries
+		TransactionID types.TransactionID `json:"transactionid"`
+		Kind          EntryKind           `json:"kind"`
+		Postings      []Posting           `json:"postings"`
+	}
+
+	// AccountBalance contains the total debits and credits of an account,
+	// as well as the resulting balance, of which at most one side is non-zero.
+	AccountBalance struct {
+		Account       string         `json:"account"`
+		Label         string         `json:"label,omitempty"`
+		Debits        types.Currency `json:"debits"`
+		Credits       types.Currency `json:"credits"`
+		DebitBalance  types.Currency `json:"debitbalance"`
+		CreditBalance types.Currency `json:"creditbalance"`
+	}
+
+	// TrialBalance contains the balances of all accounts at a given height,
+	// the total debit balance always equals the total credit balance.
+	TrialBalance struct {
+		Height             types.BlockHeight `json:"height"`
+		Accounts           []AccountBalance  `json:"accounts"`
+		TotalDebitBalance  types.Currency    `json:"totaldebitbalance"`
+		TotalCreditBalance types.Currency    `json:"totalcreditbalance"`
+	}
+
+	// accountTotals are the total debits and credits of an account, as stored per height.
+	accountTotals struct {
+		Debits  types.Currency
+		Credits types.Currency
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new ledger plugin, for the chain starting with the given genesis block.
+func NewPlugin(genesis types.Block) *Plugin {
+	return &Plugin{genesis: genesis}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time,
+// applying the genesis block while the bucket is still writable.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketOutputs, bucketJournal, bucketAccounts} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		err := p.applyBlock(p.genesis, 0, persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+			return bucket, nil
+		}))
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to apply genesis block: %v", err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock journals all transactions and the miner payouts of the block,
+// except for the genesis block, which is applied when the plugin is initialized.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	return p.applyBlock(block, height, bucket)
+}
+
+// applyBlock journals all transactions and the miner payouts of the block.
+func (p *Plugin) applyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if len(block.Transactions) == 0 {
+		return p.applyMinerPayouts(block, height, bucket)
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction journals the coin inputs, outputs and miner fees of the transaction.
+// As the consensus set only applies the transactions of newly created blocks,
+// the miner payouts of the block are journaled together with its last transaction.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	var postings postingSet
+	var spent, created types.Currency
+	for _, ci := range txn.CoinInputs {
+		b := outputsBucket.Get(rivbin.Marshal(ci.ParentID))
+		if len(b) == 0 {
+			return fmt.Errorf("spent coin output %s is unknown", ci.ParentID.String())
+		}
+		var co types.CoinOutput
+		err = rivbin.Unmarshal(b, &co)
+		if err != nil {
+			return fmt.Errorf("failed to decode coin output %s: %v", ci.ParentID.String(), err)
+		}
+		postings.credit(co.Condition.UnlockHash().String(), co.Value)
+		spent = spent.Add(co.Value)
+	}
+	for index, co := range txn.CoinOutputs {
+		err = outputsBucket.Put(rivbin.Marshal(txn.CoinOutputID(uint64(index))), rivbin.Marshal(co))
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store coin output: %v", err)
+		}
+		postings.debit(co.Condition.UnlockHash().String(), co.Value)
+		created = created.Add(co.Value)
+	}
+	for _, fee := range txn.MinerFees {
+		postings.debit(AccountFees, fee)
+		created = created.Add(fee)
+	}
+	kind := EntryKindTransfer
+	switch c := created.Cmp(spent); {
+	case c > 0:
+		kind = EntryKindMint
+		postings.credit(AccountIssuance, created.Sub(spent))
+	case c < 0:
+		kind = EntryKindBurn
+		postings.debit(AccountBurned, spent.Sub(created))
+	}
+	err = p.journal(Entry{
+		Height:        height,
+		Timestamp:     block.Timestamp,
+		BlockID:       block.ID(),
+		TransactionID: txn.ID(),
+		Kind:          kind,
+		Postings:      postings.net(),
+	}, bucket)
+	if err != nil {
+		return err
+	}
+	if n := len(block.Transactions); n > 0 && block.Transactions[n-1].ID() == txn.ID() {
+		return p.applyMinerPayouts(block, height, bucket)
+	}
+	return nil
+}
+
+// applyMinerPayouts journals the miner payouts of the block,
+// paying out the fees of its transactions, and rewarding the block creator with newly created coins.
+func (p *Plugin) applyMinerPayouts(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	var postings postingSet
+	var payouts types.Currency
+	for index, mp := range block.MinerPayouts {
+		err = outputsBucket.Put(rivbin.Marshal(block.MinerPayoutID(uint64(index))), rivbin.Marshal(types.CoinOutput{
+			Value:     mp.Value,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(mp.UnlockHash)),
+		}))
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store miner payout: %v", err)
+		}
+		postings.debit(mp.UnlockHash.String(), mp.Value)
+		payouts = payouts.Add(mp.Value)
+	}
+	var fees types.Currency
+	for _, txn := range block.Transactions {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	if fees.Cmp(payouts) > 0 {
+		fees = payouts
+	}
+	postings.credit(AccountFees, fees)
+	postings.credit(AccountRewards, payouts.Sub(fees))
+	return p.journal(Entry{
+		Height:    height,
+		Timestamp: block.Timestamp,
+		BlockID:   block.ID(),
+		Kind:      EntryKindReward,
+		Postings:  postings.net(),
+	}, bucket)
+}
+
+// journal stores the entry as the next entry at its height,
+// and adds its postings to the totals of the accounts involved.
+// Entries without postings are not stored.
+func (p *Plugin) journal(entry Entry, bucket *persist.LazyBoltBucket) error {
+	if len(entry.Postings) == 0 {
+		return nil
+	}
+	journalBucket, err := bucket.Bucket(bucketJournal)
+	if err != nil {
+		return errors.New("journal bucket does not exist")
+	}
+	accountsBucket, err := bucket.Bucket(bucketAccounts)
+	if err != nil {
+		return errors.New("accounts bucket does not exist")
+	}
+	var sequence uint32
+	cursor := journalBucket.Cursor()
+	k, _ := cursor.Seek(encodeBlockHeight(entry.Height + 1))
+	if k == nil {
+		k, _ = cursor.Last()
+	} else {
+		k, _ = cursor.Prev()
+	}
+	if k != nil && decodeBlockHeight(k) == entry.Height {
+		sequence = binary.BigEndian.Uint32(k[8:]) + 1
+	}
+	err = journalBucket.Put(encodeEntryKey(entry.Height, sequence), rivbin.Marshal(entry))
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store journal entry: %v", err)
+	}
+	for _, posting := range entry.Postings {
+		accountBucket, err := accountsBucket.CreateBucketIfNotExists([]byte(posting.Account))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket for account %s: %v", posting.Account, err)
+		}
+		totals, err := totalsAt(accountBucket, entry.Height)
+		if err != nil {
+			return err
+		}
+		totals.Debits = totals.Debits.Add(posting.Debit)
+		totals.Credits = totals.Credits.Add(posting.Credit)
+		err = accountBucket.Put(encodeBlockHeight(entry.Height), rivbin.Marshal(totals))
+		if err != nil {
+			return fmt.Errorf("failed to store totals of account %s at height %d: %v", posting.Account, entry.Height, err)
+		}
+	}
+	return nil
+}
+
+// RevertBlock removes all journal entries of the block,
+// the genesis block is never reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	journalBucket, err := bucket.Bucket(bucketJournal)
+	if err != nil {
+		return errors.New("journal bucket does not exist")
+	}
+	accountsBucket, err := bucket.Bucket(bucketAccounts)
+	if err != nil {
+		return errors.New("accounts bucket does not exist")
+	}
+	// collect the entries first, as keys cannot be deleted while iterating
+	var keys [][]byte
+	accounts := make(map[string]struct{})
+	cursor := journalBucket.Cursor()
+	for k, v := cursor.Seek(encodeBlockHeight(height)); k != nil && decodeBlockHeight(k) == height; k, v = cursor.Next() {
+		var entry Entry
+		err = rivbin.Unmarshal(v, &entry)
+		if err != nil {
+			return fmt.Errorf("failed to decode journal entry: %v", err)
+		}
+		for _, posting := range entry.Postings {
+			accounts[posting.Account] = struct{}{}
+		}
+		keys = append(keys, append([]byte(nil), k...))
+	}
+	for _, k := range keys {
+		err = journalBucket.Delete(k)
+		if err != nil {
+			return fmt.Errorf("failed to delete journal entry: %v", err)
+		}
+	}
+	// the totals after the previous change of an account are its totals once more
+	for account := range accounts {
+		accountBucket := accountsBucket.Bucket([]byte(account))
+		if accountBucket == nil {
+			continue
+		}
+		err = accountBucket.Delete(encodeBlockHeight(height))
+		if err != nil {
+			return fmt.Errorf("failed to delete totals of account %s at height %d: %v", account, height, err)
+		}
+	}
+	for _, txn := range block.Transactions {
+		for index := range txn.CoinOutputs {
+			err = outputsBucket.Delete(rivbin.Marshal(txn.CoinOutputID(uint64(index))))
+			if err != nil {
+				return fmt.Errorf("failed to delete coin output: %v", err)
+			}
+		}
+	}
+	for index := range block.MinerPayouts {
+		err = outputsBucket.Delete(rivbin.Marshal(block.MinerPayoutID(uint64(index))))
+		if err != nil {
+			return fmt.Errorf("failed to delete miner payout: %v", err)
+		}
+	}
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are only ever reverted as part of their block, see RevertBlock.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetJournal returns all journal entries of the blocks within the given inclusive range of heights,
+// in the order they were applied.
+func (p *Plugin) GetJournal(start, end types.BlockHeight) ([]Entry, error) {
+	var entries []Entry
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		journalBucket := bucket.Bucket(bucketJournal)
+		if journalBucket == nil {
+			return errors.New("journal bucket does not exist")
+		}
+		cursor := journalBucket.Cursor()
+		for k, v := cursor.Seek(encodeBlockHeight(start)); k != nil && decodeBlockHeight(k) <= end; k, v = cursor.Next() {
+			var entry Entry
+			err := rivbin.Unmarshal(v, &entry)
+			if err != nil {
+				return fmt.Errorf("failed to decode journal entry: %v", err)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetTrialBalanceAt returns the balances of all accounts as they were after the block at the given height,
+// sorted by account.
+func (p *Plugin) GetTrialBalanceAt(height types.BlockHeight) (TrialBalance, error) {
+	tb := TrialBalance{Height: height}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		accountsBucket := bucket.Bucket(bucketAccounts)
+		if accountsBucket == nil {
+			return errors.New("accounts bucket does not exist")
+		}
+		return accountsBucket.ForEach(func(k, _ []byte) error {
+			accountBucket := accountsBucket.Bucket(k)
+			if accountBucket == nil {
+				return nil
+			}
+			totals, err := totalsAt(accountBucket, height)
+			if err != nil || (totals.Debits.IsZero() && totals.Credits.IsZero()) {
+				return err
+			}
+			tb.Accounts = append(tb.Accounts, AccountBalance{
+				Account: string(k),
+				Debits:  totals.Debits,
+				Credits: totals.Credits,
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return TrialBalance{}, err
+	}
+	tb.balance()
+	return tb, nil
+}
+
+// GetAccountBalanceAt returns the balance of the given account as it was after the block at the given height,
+// an address account being named after the address.
+// Accounts which did not change yet at that height have a zero balance.
+func (p *Plugin) GetAccountBalanceAt(account string, height types.BlockHeight) (AccountBalance, error) {
+	ab := AccountBalance{Account: account}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		accountsBucket := bucket.Bucket(bucketAccounts)
+		if accountsBucket == nil {
+			return errors.New("accounts bucket does not exist")
+		}
+		accountBucket := accountsBucket.Bucket([]byte(account))
+		if accountBucket == nil {
+			return nil
+		}
+		totals, err := totalsAt(accountBucket, height)
+		ab.Debits, ab.Credits = totals.Debits, totals.Credits
+		return err
+	})
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	ab.balance()
+	return ab, nil
+}
+
+// GroupByLabel returns the trial balance with all accounts sharing the same label merged into a single account,
+// named after that label. Accounts without a label are kept as they are.
+func (tb TrialBalance) GroupByLabel(labels map[string]string) TrialBalance {
+	grouped := TrialBalance{Height: tb.Height}
+	indices := make(map[string]int)
+	for _, ab := range tb.Accounts {
+		label, ok := labels[ab.Account]
+		if !ok {
+			grouped.Accounts = append(grouped.Accounts, ab)
+			continue
+		}
+		index, ok := indices[label]
+		if !ok {
+			index = len(grouped.Accounts)
+			indices[label] = index
+			grouped.Accounts = append(grouped.Accounts, AccountBalance{Account: label, Label: label})
+		}
+		grouped.Accounts[index].Debits = grouped.Accounts[index].Debits.Add(ab.Debits)
+		grouped.Accounts[index].Credits = grouped.Accounts[index].Credits.Add(ab.Credits)
+	}
+	grouped.balance()
+	return grouped
+}
+
+// balance sorts the accounts, and computes the balance of each account as well as the totals.
+func (tb *TrialBalance) balance() {
+	sort.Slice(tb.Accounts, func(i, j int) bool {
+		return tb.Accounts[i].Account < tb.Accounts[j].Account
+	})
+	tb.TotalDebitBalance, tb.TotalCreditBalance = types.Currency{}, types.Currency{}
+	for i := range tb.Accounts {
+		ab := &tb.Accounts[i]
+		ab.balance()
+		tb.TotalDebitBalance = tb.TotalDebitBalance.Add(ab.DebitBalance)
+		tb.TotalCreditBalance = tb.TotalCreditBalance.Add(ab.CreditBalance)
+	}
+}
+
+// balance computes the balance of the account from its debits and credits.
+func (ab *AccountBalance) balance() {
+	ab.DebitBalance, ab.CreditBalance = types.Currency{}, types.Currency{}
+	if ab.Debits.Cmp(ab.Credits) > 0 {
+		ab.DebitBalance = ab.Debits.Sub(ab.Credits)
+	} else {
+		ab.CreditBalance = ab.Credits.Sub(ab.Debits)
+	}
+}
+
+// postingSet collects the debits and credits of accounts,
+// in the order the accounts are first used.
+type postingSet struct {
+	postings []Posting
+	indices  map[string]int
+}
+
+func (ps *postingSet) posting(account string) *Posting {
+	if ps.indices == nil {
+		ps.indices = make(map[string]int)
+	}
+	index, ok := ps.indices[account]
+	if !ok {
+		index = len(ps.postings)
+		ps.indices[account] = index
+		ps.postings = append(ps.postings, Posting{Account: account})
+	}
+	return &ps.postings[index]
+}
+
+func (ps *postingSet) debit(account string, value types.Currency) {
+	if value.IsZero() {
+		return
+	}
+	posting := ps.posting(account)
+	posting.Debit = posting.Debit.Add(value)
+}
+
+func (ps *postingSet) credit(account string, value types.Currency) {
+	if value.IsZero() {
+		return
+	}
+	posting := ps.posting(account)
+	posting.Credit = posting.Credit.Add(value)
+}
+
+// net returns a single posting per account, netting its debits and credits,
+// omitting the accounts of which the debits and credits cancel each other out.
+func (ps *postingSet) net() []Posting {
+	var postings []Posting
+	for _, posting := range ps.postings {
+		switch c := posting.Debit.Cmp(posting.Credit); {
+		case c > 0:
+			postings = append(postings, Posting{Account: posting.Account, Debit: posting.Debit.Sub(posting.Credit)})
+		case c < 0:
+			postings = append(postings, Posting{Account: posting.Account, Credit: posting.Credit.Sub(posting.Debit)})
+		}
+	}
+	return postings
+}
+
+// totalsAt returns the totals stored in the given account bucket,
+// as they were after the block at the given height.
+func totalsAt(accountBucket *bolt.Bucket, height types.BlockHeight) (accountTotals, error) {
+	cursor := accountBucket.Cursor()
+	k, v := cursor.Seek(encodeBlockHeight(height + 1))
+	if k == nil {
+		k, v = cursor.Last()
+	} else {
+		k, v = cursor.Prev()
+	}
+	if k == nil {
+		return accountTotals{}, nil
+	}
+	var totals accountTotals
+	err := rivbin.Unmarshal(v, &totals)
+	if err != nil {
+		return accountTotals{}, fmt.Errorf("failed to decode account totals: %v", err)
+	}
+	return totals, nil
+}
+
+func encodeBlockHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
+
+func decodeBlockHeight(b []byte) types.BlockHeight {
+	return types.BlockHeight(binary.BigEndian.Uint64(b[:8]))
+}
+
+func encodeEntryKey(height types.BlockHeight, sequence uint32) []byte {
+	b := make([]byte, 12)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	binary.BigEndian.PutUint32(b[8:], sequence)
+	return b
+}
@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginLedger(t *testing.T) {
+	db := plugintest.NewDB(t, "ledger")
+
+	uhA := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uhB := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	uhC := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{3})
+	genesis := types.Block{Transactions: []types.Transaction{{
+		CoinOutputs: []types.CoinOutput{
+			{Value: types.NewCurrency64(100), Condition: types.NewCondition(types.NewUnlockHashCondition(uhA))},
+		},
+	}}}
+
+	p := NewPlugin(genesis)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// expected balances are positive for debit balances, and negative for credit balances
+	expectTrialBalance := func(tb TrialBalance, expected map[string]int64) {
+		t.Helper()
+		if len(tb.Accounts) != len(expected) || tb.TotalDebitBalance.Cmp(tb.TotalCreditBalance) != 0 {
+			t.Fatalf("unexpected trial balance at height %d: %+v", tb.Height, tb)
+		}
+		for _, ab := range tb.Accounts {
+			balance, ok := expected[ab.Account]
+			if !ok ||
+				(balance >= 0 && (!ab.DebitBalance.Equals64(uint64(balance)) || !ab.CreditBalance.IsZero())) ||
+				(balance < 0 && (!ab.CreditBalance.Equals64(uint64(-balance)) || !ab.DebitBalance.IsZero())) {
+				t.Errorf("unexpected balance for %s at height %d: %+v", ab.Account, tb.Height, ab)
+			}
+		}
+	}
+	trialBalanceAt := func(height types.BlockHeight) TrialBalance {
+		t.Helper()
+		tb, err := p.GetTrialBalanceAt(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tb
+	}
+
+	// the genesis block is applied when initializing the plugin,
+	// such that applying it once more is a no-op
+	genesisBalance := map[string]int64{uhA.String(): 100, AccountIssuance: -100}
+	expectTrialBalance(trialBalanceAt(0), genesisBalance)
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(genesis, 0, bucket)
+	})
+	expectTrialBalance(trialBalanceAt(0), genesisBalance)
+
+	// transfer 30 coins from A to B, paying a fee of 1 coin to block creator C,
+	// who is rewarded with 10 newly created coins as well
+	block := types.Block{
+		MinerPayouts: []types.MinerPayout{{Value: types.NewCurrency64(11), UnlockHash: uhC}},
+		Transactions: []types.Transaction{{
+			CoinInputs: []types.CoinInput{{ParentID: genesis.Transactions[0].CoinOutputID(0)}},
+			CoinOutputs: []types.CoinOutput{
+				{Value: types.NewCurrency64(30), Condition: types.NewCondition(types.NewUnlockHashCondition(uhB))},
+				{Value: types.NewCurrency64(69), Condition: types.NewCondition(types.NewUnlockHashCondition(uhA))},
+			},
+			MinerFees: []types.Currency{types.NewCurrency64(1)},
+		}},
+	}
+	// blocks replayed using a read-only transaction cannot be applied
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	if err != pluginstats.ErrCatchUpUnsupported {
+		t.Fatalf("expected %v, got %v", pluginstats.ErrCatchUpUnsupported, err)
+	}
+	// new blocks are applied transaction per transaction
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyTransaction(block.Transactions[0], block, 1, bucket)
+	})
+	expectTrialBalance(trialBalanceAt(0), genesisBalance)
+	expectTrialBalance(trialBalanceAt(1), map[string]int64{
+		uhA.String(): 69, uhB.String(): 30, uhC.String(): 11,
+		AccountIssuance: -100, AccountRewards: -10, AccountFees: 0,
+	})
+	expectTrialBalance(trialBalanceAt(1).GroupByLabel(map[string]string{uhA.String(): "acme", uhB.String(): "acme"}), map[string]int64{
+		"acme": 99, uhC.String(): 11,
+		AccountIssuance: -100, AccountRewards: -10, AccountFees: 0,
+	})
+
+	for height, expected := range []uint64{0, 11} {
+		ab, err := p.GetAccountBalanceAt(uhC.String(), types.BlockHeight(height))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ab.DebitBalance.Equals64(expected) || !ab.Debits.Equals64(expected) || !ab.Credits.IsZero() {
+			t.Errorf("unexpected balance of %s at height %d: %+v", uhC.String(), height, ab)
+		}
+	}
+
+	entries, err := p.GetJournal(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Kind != EntryKindMint || entries[1].Kind != EntryKindTransfer || entries[2].Kind != EntryKindReward {
+		t.Fatalf("unexpected journal: %+v", entries)
+	}
+	if entries[1].TransactionID != block.Transactions[0].ID() || len(entries[1].Postings) != 3 {
+		t.Errorf("unexpected transfer entry: %+v", entries[1])
+	}
+
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block, 1, bucket)
+	})
+	expectTrialBalance(trialBalanceAt(1), genesisBalance)
+	entries, err = p.GetJournal(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no entries at height 1 after reverting, got %+v", entries)
+	}
+
+	// reapplying the block as a whole results in the same journal
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	entries, err = p.GetJournal(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("unexpected journal at height 1: %+v", entries)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), LabelsFile)
+	labels, err := NewLabels(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uhA := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uhB := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	for _, uh := range []types.UnlockHash{uhA, uhB} {
+		if err = labels.SetLabel(uh, "acme"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = labels.SetLabel(uhA, AccountFees); err != ErrInvalidLabel {
+		t.Errorf("expected %v, got %v", ErrInvalidLabel, err)
+	}
+	if err = labels.SetLabel(types.NilUnlockHash, "nil"); err != ErrNilLabelAddress {
+		t.Errorf("expected %v, got %v", ErrNilLabelAddress, err)
+	}
+	if err = labels.SetLabel(uhB, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// labels are persisted
+	labels, err = NewLabels(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := labels.Labels()
+	if len(list) != 1 || list[0].Address.Cmp(uhA) != 0 || list[0].Label != "acme" {
+		t.Errorf("unexpected labels: %v", list)
+	}
+	if accountLabels := labels.AccountLabels(); len(accountLabels) != 1 || accountLabels[uhA.String()] != "acme" {
+		t.Errorf("unexpected account labels: %v", accountLabels)
+	}
+}
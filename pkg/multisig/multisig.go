@@ -0,0 +1,223 @@
+package multisig
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrTransactionMismatch is returned when merging the signatures of transactions
+	// which differ in anything but their input fulfillments.
+	ErrTransactionMismatch = errors.New("transactions differ in more than their signatures")
+)
+
+type (
+	// OutputGetter is used to look up the outputs spent by a transaction,
+	// implemented by the consensus set.
+	OutputGetter interface {
+		GetCoinOutput(types.CoinOutputID) (types.CoinOutput, error)
+		GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error)
+	}
+
+	// InputStatus contains the signatures collected for a single input of a transaction.
+	InputStatus struct {
+		ParentID crypto.Hash `json:"parentid"`
+		// Signers contains the addresses which provided a valid signature for the input
+		Signers []types.UnlockHash `json:"signers"`
+		// Required is the minimum amount of signatures required to spend the input,
+		// 1 for inputs not spending a multisig output
+		Required uint64 `json:"required"`
+	}
+
+	// Status contains the signatures collected for all inputs of a transaction.
+	Status struct {
+		CoinInputs       []InputStatus `json:"coininputs"`
+		BlockStakeInputs []InputStatus `json:"blockstakeinputs"`
+		// Complete is true once all inputs have the required amount of signatures
+		Complete bool `json:"complete"`
+	}
+)
+
+// ProposalID returns the ID of the given transaction with all input fulfillments removed,
+// such that all (partially) signed versions of the same transaction share the same ID.
+func ProposalID(txn types.Transaction) types.TransactionID {
+	return unsignedTransaction(txn).ID()
+}
+
+// unsignedTransaction returns a copy of the given transaction with all input fulfillments removed.
+func unsignedTransaction(txn types.Transaction) types.Transaction {
+	unsigned := txn
+	unsigned.CoinInputs = make([]types.CoinInput, len(txn.CoinInputs))
+	for i, ci := range txn.CoinInputs {
+		unsigned.CoinInputs[i] = types.CoinInput{ParentID: ci.ParentID}
+	}
+	unsigned.BlockStakeInputs = make([]types.BlockStakeInput, len(txn.BlockStakeInputs))
+	for i, bsi := range txn.BlockStakeInputs {
+		unsigned.BlockStakeInputs[i] = types.BlockStakeInput{ParentID: bsi.ParentID}
+	}
+	return unsigned
+}
+
+// GetStatus returns the signatures collected for all inputs of the given transaction,
+// only counting valid signatures of authorized keys.
+func GetStatus(txn types.Transaction, getter OutputGetter) (Status, error) {
+	status := Status{Complete: true}
+	for index, ci := range txn.CoinInputs {
+		co, err := getter.GetCoinOutput(ci.ParentID)
+		if err != nil {
+			return Status{}, fmt.Errorf("failed to get coin output %s: %v", ci.ParentID.String(), err)
+		}
+		signers, required := validSigners(txn, uint64(index), co.Condition, ci.Fulfillment)
+		status.CoinInputs = append(status.CoinInputs, InputStatus{
+			ParentID: crypto.Hash(ci.ParentID),
+			Signers:  unlockHashes(signers),
+			Required: required,
+		})
+		status.Complete = status.Complete && uint64(len(signers)) >= required
+	}
+	for index, bsi := range txn.BlockStakeInputs {
+		bso, err := getter.GetBlockStakeOutput(bsi.ParentID)
+		if err != nil {
+			return Status{}, fmt.Errorf("failed to get blockstake output %s: %v", bsi.ParentID.String(), err)
+		}
+		signers, required := validSigners(txn, uint64(index), bso.Condition, bsi.Fulfillment)
+		status.BlockStakeInputs = append(status.BlockStakeInputs, InputStatus{
+			ParentID: crypto.Hash(bsi.ParentID),
+			Signers:  unlockHashes(signers),
+			Required: required,
+		})
+		status.Complete = status.Complete && uint64(len(signers)) >= required
+	}
+	return status, nil
+}
+
+// MergeSignatures adds the valid signatures of src missing in dst to dst,
+// returning the amount of signatures added.
+// Invalid and duplicate signatures are removed from dst,
+// such that the merged transaction is valid once enough signatures are collected.
+func MergeSignatures(dst *types.Transaction, src types.Transaction, getter OutputGetter) (int, error) {
+	if ProposalID(*dst) != ProposalID(src) {
+		return 0, ErrTransactionMismatch
+	}
+	var added int
+	for index := range dst.CoinInputs {
+		ci := &dst.CoinInputs[index]
+		co, err := getter.GetCoinOutput(ci.ParentID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get coin output %s: %v", ci.ParentID.String(), err)
+		}
+		added += mergeFulfillment(*dst, uint64(index), co.Condition, &ci.Fulfillment, src.CoinInputs[index].Fulfillment)
+	}
+	for index := range dst.BlockStakeInputs {
+		bsi := &dst.BlockStakeInputs[index]
+		bso, err := getter.GetBlockStakeOutput(bsi.ParentID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get blockstake output %s: %v", bsi.ParentID.String(), err)
+		}
+		added += mergeFulfillment(*dst, uint64(index), bso.Condition, &bsi.Fulfillment, src.BlockStakeInputs[index].Fulfillment)
+	}
+	return added, nil
+}
+
+// signer is an address which provided a valid signature for an input.
+type signer struct {
+	UnlockHash types.UnlockHash
+	Pair       types.PublicKeySignaturePair
+}
+
+// mergeFulfillment merges the valid signatures of the src fulfillment into the dst fulfillment,
+// returning the amount of signatures added.
+func mergeFulfillment(txn types.Transaction, index uint64, condition types.UnlockConditionProxy, dst *types.UnlockFulfillmentProxy, src types.UnlockFulfillmentProxy) int {
+	dstSigners, _ := validSigners(txn, index, condition, *dst)
+	srcSigners, _ := validSigners(txn, index, condition, src)
+	if _, ok := innerCondition(condition).(*types.MultiSignatureCondition); !ok {
+		// inputs not spending a multisig output are either signed or not
+		if len(dstSigners) < len(srcSigners) {
+			*dst = src
+			return 1
+		}
+		return 0
+	}
+	var added int
+	known := make(map[types.UnlockHash]struct{}, len(dstSigners))
+	for _, s := range dstSigners {
+		known[s.UnlockHash] = struct{}{}
+	}
+	for _, s := range srcSigners {
+		if _, ok := known[s.UnlockHash]; ok {
+			continue
+		}
+		known[s.UnlockHash] = struct{}{}
+		dstSigners = append(dstSigners, s)
+		added++
+	}
+	pairs := make([]types.PublicKeySignaturePair, 0, len(dstSigners))
+	for _, s := range dstSigners {
+		pairs = append(pairs, s.Pair)
+	}
+	dst.Fulfillment = types.NewMultiSignatureFulfillment(pairs)
+	return added
+}
+
+// validSigners returns the (unique) signers of the given fulfillment for the input at the given index,
+// as well as the amount of signatures required to spend the output locked by the given condition.
+// Time locks are ignored, as they do not define who can sign for an output.
+func validSigners(txn types.Transaction, index uint64, condition types.UnlockConditionProxy, fulfillment types.UnlockFulfillmentProxy) ([]signer, uint64) {
+	ctx := types.FulfillContext{
+		ExtraObjects: []interface{}{index},
+		Transaction:  txn,
+	}
+	inner := innerCondition(condition)
+	ms, ok := inner.(*types.MultiSignatureCondition)
+	if !ok {
+		if fulfillment.Fulfillment == nil || inner.Fulfill(fulfillment.Fulfillment, ctx) != nil {
+			return nil, 1
+		}
+		return []signer{{UnlockHash: condition.UnlockHash()}}, 1
+	}
+	msf, ok := fulfillment.Fulfillment.(*types.MultiSignatureFulfillment)
+	if !ok {
+		return nil, ms.MinimumSignatureCount
+	}
+	// verify each signature on its own, using a 1-of-n condition for the same keys
+	single := types.NewMultiSignatureCondition(ms.UnlockHashes, 1)
+	var signers []signer
+	seen := make(map[types.UnlockHash]struct{}, len(msf.Pairs))
+	for _, pair := range msf.Pairs {
+		uh := types.NewPubKeyUnlockHash(pair.PublicKey)
+		if _, ok := seen[uh]; ok {
+			continue
+		}
+		err := single.Fulfill(types.NewMultiSignatureFulfillment([]types.PublicKeySignaturePair{pair}), ctx)
+		if err != nil {
+			continue
+		}
+		seen[uh] = struct{}{}
+		signers = append(signers, signer{UnlockHash: uh, Pair: pair})
+	}
+	return signers, ms.MinimumSignatureCount
+}
+
+// innerCondition returns the condition wrapped by a time lock condition,
+// or the condition itself if it isn't time-locked.
+func innerCondition(condition types.UnlockConditionProxy) types.MarshalableUnlockCondition {
+	switch c := condition.Condition.(type) {
+	case nil:
+		return &types.NilCondition{}
+	case *types.TimeLockCondition:
+		return c.Condition
+	default:
+		return c
+	}
+}
+
+func unlockHashes(signers []signer) []types.UnlockHash {
+	uhs := make([]types.UnlockHash, 0, len(signers))
+	for _, s := range signers {
+		uhs = append(uhs, s.UnlockHash)
+	}
+	return uhs
+}
@@ -0,0 +1,118 @@
+package multisig
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testOutputGetter map[types.CoinOutputID]types.CoinOutput
+
+func (getter testOutputGetter) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	co, ok := getter[id]
+	if !ok {
+		return types.CoinOutput{}, errors.New("unknown coin output")
+	}
+	return co, nil
+}
+
+func (getter testOutputGetter) GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	return types.BlockStakeOutput{}, errors.New("unknown blockstake output")
+}
+
+func TestMergeSignatures(t *testing.T) {
+	keys := make([]types.KeyPair, 3)
+	uhs := make(types.UnlockHashSlice, 3)
+	for i := range keys {
+		sk, pk := crypto.GenerateKeyPair()
+		keys[i] = types.KeyPair{
+			PublicKey:  types.Ed25519PublicKey(pk),
+			PrivateKey: types.ByteSlice(sk[:]),
+		}
+		uhs[i] = types.NewPubKeyUnlockHash(keys[i].PublicKey)
+	}
+	parentID := types.CoinOutputID{1}
+	getter := testOutputGetter{parentID: {
+		Value:     types.NewCurrency64(100),
+		Condition: types.NewCondition(types.NewMultiSignatureCondition(uhs, 2)),
+	}}
+	unsigned := types.Transaction{
+		Version:    types.TransactionVersionOne,
+		CoinInputs: []types.CoinInput{{ParentID: parentID}},
+		CoinOutputs: []types.CoinOutput{{
+			Value:     types.NewCurrency64(99),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(uhs[0])),
+		}},
+		MinerFees: []types.Currency{types.NewCurrency64(1)},
+	}
+	sign := func(key types.KeyPair) types.Transaction {
+		txn := unsigned
+		fulfillment := types.NewMultiSignatureFulfillment(nil)
+		err := fulfillment.Sign(types.FulfillmentSignContext{
+			ExtraObjects: []interface{}{uint64(0)},
+			Transaction:  unsigned,
+			Key:          key,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		txn.CoinInputs = []types.CoinInput{{ParentID: parentID, Fulfillment: types.NewFulfillment(fulfillment)}}
+		return txn
+	}
+
+	txn := sign(keys[0])
+	status, err := GetStatus(txn, getter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Complete || len(status.CoinInputs[0].Signers) != 1 || status.CoinInputs[0].Required != 2 {
+		t.Fatalf("unexpected status after 1 signature: %+v", status)
+	}
+	if ProposalID(txn) != ProposalID(unsigned) {
+		t.Fatal("signing changed the proposal ID")
+	}
+
+	// merging the same signature twice adds nothing
+	added, err := MergeSignatures(&txn, sign(keys[0]), getter)
+	if err != nil || added != 0 {
+		t.Fatalf("expected no signatures to be added, added %d: %v", added, err)
+	}
+	// a signature of an unauthorized key is ignored
+	sk, pk := crypto.GenerateKeyPair()
+	added, err = MergeSignatures(&txn, sign(types.KeyPair{
+		PublicKey:  types.Ed25519PublicKey(pk),
+		PrivateKey: types.ByteSlice(sk[:]),
+	}), getter)
+	if err != nil || added != 0 {
+		t.Fatalf("expected no signatures to be added, added %d: %v", added, err)
+	}
+	added, err = MergeSignatures(&txn, sign(keys[2]), getter)
+	if err != nil || added != 1 {
+		t.Fatalf("expected 1 signature to be added, added %d: %v", added, err)
+	}
+	status, err = GetStatus(txn, getter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Complete || len(status.CoinInputs[0].Signers) != 2 {
+		t.Fatalf("unexpected status after 2 signatures: %+v", status)
+	}
+	// the merged fulfillment fulfills the multisig condition
+	err = getter[parentID].Condition.Fulfill(txn.CoinInputs[0].Fulfillment, types.FulfillContext{
+		ExtraObjects: []interface{}{uint64(0)},
+		Transaction:  txn,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// signatures of a different transaction cannot be merged
+	other := sign(keys[1])
+	other.MinerFees = []types.Currency{types.NewCurrency64(2)}
+	_, err = MergeSignatures(&txn, other, getter)
+	if err != ErrTransactionMismatch {
+		t.Fatalf("expected %v, got %v", ErrTransactionMismatch, err)
+	}
+}
@@ -0,0 +1,125 @@
+package multisig
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrUnknownProposal is returned in case a proposal cannot be found.
+	ErrUnknownProposal = errors.New("unknown multisig proposal")
+)
+
+// Proposal is a transaction for which signatures are being collected.
+type Proposal struct {
+	ID          types.TransactionID `json:"id"`
+	Transaction types.Transaction   `json:"transaction"`
+	// Created and Updated are unix epoch timestamps (in seconds)
+	Created types.Timestamp `json:"created"`
+	Updated types.Timestamp `json:"updated"`
+}
+
+// Store keeps the proposals submitted to the coordination endpoints in memory,
+// merging the signatures of all submitted versions of the same transaction.
+// Once full, the least recently updated proposal is dropped to make room for a new one.
+type Store struct {
+	mu           sync.Mutex
+	getter       OutputGetter
+	proposals    map[types.TransactionID]*Proposal
+	maxProposals int
+}
+
+// NewStore creates a new proposal store, keeping at most maxProposals proposals,
+// using the given getter to validate the signatures of submitted transactions.
+func NewStore(getter OutputGetter, maxProposals int) *Store {
+	return &Store{
+		getter:       getter,
+		proposals:    make(map[types.TransactionID]*Proposal),
+		maxProposals: maxProposals,
+	}
+}
+
+// Submit adds the given transaction as a new proposal,
+// or merges its signatures into the existing proposal for the same transaction,
+// returning the (updated) proposal and the amount of signatures added.
+func (s *Store) Submit(txn types.Transaction) (Proposal, int, error) {
+	id := ProposalID(txn)
+	now := types.Timestamp(time.Now().Unix())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proposal, ok := s.proposals[id]
+	var merged types.Transaction
+	if ok {
+		// merge into a copy, such that the proposal is left untouched should the merge fail
+		merged = proposal.Transaction
+		merged.CoinInputs = append([]types.CoinInput(nil), proposal.Transaction.CoinInputs...)
+		merged.BlockStakeInputs = append([]types.BlockStakeInput(nil), proposal.Transaction.BlockStakeInputs...)
+	} else {
+		// only keep the valid signatures of the submitted transaction
+		merged = unsignedTransaction(txn)
+		proposal = &Proposal{
+			ID:      id,
+			Created: now,
+		}
+	}
+	added, err := MergeSignatures(&merged, txn, s.getter)
+	if err != nil {
+		return Proposal{}, 0, err
+	}
+	proposal.Transaction = merged
+	proposal.Updated = now
+	if !ok {
+		if len(s.proposals) >= s.maxProposals {
+			s.dropOldest()
+		}
+		s.proposals[id] = proposal
+	}
+	return *proposal, added, nil
+}
+
+// Get returns the proposal with the given ID.
+func (s *Store) Get(id types.TransactionID) (Proposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proposal, ok := s.proposals[id]
+	if !ok {
+		return Proposal{}, ErrUnknownProposal
+	}
+	return *proposal, nil
+}
+
+// Proposals returns all proposals in the store.
+func (s *Store) Proposals() []Proposal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	proposals := make([]Proposal, 0, len(s.proposals))
+	for _, proposal := range s.proposals {
+		proposals = append(proposals, *proposal)
+	}
+	return proposals
+}
+
+// Remove deletes the proposal with the given ID, if it exists.
+func (s *Store) Remove(id types.TransactionID) {
+	s.mu.Lock()
+	delete(s.proposals, id)
+	s.mu.Unlock()
+}
+
+// dropOldest deletes the least recently updated proposal,
+// the store's lock has to be held by the caller.
+func (s *Store) dropOldest() {
+	var oldest *Proposal
+	for _, proposal := range s.proposals {
+		if oldest == nil || proposal.Updated < oldest.Updated {
+			oldest = proposal
+		}
+	}
+	if oldest != nil {
+		delete(s.proposals, oldest.ID)
+	}
+}
@@ -0,0 +1,65 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// NetworkFile is the file in the root persistent directory of a node recording the network of its data,
+// such that a node never opens the data of another network.
+const NetworkFile = "network.json"
+
+var networkMetadata = persist.Metadata{
+	Header:  "Goldchain Network",
+	Version: "1.0",
+}
+
+// dataNetwork identifies the network of which a directory contains the data.
+type dataNetwork struct {
+	Network   string        `json:"network"`
+	GenesisID types.BlockID `json:"genesisid"`
+}
+
+// checkDataNetwork returns an error in case the given directory contains the data of another network
+// than the given one, as recorded in its network file. Directories without network file are accepted,
+// and get their network recorded by recordDataNetwork once the node loaded them.
+func checkDataNetwork(dir string, network dataNetwork) error {
+	var recorded dataNetwork
+	err := persist.LoadJSON(networkMetadata, &recorded, filepath.Join(dir, NetworkFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load the network of data directory %s: %v", dir, err)
+	}
+	if recorded.Network != network.Network {
+		return fmt.Errorf("data directory %s contains the data of the %s, not of the %s, use another data directory",
+			dir, recorded.Network, network.Network)
+	}
+	if recorded.GenesisID != network.GenesisID {
+		return fmt.Errorf("data directory %s contains the data of another %s, with genesis block %s rather than %s, use another data directory",
+			dir, recorded.Network, recorded.GenesisID.String(), network.GenesisID.String())
+	}
+	return nil
+}
+
+// recordDataNetwork records the network of the data in the given directory, should it not be recorded yet.
+func recordDataNetwork(dir string, network dataNetwork) error {
+	filename := filepath.Join(dir, NetworkFile)
+	_, err := os.Stat(filename)
+	if err == nil {
+		return nil
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return err
+	}
+	return persist.SaveJSON(networkMetadata, network, filename)
+}
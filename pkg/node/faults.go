@@ -0,0 +1,12 @@
+//go:build faults
+// +build faults
+
+package node
+
+import "github.com/nbh-digital/goldchain/pkg/faults"
+
+// newFaultInjector creates the injector of the faults injected into the node,
+// as it is built using the faults build tag.
+func newFaultInjector() *faults.Injector {
+	return faults.NewInjector()
+}
@@ -0,0 +1,184 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// NetworkConfig contains the configuration of the network a node runs on.
+type NetworkConfig struct {
+	NetworkConfig        daemon.NetworkConfig
+	NetworkDescriptor    config.NetworkDescriptor
+	GenesisMintCondition types.UnlockConditionProxy
+	GenesisAuthCondition types.UnlockConditionProxy
+	// CheckpointAuthority is the address signing the checkpoints of the network,
+	// the nil address if the network has no checkpoints
+	CheckpointAuthority types.UnlockHash
+	// Checkpoints are the block IDs embedded in the release, by height
+	Checkpoints map[types.BlockHeight]types.BlockID
+	// Forks is the hard fork activation table of the network, defining the size limits at any height
+	Forks config.ForkSchedule
+	// TransactionVersions defines the heights from which the transaction versions are valid on the network
+	TransactionVersions goldchaintypes.TransactionVersionRegistry
+}
+
+// SetupNetwork injects the correct chain constants and genesis nodes based on the chosen network,
+// it also ensures that features added during the lifetime of the blockchain,
+// only get activated on a certain block height, giving everyone sufficient time to upgrade should such features be introduced.
+// The given bootstrap peers are used instead of the default ones of the network, if any are given,
+// and all devnet chain constants overwritten by the given constants file are reported to the given writer.
+func SetupNetwork(info types.BlockchainInfo, bootstrapPeers []modules.NetAddress, chainConstantsFile string, output io.Writer) (NetworkConfig, error) {
+	// return the network configuration, based on the network name,
+	// which includes the genesis block as well as the bootstrap peers
+	switch info.NetworkName {
+	case config.NetworkNameStandard:
+		return NetworkConfig{}, errors.New("standard net is disabled for goldchain, it is not ready for production") // TODO: enable again
+
+		// constants := config.GetStandardnetGenesis()
+		// networkConfig := config.GetStandardDaemonNetworkConfig()
+		// genesisAuthCondition := config.GetStandardnetGenesisAuthCoinCondition()
+
+		// // Register the transaction controllers for all transaction versions
+		// // supported on the standard network
+		// goldchaintypes.RegisterTransactionTypesForStandardNetwork(constants.CurrencyUnits.OneCoin, networkConfig)
+
+		// todo set bootstrap peers only if not set yet
+		// cfg.BootstrapPeers = config.GetStandardnetBootstrapPeers()
+
+		// // return the standard genesis block and bootstrap peers
+		// return NetworkConfig{
+		// 	NetworkConfig: daemon.NetworkConfig{
+		// 		Constants:      constants,
+		// 		BootstrapPeers: cfg.BootstrapPeers,
+		// 	},
+		// 	GenesisAuthCondition: genesisAuthCondition,
+		// }, nil
+
+	case config.NetworkNameTest:
+
+		constants := config.GetTestnetGenesis()
+		forks, err := scheduleForks(&constants, config.GetTestnetForks())
+		if err != nil {
+			return NetworkConfig{}, err
+		}
+		versions, err := transactionVersionRegistry(forks, nil)
+		if err != nil {
+			return NetworkConfig{}, err
+		}
+		genesisMintCondition := config.GetTestnetGenesisMintCondition()
+		genesisAuthCondition := config.GetTestnetGenesisAuthCoinCondition()
+
+		if len(bootstrapPeers) == 0 {
+			bootstrapPeers = config.GetTestnetBootstrapPeers()
+		}
+
+		// return the testnet genesis block and bootstrap peers
+		return NetworkConfig{
+			NetworkConfig: daemon.NetworkConfig{
+				Constants:      constants,
+				BootstrapPeers: bootstrapPeers,
+			},
+			NetworkDescriptor:    config.GetTestnetNetworkDescriptor(),
+			GenesisMintCondition: genesisMintCondition,
+			GenesisAuthCondition: genesisAuthCondition,
+			CheckpointAuthority:  config.GetTestnetDaemonNetworkConfig().FoundationPoolAddress,
+			Checkpoints:          config.GetTestnetCheckpoints(),
+			Forks:                forks,
+			TransactionVersions:  versions,
+		}, nil
+
+	case config.NetworkNameDev:
+
+		constants := config.GetDevnetGenesis()
+		forkList := config.GetDevnetForks()
+		var versionOverrides goldchaintypes.TransactionVersionActivations
+		if chainConstantsFile != "" {
+			overrides, err := config.LoadChainConstantsOverrides(chainConstantsFile)
+			if err != nil {
+				return NetworkConfig{}, err
+			}
+			changes, err := overrides.Apply(&constants, info.CoinUnit)
+			if err != nil {
+				return NetworkConfig{}, fmt.Errorf("invalid chain constants overrides %s: %v", chainConstantsFile, err)
+			}
+			if overrides.Forks != nil {
+				forkList = overrides.Forks
+			}
+			versionOverrides = overrides.TransactionVersions
+			fmt.Fprintf(output, "Overwriting devnet chain constants using %s, all nodes of this network have to use the same overrides:\n", chainConstantsFile)
+			for _, change := range changes {
+				fmt.Fprintln(output, "  - "+change)
+			}
+		}
+		forks, err := scheduleForks(&constants, forkList)
+		if err != nil {
+			return NetworkConfig{}, err
+		}
+		versions, err := transactionVersionRegistry(forks, versionOverrides)
+		if err != nil {
+			return NetworkConfig{}, err
+		}
+		genesisMintCondition := config.GetDevnetGenesisMintCondition()
+		genesisAuthCondition := config.GetDevnetGenesisAuthCoinCondition()
+
+		if len(bootstrapPeers) == 0 {
+			bootstrapPeers = config.GetDevnetBootstrapPeers()
+		}
+
+		// return the devnet genesis block and bootstrap peers
+		return NetworkConfig{
+			NetworkConfig: daemon.NetworkConfig{
+				Constants:      constants,
+				BootstrapPeers: bootstrapPeers,
+			},
+			NetworkDescriptor:    config.GetDevnetNetworkDescriptor(),
+			GenesisMintCondition: genesisMintCondition,
+			GenesisAuthCondition: genesisAuthCondition,
+			CheckpointAuthority:  config.GetDevnetDaemonNetworkConfig().FoundationPoolAddress,
+			Checkpoints:          config.GetDevnetCheckpoints(),
+			Forks:                forks,
+			TransactionVersions:  versions,
+		}, nil
+
+	default:
+		// network isn't recognised
+		return NetworkConfig{}, fmt.Errorf(
+			"Netork name %q not recognized", info.NetworkName)
+	}
+}
+
+// scheduleForks creates the fork schedule of a network, starting from the limits of the given genesis constants.
+// The size limits of the constants are raised to the highest limits of the schedule,
+// as the consensus set enforces those at every height, leaving the limits in effect at a given height to the forks plugin.
+func scheduleForks(constants *types.ChainConstants, forkList []config.Fork) (config.ForkSchedule, error) {
+	forks, err := config.NewForkSchedule(config.LimitsOf(*constants), forkList)
+	if err != nil {
+		return config.ForkSchedule{}, fmt.Errorf("invalid fork schedule: %v", err)
+	}
+	limits := forks.MaxLimits()
+	constants.BlockSizeLimit = limits.BlockSizeLimit
+	constants.ArbitraryDataSizeLimit = limits.ArbitraryDataSizeLimit
+	return forks, nil
+}
+
+// transactionVersionRegistry creates the transaction version registry of a network,
+// activating the transaction versions at the heights of their forks,
+// unless replaced by the given overrides.
+func transactionVersionRegistry(forks config.ForkSchedule, overrides goldchaintypes.TransactionVersionActivations) (goldchaintypes.TransactionVersionRegistry, error) {
+	activations := goldchaintypes.TransactionVersionActivationsOf(forks.Forks())
+	for version, height := range overrides {
+		activations[version] = height
+	}
+	registry, err := goldchaintypes.NewTransactionVersionRegistry(activations)
+	if err != nil {
+		return goldchaintypes.TransactionVersionRegistry{}, fmt.Errorf("invalid transaction version activations: %v", err)
+	}
+	return registry, nil
+}
@@ -0,0 +1,1161 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/nbh-digital/goldchain/pkg/alerts"
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/authregistry"
+	"github.com/nbh-digital/goldchain/pkg/blockstream"
+	"github.com/nbh-digital/goldchain/pkg/cache"
+	"github.com/nbh-digital/goldchain/pkg/chainstats"
+	"github.com/nbh-digital/goldchain/pkg/compact"
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/delegation"
+	"github.com/nbh-digital/goldchain/pkg/events"
+	"github.com/nbh-digital/goldchain/pkg/eventsink"
+	"github.com/nbh-digital/goldchain/pkg/expiry"
+	"github.com/nbh-digital/goldchain/pkg/extplugin"
+	"github.com/nbh-digital/goldchain/pkg/faults"
+	"github.com/nbh-digital/goldchain/pkg/finality"
+	"github.com/nbh-digital/goldchain/pkg/forks"
+	"github.com/nbh-digital/goldchain/pkg/governance"
+	"github.com/nbh-digital/goldchain/pkg/ledger"
+	"github.com/nbh-digital/goldchain/pkg/multisig"
+	"github.com/nbh-digital/goldchain/pkg/peers"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	"github.com/nbh-digital/goldchain/pkg/portmap"
+	"github.com/nbh-digital/goldchain/pkg/relay"
+	"github.com/nbh-digital/goldchain/pkg/reorgs"
+	"github.com/nbh-digital/goldchain/pkg/richlist"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/nbh-digital/goldchain/pkg/spends"
+	"github.com/nbh-digital/goldchain/pkg/stakes"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	goldchainwallet "github.com/nbh-digital/goldchain/pkg/wallet"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	authcointxapi "github.com/threefoldtech/rivine/extensions/authcointx/api"
+	"github.com/threefoldtech/rivine/extensions/minting"
+	mintingapi "github.com/threefoldtech/rivine/extensions/minting/api"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/modules/blockcreator"
+	"github.com/threefoldtech/rivine/modules/consensus"
+	"github.com/threefoldtech/rivine/modules/explorer"
+	"github.com/threefoldtech/rivine/modules/gateway"
+	"github.com/threefoldtech/rivine/modules/transactionpool"
+	"github.com/threefoldtech/rivine/modules/wallet"
+	rivineapi "github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// maxConcurrentRPC is the maximum amount of concurrent RPC's to be handled
+	// per peer
+	maxConcurrentRPC = 1
+)
+
+// ErrClosed is returned when starting a node which is already closed.
+var ErrClosed = errors.New("node is closed")
+
+// Config contains all configurable variables of an embedded node.
+type Config struct {
+	daemon.Config
+
+	// Modules are the modules to load, their dependencies have to be included
+	Modules daemon.ModuleIdentifierSet
+
+	// InMemory stores the data of all modules in a temporary directory,
+	// which is removed when the node is closed, rather than in the RootPersistentDir.
+	// The Rivine modules require a file system, so the data isn't truly kept in memory only.
+	InMemory bool
+
+	// ChainConstantsFile is the path to a JSON file overwriting individual chain constants,
+	// only supported for the devnet, an empty string uses the default constants
+	ChainConstantsFile string
+
+	// RelayPolicy is applied to all transactions received from peers or accepted locally
+	RelayPolicy relay.Policy
+
+	// OrphanPoolSize is the maximum amount of transaction sets received from peers before their parents,
+	// which are kept until their parents arrive, 0 disables the orphan pool
+	OrphanPoolSize int
+
+	// RebroadcastInterval is the interval at which the transaction sets accepted locally are rebroadcasted,
+	// as long as they are unconfirmed, 0 disables rebroadcasting
+	RebroadcastInterval time.Duration
+
+	// PeerPolicy defines when the peers which relay invalid blocks or transactions, or fail to respond, are banned
+	PeerPolicy peers.Policy
+	// GatewayLimits limit the bandwidth used to relay blocks and transactions with the peers,
+	// as well as the rate at which a peer can call RPCs, nothing is limited by default
+	GatewayLimits peers.Limits
+	// PortMapping maps the RPC port on the NAT gateway using NAT-PMP, such that peers can connect to the node,
+	// no port is mapped if nil (the gateway always tries UPnP)
+	PortMapping *portmap.Config
+
+	// CacheSize is the amount of blocks, coin outputs and blockstake outputs
+	// cached in front of the consensus database for the API, 0 disables caching
+	CacheSize int
+
+	// MultiSigProposals is the maximum amount of multisig transactions for which the node collects signatures,
+	// 0 disables the multisig coordination endpoints
+	MultiSigProposals int
+
+	// MaxReorgDepth is the maximum amount of blocks a reorganization can revert,
+	// forks reverting more blocks are refused, 0 allows reorganizations of any depth
+	MaxReorgDepth types.BlockHeight
+
+	// ImportFile is the path to a block stream, as exported from the /consensus/rawblocks endpoint,
+	// of which the blocks are validated and added to the consensus set when the node starts, before syncing with peers
+	ImportFile string
+
+	// DisableBlockCreation loads the block creator with block creation disabled,
+	// such that blocks are only created once it is enabled over the API, or on demand on the devnet
+	DisableBlockCreation bool
+
+	// AlertRules define the on-chain activity for which alerts are raised,
+	// which are written to the Output, no alerts are raised if nil
+	AlertRules *alerts.Rules
+
+	// RemoteSigner is used to sign wallet transactions should it be defined,
+	// such that the node never holds the seed
+	RemoteSigner *signer.Client
+
+	// WalletPasswordSource provides the password used to unlock the wallet when the node starts,
+	// such that an unattended node can create blocks, the wallet stays locked if nil
+	WalletPasswordSource goldchainwallet.PasswordSource
+
+	// SweepPolicy defines the tagged wallet addresses swept to cold storage, nothing is swept if nil
+	SweepPolicy *goldchainwallet.SweepPolicy
+	// SpendPolicy limits the coins sent by the wallet, no limits apply if none of them is defined
+	SpendPolicy goldchainwallet.SpendPolicy
+	// SpendApprovalPassword is the password required to approve the spends above the approval threshold
+	// of the SpendPolicy, it is required when that threshold is defined, and has to differ from the APIPassword
+	SpendApprovalPassword string
+
+	// PluginTimeout is the maximum duration of a single call of a consensus set plugin, 0 disables the timeout.
+	// Calls exceeding it are reported, and degrade non-critical plugins should DegradePlugins be enabled.
+	PluginTimeout time.Duration
+	// DegradePlugins degrades the non-critical (informational) consensus set plugins which fail, panic or time out,
+	// skipping them until the consensus set is resynced, rather than refusing the block being applied
+	DegradePlugins bool
+
+	// ExternalPlugins are the plugins launched as separate processes, fed all consensus changes,
+	// such that indexers don't have to be compiled into the node, they require the consensus module
+	ExternalPlugins []extplugin.Config
+
+	// EventSink is the broker (NATS or Kafka) all events recorded by the events plugin are published to,
+	// no events are published if nil, it requires the consensus module
+	EventSink *eventsink.Config
+
+	// Output is used to report the progress of loading and closing the node,
+	// as well as any non-fatal errors, nothing is reported if nil
+	Output io.Writer
+}
+
+// DefaultConfig returns the default configuration of an embedded devnet node,
+// running the gateway, consensus set and transaction pool in memory.
+func DefaultConfig() Config {
+	cfg := daemon.DefaultConfig()
+	cfg.BlockchainInfo = config.GetBlockchainInfo()
+	cfg.BlockchainInfo.NetworkName = config.NetworkNameDev
+	cfg.APIaddr = "localhost:22110"
+	cfg.RPCaddr = ":22112"
+	return Config{
+		Config: cfg,
+		Modules: daemon.ForceNewIdentifierSet(
+			daemon.GatewayModule.Identifier(),
+			daemon.ConsensusSetModule.Identifier(),
+			daemon.TransactionPoolModule.Identifier(),
+		),
+		InMemory:            true,
+		OrphanPoolSize:      100,
+		RebroadcastInterval: 10 * time.Minute,
+		PeerPolicy:          peers.DefaultPolicy(),
+		CacheSize:           4096,
+	}
+}
+
+// Node is a goldchain node embedded in the current process.
+//
+// The transaction versions of goldchain are registered globally when a node is created,
+// therefore only one node can run within a single process.
+type Node struct {
+	cfg     Config
+	network NetworkConfig
+	router  *goldchainapi.DocumentedRouter
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	closers []closer
+	tempDir string
+
+	mu      sync.Mutex
+	started bool
+	closed  bool
+
+	gateway      modules.Gateway
+	cs           modules.ConsensusSet
+	tpool        modules.TransactionPool
+	wallet       modules.Wallet
+	blockCreator *staking.Controller
+	explorer     modules.Explorer
+	alerts       *alerts.Monitor
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+// New loads the configured modules of a node and registers all their HTTP handlers.
+// The node only starts syncing once it is started, and has to be closed in order to release its resources.
+func New(cfg Config) (*Node, error) {
+	if cfg.Output == nil {
+		cfg.Output = ioutil.Discard
+	}
+	n := &Node{
+		cfg:    cfg,
+		router: goldchainapi.NewDocumentedRouter(),
+	}
+	n.ctx, n.cancel = context.WithCancel(context.Background())
+	if cfg.InMemory {
+		dir, err := ioutil.TempDir("", "goldchain-node")
+		if err != nil {
+			n.cancel()
+			return nil, fmt.Errorf("failed to create temporary data directory: %v", err)
+		}
+		n.tempDir = dir
+		n.cfg.RootPersistentDir = dir
+	}
+	err := n.load()
+	if err != nil {
+		n.Close()
+		return nil, err
+	}
+	return n, nil
+}
+
+// load initializes all configured modules, in order of their dependencies.
+func (n *Node) load() error {
+	cfg := n.cfg
+	var (
+		i             int
+		modulesToLoad = cfg.Modules.Len()
+	)
+	printModuleIsLoading := func(name string) {
+		n.printf("Loading %s (%d/%d)...\r\n", name, i, modulesToLoad)
+		i++
+	}
+
+	network, err := SetupNetwork(cfg.BlockchainInfo, cfg.BootstrapPeers, cfg.ChainConstantsFile, cfg.Output)
+	if err != nil {
+		return fmt.Errorf("failed to create network config: %v", err)
+	}
+	err = network.NetworkConfig.Constants.Validate()
+	if err != nil {
+		return fmt.Errorf("failed to validate network config: %v", err)
+	}
+	n.network = network
+	constants := network.NetworkConfig.Constants
+
+	// refuse the data of other networks before any module opens it
+	dataNet := dataNetwork{Network: cfg.BlockchainInfo.NetworkName, GenesisID: constants.GenesisBlockID()}
+	err = checkDataNetwork(cfg.RootPersistentDir, dataNet)
+	if err != nil {
+		return err
+	}
+
+	// faults are only injected into nodes built using the faults build tag
+	injector := newFaultInjector()
+	if injector != nil {
+		n.printf("Fault injection is enabled, faults can be injected using the /debug API\n")
+	}
+
+	// register the goldchain transaction versions,
+	// the controllers of the minting and auth coin extensions are replaced by their plugins
+	goldchaintypes.RegisterTransactionVersions(goldchaintypes.TransactionControllerGetters{})
+
+	// Initialize the Rivine modules
+	var compactGateway *compact.Gateway
+	if cfg.Modules.Contains(daemon.GatewayModule.Identifier()) {
+		printModuleIsLoading("gateway")
+		g, err := gateway.New(cfg.RPCaddr, !cfg.NoBootstrap, maxConcurrentRPC,
+			filepath.Join(cfg.RootPersistentDir, modules.GatewayDir),
+			cfg.BlockchainInfo, constants, network.NetworkConfig.BootstrapPeers, cfg.VerboseLogging)
+		if err != nil {
+			return err
+		}
+		// score the peers on the blocks and transactions they relay, banning misbehaving peers
+		sg, err := peers.NewGateway(g, cfg.PeerPolicy, cfg.GatewayLimits, filepath.Join(cfg.RootPersistentDir, modules.GatewayDir, peers.BansFile))
+		if err != nil {
+			g.Close()
+			return err
+		}
+		var relayGateway modules.Gateway = sg
+		if injector != nil {
+			// drop messages before they are scored, as if the peers misbehave
+			relayGateway = faults.NewGateway(sg, injector)
+		}
+		// fetch the blocks announced by upgraded peers as compact blocks, once the transaction pool is loaded
+		compactGateway = compact.NewGateway(relayGateway)
+		n.gateway = compactGateway
+		n.onClose("gateway", sg.Close)
+		// connect over both address families on dual-stack hosts, such that the node is known by both of its addresses
+		ds := peers.NewDualStack(sg, cfg.RPCaddr)
+		n.onClose("dual-stack connections", ds.Close)
+		rivineapi.RegisterGatewayHTTPHandlers(n.router, sg, cfg.APIPassword)
+		goldchainapi.RegisterPeerScoresHTTPHandlers(n.router, sg, cfg.APIPassword)
+		var mapper *portmap.Mapper
+		if cfg.PortMapping != nil {
+			port, err := strconv.ParseUint(sg.Address().Port(), 10, 16)
+			if err != nil {
+				return fmt.Errorf("invalid RPC port %q: %v", sg.Address().Port(), err)
+			}
+			mapper, err = portmap.NewMapper(*cfg.PortMapping, uint16(port), cfg.Output)
+			if err != nil {
+				return fmt.Errorf("failed to map the RPC port: %v", err)
+			}
+			n.onClose("port mapping", mapper.Close)
+		}
+		goldchainapi.RegisterPortMappingHTTPHandlers(n.router, mapper)
+	}
+
+	var (
+		// apiCS is the consensus set used by the API handlers,
+		// a caching wrapper of cs should caching be enabled
+		apiCS modules.ConsensusSet
+
+		// plugins
+		authCoinTxPlugin *authcointx.Plugin
+		mintingPlugin    *minting.Plugin
+		delegationPlugin *delegation.Plugin
+		chainStatsPlugin *chainstats.Plugin
+		richListPlugin   *richlist.Plugin
+		ledgerPlugin     *ledger.Plugin
+		spendsPlugin     *spends.Plugin
+	)
+	if cfg.Modules.Contains(daemon.ConsensusSetModule.Identifier()) {
+		printModuleIsLoading("consensus set")
+		cs, err := consensus.New(n.gateway, !cfg.NoBootstrap,
+			filepath.Join(cfg.RootPersistentDir, modules.ConsensusDir),
+			cfg.BlockchainInfo, constants, cfg.VerboseLogging)
+		if err != nil {
+			return err
+		}
+		n.cs = cs
+		n.onClose("consensus set", cs.Close)
+
+		apiCS = cs
+		if cfg.CacheSize > 0 {
+			cachedCS, err := cache.NewConsensusSet(cs, cfg.CacheSize, n.ctx.Done())
+			if err != nil {
+				return fmt.Errorf("failed to create consensus cache: %v", err)
+			}
+			apiCS = cachedCS
+			goldchainapi.RegisterConsensusCacheHTTPHandlers(n.router, cachedCS)
+		}
+		// the transaction versions added after the genesis block are refused prior to their activation height,
+		// before any of their own validators run
+		//
+		// expiring transactions are balanced like regular transactions,
+		// and are only valid up to (and including) their expiration height
+		cs.SetTransactionVersionMappedValidators(
+			goldchaintypes.TransactionVersionExpiring,
+			network.TransactionVersions.ValidateTransactionVersion,
+			consensus.ValidateCoinOutputsAreBalanced,
+			consensus.ValidateBlockStakeOutputsAreBalanced,
+			goldchaintypes.ValidateTransactionNotExpired,
+		)
+		// blockstake delegation transactions are balanced like regular transactions,
+		// and respend all their blockstake inputs unchanged
+		cs.SetTransactionVersionMappedValidators(
+			goldchaintypes.TransactionVersionBlockStakeDelegation,
+			network.TransactionVersions.ValidateTransactionVersion,
+			consensus.ValidateCoinOutputsAreBalanced,
+			consensus.ValidateBlockStakeOutputsAreBalanced,
+			goldchaintypes.ValidateBlockStakeDelegation,
+		)
+		// vote transactions are balanced like regular transactions,
+		// and respend all their blockstake inputs unchanged, up to the end height of their proposal
+		cs.SetTransactionVersionMappedValidators(
+			goldchaintypes.TransactionVersionVote,
+			network.TransactionVersions.ValidateTransactionVersion,
+			consensus.ValidateCoinOutputsAreBalanced,
+			consensus.ValidateBlockStakeOutputsAreBalanced,
+			goldchaintypes.ValidateVote,
+		)
+		// blockstake inputs are validated by the delegation plugin instead,
+		// as operators can fulfill the blockstake input of block creating transactions once delegation is active,
+		// and the other transaction versions are only valid from their activation height onwards as well
+		cs.SetTransactionValidators(append(delegation.StandardTransactionValidators(),
+			network.TransactionVersions.ValidateTransactionVersion)...)
+
+		rivineapi.RegisterConsensusHTTPHandlers(n.router, apiCS)
+		goldchainapi.RegisterConsensusValidateHTTPHandlers(n.router, cs)
+		goldchainapi.RegisterRawBlocksHTTPHandlers(n.router, cs)
+		goldchainapi.RegisterTransactionVersionsHTTPHandlers(n.router, cs, network.TransactionVersions)
+
+		// all plugins are monitored, as a slow or failing plugin stalls block application,
+		// and the plugins which are informational only can be degraded rather than halting consensus
+		pluginMonitor, err := pluginstats.NewMonitor(pluginstats.Config{
+			StallThreshold: pluginstats.DefaultStallThreshold,
+			Timeout:        cfg.PluginTimeout,
+			Degrade:        cfg.DegradePlugins,
+			DegradedFile:   filepath.Join(cfg.RootPersistentDir, modules.ConsensusDir, pluginstats.DegradedFile),
+		})
+		if err != nil {
+			return err
+		}
+		registerPlugin := func(name string, plugin modules.ConsensusSetPlugin, critical bool) error {
+			if _, degraded := pluginMonitor.Degraded(name); degraded {
+				return pluginstats.ErrDegraded
+			}
+			wrapped := pluginMonitor.Wrap(name, plugin, cs.Height(), critical)
+			err := cs.RegisterPlugin(n.ctx, name, wrapped)
+			if err != nil {
+				pluginMonitor.Remove(wrapped)
+			}
+			return err
+		}
+		// external plugins are fed the consensus changes once committed instead,
+		// as they run out of process, and cannot take part in the database transaction applying a block
+		externalPlugins := make([]*extplugin.Host, 0, len(cfg.ExternalPlugins))
+		for _, pluginCfg := range cfg.ExternalPlugins {
+			host := extplugin.NewHost(pluginCfg, cs, cfg.Output)
+			n.onClose("external plugin "+pluginCfg.Name, host.Close)
+			externalPlugins = append(externalPlugins, host)
+		}
+		goldchainapi.RegisterConsensusPluginsHTTPHandlers(n.router, pluginMonitor, externalPlugins)
+
+		// register the auth coin tx plugin
+		// > NOTE: this also overwrites the standard tx controllers!!!!
+		authCoinTxPlugin = authcointx.NewPlugin(
+			network.GenesisAuthCondition,
+			goldchaintypes.TransactionVersionAuthAddressUpdateTx,
+			goldchaintypes.TransactionVersionAuthConditionUpdateTx,
+			nil, // no custom opts
+		)
+		err = registerPlugin("authcointx", authCoinTxPlugin, true)
+		if err != nil {
+			n.closePlugin("authCoinTxPlugin", authCoinTxPlugin.Close)
+			return fmt.Errorf("failed to register the auth coin tx extension: %v", err)
+		}
+		// add the HTTP handlers for the auth coin tx extension as well
+		authcointxapi.RegisterConsensusuthCoinHTTPHandlers(n.router, authCoinTxPlugin)
+
+		// register the minting extension plugin
+		mintingPlugin = minting.NewMintingPlugin(
+			network.GenesisMintCondition,
+			goldchaintypes.MinterDefinitionTxVersion,
+			goldchaintypes.CoinCreationTxVersion,
+			&minting.PluginOptions{
+				CoinDestructionTransactionVersion: goldchaintypes.CoinDestructionTxVersion,
+			},
+		)
+		err = registerPlugin("minting", mintingPlugin, true)
+		if err != nil {
+			n.closePlugin("mintingPlugin", mintingPlugin.Close)
+			return fmt.Errorf("failed to register the minting extension: %v", err)
+		}
+		// add the HTTP handlers for the auth coin tx extension as well
+		mintingapi.RegisterConsensusMintingHTTPHandlers(n.router, mintingPlugin)
+
+		// register the blockstake delegation plugin,
+		// which is required for validating blockstake inputs
+		delegationActivation, _ := network.TransactionVersions.ActivationHeight(goldchaintypes.TransactionVersionBlockStakeDelegation)
+		delegationPlugin = delegation.NewPlugin(goldchaintypes.TransactionVersionBlockStakeDelegation, delegationActivation)
+		err = registerPlugin("delegation", delegationPlugin, true)
+		if err != nil {
+			n.closePlugin("delegationPlugin", delegationPlugin.Close)
+			return fmt.Errorf("failed to register the blockstake delegation plugin: %v", err)
+		}
+		goldchainapi.RegisterConsensusDelegationHTTPHandlers(n.router, delegationPlugin)
+
+		// register the finality plugin, refusing deep reorganizations and blocks conflicting with a checkpoint
+		finalityPlugin, err := finality.NewPlugin(cfg.MaxReorgDepth, network.CheckpointAuthority, network.Checkpoints,
+			filepath.Join(cfg.RootPersistentDir, finality.CheckpointsFile))
+		if err != nil {
+			return err
+		}
+		err = registerPlugin("finality", finalityPlugin, true)
+		if err != nil {
+			n.closePlugin("finalityPlugin", finalityPlugin.Close)
+		} else {
+			// a registered plugin is closed by the consensus set
+			err = finalityPlugin.VerifyChain(cs)
+		}
+		if _, ok := err.(*finality.ConflictError); ok {
+			return fmt.Errorf("the blockchain conflicts with a checkpoint, remove the %s directory to resync: %v", modules.ConsensusDir, err)
+		} else if err != nil {
+			return fmt.Errorf("failed to register the finality plugin: %v", err)
+		}
+		goldchainapi.RegisterFinalityHTTPHandlers(n.router, cs, finalityPlugin, cfg.APIPassword)
+
+		// register the forks plugin, enforcing the size limits in effect at the height of every block and transaction
+		forksPlugin := forks.NewPlugin(network.Forks)
+		err = registerPlugin("forks", forksPlugin, true)
+		if err != nil {
+			n.closePlugin("forksPlugin", forksPlugin.Close)
+			return fmt.Errorf("failed to register the forks plugin: %v", err)
+		}
+		goldchainapi.RegisterForksHTTPHandlers(n.router, cs, network.Forks)
+
+		// register the stake distribution plugin
+		stakesPlugin := stakes.NewPlugin(constants.GenesisBlock())
+		err = registerPlugin("stakes", stakesPlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the stake distribution is informational only, so the node can run without it
+			n.printf("Stake distribution endpoint is disabled: %v\n", err)
+			n.closePlugin("stakesPlugin", stakesPlugin.Close)
+			stakesPlugin = nil
+		} else if err != nil {
+			n.closePlugin("stakesPlugin", stakesPlugin.Close)
+			return fmt.Errorf("failed to register the stake distribution plugin: %v", err)
+		}
+		goldchainapi.RegisterStakesHTTPHandlers(n.router, cs, stakesPlugin, constants)
+
+		// register the governance plugin, keeping track of the votes on governance proposals
+		governancePlugin := governance.NewPlugin(goldchaintypes.TransactionVersionVote)
+		err = registerPlugin("governance", governancePlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the votes are informational only, so the node can run without them
+			n.printf("Governance endpoints are disabled: %v\n", err)
+			n.closePlugin("governancePlugin", governancePlugin.Close)
+			governancePlugin = nil
+		} else if err != nil {
+			n.closePlugin("governancePlugin", governancePlugin.Close)
+			return fmt.Errorf("failed to register the governance plugin: %v", err)
+		}
+		goldchainapi.RegisterGovernanceHTTPHandlers(n.router, cs, governancePlugin, stakesPlugin)
+
+		// register the chain statistics and rich list plugins, only used by the explorer
+		if cfg.Modules.Contains(daemon.ExplorerModule.Identifier()) {
+			chainStatsPlugin = chainstats.NewPlugin(constants.GenesisBlock(), constants.TransactionFeeCondition.UnlockHash())
+			err = registerPlugin("chainstats", chainStatsPlugin, false)
+			if err == pluginstats.ErrDegraded {
+				// the statistics are informational only, so the node can run without them
+				n.printf("Chain statistics endpoint is disabled: %v\n", err)
+				n.closePlugin("chainStatsPlugin", chainStatsPlugin.Close)
+				chainStatsPlugin = nil
+			} else if err != nil {
+				n.closePlugin("chainStatsPlugin", chainStatsPlugin.Close)
+				return fmt.Errorf("failed to register the chain statistics plugin: %v", err)
+			}
+
+			richListPlugin = richlist.NewPlugin(constants.GenesisBlock())
+			err = registerPlugin("richlist", richListPlugin, false)
+			if err == pluginstats.ErrDegraded {
+				// the rich list is informational only, so the node can run without it
+				n.printf("Rich list endpoints are disabled: %v\n", err)
+				n.closePlugin("richListPlugin", richListPlugin.Close)
+				richListPlugin = nil
+			} else if err != nil {
+				n.closePlugin("richListPlugin", richListPlugin.Close)
+				return fmt.Errorf("failed to register the rich list plugin: %v", err)
+			}
+		}
+
+		// register the ledger plugin
+		ledgerPlugin = ledger.NewPlugin(constants.GenesisBlock())
+		err = registerPlugin("ledger", ledgerPlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the ledger is derived from the blockchain only, so the node can run without it
+			n.printf("Ledger endpoints are disabled: %v\n", err)
+			n.closePlugin("ledgerPlugin", ledgerPlugin.Close)
+			ledgerPlugin = nil
+		} else if err != nil {
+			n.closePlugin("ledgerPlugin", ledgerPlugin.Close)
+			return fmt.Errorf("failed to register the ledger plugin: %v", err)
+		}
+		// register the authorized address registry plugin
+		authRegistryPlugin := authregistry.NewPlugin(goldchaintypes.TransactionVersionAuthAddressUpdateTx)
+		err = registerPlugin("authregistry", authRegistryPlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the registry is derived from the blockchain only, so the node can run without it
+			n.printf("Authorized address registry endpoint is disabled: %v\n", err)
+			n.closePlugin("authRegistryPlugin", authRegistryPlugin.Close)
+			authRegistryPlugin = nil
+		} else if err != nil {
+			n.closePlugin("authRegistryPlugin", authRegistryPlugin.Close)
+			return fmt.Errorf("failed to register the authorized address registry plugin: %v", err)
+		}
+		goldchainapi.RegisterAuthRegistryHTTPHandlers(n.router, cs, authRegistryPlugin)
+		// register the spent outputs plugin, used to report double spent transactions
+		spendsPlugin = spends.NewPlugin()
+		err = registerPlugin("spends", spendsPlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the spent outputs are derived from the blockchain only, so the node can run without them
+			n.printf("Double spend endpoints are disabled: %v\n", err)
+			n.closePlugin("spendsPlugin", spendsPlugin.Close)
+			spendsPlugin = nil
+		} else if err != nil {
+			n.closePlugin("spendsPlugin", spendsPlugin.Close)
+			return fmt.Errorf("failed to register the spends plugin: %v", err)
+		}
+		if injector != nil {
+			// delay the database transaction of every block, as plugins are called within it
+			faultsPlugin := faults.NewPlugin(injector)
+			err = registerPlugin("faults", faultsPlugin, true)
+			if err != nil {
+				n.closePlugin("faultsPlugin", faultsPlugin.Close)
+				return fmt.Errorf("failed to register the faults plugin: %v", err)
+			}
+		}
+		// register the events plugin, recording the changes of the blockchain for downstream indexers
+		eventsPlugin := events.NewPlugin(constants.GenesisBlock(), goldchaintypes.TransactionVersionAuthAddressUpdateTx)
+		err = registerPlugin("events", eventsPlugin, false)
+		if err == pluginstats.ErrDegraded {
+			// the events are derived from the blockchain only, so the node can run without them
+			n.printf("Events endpoints are disabled: %v\n", err)
+			n.closePlugin("eventsPlugin", eventsPlugin.Close)
+			eventsPlugin = nil
+		} else if err != nil {
+			n.closePlugin("eventsPlugin", eventsPlugin.Close)
+			return fmt.Errorf("failed to register the events plugin: %v", err)
+		}
+		if eventsPlugin != nil {
+			// notify the callers waiting for events once these are committed
+			err = cs.ConsensusSetSubscribe(eventsPlugin, modules.ConsensusChangeRecent, n.ctx.Done())
+			if err != nil {
+				return fmt.Errorf("failed to subscribe the events plugin: %v", err)
+			}
+			eventCursors, err := events.NewCursors(filepath.Join(cfg.RootPersistentDir, modules.ConsensusDir, events.CursorsFile))
+			if err != nil {
+				return err
+			}
+			goldchainapi.RegisterEventsHTTPHandlers(n.router, eventsPlugin, eventCursors, cfg.APIPassword)
+			if cfg.EventSink != nil {
+				publisher, err := eventsink.NewPublisher(*cfg.EventSink, eventsPlugin, eventCursors, cfg.Output)
+				if err != nil {
+					return fmt.Errorf("failed to create event sink: %v", err)
+				}
+				n.closers = append(n.closers, closer{close: publisher.Close})
+			}
+		} else if cfg.EventSink != nil {
+			n.printf("Event sink is disabled, as the events plugin is disabled\n")
+		}
+
+		if cfg.AlertRules != nil {
+			monitor, err := alerts.NewMonitor(*cfg.AlertRules, network.NetworkDescriptor, cs, cfg.Output)
+			if err != nil {
+				return fmt.Errorf("failed to create alert monitor: %v", err)
+			}
+			n.alerts = monitor
+			n.closers = append(n.closers, closer{close: monitor.Close})
+			goldchainapi.RegisterAlertsHTTPHandlers(n.router, monitor)
+		}
+
+		// reorganizations are always recorded, such that integrations can act upon them
+		tracker, err := reorgs.NewTracker(cs, filepath.Join(cfg.RootPersistentDir, modules.ConsensusDir, reorgs.File), cfg.Output)
+		if err != nil {
+			return fmt.Errorf("failed to create reorganization tracker: %v", err)
+		}
+		n.closers = append(n.closers, closer{close: tracker.Close})
+		goldchainapi.RegisterReorgsHTTPHandlers(n.router, tracker)
+
+		if ledgerPlugin != nil {
+			ledgerLabels, err := ledger.NewLabels(filepath.Join(cfg.RootPersistentDir, modules.ConsensusDir, ledger.LabelsFile))
+			if err != nil {
+				return err
+			}
+			goldchainapi.RegisterLedgerHTTPHandlers(n.router, cs, ledgerPlugin, ledgerLabels, cfg.APIPassword)
+		}
+	}
+
+	var recentTxns *spends.RecentTransactions
+	if cfg.Modules.Contains(daemon.TransactionPoolModule.Identifier()) {
+		printModuleIsLoading("transaction pool")
+		tpool, err := transactionpool.New(n.cs, n.gateway,
+			filepath.Join(cfg.RootPersistentDir, modules.TransactionPoolDir),
+			cfg.BlockchainInfo, constants, cfg.VerboseLogging)
+		if err != nil {
+			return err
+		}
+		n.onClose("transaction pool", tpool.Close)
+		if compactGateway != nil {
+			compactGateway.Enable(n.cs, tpool, constants.BlockSizeLimit)
+			goldchainapi.RegisterCompactBlocksHTTPHandlers(n.router, compactGateway)
+		}
+		// rebroadcast the transaction sets accepted locally until they are confirmed
+		var localTPool modules.TransactionPool = tpool
+		var rebroadcaster *relay.Rebroadcaster
+		if n.gateway != nil && cfg.RebroadcastInterval > 0 {
+			rebroadcaster = relay.NewRebroadcaster(tpool, n.gateway, cfg.RebroadcastInterval)
+			n.closers = append(n.closers, closer{close: rebroadcaster.Close})
+			localTPool = rebroadcaster
+		}
+		// apply our relay policy on all transaction sets received from peers,
+		// keeping those received before their parents in the orphan pool
+		if n.gateway != nil {
+			var orphans *relay.OrphanPool
+			if cfg.OrphanPoolSize > 0 {
+				orphans = relay.NewOrphanPool(n.cs, tpool, cfg.OrphanPoolSize)
+				n.closers = append(n.closers, closer{close: orphans.Close})
+			}
+			relayFilter := relay.NewFilter(cfg.RelayPolicy, tpool, orphans, constants)
+			relayFilter.RegisterRPC(n.gateway)
+			goldchainapi.RegisterRelayPolicyHTTPHandlers(n.router, relayFilter, rebroadcaster)
+		}
+		// as well as on all transaction sets accepted locally,
+		// such that we never accept transactions our peers would not relay
+		relayTPool := relay.NewTransactionPool(localTPool, cfg.RelayPolicy)
+		n.tpool = relayTPool
+		rivineapi.RegisterTransactionPoolHTTPHandlers(n.router, apiCS, n.tpool, cfg.APIPassword)
+		goldchainapi.RegisterTransactionPoolBroadcastHTTPHandlers(n.router, relay.NewBroadcaster(n.cs, relayTPool, n.gateway), cfg.APIPassword)
+		goldchainapi.RegisterTransactionPoolRawTransactionsHTTPHandlers(n.router, n.tpool)
+		// remember the transactions seen in the pool, such that dropped transactions can be reported as well
+		recentTxns = spends.NewRecentTransactions(tpool, spends.DefaultRecentTransactions)
+		n.closers = append(n.closers, closer{close: recentTxns.Close})
+		goldchainapi.RegisterDoubleSpendsHTTPHandlers(n.router, n.cs, n.tpool, spendsPlugin, recentTxns)
+		if cfg.MultiSigProposals > 0 {
+			goldchainapi.RegisterMultiSigHTTPHandlers(n.router, multisig.NewStore(n.cs, cfg.MultiSigProposals), n.cs, n.tpool)
+		}
+	}
+	if cfg.AlertRules != nil && n.cs == nil {
+		return errors.New("alerts require the consensus module")
+	}
+	if cfg.AlertRules != nil && cfg.AlertRules.StakingStalled && !cfg.Modules.Contains(daemon.BlockCreatorModule.Identifier()) {
+		return errors.New("staking alerts require the block creator module")
+	}
+	if cfg.RemoteSigner != nil && n.cs == nil {
+		return errors.New("a remote signer requires the consensus module")
+	}
+	if len(cfg.ExternalPlugins) > 0 && !cfg.Modules.Contains(daemon.ConsensusSetModule.Identifier()) {
+		return errors.New("external plugins require the consensus module")
+	}
+	if cfg.EventSink != nil && !cfg.Modules.Contains(daemon.ConsensusSetModule.Identifier()) {
+		return errors.New("an event sink requires the consensus module")
+	}
+	if cfg.ImportFile != "" && !cfg.Modules.Contains(daemon.ConsensusSetModule.Identifier()) {
+		return errors.New("importing blocks requires the consensus module")
+	}
+	if cfg.DisableBlockCreation && !cfg.Modules.Contains(daemon.BlockCreatorModule.Identifier()) {
+		return errors.New("disabling block creation requires the block creator module")
+	}
+	if cfg.WalletPasswordSource != nil && !cfg.Modules.Contains(daemon.WalletModule.Identifier()) {
+		return errors.New("unlocking the wallet requires the wallet module")
+	}
+	if cfg.SpendPolicy.Enabled() && !cfg.Modules.Contains(daemon.WalletModule.Identifier()) {
+		return errors.New("a spend policy requires the wallet module")
+	}
+	if cfg.SweepPolicy != nil && !cfg.Modules.Contains(daemon.WalletModule.Identifier()) {
+		return errors.New("a sweep policy requires the wallet module")
+	}
+	if !cfg.SpendPolicy.ApprovalThreshold.IsZero() {
+		if cfg.SpendApprovalPassword == "" {
+			return errors.New("a spend approval threshold requires an approval password")
+		}
+		if cfg.SpendApprovalPassword == cfg.APIPassword {
+			return errors.New("the spend approval password has to differ from the API password")
+		}
+	}
+	// walletRouter records all wallet API mutations in the audit log
+	var walletRouter rivineapi.Router
+	if cfg.Modules.Contains(daemon.WalletModule.Identifier()) {
+		printModuleIsLoading("wallet")
+		w, err := wallet.New(n.cs, n.tpool,
+			filepath.Join(cfg.RootPersistentDir, modules.WalletDir),
+			cfg.BlockchainInfo, constants, cfg.VerboseLogging)
+		if err != nil {
+			return err
+		}
+		n.wallet = w
+		n.onClose("wallet", w.Close)
+		walletRouter, err = n.newWalletRouter()
+		if err != nil {
+			return err
+		}
+		var approvals *goldchainapi.SpendApprovals
+		if cfg.SpendPolicy.Enabled() {
+			guard, err := goldchainwallet.NewSpendGuard(cfg.SpendPolicy,
+				filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.SpendsFile))
+			if err != nil {
+				return err
+			}
+			approvals = goldchainapi.NewSpendApprovals(guard, w)
+			goldchainapi.RegisterWalletSpendsHTTPHandlers(walletRouter, approvals, cfg.APIPassword, cfg.SpendApprovalPassword)
+		}
+		accounts, err := goldchainwallet.NewAccounts(w, filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.AccountsFile))
+		if err != nil {
+			return err
+		}
+		goldchainapi.RegisterWalletHTTPHandlers(walletRouter, w, n.tpool, n.cs, constants, cfg.RemoteSigner, approvals, accounts, cfg.APIPassword)
+		goldchainapi.RegisterWalletAccountsHTTPHandlers(walletRouter, accounts, cfg.APIPassword)
+		labels, err := goldchainwallet.NewAddressLabels(w, filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.AddressLabelsFile))
+		if err != nil {
+			return err
+		}
+		goldchainapi.RegisterWalletAddressLabelsHTTPHandlers(walletRouter, labels, cfg.APIPassword)
+		deposits, err := goldchainwallet.NewDeposits(labels, n.cs,
+			filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.DepositsFile), cfg.Output)
+		if err != nil {
+			return err
+		}
+		n.closers = append(n.closers, closer{close: deposits.Close})
+		goldchainapi.RegisterWalletDepositsHTTPHandlers(walletRouter, deposits, cfg.APIPassword)
+		var sweeper *goldchainwallet.Sweeper
+		if cfg.SweepPolicy != nil {
+			sweeper, err = goldchainwallet.NewSweeper(*cfg.SweepPolicy, w, n.tpool, n.cs, labels, constants,
+				filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.SweepsFile), cfg.Output)
+			if err != nil {
+				return err
+			}
+			n.closers = append(n.closers, closer{close: sweeper.Close})
+		}
+		goldchainapi.RegisterWalletSweepsHTTPHandlers(walletRouter, sweeper, cfg.APIPassword)
+		goldchainapi.RegisterWalletBalanceHTTPHandlers(walletRouter, w, n.cs, authCoinTxPlugin, cfg.APIPassword)
+		goldchainapi.RegisterWalletContactsHTTPHandlers(walletRouter, goldchainwallet.NewAddressBook(w,
+			filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.AddressBookFile)), cfg.APIPassword)
+		payouts, err := goldchainwallet.NewPayouts(filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.PayoutsFile))
+		if err != nil {
+			return err
+		}
+		distributions := goldchainwallet.NewDistributionLog(filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.DistributionsFile))
+		goldchainapi.RegisterWalletPayoutsHTTPHandlers(walletRouter, payouts, distributions, w, n.tpool, n.cs, constants, approvals, cfg.APIPassword)
+		// scheduled payouts are executed by the daemon, within the spend policy
+		scheduler := goldchainwallet.NewPayoutScheduler(payouts, goldchainapi.NewScheduledPayoutFunc(payouts, distributions, w, n.tpool, n.cs, constants, approvals,
+			func(name string, run goldchainwallet.PayoutRun, err error) {
+				switch {
+				case run.TransactionID != nil:
+					n.printf("Sent scheduled payout %s in transaction %s\n", name, run.TransactionID.String())
+				case run.PendingSpend != "":
+					n.printf("Scheduled payout %s awaits approval as pending spend %s\n", name, run.PendingSpend)
+				default:
+					n.printf("Scheduled payout %s failed: %s\n", name, run.Error)
+				}
+				if err != nil {
+					n.printf("Failed to record scheduled payout %s: %v\n", name, err)
+				}
+			}))
+		n.onClose("payout scheduler", scheduler.Close)
+	} else if cfg.RemoteSigner != nil {
+		walletRouter, err = n.newWalletRouter()
+		if err != nil {
+			return err
+		}
+		goldchainapi.RegisterRemoteSignerHTTPHandlers(walletRouter, cfg.RemoteSigner, n.cs, cfg.APIPassword)
+	}
+	// devMiner creates blocks on demand on the devnet
+	var devMiner *staking.Miner
+	if cfg.Modules.Contains(daemon.BlockCreatorModule.Identifier()) {
+		printModuleIsLoading("block creator")
+		if n.cs == nil || n.tpool == nil || n.wallet == nil {
+			return errors.New("the block creator requires the consensus, transaction pool and wallet modules")
+		}
+		// the block creator is created again whenever block creation is enabled at runtime
+		// blocks are created using the blockstakes delegated to the wallet as well
+		w := delegation.NewBlockCreatorWallet(n.wallet, delegationPlugin)
+		// blocks only include the transactions which fit under the limits in effect at their height,
+		// leaving out the expiring transactions which can no longer be part of them, and their dependents
+		tpool := forks.NewTransactionPool(expiry.NewTransactionPool(n.tpool, n.cs), n.cs, n.network.Forks)
+		b, err := staking.NewController(n.cs, w, constants, func() (modules.BlockCreator, error) {
+			return blockcreator.New(n.cs, tpool, w,
+				filepath.Join(cfg.RootPersistentDir, modules.BlockCreatorDir),
+				cfg.BlockchainInfo, constants, cfg.VerboseLogging)
+		})
+		if err != nil {
+			return err
+		}
+		n.blockCreator = b
+		n.onClose("block creator", b.Close)
+		if cfg.DisableBlockCreation {
+			err = b.Disable()
+			if err != nil {
+				return fmt.Errorf("failed to disable block creation: %v", err)
+			}
+		}
+		if cfg.BlockchainInfo.NetworkName == config.NetworkNameDev {
+			// blocks are created on demand on the devnet, such that tests do not have to wait for them
+			devMiner = staking.NewMiner(n.cs, w, tpool, constants)
+			goldchainapi.RegisterDevHTTPHandlers(n.router, n.cs, devMiner, cfg.APIPassword)
+		}
+		// the health of the block creator is always monitored, alerts are only raised when configured
+		var stallFactor uint64
+		var onChange func(staking.Health)
+		if cfg.AlertRules != nil {
+			stallFactor = cfg.AlertRules.StakingStallFactor
+			if cfg.AlertRules.StakingStalled {
+				onChange = n.raiseStakingAlert
+			}
+		}
+		monitor := staking.NewHealthMonitor(b, n.cs, n.gateway, constants, stallFactor, onChange)
+		n.onClose("block creator health monitor", monitor.Close)
+		goldchainapi.RegisterBlockCreatorHTTPHandlers(n.router, n.cs, b, monitor, cfg.APIPassword)
+	}
+	if cfg.Modules.Contains(daemon.ExplorerModule.Identifier()) {
+		printModuleIsLoading("explorer")
+		e, err := explorer.New(n.cs,
+			filepath.Join(cfg.RootPersistentDir, modules.ExplorerDir),
+			cfg.BlockchainInfo, constants, cfg.VerboseLogging)
+		if err != nil {
+			return err
+		}
+		n.explorer = e
+		n.onClose("explorer", e.Close)
+		rivineapi.RegisterExplorerHTTPHandlers(n.router, apiCS, e, n.tpool)
+		goldchainapi.RegisterExplorerRawBlocksHTTPHandlers(n.router, e)
+		goldchainapi.RegisterExplorerRawTransactionsHTTPHandlers(n.router, e)
+		goldchainapi.RegisterExplorerNetworkHTTPHandlers(n.router, network.NetworkDescriptor)
+		goldchainapi.RegisterExplorerValidateAddressHTTPHandlers(n.router, network.NetworkDescriptor, authCoinTxPlugin)
+		goldchainapi.RegisterExplorerStatsHTTPHandlers(n.router, e, chainStatsPlugin, constants)
+		goldchainapi.RegisterExplorerRichListHTTPHandlers(n.router, richListPlugin)
+		goldchainapi.RegisterExplorerAddressStateHTTPHandlers(n.router, n.cs, ledgerPlugin, authCoinTxPlugin)
+
+		// register extension HTTP handlers
+		authcointxapi.RegisterExplorerAuthCoinHTTPHandlers(n.router, authCoinTxPlugin)
+		mintingapi.RegisterExplorerMintingHTTPHandlers(n.router, mintingPlugin)
+	}
+	if n.tpool != nil {
+		// transactions can only be composed using funding addresses if the explorer is loaded
+		goldchainapi.RegisterTransactionPoolComposeHTTPHandlers(n.router, n.cs, n.tpool, n.explorer, constants)
+		goldchainapi.RegisterTransactionStatusHTTPHandlers(n.router, n.cs, n.tpool, n.explorer, spendsPlugin, recentTxns)
+	}
+
+	if n.wallet != nil {
+		// watch-only addresses are backfilled using the explorer, should it be loaded
+		imported, err := goldchainwallet.NewImportedAddresses(n.cs, n.explorer,
+			filepath.Join(cfg.RootPersistentDir, modules.WalletDir, goldchainwallet.ImportedAddressesFile), cfg.Output)
+		if err != nil {
+			return err
+		}
+		n.closers = append(n.closers, closer{close: imported.Close})
+		goldchainapi.RegisterWalletImportedAddressesHTTPHandlers(walletRouter, imported, cfg.APIPassword)
+	}
+
+	if injector != nil {
+		// reorganizations can only be forced on the devnet, where blocks are created on demand
+		goldchainapi.RegisterFaultsHTTPHandlers(n.router, injector, n.cs, devMiner, cfg.APIPassword)
+	}
+
+	// register our special daemon HTTP handlers
+	n.router.GET("/daemon/constants", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		rivineapi.WriteJSON(w, modules.NewDaemonConstants(cfg.BlockchainInfo, constants))
+	})
+	n.router.GET("/daemon/version", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		rivineapi.WriteJSON(w, daemon.Version{
+			ChainVersion:    cfg.BlockchainInfo.ChainVersion,
+			ProtocolVersion: cfg.BlockchainInfo.ProtocolVersion,
+		})
+	})
+	goldchainapi.RegisterOpenAPIHTTPHandlers(n.router, goldchainapi.OpenAPIInfo{
+		Title: fmt.Sprintf("%s daemon API", cfg.BlockchainInfo.Name),
+		Description: fmt.Sprintf("The API of the %s daemon on the %s network. Every request requires the user agent of the daemon (Rivine-Agent by default).",
+			cfg.BlockchainInfo.Name, cfg.BlockchainInfo.NetworkName),
+		Version: cfg.BlockchainInfo.ChainVersion.String(),
+	})
+
+	// the data is only recorded to be of this network once all modules accepted it,
+	// as the consensus set refuses a database with another genesis block
+	err = recordDataNetwork(cfg.RootPersistentDir, dataNet)
+	if err != nil {
+		return fmt.Errorf("failed to record the network of the data directory: %v", err)
+	}
+	return nil
+}
+
+// Start starts syncing the consensus set of the node, if loaded,
+// and unlocks its wallet should a password source be configured.
+func (n *Node) Start() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return ErrClosed
+	}
+	if n.started {
+		return nil
+	}
+	n.started = true
+	if n.cfg.ImportFile != "" {
+		err := n.importBlocks(n.cfg.ImportFile)
+		if err != nil {
+			return fmt.Errorf("failed to import blocks from %s: %v", n.cfg.ImportFile, err)
+		}
+	}
+	if n.cs != nil {
+		n.cs.Start()
+	}
+	// plain wallets are unlocked when loaded
+	if n.wallet != nil && n.cfg.WalletPasswordSource != nil && !n.wallet.Unlocked() {
+		n.printf("Unlocking the wallet...\n")
+		err := goldchainwallet.Unlock(n.wallet, n.cfg.WalletPasswordSource)
+		if err != nil {
+			return fmt.Errorf("failed to unlock the wallet: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close closes all modules of the node in the reverse order in which they were loaded,
+// and removes its data should the node be kept in memory.
+// The first error encountered is returned, once all modules are closed.
+func (n *Node) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		return nil
+	}
+	n.closed = true
+	n.cancel()
+
+	var firstErr error
+	for i := len(n.closers) - 1; i >= 0; i-- {
+		c := n.closers[i]
+		if c.name != "" {
+			n.printf("Closing %s...\n", c.name)
+		}
+		err := c.close()
+		if err == nil {
+			continue
+		}
+		if c.name != "" {
+			n.printf("Error during %s shutdown: %v\n", c.name, err)
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	n.closers = nil
+	if n.tempDir != "" {
+		err := os.RemoveAll(n.tempDir)
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to remove temporary data directory: %v", err)
+		}
+	}
+	return firstErr
+}
+
+// importBlocks validates and adds the blocks of the given block stream file to the consensus set.
+func (n *Node) importBlocks(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	n.printf("Importing blocks from %s...\n", filename)
+	result, err := blockstream.Import(n.cs, file, n.network.NetworkConfig.Constants.BlockSizeLimit)
+	n.printf("Imported %d blocks (%d already known), height is %d\n", result.Imported, result.Known, n.cs.Height())
+	return err
+}
+
+// newWalletRouter opens the audit log of the wallet directory, returning a router
+// which records every wallet API mutation of the handlers registered to it in that log.
+func (n *Node) newWalletRouter() (rivineapi.Router, error) {
+	dir := filepath.Join(n.cfg.RootPersistentDir, modules.WalletDir)
+	// without the wallet module, the wallet directory only holds the audit log
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	auditLog, err := goldchainwallet.OpenAuditLog(filepath.Join(dir, goldchainwallet.AuditFile))
+	if err != nil {
+		return nil, err
+	}
+	goldchainapi.RegisterWalletAuditHTTPHandlers(n.router, auditLog, n.cfg.APIPassword)
+	return goldchainapi.NewAuditedRouter(n.router, auditLog, []goldchainapi.AuditCredential{
+		{Name: "api", Password: n.cfg.APIPassword},
+		{Name: "spend-approval", Password: n.cfg.SpendApprovalPassword},
+	}, func(record goldchainwallet.AuditRecord, err error) {
+		n.printf("Failed to record the completion of %s %s in the audit log: %v\n", record.Method, record.Path, err)
+	}), nil
+}
+
+// Router returns the router to which the HTTP handlers of all loaded modules are registered,
+// such that the embedding process can serve them, or register its own handlers along them.
+func (n *Node) Router() *goldchainapi.DocumentedRouter {
+	return n.router
+}
+
+// Gateway returns the gateway of the node, nil if not loaded.
+func (n *Node) Gateway() modules.Gateway {
+	return n.gateway
+}
+
+// ConsensusSet returns the consensus set of the node, nil if not loaded.
+func (n *Node) ConsensusSet() modules.ConsensusSet {
+	return n.cs
+}
+
+// TransactionPool returns the transaction pool of the node, nil if not loaded.
+// The relay policy of the node is applied on all transactions accepted by it.
+func (n *Node) TransactionPool() modules.TransactionPool {
+	return n.tpool
+}
+
+// Wallet returns the wallet of the node, nil if not loaded.
+func (n *Node) Wallet() modules.Wallet {
+	return n.wallet
+}
+
+// BlockCreator returns the controller of the block creator of the node, nil if not loaded.
+func (n *Node) BlockCreator() *staking.Controller {
+	return n.blockCreator
+}
+
+// Explorer returns the explorer of the node, nil if not loaded.
+func (n *Node) Explorer() modules.Explorer {
+	return n.explorer
+}
+
+// raiseStakingAlert raises an alert for the given change of the health of the block creator.
+func (n *Node) raiseStakingAlert(health staking.Health) {
+	block := n.cs.CurrentBlock()
+	alert := alerts.Alert{
+		Kind:      alerts.KindStakingRecovered,
+		Height:    n.cs.Height(),
+		Timestamp: health.CheckedAt,
+		BlockID:   block.ID(),
+		Message:   "block creation is no longer stalled",
+	}
+	if !health.Healthy {
+		alert.Kind = alerts.KindStakingStalled
+		alert.Message = "block creation appears to be stalled: " + strings.Join(health.Problems, "; ")
+	}
+	n.alerts.Raise(alert)
+}
+
+// Alerts returns the monitor raising the alerts of the node, nil if no alert rules are configured.
+func (n *Node) Alerts() *alerts.Monitor {
+	return n.alerts
+}
+
+// Constants returns the chain constants of the network of the node.
+func (n *Node) Constants() types.ChainConstants {
+	return n.network.NetworkConfig.Constants
+}
+
+// NetworkDescriptor returns the descriptor of the network of the node.
+func (n *Node) NetworkDescriptor() config.NetworkDescriptor {
+	return n.network.NetworkDescriptor
+}
+
+// onClose registers a module to be closed when the node is closed.
+func (n *Node) onClose(name string, close func() error) {
+	n.closers = append(n.closers, closer{name: name, close: close})
+}
+
+// closePlugin releases the resources of a plugin which couldn't be registered.
+func (n *Node) closePlugin(name string, close func() error) {
+	err := close()
+	if err != nil {
+		n.printf("Error during closing of the %s : %v\n", name, err)
+	}
+}
+
+func (n *Node) printf(format string, args ...interface{}) {
+	fmt.Fprintf(n.cfg.Output, format, args...)
+}
@@ -0,0 +1,148 @@
+package node
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/alerts"
+	goldchainapi "github.com/nbh-digital/goldchain/pkg/api"
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestInMemoryNode(t *testing.T) {
+	if testing.Short() {
+		// closing the gateway waits for its port forwarding attempt to time out
+		t.SkipNow()
+	}
+	cfg := DefaultConfig()
+	cfg.RPCaddr = "localhost:0"
+	cfg.NoBootstrap = true
+	cfg.CacheSize = 0
+
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Gateway() == nil || n.ConsensusSet() == nil || n.TransactionPool() == nil {
+		t.Fatal("expected the gateway, consensus set and transaction pool to be loaded")
+	}
+	if n.Wallet() != nil || n.Explorer() != nil {
+		t.Error("expected the wallet and explorer not to be loaded")
+	}
+	if err = n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if height := n.ConsensusSet().Height(); height != 0 {
+		t.Errorf("expected a new devnet chain, got height %d", height)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/daemon/constants", nil)
+	rec := httptest.NewRecorder()
+	n.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	var constants modules.DaemonConstants
+	if err = json.Unmarshal(rec.Body.Bytes(), &constants); err != nil {
+		t.Fatal(err)
+	}
+	if constants.ChainInfo.NetworkName != cfg.BlockchainInfo.NetworkName {
+		t.Errorf("unexpected network %q", constants.ChainInfo.NetworkName)
+	}
+
+	dir := n.tempDir
+	if err = n.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary data directory %s to be removed", dir)
+	}
+	if err = n.Start(); err != ErrClosed {
+		t.Errorf("expected %v, got %v", ErrClosed, err)
+	}
+}
+
+func TestRemoteSignerRequiresConsensus(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Modules = daemon.ModuleIdentifierSet{}
+	cfg.RemoteSigner = signer.NewClient("localhost:22120", "password")
+	n, err := New(cfg)
+	if err == nil {
+		n.Close()
+		t.Fatal("expected a remote signer without consensus set to be refused")
+	}
+}
+
+func TestDataNetwork(t *testing.T) {
+	dir := t.TempDir()
+	devnet := dataNetwork{Network: "devnet", GenesisID: types.BlockID{1}}
+	if err := checkDataNetwork(dir, devnet); err != nil {
+		t.Fatalf("expected a directory without network file to be accepted, got %v", err)
+	}
+	if err := recordDataNetwork(dir, devnet); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkDataNetwork(dir, devnet); err != nil {
+		t.Fatalf("expected the recorded network to be accepted, got %v", err)
+	}
+	if err := checkDataNetwork(dir, dataNetwork{Network: "testnet", GenesisID: types.BlockID{1}}); err == nil {
+		t.Error("expected the data of another network to be refused")
+	}
+	if err := checkDataNetwork(dir, dataNetwork{Network: "devnet", GenesisID: types.BlockID{2}}); err == nil {
+		t.Error("expected the data of another genesis block to be refused")
+	}
+}
+
+func TestOpenAPIDocumentsAllRoutes(t *testing.T) {
+	if testing.Short() {
+		// closing the gateway waits for its port forwarding attempt to time out
+		t.SkipNow()
+	}
+	cfg := DefaultConfig()
+	cfg.RPCaddr = "localhost:0"
+	cfg.NoBootstrap = true
+	cfg.Modules = daemon.ModuleIdentifierSet{}
+	for _, id := range "gctwbe" {
+		cfg.Modules.Append(daemon.ModuleIdentifier(id))
+	}
+	cfg.AlertRules = &alerts.Rules{}
+	cfg.MultiSigProposals = 10
+	cfg.SpendPolicy.ApprovalThreshold = types.NewCurrency64(1)
+	cfg.SpendApprovalPassword = "approve"
+
+	n, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+	if undocumented := n.Router().Undocumented(); len(undocumented) != 0 {
+		t.Errorf("routes without annotation in pkg/api/openapi_operations.go: %v", undocumented)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/spec", nil)
+	rec := httptest.NewRecorder()
+	n.Router().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	var doc goldchainapi.OpenAPIDocument
+	if err = json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	op, ok := doc.Paths["/consensus/authcoin/condition/{height}"]["get"]
+	if !ok {
+		t.Fatal("expected the authcoin condition endpoint to be documented")
+	}
+	if len(op.Parameters) != 1 || op.Parameters[0].Name != "height" || op.Parameters[0].In != "path" {
+		t.Errorf("unexpected parameters: %v", op.Parameters)
+	}
+	if op, ok = doc.Paths["/wallet/coins"]["post"]; !ok || len(op.Security) != 1 {
+		t.Errorf("expected the wallet coins endpoint to require the API password: %v", op)
+	}
+}
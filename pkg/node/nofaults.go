@@ -0,0 +1,11 @@
+//go:build !faults
+// +build !faults
+
+package node
+
+import "github.com/nbh-digital/goldchain/pkg/faults"
+
+// newFaultInjector returns nil, as faults are only injected into nodes built using the faults build tag.
+func newFaultInjector() *faults.Injector {
+	return nil
+}
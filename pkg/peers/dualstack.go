@@ -0,0 +1,208 @@
+package peers
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/fastrand"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+)
+
+const (
+	// dualStackDelay is the delay after which the address families of the outbound peers are checked first,
+	// such that the gateway can connect to its bootstrap peers first
+	dualStackDelay = time.Minute
+	// dualStackInterval is the interval at which the address families of the outbound peers are checked
+	dualStackInterval = 10 * time.Minute
+	// maxSharedNodes is the maximum amount of nodes shared by a peer using the ShareNodes RPC
+	maxSharedNodes = 10
+	// shareNodesDeadline is the deadline of a ShareNodes RPC call
+	shareNodesDeadline = 2 * time.Minute
+)
+
+// Family is an IP address family.
+type Family string
+
+// address families
+const (
+	FamilyIPv4 Family = "ipv4"
+	FamilyIPv6 Family = "ipv6"
+)
+
+// errNoFamilyPeer is returned in case no peer of an address family could be connected to.
+var errNoFamilyPeer = errors.New("no node of the address family could be connected to")
+
+// AddressFamily returns the address family of the IP host of the given address,
+// false if the host of the address is not an IP address.
+func AddressFamily(addr modules.NetAddress) (Family, bool) {
+	ip := net.ParseIP(addr.Host())
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return FamilyIPv4, true
+	}
+	return FamilyIPv6, true
+}
+
+// listenFamilies returns the address families the gateway listens on, given its RPC address.
+func listenFamilies(rpcAddr string) []Family {
+	host, _, err := net.SplitHostPort(rpcAddr)
+	if err != nil {
+		return nil
+	}
+	if host == "" || host == "::" {
+		// the unspecified IPv6 address listens on both families
+		return []Family{FamilyIPv4, FamilyIPv6}
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	if ip.To4() != nil {
+		return []Family{FamilyIPv4}
+	}
+	return []Family{FamilyIPv6}
+}
+
+// hostFamilies returns the address families of which the host has an address reachable from the internet:
+// any IPv4 address other than a loopback or link-local address, as it can be reachable through NAT,
+// and any global IPv6 address, unique local addresses excluded.
+func hostFamilies(addrs []net.Addr) map[Family]bool {
+	_, uniqueLocal, _ := net.ParseCIDR("fc00::/7")
+	families := make(map[Family]bool, 2)
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			families[FamilyIPv4] = true
+		} else if !uniqueLocal.Contains(ipNet.IP) {
+			families[FamilyIPv6] = true
+		}
+	}
+	return families
+}
+
+// DualStack keeps an outbound connection to a peer over every address family the gateway listens on
+// and the host has an address of, such that the gateway is known by its address of each family.
+//
+// The gateway advertises a single address in its handshake, while the peers it connects to
+// record the address of the connection instead, and share it with their peers.
+// Connecting over both families therefore advertises both addresses of a dual-stack node,
+// allowing nodes which are only reachable over one of the families to find it.
+// Nodes of the missing family are found by asking the connected peers to share their nodes.
+type DualStack struct {
+	gateway  modules.Gateway
+	families []Family
+	// interfaceAddrs returns the addresses of the host
+	interfaceAddrs func() ([]net.Addr, error)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewDualStack creates a new DualStack for the given gateway, listening on the given RPC address.
+// Nothing is done in case the gateway listens on a single address family.
+func NewDualStack(g modules.Gateway, rpcAddr string) *DualStack {
+	ds := &DualStack{
+		gateway:        g,
+		families:       listenFamilies(rpcAddr),
+		interfaceAddrs: net.InterfaceAddrs,
+		stop:           make(chan struct{}),
+	}
+	if len(ds.families) < 2 {
+		return ds
+	}
+	ds.wg.Add(1)
+	go func() {
+		defer ds.wg.Done()
+		delay := dualStackDelay
+		for {
+			select {
+			case <-ds.stop:
+				return
+			case <-time.After(delay):
+			}
+			ds.connectMissingFamilies()
+			delay = dualStackInterval
+		}
+	}()
+	return ds
+}
+
+// Close stops connecting over the missing address families.
+func (ds *DualStack) Close() error {
+	close(ds.stop)
+	ds.wg.Wait()
+	return nil
+}
+
+// outboundFamilies returns the address families of the outbound peers of the gateway.
+func (ds *DualStack) outboundFamilies() map[Family]bool {
+	families := make(map[Family]bool, 2)
+	for _, peer := range ds.gateway.Peers() {
+		if peer.Inbound {
+			continue
+		}
+		if family, ok := AddressFamily(peer.NetAddress); ok {
+			families[family] = true
+		}
+	}
+	return families
+}
+
+// connectMissingFamilies connects to a peer of every address family the gateway listens on and the host has an address of,
+// for which the gateway has no outbound peer yet, returning the families it failed to connect over.
+func (ds *DualStack) connectMissingFamilies() []Family {
+	addrs, err := ds.interfaceAddrs()
+	if err != nil {
+		return ds.families
+	}
+	host, outbound := hostFamilies(addrs), ds.outboundFamilies()
+	var failed []Family
+	for _, family := range ds.families {
+		if !host[family] || outbound[family] {
+			continue
+		}
+		if err := ds.connectFamily(family); err != nil {
+			failed = append(failed, family)
+		}
+	}
+	return failed
+}
+
+// connectFamily connects to a node of the given address family, as shared by the connected peers.
+func (ds *DualStack) connectFamily(family Family) error {
+	peers := ds.gateway.Peers()
+	connected := make(map[modules.NetAddress]struct{}, len(peers))
+	for _, peer := range peers {
+		connected[peer.NetAddress] = struct{}{}
+	}
+	for _, i := range fastrand.Perm(len(peers)) {
+		var nodes []modules.NetAddress
+		err := ds.gateway.RPC(peers[i].NetAddress, "ShareNodes", func(conn modules.PeerConn) error {
+			conn.SetDeadline(time.Now().Add(shareNodesDeadline))
+			return siabin.ReadObject(conn, &nodes, maxSharedNodes*modules.MaxEncodedNetAddressLength)
+		})
+		if err != nil {
+			continue
+		}
+		for _, node := range nodes {
+			if _, ok := connected[node]; ok || node.IsStdValid() != nil || node.IsLocal() {
+				continue
+			}
+			if nodeFamily, ok := AddressFamily(node); !ok || nodeFamily != family {
+				continue
+			}
+			if ds.gateway.Connect(node) == nil {
+				return nil
+			}
+		}
+	}
+	return errNoFamilyPeer
+}
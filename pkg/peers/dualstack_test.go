@@ -0,0 +1,149 @@
+package peers
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+)
+
+// nodesConn is a peer connection from which the shared nodes are read.
+type nodesConn struct {
+	net.Conn
+	r *bytes.Reader
+}
+
+func (conn nodesConn) RPCAddr() modules.NetAddress { return "" }
+
+func (conn nodesConn) Read(b []byte) (int, error) { return conn.r.Read(b) }
+
+func (conn nodesConn) SetDeadline(time.Time) error { return nil }
+
+// nodesGateway is a gateway of which all peers share the same nodes.
+type nodesGateway struct {
+	modules.Gateway
+
+	peers     []modules.Peer
+	nodes     []modules.NetAddress
+	reachable map[modules.NetAddress]bool
+}
+
+func (g *nodesGateway) Peers() []modules.Peer { return g.peers }
+
+func (g *nodesGateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	if name != "ShareNodes" {
+		return errors.New("unexpected RPC " + name)
+	}
+	var buf bytes.Buffer
+	if err := siabin.WriteObject(&buf, g.nodes); err != nil {
+		return err
+	}
+	return fn(nodesConn{r: bytes.NewReader(buf.Bytes())})
+}
+
+func (g *nodesGateway) Connect(addr modules.NetAddress) error {
+	if !g.reachable[addr] {
+		return errors.New("unreachable")
+	}
+	g.peers = append(g.peers, modules.Peer{NetAddress: addr})
+	return nil
+}
+
+func TestListenFamilies(t *testing.T) {
+	testCases := map[string]int{
+		":22112":             2,
+		"[::]:22112":         2,
+		"0.0.0.0:22112":      1,
+		"[2001:db8::]:22112": 1,
+		"invalid":            0,
+	}
+	for addr, expected := range testCases {
+		if families := listenFamilies(addr); len(families) != expected {
+			t.Errorf("%s: expected %d families, got %v", addr, expected, families)
+		}
+	}
+}
+
+func TestHostFamilies(t *testing.T) {
+	cidrs := func(strs ...string) []net.Addr {
+		var addrs []net.Addr
+		for _, str := range strs {
+			ip, ipNet, err := net.ParseCIDR(str)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ipNet.IP = ip
+			addrs = append(addrs, ipNet)
+		}
+		return addrs
+	}
+	families := hostFamilies(cidrs("127.0.0.1/8", "::1/128", "fe80::1/64", "fd00::1/64", "192.168.1.2/24"))
+	if !families[FamilyIPv4] || families[FamilyIPv6] {
+		t.Errorf("expected only the private IPv4 address to count, got %v", families)
+	}
+	families = hostFamilies(cidrs("2001:db8::7/64"))
+	if families[FamilyIPv4] || !families[FamilyIPv6] {
+		t.Errorf("expected the global IPv6 address to count, got %v", families)
+	}
+}
+
+func TestDualStackConnectsMissingFamily(t *testing.T) {
+	g := &nodesGateway{
+		peers: []modules.Peer{
+			{NetAddress: "203.0.113.7:22112"},
+			{NetAddress: "[2001:db8::9]:22112", Inbound: true},
+		},
+		nodes: []modules.NetAddress{
+			"198.51.100.1:22112",
+			"[2001:db8::9]:22112",
+			"[fd00::1]:22112",
+			"[2001:db8::1]:22112",
+			"[2001:db8::2]:22112",
+		},
+		reachable: map[modules.NetAddress]bool{"[2001:db8::2]:22112": true},
+	}
+	ds := &DualStack{
+		gateway:  g,
+		families: []Family{FamilyIPv4, FamilyIPv6},
+	}
+	ipv4Only := func() ([]net.Addr, error) {
+		return []net.Addr{&net.IPNet{IP: net.ParseIP("192.168.1.2"), Mask: net.CIDRMask(24, 32)}}, nil
+	}
+	dualStack := func() ([]net.Addr, error) {
+		addrs, _ := ipv4Only()
+		return append(addrs, &net.IPNet{IP: net.ParseIP("2001:db8::7"), Mask: net.CIDRMask(64, 128)}), nil
+	}
+
+	// nothing is connected to over a family the host has no address of
+	ds.interfaceAddrs = ipv4Only
+	if failed := ds.connectMissingFamilies(); len(failed) != 0 || len(g.peers) != 2 {
+		t.Fatalf("expected no connection, failed %v, peers %v", failed, g.peers)
+	}
+
+	// the inbound IPv6 peer does not advertise the address of the node, an outbound one does,
+	// the nodes which are connected already, local or unreachable are skipped
+	ds.interfaceAddrs = dualStack
+	if failed := ds.connectMissingFamilies(); len(failed) != 0 {
+		t.Fatalf("expected to connect over all families, failed %v", failed)
+	}
+	if len(g.peers) != 3 || g.peers[2].NetAddress != "[2001:db8::2]:22112" {
+		t.Fatalf("expected to connect to the reachable IPv6 node, peers %v", g.peers)
+	}
+	if families := ds.outboundFamilies(); !families[FamilyIPv4] || !families[FamilyIPv6] {
+		t.Fatalf("expected outbound peers of both families, got %v", families)
+	}
+
+	// nothing is connected to once there is an outbound peer of every family
+	g.reachable = nil
+	if failed := ds.connectMissingFamilies(); len(failed) != 0 || len(g.peers) != 3 {
+		t.Fatalf("expected no connection, failed %v, peers %v", failed, g.peers)
+	}
+	g.peers = g.peers[:2]
+	if failed := ds.connectMissingFamilies(); len(failed) != 1 || failed[0] != FamilyIPv6 {
+		t.Fatalf("expected to fail connecting over IPv6, failed %v", failed)
+	}
+}
@@ -0,0 +1,503 @@
+package peers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+)
+
+const (
+	// BansFile is the name of the file in which the bans are stored, in the gateway directory.
+	BansFile = "peerbans.json"
+
+	// sweepInterval is the interval at which the uptime of the peers is updated,
+	// and reconnected banned peers are disconnected
+	sweepInterval = 10 * time.Second
+	// forgetAfter is the duration after which disconnected peers are forgotten
+	forgetAfter = 24 * time.Hour
+	// broadcastRetryDelay is the delay after which a failed broadcast to a peer is tried once more
+	broadcastRetryDelay = 10 * time.Second
+)
+
+// ErrNotBanned is returned when unbanning a host which isn't banned.
+var ErrNotBanned = errors.New("host is not banned")
+
+var bansMetadata = persist.Metadata{
+	Header:  "Goldchain Peer Bans",
+	Version: "1.0",
+}
+
+// Gateway wraps a gateway, scoring its peers based on the RPCs registered and called through it,
+// penalizing peers which relay invalid blocks or transactions, or fail to respond, and banning the hosts
+// of peers of which the score drops below the ban score of the policy. Connected peers are returned
+// in order of their score, such that the peers with the highest score are preferred when syncing.
+// The bandwidth of these RPCs is throttled according to the limits, which also limit the rate at which
+// a peer can call RPCs.
+//
+// Only the RPCs registered, called and broadcasted through this Gateway are scored and throttled,
+// so it has to be given to the consensus set and transaction pool, instead of the wrapped gateway.
+type Gateway struct {
+	modules.Gateway
+	policy   Policy
+	limits   Limits
+	filename string
+	now      func() time.Time
+
+	// upload and download throttle the bandwidth of all peers together, nil if unlimited
+	upload, download *bucket
+
+	mu    sync.Mutex
+	peers map[modules.NetAddress]*peerState
+	bans  map[string]Ban
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+var _ modules.Gateway = (*Gateway)(nil)
+
+// NewGateway creates a new Gateway scoring the peers of the given gateway,
+// banning them according to the given policy, and persisting the bans in the given file,
+// an empty filename keeps the bans in memory only. The RPCs with the peers are limited by the given limits.
+func NewGateway(g modules.Gateway, policy Policy, limits Limits, filename string) (*Gateway, error) {
+	sg := &Gateway{
+		Gateway:  g,
+		policy:   policy,
+		limits:   limits,
+		filename: filename,
+		now:      time.Now,
+		upload:   newBandwidthBucket(limits.UploadRate),
+		download: newBandwidthBucket(limits.DownloadRate),
+		peers:    make(map[modules.NetAddress]*peerState),
+		bans:     make(map[string]Ban),
+		stop:     make(chan struct{}),
+	}
+	if filename != "" {
+		var bans []Ban
+		err := persist.LoadJSON(bansMetadata, &bans, filename)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to load peer bans: %v", err)
+		}
+		for _, ban := range bans {
+			sg.bans[ban.Host] = ban
+		}
+	}
+	sg.wg.Add(1)
+	go func() {
+		defer sg.wg.Done()
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-sg.stop:
+				return
+			case <-ticker.C:
+				sg.sweep()
+			}
+		}
+	}()
+	return sg, nil
+}
+
+// Policy returns the ban policy of the gateway.
+func (sg *Gateway) Policy() Policy {
+	return sg.policy
+}
+
+// Limits returns the bandwidth and message rate limits of the gateway.
+func (sg *Gateway) Limits() Limits {
+	return sg.limits
+}
+
+// Connect implements modules.Gateway.Connect, refusing to connect to banned hosts.
+func (sg *Gateway) Connect(addr modules.NetAddress) error {
+	addr = normalizeAddress(addr)
+	if sg.banned(addr) {
+		return fmt.Errorf("cannot connect to %s: %v", addr, errPeerBanned)
+	}
+	return sg.Gateway.Connect(addr)
+}
+
+// normalizeAddress restores the brackets around the IPv6 host of the given address,
+// as they are dropped when the address is resolved by the connect handler of the API.
+func normalizeAddress(addr modules.NetAddress) modules.NetAddress {
+	if _, _, err := net.SplitHostPort(string(addr)); err == nil {
+		return addr
+	}
+	i := strings.LastIndexByte(string(addr), ':')
+	if i < 0 {
+		return addr
+	}
+	ip := net.ParseIP(string(addr[:i]))
+	if ip == nil || ip.To4() != nil {
+		return addr
+	}
+	return modules.NetAddress(net.JoinHostPort(ip.String(), string(addr[i+1:])))
+}
+
+// Peers implements modules.Gateway.Peers, returning the connected peers which aren't banned,
+// ordered by descending score, and ascending latency for peers with the same score.
+func (sg *Gateway) Peers() []modules.Peer {
+	peers := sg.Gateway.Peers()
+	now := sg.now()
+
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	scores := make(map[modules.NetAddress]PeerScore, len(peers))
+	allowed := peers[:0]
+	for _, peer := range peers {
+		if sg.bannedLocked(peer.NetAddress, now) {
+			continue
+		}
+		allowed = append(allowed, peer)
+		if state, ok := sg.peers[peer.NetAddress]; ok {
+			scores[peer.NetAddress] = state.peerScore(peer.NetAddress, now)
+		} else {
+			scores[peer.NetAddress] = PeerScore{Score: MaxScore}
+		}
+	}
+	sort.SliceStable(allowed, func(i, j int) bool {
+		si, sj := scores[allowed[i].NetAddress], scores[allowed[j].NetAddress]
+		if si.Score != sj.Score {
+			return si.Score > sj.Score
+		}
+		return si.Latency < sj.Latency
+	})
+	return allowed
+}
+
+// RegisterRPC implements modules.Gateway.RegisterRPC,
+// scoring the peers calling the RPC, and refusing the calls of banned peers.
+func (sg *Gateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	sg.Gateway.RegisterRPC(name, func(conn modules.PeerConn) error {
+		addr := conn.RPCAddr()
+		if sg.banned(addr) {
+			// disconnecting is done asynchronously, as the RPC is handled by the gateway
+			go sg.Gateway.Disconnect(addr)
+			return errPeerBanned
+		}
+		if !sg.allowMessage(addr) {
+			return errRateLimited
+		}
+		err := sg.metered(fn, false)(conn)
+		sg.record(addr, classifyRPC(name, true, err))
+		return err
+	})
+}
+
+// RegisterConnectCall implements modules.Gateway.RegisterConnectCall,
+// scoring the peers on which the RPC is called when connecting.
+func (sg *Gateway) RegisterConnectCall(name string, fn modules.RPCFunc) {
+	sg.Gateway.RegisterConnectCall(name, func(conn modules.PeerConn) error {
+		addr := conn.RPCAddr()
+		if sg.banned(addr) {
+			go sg.Gateway.Disconnect(addr)
+			return errPeerBanned
+		}
+		err := sg.metered(fn, true)(conn)
+		sg.record(addr, classifyRPC(name, false, err))
+		return err
+	})
+}
+
+// RPC implements modules.Gateway.RPC, scoring the peer on which the RPC is called,
+// and refusing to call RPCs on banned peers.
+func (sg *Gateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	if sg.banned(addr) {
+		return errPeerBanned
+	}
+	var (
+		called bool
+		fnErr  error
+	)
+	err := sg.Gateway.RPC(addr, name, func(conn modules.PeerConn) error {
+		called = true
+		fnErr = sg.metered(fn, true)(conn)
+		return fnErr
+	})
+	if !called {
+		// the RPC could not be initiated on the connection
+		if err != nil {
+			sg.record(addr, outcomeFailed)
+		}
+		return err
+	}
+	sg.record(addr, classifyRPC(name, false, fnErr))
+	return err
+}
+
+// Broadcast implements modules.Gateway.Broadcast, broadcasting through this Gateway,
+// such that the broadcasts are throttled and scored as any other RPC. As the wrapped gateway does,
+// the RPC is tried once more after a delay for the peers on which it failed.
+func (sg *Gateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
+	// only encode obj once, instead of using WriteObject
+	enc := siabin.Marshal(obj)
+	fn := func(conn modules.PeerConn) error {
+		return siabin.WritePrefix(conn, enc)
+	}
+	var wg sync.WaitGroup
+	for _, peer := range peers {
+		wg.Add(1)
+		go func(addr modules.NetAddress) {
+			defer wg.Done()
+			err := sg.RPC(addr, name, fn)
+			if err == nil || err == errPeerBanned {
+				return
+			}
+			select {
+			case <-time.After(broadcastRetryDelay):
+			case <-sg.stop:
+				return
+			}
+			sg.RPC(addr, name, fn)
+		}(peer.NetAddress)
+	}
+	wg.Wait()
+}
+
+// Close stops scoring the peers, persisting the bans, and closes the wrapped gateway.
+func (sg *Gateway) Close() error {
+	close(sg.stop)
+	sg.wg.Wait()
+	sg.mu.Lock()
+	err := sg.saveBansLocked()
+	sg.mu.Unlock()
+	if cerr := sg.Gateway.Close(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// Scores returns the scores of all connected peers, as well as the peers which were connected recently,
+// ordered by descending score.
+func (sg *Gateway) Scores() []PeerScore {
+	// update the connected peers first, as they are only updated periodically otherwise
+	sg.sweep()
+	now := sg.now()
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	scores := make([]PeerScore, 0, len(sg.peers))
+	for addr, state := range sg.peers {
+		scores = append(scores, state.peerScore(addr, now))
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].NetAddress < scores[j].NetAddress
+	})
+	return scores
+}
+
+// Bans returns the active bans, ordered by host.
+func (sg *Gateway) Bans() []Ban {
+	now := sg.now()
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	bans := make([]Ban, 0, len(sg.bans))
+	for _, ban := range sg.bans {
+		if now.Before(ban.Until) {
+			bans = append(bans, ban)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Host < bans[j].Host
+	})
+	return bans
+}
+
+// Ban bans the given host for the given duration, disconnecting all its peers.
+func (sg *Gateway) Ban(host string, duration time.Duration, reason string) error {
+	sg.mu.Lock()
+	sg.bans[host] = Ban{Host: host, Until: sg.now().Add(duration), Reason: reason}
+	err := sg.saveBansLocked()
+	sg.mu.Unlock()
+	sg.disconnectHost(host)
+	return err
+}
+
+// Unban lifts the ban of the given host, forgiving the penalties of its peers.
+func (sg *Gateway) Unban(host string) error {
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	ban, ok := sg.bans[host]
+	if !ok || !sg.now().Before(ban.Until) {
+		return ErrNotBanned
+	}
+	delete(sg.bans, host)
+	for addr, state := range sg.peers {
+		if addr.Host() == host {
+			state.penalty = 0
+		}
+	}
+	return sg.saveBansLocked()
+}
+
+// metered returns an RPC function counting and throttling the bytes sent and received,
+// recording the duration until the peer first responds as its latency, should the RPC be called on the peer.
+func (sg *Gateway) metered(fn modules.RPCFunc, outbound bool) modules.RPCFunc {
+	return func(conn modules.PeerConn) error {
+		mc := &meteredConn{PeerConn: conn, upload: sg.upload, download: sg.download, start: time.Now()}
+		err := fn(mc)
+		sg.mu.Lock()
+		state := sg.stateLocked(conn.RPCAddr(), sg.now())
+		state.metrics.BytesUploaded += mc.written
+		state.metrics.BytesDownloaded += mc.read
+		if outbound && !mc.firstRead.IsZero() {
+			state.recordLatency(mc.firstRead.Sub(mc.start))
+		}
+		sg.mu.Unlock()
+		return err
+	}
+}
+
+// allowMessage returns false if the given peer exceeds the message rate.
+func (sg *Gateway) allowMessage(addr modules.NetAddress) bool {
+	if sg.limits.PeerMessageRate <= 0 {
+		return true
+	}
+	now := sg.now()
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	state := sg.stateLocked(addr, now)
+	if state.messages == nil {
+		rate := sg.limits.PeerMessageRate
+		state.messages = newBucket(rate, math.Max(1, rate*messageBurstDuration.Seconds()))
+	}
+	if state.messages.allow(now) {
+		return true
+	}
+	state.metrics.RateLimitedRPCs++
+	return false
+}
+
+// record records the outcome of an RPC with the given peer, banning it should its score drop below the ban score.
+func (sg *Gateway) record(addr modules.NetAddress, o outcome) {
+	now := sg.now()
+	sg.mu.Lock()
+	state := sg.stateLocked(addr, now)
+	penalty := state.record(o, now)
+	score := state.score(now)
+	if penalty == 0 || score >= sg.policy.BanScore || sg.policy.BanDuration <= 0 || sg.bannedLocked(addr, now) {
+		sg.mu.Unlock()
+		return
+	}
+	host := addr.Host()
+	sg.bans[host] = Ban{
+		Host:  host,
+		Until: now.Add(sg.policy.BanDuration),
+		Reason: fmt.Sprintf("score %.2f of %s (%d invalid blocks, %d invalid transaction sets, %d failed RPCs)",
+			score, addr, state.metrics.InvalidBlocks, state.metrics.InvalidTransactionSets, state.metrics.FailedRPCs),
+	}
+	sg.saveBansLocked()
+	sg.mu.Unlock()
+	// the RPC might be handled by the gateway, so disconnecting is done asynchronously
+	go sg.disconnectHost(host)
+}
+
+// sweep updates the uptime of all peers, disconnecting the peers of banned hosts,
+// and forgets expired bans as well as the peers which haven't been connected for a while.
+func (sg *Gateway) sweep() {
+	peers := sg.Gateway.Peers()
+	now := sg.now()
+
+	sg.mu.Lock()
+	connected := make(map[modules.NetAddress]struct{}, len(peers))
+	var disconnect []modules.NetAddress
+	for _, peer := range peers {
+		connected[peer.NetAddress] = struct{}{}
+		if sg.bannedLocked(peer.NetAddress, now) {
+			disconnect = append(disconnect, peer.NetAddress)
+			continue
+		}
+		state := sg.stateLocked(peer.NetAddress, now)
+		if state.connectedSince.IsZero() {
+			state.connectedSince = now
+		}
+		state.lastSeen = now
+	}
+	for addr, state := range sg.peers {
+		if _, ok := connected[addr]; ok {
+			continue
+		}
+		if !state.connectedSince.IsZero() {
+			state.metrics.Uptime += now.Sub(state.connectedSince)
+			state.connectedSince = time.Time{}
+		}
+		if now.Sub(state.lastSeen) > forgetAfter {
+			delete(sg.peers, addr)
+		}
+	}
+	expired := false
+	for host, ban := range sg.bans {
+		if !now.Before(ban.Until) {
+			delete(sg.bans, host)
+			expired = true
+		}
+	}
+	if expired {
+		sg.saveBansLocked()
+	}
+	sg.mu.Unlock()
+
+	for _, addr := range disconnect {
+		sg.Gateway.Disconnect(addr)
+	}
+}
+
+// disconnectHost disconnects all peers of the given host.
+func (sg *Gateway) disconnectHost(host string) {
+	for _, peer := range sg.Gateway.Peers() {
+		if peer.NetAddress.Host() == host {
+			sg.Gateway.Disconnect(peer.NetAddress)
+		}
+	}
+}
+
+// banned returns true if the host of the given peer is banned.
+func (sg *Gateway) banned(addr modules.NetAddress) bool {
+	now := sg.now()
+	sg.mu.Lock()
+	defer sg.mu.Unlock()
+	return sg.bannedLocked(addr, now)
+}
+
+func (sg *Gateway) bannedLocked(addr modules.NetAddress, now time.Time) bool {
+	ban, ok := sg.bans[addr.Host()]
+	return ok && now.Before(ban.Until)
+}
+
+// stateLocked returns the state of the given peer, creating it if it doesn't exist yet.
+func (sg *Gateway) stateLocked(addr modules.NetAddress, now time.Time) *peerState {
+	state, ok := sg.peers[addr]
+	if !ok {
+		state = &peerState{
+			metrics:  Metrics{FirstSeen: now},
+			lastSeen: now,
+		}
+		sg.peers[addr] = state
+	}
+	return state
+}
+
+// saveBansLocked persists the active bans, if a file is defined.
+func (sg *Gateway) saveBansLocked() error {
+	if sg.filename == "" {
+		return nil
+	}
+	bans := make([]Ban, 0, len(sg.bans))
+	for _, ban := range sg.bans {
+		bans = append(bans, ban)
+	}
+	return persist.SaveJSON(bansMetadata, bans, sg.filename)
+}
@@ -0,0 +1,324 @@
+package peers
+
+import (
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+)
+
+// testConn is a peer connection discarding all writes.
+type testConn struct {
+	net.Conn
+	addr modules.NetAddress
+}
+
+func (conn testConn) RPCAddr() modules.NetAddress { return conn.addr }
+
+func (conn testConn) Write(b []byte) (int, error) { return len(b), nil }
+
+// testGateway is a gateway calling all RPCs directly, without any connection.
+type testGateway struct {
+	modules.Gateway
+
+	mu           sync.Mutex
+	peers        []modules.Peer
+	handlers     map[string]modules.RPCFunc
+	disconnected []modules.NetAddress
+}
+
+func (g *testGateway) Peers() []modules.Peer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]modules.Peer(nil), g.peers...)
+}
+
+func (g *testGateway) Disconnect(addr modules.NetAddress) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i, peer := range g.peers {
+		if peer.NetAddress == addr {
+			g.peers = append(g.peers[:i], g.peers[i+1:]...)
+			g.disconnected = append(g.disconnected, addr)
+			return nil
+		}
+	}
+	return errors.New("not connected to that node")
+}
+
+func (g *testGateway) RegisterRPC(name string, fn modules.RPCFunc) {
+	g.handlers[name] = fn
+}
+
+func (g *testGateway) RPC(addr modules.NetAddress, name string, fn modules.RPCFunc) error {
+	return fn(testConn{addr: addr})
+}
+
+func (g *testGateway) Close() error { return nil }
+
+// call calls the RPC handler registered with the given name, as the given peer.
+func (g *testGateway) call(addr modules.NetAddress, name string) error {
+	return g.handlers[name](testConn{addr: addr})
+}
+
+func newTestGateway(t *testing.T, filename string, addrs ...modules.NetAddress) (*Gateway, *testGateway, *time.Time) {
+	return newTestGatewayWithLimits(t, filename, Limits{}, addrs...)
+}
+
+func newTestGatewayWithLimits(t *testing.T, filename string, limits Limits, addrs ...modules.NetAddress) (*Gateway, *testGateway, *time.Time) {
+	g := &testGateway{handlers: make(map[string]modules.RPCFunc)}
+	for _, addr := range addrs {
+		g.peers = append(g.peers, modules.Peer{NetAddress: addr})
+	}
+	sg, err := NewGateway(g, DefaultPolicy(), limits, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sg.now = func() time.Time { return now }
+	return sg, g, &now
+}
+
+func TestClassifyRPC(t *testing.T) {
+	invalid := errors.New("block is known to be invalid")
+	testCases := []struct {
+		rpc     string
+		inbound bool
+		err     error
+		outcome outcome
+	}{
+		{rpcRelayHeader, true, nil, outcomeSuccess},
+		{rpcRelayHeader, true, modules.ErrBlockKnown, outcomeNeutral},
+		{rpcRelayHeader, true, invalid, outcomeInvalidBlock},
+		{rpcRelayHeader, true, io.ErrUnexpectedEOF, outcomeFailed},
+		{rpcSendBlocks, false, invalid, outcomeInvalidBlock},
+		{rpcSendBlocks, false, modules.ErrNonExtendingBlock, outcomeNeutral},
+		{rpcSendBlocks, false, errors.New("SendBlocks RPC timed and never received any blocks"), outcomeFailed},
+		// peers requesting unknown blocks aren't sending invalid blocks
+		{rpcSendBlocks, true, invalid, outcomeFailed},
+		{rpcSendBlk, false, invalid, outcomeInvalidBlock},
+		{rpcSendCmpctBlk, false, invalid, outcomeInvalidBlock},
+		{rpcRelayTransactionSet, true, errors.New("transaction spends a nonexisting coin output"), outcomeInvalidTransactionSet},
+		{rpcRelayTransactionSet, true, modules.ErrDuplicateTransactionSet, outcomeNeutral},
+		{"ShareNodes", false, invalid, outcomeFailed},
+	}
+	for _, tc := range testCases {
+		if o := classifyRPC(tc.rpc, tc.inbound, tc.err); o != tc.outcome {
+			t.Errorf("%s (inbound: %v) returning %v: expected outcome %d, got %d", tc.rpc, tc.inbound, tc.err, tc.outcome, o)
+		}
+	}
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	testCases := []struct {
+		addr, expected modules.NetAddress
+	}{
+		{"203.0.113.7:22112", "203.0.113.7:22112"},
+		{"[2001:db8::7]:22112", "[2001:db8::7]:22112"},
+		{"2001:db8::7:22112", "[2001:db8::7]:22112"},
+		{"localhost:22112", "localhost:22112"},
+		{"invalid", "invalid"},
+	}
+	for _, tc := range testCases {
+		if addr := normalizeAddress(tc.addr); addr != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.addr, tc.expected, addr)
+		}
+	}
+}
+
+func TestGatewayScores(t *testing.T) {
+	const (
+		good modules.NetAddress = "1.1.1.1:23112"
+		bad  modules.NetAddress = "2.2.2.2:23112"
+	)
+	sg, g, now := newTestGateway(t, "", good, bad)
+	defer sg.Close()
+
+	invalid := errors.New("invalid transaction")
+	sg.RegisterRPC(rpcRelayTransactionSet, func(conn modules.PeerConn) error {
+		if conn.RPCAddr() == bad {
+			return invalid
+		}
+		return nil
+	})
+	if err := g.call(good, rpcRelayTransactionSet); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.call(bad, rpcRelayTransactionSet); err != invalid {
+		t.Fatal("expected the RPC error, got", err)
+	}
+	if err := sg.RPC(bad, rpcSendBlocks, func(modules.PeerConn) error { return io.EOF }); err != io.EOF {
+		t.Fatal("expected the RPC error, got", err)
+	}
+
+	scores := sg.Scores()
+	if len(scores) != 2 || scores[0].NetAddress != good || scores[1].NetAddress != bad {
+		t.Fatal("unexpected scores:", scores)
+	}
+	if scores[0].Score != MaxScore || scores[0].SuccessfulRPCs != 1 {
+		t.Error("unexpected score of the good peer:", scores[0])
+	}
+	if expected := float64(MaxScore - InvalidTransactionSetPenalty - FailedRPCPenalty); scores[1].Score != expected ||
+		scores[1].InvalidTransactionSets != 1 || scores[1].FailedRPCs != 1 {
+		t.Errorf("expected score %v of the bad peer, got %v", expected, scores[1])
+	}
+	// the penalties are halved after their half-life
+	*now = now.Add(PenaltyHalfLife)
+	if expected := float64(MaxScore - (InvalidTransactionSetPenalty+FailedRPCPenalty)/2); sg.Scores()[1].Score != expected {
+		t.Errorf("expected decayed score %v of the bad peer, got %v", expected, sg.Scores()[1].Score)
+	}
+
+	// peers are ordered by score
+	g.peers = []modules.Peer{{NetAddress: bad}, {NetAddress: good}}
+	if peers := sg.Peers(); len(peers) != 2 || peers[0].NetAddress != good {
+		t.Error("expected the good peer to be preferred, got", peers)
+	}
+
+	// uptime is tracked while connected, both peers being connected since the scores were first listed
+	*now = now.Add(time.Hour)
+	g.Disconnect(good)
+	sg.sweep()
+	*now = now.Add(time.Hour)
+	for _, score := range sg.Scores() {
+		if score.NetAddress == good && (score.Connected || score.Uptime != 2*time.Hour) {
+			t.Error("expected two hours of uptime of the disconnected good peer, got", score)
+		}
+		if score.NetAddress == bad && (!score.Connected || score.Uptime != 3*time.Hour) {
+			t.Error("expected three hours of uptime of the connected bad peer, got", score)
+		}
+	}
+}
+
+func TestGatewayBans(t *testing.T) {
+	const (
+		good modules.NetAddress = "1.1.1.1:23112"
+		bad  modules.NetAddress = "2.2.2.2:23112"
+	)
+	filename := filepath.Join(t.TempDir(), BansFile)
+	sg, g, now := newTestGateway(t, filename, good, bad)
+
+	called := 0
+	sg.RegisterRPC(rpcRelayHeader, func(conn modules.PeerConn) error {
+		called++
+		if conn.RPCAddr() == bad {
+			return errors.New("block timestamp is too early")
+		}
+		return nil
+	})
+	for i := 0; i*InvalidBlockPenalty <= MaxScore; i++ {
+		g.call(bad, rpcRelayHeader)
+	}
+	bans := sg.Bans()
+	if len(bans) != 1 || bans[0].Host != "2.2.2.2" || !bans[0].Until.Equal(now.Add(DefaultPolicy().BanDuration)) {
+		t.Fatal("expected the bad host to be banned, got", bans)
+	}
+	// disconnecting is done asynchronously
+	for i := 0; len(sg.Peers()) != 1 || len(g.Peers()) != 1; i++ {
+		if i == 100 {
+			t.Fatal("expected the bad peer to be disconnected, got", g.Peers())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// banned peers can't call nor be called, and cannot be connected to
+	called = 0
+	if err := g.call(bad, rpcRelayHeader); err != errPeerBanned || called != 0 {
+		t.Error("expected the RPC of the banned peer to be refused, got", err)
+	}
+	if err := sg.RPC(bad, rpcSendBlk, func(modules.PeerConn) error { return nil }); err != errPeerBanned {
+		t.Error("expected the RPC on the banned peer to be refused, got", err)
+	}
+	if err := sg.Connect("2.2.2.2:23113"); err == nil {
+		t.Error("expected connecting to the banned host to be refused")
+	}
+	if err := sg.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// bans are persisted
+	sg, _, now = newTestGateway(t, filename, good)
+	defer sg.Close()
+	if bans := sg.Bans(); len(bans) != 1 || bans[0].Host != "2.2.2.2" {
+		t.Fatal("expected the ban to be loaded, got", bans)
+	}
+	if err := sg.Unban("2.2.2.2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := sg.Unban("2.2.2.2"); err != ErrNotBanned {
+		t.Error("expected the host not to be banned anymore, got", err)
+	}
+	if err := sg.Ban("3.3.3.3", time.Hour, "manual"); err != nil {
+		t.Fatal(err)
+	}
+	// bans expire
+	*now = now.Add(time.Hour)
+	if bans := sg.Bans(); len(bans) != 0 {
+		t.Error("expected the ban to be expired, got", bans)
+	}
+}
+
+func TestBucket(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newBucket(2, 4)
+	for i := 0; i < 4; i++ {
+		if !b.allow(now) {
+			t.Fatal("expected the burst to be allowed, refused message", i)
+		}
+	}
+	if b.allow(now) {
+		t.Fatal("expected the message exceeding the burst to be refused")
+	}
+	if !b.allow(now.Add(500*time.Millisecond)) || b.allow(now.Add(500*time.Millisecond)) {
+		t.Fatal("expected a single message to be allowed after half a second")
+	}
+
+	if newBandwidthBucket(0) != nil {
+		t.Fatal("expected no bucket for an unlimited rate")
+	}
+	b = newBandwidthBucket(1000)
+	if b.chunk() != minBandwidthBurst {
+		t.Fatalf("expected a burst of %d bytes, got %d", minBandwidthBurst, b.chunk())
+	}
+	if wait := b.reserve(minBandwidthBurst, now); wait != 0 {
+		t.Fatal("expected the burst to be sent immediately, got a wait of", wait)
+	}
+	if wait := b.reserve(2000, now); wait != 2*time.Second {
+		t.Fatal("expected to wait 2 seconds, got", wait)
+	}
+	if wait := b.reserve(1000, now.Add(time.Second)); wait != 2*time.Second {
+		t.Fatal("expected to wait 2 seconds, got", wait)
+	}
+}
+
+func TestGatewayLimits(t *testing.T) {
+	const peer modules.NetAddress = "1.1.1.1:23112"
+	sg, g, _ := newTestGatewayWithLimits(t, "", Limits{PeerMessageRate: 0.5}, peer)
+	defer sg.Close()
+
+	sg.RegisterRPC(rpcRelayTransactionSet, func(modules.PeerConn) error { return nil })
+	burst := int(0.5 * messageBurstDuration.Seconds())
+	for i := 0; i < burst; i++ {
+		if err := g.call(peer, rpcRelayTransactionSet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.call(peer, rpcRelayTransactionSet); err != errRateLimited {
+		t.Fatal("expected the RPC exceeding the message rate to be refused, got", err)
+	}
+
+	// broadcasts are metered
+	sg.Broadcast(rpcRelayHeader, []byte{1, 2, 3}, sg.Peers())
+	scores := sg.Scores()
+	if len(scores) != 1 || scores[0].RateLimitedRPCs != 1 || scores[0].SuccessfulRPCs != uint64(burst)+1 || scores[0].Score != MaxScore {
+		t.Fatal("expected the rate limited RPC to be counted without penalty, got", scores)
+	}
+	// the length prefix of the encoded object, followed by the length prefixed object
+	if scores[0].BytesUploaded != 8+8+3 {
+		t.Fatal("expected 19 bytes to be uploaded, got", scores[0].BytesUploaded)
+	}
+}
@@ -0,0 +1,229 @@
+package peers
+
+import (
+	"errors"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	siasync "github.com/threefoldtech/rivine/sync"
+)
+
+// penalties subtracted from the score of a peer, which recovers over time
+const (
+	// MaxScore is the score of a peer without any (recent) penalties
+	MaxScore = 100
+	// InvalidBlockPenalty is the penalty of relaying an invalid block or block header,
+	// or sending undecodable block data
+	InvalidBlockPenalty = 50
+	// InvalidTransactionSetPenalty is the penalty of relaying an invalid or undecodable transaction set
+	InvalidTransactionSetPenalty = 10
+	// FailedRPCPenalty is the penalty of an RPC with the peer failing on the connection,
+	// e.g. because the peer timed out or disconnected
+	FailedRPCPenalty = 2
+	// PenaltyHalfLife is the duration after which half of the penalties of a peer are forgiven
+	PenaltyHalfLife = time.Hour
+)
+
+// Policy defines which peers are banned.
+type Policy struct {
+	// BanScore is the score below which a peer is banned
+	BanScore float64 `json:"banscore"`
+	// BanDuration is the duration of a ban, 0 disables banning
+	BanDuration time.Duration `json:"banduration"`
+}
+
+// DefaultPolicy returns the default ban policy,
+// banning peers for a day when their score drops below 0.
+func DefaultPolicy() Policy {
+	return Policy{
+		BanScore:    0,
+		BanDuration: 24 * time.Hour,
+	}
+}
+
+// Metrics are the statistics kept for a peer, since the peer was first seen.
+type Metrics struct {
+	InvalidBlocks          uint64 `json:"invalidblocks"`
+	InvalidTransactionSets uint64 `json:"invalidtransactionsets"`
+	FailedRPCs             uint64 `json:"failedrpcs"`
+	SuccessfulRPCs         uint64 `json:"successfulrpcs"`
+	// RateLimitedRPCs is the amount of RPCs called by the peer which were refused, as it exceeded the message rate
+	RateLimitedRPCs uint64 `json:"ratelimitedrpcs"`
+	// BytesUploaded and BytesDownloaded are the amount of bytes sent to and received from the peer,
+	// during the RPCs registered and called through the Gateway
+	BytesUploaded   uint64 `json:"bytesuploaded"`
+	BytesDownloaded uint64 `json:"bytesdownloaded"`
+	// Latency is the moving average of the duration until the peer responds to the RPCs called on it,
+	// 0 if no RPC was called on the peer yet
+	Latency time.Duration `json:"latency"`
+	// FirstSeen is the time the peer was first connected
+	FirstSeen time.Time `json:"firstseen"`
+	// Uptime is the total duration the peer has been connected since it was first seen
+	Uptime time.Duration `json:"uptime"`
+}
+
+// PeerScore is the score of a (recently connected) peer, along with its metrics.
+type PeerScore struct {
+	NetAddress modules.NetAddress `json:"netaddress"`
+	// Score is MaxScore minus the penalties of the peer, decaying over time,
+	// the peer is banned when its score drops below the BanScore of the Policy
+	Score     float64 `json:"score"`
+	Connected bool    `json:"connected"`
+	Metrics
+}
+
+// Ban is a host of which all connections are refused until the ban expires.
+type Ban struct {
+	Host   string    `json:"host"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason"`
+}
+
+// outcome is the outcome of an RPC with a peer, as it affects the score of the peer
+type outcome uint8
+
+const (
+	outcomeSuccess outcome = iota
+	// outcomeNeutral is an RPC that failed without it being the fault of the peer
+	outcomeNeutral
+	outcomeFailed
+	outcomeInvalidBlock
+	outcomeInvalidTransactionSet
+)
+
+// the RPCs of the consensus set, transaction pool and compact block relay of which the errors identify invalid data
+const (
+	rpcRelayHeader         = "RelayHeader"
+	rpcSendBlocks          = "SendBlocks"
+	rpcSendBlk             = "SendBlk"
+	rpcSendCmpctBlk        = "SendCmpctBlk"
+	rpcRelayTransactionSet = "RelayTransactionSet"
+)
+
+// connectionErrorMessages are the messages of the (unexported) errors of the smux session and
+// consensus set which indicate a failing connection rather than invalid data
+var connectionErrorMessages = map[string]struct{}{
+	"broken pipe":   {},
+	"Read timeout":  {},
+	"Write timeout": {},
+	"SendBlocks RPC timed and never received any blocks": {},
+}
+
+// errPeerBanned is returned for all RPCs with a banned peer
+var errPeerBanned = errors.New("peer is banned")
+
+// classifyRPC classifies the error returned by the RPC function of the given RPC,
+// handling an RPC called by the peer should inbound be true, or calling it on the peer otherwise.
+func classifyRPC(rpc string, inbound bool, err error) outcome {
+	switch err {
+	case nil:
+		return outcomeSuccess
+	case modules.ErrBlockKnown, modules.ErrNonExtendingBlock, modules.ErrDuplicateTransactionSet, siasync.ErrStopped, errPeerBanned, errRateLimited:
+		return outcomeNeutral
+	}
+	if isConnectionError(err) {
+		return outcomeFailed
+	}
+	// only the RPCs receiving data from the peer can fail because of the peer,
+	// the RPCs sending data fail because of the peer requesting unknown data at most
+	switch {
+	case inbound && rpc == rpcRelayHeader, !inbound && (rpc == rpcSendBlocks || rpc == rpcSendBlk || rpc == rpcSendCmpctBlk):
+		return outcomeInvalidBlock
+	case inbound && rpc == rpcRelayTransactionSet:
+		return outcomeInvalidTransactionSet
+	}
+	return outcomeFailed
+}
+
+// isConnectionError returns true if the error was caused by the connection failing.
+func isConnectionError(err error) bool {
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF || err == io.ErrClosedPipe {
+		return true
+	}
+	_, ok := connectionErrorMessages[err.Error()]
+	return ok
+}
+
+// peerState is the state kept for a peer
+type peerState struct {
+	metrics Metrics
+	// penalty is the sum of the penalties of the peer, as decayed until penaltyTime
+	penalty     float64
+	penaltyTime time.Time
+	// connectedSince is the time the peer was connected, zero if it isn't connected
+	connectedSince time.Time
+	// lastSeen is the last time the peer was connected
+	lastSeen time.Time
+	// messages limits the rate of the RPCs called by the peer, nil until the peer calls its first RPC
+	messages *bucket
+}
+
+// decayedPenalty returns the penalty of the peer at the given time.
+func (state *peerState) decayedPenalty(now time.Time) float64 {
+	if state.penalty == 0 {
+		return 0
+	}
+	return state.penalty * math.Pow(0.5, float64(now.Sub(state.penaltyTime))/float64(PenaltyHalfLife))
+}
+
+// score returns the score of the peer at the given time.
+func (state *peerState) score(now time.Time) float64 {
+	return MaxScore - state.decayedPenalty(now)
+}
+
+// penalize adds the given penalty to the penalties of the peer.
+func (state *peerState) penalize(penalty float64, now time.Time) {
+	state.penalty = state.decayedPenalty(now) + penalty
+	state.penaltyTime = now
+}
+
+// record records the outcome of an RPC with the peer, returning the penalty it incurred.
+func (state *peerState) record(o outcome, now time.Time) float64 {
+	var penalty float64
+	switch o {
+	case outcomeSuccess:
+		state.metrics.SuccessfulRPCs++
+	case outcomeFailed:
+		state.metrics.FailedRPCs++
+		penalty = FailedRPCPenalty
+	case outcomeInvalidBlock:
+		state.metrics.InvalidBlocks++
+		penalty = InvalidBlockPenalty
+	case outcomeInvalidTransactionSet:
+		state.metrics.InvalidTransactionSets++
+		penalty = InvalidTransactionSetPenalty
+	}
+	if penalty > 0 {
+		state.penalize(penalty, now)
+	}
+	return penalty
+}
+
+// recordLatency adds the given response time to the moving average latency of the peer.
+func (state *peerState) recordLatency(d time.Duration) {
+	if state.metrics.Latency == 0 {
+		state.metrics.Latency = d
+		return
+	}
+	state.metrics.Latency = (state.metrics.Latency*7 + d) / 8
+}
+
+// peerScore returns the score of the peer with the given address at the given time.
+func (state *peerState) peerScore(addr modules.NetAddress, now time.Time) PeerScore {
+	ps := PeerScore{
+		NetAddress: addr,
+		Score:      math.Round(state.score(now)*100) / 100,
+		Connected:  !state.connectedSince.IsZero(),
+		Metrics:    state.metrics,
+	}
+	if ps.Connected {
+		ps.Uptime += now.Sub(state.connectedSince)
+	}
+	return ps
+}
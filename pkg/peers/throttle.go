@@ -0,0 +1,153 @@
+package peers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+)
+
+const (
+	// minBandwidthBurst is the minimum amount of bytes which can be sent or received at once,
+	// such that low bandwidth limits don't result in tiny writes and reads
+	minBandwidthBurst = 4096
+	// messageBurstDuration is the duration of RPCs a peer can call in a burst
+	messageBurstDuration = 10 * time.Second
+)
+
+// errRateLimited is returned for the RPCs called by a peer exceeding the message rate
+var errRateLimited = errors.New("peer exceeds the message rate")
+
+// Limits limits the bandwidth used for the RPCs of the peers, as well as the rate at which a peer can call RPCs,
+// such that a node doesn't saturate its connection, e.g. while a peer is syncing from it.
+type Limits struct {
+	// UploadRate is the maximum amount of bytes per second sent to all peers together, 0 is unlimited
+	UploadRate uint64 `json:"uploadrate"`
+	// DownloadRate is the maximum amount of bytes per second received from all peers together, 0 is unlimited
+	DownloadRate uint64 `json:"downloadrate"`
+	// PeerMessageRate is the maximum amount of RPCs per second a single peer can call, 0 is unlimited,
+	// a peer can call up to 10 seconds worth of RPCs in a burst
+	PeerMessageRate float64 `json:"peermessagerate"`
+}
+
+// bucket is a token bucket limiting the rate of bytes or messages.
+type bucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// newBucket creates a full bucket, refilled with the given amount of tokens per second, up to the given burst.
+func newBucket(rate, burst float64) *bucket {
+	return &bucket{rate: rate, burst: burst, tokens: burst}
+}
+
+// newBandwidthBucket creates a bucket for the given amount of bytes per second, nil if unlimited.
+func newBandwidthBucket(rate uint64) *bucket {
+	if rate == 0 {
+		return nil
+	}
+	burst := float64(rate)
+	if burst < minBandwidthBurst {
+		burst = minBandwidthBurst
+	}
+	return newBucket(float64(rate), burst)
+}
+
+// refill adds the tokens accumulated since the last refill.
+func (b *bucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+}
+
+// allow takes a token, returning false if none is available.
+func (b *bucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve takes the given amount of tokens, going in debt if they aren't available,
+// returning the duration to wait until the debt is paid off.
+func (b *bucket) reserve(n int, now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// chunk returns the maximum amount of bytes to write or read at once.
+func (b *bucket) chunk() int {
+	return int(b.burst)
+}
+
+// meteredConn counts the bytes sent and received on a connection, throttling them should a bucket be defined,
+// and records the time of its first read, as the response time of the peer.
+type meteredConn struct {
+	modules.PeerConn
+	upload, download *bucket
+
+	start     time.Time
+	firstRead time.Time
+	read      uint64
+	written   uint64
+}
+
+func (mc *meteredConn) Read(b []byte) (int, error) {
+	if mc.download != nil && len(b) > mc.download.chunk() {
+		b = b[:mc.download.chunk()]
+	}
+	n, err := mc.PeerConn.Read(b)
+	if n > 0 {
+		if mc.firstRead.IsZero() {
+			mc.firstRead = time.Now()
+		}
+		mc.read += uint64(n)
+		if mc.download != nil {
+			time.Sleep(mc.download.reserve(n, time.Now()))
+		}
+	}
+	return n, err
+}
+
+func (mc *meteredConn) Write(b []byte) (int, error) {
+	if mc.upload == nil {
+		n, err := mc.PeerConn.Write(b)
+		mc.written += uint64(n)
+		return n, err
+	}
+	var written int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > mc.upload.chunk() {
+			chunk = chunk[:mc.upload.chunk()]
+		}
+		time.Sleep(mc.upload.reserve(len(chunk), time.Now()))
+		n, err := mc.PeerConn.Write(chunk)
+		written += n
+		mc.written += uint64(n)
+		if err != nil {
+			return written, err
+		}
+		b = b[n:]
+	}
+	return written, nil
+}
@@ -0,0 +1,453 @@
+package pluginstats
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DefaultStallThreshold is the duration after which a plugin still busy applying or reverting
+// a block or transaction is reported as stalled.
+const DefaultStallThreshold = 30 * time.Second
+
+// DegradedFile is the name of the file in which the degraded plugins are stored.
+const DegradedFile = "degradedplugins.json"
+
+// ErrDegraded is returned when registering a plugin which was degraded before,
+// as its data no longer matches the blockchain.
+var ErrDegraded = errors.New("the plugin failed and was degraded, remove the consensus directory to resync it")
+
+// ErrCatchUpUnsupported is returned by an informational plugin registered on a consensus set
+// which already applied blocks the plugin needs: the consensus set replays these blocks within a read-only
+// database transaction, such that the plugin cannot store the data it derives from them.
+var ErrCatchUpUnsupported = errors.New("the plugin cannot catch up with the blocks applied before it was registered, remove the consensus directory to resync")
+
+var degradedMetadata = persist.Metadata{
+	Header:  "Goldchain Degraded Plugins",
+	Version: "1.0",
+}
+
+type (
+	// CallStats are the statistics of the calls to a single method of a plugin.
+	CallStats struct {
+		Calls  uint64 `json:"calls"`
+		Errors uint64 `json:"errors"`
+		// TotalMicroseconds is the time spent in all calls together
+		TotalMicroseconds uint64 `json:"totalmicroseconds"`
+		// MaxMicroseconds is the duration of the slowest call
+		MaxMicroseconds uint64 `json:"maxmicroseconds"`
+		// Timeouts is the amount of calls which exceeded the timeout
+		Timeouts uint64 `json:"timeouts"`
+	}
+
+	// Config configures the supervision of the plugins by a monitor.
+	Config struct {
+		// StallThreshold is the duration after which a busy plugin is reported as stalled
+		StallThreshold time.Duration
+		// Timeout is the maximum duration of a single call, 0 disables the timeout.
+		// A running call cannot be interrupted, such that a call exceeding the timeout
+		// is only reported, unless the plugin can be degraded.
+		Timeout time.Duration
+		// Degrade non-critical plugins which fail, panic or exceed the timeout,
+		// skipping all of their calls from then on, instead of failing the block being applied
+		Degrade bool
+		// DegradedFile is the path of the file storing the degraded plugins,
+		// such that they stay disabled once the node restarts, they are only kept in memory if empty
+		DegradedFile string
+	}
+
+	// PanicError is the error returned for a call of a plugin which panicked,
+	// such that the consensus set refuses the block rather than crashing the node.
+	PanicError struct {
+		Plugin string
+		Call   string
+		// Value is the value passed to panic
+		Value interface{}
+		// Stack is the stack trace of the goroutine at the time of the panic
+		Stack []byte
+	}
+
+	// Status is the status of a consensus set plugin.
+	Status struct {
+		Name string `json:"name"`
+		// Critical plugins validate the blockchain, and are never degraded
+		Critical bool `json:"critical"`
+		// Healthy is false as long as there are problems
+		Healthy bool `json:"healthy"`
+		// Problems describes all detected problems, most likely stalling block application
+		Problems []string `json:"problems"`
+		// Degraded plugins are skipped, as they failed, such that their data is no longer up to date
+		Degraded       bool   `json:"degraded"`
+		DegradedReason string `json:"degradedreason,omitempty"`
+
+		// BucketKeys is the amount of key-value pairs stored by the plugin, nested buckets included
+		BucketKeys int `json:"bucketkeys"`
+		// BucketBytes is the amount of bytes in use by the data of the plugin
+		BucketBytes int `json:"bucketbytes"`
+
+		// LastAppliedHeight is the height of the last block applied to the plugin
+		LastAppliedHeight types.BlockHeight `json:"lastappliedheight"`
+
+		ApplyBlock        CallStats `json:"applyblock"`
+		RevertBlock       CallStats `json:"revertblock"`
+		ApplyTransaction  CallStats `json:"applytransaction"`
+		RevertTransaction CallStats `json:"reverttransaction"`
+
+		// LastError is the error returned by the most recent failed call, if any
+		LastError     string          `json:"lasterror,omitempty"`
+		LastErrorTime types.Timestamp `json:"lasterrortime,omitempty"`
+		// BusyCall is the method the plugin is currently executing, if any, since BusySince
+		BusyCall  string          `json:"busycall,omitempty"`
+		BusySince types.Timestamp `json:"busysince,omitempty"`
+	}
+)
+
+// Error implements error.Error
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("plugin %s panicked in %s: %v", e.Plugin, e.Call, e.Value)
+}
+
+// Monitor keeps track of the calls to the consensus set plugins wrapped by it,
+// such that a slow or failing plugin, stalling block application, can be identified.
+// It supervises the plugins as well, recovering their panics, and degrading failing non-critical plugins if configured.
+type Monitor struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	plugins  []*meteredPlugin
+	degraded map[string]string
+}
+
+// NewMonitor creates a monitor supervising the plugins as configured,
+// loading the plugins degraded before from the degraded file.
+func NewMonitor(cfg Config) (*Monitor, error) {
+	m := &Monitor{
+		cfg:      cfg,
+		now:      time.Now,
+		degraded: make(map[string]string),
+	}
+	if cfg.DegradedFile != "" {
+		err := persist.LoadJSON(degradedMetadata, &m.degraded, cfg.DegradedFile)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to load degraded plugins: %v", err)
+		}
+	}
+	return m, nil
+}
+
+// Degraded returns the reason the plugin with the given name was degraded,
+// false in case it never was.
+func (m *Monitor) Degraded(name string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	reason, ok := m.degraded[name]
+	return reason, ok
+}
+
+// Wrap returns the plugin wrapped such that all of its calls are monitored and supervised,
+// which is to be registered to the consensus set instead of the plugin itself.
+// The given height is the current height of the consensus set,
+// being the height of the last block applied to the plugin once registered.
+// Critical plugins, validating the blockchain, are never degraded.
+func (m *Monitor) Wrap(name string, plugin modules.ConsensusSetPlugin, height types.BlockHeight, critical bool) modules.ConsensusSetPlugin {
+	mp := &meteredPlugin{
+		ConsensusSetPlugin: plugin,
+		monitor:            m,
+		name:               name,
+		critical:           critical,
+		lastAppliedHeight:  height,
+	}
+	m.mu.Lock()
+	m.plugins = append(m.plugins, mp)
+	m.mu.Unlock()
+	return mp
+}
+
+// Status returns the status of all monitored plugins, ordered by name.
+func (m *Monitor) Status() []Status {
+	m.mu.Lock()
+	plugins := make([]*meteredPlugin, len(m.plugins))
+	copy(plugins, m.plugins)
+	m.mu.Unlock()
+	statuses := make([]Status, 0, len(plugins))
+	for _, mp := range plugins {
+		if status, ok := mp.status(); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Remove stops monitoring the given wrapped plugin,
+// which is to be called in case it could not be registered to the consensus set.
+func (m *Monitor) Remove(plugin modules.ConsensusSetPlugin) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.plugins {
+		if m.plugins[i] == plugin {
+			m.plugins = append(m.plugins[:i], m.plugins[i+1:]...)
+			return
+		}
+	}
+}
+
+// degrade stores the plugin with the given name as degraded.
+func (m *Monitor) degrade(name, reason string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.degraded[name] = reason
+	if m.cfg.DegradedFile == "" {
+		return nil
+	}
+	return persist.SaveJSON(degradedMetadata, m.degraded, m.cfg.DegradedFile)
+}
+
+// meteredPlugin wraps a consensus set plugin, recording the statistics of its calls.
+type meteredPlugin struct {
+	modules.ConsensusSetPlugin
+	monitor  *Monitor
+	name     string
+	critical bool
+
+	mu                sync.Mutex
+	registered        bool
+	degradedReason    string
+	degradeError      error
+	storage           modules.PluginViewStorage
+	lastAppliedHeight types.BlockHeight
+	applyBlock        CallStats
+	revertBlock       CallStats
+	applyTransaction  CallStats
+	revertTransaction CallStats
+	lastError         string
+	lastErrorTime     time.Time
+	busyCall          string
+	busySince         time.Time
+}
+
+// InitPlugin implements modules.ConsensusSetPlugin.InitPlugin,
+// unregistering the wrapper whenever the plugin unregisters itself.
+func (mp *meteredPlugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	mp.mu.Lock()
+	mp.registered = true
+	mp.storage = storage
+	mp.mu.Unlock()
+	return mp.ConsensusSetPlugin.InitPlugin(metadata, bucket, storage, func(modules.ConsensusSetPlugin) {
+		mp.unregister()
+		if unregisterCallback != nil {
+			unregisterCallback(mp)
+		}
+	})
+}
+
+// ApplyBlock implements modules.ConsensusSetPlugin.ApplyBlock
+func (mp *meteredPlugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return mp.call("ApplyBlock", &mp.applyBlock, func() error {
+		err := mp.ConsensusSetPlugin.ApplyBlock(block, height, bucket)
+		if err == nil {
+			mp.mu.Lock()
+			mp.lastAppliedHeight = height
+			mp.mu.Unlock()
+		}
+		return err
+	})
+}
+
+// RevertBlock implements modules.ConsensusSetPlugin.RevertBlock
+func (mp *meteredPlugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return mp.call("RevertBlock", &mp.revertBlock, func() error {
+		err := mp.ConsensusSetPlugin.RevertBlock(block, height, bucket)
+		if err == nil && height > 0 {
+			mp.mu.Lock()
+			mp.lastAppliedHeight = height - 1
+			mp.mu.Unlock()
+		}
+		return err
+	})
+}
+
+// ApplyTransaction implements modules.ConsensusSetPlugin.ApplyTransaction
+func (mp *meteredPlugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return mp.call("ApplyTransaction", &mp.applyTransaction, func() error {
+		return mp.ConsensusSetPlugin.ApplyTransaction(txn, block, height, bucket)
+	})
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin.RevertTransaction
+func (mp *meteredPlugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return mp.call("RevertTransaction", &mp.revertTransaction, func() error {
+		return mp.ConsensusSetPlugin.RevertTransaction(txn, block, height, bucket)
+	})
+}
+
+// Close implements modules.ConsensusSetPlugin.Close
+func (mp *meteredPlugin) Close() error {
+	mp.unregister()
+	return mp.ConsensusSetPlugin.Close()
+}
+
+func (mp *meteredPlugin) unregister() {
+	mp.mu.Lock()
+	mp.registered = false
+	mp.storage = nil
+	mp.mu.Unlock()
+	mp.monitor.Remove(mp)
+}
+
+// call executes the given call of the plugin, recording its statistics,
+// and returns the error to be returned to the consensus set.
+// The calls of a degraded plugin are skipped, and the failures of a non-critical plugin
+// degrade the plugin instead of being returned, should plugins be degraded.
+func (mp *meteredPlugin) call(call string, stats *CallStats, fn func() error) error {
+	mp.mu.Lock()
+	degraded := mp.degradedReason != ""
+	mp.mu.Unlock()
+	if degraded {
+		return nil
+	}
+	done := mp.start(call)
+	err := mp.protect(call, fn)
+	timedOut := done(stats, err)
+	if mp.critical || !mp.monitor.cfg.Degrade {
+		return err
+	}
+	if err != nil {
+		mp.degrade(fmt.Sprintf("%s failed: %v", call, err))
+	} else if timedOut {
+		mp.degrade(fmt.Sprintf("%s exceeded the timeout of %v", call, mp.monitor.cfg.Timeout))
+	}
+	return nil
+}
+
+// protect executes the given call, returning a panic of the call as a PanicError.
+func (mp *meteredPlugin) protect(call string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{
+				Plugin: mp.name,
+				Call:   call,
+				Value:  r,
+				Stack:  debug.Stack(),
+			}
+		}
+	}()
+	return fn()
+}
+
+// degrade marks the plugin as degraded for the given reason,
+// such that all of its calls are skipped from then on.
+func (mp *meteredPlugin) degrade(reason string) {
+	err := mp.monitor.degrade(mp.name, reason)
+	mp.mu.Lock()
+	mp.degradedReason = reason
+	mp.degradeError = err
+	mp.mu.Unlock()
+}
+
+// start marks the plugin as busy executing the given call,
+// returning the function recording the statistics of the call once done,
+// which returns whether the call exceeded the timeout.
+func (mp *meteredPlugin) start(call string) func(stats *CallStats, err error) bool {
+	started := mp.monitor.now()
+	mp.mu.Lock()
+	mp.busyCall = call
+	mp.busySince = started
+	mp.mu.Unlock()
+	return func(stats *CallStats, err error) bool {
+		now := mp.monitor.now()
+		elapsed := now.Sub(started)
+		duration := uint64(elapsed / time.Microsecond)
+		mp.mu.Lock()
+		defer mp.mu.Unlock()
+		mp.busyCall = ""
+		stats.Calls++
+		stats.TotalMicroseconds += duration
+		if duration > stats.MaxMicroseconds {
+			stats.MaxMicroseconds = duration
+		}
+		if err != nil {
+			stats.Errors++
+			mp.lastError = fmt.Sprintf("%s: %v", call, err)
+			mp.lastErrorTime = now
+		} else {
+			mp.lastError = ""
+		}
+		if timeout := mp.monitor.cfg.Timeout; timeout > 0 && elapsed > timeout {
+			stats.Timeouts++
+			return true
+		}
+		return false
+	}
+}
+
+// status returns the status of the plugin, false in case it is no longer registered.
+func (mp *meteredPlugin) status() (Status, bool) {
+	mp.mu.Lock()
+	if !mp.registered {
+		mp.mu.Unlock()
+		return Status{}, false
+	}
+	status := Status{
+		Name:              mp.name,
+		Critical:          mp.critical,
+		Problems:          []string{},
+		Degraded:          mp.degradedReason != "",
+		DegradedReason:    mp.degradedReason,
+		LastAppliedHeight: mp.lastAppliedHeight,
+		ApplyBlock:        mp.applyBlock,
+		RevertBlock:       mp.revertBlock,
+		ApplyTransaction:  mp.applyTransaction,
+		RevertTransaction: mp.revertTransaction,
+		LastError:         mp.lastError,
+		BusyCall:          mp.busyCall,
+	}
+	if mp.degradedReason != "" {
+		status.Problems = append(status.Problems, "degraded: "+mp.degradedReason)
+		if mp.degradeError != nil {
+			status.Problems = append(status.Problems, "failed to store the degradation: "+mp.degradeError.Error())
+		}
+	}
+	if mp.lastError != "" {
+		status.LastErrorTime = types.Timestamp(mp.lastErrorTime.Unix())
+		status.Problems = append(status.Problems, "last call failed: "+mp.lastError)
+	}
+	if mp.busyCall != "" {
+		status.BusySince = types.Timestamp(mp.busySince.Unix())
+		if busy := mp.monitor.now().Sub(mp.busySince); busy > mp.monitor.cfg.StallThreshold {
+			status.Problems = append(status.Problems, fmt.Sprintf("stalled in %s for %v", mp.busyCall, busy.Round(time.Second)))
+		}
+	}
+	storage := mp.storage
+	mp.mu.Unlock()
+
+	if storage != nil {
+		err := storage.View(func(bucket *bolt.Bucket) error {
+			stats := bucket.Stats()
+			status.BucketKeys = stats.KeyN
+			status.BucketBytes = stats.BranchInuse + stats.LeafInuse
+			if status.BucketBytes == 0 {
+				// small buckets are stored inline, within the page of their parent
+				status.BucketBytes = stats.InlineBucketInuse
+			}
+			return nil
+		})
+		if err != nil {
+			status.Problems = append(status.Problems, "failed to read bucket: "+err.Error())
+		}
+	}
+	status.Healthy = len(status.Problems) == 0
+	return status, true
+}
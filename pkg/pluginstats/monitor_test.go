@@ -0,0 +1,233 @@
+package pluginstats
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testPlugin stores the height of every applied block, failing to apply blocks while fail is set,
+// panicking while panicValue is set, and blocking while applying blocks until block is closed, should it be set.
+type testPlugin struct {
+	fail       error
+	panicValue interface{}
+	block      chan struct{}
+	unregister modules.PluginUnregisterCallback
+}
+
+func (p *testPlugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.unregister = unregisterCallback
+	return persist.Metadata{}, nil
+}
+
+func (p *testPlugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if p.block != nil {
+		<-p.block
+	}
+	if p.panicValue != nil {
+		panic(p.panicValue)
+	}
+	if p.fail != nil {
+		return p.fail
+	}
+	return bucket.Put([]byte{byte(height)}, []byte("block"))
+}
+
+func (p *testPlugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return bucket.Delete([]byte{byte(height)})
+}
+
+func (p *testPlugin) ApplyTransaction(types.Transaction, types.Block, types.BlockHeight, *persist.LazyBoltBucket) error {
+	return nil
+}
+
+func (p *testPlugin) RevertTransaction(types.Transaction, types.Block, types.BlockHeight, *persist.LazyBoltBucket) error {
+	return nil
+}
+
+func (p *testPlugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+func (p *testPlugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+func (p *testPlugin) Close() error { return nil }
+
+// initTestPlugin registers the plugin wrapped by the monitor to a new test database,
+// returning the function applying a block at a given height, and the function returning the status of the plugin.
+func initTestPlugin(t *testing.T, monitor *Monitor, wrapped modules.ConsensusSetPlugin, unregister modules.PluginUnregisterCallback) (func(types.BlockHeight) error, func() Status) {
+	db := plugintest.NewDB(t, "test")
+	db.InitPlugin(t, wrapped, unregister)
+	apply := func(height types.BlockHeight) error {
+		return db.UpdateBucket(func(bucket *persist.LazyBoltBucket) error {
+			return wrapped.ApplyBlock(types.Block{}, height, bucket)
+		})
+	}
+	status := func() Status {
+		t.Helper()
+		statuses := monitor.Status()
+		if len(statuses) != 1 {
+			t.Fatalf("expected the status of a single plugin, got %d", len(statuses))
+		}
+		return statuses[0]
+	}
+	return apply, status
+}
+
+func TestMonitor(t *testing.T) {
+	monitor, err := NewMonitor(Config{StallThreshold: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	monitor.now = func() time.Time { return now }
+	p := &testPlugin{}
+	wrapped := monitor.Wrap("test", p, 0, true)
+	unregistered := false
+	apply, status := initTestPlugin(t, monitor, wrapped, func(plugin modules.ConsensusSetPlugin) {
+		unregistered = plugin == wrapped
+	})
+
+	for height := types.BlockHeight(0); height < 3; height++ {
+		if err = apply(height); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := status()
+	if !s.Healthy || s.Name != "test" || s.LastAppliedHeight != 2 || s.ApplyBlock.Calls != 3 || s.BucketKeys != 3 || s.BucketBytes == 0 {
+		t.Errorf("unexpected status after applying 3 blocks: %+v", s)
+	}
+
+	// failing calls are reported until a call succeeds again
+	p.fail = errors.New("broken")
+	if err = apply(3); err != p.fail {
+		t.Fatalf("expected the error of the plugin, got %v", err)
+	}
+	s = status()
+	if s.Healthy || s.ApplyBlock.Errors != 1 || s.LastAppliedHeight != 2 || s.LastError != "ApplyBlock: broken" {
+		t.Errorf("unexpected status after a failed call: %+v", s)
+	}
+	p.fail = nil
+	if err = apply(3); err != nil {
+		t.Fatal(err)
+	}
+	if s = status(); !s.Healthy || s.LastAppliedHeight != 3 {
+		t.Errorf("unexpected status after recovering: %+v", s)
+	}
+
+	// plugins busy for longer than the threshold are reported as stalled
+	p.block = make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- apply(4)
+	}()
+	for status().BusyCall == "" {
+		time.Sleep(time.Millisecond)
+	}
+	monitor.now = func() time.Time { return now.Add(2 * time.Minute) }
+	s = status()
+	if s.Healthy || s.BusyCall != "ApplyBlock" || len(s.Problems) != 1 {
+		t.Errorf("expected the plugin to be stalled, got: %+v", s)
+	}
+	close(p.block)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if s = status(); !s.Healthy || s.ApplyBlock.MaxMicroseconds != uint64(2*time.Minute/time.Microsecond) {
+		t.Errorf("unexpected status after the stalled call: %+v", s)
+	}
+
+	// unregistered plugins are no longer monitored
+	p.unregister(p)
+	if !unregistered {
+		t.Error("expected the wrapped plugin to be unregistered")
+	}
+	if statuses := monitor.Status(); len(statuses) != 0 {
+		t.Errorf("expected no status of unregistered plugins, got %v", statuses)
+	}
+}
+
+func TestMonitorSupervision(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), DegradedFile)
+	cfg := Config{
+		StallThreshold: time.Minute,
+		Timeout:        500 * time.Millisecond,
+		Degrade:        true,
+		DegradedFile:   filename,
+	}
+	monitor, err := NewMonitor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every call takes a second
+	now := time.Now()
+	monitor.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// panics are returned as errors, and critical plugins are never degraded
+	critical := &testPlugin{panicValue: "boom"}
+	apply, status := initTestPlugin(t, monitor, monitor.Wrap("critical", critical, 0, true), nil)
+	err = apply(1)
+	if perr, ok := err.(*PanicError); !ok || perr.Plugin != "critical" || perr.Call != "ApplyBlock" || perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Fatalf("expected a panic error, got %v", err)
+	}
+	critical.panicValue = nil
+	if err = apply(1); err != nil {
+		t.Fatal(err)
+	}
+	if s := status(); s.Degraded || !s.Critical || s.ApplyBlock.Timeouts != 2 || s.LastAppliedHeight != 1 {
+		t.Errorf("unexpected status of the critical plugin: %+v", s)
+	}
+	critical.unregister(critical)
+
+	// non-critical plugins are degraded instead, and skipped from then on
+	p := &testPlugin{fail: errors.New("broken")}
+	apply, status = initTestPlugin(t, monitor, monitor.Wrap("test", p, 0, false), nil)
+	if err = apply(1); err != nil {
+		t.Fatalf("expected the failure of a non-critical plugin to be ignored, got %v", err)
+	}
+	p.fail = nil
+	if err = apply(1); err != nil {
+		t.Fatal(err)
+	}
+	if s := status(); s.Healthy || !s.Degraded || s.DegradedReason != "ApplyBlock failed: broken" || s.ApplyBlock.Calls != 1 || s.LastAppliedHeight != 0 {
+		t.Errorf("unexpected status of the degraded plugin: %+v", s)
+	}
+	p.unregister(p)
+
+	// slow non-critical plugins are degraded as well
+	slow := &testPlugin{}
+	apply, status = initTestPlugin(t, monitor, monitor.Wrap("slow", slow, 0, false), nil)
+	if err = apply(1); err != nil {
+		t.Fatal(err)
+	}
+	if s := status(); !s.Degraded || s.ApplyBlock.Timeouts != 1 {
+		t.Errorf("expected the slow plugin to be degraded: %+v", s)
+	}
+
+	// degraded plugins are remembered
+	monitor, err = NewMonitor(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reason, ok := monitor.Degraded("test"); !ok || reason != "ApplyBlock failed: broken" {
+		t.Errorf("expected the test plugin to be degraded, got %q", reason)
+	}
+	if _, ok := monitor.Degraded("slow"); !ok {
+		t.Error("expected the slow plugin to be degraded")
+	}
+	if _, ok := monitor.Degraded("critical"); ok {
+		t.Error("expected the critical plugin not to be degraded")
+	}
+}
@@ -0,0 +1,169 @@
+// Package portmap maps the RPC port of a node on the NAT gateway of its network,
+// using the NAT Port Mapping Protocol (RFC 6886), such that nodes behind NAT are reachable by their peers.
+package portmap
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// Lifetime is the lifetime requested for the mapping, which is renewed halfway its lifetime
+	Lifetime = 2 * time.Hour
+	// retryInterval is the interval at which a failed mapping is retried
+	retryInterval = 5 * time.Minute
+)
+
+// Config configures the port mapping of a node.
+type Config struct {
+	// Gateway is the NAT-PMP server, the default IPv4 gateway of the host if nil
+	Gateway net.IP
+}
+
+// Status is the status of a port mapping.
+type Status struct {
+	Gateway      string `json:"gateway"`
+	InternalPort uint16 `json:"internalport"`
+	// ExternalIP and ExternalPort are the address at which the port is reachable, once it is mapped
+	ExternalIP   string `json:"externalip,omitempty"`
+	ExternalPort uint16 `json:"externalport,omitempty"`
+	// Expires is the time at which the mapping expires, unless it is renewed
+	Expires time.Time `json:"expires"`
+	// Error is the error of the last attempt to map the port, should it have failed
+	Error string `json:"error,omitempty"`
+}
+
+// Mapper maps a TCP port on a NAT gateway, renewing the mapping until it is closed,
+// at which point the mapping is deleted.
+type Mapper struct {
+	client *client
+	output io.Writer
+
+	mu     sync.Mutex
+	status Status
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewMapper creates a Mapper, mapping the given TCP port on the gateway of the given configuration,
+// writing the mappings granted and their failures to the given output.
+func NewMapper(cfg Config, port uint16, output io.Writer) (*Mapper, error) {
+	gateway := cfg.Gateway
+	if gateway == nil {
+		var err error
+		gateway, err = DefaultGateway()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return newMapper(gateway, natpmpPort, port, output), nil
+}
+
+func newMapper(gateway net.IP, gatewayPort int, port uint16, output io.Writer) *Mapper {
+	if output == nil {
+		output = ioutil.Discard
+	}
+	stop := make(chan struct{})
+	m := &Mapper{
+		// abort pending requests when closing
+		client: &client{gateway: &net.UDPAddr{IP: gateway, Port: gatewayPort}, cancel: stop},
+		output: output,
+		status: Status{
+			Gateway:      gateway.String(),
+			InternalPort: port,
+		},
+		stop: stop,
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			wait := m.refresh(time.Now())
+			select {
+			case <-m.stop:
+				return
+			case <-time.After(wait):
+			}
+		}
+	}()
+	return m
+}
+
+// Status returns the status of the port mapping.
+func (m *Mapper) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.status
+}
+
+// Close stops renewing the port mapping and deletes it.
+func (m *Mapper) Close() error {
+	close(m.stop)
+	m.wg.Wait()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.status.ExternalPort == 0 {
+		return nil
+	}
+	c := &client{gateway: m.client.gateway}
+	_, err := c.mapTCP(m.status.InternalPort, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to delete the port mapping: %v", err)
+	}
+	return nil
+}
+
+// refresh requests or renews the port mapping, returning the duration after which it has to be refreshed again.
+func (m *Mapper) refresh(now time.Time) time.Duration {
+	m.mu.Lock()
+	status := m.status
+	m.mu.Unlock()
+
+	// request the same external port as before, or as the internal port for a new mapping
+	externalPort := status.ExternalPort
+	if externalPort == 0 {
+		externalPort = status.InternalPort
+	}
+	ip, err := m.client.externalAddress()
+	var granted mapping
+	if err == nil {
+		granted, err = m.client.mapTCP(status.InternalPort, externalPort, Lifetime)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		select {
+		case <-m.stop:
+			// the request was aborted
+			return 0
+		default:
+		}
+		if m.status.Error != err.Error() {
+			fmt.Fprintf(m.output, "Failed to map port %d on NAT gateway %s: %v\n", status.InternalPort, status.Gateway, err)
+		}
+		m.status.Error = err.Error()
+		return retryInterval
+	}
+	if status.ExternalIP != ip.String() || status.ExternalPort != granted.externalPort || status.Error != "" {
+		fmt.Fprintf(m.output, "Mapped port %d on NAT gateway %s to %s\n", status.InternalPort, status.Gateway,
+			net.JoinHostPort(ip.String(), fmt.Sprint(granted.externalPort)))
+		if granted.externalPort != status.InternalPort {
+			fmt.Fprintf(m.output, "The NAT gateway mapped port %d to another external port, peers will be unable to connect to the advertised port\n",
+				status.InternalPort)
+		}
+	}
+	m.status.ExternalIP = ip.String()
+	m.status.ExternalPort = granted.externalPort
+	m.status.Expires = now.Add(granted.lifetime)
+	m.status.Error = ""
+	if granted.lifetime < 2*time.Second {
+		return time.Second
+	}
+	return granted.lifetime / 2
+}
@@ -0,0 +1,141 @@
+package portmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// the NAT Port Mapping Protocol, as defined by RFC 6886
+const (
+	natpmpPort    = 5351
+	natpmpVersion = 0
+
+	opExternalAddress = 0
+	opMapTCP          = 2
+	// opResponse is added to the opcode of a request in the opcode of its response
+	opResponse = 128
+
+	// initialTimeout is the timeout of the first attempt of a request, doubled for every next attempt
+	initialTimeout = 250 * time.Millisecond
+	// maxAttempts is the amount of times a request is sent before the gateway is deemed unreachable
+	maxAttempts = 5
+)
+
+// resultCodes are the messages of the result codes of a failed request
+var resultCodes = map[uint16]string{
+	1: "unsupported version",
+	2: "not authorized or refused",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// errUnexpectedResponse is returned for a response which doesn't match its request
+var errUnexpectedResponse = errors.New("unexpected NAT-PMP response")
+
+// client sends NAT-PMP requests to a gateway.
+type client struct {
+	gateway *net.UDPAddr
+	// cancel aborts the pending request when closed, if defined
+	cancel <-chan struct{}
+}
+
+// mapping is a port mapping granted by the gateway.
+type mapping struct {
+	internalPort uint16
+	externalPort uint16
+	lifetime     time.Duration
+}
+
+// externalAddress requests the external IPv4 address of the gateway.
+func (c *client) externalAddress() (net.IP, error) {
+	resp, err := c.call([]byte{natpmpVersion, opExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+	return net.IPv4(resp[8], resp[9], resp[10], resp[11]), nil
+}
+
+// mapTCP requests the gateway to forward the given external TCP port to the given internal port,
+// for the given lifetime, the gateway can grant another external port. A lifetime of 0 deletes the mapping.
+func (c *client) mapTCP(internalPort, externalPort uint16, lifetime time.Duration) (mapping, error) {
+	req := make([]byte, 12)
+	req[0] = natpmpVersion
+	req[1] = opMapTCP
+	binary.BigEndian.PutUint16(req[4:], internalPort)
+	binary.BigEndian.PutUint16(req[6:], externalPort)
+	binary.BigEndian.PutUint32(req[8:], uint32(lifetime/time.Second))
+	resp, err := c.call(req, 16)
+	if err != nil {
+		return mapping{}, err
+	}
+	m := mapping{
+		internalPort: binary.BigEndian.Uint16(resp[8:]),
+		externalPort: binary.BigEndian.Uint16(resp[10:]),
+		lifetime:     time.Duration(binary.BigEndian.Uint32(resp[12:])) * time.Second,
+	}
+	if m.internalPort != internalPort {
+		return mapping{}, errUnexpectedResponse
+	}
+	return m, nil
+}
+
+// call sends the given request to the gateway, retrying with a doubling timeout until it responds,
+// returning the response of the given size once its result code is verified.
+func (c *client) call(req []byte, size int) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, c.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if c.cancel != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-c.cancel:
+				conn.Close()
+			case <-done:
+			}
+		}()
+	}
+
+	buf := make([]byte, 16)
+	timeout := initialTimeout
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		_, err = conn.Write(req)
+		if err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(timeout))
+		timeout *= 2
+		for {
+			var n int
+			n, err = conn.Read(buf)
+			if err != nil {
+				break
+			}
+			// ignore the responses of earlier requests, and other (multicast) announcements
+			if n < 4 || buf[0] != natpmpVersion || buf[1] != req[1]+opResponse {
+				continue
+			}
+			if code := binary.BigEndian.Uint16(buf[2:]); code != 0 {
+				if msg, ok := resultCodes[code]; ok {
+					return nil, fmt.Errorf("NAT-PMP request refused: %s", msg)
+				}
+				return nil, fmt.Errorf("NAT-PMP request refused with result code %d", code)
+			}
+			if n < size {
+				return nil, errUnexpectedResponse
+			}
+			return buf[:size], nil
+		}
+		if nerr, ok := err.(net.Error); !ok || !nerr.Timeout() {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("NAT-PMP gateway %s did not respond", c.gateway.IP)
+}
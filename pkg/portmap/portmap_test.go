@@ -0,0 +1,174 @@
+package portmap
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testServer is a NAT-PMP server, granting all mappings with an external port offset by externalOffset.
+type testServer struct {
+	conn *net.UDPConn
+
+	mu             sync.Mutex
+	externalOffset uint16
+	resultCode     uint16
+	mappings       map[uint16]uint16
+	lifetimes      []uint32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{conn: conn, mappings: make(map[uint16]uint16)}
+	go s.serve()
+	t.Cleanup(func() { conn.Close() })
+	return s
+}
+
+func (s *testServer) port() int {
+	return s.conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func (s *testServer) serve() {
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		var resp []byte
+		switch {
+		case n == 2 && buf[1] == opExternalAddress:
+			resp = make([]byte, 12)
+			copy(resp[8:], []byte{203, 0, 113, 7})
+		case n == 12 && buf[1] == opMapTCP:
+			internal := binary.BigEndian.Uint16(buf[4:])
+			lifetime := binary.BigEndian.Uint32(buf[8:])
+			s.lifetimes = append(s.lifetimes, lifetime)
+			resp = make([]byte, 16)
+			binary.BigEndian.PutUint16(resp[8:], internal)
+			if lifetime == 0 {
+				delete(s.mappings, internal)
+			} else {
+				s.mappings[internal] = binary.BigEndian.Uint16(buf[6:]) + s.externalOffset
+				binary.BigEndian.PutUint16(resp[10:], s.mappings[internal])
+			}
+			binary.BigEndian.PutUint32(resp[12:], lifetime)
+		default:
+			s.mu.Unlock()
+			continue
+		}
+		resp[1] = buf[1] + opResponse
+		binary.BigEndian.PutUint16(resp[2:], s.resultCode)
+		s.mu.Unlock()
+		s.conn.WriteToUDP(resp, addr)
+	}
+}
+
+func TestClient(t *testing.T) {
+	s := newTestServer(t)
+	c := &client{gateway: &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: s.port()}}
+
+	ip, err := c.externalAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(203, 0, 113, 7)) {
+		t.Errorf("unexpected external address: %v", ip)
+	}
+
+	s.mu.Lock()
+	s.externalOffset = 1
+	s.mu.Unlock()
+	m, err := c.mapTCP(22112, 22112, Lifetime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != (mapping{internalPort: 22112, externalPort: 22113, lifetime: Lifetime}) {
+		t.Errorf("unexpected mapping: %+v", m)
+	}
+
+	s.mu.Lock()
+	s.resultCode = 2
+	s.mu.Unlock()
+	_, err = c.mapTCP(22112, 22112, Lifetime)
+	if err == nil || !strings.Contains(err.Error(), "not authorized or refused") {
+		t.Errorf("expected the mapping to be refused, got: %v", err)
+	}
+}
+
+func TestClientUnreachable(t *testing.T) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	cancel := make(chan struct{})
+	c := &client{gateway: conn.LocalAddr().(*net.UDPAddr), cancel: cancel}
+	time.AfterFunc(100*time.Millisecond, func() { close(cancel) })
+	start := time.Now()
+	_, err = c.externalAddress()
+	if err == nil {
+		t.Fatal("expected the request to fail")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the request was not aborted, it took %v", elapsed)
+	}
+}
+
+func TestMapper(t *testing.T) {
+	s := newTestServer(t)
+	var output bytes.Buffer
+	m := newMapper(net.IPv4(127, 0, 0, 1), s.port(), 22112, &output)
+	for i := 0; i < 100 && m.Status().ExternalPort == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := m.Status()
+	if status.ExternalIP != "203.0.113.7" || status.ExternalPort != 22112 || status.Error != "" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	if time.Until(status.Expires) > Lifetime || time.Until(status.Expires) < Lifetime-time.Minute {
+		t.Errorf("unexpected expiry: %v", status.Expires)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.mappings) != 0 {
+		t.Errorf("the mapping was not deleted: %v", s.mappings)
+	}
+	if len(s.lifetimes) != 2 || s.lifetimes[0] != uint32(Lifetime/time.Second) || s.lifetimes[1] != 0 {
+		t.Errorf("unexpected requested lifetimes: %v", s.lifetimes)
+	}
+	if !strings.Contains(output.String(), "Mapped port 22112 on NAT gateway 127.0.0.1 to 203.0.113.7:22112") {
+		t.Errorf("unexpected output: %q", output.String())
+	}
+}
+
+func TestParseDefaultGateway(t *testing.T) {
+	table := `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	000200C0	00000000	0001	0	0	0	00FFFFFF	0	0	0
+eth0	00000000	010200C0	0003	0	0	0	00000000	0	0	0
+`
+	ip, err := parseDefaultGateway(strings.NewReader(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ip.Equal(net.IPv4(192, 0, 2, 1)) {
+		t.Errorf("unexpected default gateway: %v", ip)
+	}
+	noDefault := strings.Join(strings.Split(table, "\n")[:2], "\n")
+	_, err = parseDefaultGateway(strings.NewReader(noDefault))
+	if err != errNoDefaultGateway {
+		t.Errorf("expected no default gateway, got: %v", err)
+	}
+}
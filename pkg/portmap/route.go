@@ -0,0 +1,60 @@
+package portmap
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+)
+
+// routeFile is the IPv4 routing table of Linux
+const routeFile = "/proc/net/route"
+
+// errNoDefaultGateway is returned when the default gateway can't be discovered
+var errNoDefaultGateway = errors.New("no default IPv4 gateway found, it has to be configured explicitly")
+
+// DefaultGateway returns the default IPv4 gateway of the host, as found in the routing table of Linux.
+// On other systems the gateway has to be configured explicitly.
+func DefaultGateway() (net.IP, error) {
+	f, err := os.Open(routeFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errNoDefaultGateway
+		}
+		return nil, err
+	}
+	defer f.Close()
+	return parseDefaultGateway(f)
+}
+
+// parseDefaultGateway returns the gateway of the first default route of the given routing table,
+// of which the addresses are little endian hexadecimal numbers.
+func parseDefaultGateway(r io.Reader) (net.IP, error) {
+	scanner := bufio.NewScanner(r)
+	// skip the header
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+		b, err := hex.DecodeString(fields[2])
+		if err != nil || len(b) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(b))
+		if ip.IsUnspecified() {
+			continue
+		}
+		return ip, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errNoDefaultGateway
+}
@@ -0,0 +1,96 @@
+// Package postgres executes the queries of the indexer on a PostgreSQL server, using the lib/pq driver.
+//
+// Values are never inlined in the queries, but given as the $1, $2, ... parameters of a statement,
+// which are sent separately from the query using the extended query protocol.
+// The statements applying a batch of changes are executed as a single transaction.
+package postgres
+
+import (
+	"database/sql"
+
+	// registers the postgres driver
+	_ "github.com/lib/pq"
+)
+
+// Statement is a SQL statement, together with the values of its $1, $2, ... parameters.
+// A statement without parameters can consist of multiple statements, separated by semicolons.
+type Statement struct {
+	Query string
+	Args  []interface{}
+}
+
+// DB is a pool of connections to a PostgreSQL server which can be used by multiple goroutines.
+type DB struct {
+	db *sql.DB
+}
+
+// Open connects to the server at the given URL, postgres://[user[:password]@]host[:port]/database[?param=value&...],
+// supporting all connection parameters and authentication methods of lib/pq, such as sslmode.
+func Open(rawURL string) (*DB, error) {
+	db, err := sql.Open("postgres", rawURL)
+	if err != nil {
+		return nil, err
+	}
+	err = db.Ping()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &DB{db: db}, nil
+}
+
+// Close terminates all connections.
+func (db *DB) Close() error {
+	return db.db.Close()
+}
+
+// Exec executes the given statements as a single transaction, rolled back should any of them fail.
+func (db *DB) Exec(statements ...Statement) error {
+	tx, err := db.db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range statements {
+		_, err = tx.Exec(statement.Query, statement.Args...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Query executes the given statement with the given values of its parameters,
+// returning the rows it returns as text, a nil value being returned for NULL values.
+func (db *DB) Query(query string, args ...interface{}) ([][]*string, error) {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result [][]*string
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		err = rows.Scan(dest...)
+		if err != nil {
+			return nil, err
+		}
+		row := make([]*string, len(columns))
+		for i, value := range values {
+			if value.Valid {
+				s := value.String
+				row[i] = &s
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
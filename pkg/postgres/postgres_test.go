@@ -0,0 +1,235 @@
+package postgres
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// testServer accepts a single client, authenticating it using a cleartext password,
+// answering every query with the row (1, NULL), or an error if the query contains "fail",
+// using either the simple or the extended query protocol.
+func testServer(t *testing.T, password string) (string, <-chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	queries := make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		read := func() (byte, []byte) {
+			header := make([]byte, 5)
+			if _, err := io.ReadFull(r, header); err != nil {
+				return 0, nil
+			}
+			msg := make([]byte, binary.BigEndian.Uint32(header[1:])-4)
+			io.ReadFull(r, msg)
+			return header[0], msg
+		}
+		message := func(typ byte, body []byte) []byte {
+			b := make([]byte, 5, 5+len(body))
+			b[0] = typ
+			binary.BigEndian.PutUint32(b[1:], uint32(4+len(body)))
+			return append(b, body...)
+		}
+		uint32Message := func(typ byte, v uint32) []byte {
+			b := make([]byte, 4)
+			binary.BigEndian.PutUint32(b, v)
+			return message(typ, b)
+		}
+
+		// startup message, preceded by an SSLRequest which is refused
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(r, size); err != nil {
+				return
+			}
+			body := make([]byte, binary.BigEndian.Uint32(size)-4)
+			io.ReadFull(r, body)
+			if binary.BigEndian.Uint32(body) != 80877103 {
+				break
+			}
+			conn.Write([]byte{'N'})
+		}
+		conn.Write(uint32Message('R', 3))
+		if typ, msg := read(); typ != 'p' || string(msg) != password+"\x00" {
+			conn.Write(message('E', []byte("SFATAL\x00C28P01\x00Mpassword authentication failed\x00\x00")))
+			return
+		}
+		conn.Write(uint32Message('R', 0))
+		conn.Write(message('Z', []byte{'I'}))
+
+		var (
+			received []string
+			// status is the transaction status reported by ReadyForQuery
+			status byte = 'I'
+			// query is the statement parsed using the extended query protocol
+			query string
+			// failed skips the messages following an error until the next Sync
+			failed bool
+		)
+		fail := func() {
+			conn.Write(message('E', []byte("SERROR\x00C42601\x00Msyntax error\x00\x00")))
+			if status == 'T' {
+				status = 'E'
+			}
+		}
+		// a text column named a, and a text column named b
+		description := []byte{0, 2}
+		for _, name := range []string{"a", "b"} {
+			field := append([]byte(name), 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 25, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0)
+			description = append(description, field...)
+		}
+		dataRow := message('D', []byte{0, 2, 0, 0, 0, 1, '1', 0xFF, 0xFF, 0xFF, 0xFF})
+		for {
+			typ, msg := read()
+			if failed && typ != 'S' && typ != 0 && typ != 'X' {
+				continue
+			}
+			switch typ {
+			case 'Q':
+				q := strings.TrimSuffix(string(msg), "\x00")
+				received = append(received, q)
+				switch {
+				case strings.HasPrefix(q, "BEGIN"):
+					status = 'T'
+					conn.Write(message('C', []byte("BEGIN\x00")))
+				case q == "COMMIT" || q == "ROLLBACK":
+					status = 'I'
+					conn.Write(message('C', []byte(q+"\x00")))
+				case strings.Contains(q, "fail"):
+					fail()
+				case !strings.HasPrefix(q, "SELECT") && q != ";":
+					conn.Write(message('C', []byte("UPDATE 1\x00")))
+				default:
+					conn.Write(message('T', description))
+					conn.Write(dataRow)
+					conn.Write(message('C', []byte("SELECT 1\x00")))
+				}
+				conn.Write(message('Z', []byte{status}))
+			case 'P':
+				// unnamed statement, query, no parameter types
+				query = strings.SplitN(string(msg[1:]), "\x00", 2)[0]
+				if strings.Contains(query, "fail") {
+					received = append(received, query)
+					fail()
+					failed = true
+					continue
+				}
+				conn.Write(message('1', nil))
+			case 'D':
+				// every parameter is text
+				params := strings.Count(query, "$")
+				b := []byte{0, byte(params)}
+				for i := 0; i < params; i++ {
+					b = append(b, 0, 0, 0, 25)
+				}
+				conn.Write(message('t', b))
+				if strings.HasPrefix(query, "SELECT") {
+					conn.Write(message('T', description))
+				} else {
+					conn.Write(message('n', nil))
+				}
+			case 'B':
+				// unnamed portal and statement, no parameter formats, followed by the parameter values
+				b := msg[4:]
+				values := make([]string, binary.BigEndian.Uint16(b))
+				b = b[2:]
+				for i := range values {
+					size := int32(binary.BigEndian.Uint32(b))
+					b = b[4:]
+					if size < 0 {
+						values[i] = "NULL"
+						continue
+					}
+					values[i] = string(b[:size])
+					b = b[size:]
+				}
+				received = append(received, query+" ["+strings.Join(values, ", ")+"]")
+				conn.Write(message('2', nil))
+			case 'E':
+				if strings.HasPrefix(query, "SELECT") {
+					conn.Write(dataRow)
+					conn.Write(message('C', []byte("SELECT 1\x00")))
+				} else {
+					conn.Write(message('C', []byte("UPDATE 1\x00")))
+				}
+			case 'S':
+				failed = false
+				conn.Write(message('Z', []byte{status}))
+			default:
+				queries <- received
+				return
+			}
+		}
+	}()
+	return listener.Addr().String(), queries
+}
+
+func TestDB(t *testing.T) {
+	address, queries := testServer(t, "secret")
+	db, err := Open("postgres://indexer:secret@" + address + "/goldchain?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := db.Query("SELECT 1, NULL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 2 || rows[0][0] == nil || *rows[0][0] != "1" || rows[0][1] != nil {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	rows, err = db.Query("SELECT $1, NULL", "it's")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 2 || rows[0][0] == nil || *rows[0][0] != "1" || rows[0][1] != nil {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	err = db.Exec(Statement{Query: "UPDATE t SET a = $1 WHERE b = $2", Args: []interface{}{"it's", nil}}, Statement{Query: "UPDATE t SET a = b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Exec(Statement{Query: "UPDATE t SET a = $1", Args: []interface{}{int64(5)}}, Statement{Query: "fail"})
+	if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != "42601" || pqErr.Message != "syntax error" {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+	err = db.Exec(Statement{Query: "fail $1", Args: []interface{}{"x"}})
+	if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != "42601" {
+		t.Errorf("expected syntax error, got %v", err)
+	}
+	db.Close()
+	// the connection is verified when opened, the values are sent as parameters,
+	// and the statements are executed as a transaction, rolled back once one fails
+	expected := []string{
+		"SELECT 1, NULL",
+		"SELECT $1, NULL [it's]",
+		"BEGIN READ WRITE", "UPDATE t SET a = $1 WHERE b = $2 [it's, NULL]", "UPDATE t SET a = b", "COMMIT",
+		"BEGIN READ WRITE", "UPDATE t SET a = $1 [5]", "fail", "ROLLBACK",
+		"BEGIN READ WRITE", "fail $1", "ROLLBACK",
+	}
+	if received := <-queries; len(received) != len(expected)+1 || strings.Join(received[1:], "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected queries: %q", received)
+	}
+
+	address, _ = testServer(t, "secret")
+	_, err = Open("postgres://indexer:wrong@" + address + "/goldchain?sslmode=disable")
+	if pqErr, ok := err.(*pq.Error); !ok || pqErr.Code != "28P01" {
+		t.Errorf("expected authentication error, got %v", err)
+	}
+	address, _ = testServer(t, "secret")
+	if _, err = Open("postgres://indexer:secret@" + address + "/goldchain?sslmode=require"); err == nil {
+		t.Error("expected TLS to be required")
+	}
+}
@@ -0,0 +1,542 @@
+// Package qrcode implements a QR code (model 2) encoder for binary data,
+// as specified by ISO/IEC 18004, such that payment requests can be shared as images.
+package qrcode
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Level is the error correction level of a QR code,
+// a higher level allows more of the code to be damaged, at the cost of capacity.
+type Level int
+
+// The error correction levels, allowing roughly 7%, 15%, 25% and 30% of the code to be restored.
+const (
+	Low Level = iota
+	Medium
+	Quartile
+	High
+)
+
+// ErrDataTooLong is returned in case the data does not fit in a QR code of the highest version.
+var ErrDataTooLong = errors.New("data too long to be encoded as QR code")
+
+const (
+	minVersion = 1
+	maxVersion = 40
+)
+
+var (
+	// formatBits are the bits identifying the error correction level in the format information
+	formatBits = [...]uint{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+	// eccCodewordsPerBlock is indexed by level and version (index 0 is unused)
+	eccCodewordsPerBlock = [...][maxVersion + 1]int{
+		Low:      {-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		Medium:   {-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		Quartile: {-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		High:     {-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	// numErrorCorrectionBlocks is indexed by level and version (index 0 is unused)
+	numErrorCorrectionBlocks = [...][maxVersion + 1]int{
+		Low:      {-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		Medium:   {-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		Quartile: {-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		High:     {-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// Code is an encoded QR code, a square grid of dark and light modules.
+type Code struct {
+	// Version is the version (1-40) of the code, defining its size
+	Version int
+	Level   Level
+	// Size is the width and height of the code, in modules
+	Size int
+	// Mask is the mask pattern (0-7) applied to the data modules
+	Mask int
+
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// Encode encodes the given data as a QR code (in byte mode) with the given error correction level,
+// using the smallest version in which the data fits and the mask pattern which is best scannable.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < Low || level > High {
+		return nil, errors.New("invalid QR code error correction level")
+	}
+	version := minVersion
+	for ; ; version++ {
+		if version > maxVersion {
+			return nil, ErrDataTooLong
+		}
+		if 4+charCountBits(version)+len(data)*8 <= numDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	// segment header, data, terminator and padding
+	var bb bitBuffer
+	bb.append(0x4, 4) // byte mode
+	bb.append(uint(len(data)), charCountBits(version))
+	for _, b := range data {
+		bb.append(uint(b), 8)
+	}
+	capacity := numDataCodewords(version, level) * 8
+	terminator := capacity - len(bb)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bb.append(0, terminator)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := uint(0xEC); len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	codewords := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			codewords[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+
+	size := version*4 + 17
+	code := &Code{
+		Version:    version,
+		Level:      level,
+		Size:       size,
+		modules:    make([][]bool, size),
+		isFunction: make([][]bool, size),
+	}
+	for i := range code.modules {
+		code.modules[i] = make([]bool, size)
+		code.isFunction[i] = make([]bool, size)
+	}
+	code.drawFunctionPatterns()
+	code.drawCodewords(addECCAndInterleave(codewords, version, level))
+
+	minPenalty := -1
+	for mask := 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		if penalty := code.penaltyScore(); minPenalty < 0 || penalty < minPenalty {
+			code.Mask, minPenalty = mask, penalty
+		}
+		code.applyMask(mask) // undo, as masking is a XOR operation
+	}
+	code.applyMask(code.Mask)
+	code.drawFormatBits(code.Mask)
+	code.isFunction = nil
+	return code, nil
+}
+
+// Dark returns true in case the module at the given coordinates is dark,
+// (0, 0) being the top left module. Coordinates outside of the code are light.
+func (code *Code) Dark(x, y int) bool {
+	return x >= 0 && x < code.Size && y >= 0 && y < code.Size && code.modules[y][x]
+}
+
+// Image returns the code as a black and white image, using scale pixels per module,
+// surrounded by the quiet zone of 4 modules required by the specification.
+func (code *Code) Image(scale int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	const border = 4
+	width := (code.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, width, width), color.Palette{color.White, color.Black})
+	for y := 0; y < width; y++ {
+		for x := 0; x < width; x++ {
+			if code.Dark(x/scale-border, y/scale-border) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// drawFunctionPatterns draws the finder, alignment and timing patterns,
+// and reserves the modules of the format and version information.
+func (code *Code) drawFunctionPatterns() {
+	for i := 0; i < code.Size; i++ {
+		code.setFunctionModule(6, i, i%2 == 0)
+		code.setFunctionModule(i, 6, i%2 == 0)
+	}
+
+	code.drawFinderPattern(3, 3)
+	code.drawFinderPattern(code.Size-4, 3)
+	code.drawFinderPattern(3, code.Size-4)
+
+	positions := alignmentPatternPositions(code.Version)
+	n := len(positions)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			// skip the three corners occupied by the finder patterns
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) {
+				continue
+			}
+			code.drawAlignmentPattern(positions[i], positions[j])
+		}
+	}
+
+	code.drawFormatBits(0) // reserve the modules, drawn for real once the mask is chosen
+	code.drawVersion()
+}
+
+// drawFormatBits draws both copies of the format information,
+// defined by the error correction level and the given mask.
+func (code *Code) drawFormatBits(mask int) {
+	bits := formatInformation(code.Level, mask)
+	bit := func(i uint) bool { return (bits>>i)&1 != 0 }
+
+	// first copy, around the top left finder pattern
+	for i := 0; i <= 5; i++ {
+		code.setFunctionModule(8, i, bit(uint(i)))
+	}
+	code.setFunctionModule(8, 7, bit(6))
+	code.setFunctionModule(8, 8, bit(7))
+	code.setFunctionModule(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		code.setFunctionModule(14-i, 8, bit(uint(i)))
+	}
+
+	// second copy, split between the top right and bottom left finder patterns
+	for i := 0; i < 8; i++ {
+		code.setFunctionModule(code.Size-1-i, 8, bit(uint(i)))
+	}
+	for i := 8; i < 15; i++ {
+		code.setFunctionModule(8, code.Size-15+i, bit(uint(i)))
+	}
+	code.setFunctionModule(8, code.Size-8, true) // always dark
+}
+
+// drawVersion draws both copies of the version information, only present as of version 7.
+func (code *Code) drawVersion() {
+	if code.Version < 7 {
+		return
+	}
+	bits := versionInformation(code.Version)
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 != 0
+		a, b := code.Size-11+i%3, i/3
+		code.setFunctionModule(a, b, dark)
+		code.setFunctionModule(b, a, dark)
+	}
+}
+
+// drawFinderPattern draws a finder pattern, including its separator, centered at the given coordinates.
+func (code *Code) drawFinderPattern(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx < 0 || xx >= code.Size || yy < 0 || yy >= code.Size {
+				continue
+			}
+			dist := maxInt(absInt(dx), absInt(dy))
+			code.setFunctionModule(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignmentPattern draws an alignment pattern centered at the given coordinates.
+func (code *Code) drawAlignmentPattern(x, y int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			code.setFunctionModule(x+dx, y+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+		}
+	}
+}
+
+func (code *Code) setFunctionModule(x, y int, dark bool) {
+	code.modules[y][x] = dark
+	code.isFunction[y][x] = true
+}
+
+// drawCodewords draws the given codewords in the data modules,
+// in the zigzag pattern going upwards and downwards in columns of two modules wide,
+// starting from the bottom right corner.
+func (code *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := code.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < code.Size; vert++ {
+			y := vert
+			if upward {
+				y = code.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if code.isFunction[y][x] || i >= len(codewords)*8 {
+					continue // remainder bits are light
+				}
+				code.modules[y][x] = (codewords[i>>3]>>uint(7-i&7))&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by the given mask pattern.
+func (code *Code) applyMask(mask int) {
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !code.isFunction[y][x] {
+				code.modules[y][x] = !code.modules[y][x]
+			}
+		}
+	}
+}
+
+// penaltyScore computes the penalty of the current modules,
+// a lower score meaning the code is easier to scan.
+func (code *Code) penaltyScore() int {
+	var penalty, dark int
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	for i := 0; i < code.Size; i++ {
+		row := make([]bool, code.Size)
+		column := make([]bool, code.Size)
+		for j := 0; j < code.Size; j++ {
+			row[j] = code.modules[i][j]
+			column[j] = code.modules[j][i]
+			if row[j] {
+				dark++
+			}
+		}
+		for _, line := range [][]bool{row, column} {
+			// adjacent modules of the same color
+			run := 1
+			for j := 1; j <= len(line); j++ {
+				if j < len(line) && line[j] == line[j-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += 3 + run - 5
+				}
+				run = 1
+			}
+			// patterns looking like a finder pattern
+			for j := 0; j+len(finderLike[0]) <= len(line); j++ {
+				for _, pattern := range finderLike {
+					if matches(line[j:], pattern) {
+						penalty += 40
+					}
+				}
+			}
+		}
+	}
+	// 2x2 blocks of the same color
+	for y := 0; y < code.Size-1; y++ {
+		for x := 0; x < code.Size-1; x++ {
+			c := code.modules[y][x]
+			if c == code.modules[y][x+1] && c == code.modules[y+1][x] && c == code.modules[y+1][x+1] {
+				penalty += 3
+			}
+		}
+	}
+	// balance of dark and light modules
+	deviation := absInt(dark*100/(code.Size*code.Size) - 50)
+	penalty += deviation / 5 * 10
+	return penalty
+}
+
+func matches(line, pattern []bool) bool {
+	for i, dark := range pattern {
+		if line[i] != dark {
+			return false
+		}
+	}
+	return true
+}
+
+// formatInformation returns the 15 format information bits, BCH encoded and masked.
+func formatInformation(level Level, mask int) uint {
+	data := formatBits[level]<<3 | uint(mask)
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem&0x3FF) ^ 0x5412
+}
+
+// versionInformation returns the 18 version information bits, BCH encoded.
+func versionInformation(version int) uint {
+	rem := uint(version)
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return uint(version)<<12 | rem&0xFFF
+}
+
+// alignmentPatternPositions returns the ascending positions of the alignment patterns,
+// used as both x and y coordinates.
+func alignmentPatternPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// charCountBits returns the length of the character count indicator of a byte mode segment.
+func charCountBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// numRawDataModules returns the amount of modules available for data and error correction codewords,
+// including the remainder bits.
+func numRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		n := version/7 + 2
+		result -= (25*n-10)*n - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+// numDataCodewords returns the amount of data codewords of the given version and level.
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 -
+		eccCodewordsPerBlock[level][version]*numErrorCorrectionBlocks[level][version]
+}
+
+// addECCAndInterleave splits the data codewords in blocks, appends the error correction codewords to each block,
+// and interleaves the codewords of all blocks.
+func addECCAndInterleave(data []byte, version int, level Level) []byte {
+	numBlocks := numErrorCorrectionBlocks[level][version]
+	blockECCLen := eccCodewordsPerBlock[level][version]
+	rawCodewords := numRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := reedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, 0, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		datLen := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			datLen++
+		}
+		block := append([]byte(nil), data[k:k+datLen]...)
+		k += datLen
+		ecc := reedSolomonRemainder(block, divisor)
+		if i < numShortBlocks {
+			block = append(block, 0) // padding, skipped when interleaving
+		}
+		blocks = append(blocks, append(block, ecc...))
+	}
+
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// reedSolomonDivisor returns the coefficients of the Reed-Solomon generator polynomial of the given degree,
+// from highest to lowest power, excluding the leading term (which is always 1).
+func reedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// reedSolomonRemainder returns the Reed-Solomon error correction codewords of the given data.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coef := range divisor {
+			result[i] ^= gfMultiply(coef, factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8), modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+	var z uint
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= uint((y>>uint(i))&1) * uint(x)
+	}
+	return byte(z)
+}
+
+// bitBuffer is a sequence of bits, most significant bit first.
+type bitBuffer []bool
+
+// append appends the n least significant bits of val.
+func (bb *bitBuffer) append(val uint, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
+
+func absInt(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,199 @@
+package qrcode
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// the data codewords of "HELLO WORLD" as a 1-M code
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	expected := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	ecc := reedSolomonRemainder(data, reedSolomonDivisor(len(expected)))
+	if !bytes.Equal(ecc, expected) {
+		t.Errorf("expected %v, got %v", expected, ecc)
+	}
+}
+
+func TestFormatAndVersionInformation(t *testing.T) {
+	testCases := []struct {
+		level    Level
+		mask     int
+		expected uint
+	}{
+		{Low, 0, 0x77C4},
+		{Medium, 0, 0x5412},
+		{Medium, 4, 0x45F9},
+	}
+	for _, tc := range testCases {
+		if bits := formatInformation(tc.level, tc.mask); bits != tc.expected {
+			t.Errorf("level %d, mask %d: expected %015b, got %015b", tc.level, tc.mask, tc.expected, bits)
+		}
+	}
+	if bits := versionInformation(7); bits != 0x07C94 {
+		t.Errorf("version 7: expected %018b, got %018b", 0x07C94, bits)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	testCases := []struct {
+		version  int
+		level    Level
+		expected int
+	}{
+		{1, Medium, 16},
+		{7, Medium, 124},
+		{40, Low, 2956},
+		{40, High, 1276},
+	}
+	for _, tc := range testCases {
+		if n := numDataCodewords(tc.version, tc.level); n != tc.expected {
+			t.Errorf("version %d, level %d: expected %d data codewords, got %d", tc.version, tc.level, tc.expected, n)
+		}
+	}
+	if positions := alignmentPatternPositions(32); !reflect.DeepEqual(positions, []int{6, 34, 60, 86, 112, 138}) {
+		t.Errorf("unexpected alignment pattern positions for version 32: %v", positions)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, length := range []int{0, 17, 122, 300, 1000} {
+		data := make([]byte, length)
+		for i := range data {
+			data[i] = byte(i * 7)
+		}
+		for level := Low; level <= High; level++ {
+			code, err := Encode(data, level)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code.Size != code.Version*4+17 {
+				t.Fatalf("unexpected size %d for version %d", code.Size, code.Version)
+			}
+			decoded := readData(t, code)
+			if !bytes.Equal(decoded, data) {
+				t.Errorf("length %d, level %d: decoded data differs from encoded data", length, level)
+			}
+		}
+	}
+
+	_, err := Encode(make([]byte, 3000), Low)
+	if err != ErrDataTooLong {
+		t.Errorf("expected %v, got %v", ErrDataTooLong, err)
+	}
+}
+
+// readData reads the data back from an encoded code,
+// reading the format information, unmasking, and reading and de-interleaving the codewords.
+func readData(t *testing.T, code *Code) []byte {
+	// both copies of the format information have to match the level and mask
+	var first, second uint
+	for i := 0; i <= 5; i++ {
+		first |= boolBit(code.Dark(8, i)) << uint(i)
+	}
+	first |= boolBit(code.Dark(8, 7))<<6 | boolBit(code.Dark(8, 8))<<7 | boolBit(code.Dark(7, 8))<<8
+	for i := 9; i < 15; i++ {
+		first |= boolBit(code.Dark(14-i, 8)) << uint(i)
+	}
+	for i := 0; i < 8; i++ {
+		second |= boolBit(code.Dark(code.Size-1-i, 8)) << uint(i)
+	}
+	for i := 8; i < 15; i++ {
+		second |= boolBit(code.Dark(8, code.Size-15+i)) << uint(i)
+	}
+	if expected := formatInformation(code.Level, code.Mask); first != expected || second != expected {
+		t.Fatalf("invalid format information: %015b and %015b, expected %015b", first, second, expected)
+	}
+
+	// use an unmasked copy with the function modules marked to read the codewords
+	c := &Code{Version: code.Version, Level: code.Level, Size: code.Size}
+	c.modules = make([][]bool, c.Size)
+	c.isFunction = make([][]bool, c.Size)
+	for y := range c.modules {
+		c.modules[y] = append([]bool(nil), code.modules[y]...)
+		c.isFunction[y] = make([]bool, c.Size)
+	}
+	c.drawFunctionPatterns()
+	for y := range c.modules {
+		copy(c.modules[y], code.modules[y])
+	}
+	c.applyMask(code.Mask)
+	codewords := make([]byte, numRawDataModules(c.Version)/8)
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				if c.modules[y][x] {
+					codewords[i>>3] |= 1 << uint(7-i&7)
+				}
+				i++
+			}
+		}
+	}
+
+	// de-interleave the data codewords
+	numBlocks := numErrorCorrectionBlocks[c.Level][c.Version]
+	blockECCLen := eccCodewordsPerBlock[c.Level][c.Version]
+	numShortBlocks := numBlocks - len(codewords)%numBlocks
+	shortDataLen := len(codewords)/numBlocks - blockECCLen
+	blocks := make([][]byte, numBlocks)
+	k := 0
+	for n := 0; n <= shortDataLen; n++ {
+		for b := range blocks {
+			if n == shortDataLen && b < numShortBlocks {
+				continue
+			}
+			blocks[b] = append(blocks[b], codewords[k])
+			k++
+		}
+	}
+	var data []byte
+	for b, block := range blocks {
+		ecc := make([]byte, 0, blockECCLen)
+		for n := 0; n < blockECCLen; n++ {
+			ecc = append(ecc, codewords[k+n*numBlocks+b])
+		}
+		if !bytes.Equal(ecc, reedSolomonRemainder(block, reedSolomonDivisor(blockECCLen))) {
+			t.Fatalf("invalid error correction codewords for block %d", b)
+		}
+		data = append(data, block...)
+	}
+
+	// parse the byte mode segment
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("unexpected mode indicator %x", data[0]>>4)
+	}
+	var length, offset int
+	if charCountBits(c.Version) == 8 {
+		length = int(data[0]&0xF)<<4 | int(data[1]>>4)
+		offset = 1
+	} else {
+		length = int(data[0]&0xF)<<12 | int(data[1])<<4 | int(data[2]>>4)
+		offset = 2
+	}
+	decoded := make([]byte, length)
+	for n := range decoded {
+		decoded[n] = data[offset+n]<<4 | data[offset+n+1]>>4
+	}
+	return decoded
+}
+
+func boolBit(b bool) uint {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,251 @@
+package rawtx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrNoRecipients is returned in case a transaction is composed without any recipients.
+	ErrNoRecipients = errors.New("at least one recipient has to be defined")
+	// ErrNoFunding is returned in case a transaction is composed without any coin inputs or addresses funding it.
+	ErrNoFunding = errors.New("at least one coin input or funding address has to be defined")
+	// ErrAddressIndexUnavailable is returned in case a transaction is to be funded by addresses,
+	// while the unspent outputs of addresses cannot be looked up.
+	ErrAddressIndexUnavailable = errors.New("funding a transaction using addresses requires the explorer module")
+	// ErrCoinInputUnavailable is returned in case a coin input of the intent cannot be spent.
+	ErrCoinInputUnavailable = errors.New("coin input cannot be spent")
+)
+
+// AddressIndex looks up the transactions involving an address, as modules.Explorer does.
+type AddressIndex interface {
+	UnlockHash(types.UnlockHash) []types.TransactionID
+	Transaction(types.TransactionID) (types.Block, types.BlockHeight, bool)
+}
+
+type (
+	// Intent describes the transaction to compose: the recipients to pay,
+	// and the coin inputs and addresses to fund it with.
+	Intent struct {
+		Recipients []Recipient `json:"recipients"`
+		// CoinInputs are the coin outputs which have to fund the transaction,
+		// the unspent coin outputs of the funding addresses are only used if these do not suffice
+		CoinInputs []types.CoinOutputID `json:"coininputs,omitempty"`
+		// From are the addresses funding the transaction, largest coin outputs first
+		From []types.UnlockHash `json:"from,omitempty"`
+		// RefundAddress receives the remainder of the inputs,
+		// the remainder is refunded to the (unlocked) condition of the first coin input if it is not given
+		RefundAddress *types.UnlockHash `json:"refundaddress,omitempty"`
+		// MinerFee defaults to the minimum transaction fee
+		MinerFee types.Currency `json:"minerfee"`
+		Data     []byte         `json:"data,omitempty"`
+		// ExpirationHeight is the height of the last block the transaction can be part of,
+		// an expiring transaction is only composed if it is defined
+		ExpirationHeight types.BlockHeight `json:"expirationheight,omitempty"`
+	}
+
+	// Recipient is an address paid by a composed transaction.
+	Recipient struct {
+		Address types.UnlockHash `json:"address"`
+		Amount  types.Currency   `json:"amount"`
+		// LockedUntil is a block height when lower than types.LockTimeMinTimestampValue
+		// and a unix epoch timestamp (in seconds) otherwise, the output is only time-locked if it is defined
+		LockedUntil uint64 `json:"lockeduntil,omitempty"`
+	}
+
+	// Composition is an unsigned transaction composed from an intent,
+	// of which the inputs have to be fulfilled before it can be submitted.
+	Composition struct {
+		Transaction types.Transaction `json:"transaction"`
+		// SignatureHashes are the hashes to sign by the owners of the coin inputs, in the order of the coin inputs,
+		// using Ed25519 to create the signatures of their single or multi signature fulfillments
+		SignatureHashes []crypto.Hash `json:"signaturehashes"`
+		// CoinInputs are the coin outputs spent by the transaction, in the order of its coin inputs
+		CoinInputs []Input         `json:"coininputs"`
+		MinerFee   types.Currency  `json:"minerfee"`
+		Refund     *types.Currency `json:"refund,omitempty"`
+	}
+
+	// Input is a coin output spent by a composed transaction.
+	Input struct {
+		ID     types.CoinOutputID `json:"id"`
+		Output types.CoinOutput   `json:"output"`
+	}
+)
+
+// ComposeContext contains the state used to fund a composed transaction.
+type ComposeContext struct {
+	Outputs OutputGetter
+	// Index is used to look up the unspent outputs of the funding addresses, it is optional
+	Index AddressIndex
+	// Pool are the transactions of the transaction pool, of which the spent outputs are never used
+	Pool        []types.Transaction
+	Fulfillable types.FulfillableContext
+	Constants   types.ChainConstants
+}
+
+// Compose composes the unsigned transaction paying the recipients of the given intent,
+// funded by its coin inputs first, and the largest spendable coin outputs of its funding addresses next.
+// The inputs of the transaction have no fulfillment, they are to be signed by the owners of the spent outputs.
+func Compose(intent Intent, ctx ComposeContext) (Composition, error) {
+	if len(intent.Recipients) == 0 {
+		return Composition{}, ErrNoRecipients
+	}
+	if len(intent.CoinInputs) == 0 && len(intent.From) == 0 {
+		return Composition{}, ErrNoFunding
+	}
+	if len(intent.From) > 0 && ctx.Index == nil {
+		return Composition{}, ErrAddressIndexUnavailable
+	}
+	minerFee := intent.MinerFee
+	if minerFee.Cmp(ctx.Constants.MinimumTransactionFee) < 0 {
+		minerFee = ctx.Constants.MinimumTransactionFee
+	}
+
+	txn := types.Transaction{
+		Version:       ctx.Constants.DefaultTransactionVersion,
+		MinerFees:     []types.Currency{minerFee},
+		ArbitraryData: intent.Data,
+	}
+	if intent.ExpirationHeight != 0 {
+		txn.Version = goldchaintypes.TransactionVersionExpiring
+		txn.Extension = &goldchaintypes.ExpiringTransactionExtension{
+			ExpirationHeight: intent.ExpirationHeight,
+		}
+	}
+	amount := minerFee
+	for _, recipient := range intent.Recipients {
+		if recipient.Amount.IsZero() {
+			return Composition{}, fmt.Errorf("cannot pay nothing to %s", recipient.Address.String())
+		}
+		condition := types.NewCondition(types.NewUnlockHashCondition(recipient.Address))
+		if recipient.LockedUntil != 0 {
+			condition = types.NewCondition(types.NewTimeLockCondition(recipient.LockedUntil, condition.Condition))
+		}
+		txn.CoinOutputs = append(txn.CoinOutputs, types.CoinOutput{Value: recipient.Amount, Condition: condition})
+		amount = amount.Add(recipient.Amount)
+	}
+
+	spent := newPoolOutputs(ctx.Pool, types.TransactionID{}).spentCoinOutputs
+	inputs, err := pinnedInputs(intent.CoinInputs, ctx, spent)
+	if err != nil {
+		return Composition{}, err
+	}
+	var fund types.Currency
+	for _, input := range inputs {
+		fund = fund.Add(input.Output.Value)
+	}
+	if fund.Cmp(amount) < 0 && len(intent.From) > 0 {
+		for _, candidate := range addressInputs(intent.From, ctx, spent, inputs) {
+			if fund.Cmp(amount) >= 0 {
+				break
+			}
+			inputs = append(inputs, candidate)
+			fund = fund.Add(candidate.Output.Value)
+		}
+	}
+	if fund.Cmp(amount) < 0 {
+		return Composition{}, modules.ErrLowBalance
+	}
+
+	composition := Composition{CoinInputs: inputs, MinerFee: minerFee}
+	for _, input := range inputs {
+		txn.CoinInputs = append(txn.CoinInputs, types.CoinInput{ParentID: input.ID})
+	}
+	if refund := fund.Sub(amount); !refund.IsZero() {
+		condition := inputs[0].Output.Condition
+		if tlc, ok := condition.Condition.(*types.TimeLockCondition); ok {
+			condition = types.NewCondition(tlc.Condition)
+		}
+		if intent.RefundAddress != nil {
+			condition = types.NewCondition(types.NewUnlockHashCondition(*intent.RefundAddress))
+		}
+		txn.CoinOutputs = append(txn.CoinOutputs, types.CoinOutput{Value: refund, Condition: condition})
+		composition.Refund = &refund
+	}
+	composition.Transaction = txn
+	// the signature hash of an input only covers the input index, not the fulfillments of the transaction
+	for i := range txn.CoinInputs {
+		hash, err := txn.SignatureHash(uint64(i))
+		if err != nil {
+			return Composition{}, err
+		}
+		composition.SignatureHashes = append(composition.SignatureHashes, hash)
+	}
+	return composition, nil
+}
+
+// pinnedInputs looks up the given coin outputs, which all have to be spendable.
+func pinnedInputs(ids []types.CoinOutputID, ctx ComposeContext, spent map[types.CoinOutputID]struct{}) ([]Input, error) {
+	inputs := make([]Input, 0, len(ids))
+	seen := make(map[types.CoinOutputID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%v: coin output %s is given more than once", ErrCoinInputUnavailable, id.String())
+		}
+		seen[id] = struct{}{}
+		co, err := ctx.Outputs.GetCoinOutput(id)
+		if err != nil {
+			return nil, fmt.Errorf("%v: coin output %s is spent or does not exist", ErrCoinInputUnavailable, id.String())
+		}
+		if _, ok := spent[id]; ok {
+			return nil, fmt.Errorf("%v: coin output %s is spent by a transaction of the transaction pool", ErrCoinInputUnavailable, id.String())
+		}
+		if !co.Condition.Fulfillable(ctx.Fulfillable) {
+			return nil, fmt.Errorf("%v: coin output %s is locked", ErrCoinInputUnavailable, id.String())
+		}
+		inputs = append(inputs, Input{ID: id, Output: co})
+	}
+	return inputs, nil
+}
+
+// addressInputs returns the spendable coin outputs of the given addresses, largest outputs first,
+// excluding the given inputs.
+func addressInputs(addresses []types.UnlockHash, ctx ComposeContext, spent map[types.CoinOutputID]struct{}, exclude []Input) []Input {
+	seen := make(map[types.CoinOutputID]struct{}, len(exclude))
+	for _, input := range exclude {
+		seen[input.ID] = struct{}{}
+	}
+	var candidates []Input
+	for _, address := range addresses {
+		for _, txid := range ctx.Index.UnlockHash(address) {
+			block, _, ok := ctx.Index.Transaction(txid)
+			if !ok {
+				continue
+			}
+			for _, txn := range block.Transactions {
+				if txn.ID() != txid {
+					continue
+				}
+				for i, co := range txn.CoinOutputs {
+					id := txn.CoinOutputID(uint64(i))
+					if _, ok := seen[id]; ok || co.Condition.UnlockHash() != address {
+						continue
+					}
+					seen[id] = struct{}{}
+					if _, ok := spent[id]; ok {
+						continue
+					}
+					if _, err := ctx.Outputs.GetCoinOutput(id); err != nil || !co.Condition.Fulfillable(ctx.Fulfillable) {
+						continue
+					}
+					candidates = append(candidates, Input{ID: id, Output: co})
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := candidates[i].Output.Value.Cmp(candidates[j].Output.Value); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(candidates[i].ID[:], candidates[j].ID[:]) < 0
+	})
+	return candidates
+}
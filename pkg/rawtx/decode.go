@@ -0,0 +1,195 @@
+package rawtx
+
+import (
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// OutputGetter looks up the unspent outputs of the consensus set, as modules.ConsensusSet does.
+type OutputGetter interface {
+	GetCoinOutput(types.CoinOutputID) (types.CoinOutput, error)
+	GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error)
+}
+
+// InputStatus is the status of the output spent by a transaction input.
+type InputStatus string
+
+// statuses of the output spent by an input
+const (
+	// InputStatusUnspent is the status of an input spending an unspent output of the consensus set
+	InputStatusUnspent InputStatus = "unspent"
+	// InputStatusUnconfirmed is the status of an input spending an output of a transaction in the transaction pool
+	InputStatusUnconfirmed InputStatus = "unconfirmed"
+	// InputStatusUnknown is the status of an input spending an output which is spent already or does not exist
+	InputStatusUnknown InputStatus = "unknown"
+)
+
+type (
+	// DecodedTransaction is a transaction together with the IDs computed from it,
+	// and the outputs spent by its inputs.
+	DecodedTransaction struct {
+		ID          types.TransactionID      `json:"id"`
+		Version     types.TransactionVersion `json:"version"`
+		VersionName string                   `json:"versionname"`
+		// Size is the size of the siabin-encoded transaction in bytes
+		Size        int               `json:"size"`
+		Transaction types.Transaction `json:"transaction"`
+
+		CoinInputs        []DecodedCoinInput        `json:"coininputs"`
+		CoinOutputs       []DecodedCoinOutput       `json:"coinoutputs"`
+		BlockStakeInputs  []DecodedBlockStakeInput  `json:"blockstakeinputs"`
+		BlockStakeOutputs []DecodedBlockStakeOutput `json:"blockstakeoutputs"`
+
+		// CoinInputValue is the value of all coin inputs of which the spent output is known
+		CoinInputValue  types.Currency `json:"coininputvalue"`
+		CoinOutputValue types.Currency `json:"coinoutputvalue"`
+		MinerFee        types.Currency `json:"minerfee"`
+		// InputsKnown is true in case the outputs spent by all inputs are known
+		InputsKnown bool `json:"inputsknown"`
+	}
+
+	// DecodedCoinInput is a coin input together with the output it spends, if known.
+	DecodedCoinInput struct {
+		ParentID types.CoinOutputID `json:"parentid"`
+		Status   InputStatus        `json:"status"`
+		Parent   *types.CoinOutput  `json:"parent,omitempty"`
+		// SpentInPool is true in case another transaction of the transaction pool spends the same output
+		SpentInPool bool `json:"spentinpool"`
+	}
+
+	// DecodedCoinOutput is a coin output together with its ID.
+	DecodedCoinOutput struct {
+		ID      types.CoinOutputID `json:"id"`
+		Address types.UnlockHash   `json:"address"`
+		Output  types.CoinOutput   `json:"output"`
+	}
+
+	// DecodedBlockStakeInput is a blockstake input together with the output it spends, if known.
+	DecodedBlockStakeInput struct {
+		ParentID types.BlockStakeOutputID `json:"parentid"`
+		Status   InputStatus              `json:"status"`
+		Parent   *types.BlockStakeOutput  `json:"parent,omitempty"`
+		// SpentInPool is true in case another transaction of the transaction pool spends the same output
+		SpentInPool bool `json:"spentinpool"`
+	}
+
+	// DecodedBlockStakeOutput is a blockstake output together with its ID.
+	DecodedBlockStakeOutput struct {
+		ID      types.BlockStakeOutputID `json:"id"`
+		Address types.UnlockHash         `json:"address"`
+		Output  types.BlockStakeOutput   `json:"output"`
+	}
+)
+
+// Decode decodes the given transaction, looking up the outputs spent by its inputs
+// in the consensus set and in the given transactions of the transaction pool.
+func Decode(txn types.Transaction, outputs OutputGetter, pool []types.Transaction) DecodedTransaction {
+	id := txn.ID()
+	decoded := DecodedTransaction{
+		ID:                id,
+		Version:           txn.Version,
+		VersionName:       versionName(txn.Version),
+		Size:              len(siabin.Marshal(txn)),
+		Transaction:       txn,
+		CoinInputs:        make([]DecodedCoinInput, 0, len(txn.CoinInputs)),
+		CoinOutputs:       make([]DecodedCoinOutput, 0, len(txn.CoinOutputs)),
+		BlockStakeInputs:  make([]DecodedBlockStakeInput, 0, len(txn.BlockStakeInputs)),
+		BlockStakeOutputs: make([]DecodedBlockStakeOutput, 0, len(txn.BlockStakeOutputs)),
+		InputsKnown:       true,
+	}
+	unconfirmed := newPoolOutputs(pool, id)
+
+	for _, ci := range txn.CoinInputs {
+		input := DecodedCoinInput{ParentID: ci.ParentID, Status: InputStatusUnknown}
+		if co, err := outputs.GetCoinOutput(ci.ParentID); err == nil {
+			input.Status, input.Parent = InputStatusUnspent, &co
+		} else if co, ok := unconfirmed.coinOutputs[ci.ParentID]; ok {
+			input.Status, input.Parent = InputStatusUnconfirmed, &co
+		}
+		_, input.SpentInPool = unconfirmed.spentCoinOutputs[ci.ParentID]
+		if input.Parent != nil {
+			decoded.CoinInputValue = decoded.CoinInputValue.Add(input.Parent.Value)
+		} else {
+			decoded.InputsKnown = false
+		}
+		decoded.CoinInputs = append(decoded.CoinInputs, input)
+	}
+	for i, co := range txn.CoinOutputs {
+		decoded.CoinOutputs = append(decoded.CoinOutputs, DecodedCoinOutput{
+			ID:      txn.CoinOutputID(uint64(i)),
+			Address: co.Condition.UnlockHash(),
+			Output:  co,
+		})
+		decoded.CoinOutputValue = decoded.CoinOutputValue.Add(co.Value)
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		input := DecodedBlockStakeInput{ParentID: bsi.ParentID, Status: InputStatusUnknown}
+		if bso, err := outputs.GetBlockStakeOutput(bsi.ParentID); err == nil {
+			input.Status, input.Parent = InputStatusUnspent, &bso
+		} else if bso, ok := unconfirmed.blockStakeOutputs[bsi.ParentID]; ok {
+			input.Status, input.Parent = InputStatusUnconfirmed, &bso
+		}
+		_, input.SpentInPool = unconfirmed.spentBlockStakeOutputs[bsi.ParentID]
+		if input.Parent == nil {
+			decoded.InputsKnown = false
+		}
+		decoded.BlockStakeInputs = append(decoded.BlockStakeInputs, input)
+	}
+	for i, bso := range txn.BlockStakeOutputs {
+		decoded.BlockStakeOutputs = append(decoded.BlockStakeOutputs, DecodedBlockStakeOutput{
+			ID:      txn.BlockStakeOutputID(uint64(i)),
+			Address: bso.Condition.UnlockHash(),
+			Output:  bso,
+		})
+	}
+	for _, fee := range txn.MinerFees {
+		decoded.MinerFee = decoded.MinerFee.Add(fee)
+	}
+	return decoded
+}
+
+// poolOutputs contains the outputs created and spent by the transactions of the transaction pool.
+type poolOutputs struct {
+	coinOutputs            map[types.CoinOutputID]types.CoinOutput
+	blockStakeOutputs      map[types.BlockStakeOutputID]types.BlockStakeOutput
+	spentCoinOutputs       map[types.CoinOutputID]struct{}
+	spentBlockStakeOutputs map[types.BlockStakeOutputID]struct{}
+}
+
+// newPoolOutputs indexes the outputs of the given pool transactions, ignoring the transaction with the given ID.
+func newPoolOutputs(pool []types.Transaction, ignore types.TransactionID) poolOutputs {
+	outputs := poolOutputs{
+		coinOutputs:            make(map[types.CoinOutputID]types.CoinOutput),
+		blockStakeOutputs:      make(map[types.BlockStakeOutputID]types.BlockStakeOutput),
+		spentCoinOutputs:       make(map[types.CoinOutputID]struct{}),
+		spentBlockStakeOutputs: make(map[types.BlockStakeOutputID]struct{}),
+	}
+	for _, txn := range pool {
+		if txn.ID() == ignore {
+			continue
+		}
+		for i, co := range txn.CoinOutputs {
+			outputs.coinOutputs[txn.CoinOutputID(uint64(i))] = co
+		}
+		for i, bso := range txn.BlockStakeOutputs {
+			outputs.blockStakeOutputs[txn.BlockStakeOutputID(uint64(i))] = bso
+		}
+		for _, ci := range txn.CoinInputs {
+			outputs.spentCoinOutputs[ci.ParentID] = struct{}{}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			outputs.spentBlockStakeOutputs[bsi.ParentID] = struct{}{}
+		}
+	}
+	return outputs
+}
+
+func versionName(version types.TransactionVersion) string {
+	for _, info := range goldchaintypes.TransactionVersions() {
+		if info.Version == version {
+			return info.Name
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,152 @@
+package rawtx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testOutputs struct {
+	coinOutputs map[types.CoinOutputID]types.CoinOutput
+}
+
+func (o testOutputs) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	co, ok := o.coinOutputs[id]
+	if !ok {
+		return types.CoinOutput{}, errors.New("not found")
+	}
+	return co, nil
+}
+
+func (o testOutputs) GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	return types.BlockStakeOutput{}, errors.New("not found")
+}
+
+type testIndex struct {
+	block types.Block
+}
+
+func (i testIndex) UnlockHash(uh types.UnlockHash) []types.TransactionID {
+	var ids []types.TransactionID
+	for _, txn := range i.block.Transactions {
+		for _, co := range txn.CoinOutputs {
+			if co.Condition.UnlockHash() == uh {
+				ids = append(ids, txn.ID())
+				break
+			}
+		}
+	}
+	return ids
+}
+
+func (i testIndex) Transaction(types.TransactionID) (types.Block, types.BlockHeight, bool) {
+	return i.block, 1, true
+}
+
+func testAddress(b byte) types.UnlockHash {
+	return types.UnlockHash{Type: types.UnlockTypePubKey, Hash: [32]byte{b}}
+}
+
+func TestCompose(t *testing.T) {
+	constants := config.GetDevnetGenesis()
+	constants.MinimumTransactionFee = types.NewCurrency64(1)
+	alice, bob, carol := testAddress(1), testAddress(2), testAddress(3)
+	funding := types.Transaction{
+		Version: types.TransactionVersionOne,
+		CoinOutputs: []types.CoinOutput{
+			{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(alice))},
+			{Value: types.NewCurrency64(30), Condition: types.NewCondition(types.NewUnlockHashCondition(alice))},
+			{Value: types.NewCurrency64(20), Condition: types.NewCondition(types.NewUnlockHashCondition(bob))},
+		},
+	}
+	outputs := testOutputs{coinOutputs: make(map[types.CoinOutputID]types.CoinOutput)}
+	for i, co := range funding.CoinOutputs {
+		outputs.coinOutputs[funding.CoinOutputID(uint64(i))] = co
+	}
+	ctx := ComposeContext{
+		Outputs:   outputs,
+		Index:     testIndex{block: types.Block{Transactions: []types.Transaction{funding}}},
+		Constants: constants,
+	}
+	fee := constants.MinimumTransactionFee
+
+	// the largest output of alice is selected, refunding the remainder to alice
+	intent := Intent{
+		Recipients: []Recipient{{Address: carol, Amount: types.NewCurrency64(25).Sub(fee)}},
+		From:       []types.UnlockHash{alice},
+	}
+	composition, err := Compose(intent, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(composition.CoinInputs) != 1 || composition.CoinInputs[0].ID != funding.CoinOutputID(1) {
+		t.Errorf("unexpected coin inputs: %+v", composition.CoinInputs)
+	}
+	if composition.Refund == nil || !composition.Refund.Equals64(5) {
+		t.Errorf("unexpected refund: %v", composition.Refund)
+	}
+	txn := composition.Transaction
+	if len(txn.CoinOutputs) != 2 || txn.CoinOutputs[1].Condition.UnlockHash() != alice || txn.CoinInputs[0].Fulfillment.FulfillmentType() != types.FulfillmentTypeNil {
+		t.Errorf("unexpected transaction: %+v", txn)
+	}
+	if len(composition.SignatureHashes) != 1 {
+		t.Errorf("expected a signature hash per coin input, got %d", len(composition.SignatureHashes))
+	}
+	if decoded := Decode(txn, outputs, nil); !decoded.InputsKnown || !decoded.CoinInputValue.Equals64(30) || decoded.CoinInputs[0].Status != InputStatusUnspent {
+		t.Errorf("unexpected decoded transaction: %+v", decoded)
+	}
+
+	// pinned inputs are used first, without any change the refund output is omitted
+	intent = Intent{
+		Recipients: []Recipient{{Address: carol, Amount: types.NewCurrency64(30).Sub(fee), LockedUntil: 100}},
+		CoinInputs: []types.CoinOutputID{funding.CoinOutputID(2)},
+		From:       []types.UnlockHash{alice},
+	}
+	composition, err = Compose(intent, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(composition.CoinInputs) != 2 || composition.CoinInputs[0].ID != funding.CoinOutputID(2) || composition.CoinInputs[1].ID != funding.CoinOutputID(1) {
+		t.Errorf("unexpected coin inputs: %+v", composition.CoinInputs)
+	}
+	if composition.Refund == nil || !composition.Refund.Equals64(20) || composition.Transaction.CoinOutputs[1].Condition.UnlockHash() != bob {
+		t.Errorf("unexpected refund: %v", composition.Refund)
+	}
+	if composition.Transaction.CoinOutputs[0].Condition.ConditionType() != types.ConditionTypeTimeLock {
+		t.Error("expected the recipient output to be time-locked")
+	}
+
+	// outputs spent by the transaction pool are not used, and show up as such when decoding
+	pool := []types.Transaction{{Version: types.TransactionVersionOne, CoinInputs: []types.CoinInput{{ParentID: funding.CoinOutputID(1)}}}}
+	ctx.Pool = pool
+	intent = Intent{Recipients: []Recipient{{Address: carol, Amount: types.NewCurrency64(5)}}, From: []types.UnlockHash{alice}}
+	composition, err = Compose(intent, ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if composition.CoinInputs[0].ID != funding.CoinOutputID(0) {
+		t.Errorf("unexpected coin inputs: %+v", composition.CoinInputs)
+	}
+	if decoded := Decode(pool[0], outputs, append(pool, composition.Transaction)); decoded.CoinInputs[0].SpentInPool {
+		t.Error("expected the input to only be spent by the decoded transaction itself")
+	}
+	if decoded := Decode(txn, outputs, pool); !decoded.CoinInputs[0].SpentInPool {
+		t.Error("expected the input to be spent by the transaction pool")
+	}
+
+	intent.Recipients[0].Amount = types.NewCurrency64(100)
+	if _, err = Compose(intent, ctx); err != modules.ErrLowBalance {
+		t.Errorf("expected low balance, got %v", err)
+	}
+	intent.CoinInputs = []types.CoinOutputID{funding.CoinOutputID(1)}
+	if _, err = Compose(intent, ctx); err == nil {
+		t.Error("expected an input spent by the transaction pool to be refused")
+	}
+	ctx.Index = nil
+	if _, err = Compose(Intent{Recipients: intent.Recipients, From: intent.From}, ctx); err != ErrAddressIndexUnavailable {
+		t.Errorf("expected the address index to be required, got %v", err)
+	}
+}
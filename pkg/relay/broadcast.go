@@ -0,0 +1,167 @@
+package relay
+
+import (
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// BroadcastStatus is the outcome of a broadcast.
+type BroadcastStatus string
+
+const (
+	// BroadcastRejected is the status of a transaction set rejected locally, which is not relayed to any peer.
+	BroadcastRejected BroadcastStatus = "rejected"
+	// BroadcastAccepted is the status of a transaction set accepted by the local transaction pool
+	// and relayed to the peers, awaiting confirmation.
+	BroadcastAccepted BroadcastStatus = "accepted"
+)
+
+// The checks a transaction set has to pass before being relayed, in the order they are applied.
+const (
+	// CheckPolicy checks every transaction against the relay policy of the node
+	CheckPolicy = "policy"
+	// CheckConsensus validates the transaction set against the current consensus state,
+	// including all plugin validators and the unconfirmed transactions it spends from
+	CheckConsensus = "consensus"
+	// CheckTransactionPool adds the transaction set to the local transaction pool,
+	// refusing it should it conflict with the pool, pay too little fees or exceed its size
+	CheckTransactionPool = "transactionpool"
+)
+
+// BroadcastResult is the result of the broadcast of a transaction set.
+type BroadcastResult struct {
+	Status         BroadcastStatus       `json:"status"`
+	TransactionIDs []types.TransactionID `json:"transactionids"`
+	// RejectedBy is the check which rejected the transaction set, only defined if rejected
+	RejectedBy string `json:"rejectedby,omitempty"`
+	// Error is the reason the transaction set was rejected, only defined if rejected
+	Error string `json:"error,omitempty"`
+	// Known is true in case all transactions of the set were already in the transaction pool,
+	// in which case the set is not relayed again
+	Known bool `json:"known,omitempty"`
+	// Peers is the amount of peers the accepted transaction set is relayed to
+	Peers int `json:"peers"`
+}
+
+// Broadcaster broadcasts transaction sets in two phases: a transaction set is first checked locally,
+// returning the error of the first check it fails, and only relayed to the peers once accepted
+// by the local transaction pool, such that integrators can tell a transaction set rejected locally
+// from one awaiting confirmation.
+type Broadcaster struct {
+	cs      modules.ConsensusSet
+	tpool   *TransactionPool
+	gateway modules.Gateway
+}
+
+// NewBroadcaster creates a new Broadcaster, checking transaction sets against the given consensus set
+// and the policy of the given transaction pool, which relays them to the peers of the given gateway.
+// The gateway is optional.
+func NewBroadcaster(cs modules.ConsensusSet, tpool *TransactionPool, g modules.Gateway) *Broadcaster {
+	return &Broadcaster{
+		cs:      cs,
+		tpool:   tpool,
+		gateway: g,
+	}
+}
+
+// Broadcast checks the given transaction set locally and relays it to the peers,
+// should it pass all checks.
+func (b *Broadcaster) Broadcast(ts []types.Transaction) BroadcastResult {
+	result := BroadcastResult{TransactionIDs: make([]types.TransactionID, 0, len(ts))}
+	for _, txn := range ts {
+		result.TransactionIDs = append(result.TransactionIDs, txn.ID())
+	}
+	if b.known(ts) {
+		result.Status = BroadcastAccepted
+		result.Known = true
+		return result
+	}
+	reject := func(check string, err error) BroadcastResult {
+		result.Status = BroadcastRejected
+		result.RejectedBy = check
+		result.Error = err.Error()
+		return result
+	}
+
+	// phase 1: check the transaction set locally
+	for _, txn := range ts {
+		if err := b.tpool.policy.Check(txn); err != nil {
+			return reject(CheckPolicy, err)
+		}
+	}
+	if _, err := b.cs.TryTransactionSet(append(b.unconfirmedParents(ts), ts...)); err != nil {
+		return reject(CheckConsensus, err)
+	}
+
+	// phase 2: the transaction pool relays the transaction set once it accepted it
+	if err := b.tpool.AcceptTransactionSet(ts); err != nil {
+		return reject(CheckTransactionPool, err)
+	}
+	result.Status = BroadcastAccepted
+	if b.gateway != nil {
+		result.Peers = len(b.gateway.Peers())
+	}
+	return result
+}
+
+// known returns whether all transactions of the given set are in the transaction pool.
+func (b *Broadcaster) known(ts []types.Transaction) bool {
+	if len(ts) == 0 {
+		return false
+	}
+	for _, txn := range ts {
+		if _, err := b.tpool.Transaction(txn.ID()); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// unconfirmedParents returns the transactions of the transaction pool creating the outputs
+// spent by the given transaction set, directly or indirectly, in the order of the transaction pool.
+func (b *Broadcaster) unconfirmedParents(ts []types.Transaction) []types.Transaction {
+	pool := b.tpool.TransactionList()
+	if len(pool) == 0 {
+		return nil
+	}
+	creators := make(map[crypto.Hash]int)
+	for index, txn := range pool {
+		created := make(map[crypto.Hash]struct{})
+		addCreatedOutputs(created, []types.Transaction{txn})
+		for id := range created {
+			creators[id] = index
+		}
+	}
+	parents := make(map[int]struct{})
+	var visit func(txn types.Transaction)
+	spend := func(id crypto.Hash) {
+		index, ok := creators[id]
+		if !ok {
+			return
+		}
+		if _, ok := parents[index]; ok {
+			return
+		}
+		parents[index] = struct{}{}
+		visit(pool[index])
+	}
+	visit = func(txn types.Transaction) {
+		for _, ci := range txn.CoinInputs {
+			spend(crypto.Hash(ci.ParentID))
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			spend(crypto.Hash(bsi.ParentID))
+		}
+	}
+	for _, txn := range ts {
+		visit(txn)
+	}
+	var txns []types.Transaction
+	for index, txn := range pool {
+		if _, ok := parents[index]; ok {
+			txns = append(txns, txn)
+		}
+	}
+	return txns
+}
@@ -0,0 +1,234 @@
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// OrphanExpiry is the duration an orphan transaction set is kept, waiting for its parents.
+const OrphanExpiry = time.Hour
+
+// OrphanMetrics contains the statistics of an OrphanPool since it was created.
+type OrphanMetrics struct {
+	// Orphans is the current amount of orphan transaction sets
+	Orphans int `json:"orphans"`
+	// AcceptedSets is the amount of orphan transaction sets accepted once their parents arrived
+	AcceptedSets uint64 `json:"acceptedsets"`
+	// ExpiredSets is the amount of orphan transaction sets dropped as their parents never arrived,
+	// or to make room for newer orphans
+	ExpiredSets uint64 `json:"expiredsets"`
+}
+
+// OrphanPool keeps the transaction sets received from peers before their parents,
+// being the transactions creating the outputs they spend, for up to OrphanExpiry.
+// An orphan set is given to the transaction pool once all its parents are confirmed or in the transaction pool.
+type OrphanPool struct {
+	cs      modules.ConsensusSet
+	tpool   modules.TransactionPool
+	maxSets int
+
+	mu sync.Mutex
+	// orphans by transaction set hash
+	orphans map[crypto.Hash]*orphan
+	// the hashes of the orphan sets waiting for an output, by output ID
+	waiting map[crypto.Hash]map[crypto.Hash]struct{}
+	metrics OrphanMetrics
+}
+
+type orphan struct {
+	set      []types.Transaction
+	missing  map[crypto.Hash]struct{}
+	received time.Time
+}
+
+// NewOrphanPool creates a new orphan pool, keeping at most the given amount of transaction sets,
+// subscribing it to the given transaction pool, in order to detect the arrival of parents.
+func NewOrphanPool(cs modules.ConsensusSet, tpool modules.TransactionPool, maxSets int) *OrphanPool {
+	p := &OrphanPool{
+		cs:      cs,
+		tpool:   tpool,
+		maxSets: maxSets,
+		orphans: make(map[crypto.Hash]*orphan),
+		waiting: make(map[crypto.Hash]map[crypto.Hash]struct{}),
+	}
+	tpool.TransactionPoolSubscribe(p)
+	return p
+}
+
+// Close unsubscribes the orphan pool from the transaction pool.
+func (p *OrphanPool) Close() error {
+	p.tpool.Unsubscribe(p)
+	return nil
+}
+
+// Metrics returns a snapshot of the statistics of the orphan pool.
+func (p *OrphanPool) Metrics() OrphanMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	metrics := p.metrics
+	metrics.Orphans = len(p.orphans)
+	return metrics
+}
+
+// Add keeps the given transaction set, refused by the transaction pool, should it spend outputs which do not exist yet.
+// False is returned if the set isn't an orphan, in which case it is not kept.
+// Add is not to be called while the transaction pool notifies its subscribers.
+func (p *OrphanPool) Add(set []types.Transaction) bool {
+	return p.add(set, time.Now())
+}
+
+func (p *OrphanPool) add(set []types.Transaction, received time.Time) bool {
+	missing := p.missingParents(set)
+	if len(missing) == 0 {
+		return false
+	}
+	id := crypto.HashObject(set)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, ok := p.orphans[id]; ok {
+		return true
+	}
+	p.expire(time.Now())
+	for len(p.orphans) >= p.maxSets {
+		// make room by dropping the oldest orphan
+		var oldest crypto.Hash
+		var oldestReceived time.Time
+		for id, o := range p.orphans {
+			if oldestReceived.IsZero() || o.received.Before(oldestReceived) {
+				oldest, oldestReceived = id, o.received
+			}
+		}
+		p.remove(oldest)
+		p.metrics.ExpiredSets++
+	}
+	p.orphans[id] = &orphan{set: set, missing: missing, received: received}
+	for parent := range missing {
+		if p.waiting[parent] == nil {
+			p.waiting[parent] = make(map[crypto.Hash]struct{})
+		}
+		p.waiting[parent][id] = struct{}{}
+	}
+	return true
+}
+
+// ReceiveUpdatedUnconfirmedTransactions implements modules.TransactionPoolSubscriber,
+// giving the orphan sets of which all parents arrived to the transaction pool.
+func (p *OrphanPool) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, cc modules.ConsensusChange) {
+	created := make(map[crypto.Hash]struct{})
+	addCreatedOutputs(created, txns)
+	for _, block := range cc.AppliedBlocks {
+		addCreatedOutputs(created, block.Transactions)
+	}
+
+	p.mu.Lock()
+	p.expire(time.Now())
+	var ready []*orphan
+	for parent := range created {
+		for id := range p.waiting[parent] {
+			o := p.orphans[id]
+			delete(o.missing, parent)
+			if len(o.missing) == 0 {
+				ready = append(ready, o)
+				p.remove(id)
+			}
+		}
+		delete(p.waiting, parent)
+	}
+	p.mu.Unlock()
+	if len(ready) > 0 {
+		// the transaction pool cannot be used while it is notifying its subscribers
+		go p.accept(ready)
+	}
+}
+
+// accept gives the orphans of which all parents arrived to the transaction pool,
+// keeping them as orphans should other parents still be missing.
+func (p *OrphanPool) accept(orphans []*orphan) {
+	for _, o := range orphans {
+		err := p.tpool.AcceptTransactionSet(o.set)
+		if err == nil {
+			p.mu.Lock()
+			p.metrics.AcceptedSets++
+			p.mu.Unlock()
+			continue
+		}
+		p.add(o.set, o.received)
+	}
+}
+
+// expire drops the orphans older than OrphanExpiry, the lock has to be held.
+func (p *OrphanPool) expire(now time.Time) {
+	for id, o := range p.orphans {
+		if now.Sub(o.received) > OrphanExpiry {
+			p.remove(id)
+			p.metrics.ExpiredSets++
+		}
+	}
+}
+
+// remove drops an orphan, the lock has to be held.
+func (p *OrphanPool) remove(id crypto.Hash) {
+	o, ok := p.orphans[id]
+	if !ok {
+		return
+	}
+	for parent := range o.missing {
+		delete(p.waiting[parent], id)
+		if len(p.waiting[parent]) == 0 {
+			delete(p.waiting, parent)
+		}
+	}
+	delete(p.orphans, id)
+}
+
+// missingParents returns the IDs of the outputs spent by the given transaction set,
+// which are neither created by the set, unspent in the consensus set nor created by the transaction pool.
+func (p *OrphanPool) missingParents(set []types.Transaction) map[crypto.Hash]struct{} {
+	created := make(map[crypto.Hash]struct{})
+	addCreatedOutputs(created, set)
+	var pool map[crypto.Hash]struct{}
+	missing := make(map[crypto.Hash]struct{})
+	isMissing := func(id crypto.Hash, exists func() bool) bool {
+		if _, ok := created[id]; ok || exists() {
+			return false
+		}
+		if pool == nil {
+			pool = make(map[crypto.Hash]struct{})
+			addCreatedOutputs(pool, p.tpool.TransactionList())
+		}
+		_, ok := pool[id]
+		return !ok
+	}
+	for _, txn := range set {
+		for _, ci := range txn.CoinInputs {
+			id := ci.ParentID
+			if isMissing(crypto.Hash(id), func() bool { _, err := p.cs.GetCoinOutput(id); return err == nil }) {
+				missing[crypto.Hash(id)] = struct{}{}
+			}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			id := bsi.ParentID
+			if isMissing(crypto.Hash(id), func() bool { _, err := p.cs.GetBlockStakeOutput(id); return err == nil }) {
+				missing[crypto.Hash(id)] = struct{}{}
+			}
+		}
+	}
+	return missing
+}
+
+// addCreatedOutputs adds the IDs of all outputs created by the given transactions to the given set.
+func addCreatedOutputs(ids map[crypto.Hash]struct{}, txns []types.Transaction) {
+	for _, txn := range txns {
+		for i := range txn.CoinOutputs {
+			ids[crypto.Hash(txn.CoinOutputID(uint64(i)))] = struct{}{}
+		}
+		for i := range txn.BlockStakeOutputs {
+			ids[crypto.Hash(txn.BlockStakeOutputID(uint64(i)))] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package relay
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// relayTransactionSetRPC is the name of the gateway RPC,
+// used by the transaction pool to receive transaction sets from peers.
+const relayTransactionSetRPC = "RelayTransactionSet"
+
+// Policy defines the rules a transaction set has to respect,
+// on top of consensus validity, in order to be accepted from peers and relayed further.
+// A zero-value Policy accepts all transaction sets.
+type Policy struct {
+	// MinimumMinerFee is the minimum total miner fee a transaction has to pay
+	// in order to be relayed. Transactions without coin inputs (e.g. auth address updates)
+	// are not required to pay fees, and are thus not subject to this rule.
+	MinimumMinerFee types.Currency `json:"minimumminerfee"`
+	// MaxArbitraryDataSize is the maximum size (in bytes) of the arbitrary data of a transaction
+	// in order to be relayed. 0 means that only the consensus limit applies.
+	MaxArbitraryDataSize uint64 `json:"maxarbitrarydatasize"`
+	// DustThreshold is the value below which a coin output is considered dust,
+	// zero disables the dust rules. Just like the miner fee rule,
+	// the dust rules only apply to transactions with coin inputs.
+	DustThreshold types.Currency `json:"dustthreshold"`
+	// DustOutputFee is the miner fee to be paid for each dust output created by a transaction,
+	// on top of the minimum miner fee. Transactions creating dust outputs are refused
+	// if no dust output fee is defined.
+	DustOutputFee types.Currency `json:"dustoutputfee"`
+}
+
+// Check returns an error in case the given transaction does not respect this policy.
+func (p Policy) Check(txn types.Transaction) error {
+	_, err := p.check(txn)
+	return err
+}
+
+type dropReason uint8
+
+const (
+	dropReasonNone dropReason = iota
+	dropReasonMinerFee
+	dropReasonArbitraryData
+	dropReasonDust
+)
+
+func (p Policy) check(txn types.Transaction) (dropReason, error) {
+	if p.MaxArbitraryDataSize > 0 && uint64(len(txn.ArbitraryData)) > p.MaxArbitraryDataSize {
+		return dropReasonArbitraryData, fmt.Errorf("arbitrary data of transaction %s is %d bytes, exceeding the relay limit of %d bytes",
+			txn.ID().String(), len(txn.ArbitraryData), p.MaxArbitraryDataSize)
+	}
+	if len(txn.CoinInputs) == 0 {
+		return dropReasonNone, nil
+	}
+	var fee types.Currency
+	for _, minerFee := range txn.MinerFees {
+		fee = fee.Add(minerFee)
+	}
+	if !p.MinimumMinerFee.IsZero() && fee.Cmp(p.MinimumMinerFee) < 0 {
+		return dropReasonMinerFee, fmt.Errorf("miner fee of transaction %s is %s, below the relay floor of %s",
+			txn.ID().String(), fee.String(), p.MinimumMinerFee.String())
+	}
+	if !p.DustThreshold.IsZero() {
+		var dustOutputs uint64
+		for _, co := range txn.CoinOutputs {
+			if co.Value.Cmp(p.DustThreshold) < 0 {
+				dustOutputs++
+			}
+		}
+		if dustOutputs > 0 {
+			if p.DustOutputFee.IsZero() {
+				return dropReasonDust, fmt.Errorf("transaction %s creates %d coin output(s) below the dust threshold of %s",
+					txn.ID().String(), dustOutputs, p.DustThreshold.String())
+			}
+			requiredFee := p.MinimumMinerFee.Add(p.DustOutputFee.Mul64(dustOutputs))
+			if fee.Cmp(requiredFee) < 0 {
+				return dropReasonDust, fmt.Errorf("miner fee of transaction %s is %s, while its %d dust output(s) require a miner fee of %s",
+					txn.ID().String(), fee.String(), dustOutputs, requiredFee.String())
+			}
+		}
+	}
+	return dropReasonNone, nil
+}
+
+// Metrics contains the relay statistics collected by a Filter since it was created.
+type Metrics struct {
+	AcceptedSets           uint64 `json:"acceptedsets"`
+	DroppedSets            uint64 `json:"droppedsets"`
+	DroppedMinerFee        uint64 `json:"droppedminerfee"`
+	DroppedArbitraryData   uint64 `json:"droppedarbitrarydata"`
+	DroppedDust            uint64 `json:"droppeddust"`
+	DroppedInvalidEncoding uint64 `json:"droppedinvalidencoding"`
+	// OrphanedSets is the amount of transaction sets received before their parents,
+	// which are kept in the orphan pool
+	OrphanedSets uint64 `json:"orphanedsets"`
+}
+
+// Filter applies a relay Policy to all transaction sets received from peers,
+// prior to giving them to the transaction pool, which validates and relays them further.
+type Filter struct {
+	policy         Policy
+	tpool          modules.TransactionPool
+	orphans        *OrphanPool
+	blockSizeLimit uint64
+
+	mu      sync.Mutex
+	metrics Metrics
+}
+
+// NewFilter creates a new relay filter for the given policy and transaction pool,
+// keeping the transaction sets received before their parents in the given orphan pool, if not nil.
+func NewFilter(policy Policy, tpool modules.TransactionPool, orphans *OrphanPool, constants types.ChainConstants) *Filter {
+	return &Filter{
+		policy:         policy,
+		tpool:          tpool,
+		orphans:        orphans,
+		blockSizeLimit: constants.BlockSizeLimit,
+	}
+}
+
+// RegisterRPC replaces the transaction relay RPC, as registered by the transaction pool,
+// with the RPC of this filter. The transaction pool will unregister it when it gets closed.
+func (f *Filter) RegisterRPC(g modules.Gateway) {
+	g.UnregisterRPC(relayTransactionSetRPC)
+	g.RegisterRPC(relayTransactionSetRPC, f.relayTransactionSet)
+}
+
+// Policy returns the policy applied by this filter.
+func (f *Filter) Policy() Policy {
+	return f.policy
+}
+
+// Orphans returns the orphan pool of this filter, nil if disabled.
+func (f *Filter) Orphans() *OrphanPool {
+	return f.orphans
+}
+
+// Metrics returns a snapshot of the relay statistics of this filter.
+func (f *Filter) Metrics() Metrics {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.metrics
+}
+
+// relayTransactionSet is the RPC that accepts a transaction set from a peer,
+// dropping it silently in case any of its transactions do not respect the relay policy.
+func (f *Filter) relayTransactionSet(conn modules.PeerConn) error {
+	var ts []types.Transaction
+	err := siabin.ReadObject(conn, &ts, f.blockSizeLimit)
+	if err != nil {
+		f.mu.Lock()
+		f.metrics.DroppedSets++
+		f.metrics.DroppedInvalidEncoding++
+		f.mu.Unlock()
+		return err
+	}
+	for _, txn := range ts {
+		reason, _ := f.policy.check(txn)
+		if reason != dropReasonNone {
+			f.mu.Lock()
+			f.metrics.DroppedSets++
+			switch reason {
+			case dropReasonMinerFee:
+				f.metrics.DroppedMinerFee++
+			case dropReasonArbitraryData:
+				f.metrics.DroppedArbitraryData++
+			case dropReasonDust:
+				f.metrics.DroppedDust++
+			}
+			f.mu.Unlock()
+			// not relaying a transaction set isn't a peer error
+			return nil
+		}
+	}
+	f.mu.Lock()
+	f.metrics.AcceptedSets++
+	f.mu.Unlock()
+	err = f.tpool.AcceptTransactionSet(ts)
+	if err != nil && err != modules.ErrDuplicateTransactionSet && f.orphans != nil && f.orphans.Add(ts) {
+		f.mu.Lock()
+		f.metrics.OrphanedSets++
+		f.mu.Unlock()
+		// the set is accepted once its parents arrive
+		return nil
+	}
+	return err
+}
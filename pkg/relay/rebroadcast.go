@@ -0,0 +1,127 @@
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// RebroadcastMaxAge is the duration a local transaction set is rebroadcasted,
+// should it not be confirmed or dropped by the transaction pool before.
+const RebroadcastMaxAge = 24 * time.Hour
+
+// Rebroadcaster wraps a transaction pool, periodically rebroadcasting the transaction sets accepted locally
+// (e.g. submitted over the API or created by the wallet) to all peers, as long as they are unconfirmed,
+// such that they reach the block creators, even if peers dropped them or weren't connected when they were first relayed.
+type Rebroadcaster struct {
+	modules.TransactionPool
+	gateway modules.Gateway
+
+	mu   sync.Mutex
+	sets map[crypto.Hash]localSet
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+type localSet struct {
+	set      []types.Transaction
+	accepted time.Time
+}
+
+var _ modules.TransactionPool = (*Rebroadcaster)(nil)
+
+// NewRebroadcaster creates a new Rebroadcaster, wrapping the given transaction pool,
+// rebroadcasting the transaction sets it accepts to the peers of the given gateway at the given interval.
+func NewRebroadcaster(tpool modules.TransactionPool, g modules.Gateway, interval time.Duration) *Rebroadcaster {
+	r := &Rebroadcaster{
+		TransactionPool: tpool,
+		gateway:         g,
+		sets:            make(map[crypto.Hash]localSet),
+		stop:            make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.rebroadcast(time.Now())
+			}
+		}
+	}()
+	return r
+}
+
+// AcceptTransactionSet implements modules.TransactionPool.AcceptTransactionSet,
+// rebroadcasting the transaction set from now on, should it be accepted.
+func (r *Rebroadcaster) AcceptTransactionSet(ts []types.Transaction) error {
+	err := r.TransactionPool.AcceptTransactionSet(ts)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.sets[crypto.HashObject(ts)] = localSet{set: ts, accepted: time.Now()}
+	r.mu.Unlock()
+	return nil
+}
+
+// Close stops rebroadcasting, leaving the wrapped transaction pool open.
+func (r *Rebroadcaster) Close() error {
+	close(r.stop)
+	r.wg.Wait()
+	return nil
+}
+
+// Pending returns the amount of local transaction sets which are rebroadcasted.
+func (r *Rebroadcaster) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sets)
+}
+
+// rebroadcast broadcasts all local transaction sets which are still in the transaction pool,
+// forgetting those which are confirmed, dropped or older than RebroadcastMaxAge.
+func (r *Rebroadcaster) rebroadcast(now time.Time) {
+	r.mu.Lock()
+	sets := make(map[crypto.Hash]localSet, len(r.sets))
+	for id, ls := range r.sets {
+		sets[id] = ls
+	}
+	r.mu.Unlock()
+
+	var pending [][]types.Transaction
+	for id, ls := range sets {
+		if now.Sub(ls.accepted) <= RebroadcastMaxAge && r.unconfirmed(ls.set) {
+			pending = append(pending, ls.set)
+			continue
+		}
+		r.mu.Lock()
+		delete(r.sets, id)
+		r.mu.Unlock()
+	}
+	if len(pending) == 0 {
+		return
+	}
+	peers := r.gateway.Peers()
+	for _, set := range pending {
+		r.gateway.Broadcast(relayTransactionSetRPC, set, peers)
+	}
+}
+
+// unconfirmed returns whether all transactions of the set are still in the transaction pool.
+func (r *Rebroadcaster) unconfirmed(set []types.Transaction) bool {
+	for _, txn := range set {
+		if _, err := r.TransactionPool.Transaction(txn.ID()); err != nil {
+			return false
+		}
+	}
+	return true
+}
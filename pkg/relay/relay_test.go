@@ -0,0 +1,242 @@
+package relay
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testTPool is a transaction pool accepting all transaction sets of which the parents are known,
+// notifying its subscriber synchronously, as the transaction pool does while holding its lock.
+type testTPool struct {
+	modules.TransactionPool
+
+	mu         sync.Mutex
+	txns       []types.Transaction
+	subscriber modules.TransactionPoolSubscriber
+	accepted   chan []types.Transaction
+}
+
+func (tp *testTPool) AcceptTransactionSet(ts []types.Transaction) error {
+	tp.mu.Lock()
+	created := make(map[types.CoinOutputID]bool)
+	for _, txn := range append(tp.txns, ts...) {
+		for i := range txn.CoinOutputs {
+			created[txn.CoinOutputID(uint64(i))] = true
+		}
+	}
+	for _, txn := range ts {
+		for _, ci := range txn.CoinInputs {
+			if !created[ci.ParentID] && ci.ParentID != (types.CoinOutputID{}) {
+				tp.mu.Unlock()
+				return errors.New("missing parent")
+			}
+		}
+	}
+	tp.txns = append(tp.txns, ts...)
+	txns := tp.txns
+	tp.mu.Unlock()
+	if tp.subscriber != nil {
+		tp.subscriber.ReceiveUpdatedUnconfirmedTransactions(txns, modules.ConsensusChange{})
+	}
+	if tp.accepted != nil {
+		tp.accepted <- ts
+	}
+	return nil
+}
+
+func (tp *testTPool) TransactionList() []types.Transaction {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	return append([]types.Transaction(nil), tp.txns...)
+}
+
+func (tp *testTPool) Transaction(id types.TransactionID) (types.Transaction, error) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+	for _, txn := range tp.txns {
+		if txn.ID() == id {
+			return txn, nil
+		}
+	}
+	return types.Transaction{}, errors.New("not found")
+}
+
+func (tp *testTPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) { tp.subscriber = s }
+func (tp *testTPool) Unsubscribe(modules.TransactionPoolSubscriber)                { tp.subscriber = nil }
+
+// testCS is a consensus set of which only the zero coin output ID is unspent.
+type testCS struct {
+	modules.ConsensusSet
+}
+
+func (testCS) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	if id == (types.CoinOutputID{}) {
+		return types.CoinOutput{}, nil
+	}
+	return types.CoinOutput{}, errors.New("not found")
+}
+
+// TryTransactionSet accepts the transaction sets of which all parents are unspent or created by the set itself.
+func (cs testCS) TryTransactionSet(ts []types.Transaction) (modules.ConsensusChange, error) {
+	created := map[types.CoinOutputID]bool{{}: true}
+	for _, txn := range ts {
+		for _, ci := range txn.CoinInputs {
+			if !created[ci.ParentID] {
+				return modules.ConsensusChange{}, errors.New("spends an unknown coin output")
+			}
+			delete(created, ci.ParentID)
+		}
+		for i := range txn.CoinOutputs {
+			created[txn.CoinOutputID(uint64(i))] = true
+		}
+	}
+	return modules.ConsensusChange{}, nil
+}
+
+// testGateway records the transaction sets broadcasted to its peers.
+type testGateway struct {
+	modules.Gateway
+	broadcasted chan interface{}
+}
+
+func (g *testGateway) Peers() []modules.Peer { return nil }
+func (g *testGateway) Broadcast(name string, obj interface{}, peers []modules.Peer) {
+	g.broadcasted <- obj
+}
+
+// spending returns a transaction spending the given coin output, creating a coin output of the given value.
+func spending(parent types.CoinOutputID, value uint64) types.Transaction {
+	return types.Transaction{
+		Version:     types.TransactionVersionOne,
+		CoinInputs:  []types.CoinInput{{ParentID: parent}},
+		CoinOutputs: []types.CoinOutput{{Value: types.NewCurrency64(value)}},
+	}
+}
+
+func TestOrphanPool(t *testing.T) {
+	tpool := &testTPool{accepted: make(chan []types.Transaction, 10)}
+	orphans := NewOrphanPool(testCS{}, tpool, 2)
+	defer orphans.Close()
+
+	parent := spending(types.CoinOutputID{}, 1)
+	child := spending(parent.CoinOutputID(0), 2)
+	grandchild := spending(child.CoinOutputID(0), 3)
+	if orphans.Add([]types.Transaction{parent}) {
+		t.Fatal("expected a transaction spending a confirmed output not to be an orphan")
+	}
+	if !orphans.Add([]types.Transaction{grandchild}) || !orphans.Add([]types.Transaction{child}) {
+		t.Fatal("expected transactions spending unknown outputs to be orphans")
+	}
+	// the oldest orphan is dropped to make room
+	if !orphans.Add([]types.Transaction{spending(types.CoinOutputID{1}, 4)}) {
+		t.Fatal("expected a transaction spending an unknown output to be an orphan")
+	}
+	if metrics := orphans.Metrics(); metrics.Orphans != 2 || metrics.ExpiredSets != 1 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+
+	// the child is accepted once its parent arrives
+	if err := tpool.AcceptTransactionSet([]types.Transaction{parent}); err != nil {
+		t.Fatal(err)
+	}
+	<-tpool.accepted
+	select {
+	case set := <-tpool.accepted:
+		if len(set) != 1 || set[0].ID() != child.ID() {
+			t.Fatalf("unexpected transaction set accepted: %v", set)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("orphan was not accepted")
+	}
+	if metrics := orphans.Metrics(); metrics.Orphans != 1 || metrics.AcceptedSets != 1 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestRebroadcaster(t *testing.T) {
+	tpool := &testTPool{}
+	g := &testGateway{broadcasted: make(chan interface{}, 10)}
+	r := NewRebroadcaster(tpool, g, time.Hour)
+	defer r.Close()
+
+	set := []types.Transaction{spending(types.CoinOutputID{}, 1)}
+	if err := r.AcceptTransactionSet(set); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AcceptTransactionSet([]types.Transaction{spending(types.CoinOutputID{1}, 2)}); err == nil {
+		t.Fatal("expected the transaction set to be refused")
+	}
+	if r.Pending() != 1 {
+		t.Fatalf("expected 1 pending transaction set, got %d", r.Pending())
+	}
+
+	// unconfirmed sets are rebroadcasted until they age out
+	r.rebroadcast(time.Now())
+	if broadcasted := (<-g.broadcasted).([]types.Transaction); len(broadcasted) != 1 || broadcasted[0].ID() != set[0].ID() {
+		t.Fatalf("unexpected broadcasted transaction set: %v", broadcasted)
+	}
+	r.rebroadcast(time.Now().Add(RebroadcastMaxAge + time.Minute))
+	if r.Pending() != 0 || len(g.broadcasted) != 0 {
+		t.Fatal("expected the aged out transaction set to be forgotten")
+	}
+
+	// confirmed sets, no longer in the transaction pool, are forgotten
+	if err := r.AcceptTransactionSet(set); err != nil {
+		t.Fatal(err)
+	}
+	tpool.txns = nil
+	r.rebroadcast(time.Now())
+	if r.Pending() != 0 || len(g.broadcasted) != 0 {
+		t.Fatal("expected the confirmed transaction set to be forgotten")
+	}
+}
+
+func TestBroadcaster(t *testing.T) {
+	tpool := &testTPool{}
+	b := NewBroadcaster(testCS{}, NewTransactionPool(tpool, Policy{MinimumMinerFee: types.NewCurrency64(1)}), nil)
+
+	parent := spending(types.CoinOutputID{}, 1)
+	parent.MinerFees = []types.Currency{types.NewCurrency64(1)}
+	if result := b.Broadcast([]types.Transaction{parent}); result.Status != BroadcastAccepted || result.Known ||
+		len(result.TransactionIDs) != 1 || result.TransactionIDs[0] != parent.ID() {
+		t.Fatalf("expected the transaction to be accepted, got %+v", result)
+	}
+	if result := b.Broadcast([]types.Transaction{parent}); result.Status != BroadcastAccepted || !result.Known {
+		t.Errorf("expected the transaction to be known, got %+v", result)
+	}
+
+	// transaction sets are rejected by the first check they fail, without reaching the transaction pool
+	cheap := spending(parent.CoinOutputID(0), 2)
+	if result := b.Broadcast([]types.Transaction{cheap}); result.Status != BroadcastRejected || result.RejectedBy != CheckPolicy || result.Error == "" {
+		t.Errorf("expected the transaction to be rejected by the policy, got %+v", result)
+	}
+	orphan := spending(types.CoinOutputID{1}, 3)
+	orphan.MinerFees = parent.MinerFees
+	if result := b.Broadcast([]types.Transaction{orphan}); result.Status != BroadcastRejected || result.RejectedBy != CheckConsensus {
+		t.Errorf("expected the transaction to be rejected by the consensus, got %+v", result)
+	}
+	if len(tpool.TransactionList()) != 1 {
+		t.Fatal("expected the rejected transactions not to reach the transaction pool")
+	}
+
+	// the unconfirmed parents of a transaction are validated together with it
+	child := spending(parent.CoinOutputID(0), 4)
+	child.MinerFees = parent.MinerFees
+	grandchild := spending(child.CoinOutputID(0), 5)
+	grandchild.MinerFees = parent.MinerFees
+	if result := b.Broadcast([]types.Transaction{child}); result.Status != BroadcastAccepted {
+		t.Fatalf("expected the child to be accepted, got %+v", result)
+	}
+	if parents := b.unconfirmedParents([]types.Transaction{grandchild}); len(parents) != 2 ||
+		parents[0].ID() != parent.ID() || parents[1].ID() != child.ID() {
+		t.Errorf("unexpected unconfirmed parents: %v", parents)
+	}
+	if result := b.Broadcast([]types.Transaction{grandchild}); result.Status != BroadcastAccepted {
+		t.Errorf("expected the grandchild to be accepted, got %+v", result)
+	}
+}
@@ -0,0 +1,36 @@
+package relay
+
+import (
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// TransactionPool wraps a transaction pool, applying a relay Policy
+// to all transaction sets accepted locally (e.g. submitted over the API or created by the wallet),
+// such that the node never accepts transactions its peers would not relay.
+type TransactionPool struct {
+	modules.TransactionPool
+	policy Policy
+}
+
+var _ modules.TransactionPool = (*TransactionPool)(nil)
+
+// NewTransactionPool creates a new transaction pool, applying the given policy,
+// wrapping the given transaction pool.
+func NewTransactionPool(tpool modules.TransactionPool, policy Policy) *TransactionPool {
+	return &TransactionPool{
+		TransactionPool: tpool,
+		policy:          policy,
+	}
+}
+
+// AcceptTransactionSet implements modules.TransactionPool.AcceptTransactionSet,
+// returning an error for any transaction set of which a transaction does not respect the policy.
+func (tp *TransactionPool) AcceptTransactionSet(ts []types.Transaction) error {
+	for _, txn := range ts {
+		if err := tp.policy.Check(txn); err != nil {
+			return err
+		}
+	}
+	return tp.TransactionPool.AcceptTransactionSet(ts)
+}
@@ -0,0 +1,201 @@
+package reorgs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// File is the name of the file in which the most recent reorganizations are stored.
+	File = "reorgs.json"
+	// MaxRecorded is the amount of most recent reorganizations which are kept.
+	MaxRecorded = 100
+)
+
+var reorgsMetadata = persist.Metadata{
+	Header:  "Goldchain Reorganizations",
+	Version: "1.0",
+}
+
+type (
+	// Reorg is a reorganization of the blockchain, replacing the most recent blocks of the chain
+	// by the blocks of a longer fork.
+	Reorg struct {
+		// ID is the sequence number of the reorganization, increasing by one for every reorganization the node records
+		ID uint64 `json:"id"`
+		// DetectedAt is the local time at which the node switched to the fork
+		DetectedAt types.Timestamp `json:"detectedat"`
+		// ForkHeight is the height of the last block shared by both chains
+		ForkHeight types.BlockHeight `json:"forkheight"`
+		// Depth is the amount of blocks reverted
+		Depth uint64 `json:"depth"`
+		// RevertedBlocks are the IDs of the reverted blocks, and AppliedBlocks the IDs of the blocks replacing them,
+		// both in ascending height order, starting at the height following the ForkHeight
+		RevertedBlocks []types.BlockID `json:"revertedblocks"`
+		AppliedBlocks  []types.BlockID `json:"appliedblocks"`
+	}
+
+	// reorgsFile is the stored form of the recorded reorganizations.
+	reorgsFile struct {
+		LastID uint64  `json:"lastid"`
+		Reorgs []Reorg `json:"reorgs"`
+	}
+)
+
+// Tracker records the reorganizations of the blockchain applied by a consensus set,
+// keeping the most recent ones on disk, and notifying all clients waiting for them.
+type Tracker struct {
+	cs       modules.ConsensusSet
+	filename string
+	output   io.Writer
+	closeCh  chan struct{}
+
+	mu     sync.Mutex
+	height types.BlockHeight
+	lastID uint64
+	// reorgs are the most recent reorganizations, oldest first
+	reorgs []Reorg
+	// changed is closed (and replaced) whenever a reorganization is recorded
+	changed chan struct{}
+}
+
+// NewTracker creates a new Tracker, storing the reorganizations in the given file,
+// subscribing it to the given consensus set, which is expected not to be started yet.
+// Reorganizations, as well as failures to store them, are logged to the given writer.
+func NewTracker(cs modules.ConsensusSet, filename string, output io.Writer) (*Tracker, error) {
+	t, err := newTracker(filename, output)
+	if err != nil {
+		return nil, err
+	}
+	t.cs = cs
+	t.height = cs.Height()
+	err = cs.ConsensusSetSubscribe(t, modules.ConsensusChangeRecent, t.closeCh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	return t, nil
+}
+
+func newTracker(filename string, output io.Writer) (*Tracker, error) {
+	if output == nil {
+		output = ioutil.Discard
+	}
+	t := &Tracker{
+		filename: filename,
+		output:   output,
+		closeCh:  make(chan struct{}),
+		changed:  make(chan struct{}),
+	}
+	var file reorgsFile
+	err := persist.LoadJSON(reorgsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load reorganizations: %v", err)
+	}
+	t.lastID, t.reorgs = file.LastID, file.Reorgs
+	return t, nil
+}
+
+// Close unsubscribes the Tracker from the consensus set.
+func (t *Tracker) Close() error {
+	if t.cs != nil {
+		t.cs.Unsubscribe(t)
+	}
+	close(t.closeCh)
+	return nil
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// recording every consensus change reverting blocks as a reorganization.
+func (t *Tracker) ProcessConsensusChange(cc modules.ConsensusChange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.height -= types.BlockHeight(len(cc.RevertedBlocks))
+	if len(cc.RevertedBlocks) > 0 {
+		t.lastID++
+		reorg := Reorg{
+			ID:             t.lastID,
+			DetectedAt:     types.CurrentTimestamp(),
+			ForkHeight:     t.height,
+			Depth:          uint64(len(cc.RevertedBlocks)),
+			RevertedBlocks: make([]types.BlockID, 0, len(cc.RevertedBlocks)),
+			AppliedBlocks:  make([]types.BlockID, 0, len(cc.AppliedBlocks)),
+		}
+		// the blocks are reverted starting with the most recent one
+		for i := len(cc.RevertedBlocks) - 1; i >= 0; i-- {
+			reorg.RevertedBlocks = append(reorg.RevertedBlocks, cc.RevertedBlocks[i].ID())
+		}
+		for _, block := range cc.AppliedBlocks {
+			reorg.AppliedBlocks = append(reorg.AppliedBlocks, block.ID())
+		}
+		t.record(reorg)
+	}
+	t.height += types.BlockHeight(len(cc.AppliedBlocks))
+}
+
+// record stores the given reorganization and notifies all waiting clients, the caller holding mu.
+func (t *Tracker) record(reorg Reorg) {
+	fmt.Fprintf(t.output, "Chain reorganization at height %d: %d blocks reverted, %d blocks applied\n",
+		reorg.ForkHeight, len(reorg.RevertedBlocks), len(reorg.AppliedBlocks))
+	t.reorgs = append(t.reorgs, reorg)
+	if len(t.reorgs) > MaxRecorded {
+		t.reorgs = t.reorgs[len(t.reorgs)-MaxRecorded:]
+	}
+	err := persist.SaveJSON(reorgsMetadata, reorgsFile{LastID: t.lastID, Reorgs: t.reorgs}, t.filename)
+	if err != nil {
+		fmt.Fprintf(t.output, "Failed to store reorganization %d: %v\n", reorg.ID, err)
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// Reorgs returns the recorded reorganizations with an ID greater than the given one,
+// which reverted at least the given amount of blocks, oldest first,
+// as well as the ID of the last recorded reorganization.
+func (t *Tracker) Reorgs(after, minDepth uint64) ([]Reorg, uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	reorgs, _ := t.reorgsAfter(after, minDepth)
+	return reorgs, t.lastID
+}
+
+// Wait returns the same reorganizations as Reorgs,
+// waiting for such a reorganization to be recorded should there be none yet,
+// until the given context is done.
+func (t *Tracker) Wait(ctx context.Context, after, minDepth uint64) ([]Reorg, uint64) {
+	for {
+		t.mu.Lock()
+		reorgs, changed := t.reorgsAfter(after, minDepth)
+		lastID := t.lastID
+		t.mu.Unlock()
+		if len(reorgs) > 0 {
+			return reorgs, lastID
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return reorgs, lastID
+		case <-t.closeCh:
+			return reorgs, lastID
+		}
+	}
+}
+
+// reorgsAfter returns the reorganizations selected by Reorgs,
+// as well as the channel closed once another reorganization is recorded, the caller holding mu.
+func (t *Tracker) reorgsAfter(after, minDepth uint64) ([]Reorg, <-chan struct{}) {
+	reorgs := []Reorg{}
+	for _, reorg := range t.reorgs {
+		if reorg.ID > after && reorg.Depth >= minDepth {
+			reorgs = append(reorgs, reorg)
+		}
+	}
+	return reorgs, t.changed
+}
@@ -0,0 +1,101 @@
+package reorgs
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestTracker(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), File)
+	tracker, err := newTracker(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks := func(timestamps ...types.Timestamp) []types.Block {
+		var blocks []types.Block
+		for _, timestamp := range timestamps {
+			blocks = append(blocks, types.Block{Timestamp: timestamp})
+		}
+		return blocks
+	}
+	ids := func(blocks []types.Block) []types.BlockID {
+		var ids []types.BlockID
+		for _, block := range blocks {
+			ids = append(ids, block.ID())
+		}
+		return ids
+	}
+
+	chain := blocks(1, 2, 3)
+	tracker.ProcessConsensusChange(modules.ConsensusChange{AppliedBlocks: chain})
+	if reorgs, lastID := tracker.Reorgs(0, 0); len(reorgs) != 0 || lastID != 0 {
+		t.Fatalf("expected no reorganizations, got %v", reorgs)
+	}
+
+	// waiting clients are notified of the reorganization
+	done := make(chan []Reorg)
+	go func() {
+		reorgs, _ := tracker.Wait(context.Background(), 0, 2)
+		done <- reorgs
+	}()
+	fork := blocks(12, 13, 14)
+	tracker.ProcessConsensusChange(modules.ConsensusChange{
+		RevertedBlocks: []types.Block{chain[2], chain[1]},
+		AppliedBlocks:  fork,
+	})
+	var reorgs []Reorg
+	select {
+	case reorgs = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting client was not notified")
+	}
+	if len(reorgs) != 1 {
+		t.Fatalf("unexpected reorganizations: %v", reorgs)
+	}
+	reorg := reorgs[0]
+	if reorg.ID != 1 || reorg.ForkHeight != 1 || reorg.Depth != 2 {
+		t.Errorf("unexpected reorganization: %+v", reorg)
+	}
+	for i, id := range ids(chain[1:]) {
+		if reorg.RevertedBlocks[i] != id {
+			t.Errorf("unexpected reverted block #%d: %s", i, reorg.RevertedBlocks[i].String())
+		}
+	}
+	for i, id := range ids(fork) {
+		if reorg.AppliedBlocks[i] != id {
+			t.Errorf("unexpected applied block #%d: %s", i, reorg.AppliedBlocks[i].String())
+		}
+	}
+
+	// the height is tracked across reorganizations
+	tracker.ProcessConsensusChange(modules.ConsensusChange{
+		RevertedBlocks: fork[2:],
+		AppliedBlocks:  blocks(24, 25),
+	})
+	if reorgs, lastID := tracker.Reorgs(1, 0); len(reorgs) != 1 || reorgs[0].ForkHeight != 3 || lastID != 2 {
+		t.Fatalf("unexpected reorganizations: %v", reorgs)
+	}
+	if reorgs, _ := tracker.Reorgs(0, 2); len(reorgs) != 1 || reorgs[0].ID != 1 {
+		t.Fatalf("unexpected reorganizations of at least 2 blocks: %v", reorgs)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if reorgs, _ := tracker.Wait(ctx, 2, 0); len(reorgs) != 0 {
+		t.Fatalf("expected no reorganizations after waiting, got %v", reorgs)
+	}
+
+	// the reorganizations are kept across restarts
+	tracker.Close()
+	tracker, err = newTracker(filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reorgs, lastID := tracker.Reorgs(0, 0); len(reorgs) != 2 || lastID != 2 {
+		t.Fatalf("unexpected reorganizations after restart: %v", reorgs)
+	}
+}
@@ -0,0 +1,564 @@
+package richlist
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "RichListPlugin"
+)
+
+var (
+	// bucketOutputs maps the IDs of all coin outputs ever created to the outputs,
+	// such that the owner and value of spent outputs are known
+	bucketOutputs = []byte("outputs")
+	// bucketBalances maps all addresses owning coins to their balance
+	bucketBalances = []byte("balances")
+	// bucketRanking contains a key per address owning coins, composed of its balance and the address,
+	// such that iterating it in reverse order returns the addresses owning the most coins first
+	bucketRanking = []byte("ranking")
+	// bucketBins maps the amount of digits of a balance to the amount of addresses
+	// owning a balance of that amount of digits, and the total of these balances
+	bucketBins = []byte("bins")
+
+	// keyHeight is the key of the height of the last applied block, stored in the plugin bucket itself
+	keyHeight = []byte("height")
+)
+
+type (
+	// Plugin is a consensus set plugin, maintaining the coin balance of all addresses,
+	// indexed such that the addresses owning the most coins and the distribution of all balances
+	// can be returned without scanning the blockchain.
+	// The balance of an address includes its locked coins.
+	Plugin struct {
+		genesis            types.Block
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// Holder is an address owning coins.
+	Holder struct {
+		UnlockHash types.UnlockHash `json:"unlockhash"`
+		Balance    types.Currency   `json:"balance"`
+	}
+
+	// RichList contains the addresses owning the most coins,
+	// as well as the amount of addresses owning coins and their total balance.
+	RichList struct {
+		Height types.BlockHeight `json:"height"`
+		// Holders are the addresses owning the most coins, largest balance first
+		Holders   []Holder       `json:"holders"`
+		Addresses uint64         `json:"addresses"`
+		Total     types.Currency `json:"total"`
+	}
+
+	// Bin is a bin of the balance distribution, containing all balances
+	// of at least Min and less than Max, which is ten times Min.
+	Bin struct {
+		Min       types.Currency `json:"min"`
+		Max       types.Currency `json:"max"`
+		Addresses uint64         `json:"addresses"`
+		Total     types.Currency `json:"total"`
+	}
+
+	// Distribution is the distribution of the balances of all addresses owning coins,
+	// binned per order of magnitude of the balance.
+	Distribution struct {
+		Height types.BlockHeight `json:"height"`
+		// Bins contains a bin per order of magnitude, from the smallest unit up to the largest balance
+		Bins      []Bin          `json:"bins"`
+		Addresses uint64         `json:"addresses"`
+		Total     types.Currency `json:"total"`
+	}
+
+	// binRecord is the stored form of a bin.
+	binRecord struct {
+		Addresses uint64
+		Total     types.Currency
+	}
+
+	// balanceDiff is the amount of coins added to and removed from the balance of an address.
+	balanceDiff struct {
+		added, removed types.Currency
+	}
+
+	// balanceDiffs collects the balance differences per address,
+	// such that each address is updated only once.
+	balanceDiffs map[types.UnlockHash]*balanceDiff
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new rich list plugin, for the chain starting with the given genesis block.
+func NewPlugin(genesis types.Block) *Plugin {
+	return &Plugin{genesis: genesis}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time,
+// applying the genesis block while the bucket is still writable.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketOutputs, bucketBalances, bucketRanking, bucketBins} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		err := p.applyBlock(p.genesis, 0, persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+			return bucket, nil
+		}))
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to apply genesis block: %v", err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies the coin inputs and outputs of all transactions and the miner payouts of the block,
+// except for the genesis block, which is applied when the plugin is initialized.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	return p.applyBlock(block, height, bucket)
+}
+
+// applyBlock applies the coin inputs and outputs of all transactions and the miner payouts of the block.
+func (p *Plugin) applyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if len(block.Transactions) == 0 {
+		return p.applyMinerPayouts(block, height, bucket)
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction applies the coin inputs and outputs of the transaction to the balances of the addresses involved.
+// As the consensus set only applies the transactions of newly created blocks,
+// the miner payouts of the block are applied together with its last transaction.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	diffs := make(balanceDiffs)
+	for _, ci := range txn.CoinInputs {
+		co, err := getOutput(outputsBucket, ci.ParentID)
+		if err != nil {
+			return err
+		}
+		diffs.remove(co.Condition.UnlockHash(), co.Value)
+	}
+	for index, co := range txn.CoinOutputs {
+		err = putOutput(outputsBucket, txn.CoinOutputID(uint64(index)), co)
+		if err != nil {
+			return err
+		}
+		diffs.add(co.Condition.UnlockHash(), co.Value)
+	}
+	err = diffs.apply(bucket)
+	if err != nil {
+		return err
+	}
+	if n := len(block.Transactions); n > 0 && block.Transactions[n-1].ID() == txn.ID() {
+		return p.applyMinerPayouts(block, height, bucket)
+	}
+	return nil
+}
+
+// applyMinerPayouts applies the miner payouts of the block,
+// and stores its height as the height of the last applied block.
+func (p *Plugin) applyMinerPayouts(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	diffs := make(balanceDiffs)
+	for index, mp := range block.MinerPayouts {
+		co := types.CoinOutput{
+			Value:     mp.Value,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(mp.UnlockHash)),
+		}
+		err = putOutput(outputsBucket, block.MinerPayoutID(uint64(index)), co)
+		if err != nil {
+			return err
+		}
+		diffs.add(mp.UnlockHash, mp.Value)
+	}
+	err = diffs.apply(bucket)
+	if err != nil {
+		return err
+	}
+	return putHeight(bucket, height)
+}
+
+// RevertBlock reverts the coin inputs and outputs of all transactions and the miner payouts of the block,
+// the genesis block is never reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("coin outputs bucket does not exist")
+	}
+	diffs := make(balanceDiffs)
+	for _, txn := range block.Transactions {
+		for _, ci := range txn.CoinInputs {
+			co, err := getOutput(outputsBucket, ci.ParentID)
+			if err != nil {
+				return err
+			}
+			diffs.add(co.Condition.UnlockHash(), co.Value)
+		}
+		for index, co := range txn.CoinOutputs {
+			err = outputsBucket.Delete(rivbin.Marshal(txn.CoinOutputID(uint64(index))))
+			if err != nil {
+				return fmt.Errorf("failed to delete coin output: %v", err)
+			}
+			diffs.remove(co.Condition.UnlockHash(), co.Value)
+		}
+	}
+	for index, mp := range block.MinerPayouts {
+		err = outputsBucket.Delete(rivbin.Marshal(block.MinerPayoutID(uint64(index))))
+		if err != nil {
+			return fmt.Errorf("failed to delete miner payout: %v", err)
+		}
+		diffs.remove(mp.UnlockHash, mp.Value)
+	}
+	err = diffs.apply(bucket)
+	if err != nil {
+		return err
+	}
+	return putHeight(bucket, height-1)
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are only reverted as part of their block.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetRichList returns the given amount of addresses owning the most coins.
+func (p *Plugin) GetRichList(count int) (RichList, error) {
+	var list RichList
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		rankingBucket := bucket.Bucket(bucketRanking)
+		if rankingBucket == nil {
+			return errors.New("ranking bucket does not exist")
+		}
+		var err error
+		list.Height, list.Addresses, list.Total, err = summary(bucket)
+		if err != nil {
+			return err
+		}
+		cursor := rankingBucket.Cursor()
+		for k, _ := cursor.Last(); k != nil && len(list.Holders) < count; k, _ = cursor.Prev() {
+			holder, err := decodeRankingKey(k)
+			if err != nil {
+				return err
+			}
+			list.Holders = append(list.Holders, holder)
+		}
+		return nil
+	})
+	if err != nil {
+		return RichList{}, err
+	}
+	return list, nil
+}
+
+// GetDistribution returns the distribution of the balances of all addresses owning coins.
+func (p *Plugin) GetDistribution() (Distribution, error) {
+	var distribution Distribution
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		binsBucket := bucket.Bucket(bucketBins)
+		if binsBucket == nil {
+			return errors.New("bins bucket does not exist")
+		}
+		var err error
+		distribution.Height, distribution.Addresses, distribution.Total, err = summary(bucket)
+		if err != nil {
+			return err
+		}
+		k, _ := binsBucket.Cursor().Last()
+		if k == nil {
+			return nil
+		}
+		min := types.NewCurrency64(1)
+		for digits := byte(1); digits <= k[0]; digits++ {
+			record, err := getBin(binsBucket, digits)
+			if err != nil {
+				return err
+			}
+			max := min.Mul64(10)
+			distribution.Bins = append(distribution.Bins, Bin{
+				Min:       min,
+				Max:       max,
+				Addresses: record.Addresses,
+				Total:     record.Total,
+			})
+			min = max
+		}
+		return nil
+	})
+	if err != nil {
+		return Distribution{}, err
+	}
+	return distribution, nil
+}
+
+// summary returns the height of the last applied block,
+// as well as the amount of addresses owning coins and their total balance.
+func summary(bucket *bolt.Bucket) (height types.BlockHeight, addresses uint64, total types.Currency, err error) {
+	if b := bucket.Get(keyHeight); len(b) == 8 {
+		height = types.BlockHeight(binary.BigEndian.Uint64(b))
+	}
+	binsBucket := bucket.Bucket(bucketBins)
+	if binsBucket == nil {
+		return 0, 0, types.Currency{}, errors.New("bins bucket does not exist")
+	}
+	err = binsBucket.ForEach(func(_, v []byte) error {
+		var record binRecord
+		err := rivbin.Unmarshal(v, &record)
+		if err != nil {
+			return fmt.Errorf("failed to decode balance bin: %v", err)
+		}
+		addresses += record.Addresses
+		total = total.Add(record.Total)
+		return nil
+	})
+	return
+}
+
+func (diffs balanceDiffs) add(uh types.UnlockHash, value types.Currency) {
+	diffs.get(uh).added = diffs.get(uh).added.Add(value)
+}
+
+func (diffs balanceDiffs) remove(uh types.UnlockHash, value types.Currency) {
+	diffs.get(uh).removed = diffs.get(uh).removed.Add(value)
+}
+
+func (diffs balanceDiffs) get(uh types.UnlockHash) *balanceDiff {
+	if _, ok := diffs[uh]; !ok {
+		diffs[uh] = new(balanceDiff)
+	}
+	return diffs[uh]
+}
+
+// apply updates the balance, ranking and bin of all addresses of which the balance changed.
+func (diffs balanceDiffs) apply(bucket *persist.LazyBoltBucket) error {
+	balancesBucket, err := bucket.Bucket(bucketBalances)
+	if err != nil {
+		return errors.New("balances bucket does not exist")
+	}
+	rankingBucket, err := bucket.Bucket(bucketRanking)
+	if err != nil {
+		return errors.New("ranking bucket does not exist")
+	}
+	binsBucket, err := bucket.Bucket(bucketBins)
+	if err != nil {
+		return errors.New("bins bucket does not exist")
+	}
+	for uh, diff := range diffs {
+		if diff.added.Equals(diff.removed) {
+			continue
+		}
+		key := rivbin.Marshal(uh)
+		var balance types.Currency
+		if b := balancesBucket.Get(key); len(b) > 0 {
+			err = rivbin.Unmarshal(b, &balance)
+			if err != nil {
+				return fmt.Errorf("failed to decode balance of address %s: %v", uh.String(), err)
+			}
+		}
+		updated := balance.Add(diff.added)
+		if updated.Cmp(diff.removed) < 0 {
+			return fmt.Errorf("balance of address %s would become negative", uh.String())
+		}
+		updated = updated.Sub(diff.removed)
+
+		if updated.IsZero() {
+			err = balancesBucket.Delete(key)
+		} else {
+			err = balancesBucket.Put(key, rivbin.Marshal(updated))
+		}
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store balance of address %s: %v", uh.String(), err)
+		}
+		if !balance.IsZero() {
+			err = rankingBucket.Delete(rankingKey(uh, balance))
+			if err != nil {
+				return fmt.Errorf("failed to delete ranking of address %s: %v", uh.String(), err)
+			}
+			err = updateBin(binsBucket, balance, false)
+			if err != nil {
+				return err
+			}
+		}
+		if !updated.IsZero() {
+			err = rankingBucket.Put(rankingKey(uh, updated), nil)
+			if err != nil {
+				return fmt.Errorf("failed to store ranking of address %s: %v", uh.String(), err)
+			}
+			err = updateBin(binsBucket, updated, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// updateBin adds the balance to, or removes it from, the bin of its order of magnitude.
+func updateBin(binsBucket *bolt.Bucket, balance types.Currency, add bool) error {
+	digits := byte(len(balance.String()))
+	record, err := getBin(binsBucket, digits)
+	if err != nil {
+		return err
+	}
+	if add {
+		record.Addresses++
+		record.Total = record.Total.Add(balance)
+	} else {
+		record.Addresses--
+		record.Total = record.Total.Sub(balance)
+	}
+	if record.Addresses == 0 {
+		err = binsBucket.Delete([]byte{digits})
+	} else {
+		err = binsBucket.Put([]byte{digits}, rivbin.Marshal(record))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store balance bin: %v", err)
+	}
+	return nil
+}
+
+func getBin(binsBucket *bolt.Bucket, digits byte) (binRecord, error) {
+	var record binRecord
+	if b := binsBucket.Get([]byte{digits}); len(b) > 0 {
+		err := rivbin.Unmarshal(b, &record)
+		if err != nil {
+			return binRecord{}, fmt.Errorf("failed to decode balance bin: %v", err)
+		}
+	}
+	return record, nil
+}
+
+func getOutput(outputsBucket *bolt.Bucket, id types.CoinOutputID) (types.CoinOutput, error) {
+	b := outputsBucket.Get(rivbin.Marshal(id))
+	if len(b) == 0 {
+		return types.CoinOutput{}, fmt.Errorf("spent coin output %s is unknown", id.String())
+	}
+	var co types.CoinOutput
+	err := rivbin.Unmarshal(b, &co)
+	if err != nil {
+		return types.CoinOutput{}, fmt.Errorf("failed to decode coin output %s: %v", id.String(), err)
+	}
+	return co, nil
+}
+
+func putOutput(outputsBucket *bolt.Bucket, id types.CoinOutputID, co types.CoinOutput) error {
+	err := outputsBucket.Put(rivbin.Marshal(id), rivbin.Marshal(co))
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store coin output: %v", err)
+	}
+	return nil
+}
+
+func putHeight(bucket *persist.LazyBoltBucket, height types.BlockHeight) error {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	err := bucket.Put(keyHeight, b)
+	if err == bolt.ErrTxNotWritable {
+		return pluginstats.ErrCatchUpUnsupported
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store height: %v", err)
+	}
+	return nil
+}
+
+// rankingKey returns the key of an address in the ranking bucket:
+// the length of the big-endian balance, the balance itself and the address,
+// such that the keys are ordered by balance.
+func rankingKey(uh types.UnlockHash, balance types.Currency) []byte {
+	b := balance.Big().Bytes()
+	key := append([]byte{byte(len(b))}, b...)
+	return append(key, rivbin.Marshal(uh)...)
+}
+
+func decodeRankingKey(key []byte) (Holder, error) {
+	if len(key) == 0 || len(key) < 1+int(key[0]) {
+		return Holder{}, errors.New("invalid ranking key")
+	}
+	n := 1 + int(key[0])
+	var holder Holder
+	holder.Balance = types.NewCurrency(new(big.Int).SetBytes(key[1:n]))
+	err := rivbin.Unmarshal(key[n:], &holder.UnlockHash)
+	if err != nil {
+		return Holder{}, fmt.Errorf("failed to decode address of ranking key: %v", err)
+	}
+	return holder, nil
+}
@@ -0,0 +1,112 @@
+package richlist
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginRichList(t *testing.T) {
+	db := plugintest.NewDB(t, "richlist")
+
+	address := func(b byte) types.UnlockHash {
+		return types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{b})
+	}
+	output := func(value uint64, b byte) types.CoinOutput {
+		return types.CoinOutput{Value: types.NewCurrency64(value), Condition: types.NewCondition(types.NewUnlockHashCondition(address(b)))}
+	}
+	genesis := types.Block{Transactions: []types.Transaction{{
+		Version:     types.TransactionVersionOne,
+		CoinOutputs: []types.CoinOutput{output(5000, 1), output(300, 2)},
+	}}}
+	// address 1 transfers 1200 to address 3 and 50 to address 4, paying a fee of 10 to the block creator (address 2)
+	transfer := types.Transaction{
+		Version:     types.TransactionVersionOne,
+		CoinInputs:  []types.CoinInput{{ParentID: genesis.Transactions[0].CoinOutputID(0)}},
+		CoinOutputs: []types.CoinOutput{output(1200, 3), output(50, 4), output(3740, 1)},
+		MinerFees:   []types.Currency{types.NewCurrency64(10)},
+	}
+	block := types.Block{
+		Timestamp:    1,
+		Transactions: []types.Transaction{transfer},
+		MinerPayouts: []types.MinerPayout{{Value: types.NewCurrency64(10), UnlockHash: address(2)}},
+	}
+
+	p := NewPlugin(genesis)
+	db.InitPlugin(t, p, nil)
+
+	// blocks cannot be replayed using a read-only transaction
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	if err != pluginstats.ErrCatchUpUnsupported {
+		t.Fatalf("expected catching up to be unsupported, got %v", err)
+	}
+
+	err = db.UpdateBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyTransaction(transfer, block, 1, bucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := p.GetRichList(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Holder{
+		{UnlockHash: address(1), Balance: types.NewCurrency64(3740)},
+		{UnlockHash: address(3), Balance: types.NewCurrency64(1200)},
+		{UnlockHash: address(2), Balance: types.NewCurrency64(310)},
+	}
+	if list.Height != 1 || list.Addresses != 4 || !list.Total.Equals64(5300) || len(list.Holders) != len(expected) {
+		t.Fatalf("unexpected rich list: %+v", list)
+	}
+	for i, holder := range expected {
+		if list.Holders[i].UnlockHash != holder.UnlockHash || !list.Holders[i].Balance.Equals(holder.Balance) {
+			t.Errorf("unexpected holder #%d: %+v", i+1, list.Holders[i])
+		}
+	}
+
+	distribution, err := p.GetDistribution()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 50 | 310 | 1200, 3740
+	if len(distribution.Bins) != 4 || distribution.Addresses != 4 || !distribution.Total.Equals64(5300) {
+		t.Fatalf("unexpected distribution: %+v", distribution)
+	}
+	for i, count := range []uint64{0, 1, 1, 2} {
+		bin := distribution.Bins[i]
+		if bin.Addresses != count || !bin.Max.Equals(bin.Min.Mul64(10)) {
+			t.Errorf("unexpected bin #%d: %+v", i+1, bin)
+		}
+	}
+	if bin := distribution.Bins[3]; !bin.Min.Equals64(1000) || !bin.Total.Equals64(4940) {
+		t.Errorf("unexpected largest bin: %+v", bin)
+	}
+
+	// reverting restores the balances of the genesis block
+	err = db.UpdateBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block, 1, bucket)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list, err = p.GetRichList(10); err != nil {
+		t.Fatal(err)
+	}
+	if list.Height != 0 || list.Addresses != 2 || len(list.Holders) != 2 ||
+		!list.Holders[0].Balance.Equals64(5000) || !list.Holders[1].Balance.Equals64(300) {
+		t.Errorf("unexpected rich list after revert: %+v", list)
+	}
+	if distribution, err = p.GetDistribution(); err != nil {
+		t.Fatal(err)
+	}
+	if len(distribution.Bins) != 4 || distribution.Bins[1].Addresses != 0 || distribution.Bins[2].Addresses != 1 {
+		t.Errorf("unexpected distribution after revert: %+v", distribution)
+	}
+}
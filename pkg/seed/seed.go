@@ -0,0 +1,255 @@
+package seed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+	bip39 "github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+// Language identifies a BIP39 word list.
+type Language string
+
+// All languages for which a BIP39 word list is available.
+// Rivine wallets only accept English mnemonics.
+const (
+	LanguageEnglish            Language = "english"
+	LanguageSpanish            Language = "spanish"
+	LanguageFrench             Language = "french"
+	LanguageItalian            Language = "italian"
+	LanguageJapanese           Language = "japanese"
+	LanguageKorean             Language = "korean"
+	LanguageChineseSimplified  Language = "chinese_simplified"
+	LanguageChineseTraditional Language = "chinese_traditional"
+)
+
+// Languages lists all supported languages, in the order they are tried
+// when detecting the language of a mnemonic.
+var Languages = []Language{
+	LanguageEnglish, LanguageSpanish, LanguageFrench, LanguageItalian,
+	LanguageJapanese, LanguageKorean, LanguageChineseSimplified, LanguageChineseTraditional,
+}
+
+var wordLists = map[Language][]string{
+	LanguageEnglish:            wordlists.English,
+	LanguageSpanish:            wordlists.Spanish,
+	LanguageFrench:             wordlists.French,
+	LanguageItalian:            wordlists.Italian,
+	LanguageJapanese:           wordlists.Japanese,
+	LanguageKorean:             wordlists.Korean,
+	LanguageChineseSimplified:  wordlists.ChineseSimplified,
+	LanguageChineseTraditional: wordlists.ChineseTraditional,
+}
+
+// ParseLanguage parses the given language name, case insensitive.
+func ParseLanguage(str string) (Language, error) {
+	lang := Language(strings.ToLower(str))
+	if _, ok := wordLists[lang]; !ok {
+		return "", fmt.Errorf("unknown language %q", str)
+	}
+	return lang, nil
+}
+
+// Errors returned when converting seeds.
+var (
+	ErrInvalidEntropySize = errors.New("BIP39 entropy has to be 128, 160, 192, 224 or 256 bits")
+	ErrInvalidChecksum    = errors.New("invalid mnemonic checksum")
+	ErrAmbiguousLanguage  = errors.New("mnemonic is valid in multiple languages, define its language explicitly")
+	ErrSiaMnemonic        = errors.New("mnemonic looks like a Sia seed, which uses a different word list and cannot be converted")
+)
+
+// EncodeMnemonic encodes the given BIP39 entropy as a mnemonic using the word list of the given language.
+func EncodeMnemonic(entropy []byte, lang Language) (string, error) {
+	words, ok := wordLists[lang]
+	if !ok {
+		return "", fmt.Errorf("unknown language %q", lang)
+	}
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", ErrInvalidEntropySize
+	}
+	// the entropy is followed by one checksum bit per 32 bits of entropy,
+	// and the result is split in groups of 11 bits, one word per group
+	checksum := sha256.Sum256(entropy)
+	data := append(append([]byte{}, entropy...), checksum[0])
+	n := (len(entropy)*8 + len(entropy)/4) / 11
+	mnemonic := make([]string, n)
+	for i := range mnemonic {
+		mnemonic[i] = words[readBits(data, i*11, 11)]
+	}
+	separator := " "
+	if lang == LanguageJapanese {
+		separator = "　" // ideographic space, as required by BIP39
+	}
+	return strings.Join(mnemonic, separator), nil
+}
+
+// DecodeMnemonic decodes the BIP39 entropy of the given mnemonic.
+// The language of the mnemonic is detected should no language be given,
+// returning ErrAmbiguousLanguage should it decode to different entropy in multiple languages.
+func DecodeMnemonic(mnemonic string, lang Language) ([]byte, Language, error) {
+	words := strings.Fields(mnemonic) // also splits on the ideographic space
+	if len(words) == 28 || len(words) == 29 {
+		return nil, "", ErrSiaMnemonic
+	}
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, "", fmt.Errorf("mnemonic has %d words, while a BIP39 mnemonic has 12, 15, 18, 21 or 24 words", len(words))
+	}
+	if lang != "" {
+		entropy, err := decodeWords(words, lang)
+		return entropy, lang, err
+	}
+	var (
+		detected Language
+		entropy  []byte
+	)
+	for _, candidate := range Languages {
+		e, err := decodeWords(words, candidate)
+		if err != nil {
+			continue
+		}
+		if entropy != nil && string(e) != string(entropy) {
+			return nil, "", ErrAmbiguousLanguage
+		}
+		if entropy == nil {
+			detected, entropy = candidate, e
+		}
+	}
+	if entropy == nil {
+		return nil, "", errors.New("mnemonic is not a valid BIP39 mnemonic in any supported language")
+	}
+	return entropy, detected, nil
+}
+
+func decodeWords(words []string, lang Language) ([]byte, error) {
+	list, ok := wordLists[lang]
+	if !ok {
+		return nil, fmt.Errorf("unknown language %q", lang)
+	}
+	indices := make(map[string]int, len(list))
+	for index, word := range list {
+		indices[word] = index
+	}
+	data := make([]byte, (len(words)*11+7)/8)
+	for i, word := range words {
+		index, ok := indices[word]
+		if !ok {
+			return nil, fmt.Errorf("word %q is not part of the %s word list", word, lang)
+		}
+		writeBits(data, i*11, 11, index)
+	}
+	entropy := data[:len(words)*4/3]
+	checksumBits := len(words) / 3
+	checksum := sha256.Sum256(entropy)
+	if readBits(data, len(entropy)*8, checksumBits) != readBits(checksum[:], 0, checksumBits) {
+		return nil, ErrInvalidChecksum
+	}
+	return entropy, nil
+}
+
+// readBits reads n bits from data, starting at the given bit offset, most significant bit first.
+func readBits(data []byte, offset, n int) int {
+	var value int
+	for i := offset; i < offset+n; i++ {
+		value = value<<1 | int(data[i/8]>>uint(7-i%8)&1)
+	}
+	return value
+}
+
+// writeBits writes the n least significant bits of value to data, starting at the given bit offset.
+func writeBits(data []byte, offset, n, value int) {
+	for i := 0; i < n; i++ {
+		if value>>uint(n-1-i)&1 == 1 {
+			bit := offset + i
+			data[bit/8] |= 1 << uint(7-bit%8)
+		}
+	}
+}
+
+// Conversion is the result of converting a seed, in any of the supported formats,
+// into the seed used by Rivine wallets.
+type Conversion struct {
+	// Seed is the seed as used by a Rivine wallet
+	Seed modules.Seed
+	// Entropy is the BIP39 entropy the seed was converted from
+	Entropy []byte
+	// Language is the language of the converted mnemonic, empty if a hex-encoded seed was converted
+	Language Language
+	// Warnings explain how the seed differs from what one might expect
+	Warnings []string
+}
+
+// Convert converts the given seed, a hex-encoded seed (or BIP39 entropy) or a BIP39 mnemonic,
+// into the seed used by Rivine wallets. The language of a mnemonic is detected should no language be given.
+func Convert(str string, lang Language) (Conversion, error) {
+	str = strings.TrimSpace(str)
+	var (
+		c   Conversion
+		err error
+	)
+	if b, hexErr := hex.DecodeString(str); hexErr == nil && len(b) > 0 {
+		if len(b) < 16 || len(b) > 32 || len(b)%4 != 0 {
+			return Conversion{}, ErrInvalidEntropySize
+		}
+		c.Entropy = b
+	} else {
+		c.Entropy, c.Language, err = DecodeMnemonic(str, lang)
+		if err != nil {
+			return Conversion{}, err
+		}
+	}
+	copy(c.Seed[:], c.Entropy)
+
+	if len(c.Entropy) < crypto.EntropySize {
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"the seed only contains %d bits of entropy, while Rivine seeds contain %d bits: "+
+				"Rivine wallets pad it with zero bytes, resulting in the seed shown, which is not a standard BIP39 seed",
+			len(c.Entropy)*8, crypto.EntropySize*8))
+	}
+	if c.Language != "" && c.Language != LanguageEnglish {
+		c.Warnings = append(c.Warnings, fmt.Sprintf(
+			"the mnemonic is %s, while Rivine wallets only accept English mnemonics: "+
+				"recover the wallet using the English mnemonic of the same seed", c.Language))
+	}
+	c.Warnings = append(c.Warnings,
+		"Rivine wallets derive their addresses from the seed directly, rather than using a BIP39 passphrase and BIP32/BIP44 derivation paths: "+
+			"the same mnemonic restores different addresses in other (BIP39) wallets, and vice versa, "+
+			"compare the derived addresses before sending any funds")
+	return c, nil
+}
+
+// Mnemonic returns the seed as a mnemonic in the given language,
+// which is the mnemonic accepted by Rivine wallets in case the language is English.
+func (c Conversion) Mnemonic(lang Language) (string, error) {
+	return EncodeMnemonic(c.Seed[:], lang)
+}
+
+// Address returns the address derived by Rivine wallets from the given seed at the given index.
+func Address(seed modules.Seed, index uint64) types.UnlockHash {
+	_, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, index))
+	return types.NewEd25519PubKeyUnlockHash(pk)
+}
+
+// WithPassphrase derives the seed of the key space protected by the given BIP39 passphrase,
+// also known as the 25th word, such that the same mnemonic unlocks a different wallet for every passphrase.
+// The derived seed is formed by the first 256 bits of the BIP39 seed of the English mnemonic of the given seed and the passphrase,
+// the passphrase being used as given, without Unicode normalization.
+// The given seed is returned unchanged should the passphrase be empty.
+func WithPassphrase(s modules.Seed, passphrase string) (modules.Seed, error) {
+	if passphrase == "" {
+		return s, nil
+	}
+	mnemonic, err := modules.NewMnemonic(s)
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	var derived modules.Seed
+	copy(derived[:], bip39.NewSeed(mnemonic, passphrase))
+	return derived, nil
+}
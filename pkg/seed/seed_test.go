@@ -0,0 +1,181 @@
+package seed
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	bip39 "github.com/tyler-smith/go-bip39"
+)
+
+// test vectors of the BIP39 specification
+var bip39TestVectors = []struct {
+	entropy  string
+	mnemonic string
+}{
+	{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+	{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+	{"0000000000000000000000000000000000000000000000000000000000000000", strings.Repeat("abandon ", 23) + "art"},
+	{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth useful legal winner thank year wave sausage worth title"},
+	{"8080808080808080808080808080808080808080808080808080808080808080",
+		"letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless"},
+	{"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", strings.Repeat("zoo ", 23) + "vote"},
+}
+
+func TestMnemonicTestVectors(t *testing.T) {
+	for _, vector := range bip39TestVectors {
+		entropy, _ := hex.DecodeString(vector.entropy)
+		mnemonic, err := EncodeMnemonic(entropy, LanguageEnglish)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != vector.mnemonic {
+			t.Errorf("unexpected mnemonic for %s: %q", vector.entropy, mnemonic)
+		}
+		decoded, lang, err := DecodeMnemonic(vector.mnemonic, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lang != LanguageEnglish || !bytes.Equal(decoded, entropy) {
+			t.Errorf("unexpected entropy for %q: %x (%s)", vector.mnemonic, decoded, lang)
+		}
+	}
+}
+
+func TestMnemonicRivineCompatibility(t *testing.T) {
+	for _, size := range []int{16, 20, 24, 28, 32} {
+		for i := 0; i < 25; i++ {
+			h := crypto.HashAll(size, i)
+			entropy := h[:size]
+			mnemonic, err := EncodeMnemonic(entropy, LanguageEnglish)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := bip39.NewMnemonic(entropy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mnemonic != expected {
+				t.Fatalf("mnemonic %q differs from the one of Rivine: %q", mnemonic, expected)
+			}
+			// Rivine wallets pad short entropy with zero bytes
+			expectedSeed, err := modules.InitialSeedFromMnemonic(mnemonic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := Convert(mnemonic, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Seed != expectedSeed {
+				t.Fatalf("seed %v of %q differs from the one of Rivine: %v", c.Seed, mnemonic, expectedSeed)
+			}
+			if warned := len(c.Warnings) > 1; warned != (size < crypto.EntropySize) {
+				t.Errorf("unexpected warnings for %d bytes of entropy: %v", size, c.Warnings)
+			}
+		}
+	}
+}
+
+func TestMnemonicLanguages(t *testing.T) {
+	h := crypto.HashObject("goldchain")
+	entropy := h[:]
+	english, err := EncodeMnemonic(entropy, LanguageEnglish)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, lang := range Languages {
+		mnemonic, err := EncodeMnemonic(entropy, lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := Convert(mnemonic, lang)
+		if err != nil {
+			t.Fatalf("failed to convert %s mnemonic: %v", lang, err)
+		}
+		if !bytes.Equal(c.Seed[:], entropy) {
+			t.Errorf("unexpected seed for %s mnemonic: %v", lang, c.Seed)
+		}
+		if converted, _ := c.Mnemonic(LanguageEnglish); converted != english {
+			t.Errorf("unexpected English mnemonic for %s mnemonic: %q", lang, converted)
+		}
+		if _, detected, err := DecodeMnemonic(mnemonic, ""); err != nil || (detected != lang && lang != LanguageChineseTraditional) {
+			t.Errorf("failed to detect %s mnemonic: %s, %v", lang, detected, err)
+		}
+	}
+}
+
+func TestConvertGenesisSeed(t *testing.T) {
+	const (
+		mnemonic = "carbon boss inject cover mountain fetch fiber fit tornado cloth wing dinosaur proof joy intact fabric thumb rebel borrow poet chair network expire else"
+		address  = "015a080a9259b9d4aaa550e2156f49b1a79a64c7ea463d810d4493e8242e6791584fbdac553e6f"
+	)
+	c, err := Convert(mnemonic, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromHex, err := Convert(c.Seed.String(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromHex.Seed != c.Seed || fromHex.Language != "" {
+		t.Errorf("unexpected conversion of hex seed: %+v", fromHex)
+	}
+	// the first address handed out by a new wallet is the one following the preloaded addresses
+	if uh := Address(c.Seed, modules.WalletSeedPreloadDepth); uh.String() != address {
+		t.Errorf("unexpected genesis address: %s", uh.String())
+	}
+}
+
+func TestConvertInvalidSeeds(t *testing.T) {
+	valid := strings.Repeat("abandon ", 23) + "art"
+	for _, str := range []string{
+		"",
+		"00ff",
+		strings.Repeat("abandon ", 23),
+		strings.Repeat("abandon ", 24),
+		strings.Repeat("abandon ", 23) + "goldchain",
+		strings.Repeat("abbey ", 29),
+	} {
+		if c, err := Convert(str, ""); err == nil {
+			t.Errorf("expected %q to be invalid, got %+v", str, c)
+		}
+	}
+	if _, err := Convert(strings.Repeat("abbey ", 28), ""); err != ErrSiaMnemonic {
+		t.Errorf("expected %v, got %v", ErrSiaMnemonic, err)
+	}
+	if _, err := Convert(valid, LanguageSpanish); err == nil {
+		t.Error("expected English mnemonic to be invalid as Spanish mnemonic")
+	}
+}
+
+func TestWithPassphrase(t *testing.T) {
+	var s modules.Seed // the 24-word test vector of the BIP39 specification
+	derived, err := WithPassphrase(s, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived != s {
+		t.Error("expected an empty passphrase to keep the seed unchanged")
+	}
+	derived, err = WithPassphrase(s, "TREZOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd30971"
+	if hex.EncodeToString(derived[:]) != expected {
+		t.Errorf("unexpected derived seed %x, expected %s", derived, expected)
+	}
+	other, err := WithPassphrase(s, "trezor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == derived || Address(other, 0) == Address(derived, 0) {
+		t.Error("expected different passphrases to derive different key spaces")
+	}
+}
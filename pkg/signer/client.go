@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/threefoldtech/rivine/pkg/api"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type (
+	// AddressesResponse contains the addresses a signer can sign for,
+	// as returned by a GET call to /signer/addresses.
+	AddressesResponse struct {
+		Addresses []types.UnlockHash `json:"addresses"`
+	}
+
+	// ChallengeRequest contains the challenge to sign and the address to sign it with,
+	// as given as the body of a POST call to /signer/challenge.
+	ChallengeRequest struct {
+		Address   types.UnlockHash `json:"address"`
+		Challenge types.ByteSlice  `json:"challenge"`
+	}
+)
+
+// Client is used to sign transactions and challenges using a remote signer.
+type Client struct {
+	client *api.HTTPClient
+}
+
+// NewClient creates a client for the signer at the given address,
+// authenticating using the given password.
+func NewClient(address, password string) *Client {
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	return &Client{client: &api.HTTPClient{
+		RootURL:   strings.TrimSuffix(address, "/"),
+		Password:  password,
+		UserAgent: daemon.RivineUserAgent,
+	}}
+}
+
+// Addresses returns the addresses the remote signer can sign for.
+func (c *Client) Addresses() ([]types.UnlockHash, error) {
+	var resp AddressesResponse
+	err := c.client.GetAPI("/signer/addresses", &resp)
+	return resp.Addresses, err
+}
+
+// SignTransaction signs the requested transaction using the remote signer.
+func (c *Client) SignTransaction(req SignRequest) (types.Transaction, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	var txn types.Transaction
+	err = c.client.PostResp("/signer/sign", string(b), &txn)
+	return txn, err
+}
+
+// SignChallenge signs the given challenge using the key of the given address, known by the remote signer.
+func (c *Client) SignChallenge(address types.UnlockHash, challenge []byte) (Challenge, error) {
+	b, err := json.Marshal(ChallengeRequest{Address: address, Challenge: challenge})
+	if err != nil {
+		return Challenge{}, err
+	}
+	var resp Challenge
+	err = c.client.PostResp("/signer/challenge", string(b), &resp)
+	return resp, err
+}
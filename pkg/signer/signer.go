@@ -0,0 +1,261 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/multisig"
+)
+
+var (
+	// ErrUnknownAddress is returned when signing a challenge for an address
+	// whose key isn't derived by the signer.
+	ErrUnknownAddress = errors.New("address is not derived from the seed of the signer")
+	// ErrInvalidChallenge is returned when a challenge signature is invalid.
+	ErrInvalidChallenge = errors.New("invalid challenge signature")
+)
+
+// challengeSpecifier prefixes all signed challenges,
+// such that a challenge can never be a valid signature hash of a transaction.
+var challengeSpecifier = types.Specifier{'g', 'o', 'l', 'd', 'c', 'h', 'a', 'i', 'n', ' ', 'c', 'h', 'a', 'l', 'l'}
+
+type (
+	// SignRequest contains a transaction to be signed,
+	// as well as the conditions of the outputs it spends and of its extension fulfillments,
+	// such that the signer doesn't need access to the consensus set.
+	SignRequest struct {
+		Transaction types.Transaction `json:"transaction"`
+		// CoinInputConditions contains the condition of the output spent by each coin input, in order
+		CoinInputConditions []types.UnlockConditionProxy `json:"coininputconditions"`
+		// BlockStakeInputConditions contains the condition of the output spent by each blockstake input, in order
+		BlockStakeInputConditions []types.UnlockConditionProxy `json:"blockstakeinputconditions"`
+		// ExtensionConditions contains the condition of each fulfillment defined by the transaction extension,
+		// in the order they are signed, such as the active mint condition of a coin creation transaction
+		ExtensionConditions []types.UnlockConditionProxy `json:"extensionconditions,omitempty"`
+	}
+
+	// Challenge is an arbitrary message signed by the key of an address,
+	// proving ownership of that address without revealing the seed.
+	Challenge struct {
+		Address   types.UnlockHash `json:"address"`
+		Challenge types.ByteSlice  `json:"challenge"`
+		PublicKey types.PublicKey  `json:"publickey"`
+		Signature types.ByteSlice  `json:"signature"`
+	}
+)
+
+// NewSignRequest creates the request to sign the given transaction,
+// looking up the conditions of the outputs it spends using the given getter.
+// The conditions of the extension fulfillments are those of the transaction controllers,
+// registered for the version of the transaction.
+func NewSignRequest(txn types.Transaction, getter multisig.OutputGetter) (SignRequest, error) {
+	req := SignRequest{
+		Transaction:               txn,
+		CoinInputConditions:       make([]types.UnlockConditionProxy, 0, len(txn.CoinInputs)),
+		BlockStakeInputConditions: make([]types.UnlockConditionProxy, 0, len(txn.BlockStakeInputs)),
+	}
+	for _, ci := range txn.CoinInputs {
+		co, err := getter.GetCoinOutput(ci.ParentID)
+		if err != nil {
+			return SignRequest{}, fmt.Errorf("failed to get coin output %s: %v", ci.ParentID.String(), err)
+		}
+		req.CoinInputConditions = append(req.CoinInputConditions, co.Condition)
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		bso, err := getter.GetBlockStakeOutput(bsi.ParentID)
+		if err != nil {
+			return SignRequest{}, fmt.Errorf("failed to get blockstake output %s: %v", bsi.ParentID.String(), err)
+		}
+		req.BlockStakeInputConditions = append(req.BlockStakeInputConditions, bso.Condition)
+	}
+	// record the conditions without signing, on a copy as the extension might be replaced
+	cpy := txn
+	err := cpy.SignExtension(func(_ *types.UnlockFulfillmentProxy, condition types.UnlockConditionProxy, _ ...interface{}) error {
+		req.ExtensionConditions = append(req.ExtensionConditions, condition)
+		return nil
+	})
+	if err != nil {
+		return SignRequest{}, fmt.Errorf("failed to get the conditions of the transaction extension: %v", err)
+	}
+	return req, nil
+}
+
+// Signer signs transactions and challenges using the keys derived from a single seed,
+// such that the seed never has to be loaded by an (internet-exposed) daemon.
+type Signer struct {
+	keys      map[types.UnlockHash]keyPair
+	addresses []types.UnlockHash
+}
+
+type keyPair struct {
+	pk crypto.PublicKey
+	sk crypto.SecretKey
+}
+
+// New creates a signer for the first given amount of addresses derived from the given seed,
+// using the same derivation as Rivine wallets.
+func New(seed modules.Seed, addresses uint64) *Signer {
+	s := &Signer{
+		keys:      make(map[types.UnlockHash]keyPair, addresses),
+		addresses: make([]types.UnlockHash, 0, addresses),
+	}
+	for index := uint64(0); index < addresses; index++ {
+		sk, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, index))
+		uh := types.NewEd25519PubKeyUnlockHash(pk)
+		s.keys[uh] = keyPair{pk: pk, sk: sk}
+		s.addresses = append(s.addresses, uh)
+	}
+	return s
+}
+
+// Addresses returns the addresses the signer can sign for, ordered by index.
+func (s *Signer) Addresses() []types.UnlockHash {
+	return append([]types.UnlockHash(nil), s.addresses...)
+}
+
+// SignTransaction signs all inputs and extension fulfillments of the requested transaction
+// which can be signed by the keys of the signer, the same way a Rivine wallet signs greedily.
+// Fulfillments which cannot be signed are left untouched.
+func (s *Signer) SignTransaction(req SignRequest) (types.Transaction, error) {
+	txn := req.Transaction
+	if len(req.CoinInputConditions) != len(txn.CoinInputs) {
+		return types.Transaction{}, fmt.Errorf("%d coin input conditions given for %d coin inputs",
+			len(req.CoinInputConditions), len(txn.CoinInputs))
+	}
+	if len(req.BlockStakeInputConditions) != len(txn.BlockStakeInputs) {
+		return types.Transaction{}, fmt.Errorf("%d blockstake input conditions given for %d blockstake inputs",
+			len(req.BlockStakeInputConditions), len(txn.BlockStakeInputs))
+	}
+	for i := range txn.CoinInputs {
+		err := s.signFulfillment(&txn, &txn.CoinInputs[i].Fulfillment, req.CoinInputConditions[i], uint64(i))
+		if err != nil {
+			return types.Transaction{}, fmt.Errorf("failed to sign coin input #%d: %v", i, err)
+		}
+	}
+	for i := range txn.BlockStakeInputs {
+		err := s.signFulfillment(&txn, &txn.BlockStakeInputs[i].Fulfillment, req.BlockStakeInputConditions[i], uint64(i))
+		if err != nil {
+			return types.Transaction{}, fmt.Errorf("failed to sign blockstake input #%d: %v", i, err)
+		}
+	}
+	// the conditions given by the transaction controllers are ignored,
+	// as the signer has no access to the consensus set to know them
+	var index int
+	err := txn.SignExtension(func(fulfillment *types.UnlockFulfillmentProxy, _ types.UnlockConditionProxy, extraObjects ...interface{}) error {
+		if fulfillment == nil {
+			return errors.New("nil fulfillment proxy cannot be signed")
+		}
+		if index >= len(req.ExtensionConditions) {
+			return errors.New("no condition given for extension fulfillment")
+		}
+		condition := req.ExtensionConditions[index]
+		index++
+		return s.signFulfillment(&txn, fulfillment, condition, extraObjects...)
+	})
+	if err != nil {
+		return types.Transaction{}, fmt.Errorf("failed to sign extension: %v", err)
+	}
+	return txn, nil
+}
+
+// signFulfillment signs the given fulfillment, should the given condition be fulfilled by a key of the signer.
+// A nil condition is signed using the first key of the signer.
+func (s *Signer) signFulfillment(txn *types.Transaction, fulfillment *types.UnlockFulfillmentProxy, condition types.UnlockConditionProxy, extraObjects ...interface{}) error {
+	inner := condition.Condition
+	if tlc, ok := inner.(*types.TimeLockCondition); ok {
+		inner = tlc.Condition
+	}
+	switch c := inner.(type) {
+	case nil, *types.NilCondition:
+		if len(s.addresses) == 0 {
+			return nil
+		}
+		return s.signSingle(txn, fulfillment, s.addresses[0], extraObjects)
+	case *types.UnlockHashCondition:
+		if c.TargetUnlockHash.Type != types.UnlockTypePubKey {
+			return nil
+		}
+		return s.signSingle(txn, fulfillment, c.TargetUnlockHash, extraObjects)
+	case *types.MultiSignatureCondition:
+		for _, uh := range c.UnlockHashes {
+			key, ok := s.keys[uh]
+			if !ok {
+				continue
+			}
+			if fulfillment.FulfillmentType() == types.FulfillmentTypeNil {
+				fulfillment.Fulfillment = &types.MultiSignatureFulfillment{}
+			}
+			err := fulfillment.Sign(types.FulfillmentSignContext{
+				ExtraObjects: extraObjects,
+				Transaction:  *txn,
+				Key: types.KeyPair{
+					PublicKey:  types.Ed25519PublicKey(key.pk),
+					PrivateKey: types.ByteSlice(key.sk[:]),
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected condition type %T", c)
+	}
+}
+
+func (s *Signer) signSingle(txn *types.Transaction, fulfillment *types.UnlockFulfillmentProxy, uh types.UnlockHash, extraObjects []interface{}) error {
+	key, ok := s.keys[uh]
+	if !ok {
+		return nil
+	}
+	fulfillment.Fulfillment = types.NewSingleSignatureFulfillment(types.Ed25519PublicKey(key.pk))
+	return fulfillment.Fulfillment.Sign(types.FulfillmentSignContext{
+		ExtraObjects: extraObjects,
+		Transaction:  *txn,
+		Key:          key.sk,
+	})
+}
+
+// SignChallenge signs the given challenge using the key of the given address.
+func (s *Signer) SignChallenge(address types.UnlockHash, challenge []byte) (Challenge, error) {
+	key, ok := s.keys[address]
+	if !ok {
+		return Challenge{}, ErrUnknownAddress
+	}
+	sig := crypto.SignHash(challengeHash(challenge), key.sk)
+	return Challenge{
+		Address:   address,
+		Challenge: challenge,
+		PublicKey: types.Ed25519PublicKey(key.pk),
+		Signature: sig[:],
+	}, nil
+}
+
+// Verify verifies the signature of the challenge, and that its public key belongs to its address.
+func (c Challenge) Verify() error {
+	if c.PublicKey.Algorithm != types.SignatureAlgoEd25519 || len(c.PublicKey.Key) != crypto.PublicKeySize ||
+		len(c.Signature) != crypto.SignatureSize {
+		return ErrInvalidChallenge
+	}
+	if types.NewPubKeyUnlockHash(c.PublicKey) != c.Address {
+		return ErrInvalidChallenge
+	}
+	var (
+		pk  crypto.PublicKey
+		sig crypto.Signature
+	)
+	copy(pk[:], c.PublicKey.Key)
+	copy(sig[:], c.Signature)
+	if crypto.VerifyHash(challengeHash(c.Challenge), pk, sig) != nil {
+		return ErrInvalidChallenge
+	}
+	return nil
+}
+
+func challengeHash(challenge []byte) crypto.Hash {
+	return crypto.HashAll(challengeSpecifier, challenge)
+}
@@ -0,0 +1,114 @@
+package signer
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testOutputGetter map[types.CoinOutputID]types.CoinOutput
+
+func (getter testOutputGetter) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	co, ok := getter[id]
+	if !ok {
+		return types.CoinOutput{}, errors.New("unknown coin output")
+	}
+	return co, nil
+}
+
+func (getter testOutputGetter) GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	return types.BlockStakeOutput{}, errors.New("unknown blockstake output")
+}
+
+func TestSignTransaction(t *testing.T) {
+	seed := modules.Seed(crypto.HashObject("goldchain signer"))
+	s := New(seed, 3)
+	addresses := s.Addresses()
+	if len(addresses) != 3 {
+		t.Fatalf("expected 3 addresses, got %d", len(addresses))
+	}
+	_, foreignPK := crypto.GenerateKeyPair()
+	foreign := types.NewEd25519PubKeyUnlockHash(foreignPK)
+
+	getter := testOutputGetter{
+		{1}: {Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(addresses[0]))},
+		{2}: {Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewTimeLockCondition(1, types.NewUnlockHashCondition(addresses[2])))},
+		{3}: {Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewMultiSignatureCondition(types.UnlockHashSlice{addresses[1], foreign}, 1))},
+		{4}: {Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(foreign))},
+	}
+	txn := types.Transaction{
+		Version: types.TransactionVersionOne,
+		CoinInputs: []types.CoinInput{
+			{ParentID: types.CoinOutputID{1}},
+			{ParentID: types.CoinOutputID{2}},
+			{ParentID: types.CoinOutputID{3}},
+			{ParentID: types.CoinOutputID{4}},
+		},
+		CoinOutputs: []types.CoinOutput{{
+			Value:     types.NewCurrency64(39),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(foreign)),
+		}},
+		MinerFees: []types.Currency{types.NewCurrency64(1)},
+	}
+	req, err := NewSignRequest(txn, getter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := s.SignTransaction(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ci := range signed.CoinInputs[:3] {
+		err = req.CoinInputConditions[i].Fulfill(ci.Fulfillment, types.FulfillContext{
+			ExtraObjects: []interface{}{uint64(i)},
+			BlockHeight:  1,
+			BlockTime:    1,
+			Transaction:  signed,
+		})
+		if err != nil {
+			t.Errorf("coin input #%d is not signed correctly: %v", i, err)
+		}
+	}
+	if signed.CoinInputs[3].Fulfillment.FulfillmentType() != types.FulfillmentTypeNil {
+		t.Error("coin input of a foreign address should not be signed")
+	}
+
+	req.CoinInputConditions = req.CoinInputConditions[1:]
+	if _, err = s.SignTransaction(req); err == nil {
+		t.Error("expected a request with missing conditions to be refused")
+	}
+	if _, err = NewSignRequest(types.Transaction{CoinInputs: []types.CoinInput{{ParentID: types.CoinOutputID{5}}}}, getter); err == nil {
+		t.Error("expected a transaction spending an unknown output to be refused")
+	}
+}
+
+func TestSignChallenge(t *testing.T) {
+	seed := modules.Seed(crypto.HashObject("goldchain signer"))
+	s := New(seed, 1)
+	address := s.Addresses()[0]
+	challenge, err := s.SignChallenge(address, []byte("login nonce 42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = challenge.Verify(); err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := challenge
+	tampered.Challenge = []byte("login nonce 43")
+	if tampered.Verify() != ErrInvalidChallenge {
+		t.Error("expected a tampered challenge to be invalid")
+	}
+	_, foreignPK := crypto.GenerateKeyPair()
+	tampered = challenge
+	tampered.Address = types.NewEd25519PubKeyUnlockHash(foreignPK)
+	if tampered.Verify() != ErrInvalidChallenge {
+		t.Error("expected a challenge of another address to be invalid")
+	}
+	if _, err = s.SignChallenge(tampered.Address, []byte("login nonce 42")); err != ErrUnknownAddress {
+		t.Errorf("expected %v, got %v", ErrUnknownAddress, err)
+	}
+}
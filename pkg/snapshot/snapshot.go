@@ -0,0 +1,261 @@
+// Package snapshot creates, verifies and installs consensus snapshots:
+// tar archives of the consensus directory of a daemon, which new daemons install
+// in order to reach the tip of the chain without syncing it from the genesis block.
+package snapshot
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrUntrusted is returned when verifying a snapshot which is neither embedded in the release,
+	// nor pinned by the operator, nor signed by the foundation.
+	ErrUntrusted = errors.New("snapshot is neither embedded in this release, pinned nor signed by the foundation")
+	// ErrInvalidSignature is returned in case the signature of a snapshot is invalid,
+	// or isn't created by the key of the address signing the snapshots of the network.
+	ErrInvalidSignature = errors.New("snapshot is not signed by the foundation")
+	// ErrNoAuthority is returned when verifying a signed snapshot on a network
+	// for which no address is defined to sign snapshots.
+	ErrNoAuthority = errors.New("no address is defined to sign snapshots on this network")
+)
+
+// snapshotSpecifier prefixes the message signed for a snapshot,
+// such that no other signed challenge is a valid snapshot signature.
+var snapshotSpecifier = types.Specifier{'g', 'o', 'l', 'd', 'c', 'h', 'a', 'i', 'n', ' ', 's', 'n', 'a', 'p'}
+
+// Signature is the signature of the hash of a snapshot, created using the key of the foundation address of the network,
+// such that daemons can verify snapshots published outside of a release.
+type Signature struct {
+	Hash      crypto.Hash     `json:"hash"`
+	PublicKey types.PublicKey `json:"publickey"`
+	Signature types.ByteSlice `json:"signature"`
+}
+
+// Message returns the message to be signed for the snapshot with the given hash,
+// to be signed as a challenge by the foundation address, e.g. using a remote signer.
+func Message(hash crypto.Hash) []byte {
+	return rivbin.MarshalAll(snapshotSpecifier, hash)
+}
+
+// NewSignature creates a snapshot signature from the challenge signing the message of the given hash.
+func NewSignature(hash crypto.Hash, challenge signer.Challenge) Signature {
+	return Signature{
+		Hash:      hash,
+		PublicKey: challenge.PublicKey,
+		Signature: challenge.Signature,
+	}
+}
+
+// Verify verifies that the snapshot signature is created by the key of the given address.
+func (sig Signature) Verify(authority types.UnlockHash) error {
+	if authority.Type != types.UnlockTypePubKey {
+		return ErrNoAuthority
+	}
+	challenge := signer.Challenge{
+		Address:   authority,
+		Challenge: Message(sig.Hash),
+		PublicKey: sig.PublicKey,
+		Signature: sig.Signature,
+	}
+	if challenge.Verify() != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Trust defines the snapshots trusted by a daemon.
+type Trust struct {
+	// Hashes are the hashes of the trusted snapshots, embedded in the release or pinned by the operator
+	Hashes []crypto.Hash
+	// Authority is the address signing the snapshots of the network,
+	// the nil address if the network has no signed snapshots
+	Authority types.UnlockHash
+}
+
+// Verify verifies that the snapshot with the given hash is trusted,
+// either as its hash is trusted, or as it is signed by the authority.
+// The signature is optional, and ignored in case the hash is trusted.
+func (t Trust) Verify(hash crypto.Hash, sig *Signature) error {
+	for _, trusted := range t.Hashes {
+		if trusted == hash {
+			return nil
+		}
+	}
+	if sig == nil {
+		return ErrUntrusted
+	}
+	if sig.Hash != hash {
+		return fmt.Errorf("snapshot signature is created for snapshot %s, not for snapshot %s", sig.Hash.String(), hash.String())
+	}
+	return sig.Verify(t.Authority)
+}
+
+// Create writes the snapshot of the given consensus directory to the given writer, returning its hash.
+// The directory must not be used while the snapshot is created, so the daemon using it has to be stopped.
+func Create(dir string, w io.Writer) (crypto.Hash, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	h := crypto.NewHash()
+	tw := tar.NewWriter(io.MultiWriter(w, h))
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		err = addFile(tw, filepath.Join(dir, info.Name()), info)
+		if err != nil {
+			return crypto.Hash{}, fmt.Errorf("failed to add %s to the snapshot: %v", info.Name(), err)
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	var hash crypto.Hash
+	copy(hash[:], h.Sum(nil))
+	return hash, nil
+}
+
+func addFile(tw *tar.Writer, path string, info os.FileInfo) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	err = tw.WriteHeader(header)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(tw, file)
+	return err
+}
+
+// Download downloads the snapshot at the given URL to the given writer, returning its hash.
+func Download(ctx context.Context, url string, w io.Writer) (crypto.Hash, error) {
+	resp, err := get(ctx, url)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	defer resp.Body.Close()
+	h := crypto.NewHash()
+	_, err = io.Copy(io.MultiWriter(w, h), resp.Body)
+	if err != nil {
+		return crypto.Hash{}, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	var hash crypto.Hash
+	copy(hash[:], h.Sum(nil))
+	return hash, nil
+}
+
+// DownloadSignature downloads the JSON-encoded snapshot signature at the given URL,
+// returning nil without error should it not exist.
+func DownloadSignature(ctx context.Context, url string) (*Signature, error) {
+	resp, err := get(ctx, url)
+	if err == errNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var sig Signature
+	err = json.NewDecoder(resp.Body).Decode(&sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the snapshot signature %s: %v", url, err)
+	}
+	return &sig, nil
+}
+
+var errNotFound = errors.New("not found")
+
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// Install extracts the given snapshot into the given consensus directory, which must not exist yet.
+// The snapshot is extracted into a temporary directory first, such that the consensus directory
+// only exists once the snapshot is installed entirely.
+func Install(archive io.Reader, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("consensus directory %s exists already", dir)
+	}
+	tmp := dir + ".snapshot"
+	err := os.RemoveAll(tmp)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(tmp, 0700)
+	if err != nil {
+		return err
+	}
+	err = extract(tar.NewReader(archive), tmp)
+	if err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return os.Rename(tmp, dir)
+}
+
+func extract(tr *tar.Reader, dir string) error {
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid snapshot: %v", err)
+		}
+		// snapshots only contain the files of the consensus directory itself
+		name := header.Name
+		if header.Typeflag != tar.TypeReg || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+			return fmt.Errorf("invalid snapshot: unexpected entry %q", name)
+		}
+		file, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(file, tr)
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract %s: %v", name, err)
+		}
+	}
+}
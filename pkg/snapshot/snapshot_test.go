@@ -0,0 +1,88 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/signer"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+)
+
+func TestCreateInstall(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "consensus")
+	if err := os.MkdirAll(filepath.Join(src, "ignored"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(src, "consensus.db"), []byte("blocks"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	hash, err := Create(src, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash != crypto.HashBytes(buf.Bytes()) {
+		t.Fatal("expected the hash of the created archive")
+	}
+
+	dst := filepath.Join(t.TempDir(), "consensus")
+	if err = Install(bytes.NewReader(buf.Bytes()), dst); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(dst, "consensus.db")); err != nil || string(b) != "blocks" {
+		t.Fatalf("unexpected installed database: %q (%v)", b, err)
+	}
+	if _, err = os.Stat(filepath.Join(dst, "ignored")); !os.IsNotExist(err) {
+		t.Error("expected directories not to be part of the snapshot")
+	}
+	if err = Install(bytes.NewReader(buf.Bytes()), dst); err == nil {
+		t.Error("expected an existing consensus directory not to be overwritten")
+	}
+
+	// entries outside of the consensus directory are refused
+	buf.Reset()
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "../consensus.db", Typeflag: tar.TypeReg, Mode: 0600})
+	tw.Close()
+	dst = filepath.Join(t.TempDir(), "consensus")
+	if err = Install(bytes.NewReader(buf.Bytes()), dst); err == nil {
+		t.Fatal("expected an entry outside of the consensus directory to be refused")
+	}
+	if _, err = os.Stat(dst); !os.IsNotExist(err) {
+		t.Error("expected no consensus directory after a failed installation")
+	}
+}
+
+func TestTrust(t *testing.T) {
+	s := signer.New(modules.Seed{1}, 1)
+	authority := s.Addresses()[0]
+	hash := crypto.HashBytes([]byte("snapshot"))
+	challenge, err := s.SignChallenge(authority, Message(hash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := NewSignature(hash, challenge)
+
+	trust := Trust{Hashes: []crypto.Hash{crypto.HashBytes([]byte("embedded"))}, Authority: authority}
+	if err = trust.Verify(crypto.HashBytes([]byte("embedded")), nil); err != nil {
+		t.Errorf("expected an embedded snapshot to be trusted, got %v", err)
+	}
+	if err = trust.Verify(hash, nil); err != ErrUntrusted {
+		t.Errorf("expected %v, got %v", ErrUntrusted, err)
+	}
+	if err = trust.Verify(hash, &sig); err != nil {
+		t.Errorf("expected a signed snapshot to be trusted, got %v", err)
+	}
+	if err = trust.Verify(crypto.HashBytes([]byte("other")), &sig); err == nil {
+		t.Error("expected a signature of another snapshot to be refused")
+	}
+	trust.Authority = signer.New(modules.Seed{2}, 1).Addresses()[0]
+	if err = trust.Verify(hash, &sig); err != ErrInvalidSignature {
+		t.Errorf("expected %v, got %v", ErrInvalidSignature, err)
+	}
+}
@@ -0,0 +1,254 @@
+package spends
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "SpendsPlugin"
+)
+
+var (
+	// bucketCoinInputs maps the IDs of all spent coin outputs to the confirmed transaction spending them
+	bucketCoinInputs = []byte("coininputs")
+	// bucketBlockStakeInputs maps the IDs of all spent blockstake outputs to the confirmed transaction spending them
+	bucketBlockStakeInputs = []byte("blockstakeinputs")
+)
+
+// Output types of a Spend.
+const (
+	OutputTypeCoin       = "coin"
+	OutputTypeBlockStake = "blockstake"
+)
+
+type (
+	// Plugin is a consensus set plugin, indexing the confirmed transaction spending each output,
+	// such that a transaction spending an output already spent by another confirmed transaction
+	// can be reported as double spent.
+	Plugin struct {
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// Spend is an output spent by a confirmed transaction.
+	Spend struct {
+		OutputID   crypto.Hash `json:"outputid"`
+		OutputType string      `json:"outputtype"`
+		// SpentBy is the ID of the confirmed transaction spending the output
+		SpentBy types.TransactionID `json:"spentby"`
+		// Height is the height of the block containing the spending transaction
+		Height types.BlockHeight `json:"height"`
+		// Unconfirmed is true in case the spending transaction is in the transaction pool instead,
+		// in which case the height is undefined
+		Unconfirmed bool `json:"unconfirmed,omitempty"`
+	}
+
+	// spender is the transaction spending an output, as stored per output.
+	spender struct {
+		TransactionID types.TransactionID
+		Height        types.BlockHeight
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new spends plugin.
+func NewPlugin() *Plugin {
+	return &Plugin{}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketCoinInputs, bucketBlockStakeInputs} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock indexes the outputs spent by all transactions of the block.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction indexes the outputs spent by the transaction.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(txn.CoinInputs) == 0 && len(txn.BlockStakeInputs) == 0 {
+		return nil
+	}
+	coinInputs, err := bucket.Bucket(bucketCoinInputs)
+	if err != nil {
+		return errors.New("coin inputs bucket does not exist")
+	}
+	blockStakeInputs, err := bucket.Bucket(bucketBlockStakeInputs)
+	if err != nil {
+		return errors.New("blockstake inputs bucket does not exist")
+	}
+	value := rivbin.Marshal(spender{TransactionID: txn.ID(), Height: height})
+	put := func(b *bolt.Bucket, id crypto.Hash) error {
+		err := b.Put(id[:], value)
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store spent output: %v", err)
+		}
+		return nil
+	}
+	for _, ci := range txn.CoinInputs {
+		err = put(coinInputs, crypto.Hash(ci.ParentID))
+		if err != nil {
+			return err
+		}
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		err = put(blockStakeInputs, crypto.Hash(bsi.ParentID))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertBlock removes the outputs spent by all transactions of the block from the index.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	coinInputs, err := bucket.Bucket(bucketCoinInputs)
+	if err != nil {
+		return errors.New("coin inputs bucket does not exist")
+	}
+	blockStakeInputs, err := bucket.Bucket(bucketBlockStakeInputs)
+	if err != nil {
+		return errors.New("blockstake inputs bucket does not exist")
+	}
+	for _, txn := range block.Transactions {
+		for _, ci := range txn.CoinInputs {
+			err = coinInputs.Delete(ci.ParentID[:])
+			if err != nil {
+				return fmt.Errorf("failed to delete spent coin output: %v", err)
+			}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			err = blockStakeInputs.Delete(bsi.ParentID[:])
+			if err != nil {
+				return fmt.Errorf("failed to delete spent blockstake output: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// RevertTransaction implements modules.ConsensusSetPlugin,
+// transactions are only reverted as part of their block.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// Conflicts returns the outputs spent by the given transaction,
+// which are already spent by another confirmed transaction.
+func (p *Plugin) Conflicts(txn types.Transaction) ([]Spend, error) {
+	id := txn.ID()
+	var conflicts []Spend
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		coinInputs := bucket.Bucket(bucketCoinInputs)
+		if coinInputs == nil {
+			return errors.New("coin inputs bucket does not exist")
+		}
+		blockStakeInputs := bucket.Bucket(bucketBlockStakeInputs)
+		if blockStakeInputs == nil {
+			return errors.New("blockstake inputs bucket does not exist")
+		}
+		check := func(b *bolt.Bucket, outputID crypto.Hash, outputType string) error {
+			v := b.Get(outputID[:])
+			if len(v) == 0 {
+				return nil
+			}
+			var s spender
+			err := rivbin.Unmarshal(v, &s)
+			if err != nil {
+				return fmt.Errorf("failed to decode spender of %s output %s: %v", outputType, outputID.String(), err)
+			}
+			if s.TransactionID != id {
+				conflicts = append(conflicts, Spend{
+					OutputID:   outputID,
+					OutputType: outputType,
+					SpentBy:    s.TransactionID,
+					Height:     s.Height,
+				})
+			}
+			return nil
+		}
+		for _, ci := range txn.CoinInputs {
+			err := check(coinInputs, crypto.Hash(ci.ParentID), OutputTypeCoin)
+			if err != nil {
+				return err
+			}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			err := check(blockStakeInputs, crypto.Hash(bsi.ParentID), OutputTypeBlockStake)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return conflicts, err
+}
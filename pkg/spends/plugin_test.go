@@ -0,0 +1,110 @@
+package spends
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginConflicts(t *testing.T) {
+	db := plugintest.NewDB(t, "spends")
+
+	p := NewPlugin()
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	conflicts := func(txn types.Transaction) []Spend {
+		t.Helper()
+		conflicts, err := p.Conflicts(txn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conflicts
+	}
+
+	coinOutputID := types.CoinOutputID{1}
+	blockStakeOutputID := types.BlockStakeOutputID{2}
+	confirmed := types.Transaction{
+		Version:          types.TransactionVersionOne,
+		CoinInputs:       []types.CoinInput{{ParentID: coinOutputID}},
+		BlockStakeInputs: []types.BlockStakeInput{{ParentID: blockStakeOutputID}},
+	}
+	doubleSpend := types.Transaction{
+		Version:     types.TransactionVersionOne,
+		CoinInputs:  []types.CoinInput{{ParentID: types.CoinOutputID{3}}, {ParentID: coinOutputID}},
+		CoinOutputs: []types.CoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	block := types.Block{Transactions: []types.Transaction{confirmed}}
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 5, bucket)
+	})
+
+	// the confirmed transaction does not conflict with itself
+	if c := conflicts(confirmed); len(c) != 0 {
+		t.Fatalf("unexpected conflicts for the confirmed transaction: %v", c)
+	}
+	c := conflicts(doubleSpend)
+	if len(c) != 1 || c[0].OutputType != OutputTypeCoin || c[0].OutputID != crypto.Hash(coinOutputID) ||
+		c[0].SpentBy != confirmed.ID() || c[0].Height != 5 {
+		t.Fatalf("unexpected conflicts for the double spend: %v", c)
+	}
+	c = conflicts(types.Transaction{BlockStakeInputs: []types.BlockStakeInput{{ParentID: blockStakeOutputID}}})
+	if len(c) != 1 || c[0].OutputType != OutputTypeBlockStake {
+		t.Fatalf("unexpected conflicts for the blockstake double spend: %v", c)
+	}
+
+	// conflicts are gone once the spending block is reverted
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block, 5, bucket)
+	})
+	if c := conflicts(doubleSpend); len(c) != 0 {
+		t.Fatalf("unexpected conflicts after revert: %v", c)
+	}
+}
+
+func TestRecentTransactions(t *testing.T) {
+	tpool := &testTPool{}
+	r := NewRecentTransactions(tpool, 2)
+	defer r.Close()
+
+	txns := make([]types.Transaction, 3)
+	for i := range txns {
+		txns[i] = types.Transaction{
+			Version:     types.TransactionVersionOne,
+			CoinOutputs: []types.CoinOutput{{Value: types.NewCurrency64(uint64(i + 1))}},
+		}
+	}
+	tpool.subscriber.ReceiveUpdatedUnconfirmedTransactions(txns[:2], modules.ConsensusChange{})
+	// the pool no longer contains the first transaction, which is still remembered
+	tpool.subscriber.ReceiveUpdatedUnconfirmedTransactions(txns[1:2], modules.ConsensusChange{})
+	if _, ok := r.Transaction(txns[0].ID()); !ok {
+		t.Fatal("expected the dropped transaction to be remembered")
+	}
+	// the oldest transaction is forgotten to make room
+	tpool.subscriber.ReceiveUpdatedUnconfirmedTransactions(txns[1:], modules.ConsensusChange{})
+	if _, ok := r.Transaction(txns[0].ID()); ok {
+		t.Fatal("expected the oldest transaction to be forgotten")
+	}
+	for _, txn := range txns[1:] {
+		if _, ok := r.Transaction(txn.ID()); !ok {
+			t.Fatalf("expected transaction %s to be remembered", txn.ID().String())
+		}
+	}
+}
+
+// testTPool records its subscriber only.
+type testTPool struct {
+	modules.TransactionPool
+	subscriber modules.TransactionPoolSubscriber
+}
+
+func (tp *testTPool) TransactionPoolSubscribe(s modules.TransactionPoolSubscriber) { tp.subscriber = s }
+func (tp *testTPool) Unsubscribe(modules.TransactionPoolSubscriber)                { tp.subscriber = nil }
@@ -0,0 +1,69 @@
+package spends
+
+import (
+	"sync"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DefaultRecentTransactions is the default amount of transactions remembered by RecentTransactions.
+const DefaultRecentTransactions = 10000
+
+// RecentTransactions remembers the most recent transactions seen in the transaction pool,
+// such that a transaction which disappeared from the pool without being confirmed
+// can still be checked for double spends using its ID only.
+type RecentTransactions struct {
+	tpool   modules.TransactionPool
+	maxTxns int
+
+	mu    sync.Mutex
+	txns  map[types.TransactionID]types.Transaction
+	order []types.TransactionID
+}
+
+// NewRecentTransactions creates a new RecentTransactions, remembering at most the given amount of transactions,
+// subscribing it to the given transaction pool.
+func NewRecentTransactions(tpool modules.TransactionPool, maxTxns int) *RecentTransactions {
+	r := &RecentTransactions{
+		tpool:   tpool,
+		maxTxns: maxTxns,
+		txns:    make(map[types.TransactionID]types.Transaction),
+	}
+	tpool.TransactionPoolSubscribe(r)
+	return r
+}
+
+// Close unsubscribes from the transaction pool.
+func (r *RecentTransactions) Close() error {
+	r.tpool.Unsubscribe(r)
+	return nil
+}
+
+// Transaction returns the transaction with the given ID,
+// should it be in the transaction pool or have been seen there recently.
+func (r *RecentTransactions) Transaction(id types.TransactionID) (types.Transaction, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	txn, ok := r.txns[id]
+	return txn, ok
+}
+
+// ReceiveUpdatedUnconfirmedTransactions implements modules.TransactionPoolSubscriber,
+// remembering all transactions added to the pool, forgetting the oldest ones when full.
+func (r *RecentTransactions) ReceiveUpdatedUnconfirmedTransactions(txns []types.Transaction, cc modules.ConsensusChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, txn := range txns {
+		id := txn.ID()
+		if _, ok := r.txns[id]; ok {
+			continue
+		}
+		r.txns[id] = txn
+		r.order = append(r.order, id)
+	}
+	for len(r.order) > r.maxTxns {
+		delete(r.txns, r.order[0])
+		r.order = r.order[1:]
+	}
+}
@@ -0,0 +1,348 @@
+package stakes
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	bolt "github.com/rivine/bbolt"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	pluginDBVersion = "1.0.0.0"
+	pluginDBHeader  = "StakeDistributionPlugin"
+)
+
+var (
+	// bucketOutputs maps the IDs of all blockstake outputs ever created to the outputs,
+	// such that the owner and value of spent outputs are known
+	bucketOutputs = []byte("outputs")
+	// bucketStakes contains a bucket per address, mapping the block heights at which its stake changed,
+	// to its total stake after that block
+	bucketStakes = []byte("stakes")
+)
+
+type (
+	// Plugin is a consensus set plugin, keeping track of the blockstake distribution over time.
+	Plugin struct {
+		genesis            types.Block
+		storage            modules.PluginViewStorage
+		unregisterCallback modules.PluginUnregisterCallback
+	}
+
+	// AddressStake is the amount of blockstakes owned by an address.
+	AddressStake struct {
+		UnlockHash types.UnlockHash `json:"unlockhash"`
+		Stake      types.Currency   `json:"stake"`
+	}
+
+	// Distribution is the distribution of all blockstakes at a given height.
+	Distribution struct {
+		Height types.BlockHeight `json:"height"`
+		// Stakes contains all addresses owning blockstakes, largest stake first
+		Stakes []AddressStake `json:"stakes"`
+		Total  types.Currency `json:"total"`
+		// NakamotoCoefficient is the minimum amount of addresses controlling more than half of all blockstakes
+		NakamotoCoefficient int `json:"nakamotocoefficient"`
+	}
+)
+
+var _ modules.ConsensusSetPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a new stake distribution plugin, for the chain starting with the given genesis block.
+func NewPlugin(genesis types.Block) *Plugin {
+	return &Plugin{genesis: genesis}
+}
+
+// InitPlugin initializes the buckets of the plugin for the first time,
+// applying the genesis block while the bucket is still writable.
+func (p *Plugin) InitPlugin(metadata *persist.Metadata, bucket *bolt.Bucket, storage modules.PluginViewStorage, unregisterCallback modules.PluginUnregisterCallback) (persist.Metadata, error) {
+	p.storage = storage
+	p.unregisterCallback = unregisterCallback
+	if metadata == nil {
+		for _, name := range [][]byte{bucketOutputs, bucketStakes} {
+			_, err := bucket.CreateBucketIfNotExists(name)
+			if err != nil {
+				return persist.Metadata{}, fmt.Errorf("failed to create %s bucket: %v", name, err)
+			}
+		}
+		err := p.applyBlock(p.genesis, 0, persist.NewLazyBoltBucket(func() (*bolt.Bucket, error) {
+			return bucket, nil
+		}))
+		if err != nil {
+			return persist.Metadata{}, fmt.Errorf("failed to apply genesis block: %v", err)
+		}
+		metadata = &persist.Metadata{
+			Version: pluginDBVersion,
+			Header:  pluginDBHeader,
+		}
+	} else if metadata.Version != pluginDBVersion {
+		return persist.Metadata{}, errors.New("There is only 1 version of this plugin, version mismatch")
+	} else if metadata.Header != pluginDBHeader {
+		return persist.Metadata{}, errors.New("There is only 1 header of this plugin, header mismatch")
+	}
+	return *metadata, nil
+}
+
+// ApplyBlock applies the blockstake inputs and outputs of all transactions of the block,
+// except for the genesis block, which is applied when the plugin is initialized.
+func (p *Plugin) ApplyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	return p.applyBlock(block, height, bucket)
+}
+
+// applyBlock applies the blockstake inputs and outputs of all transactions of the block.
+func (p *Plugin) applyBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	for _, txn := range block.Transactions {
+		err := p.ApplyTransaction(txn, block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyTransaction applies the blockstake inputs and outputs of the transaction,
+// updating the stake of all addresses involved at the given height.
+func (p *Plugin) ApplyTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(txn.BlockStakeInputs) == 0 && len(txn.BlockStakeOutputs) == 0 {
+		return nil
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("blockstake outputs bucket does not exist")
+	}
+	stakesBucket, err := bucket.Bucket(bucketStakes)
+	if err != nil {
+		return errors.New("stakes bucket does not exist")
+	}
+	// collect the stake difference per address, such that each address is updated only once
+	type stakeDiff struct {
+		added, removed types.Currency
+	}
+	diffs := make(map[types.UnlockHash]*stakeDiff)
+	diff := func(uh types.UnlockHash) *stakeDiff {
+		if _, ok := diffs[uh]; !ok {
+			diffs[uh] = new(stakeDiff)
+		}
+		return diffs[uh]
+	}
+	for _, bsi := range txn.BlockStakeInputs {
+		b := outputsBucket.Get(rivbin.Marshal(bsi.ParentID))
+		if len(b) == 0 {
+			return fmt.Errorf("spent blockstake output %s is unknown", bsi.ParentID.String())
+		}
+		var bso types.BlockStakeOutput
+		err = rivbin.Unmarshal(b, &bso)
+		if err != nil {
+			return fmt.Errorf("failed to decode blockstake output %s: %v", bsi.ParentID.String(), err)
+		}
+		d := diff(bso.Condition.UnlockHash())
+		d.removed = d.removed.Add(bso.Value)
+	}
+	for index, bso := range txn.BlockStakeOutputs {
+		err = outputsBucket.Put(rivbin.Marshal(txn.BlockStakeOutputID(uint64(index))), rivbin.Marshal(bso))
+		if err == bolt.ErrTxNotWritable {
+			return pluginstats.ErrCatchUpUnsupported
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store blockstake output: %v", err)
+		}
+		d := diff(bso.Condition.UnlockHash())
+		d.added = d.added.Add(bso.Value)
+	}
+	for uh, d := range diffs {
+		addressBucket, err := stakesBucket.CreateBucketIfNotExists(rivbin.Marshal(uh))
+		if err != nil {
+			return fmt.Errorf("failed to create stake bucket for address %s: %v", uh.String(), err)
+		}
+		stake, err := stakeAt(addressBucket, height)
+		if err != nil {
+			return err
+		}
+		stake = stake.Add(d.added)
+		if stake.Cmp(d.removed) < 0 {
+			return fmt.Errorf("stake of address %s would become negative at height %d", uh.String(), height)
+		}
+		err = addressBucket.Put(encodeBlockHeight(height), rivbin.Marshal(stake.Sub(d.removed)))
+		if err != nil {
+			return fmt.Errorf("failed to store stake of address %s at height %d: %v", uh.String(), height, err)
+		}
+	}
+	return nil
+}
+
+// RevertBlock reverts the blockstake inputs and outputs of all transactions of the block,
+// the genesis block is never reverted.
+func (p *Plugin) RevertBlock(block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if height == 0 {
+		return nil
+	}
+	for i := len(block.Transactions) - 1; i >= 0; i-- {
+		err := p.RevertTransaction(block.Transactions[i], block, height, bucket)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevertTransaction reverts the blockstake inputs and outputs of the transaction,
+// by deleting the stake of all addresses involved at the given height.
+// As this reverts the stake changes of all transactions of the block for these addresses,
+// it is only to be used for reverting entire blocks, last transaction first.
+func (p *Plugin) RevertTransaction(txn types.Transaction, block types.Block, height types.BlockHeight, bucket *persist.LazyBoltBucket) error {
+	if bucket == nil {
+		return errors.New("plugin bucket does not exist")
+	}
+	if len(txn.BlockStakeInputs) == 0 && len(txn.BlockStakeOutputs) == 0 {
+		return nil
+	}
+	outputsBucket, err := bucket.Bucket(bucketOutputs)
+	if err != nil {
+		return errors.New("blockstake outputs bucket does not exist")
+	}
+	stakesBucket, err := bucket.Bucket(bucketStakes)
+	if err != nil {
+		return errors.New("stakes bucket does not exist")
+	}
+	addresses := make(map[types.UnlockHash]struct{})
+	for _, bsi := range txn.BlockStakeInputs {
+		var bso types.BlockStakeOutput
+		err = rivbin.Unmarshal(outputsBucket.Get(rivbin.Marshal(bsi.ParentID)), &bso)
+		if err != nil {
+			return fmt.Errorf("failed to decode blockstake output %s: %v", bsi.ParentID.String(), err)
+		}
+		addresses[bso.Condition.UnlockHash()] = struct{}{}
+	}
+	for index, bso := range txn.BlockStakeOutputs {
+		err = outputsBucket.Delete(rivbin.Marshal(txn.BlockStakeOutputID(uint64(index))))
+		if err != nil {
+			return fmt.Errorf("failed to delete blockstake output: %v", err)
+		}
+		addresses[bso.Condition.UnlockHash()] = struct{}{}
+	}
+	// the stake after the previous change of an address is its stake once more
+	for uh := range addresses {
+		addressBucket := stakesBucket.Bucket(rivbin.Marshal(uh))
+		if addressBucket == nil {
+			continue
+		}
+		err = addressBucket.Delete(encodeBlockHeight(height))
+		if err != nil {
+			return fmt.Errorf("failed to delete stake of address %s at height %d: %v", uh.String(), height, err)
+		}
+	}
+	return nil
+}
+
+// TransactionValidatorVersionFunctionMapping implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidatorVersionFunctionMapping() map[types.TransactionVersion][]modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// TransactionValidators implements modules.ConsensusSetPlugin,
+// the plugin does not validate any transactions.
+func (p *Plugin) TransactionValidators() []modules.PluginTransactionValidationFunction {
+	return nil
+}
+
+// Close releases the storage of the plugin.
+func (p *Plugin) Close() error {
+	if p.storage == nil {
+		return nil
+	}
+	return p.storage.Close()
+}
+
+// GetDistributionAt returns the blockstake distribution as it was after the block at the given height.
+func (p *Plugin) GetDistributionAt(height types.BlockHeight) (Distribution, error) {
+	distribution := Distribution{Height: height}
+	err := p.storage.View(func(bucket *bolt.Bucket) error {
+		stakesBucket := bucket.Bucket(bucketStakes)
+		if stakesBucket == nil {
+			return errors.New("stakes bucket does not exist")
+		}
+		return stakesBucket.ForEach(func(k, _ []byte) error {
+			addressBucket := stakesBucket.Bucket(k)
+			if addressBucket == nil {
+				return nil
+			}
+			stake, err := stakeAt(addressBucket, height)
+			if err != nil || stake.IsZero() {
+				return err
+			}
+			var uh types.UnlockHash
+			err = rivbin.Unmarshal(k, &uh)
+			if err != nil {
+				return fmt.Errorf("failed to decode address: %v", err)
+			}
+			distribution.Stakes = append(distribution.Stakes, AddressStake{UnlockHash: uh, Stake: stake})
+			distribution.Total = distribution.Total.Add(stake)
+			return nil
+		})
+	})
+	if err != nil {
+		return Distribution{}, err
+	}
+	sort.Slice(distribution.Stakes, func(i, j int) bool {
+		if c := distribution.Stakes[i].Stake.Cmp(distribution.Stakes[j].Stake); c != 0 {
+			return c > 0
+		}
+		return distribution.Stakes[i].UnlockHash.Cmp(distribution.Stakes[j].UnlockHash) < 0
+	})
+	var controlled types.Currency
+	for _, stake := range distribution.Stakes {
+		controlled = controlled.Add(stake.Stake)
+		distribution.NakamotoCoefficient++
+		if controlled.Mul64(2).Cmp(distribution.Total) > 0 {
+			break
+		}
+	}
+	return distribution, nil
+}
+
+// stakeAt returns the stake stored in the given address bucket,
+// as it was after the block at the given height.
+func stakeAt(addressBucket *bolt.Bucket, height types.BlockHeight) (types.Currency, error) {
+	cursor := addressBucket.Cursor()
+	k, v := cursor.Seek(encodeBlockHeight(height + 1))
+	if k == nil {
+		k, v = cursor.Last()
+	} else {
+		k, v = cursor.Prev()
+	}
+	if k == nil {
+		return types.Currency{}, nil
+	}
+	var stake types.Currency
+	err := rivbin.Unmarshal(v, &stake)
+	if err != nil {
+		return types.Currency{}, fmt.Errorf("failed to decode stake: %v", err)
+	}
+	return stake, nil
+}
+
+func encodeBlockHeight(height types.BlockHeight) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(height))
+	return b
+}
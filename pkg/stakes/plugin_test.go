@@ -0,0 +1,83 @@
+package stakes
+
+import (
+	"testing"
+
+	"github.com/nbh-digital/goldchain/internal/plugintest"
+	"github.com/nbh-digital/goldchain/pkg/pluginstats"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPluginDistribution(t *testing.T) {
+	db := plugintest.NewDB(t, "stakes")
+
+	uhA := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uhB := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	genesis := types.Block{Transactions: []types.Transaction{{
+		BlockStakeOutputs: []types.BlockStakeOutput{
+			{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(uhA))},
+			{Value: types.NewCurrency64(5), Condition: types.NewCondition(types.NewUnlockHashCondition(uhB))},
+		},
+	}}}
+
+	p := NewPlugin(genesis)
+	db.InitPlugin(t, p, nil)
+	update := func(fn func(bucket *persist.LazyBoltBucket) error) {
+		err := db.UpdateBucket(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectDistribution := func(height types.BlockHeight, expected map[types.UnlockHash]uint64, nakamotoCoefficient int) {
+		t.Helper()
+		distribution, err := p.GetDistributionAt(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(distribution.Stakes) != len(expected) || distribution.NakamotoCoefficient != nakamotoCoefficient {
+			t.Fatalf("unexpected distribution at height %d: %+v", height, distribution)
+		}
+		for _, stake := range distribution.Stakes {
+			if !stake.Stake.Equals64(expected[stake.UnlockHash]) {
+				t.Errorf("unexpected stake for %s at height %d: %s", stake.UnlockHash.String(), height, stake.Stake.String())
+			}
+		}
+	}
+
+	// the genesis block is applied when initializing the plugin,
+	// such that applying it once more is a no-op
+	expectDistribution(0, map[types.UnlockHash]uint64{uhA: 10, uhB: 5}, 1)
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(genesis, 0, bucket)
+	})
+	expectDistribution(0, map[types.UnlockHash]uint64{uhA: 10, uhB: 5}, 1)
+
+	// transfer 6 blockstakes from A to B
+	block := types.Block{Transactions: []types.Transaction{{
+		BlockStakeInputs: []types.BlockStakeInput{{ParentID: genesis.Transactions[0].BlockStakeOutputID(0)}},
+		BlockStakeOutputs: []types.BlockStakeOutput{
+			{Value: types.NewCurrency64(4), Condition: types.NewCondition(types.NewUnlockHashCondition(uhA))},
+			{Value: types.NewCurrency64(6), Condition: types.NewCondition(types.NewUnlockHashCondition(uhB))},
+		},
+	}}}
+	// blocks replayed using a read-only transaction cannot be applied
+	err := db.ViewBucket(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	if err != pluginstats.ErrCatchUpUnsupported {
+		t.Fatalf("expected %v, got %v", pluginstats.ErrCatchUpUnsupported, err)
+	}
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.ApplyBlock(block, 1, bucket)
+	})
+	expectDistribution(0, map[types.UnlockHash]uint64{uhA: 10, uhB: 5}, 1)
+	expectDistribution(1, map[types.UnlockHash]uint64{uhA: 4, uhB: 11}, 1)
+	expectDistribution(5, map[types.UnlockHash]uint64{uhA: 4, uhB: 11}, 1)
+
+	update(func(bucket *persist.LazyBoltBucket) error {
+		return p.RevertBlock(block, 1, bucket)
+	})
+	expectDistribution(1, map[types.UnlockHash]uint64{uhA: 10, uhB: 5}, 1)
+}
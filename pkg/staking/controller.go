@@ -0,0 +1,429 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ErrNoBlockCreator is returned when enabling block creation on a closed Controller.
+var ErrNoBlockCreator = errors.New("the block creator is closed")
+
+type (
+	// CreatedBlock is a block, as identified by the blockstakes used to create it.
+	CreatedBlock struct {
+		Height    types.BlockHeight `json:"height"`
+		ID        types.BlockID     `json:"id"`
+		Timestamp types.Timestamp   `json:"timestamp"`
+		// Creator is the owner of the blockstake output used to create the block
+		Creator types.UnlockHash `json:"creator"`
+		// Stake is the value of the blockstake output used to create the block
+		Stake types.Currency `json:"stake"`
+	}
+
+	// Statistics contains the block creation statistics of a Controller since it was created,
+	// only counting the blocks applied (or reverted) once the consensus set is synced.
+	Statistics struct {
+		// BlocksCreated is the amount of blocks created using the blockstakes of the wallet
+		BlocksCreated uint64 `json:"blockscreated"`
+		// OrphanedBlocks is the amount of blocks created using the blockstakes of the wallet,
+		// which got reverted afterwards, these blocks are no longer counted as created
+		OrphanedBlocks uint64 `json:"orphanedblocks"`
+		// MissedSlots is the amount of blocks created by others while the wallet owned blockstakes,
+		// but could not create blocks itself, as block creation was disabled or the wallet was locked
+		MissedSlots uint64 `json:"missedslots"`
+		// LastCreatedBlock is the last block created using the blockstakes of the wallet
+		LastCreatedBlock *CreatedBlock `json:"lastcreatedblock,omitempty"`
+	}
+
+	// Status describes the block creation of a Controller.
+	Status struct {
+		Enabled        bool `json:"enabled"`
+		WalletUnlocked bool `json:"walletunlocked"`
+		// OwnedStake is the amount of blockstakes owned by the wallet, zero while it is locked
+		OwnedStake types.Currency `json:"ownedstake"`
+		// ActiveStake is the amount of blockstakes owned by the wallet which can be used to create blocks,
+		// as blockstakes have to age before they can be used, unless they were used to create the block they are part of
+		ActiveStake types.Currency `json:"activestake"`
+		// TotalStake is the amount of blockstakes of the network
+		TotalStake types.Currency `json:"totalstake"`
+		// ExpectedSecondsToNextBlock is the average amount of seconds it takes to create a block
+		// using the active stake of the wallet, assuming all blockstakes of the network participate,
+		// zero when the wallet has no active stake
+		ExpectedSecondsToNextBlock uint64 `json:"expectedsecondstonextblock"`
+		Statistics
+	}
+)
+
+// NewCreatedBlock returns the creator of the given block at the given height,
+// false for blocks without block creating transaction (e.g. the genesis block).
+func NewCreatedBlock(block types.Block, height types.BlockHeight) (CreatedBlock, bool) {
+	if len(block.Transactions) == 0 {
+		return CreatedBlock{}, false
+	}
+	// the block creating transaction respends the blockstake output used to create the block
+	txn := block.Transactions[0]
+	if len(txn.BlockStakeInputs) != 1 || len(txn.BlockStakeOutputs) != 1 || len(txn.CoinInputs) != 0 {
+		return CreatedBlock{}, false
+	}
+	return CreatedBlock{
+		Height:    height,
+		ID:        block.ID(),
+		Timestamp: block.Timestamp,
+		Creator:   txn.BlockStakeOutputs[0].Condition.UnlockHash(),
+		Stake:     txn.BlockStakeOutputs[0].Value,
+	}, true
+}
+
+// wallet is the part of modules.Wallet used by the Controller.
+type wallet interface {
+	Unlocked() bool
+	AllAddresses() ([]types.UnlockHash, error)
+	GetUnspentBlockStakeOutputs() ([]types.UnspentBlockStakeOutput, error)
+}
+
+// observation is a block applied or reverted by the consensus set,
+// waiting to be compared against the addresses of the wallet.
+type observation struct {
+	block   CreatedBlock
+	applied bool
+	// creating defines whether block creation was enabled when the block was applied
+	creating bool
+}
+
+// Controller enables and disables the block creator at runtime,
+// and keeps statistics about the blocks created using the blockstakes of the wallet.
+//
+// As the block creator cannot be paused, disabling block creation closes it,
+// while enabling block creation creates it once more, catching up with the consensus set.
+type Controller struct {
+	cs        modules.ConsensusSet
+	wallet    wallet
+	constants types.ChainConstants
+	create    func() (modules.BlockCreator, error)
+
+	// lifecycleMu serializes enabling and disabling the block creator,
+	// which cannot happen while holding mu, as the consensus set calls the Controller while doing so
+	lifecycleMu sync.Mutex
+	creator     modules.BlockCreator
+	closed      bool
+
+	signal  chan struct{}
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	height  types.BlockHeight
+	enabled bool
+	stats   Statistics
+	pending []observation
+	// ownedStake is the last known amount of blockstakes owned by the wallet
+	ownedStake types.Currency
+}
+
+// NewController creates a Controller with block creation enabled, creating the block creator using the given function,
+// and subscribing it to the given consensus set, which is expected not to be started yet.
+func NewController(cs modules.ConsensusSet, w modules.Wallet, constants types.ChainConstants, create func() (modules.BlockCreator, error)) (*Controller, error) {
+	c := newController(w, constants, create)
+	c.wg.Add(1)
+	go c.processObservations()
+	err := c.Enable()
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	c.cs = cs
+	c.height = cs.Height()
+	err = cs.ConsensusSetSubscribe(c, modules.ConsensusChangeRecent, c.closeCh)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	return c, nil
+}
+
+func newController(w wallet, constants types.ChainConstants, create func() (modules.BlockCreator, error)) *Controller {
+	c := &Controller{
+		wallet:    w,
+		constants: constants,
+		create:    create,
+		signal:    make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+	}
+	return c
+}
+
+// Enable creates the block creator, should block creation be disabled.
+func (c *Controller) Enable() error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+	if c.closed {
+		return ErrNoBlockCreator
+	}
+	if c.creator != nil {
+		return nil
+	}
+	creator, err := c.create()
+	if err != nil {
+		return fmt.Errorf("failed to create the block creator: %v", err)
+	}
+	c.creator = creator
+	c.mu.Lock()
+	c.enabled = true
+	c.mu.Unlock()
+	return nil
+}
+
+// Disable closes the block creator, should block creation be enabled.
+func (c *Controller) Disable() error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+	return c.disable()
+}
+
+func (c *Controller) disable() error {
+	if c.creator == nil {
+		return nil
+	}
+	c.mu.Lock()
+	c.enabled = false
+	c.mu.Unlock()
+	err := c.creator.Close()
+	c.creator = nil
+	if err != nil {
+		return fmt.Errorf("failed to close the block creator: %v", err)
+	}
+	return nil
+}
+
+// Enabled returns whether block creation is enabled.
+func (c *Controller) Enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.enabled
+}
+
+// Close implements modules.BlockCreator,
+// closing the block creator and unsubscribing the Controller from the consensus set.
+func (c *Controller) Close() error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	err := c.disable()
+	if c.cs != nil {
+		c.cs.Unsubscribe(c)
+	}
+	close(c.closeCh)
+	c.wg.Wait()
+	return err
+}
+
+// Statistics returns a snapshot of the block creation statistics of this Controller.
+func (c *Controller) Statistics() Statistics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	if stats.LastCreatedBlock != nil {
+		block := *stats.LastCreatedBlock
+		stats.LastCreatedBlock = &block
+	}
+	return stats
+}
+
+// Status returns the block creation status of this Controller,
+// including the expected time until the wallet creates its next block.
+func (c *Controller) Status() (Status, error) {
+	status := Status{
+		Enabled:        c.Enabled(),
+		WalletUnlocked: c.wallet.Unlocked(),
+		TotalStake:     c.constants.GenesisBlockStakeCount(),
+		Statistics:     c.Statistics(),
+	}
+	if !status.WalletUnlocked {
+		return status, nil
+	}
+	outputs, err := c.wallet.GetUnspentBlockStakeOutputs()
+	if err != nil {
+		return Status{}, fmt.Errorf("failed to get the blockstake outputs of the wallet: %v", err)
+	}
+	now := types.CurrentTimestamp()
+	for _, ubso := range outputs {
+		status.OwnedStake = status.OwnedStake.Add(ubso.Value)
+		if c.isActive(ubso, now) {
+			status.ActiveStake = status.ActiveStake.Add(ubso.Value)
+		}
+	}
+	if !status.ActiveStake.IsZero() {
+		// each blockstake has the same chance of creating the next block
+		expected := status.TotalStake.Mul64(uint64(c.constants.BlockFrequency)).Div(status.ActiveStake)
+		status.ExpectedSecondsToNextBlock, _ = expected.Uint64()
+	}
+	return status, nil
+}
+
+// isActive returns whether the given blockstake output can be used by the block creator at the given time,
+// applying the same aging rule as the block creator.
+func (c *Controller) isActive(ubso types.UnspentBlockStakeOutput, now types.Timestamp) bool {
+	return isActive(c.cs, c.constants, ubso, now)
+}
+
+// isActive returns whether the given blockstake output can be used to create a block at the given time:
+// blockstake outputs created by the first output of a block creating transaction can be used immediately,
+// all other blockstake outputs only once they aged.
+func isActive(cs modules.ConsensusSet, constants types.ChainConstants, ubso types.UnspentBlockStakeOutput, now types.Timestamp) bool {
+	if ubso.Indexes.TransactionIndex == 0 && ubso.Indexes.OutputIndex == 0 {
+		return true
+	}
+	if cs == nil {
+		return false
+	}
+	block, ok := cs.BlockAtHeight(ubso.Indexes.BlockHeight)
+	if !ok {
+		return false
+	}
+	return block.Timestamp+types.Timestamp(constants.BlockStakeAging) <= now
+}
+
+// CreatedBlocks returns all blocks within the given inclusive range of heights,
+// which were created using the blockstakes of the wallet, which has to be unlocked.
+func (c *Controller) CreatedBlocks(start, end types.BlockHeight) ([]CreatedBlock, error) {
+	owned, err := c.ownedAddresses()
+	if err != nil {
+		return nil, err
+	}
+	blocks := []CreatedBlock{}
+	for height := start; height <= end; height++ {
+		block, ok := c.cs.BlockAtHeight(height)
+		if !ok {
+			break // the chain got reorganized to a shorter one
+		}
+		created, ok := NewCreatedBlock(block, height)
+		if !ok {
+			continue
+		}
+		if _, ok = owned[created.Creator]; ok {
+			blocks = append(blocks, created)
+		}
+	}
+	return blocks, nil
+}
+
+// ownedAddresses returns all addresses of the wallet, which has to be unlocked,
+// as well as the owners of the blockstakes the wallet can use to create blocks (e.g. delegated to it).
+func (c *Controller) ownedAddresses() (map[types.UnlockHash]struct{}, error) {
+	if !c.wallet.Unlocked() {
+		return nil, modules.ErrLockedWallet
+	}
+	addresses, err := c.wallet.AllAddresses()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the addresses of the wallet: %v", err)
+	}
+	outputs, err := c.wallet.GetUnspentBlockStakeOutputs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the blockstakes of the wallet: %v", err)
+	}
+	owned := make(map[types.UnlockHash]struct{}, len(addresses))
+	for _, uh := range addresses {
+		owned[uh] = struct{}{}
+	}
+	for _, ubso := range outputs {
+		owned[ubso.Condition.UnlockHash()] = struct{}{}
+	}
+	return owned, nil
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// queueing all blocks applied and reverted once the consensus set is synced.
+// As the wallet cannot be used while the consensus set calls its subscribers,
+// the queued blocks are compared against the addresses of the wallet in the background.
+func (c *Controller) ProcessConsensusChange(cc modules.ConsensusChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, block := range cc.RevertedBlocks {
+		if cc.Synced {
+			c.observe(block, c.height, false)
+		}
+		c.height--
+	}
+	for _, block := range cc.AppliedBlocks {
+		c.height++
+		if cc.Synced {
+			c.observe(block, c.height, true)
+		}
+	}
+}
+
+// observe queues an applied or reverted block, the caller holding mu.
+func (c *Controller) observe(block types.Block, height types.BlockHeight, applied bool) {
+	created, ok := NewCreatedBlock(block, height)
+	if !ok {
+		return
+	}
+	c.pending = append(c.pending, observation{block: created, applied: applied, creating: c.enabled})
+	select {
+	case c.signal <- struct{}{}:
+	default:
+	}
+}
+
+// processObservations compares all queued blocks against the addresses of the wallet,
+// until the Controller is closed.
+func (c *Controller) processObservations() {
+	defer c.wg.Done()
+	for {
+		select {
+		case <-c.closeCh:
+			return
+		case <-c.signal:
+		}
+		c.mu.Lock()
+		pending := c.pending
+		c.pending = nil
+		c.mu.Unlock()
+		c.process(pending)
+	}
+}
+
+// process updates the statistics for the given observations.
+func (c *Controller) process(observations []observation) {
+	owned, err := c.ownedAddresses()
+	var ownedStake types.Currency
+	if err == nil {
+		outputs, err := c.wallet.GetUnspentBlockStakeOutputs()
+		if err != nil {
+			owned = nil
+		}
+		for _, ubso := range outputs {
+			ownedStake = ownedStake.Add(ubso.Value)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if owned != nil {
+		c.ownedStake = ownedStake
+	}
+	for _, obs := range observations {
+		if _, ok := owned[obs.block.Creator]; ok {
+			if obs.applied {
+				c.stats.BlocksCreated++
+				block := obs.block
+				c.stats.LastCreatedBlock = &block
+			} else if c.stats.BlocksCreated > 0 {
+				c.stats.BlocksCreated--
+				c.stats.OrphanedBlocks++
+			}
+			continue
+		}
+		// the wallet cannot create blocks while locked
+		if obs.applied && !c.ownedStake.IsZero() && (owned == nil || !obs.creating) {
+			c.stats.MissedSlots++
+		}
+	}
+}
@@ -0,0 +1,246 @@
+package staking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testWallet struct {
+	unlocked  bool
+	addresses []types.UnlockHash
+	outputs   []types.UnspentBlockStakeOutput
+}
+
+func (w *testWallet) Unlocked() bool { return w.unlocked }
+
+func (w *testWallet) AllAddresses() ([]types.UnlockHash, error) {
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	return w.addresses, nil
+}
+
+func (w *testWallet) GetUnspentBlockStakeOutputs() ([]types.UnspentBlockStakeOutput, error) {
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	return w.outputs, nil
+}
+
+type testCreator struct {
+	closed bool
+}
+
+func (c *testCreator) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestController(t *testing.T) {
+	constants := config.GetDevnetGenesis()
+	address := func(b byte) types.UnlockHash {
+		return types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{b})
+	}
+	block := func(timestamp, creator byte) types.Block {
+		condition := types.NewCondition(types.NewUnlockHashCondition(address(creator)))
+		return types.Block{
+			Timestamp: types.Timestamp(timestamp),
+			Transactions: []types.Transaction{{
+				Version:           types.TransactionVersionOne,
+				BlockStakeInputs:  []types.BlockStakeInput{{ParentID: types.BlockStakeOutputID{timestamp}}},
+				BlockStakeOutputs: []types.BlockStakeOutput{{Value: types.NewCurrency64(10), Condition: condition}},
+			}},
+		}
+	}
+
+	w := &testWallet{
+		unlocked:  true,
+		addresses: []types.UnlockHash{address(1)},
+		outputs: []types.UnspentBlockStakeOutput{{
+			Value:     constants.GenesisBlockStakeCount().Div64(4),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(address(1))),
+		}},
+	}
+	var creators []*testCreator
+	c := newController(w, constants, func() (modules.BlockCreator, error) {
+		creators = append(creators, &testCreator{})
+		return creators[len(creators)-1], nil
+	})
+	defer c.Close()
+	if err := c.Enable(); err != nil {
+		t.Fatal(err)
+	}
+
+	process := func(cc modules.ConsensusChange) {
+		c.ProcessConsensusChange(cc)
+		c.process(c.pending)
+		c.pending = nil
+	}
+	// blocks are only counted once synced
+	process(modules.ConsensusChange{AppliedBlocks: []types.Block{block(1, 2), block(2, 2)}})
+	process(modules.ConsensusChange{AppliedBlocks: []types.Block{block(3, 1), block(4, 2)}, Synced: true})
+	if err := c.Disable(); err != nil {
+		t.Fatal(err)
+	}
+	if !creators[0].closed || c.Enabled() {
+		t.Fatal("expected the block creator to be closed")
+	}
+	// the first created block gets orphaned, while a block created by others is missed
+	process(modules.ConsensusChange{
+		RevertedBlocks: []types.Block{block(4, 2), block(3, 1)},
+		AppliedBlocks:  []types.Block{block(5, 2), block(6, 1)},
+		Synced:         true,
+	})
+	// blocks cannot be created while the wallet is locked
+	w.unlocked = false
+	if err := c.Enable(); err != nil {
+		t.Fatal(err)
+	}
+	process(modules.ConsensusChange{AppliedBlocks: []types.Block{block(7, 2)}, Synced: true})
+
+	stats := c.Statistics()
+	if stats.BlocksCreated != 1 || stats.OrphanedBlocks != 1 || stats.MissedSlots != 2 {
+		t.Errorf("unexpected statistics: %+v", stats)
+	}
+	if stats.LastCreatedBlock == nil || stats.LastCreatedBlock.Height != 4 || stats.LastCreatedBlock.Timestamp != 6 {
+		t.Errorf("unexpected last created block: %+v", stats.LastCreatedBlock)
+	}
+	if len(creators) != 2 || creators[1].closed || !c.Enabled() {
+		t.Error("expected block creation to be enabled once more")
+	}
+
+	w.unlocked = true
+	status, err := c.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Enabled || status.ActiveStake.Cmp(w.outputs[0].Value) != 0 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+	expectedTime := uint64(constants.BlockFrequency) * 4 // the wallet owns a quarter of all blockstakes
+	if status.ExpectedSecondsToNextBlock != expectedTime {
+		t.Errorf("expected a block every %d seconds, not every %d seconds", expectedTime, status.ExpectedSecondsToNextBlock)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !creators[1].closed {
+		t.Error("expected the block creator to be closed")
+	}
+	if err := c.Enable(); err != ErrNoBlockCreator {
+		t.Errorf("expected a closed controller not to enable block creation, got %v", err)
+	}
+}
+
+func TestProcessObservations(t *testing.T) {
+	w := &testWallet{unlocked: true}
+	c := newController(w, config.GetDevnetGenesis(), nil)
+	c.wg.Add(1)
+	go c.processObservations()
+	defer c.Close()
+	c.mu.Lock()
+	c.observe(types.Block{Transactions: []types.Transaction{{
+		BlockStakeInputs:  []types.BlockStakeInput{{}},
+		BlockStakeOutputs: []types.BlockStakeOutput{{Value: types.NewCurrency64(1)}},
+	}}}, 1, true)
+	c.mu.Unlock()
+	// the block is processed in the background
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		c.mu.Lock()
+		pending := len(c.pending)
+		c.mu.Unlock()
+		if pending == 0 {
+			return
+		}
+	}
+	t.Fatal("timeout waiting for the observation to be processed")
+}
+
+type testConsensusState struct {
+	tip    types.Timestamp
+	synced bool
+}
+
+func (cs *testConsensusState) CurrentBlock() types.Block { return types.Block{Timestamp: cs.tip} }
+func (cs *testConsensusState) Synced() bool              { return cs.synced }
+
+type testGateway struct {
+	peers int
+}
+
+func (g *testGateway) Peers() []modules.Peer { return make([]modules.Peer, g.peers) }
+
+func TestHealthMonitor(t *testing.T) {
+	constants := config.GetDevnetGenesis()
+	frequency := time.Duration(constants.BlockFrequency) * time.Second
+	start := time.Unix(1000000, 0)
+	now := start
+	cs := &testConsensusState{tip: types.Timestamp(start.Unix()), synced: true}
+	g := &testGateway{peers: 1}
+	status := Status{Enabled: true, WalletUnlocked: true, ExpectedSecondsToNextBlock: uint64(constants.BlockFrequency) * 2}
+	var changes []Health
+	m := newHealthMonitor(func() (Status, error) { return status, nil }, cs, g, constants, 0, func(health Health) {
+		changes = append(changes, health)
+	})
+	m.now = func() time.Time { return now }
+	expectProblems := func(expected int) Health {
+		t.Helper()
+		m.check()
+		health := m.Health()
+		if len(health.Problems) != expected || health.Healthy != (expected == 0) {
+			t.Fatalf("expected %d problems, got %+v", expected, health)
+		}
+		return health
+	}
+
+	expectProblems(0)
+	if len(changes) != 0 {
+		t.Errorf("expected no change while healthy, got %+v", changes)
+	}
+	// the stake becomes active at the first check, the block creator stalls once it hasn't created
+	// a block within DefaultStallFactor times the expected time, while the blockchain grows
+	now = start.Add(DefaultStallFactor*2*frequency + time.Second)
+	cs.tip = types.Timestamp(now.Unix())
+	health := expectProblems(1)
+	if health.SecondsSinceLastBlock != uint64(DefaultStallFactor*2*frequency/time.Second)+1 {
+		t.Errorf("unexpected health: %+v", health)
+	}
+	// a growing duration isn't a change
+	now = now.Add(time.Second)
+	expectProblems(1)
+	if len(changes) != 1 || changes[0].Healthy {
+		t.Fatalf("expected a single change, got %+v", changes)
+	}
+	// creating a block recovers
+	status.LastCreatedBlock = &CreatedBlock{Timestamp: types.Timestamp(now.Unix())}
+	expectProblems(0)
+	if len(changes) != 2 || !changes[1].Healthy {
+		t.Fatalf("expected recovery, got %+v", changes)
+	}
+
+	// the likely causes are diagnosed
+	status.WalletUnlocked = false
+	g.peers = 0
+	cs.synced = false
+	expectProblems(3)
+	status.WalletUnlocked = true
+	g.peers = 1
+	cs.synced = true
+	cs.tip = types.Timestamp(now.Add(2 * frequency).Unix())
+	expectProblems(1) // clock running behind
+	cs.tip = types.Timestamp(now.Add(-(DefaultStallFactor + 1) * frequency).Unix())
+	expectProblems(1) // no blocks were added to the blockchain
+	if len(changes) != 5 {
+		t.Errorf("expected every change of problems to be reported, got %d changes", len(changes))
+	}
+
+	// no problems are reported while block creation is disabled
+	status.Enabled = false
+	expectProblems(0)
+}
@@ -0,0 +1,202 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// blockSizeReserve is the part of the block size limit not used by pool transactions,
+// leaving room for the block header, miner payouts and block creating transaction, as the block creator does.
+const blockSizeReserve = 5e3
+
+var (
+	// ErrNoActiveBlockStakes is returned in case blocks are mined by a wallet without blockstakes which can be used to create blocks.
+	ErrNoActiveBlockStakes = errors.New("the wallet has no blockstakes which can be used to create blocks")
+	// ErrFutureThreshold is returned in case no block can be created without its timestamp exceeding the future threshold,
+	// as blocks are created faster than the block frequency of the chain.
+	ErrFutureThreshold = errors.New("no block can be created without exceeding the future threshold of the chain, " +
+		"wait for time to catch up or lower the block frequency of the chain")
+)
+
+// Miner creates blocks on demand, using the blockstakes of a wallet,
+// rather than waiting for the time slots of those blockstakes to arrive, as the block creator does.
+// Block timestamps are chosen as early as possible, starting right after the timestamp of the parent block,
+// such that consecutive blocks are spaced by the block frequency of the chain on average,
+// fast-forwarding the time of the chain as far as the future threshold allows.
+// It is intended for test networks, where it makes block creation deterministic.
+type Miner struct {
+	cs        modules.ConsensusSet
+	wallet    modules.Wallet
+	tpool     modules.TransactionPool
+	constants types.ChainConstants
+
+	mu sync.Mutex
+}
+
+// NewMiner creates a new Miner, creating blocks using the blockstakes of the given wallet,
+// which include the transactions of the given transaction pool.
+func NewMiner(cs modules.ConsensusSet, w modules.Wallet, tpool modules.TransactionPool, constants types.ChainConstants) *Miner {
+	return &Miner{
+		cs:        cs,
+		wallet:    w,
+		tpool:     tpool,
+		constants: constants,
+	}
+}
+
+// Mine creates the given amount of blocks, one after the other, submitting each of them to the consensus set.
+// The IDs of the created blocks are returned, which are fewer than requested in case an error is returned.
+func (m *Miner) Mine(count int) ([]types.BlockID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]types.BlockID, 0, count)
+	for len(ids) < count {
+		block, err := m.solveBlock(types.CurrentTimestamp() + types.Timestamp(m.constants.FutureThreshold))
+		if err != nil {
+			return ids, err
+		}
+		err = m.cs.AcceptBlock(block)
+		if err != nil {
+			return ids, fmt.Errorf("failed to submit block: %v", err)
+		}
+		ids = append(ids, block.ID())
+	}
+	return ids, nil
+}
+
+// solveBlock creates a block extending the current block, with the earliest timestamp (up to the given one)
+// at which any active blockstake output of the wallet is allowed to create a block.
+func (m *Miner) solveBlock(maxTimestamp types.Timestamp) (types.Block, error) {
+	ubsos, err := m.wallet.GetUnspentBlockStakeOutputs()
+	if err != nil {
+		return types.Block{}, err
+	}
+	parent := m.cs.CurrentBlock()
+	ubso, timestamp, err := m.solve(parent, m.cs.Height(), ubsos, 0, maxTimestamp)
+	if err != nil {
+		return types.Block{}, err
+	}
+	return m.createBlock(parent.ID(), timestamp, ubso)
+}
+
+// solve returns the earliest timestamp after the given one (and up to the given maximum) at which any of the given blockstake outputs
+// is allowed to create a child of the given parent block, along with that blockstake output.
+func (m *Miner) solve(parent types.Block, height types.BlockHeight, ubsos []types.UnspentBlockStakeOutput, after, maxTimestamp types.Timestamp) (types.UnspentBlockStakeOutput, types.Timestamp, error) {
+	parentID := parent.ID()
+	target, ok := m.cs.ChildTarget(parentID)
+	if !ok {
+		return types.UnspentBlockStakeOutput{}, 0, errors.New("failed to get the target of the next block")
+	}
+	start, ok := m.cs.MinimumValidChildTimestamp(parentID)
+	if !ok {
+		return types.UnspentBlockStakeOutput{}, 0, errors.New("failed to get the minimum timestamp of the next block")
+	}
+	if start <= parent.Timestamp {
+		start = parent.Timestamp + 1
+	}
+	if start <= after {
+		start = after + 1
+	}
+	stakeModifier := m.cs.CalculateStakeModifier(height+1, parent, m.constants.StakeModifierDelay-1)
+	var active bool
+	for timestamp := start; timestamp <= maxTimestamp; timestamp++ {
+		for _, ubso := range ubsos {
+			if !isActive(m.cs, m.constants, ubso, timestamp) {
+				continue
+			}
+			active = true
+			// the same proof as verified by the consensus set
+			pobsHash := crypto.HashAll(stakeModifier.Bytes(), ubso.Indexes.BlockHeight,
+				ubso.Indexes.TransactionIndex, ubso.Indexes.OutputIndex, uint64(timestamp))
+			value := new(big.Int).SetBytes(pobsHash[:])
+			value.Div(value, ubso.Value.Big())
+			if value.Cmp(target.Int()) == -1 {
+				return ubso, timestamp, nil
+			}
+		}
+	}
+	if !active {
+		return types.UnspentBlockStakeOutput{}, 0, ErrNoActiveBlockStakes
+	}
+	return types.UnspentBlockStakeOutput{}, 0, ErrFutureThreshold
+}
+
+// createBlock creates a block using the given blockstake output, respending it in the first transaction,
+// followed by as many transactions of the transaction pool as fit in the block.
+func (m *Miner) createBlock(parentID types.BlockID, timestamp types.Timestamp, ubso types.UnspentBlockStakeOutput) (types.Block, error) {
+	builder := m.wallet.StartTransaction()
+	err := builder.SpendBlockStake(ubso.BlockStakeOutputID)
+	if err != nil {
+		return types.Block{}, fmt.Errorf("failed to respend blockstake output %s: %v", ubso.BlockStakeOutputID.String(), err)
+	}
+	builder.AddBlockStakeOutput(types.BlockStakeOutput{
+		Value:     ubso.Value,
+		Condition: ubso.Condition,
+	})
+	txns, err := builder.Sign()
+	if err != nil {
+		builder.Drop()
+		return types.Block{}, fmt.Errorf("failed to sign the block creating transaction: %v", err)
+	}
+	return m.assembleBlock(parentID, timestamp, ubso, append(txns, m.poolTransactions(ubso.BlockStakeOutputID)...))
+}
+
+// assembleBlock creates a block containing the given transactions, created using the given blockstake output,
+// paying the block creator fee and the transaction fees.
+func (m *Miner) assembleBlock(parentID types.BlockID, timestamp types.Timestamp, ubso types.UnspentBlockStakeOutput, txns []types.Transaction) (types.Block, error) {
+	block := types.Block{
+		ParentID:     parentID,
+		Timestamp:    timestamp,
+		POBSOutput:   ubso.Indexes,
+		Transactions: txns,
+	}
+	if !m.constants.BlockCreatorFee.IsZero() {
+		block.MinerPayouts = append(block.MinerPayouts, types.MinerPayout{
+			Value: m.constants.BlockCreatorFee, UnlockHash: ubso.Condition.UnlockHash()})
+	}
+	if fees := block.CalculateTotalMinerFees(); !fees.IsZero() {
+		condition := m.constants.TransactionFeeCondition
+		if condition.ConditionType() == types.ConditionTypeNil {
+			condition = ubso.Condition
+		}
+		block.MinerPayouts = append(block.MinerPayouts, types.MinerPayout{
+			Value: fees, UnlockHash: condition.UnlockHash()})
+	}
+	for _, txn := range block.Transactions {
+		payouts, err := txn.CustomMinerPayouts()
+		if err != nil {
+			return types.Block{}, fmt.Errorf("failed to get the custom miner payouts of transaction %s: %v", txn.ID().String(), err)
+		}
+		block.MinerPayouts = append(block.MinerPayouts, payouts...)
+	}
+	return block, nil
+}
+
+// poolTransactions returns the transactions of the transaction pool, in order, until the block is full,
+// stopping at the first transaction spending the blockstake output used to create the block,
+// as it is respent by the block creating transaction already.
+func (m *Miner) poolTransactions(used types.BlockStakeOutputID) []types.Transaction {
+	remaining := int(m.constants.BlockSizeLimit - blockSizeReserve)
+	var txns []types.Transaction
+	for _, txn := range m.tpool.TransactionList() {
+		for _, bsi := range txn.BlockStakeInputs {
+			if bsi.ParentID == used {
+				return txns
+			}
+		}
+		// transactions might depend on previous ones, so no transaction is skipped
+		remaining -= len(siabin.Marshal(txn))
+		if remaining < 0 {
+			return txns
+		}
+		txns = append(txns, txn)
+	}
+	return txns
+}
@@ -0,0 +1,213 @@
+package staking
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DefaultStallFactor is the default multiple of the expected time between two blocks created by the wallet,
+// after which block creation is considered stalled. As block creation is a random process,
+// the chance of a healthy block creator not creating a block within this window is about e^-5 (0.7%).
+const DefaultStallFactor = 5
+
+// Health describes whether the block creator of a HealthMonitor creates blocks as expected,
+// as well as the problems which could explain why it doesn't.
+type Health struct {
+	// Healthy is false as long as there are problems
+	Healthy bool `json:"healthy"`
+	// Problems describes all detected problems, most likely stalling block creation
+	Problems []string `json:"problems"`
+	// Enabled defines whether block creation is enabled, no problems are reported while it is disabled
+	Enabled bool `json:"enabled"`
+	Synced  bool `json:"synced"`
+	Peers   int  `json:"peers"`
+	// SecondsSinceLastBlock is the amount of seconds since the wallet last created a block,
+	// or since its blockstakes became active, should that be more recent
+	SecondsSinceLastBlock uint64 `json:"secondssincelastblock"`
+	// ExpectedSecondsToNextBlock is the average amount of seconds it takes the wallet to create a block,
+	// zero when the wallet has no active stake
+	ExpectedSecondsToNextBlock uint64 `json:"expectedsecondstonextblock"`
+	// StallThresholdSeconds is the amount of seconds without a created block,
+	// after which block creation is considered stalled, zero when the wallet has no active stake
+	StallThresholdSeconds uint64 `json:"stallthresholdseconds"`
+	// CheckedAt is the time of the most recent check
+	CheckedAt types.Timestamp `json:"checkedat"`
+}
+
+// consensusState is the part of modules.ConsensusSet used by the HealthMonitor.
+type consensusState interface {
+	CurrentBlock() types.Block
+	Synced() bool
+}
+
+// gateway is the part of modules.Gateway used by the HealthMonitor.
+type gateway interface {
+	Peers() []modules.Peer
+}
+
+// HealthMonitor periodically checks whether the blockstakes of a Controller produce blocks
+// within the statistically expected window, and diagnoses the likely cause when they don't:
+// a locked wallet, no peers, an unsynced consensus set or a drifting clock.
+type HealthMonitor struct {
+	status      func() (Status, error)
+	cs          consensusState
+	gateway     gateway
+	frequency   time.Duration
+	stallFactor uint64
+	onChange    func(Health)
+	now         func() time.Time
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	health Health
+	// activeSince is the time at which the wallet was first seen with active stake,
+	// zero while it has none
+	activeSince time.Time
+	// kinds identify the problems detected by the most recent check
+	kinds []string
+}
+
+// NewHealthMonitor creates a HealthMonitor for the given Controller, checking its health once per block frequency.
+// The given function is called (from the goroutine of the HealthMonitor) whenever the detected problems change,
+// it can be nil. A zero stall factor defaults to DefaultStallFactor.
+func NewHealthMonitor(controller *Controller, cs modules.ConsensusSet, g modules.Gateway, constants types.ChainConstants, stallFactor uint64, onChange func(Health)) *HealthMonitor {
+	m := newHealthMonitor(controller.Status, cs, g, constants, stallFactor, onChange)
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+func newHealthMonitor(status func() (Status, error), cs consensusState, g gateway, constants types.ChainConstants, stallFactor uint64, onChange func(Health)) *HealthMonitor {
+	if stallFactor == 0 {
+		stallFactor = DefaultStallFactor
+	}
+	return &HealthMonitor{
+		status:      status,
+		cs:          cs,
+		gateway:     g,
+		frequency:   time.Duration(constants.BlockFrequency) * time.Second,
+		stallFactor: stallFactor,
+		onChange:    onChange,
+		now:         time.Now,
+		closeCh:     make(chan struct{}),
+		health:      Health{Healthy: true, Problems: []string{}},
+	}
+}
+
+// Close stops the HealthMonitor.
+func (m *HealthMonitor) Close() error {
+	close(m.closeCh)
+	m.wg.Wait()
+	return nil
+}
+
+// Health returns the result of the most recent check.
+func (m *HealthMonitor) Health() Health {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health := m.health
+	health.Problems = append([]string{}, m.health.Problems...)
+	return health
+}
+
+// run checks the health once per block frequency, until the HealthMonitor is closed.
+func (m *HealthMonitor) run() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.frequency)
+	defer ticker.Stop()
+	m.check()
+	for {
+		select {
+		case <-m.closeCh:
+			return
+		case <-ticker.C:
+			m.check()
+		}
+	}
+}
+
+// check checks the health of the block creator,
+// calling onChange should the detected problems have changed since the previous check.
+func (m *HealthMonitor) check() {
+	now := m.now()
+	health := Health{
+		Problems:  []string{},
+		Synced:    m.cs.Synced(),
+		Peers:     len(m.gateway.Peers()),
+		CheckedAt: types.Timestamp(now.Unix()),
+	}
+	// kinds identify the detected problems, such that a growing duration isn't considered a change
+	var kinds []string
+	problem := func(kind, format string, args ...interface{}) {
+		kinds = append(kinds, kind)
+		health.Problems = append(health.Problems, fmt.Sprintf(format, args...))
+	}
+	status, err := m.status()
+	if err != nil {
+		problem("status", "failed to get the block creation status: %v", err)
+	}
+	health.Enabled = status.Enabled
+	health.ExpectedSecondsToNextBlock = status.ExpectedSecondsToNextBlock
+
+	m.mu.Lock()
+	if !status.Enabled || status.ExpectedSecondsToNextBlock == 0 {
+		m.activeSince = time.Time{}
+	} else if m.activeSince.IsZero() {
+		m.activeSince = now
+	}
+	since := m.activeSince
+	m.mu.Unlock()
+
+	if status.Enabled {
+		if err == nil && !status.WalletUnlocked {
+			problem("locked", "the wallet is locked")
+		}
+		if health.Peers == 0 {
+			problem("peers", "the node has no peers")
+		}
+		if !health.Synced {
+			problem("synced", "the consensus set is not synced")
+		}
+		// the block creator uses the local clock, blocks too far in the future are refused by other nodes,
+		// while a clock running behind prevents the block creator from creating blocks
+		tip := time.Unix(int64(m.cs.CurrentBlock().Timestamp), 0)
+		if drift := tip.Sub(now); drift > m.frequency {
+			problem("clock", "the last block is %v ahead of the local clock, which is likely running behind", drift.Round(time.Second))
+		} else if age := now.Sub(tip); health.Synced && age > time.Duration(m.stallFactor)*m.frequency {
+			problem("chain", "no block was added to the blockchain for %v, the local clock may be running ahead or the network is stalled", age.Round(time.Second))
+		}
+		if !since.IsZero() {
+			if last := status.LastCreatedBlock; last != nil && time.Unix(int64(last.Timestamp), 0).After(since) {
+				since = time.Unix(int64(last.Timestamp), 0)
+			}
+			elapsed := now.Sub(since)
+			if elapsed < 0 {
+				elapsed = 0
+			}
+			expected := time.Duration(status.ExpectedSecondsToNextBlock) * time.Second
+			threshold := time.Duration(m.stallFactor) * expected
+			health.SecondsSinceLastBlock = uint64(elapsed / time.Second)
+			health.StallThresholdSeconds = uint64(threshold / time.Second)
+			if elapsed > threshold {
+				problem("stalled", "no block was created for %v, while one is expected every %v", elapsed.Round(time.Second), expected)
+			}
+		}
+	}
+	health.Healthy = len(health.Problems) == 0
+
+	m.mu.Lock()
+	changed := strings.Join(m.kinds, ",") != strings.Join(kinds, ",")
+	m.health = health
+	m.kinds = kinds
+	m.mu.Unlock()
+	if changed && m.onChange != nil {
+		m.onChange(health)
+	}
+}
@@ -0,0 +1,158 @@
+package staking
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ErrNoForkBlockStakes is returned in case a reorganization is forced by a wallet
+// which owned no blockstakes at the height from which the competing chain is mined.
+var ErrNoForkBlockStakes = errors.New("the wallet owned no blockstakes at the height from which the competing chain is mined")
+
+// Reorg forces a reorganization of the given depth, by mining a competing chain on top of the block
+// the given amount of blocks below the current block, until it replaces the current chain,
+// which takes one block more than the depth. The blocks of the competing chain only contain their block creating transaction,
+// such that all transactions of the reverted blocks are reverted, which the transaction pool drops rather than taking back.
+// The IDs of the blocks of the competing chain are returned, which are fewer than mined in case an error is returned.
+//
+// The competing chain is created using the blockstakes owned by the wallet at the height from which it is mined,
+// whether they are spent since then or not, as the wallet signs using its keys directly.
+func (m *Miner) Reorg(depth types.BlockHeight) ([]types.BlockID, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	height := m.cs.Height()
+	if depth == 0 || depth > height {
+		return nil, fmt.Errorf("invalid reorganization depth %d: has to be from 1 to the current height %d", depth, height)
+	}
+	height -= depth
+	parent, ok := m.cs.BlockAtHeight(height)
+	if !ok {
+		return nil, fmt.Errorf("failed to get the block at height %d", height)
+	}
+	ubso, err := m.unspentBlockStakeOutputAt(height)
+	if err != nil {
+		return nil, err
+	}
+
+	// the competing chain is usually longest once it is one block longer,
+	// while its blocks might have lower targets, in which case it takes a few more
+	var (
+		ids   []types.BlockID
+		after types.Timestamp
+	)
+	for len(ids) < 2*int(depth+1) {
+		maxTimestamp := types.CurrentTimestamp() + types.Timestamp(m.constants.FutureThreshold)
+		_, timestamp, err := m.solve(parent, height, []types.UnspentBlockStakeOutput{ubso}, after, maxTimestamp)
+		if err != nil {
+			return ids, err
+		}
+		block, err := m.createForkBlock(parent.ID(), timestamp, ubso)
+		if err != nil {
+			return ids, err
+		}
+		err = m.cs.AcceptBlock(block)
+		if err == modules.ErrBlockKnown {
+			// the reverted block contained no other transactions either, so a later timestamp is used
+			after = timestamp
+			continue
+		}
+		after = 0
+		if err != nil && err != modules.ErrNonExtendingBlock {
+			return ids, fmt.Errorf("failed to submit block: %v", err)
+		}
+		ids = append(ids, block.ID())
+		if err == nil && m.cs.CurrentBlock().ID() == block.ID() {
+			return ids, nil
+		}
+		// the next block is created using the blockstakes respent by this one
+		parent = block
+		height++
+		ubso = types.UnspentBlockStakeOutput{
+			BlockStakeOutputID: block.Transactions[0].BlockStakeOutputID(0),
+			Indexes:            types.BlockStakeOutputIndexes{BlockHeight: height},
+			Value:              ubso.Value,
+			Condition:          ubso.Condition,
+		}
+	}
+	return ids, errors.New("the competing chain did not replace the current chain")
+}
+
+// unspentBlockStakeOutputAt returns a blockstake output owned by the wallet, which was unspent after the block at the given height,
+// by looking for it in the blocks at and below that height.
+func (m *Miner) unspentBlockStakeOutputAt(height types.BlockHeight) (types.UnspentBlockStakeOutput, error) {
+	addresses, err := m.wallet.AllAddresses()
+	if err != nil {
+		return types.UnspentBlockStakeOutput{}, err
+	}
+	owned := make(map[types.UnlockHash]struct{}, len(addresses))
+	for _, address := range addresses {
+		owned[address] = struct{}{}
+	}
+	// the outputs spent by the blocks above the block of which the outputs are looked at
+	spent := make(map[types.BlockStakeOutputID]struct{})
+	for h := int64(height); h >= 0; h-- {
+		block, ok := m.cs.BlockAtHeight(types.BlockHeight(h))
+		if !ok {
+			return types.UnspentBlockStakeOutput{}, fmt.Errorf("failed to get the block at height %d", h)
+		}
+		// outputs can be spent by the later transactions of the same block
+		for i := len(block.Transactions) - 1; i >= 0; i-- {
+			txn := block.Transactions[i]
+			for j, bso := range txn.BlockStakeOutputs {
+				id := txn.BlockStakeOutputID(uint64(j))
+				if _, ok := spent[id]; ok {
+					continue
+				}
+				if _, ok := owned[bso.Condition.UnlockHash()]; !ok {
+					continue
+				}
+				return types.UnspentBlockStakeOutput{
+					BlockStakeOutputID: id,
+					Indexes: types.BlockStakeOutputIndexes{
+						BlockHeight:      types.BlockHeight(h),
+						TransactionIndex: uint64(i),
+						OutputIndex:      uint64(j),
+					},
+					Value:     bso.Value,
+					Condition: bso.Condition,
+				}, nil
+			}
+			for _, bsi := range txn.BlockStakeInputs {
+				spent[bsi.ParentID] = struct{}{}
+			}
+		}
+	}
+	return types.UnspentBlockStakeOutput{}, ErrNoForkBlockStakes
+}
+
+// createForkBlock creates a block using the given blockstake output, respending it in its only transaction,
+// which is signed using the key of the wallet directly, as the output might be spent in the current chain.
+func (m *Miner) createForkBlock(parentID types.BlockID, timestamp types.Timestamp, ubso types.UnspentBlockStakeOutput) (types.Block, error) {
+	address := ubso.Condition.UnlockHash()
+	if address.Type != types.UnlockTypePubKey {
+		return types.Block{}, fmt.Errorf("unsupported condition of blockstake output %s: %v", ubso.BlockStakeOutputID.String(), address.Type)
+	}
+	pk, sk, err := m.wallet.GetKey(address)
+	if err != nil {
+		return types.Block{}, fmt.Errorf("failed to get the key of %s: %v", address.String(), err)
+	}
+	txn := types.Transaction{
+		Version:           m.constants.DefaultTransactionVersion,
+		BlockStakeInputs:  []types.BlockStakeInput{{ParentID: ubso.BlockStakeOutputID}},
+		BlockStakeOutputs: []types.BlockStakeOutput{{Value: ubso.Value, Condition: ubso.Condition}},
+	}
+	fulfillment := types.NewSingleSignatureFulfillment(pk)
+	err = fulfillment.Sign(types.FulfillmentSignContext{
+		ExtraObjects: []interface{}{uint64(0)},
+		Transaction:  txn,
+		Key:          sk,
+	})
+	if err != nil {
+		return types.Block{}, fmt.Errorf("failed to sign the block creating transaction: %v", err)
+	}
+	txn.BlockStakeInputs[0].Fulfillment = types.NewFulfillment(fulfillment)
+	return m.assembleBlock(parentID, timestamp, ubso, []types.Transaction{txn})
+}
@@ -0,0 +1,262 @@
+// Package testnet runs a goldchain devnet within the current process,
+// for integration tests of services built on top of goldchain, such as the faucet.
+//
+// The devnet consists of a single in-memory node, of which the wallet owns all coins and blockstakes of the devnet genesis block.
+// No blocks are created unless they are mined explicitly, such that tests are deterministic.
+package testnet
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/nbh-digital/goldchain/pkg/node"
+	"github.com/nbh-digital/goldchain/pkg/staking"
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/modules/wallet"
+	"github.com/threefoldtech/rivine/pkg/daemon"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// FoundationMnemonic is the mnemonic of the devnet wallet owning all coins and blockstakes of the genesis block,
+// which is also allowed to authorize addresses and mint coins.
+const FoundationMnemonic = "carbon boss inject cover mountain fetch fiber fit tornado cloth wing dinosaur proof joy intact fabric thumb rebel borrow poet chair network expire else"
+
+// DefaultConfig returns the configuration of the node of an in-process devnet,
+// running the gateway, consensus set, transaction pool and wallet in memory,
+// without connecting to other nodes.
+func DefaultConfig() node.Config {
+	cfg := node.DefaultConfig()
+	cfg.RPCaddr = "localhost:0"
+	cfg.NoBootstrap = true
+	cfg.Modules = daemon.ForceNewIdentifierSet(
+		daemon.GatewayModule.Identifier(),
+		daemon.ConsensusSetModule.Identifier(),
+		daemon.TransactionPoolModule.Identifier(),
+		daemon.WalletModule.Identifier(),
+	)
+	return cfg
+}
+
+// Network is a devnet running within the current process.
+// As it embeds a node, only one network can run within a single process at a time.
+type Network struct {
+	node  *node.Node
+	miner *staking.Miner
+	info  types.BlockchainInfo
+	dir   string
+
+	mu      sync.Mutex
+	wallets []*wallet.Wallet
+}
+
+// New starts a devnet using the given node configuration, which has to be an in-memory devnet node with a wallet,
+// of which the wallet is initialized using the FoundationMnemonic.
+// The genesis address of the foundation is authorized in the first block, such that the chain starts at height 1.
+// The network has to be closed in order to release its resources.
+func New(cfg node.Config) (*Network, error) {
+	if cfg.BlockchainInfo.NetworkName != config.NetworkNameDev {
+		return nil, fmt.Errorf("a test network has to run on the devnet, not on the %s", cfg.BlockchainInfo.NetworkName)
+	}
+	if !cfg.InMemory {
+		return nil, errors.New("a test network has to run in memory")
+	}
+	if !cfg.Modules.Contains(daemon.WalletModule.Identifier()) {
+		return nil, errors.New("a test network requires the wallet module")
+	}
+	// blocks are only created when mined explicitly
+	cfg.DisableBlockCreation = cfg.Modules.Contains(daemon.BlockCreatorModule.Identifier())
+
+	seed, err := modules.InitialSeedFromMnemonic(FoundationMnemonic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the foundation mnemonic: %v", err)
+	}
+	dir, err := ioutil.TempDir("", "goldchain-testnet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary wallet directory: %v", err)
+	}
+	n, err := node.New(cfg)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	network := &Network{
+		node:  n,
+		miner: staking.NewMiner(n.ConsensusSet(), n.Wallet(), n.TransactionPool(), n.Constants()),
+		info:  cfg.BlockchainInfo,
+		dir:   dir,
+	}
+	if _, err = n.Wallet().Init(seed); err != nil {
+		network.Close()
+		return nil, fmt.Errorf("failed to initialize the foundation wallet: %v", err)
+	}
+	if err = n.Start(); err != nil {
+		network.Close()
+		return nil, err
+	}
+	// the genesis address of the foundation receives the change of the coins it sends
+	if err = network.Authorize(foundationAddress()); err != nil {
+		network.Close()
+		return nil, fmt.Errorf("failed to authorize the foundation address: %v", err)
+	}
+	return network, nil
+}
+
+// Node returns the node of the network, which can be used to access its modules and HTTP handlers.
+func (n *Network) Node() *node.Node {
+	return n.node
+}
+
+// Foundation returns the wallet owning all coins and blockstakes of the genesis block,
+// which is used to mine blocks, fund wallets and authorize addresses.
+func (n *Network) Foundation() modules.Wallet {
+	return n.node.Wallet()
+}
+
+// Mine creates the given amount of blocks using the blockstakes of the foundation wallet,
+// confirming the transactions of the transaction pool, and returns their IDs.
+// As the devnet genesis block lies in the past, thousands of blocks can be mined at once.
+func (n *Network) Mine(count int) ([]types.BlockID, error) {
+	return n.miner.Mine(count)
+}
+
+// Reorg forces a reorganization of the given depth, replacing the last blocks by a competing chain of blocks
+// mined by the foundation wallet, one block longer, such that the transactions they confirmed are reverted.
+// The reverted transactions are dropped by the transaction pool, and have to be resubmitted.
+// The IDs of the blocks of the competing chain are returned.
+func (n *Network) Reorg(depth types.BlockHeight) ([]types.BlockID, error) {
+	return n.miner.Reorg(depth)
+}
+
+// Authorize authorizes the given addresses to send and receive coins,
+// using a transaction signed by the foundation wallet, which is confirmed by mining a block.
+func (n *Network) Authorize(addresses ...types.UnlockHash) error {
+	autx := authcointx.AuthAddressUpdateTransaction{
+		Nonce:         types.RandomTransactionNonce(),
+		AuthAddresses: addresses,
+	}
+	txn, err := n.Foundation().GreedySign(autx.Transaction(goldchaintypes.TransactionVersionAuthAddressUpdateTx))
+	if err != nil {
+		return fmt.Errorf("failed to sign the authorization transaction: %v", err)
+	}
+	return n.confirm(txn)
+}
+
+// Deauthorize deauthorizes the given addresses, such that they can no longer send and receive coins,
+// using a transaction signed by the foundation wallet, which is confirmed by mining a block.
+func (n *Network) Deauthorize(addresses ...types.UnlockHash) error {
+	autx := authcointx.AuthAddressUpdateTransaction{
+		Nonce:           types.RandomTransactionNonce(),
+		DeauthAddresses: addresses,
+	}
+	txn, err := n.Foundation().GreedySign(autx.Transaction(goldchaintypes.TransactionVersionAuthAddressUpdateTx))
+	if err != nil {
+		return fmt.Errorf("failed to sign the deauthorization transaction: %v", err)
+	}
+	return n.confirm(txn)
+}
+
+// Fund authorizes the given address and sends it the given amount of coins from the foundation wallet,
+// confirming both by mining blocks, such that the coins can be spent immediately.
+func (n *Network) Fund(address types.UnlockHash, amount types.Currency) (types.TransactionID, error) {
+	err := n.Authorize(address)
+	if err != nil {
+		return types.TransactionID{}, err
+	}
+	refund := foundationAddress()
+	txn, err := n.Foundation().SendOutputs([]types.CoinOutput{{
+		Value:     amount,
+		Condition: types.NewCondition(types.NewUnlockHashCondition(address)),
+	}}, nil, nil, &refund, true)
+	if err != nil {
+		return types.TransactionID{}, fmt.Errorf("failed to send coins to %s: %v", address.String(), err)
+	}
+	if _, err = n.Mine(1); err != nil {
+		return types.TransactionID{}, err
+	}
+	return txn.ID(), nil
+}
+
+// Wallet is a wallet created for a test network.
+type Wallet struct {
+	modules.Wallet
+	// Address is the authorized address of the wallet, which received its funds.
+	// As only authorized addresses can receive coins, it should be used as the refund address when sending coins.
+	Address types.UnlockHash
+}
+
+// NewWallet creates a new wallet with a random seed, tracking the chain of the network,
+// of which the first address is authorized and funded with the given amount of coins, unless it is zero.
+// The wallet is closed when the network is closed.
+func (n *Network) NewWallet(funds types.Currency) (Wallet, error) {
+	n.mu.Lock()
+	dir := filepath.Join(n.dir, strconv.Itoa(len(n.wallets)))
+	w, err := wallet.New(n.node.ConsensusSet(), n.node.TransactionPool(), dir,
+		n.info, n.node.Constants(), false)
+	if err == nil {
+		n.wallets = append(n.wallets, w)
+	}
+	n.mu.Unlock()
+	if err != nil {
+		return Wallet{}, fmt.Errorf("failed to create wallet: %v", err)
+	}
+	if _, err = w.Init(modules.Seed{}); err != nil {
+		return Wallet{}, fmt.Errorf("failed to initialize wallet: %v", err)
+	}
+	address, err := w.NextAddress()
+	if err != nil {
+		return Wallet{}, fmt.Errorf("failed to create wallet address: %v", err)
+	}
+	if funds.IsZero() {
+		err = n.Authorize(address)
+	} else {
+		_, err = n.Fund(address, funds)
+	}
+	if err != nil {
+		return Wallet{}, err
+	}
+	return Wallet{Wallet: w, Address: address}, nil
+}
+
+// Close closes all wallets created for the network, as well as its node,
+// returning the first error which occurred.
+func (n *Network) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var firstErr error
+	for _, w := range n.wallets {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	n.wallets = nil
+	if err := n.node.Close(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := os.RemoveAll(n.dir); err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("failed to remove temporary wallet directory: %v", err)
+	}
+	return firstErr
+}
+
+// confirm submits the given transaction to the transaction pool, and mines a block confirming it.
+func (n *Network) confirm(txn types.Transaction) error {
+	err := n.node.TransactionPool().AcceptTransactionSet([]types.Transaction{txn})
+	if err != nil {
+		return fmt.Errorf("failed to submit transaction %s: %v", txn.ID().String(), err)
+	}
+	_, err = n.Mine(1)
+	return err
+}
+
+// foundationAddress returns the devnet genesis address, owned by the foundation wallet.
+func foundationAddress() types.UnlockHash {
+	return config.GetDevnetGenesisAuthCoinCondition().UnlockHash()
+}
@@ -0,0 +1,80 @@
+package testnet
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestNetwork(t *testing.T) {
+	if testing.Short() {
+		// closing the gateway waits for its port forwarding attempt to time out
+		t.SkipNow()
+	}
+	network, err := New(DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer network.Close()
+	cs := network.Node().ConsensusSet()
+
+	ids, err := network.Mine(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first block authorizes the foundation address
+	if len(ids) != 3 || cs.Height() != 4 || cs.CurrentBlock().ID() != ids[2] {
+		t.Fatalf("expected 3 mined blocks on top of the first one, got height %d", cs.Height())
+	}
+
+	oneCoin := network.Node().Constants().CurrencyUnits.OneCoin
+	sender, err := network.NewWallet(oneCoin.Mul64(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance, _, err := sender.ConfirmedBalance(); err != nil || !balance.Equals(oneCoin.Mul64(100)) {
+		t.Fatalf("unexpected balance of the funded wallet: %v (%v)", balance.String(), err)
+	}
+
+	// funded wallets can send coins to the authorized addresses of other wallets
+	receiver, err := network.NewWallet(types.ZeroCurrency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sender.SendOutputs([]types.CoinOutput{{
+		Value:     oneCoin.Mul64(10),
+		Condition: types.NewCondition(types.NewUnlockHashCondition(receiver.Address)),
+	}}, nil, nil, &sender.Address, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = network.Mine(1); err != nil {
+		t.Fatal(err)
+	}
+	if balance, _, err := receiver.ConfirmedBalance(); err != nil || !balance.Equals(oneCoin.Mul64(10)) {
+		t.Fatalf("unexpected balance of the receiving wallet: %v (%v)", balance.String(), err)
+	}
+
+	// a reorganization reverts the transaction confirmed by the last block
+	height := cs.Height()
+	ids, err = network.Reorg(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 3 || cs.Height() != height+1 || cs.CurrentBlock().ID() != ids[2] {
+		t.Fatalf("expected a competing chain of 3 blocks up to height %d, got %d blocks up to height %d", height+1, len(ids), cs.Height())
+	}
+	if block, _ := cs.BlockAtHeight(height - 1); block.ID() != ids[0] {
+		t.Fatal("expected the competing chain to replace the last 2 blocks")
+	}
+	if balance, _, err := receiver.ConfirmedBalance(); err != nil || !balance.IsZero() {
+		t.Fatalf("expected the transaction to the receiving wallet to be reverted, got a balance of %v (%v)", balance.String(), err)
+	}
+	// the foundation wallet keeps creating blocks on top of the competing chain
+	if _, err = network.Mine(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = network.Reorg(0); err == nil {
+		t.Fatal("expected a reorganization of depth 0 to be refused")
+	}
+}
@@ -0,0 +1,301 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// SpecifierBlockStakeDelegationTransaction is the specifier used as part of
+	// the signature hash and ID of a blockstake delegation transaction.
+	SpecifierBlockStakeDelegationTransaction = types.Specifier{'b', 's', ' ', 'd', 'e', 'l', 'e', 'g', 'a', 't', 'i', 'o', 'n', ' ', 't', 'x'}
+)
+
+type (
+	// BlockStakeDelegationTransaction is a regular transaction, respending blockstake outputs unchanged,
+	// which delegates the right to create blocks using the blockstakes of the addresses owning these outputs
+	// to an operator, without transferring the ownership of the blockstakes.
+	// Delegating to the nil address revokes the delegation of these addresses.
+	//
+	// The operator can only respend the blockstake outputs of these addresses as part of the block creating transaction,
+	// which respends a blockstake output unchanged, such that the ownership of the blockstakes never changes.
+	BlockStakeDelegationTransaction struct {
+		CoinInputs        []types.CoinInput        `json:"coininputs"`
+		CoinOutputs       []types.CoinOutput       `json:"coinoutputs,omitempty"`
+		BlockStakeInputs  []types.BlockStakeInput  `json:"blockstakeinputs"`
+		BlockStakeOutputs []types.BlockStakeOutput `json:"blockstakeoutputs"`
+		MinerFees         []types.Currency         `json:"minerfees"`
+		ArbitraryData     []byte                   `json:"arbitrarydata,omitempty"`
+		// Operator is the address allowed to create blocks using the delegated blockstakes,
+		// the nil address revokes the delegation.
+		Operator types.UnlockHash `json:"operator"`
+	}
+
+	// BlockStakeDelegationTransactionExtension defines the BlockStakeDelegationTransaction Extension Data
+	BlockStakeDelegationTransactionExtension struct {
+		Operator types.UnlockHash
+	}
+)
+
+// BlockStakeDelegationTransactionFromTransaction creates a BlockStakeDelegationTransaction,
+// using a regular in-memory rivine transaction.
+func BlockStakeDelegationTransactionFromTransaction(tx types.Transaction, expectedVersion types.TransactionVersion) (BlockStakeDelegationTransaction, error) {
+	if tx.Version != expectedVersion {
+		return BlockStakeDelegationTransaction{}, fmt.Errorf(
+			"a blockstake delegation transaction requires tx version %d",
+			expectedVersion)
+	}
+	return BlockStakeDelegationTransactionFromTransactionData(types.TransactionData{
+		CoinInputs:        tx.CoinInputs,
+		CoinOutputs:       tx.CoinOutputs,
+		BlockStakeInputs:  tx.BlockStakeInputs,
+		BlockStakeOutputs: tx.BlockStakeOutputs,
+		MinerFees:         tx.MinerFees,
+		ArbitraryData:     tx.ArbitraryData,
+		Extension:         tx.Extension,
+	})
+}
+
+// BlockStakeDelegationTransactionFromTransactionData creates a BlockStakeDelegationTransaction,
+// using the TransactionData from a regular in-memory rivine transaction.
+func BlockStakeDelegationTransactionFromTransactionData(txData types.TransactionData) (BlockStakeDelegationTransaction, error) {
+	extensionData, ok := txData.Extension.(*BlockStakeDelegationTransactionExtension)
+	if !ok {
+		return BlockStakeDelegationTransaction{}, errors.New("invalid extension data for a BlockStakeDelegationTransaction")
+	}
+	return BlockStakeDelegationTransaction{
+		CoinInputs:        txData.CoinInputs,
+		CoinOutputs:       txData.CoinOutputs,
+		BlockStakeInputs:  txData.BlockStakeInputs,
+		BlockStakeOutputs: txData.BlockStakeOutputs,
+		MinerFees:         txData.MinerFees,
+		ArbitraryData:     txData.ArbitraryData,
+		Operator:          extensionData.Operator,
+	}, nil
+}
+
+// TransactionData returns this BlockStakeDelegationTransaction
+// as regular rivine transaction data.
+func (dtx *BlockStakeDelegationTransaction) TransactionData() types.TransactionData {
+	return types.TransactionData{
+		CoinInputs:        dtx.CoinInputs,
+		CoinOutputs:       dtx.CoinOutputs,
+		BlockStakeInputs:  dtx.BlockStakeInputs,
+		BlockStakeOutputs: dtx.BlockStakeOutputs,
+		MinerFees:         dtx.MinerFees,
+		ArbitraryData:     dtx.ArbitraryData,
+		Extension: &BlockStakeDelegationTransactionExtension{
+			Operator: dtx.Operator,
+		},
+	}
+}
+
+// Transaction returns this BlockStakeDelegationTransaction
+// as regular rivine transaction, using the given version as the type.
+func (dtx *BlockStakeDelegationTransaction) Transaction(version types.TransactionVersion) types.Transaction {
+	return types.Transaction{
+		Version:           version,
+		CoinInputs:        dtx.CoinInputs,
+		CoinOutputs:       dtx.CoinOutputs,
+		BlockStakeInputs:  dtx.BlockStakeInputs,
+		BlockStakeOutputs: dtx.BlockStakeOutputs,
+		MinerFees:         dtx.MinerFees,
+		ArbitraryData:     dtx.ArbitraryData,
+		Extension: &BlockStakeDelegationTransactionExtension{
+			Operator: dtx.Operator,
+		},
+	}
+}
+
+// DelegationOperator returns the operator the given transaction delegates to,
+// and true in case the transaction is a blockstake delegation transaction.
+func DelegationOperator(tx types.Transaction) (types.UnlockHash, bool) {
+	extensionData, ok := tx.Extension.(*BlockStakeDelegationTransactionExtension)
+	if !ok {
+		return types.UnlockHash{}, false
+	}
+	return extensionData.Operator, true
+}
+
+// DelegatingAddresses returns the addresses delegating their blockstakes using the given (valid) blockstake delegation transaction,
+// being the owners of the blockstake outputs it respends, in order of appearance.
+func DelegatingAddresses(tx types.Transaction) []types.UnlockHash {
+	return blockStakeOutputOwners(tx)
+}
+
+// blockStakeOutputOwners returns the unique owners of the blockstake outputs of the given transaction, in order of appearance.
+func blockStakeOutputOwners(tx types.Transaction) []types.UnlockHash {
+	var addresses []types.UnlockHash
+	seen := make(map[types.UnlockHash]struct{})
+	for _, bso := range tx.BlockStakeOutputs {
+		uh := bso.Condition.UnlockHash()
+		if _, ok := seen[uh]; ok {
+			continue
+		}
+		seen[uh] = struct{}{}
+		addresses = append(addresses, uh)
+	}
+	return addresses
+}
+
+// BlockStakeDelegationTransactionController defines a goldchain-specific transaction controller,
+// for a BlockStakeDelegationTransaction. It allows blockstake holders to delegate block creation to an operator.
+type BlockStakeDelegationTransactionController struct {
+	// TransactionVersion is used to validate/set the transaction version
+	// of a blockstake delegation transaction.
+	TransactionVersion types.TransactionVersion
+}
+
+// ensure at compile time that BlockStakeDelegationTransactionController
+// implements the desired interfaces
+var (
+	_ types.TransactionController      = BlockStakeDelegationTransactionController{}
+	_ types.TransactionSignatureHasher = BlockStakeDelegationTransactionController{}
+	_ types.TransactionIDEncoder       = BlockStakeDelegationTransactionController{}
+)
+
+// EncodeTransactionData implements TransactionController.EncodeTransactionData
+func (dtc BlockStakeDelegationTransactionController) EncodeTransactionData(w io.Writer, txData types.TransactionData) error {
+	dtx, err := BlockStakeDelegationTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to a BlockStakeDelegationTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).Encode(dtx)
+}
+
+// DecodeTransactionData implements TransactionController.DecodeTransactionData
+func (dtc BlockStakeDelegationTransactionController) DecodeTransactionData(r io.Reader) (types.TransactionData, error) {
+	var dtx BlockStakeDelegationTransaction
+	err := rivbin.NewDecoder(r).Decode(&dtx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to binary-decode tx as a BlockStakeDelegationTx: %v", err)
+	}
+	// return blockstake delegation tx as regular rivine tx data
+	return dtx.TransactionData(), nil
+}
+
+// JSONEncodeTransactionData implements TransactionController.JSONEncodeTransactionData
+func (dtc BlockStakeDelegationTransactionController) JSONEncodeTransactionData(txData types.TransactionData) ([]byte, error) {
+	dtx, err := BlockStakeDelegationTransactionFromTransactionData(txData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert txData to a BlockStakeDelegationTx: %v", err)
+	}
+	return json.Marshal(dtx)
+}
+
+// JSONDecodeTransactionData implements TransactionController.JSONDecodeTransactionData
+func (dtc BlockStakeDelegationTransactionController) JSONDecodeTransactionData(data []byte) (types.TransactionData, error) {
+	var dtx BlockStakeDelegationTransaction
+	err := json.Unmarshal(data, &dtx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to json-decode tx as a BlockStakeDelegationTx: %v", err)
+	}
+	// return blockstake delegation tx as regular rivine tx data
+	return dtx.TransactionData(), nil
+}
+
+// SignatureHash implements TransactionSignatureHasher.SignatureHash
+func (dtc BlockStakeDelegationTransactionController) SignatureHash(t types.Transaction, extraObjects ...interface{}) (crypto.Hash, error) {
+	dtx, err := BlockStakeDelegationTransactionFromTransaction(t, dtc.TransactionVersion)
+	if err != nil {
+		return crypto.Hash{}, fmt.Errorf("failed to use tx as a blockstake delegation tx: %v", err)
+	}
+
+	h := crypto.NewHash()
+	enc := rivbin.NewEncoder(h)
+
+	enc.EncodeAll(
+		t.Version,
+		SpecifierBlockStakeDelegationTransaction,
+	)
+
+	if len(extraObjects) > 0 {
+		enc.EncodeAll(extraObjects...)
+	}
+
+	coinParentIDSlice := make([]types.CoinOutputID, 0, len(dtx.CoinInputs))
+	for _, ci := range dtx.CoinInputs {
+		coinParentIDSlice = append(coinParentIDSlice, ci.ParentID)
+	}
+	blockStakeParentIDSlice := make([]types.BlockStakeOutputID, 0, len(dtx.BlockStakeInputs))
+	for _, bsi := range dtx.BlockStakeInputs {
+		blockStakeParentIDSlice = append(blockStakeParentIDSlice, bsi.ParentID)
+	}
+
+	enc.EncodeAll(
+		coinParentIDSlice,
+		dtx.CoinOutputs,
+		blockStakeParentIDSlice,
+		dtx.BlockStakeOutputs,
+		dtx.MinerFees,
+		dtx.ArbitraryData,
+		dtx.Operator,
+	)
+
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	return hash, nil
+}
+
+// EncodeTransactionIDInput implements TransactionIDEncoder.EncodeTransactionIDInput
+func (dtc BlockStakeDelegationTransactionController) EncodeTransactionIDInput(w io.Writer, txData types.TransactionData) error {
+	dtx, err := BlockStakeDelegationTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to a BlockStakeDelegationTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).EncodeAll(SpecifierBlockStakeDelegationTransaction, dtx)
+}
+
+// ValidateBlockStakeDelegation is a validator function that checks that a blockstake delegation transaction
+// respends at least one blockstake output, respending each blockstake output unchanged (in the same order),
+// and delegates to a single signature address other than the delegating addresses, or to the nil address.
+func ValidateBlockStakeDelegation(tx types.Transaction, ctx types.TransactionValidationContext, css modules.ConsensusStateGetter) error {
+	operator, ok := DelegationOperator(tx)
+	if !ok {
+		return errors.New("blockstake delegation transaction has no operator defined")
+	}
+	if operator.Type != types.UnlockTypePubKey && operator.Type != types.UnlockTypeNil {
+		return types.NewClientError(fmt.Errorf("blockstakes can only be delegated to a single signature address, not to %s",
+			operator.String()), types.ClientErrorBadRequest)
+	}
+	if len(tx.BlockStakeInputs) == 0 {
+		return types.NewClientError(errors.New("a blockstake delegation has to respend a blockstake output of each delegating address"),
+			types.ClientErrorBadRequest)
+	}
+	if len(tx.BlockStakeOutputs) != len(tx.BlockStakeInputs) {
+		return types.NewClientError(errors.New("a blockstake delegation has to respend all its blockstake inputs unchanged"),
+			types.ClientErrorBadRequest)
+	}
+	for index, bsi := range tx.BlockStakeInputs {
+		bso, err := css.UnspentBlockStakeOutputGet(bsi.ParentID)
+		if err != nil {
+			return fmt.Errorf(
+				"unable to find parent ID %s as an unspent block stake output in the current consensus state at block height %d",
+				bsi.ParentID.String(), ctx.BlockHeight)
+		}
+		if !SameBlockStakeOutput(bso, tx.BlockStakeOutputs[index]) {
+			return types.NewClientError(fmt.Errorf("blockstake output #%d does not respend blockstake input #%d unchanged", index, index),
+				types.ClientErrorBadRequest)
+		}
+		if bso.Condition.UnlockHash() == operator {
+			return types.NewClientError(fmt.Errorf("address %s cannot delegate its blockstakes to itself", operator.String()),
+				types.ClientErrorBadRequest)
+		}
+	}
+	return nil
+}
+
+// SameBlockStakeOutput returns true in case both blockstake outputs have the same value and condition.
+func SameBlockStakeOutput(a, b types.BlockStakeOutput) bool {
+	return a.Value.Equals(b.Value) && bytes.Equal(rivbin.Marshal(a.Condition), rivbin.Marshal(b.Condition))
+}
@@ -0,0 +1,81 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testConsensusState struct {
+	modules.ConsensusStateGetter
+	outputs map[types.BlockStakeOutputID]types.BlockStakeOutput
+}
+
+func (css testConsensusState) UnspentBlockStakeOutputGet(id types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	bso, ok := css.outputs[id]
+	if !ok {
+		return types.BlockStakeOutput{}, errors.New("unknown blockstake output")
+	}
+	return bso, nil
+}
+
+func TestValidateBlockStakeDelegation(t *testing.T) {
+	delegator := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	operator := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	output := types.BlockStakeOutput{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(delegator))}
+	css := testConsensusState{outputs: map[types.BlockStakeOutputID]types.BlockStakeOutput{{1}: output}}
+	controller := BlockStakeDelegationTransactionController{TransactionVersion: TransactionVersionBlockStakeDelegation}
+
+	delegation := func(operator types.UnlockHash, outputs ...types.BlockStakeOutput) types.Transaction {
+		dtx := BlockStakeDelegationTransaction{
+			BlockStakeInputs:  []types.BlockStakeInput{{ParentID: types.BlockStakeOutputID{1}}},
+			BlockStakeOutputs: outputs,
+			MinerFees:         []types.Currency{types.NewCurrency64(1)},
+			Operator:          operator,
+		}
+		return dtx.Transaction(TransactionVersionBlockStakeDelegation)
+	}
+	txn := delegation(operator, output)
+	if err := ValidateBlockStakeDelegation(txn, types.TransactionValidationContext{}, css); err != nil {
+		t.Errorf("expected delegation to be valid: %v", err)
+	}
+	if err := ValidateBlockStakeDelegation(delegation(types.NilUnlockHash, output), types.TransactionValidationContext{}, css); err != nil {
+		t.Errorf("expected revocation to be valid: %v", err)
+	}
+
+	// the operator is part of the encoding and signature hash
+	var buf bytes.Buffer
+	if err := controller.EncodeTransactionData(&buf, types.TransactionData{Extension: txn.Extension}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := controller.DecodeTransactionData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedOperator, ok := DelegationOperator(types.Transaction{Extension: decoded.Extension}); !ok || decodedOperator != operator {
+		t.Errorf("unexpected operator after decoding: %v", decodedOperator)
+	}
+	hash, err := controller.SignatureHash(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherHash, _ := controller.SignatureHash(delegation(types.NilUnlockHash, output)); otherHash == hash {
+		t.Error("expected the signature hash to depend on the operator")
+	}
+
+	for name, txn := range map[string]types.Transaction{
+		"changed value":        delegation(operator, types.BlockStakeOutput{Value: types.NewCurrency64(9), Condition: output.Condition}),
+		"changed condition":    delegation(operator, types.BlockStakeOutput{Value: output.Value, Condition: types.NewCondition(types.NewUnlockHashCondition(operator))}),
+		"missing output":       delegation(operator),
+		"delegating to itself": delegation(delegator, output),
+		"multisig operator":    delegation(types.NewUnlockHash(types.UnlockTypeMultiSig, crypto.Hash{3}), output),
+	} {
+		if err := ValidateBlockStakeDelegation(txn, types.TransactionValidationContext{}, css); err == nil {
+			t.Errorf("%s: expected delegation to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Condition descriptors are a textual representation of unlock conditions,
+// allowing (nested) conditions to be stored and communicated unambiguously:
+//
+//	nil()
+//	addr(<unlockhash>)
+//	multi(<minimumsignaturecount>,<unlockhash>,<unlockhash>...)
+//	timelock(<locktime>,nil()|addr(...)|multi(...))
+//	htlc(<sender>,<receiver>,<hashedsecret>,<timelock>)
+//
+// A lock time is a block height when lower than 500 000 000, a unix epoch timestamp (in seconds) otherwise.
+// The timelock of an htlc (atomic swap) condition is always a unix epoch timestamp (in seconds).
+const (
+	DescriptorNil        = "nil"
+	DescriptorAddress    = "addr"
+	DescriptorMultiSig   = "multi"
+	DescriptorTimeLock   = "timelock"
+	DescriptorAtomicSwap = "htlc"
+)
+
+// ConditionDescriptor returns the descriptor of the given unlock condition.
+func ConditionDescriptor(condition types.UnlockCondition) (string, error) {
+	switch c := condition.(type) {
+	case nil:
+		return DescriptorNil + "()", nil
+	case types.UnlockConditionProxy:
+		return ConditionDescriptor(c.Condition)
+	case *types.UnlockConditionProxy:
+		return ConditionDescriptor(c.Condition)
+	case *types.NilCondition:
+		return DescriptorNil + "()", nil
+	case *types.UnlockHashCondition:
+		return fmt.Sprintf("%s(%s)", DescriptorAddress, c.TargetUnlockHash.String()), nil
+	case *types.MultiSignatureCondition:
+		args := make([]string, 0, len(c.UnlockHashes)+1)
+		args = append(args, strconv.FormatUint(c.MinimumSignatureCount, 10))
+		for _, uh := range c.UnlockHashes {
+			args = append(args, uh.String())
+		}
+		return fmt.Sprintf("%s(%s)", DescriptorMultiSig, strings.Join(args, ",")), nil
+	case *types.TimeLockCondition:
+		inner, err := ConditionDescriptor(c.Condition)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s(%d,%s)", DescriptorTimeLock, c.LockTime, inner), nil
+	case *types.AtomicSwapCondition:
+		return fmt.Sprintf("%s(%s,%s,%s,%d)", DescriptorAtomicSwap,
+			c.Sender.String(), c.Receiver.String(), c.HashedSecret.String(), c.TimeLock), nil
+	default:
+		return "", fmt.Errorf("condition type %d has no descriptor", condition.ConditionType())
+	}
+}
+
+// ParseConditionDescriptor parses the given descriptor as an unlock condition.
+func ParseConditionDescriptor(descriptor string) (types.UnlockConditionProxy, error) {
+	p := descriptorParser{str: descriptor}
+	node, err := p.parseNode()
+	if err != nil {
+		return types.UnlockConditionProxy{}, err
+	}
+	p.skipSpaces()
+	if p.pos != len(p.str) {
+		return types.UnlockConditionProxy{}, p.errorf("unexpected %q", p.str[p.pos:])
+	}
+	condition, err := node.condition()
+	if err != nil {
+		return types.UnlockConditionProxy{}, err
+	}
+	return types.NewCondition(condition), nil
+}
+
+// descriptorNode is either a literal argument,
+// or a condition with its (literal or condition) arguments.
+type descriptorNode struct {
+	name    string
+	literal bool
+	args    []descriptorNode
+}
+
+func (n descriptorNode) condition() (types.MarshalableUnlockCondition, error) {
+	if n.literal {
+		return nil, fmt.Errorf("expected a condition, got literal %q", n.name)
+	}
+	switch n.name {
+	case DescriptorNil:
+		if err := n.expectArgs(0); err != nil {
+			return nil, err
+		}
+		return &types.NilCondition{}, nil
+
+	case DescriptorAddress:
+		if err := n.expectArgs(1); err != nil {
+			return nil, err
+		}
+		uh, err := n.unlockHashArg(0)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewUnlockHashCondition(uh), nil
+
+	case DescriptorMultiSig:
+		if len(n.args) < 2 {
+			return nil, fmt.Errorf("%s requires a minimum signature count and at least one unlock hash", n.name)
+		}
+		count, err := n.uint64Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		uhs := make(types.UnlockHashSlice, 0, len(n.args)-1)
+		for i := 1; i < len(n.args); i++ {
+			uh, err := n.unlockHashArg(i)
+			if err != nil {
+				return nil, err
+			}
+			uhs = append(uhs, uh)
+		}
+		if count == 0 || count > uint64(len(uhs)) {
+			return nil, fmt.Errorf("%s requires a minimum signature count between 1 and %d, got %d", n.name, len(uhs), count)
+		}
+		return types.NewMultiSignatureCondition(uhs, count), nil
+
+	case DescriptorTimeLock:
+		if err := n.expectArgs(2); err != nil {
+			return nil, err
+		}
+		lockTime, err := n.uint64Arg(0)
+		if err != nil {
+			return nil, err
+		}
+		if lockTime == 0 {
+			return nil, fmt.Errorf("%s requires a non-zero lock time", n.name)
+		}
+		inner, err := n.args[1].condition()
+		if err != nil {
+			return nil, err
+		}
+		switch inner.ConditionType() {
+		case types.ConditionTypeNil, types.ConditionTypeUnlockHash, types.ConditionTypeMultiSignature:
+		default:
+			return nil, fmt.Errorf("%s only supports %s, %s and %s conditions", n.name, DescriptorNil, DescriptorAddress, DescriptorMultiSig)
+		}
+		return types.NewTimeLockCondition(lockTime, inner), nil
+
+	case DescriptorAtomicSwap:
+		if err := n.expectArgs(4); err != nil {
+			return nil, err
+		}
+		sender, err := n.unlockHashArg(0)
+		if err != nil {
+			return nil, err
+		}
+		receiver, err := n.unlockHashArg(1)
+		if err != nil {
+			return nil, err
+		}
+		var hs types.AtomicSwapHashedSecret
+		if !n.args[2].literal {
+			return nil, fmt.Errorf("%s expects a hashed secret as argument #3", n.name)
+		}
+		if err = hs.LoadString(n.args[2].name); err != nil {
+			return nil, fmt.Errorf("invalid hashed secret %q: %v", n.args[2].name, err)
+		}
+		timeLock, err := n.uint64Arg(3)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AtomicSwapCondition{
+			Sender:       sender,
+			Receiver:     receiver,
+			HashedSecret: hs,
+			TimeLock:     types.Timestamp(timeLock),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown condition %q", n.name)
+	}
+}
+
+func (n descriptorNode) expectArgs(count int) error {
+	if len(n.args) != count {
+		return fmt.Errorf("%s requires %d argument(s), got %d", n.name, count, len(n.args))
+	}
+	return nil
+}
+
+func (n descriptorNode) unlockHashArg(index int) (types.UnlockHash, error) {
+	arg := n.args[index]
+	if !arg.literal {
+		return types.UnlockHash{}, fmt.Errorf("%s expects an unlock hash as argument #%d", n.name, index+1)
+	}
+	var uh types.UnlockHash
+	if err := uh.LoadString(arg.name); err != nil {
+		return types.UnlockHash{}, fmt.Errorf("invalid unlock hash %q: %v", arg.name, err)
+	}
+	return uh, nil
+}
+
+func (n descriptorNode) uint64Arg(index int) (uint64, error) {
+	arg := n.args[index]
+	if !arg.literal {
+		return 0, fmt.Errorf("%s expects a number as argument #%d", n.name, index+1)
+	}
+	x, err := strconv.ParseUint(arg.name, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q: %v", arg.name, err)
+	}
+	return x, nil
+}
+
+// descriptorParser parses a descriptor string into a tree of nodes.
+type descriptorParser struct {
+	str string
+	pos int
+}
+
+// parseNode parses a literal or a condition with its arguments.
+func (p *descriptorParser) parseNode() (descriptorNode, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.str) && !strings.ContainsRune("(),", rune(p.str[p.pos])) && p.str[p.pos] != ' ' {
+		p.pos++
+	}
+	if p.pos == start {
+		return descriptorNode{}, p.errorf("expected a condition or argument")
+	}
+	node := descriptorNode{name: p.str[start:p.pos]}
+	p.skipSpaces()
+	if p.pos >= len(p.str) || p.str[p.pos] != '(' {
+		node.literal = true
+		return node, nil
+	}
+	p.pos++ // consume '('
+	p.skipSpaces()
+	if p.pos < len(p.str) && p.str[p.pos] == ')' {
+		p.pos++
+		return node, nil
+	}
+	for {
+		arg, err := p.parseNode()
+		if err != nil {
+			return descriptorNode{}, err
+		}
+		node.args = append(node.args, arg)
+		p.skipSpaces()
+		if p.pos >= len(p.str) {
+			return descriptorNode{}, errors.New("unexpected end of descriptor, missing ')'")
+		}
+		switch p.str[p.pos] {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return node, nil
+		default:
+			return descriptorNode{}, p.errorf("expected ',' or ')'")
+		}
+	}
+}
+
+func (p *descriptorParser) skipSpaces() {
+	for p.pos < len(p.str) && p.str[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *descriptorParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid descriptor at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
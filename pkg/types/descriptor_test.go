@@ -0,0 +1,64 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestConditionDescriptorRoundTrip(t *testing.T) {
+	uh1 := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	uh2 := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	conditions := []types.MarshalableUnlockCondition{
+		&types.NilCondition{},
+		types.NewUnlockHashCondition(uh1),
+		types.NewMultiSignatureCondition(types.UnlockHashSlice{uh1, uh2}, 1),
+		types.NewTimeLockCondition(42, types.NewUnlockHashCondition(uh1)),
+		types.NewTimeLockCondition(types.LockTimeMinTimestampValue, types.NewMultiSignatureCondition(types.UnlockHashSlice{uh1, uh2}, 2)),
+		&types.AtomicSwapCondition{
+			Sender:       uh1,
+			Receiver:     uh2,
+			HashedSecret: types.AtomicSwapHashedSecret{3},
+			TimeLock:     1564142400,
+		},
+	}
+	for _, condition := range conditions {
+		descriptor, err := ConditionDescriptor(condition)
+		if err != nil {
+			t.Errorf("failed to describe condition type %d: %v", condition.ConditionType(), err)
+			continue
+		}
+		parsed, err := ParseConditionDescriptor(descriptor)
+		if err != nil {
+			t.Errorf("failed to parse descriptor %q: %v", descriptor, err)
+			continue
+		}
+		if !parsed.Equal(types.NewCondition(condition)) {
+			t.Errorf("descriptor %q did not round-trip", descriptor)
+		}
+	}
+}
+
+func TestParseConditionDescriptorErrors(t *testing.T) {
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1}).String()
+	descriptors := []string{
+		"",
+		"nil",
+		"nil(",
+		"nil() nil()",
+		"foo()",
+		"addr()",
+		"addr(abc)",
+		"multi(0," + uh + ")",
+		"multi(2," + uh + ")",
+		"timelock(0,nil())",
+		"timelock(42," + uh + ")",
+		"timelock(42,timelock(42,nil()))",
+	}
+	for _, descriptor := range descriptors {
+		if _, err := ParseConditionDescriptor(descriptor); err == nil {
+			t.Errorf("expected descriptor %q to be invalid", descriptor)
+		}
+	}
+}
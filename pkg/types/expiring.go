@@ -0,0 +1,260 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Goldchain Transaction Versions
+const (
+	// TransactionVersionExpiring is the transaction version for the expiring transaction,
+	// a regular transaction which can only be included in a block up to a given block height.
+	TransactionVersionExpiring types.TransactionVersion = iota + 192
+	// TransactionVersionBlockStakeDelegation is the transaction version for the blockstake delegation transaction,
+	// a transaction delegating the right to create blocks using the blockstakes of an address to an operator.
+	TransactionVersionBlockStakeDelegation
+	// TransactionVersionVote is the transaction version for the vote transaction,
+	// a transaction casting the vote of blockstake holders on a governance proposal.
+	TransactionVersionVote
+)
+
+var (
+	// SpecifierExpiringTransaction is the specifier used as part of
+	// the signature hash and ID of an expiring transaction.
+	SpecifierExpiringTransaction = types.Specifier{'e', 'x', 'p', 'i', 'r', 'i', 'n', 'g', ' ', 't', 'x'}
+)
+
+// ErrTransactionExpired is returned by the expiring transaction validator,
+// for an expiring transaction validated past its expiration height.
+var ErrTransactionExpired = errors.New("transaction has expired")
+
+type (
+	// ExpiringTransaction is a regular transaction,
+	// which becomes permanently invalid once the blockchain grows past its expiration height.
+	ExpiringTransaction struct {
+		CoinInputs        []types.CoinInput        `json:"coininputs"`
+		CoinOutputs       []types.CoinOutput       `json:"coinoutputs,omitempty"`
+		BlockStakeInputs  []types.BlockStakeInput  `json:"blockstakeinputs,omitempty"`
+		BlockStakeOutputs []types.BlockStakeOutput `json:"blockstakeoutputs,omitempty"`
+		MinerFees         []types.Currency         `json:"minerfees"`
+		ArbitraryData     []byte                   `json:"arbitrarydata,omitempty"`
+		// ExpirationHeight is the height of the last block this transaction can be part of.
+		ExpirationHeight types.BlockHeight `json:"expirationheight"`
+	}
+
+	// ExpiringTransactionExtension defines the ExpiringTransaction Extension Data
+	ExpiringTransactionExtension struct {
+		ExpirationHeight types.BlockHeight
+	}
+)
+
+// ExpiringTransactionFromTransaction creates an ExpiringTransaction,
+// using a regular in-memory rivine transaction.
+func ExpiringTransactionFromTransaction(tx types.Transaction, expectedVersion types.TransactionVersion) (ExpiringTransaction, error) {
+	if tx.Version != expectedVersion {
+		return ExpiringTransaction{}, fmt.Errorf(
+			"an expiring transaction requires tx version %d",
+			expectedVersion)
+	}
+	return ExpiringTransactionFromTransactionData(types.TransactionData{
+		CoinInputs:        tx.CoinInputs,
+		CoinOutputs:       tx.CoinOutputs,
+		BlockStakeInputs:  tx.BlockStakeInputs,
+		BlockStakeOutputs: tx.BlockStakeOutputs,
+		MinerFees:         tx.MinerFees,
+		ArbitraryData:     tx.ArbitraryData,
+		Extension:         tx.Extension,
+	})
+}
+
+// ExpiringTransactionFromTransactionData creates an ExpiringTransaction,
+// using the TransactionData from a regular in-memory rivine transaction.
+func ExpiringTransactionFromTransactionData(txData types.TransactionData) (ExpiringTransaction, error) {
+	extensionData, ok := txData.Extension.(*ExpiringTransactionExtension)
+	if !ok {
+		return ExpiringTransaction{}, errors.New("invalid extension data for an ExpiringTransaction")
+	}
+	return ExpiringTransaction{
+		CoinInputs:        txData.CoinInputs,
+		CoinOutputs:       txData.CoinOutputs,
+		BlockStakeInputs:  txData.BlockStakeInputs,
+		BlockStakeOutputs: txData.BlockStakeOutputs,
+		MinerFees:         txData.MinerFees,
+		ArbitraryData:     txData.ArbitraryData,
+		ExpirationHeight:  extensionData.ExpirationHeight,
+	}, nil
+}
+
+// TransactionData returns this ExpiringTransaction
+// as regular rivine transaction data.
+func (etx *ExpiringTransaction) TransactionData() types.TransactionData {
+	return types.TransactionData{
+		CoinInputs:        etx.CoinInputs,
+		CoinOutputs:       etx.CoinOutputs,
+		BlockStakeInputs:  etx.BlockStakeInputs,
+		BlockStakeOutputs: etx.BlockStakeOutputs,
+		MinerFees:         etx.MinerFees,
+		ArbitraryData:     etx.ArbitraryData,
+		Extension: &ExpiringTransactionExtension{
+			ExpirationHeight: etx.ExpirationHeight,
+		},
+	}
+}
+
+// Transaction returns this ExpiringTransaction
+// as regular rivine transaction, using the given version as the type.
+func (etx *ExpiringTransaction) Transaction(version types.TransactionVersion) types.Transaction {
+	return types.Transaction{
+		Version:           version,
+		CoinInputs:        etx.CoinInputs,
+		CoinOutputs:       etx.CoinOutputs,
+		BlockStakeInputs:  etx.BlockStakeInputs,
+		BlockStakeOutputs: etx.BlockStakeOutputs,
+		MinerFees:         etx.MinerFees,
+		ArbitraryData:     etx.ArbitraryData,
+		Extension: &ExpiringTransactionExtension{
+			ExpirationHeight: etx.ExpirationHeight,
+		},
+	}
+}
+
+// ExpirationHeight returns the expiration height of the given transaction,
+// and true in case the transaction is an expiring transaction.
+func ExpirationHeight(tx types.Transaction) (types.BlockHeight, bool) {
+	extensionData, ok := tx.Extension.(*ExpiringTransactionExtension)
+	if !ok {
+		return 0, false
+	}
+	return extensionData.ExpirationHeight, true
+}
+
+// ExpiringTransactionController defines a goldchain-specific transaction controller,
+// for an ExpiringTransaction. It allows the creation of transactions valid up to a given block height.
+type ExpiringTransactionController struct {
+	// TransactionVersion is used to validate/set the transaction version
+	// of an expiring transaction.
+	TransactionVersion types.TransactionVersion
+}
+
+// ensure at compile time that ExpiringTransactionController
+// implements the desired interfaces
+var (
+	_ types.TransactionController      = ExpiringTransactionController{}
+	_ types.TransactionSignatureHasher = ExpiringTransactionController{}
+	_ types.TransactionIDEncoder       = ExpiringTransactionController{}
+)
+
+// EncodeTransactionData implements TransactionController.EncodeTransactionData
+func (etc ExpiringTransactionController) EncodeTransactionData(w io.Writer, txData types.TransactionData) error {
+	etx, err := ExpiringTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to an ExpiringTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).Encode(etx)
+}
+
+// DecodeTransactionData implements TransactionController.DecodeTransactionData
+func (etc ExpiringTransactionController) DecodeTransactionData(r io.Reader) (types.TransactionData, error) {
+	var etx ExpiringTransaction
+	err := rivbin.NewDecoder(r).Decode(&etx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to binary-decode tx as an ExpiringTx: %v", err)
+	}
+	// return expiring tx as regular rivine tx data
+	return etx.TransactionData(), nil
+}
+
+// JSONEncodeTransactionData implements TransactionController.JSONEncodeTransactionData
+func (etc ExpiringTransactionController) JSONEncodeTransactionData(txData types.TransactionData) ([]byte, error) {
+	etx, err := ExpiringTransactionFromTransactionData(txData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert txData to an ExpiringTx: %v", err)
+	}
+	return json.Marshal(etx)
+}
+
+// JSONDecodeTransactionData implements TransactionController.JSONDecodeTransactionData
+func (etc ExpiringTransactionController) JSONDecodeTransactionData(data []byte) (types.TransactionData, error) {
+	var etx ExpiringTransaction
+	err := json.Unmarshal(data, &etx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to json-decode tx as an ExpiringTx: %v", err)
+	}
+	// return expiring tx as regular rivine tx data
+	return etx.TransactionData(), nil
+}
+
+// SignatureHash implements TransactionSignatureHasher.SignatureHash
+func (etc ExpiringTransactionController) SignatureHash(t types.Transaction, extraObjects ...interface{}) (crypto.Hash, error) {
+	etx, err := ExpiringTransactionFromTransaction(t, etc.TransactionVersion)
+	if err != nil {
+		return crypto.Hash{}, fmt.Errorf("failed to use tx as an expiring tx: %v", err)
+	}
+
+	h := crypto.NewHash()
+	enc := rivbin.NewEncoder(h)
+
+	enc.EncodeAll(
+		t.Version,
+		SpecifierExpiringTransaction,
+	)
+
+	if len(extraObjects) > 0 {
+		enc.EncodeAll(extraObjects...)
+	}
+
+	coinParentIDSlice := make([]types.CoinOutputID, 0, len(etx.CoinInputs))
+	for _, ci := range etx.CoinInputs {
+		coinParentIDSlice = append(coinParentIDSlice, ci.ParentID)
+	}
+	blockStakeParentIDSlice := make([]types.BlockStakeOutputID, 0, len(etx.BlockStakeInputs))
+	for _, bsi := range etx.BlockStakeInputs {
+		blockStakeParentIDSlice = append(blockStakeParentIDSlice, bsi.ParentID)
+	}
+
+	enc.EncodeAll(
+		coinParentIDSlice,
+		etx.CoinOutputs,
+		blockStakeParentIDSlice,
+		etx.BlockStakeOutputs,
+		etx.MinerFees,
+		etx.ArbitraryData,
+		etx.ExpirationHeight,
+	)
+
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	return hash, nil
+}
+
+// EncodeTransactionIDInput implements TransactionIDEncoder.EncodeTransactionIDInput
+func (etc ExpiringTransactionController) EncodeTransactionIDInput(w io.Writer, txData types.TransactionData) error {
+	etx, err := ExpiringTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to an ExpiringTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).EncodeAll(SpecifierExpiringTransaction, etx)
+}
+
+// ValidateTransactionNotExpired is a validator function that checks
+// that an expiring transaction is validated at or below its expiration height.
+func ValidateTransactionNotExpired(tx types.Transaction, ctx types.TransactionValidationContext, _ modules.ConsensusStateGetter) error {
+	expirationHeight, ok := ExpirationHeight(tx)
+	if !ok {
+		return errors.New("expiring transaction has no expiration height defined")
+	}
+	if ctx.BlockHeight > expirationHeight {
+		return types.NewClientError(fmt.Errorf("%v: expired at height %d, current height is %d",
+			ErrTransactionExpired, expirationHeight, ctx.BlockHeight), types.ClientErrorBadRequest)
+	}
+	return nil
+}
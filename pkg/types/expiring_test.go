@@ -0,0 +1,100 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestExpiringTransaction(t *testing.T) {
+	controller := ExpiringTransactionController{TransactionVersion: TransactionVersionExpiring}
+	owner := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	_, pk := crypto.GenerateKeyPair()
+	fulfillment := types.NewFulfillment(types.NewSingleSignatureFulfillment(types.Ed25519PublicKey(pk)))
+	expiring := func(expirationHeight types.BlockHeight) types.Transaction {
+		etx := ExpiringTransaction{
+			CoinInputs:       []types.CoinInput{{ParentID: types.CoinOutputID{1}, Fulfillment: fulfillment}},
+			CoinOutputs:      []types.CoinOutput{{Value: types.NewCurrency64(9), Condition: types.NewCondition(types.NewUnlockHashCondition(owner))}},
+			MinerFees:        []types.Currency{types.NewCurrency64(1)},
+			ArbitraryData:    []byte("expiring"),
+			ExpirationHeight: expirationHeight,
+		}
+		return etx.Transaction(TransactionVersionExpiring)
+	}
+	txn := expiring(100)
+	txData := types.TransactionData{
+		CoinInputs:    txn.CoinInputs,
+		CoinOutputs:   txn.CoinOutputs,
+		MinerFees:     txn.MinerFees,
+		ArbitraryData: txn.ArbitraryData,
+		Extension:     txn.Extension,
+	}
+
+	// the expiration height survives a binary and JSON round trip
+	var buf bytes.Buffer
+	if err := controller.EncodeTransactionData(&buf, txData); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := controller.DecodeTransactionData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, txData) {
+		t.Errorf("unexpected transaction data after binary decoding: %+v", decoded)
+	}
+	b, err := controller.JSONEncodeTransactionData(txData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"expirationheight":100`)) {
+		t.Errorf("expected the expiration height to be encoded: %s", b)
+	}
+	decoded, err = controller.JSONDecodeTransactionData(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height, ok := ExpirationHeight(types.Transaction{Extension: decoded.Extension}); !ok || height != 100 {
+		t.Errorf("unexpected expiration height after JSON decoding: %d", height)
+	}
+	var etx ExpiringTransaction
+	if err := json.Unmarshal(b, &etx); err != nil || etx.ExpirationHeight != 100 || len(etx.CoinOutputs) != 1 {
+		t.Errorf("unexpected expiring transaction after JSON decoding: %+v (%v)", etx, err)
+	}
+	if err := controller.EncodeTransactionData(&buf, types.TransactionData{}); err == nil {
+		t.Error("expected transaction data without an expiration height to be refused")
+	}
+
+	// the expiration height is part of the signature hash
+	hash, err := controller.SignatureHash(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherHash, _ := controller.SignatureHash(expiring(101)); otherHash == hash {
+		t.Error("expected the signature hash to depend on the expiration height")
+	}
+	if extraHash, _ := controller.SignatureHash(txn, uint64(0)); extraHash == hash {
+		t.Error("expected the signature hash to depend on the extra objects")
+	}
+	if _, err := controller.SignatureHash(types.Transaction{Version: types.TransactionVersionOne}); err == nil {
+		t.Error("expected the signature hash of a regular transaction to be refused")
+	}
+
+	// the transaction is valid up to (and including) its expiration height
+	validate := func(txn types.Transaction, height types.BlockHeight) error {
+		return ValidateTransactionNotExpired(txn, types.TransactionValidationContext{ValidationContext: types.ValidationContext{BlockHeight: height}}, nil)
+	}
+	if err := validate(txn, 100); err != nil {
+		t.Errorf("expected the transaction to be valid at its expiration height: %v", err)
+	}
+	if err := validate(txn, 101); err == nil || !strings.Contains(err.Error(), ErrTransactionExpired.Error()) {
+		t.Errorf("expected the transaction to be refused after its expiration height, got: %v", err)
+	}
+	if err := validate(types.Transaction{Version: TransactionVersionExpiring}, 1); err == nil {
+		t.Error("expected a transaction without an expiration height to be refused")
+	}
+}
@@ -0,0 +1,90 @@
+package types
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Signed messages prove control of a single signature address without creating a transaction,
+// by signing a message using the key of the address. The signed hash is the BLAKE2b-256 hash of
+//
+//	Goldchain Signed Message:\n<message>
+//
+// such that a message signature can never be mistaken for the signature of a transaction.
+// A signature is presented as <public key>:<hex-encoded signature>, e.g. ed25519:<key>:<signature>.
+const signedMessagePrefix = "Goldchain Signed Message:\n"
+
+// MessageSignature is the signature of a message, along with the public key of the signing address.
+type MessageSignature struct {
+	PublicKey types.PublicKey
+	Signature crypto.Signature
+}
+
+// SignedMessageHash returns the hash signed to sign the given message.
+func SignedMessageHash(message string) crypto.Hash {
+	return crypto.HashBytes([]byte(signedMessagePrefix + message))
+}
+
+// SignMessage signs the given message using the given key.
+func SignMessage(message string, sk crypto.SecretKey) MessageSignature {
+	return MessageSignature{
+		PublicKey: types.Ed25519PublicKey(sk.PublicKey()),
+		Signature: crypto.SignHash(SignedMessageHash(message), sk),
+	}
+}
+
+// Verify verifies the signature of the given message, signed using the key of the given address.
+func (sig MessageSignature) Verify(address types.UnlockHash, message string) error {
+	if signer := types.NewPubKeyUnlockHash(sig.PublicKey); signer.Cmp(address) != 0 {
+		return fmt.Errorf("message is signed by %s rather than by %s", signer.String(), address.String())
+	}
+	var pk crypto.PublicKey
+	if sig.PublicKey.Algorithm != types.SignatureAlgoEd25519 || len(sig.PublicKey.Key) != len(pk) {
+		return errors.New("message is not signed using an ed25519 key")
+	}
+	copy(pk[:], sig.PublicKey.Key)
+	if err := crypto.VerifyHash(SignedMessageHash(message), pk, sig.Signature); err != nil {
+		return fmt.Errorf("invalid message signature: %v", err)
+	}
+	return nil
+}
+
+// String returns the signature as <public key>:<hex-encoded signature>.
+func (sig MessageSignature) String() string {
+	return sig.PublicKey.String() + ":" + hex.EncodeToString(sig.Signature[:])
+}
+
+// LoadString parses a signature formatted as <public key>:<hex-encoded signature>.
+func (sig *MessageSignature) LoadString(str string) error {
+	idx := strings.LastIndexByte(str, ':')
+	if idx < 0 {
+		return errors.New("message signature has to be formatted as <public key>:<signature>")
+	}
+	var pk types.PublicKey
+	err := pk.LoadString(str[:idx])
+	if err != nil {
+		return fmt.Errorf("invalid message signature public key: %v", err)
+	}
+	b, err := hex.DecodeString(str[idx+1:])
+	if err != nil || len(b) != crypto.SignatureSize {
+		return errors.New("invalid message signature: expected a hex-encoded signature of 64 bytes")
+	}
+	sig.PublicKey = pk
+	copy(sig.Signature[:], b)
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.MarshalText
+func (sig MessageSignature) MarshalText() ([]byte, error) {
+	return []byte(sig.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.UnmarshalText
+func (sig *MessageSignature) UnmarshalText(b []byte) error {
+	return sig.LoadString(string(b))
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestSignMessage(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	address := types.NewPubKeyUnlockHash(types.Ed25519PublicKey(pk))
+	message := "Authorize " + address.String() + " on the goldchain testnet faucet (challenge 0123)"
+
+	sig := SignMessage(message, sk)
+	if err := sig.Verify(address, message); err != nil {
+		t.Fatal(err)
+	}
+
+	// signatures round-trip as text, including as JSON strings
+	var parsed MessageSignature
+	if err := parsed.LoadString(sig.String()); err != nil || parsed.String() != sig.String() {
+		t.Fatalf("signature %s did not round-trip: %s (%v)", sig.String(), parsed.String(), err)
+	}
+	b, err := json.Marshal(struct {
+		Signature MessageSignature `json:"signature"`
+	}{sig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"signature":"` + sig.String() + `"}`; string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	// the signature only proves control of the address for the signed message
+	otherSK, otherPK := crypto.GenerateKeyPair()
+	otherAddress := types.NewPubKeyUnlockHash(types.Ed25519PublicKey(otherPK))
+	if sig.Verify(address, message+".") == nil {
+		t.Error("expected the signature of another message to be refused")
+	}
+	if sig.Verify(otherAddress, message) == nil {
+		t.Error("expected the signature to be refused for another address")
+	}
+	forged := sig
+	forged.Signature = SignMessage(message, otherSK).Signature
+	if forged.Verify(address, message) == nil {
+		t.Error("expected a signature using another key to be refused")
+	}
+	// a transaction signature is never a message signature
+	if (MessageSignature{PublicKey: sig.PublicKey, Signature: crypto.SignHash(crypto.HashBytes([]byte(message)), sk)}).Verify(address, message) == nil {
+		t.Error("expected a signature of the unprefixed message to be refused")
+	}
+
+	for _, str := range []string{"", "ed25519:00", sig.PublicKey.String() + ":zz", sig.PublicKey.String() + ":0011"} {
+		if parsed.LoadString(str) == nil {
+			t.Errorf("expected %q to be refused", str)
+		}
+	}
+}
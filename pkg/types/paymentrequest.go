@@ -0,0 +1,157 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// Payment requests are URIs asking for a payment to a goldchain address,
+// similar to (and compatible with the syntax of) BIP 21 bitcoin URIs:
+//
+//	goldchain:<unlockhash>[?amount=<coins>][&memo=<text>][&auth=1]
+//
+// The amount is expressed in coins, using a decimal point where needed.
+// The memo is attached as arbitrary data to the transaction paying the request.
+// The auth flag indicates the address has to be authorized to receive coins.
+// Unknown parameters are ignored, unless they are prefixed with "req-",
+// in which case the request cannot be paid by software not supporting them.
+const (
+	PaymentRequestScheme = "goldchain"
+
+	paymentRequestAmount = "amount"
+	paymentRequestMemo   = "memo"
+	paymentRequestAuth   = "auth"
+)
+
+// PaymentRequest is a request for a payment to a goldchain address.
+type PaymentRequest struct {
+	Address types.UnlockHash
+	// Amount is zero in case the payer is to choose the amount
+	Amount       types.Currency
+	Memo         string
+	AuthRequired bool
+}
+
+// String returns the payment request as a URI.
+func (pr PaymentRequest) String() string {
+	values := url.Values{}
+	if !pr.Amount.IsZero() {
+		values.Set(paymentRequestAmount, formatPaymentRequestAmount(pr.Amount))
+	}
+	if pr.Memo != "" {
+		values.Set(paymentRequestMemo, pr.Memo)
+	}
+	if pr.AuthRequired {
+		values.Set(paymentRequestAuth, "1")
+	}
+	uri := PaymentRequestScheme + ":" + pr.Address.String()
+	if len(values) > 0 {
+		// spaces are encoded as %20 rather than +, as not all URI parsers decode the latter
+		uri += "?" + strings.Replace(values.Encode(), "+", "%20", -1)
+	}
+	return uri
+}
+
+// IsPaymentRequest returns true in case the given string uses the payment request URI scheme.
+func IsPaymentRequest(str string) bool {
+	return len(str) > len(PaymentRequestScheme) &&
+		strings.EqualFold(str[:len(PaymentRequestScheme)+1], PaymentRequestScheme+":")
+}
+
+// ParsePaymentRequest parses a payment request URI.
+func ParsePaymentRequest(str string) (PaymentRequest, error) {
+	if !IsPaymentRequest(str) {
+		return PaymentRequest{}, fmt.Errorf("payment request has to start with %q", PaymentRequestScheme+":")
+	}
+	str = str[len(PaymentRequestScheme)+1:]
+	var query string
+	if idx := strings.IndexByte(str, '?'); idx >= 0 {
+		str, query = str[:idx], str[idx+1:]
+	}
+	if str == "" {
+		return PaymentRequest{}, errors.New("payment request has no address")
+	}
+	var pr PaymentRequest
+	err := pr.Address.LoadString(str)
+	if err != nil {
+		return PaymentRequest{}, fmt.Errorf("invalid payment request address: %v", err)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return PaymentRequest{}, fmt.Errorf("invalid payment request parameters: %v", err)
+	}
+	for key, vals := range values {
+		if len(vals) != 1 {
+			return PaymentRequest{}, fmt.Errorf("payment request parameter %q is defined more than once", key)
+		}
+		switch key {
+		case paymentRequestAmount:
+			pr.Amount, err = parsePaymentRequestAmount(vals[0])
+			if err != nil {
+				return PaymentRequest{}, fmt.Errorf("invalid payment request amount %q: %v", vals[0], err)
+			}
+		case paymentRequestMemo:
+			pr.Memo = vals[0]
+		case paymentRequestAuth:
+			pr.AuthRequired, err = strconv.ParseBool(vals[0])
+			if err != nil {
+				return PaymentRequest{}, fmt.Errorf("invalid payment request auth flag %q", vals[0])
+			}
+		default:
+			if strings.HasPrefix(key, "req-") {
+				return PaymentRequest{}, fmt.Errorf("unsupported required payment request parameter %q", key)
+			}
+		}
+	}
+	return pr, nil
+}
+
+// paymentRequestPrecision is the amount of decimals of a coin amount,
+// as defined by the network descriptor, the precision being shared by all goldchain networks.
+var paymentRequestPrecision = int(config.GetStandardNetworkDescriptor().CoinPrecision)
+
+// formatPaymentRequestAmount formats the given currency as an amount of coins, without trailing zeros.
+func formatPaymentRequestAmount(c types.Currency) string {
+	str := c.String()
+	if len(str) <= paymentRequestPrecision {
+		str = strings.Repeat("0", paymentRequestPrecision-len(str)+1) + str
+	}
+	idx := len(str) - paymentRequestPrecision
+	integral, fractional := str[:idx], strings.TrimRight(str[idx:], "0")
+	if fractional == "" {
+		return integral
+	}
+	return integral + "." + fractional
+}
+
+// parsePaymentRequestAmount parses an amount of coins as currency.
+func parsePaymentRequestAmount(str string) (types.Currency, error) {
+	integral, fractional := str, ""
+	if idx := strings.IndexByte(str, '.'); idx >= 0 {
+		integral, fractional = str[:idx], str[idx+1:]
+	}
+	if integral == "" && fractional == "" {
+		return types.Currency{}, errors.New("no digits")
+	}
+	if len(fractional) > paymentRequestPrecision {
+		return types.Currency{}, fmt.Errorf("more than %d decimals", paymentRequestPrecision)
+	}
+	digits := integral + fractional + strings.Repeat("0", paymentRequestPrecision-len(fractional))
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return types.Currency{}, errors.New("not a decimal number")
+		}
+	}
+	i, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return types.Currency{}, errors.New("not a decimal number")
+	}
+	return types.NewCurrency(i), nil
+}
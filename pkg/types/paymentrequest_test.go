@@ -0,0 +1,63 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestPaymentRequestRoundTrip(t *testing.T) {
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	oneCoin := types.DefaultCurrencyUnits().OneCoin
+	requests := []PaymentRequest{
+		{Address: uh},
+		{Address: uh, Amount: oneCoin.Mul64(42)},
+		{Address: uh, Amount: oneCoin.Div64(4).Add(oneCoin.Mul64(3))},
+		{Address: uh, Amount: types.NewCurrency64(1), Memo: "invoice #42 & co", AuthRequired: true},
+	}
+	for _, pr := range requests {
+		uri := pr.String()
+		parsed, err := ParsePaymentRequest(uri)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", uri, err)
+			continue
+		}
+		if parsed.Address.Cmp(pr.Address) != 0 || !parsed.Amount.Equals(pr.Amount) ||
+			parsed.Memo != pr.Memo || parsed.AuthRequired != pr.AuthRequired {
+			t.Errorf("%q did not round-trip: %+v", uri, parsed)
+		}
+	}
+
+	expected := "goldchain:" + uh.String() + "?amount=3.25&auth=1&memo=for%20lunch"
+	if uri := (PaymentRequest{Address: uh, Amount: oneCoin.Mul64(13).Div64(4), Memo: "for lunch", AuthRequired: true}).String(); uri != expected {
+		t.Errorf("expected %q, got %q", expected, uri)
+	}
+}
+
+func TestParsePaymentRequestErrors(t *testing.T) {
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1}).String()
+	uris := []string{
+		"",
+		uh,
+		"bitcoin:" + uh,
+		"goldchain:",
+		"goldchain:foo",
+		"goldchain:" + uh + "?amount=",
+		"goldchain:" + uh + "?amount=-1",
+		"goldchain:" + uh + "?amount=1e3",
+		"goldchain:" + uh + "?amount=0.0000000001",
+		"goldchain:" + uh + "?amount=1&amount=2",
+		"goldchain:" + uh + "?auth=maybe",
+		"goldchain:" + uh + "?req-foo=bar",
+	}
+	for _, uri := range uris {
+		if _, err := ParsePaymentRequest(uri); err == nil {
+			t.Errorf("expected %q to be invalid", uri)
+		}
+	}
+	// unknown optional parameters and upper case schemes are accepted
+	if _, err := ParsePaymentRequest("GOLDCHAIN:" + uh + "?label=foo"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,215 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/extensions/authcointx"
+	"github.com/threefoldtech/rivine/extensions/minting"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ErrTransactionVersionNotActive is returned by the transaction version validator,
+// for a transaction of a version which is not active yet at the height of the transaction.
+var ErrTransactionVersionNotActive = errors.New("transaction version is not active")
+
+// TransactionVersionInfo describes a transaction version of goldchain.
+type TransactionVersionInfo struct {
+	Version types.TransactionVersion `json:"version"`
+	Name    string                   `json:"name"`
+}
+
+// transactionVersions lists all transaction versions of goldchain, ordered by version.
+// A new transaction version is added here, and mapped to the hard fork activating it in transactionVersionForks.
+var transactionVersions = []TransactionVersionInfo{
+	{Version: types.TransactionVersionZero, Name: "legacy"},
+	{Version: types.TransactionVersionOne, Name: "regular"},
+	{Version: MinterDefinitionTxVersion, Name: "minter definition"},
+	{Version: CoinCreationTxVersion, Name: "coin creation"},
+	{Version: CoinDestructionTxVersion, Name: "coin destruction"},
+	{Version: TransactionVersionAuthAddressUpdateTx, Name: "auth address update"},
+	{Version: TransactionVersionAuthConditionUpdateTx, Name: "auth condition update"},
+	{Version: TransactionVersionExpiring, Name: "expiring"},
+	{Version: TransactionVersionBlockStakeDelegation, Name: "blockstake delegation"},
+	{Version: TransactionVersionVote, Name: "vote"},
+}
+
+// transactionVersionForks maps the transaction versions added after the genesis block to the name of the hard fork
+// activating them, as scheduled per network in pkg/config. All other versions are valid from the genesis block onwards,
+// while these versions are never valid without an activation height.
+var transactionVersionForks = map[types.TransactionVersion]string{
+	TransactionVersionExpiring:             config.ForkExpiringTransactions,
+	TransactionVersionBlockStakeDelegation: config.ForkBlockStakeDelegation,
+	TransactionVersionVote:                 config.ForkGovernanceVotes,
+}
+
+// TransactionVersions returns all transaction versions of goldchain, ordered by version.
+func TransactionVersions() []TransactionVersionInfo {
+	return append([]TransactionVersionInfo(nil), transactionVersions...)
+}
+
+// TransactionControllerGetters contains the consensus getters used by the transaction controllers of the
+// minting and auth coin extensions, to validate and sign these transactions. They are left undefined by processes
+// which only encode and decode these transactions, or which register the plugins of these extensions, which register their own controllers.
+type TransactionControllerGetters struct {
+	MintConditionGetter minting.MintConditionGetter
+	AuthInfoGetter      authcointx.AuthInfoGetter
+}
+
+// RegisterTransactionVersions registers the controllers of all goldchain transaction versions,
+// such that they can be encoded and decoded, using the given getters for the controllers of the extensions.
+func RegisterTransactionVersions(getters TransactionControllerGetters) {
+	types.RegisterTransactionVersion(MinterDefinitionTxVersion, minting.MinterDefinitionTransactionController{
+		MintConditionGetter: getters.MintConditionGetter,
+		TransactionVersion:  MinterDefinitionTxVersion,
+	})
+	types.RegisterTransactionVersion(CoinCreationTxVersion, minting.CoinCreationTransactionController{
+		MintConditionGetter: getters.MintConditionGetter,
+		TransactionVersion:  CoinCreationTxVersion,
+	})
+	types.RegisterTransactionVersion(CoinDestructionTxVersion, minting.CoinDestructionTransactionController{
+		TransactionVersion: CoinDestructionTxVersion,
+	})
+	types.RegisterTransactionVersion(TransactionVersionAuthAddressUpdateTx, authcointx.AuthAddressUpdateTransactionController{
+		AuthInfoGetter:     getters.AuthInfoGetter,
+		TransactionVersion: TransactionVersionAuthAddressUpdateTx,
+	})
+	types.RegisterTransactionVersion(TransactionVersionAuthConditionUpdateTx, authcointx.AuthConditionUpdateTransactionController{
+		AuthInfoGetter:     getters.AuthInfoGetter,
+		TransactionVersion: TransactionVersionAuthConditionUpdateTx,
+	})
+	types.RegisterTransactionVersion(TransactionVersionExpiring, ExpiringTransactionController{
+		TransactionVersion: TransactionVersionExpiring,
+	})
+	types.RegisterTransactionVersion(TransactionVersionBlockStakeDelegation, BlockStakeDelegationTransactionController{
+		TransactionVersion: TransactionVersionBlockStakeDelegation,
+	})
+	types.RegisterTransactionVersion(TransactionVersionVote, VoteTransactionController{
+		TransactionVersion: TransactionVersionVote,
+	})
+}
+
+// TransactionVersionActivations maps transaction versions to the height of the first block which can contain them.
+type TransactionVersionActivations map[types.TransactionVersion]types.BlockHeight
+
+// TransactionVersionActivationsOf returns the activation heights of the transaction versions of a network,
+// as defined by the heights of the given hard forks of that network.
+// Versions of which the fork is not scheduled are left out, such that the registry refuses them.
+func TransactionVersionActivationsOf(forks []config.Fork) TransactionVersionActivations {
+	activations := make(TransactionVersionActivations, len(transactionVersionForks))
+	for version, name := range transactionVersionForks {
+		for _, fork := range forks {
+			if fork.Name == name {
+				activations[version] = fork.Height
+				break
+			}
+		}
+	}
+	return activations
+}
+
+type (
+	// TransactionVersionRegistry answers which transaction versions are valid at a given height,
+	// as defined by the activation heights of a network.
+	TransactionVersionRegistry struct {
+		activations TransactionVersionActivations
+	}
+
+	// TransactionVersionStatus is the status of a transaction version at a given height.
+	TransactionVersionStatus struct {
+		TransactionVersionInfo
+		// ActivationHeight is the height of the first block which can contain transactions of this version
+		ActivationHeight types.BlockHeight `json:"activationheight"`
+		Active           bool              `json:"active"`
+	}
+)
+
+// NewTransactionVersionRegistry creates a transaction version registry using the given activation heights,
+// refusing activation heights of unknown versions, and of the regular transaction versions which are always valid.
+// Every version added after the genesis block requires an activation height, as its fork might be missing
+// from the schedule of the network, or be misnamed. A version is only valid from the genesis block onwards
+// if explicitly activated at height 0.
+func NewTransactionVersionRegistry(activations TransactionVersionActivations) (TransactionVersionRegistry, error) {
+	for version, fork := range transactionVersionForks {
+		if _, ok := activations[version]; !ok {
+			return TransactionVersionRegistry{}, fmt.Errorf("transaction version %d has no activation height, as the %q fork is not scheduled", version, fork)
+		}
+	}
+	registry := TransactionVersionRegistry{activations: make(TransactionVersionActivations, len(activations))}
+	for version, height := range activations {
+		if !isKnownTransactionVersion(version) {
+			return TransactionVersionRegistry{}, fmt.Errorf("cannot activate unknown transaction version %d", version)
+		}
+		if version == types.TransactionVersionZero || version == types.TransactionVersionOne {
+			return TransactionVersionRegistry{}, fmt.Errorf("transaction version %d is always active", version)
+		}
+		registry.activations[version] = height
+	}
+	return registry, nil
+}
+
+// ActivationHeight returns the height of the first block which can contain transactions of the given version,
+// and false in case the version is unknown.
+func (r TransactionVersionRegistry) ActivationHeight(version types.TransactionVersion) (types.BlockHeight, bool) {
+	if !isKnownTransactionVersion(version) {
+		return 0, false
+	}
+	return r.activations[version], true
+}
+
+// IsValidAt returns true in case transactions of the given version are valid at the given height.
+func (r TransactionVersionRegistry) IsValidAt(version types.TransactionVersion, height types.BlockHeight) bool {
+	activation, ok := r.ActivationHeight(version)
+	return ok && height >= activation
+}
+
+// StatusAt returns the status of all transaction versions at the given height, ordered by version.
+func (r TransactionVersionRegistry) StatusAt(height types.BlockHeight) []TransactionVersionStatus {
+	statuses := make([]TransactionVersionStatus, 0, len(transactionVersions))
+	for _, info := range transactionVersions {
+		activation := r.activations[info.Version]
+		statuses = append(statuses, TransactionVersionStatus{
+			TransactionVersionInfo: info,
+			ActivationHeight:       activation,
+			Active:                 height >= activation,
+		})
+	}
+	return statuses
+}
+
+// Activations returns the activation heights of the versions activated after the genesis block, ordered by version.
+func (r TransactionVersionRegistry) Activations() []TransactionVersionStatus {
+	var statuses []TransactionVersionStatus
+	for _, status := range r.StatusAt(0) {
+		if status.ActivationHeight > 0 {
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// ValidateTransactionVersion is a validator function that checks that the version of the transaction
+// is active at the height of the transaction.
+func (r TransactionVersionRegistry) ValidateTransactionVersion(tx types.Transaction, ctx types.TransactionValidationContext, _ modules.ConsensusStateGetter) error {
+	if r.IsValidAt(tx.Version, ctx.BlockHeight) {
+		return nil
+	}
+	activation, ok := r.ActivationHeight(tx.Version)
+	if !ok {
+		return types.NewClientError(fmt.Errorf("%v: transaction version %d is unknown", ErrTransactionVersionNotActive, tx.Version),
+			types.ClientErrorBadRequest)
+	}
+	return types.NewClientError(fmt.Errorf("%v: transaction version %d activates at height %d, current height is %d",
+		ErrTransactionVersionNotActive, tx.Version, activation, ctx.BlockHeight), types.ClientErrorBadRequest)
+}
+
+func isKnownTransactionVersion(version types.TransactionVersion) bool {
+	for _, info := range transactionVersions {
+		if info.Version == version {
+			return true
+		}
+	}
+	return false
+}
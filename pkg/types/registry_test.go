@@ -0,0 +1,105 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nbh-digital/goldchain/pkg/config"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestTransactionVersionRegistry(t *testing.T) {
+	// every network activates the versions added after the genesis block at the height of their fork,
+	// refusing each version in the block prior to its activation
+	for networkName, forks := range map[string][]config.Fork{
+		config.NetworkNameStandard: config.GetStandardnetForks(),
+		config.NetworkNameTest:     config.GetTestnetForks(),
+		config.NetworkNameDev:      config.GetDevnetForks(),
+	} {
+		registry, err := NewTransactionVersionRegistry(TransactionVersionActivationsOf(forks))
+		if err != nil {
+			t.Errorf("invalid activations of network %s: %v", networkName, err)
+			continue
+		}
+		for version := range transactionVersionForks {
+			activation, ok := registry.ActivationHeight(version)
+			if !ok || activation == 0 {
+				t.Errorf("network %s: expected version %d to activate after the genesis block, got height %d", networkName, version, activation)
+				continue
+			}
+			ctx := func(height types.BlockHeight) types.TransactionValidationContext {
+				return types.TransactionValidationContext{ValidationContext: types.ValidationContext{BlockHeight: height}}
+			}
+			err = registry.ValidateTransactionVersion(types.Transaction{Version: version}, ctx(activation-1), nil)
+			if err == nil || !strings.Contains(err.Error(), ErrTransactionVersionNotActive.Error()) {
+				t.Errorf("network %s: expected version %d to be refused at height %d, got: %v", networkName, version, activation-1, err)
+			}
+			if err = registry.ValidateTransactionVersion(types.Transaction{Version: version}, ctx(activation), nil); err != nil {
+				t.Errorf("network %s: expected version %d to be valid at height %d: %v", networkName, version, activation, err)
+			}
+		}
+	}
+	// versions of which the fork is not scheduled have no activation height, and are refused by the registry
+	if activations := TransactionVersionActivationsOf(nil); len(activations) != 0 {
+		t.Errorf("unexpected activations without forks: %v", activations)
+	}
+	activations := TransactionVersionActivationsOf([]config.Fork{
+		{Name: config.ForkExpiringTransactions, Height: 5},
+		{Name: "governance vote", Height: 10},
+	})
+	if len(activations) != 1 || activations[TransactionVersionExpiring] != 5 {
+		t.Errorf("unexpected activations: %v", activations)
+	}
+	if _, err := NewTransactionVersionRegistry(activations); err == nil || !strings.Contains(err.Error(), "not scheduled") {
+		t.Errorf("expected versions without activation height to be refused, got %v", err)
+	}
+
+	registry, err := NewTransactionVersionRegistry(TransactionVersionActivations{
+		TransactionVersionExpiring:             0,
+		TransactionVersionBlockStakeDelegation: 0,
+		TransactionVersionVote:                 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		version types.TransactionVersion
+		height  types.BlockHeight
+		valid   bool
+	}{
+		{types.TransactionVersionOne, 0, true},
+		{TransactionVersionExpiring, 0, true},
+		{TransactionVersionVote, 9, false},
+		{TransactionVersionVote, 10, true},
+		{types.TransactionVersion(42), 10, false},
+	}
+	for _, testCase := range testCases {
+		if valid := registry.IsValidAt(testCase.version, testCase.height); valid != testCase.valid {
+			t.Errorf("version %d at height %d: expected valid %v, got %v", testCase.version, testCase.height, testCase.valid, valid)
+		}
+		err := registry.ValidateTransactionVersion(types.Transaction{Version: testCase.version},
+			types.TransactionValidationContext{ValidationContext: types.ValidationContext{BlockHeight: testCase.height}}, nil)
+		if (err == nil) != testCase.valid {
+			t.Errorf("version %d at height %d: unexpected validation result: %v", testCase.version, testCase.height, err)
+		}
+	}
+	if activations := registry.Activations(); len(activations) != 1 || activations[0].Version != TransactionVersionVote || activations[0].ActivationHeight != 10 {
+		t.Errorf("unexpected activations: %+v", activations)
+	}
+	if statuses := registry.StatusAt(9); len(statuses) != len(transactionVersions) || statuses[len(statuses)-1].Active {
+		t.Errorf("unexpected statuses at height 9: %+v", statuses)
+	}
+
+	for _, invalid := range []TransactionVersionActivations{
+		{types.TransactionVersionOne: 10},
+		{types.TransactionVersion(42): 10},
+	} {
+		activations := TransactionVersionActivations{TransactionVersionExpiring: 1, TransactionVersionBlockStakeDelegation: 2, TransactionVersionVote: 3}
+		for version, height := range invalid {
+			activations[version] = height
+		}
+		if _, err = NewTransactionVersionRegistry(activations); err == nil {
+			t.Errorf("expected activations %v to be rejected", activations)
+		}
+	}
+}
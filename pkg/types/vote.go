@@ -0,0 +1,376 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/rivbin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// SpecifierVoteTransaction is the specifier used as part of
+	// the signature hash and ID of a vote transaction.
+	SpecifierVoteTransaction = types.Specifier{'v', 'o', 't', 'e', ' ', 't', 'x'}
+)
+
+const (
+	// MaxProposalTitleLength is the maximum length in bytes of the title of a proposal.
+	MaxProposalTitleLength = 128
+	// MaxProposalReferenceLength is the maximum length in bytes of the reference of a proposal.
+	MaxProposalReferenceLength = 256
+)
+
+// ErrVotingClosed is returned by the vote validator,
+// for a vote validated past the end height of its proposal.
+var ErrVotingClosed = errors.New("voting on the proposal has ended")
+
+// VoteChoice is the choice of a voter on a proposal.
+type VoteChoice uint8
+
+// The choices a voter can make on a proposal.
+const (
+	VoteChoiceYes VoteChoice = iota + 1
+	VoteChoiceNo
+	VoteChoiceAbstain
+)
+
+var voteChoiceNames = map[VoteChoice]string{
+	VoteChoiceYes:     "yes",
+	VoteChoiceNo:      "no",
+	VoteChoiceAbstain: "abstain",
+}
+
+// String returns the name of the choice.
+func (c VoteChoice) String() string {
+	if name, ok := voteChoiceNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(c))
+}
+
+// Valid returns true in case the choice is one of the defined choices.
+func (c VoteChoice) Valid() bool {
+	_, ok := voteChoiceNames[c]
+	return ok
+}
+
+// MarshalText implements encoding.TextMarshaler.MarshalText
+func (c VoteChoice) MarshalText() ([]byte, error) {
+	if !c.Valid() {
+		return nil, fmt.Errorf("invalid vote choice %d", uint8(c))
+	}
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.UnmarshalText
+func (c *VoteChoice) UnmarshalText(b []byte) error {
+	for choice, name := range voteChoiceNames {
+		if name == string(b) {
+			*c = choice
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid vote choice %q, expected yes, no or abstain", string(b))
+}
+
+// Proposal is a governance proposal, such as a change of a chain parameter,
+// blockstake holders can vote on up to (and including) its end height.
+// A proposal is not created separately, it is defined by the votes cast on it,
+// and identified by the hash of its definition.
+type Proposal struct {
+	Title string `json:"title"`
+	// Reference optionally refers to a full description of the proposal
+	Reference string `json:"reference,omitempty"`
+	// EndHeight is the height of the last block which can contain a vote on the proposal
+	EndHeight types.BlockHeight `json:"endheight"`
+}
+
+// ID returns the identifier of the proposal, being the hash of its definition.
+func (p Proposal) ID() crypto.Hash {
+	return crypto.HashBytes(rivbin.Marshal(p))
+}
+
+type (
+	// VoteTransaction is a regular transaction, respending blockstake outputs unchanged,
+	// which casts the vote of the addresses owning these outputs on a proposal.
+	// A vote is weighted by the blockstakes of the voting address at the end of the vote,
+	// and replaces any earlier vote of that address on the same proposal.
+	VoteTransaction struct {
+		CoinInputs        []types.CoinInput        `json:"coininputs"`
+		CoinOutputs       []types.CoinOutput       `json:"coinoutputs,omitempty"`
+		BlockStakeInputs  []types.BlockStakeInput  `json:"blockstakeinputs"`
+		BlockStakeOutputs []types.BlockStakeOutput `json:"blockstakeoutputs"`
+		MinerFees         []types.Currency         `json:"minerfees"`
+		ArbitraryData     []byte                   `json:"arbitrarydata,omitempty"`
+		// Proposal is the proposal voted on
+		Proposal Proposal `json:"proposal"`
+		// Choice is the vote cast on the proposal
+		Choice VoteChoice `json:"choice"`
+	}
+
+	// VoteTransactionExtension defines the VoteTransaction Extension Data
+	VoteTransactionExtension struct {
+		Proposal Proposal
+		Choice   VoteChoice
+	}
+)
+
+// VoteTransactionFromTransaction creates a VoteTransaction,
+// using a regular in-memory rivine transaction.
+func VoteTransactionFromTransaction(tx types.Transaction, expectedVersion types.TransactionVersion) (VoteTransaction, error) {
+	if tx.Version != expectedVersion {
+		return VoteTransaction{}, fmt.Errorf(
+			"a vote transaction requires tx version %d",
+			expectedVersion)
+	}
+	return VoteTransactionFromTransactionData(types.TransactionData{
+		CoinInputs:        tx.CoinInputs,
+		CoinOutputs:       tx.CoinOutputs,
+		BlockStakeInputs:  tx.BlockStakeInputs,
+		BlockStakeOutputs: tx.BlockStakeOutputs,
+		MinerFees:         tx.MinerFees,
+		ArbitraryData:     tx.ArbitraryData,
+		Extension:         tx.Extension,
+	})
+}
+
+// VoteTransactionFromTransactionData creates a VoteTransaction,
+// using the TransactionData from a regular in-memory rivine transaction.
+func VoteTransactionFromTransactionData(txData types.TransactionData) (VoteTransaction, error) {
+	extensionData, ok := txData.Extension.(*VoteTransactionExtension)
+	if !ok {
+		return VoteTransaction{}, errors.New("invalid extension data for a VoteTransaction")
+	}
+	return VoteTransaction{
+		CoinInputs:        txData.CoinInputs,
+		CoinOutputs:       txData.CoinOutputs,
+		BlockStakeInputs:  txData.BlockStakeInputs,
+		BlockStakeOutputs: txData.BlockStakeOutputs,
+		MinerFees:         txData.MinerFees,
+		ArbitraryData:     txData.ArbitraryData,
+		Proposal:          extensionData.Proposal,
+		Choice:            extensionData.Choice,
+	}, nil
+}
+
+// TransactionData returns this VoteTransaction
+// as regular rivine transaction data.
+func (vtx *VoteTransaction) TransactionData() types.TransactionData {
+	return types.TransactionData{
+		CoinInputs:        vtx.CoinInputs,
+		CoinOutputs:       vtx.CoinOutputs,
+		BlockStakeInputs:  vtx.BlockStakeInputs,
+		BlockStakeOutputs: vtx.BlockStakeOutputs,
+		MinerFees:         vtx.MinerFees,
+		ArbitraryData:     vtx.ArbitraryData,
+		Extension: &VoteTransactionExtension{
+			Proposal: vtx.Proposal,
+			Choice:   vtx.Choice,
+		},
+	}
+}
+
+// Transaction returns this VoteTransaction
+// as regular rivine transaction, using the given version as the type.
+func (vtx *VoteTransaction) Transaction(version types.TransactionVersion) types.Transaction {
+	return types.Transaction{
+		Version:           version,
+		CoinInputs:        vtx.CoinInputs,
+		CoinOutputs:       vtx.CoinOutputs,
+		BlockStakeInputs:  vtx.BlockStakeInputs,
+		BlockStakeOutputs: vtx.BlockStakeOutputs,
+		MinerFees:         vtx.MinerFees,
+		ArbitraryData:     vtx.ArbitraryData,
+		Extension: &VoteTransactionExtension{
+			Proposal: vtx.Proposal,
+			Choice:   vtx.Choice,
+		},
+	}
+}
+
+// TransactionVote returns the proposal and choice of the given transaction,
+// and true in case the transaction is a vote transaction.
+func TransactionVote(tx types.Transaction) (Proposal, VoteChoice, bool) {
+	extensionData, ok := tx.Extension.(*VoteTransactionExtension)
+	if !ok {
+		return Proposal{}, 0, false
+	}
+	return extensionData.Proposal, extensionData.Choice, true
+}
+
+// VotingAddresses returns the addresses voting using the given (valid) vote transaction,
+// being the owners of the blockstake outputs it respends, in order of appearance.
+func VotingAddresses(tx types.Transaction) []types.UnlockHash {
+	return blockStakeOutputOwners(tx)
+}
+
+// VoteTransactionController defines a goldchain-specific transaction controller,
+// for a VoteTransaction. It allows blockstake holders to vote on governance proposals.
+type VoteTransactionController struct {
+	// TransactionVersion is used to validate/set the transaction version
+	// of a vote transaction.
+	TransactionVersion types.TransactionVersion
+}
+
+// ensure at compile time that VoteTransactionController
+// implements the desired interfaces
+var (
+	_ types.TransactionController      = VoteTransactionController{}
+	_ types.TransactionSignatureHasher = VoteTransactionController{}
+	_ types.TransactionIDEncoder       = VoteTransactionController{}
+)
+
+// EncodeTransactionData implements TransactionController.EncodeTransactionData
+func (vtc VoteTransactionController) EncodeTransactionData(w io.Writer, txData types.TransactionData) error {
+	vtx, err := VoteTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to a VoteTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).Encode(vtx)
+}
+
+// DecodeTransactionData implements TransactionController.DecodeTransactionData
+func (vtc VoteTransactionController) DecodeTransactionData(r io.Reader) (types.TransactionData, error) {
+	var vtx VoteTransaction
+	err := rivbin.NewDecoder(r).Decode(&vtx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to binary-decode tx as a VoteTx: %v", err)
+	}
+	// return vote tx as regular rivine tx data
+	return vtx.TransactionData(), nil
+}
+
+// JSONEncodeTransactionData implements TransactionController.JSONEncodeTransactionData
+func (vtc VoteTransactionController) JSONEncodeTransactionData(txData types.TransactionData) ([]byte, error) {
+	vtx, err := VoteTransactionFromTransactionData(txData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert txData to a VoteTx: %v", err)
+	}
+	return json.Marshal(vtx)
+}
+
+// JSONDecodeTransactionData implements TransactionController.JSONDecodeTransactionData
+func (vtc VoteTransactionController) JSONDecodeTransactionData(data []byte) (types.TransactionData, error) {
+	var vtx VoteTransaction
+	err := json.Unmarshal(data, &vtx)
+	if err != nil {
+		return types.TransactionData{}, fmt.Errorf(
+			"failed to json-decode tx as a VoteTx: %v", err)
+	}
+	// return vote tx as regular rivine tx data
+	return vtx.TransactionData(), nil
+}
+
+// SignatureHash implements TransactionSignatureHasher.SignatureHash
+func (vtc VoteTransactionController) SignatureHash(t types.Transaction, extraObjects ...interface{}) (crypto.Hash, error) {
+	vtx, err := VoteTransactionFromTransaction(t, vtc.TransactionVersion)
+	if err != nil {
+		return crypto.Hash{}, fmt.Errorf("failed to use tx as a vote tx: %v", err)
+	}
+
+	h := crypto.NewHash()
+	enc := rivbin.NewEncoder(h)
+
+	enc.EncodeAll(
+		t.Version,
+		SpecifierVoteTransaction,
+	)
+
+	if len(extraObjects) > 0 {
+		enc.EncodeAll(extraObjects...)
+	}
+
+	coinParentIDSlice := make([]types.CoinOutputID, 0, len(vtx.CoinInputs))
+	for _, ci := range vtx.CoinInputs {
+		coinParentIDSlice = append(coinParentIDSlice, ci.ParentID)
+	}
+	blockStakeParentIDSlice := make([]types.BlockStakeOutputID, 0, len(vtx.BlockStakeInputs))
+	for _, bsi := range vtx.BlockStakeInputs {
+		blockStakeParentIDSlice = append(blockStakeParentIDSlice, bsi.ParentID)
+	}
+
+	enc.EncodeAll(
+		coinParentIDSlice,
+		vtx.CoinOutputs,
+		blockStakeParentIDSlice,
+		vtx.BlockStakeOutputs,
+		vtx.MinerFees,
+		vtx.ArbitraryData,
+		vtx.Proposal,
+		vtx.Choice,
+	)
+
+	var hash crypto.Hash
+	h.Sum(hash[:0])
+	return hash, nil
+}
+
+// EncodeTransactionIDInput implements TransactionIDEncoder.EncodeTransactionIDInput
+func (vtc VoteTransactionController) EncodeTransactionIDInput(w io.Writer, txData types.TransactionData) error {
+	vtx, err := VoteTransactionFromTransactionData(txData)
+	if err != nil {
+		return fmt.Errorf("failed to convert txData to a VoteTx: %v", err)
+	}
+	return rivbin.NewEncoder(w).EncodeAll(SpecifierVoteTransaction, vtx)
+}
+
+// ValidateProposal validates that the proposal has a title and that its title and reference are not too long.
+func ValidateProposal(proposal Proposal) error {
+	if proposal.Title == "" {
+		return errors.New("a proposal requires a title")
+	}
+	if len(proposal.Title) > MaxProposalTitleLength {
+		return fmt.Errorf("the title of a proposal cannot be longer than %d bytes", MaxProposalTitleLength)
+	}
+	if len(proposal.Reference) > MaxProposalReferenceLength {
+		return fmt.Errorf("the reference of a proposal cannot be longer than %d bytes", MaxProposalReferenceLength)
+	}
+	return nil
+}
+
+// ValidateVote is a validator function that checks that a vote transaction casts a valid choice
+// on a valid proposal which is still open at the height of the transaction,
+// and respends at least one blockstake output, respending each blockstake output unchanged (in the same order).
+func ValidateVote(tx types.Transaction, ctx types.TransactionValidationContext, css modules.ConsensusStateGetter) error {
+	proposal, choice, ok := TransactionVote(tx)
+	if !ok {
+		return errors.New("vote transaction has no vote defined")
+	}
+	if !choice.Valid() {
+		return types.NewClientError(fmt.Errorf("invalid vote choice %d", uint8(choice)), types.ClientErrorBadRequest)
+	}
+	if err := ValidateProposal(proposal); err != nil {
+		return types.NewClientError(err, types.ClientErrorBadRequest)
+	}
+	if ctx.BlockHeight > proposal.EndHeight {
+		return types.NewClientError(fmt.Errorf("%v: proposal %s ended at height %d, current height is %d",
+			ErrVotingClosed, proposal.ID().String(), proposal.EndHeight, ctx.BlockHeight), types.ClientErrorBadRequest)
+	}
+	if len(tx.BlockStakeInputs) == 0 {
+		return types.NewClientError(errors.New("a vote has to respend a blockstake output of each voting address"),
+			types.ClientErrorBadRequest)
+	}
+	if len(tx.BlockStakeOutputs) != len(tx.BlockStakeInputs) {
+		return types.NewClientError(errors.New("a vote has to respend all its blockstake inputs unchanged"),
+			types.ClientErrorBadRequest)
+	}
+	for index, bsi := range tx.BlockStakeInputs {
+		bso, err := css.UnspentBlockStakeOutputGet(bsi.ParentID)
+		if err != nil {
+			return fmt.Errorf(
+				"unable to find parent ID %s as an unspent block stake output in the current consensus state at block height %d",
+				bsi.ParentID.String(), ctx.BlockHeight)
+		}
+		if !SameBlockStakeOutput(bso, tx.BlockStakeOutputs[index]) {
+			return types.NewClientError(fmt.Errorf("blockstake output #%d does not respend blockstake input #%d unchanged", index, index),
+				types.ClientErrorBadRequest)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestValidateVote(t *testing.T) {
+	voter := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	output := types.BlockStakeOutput{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(voter))}
+	css := testConsensusState{outputs: map[types.BlockStakeOutputID]types.BlockStakeOutput{{1}: output}}
+	controller := VoteTransactionController{TransactionVersion: TransactionVersionVote}
+	proposal := Proposal{Title: "lower the minimum miner fee", Reference: "https://example.org/proposals/1", EndHeight: 100}
+
+	vote := func(proposal Proposal, choice VoteChoice, outputs ...types.BlockStakeOutput) types.Transaction {
+		vtx := VoteTransaction{
+			BlockStakeInputs:  []types.BlockStakeInput{{ParentID: types.BlockStakeOutputID{1}}},
+			BlockStakeOutputs: outputs,
+			MinerFees:         []types.Currency{types.NewCurrency64(1)},
+			Proposal:          proposal,
+			Choice:            choice,
+		}
+		return vtx.Transaction(TransactionVersionVote)
+	}
+	validate := func(txn types.Transaction, height types.BlockHeight) error {
+		return ValidateVote(txn, types.TransactionValidationContext{ValidationContext: types.ValidationContext{BlockHeight: height}}, css)
+	}
+	txn := vote(proposal, VoteChoiceYes, output)
+	if err := validate(txn, 100); err != nil {
+		t.Errorf("expected vote to be valid: %v", err)
+	}
+	if err := validate(txn, 101); err == nil || !strings.Contains(err.Error(), ErrVotingClosed.Error()) {
+		t.Errorf("expected the vote to be refused after the end height, got: %v", err)
+	}
+
+	// the vote is part of the encoding and signature hash
+	var buf bytes.Buffer
+	if err := controller.EncodeTransactionData(&buf, types.TransactionData{Extension: txn.Extension}); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := controller.DecodeTransactionData(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decodedProposal, choice, ok := TransactionVote(types.Transaction{Extension: decoded.Extension}); !ok || decodedProposal != proposal || choice != VoteChoiceYes {
+		t.Errorf("unexpected vote after decoding: %v %v", decodedProposal, choice)
+	}
+	hash, err := controller.SignatureHash(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherHash, _ := controller.SignatureHash(vote(proposal, VoteChoiceNo, output)); otherHash == hash {
+		t.Error("expected the signature hash to depend on the choice")
+	}
+	b, err := controller.JSONEncodeTransactionData(types.TransactionData{Extension: txn.Extension})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"choice":"yes"`)) {
+		t.Errorf("expected the choice to be encoded by name: %s", b)
+	}
+	var choice VoteChoice
+	if err := json.Unmarshal([]byte(`"maybe"`), &choice); err == nil {
+		t.Error("expected an unknown choice to be refused")
+	}
+
+	for name, txn := range map[string]types.Transaction{
+		"invalid choice":    vote(proposal, 4, output),
+		"missing title":     vote(Proposal{EndHeight: 100}, VoteChoiceNo, output),
+		"long title":        vote(Proposal{Title: strings.Repeat("a", MaxProposalTitleLength+1), EndHeight: 100}, VoteChoiceNo, output),
+		"long reference":    vote(Proposal{Title: "a", Reference: strings.Repeat("a", MaxProposalReferenceLength+1), EndHeight: 100}, VoteChoiceNo, output),
+		"changed value":     vote(proposal, VoteChoiceAbstain, types.BlockStakeOutput{Value: types.NewCurrency64(9), Condition: output.Condition}),
+		"changed condition": vote(proposal, VoteChoiceAbstain, types.BlockStakeOutput{Value: output.Value, Condition: types.NewCondition(&types.NilCondition{})}),
+		"missing output":    vote(proposal, VoteChoiceAbstain),
+	} {
+		if err := validate(txn, 1); err == nil {
+			t.Errorf("%s: expected vote to be invalid", name)
+		}
+	}
+}
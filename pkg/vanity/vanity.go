@@ -0,0 +1,167 @@
+// Package vanity searches for wallet seeds deriving an address with a desired prefix,
+// such as the branded addresses of a foundation.
+//
+// Every wallet address is formed by the 01 (public key) type prefix, followed by the hex-encoded hash
+// of the public key and a checksum. The desired prefix is matched against the hash following the type prefix,
+// which is uniformly distributed, such that every hex character of the prefix multiplies
+// the expected amount of attempts by 16.
+package vanity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/NebulousLabs/fastrand"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+// MaxPrefixLength is the maximum length of a desired prefix,
+// beyond which no match would be found in the lifetime of the universe.
+const MaxPrefixLength = 16
+
+var (
+	// ErrEmptyPrefix is returned in case a search is created for an empty prefix.
+	ErrEmptyPrefix = errors.New("the prefix cannot be empty")
+	// ErrPrefixTooLong is returned in case a search is created for a prefix longer than MaxPrefixLength.
+	ErrPrefixTooLong = fmt.Errorf("the prefix cannot be longer than %d characters", MaxPrefixLength)
+)
+
+// Match is a seed deriving an address with the desired prefix.
+type Match struct {
+	Seed modules.Seed
+	// Index is the index of the address derived from the seed,
+	// which is lower than modules.PublicKeysPerSeed, such that it is spendable by a wallet loading the seed
+	Index   uint64
+	Address types.UnlockHash
+}
+
+// Search searches for a seed deriving an address of which the hash starts with a prefix.
+type Search struct {
+	prefix   string
+	nibbles  []byte
+	attempts uint64
+}
+
+// NewSearch creates a search for the given prefix of the hex-encoded hash of an address,
+// not including the 01 type prefix of the address. The prefix is case insensitive.
+func NewSearch(prefix string) (*Search, error) {
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return nil, ErrEmptyPrefix
+	}
+	if len(prefix) > MaxPrefixLength {
+		return nil, ErrPrefixTooLong
+	}
+	nibbles := make([]byte, 0, len(prefix))
+	for i, c := range prefix {
+		switch {
+		case c >= '0' && c <= '9':
+			nibbles = append(nibbles, byte(c-'0'))
+		case c >= 'a' && c <= 'f':
+			nibbles = append(nibbles, byte(c-'a'+10))
+		default:
+			return nil, fmt.Errorf("invalid character %q at position %d of the prefix: only hex characters are allowed", c, i)
+		}
+	}
+	return &Search{prefix: prefix, nibbles: nibbles}, nil
+}
+
+// Prefix returns the (lowercase) prefix searched for.
+func (s *Search) Prefix() string {
+	return s.prefix
+}
+
+// Difficulty returns the expected amount of addresses to derive before finding a match.
+func (s *Search) Difficulty() float64 {
+	return math.Pow(16, float64(len(s.nibbles)))
+}
+
+// Attempts returns the amount of addresses derived so far, safe to be called while the search runs.
+func (s *Search) Attempts() uint64 {
+	return atomic.LoadUint64(&s.attempts)
+}
+
+// Run searches using the given amount of workers, each deriving the addresses of random seeds,
+// until a match is found or the context is done, in which case the error of the context is returned.
+func (s *Search) Run(ctx context.Context, workers int) (Match, error) {
+	if workers < 1 {
+		workers = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		match Match
+		found bool
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m, ok := s.search(ctx)
+			if ok {
+				once.Do(func() {
+					match, found = m, true
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+	if !found {
+		return Match{}, ctx.Err()
+	}
+	return match, nil
+}
+
+// search derives all spendable addresses of random seeds, until a match is found or the context is done.
+func (s *Search) search(ctx context.Context) (Match, bool) {
+	var candidate modules.Seed
+	for {
+		select {
+		case <-ctx.Done():
+			return Match{}, false
+		default:
+		}
+		fastrand.Read(candidate[:])
+		for index := uint64(0); index < modules.PublicKeysPerSeed; index++ {
+			address := seed.Address(candidate, index)
+			if s.matches(address) {
+				atomic.AddUint64(&s.attempts, index%256+1)
+				return Match{Seed: candidate, Index: index, Address: address}, true
+			}
+			// check the context regularly, as deriving all addresses of a seed takes a while
+			if index%256 == 255 {
+				atomic.AddUint64(&s.attempts, 256)
+				if ctx.Err() != nil {
+					return Match{}, false
+				}
+			}
+		}
+		atomic.AddUint64(&s.attempts, modules.PublicKeysPerSeed%256)
+	}
+}
+
+// matches returns whether the hash of the given address starts with the prefix searched for.
+func (s *Search) matches(address types.UnlockHash) bool {
+	for i, nibble := range s.nibbles {
+		b := address.Hash[i/2]
+		if i%2 == 0 {
+			b >>= 4
+		}
+		if b&0x0f != nibble {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package vanity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+func TestNewSearch(t *testing.T) {
+	for _, prefix := range []string{"", "0x1", "g", "00000000000000000"} {
+		if _, err := NewSearch(prefix); err == nil {
+			t.Errorf("expected prefix %q to be invalid", prefix)
+		}
+	}
+	s, err := NewSearch("C0fFee")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Prefix() != "c0ffee" || s.Difficulty() != 1<<24 {
+		t.Errorf("unexpected prefix %q of difficulty %v", s.Prefix(), s.Difficulty())
+	}
+}
+
+func TestSearch(t *testing.T) {
+	s, err := NewSearch("Ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	match, err := s.Run(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(match.Address.String(), "01ab") {
+		t.Errorf("address %s does not match the prefix", match.Address.String())
+	}
+	if derived := seed.Address(match.Seed, match.Index); derived != match.Address {
+		t.Errorf("seed derives %s at index %d rather than %s", derived.String(), match.Index, match.Address.String())
+	}
+	if s.Attempts() == 0 {
+		t.Error("expected the attempts to be counted")
+	}
+
+	// a search stops once its context is done
+	s, err = NewSearch("0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = s.Run(ctx, 2)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected the deadline to be exceeded, got %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// DefaultAccelerationFeeFactor is the factor applied to the minimum transaction fee,
+	// in order to compute the miner fee of a child transaction when none is given.
+	DefaultAccelerationFeeFactor = 5
+	// MaxAccelerationFeeFactor is the factor applied to the minimum transaction fee,
+	// in order to compute the maximum miner fee a child transaction can pay.
+	MaxAccelerationFeeFactor = 50
+)
+
+var (
+	// ErrNoAccelerableOutput is returned in case an unconfirmed transaction
+	// has no unspent coin output which can be spent by the wallet.
+	ErrNoAccelerableOutput = errors.New("transaction has no unspent coin output owned by the wallet")
+)
+
+// InvalidAccelerationFeeError is returned in case the miner fee of a child transaction
+// is below the minimum transaction fee, or exceeds the maximum acceleration fee.
+type InvalidAccelerationFeeError struct {
+	Reason string
+}
+
+// Error implements error.Error
+func (err InvalidAccelerationFeeError) Error() string {
+	return "invalid acceleration fee: " + err.Reason
+}
+
+// AccelerableTransaction is an unconfirmed transaction,
+// which has at least one coin output that can be spent by the wallet,
+// such that its confirmation can be accelerated using a child transaction.
+type AccelerableTransaction struct {
+	TransactionID types.TransactionID `json:"transactionid"`
+	// MinerFee is the total miner fee paid by the unconfirmed transaction
+	MinerFee types.Currency `json:"minerfee"`
+	// Stuck is true in case the transaction pays no more than the minimum transaction fee
+	Stuck bool `json:"stuck"`
+	// OutputID identifies the coin output that would be spent by a child transaction
+	OutputID types.CoinOutputID `json:"outputid"`
+	Output   types.CoinOutput   `json:"output"`
+}
+
+// AccelerableTransactions returns all unconfirmed transactions of the transaction pool,
+// which can be accelerated by the given wallet.
+func AccelerableTransactions(w modules.Wallet, tpool modules.TransactionPool) ([]AccelerableTransaction, error) {
+	txns := tpool.TransactionList()
+	spent := spentCoinOutputs(txns)
+	minimumFee, _ := tpool.FeeEstimation()
+	var accelerable []AccelerableTransaction
+	for _, txn := range txns {
+		id, co, err := accelerableOutput(w, txn, spent)
+		if err == ErrNoAccelerableOutput {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fee := transactionFee(txn)
+		accelerable = append(accelerable, AccelerableTransaction{
+			TransactionID: txn.ID(),
+			MinerFee:      fee,
+			Stuck:         fee.Cmp(minimumFee) <= 0,
+			OutputID:      id,
+			Output:        co,
+		})
+	}
+	return accelerable, nil
+}
+
+// Accelerate creates, signs and submits a child transaction,
+// spending an output of the given unconfirmed transaction back to the same condition,
+// paying the given miner fee such that the transaction and its parent get confirmed sooner.
+// The transaction pool accepts the child transaction together with its unconfirmed parent.
+// The fee is validated and defaulted by AccelerationFee.
+func Accelerate(w modules.Wallet, tpool modules.TransactionPool, id types.TransactionID, fee types.Currency, constants types.ChainConstants) (types.Transaction, error) {
+	parent, err := tpool.Transaction(id)
+	if err != nil {
+		return types.Transaction{}, fmt.Errorf("failed to find unconfirmed transaction %s: %v", id.String(), err)
+	}
+	outputID, co, err := accelerableOutput(w, parent, spentCoinOutputs(tpool.TransactionList()))
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	fee, err = AccelerationFee(tpool, fee, constants)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	if co.Value.Cmp(fee) <= 0 {
+		return types.Transaction{}, fmt.Errorf("output %s of %s is insufficient to pay a miner fee of %s",
+			outputID.String(), co.Value.String(), fee.String())
+	}
+
+	pk, sk, err := w.GetKey(co.Condition.UnlockHash())
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	child := types.Transaction{
+		Version: constants.DefaultTransactionVersion,
+		CoinInputs: []types.CoinInput{{
+			ParentID:    outputID,
+			Fulfillment: types.NewFulfillment(types.NewSingleSignatureFulfillment(pk)),
+		}},
+		CoinOutputs: []types.CoinOutput{{
+			Value:     co.Value.Sub(fee),
+			Condition: co.Condition,
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	err = child.CoinInputs[0].Fulfillment.Sign(types.FulfillmentSignContext{
+		ExtraObjects: []interface{}{uint64(0)},
+		Transaction:  child,
+		Key:          sk,
+	})
+	if err != nil {
+		return types.Transaction{}, fmt.Errorf("failed to sign child transaction: %v", err)
+	}
+	err = tpool.AcceptTransactionSet([]types.Transaction{child})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	return child, nil
+}
+
+// AccelerationFee returns the miner fee paid by a child transaction paying the given fee.
+// If the given fee is zero, the minimum transaction fee estimated by the transaction pool
+// multiplied by DefaultAccelerationFeeFactor is paid. A fee below the minimum transaction fee
+// of the chain, or above the estimated minimum fee multiplied by MaxAccelerationFeeFactor, is refused.
+func AccelerationFee(tpool modules.TransactionPool, fee types.Currency, constants types.ChainConstants) (types.Currency, error) {
+	minimumFee, _ := tpool.FeeEstimation()
+	if minimumFee.Cmp(constants.MinimumTransactionFee) < 0 {
+		minimumFee = constants.MinimumTransactionFee
+	}
+	if fee.IsZero() {
+		return minimumFee.Mul64(DefaultAccelerationFeeFactor), nil
+	}
+	if fee.Cmp(constants.MinimumTransactionFee) < 0 {
+		return types.Currency{}, InvalidAccelerationFeeError{Reason: fmt.Sprintf("miner fee %s is below the minimum transaction fee %s",
+			fee.String(), constants.MinimumTransactionFee.String())}
+	}
+	if maxFee := minimumFee.Mul64(MaxAccelerationFeeFactor); fee.Cmp(maxFee) > 0 {
+		return types.Currency{}, InvalidAccelerationFeeError{Reason: fmt.Sprintf("miner fee %s exceeds the maximum acceleration fee %s (%d times the estimated minimum fee)",
+			fee.String(), maxFee.String(), MaxAccelerationFeeFactor)}
+	}
+	return fee, nil
+}
+
+// accelerableOutput returns the first unspent coin output of the transaction,
+// locked by an unlock hash condition owned by the wallet.
+func accelerableOutput(w modules.Wallet, txn types.Transaction, spent map[types.CoinOutputID]struct{}) (types.CoinOutputID, types.CoinOutput, error) {
+	for i, co := range txn.CoinOutputs {
+		if co.Condition.ConditionType() != types.ConditionTypeUnlockHash {
+			continue
+		}
+		id := txn.CoinOutputID(uint64(i))
+		if _, ok := spent[id]; ok {
+			continue
+		}
+		_, _, err := w.GetKey(co.Condition.UnlockHash())
+		if err == modules.ErrLockedWallet {
+			return types.CoinOutputID{}, types.CoinOutput{}, err
+		}
+		if err == nil {
+			return id, co, nil
+		}
+	}
+	return types.CoinOutputID{}, types.CoinOutput{}, ErrNoAccelerableOutput
+}
+
+func spentCoinOutputs(txns []types.Transaction) map[types.CoinOutputID]struct{} {
+	spent := make(map[types.CoinOutputID]struct{})
+	for _, txn := range txns {
+		for _, ci := range txn.CoinInputs {
+			spent[ci.ParentID] = struct{}{}
+		}
+	}
+	return spent
+}
+
+func transactionFee(txn types.Transaction) types.Currency {
+	var fee types.Currency
+	for _, minerFee := range txn.MinerFees {
+		fee = fee.Add(minerFee)
+	}
+	return fee
+}
@@ -0,0 +1,50 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testFeeTransactionPool is a transaction pool only estimating fees.
+type testFeeTransactionPool struct {
+	modules.TransactionPool
+	minimumFee types.Currency
+}
+
+func (tpool *testFeeTransactionPool) FeeEstimation() (types.Currency, types.Currency) {
+	return tpool.minimumFee, tpool.minimumFee.Mul64(2)
+}
+
+func TestAccelerationFee(t *testing.T) {
+	constants := types.ChainConstants{MinimumTransactionFee: types.NewCurrency64(10)}
+	testCases := []struct {
+		estimate, fee, expected uint64
+		valid                   bool
+	}{
+		// the default fee is a multiple of the estimated minimum fee
+		{estimate: 20, fee: 0, expected: 20 * DefaultAccelerationFeeFactor, valid: true},
+		// an estimate below the minimum transaction fee is ignored
+		{estimate: 1, fee: 0, expected: 10 * DefaultAccelerationFeeFactor, valid: true},
+		{estimate: 20, fee: 10, expected: 10, valid: true},
+		{estimate: 20, fee: 9},
+		{estimate: 20, fee: 20 * MaxAccelerationFeeFactor, expected: 20 * MaxAccelerationFeeFactor, valid: true},
+		{estimate: 20, fee: 20*MaxAccelerationFeeFactor + 1},
+		{estimate: 1, fee: 10*MaxAccelerationFeeFactor + 1},
+	}
+	for i, tc := range testCases {
+		fee, err := AccelerationFee(&testFeeTransactionPool{minimumFee: types.NewCurrency64(tc.estimate)}, types.NewCurrency64(tc.fee), constants)
+		if !tc.valid {
+			if _, ok := err.(InvalidAccelerationFeeError); !ok {
+				t.Errorf("#%d: expected fee %d to be refused, got %v (%v)", i, tc.fee, fee, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		} else if !fee.Equals64(tc.expected) {
+			t.Errorf("#%d: expected fee %d, got %s", i, tc.expected, fee.String())
+		}
+	}
+}
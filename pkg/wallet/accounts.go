@@ -0,0 +1,406 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+// AccountsFile is the name of the file listing the sub-accounts of the wallet, stored in the wallet directory.
+const AccountsFile = "accounts.json"
+
+var (
+	// ErrAccountExists is returned in case an account is created with a label already in use.
+	ErrAccountExists = errors.New("an account with that label already exists")
+	// ErrUnknownAccount is returned in case no account exists with a given label.
+	ErrUnknownAccount = errors.New("unknown account")
+	// ErrInvalidAccountLabel is returned in case an account label is empty, too long or contains invalid characters.
+	ErrInvalidAccountLabel = fmt.Errorf(
+		"an account label has to start with a letter, consist only of letters, digits, '-', '_' and '.', and be at most %d characters long",
+		MaxContactNameLength)
+	// ErrAccountAddressesExhausted is returned in case all addresses of an account are generated already.
+	ErrAccountAddressesExhausted = fmt.Errorf("all %d addresses of the account are generated", modules.PublicKeysPerSeed)
+)
+
+var (
+	accountsMetadata = persist.Metadata{
+		Header:  "Goldchain Wallet Accounts",
+		Version: "1.0",
+	}
+	accountSeedSpecifier = types.Specifier{'a', 'c', 'c', 'o', 'u', 'n', 't'}
+)
+
+// Account is a labeled sub-account of the wallet, backed by its own branch of the primary seed.
+type Account struct {
+	Label string `json:"label"`
+	// Addresses are the addresses handed out for the account, in the order they were generated
+	Addresses []types.UnlockHash `json:"addresses"`
+}
+
+// AccountBalance contains the confirmed balance of an account, or of a selection of labeled addresses.
+type AccountBalance struct {
+	// Spendable is the value of the unlocked coin outputs of the account
+	Spendable types.Currency `json:"spendable"`
+	// Locked is the value of the time-locked coin outputs of the account
+	Locked types.Currency `json:"locked"`
+	// BlockStakes is the value of the unlocked blockstake outputs of the account
+	BlockStakes types.Currency `json:"blockstakes"`
+}
+
+// AccountTransaction is a confirmed transaction involving an account, or a selection of labeled addresses.
+type AccountTransaction struct {
+	ID        types.TransactionID `json:"id"`
+	Height    types.BlockHeight   `json:"height"`
+	Timestamp types.Timestamp     `json:"timestamp"`
+	// Received is the value of the coins paid to the account, refunds and miner payouts included
+	Received types.Currency `json:"received"`
+	// Spent is the value of the coin outputs of the account spent by the transaction
+	Spent types.Currency `json:"spent"`
+}
+
+// DeriveAccountSeed derives the seed of the account with the given label from the primary seed of a wallet,
+// such that the same mnemonic restores every account by recreating it using the same label.
+func DeriveAccountSeed(primary modules.Seed, label string) modules.Seed {
+	var accountSeed modules.Seed
+	hash := crypto.HashAll(accountSeedSpecifier, primary, label)
+	copy(accountSeed[:], hash[:])
+	return accountSeed
+}
+
+// Accounts manages the labeled sub-accounts of a wallet, such as "faucet", "hot" and "cold-change",
+// allowing a single seed to back multiple logical wallets.
+//
+// The seed of every account is derived from the primary seed and the label of the account,
+// and loaded into the wallet as an auxiliary seed, such that the wallet tracks and can spend its outputs.
+// Balances and history of an account are those of the addresses derived from its seed.
+// The outputs of an account are only used to fund a transaction if the account is selected explicitly,
+// see Funding. As the addresses of an account are derived from the primary seed, most calls require the wallet to be unlocked.
+type Accounts struct {
+	w        modules.Wallet
+	filename string
+
+	mu       sync.Mutex
+	accounts map[string]*Account
+	// keys contains the addresses of the seed of every account, derived once the wallet is unlocked,
+	// the address sets are never modified once derived
+	keys map[string]map[types.UnlockHash]struct{}
+}
+
+// accountsFile is the persisted form of the accounts.
+type accountsFile struct {
+	Accounts []Account `json:"accounts"`
+}
+
+// NewAccounts loads the accounts of the given wallet stored in the given file,
+// which is only created once the first account is created.
+func NewAccounts(w modules.Wallet, filename string) (*Accounts, error) {
+	a := &Accounts{
+		w:        w,
+		filename: filename,
+		accounts: make(map[string]*Account),
+		keys:     make(map[string]map[types.UnlockHash]struct{}),
+	}
+	var file accountsFile
+	err := persist.LoadJSON(accountsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load wallet accounts: %v", err)
+	}
+	for i := range file.Accounts {
+		a.accounts[file.Accounts[i].Label] = &file.Accounts[i]
+	}
+	return a, nil
+}
+
+// Create creates the account with the given label, loading its seed into the (unlocked) wallet.
+// The passphrase is the one the wallet is encrypted with, and empty for a plain wallet.
+// Creating an account of a restored wallet restores the account as well.
+func (a *Accounts) Create(label, passphrase string) (Account, error) {
+	if !isValidName(label) {
+		return Account{}, ErrInvalidAccountLabel
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.accounts[label]; ok {
+		return Account{}, ErrAccountExists
+	}
+	primary, _, err := a.w.PrimarySeed()
+	if err != nil {
+		return Account{}, err
+	}
+	accountSeed := DeriveAccountSeed(primary, label)
+	seeds, err := a.w.AllSeeds()
+	if err != nil {
+		return Account{}, err
+	}
+	if !containsSeed(seeds, accountSeed) {
+		if passphrase == "" {
+			err = a.w.LoadPlainSeed(accountSeed)
+		} else {
+			err = a.w.LoadSeed(crypto.TwofishKey(crypto.HashObject(passphrase)), accountSeed)
+		}
+		if err != nil {
+			return Account{}, fmt.Errorf("failed to load the seed of the account: %v", err)
+		}
+	}
+	account := &Account{Label: label, Addresses: []types.UnlockHash{}}
+	a.accounts[label] = account
+	err = a.save()
+	if err != nil {
+		delete(a.accounts, label)
+		return Account{}, err
+	}
+	a.keys[label] = accountAddresses(accountSeed)
+	return copyAccount(account), nil
+}
+
+// Accounts returns all accounts, sorted by label.
+func (a *Accounts) Accounts() []Account {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	accounts := make([]Account, 0, len(a.accounts))
+	for _, account := range a.accounts {
+		accounts = append(accounts, copyAccount(account))
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Label < accounts[j].Label
+	})
+	return accounts
+}
+
+// Account returns the account with the given label.
+func (a *Accounts) Account(label string) (Account, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	account, ok := a.accounts[label]
+	if !ok {
+		return Account{}, ErrUnknownAccount
+	}
+	return copyAccount(account), nil
+}
+
+// NextAddress generates the next address of the account with the given label.
+func (a *Accounts) NextAddress(label string) (types.UnlockHash, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	account, ok := a.accounts[label]
+	if !ok {
+		return types.UnlockHash{}, ErrUnknownAccount
+	}
+	index := uint64(len(account.Addresses))
+	if index >= modules.PublicKeysPerSeed {
+		return types.UnlockHash{}, ErrAccountAddressesExhausted
+	}
+	primary, _, err := a.w.PrimarySeed()
+	if err != nil {
+		return types.UnlockHash{}, err
+	}
+	address := seed.Address(DeriveAccountSeed(primary, label), index)
+	account.Addresses = append(account.Addresses, address)
+	err = a.save()
+	if err != nil {
+		account.Addresses = account.Addresses[:index]
+		return types.UnlockHash{}, err
+	}
+	return address, nil
+}
+
+// Balance returns the confirmed balance of the account with the given label.
+func (a *Accounts) Balance(label string) (AccountBalance, error) {
+	owns, err := a.Funding(label)
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	return balanceOf(a.w, owns)
+}
+
+// Transactions returns the confirmed transactions involving the account with the given label,
+// lowest height first.
+func (a *Accounts) Transactions(label string) ([]AccountTransaction, error) {
+	owns, err := a.Funding(label)
+	if err != nil {
+		return nil, err
+	}
+	return transactionsOf(a.w, owns)
+}
+
+// Funding returns whether an address belongs to the account with the given label,
+// used as the Funding filter of the build options to send from that account only.
+// For an empty label it returns whether an address belongs to none of the accounts,
+// such that the funds of the accounts are kept apart from those of the wallet itself.
+func (a *Accounts) Funding(label string) (func(types.UnlockHash) bool, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if label != "" {
+		if _, ok := a.accounts[label]; !ok {
+			return nil, ErrUnknownAccount
+		}
+	}
+	err := a.deriveKeys()
+	if err != nil {
+		return nil, err
+	}
+	if label != "" {
+		keys := a.keys[label]
+		return func(address types.UnlockHash) bool {
+			_, ok := keys[address]
+			return ok
+		}, nil
+	}
+	all := make([]map[types.UnlockHash]struct{}, 0, len(a.keys))
+	for _, keys := range a.keys {
+		all = append(all, keys)
+	}
+	return func(address types.UnlockHash) bool {
+		for _, keys := range all {
+			if _, ok := keys[address]; ok {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// Empty returns true in case no account exists.
+func (a *Accounts) Empty() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.accounts) == 0
+}
+
+// deriveKeys derives the addresses of the accounts of which they are not derived yet,
+// which requires the wallet to be unlocked should there be any, the caller holding mu.
+func (a *Accounts) deriveKeys() error {
+	if len(a.keys) == len(a.accounts) {
+		return nil
+	}
+	primary, _, err := a.w.PrimarySeed()
+	if err != nil {
+		return err
+	}
+	for label := range a.accounts {
+		if _, ok := a.keys[label]; !ok {
+			a.keys[label] = accountAddresses(DeriveAccountSeed(primary, label))
+		}
+	}
+	return nil
+}
+
+// save stores the accounts, the caller holding mu.
+func (a *Accounts) save() error {
+	var file accountsFile
+	for _, account := range a.accounts {
+		file.Accounts = append(file.Accounts, *account)
+	}
+	sort.Slice(file.Accounts, func(i, j int) bool {
+		return file.Accounts[i].Label < file.Accounts[j].Label
+	})
+	err := persist.SaveJSON(accountsMetadata, file, a.filename)
+	if err != nil {
+		return fmt.Errorf("failed to store wallet accounts: %v", err)
+	}
+	return nil
+}
+
+// accountAddresses returns all addresses of the given account seed the wallet tracks.
+func accountAddresses(accountSeed modules.Seed) map[types.UnlockHash]struct{} {
+	addresses := make(map[types.UnlockHash]struct{}, modules.PublicKeysPerSeed)
+	for index := uint64(0); index < modules.PublicKeysPerSeed; index++ {
+		addresses[seed.Address(accountSeed, index)] = struct{}{}
+	}
+	return addresses
+}
+
+func containsSeed(seeds []modules.Seed, s modules.Seed) bool {
+	for _, known := range seeds {
+		if known == s {
+			return true
+		}
+	}
+	return false
+}
+
+func copyAccount(account *Account) Account {
+	return Account{
+		Label:     account.Label,
+		Addresses: append([]types.UnlockHash{}, account.Addresses...),
+	}
+}
+
+// balanceOf returns the confirmed balance of the addresses of the wallet selected by owns.
+func balanceOf(w modules.Wallet, owns func(types.UnlockHash) bool) (AccountBalance, error) {
+	unlocked, unlockedBlockStakes, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	locked, _, err := w.LockedUnspendOutputs()
+	if err != nil {
+		return AccountBalance{}, err
+	}
+	var balance AccountBalance
+	for _, co := range unlocked {
+		if owns(co.Condition.UnlockHash()) {
+			balance.Spendable = balance.Spendable.Add(co.Value)
+		}
+	}
+	for _, co := range locked {
+		if owns(co.Condition.UnlockHash()) {
+			balance.Locked = balance.Locked.Add(co.Value)
+		}
+	}
+	for _, bso := range unlockedBlockStakes {
+		if owns(bso.Condition.UnlockHash()) {
+			balance.BlockStakes = balance.BlockStakes.Add(bso.Value)
+		}
+	}
+	return balance, nil
+}
+
+// transactionsOf returns the confirmed transactions involving the addresses of the wallet selected by owns,
+// lowest height first.
+func transactionsOf(w modules.Wallet, owns func(types.UnlockHash) bool) ([]AccountTransaction, error) {
+	pts, err := w.Transactions(0, math.MaxUint64)
+	if err != nil {
+		return nil, err
+	}
+	txns := []AccountTransaction{}
+	for _, pt := range pts {
+		txn := AccountTransaction{
+			ID:        pt.TransactionID,
+			Height:    pt.ConfirmationHeight,
+			Timestamp: pt.ConfirmationTimestamp,
+		}
+		involved := false
+		for _, input := range pt.Inputs {
+			if !owns(input.RelatedAddress) {
+				continue
+			}
+			involved = true
+			if input.FundType == types.SpecifierCoinInput {
+				txn.Spent = txn.Spent.Add(input.Value)
+			}
+		}
+		for _, output := range pt.Outputs {
+			if !owns(output.RelatedAddress) {
+				continue
+			}
+			involved = true
+			if output.FundType == types.SpecifierCoinOutput || output.FundType == types.SpecifierMinerPayout {
+				txn.Received = txn.Received.Add(output.Value)
+			}
+		}
+		if involved {
+			txns = append(txns, txn)
+		}
+	}
+	return txns, nil
+}
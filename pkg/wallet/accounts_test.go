@@ -0,0 +1,121 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+// testAccountsWallet is an unlocked plain wallet of which the unlocked outputs are given.
+type testAccountsWallet struct {
+	modules.Wallet
+	primary modules.Seed
+	seeds   []modules.Seed
+	unspent map[types.CoinOutputID]types.CoinOutput
+}
+
+func (w *testAccountsWallet) PrimarySeed() (modules.Seed, uint64, error) { return w.primary, 0, nil }
+func (w *testAccountsWallet) AllSeeds() ([]modules.Seed, error)          { return w.seeds, nil }
+func (w *testAccountsWallet) LoadPlainSeed(s modules.Seed) error {
+	w.seeds = append(w.seeds, s)
+	return nil
+}
+func (w *testAccountsWallet) UnlockedUnspendOutputs() (map[types.CoinOutputID]types.CoinOutput, map[types.BlockStakeOutputID]types.BlockStakeOutput, error) {
+	return w.unspent, nil, nil
+}
+func (w *testAccountsWallet) LockedUnspendOutputs() (map[types.CoinOutputID]types.CoinOutput, map[types.BlockStakeOutputID]types.BlockStakeOutput, error) {
+	return nil, nil, nil
+}
+
+func TestAccounts(t *testing.T) {
+	w := &testAccountsWallet{primary: modules.Seed{1}, unspent: make(map[types.CoinOutputID]types.CoinOutput)}
+	w.seeds = []modules.Seed{w.primary}
+	filename := filepath.Join(t.TempDir(), AccountsFile)
+	accounts, err := NewAccounts(w, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !accounts.Empty() {
+		t.Fatal("expected no accounts")
+	}
+	if _, err := accounts.Create("1st", ""); err != ErrInvalidAccountLabel {
+		t.Errorf("expected an invalid label, got %v", err)
+	}
+	for _, label := range []string{"hot", "faucet"} {
+		if _, err := accounts.Create(label, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := accounts.Create("hot", ""); err != ErrAccountExists {
+		t.Errorf("expected the account to exist, got %v", err)
+	}
+	// the seed of every account is loaded into the wallet
+	hotSeed := DeriveAccountSeed(w.primary, "hot")
+	if len(w.seeds) != 3 || w.seeds[1] != hotSeed || hotSeed == DeriveAccountSeed(w.primary, "faucet") {
+		t.Fatal("expected the distinct seeds of both accounts to be loaded")
+	}
+
+	address, err := accounts.NextAddress("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address != seed.Address(hotSeed, 0) {
+		t.Errorf("expected the first address of the account seed, got %s", address.String())
+	}
+	if _, err := accounts.NextAddress("cold"); err != ErrUnknownAccount {
+		t.Errorf("expected an unknown account, got %v", err)
+	}
+
+	// the funds of an account are only those of its own addresses
+	pay := func(value uint64, to types.UnlockHash) {
+		w.unspent[types.CoinOutputID{byte(len(w.unspent) + 1)}] = types.CoinOutput{
+			Value:     types.NewCurrency64(value),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(to)),
+		}
+	}
+	pay(10, address)
+	pay(20, seed.Address(hotSeed, 42)) // not handed out, but derived from the account seed
+	pay(40, seed.Address(DeriveAccountSeed(w.primary, "faucet"), 0))
+	pay(80, seed.Address(w.primary, 0))
+	balance, err := accounts.Balance("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Spendable.Equals64(30) {
+		t.Errorf("expected the hot account to own 30, got %s", balance.Spendable.String())
+	}
+	own, err := accounts.Funding("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !own(seed.Address(w.primary, 0)) || own(address) {
+		t.Error("expected only the addresses of the primary seed to fund transactions without an account")
+	}
+
+	// accounts are restored using the same label, their seed being loaded already
+	accounts, err = NewAccounts(w, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := accounts.Account("hot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(account.Addresses) != 1 || account.Addresses[0] != address {
+		t.Errorf("expected the generated address to be stored, got %v", account.Addresses)
+	}
+	accounts, err = NewAccounts(w, filepath.Join(t.TempDir(), AccountsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := accounts.Create("hot", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.seeds) != 3 {
+		t.Errorf("expected the seed of a restored account not to be loaded twice, got %d seeds", len(w.seeds))
+	}
+}
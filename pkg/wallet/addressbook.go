@@ -0,0 +1,279 @@
+package wallet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// AddressBookFile is the name of the address book file, stored in the wallet directory.
+	AddressBookFile = "addressbook.json"
+	// MaxContactNameLength is the maximum length of the name of a contact.
+	MaxContactNameLength = 64
+)
+
+var (
+	// ErrUnknownContact is returned in case no contact exists with a given name.
+	ErrUnknownContact = errors.New("unknown contact")
+	// ErrContactExists is returned in case a contact is added with a name already in use.
+	ErrContactExists = errors.New("a contact with that name already exists")
+	// ErrInvalidContactName is returned in case a contact name is empty, too long or contains invalid characters.
+	ErrInvalidContactName = fmt.Errorf(
+		"a contact name has to start with a letter, consist only of letters, digits, '-', '_' and '.', and be at most %d characters long",
+		MaxContactNameLength)
+	// ErrNilContactAddress is returned in case a contact is given the nil address.
+	ErrNilContactAddress = errors.New("the address of a contact cannot be the nil address")
+	// ErrForeignAddressBook is returned in case the address book cannot be decrypted,
+	// as it was encrypted using another seed than the primary seed of the wallet.
+	ErrForeignAddressBook = errors.New("address book was not encrypted using the primary seed of the wallet")
+)
+
+var (
+	addressBookMetadata = persist.Metadata{
+		Header:  "Goldchain Address Book",
+		Version: "1.0",
+	}
+	addressBookKeySpecifier = types.Specifier{'a', 'd', 'd', 'r', 'e', 's', 's', 'b', 'o', 'o', 'k'}
+)
+
+// Contact is a named address of the address book.
+type Contact struct {
+	Name    string           `json:"name"`
+	Address types.UnlockHash `json:"address"`
+	// Created and Updated are unix epoch timestamps (in seconds)
+	Created types.Timestamp `json:"created"`
+	Updated types.Timestamp `json:"updated"`
+}
+
+// PrimarySeedGetter is the part of the wallet used to derive the encryption key of the address book.
+type PrimarySeedGetter interface {
+	PrimarySeed() (modules.Seed, uint64, error)
+}
+
+// AddressBook keeps the named contacts of a wallet on disk,
+// encrypted using a key derived from the primary seed of the wallet.
+// As a consequence the address book can only be used while the wallet is unlocked.
+type AddressBook struct {
+	mu       sync.Mutex
+	wallet   PrimarySeedGetter
+	filename string
+}
+
+// addressBookFile is the persisted form of the address book.
+type addressBookFile struct {
+	Contacts crypto.Ciphertext `json:"contacts"`
+}
+
+// NewAddressBook creates an address book, persisted in the given file,
+// which is only created once the first contact is added.
+func NewAddressBook(w PrimarySeedGetter, filename string) *AddressBook {
+	return &AddressBook{
+		wallet:   w,
+		filename: filename,
+	}
+}
+
+// ValidateContactName returns ErrInvalidContactName in case the given name cannot be used for a contact.
+// Valid names can never be mistaken for an address or condition.
+func ValidateContactName(name string) error {
+	if !isValidName(name) {
+		return ErrInvalidContactName
+	}
+	return nil
+}
+
+// isValidName returns whether the given name starts with a letter, consists only of letters, digits, '-', '_' and '.',
+// and is at most MaxContactNameLength characters long.
+func isValidName(name string) bool {
+	if name == "" || len(name) > MaxContactNameLength {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Contacts returns all contacts, sorted by name.
+func (ab *AddressBook) Contacts() ([]Contact, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	contacts, _, err := ab.load()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Contact, 0, len(contacts))
+	for _, contact := range contacts {
+		list = append(list, contact)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// Contact returns the contact with the given name.
+func (ab *AddressBook) Contact(name string) (Contact, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	contacts, _, err := ab.load()
+	if err != nil {
+		return Contact{}, err
+	}
+	contact, ok := contacts[name]
+	if !ok {
+		return Contact{}, ErrUnknownContact
+	}
+	return contact, nil
+}
+
+// AddContact adds a new contact with the given name and address.
+func (ab *AddressBook) AddContact(name string, address types.UnlockHash) (Contact, error) {
+	err := ValidateContactName(name)
+	if err != nil {
+		return Contact{}, err
+	}
+	if address.Type == types.UnlockTypeNil {
+		return Contact{}, ErrNilContactAddress
+	}
+	return ab.update(name, func(contact Contact, exists bool) (Contact, error) {
+		if exists {
+			return Contact{}, ErrContactExists
+		}
+		now := types.Timestamp(time.Now().Unix())
+		return Contact{
+			Name:    name,
+			Address: address,
+			Created: now,
+			Updated: now,
+		}, nil
+	})
+}
+
+// UpdateContact updates the address of the contact with the given name.
+func (ab *AddressBook) UpdateContact(name string, address types.UnlockHash) (Contact, error) {
+	if address.Type == types.UnlockTypeNil {
+		return Contact{}, ErrNilContactAddress
+	}
+	return ab.update(name, func(contact Contact, exists bool) (Contact, error) {
+		if !exists {
+			return Contact{}, ErrUnknownContact
+		}
+		contact.Address = address
+		contact.Updated = types.Timestamp(time.Now().Unix())
+		return contact, nil
+	})
+}
+
+// RemoveContact removes the contact with the given name.
+func (ab *AddressBook) RemoveContact(name string) error {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	contacts, key, err := ab.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := contacts[name]; !ok {
+		return ErrUnknownContact
+	}
+	delete(contacts, name)
+	return ab.save(contacts, key)
+}
+
+// update applies the given function to the (possibly non-existing) contact with the given name,
+// persisting the contact it returns.
+func (ab *AddressBook) update(name string, fn func(contact Contact, exists bool) (Contact, error)) (Contact, error) {
+	ab.mu.Lock()
+	defer ab.mu.Unlock()
+	contacts, key, err := ab.load()
+	if err != nil {
+		return Contact{}, err
+	}
+	contact, ok := contacts[name]
+	contact, err = fn(contact, ok)
+	if err != nil {
+		return Contact{}, err
+	}
+	contacts[name] = contact
+	err = ab.save(contacts, key)
+	if err != nil {
+		return Contact{}, err
+	}
+	return contact, nil
+}
+
+// load reads and decrypts the contacts, returning the key used to decrypt them,
+// the address book's lock has to be held by the caller.
+func (ab *AddressBook) load() (map[string]Contact, crypto.TwofishKey, error) {
+	key, err := ab.key()
+	if err != nil {
+		return nil, crypto.TwofishKey{}, err
+	}
+	contacts := make(map[string]Contact)
+	var file addressBookFile
+	err = persist.LoadJSON(addressBookMetadata, &file, ab.filename)
+	if os.IsNotExist(err) {
+		return contacts, key, nil
+	}
+	if err != nil {
+		return nil, crypto.TwofishKey{}, fmt.Errorf("failed to load address book: %v", err)
+	}
+	plaintext, err := key.DecryptBytes(file.Contacts)
+	if err != nil {
+		return nil, crypto.TwofishKey{}, ErrForeignAddressBook
+	}
+	var list []Contact
+	err = json.Unmarshal(plaintext, &list)
+	if err != nil {
+		return nil, crypto.TwofishKey{}, fmt.Errorf("failed to decode address book: %v", err)
+	}
+	for _, contact := range list {
+		contacts[contact.Name] = contact
+	}
+	return contacts, key, nil
+}
+
+// save encrypts and writes the contacts,
+// the address book's lock has to be held by the caller.
+func (ab *AddressBook) save(contacts map[string]Contact, key crypto.TwofishKey) error {
+	list := make([]Contact, 0, len(contacts))
+	for _, contact := range contacts {
+		list = append(list, contact)
+	}
+	plaintext, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to encode address book: %v", err)
+	}
+	err = persist.SaveJSON(addressBookMetadata, addressBookFile{
+		Contacts: key.EncryptBytes(plaintext),
+	}, ab.filename)
+	if err != nil {
+		return fmt.Errorf("failed to save address book: %v", err)
+	}
+	return nil
+}
+
+// key derives the encryption key of the address book from the primary seed of the wallet,
+// returning modules.ErrLockedWallet in case the wallet is locked.
+func (ab *AddressBook) key() (crypto.TwofishKey, error) {
+	seed, _, err := ab.wallet.PrimarySeed()
+	if err != nil {
+		return crypto.TwofishKey{}, err
+	}
+	return crypto.TwofishKey(crypto.HashAll(seed, addressBookKeySpecifier)), nil
+}
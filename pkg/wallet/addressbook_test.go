@@ -0,0 +1,100 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testSeedGetter struct {
+	seed   modules.Seed
+	locked bool
+}
+
+func (g *testSeedGetter) PrimarySeed() (modules.Seed, uint64, error) {
+	if g.locked {
+		return modules.Seed{}, 0, modules.ErrLockedWallet
+	}
+	return g.seed, 0, nil
+}
+
+func TestAddressBook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "addressbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "addressbook.json")
+
+	w := &testSeedGetter{seed: modules.Seed{1}}
+	ab := NewAddressBook(w, filename)
+	alice := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	bob := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+
+	if _, err = ab.AddContact("alice", alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.AddContact("bob", alice); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.AddContact("alice", bob); err != ErrContactExists {
+		t.Errorf("expected %v, got %v", ErrContactExists, err)
+	}
+	if _, err = ab.UpdateContact("bob", bob); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.UpdateContact("bob", types.NilUnlockHash); err != ErrNilContactAddress {
+		t.Errorf("expected %v, got %v", ErrNilContactAddress, err)
+	}
+	if _, err = ab.UpdateContact("carol", bob); err != ErrUnknownContact {
+		t.Errorf("expected %v, got %v", ErrUnknownContact, err)
+	}
+
+	// contacts are persisted, and can only be read using the same seed
+	ab = NewAddressBook(w, filename)
+	contacts, err := ab.Contacts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 2 || contacts[0].Name != "alice" || contacts[0].Address.Cmp(alice) != 0 ||
+		contacts[1].Name != "bob" || contacts[1].Address.Cmp(bob) != 0 {
+		t.Errorf("unexpected contacts: %v", contacts)
+	}
+	if _, err = NewAddressBook(&testSeedGetter{seed: modules.Seed{2}}, filename).Contacts(); err != ErrForeignAddressBook {
+		t.Errorf("expected %v, got %v", ErrForeignAddressBook, err)
+	}
+	w.locked = true
+	if _, err = ab.Contact("alice"); err != modules.ErrLockedWallet {
+		t.Errorf("expected %v, got %v", modules.ErrLockedWallet, err)
+	}
+	w.locked = false
+
+	if err = ab.RemoveContact("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.Contact("alice"); err != ErrUnknownContact {
+		t.Errorf("expected %v, got %v", ErrUnknownContact, err)
+	}
+	if err = ab.RemoveContact("alice"); err != ErrUnknownContact {
+		t.Errorf("expected %v, got %v", ErrUnknownContact, err)
+	}
+}
+
+func TestValidateContactName(t *testing.T) {
+	for _, name := range []string{"alice", "Bob.Smith", "c-3po_2"} {
+		if err := ValidateContactName(name); err != nil {
+			t.Errorf("expected %q to be valid: %v", name, err)
+		}
+	}
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1}).String()
+	for _, name := range []string{"", "3po", "-alice", "alice smith", "alice(", uh, string(make([]byte, MaxContactNameLength+1))} {
+		if err := ValidateContactName(name); err != ErrInvalidContactName {
+			t.Errorf("expected %q to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// AddressUsage describes how coins were received by an address of the primary seed of the wallet.
+type AddressUsage struct {
+	// Index is the index of the address within the primary seed
+	Index   uint64           `json:"index"`
+	Address types.UnlockHash `json:"address"`
+	// Deposits is the amount of transactions not funded by the wallet, which paid coins to the address
+	Deposits int `json:"deposits"`
+	// ChangeOutputs is the amount of coin outputs paid to the address by transactions funded by the wallet
+	ChangeOutputs int `json:"changeoutputs"`
+	// MinerPayouts is the amount of block rewards and fees paid to the address
+	MinerPayouts int `json:"minerpayouts"`
+	// Received is the total value of all coins received by the address
+	Received types.Currency `json:"received"`
+	// Reused is true in case the address received more than one deposit,
+	// or received deposits as well as change
+	Reused bool `json:"reused"`
+}
+
+// AddressReport describes how the addresses of the primary seed of the wallet are used,
+// in support of a policy of using every deposit address only once.
+type AddressReport struct {
+	// Height is the consensus height the wallet was analysed at
+	Height types.BlockHeight `json:"height"`
+
+	// GeneratedAddresses is the amount of addresses generated from the primary seed,
+	// including the addresses the wallet preloads but did not hand out yet
+	GeneratedAddresses uint64 `json:"generatedaddresses"`
+	// UsedAddresses is the amount of addresses of the primary seed which received coins
+	UsedAddresses int `json:"usedaddresses"`
+	// HighestUsedIndex is the index of the highest address which received coins, undefined if none did
+	HighestUsedIndex *uint64 `json:"highestusedindex,omitempty"`
+	// Gap is the amount of generated addresses following the highest used address
+	Gap uint64 `json:"gap"`
+	// RecoveryDepth is the amount of addresses restored when recovering the wallet from its seed,
+	// coins received by addresses beyond that depth are only found once enough addresses are generated again
+	RecoveryDepth uint64 `json:"recoverydepth"`
+
+	// Addresses contains all used addresses, lowest index first
+	Addresses []AddressUsage `json:"addresses"`
+	// ReusedAddresses is the amount of used addresses which are reused
+	ReusedAddresses int `json:"reusedaddresses"`
+	// ChangeAddresses is the amount of used addresses which received change
+	ChangeAddresses int `json:"changeaddresses"`
+
+	// Recommendations are human-readable suggestions to resolve the issues found
+	Recommendations []string `json:"recommendations"`
+}
+
+// AnalyseAddresses reports which addresses of the primary seed of the wallet received coins,
+// distinguishing deposits from change, based on all (confirmed and unconfirmed) transactions of the wallet.
+// The analysis stops early should the given context be done.
+func AnalyseAddresses(ctx context.Context, w modules.Wallet, cs modules.ConsensusSet) (AddressReport, error) {
+	seed, progress, err := w.PrimarySeed()
+	if err != nil {
+		return AddressReport{}, err
+	}
+	report := AddressReport{
+		Height:             cs.Height(),
+		GeneratedAddresses: progress + modules.WalletSeedPreloadDepth,
+		RecoveryDepth:      modules.PublicKeysPerSeed,
+	}
+	indices := make(map[types.UnlockHash]uint64, report.GeneratedAddresses)
+	for index := uint64(0); index < report.GeneratedAddresses; index++ {
+		if index%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return AddressReport{}, err
+			}
+		}
+		_, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, index))
+		indices[types.NewEd25519PubKeyUnlockHash(pk)] = index
+	}
+
+	confirmed, err := w.Transactions(0, report.Height)
+	if err != nil {
+		return AddressReport{}, err
+	}
+	unconfirmed, err := w.UnconfirmedTransactions()
+	if err != nil {
+		return AddressReport{}, err
+	}
+	usages := make(map[uint64]*AddressUsage)
+	for _, pt := range append(confirmed, unconfirmed...) {
+		if err := ctx.Err(); err != nil {
+			return AddressReport{}, err
+		}
+		var funded bool
+		for _, input := range pt.Inputs {
+			if input.WalletAddress {
+				funded = true
+				break
+			}
+		}
+		deposited := make(map[uint64]struct{})
+		for _, output := range pt.Outputs {
+			if !output.WalletAddress {
+				continue
+			}
+			index, ok := indices[output.RelatedAddress]
+			if !ok {
+				continue // not an address of the primary seed
+			}
+			usage, ok := usages[index]
+			if !ok {
+				usage = &AddressUsage{Index: index, Address: output.RelatedAddress}
+				usages[index] = usage
+			}
+			switch output.FundType {
+			case types.SpecifierMinerPayout:
+				usage.MinerPayouts++
+			case types.SpecifierCoinOutput:
+				if funded {
+					usage.ChangeOutputs++
+				} else if _, ok := deposited[index]; !ok {
+					deposited[index] = struct{}{}
+					usage.Deposits++
+				}
+			default:
+				continue
+			}
+			usage.Received = usage.Received.Add(output.Value)
+		}
+	}
+
+	report.Addresses = make([]AddressUsage, 0, len(usages))
+	for index := uint64(0); index < report.GeneratedAddresses && len(report.Addresses) < len(usages); index++ {
+		usage, ok := usages[index]
+		if !ok {
+			continue
+		}
+		usage.Reused = usage.Deposits > 1 || (usage.Deposits > 0 && usage.ChangeOutputs > 0)
+		if usage.Reused {
+			report.ReusedAddresses++
+		}
+		if usage.ChangeOutputs > 0 {
+			report.ChangeAddresses++
+		}
+		report.Addresses = append(report.Addresses, *usage)
+	}
+	report.UsedAddresses = len(report.Addresses)
+	if report.UsedAddresses > 0 {
+		highest := report.Addresses[report.UsedAddresses-1].Index
+		report.HighestUsedIndex = &highest
+		report.Gap = report.GeneratedAddresses - highest - 1
+	} else {
+		report.Gap = report.GeneratedAddresses
+	}
+	report.Recommendations = addressRecommendations(report)
+	return report, nil
+}
+
+// addressRecommendations returns suggestions to resolve the issues found in the given report.
+func addressRecommendations(report AddressReport) []string {
+	var recommendations []string
+	var multipleDeposits, mixed int
+	for _, usage := range report.Addresses {
+		if usage.Deposits > 1 {
+			multipleDeposits++
+		}
+		if usage.Deposits > 0 && usage.ChangeOutputs > 0 {
+			mixed++
+		}
+	}
+	if multipleDeposits > 0 {
+		recommendations = append(recommendations, fmt.Sprintf(
+			"%d address(es) received more than one deposit: hand out a new address for every deposit", multipleDeposits))
+	}
+	if mixed > 0 {
+		recommendations = append(recommendations, fmt.Sprintf(
+			"%d address(es) received both deposits and change: send change to new addresses, rather than reusing the address of a spent input", mixed))
+	}
+	if report.HighestUsedIndex != nil && *report.HighestUsedIndex >= report.RecoveryDepth {
+		recommendations = append(recommendations, fmt.Sprintf(
+			"addresses up to index %d received coins, while only the first %d addresses are restored when recovering the wallet from its seed: "+
+				"generate at least %d new addresses after recovering it", *report.HighestUsedIndex, report.RecoveryDepth,
+			*report.HighestUsedIndex+1-report.RecoveryDepth))
+	}
+	return recommendations
+}
+
+// CountUsedSeedAddresses returns how many of the addresses restored when recovering a wallet from the given seed
+// ever received coins or block stakes, 0 meaning that the seed was never used.
+// The entire blockchain is scanned, such that the seed doesn't have to be loaded into a wallet,
+// as the wallet only rescans the blockchain for loaded seeds once it is restarted.
+func CountUsedSeedAddresses(ctx context.Context, cs modules.ConsensusSet, seed modules.Seed) (int, error) {
+	scanner := &seedScanner{
+		addresses: make(map[types.UnlockHash]bool, modules.PublicKeysPerSeed),
+	}
+	for index := uint64(0); index < modules.PublicKeysPerSeed; index++ {
+		_, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(seed, index))
+		scanner.addresses[types.NewEd25519PubKeyUnlockHash(pk)] = false
+	}
+	err := cs.ConsensusSetSubscribe(scanner, modules.ConsensusChangeBeginning, ctx.Done())
+	if err != nil {
+		return 0, err
+	}
+	cs.Unsubscribe(scanner)
+	return scanner.used, nil
+}
+
+// seedScanner marks the addresses of a seed which received coins or block stakes.
+type seedScanner struct {
+	addresses map[types.UnlockHash]bool
+	used      int
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.ProcessConsensusChange
+func (scanner *seedScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
+	for _, diff := range cc.CoinOutputDiffs {
+		scanner.markUsed(diff.CoinOutput.Condition.UnlockHash())
+	}
+	for _, diff := range cc.BlockStakeOutputDiffs {
+		scanner.markUsed(diff.BlockStakeOutput.Condition.UnlockHash())
+	}
+}
+
+func (scanner *seedScanner) markUsed(uh types.UnlockHash) {
+	if used, ok := scanner.addresses[uh]; ok && !used {
+		scanner.addresses[uh] = true
+		scanner.used++
+	}
+}
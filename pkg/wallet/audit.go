@@ -0,0 +1,235 @@
+package wallet
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// AuditFile is the name of the file logging every wallet API mutation, stored in the wallet directory.
+const AuditFile = "audit.log"
+
+type (
+	// AuditRecord is the record of a wallet API mutation, chained to the previous record by its hash,
+	// such that records cannot be altered, removed or reordered without breaking the chain.
+	AuditRecord struct {
+		// Sequence is the position of the record in the log, starting at 1
+		Sequence uint64 `json:"sequence"`
+		// Time is the unix epoch timestamp (in seconds) at which the request was handled
+		Time   types.Timestamp `json:"time"`
+		Method string          `json:"method"`
+		// Endpoint is the route of the request, Path the path it was made to
+		Endpoint string `json:"endpoint"`
+		Path     string `json:"path"`
+		// Parameters are the route and query parameters of the request
+		Parameters map[string]string `json:"parameters,omitempty"`
+		// Body is the JSON or form-encoded body of the request as a JSON object, with all secrets redacted
+		Body   json.RawMessage `json:"body,omitempty"`
+		Caller AuditCaller     `json:"caller"`
+		// Status is the HTTP status of the response
+		Status int `json:"status"`
+		// TransactionIDs identify the transactions created by the request
+		TransactionIDs []types.TransactionID `json:"transactionids,omitempty"`
+		// Error is the error the request failed with, should it have failed
+		Error string `json:"error,omitempty"`
+		// Pending is true for the record of a request which is about to be handled,
+		// of which the outcome is recorded by a later record completing it
+		Pending bool `json:"pending,omitempty"`
+		// Completes is the sequence of the pending record of which this record holds the outcome
+		Completes uint64 `json:"completes,omitempty"`
+		// PreviousHash is the hash of the previous record, empty for the first record
+		PreviousHash string `json:"previoushash"`
+		// Hash is the hex-encoded SHA-256 hash of the JSON encoding of the record without its hash
+		Hash string `json:"hash"`
+	}
+
+	// AuditCaller identifies the caller of a wallet API request.
+	AuditCaller struct {
+		// Credential names the API password the request was authenticated with, empty if none matched
+		Credential string `json:"credential,omitempty"`
+		// User is the username of the basic authentication of the request, which is not used for authentication
+		User       string `json:"user,omitempty"`
+		RemoteAddr string `json:"remoteaddr"`
+		UserAgent  string `json:"useragent,omitempty"`
+	}
+
+	// AuditHead is the last record of a verified audit log.
+	AuditHead struct {
+		// Records is the amount of records of the log
+		Records uint64 `json:"records"`
+		// Hash is the hash of the last record, empty if the log has no records
+		Hash string `json:"hash"`
+	}
+)
+
+// hash computes the hash of the record, ignoring its current hash.
+func (record AuditRecord) hash() (string, error) {
+	record.Hash = ""
+	b, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditLog is an append-only, hash-chained log of wallet API mutations, storing a JSON record per line.
+type AuditLog struct {
+	filename string
+	now      func() time.Time
+
+	mu   sync.Mutex
+	head AuditHead
+}
+
+// OpenAuditLog opens the audit log stored in the given file, which is only created once the first record is appended.
+// An existing log is verified, as new records can only be chained to an intact log.
+func OpenAuditLog(filename string) (*AuditLog, error) {
+	l := &AuditLog{filename: filename, now: time.Now}
+	file, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	l.head, err = VerifyAuditLog(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify audit log %s: %v", filename, err)
+	}
+	return l, nil
+}
+
+// Append chains the given record to the log, returning it as appended.
+// The sequence, previous hash and hash of the record are set by the log, as is its time if undefined.
+func (l *AuditLog) Append(record AuditRecord) (AuditRecord, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if record.Time == 0 {
+		record.Time = types.Timestamp(l.now().Unix())
+	}
+	record.Sequence = l.head.Records + 1
+	record.PreviousHash = l.head.Hash
+	var err error
+	record.Hash, err = record.hash()
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return AuditRecord{}, err
+	}
+	file, err := os.OpenFile(l.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if err == nil {
+		err = file.Sync()
+	}
+	if err != nil {
+		file.Close()
+		return AuditRecord{}, fmt.Errorf("failed to append audit record: %v", err)
+	}
+	if err = file.Close(); err != nil {
+		return AuditRecord{}, fmt.Errorf("failed to append audit record: %v", err)
+	}
+	l.head = AuditHead{Records: record.Sequence, Hash: record.Hash}
+	return record, nil
+}
+
+// Head returns the last record appended to the log.
+func (l *AuditLog) Head() AuditHead {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.head
+}
+
+// Export writes the log as it is stored to the given writer, returning the head of the exported log.
+// Records appended while exporting are not exported.
+func (l *AuditLog) Export(w io.Writer) (AuditHead, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		return AuditHead{}, nil
+	}
+	if err != nil {
+		return AuditHead{}, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return l.head, err
+}
+
+// Verify verifies the log as it is stored, returning its head.
+func (l *AuditLog) Verify() (AuditHead, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		if l.head.Records > 0 {
+			return AuditHead{}, fmt.Errorf("audit log %s was removed", l.filename)
+		}
+		return AuditHead{}, nil
+	}
+	if err != nil {
+		return AuditHead{}, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	defer file.Close()
+	head, err := VerifyAuditLog(file)
+	if err != nil {
+		return AuditHead{}, err
+	}
+	if head != l.head {
+		return AuditHead{}, fmt.Errorf("audit log ends with record %d (%s), while record %d (%s) was appended last",
+			head.Records, head.Hash, l.head.Records, l.head.Hash)
+	}
+	return head, nil
+}
+
+// VerifyAuditLog verifies the hash chain of the audit log read from the given reader, returning its head.
+// An error is returned for the first record which is malformed, out of sequence, or of which the hash doesn't match.
+func VerifyAuditLog(r io.Reader) (AuditHead, error) {
+	var head AuditHead
+	scanner := bufio.NewScanner(r)
+	// records aren't bounded by the default maximum line length
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var record AuditRecord
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			return AuditHead{}, fmt.Errorf("line %d: malformed record: %v", line, err)
+		}
+		if record.Sequence != head.Records+1 {
+			return AuditHead{}, fmt.Errorf("line %d: expected record %d, found record %d", line, head.Records+1, record.Sequence)
+		}
+		if record.Completes >= record.Sequence {
+			return AuditHead{}, fmt.Errorf("line %d: record %d completes record %d, which doesn't precede it", line, record.Sequence, record.Completes)
+		}
+		if record.PreviousHash != head.Hash {
+			return AuditHead{}, fmt.Errorf("line %d: record %d isn't chained to the previous record", line, record.Sequence)
+		}
+		hash, err := record.hash()
+		if err != nil {
+			return AuditHead{}, fmt.Errorf("line %d: %v", line, err)
+		}
+		if hash != record.Hash {
+			return AuditHead{}, fmt.Errorf("line %d: the hash of record %d doesn't match its content", line, record.Sequence)
+		}
+		head = AuditHead{Records: record.Sequence, Hash: record.Hash}
+	}
+	if err := scanner.Err(); err != nil {
+		return AuditHead{}, fmt.Errorf("failed to read audit log: %v", err)
+	}
+	return head, nil
+}
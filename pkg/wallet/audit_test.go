@@ -0,0 +1,91 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, AuditFile)
+
+	l, err := OpenAuditLog(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head, err := l.Verify(); err != nil || head.Records != 0 {
+		t.Fatalf("expected an empty audit log, got %+v (%v)", head, err)
+	}
+	for i, path := range []string{"/wallet/coins", "/wallet/lock", "/wallet/coins"} {
+		record, err := l.Append(AuditRecord{
+			Method:         "POST",
+			Endpoint:       path,
+			Path:           path,
+			Body:           json.RawMessage(`{"coinoutputs":[{"value":"1000"}]}`),
+			Caller:         AuditCaller{Credential: "api", RemoteAddr: "127.0.0.1:1234"},
+			Status:         200,
+			TransactionIDs: []types.TransactionID{{byte(i)}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.Sequence != uint64(i+1) || record.Hash == "" || (i == 0) != (record.PreviousHash == "") {
+			t.Errorf("unexpected record: %+v", record)
+		}
+	}
+	head := l.Head()
+	if head.Records != 3 {
+		t.Fatalf("unexpected head: %+v", head)
+	}
+
+	// the log is verified when opened, and exported as stored
+	if l, err = OpenAuditLog(filename); err != nil {
+		t.Fatal(err)
+	}
+	if l.Head() != head {
+		t.Errorf("expected head %+v, got %+v", head, l.Head())
+	}
+	var buf bytes.Buffer
+	if _, err = l.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if exported, err := VerifyAuditLog(bytes.NewReader(buf.Bytes())); err != nil || exported != head {
+		t.Fatalf("expected exported head %+v, got %+v (%v)", head, exported, err)
+	}
+
+	// altering, removing or reordering records breaks the chain
+	lines := strings.SplitAfter(buf.String(), "\n")
+	for name, tampered := range map[string]string{
+		"altered":   lines[0] + strings.Replace(lines[1], "/wallet/lock", "/wallet/unlock", 2) + lines[2],
+		"removed":   lines[0] + lines[2],
+		"reordered": lines[1] + lines[0] + lines[2],
+		"truncated": lines[0] + lines[1] + lines[2][:len(lines[2])/2],
+	} {
+		if _, err := VerifyAuditLog(strings.NewReader(tampered)); err == nil {
+			t.Errorf("expected the %s audit log to be refused", name)
+		}
+	}
+	if err = ioutil.WriteFile(filename, []byte(lines[0]+lines[1]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.Verify(); err == nil {
+		t.Error("expected the truncated audit log to be refused")
+	}
+	if err = ioutil.WriteFile(filename, []byte(lines[1]), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = OpenAuditLog(filename); err == nil {
+		t.Error("expected an audit log missing its first record to be refused")
+	}
+}
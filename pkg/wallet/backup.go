@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NebulousLabs/fastrand"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// BackupVersion is the version of the backup format created by EncryptBackup.
+	BackupVersion = 1
+	// BackupHeader identifies a goldchain wallet backup.
+	BackupHeader = "Goldchain Wallet Backup"
+
+	// backupKDF is the key derivation function used to derive the encryption key from the passphrase
+	backupKDF = "pbkdf2-sha256"
+	// backupKDFIterations is the amount of pbkdf2 iterations of backups created by EncryptBackup
+	backupKDFIterations = 200000
+	backupSaltSize      = 32
+)
+
+var (
+	// ErrInvalidBackup is returned when decoding a file which isn't a goldchain wallet backup.
+	ErrInvalidBackup = errors.New("file is not a goldchain wallet backup")
+	// ErrUnsupportedBackupVersion is returned when decoding a backup created by a newer version.
+	ErrUnsupportedBackupVersion = errors.New("unsupported wallet backup version, a newer version is required to restore it")
+	// ErrInvalidBackupPassphrase is returned when a backup cannot be decrypted using the given passphrase,
+	// or when its contents were tampered with.
+	ErrInvalidBackupPassphrase = errors.New("invalid backup passphrase or corrupted backup")
+	// ErrEmptyBackupPassphrase is returned when encrypting a backup without passphrase.
+	ErrEmptyBackupPassphrase = errors.New("a backup has to be encrypted using a non-empty passphrase")
+)
+
+// Backup contains everything needed to restore a wallet.
+type Backup struct {
+	// Network is the name of the network of the wallet
+	Network string `json:"network"`
+	// Created is the unix epoch timestamp (in seconds) at which the backup was created
+	Created types.Timestamp `json:"created"`
+	// PrimarySeed is the mnemonic of the primary seed of the wallet
+	PrimarySeed string `json:"primaryseed"`
+	// Seeds contains the mnemonics of all other seeds loaded into the wallet
+	Seeds []string `json:"seeds,omitempty"`
+	// AddressesGenerated is the amount of addresses handed out by the primary seed,
+	// restored such that the restored wallet doesn't hand out the same addresses again
+	AddressesGenerated uint64 `json:"addressesgenerated"`
+	// Addresses contains all addresses of the wallet, used to verify the restored wallet
+	Addresses []types.UnlockHash `json:"addresses"`
+	// Contacts contains the address book of the wallet
+	Contacts []Contact `json:"contacts,omitempty"`
+}
+
+// Validate returns an error should the backup contain an invalid mnemonic.
+func (b Backup) Validate() error {
+	for _, mnemonic := range append([]string{b.PrimarySeed}, b.Seeds...) {
+		if _, err := modules.InitialSeedFromMnemonic(mnemonic); err != nil {
+			return fmt.Errorf("backup contains an invalid seed: %v", err)
+		}
+	}
+	return nil
+}
+
+// backupFile is the persisted (encrypted) form of a backup.
+// All properties but the ciphertext are readable without passphrase,
+// such that the format can evolve without breaking older backups.
+type backupFile struct {
+	Header     string            `json:"header"`
+	Version    int               `json:"version"`
+	KDF        string            `json:"kdf"`
+	Iterations int               `json:"iterations"`
+	Salt       []byte            `json:"salt"`
+	Backup     crypto.Ciphertext `json:"backup"`
+}
+
+// EncryptBackup encrypts the given backup using a key derived from the given passphrase,
+// returning the JSON-encoded backup file.
+func EncryptBackup(b Backup, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, ErrEmptyBackupPassphrase
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	f := backupFile{
+		Header:     BackupHeader,
+		Version:    BackupVersion,
+		KDF:        backupKDF,
+		Iterations: backupKDFIterations,
+		Salt:       fastrand.Bytes(backupSaltSize),
+	}
+	f.Backup = backupKey(passphrase, f.Salt, f.Iterations).EncryptBytes(plaintext)
+	return json.MarshalIndent(f, "", "  ")
+}
+
+// DecryptBackup decrypts the given JSON-encoded backup file using the given passphrase.
+func DecryptBackup(data []byte, passphrase string) (Backup, error) {
+	var f backupFile
+	if err := json.Unmarshal(data, &f); err != nil || f.Header != BackupHeader {
+		return Backup{}, ErrInvalidBackup
+	}
+	if f.Version > BackupVersion {
+		return Backup{}, ErrUnsupportedBackupVersion
+	}
+	if f.KDF != backupKDF || f.Iterations <= 0 {
+		return Backup{}, fmt.Errorf("unsupported backup key derivation %s (%d iterations)", f.KDF, f.Iterations)
+	}
+	plaintext, err := backupKey(passphrase, f.Salt, f.Iterations).DecryptBytes(f.Backup)
+	if err != nil {
+		return Backup{}, ErrInvalidBackupPassphrase
+	}
+	var b Backup
+	if err = json.Unmarshal(plaintext, &b); err != nil {
+		return Backup{}, fmt.Errorf("failed to decode decrypted backup: %v", err)
+	}
+	if err = b.Validate(); err != nil {
+		return Backup{}, err
+	}
+	return b, nil
+}
+
+func backupKey(passphrase string, salt []byte, iterations int) (key crypto.TwofishKey) {
+	copy(key[:], pbkdf2.Key([]byte(passphrase), salt, iterations, len(key), sha256.New))
+	return
+}
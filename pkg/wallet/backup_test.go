@@ -0,0 +1,78 @@
+package wallet
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestBackupRoundTrip(t *testing.T) {
+	primary, err := modules.NewMnemonic(modules.Seed(crypto.HashObject("primary")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := modules.NewMnemonic(modules.Seed(crypto.HashObject("other")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pk := crypto.GenerateKeyPair()
+	address := types.NewEd25519PubKeyUnlockHash(pk)
+	backup := Backup{
+		Network:            "devnet",
+		Created:            1600000000,
+		PrimarySeed:        primary,
+		Seeds:              []string{other},
+		AddressesGenerated: 3,
+		Addresses:          []types.UnlockHash{address},
+		Contacts:           []Contact{{Name: "alice", Address: address, Created: 1, Updated: 2}},
+	}
+	b, err := EncryptBackup(backup, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := DecryptBackup(b, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decrypted, backup) {
+		t.Errorf("unexpected decrypted backup: %+v", decrypted)
+	}
+	if _, err = DecryptBackup(b, "battery staple"); err != ErrInvalidBackupPassphrase {
+		t.Errorf("expected %v, got %v", ErrInvalidBackupPassphrase, err)
+	}
+
+	var f backupFile
+	if err = json.Unmarshal(b, &f); err != nil {
+		t.Fatal(err)
+	}
+	f.Backup[len(f.Backup)-1] ^= 1
+	tampered, _ := json.Marshal(f)
+	if _, err = DecryptBackup(tampered, "correct horse"); err != ErrInvalidBackupPassphrase {
+		t.Errorf("expected tampered backup to fail with %v, got %v", ErrInvalidBackupPassphrase, err)
+	}
+	f.Version = BackupVersion + 1
+	newer, _ := json.Marshal(f)
+	if _, err = DecryptBackup(newer, "correct horse"); err != ErrUnsupportedBackupVersion {
+		t.Errorf("expected %v, got %v", ErrUnsupportedBackupVersion, err)
+	}
+	if _, err = DecryptBackup([]byte(`{"contacts":""}`), "correct horse"); err != ErrInvalidBackup {
+		t.Errorf("expected %v, got %v", ErrInvalidBackup, err)
+	}
+}
+
+func TestEncryptInvalidBackup(t *testing.T) {
+	primary, err := modules.NewMnemonic(modules.Seed{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = EncryptBackup(Backup{PrimarySeed: primary}, ""); err != ErrEmptyBackupPassphrase {
+		t.Errorf("expected %v, got %v", ErrEmptyBackupPassphrase, err)
+	}
+	if _, err = EncryptBackup(Backup{PrimarySeed: "not a mnemonic"}, "passphrase"); err == nil {
+		t.Error("expected a backup with an invalid seed to be refused")
+	}
+}
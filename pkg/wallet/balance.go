@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"sort"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// AuthStateGetter returns whether addresses are currently authorized to send and receive coins,
+// as implemented by the authcointx plugin.
+type AuthStateGetter interface {
+	GetAddressesAuthStateNow(addresses []types.UnlockHash, exitEarlyFn func(index int, state bool) bool) ([]bool, error)
+}
+
+// UnauthorizedFunds are the unlocked coins owned by a wallet address which is currently not authorized.
+type UnauthorizedFunds struct {
+	Address types.UnlockHash `json:"address"`
+	Value   types.Currency   `json:"value"`
+}
+
+// Balance is a breakdown of the coins of the wallet, such that the coins which cannot be spent
+// right away are never reported as part of a single, misleading total.
+// All values are those of the confirmed outputs, unless documented otherwise.
+type Balance struct {
+	// Height is the height of the block the outputs are checked against
+	Height types.BlockHeight `json:"height"`
+
+	// Spendable is the value of the unlocked outputs of the wallet on authorized addresses
+	Spendable types.Currency `json:"spendable"`
+	// Locked is the value of the time-locked outputs of the wallet
+	Locked types.Currency `json:"locked"`
+	// MultiSig is the value of the unlocked multisig outputs the wallet co-owns,
+	// which can only be spent together with the other owners
+	MultiSig types.Currency `json:"multisig"`
+	// Unauthorized is the value of the unlocked outputs on addresses which are currently not authorized,
+	// which cannot be spent until the address is authorized again
+	Unauthorized types.Currency `json:"unauthorized"`
+	// UnauthorizedAddresses lists the unauthorized funds per address, largest first
+	UnauthorizedAddresses []UnauthorizedFunds `json:"unauthorizedaddresses"`
+
+	// PendingIncoming is the value the unconfirmed transactions send to the wallet, refunds included
+	PendingIncoming types.Currency `json:"pendingincoming"`
+	// PendingOutgoing is the value the unconfirmed transactions spend from the wallet, refunded value included
+	PendingOutgoing types.Currency `json:"pendingoutgoing"`
+	// Expected is the spendable value once all unconfirmed transactions are confirmed
+	Expected types.Currency `json:"expected"`
+}
+
+// GetBalance returns the breakdown of the coin balance of the wallet.
+// Unlocked funds are considered spendable regardless of the authorization of their address
+// should no auth state getter be given.
+func GetBalance(w modules.Wallet, cs modules.ConsensusSet, auth AuthStateGetter) (Balance, error) {
+	balance := Balance{
+		Height:                cs.Height(),
+		UnauthorizedAddresses: []UnauthorizedFunds{},
+	}
+	unlocked, _, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return Balance{}, err
+	}
+	locked, _, err := w.LockedUnspendOutputs()
+	if err != nil {
+		return Balance{}, err
+	}
+	for _, co := range locked {
+		balance.Locked = balance.Locked.Add(co.Value)
+	}
+
+	perAddress := make(map[types.UnlockHash]types.Currency)
+	for _, co := range unlocked {
+		if co.Condition.ConditionType() == types.ConditionTypeMultiSignature {
+			balance.MultiSig = balance.MultiSig.Add(co.Value)
+			continue
+		}
+		uh := co.Condition.UnlockHash()
+		perAddress[uh] = perAddress[uh].Add(co.Value)
+	}
+	addresses := make([]types.UnlockHash, 0, len(perAddress))
+	for uh := range perAddress {
+		addresses = append(addresses, uh)
+	}
+	var states []bool
+	if auth != nil && len(addresses) > 0 {
+		states, err = auth.GetAddressesAuthStateNow(addresses, nil)
+		if err != nil {
+			return Balance{}, err
+		}
+	}
+	for i, uh := range addresses {
+		if states != nil && !states[i] {
+			balance.Unauthorized = balance.Unauthorized.Add(perAddress[uh])
+			balance.UnauthorizedAddresses = append(balance.UnauthorizedAddresses, UnauthorizedFunds{
+				Address: uh,
+				Value:   perAddress[uh],
+			})
+			continue
+		}
+		balance.Spendable = balance.Spendable.Add(perAddress[uh])
+	}
+	sort.Slice(balance.UnauthorizedAddresses, func(i, j int) bool {
+		if c := balance.UnauthorizedAddresses[i].Value.Cmp(balance.UnauthorizedAddresses[j].Value); c != 0 {
+			return c > 0
+		}
+		return balance.UnauthorizedAddresses[i].Address.String() < balance.UnauthorizedAddresses[j].Address.String()
+	})
+
+	balance.PendingOutgoing, balance.PendingIncoming, err = w.UnconfirmedBalance()
+	if err != nil {
+		return Balance{}, err
+	}
+	expected := balance.Spendable.Add(balance.PendingIncoming)
+	if expected.Cmp(balance.PendingOutgoing) > 0 {
+		balance.Expected = expected.Sub(balance.PendingOutgoing)
+	}
+	return balance, nil
+}
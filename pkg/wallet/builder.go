@@ -0,0 +1,353 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrNoOutputs is returned in case a transaction is to be built without any outputs.
+	ErrNoOutputs = errors.New("at least one coin or blockstake output has to be sent")
+	// ErrConditionNotLockable is returned in case an output is to be time-locked,
+	// while its condition cannot be wrapped in a time lock condition.
+	ErrConditionNotLockable = errors.New("only nil, unlock hash and multisignature conditions can be time-locked")
+)
+
+// BuildOptions contains the optional properties of a transaction built by the wallet.
+type BuildOptions struct {
+	// RefundAddress is the address refunds are sent to,
+	// the address of the largest coin input is used if none is given
+	RefundAddress *types.UnlockHash
+	// ExpirationHeight is the height of the last block the transaction can be part of,
+	// an expiring transaction is only built if it is defined
+	ExpirationHeight types.BlockHeight
+	// LockedUntil is the lock time applied to all coin and blockstake outputs sent (refunds excluded),
+	// a block height when lower than types.LockTimeMinTimestampValue and a unix epoch timestamp (in seconds) otherwise,
+	// the outputs are only time-locked if it is defined
+	LockedUntil uint64
+
+	// CoinSelection is the strategy used to select the coin outputs funding the transaction,
+	// largest-first is used if none is given
+	CoinSelection CoinSelectionStrategy
+	// IncludeCoinOutputs are the coin outputs which have to fund the transaction,
+	// other coin outputs are only selected if these do not suffice
+	IncludeCoinOutputs []types.CoinOutputID
+	// ExcludeCoinOutputs are the coin outputs which are never used to fund the transaction
+	ExcludeCoinOutputs []types.CoinOutputID
+	// Funding returns whether the outputs of an address can fund the transaction,
+	// such as the addresses of a single account, all outputs of the wallet can if it is nil
+	Funding func(types.UnlockHash) bool
+}
+
+// funds returns whether the given condition can fund a transaction built using the options.
+func (opts BuildOptions) funds(condition types.UnlockConditionProxy) bool {
+	return isSingleSignatureCondition(condition) && (opts.Funding == nil || opts.Funding(condition.UnlockHash()))
+}
+
+// FundedTransaction is a transaction funded and signed using the outputs of a wallet,
+// which has not (yet) been submitted to the transaction pool.
+type FundedTransaction struct {
+	Transaction types.Transaction `json:"transaction"`
+	// CoinInputs are the coin outputs selected to fund the transaction
+	CoinInputs []FundingCoinOutput `json:"coininputs"`
+	// BlockStakeInputs are the blockstake outputs selected to fund the transaction
+	BlockStakeInputs []FundingBlockStakeOutput `json:"blockstakeinputs,omitempty"`
+	MinerFee         types.Currency            `json:"minerfee"`
+}
+
+// FundingCoinOutput is a coin output selected to fund a transaction.
+type FundingCoinOutput struct {
+	ID     types.CoinOutputID `json:"id"`
+	Output types.CoinOutput   `json:"output"`
+}
+
+// FundingBlockStakeOutput is a blockstake output selected to fund a transaction.
+type FundingBlockStakeOutput struct {
+	ID     types.BlockStakeOutputID `json:"id"`
+	Output types.BlockStakeOutput   `json:"output"`
+}
+
+// BuildTransaction creates a transaction sending the given outputs, funded by the wallet,
+// in the same way as modules.Wallet.SendOutputs would, paying the minimum transaction fee.
+// Contrary to SendOutputs the funding outputs are not reserved by the wallet,
+// nor is the transaction submitted to the transaction pool.
+// Outputs already spent by transactions in the transaction pool are never used.
+func BuildTransaction(w modules.Wallet, tpool modules.TransactionPool, coinOutputs []types.CoinOutput, blockStakeOutputs []types.BlockStakeOutput, data []byte, opts BuildOptions, constants types.ChainConstants) (FundedTransaction, error) {
+	if len(coinOutputs) == 0 && len(blockStakeOutputs) == 0 {
+		return FundedTransaction{}, ErrNoOutputs
+	}
+	unspentCoinOutputs, unspentBlockStakeOutputs, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return FundedTransaction{}, err
+	}
+	if opts.LockedUntil != 0 {
+		coinOutputs, blockStakeOutputs, err = timeLockOutputs(coinOutputs, blockStakeOutputs, opts.LockedUntil)
+		if err != nil {
+			return FundedTransaction{}, err
+		}
+	}
+	spent := spentCoinOutputs(tpool.TransactionList())
+	spentBlockStakes := spentBlockStakeOutputs(tpool.TransactionList())
+
+	ft := FundedTransaction{
+		Transaction: types.Transaction{
+			Version:       constants.DefaultTransactionVersion,
+			CoinOutputs:   coinOutputs,
+			MinerFees:     []types.Currency{constants.MinimumTransactionFee},
+			ArbitraryData: data,
+		},
+		MinerFee: constants.MinimumTransactionFee,
+	}
+	if opts.ExpirationHeight != 0 {
+		ft.Transaction.Version = goldchaintypes.TransactionVersionExpiring
+		ft.Transaction.Extension = &goldchaintypes.ExpiringTransactionExtension{
+			ExpirationHeight: opts.ExpirationHeight,
+		}
+	}
+
+	// fund the coin outputs and miner fee, using the pinned outputs and the selection strategy
+	coinAmount := constants.MinimumTransactionFee
+	for _, co := range coinOutputs {
+		coinAmount = coinAmount.Add(co.Value)
+	}
+	refundAddress, err := fundCoins(&ft, unspentCoinOutputs, spent, coinAmount, opts, constants)
+	if err != nil {
+		return FundedTransaction{}, err
+	}
+
+	// fund the blockstake outputs, largest outputs first
+	if len(blockStakeOutputs) > 0 {
+		var bsAmount types.Currency
+		for _, bso := range blockStakeOutputs {
+			bsAmount = bsAmount.Add(bso.Value)
+		}
+		var bsCandidates []FundingBlockStakeOutput
+		for id, bso := range unspentBlockStakeOutputs {
+			if _, ok := spentBlockStakes[id]; ok || !opts.funds(bso.Condition) {
+				continue
+			}
+			bsCandidates = append(bsCandidates, FundingBlockStakeOutput{ID: id, Output: bso})
+		}
+		sort.Slice(bsCandidates, func(i, j int) bool {
+			if c := bsCandidates[i].Output.Value.Cmp(bsCandidates[j].Output.Value); c != 0 {
+				return c > 0
+			}
+			return bytes.Compare(bsCandidates[i].ID[:], bsCandidates[j].ID[:]) < 0
+		})
+		var bsFund types.Currency
+		for _, candidate := range bsCandidates {
+			if bsFund.Cmp(bsAmount) >= 0 {
+				break
+			}
+			ft.BlockStakeInputs = append(ft.BlockStakeInputs, candidate)
+			bsFund = bsFund.Add(candidate.Output.Value)
+		}
+		if bsFund.Cmp(bsAmount) < 0 {
+			return FundedTransaction{}, modules.ErrLowBalance
+		}
+		ft.Transaction.BlockStakeOutputs = blockStakeOutputs
+		if bsFund.Cmp(bsAmount) > 0 {
+			ft.Transaction.BlockStakeOutputs = append(ft.Transaction.BlockStakeOutputs, types.BlockStakeOutput{
+				Value:     bsFund.Sub(bsAmount),
+				Condition: types.NewCondition(types.NewUnlockHashCondition(*refundAddress)),
+			})
+		}
+	}
+
+	// add and sign all inputs
+	err = signFundedTransaction(w, &ft)
+	if err != nil {
+		return FundedTransaction{}, err
+	}
+	return ft, nil
+}
+
+// fundCoins selects the coin outputs funding the given amount (miner fee included) of the transaction,
+// using the pinned outputs and the selection strategy of the options, adding a refund output should it be needed.
+// The refund address is returned, as it is also used to refund blockstakes.
+func fundCoins(ft *FundedTransaction, unspentCoinOutputs map[types.CoinOutputID]types.CoinOutput, spent map[types.CoinOutputID]struct{}, coinAmount types.Currency, opts BuildOptions, constants types.ChainConstants) (*types.UnlockHash, error) {
+	excluded := make(map[types.CoinOutputID]struct{}, len(opts.ExcludeCoinOutputs)+len(opts.IncludeCoinOutputs))
+	for _, id := range opts.ExcludeCoinOutputs {
+		excluded[id] = struct{}{}
+	}
+	var pinned []FundingCoinOutput
+	for _, id := range opts.IncludeCoinOutputs {
+		if _, ok := excluded[id]; ok {
+			// pinned twice, or pinned and excluded
+			continue
+		}
+		co, ok := unspentCoinOutputs[id]
+		if _, spent := spent[id]; !ok || spent || !opts.funds(co.Condition) {
+			return nil, UnavailableOutputError{ID: id}
+		}
+		pinned = append(pinned, FundingCoinOutput{ID: id, Output: co})
+		excluded[id] = struct{}{}
+	}
+	var candidates []FundingCoinOutput
+	for id, co := range unspentCoinOutputs {
+		if _, ok := spent[id]; ok || !opts.funds(co.Condition) {
+			continue
+		}
+		if _, ok := excluded[id]; ok {
+			continue
+		}
+		candidates = append(candidates, FundingCoinOutput{ID: id, Output: co})
+	}
+	// a change output costs about as much as the minimum transaction fee,
+	// overshooting the amount by less than that is cheaper when paid as miner fee
+	selection, err := selectCoinOutputs(opts.CoinSelection, candidates, pinned, coinAmount, constants.MinimumTransactionFee)
+	if err != nil {
+		return nil, err
+	}
+	ft.CoinInputs = selection.Inputs
+	coinFund := selection.Fund
+	refundAddress := opts.RefundAddress
+	if refundAddress == nil {
+		uh := largestCoinInput(ft.CoinInputs).Output.Condition.UnlockHash()
+		refundAddress = &uh
+	}
+	if selection.Changeless {
+		if excess := coinFund.Sub(coinAmount); !excess.IsZero() {
+			ft.MinerFee = ft.MinerFee.Add(excess)
+			ft.Transaction.MinerFees[0] = ft.MinerFee
+		}
+	} else if coinFund.Cmp(coinAmount) > 0 {
+		ft.Transaction.CoinOutputs = append(ft.Transaction.CoinOutputs, types.CoinOutput{
+			Value:     coinFund.Sub(coinAmount),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(*refundAddress)),
+		})
+	}
+	return refundAddress, nil
+}
+
+// SendOutputs builds a transaction sending the given outputs, funded by the wallet,
+// and submits it to the transaction pool.
+func SendOutputs(w modules.Wallet, tpool modules.TransactionPool, coinOutputs []types.CoinOutput, blockStakeOutputs []types.BlockStakeOutput, data []byte, opts BuildOptions, constants types.ChainConstants) (types.Transaction, error) {
+	ft, err := BuildTransaction(w, tpool, coinOutputs, blockStakeOutputs, data, opts, constants)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	err = tpool.AcceptTransactionSet([]types.Transaction{ft.Transaction})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	return ft.Transaction, nil
+}
+
+// timeLockOutputs returns copies of the given outputs, with their conditions wrapped in a time lock condition.
+func timeLockOutputs(coinOutputs []types.CoinOutput, blockStakeOutputs []types.BlockStakeOutput, lockTime uint64) ([]types.CoinOutput, []types.BlockStakeOutput, error) {
+	lockedCoinOutputs := make([]types.CoinOutput, 0, len(coinOutputs))
+	for _, co := range coinOutputs {
+		condition, err := timeLockCondition(co.Condition, lockTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		lockedCoinOutputs = append(lockedCoinOutputs, types.CoinOutput{Value: co.Value, Condition: condition})
+	}
+	lockedBlockStakeOutputs := make([]types.BlockStakeOutput, 0, len(blockStakeOutputs))
+	for _, bso := range blockStakeOutputs {
+		condition, err := timeLockCondition(bso.Condition, lockTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		lockedBlockStakeOutputs = append(lockedBlockStakeOutputs, types.BlockStakeOutput{Value: bso.Value, Condition: condition})
+	}
+	return lockedCoinOutputs, lockedBlockStakeOutputs, nil
+}
+
+// timeLockCondition wraps the given condition in a time lock condition.
+func timeLockCondition(condition types.UnlockConditionProxy, lockTime uint64) (types.UnlockConditionProxy, error) {
+	switch condition.ConditionType() {
+	case types.ConditionTypeNil, types.ConditionTypeUnlockHash, types.ConditionTypeMultiSignature:
+		return types.NewCondition(types.NewTimeLockCondition(lockTime, condition.Condition)), nil
+	default:
+		return types.UnlockConditionProxy{}, ErrConditionNotLockable
+	}
+}
+
+// largestCoinInput returns the largest of the given (non-empty) funding coin outputs.
+func largestCoinInput(inputs []FundingCoinOutput) FundingCoinOutput {
+	largest := inputs[0]
+	for _, input := range inputs[1:] {
+		if input.Output.Value.Cmp(largest.Output.Value) > 0 {
+			largest = input
+		}
+	}
+	return largest
+}
+
+// signFundedTransaction adds the funding outputs as inputs to the transaction, and signs them.
+func signFundedTransaction(w modules.Wallet, ft *FundedTransaction) error {
+	keys := make([]types.ByteSlice, 0, len(ft.CoinInputs)+len(ft.BlockStakeInputs))
+	for _, input := range ft.CoinInputs {
+		pk, sk, err := w.GetKey(input.Output.Condition.UnlockHash())
+		if err != nil {
+			return err
+		}
+		ft.Transaction.CoinInputs = append(ft.Transaction.CoinInputs, types.CoinInput{
+			ParentID:    input.ID,
+			Fulfillment: types.NewFulfillment(types.NewSingleSignatureFulfillment(pk)),
+		})
+		keys = append(keys, sk)
+	}
+	for _, input := range ft.BlockStakeInputs {
+		pk, sk, err := w.GetKey(input.Output.Condition.UnlockHash())
+		if err != nil {
+			return err
+		}
+		ft.Transaction.BlockStakeInputs = append(ft.Transaction.BlockStakeInputs, types.BlockStakeInput{
+			ParentID:    input.ID,
+			Fulfillment: types.NewFulfillment(types.NewSingleSignatureFulfillment(pk)),
+		})
+		keys = append(keys, sk)
+	}
+	// all inputs have to be defined prior to signing
+	for i := range ft.Transaction.CoinInputs {
+		err := ft.Transaction.CoinInputs[i].Fulfillment.Sign(types.FulfillmentSignContext{
+			ExtraObjects: []interface{}{uint64(i)},
+			Transaction:  ft.Transaction,
+			Key:          keys[i],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	offset := len(ft.Transaction.CoinInputs)
+	for i := range ft.Transaction.BlockStakeInputs {
+		err := ft.Transaction.BlockStakeInputs[i].Fulfillment.Sign(types.FulfillmentSignContext{
+			ExtraObjects: []interface{}{uint64(i)},
+			Transaction:  ft.Transaction,
+			Key:          keys[offset+i],
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSingleSignatureCondition returns true in case the (fulfillable) condition
+// can be fulfilled using a single signature of a wallet key.
+func isSingleSignatureCondition(condition types.UnlockConditionProxy) bool {
+	switch condition.ConditionType() {
+	case types.ConditionTypeUnlockHash, types.ConditionTypeTimeLock:
+		return condition.UnlockHash().Type == types.UnlockTypePubKey
+	default:
+		return false
+	}
+}
+
+func spentBlockStakeOutputs(txns []types.Transaction) map[types.BlockStakeOutputID]struct{} {
+	spent := make(map[types.BlockStakeOutputID]struct{})
+	for _, txn := range txns {
+		for _, bsi := range txn.BlockStakeInputs {
+			spent[bsi.ParentID] = struct{}{}
+		}
+	}
+	return spent
+}
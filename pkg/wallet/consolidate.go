@@ -0,0 +1,214 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/pkg/encoding/siabin"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// DefaultConsolidationMaxInputs is the maximum amount of coin outputs
+	// merged by a single consolidation transaction, when no maximum is given.
+	DefaultConsolidationMaxInputs = 100
+	// MaxConsolidationFeeFactor is the factor applied to the minimum transaction fee,
+	// in order to compute the maximum miner fee a consolidation transaction can pay.
+	MaxConsolidationFeeFactor = 50
+)
+
+var (
+	// ErrNothingToConsolidate is returned in case the wallet doesn't have
+	// enough coin outputs to consolidate.
+	ErrNothingToConsolidate = errors.New("wallet has no coin outputs which can be consolidated")
+)
+
+// InvalidConsolidationFeeError is returned in case the miner fee of a consolidation transaction
+// is below the minimum transaction fee, or exceeds the maximum consolidation fee.
+type InvalidConsolidationFeeError struct {
+	Reason string
+}
+
+// Error implements error.Error
+func (err InvalidConsolidationFeeError) Error() string {
+	return "invalid consolidation fee: " + err.Reason
+}
+
+// ConsolidationOptions contains the optional properties of a consolidation.
+type ConsolidationOptions struct {
+	// MaxInputs is the maximum amount of coin outputs merged by a single transaction,
+	// DefaultConsolidationMaxInputs is used if none is given
+	MaxInputs int `json:"maxinputs,omitempty"`
+	// MinerFee is the miner fee paid by each transaction, the minimum transaction fee is used if none is given,
+	// it cannot exceed the estimated minimum fee multiplied by MaxConsolidationFeeFactor
+	MinerFee types.Currency `json:"minerfee"`
+	// MaxValue limits the consolidation to coin outputs of a value lower than it,
+	// all coin outputs are consolidated if none is given
+	MaxValue types.Currency `json:"maxvalue"`
+	// Address is the wallet address the merged coin outputs are sent to,
+	// a new wallet address is used if none is given
+	Address *types.UnlockHash `json:"address,omitempty"`
+}
+
+// Consolidate merges the unlocked coin outputs of the wallet, smallest outputs first,
+// into a single coin output per transaction, submitting all transactions to the transaction pool.
+// Multiple transactions are created in case the wallet has more coin outputs than fit in a single transaction.
+// The transactions submitted before an error occurred are returned together with the error,
+// no further transactions are created once the given context is done.
+// The coin outputs can only be merged into an address of the wallet, returning ErrAddressNotOwned otherwise.
+func Consolidate(ctx context.Context, w modules.Wallet, tpool modules.TransactionPool, opts ConsolidationOptions, constants types.ChainConstants) ([]types.Transaction, error) {
+	if opts.MaxInputs <= 0 {
+		opts.MaxInputs = DefaultConsolidationMaxInputs
+	}
+	fee, err := consolidationFee(tpool, opts.MinerFee, constants)
+	if err != nil {
+		return nil, err
+	}
+	opts.MinerFee = fee
+	if opts.Address != nil {
+		_, _, err = w.GetKey(*opts.Address)
+		if err == modules.ErrLockedWallet {
+			return nil, err
+		}
+		if err != nil {
+			return nil, ErrAddressNotOwned
+		}
+	}
+
+	unspentCoinOutputs, _, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return nil, err
+	}
+	spent := spentCoinOutputs(tpool.TransactionList())
+	var candidates []FundingCoinOutput
+	for id, co := range unspentCoinOutputs {
+		if _, ok := spent[id]; ok || !isSingleSignatureCondition(co.Condition) {
+			continue
+		}
+		if !opts.MaxValue.IsZero() && co.Value.Cmp(opts.MaxValue) >= 0 {
+			continue
+		}
+		candidates = append(candidates, FundingCoinOutput{ID: id, Output: co})
+	}
+	// smallest outputs first, and the lowest ID first for outputs of equal value
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := candidates[i].Output.Value.Cmp(candidates[j].Output.Value); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(candidates[i].ID[:], candidates[j].ID[:]) < 0
+	})
+	if len(candidates) < 2 {
+		return nil, ErrNothingToConsolidate
+	}
+
+	if opts.Address == nil {
+		uh, err := w.NextAddress()
+		if err != nil {
+			return nil, err
+		}
+		opts.Address = &uh
+	}
+
+	var txns []types.Transaction
+	for len(candidates) >= 2 {
+		if err := ctx.Err(); err != nil {
+			return txns, err
+		}
+		n := opts.MaxInputs
+		if n > len(candidates) {
+			n = len(candidates)
+		}
+		ft, err := buildConsolidationTransaction(w, candidates[:n], opts, constants)
+		if err != nil {
+			return txns, err
+		}
+		// reduce the amount of inputs, should the transaction exceed the size limit
+		sizeLimit := constants.TransactionPool.TransactionSizeLimit
+		for size := len(siabin.Marshal(ft.Transaction)); size > sizeLimit; size = len(siabin.Marshal(ft.Transaction)) {
+			n = n * sizeLimit / size
+			if n < 2 {
+				return txns, errors.New("consolidation transaction exceeds the transaction size limit")
+			}
+			ft, err = buildConsolidationTransaction(w, candidates[:n], opts, constants)
+			if err != nil {
+				return txns, err
+			}
+		}
+		candidates = candidates[n:]
+		if ft.Transaction.CoinOutputs == nil {
+			// the outputs do not cover the miner fee, skip them in favour of the larger outputs
+			continue
+		}
+		err = tpool.AcceptTransactionSet([]types.Transaction{ft.Transaction})
+		if err != nil {
+			return txns, err
+		}
+		txns = append(txns, ft.Transaction)
+	}
+	if len(txns) == 0 {
+		return nil, ErrNothingToConsolidate
+	}
+	return txns, nil
+}
+
+// consolidationFee returns the miner fee paid by each consolidation transaction paying the given fee,
+// the minimum transaction fee if the given fee is zero. A fee below the minimum transaction fee of the chain,
+// or above the minimum fee estimated by the transaction pool multiplied by MaxConsolidationFeeFactor, is refused.
+func consolidationFee(tpool modules.TransactionPool, fee types.Currency, constants types.ChainConstants) (types.Currency, error) {
+	if fee.IsZero() {
+		return constants.MinimumTransactionFee, nil
+	}
+	if fee.Cmp(constants.MinimumTransactionFee) < 0 {
+		return types.Currency{}, InvalidConsolidationFeeError{Reason: fmt.Sprintf("miner fee %s is below the minimum transaction fee %s",
+			fee.String(), constants.MinimumTransactionFee.String())}
+	}
+	minimumFee, _ := tpool.FeeEstimation()
+	if minimumFee.Cmp(constants.MinimumTransactionFee) < 0 {
+		minimumFee = constants.MinimumTransactionFee
+	}
+	if maxFee := minimumFee.Mul64(MaxConsolidationFeeFactor); fee.Cmp(maxFee) > 0 {
+		return types.Currency{}, InvalidConsolidationFeeError{Reason: fmt.Sprintf("miner fee %s exceeds the maximum consolidation fee %s (%d times the estimated minimum fee)",
+			fee.String(), maxFee.String(), MaxConsolidationFeeFactor)}
+	}
+	return fee, nil
+}
+
+// buildConsolidationTransaction creates a signed transaction merging the given coin outputs into one.
+// No transaction is created in case the coin outputs do not cover the miner fee.
+func buildConsolidationTransaction(w modules.Wallet, inputs []FundingCoinOutput, opts ConsolidationOptions, constants types.ChainConstants) (FundedTransaction, error) {
+	return buildMergeTransaction(w, inputs, types.NewCondition(types.NewUnlockHashCondition(*opts.Address)), opts.MinerFee, constants)
+}
+
+// buildMergeTransaction creates a signed transaction sending the value of the given coin outputs,
+// minus the miner fee, to the given condition as a single coin output.
+// No transaction is created in case the coin outputs do not cover the miner fee.
+func buildMergeTransaction(w modules.Wallet, inputs []FundingCoinOutput, condition types.UnlockConditionProxy, minerFee types.Currency, constants types.ChainConstants) (FundedTransaction, error) {
+	var fund types.Currency
+	for _, input := range inputs {
+		fund = fund.Add(input.Output.Value)
+	}
+	if fund.Cmp(minerFee) <= 0 {
+		return FundedTransaction{}, nil
+	}
+	ft := FundedTransaction{
+		Transaction: types.Transaction{
+			Version: constants.DefaultTransactionVersion,
+			CoinOutputs: []types.CoinOutput{{
+				Value:     fund.Sub(minerFee),
+				Condition: condition,
+			}},
+			MinerFees: []types.Currency{minerFee},
+		},
+		CoinInputs: inputs,
+		MinerFee:   minerFee,
+	}
+	err := signFundedTransaction(w, &ft)
+	if err != nil {
+		return FundedTransaction{}, err
+	}
+	return ft, nil
+}
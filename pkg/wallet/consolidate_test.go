@@ -0,0 +1,143 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testConsolidationTransactionPool accepts all transactions, estimating the given minimum fee.
+type testConsolidationTransactionPool struct {
+	testSweepTransactionPool
+	minimumFee types.Currency
+}
+
+func (tpool *testConsolidationTransactionPool) FeeEstimation() (types.Currency, types.Currency) {
+	return tpool.minimumFee, tpool.minimumFee.Mul64(2)
+}
+
+func testConsolidationConstants() types.ChainConstants {
+	constants := types.ChainConstants{
+		DefaultTransactionVersion: types.TransactionVersionOne,
+		MinimumTransactionFee:     types.NewCurrency64(10),
+	}
+	constants.TransactionPool.TransactionSizeLimit = 16e3
+	return constants
+}
+
+func TestBuildMergeTransaction(t *testing.T) {
+	w := &testSweepWallet{&testLabelsWallet{testAccountsWallet: &testAccountsWallet{primary: modules.Seed{1}}}}
+	var inputs []FundingCoinOutput
+	for i, value := range []uint64{5, 8} {
+		address, err := w.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		inputs = append(inputs, FundingCoinOutput{
+			ID: types.CoinOutputID{byte(i + 1)},
+			Output: types.CoinOutput{
+				Value:     types.NewCurrency64(value),
+				Condition: types.NewCondition(types.NewUnlockHashCondition(address)),
+			},
+		})
+	}
+	var to types.UnlockHash
+	to.Type = types.UnlockTypePubKey
+	to.Hash[0] = 42
+	condition := types.NewCondition(types.NewUnlockHashCondition(to))
+	constants := testConsolidationConstants()
+
+	ft, err := buildMergeTransaction(w, inputs, condition, types.NewCurrency64(3), constants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := ft.Transaction
+	if len(txn.CoinOutputs) != 1 || !txn.CoinOutputs[0].Value.Equals64(10) || txn.CoinOutputs[0].Condition.UnlockHash() != to {
+		t.Errorf("expected the inputs minus the fee to be sent to %s, got %v", to.String(), txn.CoinOutputs)
+	}
+	if len(txn.MinerFees) != 1 || !txn.MinerFees[0].Equals64(3) || !ft.MinerFee.Equals64(3) {
+		t.Errorf("expected a miner fee of 3, got %v", txn.MinerFees)
+	}
+	if txn.Version != constants.DefaultTransactionVersion || len(txn.CoinInputs) != len(inputs) {
+		t.Fatalf("unexpected transaction: %v", txn)
+	}
+	for i, ci := range txn.CoinInputs {
+		if ci.ParentID != inputs[i].ID {
+			t.Errorf("expected input %d to spend %s, got %s", i, inputs[i].ID.String(), ci.ParentID.String())
+		}
+		err = inputs[i].Output.Condition.Fulfill(ci.Fulfillment, types.FulfillContext{
+			ExtraObjects: []interface{}{uint64(i)},
+			Transaction:  txn,
+		})
+		if err != nil {
+			t.Errorf("invalid signature of input %d: %v", i, err)
+		}
+	}
+
+	// no transaction is created should the inputs not cover the fee
+	ft, err = buildMergeTransaction(w, inputs, condition, types.NewCurrency64(13), constants)
+	if err != nil || ft.Transaction.CoinOutputs != nil || ft.CoinInputs != nil {
+		t.Errorf("expected no transaction for a fee equal to the inputs, got %v (%v)", ft.Transaction, err)
+	}
+
+	// the inputs have to be owned by the wallet
+	inputs[1].Output.Condition = condition
+	_, err = buildMergeTransaction(w, inputs, condition, types.NewCurrency64(3), constants)
+	if err == nil {
+		t.Errorf("expected an input not owned by the wallet to be refused")
+	}
+}
+
+func TestConsolidate(t *testing.T) {
+	w := &testSweepWallet{&testLabelsWallet{testAccountsWallet: &testAccountsWallet{
+		primary: modules.Seed{1},
+		unspent: make(map[types.CoinOutputID]types.CoinOutput),
+	}}}
+	for i := byte(1); i <= 3; i++ {
+		address, err := w.NextAddress()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.unspent[types.CoinOutputID{i}] = types.CoinOutput{
+			Value:     types.NewCurrency64(1000),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(address)),
+		}
+	}
+	own, err := w.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var external types.UnlockHash
+	external.Type = types.UnlockTypePubKey
+	external.Hash[0] = 42
+	constants := testConsolidationConstants()
+	tpool := &testConsolidationTransactionPool{minimumFee: types.NewCurrency64(20)}
+
+	_, err = Consolidate(context.Background(), w, tpool, ConsolidationOptions{Address: &external}, constants)
+	if err != ErrAddressNotOwned {
+		t.Errorf("expected the merged outputs not to be sent to an external address, got %v", err)
+	}
+	for _, fee := range []uint64{9, 20*MaxConsolidationFeeFactor + 1} {
+		_, err = Consolidate(context.Background(), w, tpool, ConsolidationOptions{MinerFee: types.NewCurrency64(fee)}, constants)
+		if _, ok := err.(InvalidConsolidationFeeError); !ok {
+			t.Errorf("expected fee %d to be refused, got %v", fee, err)
+		}
+	}
+	if len(tpool.txns) != 0 {
+		t.Fatalf("expected no transaction to be submitted, got %d", len(tpool.txns))
+	}
+
+	txns, err := Consolidate(context.Background(), w, tpool, ConsolidationOptions{
+		MinerFee: types.NewCurrency64(20 * MaxConsolidationFeeFactor),
+		Address:  &own,
+	}, constants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 || len(txns[0].CoinInputs) != 3 || !txns[0].CoinOutputs[0].Value.Equals64(3000-20*MaxConsolidationFeeFactor) ||
+		txns[0].CoinOutputs[0].Condition.UnlockHash() != own {
+		t.Errorf("expected all outputs to be merged into %s, got %v", own.String(), txns)
+	}
+}
@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrNothingToDelegate is returned in case the wallet doesn't have
+	// any blockstake outputs which can be delegated.
+	ErrNothingToDelegate = errors.New("wallet has no blockstake outputs which can be delegated")
+)
+
+// Delegate delegates the right to create blocks using the blockstakes of the given addresses
+// (all addresses of the wallet owning blockstakes if none are given) to the given operator,
+// revoking the delegation of these addresses in case the operator is the nil address.
+// All unlocked blockstake outputs of these addresses are respent unchanged by a blockstake delegation transaction,
+// funded by the wallet paying the minimum transaction fee, which is submitted to the transaction pool.
+func Delegate(w modules.Wallet, tpool modules.TransactionPool, operator types.UnlockHash, addresses []types.UnlockHash, constants types.ChainConstants) (types.Transaction, error) {
+	txn, err := respendBlockStakes(w, tpool, goldchaintypes.TransactionVersionBlockStakeDelegation,
+		&goldchaintypes.BlockStakeDelegationTransactionExtension{Operator: operator}, addresses, constants)
+	if err == errNoBlockStakesToRespend {
+		return types.Transaction{}, ErrNothingToDelegate
+	}
+	return txn, err
+}
+
+// errNoBlockStakesToRespend is returned by respendBlockStakes in case none of the given addresses
+// own an unlocked blockstake output.
+var errNoBlockStakesToRespend = errors.New("no blockstake outputs to respend")
+
+// respendBlockStakes creates a transaction of the given version and extension, respending all unlocked blockstake outputs
+// of the given addresses (all addresses of the wallet owning blockstakes if none are given) unchanged,
+// funded by the wallet paying the minimum transaction fee, and submits it to the transaction pool.
+func respendBlockStakes(w modules.Wallet, tpool modules.TransactionPool, version types.TransactionVersion, extension interface{}, addresses []types.UnlockHash, constants types.ChainConstants) (types.Transaction, error) {
+	unspentCoinOutputs, unspentBlockStakeOutputs, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	selected := make(map[types.UnlockHash]struct{}, len(addresses))
+	for _, uh := range addresses {
+		selected[uh] = struct{}{}
+	}
+	spentBlockStakes := spentBlockStakeOutputs(tpool.TransactionList())
+	var inputs []FundingBlockStakeOutput
+	for id, bso := range unspentBlockStakeOutputs {
+		if _, ok := spentBlockStakes[id]; ok || !isSingleSignatureCondition(bso.Condition) {
+			continue
+		}
+		if _, ok := selected[bso.Condition.UnlockHash()]; !ok && len(addresses) > 0 {
+			continue
+		}
+		inputs = append(inputs, FundingBlockStakeOutput{ID: id, Output: bso})
+	}
+	if len(inputs) == 0 {
+		return types.Transaction{}, errNoBlockStakesToRespend
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		return bytes.Compare(inputs[i].ID[:], inputs[j].ID[:]) < 0
+	})
+
+	ft := FundedTransaction{
+		Transaction: types.Transaction{
+			Version:   version,
+			MinerFees: []types.Currency{constants.MinimumTransactionFee},
+			Extension: extension,
+		},
+		BlockStakeInputs: inputs,
+		MinerFee:         constants.MinimumTransactionFee,
+	}
+	for _, input := range inputs {
+		ft.Transaction.BlockStakeOutputs = append(ft.Transaction.BlockStakeOutputs, input.Output)
+	}
+	_, err = fundCoins(&ft, unspentCoinOutputs, spentCoinOutputs(tpool.TransactionList()), constants.MinimumTransactionFee, BuildOptions{}, constants)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	err = signFundedTransaction(w, &ft)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	err = tpool.AcceptTransactionSet([]types.Transaction{ft.Transaction})
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	return ft.Transaction, nil
+}
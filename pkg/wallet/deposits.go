@@ -0,0 +1,376 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// DepositsFile is the name of the file listing the allocated deposit addresses, stored in the wallet directory.
+	DepositsFile = "deposits.json"
+	// DepositTag is the tag attached to every allocated deposit address, next to the customer reference as its label.
+	DepositTag = "deposit"
+	// DepositSignatureHeader contains the hex-encoded HMAC-SHA256 of the body of a deposit notification,
+	// using the secret of the webhook as key
+	DepositSignatureHeader = "X-Goldchain-Signature"
+
+	// depositQueueSize is the amount of notifications which can wait to be posted,
+	// notifications created while the queue is full are not posted
+	depositQueueSize = 1024
+	// depositWebhookTimeout is the maximum duration of a single webhook request
+	depositWebhookTimeout = 10 * time.Second
+	// depositWebhookAttempts is the amount of times a notification is posted before it is dropped
+	depositWebhookAttempts = 3
+)
+
+var (
+	// ErrEmptyDepositReference is returned in case a deposit address is allocated without customer reference.
+	ErrEmptyDepositReference = errors.New("a deposit address requires a customer reference")
+	// ErrInvalidDepositWebhook is returned in case the webhook of a deposit address is not an HTTP(S) URL.
+	ErrInvalidDepositWebhook = errors.New("the webhook of a deposit address has to be an HTTP(S) URL")
+	// ErrUnknownDepositAddress is returned in case an address is requested which is not an allocated deposit address.
+	ErrUnknownDepositAddress = errors.New("address is not an allocated deposit address")
+)
+
+var depositsMetadata = persist.Metadata{
+	Header:  "Goldchain Wallet Deposit Addresses",
+	Version: "1.0",
+}
+
+// DepositWebhook is an URL to which the deposits to an address are posted as JSON.
+type DepositWebhook struct {
+	URL string `json:"url"`
+	// Secret is used to sign the body of each request, using HMAC-SHA256,
+	// the hex-encoded signature being sent as the DepositSignatureHeader, no signature is sent if empty
+	Secret string `json:"secret,omitempty"`
+}
+
+// DepositAddress is a fresh address of the wallet allocated to receive the deposits of a customer.
+type DepositAddress struct {
+	Address types.UnlockHash `json:"address"`
+	// Reference is the external customer reference, used as the label of the address
+	Reference string `json:"reference"`
+	// Webhook is the URL the deposits are posted to, no notifications are posted if empty
+	Webhook string `json:"webhook,omitempty"`
+	// Height is the consensus height at which the address was allocated
+	Height types.BlockHeight `json:"height"`
+}
+
+// DepositNotification is posted to the webhook of a deposit address
+// for every coin output paid to it by a transaction of an applied block,
+// and again, with Reverted set, when that block is reverted.
+type DepositNotification struct {
+	Address       types.UnlockHash    `json:"address"`
+	Reference     string              `json:"reference"`
+	TransactionID types.TransactionID `json:"transactionid"`
+	CoinOutputID  types.CoinOutputID  `json:"coinoutputid"`
+	Value         types.Currency      `json:"value"`
+	Height        types.BlockHeight   `json:"height"`
+	BlockID       types.BlockID       `json:"blockid"`
+	Timestamp     types.Timestamp     `json:"timestamp"`
+	Reverted      bool                `json:"reverted"`
+}
+
+// Deposits allocates deposit addresses for the customers of an exchange,
+// each of them a fresh address of the wallet labeled using the reference of the customer,
+// and posts the deposits paid to them to the webhook given at allocation.
+//
+// Only deposits confirmed while the daemon runs are posted, a notification which cannot be posted
+// after a few attempts is logged and dropped. The history of a deposit address, as returned by
+// the transactions of its label, can be used to reconcile missed notifications.
+type Deposits struct {
+	labels   *AddressLabels
+	cs       modules.ConsensusSet
+	filename string
+	output   io.Writer
+	client   *http.Client
+	queue    chan depositDelivery
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	height    types.BlockHeight
+	addresses map[types.UnlockHash]depositEntry
+}
+
+// depositEntry is the persisted form of a deposit address, including the secret of its webhook.
+type depositEntry struct {
+	DepositAddress
+	Secret string `json:"secret,omitempty"`
+}
+
+// depositsFile is the persisted form of the deposit addresses.
+type depositsFile struct {
+	Addresses []depositEntry `json:"addresses"`
+}
+
+type depositDelivery struct {
+	webhook      DepositWebhook
+	notification DepositNotification
+}
+
+// NewDeposits loads the deposit addresses stored in the given file, labeling new ones using the given labels,
+// and subscribes to the given consensus set to post their deposits. Webhook failures are logged to the given writer.
+func NewDeposits(labels *AddressLabels, cs modules.ConsensusSet, filename string, output io.Writer) (*Deposits, error) {
+	d, err := newDeposits(labels, filename, output)
+	if err != nil {
+		return nil, err
+	}
+	d.cs = cs
+	d.height = cs.Height()
+	err = cs.ConsensusSetSubscribe(d, modules.ConsensusChangeRecent, d.closeCh)
+	if err != nil {
+		d.Close()
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	return d, nil
+}
+
+func newDeposits(labels *AddressLabels, filename string, output io.Writer) (*Deposits, error) {
+	if output == nil {
+		output = ioutil.Discard
+	}
+	d := &Deposits{
+		labels:    labels,
+		filename:  filename,
+		output:    output,
+		client:    &http.Client{Timeout: depositWebhookTimeout},
+		queue:     make(chan depositDelivery, depositQueueSize),
+		closeCh:   make(chan struct{}),
+		addresses: make(map[types.UnlockHash]depositEntry),
+	}
+	var file depositsFile
+	err := persist.LoadJSON(depositsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load wallet deposit addresses: %v", err)
+	}
+	for _, entry := range file.Addresses {
+		d.addresses[entry.Address] = entry
+	}
+	d.wg.Add(1)
+	go d.postDeposits()
+	return d, nil
+}
+
+// Close unsubscribes from the consensus set and stops posting deposits.
+func (d *Deposits) Close() error {
+	if d.cs != nil {
+		d.cs.Unsubscribe(d)
+	}
+	close(d.closeCh)
+	d.wg.Wait()
+	return nil
+}
+
+// Allocate generates a fresh address of the wallet, labels it using the given customer reference
+// and tags it as deposit, and registers it such that its deposits are posted to the given webhook, if any.
+func (d *Deposits) Allocate(reference string, webhook DepositWebhook) (DepositAddress, error) {
+	if strings.TrimSpace(reference) == "" {
+		return DepositAddress{}, ErrEmptyDepositReference
+	}
+	if webhook.URL != "" {
+		u, err := url.Parse(webhook.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return DepositAddress{}, ErrInvalidDepositWebhook
+		}
+	}
+	label, err := d.labels.Set(types.UnlockHash{}, reference, []string{DepositTag})
+	if err != nil {
+		return DepositAddress{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry := depositEntry{
+		DepositAddress: DepositAddress{
+			Address:   label.Address,
+			Reference: label.Label,
+			Webhook:   webhook.URL,
+			Height:    d.height,
+		},
+		Secret: webhook.Secret,
+	}
+	d.addresses[entry.Address] = entry
+	err = d.save()
+	if err != nil {
+		delete(d.addresses, entry.Address)
+		// the address is never handed out, so it no longer serves as deposit address
+		d.labels.Remove(entry.Address)
+		return DepositAddress{}, err
+	}
+	return entry.DepositAddress, nil
+}
+
+// Address returns the given deposit address.
+func (d *Deposits) Address(address types.UnlockHash) (DepositAddress, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry, ok := d.addresses[address]
+	if !ok {
+		return DepositAddress{}, ErrUnknownDepositAddress
+	}
+	return entry.DepositAddress, nil
+}
+
+// Addresses returns the deposit addresses allocated for the given customer reference,
+// or all of them if the reference is empty, sorted by allocation height and address.
+func (d *Deposits) Addresses(reference string) []DepositAddress {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	addresses := []DepositAddress{}
+	for _, entry := range d.addresses {
+		if reference == "" || entry.Reference == reference {
+			addresses = append(addresses, entry.DepositAddress)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].Height != addresses[j].Height {
+			return addresses[i].Height < addresses[j].Height
+		}
+		return addresses[i].Address.String() < addresses[j].Address.String()
+	})
+	return addresses
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// posting the coin outputs paid to deposit addresses by the reverted and applied blocks.
+func (d *Deposits) ProcessConsensusChange(cc modules.ConsensusChange) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, block := range cc.RevertedBlocks {
+		d.notify(block, d.height, true)
+		d.height--
+	}
+	for _, block := range cc.AppliedBlocks {
+		d.height++
+		d.notify(block, d.height, false)
+	}
+}
+
+// notify queues the notifications of the deposits of the given block, the caller holding mu.
+func (d *Deposits) notify(block types.Block, height types.BlockHeight, reverted bool) {
+	if len(d.addresses) == 0 {
+		return
+	}
+	for _, txn := range block.Transactions {
+		var id types.TransactionID
+		for index, co := range txn.CoinOutputs {
+			entry, ok := d.addresses[co.Condition.UnlockHash()]
+			if !ok || entry.Webhook == "" {
+				continue
+			}
+			if id == (types.TransactionID{}) {
+				id = txn.ID()
+			}
+			delivery := depositDelivery{
+				webhook: DepositWebhook{URL: entry.Webhook, Secret: entry.Secret},
+				notification: DepositNotification{
+					Address:       entry.Address,
+					Reference:     entry.Reference,
+					TransactionID: id,
+					CoinOutputID:  txn.CoinOutputID(uint64(index)),
+					Value:         co.Value,
+					Height:        height,
+					BlockID:       block.ID(),
+					Timestamp:     block.Timestamp,
+					Reverted:      reverted,
+				},
+			}
+			select {
+			case d.queue <- delivery:
+			default:
+				fmt.Fprintf(d.output, "Deposit webhook error: queue is full, deposit %s to %s is not posted\n",
+					delivery.notification.CoinOutputID.String(), entry.Address.String())
+			}
+		}
+	}
+}
+
+// postDeposits posts all queued notifications to their webhook, until the Deposits are closed.
+func (d *Deposits) postDeposits() {
+	defer d.wg.Done()
+	for {
+		select {
+		case <-d.closeCh:
+			return
+		case delivery := <-d.queue:
+			body, err := json.Marshal(delivery.notification)
+			if err != nil {
+				fmt.Fprintln(d.output, "Deposit webhook error: failed to encode deposit:", err)
+				continue
+			}
+			for attempt := 1; ; attempt++ {
+				err = d.post(delivery.webhook, body)
+				if err == nil || attempt == depositWebhookAttempts {
+					break
+				}
+				select {
+				case <-d.closeCh:
+					return
+				case <-time.After(time.Duration(attempt) * time.Second):
+				}
+			}
+			if err != nil {
+				fmt.Fprintf(d.output, "Deposit webhook error: failed to post deposit %s to webhook %s: %v\n",
+					delivery.notification.CoinOutputID.String(), delivery.webhook.URL, err)
+			}
+		}
+	}
+}
+
+// post posts the given body to the given webhook, signing it if the webhook has a secret.
+func (d *Deposits) post(webhook DepositWebhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(webhook.Secret))
+		mac.Write(body)
+		req.Header.Set(DepositSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// save stores the deposit addresses, the caller holding mu.
+func (d *Deposits) save() error {
+	file := depositsFile{Addresses: make([]depositEntry, 0, len(d.addresses))}
+	for _, entry := range d.addresses {
+		file.Addresses = append(file.Addresses, entry)
+	}
+	sort.Slice(file.Addresses, func(i, j int) bool {
+		return file.Addresses[i].Address.String() < file.Addresses[j].Address.String()
+	})
+	err := persist.SaveJSON(depositsMetadata, file, d.filename)
+	if err != nil {
+		return fmt.Errorf("failed to store wallet deposit addresses: %v", err)
+	}
+	return nil
+}
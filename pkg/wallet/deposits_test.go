@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestDeposits(t *testing.T) {
+	type request struct {
+		signature string
+		body      []byte
+	}
+	requests := make(chan request, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		requests <- request{signature: req.Header.Get(DepositSignatureHeader), body: body}
+	}))
+	defer server.Close()
+
+	w := &testLabelsWallet{testAccountsWallet: &testAccountsWallet{primary: modules.Seed{1}}}
+	dir := t.TempDir()
+	labels, err := NewAddressLabels(w, filepath.Join(dir, AddressLabelsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	filename := filepath.Join(dir, DepositsFile)
+	deposits, err := newDeposits(labels, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { deposits.Close() }()
+
+	if _, err := deposits.Allocate(" ", DepositWebhook{}); err != ErrEmptyDepositReference {
+		t.Errorf("expected an empty reference, got %v", err)
+	}
+	if _, err := deposits.Allocate("cust-1", DepositWebhook{URL: "ftp://example.com"}); err != ErrInvalidDepositWebhook {
+		t.Errorf("expected an invalid webhook, got %v", err)
+	}
+	notified, err := deposits.Allocate("cust-1", DepositWebhook{URL: server.URL, Secret: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	silent, err := deposits.Allocate("cust-2", DepositWebhook{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every deposit address is fresh, and labeled using the customer reference
+	if notified.Address == silent.Address {
+		t.Fatal("expected distinct deposit addresses")
+	}
+	label, err := labels.Label(notified.Address)
+	if err != nil || label.Label != "cust-1" || !label.HasTag(DepositTag) {
+		t.Errorf("expected the deposit address to be labeled, got %+v (%v)", label, err)
+	}
+
+	// only the deposits to addresses with a webhook are posted, both when applied and when reverted
+	pay := func(to types.UnlockHash) types.CoinOutput {
+		return types.CoinOutput{Value: types.NewCurrency64(7), Condition: types.NewCondition(types.NewUnlockHashCondition(to))}
+	}
+	block := types.Block{Transactions: []types.Transaction{{
+		CoinOutputs: []types.CoinOutput{pay(silent.Address), pay(notified.Address)},
+	}}}
+	deposits.ProcessConsensusChange(modules.ConsensusChange{AppliedBlocks: []types.Block{block}})
+	deposits.ProcessConsensusChange(modules.ConsensusChange{RevertedBlocks: []types.Block{block}})
+	for _, reverted := range []bool{false, true} {
+		var req request
+		select {
+		case req = <-requests:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected a deposit notification")
+		}
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(req.body)
+		if req.signature != hex.EncodeToString(mac.Sum(nil)) {
+			t.Error("expected the notification to be signed")
+		}
+		var notification DepositNotification
+		if err := json.Unmarshal(req.body, &notification); err != nil {
+			t.Fatal(err)
+		}
+		if notification.Address != notified.Address || notification.Reference != "cust-1" || notification.Height != 1 ||
+			notification.CoinOutputID != block.Transactions[0].CoinOutputID(1) || !notification.Value.Equals64(7) ||
+			notification.Reverted != reverted {
+			t.Errorf("unexpected notification: %+v", notification)
+		}
+	}
+	select {
+	case req := <-requests:
+		t.Errorf("unexpected notification: %s", req.body)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// deposit addresses are persisted
+	deposits.Close()
+	deposits, err = newDeposits(labels, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addresses := deposits.Addresses("cust-1"); len(addresses) != 1 || addresses[0] != notified {
+		t.Errorf("expected the deposit address to be restored, got %+v", addresses)
+	}
+	if _, err := deposits.Address(types.UnlockHash{}); err != ErrUnknownDepositAddress {
+		t.Errorf("expected an unknown deposit address, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package wallet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DistributionsFile is the name of the file logging every payout sent, stored in the wallet directory.
+const DistributionsFile = "distributions.log"
+
+type (
+	// Distribution is the auditable record of a payout sent by the wallet,
+	// such as the distribution of the transaction fee pool to its beneficiaries.
+	Distribution struct {
+		Template string `json:"template"`
+		// Time is the unix epoch timestamp (in seconds) at which the payout was sent
+		Time      types.Timestamp `json:"time"`
+		Scheduled bool            `json:"scheduled"`
+		// Height is the height of the consensus set at which the payout was sent
+		Height        types.BlockHeight   `json:"height"`
+		TransactionID types.TransactionID `json:"transactionid"`
+		Source        *types.UnlockHash   `json:"source,omitempty"`
+		// Balance is the balance of the source (or wallet) from which the shares were computed
+		Balance  types.Currency      `json:"balance"`
+		MinerFee types.Currency      `json:"minerfee"`
+		Shares   []DistributionShare `json:"shares"`
+	}
+
+	// DistributionShare is the part of a distribution sent to one of the recipients of the payout template.
+	DistributionShare struct {
+		Label      string           `json:"label,omitempty"`
+		Address    types.UnlockHash `json:"address"`
+		Percentage float64          `json:"percentage,omitempty"`
+		Value      types.Currency   `json:"value"`
+	}
+)
+
+// NewDistribution creates the record of the given payout, resolved from the given template
+// and sent in the transaction with the given ID.
+func NewDistribution(template PayoutTemplate, payout ResolvedPayout, txnID types.TransactionID, height types.BlockHeight, timestamp types.Timestamp, scheduled bool, constants types.ChainConstants) Distribution {
+	d := Distribution{
+		Template:      template.Name,
+		Time:          timestamp,
+		Scheduled:     scheduled,
+		Height:        height,
+		TransactionID: txnID,
+		Source:        template.Source,
+		Balance:       payout.Balance,
+		MinerFee:      constants.MinimumTransactionFee,
+	}
+	// the coin outputs of a resolved payout are ordered as the recipients of its template
+	for i, co := range payout.CoinOutputs {
+		recipient := template.Recipients[i]
+		d.Shares = append(d.Shares, DistributionShare{
+			Label:      recipient.Label,
+			Address:    co.Condition.UnlockHash(),
+			Percentage: recipient.Percentage,
+			Value:      co.Value,
+		})
+	}
+	return d
+}
+
+// DistributionLog is an append-only log of distributions, storing a JSON record per line.
+type DistributionLog struct {
+	filename string
+	mu       sync.Mutex
+}
+
+// NewDistributionLog creates a distribution log stored in the given file,
+// which is only created once the first distribution is recorded.
+func NewDistributionLog(filename string) *DistributionLog {
+	return &DistributionLog{filename: filename}
+}
+
+// Record appends the given distribution to the log.
+func (l *DistributionLog) Record(d Distribution) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.OpenFile(l.filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open distribution log: %v", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to record distribution: %v", err)
+	}
+	err = file.Sync()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to record distribution: %v", err)
+	}
+	return file.Close()
+}
+
+// Distributions returns the recorded distributions of the payout template with the given name,
+// or of all templates if no name is given, in the order they were sent.
+func (l *DistributionLog) Distributions(template string) ([]Distribution, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	file, err := os.Open(l.filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open distribution log: %v", err)
+	}
+	defer file.Close()
+	var distributions []Distribution
+	scanner := bufio.NewScanner(file)
+	// records aren't bounded by the default maximum line length
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var d Distribution
+		err = json.Unmarshal(scanner.Bytes(), &d)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode distribution on line %d of the distribution log: %v", line, err)
+		}
+		if template == "" || d.Template == template {
+			distributions = append(distributions, d)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read distribution log: %v", err)
+	}
+	return distributions, nil
+}
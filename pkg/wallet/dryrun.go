@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// DryRunResult is the result of a dry run of a wallet send.
+// The funded transaction is only defined in case the wallet was able to fund it,
+// and is defined even when it turns out to be invalid.
+type DryRunResult struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+
+	*FundedTransaction
+}
+
+// DryRun builds the transaction that would be sent by modules.Wallet.SendOutputs,
+// and validates it against the current consensus state (including all plugin validators),
+// without broadcasting it, nor reserving any of the wallet's outputs.
+// An error is only returned in case the wallet is locked.
+func DryRun(w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, coinOutputs []types.CoinOutput, blockStakeOutputs []types.BlockStakeOutput, data []byte, opts BuildOptions, constants types.ChainConstants) (DryRunResult, error) {
+	ft, err := BuildTransaction(w, tpool, coinOutputs, blockStakeOutputs, data, opts, constants)
+	if err == modules.ErrLockedWallet {
+		return DryRunResult{}, err
+	}
+	if err != nil {
+		return DryRunResult{Error: err.Error()}, nil
+	}
+	_, err = cs.TryTransactionSet([]types.Transaction{ft.Transaction})
+	if err != nil {
+		return DryRunResult{Error: err.Error(), FundedTransaction: &ft}, nil
+	}
+	return DryRunResult{Valid: true, FundedTransaction: &ft}, nil
+}
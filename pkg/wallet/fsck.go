@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// maxIntegrityCheckAttempts is the amount of times an integrity check is attempted,
+// should the consensus set change while checking.
+const maxIntegrityCheckAttempts = 3
+
+var (
+	// ErrConsensusChanged is returned in case the consensus set kept changing
+	// while checking the integrity of the wallet.
+	ErrConsensusChanged = errors.New("consensus set changed while checking the wallet, retry later")
+)
+
+// IntegrityReport is the result of a wallet integrity check.
+//
+// The wallet keeps its output and transaction indexes in memory,
+// and rebuilds them from the consensus set every time the daemon starts,
+// any inconsistency is thus resolved by restarting the daemon.
+type IntegrityReport struct {
+	// Height is the consensus height the wallet was checked against
+	Height types.BlockHeight `json:"height"`
+
+	CheckedCoinOutputs             int `json:"checkedcoinoutputs"`
+	CheckedBlockStakeOutputs       int `json:"checkedblockstakeoutputs"`
+	CheckedTransactions            int `json:"checkedtransactions"`
+	CheckedUnconfirmedTransactions int `json:"checkedunconfirmedtransactions"`
+
+	// MissingCoinOutputs are tracked as unspent by the wallet, but are spent or unknown to the consensus set
+	MissingCoinOutputs []types.CoinOutputID `json:"missingcoinoutputs,omitempty"`
+	// MissingBlockStakeOutputs are tracked as unspent by the wallet, but are spent or unknown to the consensus set
+	MissingBlockStakeOutputs []types.BlockStakeOutputID `json:"missingblockstakeoutputs,omitempty"`
+	// OrphanedTransactions are confirmed according to the wallet, but not part of the block at their confirmation height
+	OrphanedTransactions []types.TransactionID `json:"orphanedtransactions,omitempty"`
+	// StaleUnconfirmedTransactions are unconfirmed according to the wallet, but not part of the transaction pool
+	StaleUnconfirmedTransactions []types.TransactionID `json:"staleunconfirmedtransactions,omitempty"`
+}
+
+// Consistent returns true in case no issues were found.
+func (r IntegrityReport) Consistent() bool {
+	return len(r.MissingCoinOutputs) == 0 && len(r.MissingBlockStakeOutputs) == 0 &&
+		len(r.OrphanedTransactions) == 0 && len(r.StaleUnconfirmedTransactions) == 0
+}
+
+// CheckIntegrity validates the outputs and transactions tracked by the wallet
+// against the consensus set and transaction pool.
+// The check is retried should the consensus set change while checking.
+// The check stops early should the given context be done.
+func CheckIntegrity(ctx context.Context, w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) (IntegrityReport, error) {
+	for attempt := 0; attempt < maxIntegrityCheckAttempts; attempt++ {
+		currentBlockID := cs.CurrentBlock().ID()
+		report, err := checkIntegrity(ctx, w, cs, tpool)
+		if err != nil {
+			return IntegrityReport{}, err
+		}
+		if cs.CurrentBlock().ID() == currentBlockID {
+			return report, nil
+		}
+	}
+	return IntegrityReport{}, ErrConsensusChanged
+}
+
+func checkIntegrity(ctx context.Context, w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) (IntegrityReport, error) {
+	report := IntegrityReport{Height: cs.Height()}
+
+	// all outputs the wallet considers unspent have to be unspent in consensus
+	unlockedCoinOutputs, unlockedBlockStakeOutputs, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	lockedCoinOutputs, lockedBlockStakeOutputs, err := w.LockedUnspendOutputs()
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	for _, coinOutputs := range []map[types.CoinOutputID]types.CoinOutput{unlockedCoinOutputs, lockedCoinOutputs} {
+		for id := range coinOutputs {
+			if err := ctx.Err(); err != nil {
+				return IntegrityReport{}, err
+			}
+			report.CheckedCoinOutputs++
+			if _, err := cs.GetCoinOutput(id); err != nil {
+				report.MissingCoinOutputs = append(report.MissingCoinOutputs, id)
+			}
+		}
+	}
+	for _, bsOutputs := range []map[types.BlockStakeOutputID]types.BlockStakeOutput{unlockedBlockStakeOutputs, lockedBlockStakeOutputs} {
+		for id := range bsOutputs {
+			if err := ctx.Err(); err != nil {
+				return IntegrityReport{}, err
+			}
+			report.CheckedBlockStakeOutputs++
+			if _, err := cs.GetBlockStakeOutput(id); err != nil {
+				report.MissingBlockStakeOutputs = append(report.MissingBlockStakeOutputs, id)
+			}
+		}
+	}
+
+	// all confirmed transactions have to be part of the block at their confirmation height
+	pts, err := w.Transactions(0, report.Height)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	blockTransactions := make(map[types.BlockHeight]map[types.TransactionID]struct{})
+	for _, pt := range pts {
+		if err := ctx.Err(); err != nil {
+			return IntegrityReport{}, err
+		}
+		report.CheckedTransactions++
+		ids, ok := blockTransactions[pt.ConfirmationHeight]
+		if !ok {
+			ids = make(map[types.TransactionID]struct{})
+			if block, ok := cs.BlockAtHeight(pt.ConfirmationHeight); ok {
+				for _, txn := range block.Transactions {
+					ids[txn.ID()] = struct{}{}
+				}
+			}
+			blockTransactions[pt.ConfirmationHeight] = ids
+		}
+		if _, ok := ids[pt.TransactionID]; !ok {
+			report.OrphanedTransactions = append(report.OrphanedTransactions, pt.TransactionID)
+		}
+	}
+
+	// all unconfirmed transactions have to be part of the transaction pool
+	pts, err = w.UnconfirmedTransactions()
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	for _, pt := range pts {
+		report.CheckedUnconfirmedTransactions++
+		if _, err := tpool.Transaction(pt.TransactionID); err != nil {
+			report.StaleUnconfirmedTransactions = append(report.StaleUnconfirmedTransactions, pt.TransactionID)
+		}
+	}
+	return report, nil
+}
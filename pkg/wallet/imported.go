@@ -0,0 +1,462 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// ImportedAddressesFile is the name of the file listing the imported addresses, stored in the wallet directory.
+const ImportedAddressesFile = "importedaddresses.json"
+
+// The ways the history of an imported address is backfilled.
+const (
+	// BackfillExplorer backfills the history instantly, using the address index of the explorer
+	BackfillExplorer = "explorer"
+	// BackfillRescan backfills the history by rescanning the blockchain in the background,
+	// used in case the explorer module is not loaded
+	BackfillRescan = "rescan"
+)
+
+var (
+	// ErrAddressImported is returned in case an address is imported which is already imported.
+	ErrAddressImported = errors.New("address is already imported")
+	// ErrAddressNotImported is returned in case an address is requested which is not imported.
+	ErrAddressNotImported = errors.New("address is not imported")
+	// ErrNilImportedAddress is returned in case the nil address is imported.
+	ErrNilImportedAddress = errors.New("the nil address cannot be imported")
+)
+
+var importedAddressesMetadata = persist.Metadata{
+	Header:  "Goldchain Imported Addresses",
+	Version: "1.0",
+}
+
+// ImportedAddress is a watch-only address, of which the wallet does not own the keys.
+type ImportedAddress struct {
+	Address types.UnlockHash `json:"address"`
+	// StartHeight is the height of the first block of which the transactions are part of the history
+	StartHeight types.BlockHeight `json:"startheight"`
+	// Backfill is the way the history prior to the import is backfilled
+	Backfill string `json:"backfill"`
+	// Synced is false as long as the history prior to the import is being backfilled
+	Synced bool `json:"synced"`
+	// Transactions is the confirmed history of the address, lowest height first,
+	// miner payouts being identified by the ID of their block
+	Transactions []ImportedTransaction `json:"transactions"`
+	// ConfirmedCoinBalance and ConfirmedBlockStakeBalance are the sums of the unspent outputs
+	// paid to the address since its start height
+	ConfirmedCoinBalance       types.Currency `json:"confirmedcoinbalance"`
+	ConfirmedBlockStakeBalance types.Currency `json:"confirmedblockstakebalance"`
+}
+
+// ImportedTransaction is a confirmed transaction of an imported address.
+type ImportedTransaction struct {
+	ID     types.TransactionID `json:"id"`
+	Height types.BlockHeight   `json:"height"`
+}
+
+// ImportedAddresses tracks the history of watch-only addresses,
+// backfilling the history prior to their import using the explorer should it be loaded,
+// rather than rescanning the entire blockchain.
+// Only the imported addresses are stored, their history is backfilled again whenever the node starts.
+type ImportedAddresses struct {
+	cs       modules.ConsensusSet
+	explorer modules.Explorer
+	filename string
+	output   io.Writer
+	closeCh  chan struct{}
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	height    types.BlockHeight
+	addresses map[types.UnlockHash]*importedAddress
+	// owners maps the IDs of the outputs paid to imported addresses to those addresses
+	owners map[crypto.Hash]types.UnlockHash
+}
+
+type importedAddress struct {
+	address     types.UnlockHash
+	startHeight types.BlockHeight
+	backfill    string
+	synced      bool
+	// transactions by ID
+	transactions      map[types.TransactionID]types.BlockHeight
+	coinOutputs       map[types.CoinOutputID]struct{}
+	blockStakeOutputs map[types.BlockStakeOutputID]struct{}
+}
+
+// importedAddressesFile is the persisted form of the imported addresses.
+type importedAddressesFile struct {
+	Addresses []importedAddressEntry `json:"addresses"`
+}
+
+type importedAddressEntry struct {
+	Address     types.UnlockHash  `json:"address"`
+	StartHeight types.BlockHeight `json:"startheight"`
+}
+
+// NewImportedAddresses loads the imported addresses stored in the given file,
+// subscribing to the given consensus set, which is expected not to be started yet,
+// and backfilling the history of all imported addresses. The explorer is optional,
+// the history being backfilled by rescanning the blockchain in the background should it not be given.
+// Failures to backfill the history are logged to the given writer.
+func NewImportedAddresses(cs modules.ConsensusSet, explorer modules.Explorer, filename string, output io.Writer) (*ImportedAddresses, error) {
+	if output == nil {
+		output = ioutil.Discard
+	}
+	ia := &ImportedAddresses{
+		cs:        cs,
+		explorer:  explorer,
+		filename:  filename,
+		output:    output,
+		closeCh:   make(chan struct{}),
+		addresses: make(map[types.UnlockHash]*importedAddress),
+		owners:    make(map[crypto.Hash]types.UnlockHash),
+	}
+	var file importedAddressesFile
+	err := persist.LoadJSON(importedAddressesMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load imported addresses: %v", err)
+	}
+	ia.height = cs.Height()
+	err = cs.ConsensusSetSubscribe(ia, modules.ConsensusChangeRecent, ia.closeCh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	var imported []*importedAddress
+	ia.mu.Lock()
+	for _, entry := range file.Addresses {
+		imported = append(imported, ia.track(entry.Address, entry.StartHeight))
+	}
+	ia.mu.Unlock()
+	ia.backfill(imported)
+	return ia, nil
+}
+
+// Close stops backfilling and unsubscribes from the consensus set.
+func (ia *ImportedAddresses) Close() error {
+	ia.cs.Unsubscribe(ia)
+	close(ia.closeCh)
+	ia.wg.Wait()
+	return nil
+}
+
+// Import starts tracking the given watch-only address, its history starting at the given height,
+// which is backfilled instantly should the explorer be loaded, or in the background otherwise.
+func (ia *ImportedAddresses) Import(address types.UnlockHash, startHeight types.BlockHeight) (ImportedAddress, error) {
+	if address.Type == types.UnlockTypeNil {
+		return ImportedAddress{}, ErrNilImportedAddress
+	}
+	ia.mu.Lock()
+	if _, ok := ia.addresses[address]; ok {
+		ia.mu.Unlock()
+		return ImportedAddress{}, ErrAddressImported
+	}
+	entries := ia.entries()
+	entries = append(entries, importedAddressEntry{Address: address, StartHeight: startHeight})
+	err := persist.SaveJSON(importedAddressesMetadata, importedAddressesFile{Addresses: entries}, ia.filename)
+	if err != nil {
+		ia.mu.Unlock()
+		return ImportedAddress{}, fmt.Errorf("failed to store imported addresses: %v", err)
+	}
+	imported := ia.track(address, startHeight)
+	ia.mu.Unlock()
+
+	ia.backfill([]*importedAddress{imported})
+	return ia.Address(address)
+}
+
+// Address returns the imported address with its history and balance,
+// or an error in case the address is not imported.
+func (ia *ImportedAddresses) Address(address types.UnlockHash) (ImportedAddress, error) {
+	ia.mu.Lock()
+	imported, ok := ia.addresses[address]
+	var result ImportedAddress
+	var coinOutputs []types.CoinOutputID
+	var blockStakeOutputs []types.BlockStakeOutputID
+	if ok {
+		result, coinOutputs, blockStakeOutputs = imported.snapshot()
+	}
+	ia.mu.Unlock()
+	if !ok {
+		return ImportedAddress{}, ErrAddressNotImported
+	}
+	// the consensus set cannot be used while holding the lock, as it might be notifying us
+	for _, id := range coinOutputs {
+		if co, err := ia.cs.GetCoinOutput(id); err == nil {
+			result.ConfirmedCoinBalance = result.ConfirmedCoinBalance.Add(co.Value)
+		}
+	}
+	for _, id := range blockStakeOutputs {
+		if bso, err := ia.cs.GetBlockStakeOutput(id); err == nil {
+			result.ConfirmedBlockStakeBalance = result.ConfirmedBlockStakeBalance.Add(bso.Value)
+		}
+	}
+	return result, nil
+}
+
+// Addresses returns all imported addresses with their history and balance, sorted by address.
+func (ia *ImportedAddresses) Addresses() ([]ImportedAddress, error) {
+	ia.mu.Lock()
+	entries := ia.entries()
+	ia.mu.Unlock()
+	addresses := make([]ImportedAddress, 0, len(entries))
+	for _, entry := range entries {
+		address, err := ia.Address(entry.Address)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// adding the transactions of the applied blocks to the history of the imported addresses,
+// and removing those of the reverted blocks.
+func (ia *ImportedAddresses) ProcessConsensusChange(cc modules.ConsensusChange) {
+	ia.mu.Lock()
+	defer ia.mu.Unlock()
+	for _, block := range cc.RevertedBlocks {
+		ia.revertBlock(block)
+		ia.height--
+	}
+	for _, block := range cc.AppliedBlocks {
+		ia.height++
+		ia.applyBlock(block, ia.height, nil)
+	}
+}
+
+// track starts tracking the given address, the caller holding mu.
+func (ia *ImportedAddresses) track(address types.UnlockHash, startHeight types.BlockHeight) *importedAddress {
+	imported := &importedAddress{
+		address:           address,
+		startHeight:       startHeight,
+		backfill:          BackfillRescan,
+		transactions:      make(map[types.TransactionID]types.BlockHeight),
+		coinOutputs:       make(map[types.CoinOutputID]struct{}),
+		blockStakeOutputs: make(map[types.BlockStakeOutputID]struct{}),
+	}
+	if ia.explorer != nil {
+		imported.backfill = BackfillExplorer
+	}
+	ia.addresses[address] = imported
+	return imported
+}
+
+// entries returns the imported addresses as stored, sorted by address, the caller holding mu.
+func (ia *ImportedAddresses) entries() []importedAddressEntry {
+	entries := make([]importedAddressEntry, 0, len(ia.addresses))
+	for _, imported := range ia.addresses {
+		entries = append(entries, importedAddressEntry{Address: imported.address, StartHeight: imported.startHeight})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address.String() < entries[j].Address.String()
+	})
+	return entries
+}
+
+// backfill adds the history prior to their import to the given addresses,
+// using the explorer should it be loaded, rescanning the blockchain in the background otherwise.
+func (ia *ImportedAddresses) backfill(addresses []*importedAddress) {
+	if len(addresses) == 0 {
+		return
+	}
+	if ia.explorer == nil {
+		ia.wg.Add(1)
+		go func() {
+			defer ia.wg.Done()
+			ia.rescan(addresses)
+		}()
+		return
+	}
+	for _, imported := range addresses {
+		// the explorer cannot be used while holding the lock, as it is notified by the consensus set as well
+		var blocks []explorerBlock
+		seen := make(map[types.BlockID]struct{})
+		for _, id := range ia.explorer.UnlockHash(imported.address) {
+			block, height, ok := ia.explorer.Transaction(id)
+			if !ok || height < imported.startHeight {
+				continue
+			}
+			if _, ok := seen[block.ID()]; !ok {
+				seen[block.ID()] = struct{}{}
+				blocks = append(blocks, explorerBlock{block: block, height: height})
+			}
+		}
+		// blocks are applied in order, such that the outputs spent by a transaction are known to be paid to the address
+		sort.Slice(blocks, func(i, j int) bool {
+			return blocks[i].height < blocks[j].height
+		})
+		ia.mu.Lock()
+		for _, eb := range blocks {
+			ia.applyBlock(eb.block, eb.height, imported)
+		}
+		imported.synced = true
+		ia.mu.Unlock()
+	}
+}
+
+type explorerBlock struct {
+	block  types.Block
+	height types.BlockHeight
+}
+
+// rescan adds the history prior to their import to the given addresses, by scanning the entire blockchain.
+func (ia *ImportedAddresses) rescan(addresses []*importedAddress) {
+	scanner := &rescanner{ia: ia, addresses: addresses}
+	err := ia.cs.ConsensusSetSubscribe(scanner, modules.ConsensusChangeBeginning, ia.closeCh)
+	if err != nil {
+		if !isClosed(ia.closeCh) {
+			fmt.Fprintf(ia.output, "Failed to backfill the history of imported addresses: %v\n", err)
+		}
+		return
+	}
+	ia.cs.Unsubscribe(scanner)
+	ia.mu.Lock()
+	for _, imported := range addresses {
+		imported.synced = true
+	}
+	ia.mu.Unlock()
+}
+
+// rescanner adds all blocks of the blockchain to the history of the addresses being backfilled.
+type rescanner struct {
+	ia        *ImportedAddresses
+	addresses []*importedAddress
+	// applied is the amount of applied blocks, the genesis block included
+	applied types.BlockHeight
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber.ProcessConsensusChange
+func (scanner *rescanner) ProcessConsensusChange(cc modules.ConsensusChange) {
+	scanner.ia.mu.Lock()
+	defer scanner.ia.mu.Unlock()
+	// blocks reverted while rescanning are reverted by the ImportedAddresses itself
+	scanner.applied -= types.BlockHeight(len(cc.RevertedBlocks))
+	for _, block := range cc.AppliedBlocks {
+		for _, imported := range scanner.addresses {
+			scanner.ia.applyBlock(block, scanner.applied, imported)
+		}
+		scanner.applied++
+	}
+}
+
+// applyBlock adds the transactions of the given block to the history of the imported addresses it concerns,
+// or only to the history of the given address should one be given, the caller holding mu.
+func (ia *ImportedAddresses) applyBlock(block types.Block, height types.BlockHeight, only *importedAddress) {
+	concerns := func(address types.UnlockHash) (*importedAddress, bool) {
+		if only != nil {
+			return only, address == only.address && height >= only.startHeight
+		}
+		imported, ok := ia.addresses[address]
+		return imported, ok && height >= imported.startHeight
+	}
+	for i, payout := range block.MinerPayouts {
+		if imported, ok := concerns(payout.UnlockHash); ok {
+			id := block.MinerPayoutID(uint64(i))
+			imported.transactions[types.TransactionID(block.ID())] = height
+			imported.coinOutputs[id] = struct{}{}
+			ia.owners[crypto.Hash(id)] = imported.address
+		}
+	}
+	for _, txn := range block.Transactions {
+		txnID := txn.ID()
+		for _, ci := range txn.CoinInputs {
+			if owner, ok := ia.owners[crypto.Hash(ci.ParentID)]; ok {
+				if imported, ok := concerns(owner); ok {
+					imported.transactions[txnID] = height
+				}
+			}
+		}
+		for _, bsi := range txn.BlockStakeInputs {
+			if owner, ok := ia.owners[crypto.Hash(bsi.ParentID)]; ok {
+				if imported, ok := concerns(owner); ok {
+					imported.transactions[txnID] = height
+				}
+			}
+		}
+		for i, co := range txn.CoinOutputs {
+			if imported, ok := concerns(co.Condition.UnlockHash()); ok {
+				id := txn.CoinOutputID(uint64(i))
+				imported.transactions[txnID] = height
+				imported.coinOutputs[id] = struct{}{}
+				ia.owners[crypto.Hash(id)] = imported.address
+			}
+		}
+		for i, bso := range txn.BlockStakeOutputs {
+			if imported, ok := concerns(bso.Condition.UnlockHash()); ok {
+				id := txn.BlockStakeOutputID(uint64(i))
+				imported.transactions[txnID] = height
+				imported.blockStakeOutputs[id] = struct{}{}
+				ia.owners[crypto.Hash(id)] = imported.address
+			}
+		}
+	}
+}
+
+// revertBlock removes the transactions of the given block from the history of all imported addresses,
+// the caller holding mu.
+func (ia *ImportedAddresses) revertBlock(block types.Block) {
+	ids := []types.TransactionID{types.TransactionID(block.ID())}
+	for _, txn := range block.Transactions {
+		ids = append(ids, txn.ID())
+	}
+	for _, imported := range ia.addresses {
+		for _, id := range ids {
+			delete(imported.transactions, id)
+		}
+	}
+	// outputs created by the reverted block no longer exist, and are thus not part of the balance anymore,
+	// such that they do not have to be removed
+}
+
+// snapshot returns the address with its history, as well as the IDs of the outputs paid to it,
+// the caller holding the lock of the ImportedAddresses.
+func (imported *importedAddress) snapshot() (ImportedAddress, []types.CoinOutputID, []types.BlockStakeOutputID) {
+	result := ImportedAddress{
+		Address:      imported.address,
+		StartHeight:  imported.startHeight,
+		Backfill:     imported.backfill,
+		Synced:       imported.synced,
+		Transactions: make([]ImportedTransaction, 0, len(imported.transactions)),
+	}
+	for id, height := range imported.transactions {
+		result.Transactions = append(result.Transactions, ImportedTransaction{ID: id, Height: height})
+	}
+	sort.Slice(result.Transactions, func(i, j int) bool {
+		a, b := result.Transactions[i], result.Transactions[j]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		return a.ID.String() < b.ID.String()
+	})
+	coinOutputs := make([]types.CoinOutputID, 0, len(imported.coinOutputs))
+	for id := range imported.coinOutputs {
+		coinOutputs = append(coinOutputs, id)
+	}
+	blockStakeOutputs := make([]types.BlockStakeOutputID, 0, len(imported.blockStakeOutputs))
+	for id := range imported.blockStakeOutputs {
+		blockStakeOutputs = append(blockStakeOutputs, id)
+	}
+	return result, coinOutputs, blockStakeOutputs
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testImportCS is a consensus set of which the unspent coin outputs are given.
+type testImportCS struct {
+	modules.ConsensusSet
+	height     types.BlockHeight
+	unspent    map[types.CoinOutputID]types.CoinOutput
+	subscriber modules.ConsensusSetSubscriber
+}
+
+func (cs *testImportCS) Height() types.BlockHeight { return cs.height }
+func (cs *testImportCS) ConsensusSetSubscribe(s modules.ConsensusSetSubscriber, _ modules.ConsensusChangeID, _ <-chan struct{}) error {
+	cs.subscriber = s
+	return nil
+}
+func (cs *testImportCS) Unsubscribe(modules.ConsensusSetSubscriber) {}
+func (cs *testImportCS) GetCoinOutput(id types.CoinOutputID) (types.CoinOutput, error) {
+	if co, ok := cs.unspent[id]; ok {
+		return co, nil
+	}
+	return types.CoinOutput{}, errors.New("not found")
+}
+func (cs *testImportCS) GetBlockStakeOutput(types.BlockStakeOutputID) (types.BlockStakeOutput, error) {
+	return types.BlockStakeOutput{}, errors.New("not found")
+}
+
+// testExplorer indexes the transactions of the given blocks by the addresses they pay to.
+type testExplorer struct {
+	modules.Explorer
+	blocks []types.Block
+}
+
+func (e *testExplorer) UnlockHash(uh types.UnlockHash) []types.TransactionID {
+	var ids []types.TransactionID
+	for _, block := range e.blocks {
+		for _, txn := range block.Transactions {
+			for _, co := range txn.CoinOutputs {
+				if co.Condition.UnlockHash() == uh {
+					ids = append(ids, txn.ID())
+				}
+			}
+		}
+	}
+	return ids
+}
+
+func (e *testExplorer) Transaction(id types.TransactionID) (types.Block, types.BlockHeight, bool) {
+	for height, block := range e.blocks {
+		for _, txn := range block.Transactions {
+			if txn.ID() == id {
+				return block, types.BlockHeight(height), true
+			}
+		}
+	}
+	return types.Block{}, 0, false
+}
+
+func TestImportedAddresses(t *testing.T) {
+	uh := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	other := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	paying := func(value uint64, to types.UnlockHash, inputs ...types.CoinOutputID) types.Transaction {
+		txn := types.Transaction{
+			Version: types.TransactionVersionOne,
+			CoinOutputs: []types.CoinOutput{
+				{Value: types.NewCurrency64(value), Condition: types.NewCondition(types.NewUnlockHashCondition(to))},
+			},
+		}
+		for _, id := range inputs {
+			txn.CoinInputs = append(txn.CoinInputs, types.CoinInput{ParentID: id})
+		}
+		return txn
+	}
+	old := paying(5, uh)
+	deposit := paying(10, uh)
+	blocks := []types.Block{
+		{Transactions: []types.Transaction{old}},
+		{Timestamp: 1, Transactions: []types.Transaction{deposit}},
+		{Timestamp: 2, Transactions: []types.Transaction{paying(1, other)}},
+	}
+	cs := &testImportCS{height: 2, unspent: map[types.CoinOutputID]types.CoinOutput{
+		old.CoinOutputID(0):     old.CoinOutputs[0],
+		deposit.CoinOutputID(0): deposit.CoinOutputs[0],
+	}}
+	filename := filepath.Join(t.TempDir(), ImportedAddressesFile)
+	ia, err := NewImportedAddresses(cs, &testExplorer{blocks: blocks}, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ia.Close()
+
+	// the history is backfilled instantly from the given start height
+	address, err := ia.Import(uh, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !address.Synced || address.Backfill != BackfillExplorer || len(address.Transactions) != 1 ||
+		address.Transactions[0] != (ImportedTransaction{ID: deposit.ID(), Height: 1}) || !address.ConfirmedCoinBalance.Equals64(10) {
+		t.Fatalf("unexpected imported address: %+v", address)
+	}
+	if _, err := ia.Import(uh, 0); err != ErrAddressImported {
+		t.Fatalf("expected ErrAddressImported, got %v", err)
+	}
+
+	// new blocks spending the deposit are tracked, and reverted
+	spend := paying(10, other, deposit.CoinOutputID(0))
+	block := types.Block{Timestamp: 3, Transactions: []types.Transaction{spend}}
+	delete(cs.unspent, deposit.CoinOutputID(0))
+	cs.subscriber.ProcessConsensusChange(modules.ConsensusChange{AppliedBlocks: []types.Block{block}})
+	address, err = ia.Address(uh)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(address.Transactions) != 2 || address.Transactions[1] != (ImportedTransaction{ID: spend.ID(), Height: 3}) ||
+		!address.ConfirmedCoinBalance.IsZero() {
+		t.Fatalf("unexpected imported address: %+v", address)
+	}
+	cs.unspent[deposit.CoinOutputID(0)] = deposit.CoinOutputs[0]
+	cs.subscriber.ProcessConsensusChange(modules.ConsensusChange{RevertedBlocks: []types.Block{block}})
+	if address, _ = ia.Address(uh); len(address.Transactions) != 1 || !address.ConfirmedCoinBalance.Equals64(10) {
+		t.Fatalf("unexpected imported address after revert: %+v", address)
+	}
+
+	// imported addresses are backfilled again when loaded
+	ia2, err := NewImportedAddresses(cs, &testExplorer{blocks: blocks}, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ia2.Close()
+	addresses, err := ia2.Addresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 1 || addresses[0].StartHeight != 1 || len(addresses[0].Transactions) != 1 {
+		t.Fatalf("unexpected imported addresses: %+v", addresses)
+	}
+	if _, err := ia2.Address(other); err != ErrAddressNotImported {
+		t.Fatalf("expected ErrAddressNotImported, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// AddressLabelsFile is the name of the file containing the labels of the wallet addresses, stored in the wallet directory.
+const AddressLabelsFile = "labels.json"
+
+// MaxAddressLabelLength is the maximum length of the label of an address.
+const MaxAddressLabelLength = 128
+
+var (
+	// ErrInvalidAddressLabel is returned in case an address label is too long or contains control characters.
+	ErrInvalidAddressLabel = fmt.Errorf(
+		"an address label has to be at most %d characters long and cannot contain control characters", MaxAddressLabelLength)
+	// ErrInvalidAddressTag is returned in case an address tag is empty, too long or contains invalid characters.
+	ErrInvalidAddressTag = fmt.Errorf(
+		"an address tag has to start with a letter, consist only of letters, digits, '-', '_' and '.', and be at most %d characters long",
+		MaxContactNameLength)
+	// ErrEmptyAddressLabel is returned in case an address is labeled using neither a label nor a tag.
+	ErrEmptyAddressLabel = errors.New("an address requires a label or at least one tag")
+	// ErrUnlabeledAddress is returned in case an address has no label.
+	ErrUnlabeledAddress = errors.New("address has no label")
+)
+
+var addressLabelsMetadata = persist.Metadata{
+	Header:  "Goldchain Wallet Address Labels",
+	Version: "1.0",
+}
+
+// AddressLabel is the label and the tags attached to an address of the wallet,
+// describing for example the customer or purpose a deposit address served.
+type AddressLabel struct {
+	Address types.UnlockHash `json:"address"`
+	Label   string           `json:"label,omitempty"`
+	// Tags are sorted and unique
+	Tags []string `json:"tags,omitempty"`
+}
+
+// HasTag returns true in case the address is tagged using the given tag.
+func (l AddressLabel) HasTag(tag string) bool {
+	i := sort.SearchStrings(l.Tags, tag)
+	return i < len(l.Tags) && l.Tags[i] == tag
+}
+
+// AddressLabelFilter selects labeled addresses, an empty field matching every address.
+type AddressLabelFilter struct {
+	// Label has to equal the label of an address
+	Label string
+	// Tag has to be one of the tags of an address
+	Tag string
+}
+
+// Matches returns true in case the given labeled address is selected by the filter.
+func (f AddressLabelFilter) Matches(l AddressLabel) bool {
+	return (f.Label == "" || f.Label == l.Label) && (f.Tag == "" || l.HasTag(f.Tag))
+}
+
+// AddressLabels keeps the labels and tags attached to the addresses of a wallet,
+// such that the balance and history of the addresses sharing a label or tag can be queried.
+// Only addresses of which the wallet owns the key can be labeled, which requires the wallet to be unlocked.
+type AddressLabels struct {
+	w        modules.Wallet
+	filename string
+
+	mu     sync.Mutex
+	labels map[types.UnlockHash]AddressLabel
+}
+
+// addressLabelsFile is the persisted form of the address labels.
+type addressLabelsFile struct {
+	Labels []AddressLabel `json:"labels"`
+}
+
+// NewAddressLabels loads the address labels of the given wallet stored in the given file,
+// which is only created once the first address is labeled.
+func NewAddressLabels(w modules.Wallet, filename string) (*AddressLabels, error) {
+	al := &AddressLabels{
+		w:        w,
+		filename: filename,
+		labels:   make(map[types.UnlockHash]AddressLabel),
+	}
+	var file addressLabelsFile
+	err := persist.LoadJSON(addressLabelsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load wallet address labels: %v", err)
+	}
+	for _, label := range file.Labels {
+		al.labels[label.Address] = label
+	}
+	return al, nil
+}
+
+// Set attaches the given label and tags to an address of the wallet, replacing its previous label and tags.
+// A nil address labels a newly generated address of the wallet instead.
+func (al *AddressLabels) Set(address types.UnlockHash, label string, tags []string) (AddressLabel, error) {
+	labeled, err := newAddressLabel(label, tags)
+	if err != nil {
+		return AddressLabel{}, err
+	}
+	if address == (types.UnlockHash{}) {
+		address, err = al.w.NextAddress()
+		if err != nil {
+			return AddressLabel{}, err
+		}
+	} else if _, _, err = al.w.GetKey(address); err != nil {
+		if err == modules.ErrLockedWallet {
+			return AddressLabel{}, err
+		}
+		return AddressLabel{}, ErrAddressNotOwned
+	}
+	labeled.Address = address
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	previous, existed := al.labels[address]
+	al.labels[address] = labeled
+	err = al.save()
+	if err != nil {
+		if existed {
+			al.labels[address] = previous
+		} else {
+			delete(al.labels, address)
+		}
+		return AddressLabel{}, err
+	}
+	return copyAddressLabel(labeled), nil
+}
+
+// Remove removes the label and tags of the given address.
+func (al *AddressLabels) Remove(address types.UnlockHash) error {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	previous, ok := al.labels[address]
+	if !ok {
+		return ErrUnlabeledAddress
+	}
+	delete(al.labels, address)
+	err := al.save()
+	if err != nil {
+		al.labels[address] = previous
+		return err
+	}
+	return nil
+}
+
+// Label returns the label and tags of the given address.
+func (al *AddressLabels) Label(address types.UnlockHash) (AddressLabel, error) {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	label, ok := al.labels[address]
+	if !ok {
+		return AddressLabel{}, ErrUnlabeledAddress
+	}
+	return copyAddressLabel(label), nil
+}
+
+// Labels returns the labeled addresses selected by the given filter, sorted by label and address.
+func (al *AddressLabels) Labels(filter AddressLabelFilter) []AddressLabel {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	labels := []AddressLabel{}
+	for _, label := range al.labels {
+		if filter.Matches(label) {
+			labels = append(labels, copyAddressLabel(label))
+		}
+	}
+	sortAddressLabels(labels)
+	return labels
+}
+
+// Balance returns the confirmed balance of the addresses selected by the given filter.
+func (al *AddressLabels) Balance(filter AddressLabelFilter) (AccountBalance, error) {
+	return balanceOf(al.w, al.matching(filter))
+}
+
+// Transactions returns the confirmed transactions involving the addresses selected by the given filter,
+// lowest height first.
+func (al *AddressLabels) Transactions(filter AddressLabelFilter) ([]AccountTransaction, error) {
+	return transactionsOf(al.w, al.matching(filter))
+}
+
+// matching returns whether an address is labeled and selected by the given filter.
+func (al *AddressLabels) matching(filter AddressLabelFilter) func(types.UnlockHash) bool {
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	addresses := make(map[types.UnlockHash]struct{})
+	for address, label := range al.labels {
+		if filter.Matches(label) {
+			addresses[address] = struct{}{}
+		}
+	}
+	return func(address types.UnlockHash) bool {
+		_, ok := addresses[address]
+		return ok
+	}
+}
+
+// save stores the address labels, the caller holding mu.
+func (al *AddressLabels) save() error {
+	file := addressLabelsFile{Labels: make([]AddressLabel, 0, len(al.labels))}
+	for _, label := range al.labels {
+		file.Labels = append(file.Labels, label)
+	}
+	sortAddressLabels(file.Labels)
+	err := persist.SaveJSON(addressLabelsMetadata, file, al.filename)
+	if err != nil {
+		return fmt.Errorf("failed to store wallet address labels: %v", err)
+	}
+	return nil
+}
+
+// newAddressLabel validates the given label and tags, returning them as an address label without address.
+func newAddressLabel(label string, tags []string) (AddressLabel, error) {
+	label = strings.TrimSpace(label)
+	if len(label) > MaxAddressLabelLength || strings.IndexFunc(label, unicode.IsControl) >= 0 {
+		return AddressLabel{}, ErrInvalidAddressLabel
+	}
+	unique := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		if !isValidName(tag) {
+			return AddressLabel{}, ErrInvalidAddressTag
+		}
+		unique[tag] = struct{}{}
+	}
+	if label == "" && len(unique) == 0 {
+		return AddressLabel{}, ErrEmptyAddressLabel
+	}
+	labeled := AddressLabel{Label: label}
+	for tag := range unique {
+		labeled.Tags = append(labeled.Tags, tag)
+	}
+	sort.Strings(labeled.Tags)
+	return labeled, nil
+}
+
+func sortAddressLabels(labels []AddressLabel) {
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Label != labels[j].Label {
+			return labels[i].Label < labels[j].Label
+		}
+		return labels[i].Address.String() < labels[j].Address.String()
+	})
+}
+
+func copyAddressLabel(label AddressLabel) AddressLabel {
+	if label.Tags != nil {
+		label.Tags = append([]string{}, label.Tags...)
+	}
+	return label
+}
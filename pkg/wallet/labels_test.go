@@ -0,0 +1,116 @@
+package wallet
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+)
+
+// testLabelsWallet owns the addresses of the primary seed it generated.
+type testLabelsWallet struct {
+	*testAccountsWallet
+	progress uint64
+}
+
+func (w *testLabelsWallet) NextAddress() (types.UnlockHash, error) {
+	address := seed.Address(w.primary, w.progress)
+	w.progress++
+	return address, nil
+}
+func (w *testLabelsWallet) GetKey(address types.UnlockHash) (types.PublicKey, types.ByteSlice, error) {
+	for index := uint64(0); index < w.progress; index++ {
+		if seed.Address(w.primary, index) == address {
+			return types.PublicKey{}, nil, nil
+		}
+	}
+	return types.PublicKey{}, nil, errors.New("no key")
+}
+
+func TestAddressLabels(t *testing.T) {
+	w := &testLabelsWallet{testAccountsWallet: &testAccountsWallet{
+		primary: modules.Seed{1},
+		unspent: make(map[types.CoinOutputID]types.CoinOutput),
+	}}
+	filename := filepath.Join(t.TempDir(), AddressLabelsFile)
+	labels, err := NewAddressLabels(w, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := labels.Set(types.UnlockHash{}, "", nil); err != ErrEmptyAddressLabel {
+		t.Errorf("expected an empty label, got %v", err)
+	}
+	if _, err := labels.Set(types.UnlockHash{}, "", []string{"1st"}); err != ErrInvalidAddressTag {
+		t.Errorf("expected an invalid tag, got %v", err)
+	}
+	if _, err := labels.Set(types.UnlockHash{}, "line\nbreak", nil); err != ErrInvalidAddressLabel {
+		t.Errorf("expected an invalid label, got %v", err)
+	}
+	if _, err := labels.Set(seed.Address(w.primary, 7), "ACME", nil); err != ErrAddressNotOwned {
+		t.Errorf("expected a foreign address, got %v", err)
+	}
+
+	// a nil address labels a new address, tags being sorted and unique
+	acme, err := labels.Set(types.UnlockHash{}, " ACME Corp ", []string{"deposit", "customer", "deposit"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acme.Address != seed.Address(w.primary, 0) || acme.Label != "ACME Corp" ||
+		len(acme.Tags) != 2 || acme.Tags[0] != "customer" || acme.Tags[1] != "deposit" {
+		t.Errorf("unexpected labeled address: %+v", acme)
+	}
+	globex, err := labels.Set(types.UnlockHash{}, "Globex", []string{"customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := labels.Set(types.UnlockHash{}, "", []string{"change"}); err != nil {
+		t.Fatal(err)
+	}
+	if customers := labels.Labels(AddressLabelFilter{Tag: "customer"}); len(customers) != 2 ||
+		customers[0].Address != acme.Address || customers[1].Address != globex.Address {
+		t.Errorf("expected both customers sorted by label, got %+v", customers)
+	}
+	if l := labels.Labels(AddressLabelFilter{Label: "Globex", Tag: "deposit"}); len(l) != 0 {
+		t.Errorf("expected no address to match both filters, got %+v", l)
+	}
+
+	// balances only include the selected addresses
+	pay := func(value uint64, to types.UnlockHash) {
+		w.unspent[types.CoinOutputID{byte(len(w.unspent) + 1)}] = types.CoinOutput{
+			Value:     types.NewCurrency64(value),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(to)),
+		}
+	}
+	pay(10, acme.Address)
+	pay(20, globex.Address)
+	pay(40, seed.Address(w.primary, 7))
+	balance, err := labels.Balance(AddressLabelFilter{Tag: "customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Spendable.Equals64(30) {
+		t.Errorf("expected the customers to own 30, got %s", balance.Spendable.String())
+	}
+
+	// labels are persisted
+	if err := labels.Remove(globex.Address); err != nil {
+		t.Fatal(err)
+	}
+	if err := labels.Remove(globex.Address); err != ErrUnlabeledAddress {
+		t.Errorf("expected an unlabeled address, got %v", err)
+	}
+	labels, err = NewAddressLabels(w, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l, err := labels.Label(acme.Address); err != nil || l.Label != "ACME Corp" || !l.HasTag("deposit") {
+		t.Errorf("expected the label to be restored, got %+v (%v)", l, err)
+	}
+	if all := labels.Labels(AddressLabelFilter{}); len(all) != 2 {
+		t.Errorf("expected 2 labeled addresses, got %+v", all)
+	}
+}
@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"context"
+	"sort"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// LockedCoinOutput is an unspent coin output which cannot be spent until its lock time is reached.
+type LockedCoinOutput struct {
+	ID    types.CoinOutputID `json:"id"`
+	Value types.Currency     `json:"value"`
+	// LockTime is a block height when lower than types.LockTimeMinTimestampValue,
+	// a unix epoch timestamp (in seconds) otherwise
+	LockTime uint64 `json:"locktime"`
+	// UnlockHash is the address able to spend the output once unlocked
+	UnlockHash types.UnlockHash `json:"unlockhash"`
+	// TransactionID is the transaction which created the output
+	TransactionID types.TransactionID `json:"transactionid"`
+	// Confirmed is false in case the creating transaction is still in the transaction pool
+	Confirmed bool `json:"confirmed"`
+}
+
+// UnlockScheduleEntry contains the value unlocked once a lock time is reached.
+type UnlockScheduleEntry struct {
+	LockTime uint64         `json:"locktime"`
+	Incoming types.Currency `json:"incoming"`
+	Outgoing types.Currency `json:"outgoing"`
+}
+
+// LockedBalance contains the time-locked coin outputs owned by the wallet (incoming),
+// as well as those sent by the wallet to other addresses (outgoing), which are still locked.
+type LockedBalance struct {
+	// Height and Timestamp are those of the block the outputs are checked against
+	Height    types.BlockHeight `json:"height"`
+	Timestamp types.Timestamp   `json:"timestamp"`
+
+	Incoming      []LockedCoinOutput `json:"incoming"`
+	Outgoing      []LockedCoinOutput `json:"outgoing"`
+	IncomingTotal types.Currency     `json:"incomingtotal"`
+	OutgoingTotal types.Currency     `json:"outgoingtotal"`
+
+	// Schedule contains the value unlocked per lock time, earliest lock time first
+	Schedule []UnlockScheduleEntry `json:"schedule"`
+}
+
+// GetLockedBalance returns the incoming and outgoing time-locked coin outputs of the wallet,
+// together with their unlock schedule.
+// Outgoing outputs are found by scanning all transactions of the wallet,
+// stopping early should the given context be done.
+func GetLockedBalance(ctx context.Context, w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) (LockedBalance, error) {
+	block := cs.CurrentBlock()
+	balance := LockedBalance{
+		Height:    cs.Height(),
+		Timestamp: block.Timestamp,
+	}
+	fulfillableCtx := types.FulfillableContext{
+		BlockHeight: balance.Height,
+		BlockTime:   balance.Timestamp,
+	}
+
+	// the wallet tracks the locked outputs it owns, but not the transactions which created them
+	lockedCoinOutputs, _, err := w.LockedUnspendOutputs()
+	if err != nil {
+		return LockedBalance{}, err
+	}
+	unlockedCoinOutputs, _, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return LockedBalance{}, err
+	}
+	incoming := make(map[types.CoinOutputID]LockedCoinOutput)
+	for id, co := range lockedCoinOutputs {
+		if tl, ok := co.Condition.Condition.(*types.TimeLockCondition); ok {
+			incoming[id] = LockedCoinOutput{
+				ID:         id,
+				Value:      co.Value,
+				LockTime:   tl.LockTime,
+				UnlockHash: co.Condition.UnlockHash(),
+			}
+		}
+	}
+
+	// scan the transactions of the wallet, to find the creating transaction of incoming outputs,
+	// as well as all locked outputs sent by the wallet to other addresses
+	confirmed, err := w.Transactions(0, balance.Height)
+	if err != nil {
+		return LockedBalance{}, err
+	}
+	unconfirmed, err := w.UnconfirmedTransactions()
+	if err != nil {
+		return LockedBalance{}, err
+	}
+	pts := make([]modules.ProcessedTransaction, 0, len(confirmed)+len(unconfirmed))
+	pts = append(append(pts, confirmed...), unconfirmed...)
+	for i, pt := range pts {
+		if err := ctx.Err(); err != nil {
+			return LockedBalance{}, err
+		}
+		isConfirmed := i < len(confirmed)
+		var funded bool
+		for _, input := range pt.Inputs {
+			if input.WalletAddress {
+				funded = true
+				break
+			}
+		}
+		for index, co := range pt.Transaction.CoinOutputs {
+			tl, ok := co.Condition.Condition.(*types.TimeLockCondition)
+			if !ok {
+				continue
+			}
+			id := pt.Transaction.CoinOutputID(uint64(index))
+			if output, ok := incoming[id]; ok {
+				output.TransactionID = pt.TransactionID
+				output.Confirmed = isConfirmed
+				incoming[id] = output
+				continue
+			}
+			if _, ok := unlockedCoinOutputs[id]; ok || !funded || co.Condition.Fulfillable(fulfillableCtx) {
+				continue
+			}
+			if isConfirmed {
+				if _, err := cs.GetCoinOutput(id); err != nil {
+					continue // spent already
+				}
+			} else if _, err := tpool.Transaction(pt.TransactionID); err != nil {
+				continue // no longer in the transaction pool
+			}
+			balance.Outgoing = append(balance.Outgoing, LockedCoinOutput{
+				ID:            id,
+				Value:         co.Value,
+				LockTime:      tl.LockTime,
+				UnlockHash:    co.Condition.UnlockHash(),
+				TransactionID: pt.TransactionID,
+				Confirmed:     isConfirmed,
+			})
+		}
+	}
+	for _, output := range incoming {
+		balance.Incoming = append(balance.Incoming, output)
+	}
+	sortLockedCoinOutputs(balance.Incoming)
+	sortLockedCoinOutputs(balance.Outgoing)
+
+	// aggregate the outputs per lock time
+	schedule := make(map[uint64]*UnlockScheduleEntry)
+	entry := func(lockTime uint64) *UnlockScheduleEntry {
+		if _, ok := schedule[lockTime]; !ok {
+			schedule[lockTime] = &UnlockScheduleEntry{LockTime: lockTime}
+		}
+		return schedule[lockTime]
+	}
+	for _, output := range balance.Incoming {
+		balance.IncomingTotal = balance.IncomingTotal.Add(output.Value)
+		e := entry(output.LockTime)
+		e.Incoming = e.Incoming.Add(output.Value)
+	}
+	for _, output := range balance.Outgoing {
+		balance.OutgoingTotal = balance.OutgoingTotal.Add(output.Value)
+		e := entry(output.LockTime)
+		e.Outgoing = e.Outgoing.Add(output.Value)
+	}
+	for _, e := range schedule {
+		balance.Schedule = append(balance.Schedule, *e)
+	}
+	// block heights are always lower than timestamps, and thus scheduled first
+	sort.Slice(balance.Schedule, func(i, j int) bool {
+		return balance.Schedule[i].LockTime < balance.Schedule[j].LockTime
+	})
+	return balance, nil
+}
+
+// sortLockedCoinOutputs sorts the given outputs, earliest lock time first.
+func sortLockedCoinOutputs(outputs []LockedCoinOutput) {
+	sort.Slice(outputs, func(i, j int) bool {
+		if outputs[i].LockTime != outputs[j].LockTime {
+			return outputs[i].LockTime < outputs[j].LockTime
+		}
+		return outputs[i].ID.String() < outputs[j].ID.String()
+	})
+}
@@ -0,0 +1,31 @@
+package wallet
+
+import (
+	"errors"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrAddressNotOwned is returned in case a message is to be signed using the key of an address
+	// which isn't a single signature address of the wallet.
+	ErrAddressNotOwned = errors.New("address is not a single signature address of the wallet")
+)
+
+// SignMessage signs the given message using the key of the given address of the wallet,
+// proving control of that address.
+func SignMessage(w modules.Wallet, address types.UnlockHash, message string) (goldchaintypes.MessageSignature, error) {
+	_, sk, err := w.GetKey(address)
+	if err == modules.ErrLockedWallet {
+		return goldchaintypes.MessageSignature{}, err
+	}
+	var key crypto.SecretKey
+	if err != nil || len(sk) != len(key) {
+		return goldchaintypes.MessageSignature{}, ErrAddressNotOwned
+	}
+	copy(key[:], sk)
+	return goldchaintypes.SignMessage(message, key), nil
+}
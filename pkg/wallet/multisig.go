@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrUnknownMultiSigAddress is returned in case a transaction is to be built
+	// for a multisig address the wallet does not co-own.
+	ErrUnknownMultiSigAddress = errors.New("wallet does not co-own any output of the multisig address")
+)
+
+// MultiSigCoinOutput is an unspent coin output locked by a multisig condition co-owned by the wallet.
+type MultiSigCoinOutput struct {
+	ID    types.CoinOutputID `json:"id"`
+	Value types.Currency     `json:"value"`
+	// Locked is true in case the output is time-locked
+	Locked bool `json:"locked"`
+	// Pending is true in case the output is spent by a transaction in the transaction pool
+	Pending bool `json:"pending"`
+}
+
+// MultiSigBlockStakeOutput is an unspent blockstake output locked by a multisig condition co-owned by the wallet.
+type MultiSigBlockStakeOutput struct {
+	ID     types.BlockStakeOutputID `json:"id"`
+	Value  types.Currency           `json:"value"`
+	Locked bool                     `json:"locked"`
+}
+
+// MultiSigAddress contains the unspent outputs of a multisig address co-owned by the wallet.
+type MultiSigAddress struct {
+	Address types.UnlockHash   `json:"address"`
+	Owners  []types.UnlockHash `json:"owners"`
+	MinSigs uint64             `json:"minsigs"`
+	// Spendable is the value of all coin outputs which are neither locked nor pending
+	Spendable         types.Currency             `json:"spendable"`
+	CoinOutputs       []MultiSigCoinOutput       `json:"coinoutputs"`
+	BlockStakeOutputs []MultiSigBlockStakeOutput `json:"blockstakeoutputs"`
+}
+
+// MultiSigAddresses returns the unspent outputs of all multisig addresses co-owned by the wallet,
+// largest outputs first.
+func MultiSigAddresses(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool) ([]MultiSigAddress, error) {
+	wallets, err := w.MultiSigWallets()
+	if err != nil {
+		return nil, err
+	}
+	ctx := types.FulfillableContext{
+		BlockHeight: cs.Height(),
+		BlockTime:   cs.CurrentBlock().Timestamp,
+	}
+	spent := spentCoinOutputs(tpool.TransactionList())
+	addresses := make([]MultiSigAddress, 0, len(wallets))
+	for _, msw := range wallets {
+		address := MultiSigAddress{
+			Address: msw.Address,
+			Owners:  msw.Owners,
+			MinSigs: msw.MinSigs,
+		}
+		for _, id := range msw.CoinOutputIDs {
+			co, err := cs.GetCoinOutput(id)
+			if err != nil {
+				continue // spent in the meantime
+			}
+			_, pending := spent[id]
+			output := MultiSigCoinOutput{
+				ID:      id,
+				Value:   co.Value,
+				Locked:  !co.Condition.Fulfillable(ctx),
+				Pending: pending,
+			}
+			if !output.Locked && !output.Pending {
+				address.Spendable = address.Spendable.Add(co.Value)
+			}
+			address.CoinOutputs = append(address.CoinOutputs, output)
+		}
+		for _, id := range msw.BlockStakeOutputIDs {
+			bso, err := cs.GetBlockStakeOutput(id)
+			if err != nil {
+				continue
+			}
+			address.BlockStakeOutputs = append(address.BlockStakeOutputs, MultiSigBlockStakeOutput{
+				ID:     id,
+				Value:  bso.Value,
+				Locked: !bso.Condition.Fulfillable(ctx),
+			})
+		}
+		sort.Slice(address.CoinOutputs, func(i, j int) bool {
+			if c := address.CoinOutputs[i].Value.Cmp(address.CoinOutputs[j].Value); c != 0 {
+				return c > 0
+			}
+			return bytes.Compare(address.CoinOutputs[i].ID[:], address.CoinOutputs[j].ID[:]) < 0
+		})
+		sort.Slice(address.BlockStakeOutputs, func(i, j int) bool {
+			if c := address.BlockStakeOutputs[i].Value.Cmp(address.BlockStakeOutputs[j].Value); c != 0 {
+				return c > 0
+			}
+			return bytes.Compare(address.BlockStakeOutputs[i].ID[:], address.BlockStakeOutputs[j].ID[:]) < 0
+		})
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Address.Cmp(addresses[j].Address) < 0
+	})
+	return addresses, nil
+}
+
+// BuildMultiSigTransaction creates a transaction sending the given coin outputs,
+// funded by the spendable coin outputs of the given multisig address, largest outputs first,
+// refunding the remainder to the multisig address itself.
+// The transaction is signed with all keys of the wallet which co-own the multisig address,
+// the signatures of the other owners have to be collected before it can be submitted.
+func BuildMultiSigTransaction(w modules.Wallet, cs modules.ConsensusSet, tpool modules.TransactionPool, address types.UnlockHash, coinOutputs []types.CoinOutput, data []byte, minerFee types.Currency, constants types.ChainConstants) (types.Transaction, error) {
+	if len(coinOutputs) == 0 {
+		return types.Transaction{}, ErrNoOutputs
+	}
+	if minerFee.Cmp(constants.MinimumTransactionFee) < 0 {
+		minerFee = constants.MinimumTransactionFee
+	}
+	addresses, err := MultiSigAddresses(w, cs, tpool)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	var msa *MultiSigAddress
+	for i := range addresses {
+		if addresses[i].Address.Cmp(address) == 0 {
+			msa = &addresses[i]
+			break
+		}
+	}
+	if msa == nil {
+		return types.Transaction{}, ErrUnknownMultiSigAddress
+	}
+
+	txn := types.Transaction{
+		Version:       constants.DefaultTransactionVersion,
+		CoinOutputs:   coinOutputs,
+		MinerFees:     []types.Currency{minerFee},
+		ArbitraryData: data,
+	}
+	amount := minerFee
+	for _, co := range coinOutputs {
+		amount = amount.Add(co.Value)
+	}
+	var fund types.Currency
+	for _, output := range msa.CoinOutputs {
+		if fund.Cmp(amount) >= 0 {
+			break
+		}
+		if output.Locked || output.Pending {
+			continue
+		}
+		txn.CoinInputs = append(txn.CoinInputs, types.CoinInput{ParentID: output.ID})
+		fund = fund.Add(output.Value)
+	}
+	if fund.Cmp(amount) < 0 {
+		return types.Transaction{}, modules.ErrLowBalance
+	}
+	if refund := fund.Sub(amount); !refund.IsZero() {
+		txn.CoinOutputs = append(txn.CoinOutputs, types.CoinOutput{
+			Value:     refund,
+			Condition: types.NewCondition(types.NewMultiSignatureCondition(msa.Owners, msa.MinSigs)),
+		})
+	}
+	return w.GreedySign(txn)
+}
@@ -0,0 +1,463 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// PayoutsFile is the name of the file storing the payout templates, stored in the wallet directory.
+	PayoutsFile = "payouts.json"
+	// MaxPayoutRecipients is the maximum amount of recipients of a payout template.
+	MaxPayoutRecipients = 100
+)
+
+var (
+	// ErrUnknownPayoutTemplate is returned in case no payout template exists with a given name.
+	ErrUnknownPayoutTemplate = errors.New("unknown payout template")
+	// ErrPayoutTemplateExists is returned in case a payout template is added with a name already in use.
+	ErrPayoutTemplateExists = errors.New("a payout template with that name already exists")
+	// ErrInvalidPayoutTemplateName is returned in case a payout template name is empty, too long or contains invalid characters.
+	ErrInvalidPayoutTemplateName = fmt.Errorf(
+		"a payout template name has to start with a letter, consist only of letters, digits, '-', '_' and '.', and be at most %d characters long",
+		MaxContactNameLength)
+)
+
+var payoutsMetadata = persist.Metadata{
+	Header:  "Goldchain Wallet Payouts",
+	Version: "1.0",
+}
+
+// InvalidPayoutTemplateError is returned in case a payout template cannot be used.
+type InvalidPayoutTemplateError struct {
+	Reason string
+}
+
+// Error implements error.Error
+func (err InvalidPayoutTemplateError) Error() string {
+	return "invalid payout template: " + err.Reason
+}
+
+// PayoutRecipient is an address receiving either a fixed amount of coins,
+// or a percentage of the payout balance, with every payout.
+type PayoutRecipient struct {
+	// Label optionally names the recipient, e.g. the beneficiary of a fee pool distribution
+	Label   string           `json:"label,omitempty"`
+	Address types.UnlockHash `json:"address"`
+	// Amount is the amount of coins sent to the address, zero if a percentage is defined instead
+	Amount types.Currency `json:"amount"`
+	// Percentage is the percentage (0-100) of the payout balance sent to the address, used if no amount is defined
+	Percentage float64 `json:"percentage,omitempty"`
+}
+
+// PayoutDefinition defines the recipients of a payout template, and the optional source and schedule of its payouts.
+type PayoutDefinition struct {
+	// Source is the address funding the payouts, its change is returned to it,
+	// all unlocked coin outputs of the wallet fund the payouts if none is defined
+	Source *types.UnlockHash `json:"source,omitempty"`
+	// Recipients receive their coins in a single transaction
+	Recipients []PayoutRecipient `json:"recipients"`
+	// Schedule is the cron expression (see ParseSchedule) defining when the daemon executes the payout,
+	// the payout is only executed on request if none is defined
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// PayoutRun is the outcome of the most recent execution of a payout template.
+type PayoutRun struct {
+	// Time is the unix epoch timestamp (in seconds) of the execution
+	Time types.Timestamp `json:"time"`
+	// Scheduled is true for payouts executed by the daemon according to the schedule of the template
+	Scheduled bool `json:"scheduled"`
+	// TransactionID identifies the transaction sending the payout, nil if no transaction was sent
+	TransactionID *types.TransactionID `json:"transactionid,omitempty"`
+	// PendingSpend identifies the spend awaiting approval, should the payout exceed the approval threshold
+	PendingSpend string `json:"pendingspend,omitempty"`
+	// Error describes why the payout failed, should it have failed
+	Error string `json:"error,omitempty"`
+}
+
+// PayoutTemplate is a named payout, which can be executed on request or according to a schedule.
+type PayoutTemplate struct {
+	Name string `json:"name"`
+	PayoutDefinition
+	// Created and Updated are unix epoch timestamps (in seconds)
+	Created types.Timestamp `json:"created"`
+	Updated types.Timestamp `json:"updated"`
+	// LastRun is the most recent execution, nil if the template was never executed
+	LastRun *PayoutRun `json:"lastrun,omitempty"`
+}
+
+// NextRun returns the time the daemon executes the template next, zero in case it has no schedule.
+// A scheduled payout missed while the daemon wasn't running is executed once it runs again.
+func (t PayoutTemplate) NextRun() time.Time {
+	if t.Schedule == "" {
+		return time.Time{}
+	}
+	schedule, err := ParseSchedule(t.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	after := t.Updated
+	if t.LastRun != nil && t.LastRun.Time > after {
+		after = t.LastRun.Time
+	}
+	return schedule.Next(time.Unix(int64(after), 0))
+}
+
+// Payouts keeps the payout templates of a wallet on disk.
+type Payouts struct {
+	filename string
+	now      func() time.Time
+
+	mu        sync.Mutex
+	templates map[string]PayoutTemplate
+}
+
+// payoutsFile is the persisted form of the payout templates.
+type payoutsFile struct {
+	Templates []PayoutTemplate `json:"templates"`
+}
+
+// NewPayouts creates the payout templates, loading those stored in the given file,
+// which is only created once the first template is added.
+func NewPayouts(filename string) (*Payouts, error) {
+	p := &Payouts{
+		filename:  filename,
+		now:       time.Now,
+		templates: make(map[string]PayoutTemplate),
+	}
+	var file payoutsFile
+	err := persist.LoadJSON(payoutsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load payout templates: %v", err)
+	}
+	for _, template := range file.Templates {
+		p.templates[template.Name] = template
+	}
+	return p, nil
+}
+
+// ValidatePayoutDefinition returns an InvalidPayoutTemplateError in case the given definition cannot be used.
+func ValidatePayoutDefinition(def PayoutDefinition) error {
+	if def.Source != nil && def.Source.Type == types.UnlockTypeNil {
+		return InvalidPayoutTemplateError{Reason: "the source cannot be the nil address"}
+	}
+	if len(def.Recipients) == 0 {
+		return InvalidPayoutTemplateError{Reason: "at least one recipient has to be defined"}
+	}
+	if len(def.Recipients) > MaxPayoutRecipients {
+		return InvalidPayoutTemplateError{Reason: fmt.Sprintf("at most %d recipients can be defined", MaxPayoutRecipients)}
+	}
+	var total float64
+	for i, recipient := range def.Recipients {
+		if len(recipient.Label) > MaxContactNameLength {
+			return InvalidPayoutTemplateError{Reason: fmt.Sprintf("the label of recipient #%d is longer than %d characters", i+1, MaxContactNameLength)}
+		}
+		if recipient.Address.Type == types.UnlockTypeNil {
+			return InvalidPayoutTemplateError{Reason: fmt.Sprintf("recipient #%d cannot be the nil address", i+1)}
+		}
+		if recipient.Amount.IsZero() == (recipient.Percentage == 0) {
+			return InvalidPayoutTemplateError{Reason: fmt.Sprintf("recipient #%d has to define either an amount or a percentage", i+1)}
+		}
+		if recipient.Percentage < 0 || recipient.Percentage > 100 {
+			return InvalidPayoutTemplateError{Reason: fmt.Sprintf("the percentage of recipient #%d is not within 0-100", i+1)}
+		}
+		total += recipient.Percentage
+	}
+	// tolerate the rounding of percentages adding up to 100, e.g. 33.4 + 33.3 + 33.3
+	if total > 100+1e-9 {
+		return InvalidPayoutTemplateError{Reason: fmt.Sprintf("the percentages of all recipients add up to %g, exceeding 100", total)}
+	}
+	if def.Schedule != "" {
+		if _, err := ParseSchedule(def.Schedule); err != nil {
+			return InvalidPayoutTemplateError{Reason: err.Error()}
+		}
+	}
+	return nil
+}
+
+// Templates returns all payout templates, sorted by name.
+func (p *Payouts) Templates() []PayoutTemplate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	templates := make([]PayoutTemplate, 0, len(p.templates))
+	for _, template := range p.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
+
+// Template returns the payout template with the given name.
+func (p *Payouts) Template(name string) (PayoutTemplate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	template, ok := p.templates[name]
+	if !ok {
+		return PayoutTemplate{}, ErrUnknownPayoutTemplate
+	}
+	return template, nil
+}
+
+// AddTemplate adds a new payout template with the given name and definition.
+func (p *Payouts) AddTemplate(name string, def PayoutDefinition) (PayoutTemplate, error) {
+	if !isValidName(name) {
+		return PayoutTemplate{}, ErrInvalidPayoutTemplateName
+	}
+	if err := ValidatePayoutDefinition(def); err != nil {
+		return PayoutTemplate{}, err
+	}
+	return p.update(name, func(template PayoutTemplate, exists bool) (PayoutTemplate, error) {
+		if exists {
+			return PayoutTemplate{}, ErrPayoutTemplateExists
+		}
+		now := types.Timestamp(p.now().Unix())
+		return PayoutTemplate{
+			Name:             name,
+			PayoutDefinition: def,
+			Created:          now,
+			Updated:          now,
+		}, nil
+	})
+}
+
+// UpdateTemplate replaces the definition of the payout template with the given name.
+// The schedule restarts from the time of the update.
+func (p *Payouts) UpdateTemplate(name string, def PayoutDefinition) (PayoutTemplate, error) {
+	if err := ValidatePayoutDefinition(def); err != nil {
+		return PayoutTemplate{}, err
+	}
+	return p.update(name, func(template PayoutTemplate, exists bool) (PayoutTemplate, error) {
+		if !exists {
+			return PayoutTemplate{}, ErrUnknownPayoutTemplate
+		}
+		template.PayoutDefinition = def
+		template.Updated = types.Timestamp(p.now().Unix())
+		return template, nil
+	})
+}
+
+// RemoveTemplate removes the payout template with the given name.
+func (p *Payouts) RemoveTemplate(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	template, ok := p.templates[name]
+	if !ok {
+		return ErrUnknownPayoutTemplate
+	}
+	delete(p.templates, name)
+	if err := p.save(); err != nil {
+		p.templates[name] = template
+		return err
+	}
+	return nil
+}
+
+// RecordRun records the outcome of an execution of the payout template with the given name.
+// The run is kept even if it cannot be persisted, such that a scheduled payout isn't executed again.
+func (p *Payouts) RecordRun(name string, run PayoutRun) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	template, ok := p.templates[name]
+	if !ok {
+		return ErrUnknownPayoutTemplate
+	}
+	template.LastRun = &run
+	p.templates[name] = template
+	return p.save()
+}
+
+// Due returns the names of the scheduled payout templates which are due at the given time, sorted by name.
+func (p *Payouts) Due(now time.Time) []string {
+	var names []string
+	for _, template := range p.Templates() {
+		if next := template.NextRun(); !next.IsZero() && !next.After(now) {
+			names = append(names, template.Name)
+		}
+	}
+	return names
+}
+
+// update applies the given function to the (possibly non-existing) payout template with the given name,
+// persisting the template it returns.
+func (p *Payouts) update(name string, fn func(template PayoutTemplate, exists bool) (PayoutTemplate, error)) (PayoutTemplate, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous, ok := p.templates[name]
+	template, err := fn(previous, ok)
+	if err != nil {
+		return PayoutTemplate{}, err
+	}
+	p.templates[name] = template
+	if err = p.save(); err != nil {
+		if ok {
+			p.templates[name] = previous
+		} else {
+			delete(p.templates, name)
+		}
+		return PayoutTemplate{}, err
+	}
+	return template, nil
+}
+
+// save writes the payout templates, the lock has to be held by the caller.
+func (p *Payouts) save() error {
+	templates := make([]PayoutTemplate, 0, len(p.templates))
+	for _, template := range p.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	err := persist.SaveJSON(payoutsMetadata, payoutsFile{Templates: templates}, p.filename)
+	if err != nil {
+		return fmt.Errorf("failed to save payout templates: %v", err)
+	}
+	return nil
+}
+
+// ResolvedPayout is a payout template resolved against the current balance of its source,
+// ready to be sent using the build options.
+type ResolvedPayout struct {
+	// Balance is the sum of the unlocked coin outputs funding the payout,
+	// the percentages of the recipients applying to the balance left once the fixed amounts and miner fee are paid
+	Balance     types.Currency     `json:"balance"`
+	CoinOutputs []types.CoinOutput `json:"coinoutputs"`
+	Options     BuildOptions       `json:"-"`
+}
+
+// ResolvePayout computes the coin outputs sent by the given payout template,
+// using the unlocked coin outputs of its source not yet spent by the transaction pool.
+// In case the template defines a source, the payout is funded by all coin outputs of that source only,
+// its change being returned to the source.
+func ResolvePayout(w modules.Wallet, tpool modules.TransactionPool, template PayoutTemplate, constants types.ChainConstants) (ResolvedPayout, error) {
+	if err := ValidatePayoutDefinition(template.PayoutDefinition); err != nil {
+		return ResolvedPayout{}, err
+	}
+	unspentCoinOutputs, _, err := w.UnlockedUnspendOutputs()
+	if err != nil {
+		return ResolvedPayout{}, err
+	}
+	spent := spentCoinOutputs(tpool.TransactionList())
+	payout := ResolvedPayout{Balance: types.ZeroCurrency}
+	for id, co := range unspentCoinOutputs {
+		if _, ok := spent[id]; ok || !isSingleSignatureCondition(co.Condition) {
+			continue
+		}
+		if template.Source != nil && co.Condition.UnlockHash() != *template.Source {
+			payout.Options.ExcludeCoinOutputs = append(payout.Options.ExcludeCoinOutputs, id)
+			continue
+		}
+		payout.Balance = payout.Balance.Add(co.Value)
+		if template.Source != nil {
+			payout.Options.IncludeCoinOutputs = append(payout.Options.IncludeCoinOutputs, id)
+		}
+	}
+	if template.Source != nil {
+		payout.Options.RefundAddress = template.Source
+		// sorted, such that the options do not depend on the iteration order of the outputs
+		sortCoinOutputIDs(payout.Options.IncludeCoinOutputs)
+		sortCoinOutputIDs(payout.Options.ExcludeCoinOutputs)
+	}
+
+	fixed := constants.MinimumTransactionFee
+	for _, recipient := range template.Recipients {
+		fixed = fixed.Add(recipient.Amount)
+	}
+	if payout.Balance.Cmp(fixed) < 0 {
+		return ResolvedPayout{}, modules.ErrLowBalance
+	}
+	remaining := payout.Balance.Sub(fixed).Big()
+	for _, recipient := range template.Recipients {
+		value := recipient.Amount
+		if value.IsZero() {
+			// the shortest decimal representation of the percentage is used, such that 60 is exactly 0.6 of the balance
+			share, _ := new(big.Rat).SetString(strconv.FormatFloat(recipient.Percentage, 'f', -1, 64))
+			share.Mul(share, new(big.Rat).SetFrac(remaining, big.NewInt(100)))
+			value = types.NewCurrency(new(big.Int).Quo(share.Num(), share.Denom()))
+			if value.IsZero() {
+				return ResolvedPayout{}, modules.ErrLowBalance
+			}
+		}
+		payout.CoinOutputs = append(payout.CoinOutputs, types.CoinOutput{
+			Value:     value,
+			Condition: types.NewCondition(types.NewUnlockHashCondition(recipient.Address)),
+		})
+	}
+	return payout, nil
+}
+
+// sortCoinOutputIDs sorts the given coin output IDs in ascending order.
+func sortCoinOutputIDs(ids []types.CoinOutputID) {
+	sort.Slice(ids, func(i, j int) bool {
+		return bytes.Compare(ids[i][:], ids[j][:]) < 0
+	})
+}
+
+// PayoutScheduler executes the scheduled payout templates once they are due,
+// checking the schedules once per minute.
+type PayoutScheduler struct {
+	payouts *Payouts
+	execute func(name string)
+	now     func() time.Time
+
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewPayoutScheduler creates a PayoutScheduler for the given payout templates.
+// The given function is called (from the goroutine of the PayoutScheduler) for every payout template which is due,
+// and has to record the run of the template, such that it is no longer due.
+func NewPayoutScheduler(payouts *Payouts, execute func(name string)) *PayoutScheduler {
+	s := &PayoutScheduler{
+		payouts: payouts,
+		execute: execute,
+		now:     time.Now,
+		closeCh: make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Close stops the PayoutScheduler.
+func (s *PayoutScheduler) Close() error {
+	close(s.closeCh)
+	s.wg.Wait()
+	return nil
+}
+
+// run executes the due payout templates once per minute, until the PayoutScheduler is closed.
+func (s *PayoutScheduler) run() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		for _, name := range s.payouts.Due(s.now()) {
+			select {
+			case <-s.closeCh:
+				return
+			default:
+			}
+			s.execute(name)
+		}
+		select {
+		case <-s.closeCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+func TestParseSchedule(t *testing.T) {
+	// Wednesday
+	after := time.Date(2020, time.January, 1, 10, 30, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		schedule string
+		next     time.Time
+	}{
+		{"* * * * *", time.Date(2020, time.January, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, time.January, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 12 * * 1", time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2020, time.January, 5, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9-17/4 * * 1-5", time.Date(2020, time.January, 1, 13, 0, 0, 0, time.UTC)},
+		{"30 10 1,15 * *", time.Date(2020, time.January, 15, 10, 30, 0, 0, time.UTC)},
+		// either the day of the month or the weekday has to match, as both are restricted
+		{"0 0 15 * 5", time.Date(2020, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	} {
+		s, err := ParseSchedule(tc.schedule)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", tc.schedule, err)
+			continue
+		}
+		if next := s.Next(after); !next.Equal(tc.next) {
+			t.Errorf("expected %q to run next at %v, got %v", tc.schedule, tc.next, next)
+		}
+	}
+
+	for _, schedule := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 7", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@never"} {
+		if _, err := ParseSchedule(schedule); err == nil {
+			t.Errorf("expected %q to be refused", schedule)
+		}
+	}
+}
+
+func TestPayouts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "payouts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, PayoutsFile)
+
+	p, err := NewPayouts(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2020, time.January, 1, 10, 30, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	team := types.UnlockHash{Type: types.UnlockTypePubKey, Hash: types.UnlockHash{}.Hash}
+	team.Hash[0] = 1
+	foundation := types.UnlockHash{Type: types.UnlockTypePubKey}
+	foundation.Hash[0] = 2
+	def := PayoutDefinition{
+		Recipients: []PayoutRecipient{
+			{Address: team, Percentage: 60},
+			{Address: foundation, Percentage: 40},
+		},
+		Schedule: "0 12 * * 1",
+	}
+	for _, invalid := range []PayoutDefinition{
+		{},
+		{Recipients: []PayoutRecipient{{Percentage: 10}}},
+		{Recipients: []PayoutRecipient{{Address: team}}},
+		{Recipients: []PayoutRecipient{{Address: team, Amount: types.NewCurrency64(1), Percentage: 10}}},
+		{Recipients: []PayoutRecipient{{Address: team, Percentage: 60}, {Address: foundation, Percentage: 41}}},
+		{Recipients: []PayoutRecipient{{Address: team, Percentage: 60}}, Schedule: "weekly"},
+	} {
+		if _, err = p.AddTemplate("weekly", invalid); err == nil {
+			t.Errorf("expected %+v to be refused", invalid)
+		} else if _, ok := err.(InvalidPayoutTemplateError); !ok {
+			t.Errorf("expected an invalid payout template error, got %v", err)
+		}
+	}
+	if _, err = p.AddTemplate("1weekly", def); err != ErrInvalidPayoutTemplateName {
+		t.Errorf("expected %v, got %v", ErrInvalidPayoutTemplateName, err)
+	}
+	if _, err = p.AddTemplate("weekly", def); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.AddTemplate("weekly", def); err != ErrPayoutTemplateExists {
+		t.Errorf("expected %v, got %v", ErrPayoutTemplateExists, err)
+	}
+	if _, err = p.AddTemplate("manual", PayoutDefinition{Recipients: def.Recipients}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the scheduled template is due, once its time has come
+	if due := p.Due(now.Add(time.Hour)); len(due) != 0 {
+		t.Errorf("expected no payouts to be due, got %v", due)
+	}
+	monday := time.Date(2020, time.January, 6, 12, 0, 0, 0, time.UTC)
+	if due := p.Due(monday); len(due) != 1 || due[0] != "weekly" {
+		t.Errorf("expected the weekly payout to be due, got %v", due)
+	}
+	// a missed payout is still due, but only once
+	if due := p.Due(monday.Add(48 * time.Hour)); len(due) != 1 {
+		t.Errorf("expected the missed weekly payout to be due, got %v", due)
+	}
+	if err = p.RecordRun("weekly", PayoutRun{Time: types.Timestamp(monday.Add(48 * time.Hour).Unix()), Scheduled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if due := p.Due(monday.Add(6 * 24 * time.Hour)); len(due) != 0 {
+		t.Errorf("expected the weekly payout not to be due before next monday, got %v", due)
+	}
+	if due := p.Due(monday.Add(7 * 24 * time.Hour)); len(due) != 1 {
+		t.Errorf("expected the weekly payout to be due next monday, got %v", due)
+	}
+
+	// the templates and their runs are persisted
+	p, err = NewPayouts(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates := p.Templates()
+	if len(templates) != 2 || templates[0].Name != "manual" || templates[1].Name != "weekly" ||
+		templates[1].LastRun == nil || !templates[1].LastRun.Scheduled || len(templates[1].Recipients) != 2 {
+		t.Fatalf("unexpected templates: %+v", templates)
+	}
+	if _, err = p.UpdateTemplate("other", def); err != ErrUnknownPayoutTemplate {
+		t.Errorf("expected %v, got %v", ErrUnknownPayoutTemplate, err)
+	}
+	if err = p.RemoveTemplate("manual"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Template("manual"); err != ErrUnknownPayoutTemplate {
+		t.Errorf("expected %v, got %v", ErrUnknownPayoutTemplate, err)
+	}
+}
+
+type testPayoutWallet struct {
+	modules.Wallet
+	outputs map[types.CoinOutputID]types.CoinOutput
+}
+
+func (w testPayoutWallet) UnlockedUnspendOutputs() (map[types.CoinOutputID]types.CoinOutput, map[types.BlockStakeOutputID]types.BlockStakeOutput, error) {
+	return w.outputs, nil, nil
+}
+
+type testPayoutTransactionPool struct {
+	modules.TransactionPool
+}
+
+func (testPayoutTransactionPool) TransactionList() []types.Transaction {
+	return nil
+}
+
+func TestResolvePayout(t *testing.T) {
+	var feePool, other, team, foundation types.UnlockHash
+	for i, uh := range []*types.UnlockHash{&feePool, &other, &team, &foundation} {
+		uh.Type = types.UnlockTypePubKey
+		uh.Hash[0] = byte(i + 1)
+	}
+	w := testPayoutWallet{outputs: map[types.CoinOutputID]types.CoinOutput{
+		{1}: {Value: types.NewCurrency64(600), Condition: types.NewCondition(types.NewUnlockHashCondition(feePool))},
+		{2}: {Value: types.NewCurrency64(411), Condition: types.NewCondition(types.NewUnlockHashCondition(feePool))},
+		{3}: {Value: types.NewCurrency64(5000), Condition: types.NewCondition(types.NewUnlockHashCondition(other))},
+	}}
+	constants := types.ChainConstants{MinimumTransactionFee: types.NewCurrency64(1)}
+	template := PayoutTemplate{
+		Name: "weekly",
+		PayoutDefinition: PayoutDefinition{
+			Source: &feePool,
+			Recipients: []PayoutRecipient{
+				{Address: team, Amount: types.NewCurrency64(10)},
+				{Address: team, Percentage: 60},
+				{Address: foundation, Percentage: 33.3},
+			},
+		},
+	}
+	payout, err := ResolvePayout(w, testPayoutTransactionPool{}, template, constants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the percentages apply to the balance of the source, minus the fixed amounts and miner fee
+	if !payout.Balance.Equals64(1011) || len(payout.CoinOutputs) != 3 ||
+		!payout.CoinOutputs[0].Value.Equals64(10) || !payout.CoinOutputs[1].Value.Equals64(600) ||
+		!payout.CoinOutputs[2].Value.Equals64(333) || payout.CoinOutputs[2].Condition.UnlockHash() != foundation {
+		t.Errorf("unexpected payout: %+v", payout)
+	}
+	// the payout is funded by the outputs of the source only, returning the change to it
+	if len(payout.Options.IncludeCoinOutputs) != 2 || len(payout.Options.ExcludeCoinOutputs) != 1 ||
+		payout.Options.ExcludeCoinOutputs[0] != (types.CoinOutputID{3}) || *payout.Options.RefundAddress != feePool {
+		t.Errorf("unexpected build options: %+v", payout.Options)
+	}
+
+	// without a source, the whole wallet funds the payout
+	template.Source = nil
+	payout, err = ResolvePayout(w, testPayoutTransactionPool{}, template, constants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !payout.Balance.Equals64(6011) || !payout.CoinOutputs[1].Value.Equals64(3600) ||
+		len(payout.Options.IncludeCoinOutputs) != 0 || payout.Options.RefundAddress != nil {
+		t.Errorf("unexpected payout: %+v", payout)
+	}
+
+	template.Recipients[0].Amount = types.NewCurrency64(6011)
+	if _, err = ResolvePayout(w, testPayoutTransactionPool{}, template, constants); err != modules.ErrLowBalance {
+		t.Errorf("expected %v, got %v", modules.ErrLowBalance, err)
+	}
+}
+
+func TestDistributionLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "distributions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log := NewDistributionLog(filepath.Join(dir, DistributionsFile))
+
+	// nothing is recorded until the first distribution
+	distributions, err := log.Distributions("")
+	if err != nil || len(distributions) != 0 {
+		t.Fatalf("expected no distributions, got %v (%v)", distributions, err)
+	}
+
+	var feePool, team, foundation types.UnlockHash
+	for i, uh := range []*types.UnlockHash{&feePool, &team, &foundation} {
+		uh.Type = types.UnlockTypePubKey
+		uh.Hash[0] = byte(i + 1)
+	}
+	template := PayoutTemplate{
+		Name: "feepool",
+		PayoutDefinition: PayoutDefinition{
+			Source: &feePool,
+			Recipients: []PayoutRecipient{
+				{Label: "team", Address: team, Percentage: 60},
+				{Label: "foundation", Address: foundation, Percentage: 40},
+			},
+		},
+	}
+	payout := ResolvedPayout{
+		Balance: types.NewCurrency64(1001),
+		CoinOutputs: []types.CoinOutput{
+			{Value: types.NewCurrency64(600), Condition: types.NewCondition(types.NewUnlockHashCondition(team))},
+			{Value: types.NewCurrency64(400), Condition: types.NewCondition(types.NewUnlockHashCondition(foundation))},
+		},
+	}
+	constants := types.ChainConstants{MinimumTransactionFee: types.NewCurrency64(1)}
+	for i, name := range []string{"feepool", "other", "feepool"} {
+		template.Name = name
+		d := NewDistribution(template, payout, types.TransactionID{byte(i)}, types.BlockHeight(10+i), types.Timestamp(1000+i), i == 2, constants)
+		if err = log.Record(d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if distributions, err = log.Distributions(""); err != nil {
+		t.Fatal(err)
+	}
+	if len(distributions) != 3 {
+		t.Fatalf("expected 3 distributions, got %+v", distributions)
+	}
+	if distributions, err = log.Distributions("feepool"); err != nil {
+		t.Fatal(err)
+	}
+	if len(distributions) != 2 || distributions[1].TransactionID != (types.TransactionID{2}) || !distributions[1].Scheduled {
+		t.Fatalf("unexpected fee pool distributions: %+v", distributions)
+	}
+	d := distributions[0]
+	if d.Height != 10 || *d.Source != feePool || !d.Balance.Equals64(1001) || !d.MinerFee.Equals64(1) || len(d.Shares) != 2 ||
+		d.Shares[0].Label != "team" || d.Shares[0].Address != team || d.Shares[0].Percentage != 60 || !d.Shares[0].Value.Equals64(600) ||
+		d.Shares[1].Label != "foundation" || !d.Shares[1].Value.Equals64(400) {
+		t.Errorf("unexpected distribution: %+v", d)
+	}
+}
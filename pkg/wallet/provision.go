@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/nbh-digital/goldchain/pkg/seed"
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// MaxProvisionedAddresses is the maximum amount of addresses returned when provisioning a wallet,
+// being the addresses the wallet tracks from the start.
+const MaxProvisionedAddresses = modules.WalletSeedPreloadDepth
+
+var (
+	// ErrWalletProvisioned is returned when provisioning a wallet which has been created already.
+	ErrWalletProvisioned = errors.New("wallet has been created already")
+)
+
+// ProvisionOptions contains the properties of a wallet to be provisioned.
+type ProvisionOptions struct {
+	// Mnemonic is the mnemonic of the primary seed of the wallet,
+	// a random seed is generated if none is given
+	Mnemonic string `json:"mnemonic,omitempty"`
+	// SeedPassphrase is the BIP39 passphrase combined with the given mnemonic,
+	// in which case the wallet stores the seed derived from both
+	SeedPassphrase string `json:"seedpassphrase,omitempty"`
+	// Passphrase is the passphrase with which the wallet is encrypted, after which it is unlocked,
+	// a plain wallet is created if none is given
+	Passphrase string `json:"passphrase,omitempty"`
+	// Addresses is the amount of addresses of the wallet to return, 1 if none is given
+	Addresses int `json:"addresses,omitempty"`
+}
+
+// ProvisionedWallet describes a provisioned wallet.
+type ProvisionedWallet struct {
+	// Mnemonic is the mnemonic of the primary seed stored by the wallet
+	Mnemonic string `json:"mnemonic"`
+	// Addresses are the first addresses of the primary seed, which are tracked by the wallet
+	Addresses []types.UnlockHash `json:"addresses"`
+	// Encrypted is true if the wallet is encrypted using the given passphrase, false for a plain wallet
+	Encrypted bool `json:"encrypted"`
+}
+
+// Provision creates the wallet, from the given mnemonic or from a random seed, and unlocks it,
+// returning the mnemonic of its primary seed and its first addresses,
+// such that a wallet can be created by deployment automation rather than interactively.
+func Provision(w modules.Wallet, opts ProvisionOptions) (ProvisionedWallet, error) {
+	if opts.Addresses <= 0 {
+		opts.Addresses = 1
+	} else if opts.Addresses > MaxProvisionedAddresses {
+		return ProvisionedWallet{}, fmt.Errorf("at most %d addresses can be returned", MaxProvisionedAddresses)
+	}
+	if w.Encrypted() || w.Unlocked() {
+		return ProvisionedWallet{}, ErrWalletProvisioned
+	}
+
+	// a zero seed is generated randomly by the wallet
+	var primarySeed modules.Seed
+	if opts.Mnemonic != "" {
+		s, err := modules.InitialSeedFromMnemonic(opts.Mnemonic)
+		if err != nil {
+			return ProvisionedWallet{}, fmt.Errorf("invalid mnemonic: %v", err)
+		}
+		primarySeed = s
+	}
+	if opts.SeedPassphrase != "" {
+		if opts.Mnemonic == "" {
+			return ProvisionedWallet{}, errors.New("a seed passphrase requires a mnemonic to be given")
+		}
+		s, err := seed.WithPassphrase(primarySeed, opts.SeedPassphrase)
+		if err != nil {
+			return ProvisionedWallet{}, err
+		}
+		primarySeed = s
+	}
+
+	var err error
+	if opts.Passphrase == "" {
+		primarySeed, err = w.Init(primarySeed)
+	} else {
+		key := crypto.TwofishKey(crypto.HashObject(opts.Passphrase))
+		primarySeed, err = w.Encrypt(key, primarySeed)
+		if err == nil {
+			err = w.Unlock(key)
+		}
+	}
+	if err != nil {
+		return ProvisionedWallet{}, err
+	}
+
+	provisioned := ProvisionedWallet{Encrypted: opts.Passphrase != ""}
+	provisioned.Mnemonic, err = modules.NewMnemonic(primarySeed)
+	if err != nil {
+		return ProvisionedWallet{}, err
+	}
+	for index := 0; index < opts.Addresses; index++ {
+		provisioned.Addresses = append(provisioned.Addresses, seed.Address(primarySeed, uint64(index)))
+	}
+	return provisioned, nil
+}
@@ -0,0 +1,144 @@
+package wallet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleMacros are the shorthands accepted in place of a cron expression.
+var scheduleMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+	"@yearly":  "0 0 1 1 *",
+}
+
+// Schedule is a cron-like schedule, matching the minutes (in UTC) at which a recurring action is executed.
+type Schedule struct {
+	minutes, hours, days, months, weekdays uint64
+	// daysRestricted and weekdaysRestricted are false for fields defined as '*',
+	// a day matching either field when both are restricted, as is the case for cron
+	daysRestricted, weekdaysRestricted bool
+}
+
+// ParseSchedule parses a cron expression of 5 fields: minute (0-59), hour (0-23), day of the month (1-31),
+// month (1-12) and day of the week (0-6, 0 being Sunday), evaluated in UTC.
+// Each field is either '*', or a comma-separated list of values and ranges (e.g. 1-5),
+// optionally followed by a step (e.g. */15 or 0-30/10).
+// The macros @hourly, @daily, @weekly, @monthly and @yearly are accepted as well.
+func ParseSchedule(str string) (Schedule, error) {
+	str = strings.TrimSpace(str)
+	if expr, ok := scheduleMacros[str]; ok {
+		str = expr
+	}
+	fields := strings.Fields(str)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("invalid schedule %q: expected 5 fields (minute hour day month weekday), found %d", str, len(fields))
+	}
+	var (
+		s   Schedule
+		err error
+	)
+	for i, field := range []struct {
+		name     string
+		min, max uint
+		bits     *uint64
+	}{
+		{"minute", 0, 59, &s.minutes},
+		{"hour", 0, 23, &s.hours},
+		{"day", 1, 31, &s.days},
+		{"month", 1, 12, &s.months},
+		{"weekday", 0, 6, &s.weekdays},
+	} {
+		*field.bits, err = parseScheduleField(fields[i], field.min, field.max)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid %s field %q of schedule: %v", field.name, fields[i], err)
+		}
+	}
+	s.daysRestricted = !strings.HasPrefix(fields[2], "*")
+	s.weekdaysRestricted = !strings.HasPrefix(fields[4], "*")
+	return s, nil
+}
+
+// parseScheduleField parses a single field of a cron expression, returning the matching values as bits.
+func parseScheduleField(field string, min, max uint) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, uint64(1)
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			rng = part[:i]
+			step, err = strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || step == 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+		}
+		first, last := uint64(min), uint64(max)
+		if rng != "*" {
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			first, err = strconv.ParseUint(bounds[0], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", bounds[0])
+			}
+			last = first
+			if len(bounds) == 2 {
+				last, err = strconv.ParseUint(bounds[1], 10, 8)
+				if err != nil {
+					return 0, fmt.Errorf("invalid value %q", bounds[1])
+				}
+			} else if step != 1 {
+				// a single value with a step ranges up to the maximum, e.g. 5/15
+				last = uint64(max)
+			}
+			if first < uint64(min) || last > uint64(max) || first > last {
+				return 0, fmt.Errorf("range %s is not within %d-%d", rng, min, max)
+			}
+		}
+		for value := first; value <= last; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first minute matched by the schedule after the given time,
+// or the zero time in case the schedule never matches (e.g. on the 31st of February).
+func (s Schedule) Next(after time.Time) time.Time {
+	t := after.UTC().Truncate(time.Minute).Add(time.Minute)
+	// every possible combination repeats within 28 years, as the calendar does
+	limit := t.AddDate(28, 0, 0)
+	for t.Before(limit) {
+		if s.months&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hours&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minutes&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay returns whether the day of the given time is matched by the schedule.
+func (s Schedule) matchDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.daysRestricted && s.weekdaysRestricted {
+		return day || weekday
+	}
+	return day && weekday
+}
@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// CoinSelectionStrategy defines the algorithm used to select
+// the coin outputs which fund a transaction.
+type CoinSelectionStrategy string
+
+// coin selection strategies
+const (
+	// CoinSelectionLargestFirst selects the largest coin outputs first,
+	// minimizing the amount of inputs, it is the default strategy
+	CoinSelectionLargestFirst CoinSelectionStrategy = "largestfirst"
+	// CoinSelectionSmallestFirst selects the smallest coin outputs first,
+	// consolidating dust outputs as part of regular payments
+	CoinSelectionSmallestFirst CoinSelectionStrategy = "smallestfirst"
+	// CoinSelectionBranchAndBound searches for a set of coin outputs which funds the transaction
+	// without requiring a change output, falling back to largest-first if no such set is found
+	CoinSelectionBranchAndBound CoinSelectionStrategy = "branchandbound"
+)
+
+// maxBranchAndBoundTries limits the amount of branches visited
+// by the branch-and-bound coin selection
+const maxBranchAndBoundTries = 100000
+
+var (
+	// ErrUnknownCoinSelectionStrategy is returned in case an unknown coin selection strategy is used.
+	ErrUnknownCoinSelectionStrategy = errors.New("unknown coin selection strategy")
+)
+
+// UnavailableOutputError is returned in case a coin output pinned to fund a transaction
+// cannot be spent by the wallet.
+type UnavailableOutputError struct {
+	ID types.CoinOutputID
+}
+
+// Error implements error.Error
+func (err UnavailableOutputError) Error() string {
+	return fmt.Sprintf("coin output %s is not (or no longer) spendable by the wallet", err.ID.String())
+}
+
+// ParseCoinSelectionStrategy parses a coin selection strategy,
+// an empty string resolves to the default largest-first strategy.
+func ParseCoinSelectionStrategy(str string) (CoinSelectionStrategy, error) {
+	switch strategy := CoinSelectionStrategy(str); strategy {
+	case "":
+		return CoinSelectionLargestFirst, nil
+	case CoinSelectionLargestFirst, CoinSelectionSmallestFirst, CoinSelectionBranchAndBound:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("%v: %q", ErrUnknownCoinSelectionStrategy, str)
+	}
+}
+
+// coinSelection contains the result of a coin selection.
+type coinSelection struct {
+	Inputs []FundingCoinOutput
+	Fund   types.Currency
+	// Changeless is true in case the fund exceeds the requested amount
+	// by no more than the cost of change, in which case no change output should be created
+	Changeless bool
+}
+
+// selectCoinOutputs selects the coin outputs funding the given amount,
+// always using the pinned outputs, and selecting the other outputs from the candidates
+// using the given strategy. The candidates do not have to be sorted.
+// The cost of change is the maximum amount the branch-and-bound strategy is allowed
+// to overshoot the given amount, as to avoid a change output.
+func selectCoinOutputs(strategy CoinSelectionStrategy, candidates, pinned []FundingCoinOutput, amount, costOfChange types.Currency) (coinSelection, error) {
+	var selection coinSelection
+	for _, input := range pinned {
+		selection.Inputs = append(selection.Inputs, input)
+		selection.Fund = selection.Fund.Add(input.Output.Value)
+	}
+	if selection.Fund.Cmp(amount) >= 0 {
+		selection.Changeless = strategy == CoinSelectionBranchAndBound &&
+			selection.Fund.Cmp(amount.Add(costOfChange)) <= 0
+		return selection, nil
+	}
+	remaining := amount.Sub(selection.Fund)
+
+	// sort deterministically, largest outputs first, and the lowest ID first for outputs of equal value
+	candidates = append([]FundingCoinOutput(nil), candidates...)
+	sort.Slice(candidates, func(i, j int) bool {
+		if c := candidates[i].Output.Value.Cmp(candidates[j].Output.Value); c != 0 {
+			return c > 0
+		}
+		return bytes.Compare(candidates[i].ID[:], candidates[j].ID[:]) < 0
+	})
+
+	switch strategy {
+	case "", CoinSelectionLargestFirst:
+	case CoinSelectionSmallestFirst:
+		for i, j := 0, len(candidates)-1; i < j; i, j = i+1, j-1 {
+			candidates[i], candidates[j] = candidates[j], candidates[i]
+		}
+	case CoinSelectionBranchAndBound:
+		if inputs, ok := branchAndBound(candidates, remaining, costOfChange); ok {
+			for _, input := range inputs {
+				selection.Inputs = append(selection.Inputs, input)
+				selection.Fund = selection.Fund.Add(input.Output.Value)
+			}
+			selection.Changeless = true
+			return selection, nil
+		}
+	default:
+		return coinSelection{}, ErrUnknownCoinSelectionStrategy
+	}
+
+	var fund types.Currency
+	for _, candidate := range candidates {
+		if fund.Cmp(remaining) >= 0 {
+			break
+		}
+		selection.Inputs = append(selection.Inputs, candidate)
+		fund = fund.Add(candidate.Output.Value)
+	}
+	selection.Fund = selection.Fund.Add(fund)
+	if fund.Cmp(remaining) < 0 {
+		return coinSelection{}, modules.ErrLowBalance
+	}
+	return selection, nil
+}
+
+// branchAndBound searches depth-first for the subset of the candidates,
+// sorted from large to small, of which the sum is within [amount, amount+costOfChange],
+// preferring the subset which overshoots the amount the least.
+func branchAndBound(candidates []FundingCoinOutput, amount, costOfChange types.Currency) ([]FundingCoinOutput, bool) {
+	// available[i] is the sum of all candidates starting from index i
+	available := make([]types.Currency, len(candidates)+1)
+	for i := len(candidates) - 1; i >= 0; i-- {
+		available[i] = available[i+1].Add(candidates[i].Output.Value)
+	}
+	if available[0].Cmp(amount) < 0 {
+		return nil, false
+	}
+	upperBound := amount.Add(costOfChange)
+
+	var (
+		best      []int
+		bestWaste types.Currency
+		found     bool
+		selected  []int
+		tries     int
+	)
+	var search func(index int, sum types.Currency) bool
+	search = func(index int, sum types.Currency) bool {
+		tries++
+		if tries > maxBranchAndBoundTries {
+			return true
+		}
+		if sum.Cmp(upperBound) > 0 {
+			return false
+		}
+		if sum.Cmp(amount) >= 0 {
+			waste := sum.Sub(amount)
+			if !found || waste.Cmp(bestWaste) < 0 {
+				best = append(best[:0], selected...)
+				bestWaste = waste
+				found = true
+			}
+			// stop searching once an exact match is found
+			return waste.IsZero()
+		}
+		if index >= len(candidates) || sum.Add(available[index]).Cmp(amount) < 0 {
+			return false
+		}
+		// first explore the branch including the candidate, then the one omitting it
+		selected = append(selected, index)
+		if search(index+1, sum.Add(candidates[index].Output.Value)) {
+			return true
+		}
+		selected = selected[:len(selected)-1]
+		return search(index+1, sum)
+	}
+	search(0, types.Currency{})
+	if !found {
+		return nil, false
+	}
+	inputs := make([]FundingCoinOutput, 0, len(best))
+	for _, index := range best {
+		inputs = append(inputs, candidates[index])
+	}
+	return inputs, true
+}
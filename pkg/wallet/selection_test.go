@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/threefoldtech/rivine/types"
+)
+
+func testFundingCoinOutputs(values ...uint64) []FundingCoinOutput {
+	outputs := make([]FundingCoinOutput, 0, len(values))
+	for i, value := range values {
+		var id types.CoinOutputID
+		id[0] = byte(i + 1)
+		outputs = append(outputs, FundingCoinOutput{
+			ID:     id,
+			Output: types.CoinOutput{Value: types.NewCurrency64(value)},
+		})
+	}
+	return outputs
+}
+
+func TestSelectCoinOutputs(t *testing.T) {
+	candidates := testFundingCoinOutputs(1, 5, 8, 20)
+	testCases := []struct {
+		Strategy   CoinSelectionStrategy
+		Pinned     []FundingCoinOutput
+		Amount     uint64
+		Fund       uint64
+		Inputs     int
+		Changeless bool
+	}{
+		{CoinSelectionLargestFirst, nil, 13, 20, 1, false},
+		{CoinSelectionSmallestFirst, nil, 13, 14, 3, false},
+		// 5+8 matches exactly
+		{CoinSelectionBranchAndBound, nil, 13, 13, 2, true},
+		// 8+5 overshoots by 1, which is within the cost of change
+		{CoinSelectionBranchAndBound, nil, 12, 13, 2, true},
+		// no changeless set exists, falls back to largest first
+		{CoinSelectionBranchAndBound, nil, 30, 33, 3, false},
+		// pinned outputs are always used
+		{CoinSelectionLargestFirst, testFundingCoinOutputs(2), 1, 2, 1, false},
+		{CoinSelectionLargestFirst, testFundingCoinOutputs(2), 10, 22, 2, false},
+	}
+	for idx, testCase := range testCases {
+		selection, err := selectCoinOutputs(testCase.Strategy, candidates, testCase.Pinned,
+			types.NewCurrency64(testCase.Amount), types.NewCurrency64(1))
+		if err != nil {
+			t.Errorf("test case #%d: unexpected error: %v", idx, err)
+			continue
+		}
+		if !selection.Fund.Equals64(testCase.Fund) || len(selection.Inputs) != testCase.Inputs || selection.Changeless != testCase.Changeless {
+			t.Errorf("test case #%d: unexpected selection: fund %s, %d inputs, changeless %v",
+				idx, selection.Fund.String(), len(selection.Inputs), selection.Changeless)
+		}
+	}
+
+	_, err := selectCoinOutputs(CoinSelectionLargestFirst, candidates, nil, types.NewCurrency64(35), types.ZeroCurrency)
+	if err == nil {
+		t.Error("expected low balance error")
+	}
+}
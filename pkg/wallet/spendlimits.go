@@ -0,0 +1,274 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NebulousLabs/fastrand"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// SpendsFile is the name of the file keeping track of the recent and pending spends, stored in the wallet directory.
+const SpendsFile = "spends.json"
+
+// SpendWindow is the period over which the daily spend limit applies.
+const SpendWindow = 24 * time.Hour
+
+var (
+	// ErrApprovalRequired is returned in case a spend exceeds the approval threshold,
+	// and has to be approved before the wallet signs it.
+	ErrApprovalRequired = errors.New("spend exceeds the approval threshold and has to be approved")
+	// ErrUnknownPendingSpend is returned in case no pending spend exists with a given ID.
+	ErrUnknownPendingSpend = errors.New("unknown pending spend")
+)
+
+var spendsMetadata = persist.Metadata{
+	Header:  "Goldchain Wallet Spends",
+	Version: "1.0",
+}
+
+// SpendPolicy limits the coins a wallet sends to addresses other than its own.
+// A zero limit disables the limit.
+type SpendPolicy struct {
+	// MaxPerTransaction is the maximum amount of coins a single transaction can send
+	MaxPerTransaction types.Currency `json:"maxpertransaction"`
+	// MaxPerDay is the maximum amount of coins all transactions of the last 24 hours can send together
+	MaxPerDay types.Currency `json:"maxperday"`
+	// ApprovalThreshold is the amount of coins above which a transaction is only signed
+	// once it is approved by a second party
+	ApprovalThreshold types.Currency `json:"approvalthreshold"`
+}
+
+// Enabled returns whether any of the limits of the policy is defined.
+func (p SpendPolicy) Enabled() bool {
+	return !p.MaxPerTransaction.IsZero() || !p.MaxPerDay.IsZero() || !p.ApprovalThreshold.IsZero()
+}
+
+// SpendLimitError is returned in case a spend exceeds one of the limits of the spend policy.
+type SpendLimitError struct {
+	Limit  string
+	Amount types.Currency
+	Max    types.Currency
+}
+
+// Error implements error.Error
+func (err *SpendLimitError) Error() string {
+	return fmt.Sprintf("spend of %s exceeds the %s limit of %s", err.Amount.String(), err.Limit, err.Max.String())
+}
+
+// Spend is a spend accepted by the spend policy.
+type Spend struct {
+	Time   time.Time      `json:"time"`
+	Amount types.Currency `json:"amount"`
+	Call   string         `json:"call"`
+}
+
+// PendingSpend is a spend awaiting approval, together with the request which is executed once approved.
+type PendingSpend struct {
+	ID      string         `json:"id"`
+	Created time.Time      `json:"created"`
+	Amount  types.Currency `json:"amount"`
+	// Call is the API call (path and query) which requested the spend
+	Call string `json:"call"`
+	// Body is the body of the request, executed once the spend is approved
+	Body []byte `json:"body"`
+}
+
+// SpendGuard enforces a spend policy on the coins sent by a wallet,
+// keeping the spends of the last 24 hours and the spends awaiting approval on disk.
+type SpendGuard struct {
+	policy   SpendPolicy
+	filename string
+	now      func() time.Time
+
+	mu      sync.Mutex
+	spends  []*Spend
+	pending map[string]PendingSpend
+}
+
+// spendsFile is the persisted form of the spend guard.
+type spendsFile struct {
+	Spends  []*Spend       `json:"spends"`
+	Pending []PendingSpend `json:"pending"`
+}
+
+// NewSpendGuard creates a guard enforcing the given policy,
+// loading the recent and pending spends stored in the given file.
+func NewSpendGuard(policy SpendPolicy, filename string) (*SpendGuard, error) {
+	g := &SpendGuard{
+		policy:   policy,
+		filename: filename,
+		now:      time.Now,
+		pending:  make(map[string]PendingSpend),
+	}
+	var file spendsFile
+	err := persist.LoadJSON(spendsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load spends: %v", err)
+	}
+	g.spends = file.Spends
+	for _, spend := range file.Pending {
+		g.pending[spend.ID] = spend
+	}
+	return g, nil
+}
+
+// Policy returns the spend policy enforced by the guard.
+func (g *SpendGuard) Policy() SpendPolicy {
+	return g.policy
+}
+
+// SpentToday returns the sum of all spends of the last 24 hours.
+func (g *SpendGuard) SpentToday() types.Currency {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.prune()
+	return g.spentToday()
+}
+
+// Reserve checks the given spend against the policy, and records it should it be accepted,
+// such that it counts towards the daily limit. The returned function releases the spend,
+// and has to be called in case the transaction is not sent after all.
+// ErrApprovalRequired is returned for spends above the approval threshold, unless already approved,
+// and a *SpendLimitError for spends exceeding any other limit.
+func (g *SpendGuard) Reserve(amount types.Currency, call string, approved bool) (release func() error, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.policy.MaxPerTransaction.IsZero() && amount.Cmp(g.policy.MaxPerTransaction) > 0 {
+		return nil, &SpendLimitError{Limit: "per transaction", Amount: amount, Max: g.policy.MaxPerTransaction}
+	}
+	g.prune()
+	if !g.policy.MaxPerDay.IsZero() && g.spentToday().Add(amount).Cmp(g.policy.MaxPerDay) > 0 {
+		return nil, &SpendLimitError{Limit: "daily", Amount: amount, Max: g.policy.MaxPerDay}
+	}
+	if !approved && !g.policy.ApprovalThreshold.IsZero() && amount.Cmp(g.policy.ApprovalThreshold) > 0 {
+		return nil, ErrApprovalRequired
+	}
+	spend := &Spend{Time: g.now(), Amount: amount, Call: call}
+	g.spends = append(g.spends, spend)
+	if err = g.save(); err != nil {
+		g.spends = g.spends[:len(g.spends)-1]
+		return nil, err
+	}
+	return func() error {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		for i := range g.spends {
+			if g.spends[i] == spend {
+				g.spends = append(g.spends[:i], g.spends[i+1:]...)
+				break
+			}
+		}
+		return g.save()
+	}, nil
+}
+
+// Queue adds a spend awaiting approval, returning it.
+func (g *SpendGuard) Queue(amount types.Currency, call string, body []byte) (PendingSpend, error) {
+	spend := PendingSpend{
+		ID:      hex.EncodeToString(fastrand.Bytes(16)),
+		Created: g.now(),
+		Amount:  amount,
+		Call:    call,
+		Body:    body,
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.pending[spend.ID] = spend
+	if err := g.save(); err != nil {
+		delete(g.pending, spend.ID)
+		return PendingSpend{}, err
+	}
+	return spend, nil
+}
+
+// Pending returns all spends awaiting approval, oldest first.
+func (g *SpendGuard) Pending() []PendingSpend {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	spends := make([]PendingSpend, 0, len(g.pending))
+	for _, spend := range g.pending {
+		spends = append(spends, spend)
+	}
+	sort.Slice(spends, func(i, j int) bool {
+		return spends[i].Created.Before(spends[j].Created)
+	})
+	return spends
+}
+
+// Take removes the pending spend with the given ID, returning it,
+// such that it is executed once approved, or dropped once rejected.
+func (g *SpendGuard) Take(id string) (PendingSpend, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	spend, ok := g.pending[id]
+	if !ok {
+		return PendingSpend{}, ErrUnknownPendingSpend
+	}
+	delete(g.pending, id)
+	if err := g.save(); err != nil {
+		g.pending[id] = spend
+		return PendingSpend{}, err
+	}
+	return spend, nil
+}
+
+// prune drops the spends which no longer count towards the daily limit.
+func (g *SpendGuard) prune() {
+	cutoff := g.now().Add(-SpendWindow)
+	i := 0
+	for i < len(g.spends) && !g.spends[i].Time.After(cutoff) {
+		i++
+	}
+	g.spends = g.spends[i:]
+}
+
+func (g *SpendGuard) spentToday() types.Currency {
+	sum := types.ZeroCurrency
+	for _, spend := range g.spends {
+		sum = sum.Add(spend.Amount)
+	}
+	return sum
+}
+
+func (g *SpendGuard) save() error {
+	pending := make([]PendingSpend, 0, len(g.pending))
+	for _, spend := range g.pending {
+		pending = append(pending, spend)
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Created.Before(pending[j].Created)
+	})
+	return persist.SaveJSON(spendsMetadata, spendsFile{Spends: g.spends, Pending: pending}, g.filename)
+}
+
+// AddressLister is the part of the wallet used to identify the coins sent to other addresses.
+type AddressLister interface {
+	AllAddresses() ([]types.UnlockHash, error)
+}
+
+// OutgoingCoins returns the sum of the given coin outputs which are not sent to an address of the wallet,
+// being the coins spent by the wallet, refunds excluded.
+func OutgoingCoins(w AddressLister, outputs []types.CoinOutput) (types.Currency, error) {
+	addresses, err := w.AllAddresses()
+	if err != nil {
+		return types.Currency{}, err
+	}
+	own := make(map[types.UnlockHash]struct{}, len(addresses))
+	for _, address := range addresses {
+		own[address] = struct{}{}
+	}
+	sum := types.ZeroCurrency
+	for _, co := range outputs {
+		if _, ok := own[co.Condition.UnlockHash()]; !ok {
+			sum = sum.Add(co.Value)
+		}
+	}
+	return sum, nil
+}
@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/types"
+)
+
+type testAddressLister []types.UnlockHash
+
+func (l testAddressLister) AllAddresses() ([]types.UnlockHash, error) {
+	return l, nil
+}
+
+func TestSpendGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spends")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, SpendsFile)
+
+	policy := SpendPolicy{
+		MaxPerTransaction: types.NewCurrency64(100),
+		MaxPerDay:         types.NewCurrency64(150),
+		ApprovalThreshold: types.NewCurrency64(50),
+	}
+	g, err := NewSpendGuard(policy, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	g.now = func() time.Time { return now }
+
+	if _, err = g.Reserve(types.NewCurrency64(101), "/wallet/coins", true); err == nil {
+		t.Error("expected the per transaction limit to apply to approved spends")
+	} else if _, ok := err.(*SpendLimitError); !ok {
+		t.Errorf("expected a spend limit error, got %v", err)
+	}
+	if _, err = g.Reserve(types.NewCurrency64(60), "/wallet/coins", false); err != ErrApprovalRequired {
+		t.Errorf("expected %v, got %v", ErrApprovalRequired, err)
+	}
+	if _, err = g.Reserve(types.NewCurrency64(60), "/wallet/coins", true); err != nil {
+		t.Fatal(err)
+	}
+	release, err := g.Reserve(types.NewCurrency64(50), "/wallet/coins", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.Reserve(types.NewCurrency64(50), "/wallet/coins", false); err == nil {
+		t.Error("expected the daily limit to apply")
+	}
+	// released spends no longer count towards the daily limit
+	if err = release(); err != nil {
+		t.Fatal(err)
+	}
+	if spent := g.SpentToday(); !spent.Equals64(60) {
+		t.Errorf("expected 60 spent today, got %v", spent)
+	}
+
+	pending, err := g.Queue(types.NewCurrency64(60), "/wallet/coins?dryrun=false", []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// spends are persisted, and only count towards the daily limit for 24 hours
+	g, err = NewSpendGuard(policy, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.now = func() time.Time { return now.Add(SpendWindow) }
+	if spent := g.SpentToday(); !spent.IsZero() {
+		t.Errorf("expected nothing spent after a day, got %v", spent)
+	}
+	if spends := g.Pending(); len(spends) != 1 || spends[0].ID != pending.ID || string(spends[0].Body) != "{}" {
+		t.Fatalf("unexpected pending spends: %v", spends)
+	}
+	if _, err = g.Take(pending.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = g.Take(pending.ID); err != ErrUnknownPendingSpend {
+		t.Errorf("expected %v, got %v", ErrUnknownPendingSpend, err)
+	}
+}
+
+func TestOutgoingCoins(t *testing.T) {
+	own := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{1})
+	other := types.NewUnlockHash(types.UnlockTypePubKey, crypto.Hash{2})
+	outgoing, err := OutgoingCoins(testAddressLister{own}, []types.CoinOutput{
+		{Value: types.NewCurrency64(10), Condition: types.NewCondition(types.NewUnlockHashCondition(other))},
+		{Value: types.NewCurrency64(5), Condition: types.NewCondition(types.NewUnlockHashCondition(own))},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !outgoing.Equals64(10) {
+		t.Errorf("expected 10 outgoing coins, got %v", outgoing)
+	}
+}
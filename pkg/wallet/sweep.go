@@ -0,0 +1,335 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/persist"
+	"github.com/threefoldtech/rivine/types"
+)
+
+const (
+	// SweepsFile is the name of the file recording the sweeps of the wallet, stored in the wallet directory.
+	SweepsFile = "sweeps.json"
+
+	// sweepMaxInputs is the maximum amount of coin outputs swept by a single transaction,
+	// the outputs of a block exceeding it being swept by multiple transactions
+	sweepMaxInputs = 50
+)
+
+var (
+	// ErrNothingToSweep is returned in case none of the tagged addresses reached the sweep threshold.
+	ErrNothingToSweep = errors.New("no tagged address holds enough confirmed coins to be swept")
+	// ErrSweepingDisabled is returned in case a sweep is requested while no sweep policy is configured.
+	ErrSweepingDisabled = errors.New("sweeping is disabled, as no sweep policy is configured")
+)
+
+var sweepsMetadata = persist.Metadata{
+	Header:  "Goldchain Wallet Sweeps",
+	Version: "1.0",
+}
+
+// SweepPolicy defines which addresses of the wallet are swept to cold storage.
+type SweepPolicy struct {
+	// Tag is the tag of the addresses which are swept, such as DepositTag
+	Tag string `json:"tag"`
+	// Condition is the cold storage condition the coins are sent to
+	Condition types.UnlockConditionProxy `json:"condition"`
+	// Threshold is the minimum value of the confirmed coins of an address for it to be swept,
+	// such that dust deposits are not swept one by one
+	Threshold types.Currency `json:"threshold"`
+}
+
+// Validate returns an error in case the policy cannot be applied.
+func (policy SweepPolicy) Validate() error {
+	if !isValidName(policy.Tag) {
+		return ErrInvalidAddressTag
+	}
+	if policy.Condition.ConditionType() == types.ConditionTypeNil {
+		return errors.New("the coins cannot be swept to the nil condition")
+	}
+	return nil
+}
+
+// SweepRecord records a transaction sweeping tagged addresses,
+// mapping every swept coin output to its address and label for reconciliation.
+type SweepRecord struct {
+	TransactionID types.TransactionID `json:"transactionid"`
+	// Height is the consensus height at which the sweep was sent
+	Height    types.BlockHeight `json:"height"`
+	Timestamp types.Timestamp   `json:"timestamp"`
+	// Value is the value sent to the cold storage condition, the value of the inputs minus the miner fee
+	Value    types.Currency    `json:"value"`
+	MinerFee types.Currency    `json:"minerfee"`
+	Inputs   []SweptCoinOutput `json:"inputs"`
+	Target   types.UnlockHash  `json:"target"`
+}
+
+// SweptCoinOutput is a coin output swept to cold storage.
+type SweptCoinOutput struct {
+	ID      types.CoinOutputID `json:"id"`
+	Address types.UnlockHash   `json:"address"`
+	// Label is the label of the address at the time it was swept
+	Label string         `json:"label,omitempty"`
+	Value types.Currency `json:"value"`
+}
+
+// Sweeper sweeps the confirmed coins of the tagged addresses of the wallet to a cold storage condition,
+// once the coins of an address reach the threshold of its policy. The addresses are swept whenever
+// a block is applied while the consensus set is synced, all addresses due being swept by a single transaction.
+// Sweeps are recorded, and only sent while the wallet is unlocked.
+type Sweeper struct {
+	policy    SweepPolicy
+	w         modules.Wallet
+	tpool     modules.TransactionPool
+	cs        modules.ConsensusSet
+	labels    *AddressLabels
+	constants types.ChainConstants
+	filename  string
+	output    io.Writer
+	triggerCh chan struct{}
+	closeCh   chan struct{}
+	wg        sync.WaitGroup
+
+	// sweepMu serializes sweeps, such that coin outputs are never swept twice
+	sweepMu sync.Mutex
+	mu      sync.Mutex
+	records []SweepRecord
+}
+
+// sweepsFile is the persisted form of the sweep records.
+type sweepsFile struct {
+	Sweeps []SweepRecord `json:"sweeps"`
+}
+
+// NewSweeper creates a Sweeper applying the given policy to the addresses labeled using the given labels,
+// recording its sweeps in the given file, and subscribes it to the given consensus set.
+// Sweeps failing in the background are logged to the given writer.
+func NewSweeper(policy SweepPolicy, w modules.Wallet, tpool modules.TransactionPool, cs modules.ConsensusSet, labels *AddressLabels, constants types.ChainConstants, filename string, output io.Writer) (*Sweeper, error) {
+	err := policy.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("invalid sweep policy: %v", err)
+	}
+	if output == nil {
+		output = ioutil.Discard
+	}
+	s := &Sweeper{
+		policy:    policy,
+		w:         w,
+		tpool:     tpool,
+		cs:        cs,
+		labels:    labels,
+		constants: constants,
+		filename:  filename,
+		output:    output,
+		triggerCh: make(chan struct{}, 1),
+		closeCh:   make(chan struct{}),
+	}
+	var file sweepsFile
+	err = persist.LoadJSON(sweepsMetadata, &file, filename)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load wallet sweeps: %v", err)
+	}
+	s.records = file.Sweeps
+	err = cs.ConsensusSetSubscribe(s, modules.ConsensusChangeRecent, s.closeCh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to the consensus set: %v", err)
+	}
+	s.wg.Add(1)
+	go s.sweepBlocks()
+	return s, nil
+}
+
+// Close unsubscribes from the consensus set and stops sweeping.
+func (s *Sweeper) Close() error {
+	s.cs.Unsubscribe(s)
+	close(s.closeCh)
+	s.wg.Wait()
+	return nil
+}
+
+// Policy returns the policy applied by this Sweeper.
+func (s *Sweeper) Policy() SweepPolicy {
+	return s.policy
+}
+
+// Sweeps returns the recorded sweeps, the most recent one first.
+func (s *Sweeper) Sweeps() []SweepRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]SweepRecord, 0, len(s.records))
+	for i := len(s.records) - 1; i >= 0; i-- {
+		records = append(records, s.records[i])
+	}
+	return records
+}
+
+// ProcessConsensusChange implements modules.ConsensusSetSubscriber,
+// sweeping the tagged addresses once a block is applied while the consensus set is synced.
+func (s *Sweeper) ProcessConsensusChange(cc modules.ConsensusChange) {
+	if !cc.Synced || len(cc.AppliedBlocks) == 0 {
+		return
+	}
+	// the wallet and transaction pool cannot be used while the consensus set is notifying us
+	select {
+	case s.triggerCh <- struct{}{}:
+	default:
+	}
+}
+
+// sweepBlocks sweeps the tagged addresses whenever triggered, until the Sweeper is closed.
+func (s *Sweeper) sweepBlocks() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.closeCh:
+			return
+		case <-s.triggerCh:
+		}
+		records, err := s.Sweep()
+		switch err {
+		case nil:
+		case ErrNothingToSweep, modules.ErrLockedWallet:
+			continue
+		default:
+			fmt.Fprintln(s.output, "Sweep error:", err)
+		}
+		for _, record := range records {
+			fmt.Fprintf(s.output, "Swept %d coin output(s) to cold storage in transaction %s\n",
+				len(record.Inputs), record.TransactionID.String())
+		}
+	}
+}
+
+// Sweep sweeps the confirmed coins of all tagged addresses which reached the threshold,
+// submitting the sweep transactions to the transaction pool and recording them.
+// The sweeps sent before an error occurred are returned together with the error.
+func (s *Sweeper) Sweep() ([]SweepRecord, error) {
+	s.sweepMu.Lock()
+	defer s.sweepMu.Unlock()
+
+	candidates, err := s.candidates()
+	if err != nil {
+		return nil, err
+	}
+	var records []SweepRecord
+	for len(candidates) > 0 {
+		n := sweepMaxInputs
+		if n > len(candidates) {
+			n = len(candidates)
+		}
+		ft, err := buildMergeTransaction(s.w, candidates[:n], s.policy.Condition, s.constants.MinimumTransactionFee, s.constants)
+		if err != nil {
+			return records, err
+		}
+		candidates = candidates[n:]
+		if ft.Transaction.CoinOutputs == nil {
+			// the outputs do not cover the miner fee
+			continue
+		}
+		err = s.tpool.AcceptTransactionSet([]types.Transaction{ft.Transaction})
+		if err != nil {
+			return records, err
+		}
+		record := s.newRecord(ft)
+		err = s.record(record)
+		records = append(records, record)
+		if err != nil {
+			return records, err
+		}
+	}
+	if len(records) == 0 {
+		return nil, ErrNothingToSweep
+	}
+	return records, nil
+}
+
+// candidates returns the unspent confirmed coin outputs of the tagged addresses which reached the threshold,
+// grouped per address.
+func (s *Sweeper) candidates() ([]FundingCoinOutput, error) {
+	tagged := s.labels.matching(AddressLabelFilter{Tag: s.policy.Tag})
+	unspentCoinOutputs, _, err := s.w.UnlockedUnspendOutputs()
+	if err != nil {
+		return nil, err
+	}
+	spent := spentCoinOutputs(s.tpool.TransactionList())
+	outputs := make(map[types.UnlockHash][]FundingCoinOutput)
+	balances := make(map[types.UnlockHash]types.Currency)
+	for id, co := range unspentCoinOutputs {
+		if _, ok := spent[id]; ok || !isSingleSignatureCondition(co.Condition) {
+			continue
+		}
+		address := co.Condition.UnlockHash()
+		if !tagged(address) {
+			continue
+		}
+		outputs[address] = append(outputs[address], FundingCoinOutput{ID: id, Output: co})
+		balances[address] = balances[address].Add(co.Value)
+	}
+	var addresses []types.UnlockHash
+	for address, balance := range balances {
+		if balance.Cmp(s.policy.Threshold) >= 0 && !balance.IsZero() {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(addresses) == 0 {
+		return nil, ErrNothingToSweep
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].String() < addresses[j].String()
+	})
+	var candidates []FundingCoinOutput
+	for _, address := range addresses {
+		candidates = append(candidates, outputs[address]...)
+	}
+	// the outputs of an address are sorted by ID, such that sweeps are deterministic
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ai, aj := candidates[i].Output.Condition.UnlockHash(), candidates[j].Output.Condition.UnlockHash()
+		if ai != aj {
+			return ai.String() < aj.String()
+		}
+		return bytes.Compare(candidates[i].ID[:], candidates[j].ID[:]) < 0
+	})
+	return candidates, nil
+}
+
+// newRecord creates the record of the given sweep transaction.
+func (s *Sweeper) newRecord(ft FundedTransaction) SweepRecord {
+	record := SweepRecord{
+		TransactionID: ft.Transaction.ID(),
+		Height:        s.cs.Height(),
+		Timestamp:     types.Timestamp(time.Now().Unix()),
+		Value:         ft.Transaction.CoinOutputs[0].Value,
+		MinerFee:      ft.MinerFee,
+		Target:        s.policy.Condition.UnlockHash(),
+	}
+	for _, input := range ft.CoinInputs {
+		address := input.Output.Condition.UnlockHash()
+		swept := SweptCoinOutput{ID: input.ID, Address: address, Value: input.Output.Value}
+		if label, err := s.labels.Label(address); err == nil {
+			swept.Label = label.Label
+		}
+		record.Inputs = append(record.Inputs, swept)
+	}
+	return record
+}
+
+// record stores the given sweep record.
+func (s *Sweeper) record(record SweepRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, record)
+	err := persist.SaveJSON(sweepsMetadata, sweepsFile{Sweeps: s.records}, s.filename)
+	if err != nil {
+		return fmt.Errorf("failed to store wallet sweeps: %v", err)
+	}
+	return nil
+}
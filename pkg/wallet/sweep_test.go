@@ -0,0 +1,145 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+// testSweepWallet signs using the keys of the primary seed addresses it generated.
+type testSweepWallet struct {
+	*testLabelsWallet
+}
+
+func (w *testSweepWallet) GetKey(address types.UnlockHash) (types.PublicKey, types.ByteSlice, error) {
+	for index := uint64(0); index < w.progress; index++ {
+		sk, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(w.primary, index))
+		if types.NewEd25519PubKeyUnlockHash(pk) == address {
+			return types.Ed25519PublicKey(pk), types.ByteSlice(sk[:]), nil
+		}
+	}
+	return w.testLabelsWallet.GetKey(address)
+}
+
+// testSweepTransactionPool accepts all transactions.
+type testSweepTransactionPool struct {
+	modules.TransactionPool
+	txns []types.Transaction
+}
+
+func (tp *testSweepTransactionPool) AcceptTransactionSet(txns []types.Transaction) error {
+	tp.txns = append(tp.txns, txns...)
+	return nil
+}
+func (tp *testSweepTransactionPool) TransactionList() []types.Transaction { return tp.txns }
+
+func TestSweeper(t *testing.T) {
+	w := &testSweepWallet{&testLabelsWallet{testAccountsWallet: &testAccountsWallet{
+		primary: modules.Seed{1},
+		unspent: make(map[types.CoinOutputID]types.CoinOutput),
+	}}}
+	dir := t.TempDir()
+	labels, err := NewAddressLabels(w, filepath.Join(dir, AddressLabelsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var addresses []types.UnlockHash
+	for _, label := range []string{"dust", "acme", "hot"} {
+		tags := []string{DepositTag}
+		if label == "hot" {
+			tags = nil
+		}
+		address, err := labels.Set(types.UnlockHash{}, label, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addresses = append(addresses, address.Address)
+	}
+	dust, acme, hot := addresses[0], addresses[1], addresses[2]
+	pay := func(id byte, value uint64, to types.UnlockHash) {
+		w.unspent[types.CoinOutputID{id}] = types.CoinOutput{
+			Value:     types.NewCurrency64(value),
+			Condition: types.NewCondition(types.NewUnlockHashCondition(to)),
+		}
+	}
+	pay(1, 5, dust)
+	pay(3, 12, acme)
+	pay(2, 8, acme)
+	pay(4, 100, hot)
+
+	var cold types.UnlockHash
+	cold.Type = types.UnlockTypePubKey
+	cold.Hash[0] = 42
+	policy := SweepPolicy{
+		Tag:       DepositTag,
+		Condition: types.NewCondition(types.NewUnlockHashCondition(cold)),
+		Threshold: types.NewCurrency64(10),
+	}
+	tpool := &testSweepTransactionPool{}
+	cs := &testImportCS{height: 7}
+	constants := types.ChainConstants{MinimumTransactionFee: types.NewCurrency64(1)}
+	filename := filepath.Join(dir, SweepsFile)
+
+	invalid := policy
+	invalid.Tag = "1st"
+	if _, err := NewSweeper(invalid, w, tpool, cs, labels, constants, filename, nil); err == nil {
+		t.Error("expected the invalid tag to be refused")
+	}
+	sweeper, err := NewSweeper(policy, w, tpool, cs, labels, constants, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sweeper.Close()
+	if cs.subscriber != sweeper {
+		t.Fatal("expected the sweeper to subscribe to the consensus set")
+	}
+
+	// only the tagged addresses reaching the threshold are swept, their outputs sorted by ID
+	records, err := sweeper.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || len(tpool.txns) != 1 || records[0].TransactionID != tpool.txns[0].ID() {
+		t.Fatalf("expected a single sweep transaction, got %+v", records)
+	}
+	record := records[0]
+	if !record.Value.Equals64(19) || !record.MinerFee.Equals64(1) || record.Target != cold || record.Height != 7 ||
+		len(record.Inputs) != 2 || record.Inputs[0].ID != (types.CoinOutputID{2}) || record.Inputs[1].ID != (types.CoinOutputID{3}) ||
+		record.Inputs[0].Address != acme || record.Inputs[0].Label != "acme" {
+		t.Errorf("unexpected sweep: %+v", record)
+	}
+	txn := tpool.txns[0]
+	if len(txn.CoinOutputs) != 1 || txn.CoinOutputs[0].Condition.UnlockHash() != cold || len(txn.CoinInputs) != 2 {
+		t.Errorf("unexpected sweep transaction: %+v", txn)
+	}
+
+	// outputs spent by the transaction pool are not swept twice
+	if _, err := sweeper.Sweep(); err != ErrNothingToSweep {
+		t.Errorf("expected nothing to sweep, got %v", err)
+	}
+	pay(5, 6, dust)
+	records, err = sweeper.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !records[0].Value.Equals64(10) || len(records[0].Inputs) != 2 || records[0].Inputs[0].Address != dust {
+		t.Errorf("unexpected sweep: %+v", records)
+	}
+
+	// the sweeps are persisted, the most recent one first
+	sweeps := sweeper.Sweeps()
+	if len(sweeps) != 2 || sweeps[0].TransactionID != records[0].TransactionID || sweeps[1].TransactionID != record.TransactionID {
+		t.Fatalf("unexpected sweeps: %+v", sweeps)
+	}
+	reloaded, err := NewSweeper(policy, w, tpool, &testImportCS{}, labels, constants, filename, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloaded.Close()
+	if len(reloaded.Sweeps()) != 2 {
+		t.Errorf("expected the sweeps to be reloaded, got %+v", reloaded.Sweeps())
+	}
+}
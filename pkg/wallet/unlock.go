@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/threefoldtech/rivine/crypto"
+	"github.com/threefoldtech/rivine/modules"
+)
+
+// passwordCommandTimeout is the maximum duration of the command of a CommandPasswordSource.
+const passwordCommandTimeout = 30 * time.Second
+
+// CredentialsDirectoryEnv is the environment variable in which systemd defines
+// the directory containing the credentials loaded using LoadCredential (or SetCredential).
+const CredentialsDirectoryEnv = "CREDENTIALS_DIRECTORY"
+
+var (
+	// ErrEmptyPassword is returned when a password source provides an empty password.
+	ErrEmptyPassword = errors.New("the password source provided an empty password")
+	// ErrNoCredentialsDirectory is returned when reading a systemd credential
+	// while the daemon isn't started by systemd with credentials.
+	ErrNoCredentialsDirectory = errors.New(CredentialsDirectoryEnv + " is not defined, as the daemon is not started by systemd using LoadCredential")
+)
+
+// PasswordSource provides the password used to unlock the wallet of an unattended node,
+// such that the password doesn't have to be part of a startup script.
+// It can be implemented to fetch the password from a key management service.
+type PasswordSource interface {
+	Password() (string, error)
+}
+
+type (
+	// FilePasswordSource reads the password from a file, which cannot be accessible to the group or others.
+	FilePasswordSource string
+	// CredentialPasswordSource reads the password from the systemd credential with the given name.
+	CredentialPasswordSource string
+	// EnvPasswordSource reads the password from the given environment variable,
+	// removing it from the environment, such that it isn't inherited by child processes.
+	EnvPasswordSource string
+	// CommandPasswordSource reads the password from the output of a command, e.g. a KMS or vault client.
+	CommandPasswordSource []string
+)
+
+// ParsePasswordSource parses a password source, defined as one of:
+//
+//	file:<path>
+//	credential:<name>
+//	env:<variable>
+//	exec:<command> [arguments...]
+func ParsePasswordSource(str string) (PasswordSource, error) {
+	parts := strings.SplitN(str, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, fmt.Errorf("invalid password source %q: expected file:<path>, credential:<name>, env:<variable> or exec:<command>", str)
+	}
+	switch parts[0] {
+	case "file":
+		return FilePasswordSource(parts[1]), nil
+	case "credential":
+		if strings.ContainsRune(parts[1], filepath.Separator) {
+			return nil, fmt.Errorf("invalid credential name %q", parts[1])
+		}
+		return CredentialPasswordSource(parts[1]), nil
+	case "env":
+		return EnvPasswordSource(parts[1]), nil
+	case "exec":
+		return CommandPasswordSource(strings.Fields(parts[1])), nil
+	default:
+		return nil, fmt.Errorf("invalid password source %q: unknown kind %q", str, parts[0])
+	}
+}
+
+// Password implements PasswordSource.Password.
+func (path FilePasswordSource) Password() (string, error) {
+	info, err := os.Stat(string(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("password file %s can be accessed by others (mode %v), restrict it to its owner (chmod 600)", path, info.Mode().Perm())
+	}
+	b, err := ioutil.ReadFile(string(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read password file: %v", err)
+	}
+	return trimPassword(b)
+}
+
+// Password implements PasswordSource.Password.
+func (name CredentialPasswordSource) Password() (string, error) {
+	dir := os.Getenv(CredentialsDirectoryEnv)
+	if dir == "" {
+		return "", ErrNoCredentialsDirectory
+	}
+	// systemd restricts the credentials to the user of the service
+	b, err := ioutil.ReadFile(filepath.Join(dir, string(name)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read credential: %v", err)
+	}
+	return trimPassword(b)
+}
+
+// Password implements PasswordSource.Password.
+func (name EnvPasswordSource) Password() (string, error) {
+	password, ok := os.LookupEnv(string(name))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not defined", name)
+	}
+	os.Unsetenv(string(name))
+	return trimPassword([]byte(password))
+}
+
+// Password implements PasswordSource.Password.
+func (command CommandPasswordSource) Password() (string, error) {
+	if len(command) == 0 {
+		return "", errors.New("no password command defined")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if err != nil {
+		return "", fmt.Errorf("password command %s failed: %v: %s", command[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return trimPassword(stdout.Bytes())
+}
+
+// trimPassword removes the trailing newline most files and commands end with.
+func trimPassword(b []byte) (string, error) {
+	password := strings.TrimRight(string(b), "\r\n")
+	if password == "" {
+		return "", ErrEmptyPassword
+	}
+	return password, nil
+}
+
+// Unlock unlocks the given wallet using the password provided by the given source,
+// the same way as the /wallet/unlock endpoint does.
+func Unlock(w modules.Wallet, source PasswordSource) error {
+	password, err := source.Password()
+	if err != nil {
+		return err
+	}
+	return w.Unlock(crypto.TwofishKey(crypto.HashObject(password)))
+}
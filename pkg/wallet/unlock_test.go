@@ -0,0 +1,67 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPasswordSources(t *testing.T) {
+	dir := t.TempDir()
+	password := func(spec string) (string, error) {
+		t.Helper()
+		source, err := ParsePasswordSource(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return source.Password()
+	}
+
+	path := filepath.Join(dir, "password")
+	if err := ioutil.WriteFile(path, []byte("file secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := password("file:" + path); err != nil || p != "file secret" {
+		t.Errorf("unexpected password from file: %q (%v)", p, err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := password("file:" + path); err == nil {
+		t.Error("expected a password file readable by others to be refused")
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "wallet"), []byte("credential secret"), 0400); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(CredentialsDirectoryEnv, dir)
+	defer os.Unsetenv(CredentialsDirectoryEnv)
+	if p, err := password("credential:wallet"); err != nil || p != "credential secret" {
+		t.Errorf("unexpected password from credential: %q (%v)", p, err)
+	}
+
+	os.Setenv("GOLDCHAIN_TEST_PASSWORD", "env secret")
+	if p, err := password("env:GOLDCHAIN_TEST_PASSWORD"); err != nil || p != "env secret" {
+		t.Errorf("unexpected password from environment: %q (%v)", p, err)
+	}
+	if _, ok := os.LookupEnv("GOLDCHAIN_TEST_PASSWORD"); ok {
+		t.Error("expected the password to be removed from the environment")
+	}
+
+	if p, err := password("exec:echo command secret"); err != nil || p != "command secret" {
+		t.Errorf("unexpected password from command: %q (%v)", p, err)
+	}
+	if _, err := password("exec:false"); err == nil {
+		t.Error("expected a failing command to be an error")
+	}
+	if _, err := password("exec:true"); err != ErrEmptyPassword {
+		t.Errorf("expected an empty password to be refused, got %v", err)
+	}
+
+	for _, spec := range []string{"", "file:", "secret", "vault:wallet", "credential:../wallet"} {
+		if _, err := ParsePasswordSource(spec); err == nil {
+			t.Errorf("expected password source %q to be invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package wallet
+
+import (
+	"errors"
+
+	goldchaintypes "github.com/nbh-digital/goldchain/pkg/types"
+	"github.com/threefoldtech/rivine/modules"
+	"github.com/threefoldtech/rivine/types"
+)
+
+var (
+	// ErrNothingToVoteWith is returned in case the wallet doesn't have
+	// any blockstake outputs which can be used to vote.
+	ErrNothingToVoteWith = errors.New("wallet has no blockstake outputs which can be used to vote")
+)
+
+// Vote casts the given choice on the given proposal for the given addresses
+// (all addresses of the wallet owning blockstakes if none are given).
+// All unlocked blockstake outputs of these addresses are respent unchanged by a vote transaction,
+// funded by the wallet paying the minimum transaction fee, which is submitted to the transaction pool.
+func Vote(w modules.Wallet, tpool modules.TransactionPool, proposal goldchaintypes.Proposal, choice goldchaintypes.VoteChoice, addresses []types.UnlockHash, constants types.ChainConstants) (types.Transaction, error) {
+	txn, err := respendBlockStakes(w, tpool, goldchaintypes.TransactionVersionVote,
+		&goldchaintypes.VoteTransactionExtension{Proposal: proposal, Choice: choice}, addresses, constants)
+	if err == errNoBlockStakesToRespend {
+		return types.Transaction{}, ErrNothingToVoteWith
+	}
+	return txn, err
+}
@@ -28,7 +28,7 @@ for os in darwin windows linux; do
 	rm -rf "$folder"
 	mkdir -p "$folder"
 	# compile binaries
-	for pkg in cmd/goldchainc cmd/goldchaind; do
+	for pkg in cmd/goldchainc cmd/goldchaind cmd/goldchain-indexer; do
 		GOOS=${os} go build -a \
 			-ldflags="-X ${package}/pkg/config.rawVersion=${full_version} -s -w" \
 			-o "${folder}/${pkg}" "./${pkg}"